@@ -5,11 +5,12 @@ import (
 	"os"
 
 	"github.com/dshills/sigil/internal/cli"
+	"github.com/dshills/sigil/internal/errors"
 )
 
 func main() {
 	if err := cli.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		fmt.Fprint(os.Stderr, errors.FormatForUser(err, cli.Verbose()))
+		os.Exit(errors.ExitCode(err))
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/model"
@@ -67,6 +68,8 @@ func (a *BaseAgent) HasCapability(capability Capability) bool {
 // LeadAgent implements the lead agent responsible for primary task execution
 type LeadAgent struct {
 	*BaseAgent
+	root  string     // Repository root for exploration tools
+	tools ToolConfig // Exploration tool quotas; disabled unless enabled
 }
 
 // NewLeadAgent creates a new lead agent
@@ -76,6 +79,13 @@ func NewLeadAgent(id string, model model.Model, config AgentConfig, sandbox sand
 		CapabilityRefactoring,
 		CapabilityDocumentation,
 	}
+	// Profiles may add capabilities, such as performance analysis, that tasks
+	// are routed by
+	for _, capability := range config.Capabilities {
+		if !containsCapability(capabilities, capability) {
+			capabilities = append(capabilities, capability)
+		}
+	}
 
 	baseAgent := NewBaseAgent(id, RoleLead, model, capabilities, config, sandbox)
 
@@ -84,6 +94,21 @@ func NewLeadAgent(id string, model model.Model, config AgentConfig, sandbox sand
 	}
 }
 
+// EnableTools lets the agent explore the repository at root during Execute,
+// within the given per-task quotas
+func (a *LeadAgent) EnableTools(root string, config ToolConfig) {
+	a.root = root
+	a.tools = config
+}
+
+// toolbox returns a fresh toolbox for a task, or nil when tools are disabled
+func (a *LeadAgent) toolbox() *toolbox {
+	if !a.tools.Enabled || a.root == "" || a.tools.MaxCalls <= 0 || a.tools.MaxRounds <= 0 {
+		return nil
+	}
+	return newToolbox(a.root, a.tools)
+}
+
 // Execute performs the primary task execution
 func (a *LeadAgent) Execute(ctx context.Context, task Task) (*Result, error) {
 	logger.Debug("lead agent executing task", "agent_id", a.id, "task_id", task.ID, "task_type", task.Type)
@@ -99,19 +124,39 @@ func (a *LeadAgent) Execute(ctx context.Context, task Task) (*Result, error) {
 	// Generate the system prompt based on task
 	systemPrompt := a.generateSystemPrompt(task)
 
+	// Content that tries to instruct the model loses it the tools, which
+	// such instructions could otherwise steer
+	result.Injections = detectTaskInjection(task)
+	logInjections(a.id, task.ID, result.Injections)
+	tools := a.toolbox()
+	if tools != nil && len(result.Injections) > 0 {
+		logger.Warn("exploration tools refused for task with suspected prompt injection", "agent_id", a.id, "task_id", task.ID)
+		tools.close()
+		tools = nil
+	}
+	if tools != nil {
+		defer tools.close()
+		systemPrompt += toolInstructions(tools.config)
+	}
+
 	// Generate the user prompt with context
 	userPrompt := a.generateUserPrompt(task)
 
 	// Create model request
-	request := model.PromptInput{
+	request := confidenceRequest(model.PromptInput{
 		SystemPrompt: systemPrompt,
 		UserPrompt:   userPrompt,
 		MaxTokens:    4000,
 		Temperature:  0.1, // Lower temperature for more deterministic code generation
-	}
+	})
 
 	// Execute the model request
-	response, err := a.model.RunPrompt(ctx, request)
+	response, err := a.prompt(ctx, PhaseExecute, request)
+	if err == nil && tools != nil {
+		// Let the model pull in more context before it answers
+		response, err = a.explore(ctx, tools, request, response)
+		tools.record(result)
+	}
 	if err != nil {
 		result.Status = StatusFailed
 		result.Error = err.Error()
@@ -119,8 +164,28 @@ func (a *LeadAgent) Execute(ctx context.Context, task Task) (*Result, error) {
 		return result, errors.Wrap(err, errors.ErrorTypeModel, "Execute", "model generation failed")
 	}
 
+	// Ask rather than guess when the task is ambiguous
+	if questions := parseQuestions(response.Response); len(questions) > 0 {
+		result.Status = StatusIncomplete
+		result.Questions = questions
+		result.Reasoning = response.Response
+		result.Duration = time.Since(startTime)
+
+		logger.Info("lead agent needs clarification", "agent_id", a.id, "task_id", task.ID, "questions", len(questions))
+		return result, nil
+	}
+
 	// Parse the response and create proposals
 	proposals, reasoning, confidence := a.parseResponse(response.Response, task)
+	confidence = estimateConfidence(response, confidence)
+	for i := range proposals {
+		proposals[i].Confidence = confidence
+	}
+	a.checkProposals(proposals)
+	if len(result.Injections) > 0 {
+		restrictChanges(task, proposals)
+	}
+	a.attachValidation(task, proposals)
 
 	result.Proposals = proposals
 	result.Reasoning = reasoning
@@ -133,6 +198,44 @@ func (a *LeadAgent) Execute(ctx context.Context, task Task) (*Result, error) {
 	return result, nil
 }
 
+// explore runs the tools requested in each response and re-prompts the model
+// with the results until it answers without tool requests or the quotas run out
+func (a *LeadAgent) explore(ctx context.Context, tools *toolbox, request model.PromptInput, response model.PromptOutput) (model.PromptOutput, error) {
+	for tools.rounds < tools.config.MaxRounds {
+		calls := parseToolCalls(response.Response)
+		if len(calls) == 0 {
+			break
+		}
+
+		tools.rounds++
+		results := tools.runAll(ctx, calls)
+		if conversation := ConversationFrom(ctx); conversation != nil {
+			labels := make([]string, len(calls))
+			for i, call := range calls {
+				labels[i] = call.label()
+			}
+			conversation.recordTools(a.id, labels, results)
+		}
+		logger.Debug("lead agent used tools", "agent_id", a.id, "round", tools.rounds,
+			"calls", len(calls), "remaining", tools.remaining())
+
+		request.UserPrompt += fmt.Sprintf("\n\nYour previous response:\n%s\n\nTool results:\n%s", response.Response, results)
+		request.Sources = tools.sources
+		if tools.rounds >= tools.config.MaxRounds || tools.remaining() <= 0 {
+			request.UserPrompt += "\nThe tool quota for this task is used up. Give your final response now without tool lines.\n"
+		} else {
+			request.UserPrompt += "\nRequest more tools or give your final response.\n"
+		}
+
+		var err error
+		if response, err = a.prompt(ctx, PhaseExplore, request); err != nil {
+			return response, err
+		}
+	}
+
+	return response, nil
+}
+
 // Review provides feedback on proposals (lead agents can also review)
 func (a *LeadAgent) Review(ctx context.Context, proposal Proposal) (*ReviewResult, error) {
 	logger.Debug("lead agent reviewing proposal", "agent_id", a.id, "proposal_id", proposal.ID)
@@ -143,20 +246,21 @@ func (a *LeadAgent) Review(ctx context.Context, proposal Proposal) (*ReviewResul
 	systemPrompt := a.generateReviewSystemPrompt()
 	userPrompt := a.generateReviewUserPrompt(proposal)
 
-	request := model.PromptInput{
+	request := confidenceRequest(model.PromptInput{
 		SystemPrompt: systemPrompt,
 		UserPrompt:   userPrompt,
 		MaxTokens:    2000,
 		Temperature:  0.2,
-	}
+	})
 
-	response, err := a.model.RunPrompt(ctx, request)
+	response, err := a.prompt(ctx, PhaseReview, request)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrorTypeModel, "Review", "model generation failed")
 	}
 
 	// Parse review response
 	reviewResult := a.parseReviewResponse(response.Response, proposal)
+	reviewResult.Confidence = estimateConfidence(response, reviewResult.Confidence)
 
 	reviewResult.ProposalID = proposal.ID
 	reviewResult.ReviewerID = a.id
@@ -213,6 +317,8 @@ For each proposal, include:
 		task.Type,
 		task.Priority)
 
+	prompt += clarificationInstructions
+
 	// Add constraints if any
 	if len(task.Constraints) > 0 {
 		prompt += "\n\nConstraints to consider:\n"
@@ -250,8 +356,9 @@ func (a *LeadAgent) generateUserPrompt(task Task) string {
 			if file.IsReference {
 				prompt += "Reference: This file is for context only\n"
 			}
-			prompt += fmt.Sprintf("Content:\n%s\n", file.Content)
+			prompt += model.FenceUntrusted(file.Path, file.Content)
 		}
+		prompt += injectionWarning(detectTaskInjection(task))
 	}
 
 	// Add requirements
@@ -288,7 +395,7 @@ func (a *LeadAgent) parseResponse(content string, _ Task) ([]Proposal, string, f
 	// For now, we'll create a basic proposal from the response
 
 	proposal := Proposal{
-		ID:          fmt.Sprintf("prop_%s_%d", a.id, time.Now().Unix()),
+		ID:          fmt.Sprintf("prop_%s_%d_%s", a.id, deterministic.Now().Unix(), deterministic.TokenFor("prop/"+a.id, 6)),
 		AgentID:     a.id,
 		Type:        ProposalTypeFileChange,
 		Description: "Generated solution based on task requirements",
@@ -299,7 +406,7 @@ func (a *LeadAgent) parseResponse(content string, _ Task) ([]Proposal, string, f
 			Risk:     RiskLow,
 			Benefits: []string{"Addresses task requirements"},
 		},
-		CreatedAt: time.Now(),
+		CreatedAt: deterministic.Now(),
 	}
 
 	// In a real implementation, you'd parse the structured response to extract:
@@ -359,11 +466,12 @@ func (a *LeadAgent) generateReviewUserPrompt(proposal Proposal) string {
 			prompt += fmt.Sprintf("\n%d. %s (Type: %s)\n", i+1, change.Description, change.Type)
 			prompt += fmt.Sprintf("   Path: %s\n", change.Path)
 			if change.NewContent != "" {
-				prompt += fmt.Sprintf("   New Content:\n%s\n", change.NewContent)
+				prompt += "   New Content:\n" + model.FenceUntrusted(change.Path, change.NewContent)
 			}
 		}
 	}
 
+	prompt += formatViolations(proposal.Violations)
 	prompt += fmt.Sprintf("\nProposal Reasoning:\n%s\n", proposal.Reasoning)
 	prompt += fmt.Sprintf("Agent Confidence: %.2f\n", proposal.Confidence)
 	prompt += fmt.Sprintf("Estimated Impact: %s (Risk: %s)\n", proposal.Impact.Scope, proposal.Impact.Risk)
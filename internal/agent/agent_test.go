@@ -54,6 +54,14 @@ func (m *MockSandboxManager) GetValidationRules(path string) ([]sandbox.FileRule
 	return args.Get(0).([]sandbox.FileRule), args.Get(1).([]sandbox.ContentRule)
 }
 
+func (m *MockSandboxManager) ValidationSteps() []sandbox.ValidationStep {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]sandbox.ValidationStep)
+}
+
 func (m *MockSandboxManager) CreateSandbox() (sandbox.Sandbox, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
@@ -0,0 +1,203 @@
+// Package agent provides token-budgeted selection of file context
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dshills/sigil/internal/outline"
+)
+
+const (
+	// charsPerToken approximates how many characters make up a token
+	charsPerToken = 4
+
+	// minFileTokens is the smallest share worth giving a trimmed file;
+	// below it, the lowest-priority files are omitted instead
+	minFileTokens = 200
+)
+
+// ContextConfig bounds how much file content goes into agent prompts
+type ContextConfig struct {
+	MaxTokens         int  `yaml:"max_tokens"`         // Budget for file contents per task; 0 disables the limit
+	MaxFileTokens     int  `yaml:"max_file_tokens"`    // Cap on each context file; 0 disables the limit
+	OutlineReferences bool `yaml:"outline_references"` // Send reference files as outlines when a task has targets
+}
+
+// DefaultContextConfig returns the default context configuration
+func DefaultContextConfig() ContextConfig {
+	return ContextConfig{
+		MaxTokens:         24000,
+		MaxFileTokens:     8000,
+		OutlineReferences: true,
+	}
+}
+
+// FileBudget records how a file fared against the context budget
+type FileBudget struct {
+	Path       string `json:"path"`
+	Tokens     int    `json:"tokens"`
+	KeptTokens int    `json:"kept_tokens"`
+	Trimmed    bool   `json:"trimmed,omitempty"`
+	Outlined   bool   `json:"outlined,omitempty"` // Replaced by its declarations
+	Omitted    bool   `json:"omitted,omitempty"`
+}
+
+// EstimateTokens approximates the number of tokens in text
+func EstimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// FitFiles fits files into a token budget. Target files are always kept
+// whole, since changes are made against their full content. Other files
+// small enough for an equal share of what is left are kept whole and pass
+// on their unused share; the rest are shortened to what remains. When shares
+// grow too small, files are omitted, reference files first and then the last
+// listed. A budget of 0 keeps every file whole.
+func FitFiles(files []FileContext, budget int) ([]FileContext, []FileBudget) {
+	return ContextConfig{MaxTokens: budget}.Fit(files)
+}
+
+// Fit caps each file other than the targets at the per-file limit and then
+// fits the files into the budget, as FitFiles does. A file over its cap or
+// share is replaced by its outline when that fits, and otherwise trimmed to
+// its beginning and end. Reference files of tasks changing targets are sent
+// as outlines when OutlineReferences is set.
+func (c ContextConfig) Fit(files []FileContext) ([]FileContext, []FileBudget) {
+	files = append([]FileContext(nil), files...)
+	hasTargets := false
+	for _, file := range files {
+		hasTargets = hasTargets || file.IsTarget
+	}
+
+	report := make([]FileBudget, len(files))
+	for i, file := range files {
+		tokens := EstimateTokens(file.Content)
+		report[i] = FileBudget{Path: file.Path, Tokens: tokens, KeptTokens: tokens}
+		switch {
+		case file.IsTarget:
+		case c.OutlineReferences && hasTargets && file.IsReference:
+			if outlined, ok := outlineFile(file, &report[i]); ok && report[i].KeptTokens < tokens {
+				files[i].Content = outlined
+			} else {
+				report[i].Outlined = false
+				report[i].KeptTokens = tokens
+			}
+		}
+		if c.MaxFileTokens > 0 && !file.IsTarget && report[i].KeptTokens > c.MaxFileTokens {
+			files[i].Content = shorten(files[i], c.MaxFileTokens, &report[i])
+		}
+	}
+	budget := c.MaxTokens
+	if budget <= 0 {
+		return files, report
+	}
+
+	// Lowest priority last: references after other context
+	remaining := budget
+	var pending []int
+	for i, file := range files {
+		if file.IsTarget {
+			remaining -= report[i].KeptTokens
+		} else {
+			pending = append(pending, i)
+		}
+	}
+	remaining = max(remaining, 0)
+	sort.SliceStable(pending, func(a, b int) bool {
+		return !files[pending[a]].IsReference && files[pending[b]].IsReference
+	})
+
+	for len(pending) > 0 {
+		share := remaining / len(pending)
+		var over []int
+		for _, i := range pending {
+			if report[i].KeptTokens <= share {
+				remaining -= report[i].KeptTokens
+			} else {
+				over = append(over, i)
+			}
+		}
+		if len(over) == len(pending) {
+			break
+		}
+		pending = over
+	}
+
+	for len(pending) > 0 && remaining/len(pending) < minFileTokens {
+		last := pending[len(pending)-1]
+		report[last].KeptTokens = 0
+		report[last].Omitted = true
+		pending = pending[:len(pending)-1]
+	}
+	share := 0
+	if len(pending) > 0 {
+		share = remaining / len(pending)
+	}
+	for _, i := range pending {
+		files[i].Content = shorten(files[i], share, &report[i])
+	}
+
+	fitted := make([]FileContext, 0, len(files))
+	for i, file := range files {
+		if !report[i].Omitted {
+			fitted = append(fitted, file)
+		}
+	}
+	return fitted, report
+}
+
+// shorten fits a file into tokens, as its outline when that fits and
+// otherwise as its beginning and end, and records how in the report
+func shorten(file FileContext, tokens int, report *FileBudget) string {
+	if !report.Trimmed && !report.Outlined {
+		if outlined, ok := outlineFile(file, report); ok && report.KeptTokens <= tokens {
+			return outlined
+		}
+		report.Outlined = false
+	}
+	report.Trimmed = true
+	report.KeptTokens = tokens
+	return TrimToTokens(file.Content, tokens)
+}
+
+// outlineFile renders a file as its outline, recording it in the report
+func outlineFile(file FileContext, report *FileBudget) (string, bool) {
+	symbols := outline.Render(file.Path, file.Content)
+	if symbols == "" {
+		return "", false
+	}
+	outlined := fmt.Sprintf("[outline of %d tokens: declarations with line numbers and doc comments]\n%s", report.Tokens, symbols)
+	report.Outlined = true
+	report.KeptTokens = EstimateTokens(outlined)
+	return outlined, true
+}
+
+// TrimToTokens shortens content to about tokens, keeping whole lines from
+// its beginning and end around a marker for what was left out
+func TrimToTokens(content string, tokens int) string {
+	limit := tokens * charsPerToken
+	if len(content) <= limit {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	headBudget := limit * 2 / 3
+	tailBudget := limit - headBudget
+
+	head := 0
+	for used := 0; head < len(lines) && used+len(lines[head])+1 <= headBudget; head++ {
+		used += len(lines[head]) + 1
+	}
+	tail := len(lines)
+	for used := 0; tail > head && used+len(lines[tail-1])+1 <= tailBudget; tail-- {
+		used += len(lines[tail-1]) + 1
+	}
+
+	var result strings.Builder
+	result.WriteString(strings.Join(lines[:head], "\n"))
+	result.WriteString(fmt.Sprintf("\n... [%d lines omitted] ...\n", tail-head))
+	result.WriteString(strings.Join(lines[tail:], "\n"))
+	return result.String()
+}
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// linesOfTokens returns content of about n tokens in 40-character lines
+func linesOfTokens(n int) string {
+	lines := make([]string, n/10)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%-39d", i)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestFitFiles(t *testing.T) {
+	files := []FileContext{
+		{Path: "reference.go", Content: linesOfTokens(3000), IsReference: true},
+		{Path: "small.go", Content: linesOfTokens(400)},
+		{Path: "large.go", Content: linesOfTokens(3000)},
+		{Path: "target.go", Content: linesOfTokens(2000), IsTarget: true},
+	}
+
+	fitted, report := FitFiles(files, 4000)
+
+	require.Len(t, fitted, 4)
+	assert.Equal(t, files[3].Content, fitted[3].Content, "targets are never trimmed")
+	assert.Equal(t, files[1].Content, fitted[1].Content, "files within their share are kept whole")
+
+	// What the target and small file leave is split between the large files
+	assert.True(t, report[0].Trimmed)
+	assert.True(t, report[2].Trimmed)
+	assert.Equal(t, report[0].KeptTokens, report[2].KeptTokens)
+	assert.LessOrEqual(t, EstimateTokens(fitted[2].Content), report[2].KeptTokens+10)
+	assert.Contains(t, fitted[2].Content, "lines omitted")
+	assert.True(t, strings.HasPrefix(fitted[2].Content, files[2].Content[:40]), "the beginning is kept")
+	assert.True(t, strings.HasSuffix(fitted[2].Content, files[2].Content[len(files[2].Content)-39:]), "the end is kept")
+}
+
+func TestFitFiles_OmitsReferencesFirst(t *testing.T) {
+	files := []FileContext{
+		{Path: "reference.go", Content: linesOfTokens(3000), IsReference: true},
+		{Path: "context.go", Content: linesOfTokens(3000)},
+	}
+
+	fitted, report := FitFiles(files, 300)
+
+	require.Len(t, fitted, 1)
+	assert.Equal(t, "context.go", fitted[0].Path)
+	assert.True(t, report[0].Omitted)
+	assert.Equal(t, 300, report[1].KeptTokens)
+}
+
+func TestFitFiles_NoBudget(t *testing.T) {
+	files := []FileContext{{Path: "a.go", Content: linesOfTokens(100000)}}
+
+	fitted, report := FitFiles(files, 0)
+
+	assert.Equal(t, files, fitted)
+	assert.False(t, report[0].Trimmed)
+}
+
+// declarationsOfTokens returns Go source of about n tokens with a
+// declaration every ten lines
+func declarationsOfTokens(n int) string {
+	lines := make([]string, n/10)
+	for i := range lines {
+		if i%10 == 0 {
+			lines[i] = fmt.Sprintf("func f%d() {", i)
+		} else {
+			lines[i] = fmt.Sprintf("\t_ = %-33d", i)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestContextConfig_Fit_PerFileCap(t *testing.T) {
+	files := []FileContext{
+		{Path: "reference.go", Content: declarationsOfTokens(4000), IsReference: true},
+		{Path: "plain.txt", Content: linesOfTokens(4000)},
+		{Path: "target.go", Content: declarationsOfTokens(4000), IsTarget: true},
+		{Path: "small.go", Content: declarationsOfTokens(500)},
+	}
+
+	fitted, report := ContextConfig{MaxFileTokens: 1000}.Fit(files)
+
+	require.Len(t, fitted, 4)
+	assert.True(t, report[0].Outlined, "files with declarations are outlined")
+	assert.Contains(t, fitted[0].Content, "[outline of ")
+	assert.Contains(t, fitted[0].Content, "\n11: func f10()\n")
+	assert.LessOrEqual(t, report[0].KeptTokens, 1000)
+
+	assert.True(t, report[1].Trimmed, "files without declarations are trimmed")
+	assert.False(t, report[1].Outlined)
+	assert.Contains(t, fitted[1].Content, "lines omitted")
+
+	assert.Equal(t, files[2].Content, fitted[2].Content, "targets are never capped")
+	assert.Equal(t, files[3].Content, fitted[3].Content)
+	assert.Equal(t, files[0].Content, declarationsOfTokens(4000), "the files passed in are not changed")
+}
+
+func TestContextConfig_Fit_OutlineTooLarge(t *testing.T) {
+	var lines []string
+	for i := range 400 {
+		lines = append(lines, fmt.Sprintf("func f%d() {}", i))
+	}
+	files := []FileContext{{Path: "many.go", Content: strings.Join(lines, "\n")}}
+
+	fitted, report := ContextConfig{MaxFileTokens: 300}.Fit(files)
+
+	assert.False(t, report[0].Outlined)
+	assert.True(t, report[0].Trimmed)
+	assert.Contains(t, fitted[0].Content, "lines omitted")
+}
+
+func TestContextConfig_Fit_OutlinesReferences(t *testing.T) {
+	reference := "package store\n\n// Get returns an entry\nfunc Get(key string) string {\n" + strings.Repeat("\tkey = strings.TrimSpace(key)\n", 20) + "\treturn key\n}\n"
+	files := []FileContext{
+		{Path: "store.go", Content: reference, IsReference: true},
+		{Path: "main.go", Content: "package main\n", IsTarget: true},
+	}
+
+	fitted, report := DefaultContextConfig().Fit(files)
+	assert.True(t, report[0].Outlined)
+	assert.Contains(t, fitted[0].Content, "4: func Get(key string) string // Get returns an entry\n")
+	assert.NotContains(t, fitted[0].Content, "return key")
+
+	// Without targets, as when summarizing, references are what is asked about
+	fitted, report = DefaultContextConfig().Fit(files[:1])
+	assert.False(t, report[0].Outlined)
+	assert.Equal(t, reference, fitted[0].Content)
+}
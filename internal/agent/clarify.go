@@ -0,0 +1,93 @@
+// Package agent provides clarification requests from agents to the user
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// questionsHeader marks the section of a response that asks for clarification
+var questionsHeader = regexp.MustCompile(`(?im)^[\s*#]*questions[\s*]*:[\s*]*$`)
+
+// questionItem matches a numbered or bulleted question line
+var questionItem = regexp.MustCompile(`^\s*(?:\d+[.)]|[-*])\s+(.+)$`)
+
+// questionOptions matches a trailing "(options: a | b)" hint on a question
+var questionOptions = regexp.MustCompile(`\s*\(options?:\s*([^)]*)\)\s*$`)
+
+// Question is something an agent needs answered before it can proceed
+type Question struct {
+	ID      string   `json:"id"`
+	Text    string   `json:"text"`
+	Options []string `json:"options,omitempty"`
+}
+
+// Answer pairs a question with the user's reply
+type Answer struct {
+	Question Question `json:"question"`
+	Text     string   `json:"text"`
+}
+
+// ClarifyFunc puts an agent's questions to the user and returns the answers
+type ClarifyFunc func(ctx context.Context, questions []Question) ([]Answer, error)
+
+// DefaultMaxClarifications is the default number of times a task may go back
+// to the user for clarification
+const DefaultMaxClarifications = 2
+
+// parseQuestions extracts the questions from a QUESTIONS: section
+func parseQuestions(content string) []Question {
+	loc := questionsHeader.FindStringIndex(content)
+	if loc == nil {
+		return nil
+	}
+
+	var questions []Question
+	for _, line := range strings.Split(content[loc[1]:], "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(questions) > 0 {
+				break
+			}
+			continue
+		}
+		match := questionItem.FindStringSubmatch(line)
+		if match == nil {
+			break
+		}
+
+		question := Question{ID: fmt.Sprintf("q%d", len(questions)+1), Text: strings.TrimSpace(match[1])}
+		if options := questionOptions.FindStringSubmatch(question.Text); options != nil {
+			question.Text = strings.TrimSpace(question.Text[:len(question.Text)-len(options[0])])
+			for _, option := range strings.Split(options[1], "|") {
+				if option = strings.TrimSpace(option); option != "" {
+					question.Options = append(question.Options, option)
+				}
+			}
+		}
+		questions = append(questions, question)
+	}
+	return questions
+}
+
+// withAnswers returns the task with the answers appended to its requirements
+func withAnswers(task Task, answers []Answer) Task {
+	requirements := make([]string, 0, len(task.Context.Requirements)+len(answers))
+	requirements = append(requirements, task.Context.Requirements...)
+	for _, answer := range answers {
+		requirements = append(requirements, fmt.Sprintf("Clarification: %s Answer: %s", answer.Question.Text, answer.Text))
+	}
+	task.Context.Requirements = requirements
+	return task
+}
+
+// clarificationInstructions tells the lead agent how to ask for clarification
+const clarificationInstructions = `
+
+If the task is too ambiguous to complete without guessing, do not guess.
+Respond only with the questions that need answers, in this format:
+
+QUESTIONS:
+1. [question]
+2. [question] (options: [choice] | [choice])`
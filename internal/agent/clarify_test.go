@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuestions(t *testing.T) {
+	content := "I need more detail first.\n\nQUESTIONS:\n1. Which package should own the cache?\n2. Should entries expire? (options: yes | no)\n\nThanks"
+
+	questions := parseQuestions(content)
+
+	require.Len(t, questions, 2)
+	assert.Equal(t, Question{ID: "q1", Text: "Which package should own the cache?"}, questions[0])
+	assert.Equal(t, Question{ID: "q2", Text: "Should entries expire?", Options: []string{"yes", "no"}}, questions[1])
+
+	assert.Empty(t, parseQuestions("REASONING:\nAll clear\n\nCHANGES:\n..."))
+}
+
+func TestWithAnswers(t *testing.T) {
+	task := Task{Context: TaskContext{Requirements: []string{"keep it small"}}}
+	answers := []Answer{{Question: Question{ID: "q1", Text: "Which package?"}, Text: "internal/cache"}}
+
+	resumed := withAnswers(task, answers)
+
+	assert.Equal(t, []string{"keep it small", "Clarification: Which package? Answer: internal/cache"}, resumed.Context.Requirements)
+	assert.Equal(t, []string{"keep it small"}, task.Context.Requirements, "original task must not change")
+}
+
+// newQuestioningOrchestrator returns an orchestrator whose lead asks one
+// question until its requirements carry an answer
+func newQuestioningOrchestrator(t *testing.T) *DefaultOrchestrator {
+	t.Helper()
+
+	question := Question{ID: "q1", Text: "Which package?"}
+	answered := mock.MatchedBy(func(task Task) bool { return len(task.Context.Requirements) > 0 })
+	unanswered := mock.MatchedBy(func(task Task) bool { return len(task.Context.Requirements) == 0 })
+
+	lead := &MockAgent{id: "lead", role: RoleLead, capabilities: []Capability{CapabilityCodeGeneration}}
+	lead.On("Execute", mock.Anything, unanswered).
+		Return(&Result{TaskID: "task", AgentID: "lead", Status: StatusIncomplete, Questions: []Question{question}}, nil)
+	lead.On("Execute", mock.Anything, answered).
+		Return(&Result{TaskID: "task", AgentID: "lead", Status: StatusSuccess, Confidence: 0.9}, nil)
+
+	orchestrator := NewOrchestrator(DefaultOrchestrationConfig())
+	require.NoError(t, orchestrator.RegisterAgent(lead))
+	return orchestrator
+}
+
+func TestOrchestrator_ExecuteTask_Clarifies(t *testing.T) {
+	orchestrator := newQuestioningOrchestrator(t)
+
+	var asked []Question
+	orchestrator.SetClarifyFunc(func(_ context.Context, questions []Question) ([]Answer, error) {
+		asked = questions
+		return []Answer{{Question: questions[0], Text: "internal/cache"}}, nil
+	})
+
+	result, err := orchestrator.ExecuteTask(context.Background(), Task{ID: "task", Type: TaskTypeEdit})
+	require.NoError(t, err)
+
+	require.Len(t, asked, 1)
+	assert.Equal(t, StatusSuccess, result.Status)
+	assert.Empty(t, result.Questions)
+	require.Len(t, result.Clarifications, 1)
+	assert.Equal(t, "internal/cache", result.Clarifications[0].Text)
+}
+
+func TestOrchestrator_ExecuteTask_UnansweredQuestions(t *testing.T) {
+	orchestrator := newQuestioningOrchestrator(t)
+
+	result, err := orchestrator.ExecuteTask(context.Background(), Task{ID: "task", Type: TaskTypeEdit})
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusIncomplete, result.Status)
+	assert.Nil(t, result.FinalResult)
+	require.Len(t, result.Questions, 1)
+	assert.Equal(t, "Which package?", result.Questions[0].Text)
+}
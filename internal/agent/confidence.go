@@ -0,0 +1,271 @@
+// Package agent provides confidence estimation and calibration
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// calibrationBins is the number of equal-width confidence bins tracked
+const calibrationBins = 10
+
+// confidencePattern matches a self-assessed "CONFIDENCE: 0.8" or "CONFIDENCE: 80%" line
+var confidencePattern = regexp.MustCompile(`(?im)^[\s*#-]*confidence[\s*]*:[\s*]*([0-9]*\.?[0-9]+)\s*(%?)`)
+
+// ConfidenceConfig controls confidence calibration and abstention
+type ConfidenceConfig struct {
+	Floor        float64 `yaml:"floor"`         // Abstain when calibrated confidence is below this; 0 disables
+	PriorSamples int     `yaml:"prior_samples"` // Weight of the reported confidence against observed review outcomes
+	File         string  `yaml:"file"`          // Where review outcomes are kept across runs; empty keeps them in memory
+}
+
+// DefaultCalibrationFile is where the CLI keeps review outcomes
+const DefaultCalibrationFile = ".sigil/calibration.jsonl"
+
+// DefaultConfidenceConfig returns the default confidence configuration
+func DefaultConfidenceConfig() ConfidenceConfig {
+	return ConfidenceConfig{
+		Floor:        0.5,
+		PriorSamples: 5,
+	}
+}
+
+// CalibrationBin reports how often proposals in a confidence range were approved
+type CalibrationBin struct {
+	Lower        float64 `json:"lower"`
+	Upper        float64 `json:"upper"`
+	Samples      int64   `json:"samples"`
+	Approved     int64   `json:"approved"`
+	ApprovalRate float64 `json:"approval_rate"`
+}
+
+// estimateConfidence derives a confidence from the model's own assessment and
+// its token log probabilities, averaging the two when both are available.
+// fallback is used when the model reported neither.
+func estimateConfidence(output model.PromptOutput, fallback float64) float64 {
+	var sum float64
+	var n int
+	if c, ok := selfAssessedConfidence(output.Response); ok {
+		sum += c
+		n++
+	}
+	if c, ok := logprobConfidence(output.Metadata); ok {
+		sum += c
+		n++
+	}
+	if n == 0 {
+		return fallback
+	}
+	return sum / float64(n)
+}
+
+// selfAssessedConfidence extracts the CONFIDENCE value the prompts ask for
+func selfAssessedConfidence(content string) (float64, bool) {
+	match := confidencePattern.FindStringSubmatch(content)
+	if match == nil {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	if match[2] == "%" || value > 1 {
+		value /= 100
+	}
+	return clampConfidence(value), true
+}
+
+// logprobConfidence converts the mean token log probability to a probability
+func logprobConfidence(metadata map[string]string) (float64, bool) {
+	raw, ok := metadata[model.MetadataMeanLogprob]
+	if !ok {
+		return 0, false
+	}
+	mean, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false
+	}
+	return clampConfidence(math.Exp(mean)), true
+}
+
+// clampConfidence limits a confidence to [0, 1]
+func clampConfidence(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
+
+// confidenceRequest marks a prompt to return token log probabilities where
+// the provider supports them
+func confidenceRequest(request model.PromptInput) model.PromptInput {
+	metadata := make(map[string]string, len(request.Metadata)+1)
+	for k, v := range request.Metadata {
+		metadata[k] = v
+	}
+	metadata[model.MetadataLogprobs] = "true"
+	request.Metadata = metadata
+	return request
+}
+
+// calibrator tracks reported confidence against review outcomes and adjusts
+// new confidences toward the approval rate observed in their range
+type calibrator struct {
+	mu       sync.Mutex
+	prior    float64
+	file     string
+	samples  [calibrationBins]int64
+	approved [calibrationBins]int64
+}
+
+// calibrationOutcome is one review outcome, as a line of the calibration file
+type calibrationOutcome struct {
+	Confidence float64 `json:"confidence"`
+	Approved   bool    `json:"approved"`
+}
+
+// newCalibrator creates a calibrator; prior is how many observations the
+// reported confidence itself counts for. Outcomes kept in file, if given,
+// are loaded; a missing file starts from none and unreadable lines are
+// skipped.
+func newCalibrator(prior int, file string) *calibrator {
+	c := &calibrator{prior: float64(max(prior, 1)), file: file}
+	if file == "" {
+		return c
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("ignoring unreadable confidence calibration", "file", file, "error", err)
+		}
+		return c
+	}
+
+	skipped := 0
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var outcome calibrationOutcome
+		if err := json.Unmarshal(line, &outcome); err != nil {
+			skipped++
+			continue
+		}
+		c.add(outcome)
+	}
+	if skipped > 0 {
+		logger.Warn("skipped unreadable confidence calibration entries", "file", file, "entries", skipped)
+	}
+	return c
+}
+
+// bin returns the bin index for a confidence
+func (c *calibrator) bin(confidence float64) int {
+	return min(int(clampConfidence(confidence)*calibrationBins), calibrationBins-1)
+}
+
+// record adds a review outcome for a proposal reported at confidence
+func (c *calibrator) record(confidence float64, approved bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	outcome := calibrationOutcome{Confidence: confidence, Approved: approved}
+	c.add(outcome)
+	if c.file != "" {
+		if err := c.append(outcome); err != nil {
+			logger.Warn("failed to save confidence calibration", "file", c.file, "error", err)
+		}
+	}
+}
+
+// add counts an outcome in its bin. The caller holds c.mu or owns c.
+func (c *calibrator) add(outcome calibrationOutcome) {
+	i := c.bin(outcome.Confidence)
+	c.samples[i]++
+	if outcome.Approved {
+		c.approved[i]++
+	}
+}
+
+// append adds an outcome to the calibrator's file as one line, in a single
+// O_APPEND write, so runs sharing the file each add their outcomes rather
+// than replacing the others'. A run sees the outcomes of runs that
+// recorded before it started, not those recorded since. The caller holds
+// c.mu.
+func (c *calibrator) append(outcome calibrationOutcome) error {
+	data, err := json.Marshal(outcome)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "append", "failed to encode confidence calibration")
+	}
+	if err := os.MkdirAll(filepath.Dir(c.file), 0755); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "append", "failed to create calibration directory")
+	}
+	file, err := os.OpenFile(c.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "append", fmt.Sprintf("failed to open calibration file: %s", c.file))
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return errors.Wrap(err, errors.ErrorTypeFS, "append", "failed to write calibration file")
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "append", "failed to write calibration file")
+	}
+	return nil
+}
+
+// calibrate blends a reported confidence with the observed approval rate of
+// its bin, so the observations dominate as they accumulate
+func (c *calibrator) calibrate(confidence float64) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := c.bin(confidence)
+	return (float64(c.approved[i]) + confidence*c.prior) / (float64(c.samples[i]) + c.prior)
+}
+
+// bins returns the bins that have observations
+func (c *calibrator) bins() []CalibrationBin {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var bins []CalibrationBin
+	for i := range calibrationBins {
+		if c.samples[i] == 0 {
+			continue
+		}
+		bins = append(bins, CalibrationBin{
+			Lower:        float64(i) / calibrationBins,
+			Upper:        float64(i+1) / calibrationBins,
+			Samples:      c.samples[i],
+			Approved:     c.approved[i],
+			ApprovalRate: float64(c.approved[i]) / float64(c.samples[i]),
+		})
+	}
+	return bins
+}
+
+// Abstention records why the orchestrator stopped short of acting on a result
+type Abstention struct {
+	Confidence float64 `json:"confidence"`
+	Floor      float64 `json:"floor"`
+	Reason     string  `json:"reason"`
+}
+
+// newAbstention describes a result whose confidence fell below the floor
+func newAbstention(confidence, floor float64) *Abstention {
+	return &Abstention{
+		Confidence: confidence,
+		Floor:      floor,
+		Reason: fmt.Sprintf("confidence %.2f is below the floor of %.2f; refine the task or confirm to proceed",
+			confidence, floor),
+	}
+}
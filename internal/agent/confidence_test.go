@@ -0,0 +1,197 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dshills/sigil/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateConfidence(t *testing.T) {
+	tests := []struct {
+		name   string
+		output model.PromptOutput
+		want   float64
+	}{
+		{
+			name:   "fallback",
+			output: model.PromptOutput{Response: "REASONING:\nLooks fine"},
+			want:   0.8,
+		},
+		{
+			name:   "self assessed",
+			output: model.PromptOutput{Response: "REASONING:\nok\n\nCONFIDENCE: 0.65\n"},
+			want:   0.65,
+		},
+		{
+			name:   "self assessed percentage in markdown",
+			output: model.PromptOutput{Response: "**Confidence:** 40%"},
+			want:   0.4,
+		},
+		{
+			name:   "clamped",
+			output: model.PromptOutput{Response: "CONFIDENCE: 250"},
+			want:   1,
+		},
+		{
+			name: "logprobs",
+			output: model.PromptOutput{Response: "ok",
+				Metadata: map[string]string{model.MetadataMeanLogprob: "0"}},
+			want: 1,
+		},
+		{
+			name: "self assessed and logprobs averaged",
+			output: model.PromptOutput{Response: "CONFIDENCE: 0.5",
+				Metadata: map[string]string{model.MetadataMeanLogprob: "0"}},
+			want: 0.75,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, estimateConfidence(tt.output, 0.8), 1e-9)
+		})
+	}
+}
+
+func TestConfidenceRequest(t *testing.T) {
+	original := model.PromptInput{Metadata: map[string]string{"task": "edit"}}
+
+	request := confidenceRequest(original)
+
+	assert.Equal(t, "true", request.Metadata[model.MetadataLogprobs])
+	assert.Equal(t, "edit", request.Metadata["task"])
+	assert.NotContains(t, original.Metadata, model.MetadataLogprobs, "caller's metadata must not change")
+}
+
+func TestCalibrator(t *testing.T) {
+	c := newCalibrator(5, "")
+
+	// Without observations the reported confidence stands
+	assert.InDelta(t, 0.9, c.calibrate(0.9), 1e-9)
+
+	// Proposals reported at 0.9 are approved only half the time
+	for i := range 10 {
+		c.record(0.92, i%2 == 0)
+	}
+	assert.InDelta(t, (5+0.9*5)/(10+5.0), c.calibrate(0.9), 1e-9)
+
+	// Other bins are unaffected
+	assert.InDelta(t, 0.3, c.calibrate(0.3), 1e-9)
+
+	bins := c.bins()
+	require.Len(t, bins, 1)
+	assert.Equal(t, CalibrationBin{Lower: 0.9, Upper: 1, Samples: 10, Approved: 5, ApprovalRate: 0.5}, bins[0])
+
+	// A confidence of exactly 1 falls in the top bin
+	assert.Equal(t, calibrationBins-1, c.bin(1))
+}
+
+func TestCalibrator_PersistsAcrossOrchestrators(t *testing.T) {
+	config := DefaultOrchestrationConfig()
+	config.Confidence.File = filepath.Join(t.TempDir(), ".sigil", "calibration.jsonl")
+
+	first := NewOrchestrator(config)
+	for i := range 4 {
+		first.calibration.record(0.85, i == 0)
+	}
+
+	second := NewOrchestrator(config)
+	assert.Equal(t, first.calibration.bins(), second.calibration.bins())
+	assert.InDelta(t, (1+0.8*5)/(4+5.0), second.calibration.calibrate(0.8), 1e-9)
+
+	t.Run("concurrent runs keep each other's outcomes", func(t *testing.T) {
+		shared := config
+		shared.Confidence.File = filepath.Join(t.TempDir(), "calibration.jsonl")
+		runs := []*DefaultOrchestrator{NewOrchestrator(shared), NewOrchestrator(shared)}
+		var wg sync.WaitGroup
+		for _, run := range runs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 20 {
+					run.calibration.record(0.35, i%2 == 0)
+				}
+			}()
+		}
+		wg.Wait()
+
+		bins := NewOrchestrator(shared).calibration.bins()
+		require.Len(t, bins, 1)
+		assert.Equal(t, int64(40), bins[0].Samples)
+		assert.Equal(t, int64(20), bins[0].Approved)
+	})
+
+	t.Run("unreadable lines are skipped", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(config.Confidence.File, []byte("not json\n{\"confidence\":0.85,\"approved\":true}\n{\"confi"), 0600))
+		bins := NewOrchestrator(config).calibration.bins()
+		require.Len(t, bins, 1)
+		assert.Equal(t, int64(1), bins[0].Samples)
+	})
+}
+
+// newLeadOrchestrator returns an orchestrator with one mocked lead agent
+func newLeadOrchestrator(t *testing.T, confidence float64) *DefaultOrchestrator {
+	t.Helper()
+
+	lead := &MockAgent{id: "lead", role: RoleLead, capabilities: []Capability{CapabilityCodeGeneration}}
+	lead.On("Execute", mock.Anything, mock.Anything).
+		Return(&Result{TaskID: "task", AgentID: "lead", Status: StatusSuccess, Confidence: confidence}, nil)
+
+	orchestrator := NewOrchestrator(DefaultOrchestrationConfig())
+	require.NoError(t, orchestrator.RegisterAgent(lead))
+	return orchestrator
+}
+
+func TestOrchestrator_ExecuteTask_Abstains(t *testing.T) {
+	orchestrator := newLeadOrchestrator(t, 0.3)
+
+	result, err := orchestrator.ExecuteTask(context.Background(), Task{ID: "task", Type: TaskTypeEdit})
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusIncomplete, result.Status)
+	assert.Nil(t, result.FinalResult)
+	require.NotNil(t, result.Abstention)
+	assert.InDelta(t, 0.3, result.Abstention.Confidence, 1e-9)
+	assert.Equal(t, 0.5, result.Abstention.Floor)
+	assert.Contains(t, result.Abstention.Reason, "below the floor")
+	require.Len(t, result.Results, 1)
+	assert.Equal(t, "0.30", result.Results[0].Metadata["raw_confidence"])
+}
+
+func TestOrchestrator_ExecuteTask_ConfirmedLowConfidence(t *testing.T) {
+	orchestrator := newLeadOrchestrator(t, 0.3)
+
+	var asked *Abstention
+	orchestrator.SetConfirmFunc(func(_ context.Context, _ *Result, abstention *Abstention) (bool, error) {
+		asked = abstention
+		return true, nil
+	})
+
+	result, err := orchestrator.ExecuteTask(context.Background(), Task{ID: "task", Type: TaskTypeEdit})
+	require.NoError(t, err)
+
+	require.NotNil(t, asked)
+	assert.Equal(t, StatusSuccess, result.Status)
+	assert.Nil(t, result.Abstention)
+	assert.NotNil(t, result.FinalResult)
+}
+
+func TestOrchestrator_ExecuteTask_ConfidentProceeds(t *testing.T) {
+	orchestrator := newLeadOrchestrator(t, 0.9)
+	orchestrator.SetConfirmFunc(func(context.Context, *Result, *Abstention) (bool, error) {
+		t.Fatal("confident results must not be put to the user")
+		return false, nil
+	})
+
+	result, err := orchestrator.ExecuteTask(context.Background(), Task{ID: "task", Type: TaskTypeEdit})
+	require.NoError(t, err)
+	assert.Equal(t, StatusSuccess, result.Status)
+	assert.Nil(t, result.Abstention)
+}
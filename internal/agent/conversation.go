@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dshills/sigil/internal/glossary"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// Conversation phases
+const (
+	PhaseExecute = "execute"
+	PhaseExplore = "explore"
+	PhaseReview  = "review"
+)
+
+// Conversation records every model request agents make while it is in a
+// context, so a task's conversation can be inspected and replayed
+type Conversation struct {
+	mu    sync.Mutex
+	turns []Turn
+}
+
+// Turn is one model request an agent made and the response to it
+type Turn struct {
+	AgentID      string            `json:"agent_id"`
+	Role         AgentRole         `json:"role"`
+	Phase        string            `json:"phase"`
+	SystemPrompt string            `json:"system_prompt,omitempty"`
+	UserPrompt   string            `json:"user_prompt"`
+	Files        []TurnFile        `json:"files,omitempty"`
+	MaxTokens    int               `json:"max_tokens,omitempty"`
+	Temperature  float64           `json:"temperature,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	Response     string            `json:"response,omitempty"`
+	Model        string            `json:"model,omitempty"`
+	TokensUsed   int               `json:"tokens_used,omitempty"`
+	ToolCalls    []string          `json:"tool_calls,omitempty"`   // Tools the response requested
+	ToolResults  string            `json:"tool_results,omitempty"` // What those tools returned
+	StartTime    time.Time         `json:"start_time"`
+	Duration     time.Duration     `json:"duration"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// TurnFile is a file sent with a turn's prompt
+type TurnFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	Type    string `json:"type,omitempty"`
+}
+
+type conversationKey struct{}
+
+// WithConversation returns a context whose agent model requests are
+// recorded in conversation
+func WithConversation(ctx context.Context, conversation *Conversation) context.Context {
+	return context.WithValue(ctx, conversationKey{}, conversation)
+}
+
+// ConversationFrom returns the conversation of a context, or nil
+func ConversationFrom(ctx context.Context) *Conversation {
+	conversation, _ := ctx.Value(conversationKey{}).(*Conversation)
+	return conversation
+}
+
+// Record adds a turn to the conversation
+func (c *Conversation) Record(turn Turn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.turns = append(c.turns, turn)
+}
+
+// Turns returns the recorded turns in the order they were made
+func (c *Conversation) Turns() []Turn {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Turn(nil), c.turns...)
+}
+
+// recordTools attaches the tools an agent ran to its latest turn
+func (c *Conversation) recordTools(agentID string, calls []string, results string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := len(c.turns) - 1; i >= 0; i-- {
+		if c.turns[i].AgentID == agentID {
+			c.turns[i].ToolCalls = calls
+			c.turns[i].ToolResults = results
+			return
+		}
+	}
+}
+
+// Input returns the request the turn sent, for replaying it
+func (t Turn) Input() model.PromptInput {
+	input := model.PromptInput{
+		SystemPrompt: t.SystemPrompt,
+		UserPrompt:   t.UserPrompt,
+		MaxTokens:    t.MaxTokens,
+		Temperature:  t.Temperature,
+		Metadata:     t.Metadata,
+	}
+	for _, file := range t.Files {
+		input.Files = append(input.Files, model.FileContent{Path: file.Path, Content: file.Content, Type: file.Type})
+	}
+	return input
+}
+
+// prompt runs a model request, with the rule for fenced repository content
+// and the project glossary in its system prompt, and records it in the
+// context's conversation
+func (a *BaseAgent) prompt(ctx context.Context, phase string, request model.PromptInput) (model.PromptOutput, error) {
+	request.SystemPrompt = glossary.Instruct(withUntrustedRule(request.SystemPrompt))
+	startTime := time.Now()
+	response, err := a.model.RunPrompt(ctx, request)
+
+	if conversation := ConversationFrom(ctx); conversation != nil {
+		turn := Turn{
+			AgentID:      a.id,
+			Role:         a.role,
+			Phase:        phase,
+			SystemPrompt: request.SystemPrompt,
+			UserPrompt:   request.UserPrompt,
+			MaxTokens:    request.MaxTokens,
+			Temperature:  request.Temperature,
+			Metadata:     request.Metadata,
+			Response:     response.Response,
+			Model:        response.Model,
+			TokensUsed:   response.TokensUsed,
+			StartTime:    startTime,
+			Duration:     time.Since(startTime),
+		}
+		for _, file := range request.Files {
+			turn.Files = append(turn.Files, TurnFile{Path: file.Path, Content: file.Content, Type: file.Type})
+		}
+		if err != nil {
+			turn.Error = err.Error()
+		}
+		conversation.Record(turn)
+	}
+	return response, err
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dshills/sigil/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversation_RecordsAgentTurns(t *testing.T) {
+	root := createToolRepo(t)
+	mockModel := &MockModel{}
+	agent := NewLeadAgent("lead", mockModel, AgentConfig{}, &MockSandboxManager{})
+	agent.EnableTools(root, DefaultToolConfig())
+
+	mockModel.On("RunPrompt", mock.Anything, mock.MatchedBy(func(input model.PromptInput) bool {
+		return !strings.Contains(input.UserPrompt, "Tool results:")
+	})).Return(model.PromptOutput{Response: `TOOL: read_file {"path": "internal/run.go"}`, Model: "mock", TokensUsed: 12}, nil).Once()
+	mockModel.On("RunPrompt", mock.Anything, mock.Anything).
+		Return(model.PromptOutput{Response: "REASONING:\nrun is defined in internal/run.go"}, nil).Once()
+
+	conversation := &Conversation{}
+	ctx := WithConversation(context.Background(), conversation)
+	_, err := agent.Execute(ctx, Task{ID: "task_1", Type: TaskTypeRefactor, Description: "Rename run"})
+	require.NoError(t, err)
+
+	turns := conversation.Turns()
+	require.Len(t, turns, 2)
+	assert.Equal(t, "lead", turns[0].AgentID)
+	assert.Equal(t, RoleLead, turns[0].Role)
+	assert.Equal(t, PhaseExecute, turns[0].Phase)
+	assert.Contains(t, turns[0].SystemPrompt, "Repository tools:")
+	assert.Contains(t, turns[0].UserPrompt, "Rename run")
+	assert.Equal(t, "mock", turns[0].Model)
+	assert.Equal(t, 12, turns[0].TokensUsed)
+	assert.Equal(t, []string{"read_file internal/run.go"}, turns[0].ToolCalls)
+	assert.Contains(t, turns[0].ToolResults, "// run starts the program")
+
+	assert.Equal(t, PhaseExplore, turns[1].Phase)
+	assert.Contains(t, turns[1].UserPrompt, "Tool results:")
+	assert.Contains(t, turns[1].Response, "internal/run.go")
+	assert.Empty(t, turns[1].ToolCalls)
+
+	// A recorded turn replays the request it sent
+	input := turns[1].Input()
+	assert.Equal(t, turns[1].UserPrompt, input.UserPrompt)
+	assert.Equal(t, turns[1].Temperature, input.Temperature)
+}
+
+func TestConversation_RecordsFailedTurns(t *testing.T) {
+	mockModel := &MockModel{}
+	reviewer := NewReviewerAgent("security", mockModel, AgentConfig{}, &MockSandboxManager{}, "security")
+	mockModel.On("RunPrompt", mock.Anything, mock.Anything).Return(model.PromptOutput{}, errors.New("rate limited"))
+
+	conversation := &Conversation{}
+	_, err := reviewer.Review(WithConversation(context.Background(), conversation), Proposal{ID: "p1"})
+	require.Error(t, err)
+
+	turns := conversation.Turns()
+	require.Len(t, turns, 1)
+	assert.Equal(t, RoleReviewer, turns[0].Role)
+	assert.Equal(t, PhaseReview, turns[0].Phase)
+	assert.Equal(t, "rate limited", turns[0].Error)
+}
+
+func TestOrchestrator_ExecuteTask_AttachesConversation(t *testing.T) {
+	lead := &MockAgent{id: "lead", role: RoleLead, capabilities: []Capability{CapabilityCodeGeneration}}
+	lead.On("Execute", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ConversationFrom(args.Get(0).(context.Context)).Record(Turn{AgentID: "lead", Phase: PhaseExecute, Response: "done"})
+	}).Return(&Result{TaskID: "task", AgentID: "lead", Status: StatusSuccess, Confidence: 0.9}, nil)
+
+	orchestrator := NewOrchestrator(DefaultOrchestrationConfig())
+	require.NoError(t, orchestrator.RegisterAgent(lead))
+
+	result, err := orchestrator.ExecuteTask(context.Background(), Task{ID: "task", Type: TaskTypeEdit})
+	require.NoError(t, err)
+	require.Len(t, result.Conversation, 1)
+	assert.Equal(t, "done", result.Conversation[0].Response)
+	assert.Nil(t, ConversationFrom(context.Background()).Turns())
+}
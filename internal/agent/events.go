@@ -0,0 +1,352 @@
+package agent
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// EventType for orchestration events
+type EventType string
+
+const (
+	EventTaskStarted            EventType = "task_started"
+	EventTaskCompleted          EventType = "task_completed"
+	EventTaskFailed             EventType = "task_failed"
+	EventReviewStarted          EventType = "review_started"
+	EventReviewCompleted        EventType = "review_completed"
+	EventConsensusReached       EventType = "consensus_reached"
+	EventConflictDetected       EventType = "conflict_detected"
+	EventTaskAbstained          EventType = "task_abstained"
+	EventClarificationRequested EventType = "clarification_requested"
+	EventProgress               EventType = "progress"
+)
+
+// OrchestrationEvent represents events in the orchestration process
+type OrchestrationEvent struct {
+	Type      EventType    `json:"type"`
+	TaskID    string       `json:"task_id,omitempty"`
+	AgentID   string       `json:"agent_id,omitempty"`
+	Payload   EventPayload `json:"payload,omitempty"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// EventPayload is the typed data carried by an orchestration event
+type EventPayload interface {
+	// EventType returns the event type the payload belongs to
+	EventType() EventType
+}
+
+// TaskStartedPayload accompanies EventTaskStarted
+type TaskStartedPayload struct {
+	TaskType TaskType `json:"task_type"`
+}
+
+// TaskCompletedPayload accompanies EventTaskCompleted
+type TaskCompletedPayload struct {
+	Status    ResultStatus  `json:"status"`
+	Duration  time.Duration `json:"duration"`
+	Proposals int           `json:"proposals"`
+}
+
+// TaskFailedPayload accompanies EventTaskFailed
+type TaskFailedPayload struct {
+	Error string `json:"error"`
+}
+
+// ReviewStartedPayload accompanies EventReviewStarted
+type ReviewStartedPayload struct {
+	ProposalID string `json:"proposal_id"`
+}
+
+// ReviewCompletedPayload accompanies EventReviewCompleted
+type ReviewCompletedPayload struct {
+	ProposalID string            `json:"proposal_id"`
+	Decision   ConsensusDecision `json:"decision"`
+	Score      float64           `json:"score"`
+}
+
+// ConsensusReachedPayload accompanies EventConsensusReached
+type ConsensusReachedPayload struct {
+	ProposalID string            `json:"proposal_id"`
+	Decision   ConsensusDecision `json:"decision"`
+}
+
+// ConflictDetectedPayload accompanies EventConflictDetected
+type ConflictDetectedPayload struct {
+	ProposalID string `json:"proposal_id"`
+	Conflicts  int    `json:"conflicts"`
+}
+
+// TaskAbstainedPayload accompanies EventTaskAbstained
+type TaskAbstainedPayload struct {
+	Confidence float64 `json:"confidence"`
+	Floor      float64 `json:"floor"`
+}
+
+// ClarificationRequestedPayload accompanies EventClarificationRequested
+type ClarificationRequestedPayload struct {
+	Questions int `json:"questions"`
+}
+
+// ProgressPayload accompanies EventProgress, reported by a long-running
+// model or tool call such as an MCP server indexing a repository
+type ProgressPayload struct {
+	Source   string  `json:"source,omitempty"`
+	Token    string  `json:"token"`
+	Progress float64 `json:"progress"`
+	Total    float64 `json:"total,omitempty"`
+	Message  string  `json:"message,omitempty"`
+}
+
+// EventType implements EventPayload
+func (TaskStartedPayload) EventType() EventType { return EventTaskStarted }
+
+// EventType implements EventPayload
+func (TaskCompletedPayload) EventType() EventType { return EventTaskCompleted }
+
+// EventType implements EventPayload
+func (TaskFailedPayload) EventType() EventType { return EventTaskFailed }
+
+// EventType implements EventPayload
+func (ReviewStartedPayload) EventType() EventType { return EventReviewStarted }
+
+// EventType implements EventPayload
+func (ReviewCompletedPayload) EventType() EventType { return EventReviewCompleted }
+
+// EventType implements EventPayload
+func (ConsensusReachedPayload) EventType() EventType { return EventConsensusReached }
+
+// EventType implements EventPayload
+func (ConflictDetectedPayload) EventType() EventType { return EventConflictDetected }
+
+// EventType implements EventPayload
+func (TaskAbstainedPayload) EventType() EventType { return EventTaskAbstained }
+
+// EventType implements EventPayload
+func (ClarificationRequestedPayload) EventType() EventType { return EventClarificationRequested }
+
+// EventType implements EventPayload
+func (ProgressPayload) EventType() EventType { return EventProgress }
+
+// BackpressurePolicy controls what happens when a subscriber's buffer is full
+type BackpressurePolicy string
+
+const (
+	// BackpressureDropNewest discards the event being published
+	BackpressureDropNewest BackpressurePolicy = "drop_newest"
+	// BackpressureDropOldest discards the oldest buffered event to make room
+	BackpressureDropOldest BackpressurePolicy = "drop_oldest"
+	// BackpressureBlock waits up to BlockTimeout for room, then drops
+	BackpressureBlock BackpressurePolicy = "block"
+)
+
+// EventBusConfig configures the orchestration event bus
+type EventBusConfig struct {
+	BufferSize   int                `yaml:"buffer_size"`
+	Policy       BackpressurePolicy `yaml:"policy"`
+	BlockTimeout time.Duration      `yaml:"block_timeout"`
+}
+
+// DefaultEventBusConfig returns the default event bus configuration
+func DefaultEventBusConfig() EventBusConfig {
+	return EventBusConfig{
+		BufferSize:   100,
+		Policy:       BackpressureDropNewest,
+		BlockTimeout: 100 * time.Millisecond,
+	}
+}
+
+// EventBusStats reports event bus activity
+type EventBusStats struct {
+	Published   int64 `json:"published"`
+	Delivered   int64 `json:"delivered"`
+	Dropped     int64 `json:"dropped"`
+	Subscribers int   `json:"subscribers"`
+}
+
+// EventBus fans orchestration events out to subscribers without letting a
+// slow subscriber stall the orchestrator beyond the configured policy
+type EventBus struct {
+	config EventBusConfig
+
+	mu     sync.RWMutex
+	subs   map[uint64]*Subscription
+	nextID uint64
+	closed bool
+
+	published atomic.Int64
+	delivered atomic.Int64
+	dropped   atomic.Int64
+}
+
+// Subscription receives events from an EventBus
+type Subscription struct {
+	id      uint64
+	bus     *EventBus
+	ch      chan OrchestrationEvent
+	types   map[EventType]bool
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+// NewEventBus creates a new event bus
+func NewEventBus(config EventBusConfig) *EventBus {
+	defaults := DefaultEventBusConfig()
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaults.BufferSize
+	}
+	if config.Policy == "" {
+		config.Policy = defaults.Policy
+	}
+	if config.BlockTimeout <= 0 {
+		config.BlockTimeout = defaults.BlockTimeout
+	}
+
+	return &EventBus{
+		config: config,
+		subs:   make(map[uint64]*Subscription),
+	}
+}
+
+// Subscribe registers a subscriber for the given event types, or all events if none are given
+func (b *EventBus) Subscribe(types ...EventType) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	sub := &Subscription{
+		id:  b.nextID,
+		bus: b,
+		ch:  make(chan OrchestrationEvent, b.config.BufferSize),
+	}
+	if len(types) > 0 {
+		sub.types = make(map[EventType]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	if b.closed {
+		sub.once.Do(func() { close(sub.ch) })
+		return sub
+	}
+
+	b.subs[sub.id] = sub
+	return sub
+}
+
+// Publish delivers an event to every interested subscriber
+func (b *EventBus) Publish(event OrchestrationEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return
+	}
+
+	b.published.Add(1)
+	for _, sub := range b.subs {
+		if sub.types != nil && !sub.types[event.Type] {
+			continue
+		}
+
+		if b.deliver(sub, event) {
+			b.delivered.Add(1)
+		} else {
+			sub.dropped.Add(1)
+			b.dropped.Add(1)
+			logger.Warn("orchestration event dropped", "type", event.Type, "subscriber", sub.id, "policy", b.config.Policy)
+		}
+	}
+}
+
+// deliver applies the backpressure policy to send event to sub. Callers must hold mu.
+func (b *EventBus) deliver(sub *Subscription, event OrchestrationEvent) bool {
+	select {
+	case sub.ch <- event:
+		return true
+	default:
+	}
+
+	switch b.config.Policy {
+	case BackpressureDropOldest:
+		select {
+		case <-sub.ch:
+		default:
+		}
+		select {
+		case sub.ch <- event:
+			// The evicted event counts as the drop
+			return false
+		default:
+			return false
+		}
+
+	case BackpressureBlock:
+		timer := time.NewTimer(b.config.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case sub.ch <- event:
+			return true
+		case <-timer.C:
+			return false
+		}
+
+	case BackpressureDropNewest:
+		return false
+
+	default:
+		return false
+	}
+}
+
+// Stats returns a snapshot of bus counters
+func (b *EventBus) Stats() EventBusStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return EventBusStats{
+		Published:   b.published.Load(),
+		Delivered:   b.delivered.Load(),
+		Dropped:     b.dropped.Load(),
+		Subscribers: len(b.subs),
+	}
+}
+
+// Close unsubscribes all subscribers and rejects further events
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+
+	for id, sub := range b.subs {
+		delete(b.subs, id)
+		sub.once.Do(func() { close(sub.ch) })
+	}
+}
+
+// Events returns the channel on which events are delivered. It is closed on unsubscribe.
+func (s *Subscription) Events() <-chan OrchestrationEvent {
+	return s.ch
+}
+
+// Dropped returns the number of events this subscriber missed
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe stops delivery and closes the events channel
+func (s *Subscription) Unsubscribe() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	delete(s.bus.subs, s.id)
+	s.once.Do(func() { close(s.ch) })
+}
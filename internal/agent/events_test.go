@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(id string) OrchestrationEvent {
+	return OrchestrationEvent{
+		Type:      EventTaskStarted,
+		TaskID:    id,
+		Payload:   TaskStartedPayload{TaskType: TaskTypeEdit},
+		Timestamp: time.Now(),
+	}
+}
+
+func TestNewEventBus_Defaults(t *testing.T) {
+	bus := NewEventBus(EventBusConfig{})
+	assert.Equal(t, DefaultEventBusConfig(), bus.config)
+}
+
+func TestEventBus_MultipleSubscribers(t *testing.T) {
+	bus := NewEventBus(DefaultEventBusConfig())
+	all := bus.Subscribe()
+	failures := bus.Subscribe(EventTaskFailed)
+
+	bus.Publish(testEvent("t1"))
+	bus.Publish(OrchestrationEvent{Type: EventTaskFailed, Payload: TaskFailedPayload{Error: "boom"}})
+
+	require.Len(t, all.Events(), 2)
+	require.Len(t, failures.Events(), 1)
+
+	event := <-failures.Events()
+	assert.Equal(t, "boom", event.Payload.(TaskFailedPayload).Error)
+
+	stats := bus.Stats()
+	assert.Equal(t, int64(2), stats.Published)
+	assert.Equal(t, int64(3), stats.Delivered)
+	assert.Equal(t, int64(0), stats.Dropped)
+	assert.Equal(t, 2, stats.Subscribers)
+}
+
+func TestEventBus_DropNewest(t *testing.T) {
+	bus := NewEventBus(EventBusConfig{BufferSize: 2, Policy: BackpressureDropNewest})
+	sub := bus.Subscribe()
+
+	for _, id := range []string{"a", "b", "c"} {
+		bus.Publish(testEvent(id))
+	}
+
+	assert.Equal(t, int64(1), sub.Dropped())
+	assert.Equal(t, int64(1), bus.Stats().Dropped)
+	assert.Equal(t, "a", (<-sub.Events()).TaskID)
+	assert.Equal(t, "b", (<-sub.Events()).TaskID)
+}
+
+func TestEventBus_DropOldest(t *testing.T) {
+	bus := NewEventBus(EventBusConfig{BufferSize: 2, Policy: BackpressureDropOldest})
+	sub := bus.Subscribe()
+
+	for _, id := range []string{"a", "b", "c"} {
+		bus.Publish(testEvent(id))
+	}
+
+	assert.Equal(t, int64(1), sub.Dropped())
+	assert.Equal(t, "b", (<-sub.Events()).TaskID)
+	assert.Equal(t, "c", (<-sub.Events()).TaskID)
+}
+
+func TestEventBus_Block(t *testing.T) {
+	bus := NewEventBus(EventBusConfig{BufferSize: 1, Policy: BackpressureBlock, BlockTimeout: time.Second})
+	sub := bus.Subscribe()
+	bus.Publish(testEvent("a"))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		bus.Publish(testEvent("b"))
+	}()
+
+	assert.Equal(t, "a", (<-sub.Events()).TaskID)
+	wg.Wait()
+	assert.Equal(t, "b", (<-sub.Events()).TaskID)
+	assert.Equal(t, int64(0), sub.Dropped())
+
+	// With nobody reading, the publisher gives up after the timeout
+	short := NewEventBus(EventBusConfig{BufferSize: 1, Policy: BackpressureBlock, BlockTimeout: 10 * time.Millisecond})
+	stalled := short.Subscribe()
+	short.Publish(testEvent("x"))
+	short.Publish(testEvent("y"))
+	assert.Equal(t, int64(1), stalled.Dropped())
+}
+
+func TestEventBus_UnsubscribeAndClose(t *testing.T) {
+	bus := NewEventBus(DefaultEventBusConfig())
+	sub := bus.Subscribe()
+	other := bus.Subscribe()
+
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	_, open := <-sub.Events()
+	assert.False(t, open)
+
+	bus.Publish(testEvent("a"))
+	assert.Len(t, other.Events(), 1)
+
+	bus.Close()
+	bus.Close()
+	other.Unsubscribe()
+	assert.Equal(t, 0, bus.Stats().Subscribers)
+
+	late := bus.Subscribe()
+	_, open = <-late.Events()
+	assert.False(t, open)
+
+	// Publishing after close is a no-op
+	bus.Publish(testEvent("b"))
+	assert.Equal(t, int64(1), bus.Stats().Published)
+}
+
+func TestOrchestrator_EventsDroppedMetric(t *testing.T) {
+	config := DefaultOrchestrationConfig()
+	config.Events = EventBusConfig{BufferSize: 1, Policy: BackpressureDropNewest}
+	orchestrator := NewOrchestrator(config)
+	orchestrator.Events().Subscribe()
+
+	orchestrator.emitEvent("t1", "", TaskStartedPayload{TaskType: TaskTypeEdit})
+	orchestrator.emitEvent("t1", "", TaskFailedPayload{Error: "x"})
+
+	assert.Equal(t, int64(1), orchestrator.GetMetrics().EventsDropped)
+}
@@ -4,11 +4,14 @@ package agent
 import (
 	"fmt"
 	"strings"
-	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/model"
 	"github.com/dshills/sigil/internal/sandbox"
+	"github.com/dshills/sigil/internal/trust"
 )
 
 // Factory creates and manages agents
@@ -44,7 +47,20 @@ func (f *Factory) CreateAgent(agentID string, agentConfig AgentConfig) (Agent, e
 
 	switch agentConfig.Role {
 	case RoleLead:
-		return NewLeadAgent(agentID, agentModel, agentConfig, f.sandbox), nil
+		lead := NewLeadAgent(agentID, agentModel, agentConfig, f.sandbox)
+		if f.config.Tools.Enabled {
+			if root, err := git.GetRepositoryRoot(); err == nil {
+				tools := f.config.Tools
+				if trust.Untrusted() {
+					// Language servers load and build the project
+					tools.LSP.Enabled = false
+				}
+				lead.EnableTools(root, tools)
+			} else {
+				logger.Debug("repository tools disabled outside a git repository", "agent_id", agentID)
+			}
+		}
+		return lead, nil
 
 	case RoleReviewer:
 		specialization := agentConfig.Specialization
@@ -120,7 +136,7 @@ func (f *Factory) CreateTaskFromCommand(taskType TaskType, description string, f
 	}
 
 	task := &Task{
-		ID:          fmt.Sprintf("task_%d", time.Now().Unix()),
+		ID:          fmt.Sprintf("task_%d_%s", deterministic.Now().Unix(), deterministic.TokenFor("task/"+string(taskType), 6)),
 		Type:        taskType,
 		Description: description,
 		Context: TaskContext{
@@ -130,7 +146,7 @@ func (f *Factory) CreateTaskFromCommand(taskType TaskType, description string, f
 		},
 		Constraints: constraints,
 		Priority:    PriorityMedium,
-		CreatedAt:   time.Now(),
+		CreatedAt:   deterministic.Now(),
 	}
 
 	return task, nil
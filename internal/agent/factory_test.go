@@ -5,6 +5,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/deterministic"
 )
 
 func TestNewFactory(t *testing.T) {
@@ -18,6 +20,36 @@ func TestNewFactory(t *testing.T) {
 	assert.Equal(t, config, factory.config)
 }
 
+func TestFactory_CreateTaskFromCommand_DeterministicIDs(t *testing.T) {
+	deterministic.Set(true)
+	defer deterministic.Set(false)
+	factory := NewFactory(nil, DefaultOrchestrationConfig())
+
+	ids := func() []string {
+		deterministic.Set(true)
+		var ids []string
+		for range 3 {
+			task, err := factory.CreateTaskFromCommand(TaskTypeEdit, "rename", nil, nil, nil)
+			require.NoError(t, err)
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	first := ids()
+	assert.NotEqual(t, first[0], first[1], "tasks of one run get distinct IDs")
+	assert.NotEqual(t, first[1], first[2])
+	assert.Equal(t, first, ids(), "a rerun assigns the same IDs")
+
+	deterministic.Set(true)
+	_, err := factory.CreateTaskFromCommand(TaskTypeReview, "check", nil, nil, nil)
+	require.NoError(t, err)
+	task, err := factory.CreateTaskFromCommand(TaskTypeEdit, "rename", nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, first[0], task.ID, "tasks of other types named first do not change the ID")
+	assert.Regexp(t, `^task_\d+_[a-z0-9]{6}$`, task.ID)
+}
+
 func TestFactory_CreateLeadAgent(t *testing.T) {
 	mockSandbox := &MockSandboxManager{}
 	config := DefaultOrchestrationConfig()
@@ -221,6 +253,16 @@ func TestOrchestrationConfig_Defaults(t *testing.T) {
 	})
 }
 
+func TestWithModel(t *testing.T) {
+	config := DefaultOrchestrationConfig()
+	single := WithModel(config, "ollama:llama3")
+
+	for id, profile := range single.AgentProfiles {
+		assert.Equal(t, "ollama:llama3", profile.Model, id)
+	}
+	assert.Equal(t, "gpt-4", config.AgentProfiles["reviewer"].Model, "the original configuration is unchanged")
+}
+
 func TestResolutionMethods(t *testing.T) {
 	tests := []struct {
 		name     string
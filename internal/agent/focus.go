@@ -0,0 +1,192 @@
+// Package agent provides the review focus area taxonomy
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// FocusArea is a review focus area from the taxonomy
+type FocusArea string
+
+const (
+	FocusSecurity      FocusArea = "security"
+	FocusPerformance   FocusArea = "performance"
+	FocusStyle         FocusArea = "style"
+	FocusTesting       FocusArea = "testing"
+	FocusAccessibility FocusArea = "accessibility"
+	FocusI18n          FocusArea = "i18n"
+	FocusConcurrency   FocusArea = "concurrency"
+	FocusErrorHandling FocusArea = "error-handling"
+	FocusGeneral       FocusArea = "general" // Findings outside the taxonomy
+)
+
+// FocusDefinition describes how a focus area is reviewed: the constraint
+// added to the task, the comment type findings are filed under and the
+// reviewer specialization, if any, brought in for it
+type FocusDefinition struct {
+	Area           FocusArea
+	Aliases        []string
+	Description    string
+	ConstraintType ConstraintType
+	Severity       Severity
+	CommentType    CommentType
+	Specialization string // Empty when general reviewers cover the area
+}
+
+// Constraint returns the task constraint for the focus area
+func (d FocusDefinition) Constraint() Constraint {
+	return Constraint{
+		Type:        d.ConstraintType,
+		Description: d.Description,
+		Severity:    d.Severity,
+	}
+}
+
+// focusTaxonomy lists the focus areas in report order
+var focusTaxonomy = []FocusDefinition{
+	{
+		Area:           FocusSecurity,
+		Aliases:        []string{"sec"},
+		Description:    "Identify security vulnerabilities and unsafe practices",
+		ConstraintType: ConstraintTypeSecurity,
+		Severity:       SeverityError,
+		CommentType:    CommentTypeSecurity,
+		Specialization: SpecializationSecurity,
+	},
+	{
+		Area:           FocusPerformance,
+		Aliases:        []string{"perf"},
+		Description:    "Identify performance bottlenecks and optimization opportunities",
+		ConstraintType: ConstraintTypePerformance,
+		Severity:       SeverityWarning,
+		CommentType:    CommentTypePerformance,
+		Specialization: SpecializationPerformance,
+	},
+	{
+		Area:           FocusStyle,
+		Aliases:        []string{"lint", "formatting"},
+		Description:    "Check code style and formatting consistency",
+		ConstraintType: ConstraintTypeStyle,
+		Severity:       SeverityInfo,
+		CommentType:    CommentTypeStyle,
+	},
+	{
+		Area:           FocusTesting,
+		Aliases:        []string{"tests", "test"},
+		Description:    "Check test coverage, test quality and untested edge cases",
+		ConstraintType: ConstraintTypeTesting,
+		Severity:       SeverityWarning,
+		CommentType:    CommentTypeTesting,
+		Specialization: SpecializationTesting,
+	},
+	{
+		Area:           FocusAccessibility,
+		Aliases:        []string{"a11y"},
+		Description:    "Identify accessibility barriers such as missing labels, poor contrast and keyboard traps",
+		ConstraintType: ConstraintTypeAccessibility,
+		Severity:       SeverityWarning,
+		CommentType:    CommentTypeAccessibility,
+		Specialization: SpecializationAccessibility,
+	},
+	{
+		Area:           FocusI18n,
+		Aliases:        []string{"internationalization", "l10n", "localization"},
+		Description:    "Identify hard-coded user-facing text and locale-sensitive formatting",
+		ConstraintType: ConstraintTypeI18n,
+		Severity:       SeverityWarning,
+		CommentType:    CommentTypeI18n,
+		Specialization: SpecializationI18n,
+	},
+	{
+		Area:           FocusConcurrency,
+		Aliases:        []string{"races", "threading"},
+		Description:    "Identify data races, deadlocks, leaked goroutines or threads and unsafe shared state",
+		ConstraintType: ConstraintTypeConcurrency,
+		Severity:       SeverityError,
+		CommentType:    CommentTypeConcurrency,
+	},
+	{
+		Area:           FocusErrorHandling,
+		Aliases:        []string{"errors", "error_handling"},
+		Description:    "Check that errors are handled, wrapped with context and never silently dropped",
+		ConstraintType: ConstraintTypeErrorHandling,
+		Severity:       SeverityWarning,
+		CommentType:    CommentTypeErrorHandling,
+	},
+}
+
+// FocusAreas returns the focus area taxonomy in report order
+func FocusAreas() []FocusDefinition {
+	return append([]FocusDefinition(nil), focusTaxonomy...)
+}
+
+// ParseFocusArea resolves a focus area by name or alias
+func ParseFocusArea(name string) (FocusDefinition, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	for _, definition := range focusTaxonomy {
+		if string(definition.Area) == key {
+			return definition, nil
+		}
+		for _, alias := range definition.Aliases {
+			if alias == key {
+				return definition, nil
+			}
+		}
+	}
+
+	names := make([]string, len(focusTaxonomy))
+	for i, definition := range focusTaxonomy {
+		names[i] = string(definition.Area)
+	}
+	return FocusDefinition{}, errors.ValidationError("ParseFocusArea", fmt.Sprintf("unknown focus area: %s", name)).
+		WithHint(fmt.Sprintf("use one of: %s", strings.Join(names, ", ")))
+}
+
+// FocusForComment returns the focus area a review comment belongs to, or
+// FocusGeneral for comment types outside the taxonomy
+func FocusForComment(commentType CommentType) FocusArea {
+	for _, definition := range focusTaxonomy {
+		if definition.CommentType == commentType {
+			return definition.Area
+		}
+	}
+	return FocusGeneral
+}
+
+// WithFocusReviewers returns a copy of config with a reviewer for every
+// specialization the focus areas call for that no enabled profile covers.
+// The added reviewers use the model of the default reviewer profile.
+func WithFocusReviewers(config OrchestrationConfig, areas []FocusDefinition) OrchestrationConfig {
+	profiles := make(map[string]AgentConfig, len(config.AgentProfiles)+len(areas))
+	covered := make(map[string]bool)
+	for id, profile := range config.AgentProfiles {
+		profiles[id] = profile
+		if profile.Enabled && profile.Specialization != "" {
+			covered[profile.Specialization] = true
+		}
+	}
+
+	base, ok := config.AgentProfiles["reviewer"]
+	if !ok {
+		config.AgentProfiles = profiles
+		return config
+	}
+
+	for _, area := range areas {
+		if area.Specialization == "" || covered[area.Specialization] {
+			continue
+		}
+		profile := base
+		profile.Role = RoleReviewer
+		profile.Specialization = area.Specialization
+		profile.Enabled = true
+		profiles[area.Specialization+"_reviewer"] = profile
+		covered[area.Specialization] = true
+	}
+
+	config.AgentProfiles = profiles
+	return config
+}
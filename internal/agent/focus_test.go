@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFocusArea(t *testing.T) {
+	tests := []struct {
+		name string
+		want FocusArea
+	}{
+		{"security", FocusSecurity},
+		{"perf", FocusPerformance},
+		{" Performance ", FocusPerformance},
+		{"a11y", FocusAccessibility},
+		{"l10n", FocusI18n},
+		{"error_handling", FocusErrorHandling},
+		{"errors", FocusErrorHandling},
+		{"races", FocusConcurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition, err := ParseFocusArea(tt.name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, definition.Area)
+		})
+	}
+
+	_, err := ParseFocusArea("vibes")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown focus area: vibes")
+}
+
+func TestFocusDefinition_Constraint(t *testing.T) {
+	definition, err := ParseFocusArea("concurrency")
+	require.NoError(t, err)
+
+	constraint := definition.Constraint()
+	assert.Equal(t, ConstraintTypeConcurrency, constraint.Type)
+	assert.Equal(t, SeverityError, constraint.Severity)
+	assert.Contains(t, constraint.Description, "data races")
+}
+
+func TestFocusForComment(t *testing.T) {
+	assert.Equal(t, FocusSecurity, FocusForComment(CommentTypeSecurity))
+	assert.Equal(t, FocusErrorHandling, FocusForComment(CommentTypeErrorHandling))
+	assert.Equal(t, FocusGeneral, FocusForComment(CommentTypeLogic))
+	assert.Equal(t, FocusGeneral, FocusForComment(""))
+}
+
+func TestWithFocusReviewers(t *testing.T) {
+	config := DefaultOrchestrationConfig()
+	config.AgentProfiles["perf"] = AgentConfig{Role: RoleReviewer, Model: "gpt-4", Specialization: SpecializationPerformance, Enabled: true}
+
+	var areas []FocusDefinition
+	for _, name := range []string{"security", "performance", "style", "testing"} {
+		area, err := ParseFocusArea(name)
+		require.NoError(t, err)
+		areas = append(areas, area)
+	}
+
+	focused := WithFocusReviewers(config, areas)
+
+	security, ok := focused.AgentProfiles["security_reviewer"]
+	require.True(t, ok)
+	assert.Equal(t, RoleReviewer, security.Role)
+	assert.Equal(t, SpecializationSecurity, security.Specialization)
+	assert.Equal(t, config.AgentProfiles["reviewer"].Model, security.Model)
+	assert.True(t, security.Enabled)
+
+	assert.Contains(t, focused.AgentProfiles, "testing_reviewer")
+	assert.NotContains(t, focused.AgentProfiles, "performance_reviewer", "an enabled profile already covers performance")
+	assert.Len(t, focused.AgentProfiles, len(config.AgentProfiles)+2, "style has no specialized reviewer")
+	assert.NotContains(t, config.AgentProfiles, "security_reviewer", "the original config is not modified")
+}
+
+func TestWithFocusReviewers_AccessibilityAndI18n(t *testing.T) {
+	a11y, err := ParseFocusArea("a11y")
+	require.NoError(t, err)
+	i18n, err := ParseFocusArea("i18n")
+	require.NoError(t, err)
+
+	focused := WithFocusReviewers(DefaultOrchestrationConfig(), []FocusDefinition{a11y, i18n})
+
+	assert.Equal(t, SpecializationAccessibility, focused.AgentProfiles["accessibility_reviewer"].Specialization)
+	assert.Equal(t, SpecializationI18n, focused.AgentProfiles["i18n_reviewer"].Specialization)
+}
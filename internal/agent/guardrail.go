@@ -0,0 +1,138 @@
+// Package agent provides guardrail checks for proposed changes
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/sandbox"
+	"gopkg.in/yaml.v3"
+)
+
+// Violation is a guardrail finding attached to a proposal
+type Violation struct {
+	Path    string        `json:"path"`
+	Line    int           `json:"line,omitempty"`
+	Rule    ViolationRule `json:"rule"`
+	Message string        `json:"message"`
+	Hint    string        `json:"hint,omitempty"` // How to fix it, when the rule says
+}
+
+// ViolationRule identifies the check that produced a violation
+type ViolationRule string
+
+const (
+	ViolationSandbox ViolationRule = "sandbox" // Sandbox content, secret and path rules
+	ViolationSyntax  ViolationRule = "syntax"  // File does not parse
+)
+
+// String formats the violation for prompts and logs
+func (v Violation) String() string {
+	location := v.Path
+	if v.Line > 0 {
+		location = fmt.Sprintf("%s:%d", v.Path, v.Line)
+	}
+	text := fmt.Sprintf("%s [%s]: %s", location, v.Rule, v.Message)
+	if v.Hint != "" {
+		text += fmt.Sprintf(" (fix: %s)", v.Hint)
+	}
+	return text
+}
+
+// checkProposals runs the guardrails over every proposal's changes and
+// attaches the violations found, so problems surface with the proposal
+// rather than in review
+func (a *BaseAgent) checkProposals(proposals []Proposal) {
+	for i := range proposals {
+		proposal := &proposals[i]
+		proposal.Violations = a.checkChanges(proposal.Changes)
+		if len(proposal.Violations) > 0 {
+			logger.Warn("proposal failed guardrails", "agent_id", a.id, "proposal_id", proposal.ID,
+				"violations", len(proposal.Violations))
+		}
+	}
+}
+
+// checkChanges validates each change against the sandbox rules and, for
+// whole-file content, checks that the file parses
+func (a *BaseAgent) checkChanges(changes []Change) []Violation {
+	var violations []Violation
+	for _, change := range changes {
+		if a.sandbox != nil {
+			if err := a.sandbox.ValidateCode(change.Path, change.NewContent); err != nil {
+				violation := Violation{Path: change.Path, Rule: ViolationSandbox, Message: err.Error()}
+				if ruleViolation, ok := sandbox.ViolationOf(err); ok {
+					violation.Line = ruleViolation.Line
+					violation.Hint = ruleViolation.Remediation
+					if violation.Line > 0 && change.StartLine > 0 {
+						// Lines count from the start of the changed range
+						violation.Line += change.StartLine - 1
+					}
+				}
+				violations = append(violations, violation)
+			}
+		}
+
+		if isWholeFile(change) {
+			if message := syntaxError(change.Path, change.NewContent); message != "" {
+				violations = append(violations, Violation{Path: change.Path, Rule: ViolationSyntax, Message: message})
+			}
+		}
+	}
+	return violations
+}
+
+// isWholeFile reports whether a change carries a complete file, as opposed to
+// a line range or a deletion that cannot be parsed on its own
+func isWholeFile(change Change) bool {
+	switch change.Type {
+	case ChangeTypeCreate, ChangeTypeUpdate:
+		return change.StartLine == 0 && change.EndLine == 0
+	default:
+		return false
+	}
+}
+
+// syntaxError parses content according to the file type and returns the
+// first error found, or "" when it parses; unknown file types are not checked
+func syntaxError(path, content string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		// Later parse errors usually cascade from the first, so report only it
+		_, err := parser.ParseFile(token.NewFileSet(), path, content, parser.SkipObjectResolution)
+		if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+			return fmt.Sprintf("line %d: %s", list[0].Pos.Line, list[0].Msg)
+		} else if err != nil {
+			return err.Error()
+		}
+	case ".json":
+		var v any
+		if err := json.Unmarshal([]byte(content), &v); err != nil {
+			return err.Error()
+		}
+	case ".yml", ".yaml":
+		var v any
+		if err := yaml.Unmarshal([]byte(content), &v); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// formatViolations lists violations for a review prompt
+func formatViolations(violations []Violation) string {
+	if len(violations) == 0 {
+		return ""
+	}
+	prompt := "\nGuardrail Violations (found automatically before review):\n"
+	for _, violation := range violations {
+		prompt += fmt.Sprintf("- %s\n", violation)
+	}
+	return prompt
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+func TestBaseAgent_CheckProposals(t *testing.T) {
+	mockSandbox := &MockSandboxManager{}
+	mockSandbox.On("ValidateCode", "config.go", mock.Anything).
+		Return(errors.New("content in config.go matches blocked pattern (rule: No credentials)"))
+	mockSandbox.On("ValidateCode", mock.Anything, mock.Anything).Return(nil)
+
+	agent := NewBaseAgent("lead", RoleLead, &MockModel{}, nil, AgentConfig{}, mockSandbox)
+
+	proposals := []Proposal{
+		{
+			ID: "clean",
+			Changes: []Change{
+				{Type: ChangeTypeCreate, Path: "main.go", NewContent: "package main\n\nfunc main() {}\n"},
+				{Type: ChangeTypeUpdate, Path: "settings.json", NewContent: `{"debug": true}`},
+			},
+		},
+		{
+			ID: "dirty",
+			Changes: []Change{
+				{Type: ChangeTypeUpdate, Path: "config.go", NewContent: "package config\n\nconst token = \"abc\"\n"},
+				{Type: ChangeTypeCreate, Path: "broken.go", NewContent: "package broken\n\nfunc f( {\n"},
+				{Type: ChangeTypeUpdate, Path: "app.yaml", NewContent: "key: [unclosed"},
+				// Line-range edits are fragments and are not parsed
+				{Type: ChangeTypeUpdate, Path: "partial.go", NewContent: "return nil", StartLine: 3, EndLine: 4},
+			},
+		},
+	}
+
+	agent.checkProposals(proposals)
+
+	assert.Empty(t, proposals[0].Violations)
+
+	violations := proposals[1].Violations
+	require.Len(t, violations, 3)
+	assert.Equal(t, Violation{Path: "config.go", Rule: ViolationSandbox,
+		Message: "content in config.go matches blocked pattern (rule: No credentials)"}, violations[0])
+	assert.Equal(t, "broken.go", violations[1].Path)
+	assert.Equal(t, ViolationSyntax, violations[1].Rule)
+	assert.Contains(t, violations[1].Message, "line 3")
+	assert.Equal(t, "app.yaml", violations[2].Path)
+	assert.Equal(t, ViolationSyntax, violations[2].Rule)
+}
+
+func TestSyntaxError(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		wantErr bool
+	}{
+		{"valid go", "a.go", "package a\n", false},
+		{"invalid go", "a.go", "package a\nfunc (", true},
+		{"invalid json", "a.json", "{", true},
+		{"valid yaml", "a.yml", "a: 1\n", false},
+		{"unchecked type", "a.txt", "{{{", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, syntaxError(tt.path, tt.content) != "")
+		})
+	}
+}
+
+func TestFormatViolations(t *testing.T) {
+	assert.Empty(t, formatViolations(nil))
+
+	prompt := formatViolations([]Violation{{Path: "a.go", Rule: ViolationSyntax, Message: "line 2: expected ')'"}})
+	assert.Contains(t, prompt, "Guardrail Violations")
+	assert.Contains(t, prompt, "- a.go [syntax]: line 2: expected ')'")
+}
+
+func TestBaseAgent_CheckChanges_RuleViolation(t *testing.T) {
+	mockSandbox := &MockSandboxManager{}
+	mockSandbox.On("ValidateCode", "exit.go", mock.Anything).Return(&sandbox.RuleViolation{
+		Kind: sandbox.ViolationContent, Rule: "No dangerous operations", File: "exit.go", Line: 2,
+		Excerpt: "os.Exit(", Message: "content in exit.go:2 matches blocked pattern", Remediation: "return an error",
+	})
+
+	agent := NewBaseAgent("lead", RoleLead, &MockModel{}, nil, AgentConfig{}, mockSandbox)
+	violations := agent.checkChanges([]Change{
+		{Type: ChangeTypeUpdate, Path: "exit.go", NewContent: "\nos.Exit(1)", StartLine: 10, EndLine: 11},
+	})
+
+	require.Len(t, violations, 1)
+	assert.Equal(t, 11, violations[0].Line)
+	assert.Equal(t, "return an error", violations[0].Hint)
+	assert.Equal(t, `exit.go:11 [sandbox]: content in exit.go:2 matches blocked pattern: "os.Exit(" (rule: No dangerous operations) (fix: return an error)`,
+		violations[0].String())
+}
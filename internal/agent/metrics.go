@@ -0,0 +1,240 @@
+package agent
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyWindowSize is the number of recent samples kept for percentile calculation
+const latencyWindowSize = 512
+
+// latencyWindow is a fixed-size ring buffer of task durations
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+// newLatencyWindow creates a latency window with the given capacity
+func newLatencyWindow(size int) *latencyWindow {
+	return &latencyWindow{samples: make([]time.Duration, size)}
+}
+
+// add records a sample, evicting the oldest once the window is full
+func (w *latencyWindow) add(d time.Duration) {
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % len(w.samples)
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+// percentiles computes nearest-rank percentiles over the current window
+func (w *latencyWindow) percentiles() LatencyPercentiles {
+	n := w.next
+	if w.full {
+		n = len(w.samples)
+	}
+	if n == 0 {
+		return LatencyPercentiles{}
+	}
+
+	sorted := make([]time.Duration, n)
+	copy(sorted, w.samples[:n])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := func(p float64) time.Duration {
+		idx := int(p*float64(n)+0.5) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= n {
+			idx = n - 1
+		}
+		return sorted[idx]
+	}
+
+	return LatencyPercentiles{
+		Samples: n,
+		P50:     rank(0.50),
+		P90:     rank(0.90),
+		P99:     rank(0.99),
+		Max:     sorted[n-1],
+	}
+}
+
+// taskTypeStats accumulates metrics for one task type
+type taskTypeStats struct {
+	total     int64
+	completed int64
+	failed    int64
+	latency   *latencyWindow
+}
+
+// metricsCollector records orchestration metrics. Counters are atomic so the
+// hot path never blocks readers; everything else is guarded by mu and only
+// leaves the collector through deep-copied snapshots.
+type metricsCollector struct {
+	total     atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+
+	mu          sync.Mutex
+	average     time.Duration
+	latency     *latencyWindow
+	byType      map[TaskType]*taskTypeStats
+	agentTasks  map[string]int64
+	reviews     int64
+	consensus   int64
+	conflicts   int64
+	scoreSum    float64
+	lastUpdated time.Time
+}
+
+// newMetricsCollector creates an empty collector
+func newMetricsCollector() *metricsCollector {
+	return &metricsCollector{
+		latency:     newLatencyWindow(latencyWindowSize),
+		byType:      make(map[TaskType]*taskTypeStats),
+		agentTasks:  make(map[string]int64),
+		lastUpdated: time.Now(),
+	}
+}
+
+// registerAgent makes an agent visible in utilization figures
+func (m *metricsCollector) registerAgent(agentID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.agentTasks[agentID]; !ok {
+		m.agentTasks[agentID] = 0
+	}
+}
+
+// typeStats returns the stats for a task type, creating them on first use.
+// Callers must hold mu.
+func (m *metricsCollector) typeStats(taskType TaskType) *taskTypeStats {
+	stats, ok := m.byType[taskType]
+	if !ok {
+		stats = &taskTypeStats{latency: newLatencyWindow(latencyWindowSize)}
+		m.byType[taskType] = stats
+	}
+	return stats
+}
+
+// recordStart counts a task as started
+func (m *metricsCollector) recordStart(taskType TaskType) {
+	m.total.Add(1)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.typeStats(taskType).total++
+	m.lastUpdated = time.Now()
+}
+
+// recordSuccess records a completed task led by agentID
+func (m *metricsCollector) recordSuccess(taskType TaskType, agentID string, duration time.Duration) {
+	completed := m.completed.Add(1)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if completed == 1 {
+		m.average = duration
+	} else {
+		// Exponential moving average
+		alpha := 0.1
+		m.average = time.Duration(float64(m.average)*(1-alpha) + float64(duration)*alpha)
+	}
+
+	m.latency.add(duration)
+	stats := m.typeStats(taskType)
+	stats.completed++
+	stats.latency.add(duration)
+
+	if agentID != "" {
+		m.agentTasks[agentID]++
+	}
+	m.lastUpdated = time.Now()
+}
+
+// recordFailure records a failed task
+func (m *metricsCollector) recordFailure(taskType TaskType, duration time.Duration) {
+	m.failed.Add(1)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.latency.add(duration)
+	stats := m.typeStats(taskType)
+	stats.failed++
+	stats.latency.add(duration)
+	m.lastUpdated = time.Now()
+}
+
+// recordReview records the outcome of a proposal review
+func (m *metricsCollector) recordReview(result *ConsensusResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reviews++
+	if result.Decision != ConsensusNoConsensus {
+		m.consensus++
+	}
+	if len(result.Conflicts) > 0 {
+		m.conflicts++
+	}
+	m.scoreSum += result.Score
+	m.lastUpdated = time.Now()
+}
+
+// snapshot returns a deep copy of the current metrics
+func (m *metricsCollector) snapshot() OrchestrationMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snap := OrchestrationMetrics{
+		TotalTasks:       m.total.Load(),
+		CompletedTasks:   m.completed.Load(),
+		FailedTasks:      m.failed.Load(),
+		AverageTaskTime:  m.average,
+		Latency:          m.latency.percentiles(),
+		ByTaskType:       make(map[TaskType]TaskTypeMetrics, len(m.byType)),
+		AgentUtilization: make(map[string]float64, len(m.agentTasks)),
+		LastUpdated:      m.lastUpdated,
+	}
+
+	for taskType, stats := range m.byType {
+		snap.ByTaskType[taskType] = TaskTypeMetrics{
+			TotalTasks:     stats.total,
+			CompletedTasks: stats.completed,
+			FailedTasks:    stats.failed,
+			Latency:        stats.latency.percentiles(),
+		}
+	}
+
+	for agentID, led := range m.agentTasks {
+		if snap.CompletedTasks > 0 {
+			snap.AgentUtilization[agentID] = float64(led) / float64(snap.CompletedTasks)
+		} else {
+			snap.AgentUtilization[agentID] = 0.0
+		}
+	}
+
+	if m.reviews > 0 {
+		snap.ConsensusRate = float64(m.consensus) / float64(m.reviews)
+		snap.ConflictRate = float64(m.conflicts) / float64(m.reviews)
+		snap.QualityScore = m.scoreSum / float64(m.reviews)
+	}
+
+	return snap
+}
+
+// MetricsJSON returns the current metrics snapshot encoded as JSON
+func (o *DefaultOrchestrator) MetricsJSON() ([]byte, error) {
+	return json.MarshalIndent(o.GetMetrics(), "", "  ")
+}
@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyWindow_Percentiles(t *testing.T) {
+	window := newLatencyWindow(100)
+	assert.Equal(t, LatencyPercentiles{}, window.percentiles())
+
+	for i := 1; i <= 100; i++ {
+		window.add(time.Duration(i) * time.Millisecond)
+	}
+
+	p := window.percentiles()
+	assert.Equal(t, 100, p.Samples)
+	assert.Equal(t, 50*time.Millisecond, p.P50)
+	assert.Equal(t, 90*time.Millisecond, p.P90)
+	assert.Equal(t, 99*time.Millisecond, p.P99)
+	assert.Equal(t, 100*time.Millisecond, p.Max)
+
+	// Older samples are evicted once the window wraps
+	window.add(500 * time.Millisecond)
+	p = window.percentiles()
+	assert.Equal(t, 100, p.Samples)
+	assert.Equal(t, 500*time.Millisecond, p.Max)
+}
+
+func TestMetricsCollector_Snapshot(t *testing.T) {
+	m := newMetricsCollector()
+	m.registerAgent("lead")
+	m.registerAgent("idle")
+
+	m.recordStart(TaskTypeEdit)
+	m.recordSuccess(TaskTypeEdit, "lead", 10*time.Millisecond)
+	m.recordStart(TaskTypeReview)
+	m.recordFailure(TaskTypeReview, 5*time.Millisecond)
+	m.recordReview(&ConsensusResult{Decision: ConsensusApprove, Score: 0.8})
+	m.recordReview(&ConsensusResult{Decision: ConsensusNoConsensus, Conflicts: []Conflict{{}}, Score: 0.4})
+
+	snap := m.snapshot()
+	assert.Equal(t, int64(2), snap.TotalTasks)
+	assert.Equal(t, int64(1), snap.CompletedTasks)
+	assert.Equal(t, int64(1), snap.FailedTasks)
+	assert.Equal(t, 10*time.Millisecond, snap.AverageTaskTime)
+	assert.Equal(t, 2, snap.Latency.Samples)
+
+	assert.Equal(t, int64(1), snap.ByTaskType[TaskTypeEdit].CompletedTasks)
+	assert.Equal(t, int64(1), snap.ByTaskType[TaskTypeReview].FailedTasks)
+	assert.Equal(t, 10*time.Millisecond, snap.ByTaskType[TaskTypeEdit].Latency.P50)
+
+	assert.Equal(t, 1.0, snap.AgentUtilization["lead"])
+	assert.Equal(t, 0.0, snap.AgentUtilization["idle"])
+	assert.Equal(t, 0.5, snap.ConsensusRate)
+	assert.Equal(t, 0.5, snap.ConflictRate)
+	assert.InDelta(t, 0.6, snap.QualityScore, 1e-9)
+
+	// Snapshots must not alias collector state
+	snap.AgentUtilization["lead"] = 42
+	snap.ByTaskType[TaskTypeEdit] = TaskTypeMetrics{}
+	again := m.snapshot()
+	assert.Equal(t, 1.0, again.AgentUtilization["lead"])
+	assert.Equal(t, int64(1), again.ByTaskType[TaskTypeEdit].CompletedTasks)
+}
+
+func TestMetricsCollector_Concurrent(t *testing.T) {
+	m := newMetricsCollector()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.recordStart(TaskTypeGenerate)
+				if j%2 == 0 {
+					m.recordSuccess(TaskTypeGenerate, "agent", time.Duration(j)*time.Microsecond)
+				} else {
+					m.recordFailure(TaskTypeGenerate, time.Duration(j)*time.Microsecond)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				snap := m.snapshot()
+				snap.AgentUtilization["reader"] = 1
+			}
+		}()
+	}
+	wg.Wait()
+
+	snap := m.snapshot()
+	assert.Equal(t, int64(800), snap.TotalTasks)
+	assert.Equal(t, snap.TotalTasks, snap.CompletedTasks+snap.FailedTasks)
+	assert.Equal(t, int64(800), snap.ByTaskType[TaskTypeGenerate].TotalTasks)
+}
+
+func TestOrchestrator_MetricsJSON(t *testing.T) {
+	orchestrator := NewOrchestrator(DefaultOrchestrationConfig())
+	orchestrator.metrics.recordStart(TaskTypeDocument)
+	orchestrator.metrics.recordSuccess(TaskTypeDocument, "lead", time.Second)
+
+	data, err := orchestrator.MetricsJSON()
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, float64(1), decoded["total_tasks"])
+	assert.Contains(t, decoded, "latency")
+	assert.Contains(t, decoded["by_task_type"], "document")
+}
@@ -4,62 +4,56 @@ package agent
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/sandbox"
 )
 
 // DefaultOrchestrator implements the Orchestrator interface
 type DefaultOrchestrator struct {
-	agents  map[string]Agent
-	config  OrchestrationConfig
-	metrics OrchestrationMetrics
-	mu      sync.RWMutex
-	eventCh chan OrchestrationEvent
-	stopCh  chan struct{}
+	agents      map[string]Agent
+	config      OrchestrationConfig
+	metrics     *metricsCollector
+	calibration *calibrator
+	confirm     ConfirmFunc
+	clarify     ClarifyFunc
+	mu          sync.RWMutex
+	events      *EventBus
+	logSub      *Subscription
+	traces      map[string]*trace // Tasks being traced, by ID
+	sample      func() float64
 }
 
-// OrchestrationEvent represents events in the orchestration process
-type OrchestrationEvent struct {
-	Type      EventType         `json:"type"`
-	TaskID    string            `json:"task_id,omitempty"`
-	AgentID   string            `json:"agent_id,omitempty"`
-	Data      map[string]string `json:"data,omitempty"`
-	Timestamp time.Time         `json:"timestamp"`
-}
-
-// EventType for orchestration events
-type EventType string
-
-const (
-	EventTaskStarted      EventType = "task_started"
-	EventTaskCompleted    EventType = "task_completed"
-	EventTaskFailed       EventType = "task_failed"
-	EventReviewStarted    EventType = "review_started"
-	EventReviewCompleted  EventType = "review_completed"
-	EventConsensusReached EventType = "consensus_reached"
-	EventConflictDetected EventType = "conflict_detected"
-)
+// ConfirmFunc asks the user whether to proceed with a result whose confidence
+// is below the floor
+type ConfirmFunc func(ctx context.Context, result *Result, abstention *Abstention) (bool, error)
 
 // NewOrchestrator creates a new orchestrator
 func NewOrchestrator(config OrchestrationConfig) *DefaultOrchestrator {
 	return &DefaultOrchestrator{
-		agents: make(map[string]Agent),
-		config: config,
-		metrics: OrchestrationMetrics{
-			TotalTasks:       0,
-			CompletedTasks:   0,
-			FailedTasks:      0,
-			AgentUtilization: make(map[string]float64),
-			LastUpdated:      time.Now(),
-		},
-		eventCh: make(chan OrchestrationEvent, 100),
-		stopCh:  make(chan struct{}),
+		agents:      make(map[string]Agent),
+		config:      config,
+		metrics:     newMetricsCollector(),
+		calibration: newCalibrator(config.Confidence.PriorSamples, config.Confidence.File),
+		events:      NewEventBus(config.Events),
+		sample:      defaultSample,
 	}
 }
 
+// SetConfirmFunc sets how low-confidence results are put to the user.
+// Without one the orchestrator abstains from such results.
+func (o *DefaultOrchestrator) SetConfirmFunc(confirm ConfirmFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.confirm = confirm
+}
+
 // RegisterAgent adds an agent to the orchestrator
 func (o *DefaultOrchestrator) RegisterAgent(agent Agent) error {
 	o.mu.Lock()
@@ -77,7 +71,7 @@ func (o *DefaultOrchestrator) RegisterAgent(agent Agent) error {
 	}
 
 	o.agents[agentID] = agent
-	o.metrics.AgentUtilization[agentID] = 0.0
+	o.metrics.registerAgent(agentID)
 
 	logger.Info("registered agent", "agent_id", agentID, "role", agent.GetRole(),
 		"capabilities", len(agent.GetCapabilities()))
@@ -131,17 +125,25 @@ func (o *DefaultOrchestrator) GetAgentsByCapability(capability Capability) []Age
 	return agents
 }
 
-// ExecuteTask coordinates task execution across multiple agents
+// ExecuteTask coordinates task execution across multiple agents. Sampled
+// and, when configured, failed tasks are recorded in debug bundles.
 func (o *DefaultOrchestrator) ExecuteTask(ctx context.Context, task Task) (*OrchestrationResult, error) {
+	trace := o.startTrace(task)
+	result, err := o.executeTask(ctx, task, trace)
+	o.finishTrace(trace, task, result, err)
+	return result, err
+}
+
+// executeTask runs a task, recording the timing of its phases in trace
+func (o *DefaultOrchestrator) executeTask(ctx context.Context, task Task, trace *trace) (*OrchestrationResult, error) {
 	logger.Info("orchestrating task execution", "task_id", task.ID, "task_type", task.Type)
 
 	startTime := time.Now()
-	o.emitEvent(EventTaskStarted, task.ID, "", nil)
+	o.emitEvent(task.ID, "", TaskStartedPayload{TaskType: task.Type})
+	ctx = o.reportProgress(ctx, task.ID)
 
 	// Update metrics
-	o.mu.Lock()
-	o.metrics.TotalTasks++
-	o.mu.Unlock()
+	o.metrics.recordStart(task.Type)
 
 	result := &OrchestrationResult{
 		TaskID:    task.ID,
@@ -150,44 +152,103 @@ func (o *DefaultOrchestrator) ExecuteTask(ctx context.Context, task Task) (*Orch
 		Timestamp: startTime,
 	}
 
+	// Keep transcripts of the sandbox runs and agent conversation, however
+	// the task ends
+	transcript := &sandbox.Transcript{}
+	ctx = sandbox.WithTranscript(ctx, transcript)
+	conversation := &Conversation{}
+	ctx = WithConversation(ctx, conversation)
+	defer func() {
+		result.Transcript = transcript.Executions()
+		result.Conversation = conversation.Turns()
+	}()
+
 	// Find suitable lead agent
 	leadAgent, err := o.selectLeadAgent(task)
 	if err != nil {
-		o.updateFailureMetrics()
 		result.Status = StatusFailed
 		result.Duration = time.Since(startTime)
-		o.emitEvent(EventTaskFailed, task.ID, "", map[string]string{"error": err.Error()})
+		o.metrics.recordFailure(task.Type, result.Duration)
+		o.emitEvent(task.ID, "", TaskFailedPayload{Error: err.Error()})
 		return result, errors.Wrap(err, errors.ErrorTypeConfig, "ExecuteTask", "failed to select lead agent")
 	}
 
 	result.LeadAgent = leadAgent.GetID()
 
+	// Keep file context within the prompt budget
+	task.Context.Files = o.fitContext(task)
+
 	// Create execution context with timeout
 	execCtx, cancel := context.WithTimeout(ctx, o.config.TaskTimeout)
 	defer cancel()
 
 	// Execute task with lead agent
-	leadResult, err := leadAgent.Execute(execCtx, task)
+	leadStart := time.Now()
+	leadResult, err := o.executeLead(ctx, execCtx, leadAgent, task, result)
+	trace.time("lead", leadAgent.GetID(), leadStart)
 	if err != nil {
-		o.updateFailureMetrics()
 		result.Status = StatusFailed
 		result.Duration = time.Since(startTime)
-		o.emitEvent(EventTaskFailed, task.ID, leadAgent.GetID(), map[string]string{"error": err.Error()})
+		o.metrics.recordFailure(task.Type, result.Duration)
+		o.emitEvent(task.ID, leadAgent.GetID(), TaskFailedPayload{Error: err.Error()})
 		return result, errors.Wrap(err, errors.ErrorTypeInternal, "ExecuteTask", "lead agent execution failed")
 	}
 
+	// Questions nobody answered leave the task incomplete
+	if leadResult.Status == StatusIncomplete && len(leadResult.Questions) > 0 {
+		result.Status = StatusIncomplete
+		result.Questions = leadResult.Questions
+		result.Results = append(result.Results, *leadResult)
+		result.Duration = time.Since(startTime)
+		o.metrics.recordSuccess(task.Type, leadAgent.GetID(), result.Duration)
+
+		logger.Info("task needs clarification", "task_id", task.ID, "questions", len(leadResult.Questions))
+		return result, nil
+	}
+
+	// Calibrate the lead's confidence against past review outcomes
+	rawConfidence := leadResult.Confidence
+	leadResult.Confidence = o.calibration.calibrate(rawConfidence)
+	if leadResult.Metadata == nil {
+		leadResult.Metadata = make(map[string]string)
+	}
+	leadResult.Metadata["raw_confidence"] = strconv.FormatFloat(rawConfidence, 'f', 2, 64)
+
 	result.Results = append(result.Results, *leadResult)
 
+	// Stop short of acting on a low-confidence result unless the user agrees
+	if floor := o.config.Confidence.Floor; leadResult.Confidence < floor {
+		abstention := newAbstention(leadResult.Confidence, floor)
+		if !o.confirmLowConfidence(execCtx, leadResult, abstention) {
+			result.Status = StatusIncomplete
+			result.Abstention = abstention
+			result.Duration = time.Since(startTime)
+			o.metrics.recordSuccess(task.Type, leadAgent.GetID(), result.Duration)
+
+			logger.Info("abstained from low-confidence result", "task_id", task.ID,
+				"confidence", leadResult.Confidence, "floor", floor)
+
+			o.emitEvent(task.ID, leadAgent.GetID(), TaskAbstainedPayload{
+				Confidence: leadResult.Confidence,
+				Floor:      floor,
+			})
+			return result, nil
+		}
+	}
+
 	// If proposals were generated, coordinate review process
 	if len(leadResult.Proposals) > 0 {
 		for _, proposal := range leadResult.Proposals {
+			reviewStart := time.Now()
 			consensus, err := o.ReviewProposal(execCtx, proposal)
+			trace.time("review", proposal.ID, reviewStart)
 			if err != nil {
 				logger.Warn("proposal review failed", "proposal_id", proposal.ID, "error", err)
 				continue
 			}
 
 			result.Consensus = consensus
+			o.calibration.record(proposal.Confidence, consensus.Decision == ConsensusApprove)
 
 			// Check if consensus approves the proposal
 			if consensus.Decision == ConsensusApprove {
@@ -201,26 +262,96 @@ func (o *DefaultOrchestrator) ExecuteTask(ctx context.Context, task Task) (*Orch
 
 	// Update metrics
 	result.Duration = time.Since(startTime)
-	o.updateSuccessMetrics(result.Duration)
+	o.metrics.recordSuccess(task.Type, leadAgent.GetID(), result.Duration)
 
 	logger.Info("task orchestration completed", "task_id", task.ID, "status", result.Status,
 		"duration", result.Duration, "proposals", len(leadResult.Proposals))
 
-	o.emitEvent(EventTaskCompleted, task.ID, leadAgent.GetID(), map[string]string{
-		"status":    string(result.Status),
-		"duration":  result.Duration.String(),
-		"proposals": fmt.Sprintf("%d", len(leadResult.Proposals)),
+	o.emitEvent(task.ID, leadAgent.GetID(), TaskCompletedPayload{
+		Status:    result.Status,
+		Duration:  result.Duration,
+		Proposals: len(leadResult.Proposals),
 	})
 
 	return result, nil
 }
 
+// confirmLowConfidence asks the user whether to proceed, if a confirm
+// function is set
+func (o *DefaultOrchestrator) confirmLowConfidence(ctx context.Context, result *Result, abstention *Abstention) bool {
+	o.mu.RLock()
+	confirm := o.confirm
+	o.mu.RUnlock()
+
+	if confirm == nil {
+		return false
+	}
+	proceed, err := confirm(ctx, result, abstention)
+	if err != nil {
+		logger.Warn("failed to confirm low-confidence result", "task_id", result.TaskID, "error", err)
+		return false
+	}
+	return proceed
+}
+
+// SetClarifyFunc sets how agents' questions are put to the user. Without
+// one, tasks that need clarification end incomplete with their questions.
+func (o *DefaultOrchestrator) SetClarifyFunc(clarify ClarifyFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.clarify = clarify
+}
+
+// fitContext shortens the task's files to the configured context limits
+func (o *DefaultOrchestrator) fitContext(task Task) []FileContext {
+	files, report := o.config.Context.Fit(task.Context.Files)
+	for _, file := range report {
+		if file.Omitted || file.Trimmed || file.Outlined {
+			logger.Info("file context over budget", "task_id", task.ID, "path", file.Path,
+				"tokens", file.Tokens, "kept_tokens", file.KeptTokens, "outlined", file.Outlined, "omitted", file.Omitted)
+		}
+	}
+	return files
+}
+
+// executeLead runs the task with the lead agent. While the agent asks for
+// clarification, the questions go to the user and the task is resumed with
+// the answers appended to its requirements.
+func (o *DefaultOrchestrator) executeLead(ctx, execCtx context.Context, lead Agent, task Task, result *OrchestrationResult) (*Result, error) {
+	o.mu.RLock()
+	clarify := o.clarify
+	o.mu.RUnlock()
+
+	for round := 0; ; round++ {
+		leadResult, err := lead.Execute(execCtx, task)
+		if err != nil || leadResult.Status != StatusIncomplete || len(leadResult.Questions) == 0 {
+			return leadResult, err
+		}
+
+		o.emitEvent(task.ID, lead.GetID(), ClarificationRequestedPayload{Questions: len(leadResult.Questions)})
+		if clarify == nil || round >= o.config.MaxClarifications {
+			return leadResult, nil
+		}
+
+		// Answering is up to the user, so it is not bound by the task timeout
+		answers, err := clarify(ctx, leadResult.Questions)
+		if err != nil {
+			logger.Warn("failed to get clarification", "task_id", task.ID, "error", err)
+			return leadResult, nil
+		}
+
+		logger.Debug("resuming task with clarifications", "task_id", task.ID, "answers", len(answers))
+		result.Clarifications = append(result.Clarifications, answers...)
+		task = withAnswers(task, answers)
+	}
+}
+
 // ReviewProposal coordinates proposal review across multiple agents
 func (o *DefaultOrchestrator) ReviewProposal(ctx context.Context, proposal Proposal) (*ConsensusResult, error) {
 	logger.Debug("orchestrating proposal review", "proposal_id", proposal.ID)
 
 	startTime := time.Now()
-	o.emitEvent(EventReviewStarted, "", "", map[string]string{"proposal_id": proposal.ID})
+	o.emitEvent("", "", ReviewStartedPayload{ProposalID: proposal.ID})
 
 	result := &ConsensusResult{
 		ProposalID:   proposal.ID,
@@ -272,9 +403,9 @@ func (o *DefaultOrchestrator) ReviewProposal(ctx context.Context, proposal Propo
 	result.Conflicts = consensus.conflicts
 
 	if len(consensus.conflicts) > 0 {
-		o.emitEvent(EventConflictDetected, "", "", map[string]string{
-			"proposal_id": proposal.ID,
-			"conflicts":   fmt.Sprintf("%d", len(consensus.conflicts)),
+		o.emitEvent("", "", ConflictDetectedPayload{
+			ProposalID: proposal.ID,
+			Conflicts:  len(consensus.conflicts),
 		})
 
 		// Attempt conflict resolution
@@ -290,46 +421,60 @@ func (o *DefaultOrchestrator) ReviewProposal(ctx context.Context, proposal Propo
 		}
 	}
 
+	o.metrics.recordReview(result)
+
 	logger.Info("proposal review completed", "proposal_id", proposal.ID, "decision", result.Decision,
 		"score", result.Score, "reviewers", len(reviewers), "conflicts", len(result.Conflicts))
 
-	o.emitEvent(EventReviewCompleted, "", "", map[string]string{
-		"proposal_id": proposal.ID,
-		"decision":    string(result.Decision),
-		"score":       fmt.Sprintf("%.2f", result.Score),
+	o.emitEvent("", "", ReviewCompletedPayload{
+		ProposalID: proposal.ID,
+		Decision:   result.Decision,
+		Score:      result.Score,
 	})
 
 	if result.Decision != ConsensusNoConsensus {
-		o.emitEvent(EventConsensusReached, "", "", map[string]string{
-			"proposal_id": proposal.ID,
-			"decision":    string(result.Decision),
+		o.emitEvent("", "", ConsensusReachedPayload{
+			ProposalID: proposal.ID,
+			Decision:   result.Decision,
 		})
 	}
 
 	return result, nil
 }
 
-// GetMetrics returns orchestration metrics
+// GetMetrics returns a point-in-time snapshot of orchestration metrics
 func (o *DefaultOrchestrator) GetMetrics() OrchestrationMetrics {
-	o.mu.RLock()
-	defer o.mu.RUnlock()
-	return o.metrics
+	snap := o.metrics.snapshot()
+	snap.EventsDropped = o.events.Stats().Dropped
+	snap.Calibration = o.calibration.bins()
+	return snap
+}
+
+// Events returns the orchestration event bus for subscribers
+func (o *DefaultOrchestrator) Events() *EventBus {
+	return o.events
 }
 
-// selectLeadAgent selects the most suitable lead agent for a task
-func (o *DefaultOrchestrator) selectLeadAgent(_ Task) (Agent, error) {
+// selectLeadAgent selects the most suitable lead agent for a task: the
+// first by priority of those with the capabilities its type is routed by,
+// or of all leads when none has them
+func (o *DefaultOrchestrator) selectLeadAgent(task Task) (Agent, error) {
 	leadAgents := o.GetAgentsByRole(RoleLead)
 	if len(leadAgents) == 0 {
 		return nil, errors.New(errors.ErrorTypeConfig, "selectLeadAgent", "no lead agents available")
 	}
+	o.sortByPriority(leadAgents)
 
-	// For now, select the first available lead agent
-	// In a more sophisticated implementation, you'd consider:
-	// - Agent specialization
-	// - Current load
-	// - Task requirements
-	// - Agent performance history
-
+	required := o.config.Routing.Capabilities(task.Type)
+	for _, lead := range leadAgents {
+		if hasCapabilities(lead, required) {
+			return lead, nil
+		}
+	}
+	if len(required) > 0 {
+		logger.Info("no lead agent has the capabilities the task is routed by, using the first",
+			"task_type", task.Type, "capabilities", required, "agent_id", leadAgents[0].GetID())
+	}
 	return leadAgents[0], nil
 }
 
@@ -546,12 +691,12 @@ func (o *DefaultOrchestrator) buildConsensus(reviews []ReviewResult) consensusDa
 // resolveConflicts attempts to resolve conflicts between reviews
 func (o *DefaultOrchestrator) resolveConflicts(conflicts []Conflict, reviews []ReviewResult) (*Resolution, error) {
 	if len(conflicts) == 0 {
-		return &Resolution{Method: o.config.ConflictResolution, Timestamp: time.Now()}, nil
+		return &Resolution{Method: o.config.ConflictResolution, Timestamp: deterministic.Now()}, nil
 	}
 
 	resolution := &Resolution{
 		Method:    o.config.ConflictResolution,
-		Timestamp: time.Now(),
+		Timestamp: deterministic.Now(),
 	}
 
 	switch o.config.ConflictResolution {
@@ -598,72 +743,64 @@ func (o *DefaultOrchestrator) resolveConflicts(conflicts []Conflict, reviews []R
 	return resolution, nil
 }
 
-// updateSuccessMetrics updates metrics for successful task completion
-func (o *DefaultOrchestrator) updateSuccessMetrics(duration time.Duration) {
-	o.mu.Lock()
-	defer o.mu.Unlock()
-
-	o.metrics.CompletedTasks++
-
-	// Update average task time
-	if o.metrics.CompletedTasks == 1 {
-		o.metrics.AverageTaskTime = duration
-	} else {
-		// Exponential moving average
-		alpha := 0.1
-		o.metrics.AverageTaskTime = time.Duration(float64(o.metrics.AverageTaskTime)*(1-alpha) + float64(duration)*alpha)
-	}
-
-	o.metrics.LastUpdated = time.Now()
-}
-
-// updateFailureMetrics updates metrics for failed task completion
-func (o *DefaultOrchestrator) updateFailureMetrics() {
-	o.mu.Lock()
-	defer o.mu.Unlock()
-
-	o.metrics.FailedTasks++
-	o.metrics.LastUpdated = time.Now()
-}
-
-// emitEvent emits an orchestration event
-func (o *DefaultOrchestrator) emitEvent(eventType EventType, taskID, agentID string, data map[string]string) {
+// emitEvent publishes an orchestration event
+func (o *DefaultOrchestrator) emitEvent(taskID, agentID string, payload EventPayload) {
 	event := OrchestrationEvent{
-		Type:      eventType,
+		Type:      payload.EventType(),
 		TaskID:    taskID,
 		AgentID:   agentID,
-		Data:      data,
-		Timestamp: time.Now(),
+		Payload:   payload,
+		Timestamp: deterministic.Now(),
 	}
+	o.events.Publish(event)
+	o.recordEvent(event)
+}
 
-	select {
-	case o.eventCh <- event:
-	default:
-		// Event channel full, drop event
-		logger.Warn("orchestration event dropped", "type", eventType)
-	}
+// reportProgress returns a context under which the progress of model and
+// tool calls made for a task is published as progress events, as well as
+// reported to any progress function ctx already had
+func (o *DefaultOrchestrator) reportProgress(ctx context.Context, taskID string) context.Context {
+	previous := model.ProgressFromContext(ctx)
+	return model.WithProgress(ctx, func(p model.Progress) {
+		o.emitEvent(taskID, "", ProgressPayload{
+			Source:   p.Source,
+			Token:    p.Token,
+			Progress: p.Progress,
+			Total:    p.Total,
+			Message:  p.Message,
+		})
+		if previous != nil {
+			previous(p)
+		}
+	})
 }
 
 // Start starts the orchestrator background processes
 func (o *DefaultOrchestrator) Start() {
-	go o.eventProcessor()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.logSub != nil {
+		return
+	}
+	o.logSub = o.events.Subscribe()
+	go o.eventProcessor(o.logSub)
 }
 
-// Stop stops the orchestrator
+// Stop stops the orchestrator background processes
 func (o *DefaultOrchestrator) Stop() {
-	close(o.stopCh)
-}
+	o.mu.Lock()
+	defer o.mu.Unlock()
 
-// eventProcessor processes orchestration events
-func (o *DefaultOrchestrator) eventProcessor() {
-	for {
-		select {
-		case event := <-o.eventCh:
-			logger.Debug("orchestration event", "type", event.Type, "task_id", event.TaskID, "agent_id", event.AgentID)
-			// Process event (could emit to external systems, update metrics, etc.)
+	if o.logSub != nil {
+		o.logSub.Unsubscribe()
+		o.logSub = nil
+	}
+}
 
-		case <-o.stopCh:
-			return
-		}
+// eventProcessor logs orchestration events until the subscription is closed
+func (o *DefaultOrchestrator) eventProcessor(sub *Subscription) {
+	for event := range sub.Events() {
+		logger.Debug("orchestration event", "type", event.Type, "task_id", event.TaskID, "agent_id", event.AgentID)
 	}
 }
@@ -6,8 +6,10 @@ import (
 	"time"
 
 	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/sandbox"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewOrchestrator(t *testing.T) {
@@ -18,8 +20,7 @@ func TestNewOrchestrator(t *testing.T) {
 	assert.Equal(t, config, orchestrator.config)
 	assert.NotNil(t, orchestrator.agents)
 	assert.Empty(t, orchestrator.agents)
-	assert.NotNil(t, orchestrator.eventCh)
-	assert.NotNil(t, orchestrator.stopCh)
+	assert.NotNil(t, orchestrator.events)
 }
 
 func TestOrchestrator_RegisterAgent(t *testing.T) {
@@ -213,6 +214,9 @@ func TestEventType_Constants(t *testing.T) {
 		{"review completed", EventReviewCompleted, "review_completed"},
 		{"consensus reached", EventConsensusReached, "consensus_reached"},
 		{"conflict detected", EventConflictDetected, "conflict_detected"},
+		{"task abstained", EventTaskAbstained, "task_abstained"},
+		{"clarification requested", EventClarificationRequested, "clarification_requested"},
+		{"progress", EventProgress, "progress"},
 	}
 
 	for _, tt := range tests {
@@ -229,17 +233,37 @@ func TestOrchestrationEvent_Structure(t *testing.T) {
 		Type:      EventTaskStarted,
 		TaskID:    "task-123",
 		AgentID:   "agent-456",
-		Data:      map[string]string{"key": "value"},
+		Payload:   TaskStartedPayload{TaskType: TaskTypeEdit},
 		Timestamp: timestamp,
 	}
 
 	assert.Equal(t, EventTaskStarted, event.Type)
 	assert.Equal(t, "task-123", event.TaskID)
 	assert.Equal(t, "agent-456", event.AgentID)
-	assert.Equal(t, "value", event.Data["key"])
+	assert.Equal(t, EventTaskStarted, event.Payload.EventType())
+	assert.Equal(t, TaskTypeEdit, event.Payload.(TaskStartedPayload).TaskType)
 	assert.Equal(t, timestamp, event.Timestamp)
 }
 
+func TestOrchestrator_ReportProgress(t *testing.T) {
+	orchestrator := NewOrchestrator(DefaultOrchestrationConfig())
+	sub := orchestrator.Events().Subscribe(EventProgress)
+	defer sub.Unsubscribe()
+
+	var forwarded []model.Progress
+	ctx := model.WithProgress(context.Background(), func(p model.Progress) { forwarded = append(forwarded, p) })
+	ctx = orchestrator.reportProgress(ctx, "task-1")
+
+	progress := model.Progress{Source: "indexer", Token: "7", Progress: 40, Total: 100, Message: "parsing"}
+	model.ProgressFromContext(ctx)(progress)
+
+	require.Len(t, sub.Events(), 1)
+	event := <-sub.Events()
+	assert.Equal(t, "task-1", event.TaskID)
+	assert.Equal(t, ProgressPayload{Source: "indexer", Token: "7", Progress: 40, Total: 100, Message: "parsing"}, event.Payload)
+	assert.Equal(t, []model.Progress{progress}, forwarded, "progress still reaches the caller's function")
+}
+
 func TestOrchestrator_StartStop(t *testing.T) {
 	config := DefaultOrchestrationConfig()
 	orchestrator := NewOrchestrator(config)
@@ -247,9 +271,12 @@ func TestOrchestrator_StartStop(t *testing.T) {
 	// Start the orchestrator
 	orchestrator.Start()
 
-	// Stop should not panic - avoid multiple stops for now
-	// since the implementation doesn't guard against it
+	assert.Equal(t, 1, orchestrator.Events().Stats().Subscribers)
+
+	// Stop is idempotent
+	orchestrator.Stop()
 	orchestrator.Stop()
+	assert.Equal(t, 0, orchestrator.Events().Stats().Subscribers)
 }
 
 // MockAgent implements Agent interface for testing orchestrator interactions
@@ -304,3 +331,20 @@ func (m *MockAgent) Review(ctx context.Context, proposal Proposal) (*ReviewResul
 	}
 	return args.Get(0).(*ReviewResult), args.Error(1)
 }
+
+func TestOrchestrator_ExecuteTask_AttachesTranscript(t *testing.T) {
+	lead := &MockAgent{id: "lead", role: RoleLead, capabilities: []Capability{CapabilityCodeGeneration}}
+	lead.On("Execute", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ctx := args.Get(0).(context.Context)
+		sandbox.TranscriptFrom(ctx).Record(sandbox.ExecutionRequest{ID: "validate", Type: "validation"},
+			&sandbox.ExecutionResponse{Status: sandbox.StatusCompleted}, nil)
+	}).Return(&Result{TaskID: "task", AgentID: "lead", Status: StatusSuccess, Confidence: 0.9}, nil)
+
+	orchestrator := NewOrchestrator(DefaultOrchestrationConfig())
+	require.NoError(t, orchestrator.RegisterAgent(lead))
+
+	result, err := orchestrator.ExecuteTask(context.Background(), Task{ID: "task", Type: TaskTypeEdit})
+	require.NoError(t, err)
+	require.Len(t, result.Transcript, 1)
+	assert.Equal(t, "validate", result.Transcript[0].RequestID)
+}
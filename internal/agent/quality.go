@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/glossary"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// QualityCriterion is one point of the rubric generated output is critiqued
+// against
+type QualityCriterion struct {
+	Name        string
+	Description string
+}
+
+// DefaultQualityRubric judges generated output on accuracy, completeness
+// and formatting
+var DefaultQualityRubric = []QualityCriterion{
+	{Name: "accuracy", Description: "Every statement is supported by the sources; nothing is invented or wrong"},
+	{Name: "completeness", Description: "Everything a reader needs from this kind of output is covered"},
+	{Name: "formatting", Description: "The output follows the requested format and its conventions"},
+}
+
+// qualityPassScore is the lowest score on a 1 to 5 scale that passes a
+// criterion
+const qualityPassScore = 4
+
+// qualityJSON matches the JSON object of a critique, fenced or bare
+var qualityJSON = regexp.MustCompile("(?s)```(?:json)?\\s*(\\{.*?\\})\\s*```|(\\{.*\\})")
+
+// Critique is a reviewer's assessment of generated output
+type Critique struct {
+	Scores map[string]int `json:"scores"`
+	Issues []string       `json:"issues"`
+}
+
+// Passed reports whether every criterion scored well enough and no issues
+// were raised
+func (c Critique) Passed() bool {
+	for _, score := range c.Scores {
+		if score < qualityPassScore {
+			return false
+		}
+	}
+	return len(c.Issues) == 0
+}
+
+// QualityResult is the outcome of a quality pass
+type QualityResult struct {
+	Output   string
+	Critique Critique
+	Revised  bool
+}
+
+// QualityPass has a reviewer model critique generated output against a
+// rubric and the lead model revise it once when the critique finds
+// problems
+type QualityPass struct {
+	Lead     model.Model
+	Reviewer model.Model
+	Rubric   []QualityCriterion
+}
+
+// NewQualityPass creates a quality pass with the default rubric. The lead
+// reviews its own output when no reviewer is given.
+func NewQualityPass(lead, reviewer model.Model) *QualityPass {
+	if reviewer == nil {
+		reviewer = lead
+	}
+	return &QualityPass{Lead: lead, Reviewer: reviewer, Rubric: DefaultQualityRubric}
+}
+
+// Run critiques output, a kind of artifact such as "documentation" or
+// "commit message" generated from the sources, and returns it revised when
+// the critique did not pass. Instructions are the rules the output had to
+// follow, so the revision keeps to them.
+func (q *QualityPass) Run(ctx context.Context, kind, instructions, output string, sources []model.FileContent) (QualityResult, error) {
+	result := QualityResult{Output: output}
+
+	response, err := q.Reviewer.RunPrompt(ctx, model.PromptInput{
+		SystemPrompt: glossary.Instruct(q.critiquePrompt(kind)),
+		UserPrompt:   fmt.Sprintf("Instructions the %s had to follow:\n%s\n\n%s to critique:\n\n%s", kind, instructions, capitalize(kind), output),
+		Files:        sources,
+		Temperature:  0.1,
+	})
+	if err != nil {
+		return result, errors.Wrap(err, errors.ErrorTypeModel, "QualityPass.Run", fmt.Sprintf("failed to critique %s", kind))
+	}
+	critique, err := parseCritique(response.Response)
+	if err != nil {
+		return result, err
+	}
+	result.Critique = critique
+	logger.Debug("quality pass critique", "kind", kind, "scores", critique.Scores, "issues", len(critique.Issues))
+	if critique.Passed() {
+		return result, nil
+	}
+
+	// Low scores without issues still say what to improve
+	issues := critique.Issues
+	if len(issues) == 0 {
+		for _, criterion := range q.Rubric {
+			if score, ok := critique.Scores[criterion.Name]; ok && score < qualityPassScore {
+				issues = append(issues, fmt.Sprintf("%s scored %d of 5: %s", criterion.Name, score, criterion.Description))
+			}
+		}
+	}
+	response, err = q.Lead.RunPrompt(ctx, model.PromptInput{
+		SystemPrompt: glossary.Instruct(fmt.Sprintf("You revise a %s a reviewer critiqued. Fix every issue raised without changing what is already correct, "+
+			"keep to the original instructions and return only the revised %s, with no commentary.", kind, kind)),
+		UserPrompt: fmt.Sprintf("Instructions:\n%s\n\nOriginal %s:\n\n%s\n\nIssues to fix:\n- %s",
+			instructions, kind, output, strings.Join(issues, "\n- ")),
+		Files:       sources,
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return result, errors.Wrap(err, errors.ErrorTypeModel, "QualityPass.Run", fmt.Sprintf("failed to revise %s", kind))
+	}
+	revised := strings.TrimSpace(response.Response)
+	if revised == "" {
+		logger.Warn("quality pass revision was empty, keeping the original", "kind", kind)
+		return result, nil
+	}
+	result.Output = revised
+	result.Revised = true
+	return result, nil
+}
+
+// critiquePrompt asks for a rubric assessment as JSON
+func (q *QualityPass) critiquePrompt(kind string) string {
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("You review a generated %s against the source material before it is published. "+
+		"Score it from 1 (poor) to 5 (excellent) on each criterion:\n", kind))
+	names := make([]string, len(q.Rubric))
+	for i, criterion := range q.Rubric {
+		prompt.WriteString(fmt.Sprintf("- %s: %s\n", criterion.Name, criterion.Description))
+		names[i] = fmt.Sprintf("%q: 1-5", criterion.Name)
+	}
+	prompt.WriteString(fmt.Sprintf("\nList every concrete problem to fix as an issue; list none when there is nothing to fix. "+
+		"Answer only with JSON: {\"scores\": {%s}, \"issues\": [\"...\"]}", strings.Join(names, ", ")))
+	return prompt.String()
+}
+
+// parseCritique reads a critique from a reviewer's response
+func parseCritique(response string) (Critique, error) {
+	for _, match := range qualityJSON.FindAllStringSubmatch(response, -1) {
+		body := match[1]
+		if body == "" {
+			body = match[2]
+		}
+		var critique Critique
+		if err := json.Unmarshal([]byte(body), &critique); err == nil && critique.Scores != nil {
+			return critique, nil
+		}
+	}
+	return Critique{}, errors.New(errors.ErrorTypeModel, "parseCritique", "the reviewer did not return a rubric assessment")
+}
+
+// capitalize upper-cases the first letter of a phrase
+func capitalize(phrase string) string {
+	if phrase == "" {
+		return phrase
+	}
+	return strings.ToUpper(phrase[:1]) + phrase[1:]
+}
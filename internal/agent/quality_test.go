@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/model"
+)
+
+func TestParseCritique(t *testing.T) {
+	critique, err := parseCritique("Here is my assessment:\n```json\n{\"scores\": {\"accuracy\": 5, \"completeness\": 3}, \"issues\": [\"Missing the Close method\"]}\n```")
+	require.NoError(t, err)
+	assert.Equal(t, 3, critique.Scores["completeness"])
+	assert.Equal(t, []string{"Missing the Close method"}, critique.Issues)
+	assert.False(t, critique.Passed())
+
+	critique, err = parseCritique(`{"scores": {"accuracy": 4, "completeness": 5, "formatting": 5}, "issues": []}`)
+	require.NoError(t, err)
+	assert.True(t, critique.Passed())
+
+	_, err = parseCritique("Looks good to me")
+	assert.Error(t, err)
+}
+
+func TestQualityPass_Run(t *testing.T) {
+	lead := &MockModel{}
+	reviewer := &MockModel{}
+	pass := NewQualityPass(lead, reviewer)
+	sources := []model.FileContent{{Path: "store.go", Content: "package store\n"}}
+
+	reviewer.On("RunPrompt", mock.Anything, mock.Anything).Return(model.PromptOutput{
+		Response: `{"scores": {"accuracy": 2, "completeness": 4, "formatting": 5}, "issues": ["Open does not take a timeout"]}`,
+	}, nil).Once()
+	lead.On("RunPrompt", mock.Anything, mock.MatchedBy(func(input model.PromptInput) bool {
+		return assert.Contains(t, input.UserPrompt, "- Open does not take a timeout") &&
+			assert.Contains(t, input.UserPrompt, "Original documentation:\n\n# Store")
+	})).Return(model.PromptOutput{Response: "# Store\n\nOpen opens the store.\n"}, nil).Once()
+
+	result, err := pass.Run(context.Background(), "documentation", "Write Markdown", "# Store\n\nOpen takes a timeout.", sources)
+	require.NoError(t, err)
+	assert.True(t, result.Revised)
+	assert.Equal(t, "# Store\n\nOpen opens the store.", result.Output)
+	assert.Equal(t, 2, result.Critique.Scores["accuracy"])
+	lead.AssertExpectations(t)
+
+	reviewer.On("RunPrompt", mock.Anything, mock.Anything).Return(model.PromptOutput{
+		Response: `{"scores": {"accuracy": 5, "completeness": 5, "formatting": 4}, "issues": []}`,
+	}, nil).Once()
+	result, err = pass.Run(context.Background(), "summary", "Be brief", "A store.", sources)
+	require.NoError(t, err)
+	assert.False(t, result.Revised, "output that passes is not revised")
+	assert.Equal(t, "A store.", result.Output)
+	lead.AssertNumberOfCalls(t, "RunPrompt", 1)
+}
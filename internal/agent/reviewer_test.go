@@ -422,4 +422,34 @@ func TestReviewerAgent_SpecializationCapabilities(t *testing.T) {
 		// Should also have base reviewer capabilities
 		assert.Contains(t, capabilities, CapabilityCodeReview)
 	})
+
+	t.Run("accessibility specialization adds accessibility capability", func(t *testing.T) {
+		reviewer := NewReviewerAgent("a11y-reviewer", mockModel, config, mockSandbox, SpecializationAccessibility)
+		capabilities := reviewer.GetCapabilities()
+
+		assert.Contains(t, capabilities, CapabilityAccessibilityReview)
+		assert.Contains(t, capabilities, CapabilityCodeReview)
+	})
+
+	t.Run("i18n specialization adds i18n capability", func(t *testing.T) {
+		reviewer := NewReviewerAgent("i18n-reviewer", mockModel, config, mockSandbox, SpecializationI18n)
+		capabilities := reviewer.GetCapabilities()
+
+		assert.Contains(t, capabilities, CapabilityI18nReview)
+		assert.Contains(t, capabilities, CapabilityCodeReview)
+	})
+}
+
+func TestReviewerAgent_AccessibilityAndI18nPrompts(t *testing.T) {
+	config := AgentConfig{Role: RoleReviewer, Model: "test-model", Enabled: true}
+
+	a11y := NewReviewerAgent("a11y-reviewer", &MockModel{}, config, &MockSandboxManager{}, SpecializationAccessibility)
+	assert.Contains(t, a11y.getSpecializationDescription(), "ARIA")
+	assert.Contains(t, a11y.getSpecializationDescription(), "Keyboard navigation")
+	assert.Contains(t, a11y.getSpecializationFocusAreas(), "Color contrast and use of color")
+	assert.Contains(t, a11y.generateSpecializedReviewPrompt(), "expertise in accessibility")
+
+	i18n := NewReviewerAgent("i18n-reviewer", &MockModel{}, config, &MockSandboxManager{}, SpecializationI18n)
+	assert.Contains(t, i18n.getSpecializationDescription(), "Hard-coded user-facing strings")
+	assert.Contains(t, i18n.getSpecializationFocusAreas(), "Locale-sensitive date, number and currency formatting")
 }
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"sort"
+)
+
+// RoutingConfig maps task types to the capabilities of the lead agent that
+// executes them, so that e.g. documentation tasks go to a
+// documentation-capable lead. Task types without a route go to any lead.
+type RoutingConfig map[TaskType][]Capability
+
+// DefaultRoutingConfig returns the default routes: documentation tasks to
+// documentation-capable leads, optimization tasks to performance-capable
+// ones
+func DefaultRoutingConfig() RoutingConfig {
+	return RoutingConfig{
+		TaskTypeDocument: {CapabilityDocumentation},
+		TaskTypeOptimize: {CapabilityPerformanceAnalysis},
+	}
+}
+
+// Capabilities returns the capabilities tasks of a type are routed by
+func (r RoutingConfig) Capabilities(taskType TaskType) []Capability {
+	return r[taskType]
+}
+
+// hasCapabilities reports whether an agent has every capability given
+func hasCapabilities(agent Agent, required []Capability) bool {
+	for _, capability := range required {
+		if !containsCapability(agent.GetCapabilities(), capability) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsCapability reports whether capabilities holds capability
+func containsCapability(capabilities []Capability, capability Capability) bool {
+	for _, c := range capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// sortByPriority orders agents by the priority of their profiles, highest
+// (lowest number) first, then by ID
+func (o *DefaultOrchestrator) sortByPriority(agents []Agent) {
+	sort.SliceStable(agents, func(i, j int) bool {
+		pi := o.config.AgentProfiles[agents[i].GetID()].Priority
+		pj := o.config.AgentProfiles[agents[j].GetID()].Priority
+		if pi != pj {
+			return pi < pj
+		}
+		return agents[i].GetID() < agents[j].GetID()
+	})
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectLeadAgent_Routing(t *testing.T) {
+	config := DefaultOrchestrationConfig()
+	config.AgentProfiles = map[string]AgentConfig{
+		"lead":       {Role: RoleLead, Priority: 1},
+		"documenter": {Role: RoleLead, Priority: 2},
+		"optimizer":  {Role: RoleLead, Priority: 3},
+	}
+	config.Routing[TaskTypeTest] = []Capability{CapabilityTesting, CapabilityCodeGeneration}
+	orchestrator := NewOrchestrator(config)
+	for _, agent := range []*MockAgent{
+		{id: "optimizer", role: RoleLead, capabilities: []Capability{CapabilityCodeGeneration, CapabilityPerformanceAnalysis}},
+		{id: "documenter", role: RoleLead, capabilities: []Capability{CapabilityDocumentation}},
+		{id: "lead", role: RoleLead, capabilities: []Capability{CapabilityCodeGeneration, CapabilityRefactoring}},
+		{id: "perf-reviewer", role: RoleReviewer, capabilities: []Capability{CapabilityPerformanceAnalysis}},
+	} {
+		require.NoError(t, orchestrator.RegisterAgent(agent))
+	}
+
+	for taskType, want := range map[TaskType]string{
+		TaskTypeEdit:     "lead",
+		TaskTypeDocument: "documenter",
+		TaskTypeOptimize: "optimizer",
+		TaskTypeTest:     "lead", // no lead can test: the first by priority
+	} {
+		lead, err := orchestrator.selectLeadAgent(Task{Type: taskType})
+		require.NoError(t, err)
+		assert.Equal(t, want, lead.GetID(), "task type %s", taskType)
+	}
+}
+
+func TestNewLeadAgent_ConfiguredCapabilities(t *testing.T) {
+	lead := NewLeadAgent("optimizer", nil, AgentConfig{Capabilities: []Capability{CapabilityPerformanceAnalysis, CapabilityRefactoring}}, nil)
+	assert.Equal(t, []Capability{CapabilityCodeGeneration, CapabilityRefactoring, CapabilityDocumentation, CapabilityPerformanceAnalysis},
+		lead.GetCapabilities())
+}
@@ -0,0 +1,532 @@
+// Package agent provides repository exploration tools for agents
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/lsp"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// Exploration tools the lead agent can call during Execute
+const (
+	ToolListDir  = "list_dir"
+	ToolReadFile = "read_file"
+	ToolGrep     = "grep"
+	ToolGitLog   = "git_log"
+
+	// Language server tools, available when LSP is enabled
+	ToolDefinition = "definition"
+	ToolReferences = "references"
+	ToolHover      = "hover"
+)
+
+// maxGrepFileSize skips files too large to be source code
+const maxGrepFileSize = 1 << 20
+
+// toolCallPattern matches a tool request line: TOOL: name {"arg": "value"}
+var toolCallPattern = regexp.MustCompile(`(?m)^\s*TOOL:\s*([a-z_]+)\s*(\{.*\})?\s*$`)
+
+// ToolConfig bounds the exploration tools available to the lead agent.
+// Quotas apply per task.
+type ToolConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	MaxCalls     int  `yaml:"max_calls"`      // Tool calls per task
+	MaxRounds    int  `yaml:"max_rounds"`     // Model round trips spent on tool use per task
+	MaxReadBytes int  `yaml:"max_read_bytes"` // Output bytes returned by a single call
+	MaxResults   int  `yaml:"max_results"`    // Entries, matches or commits returned by a single call
+
+	LSP lsp.Config `yaml:"lsp"` // Language servers for definition, references and hover
+}
+
+// DefaultToolConfig returns the default exploration tool quotas
+func DefaultToolConfig() ToolConfig {
+	return ToolConfig{
+		Enabled:      true,
+		MaxCalls:     12,
+		MaxRounds:    4,
+		MaxReadBytes: 32 * 1024,
+		MaxResults:   100,
+		LSP:          lsp.DefaultConfig(),
+	}
+}
+
+// toolCall is a tool request parsed from a model response
+type toolCall struct {
+	Name string
+	Args toolArgs
+	err  error // Set when the arguments could not be parsed
+}
+
+// label names the call and its arguments
+func (c toolCall) label() string {
+	return strings.TrimSpace(c.Name + " " + c.Args.describe())
+}
+
+// toolArgs are the arguments a tool call may carry
+type toolArgs struct {
+	Path    string `json:"path"`
+	Pattern string `json:"pattern"`
+	Line    int    `json:"line"`   // 1-based, for language server tools
+	Symbol  string `json:"symbol"` // Identifier on the line, for language server tools
+}
+
+// toolbox runs exploration tools confined to a repository root and tracks
+// usage against the quotas of a single task
+type toolbox struct {
+	root      string
+	config    ToolConfig
+	lsp       *lsp.Manager // Nil when language servers are disabled
+	rounds    int
+	log       []string
+	exhausted bool
+
+	// injections found in tool output; once the model has seen them its
+	// further tool calls are refused
+	injections []InjectionFinding
+
+	// sources are the repository paths behind each fenced tool output, by
+	// label, for the consent gate
+	sources map[string][]string
+}
+
+// newToolbox creates a toolbox for one task. Language servers start on
+// first use and stop when the toolbox is closed.
+func newToolbox(root string, config ToolConfig) *toolbox {
+	t := &toolbox{root: root, config: config}
+	if config.LSP.Enabled {
+		t.lsp = lsp.NewManager(root, config.LSP)
+	}
+	return t
+}
+
+// close stops the language servers started for the task
+func (t *toolbox) close() {
+	if t.lsp == nil {
+		return
+	}
+	if err := t.lsp.Close(); err != nil {
+		logger.Debug("failed to stop language servers", "error", err)
+	}
+}
+
+// remaining returns the number of tool calls left for the task
+func (t *toolbox) remaining() int {
+	return t.config.MaxCalls - len(t.log)
+}
+
+// runAll runs each call in order and returns the results formatted for the model
+func (t *toolbox) runAll(ctx context.Context, calls []toolCall) string {
+	tainted := len(t.injections) > 0
+	var b strings.Builder
+	for _, call := range calls {
+		label := call.label()
+		fmt.Fprintf(&b, "\n=== %s ===\n", label)
+		if ctx.Err() != nil {
+			b.WriteString("error: " + ctx.Err().Error() + "\n")
+			continue
+		}
+		if tainted {
+			b.WriteString("error: tool call refused: earlier tool output contained suspected prompt injection\n")
+			continue
+		}
+		if t.remaining() <= 0 {
+			t.exhausted = true
+			b.WriteString("error: tool call quota exhausted\n")
+			continue
+		}
+
+		output, err := t.run(ctx, call)
+		if err != nil {
+			label += " (error)"
+			output = "error: " + err.Error()
+		} else {
+			t.injections = append(t.injections, DetectInjection(label, output)...)
+			output = model.FenceUntrusted(label, output)
+			if t.sources == nil {
+				t.sources = make(map[string][]string)
+			}
+			t.sources[label] = []string{filepath.Join(t.root, filepath.FromSlash(call.Args.Path))}
+		}
+		t.log = append(t.log, label)
+
+		b.WriteString(output)
+		if !strings.HasSuffix(output, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// run executes a single tool call
+func (t *toolbox) run(ctx context.Context, call toolCall) (string, error) {
+	if call.err != nil {
+		return "", call.err
+	}
+
+	switch call.Name {
+	case ToolListDir:
+		return t.listDir(call.Args.Path)
+	case ToolReadFile:
+		return t.readFile(call.Args.Path)
+	case ToolGrep:
+		return t.grep(call.Args.Pattern, call.Args.Path)
+	case ToolGitLog:
+		return t.gitLog(call.Args.Path)
+	case ToolDefinition, ToolReferences, ToolHover:
+		if t.lsp == nil {
+			return "", fmt.Errorf("%s requires language servers, which are disabled", call.Name)
+		}
+		return t.symbol(ctx, call.Name, call.Args)
+	default:
+		return "", fmt.Errorf("unknown tool %q", call.Name)
+	}
+}
+
+// resolve maps a repository-relative path to an absolute path, rejecting
+// paths that leave the repository or enter its .git directory
+func (t *toolbox) resolve(path string) (string, error) {
+	rel := filepath.Clean(string(filepath.Separator) + filepath.FromSlash(path))
+	abs := filepath.Join(t.root, rel)
+
+	if first, _, _ := strings.Cut(strings.TrimPrefix(filepath.ToSlash(rel), "/"), "/"); first == ".git" {
+		return "", fmt.Errorf("access to %s is not allowed", path)
+	}
+
+	// Symlinks must not lead outside the repository either
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, fs.ErrNotExist)
+	}
+	root, err := filepath.EvalSymlinks(t.root)
+	if err != nil {
+		return "", err
+	}
+	if inside, err := filepath.Rel(root, resolved); err != nil || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the repository", path)
+	}
+
+	return abs, nil
+}
+
+// relative returns path relative to the repository root with forward slashes
+func (t *toolbox) relative(path string) string {
+	rel, err := filepath.Rel(t.root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// listDir lists a directory, marking subdirectories with a trailing slash
+func (t *toolbox) listDir(path string) (string, error) {
+	abs, err := t.resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s: %w", path, err)
+	}
+
+	var lines []string
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		lines = append(lines, name)
+	}
+	return t.limitLines(lines), nil
+}
+
+// readFile returns a file's content, truncated to the per-call byte quota
+func (t *toolbox) readFile(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("read_file requires a path")
+	}
+	abs, err := t.resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(abs)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, int64(t.config.MaxReadBytes)+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return t.limitBytes(string(data)), nil
+}
+
+// grep searches text files under path for lines matching a regular expression
+func (t *toolbox) grep(pattern, path string) (string, error) {
+	if pattern == "" {
+		return "", fmt.Errorf("grep requires a pattern")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+	start, err := t.resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	full := false
+	err = filepath.WalkDir(start, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		if info, err := entry.Info(); err != nil || info.Size() > maxGrepFileSize {
+			return nil
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil || bytes.IndexByte(data, 0) >= 0 {
+			return nil
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for line := 1; scanner.Scan(); line++ {
+			if re.Match(scanner.Bytes()) {
+				if len(matches) >= t.config.MaxResults {
+					full = true
+					return filepath.SkipAll
+				}
+				matches = append(matches, fmt.Sprintf("%s:%d: %s", t.relative(file), line, strings.TrimSpace(scanner.Text())))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search %s: %w", path, err)
+	}
+
+	if len(matches) == 0 {
+		return "no matches", nil
+	}
+	output := t.limitLines(matches)
+	if full && !strings.HasSuffix(output, "[truncated]") {
+		output += "\n[truncated]"
+	}
+	return output, nil
+}
+
+// gitLog returns the recent commits touching path
+func (t *toolbox) gitLog(path string) (string, error) {
+	abs, err := t.resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	repo, err := git.NewRepository(t.root)
+	if err != nil {
+		return "", err
+	}
+	log, err := repo.Log(abs, t.config.MaxResults)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(log) == "" {
+		return "no commits", nil
+	}
+	return t.limitBytes(log), nil
+}
+
+// symbol asks a language server about the symbol on a line of a file
+func (t *toolbox) symbol(ctx context.Context, name string, args toolArgs) (string, error) {
+	if args.Path == "" || args.Line <= 0 {
+		return "", fmt.Errorf("%s requires a path and a line", name)
+	}
+	abs, err := t.resolve(args.Path)
+	if err != nil {
+		return "", err
+	}
+
+	var locations []lsp.Location
+	switch name {
+	case ToolHover:
+		text, err := t.lsp.Hover(ctx, abs, args.Line, args.Symbol)
+		if err != nil {
+			return "", err
+		}
+		if text == "" {
+			return "no information", nil
+		}
+		return t.limitBytes(text), nil
+	case ToolDefinition:
+		locations, err = t.lsp.Definition(ctx, abs, args.Line, args.Symbol)
+	default:
+		locations, err = t.lsp.References(ctx, abs, args.Line, args.Symbol)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(locations) == 0 {
+		return "no results", nil
+	}
+
+	lines := make([]string, 0, len(locations))
+	for _, location := range locations {
+		lines = append(lines, t.describeLocation(location))
+	}
+	return t.limitLines(lines), nil
+}
+
+// describeLocation formats a location with its source line when the file
+// is inside the repository
+func (t *toolbox) describeLocation(location lsp.Location) string {
+	abs, err := t.resolve(location.Path)
+	if filepath.IsAbs(location.Path) || err != nil {
+		return location.String()
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return location.String()
+	}
+	lines := strings.Split(string(data), "\n")
+	if location.Line < 1 || location.Line > len(lines) {
+		return location.String()
+	}
+	return fmt.Sprintf("%s: %s", location, strings.TrimSpace(lines[location.Line-1]))
+}
+
+// limitLines joins lines within the per-call result and byte quotas
+func (t *toolbox) limitLines(lines []string) string {
+	truncated := false
+	if len(lines) > t.config.MaxResults {
+		lines = lines[:t.config.MaxResults]
+		truncated = true
+	}
+	output := t.limitBytes(strings.Join(lines, "\n"))
+	if truncated && !strings.HasSuffix(output, "[truncated]") {
+		output += "\n[truncated]"
+	}
+	return output
+}
+
+// limitBytes truncates output to the per-call byte quota
+func (t *toolbox) limitBytes(output string) string {
+	if len(output) <= t.config.MaxReadBytes {
+		return output
+	}
+	return output[:t.config.MaxReadBytes] + "\n[truncated]"
+}
+
+// record adds the task's tool usage to the result metadata
+func (t *toolbox) record(result *Result) {
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]string)
+	}
+	result.Metadata["tool_calls"] = strconv.Itoa(len(t.log))
+	result.Metadata["tool_rounds"] = strconv.Itoa(t.rounds)
+	if len(t.log) > 0 {
+		result.Metadata["tool_log"] = strings.Join(t.log, "; ")
+	}
+	if t.exhausted {
+		result.Metadata["tool_quota_exhausted"] = "true"
+	}
+	result.Injections = append(result.Injections, t.injections...)
+}
+
+// describe summarizes the arguments for logs and result headers
+func (a toolArgs) describe() string {
+	switch {
+	case a.Line > 0:
+		return strings.TrimSpace(fmt.Sprintf("%s:%d %s", a.Path, a.Line, a.Symbol))
+	case a.Pattern != "" && a.Path != "":
+		return fmt.Sprintf("%q in %s", a.Pattern, a.Path)
+	case a.Pattern != "":
+		return fmt.Sprintf("%q", a.Pattern)
+	default:
+		return a.Path
+	}
+}
+
+// parseToolCalls extracts tool requests from a model response
+func parseToolCalls(content string) []toolCall {
+	var calls []toolCall
+	for _, match := range toolCallPattern.FindAllStringSubmatch(content, -1) {
+		call := toolCall{Name: match[1]}
+		if match[2] != "" {
+			if err := json.Unmarshal([]byte(match[2]), &call.Args); err != nil {
+				// Keep the call so the model is told its arguments were invalid
+				call.err = fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// toolInstructions describes the exploration tools for the system prompt
+func toolInstructions(config ToolConfig) string {
+	symbols := ""
+	if config.LSP.Enabled {
+		symbols = `TOOL: definition {"path": "internal/agent/agent.go", "line": 42, "symbol": "NewLeadAgent"}
+TOOL: references {"path": "internal/agent/agent.go", "line": 42, "symbol": "NewLeadAgent"}
+TOOL: hover {"path": "internal/agent/agent.go", "line": 42, "symbol": "NewLeadAgent"}
+`
+	}
+
+	return fmt.Sprintf(`
+
+Repository tools:
+The files above may not be all you need. Before answering you may request more
+context, one tool per line, using exactly this format:
+
+TOOL: list_dir {"path": "internal/agent"}
+TOOL: read_file {"path": "internal/agent/agent.go"}
+TOOL: grep {"pattern": "func New[A-Z]", "path": "internal"}
+TOOL: git_log {"path": "internal/agent/agent.go"}
+%s
+Paths are relative to the repository root; grep takes a regular expression and
+an optional path. Respond with only tool lines to receive their results. You
+may make at most %d tool calls over %d rounds for this task. When you have
+enough context, give your final response in the format above without any
+tool lines.%s`, symbols, config.MaxCalls, config.MaxRounds, symbolInstructions(config))
+}
+
+// symbolInstructions explains the language server tools when enabled
+func symbolInstructions(config ToolConfig) string {
+	if !config.LSP.Enabled {
+		return ""
+	}
+	return `
+
+definition, references and hover ask a language server about the symbol on a
+1-based line of a file and are more precise than grep for finding where code
+is defined and used.`
+}
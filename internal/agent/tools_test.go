@@ -0,0 +1,249 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dshills/sigil/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// createToolRepo creates a small repository tree for tool tests
+func createToolRepo(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":          "package main\n\nfunc main() {\n\trun()\n}\n",
+		"internal/run.go":  "package internal\n\n// run starts the program\nfunc run() {}\n",
+		"internal/data.db": "binary\x00data run()",
+		".git/config":      "[core]\n",
+	}
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0750))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0600))
+	}
+	return root
+}
+
+func TestParseToolCalls(t *testing.T) {
+	content := `Let me look around first.
+TOOL: read_file {"path": "main.go"}
+TOOL: grep {"pattern": "func run", "path": "internal"}
+TOOL: list_dir
+TOOL: git_log {"path": oops}
+Not a TOOL: line`
+
+	calls := parseToolCalls(content)
+	require.Len(t, calls, 4)
+	assert.Equal(t, toolCall{Name: ToolReadFile, Args: toolArgs{Path: "main.go"}}, calls[0])
+	assert.Equal(t, toolArgs{Pattern: "func run", Path: "internal"}, calls[1].Args)
+	assert.Equal(t, ToolListDir, calls[2].Name)
+	assert.Error(t, calls[3].err)
+
+	assert.Empty(t, parseToolCalls("REASONING:\nNo tools needed"))
+}
+
+func TestToolbox_Run(t *testing.T) {
+	root := createToolRepo(t)
+	tools := newToolbox(root, DefaultToolConfig())
+
+	tests := []struct {
+		name     string
+		call     toolCall
+		contains []string
+		excludes []string
+		wantErr  string
+	}{
+		{
+			name:     "list root",
+			call:     toolCall{Name: ToolListDir},
+			contains: []string{"internal/", "main.go"},
+			excludes: []string{".git"},
+		},
+		{
+			name:     "read file",
+			call:     toolCall{Name: ToolReadFile, Args: toolArgs{Path: "internal/run.go"}},
+			contains: []string{"// run starts the program"},
+		},
+		{
+			name:     "grep skips binary files",
+			call:     toolCall{Name: ToolGrep, Args: toolArgs{Pattern: `run\(\)`}},
+			contains: []string{"main.go:4: run()", "internal/run.go:4: func run() {}"},
+			excludes: []string{"data.db"},
+		},
+		{
+			name:     "grep without matches",
+			call:     toolCall{Name: ToolGrep, Args: toolArgs{Pattern: "missing", Path: "internal"}},
+			contains: []string{"no matches"},
+		},
+		{
+			name:    "path outside repository is confined",
+			call:    toolCall{Name: ToolReadFile, Args: toolArgs{Path: "../../etc/passwd"}},
+			wantErr: "does not exist",
+		},
+		{
+			name:    "git directory is off limits",
+			call:    toolCall{Name: ToolReadFile, Args: toolArgs{Path: ".git/config"}},
+			wantErr: "not allowed",
+		},
+		{
+			name:    "invalid pattern",
+			call:    toolCall{Name: ToolGrep, Args: toolArgs{Pattern: "("}},
+			wantErr: "invalid pattern",
+		},
+		{
+			name:    "language server tools need LSP enabled",
+			call:    toolCall{Name: ToolDefinition, Args: toolArgs{Path: "main.go", Line: 4, Symbol: "run"}},
+			wantErr: "language servers, which are disabled",
+		},
+		{
+			name:    "unknown tool",
+			call:    toolCall{Name: "delete_file"},
+			wantErr: "unknown tool",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := tools.run(context.Background(), tt.call)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, output, s)
+			}
+			for _, s := range tt.excludes {
+				assert.NotContains(t, output, s)
+			}
+		})
+	}
+}
+
+func TestToolInstructions_LSP(t *testing.T) {
+	config := DefaultToolConfig()
+	assert.NotContains(t, toolInstructions(config), "TOOL: definition")
+
+	config.LSP.Enabled = true
+	instructions := toolInstructions(config)
+	assert.Contains(t, instructions, `TOOL: references {"path": "internal/agent/agent.go", "line": 42, "symbol": "NewLeadAgent"}`)
+	assert.Contains(t, instructions, "more precise than grep")
+
+	assert.Equal(t, "main.go:4 run", toolArgs{Path: "main.go", Line: 4, Symbol: "run"}.describe())
+}
+
+func TestToolbox_RejectsSymlinkEscape(t *testing.T) {
+	root := createToolRepo(t)
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0600))
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skip("symlinks not supported")
+	}
+
+	_, err := newToolbox(root, DefaultToolConfig()).run(context.Background(), toolCall{Name: ToolReadFile, Args: toolArgs{Path: "link/secret.txt"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside the repository")
+}
+
+func TestToolbox_Quotas(t *testing.T) {
+	root := createToolRepo(t)
+	config := DefaultToolConfig()
+	config.MaxCalls = 2
+	config.MaxReadBytes = 10
+	config.MaxResults = 1
+	tools := newToolbox(root, config)
+
+	output := tools.runAll(context.Background(), []toolCall{
+		{Name: ToolReadFile, Args: toolArgs{Path: "main.go"}},
+		{Name: ToolListDir},
+		{Name: ToolGrep, Args: toolArgs{Pattern: "run"}},
+	})
+
+	assert.Contains(t, output, "package ma\n[truncated]")
+	assert.Contains(t, output, "=== list_dir ===\n<untrusted-content source=\"list_dir\"")
+	assert.Contains(t, output, "\ninternal/\n[truncated]\n</untrusted-content")
+	assert.Contains(t, output, "error: tool call quota exhausted")
+	assert.Equal(t, 0, tools.remaining())
+	assert.Equal(t, map[string][]string{
+		"read_file main.go": {filepath.Join(root, "main.go")},
+		"list_dir":          {root},
+	}, tools.sources, "fenced output is declared by the paths it came from")
+
+	result := &Result{}
+	tools.rounds = 1
+	tools.record(result)
+	assert.Equal(t, "2", result.Metadata["tool_calls"])
+	assert.Equal(t, "1", result.Metadata["tool_rounds"])
+	assert.Equal(t, "read_file main.go; list_dir", result.Metadata["tool_log"])
+	assert.Equal(t, "true", result.Metadata["tool_quota_exhausted"])
+}
+
+func TestLeadAgent_ExecuteWithTools(t *testing.T) {
+	root := createToolRepo(t)
+	mockModel := &MockModel{}
+	agent := NewLeadAgent("lead", mockModel, AgentConfig{}, &MockSandboxManager{})
+	agent.EnableTools(root, DefaultToolConfig())
+
+	mockModel.On("RunPrompt", mock.Anything, mock.MatchedBy(func(input model.PromptInput) bool {
+		return !strings.Contains(input.UserPrompt, "Tool results:")
+	})).Return(model.PromptOutput{Response: `TOOL: read_file {"path": "internal/run.go"}`}, nil).Once()
+
+	mockModel.On("RunPrompt", mock.Anything, mock.MatchedBy(func(input model.PromptInput) bool {
+		return strings.Contains(input.SystemPrompt, "Repository tools:") &&
+			strings.Contains(input.UserPrompt, "// run starts the program")
+	})).Return(model.PromptOutput{Response: "REASONING:\nrun is defined in internal/run.go"}, nil).Once()
+
+	result, err := agent.Execute(context.Background(), Task{ID: "task_1", Type: TaskTypeRefactor, Description: "Rename run"})
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusSuccess, result.Status)
+	assert.Contains(t, result.Reasoning, "internal/run.go")
+	assert.Equal(t, "1", result.Metadata["tool_calls"])
+	assert.Equal(t, "1", result.Metadata["tool_rounds"])
+	assert.Equal(t, "read_file internal/run.go", result.Metadata["tool_log"])
+	mockModel.AssertExpectations(t)
+}
+
+func TestLeadAgent_ExecuteStopsAfterMaxRounds(t *testing.T) {
+	root := createToolRepo(t)
+	mockModel := &MockModel{}
+	agent := NewLeadAgent("lead", mockModel, AgentConfig{}, &MockSandboxManager{})
+	config := DefaultToolConfig()
+	config.MaxRounds = 2
+	agent.EnableTools(root, config)
+
+	// A model that never stops asking gets one prompt plus one per round
+	mockModel.On("RunPrompt", mock.Anything, mock.Anything).
+		Return(model.PromptOutput{Response: "TOOL: list_dir"}, nil).Times(3)
+
+	result, err := agent.Execute(context.Background(), Task{ID: "task_2", Type: TaskTypeGenerate})
+	require.NoError(t, err)
+
+	assert.Equal(t, "2", result.Metadata["tool_rounds"])
+	assert.Equal(t, "2", result.Metadata["tool_calls"])
+	mockModel.AssertExpectations(t)
+}
+
+func TestLeadAgent_ExecuteWithoutTools(t *testing.T) {
+	mockModel := &MockModel{}
+	agent := NewLeadAgent("lead", mockModel, AgentConfig{}, &MockSandboxManager{})
+
+	mockModel.On("RunPrompt", mock.Anything, mock.MatchedBy(func(input model.PromptInput) bool {
+		return !strings.Contains(input.SystemPrompt, "Repository tools:")
+	})).Return(model.PromptOutput{Response: `TOOL: read_file {"path": "main.go"}`}, nil).Once()
+
+	result, err := agent.Execute(context.Background(), Task{ID: "task_3", Type: TaskTypeGenerate})
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Metadata)
+	mockModel.AssertExpectations(t)
+}
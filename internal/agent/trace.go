@@ -0,0 +1,211 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/version"
+)
+
+// Reasons a task was recorded in a debug bundle
+const (
+	TraceSampled = "sampled"
+	TraceFailed  = "failed"
+)
+
+// TraceConfig decides which tasks are recorded in full, with their prompts,
+// events and timings, in debug bundles. Tracing is off unless a sample rate
+// or failed tasks are set.
+type TraceConfig struct {
+	SampleRate float64 `yaml:"sample_rate"` // Share of tasks recorded, from 0 to 1
+	Failed     bool    `yaml:"failed"`      // Record every failed task
+	Dir        string  `yaml:"dir"`         // Directory bundles are written to
+	MaxBundles int     `yaml:"max_bundles"` // Bundles kept before the oldest are pruned (0 for no limit)
+}
+
+// DefaultTraceConfig returns the default trace configuration, with tracing
+// off
+func DefaultTraceConfig() TraceConfig {
+	return TraceConfig{
+		Dir:        ".sigil/traces",
+		MaxBundles: 20,
+	}
+}
+
+// Enabled reports whether any task may be recorded
+func (c TraceConfig) Enabled() bool {
+	return c.SampleRate > 0 || c.Failed
+}
+
+// Bundle is the full record of one task, for debugging
+type Bundle struct {
+	Reason       string               `json:"reason"`
+	SigilVersion string               `json:"sigil_version"`
+	Task         Task                 `json:"task"`
+	Result       *OrchestrationResult `json:"result,omitempty"`
+	Error        string               `json:"error,omitempty"`
+	Events       []OrchestrationEvent `json:"events"`
+	Timings      []PhaseTiming        `json:"timings"`
+}
+
+// PhaseTiming is how long a phase of a task took
+type PhaseTiming struct {
+	Phase    string        `json:"phase"`
+	ID       string        `json:"id,omitempty"` // Agent or proposal the phase was for
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// trace collects the events and timings of a task while it runs
+type trace struct {
+	mu      sync.Mutex
+	sampled bool
+	events  []OrchestrationEvent
+	timings []PhaseTiming
+}
+
+// startTrace begins recording a task, or returns nil when tracing is off.
+// Whether the task is sampled is decided now; failed tasks are recorded
+// regardless when configured.
+func (o *DefaultOrchestrator) startTrace(task Task) *trace {
+	if !o.config.Trace.Enabled() {
+		return nil
+	}
+	t := &trace{sampled: o.sample() < o.config.Trace.SampleRate}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.traces == nil {
+		o.traces = make(map[string]*trace)
+	}
+	o.traces[task.ID] = t
+	return t
+}
+
+// recordEvent adds an event to the trace of its task. Review events carry
+// no task ID, so they go to every task being traced.
+func (o *DefaultOrchestrator) recordEvent(event OrchestrationEvent) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for taskID, t := range o.traces {
+		if event.TaskID == "" || event.TaskID == taskID {
+			t.mu.Lock()
+			t.events = append(t.events, event)
+			t.mu.Unlock()
+		}
+	}
+}
+
+// time records how long a phase took since start
+func (t *trace) time(phase, id string, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timings = append(t.timings, PhaseTiming{Phase: phase, ID: id, Start: start, Duration: time.Since(start)})
+}
+
+// finishTrace stops recording a task and writes its bundle if it was
+// sampled or, when failed tasks are recorded, it failed
+func (o *DefaultOrchestrator) finishTrace(t *trace, task Task, result *OrchestrationResult, taskErr error) {
+	if t == nil {
+		return
+	}
+	o.mu.Lock()
+	delete(o.traces, task.ID)
+	o.mu.Unlock()
+
+	failed := taskErr != nil || (result != nil && result.Status == StatusFailed)
+	var reason string
+	switch {
+	case failed && o.config.Trace.Failed:
+		reason = TraceFailed
+	case t.sampled:
+		reason = TraceSampled
+	default:
+		return
+	}
+
+	t.mu.Lock()
+	bundle := Bundle{Reason: reason, SigilVersion: version.Get().Version, Task: task, Result: result, Events: t.events, Timings: t.timings}
+	t.mu.Unlock()
+	if taskErr != nil {
+		bundle.Error = taskErr.Error()
+	}
+
+	path, err := writeBundle(o.config.Trace, bundle)
+	if err != nil {
+		logger.Warn("failed to write debug bundle", "task_id", task.ID, "error", err)
+		return
+	}
+	logger.Info("wrote debug bundle", "task_id", task.ID, "reason", reason, "path", path)
+	if result != nil {
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]string)
+		}
+		result.Metadata["debug_bundle"] = path
+	}
+}
+
+// writeBundle writes a bundle to the configured directory and prunes the
+// oldest bundles beyond the limit
+func writeBundle(config TraceConfig, bundle Bundle) (string, error) {
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeFS, "writeBundle", "failed to create trace directory")
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeInternal, "writeBundle", "failed to encode debug bundle")
+	}
+	path := filepath.Join(config.Dir, filepath.Base(bundle.Task.ID)+".json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeFS, "writeBundle", fmt.Sprintf("failed to write debug bundle: %s", path))
+	}
+	return path, pruneBundles(config.Dir, config.MaxBundles)
+}
+
+// pruneBundles removes the oldest bundles in dir beyond max
+func pruneBundles(dir string, max int) error {
+	if max <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "pruneBundles", "failed to read trace directory")
+	}
+
+	type bundleFile struct {
+		path    string
+		modTime time.Time
+	}
+	var files []bundleFile
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			files = append(files, bundleFile{filepath.Join(dir, entry.Name()), info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	for _, file := range files[min(max, len(files)):] {
+		if err := os.Remove(file.path); err != nil {
+			return errors.Wrap(err, errors.ErrorTypeFS, "pruneBundles", "failed to remove old debug bundle")
+		}
+	}
+	return nil
+}
+
+// defaultSample draws the number tasks are sampled by
+func defaultSample() float64 {
+	return rand.Float64()
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// tracingOrchestrator returns an orchestrator with one lead returning
+// result and err, tracing into a temporary directory and drawing sample
+func tracingOrchestrator(t *testing.T, trace TraceConfig, sample float64, result *Result, err error) *DefaultOrchestrator {
+	lead := &MockAgent{id: "lead", role: RoleLead, capabilities: []Capability{CapabilityCodeGeneration}}
+	lead.On("Execute", mock.Anything, mock.Anything).Return(result, err)
+
+	config := DefaultOrchestrationConfig()
+	trace.Dir = t.TempDir()
+	config.Trace = trace
+	orchestrator := NewOrchestrator(config)
+	orchestrator.sample = func() float64 { return sample }
+	require.NoError(t, orchestrator.RegisterAgent(lead))
+	return orchestrator
+}
+
+// decodedBundle is a bundle as read back, with event payloads left out
+type decodedBundle struct {
+	Reason  string
+	Task    Task
+	Result  *OrchestrationResult
+	Error   string
+	Events  []struct{ Type EventType }
+	Timings []PhaseTiming
+}
+
+func readBundle(t *testing.T, path string) decodedBundle {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var bundle decodedBundle
+	require.NoError(t, json.Unmarshal(data, &bundle))
+	return bundle
+}
+
+func TestExecuteTask_Trace(t *testing.T) {
+	success := &Result{TaskID: "task", AgentID: "lead", Status: StatusSuccess, Confidence: 0.9}
+
+	t.Run("sampled task", func(t *testing.T) {
+		orchestrator := tracingOrchestrator(t, TraceConfig{SampleRate: 0.25}, 0.1, success, nil)
+		result, err := orchestrator.ExecuteTask(context.Background(), Task{ID: "task", Type: TaskTypeEdit})
+		require.NoError(t, err)
+
+		path := result.Metadata["debug_bundle"]
+		require.NotEmpty(t, path)
+		bundle := readBundle(t, path)
+		assert.Equal(t, TraceSampled, bundle.Reason)
+		assert.Equal(t, "task", bundle.Task.ID)
+		assert.Equal(t, StatusSuccess, bundle.Result.Status)
+		require.NotEmpty(t, bundle.Events)
+		assert.Equal(t, EventTaskStarted, bundle.Events[0].Type)
+		assert.Equal(t, EventTaskCompleted, bundle.Events[len(bundle.Events)-1].Type)
+		require.Len(t, bundle.Timings, 1)
+		assert.Equal(t, "lead", bundle.Timings[0].Phase)
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("task not sampled", func(t *testing.T) {
+		orchestrator := tracingOrchestrator(t, TraceConfig{SampleRate: 0.25, Failed: true}, 0.5, success, nil)
+		result, err := orchestrator.ExecuteTask(context.Background(), Task{ID: "task", Type: TaskTypeEdit})
+		require.NoError(t, err)
+		assert.NotContains(t, result.Metadata, "debug_bundle")
+		entries, err := os.ReadDir(orchestrator.config.Trace.Dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("failed task", func(t *testing.T) {
+		orchestrator := tracingOrchestrator(t, TraceConfig{Failed: true}, 0.5, nil, fmt.Errorf("model unavailable"))
+		result, err := orchestrator.ExecuteTask(context.Background(), Task{ID: "task", Type: TaskTypeEdit})
+		require.Error(t, err)
+
+		bundle := readBundle(t, result.Metadata["debug_bundle"])
+		assert.Equal(t, TraceFailed, bundle.Reason)
+		assert.Contains(t, bundle.Error, "model unavailable")
+		assert.Equal(t, EventTaskFailed, bundle.Events[len(bundle.Events)-1].Type)
+	})
+
+	t.Run("tracing off", func(t *testing.T) {
+		orchestrator := tracingOrchestrator(t, TraceConfig{}, 0, nil, fmt.Errorf("model unavailable"))
+		result, err := orchestrator.ExecuteTask(context.Background(), Task{ID: "task", Type: TaskTypeEdit})
+		require.Error(t, err)
+		assert.NotContains(t, result.Metadata, "debug_bundle")
+		assert.Empty(t, orchestrator.traces)
+	})
+}
+
+func TestPruneBundles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("task_%d.json", i))
+		require.NoError(t, os.WriteFile(path, []byte("{}"), 0600))
+		modTime := now.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	require.NoError(t, pruneBundles(dir, 2))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"task_2.json", "task_3.json"}, names)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/sandbox"
 )
 
 // Agent represents an intelligent agent that can perform code transformations
@@ -50,14 +51,18 @@ const (
 	CapabilitySecurityAnalysis    Capability = "security_analysis"
 	CapabilityPerformanceAnalysis Capability = "performance_analysis"
 	CapabilityArchitectureReview  Capability = "architecture_review"
+	CapabilityAccessibilityReview Capability = "accessibility_review"
+	CapabilityI18nReview          Capability = "i18n_review"
 )
 
 // Specialization constants to avoid goconst warnings
 const (
-	SpecializationSecurity     = "security"
-	SpecializationPerformance  = "performance"
-	SpecializationArchitecture = "architecture"
-	SpecializationTesting      = "testing"
+	SpecializationSecurity      = "security"
+	SpecializationPerformance   = "performance"
+	SpecializationArchitecture  = "architecture"
+	SpecializationTesting       = "testing"
+	SpecializationAccessibility = "accessibility"
+	SpecializationI18n          = "i18n"
 )
 
 // Task represents a high-level task to be performed by an agent
@@ -151,6 +156,10 @@ const (
 	ConstraintTypeCompatibility ConstraintType = "compatibility"
 	ConstraintTypeResource      ConstraintType = "resource"
 	ConstraintTypeTesting       ConstraintType = "testing"
+	ConstraintTypeAccessibility ConstraintType = "accessibility"
+	ConstraintTypeI18n          ConstraintType = "i18n"
+	ConstraintTypeConcurrency   ConstraintType = "concurrency"
+	ConstraintTypeErrorHandling ConstraintType = "error_handling"
 )
 
 // Priority defines task priority
@@ -175,17 +184,19 @@ const (
 
 // Result represents the result of task execution
 type Result struct {
-	TaskID     string            `json:"task_id"`
-	AgentID    string            `json:"agent_id"`
-	Status     ResultStatus      `json:"status"`
-	Proposals  []Proposal        `json:"proposals"`
-	Artifacts  []Artifact        `json:"artifacts"`
-	Reasoning  string            `json:"reasoning"`
-	Confidence float64           `json:"confidence"` // 0.0 to 1.0
-	Duration   time.Duration     `json:"duration"`
-	Timestamp  time.Time         `json:"timestamp"`
-	Error      string            `json:"error,omitempty"`
-	Metadata   map[string]string `json:"metadata,omitempty"`
+	TaskID     string             `json:"task_id"`
+	AgentID    string             `json:"agent_id"`
+	Status     ResultStatus       `json:"status"`
+	Proposals  []Proposal         `json:"proposals"`
+	Artifacts  []Artifact         `json:"artifacts"`
+	Reasoning  string             `json:"reasoning"`
+	Confidence float64            `json:"confidence"` // 0.0 to 1.0
+	Duration   time.Duration      `json:"duration"`
+	Timestamp  time.Time          `json:"timestamp"`
+	Error      string             `json:"error,omitempty"`
+	Questions  []Question         `json:"questions,omitempty"`  // Set with StatusIncomplete when the agent needs clarification
+	Injections []InjectionFinding `json:"injections,omitempty"` // Suspected prompt injection in the task's content
+	Metadata   map[string]string  `json:"metadata,omitempty"`
 }
 
 // ResultStatus defines the status of a result
@@ -209,6 +220,7 @@ type Proposal struct {
 	Confidence  float64           `json:"confidence"`
 	Impact      Impact            `json:"impact"`
 	Tests       []TestCase        `json:"tests,omitempty"`
+	Violations  []Violation       `json:"violations,omitempty"` // Guardrail findings
 	CreatedAt   time.Time         `json:"created_at"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
 }
@@ -286,6 +298,7 @@ type TestCase struct {
 	Expected    string            `json:"expected,omitempty"`
 	Timeout     time.Duration     `json:"timeout,omitempty"`
 	Environment map[string]string `json:"environment,omitempty"`
+	Optional    bool              `json:"optional,omitempty"` // A failure is reported without blocking the proposal
 }
 
 // TestType defines the type of test
@@ -354,24 +367,47 @@ type ReviewComment struct {
 	Severity   Severity    `json:"severity"`
 	Path       string      `json:"path,omitempty"`
 	Line       int         `json:"line,omitempty"`
+	EndLine    int         `json:"end_line,omitempty"`
 	Message    string      `json:"message"`
 	Suggestion string      `json:"suggestion,omitempty"`
 	Context    string      `json:"context,omitempty"`
 	References []string    `json:"references,omitempty"`
+
+	// Fingerprint identifies the finding across runs, and Status is its
+	// lifecycle status (new or recurring) when run history is tracked
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Status      string `json:"status,omitempty"`
+
+	// Owners are the likely owners of the code, from CODEOWNERS or git blame
+	Owners []string `json:"owners,omitempty"`
+
+	// Evidence is the source the finding is based on, quoted verbatim, and
+	// EvidenceStatus whether it was found at the finding's location
+	Evidence       string `json:"evidence,omitempty"`
+	EvidenceStatus string `json:"evidence_status,omitempty"`
+
+	// Models are the models that reported the finding in a cross-checked
+	// review, and Agreement is whether all of them did or only one
+	Models    []string `json:"models,omitempty"`
+	Agreement string   `json:"agreement,omitempty"`
 }
 
 // CommentType defines the type of review comment
 type CommentType string
 
 const (
-	CommentTypeGeneral     CommentType = "general"
-	CommentTypeSyntax      CommentType = "syntax"
-	CommentTypeLogic       CommentType = "logic"
-	CommentTypeStyle       CommentType = "style"
-	CommentTypePerformance CommentType = "performance"
-	CommentTypeSecurity    CommentType = "security"
-	CommentTypeDesign      CommentType = "design"
-	CommentTypeTesting     CommentType = "testing"
+	CommentTypeGeneral       CommentType = "general"
+	CommentTypeSyntax        CommentType = "syntax"
+	CommentTypeLogic         CommentType = "logic"
+	CommentTypeStyle         CommentType = "style"
+	CommentTypePerformance   CommentType = "performance"
+	CommentTypeSecurity      CommentType = "security"
+	CommentTypeDesign        CommentType = "design"
+	CommentTypeTesting       CommentType = "testing"
+	CommentTypeAccessibility CommentType = "accessibility"
+	CommentTypeI18n          CommentType = "i18n"
+	CommentTypeConcurrency   CommentType = "concurrency"
+	CommentTypeErrorHandling CommentType = "error_handling"
 )
 
 // Suggestion represents an improvement suggestion
@@ -438,15 +474,20 @@ type Orchestrator interface {
 
 // OrchestrationResult represents the result of orchestrated task execution
 type OrchestrationResult struct {
-	TaskID      string            `json:"task_id"`
-	Status      ResultStatus      `json:"status"`
-	LeadAgent   string            `json:"lead_agent"`
-	Results     []Result          `json:"results"`
-	Consensus   *ConsensusResult  `json:"consensus,omitempty"`
-	FinalResult *Result           `json:"final_result,omitempty"`
-	Duration    time.Duration     `json:"duration"`
-	Timestamp   time.Time         `json:"timestamp"`
-	Metadata    map[string]string `json:"metadata,omitempty"`
+	TaskID         string              `json:"task_id"`
+	Status         ResultStatus        `json:"status"`
+	LeadAgent      string              `json:"lead_agent"`
+	Results        []Result            `json:"results"`
+	Consensus      *ConsensusResult    `json:"consensus,omitempty"`
+	FinalResult    *Result             `json:"final_result,omitempty"`
+	Abstention     *Abstention         `json:"abstention,omitempty"` // Set when confidence was too low to proceed
+	Questions      []Question          `json:"questions,omitempty"`  // Unanswered clarification questions
+	Clarifications []Answer            `json:"clarifications,omitempty"`
+	Transcript     []sandbox.Execution `json:"transcript,omitempty"`   // Sandbox executions made for the task
+	Conversation   []Turn              `json:"conversation,omitempty"` // Model requests agents made for the task
+	Duration       time.Duration       `json:"duration"`
+	Timestamp      time.Time           `json:"timestamp"`
+	Metadata       map[string]string   `json:"metadata,omitempty"`
 }
 
 // ConsensusResult represents the result of consensus building
@@ -509,17 +550,39 @@ const (
 	ResolutionArbitration ResolutionMethod = "arbitration"
 )
 
-// OrchestrationMetrics provides metrics about orchestration performance
+// OrchestrationMetrics provides metrics about orchestration performance.
+// Values returned by GetMetrics are snapshots and safe to retain.
 type OrchestrationMetrics struct {
-	TotalTasks       int64              `json:"total_tasks"`
-	CompletedTasks   int64              `json:"completed_tasks"`
-	FailedTasks      int64              `json:"failed_tasks"`
-	AverageTaskTime  time.Duration      `json:"average_task_time"`
-	AgentUtilization map[string]float64 `json:"agent_utilization"`
-	ConsensusRate    float64            `json:"consensus_rate"`
-	ConflictRate     float64            `json:"conflict_rate"`
-	QualityScore     float64            `json:"quality_score"`
-	LastUpdated      time.Time          `json:"last_updated"`
+	TotalTasks       int64                        `json:"total_tasks"`
+	CompletedTasks   int64                        `json:"completed_tasks"`
+	FailedTasks      int64                        `json:"failed_tasks"`
+	AverageTaskTime  time.Duration                `json:"average_task_time"`
+	Latency          LatencyPercentiles           `json:"latency"`
+	ByTaskType       map[TaskType]TaskTypeMetrics `json:"by_task_type"`
+	AgentUtilization map[string]float64           `json:"agent_utilization"`
+	ConsensusRate    float64                      `json:"consensus_rate"`
+	ConflictRate     float64                      `json:"conflict_rate"`
+	QualityScore     float64                      `json:"quality_score"`
+	EventsDropped    int64                        `json:"events_dropped"`
+	Calibration      []CalibrationBin             `json:"calibration,omitempty"`
+	LastUpdated      time.Time                    `json:"last_updated"`
+}
+
+// LatencyPercentiles summarizes task latency over a recent window
+type LatencyPercentiles struct {
+	Samples int           `json:"samples"`
+	P50     time.Duration `json:"p50"`
+	P90     time.Duration `json:"p90"`
+	P99     time.Duration `json:"p99"`
+	Max     time.Duration `json:"max"`
+}
+
+// TaskTypeMetrics provides metrics for a single task type
+type TaskTypeMetrics struct {
+	TotalTasks     int64              `json:"total_tasks"`
+	CompletedTasks int64              `json:"completed_tasks"`
+	FailedTasks    int64              `json:"failed_tasks"`
+	Latency        LatencyPercentiles `json:"latency"`
 }
 
 // Configuration for agent orchestration
@@ -533,6 +596,13 @@ type OrchestrationConfig struct {
 	EnableParallelReview bool                   `yaml:"enable_parallel_review"`
 	QualityGate          QualityGateConfig      `yaml:"quality_gate"`
 	AgentProfiles        map[string]AgentConfig `yaml:"agent_profiles"`
+	Events               EventBusConfig         `yaml:"events"`
+	Tools                ToolConfig             `yaml:"tools"`
+	Confidence           ConfidenceConfig       `yaml:"confidence"`
+	MaxClarifications    int                    `yaml:"max_clarifications"`
+	Context              ContextConfig          `yaml:"context"`
+	Routing              RoutingConfig          `yaml:"routing"`
+	Trace                TraceConfig            `yaml:"trace"`
 }
 
 // QualityGateConfig defines quality gate settings
@@ -589,5 +659,25 @@ func DefaultOrchestrationConfig() OrchestrationConfig {
 				Enabled:        true,
 			},
 		},
+		Events:            DefaultEventBusConfig(),
+		Tools:             DefaultToolConfig(),
+		Confidence:        DefaultConfidenceConfig(),
+		MaxClarifications: DefaultMaxClarifications,
+		Context:           DefaultContextConfig(),
+		Routing:           DefaultRoutingConfig(),
+		Trace:             DefaultTraceConfig(),
+	}
+}
+
+// WithModel returns a copy of the configuration in which every agent
+// profile runs on the given model, so a task can be run through a single
+// provider
+func WithModel(config OrchestrationConfig, model string) OrchestrationConfig {
+	profiles := make(map[string]AgentConfig, len(config.AgentProfiles))
+	for id, profile := range config.AgentProfiles {
+		profile.Model = model
+		profiles[id] = profile
 	}
+	config.AgentProfiles = profiles
+	return config
 }
@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+// validatedTasks are the tasks whose proposals must pass the project's
+// validation steps
+var validatedTasks = map[TaskType]bool{
+	TaskTypeEdit:     true,
+	TaskTypeRefactor: true,
+}
+
+// stepTestTypes maps the step names of the built-in profiles to test types
+var stepTestTypes = map[string]TestType{
+	"build": TestTypeBuild,
+	"vet":   TestTypeLint,
+	"lint":  TestTypeLint,
+	"test":  TestTypeUnit,
+}
+
+// validationTests returns the project's validation steps as test cases
+func validationTests(manager sandbox.Manager) []TestCase {
+	if manager == nil {
+		return nil
+	}
+	steps := manager.ValidationSteps()
+	tests := make([]TestCase, 0, len(steps))
+	for _, step := range steps {
+		testType, ok := stepTestTypes[step.Name]
+		if !ok {
+			testType = TestTypeCustom
+		}
+		tests = append(tests, TestCase{
+			Name:        step.Name,
+			Description: step.Description,
+			Type:        testType,
+			Command:     step.Command,
+			Args:        step.Args,
+			Optional:    !step.Required,
+		})
+	}
+	return tests
+}
+
+// attachValidation gives proposals that change code the project's
+// validation tests, so reviewers run them against the changes
+func (a *BaseAgent) attachValidation(task Task, proposals []Proposal) {
+	if a.sandbox == nil || !validatedTasks[task.Type] {
+		return
+	}
+	var tests []TestCase
+	for i := range proposals {
+		if len(proposals[i].Changes) == 0 || len(proposals[i].Tests) > 0 {
+			continue
+		}
+		if tests == nil {
+			tests = validationTests(a.sandbox)
+		}
+		proposals[i].Tests = tests
+	}
+}
+
+// proposalFiles returns the changes of a proposal as sandbox file changes.
+// Partial changes cannot be applied without the file and are left out.
+func proposalFiles(proposal Proposal) []sandbox.FileChange {
+	var files []sandbox.FileChange
+	for _, change := range proposal.Changes {
+		switch {
+		case change.Type == ChangeTypeDelete:
+			files = append(files, sandbox.FileChange{Path: change.Path, Operation: sandbox.OperationDelete})
+		case change.Type == ChangeTypeCreate && isWholeFile(change):
+			files = append(files, sandbox.FileChange{Path: change.Path, Content: change.NewContent, Operation: sandbox.OperationCreate})
+		case isWholeFile(change):
+			files = append(files, sandbox.FileChange{Path: change.Path, Content: change.NewContent, Operation: sandbox.OperationUpdate})
+		}
+	}
+	return files
+}
+
+// runValidationTests runs a proposal's tests in one sandbox with its
+// changes applied
+func (a *BaseAgent) runValidationTests(ctx context.Context, proposal Proposal) []TestResult {
+	if len(proposal.Tests) == 0 {
+		return nil
+	}
+
+	steps := make([]sandbox.ValidationStep, len(proposal.Tests))
+	for i, test := range proposal.Tests {
+		steps[i] = sandbox.ValidationStep{
+			Name:        test.Name,
+			Command:     test.Command,
+			Args:        test.Args,
+			Required:    !test.Optional,
+			Description: test.Description,
+		}
+	}
+
+	startTime := time.Now()
+	stepResults, err := sandbox.RunValidation(ctx, a.sandbox, fmt.Sprintf("test_%s_%d", a.id, startTime.Unix()),
+		proposalFiles(proposal), steps)
+	duration := time.Since(startTime)
+
+	results := make([]TestResult, len(proposal.Tests))
+	for i, test := range proposal.Tests {
+		results[i] = TestResult{TestCase: test, Duration: duration}
+		switch {
+		case err != nil:
+			results[i].Status = TestStatusError
+			results[i].Error = err.Error()
+		case !stepResults[i].Ran:
+			results[i].Status = TestStatusSkipped
+		case stepResults[i].Passed:
+			results[i].Status = TestStatusPassed
+			results[i].Output = stepResults[i].Output
+		default:
+			results[i].Status = TestStatusFailed
+			results[i].Output = stepResults[i].Output
+			results[i].Error = fmt.Sprintf("%s failed", test.Name)
+		}
+	}
+	return results
+}
+
+// applyTestResults requests changes when a required test of the proposal
+// failed, whatever the review itself concluded
+func applyTestResults(review *ReviewResult) {
+	var failed []string
+	for _, result := range review.Tests {
+		if result.TestCase.Optional || result.Status == TestStatusPassed {
+			continue
+		}
+		failed = append(failed, result.TestCase.Name)
+		review.Comments = append(review.Comments, ReviewComment{
+			Type:     CommentTypeTesting,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("Validation step %s did not pass: %s", result.TestCase.Name, lastLine(result.Output+"\n"+result.Error)),
+		})
+	}
+	if len(failed) == 0 {
+		return
+	}
+
+	logger.Info("proposal failed validation", "proposal_id", review.ProposalID, "steps", strings.Join(failed, ", "))
+	if review.Decision == DecisionApprove {
+		review.Decision = DecisionRequestChanges
+	}
+	review.Score = min(review.Score, 0.3)
+}
+
+// lastLine returns the last non-empty line of text
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
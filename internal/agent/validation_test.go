@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+func TestAttachValidation(t *testing.T) {
+	mockSandbox := &MockSandboxManager{}
+	mockSandbox.On("ValidationSteps").Return(sandbox.ValidationProfiles()["node"]).Once()
+	agent := NewBaseAgent("lead", RoleLead, &MockModel{}, nil, AgentConfig{}, mockSandbox)
+
+	proposals := []Proposal{
+		{ID: "p1", Changes: []Change{{Type: ChangeTypeUpdate, Path: "a.js", NewContent: "x"}}},
+		{ID: "p2"},
+		{ID: "p3", Changes: []Change{{Type: ChangeTypeUpdate, Path: "b.js"}}, Tests: []TestCase{{Name: "own"}}},
+	}
+	agent.attachValidation(Task{Type: TaskTypeEdit}, proposals)
+
+	require.Len(t, proposals[0].Tests, 2)
+	assert.Equal(t, TestTypeLint, proposals[0].Tests[0].Type)
+	assert.True(t, proposals[0].Tests[0].Optional)
+	assert.Equal(t, TestTypeUnit, proposals[0].Tests[1].Type)
+	assert.False(t, proposals[0].Tests[1].Optional)
+	assert.Empty(t, proposals[1].Tests)
+	assert.Equal(t, "own", proposals[2].Tests[0].Name)
+	mockSandbox.AssertExpectations(t)
+
+	// Generated code is not validated
+	agent.attachValidation(Task{Type: TaskTypeGenerate}, []Proposal{{Changes: proposals[0].Changes}})
+	mockSandbox.AssertNumberOfCalls(t, "ValidationSteps", 1)
+}
+
+func TestRunValidationTests(t *testing.T) {
+	mockSandbox := &MockSandboxManager{}
+	agent := NewBaseAgent("reviewer", RoleReviewer, &MockModel{}, nil, AgentConfig{}, mockSandbox)
+
+	proposal := Proposal{
+		Changes: []Change{
+			{Type: ChangeTypeUpdate, Path: "main.go", NewContent: "package main"},
+			{Type: ChangeTypeUpdate, Path: "part.go", NewContent: "x", StartLine: 3, EndLine: 4},
+		},
+		Tests: []TestCase{
+			{Name: "build", Command: "go", Args: []string{"build", "./..."}},
+			{Name: "test", Command: "go", Args: []string{"test", "./..."}},
+		},
+	}
+	mockSandbox.On("ExecuteCode", mock.Anything, mock.MatchedBy(func(request sandbox.ExecutionRequest) bool {
+		return len(request.Files) == 1 && request.Files[0].Path == "main.go" && len(request.ValidationSteps) == 2
+	})).Return(&sandbox.ExecutionResponse{
+		Results: []sandbox.ExecutionResult{{Command: "go build ./...", Error: "main.go:1: undefined: x", ExitCode: 1}},
+	}, assert.AnError)
+
+	results := agent.runValidationTests(context.Background(), proposal)
+	require.Len(t, results, 2)
+	assert.Equal(t, TestStatusFailed, results[0].Status)
+	assert.Contains(t, results[0].Output, "undefined: x")
+	assert.Equal(t, TestStatusSkipped, results[1].Status)
+	mockSandbox.AssertExpectations(t)
+}
+
+func TestApplyTestResults(t *testing.T) {
+	review := &ReviewResult{
+		Decision: DecisionApprove,
+		Score:    0.9,
+		Tests: []TestResult{
+			{TestCase: TestCase{Name: "lint", Optional: true}, Status: TestStatusFailed},
+			{TestCase: TestCase{Name: "build"}, Status: TestStatusPassed},
+		},
+	}
+	applyTestResults(review)
+	assert.Equal(t, DecisionApprove, review.Decision)
+	assert.Empty(t, review.Comments)
+
+	review.Tests = append(review.Tests, TestResult{
+		TestCase: TestCase{Name: "test"},
+		Status:   TestStatusFailed,
+		Output:   "=== RUN TestX\n--- FAIL: TestX\n",
+	})
+	applyTestResults(review)
+	assert.Equal(t, DecisionRequestChanges, review.Decision)
+	assert.Equal(t, 0.3, review.Score)
+	require.Len(t, review.Comments, 1)
+	assert.Equal(t, CommentTypeTesting, review.Comments[0].Type)
+	assert.Contains(t, review.Comments[0].Message, "--- FAIL: TestX")
+}
@@ -0,0 +1,255 @@
+// Package analyzer runs external static analyzers such as golangci-lint,
+// eslint, ruff and semgrep in a sandbox and normalizes their output into
+// review findings.
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+// Analyzer is an external static analyzer resolved from configuration
+type Analyzer struct {
+	Name       string
+	Command    string
+	Args       []string
+	Format     string
+	Extensions []string
+	Type       agent.CommentType
+}
+
+// Result is the outcome of running one analyzer
+type Result struct {
+	Analyzer string                `json:"analyzer"`
+	Ran      bool                  `json:"ran"`
+	Output   string                `json:"output,omitempty"`
+	Findings []agent.ReviewComment `json:"findings,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// presets are the analyzers that can be declared by name alone
+var presets = map[string]Analyzer{
+	"golangci-lint": {
+		Command:    "golangci-lint",
+		Args:       []string{"run", "--output.json.path=stdout", "--show-stats=false", "./..."},
+		Format:     FormatGolangciLint,
+		Extensions: []string{".go"},
+		Type:       agent.CommentTypeLogic,
+	},
+	"eslint": {
+		Command:    "npx",
+		Args:       []string{"eslint", "--format", "json", "."},
+		Format:     FormatESLint,
+		Extensions: []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx"},
+		Type:       agent.CommentTypeStyle,
+	},
+	"ruff": {
+		Command:    "ruff",
+		Args:       []string{"check", "--output-format", "json", "."},
+		Format:     FormatRuff,
+		Extensions: []string{".py"},
+		Type:       agent.CommentTypeStyle,
+	},
+	"semgrep": {
+		Command: "semgrep",
+		Args:    []string{"scan", "--json", "--quiet", "--config", "auto"},
+		Format:  FormatSemgrep,
+		Type:    agent.CommentTypeSecurity,
+	},
+}
+
+// Presets returns the names of the analyzers known by name
+func Presets() []string {
+	return sortedNames(presets)
+}
+
+// Resolve builds the enabled analyzers from their configuration, filling
+// in the defaults of known tools
+func Resolve(configs []config.AnalyzerConfig) ([]Analyzer, error) {
+	analyzers := make([]Analyzer, 0, len(configs))
+	for _, cfg := range configs {
+		if cfg.Disabled {
+			continue
+		}
+
+		analyzer := presets[cfg.Name]
+		analyzer.Name = cfg.Name
+		if cfg.Command != "" {
+			analyzer.Command = cfg.Command
+		}
+		if len(cfg.Args) > 0 {
+			analyzer.Args = cfg.Args
+		}
+		if cfg.Format != "" {
+			analyzer.Format = cfg.Format
+		}
+		if len(cfg.Extensions) > 0 {
+			analyzer.Extensions = cfg.Extensions
+		}
+		if cfg.Type != "" {
+			analyzer.Type = commentType(cfg.Type)
+		}
+
+		if analyzer.Command == "" {
+			return nil, errors.ConfigError("Resolve", fmt.Sprintf("unknown analyzer: %s", cfg.Name)).
+				WithHint(fmt.Sprintf("set its command and format, or use one of: %s", strings.Join(Presets(), ", ")))
+		}
+		if analyzer.Format == "" {
+			analyzer.Format = FormatLine
+		}
+		if _, ok := lookupParser(analyzer.Format); !ok {
+			return nil, errors.ConfigError("Resolve", fmt.Sprintf("unknown format for analyzer %s: %s", cfg.Name, analyzer.Format)).
+				WithHint(fmt.Sprintf("use one of: %s", strings.Join(Formats(), ", ")))
+		}
+		if analyzer.Type == "" {
+			analyzer.Type = agent.CommentTypeGeneral
+		}
+		analyzers = append(analyzers, analyzer)
+	}
+	return analyzers, nil
+}
+
+// commentType resolves a configured type, accepting focus area names
+func commentType(name string) agent.CommentType {
+	if area, err := agent.ParseFocusArea(name); err == nil {
+		return area.CommentType
+	}
+	return agent.CommentType(name)
+}
+
+// Applies reports whether the analyzer checks any of the files
+func (a Analyzer) Applies(files []string) bool {
+	if len(a.Extensions) == 0 {
+		return true
+	}
+	for _, file := range files {
+		for _, extension := range a.Extensions {
+			if strings.EqualFold(filepath.Ext(file), extension) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Commands returns the commands the analyzers run, for the sandbox to allow
+func Commands(analyzers []Analyzer) []string {
+	commands := make([]string, 0, len(analyzers))
+	for _, analyzer := range analyzers {
+		commands = append(commands, analyzer.Command)
+	}
+	return commands
+}
+
+// Run executes the analyzers in one sandbox with the files applied on top
+// of HEAD and parses their findings. An analyzer whose output cannot be
+// parsed is reported in its result rather than failing the run.
+func Run(ctx context.Context, manager sandbox.Manager, id string, analyzers []Analyzer, files []sandbox.FileChange) ([]Result, error) {
+	steps := make([]sandbox.ValidationStep, len(analyzers))
+	for i, analyzer := range analyzers {
+		steps[i] = sandbox.ValidationStep{
+			Name:        analyzer.Name,
+			Command:     analyzer.Command,
+			Args:        analyzer.Args,
+			Description: fmt.Sprintf("Run the %s analyzer", analyzer.Name),
+		}
+	}
+
+	response, err := manager.ExecuteCode(ctx, sandbox.ExecutionRequest{
+		ID:              id,
+		Type:            "analysis",
+		Files:           files,
+		ValidationSteps: steps,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeInternal, "Run", "analyzers failed to run")
+	}
+
+	paths := NewPaths(response.WorktreeID)
+	results := make([]Result, len(analyzers))
+	for i, analyzer := range analyzers {
+		results[i] = Result{Analyzer: analyzer.Name}
+		if i >= len(response.Results) {
+			continue
+		}
+		results[i].Ran = true
+		results[i].Output = response.Results[i].Output
+
+		findings, err := Parse(analyzer, results[i].Output, paths)
+		if err != nil {
+			logger.Warn("failed to parse analyzer output", "analyzer", analyzer.Name, "error", err)
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Findings = findings
+		logger.Debug("analyzer finished", "analyzer", analyzer.Name, "findings", len(findings))
+	}
+	return results, nil
+}
+
+// Parse reads the findings from an analyzer's output, filling in the
+// analyzer's comment type and naming it in the references
+func Parse(analyzer Analyzer, output string, paths Paths) ([]agent.ReviewComment, error) {
+	parser, ok := lookupParser(analyzer.Format)
+	if !ok {
+		return nil, errors.New(errors.ErrorTypeInternal, "Parse", fmt.Sprintf("unknown format: %s", analyzer.Format))
+	}
+
+	findings, err := parser(output, paths)
+	if err != nil {
+		return nil, err
+	}
+	for i := range findings {
+		if findings[i].Type == "" {
+			findings[i].Type = analyzer.Type
+		}
+		if findings[i].Severity == "" {
+			findings[i].Severity = agent.SeverityWarning
+		}
+		findings[i].References = append([]string{analyzer.Name}, findings[i].References...)
+	}
+	return findings, nil
+}
+
+// Paths maps paths in analyzer output to repository-relative paths
+type Paths struct {
+	marker string
+}
+
+// NewPaths returns the path mapping for a sandbox worktree
+func NewPaths(worktreeID string) Paths {
+	separator := string(filepath.Separator)
+	return Paths{marker: separator + filepath.Join(".sigil", "sandbox", worktreeID) + separator}
+}
+
+// Relative makes a path from analyzer output relative to the repository.
+// Absolute paths outside the sandbox, such as the standard library, are
+// rejected.
+func (p Paths) Relative(path string) (string, bool) {
+	if index := strings.Index(path, p.marker); index >= 0 {
+		return filepath.ToSlash(path[index+len(p.marker):]), true
+	}
+	if filepath.IsAbs(path) {
+		return "", false
+	}
+	return filepath.ToSlash(strings.TrimPrefix(path, "./")), true
+}
+
+// sortedNames returns the keys of a map in order
+func sortedNames[T any](m map[string]T) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/config"
+)
+
+func TestResolve(t *testing.T) {
+	analyzers, err := Resolve([]config.AnalyzerConfig{
+		{Name: "ruff", Args: []string{"check", "--output-format", "json", "src"}},
+		{Name: "semgrep", Disabled: true},
+		{Name: "mylint", Command: "mylint", Type: "error-handling"},
+	})
+	require.NoError(t, err)
+	require.Len(t, analyzers, 2)
+
+	assert.Equal(t, "ruff", analyzers[0].Command, "presets fill in the command")
+	assert.Equal(t, FormatRuff, analyzers[0].Format)
+	assert.Equal(t, []string{"check", "--output-format", "json", "src"}, analyzers[0].Args, "configured args override the preset")
+
+	assert.Equal(t, FormatLine, analyzers[1].Format, "custom analyzers default to line output")
+	assert.Equal(t, agent.CommentTypeErrorHandling, analyzers[1].Type, "focus area names are accepted as types")
+}
+
+func TestResolve_Errors(t *testing.T) {
+	_, err := Resolve([]config.AnalyzerConfig{{Name: "mystery"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown analyzer: mystery")
+
+	_, err = Resolve([]config.AnalyzerConfig{{Name: "mylint", Command: "mylint", Format: "xml"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown format for analyzer mylint: xml")
+}
+
+func TestAnalyzer_Applies(t *testing.T) {
+	eslint := presets["eslint"]
+	assert.True(t, eslint.Applies([]string{"README.md", "web/App.TSX"}))
+	assert.False(t, eslint.Applies([]string{"main.go"}))
+	assert.True(t, presets["semgrep"].Applies([]string{"main.go"}), "analyzers without extensions apply to every file")
+}
+
+func TestPaths_Relative(t *testing.T) {
+	paths := NewPaths("abc123")
+
+	path, ok := paths.Relative("/home/me/repo/.sigil/sandbox/abc123/internal/cache.go")
+	assert.True(t, ok)
+	assert.Equal(t, "internal/cache.go", path)
+
+	path, ok = paths.Relative("./cmd/main.go")
+	assert.True(t, ok)
+	assert.Equal(t, "cmd/main.go", path)
+
+	_, ok = paths.Relative("/usr/local/go/src/runtime/map.go")
+	assert.False(t, ok, "paths outside the sandbox are dropped")
+}
+
+func TestParse(t *testing.T) {
+	mylint := Analyzer{Name: "mylint", Format: FormatLine, Type: agent.CommentTypeStyle}
+
+	findings, err := Parse(mylint, "main.go:3:1: exported function lacks a comment\nnot a finding\n", NewPaths("abc123"))
+	require.NoError(t, err)
+
+	require.Len(t, findings, 1)
+	assert.Equal(t, agent.ReviewComment{
+		Type:       agent.CommentTypeStyle,
+		Severity:   agent.SeverityWarning,
+		Path:       "main.go",
+		Line:       3,
+		Message:    "exported function lacks a comment",
+		References: []string{"mylint"},
+	}, findings[0])
+}
+
+func TestRegisterParser(t *testing.T) {
+	RegisterParser("test-format", func(output string, paths Paths) ([]agent.ReviewComment, error) {
+		return []agent.ReviewComment{{Path: "x.go", Message: output}}, nil
+	})
+
+	assert.Contains(t, Formats(), "test-format")
+	analyzers, err := Resolve([]config.AnalyzerConfig{{Name: "custom", Command: "custom", Format: "test-format"}})
+	require.NoError(t, err)
+
+	findings, err := Parse(analyzers[0], "hello", NewPaths("abc123"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", findings[0].Message)
+	assert.Equal(t, agent.CommentTypeGeneral, findings[0].Type)
+}
@@ -0,0 +1,374 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// Output formats with built-in parsers
+const (
+	FormatGolangciLint = "golangci-lint"
+	FormatESLint       = "eslint"
+	FormatRuff         = "ruff"
+	FormatSemgrep      = "semgrep"
+	FormatSARIF        = "sarif"
+	FormatLine         = "line" // file:line[:column]: message
+)
+
+// Parser reads findings from an analyzer's output. Paths in the output
+// are mapped with paths, and findings outside the repository dropped.
+type Parser func(output string, paths Paths) ([]agent.ReviewComment, error)
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = map[string]Parser{
+		FormatGolangciLint: parseGolangciLint,
+		FormatESLint:       parseESLint,
+		FormatRuff:         parseRuff,
+		FormatSemgrep:      parseSemgrep,
+		FormatSARIF:        parseSARIF,
+		FormatLine:         parseLines,
+	}
+)
+
+// diagnosticLine matches "file:12:5: message" lines
+var diagnosticLine = regexp.MustCompile(`^(\S+?):(\d+)(?::\d+)?: (.+)$`)
+
+// RegisterParser adds a parser for an output format, replacing any parser
+// already registered for it
+func RegisterParser(format string, parser Parser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	parsers[format] = parser
+}
+
+// Formats returns the output formats that have a parser
+func Formats() []string {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	return sortedNames(parsers)
+}
+
+// lookupParser returns the parser for an output format
+func lookupParser(format string) (Parser, bool) {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	parser, ok := parsers[format]
+	return parser, ok
+}
+
+// decode unmarshals the JSON document in an analyzer's output, skipping
+// log lines before it. Output without a document means no findings.
+func decode(format, output string, v interface{}) (bool, error) {
+	var firstErr error
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+			continue
+		}
+		err := json.NewDecoder(strings.NewReader(strings.Join(lines[i:], "\n"))).Decode(v)
+		if err == nil {
+			return true, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return false, errors.Wrap(firstErr, errors.ErrorTypeOutput, "decode", "failed to parse "+format+" output")
+	}
+	return false, nil
+}
+
+// parseSeverity maps a tool's severity name to a finding severity
+func parseSeverity(name string) agent.Severity {
+	switch strings.ToLower(name) {
+	case "critical", "fatal", "blocker":
+		return agent.SeverityCritical
+	case "error", "high":
+		return agent.SeverityError
+	case "info", "note", "low", "hint":
+		return agent.SeverityInfo
+	default:
+		return agent.SeverityWarning
+	}
+}
+
+// parseLines reads "file:line[:column]: message" lines
+func parseLines(output string, paths Paths) ([]agent.ReviewComment, error) {
+	var findings []agent.ReviewComment
+	for _, line := range strings.Split(output, "\n") {
+		match := diagnosticLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		path, ok := paths.Relative(match[1])
+		if !ok {
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(match[2])
+		findings = append(findings, agent.ReviewComment{Path: path, Line: lineNumber, Message: match[3]})
+	}
+	return findings, nil
+}
+
+// parseGolangciLint reads golangci-lint JSON output
+func parseGolangciLint(output string, paths Paths) ([]agent.ReviewComment, error) {
+	var report struct {
+		Issues []struct {
+			FromLinter string `json:"FromLinter"`
+			Text       string `json:"Text"`
+			Severity   string `json:"Severity"`
+			Pos        struct {
+				Filename string `json:"Filename"`
+				Line     int    `json:"Line"`
+			} `json:"Pos"`
+			LineRange *struct {
+				To int `json:"To"`
+			} `json:"LineRange"`
+		} `json:"Issues"`
+	}
+	if ok, err := decode(FormatGolangciLint, output, &report); !ok {
+		return nil, err
+	}
+
+	var findings []agent.ReviewComment
+	for _, issue := range report.Issues {
+		path, ok := paths.Relative(issue.Pos.Filename)
+		if !ok {
+			continue
+		}
+		finding := agent.ReviewComment{
+			Type:       golangciLinterType(issue.FromLinter),
+			Severity:   parseSeverity(issue.Severity),
+			Path:       path,
+			Line:       issue.Pos.Line,
+			Message:    issue.Text,
+			References: []string{issue.FromLinter},
+		}
+		if issue.LineRange != nil && issue.LineRange.To > issue.Pos.Line {
+			finding.EndLine = issue.LineRange.To
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// golangciLinterType files the findings of well-known linters under their
+// focus area, leaving the rest to the analyzer's type
+func golangciLinterType(linter string) agent.CommentType {
+	switch linter {
+	case "gosec":
+		return agent.CommentTypeSecurity
+	case "errcheck", "errorlint", "wrapcheck", "nilerr":
+		return agent.CommentTypeErrorHandling
+	case "copyloopvar", "containedctx", "contextcheck":
+		return agent.CommentTypeConcurrency
+	case "prealloc", "perfsprint":
+		return agent.CommentTypePerformance
+	default:
+		return ""
+	}
+}
+
+// parseESLint reads eslint's json formatter output
+func parseESLint(output string, paths Paths) ([]agent.ReviewComment, error) {
+	var report []struct {
+		FilePath string `json:"filePath"`
+		Messages []struct {
+			RuleID   string `json:"ruleId"`
+			Severity int    `json:"severity"`
+			Message  string `json:"message"`
+			Line     int    `json:"line"`
+			EndLine  int    `json:"endLine"`
+		} `json:"messages"`
+	}
+	if ok, err := decode(FormatESLint, output, &report); !ok {
+		return nil, err
+	}
+
+	var findings []agent.ReviewComment
+	for _, file := range report {
+		path, ok := paths.Relative(file.FilePath)
+		if !ok {
+			continue
+		}
+		for _, message := range file.Messages {
+			finding := agent.ReviewComment{
+				Severity: agent.SeverityWarning,
+				Path:     path,
+				Line:     message.Line,
+				Message:  message.Message,
+			}
+			if message.Severity == 2 {
+				finding.Severity = agent.SeverityError
+			}
+			if message.EndLine > message.Line {
+				finding.EndLine = message.EndLine
+			}
+			if message.RuleID != "" {
+				finding.References = []string{message.RuleID}
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// parseRuff reads ruff's JSON output
+func parseRuff(output string, paths Paths) ([]agent.ReviewComment, error) {
+	var report []struct {
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+		Filename string `json:"filename"`
+		Location struct {
+			Row int `json:"row"`
+		} `json:"location"`
+		EndLocation struct {
+			Row int `json:"row"`
+		} `json:"end_location"`
+		Fix *struct {
+			Message string `json:"message"`
+		} `json:"fix"`
+	}
+	if ok, err := decode(FormatRuff, output, &report); !ok {
+		return nil, err
+	}
+
+	var findings []agent.ReviewComment
+	for _, violation := range report {
+		path, ok := paths.Relative(violation.Filename)
+		if !ok {
+			continue
+		}
+		finding := agent.ReviewComment{
+			Path:       path,
+			Line:       violation.Location.Row,
+			Message:    violation.Message,
+			References: []string{violation.Code},
+		}
+		if violation.EndLocation.Row > violation.Location.Row {
+			finding.EndLine = violation.EndLocation.Row
+		}
+		if violation.Fix != nil {
+			finding.Suggestion = violation.Fix.Message
+		}
+		if strings.HasPrefix(violation.Code, "S") {
+			// flake8-bandit rules
+			finding.Type = agent.CommentTypeSecurity
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// parseSemgrep reads semgrep's JSON output
+func parseSemgrep(output string, paths Paths) ([]agent.ReviewComment, error) {
+	var report struct {
+		Results []struct {
+			CheckID string `json:"check_id"`
+			Path    string `json:"path"`
+			Start   struct {
+				Line int `json:"line"`
+			} `json:"start"`
+			End struct {
+				Line int `json:"line"`
+			} `json:"end"`
+			Extra struct {
+				Message  string `json:"message"`
+				Severity string `json:"severity"`
+				Fix      string `json:"fix"`
+			} `json:"extra"`
+		} `json:"results"`
+	}
+	if ok, err := decode(FormatSemgrep, output, &report); !ok {
+		return nil, err
+	}
+
+	var findings []agent.ReviewComment
+	for _, result := range report.Results {
+		path, ok := paths.Relative(result.Path)
+		if !ok {
+			continue
+		}
+		finding := agent.ReviewComment{
+			Severity:   parseSeverity(result.Extra.Severity),
+			Path:       path,
+			Line:       result.Start.Line,
+			Message:    strings.TrimSpace(result.Extra.Message),
+			References: []string{result.CheckID},
+		}
+		if result.End.Line > result.Start.Line {
+			finding.EndLine = result.End.Line
+		}
+		if result.Extra.Fix != "" {
+			finding.Suggestion = "Replace with: " + result.Extra.Fix
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// parseSARIF reads SARIF 2.1.0 output, which most other analyzers can emit
+func parseSARIF(output string, paths Paths) ([]agent.ReviewComment, error) {
+	var report struct {
+		Runs []struct {
+			Results []struct {
+				RuleID  string `json:"ruleId"`
+				Level   string `json:"level"`
+				Message struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+							EndLine   int `json:"endLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if ok, err := decode(FormatSARIF, output, &report); !ok {
+		return nil, err
+	}
+
+	var findings []agent.ReviewComment
+	for _, run := range report.Runs {
+		for _, result := range run.Results {
+			if len(result.Locations) == 0 {
+				continue
+			}
+			location := result.Locations[0].PhysicalLocation
+			path, ok := paths.Relative(strings.TrimPrefix(location.ArtifactLocation.URI, "file://"))
+			if !ok {
+				continue
+			}
+			finding := agent.ReviewComment{
+				Severity: parseSeverity(result.Level),
+				Path:     path,
+				Line:     location.Region.StartLine,
+				Message:  result.Message.Text,
+			}
+			if location.Region.EndLine > location.Region.StartLine {
+				finding.EndLine = location.Region.EndLine
+			}
+			if result.RuleID != "" {
+				finding.References = []string{result.RuleID}
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+)
+
+var testPaths = NewPaths("abc123")
+
+func TestParseGolangciLint(t *testing.T) {
+	output := `level=warning msg="[runner] The linter 'exportloopref' is deprecated"
+{"Issues":[
+ {"FromLinter":"errcheck","Text":"Error return value of ` + "`f.Close`" + ` is not checked","Severity":"","Pos":{"Filename":"store/file.go","Line":42,"Column":12}},
+ {"FromLinter":"gocyclo","Text":"cyclomatic complexity 31 of func ` + "`run`" + ` is high","Severity":"error","Pos":{"Filename":"main.go","Line":10},"LineRange":{"From":10,"To":80}}
+],"Report":{}}`
+
+	findings, err := parseGolangciLint(output, testPaths)
+	require.NoError(t, err)
+
+	require.Len(t, findings, 2)
+	assert.Equal(t, "store/file.go", findings[0].Path)
+	assert.Equal(t, agent.CommentTypeErrorHandling, findings[0].Type)
+	assert.Equal(t, []string{"errcheck"}, findings[0].References)
+	assert.Equal(t, agent.SeverityError, findings[1].Severity)
+	assert.Equal(t, 80, findings[1].EndLine)
+	assert.Empty(t, findings[1].Type, "other linters take the analyzer's type")
+}
+
+func TestParseESLint(t *testing.T) {
+	output := `[{"filePath":"/ci/repo/.sigil/sandbox/abc123/web/app.js","messages":[
+  {"ruleId":"no-unused-vars","severity":2,"message":"'x' is defined but never used.","line":3,"endLine":3},
+  {"ruleId":"eqeqeq","severity":1,"message":"Expected '===' and instead saw '=='.","line":9}
+]},{"filePath":"/ci/repo/.sigil/sandbox/abc123/web/clean.js","messages":[]}]`
+
+	findings, err := parseESLint(output, testPaths)
+	require.NoError(t, err)
+
+	require.Len(t, findings, 2)
+	assert.Equal(t, "web/app.js", findings[0].Path)
+	assert.Equal(t, agent.SeverityError, findings[0].Severity)
+	assert.Equal(t, agent.SeverityWarning, findings[1].Severity)
+	assert.Equal(t, []string{"eqeqeq"}, findings[1].References)
+}
+
+func TestParseRuff(t *testing.T) {
+	output := `[
+  {"code":"F401","message":"` + "`os`" + ` imported but unused","filename":"/ci/repo/.sigil/sandbox/abc123/app/main.py","location":{"row":1,"column":8},"end_location":{"row":1,"column":10},"fix":{"message":"Remove unused import: ` + "`os`" + `"}},
+  {"code":"S105","message":"Possible hardcoded password","filename":"/ci/repo/.sigil/sandbox/abc123/app/settings.py","location":{"row":4,"column":1},"end_location":{"row":4,"column":20},"fix":null}
+]`
+
+	findings, err := parseRuff(output, testPaths)
+	require.NoError(t, err)
+
+	require.Len(t, findings, 2)
+	assert.Equal(t, "app/main.py", findings[0].Path)
+	assert.Equal(t, "Remove unused import: `os`", findings[0].Suggestion)
+	assert.Equal(t, agent.CommentTypeSecurity, findings[1].Type)
+}
+
+func TestParseSemgrep(t *testing.T) {
+	output := `{"results":[{"check_id":"go.lang.security.audit.sqli","path":"db/query.go","start":{"line":12},"end":{"line":14},
+  "extra":{"message":"SQL built from user input","severity":"ERROR","fix":"db.Query(q, id)"}}],"errors":[]}`
+
+	findings, err := parseSemgrep(output, testPaths)
+	require.NoError(t, err)
+
+	require.Len(t, findings, 1)
+	assert.Equal(t, agent.ReviewComment{
+		Severity:   agent.SeverityError,
+		Path:       "db/query.go",
+		Line:       12,
+		EndLine:    14,
+		Message:    "SQL built from user input",
+		Suggestion: "Replace with: db.Query(q, id)",
+		References: []string{"go.lang.security.audit.sqli"},
+	}, findings[0])
+}
+
+func TestParseSARIF(t *testing.T) {
+	output := `{"version":"2.1.0","runs":[{"results":[
+  {"ruleId":"R1","level":"note","message":{"text":"Consider a constant"},
+   "locations":[{"physicalLocation":{"artifactLocation":{"uri":"src/a.c"},"region":{"startLine":5}}}]},
+  {"ruleId":"R2","message":{"text":"No location"}}
+]}]}`
+
+	findings, err := parseSARIF(output, testPaths)
+	require.NoError(t, err)
+
+	require.Len(t, findings, 1)
+	assert.Equal(t, "src/a.c", findings[0].Path)
+	assert.Equal(t, agent.SeverityInfo, findings[0].Severity)
+}
+
+func TestDecode(t *testing.T) {
+	var report []int
+
+	ok, err := decode("test", "no output here\n", &report)
+	assert.False(t, ok)
+	assert.NoError(t, err, "output without a document has no findings")
+
+	ok, err = decode("test", "[1, 2,\n", &report)
+	assert.False(t, ok)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse test output")
+}
@@ -0,0 +1,237 @@
+// Package cache provides shared storage for prompt responses, embeddings and
+// artifacts so results can be reused across runs, teammates and CI.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// Kind identifies the category of cached data
+type Kind string
+
+const (
+	KindPrompt    Kind = "prompts"
+	KindEmbedding Kind = "embeddings"
+	KindArtifact  Kind = "artifacts"
+)
+
+// Backend stores opaque blobs by key
+type Backend interface {
+	// Get returns the blob for key; found is false on a cache miss
+	Get(ctx context.Context, key string) (data []byte, found bool, err error)
+
+	// Put stores the blob under key
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Delete removes the blob for key; missing keys are not an error
+	Delete(ctx context.Context, key string) error
+
+	// Name returns the backend name
+	Name() string
+}
+
+// URLBackend is a backend whose entries can be shared by URL
+type URLBackend interface {
+	// SignedURL returns a URL that performs method on key without further
+	// credentials
+	SignedURL(method, key string) (string, error)
+}
+
+// Namespace scopes cache keys to a repository and commit
+type Namespace struct {
+	Repo   string `json:"repo"`
+	Commit string `json:"commit"`
+}
+
+var unsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Key builds a namespaced key of the form repo/commit/kind/hash.
+// Pass an empty commit in the namespace for entries that are valid across commits.
+func (n Namespace) Key(kind Kind, parts ...string) string {
+	repo := sanitizeSegment(n.Repo, "default")
+	commit := sanitizeSegment(n.Commit, "any")
+	return path.Join(repo, commit, string(kind), HashKey(parts...))
+}
+
+// HashKey returns a stable hex digest for the given key parts
+func HashKey(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NamespaceFromRepo derives a namespace from the repository's origin remote and HEAD commit
+func NamespaceFromRepo(repo *git.Repository) (Namespace, error) {
+	commit, err := repo.GetHeadCommit()
+	if err != nil {
+		return Namespace{}, errors.Wrap(err, errors.ErrorTypeGit, "NamespaceFromRepo", "failed to resolve HEAD")
+	}
+
+	name := ""
+	if remote, err := repo.GetRemoteURL("origin"); err == nil {
+		name = repoNameFromRemote(remote)
+	}
+	if name == "" {
+		root, err := repo.GetRoot()
+		if err != nil {
+			return Namespace{}, errors.Wrap(err, errors.ErrorTypeGit, "NamespaceFromRepo", "failed to resolve repository root")
+		}
+		name = filepath.Base(root)
+	}
+
+	return Namespace{Repo: name, Commit: commit}, nil
+}
+
+// repoNameFromRemote turns a remote URL into an owner/name style identifier
+func repoNameFromRemote(remote string) string {
+	remote = strings.TrimSuffix(strings.TrimSpace(remote), ".git")
+	if i := strings.Index(remote, "://"); i >= 0 {
+		remote = remote[i+3:]
+		if j := strings.Index(remote, "/"); j >= 0 {
+			remote = remote[j+1:]
+		}
+	} else if i := strings.Index(remote, ":"); i >= 0 {
+		// scp-like syntax: git@host:owner/name
+		remote = remote[i+1:]
+	}
+	return strings.ReplaceAll(strings.Trim(remote, "/"), "/", "-")
+}
+
+// sanitizeSegment makes a value safe for use as a single path segment
+func sanitizeSegment(value, fallback string) string {
+	value = strings.Trim(unsafeKeyChars.ReplaceAllString(value, "-"), "-.")
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// Store combines a backend with a namespace
+type Store struct {
+	backend   Backend
+	namespace Namespace
+}
+
+// NewStore creates a namespaced store over a backend
+func NewStore(backend Backend, namespace Namespace) *Store {
+	return &Store{backend: backend, namespace: namespace}
+}
+
+// Get looks up a namespaced entry
+func (s *Store) Get(ctx context.Context, kind Kind, parts ...string) ([]byte, bool, error) {
+	return s.backend.Get(ctx, s.namespace.Key(kind, parts...))
+}
+
+// Put stores a namespaced entry
+func (s *Store) Put(ctx context.Context, kind Kind, data []byte, parts ...string) error {
+	return s.backend.Put(ctx, s.namespace.Key(kind, parts...), data)
+}
+
+// SignedURL returns a URL that can be shared to perform method on a
+// namespaced entry, when the backend supports it
+func (s *Store) SignedURL(method string, kind Kind, parts ...string) (string, error) {
+	backend, ok := s.backend.(URLBackend)
+	if !ok {
+		return "", errors.New(errors.ErrorTypeConfig, "SignedURL",
+			fmt.Sprintf("the %s cache backend does not share URLs", s.backend.Name()))
+	}
+	return backend.SignedURL(method, s.namespace.Key(kind, parts...))
+}
+
+// Backend returns the underlying backend
+func (s *Store) Backend() Backend {
+	return s.backend
+}
+
+// Namespace returns the store namespace
+func (s *Store) Namespace() Namespace {
+	return s.namespace
+}
+
+// NewBackend creates a backend from configuration. Remote backends are
+// layered behind the local cache so repeated lookups stay on disk.
+func NewBackend(cfg config.CacheConfig) (Backend, error) {
+	localPath := cfg.Path
+	if localPath == "" {
+		localPath = filepath.Join(".sigil", "cache")
+	}
+	local := NewLocalBackend(localPath)
+
+	var remote Backend
+	switch strings.ToLower(cfg.Backend) {
+	case "", "local":
+		return local, nil
+	case "http":
+		if cfg.URL == "" {
+			return nil, errors.ConfigError("NewBackend", "cache url is required for the http backend")
+		}
+		remote = NewHTTPBackend(HTTPConfig{
+			BaseURL: cfg.URL,
+			Prefix:  cfg.Prefix,
+			Token:   config.EnvValue(cfg.TokenEnv),
+		})
+	case "s3", "gcs":
+		signer, err := newObjectSigner(cfg)
+		if err != nil {
+			return nil, err
+		}
+		remote = NewHTTPBackend(HTTPConfig{
+			Prefix:    cfg.Prefix,
+			Signer:    signer,
+			URLExpiry: cfg.URLExpiry,
+		})
+	default:
+		return nil, errors.ConfigError("NewBackend", fmt.Sprintf("unsupported cache backend: %s", cfg.Backend))
+	}
+
+	logger.Debug("initialized cache backend", "backend", remote.Name(), "local", localPath, "read_only", cfg.ReadOnly)
+	return NewTieredBackend(local, remote, cfg.ReadOnly), nil
+}
+
+// newObjectSigner builds a SigV4 URL signer for an S3 or GCS bucket
+func newObjectSigner(cfg config.CacheConfig) (*SigV4Signer, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.ConfigError("newObjectSigner", "cache bucket is required for object storage backends")
+	}
+
+	accessKey := config.EnvValue(cfg.AccessKeyEnv)
+	secretKey := config.EnvValue(cfg.SecretKeyEnv)
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.ConfigError("newObjectSigner", "cache access_key_env and secret_key_env must reference non-empty variables")
+	}
+
+	signer := &SigV4Signer{
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Bucket:    cfg.Bucket,
+		Region:    cfg.Region,
+		Endpoint:  cfg.URL,
+	}
+
+	if strings.EqualFold(cfg.Backend, "gcs") {
+		// GCS accepts SigV4 signatures made with HMAC interoperability keys
+		signer.Region = "auto"
+		if signer.Endpoint == "" {
+			signer.Endpoint = "https://storage.googleapis.com"
+		}
+	} else if signer.Region == "" {
+		signer.Region = "us-east-1"
+	}
+
+	return signer, nil
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// CachedEmbedder wraps an embedder and serves the embeddings of texts it has
+// seen from a store, so a team and CI embed each chunk once
+type CachedEmbedder struct {
+	model.Embedder
+	store *Store
+}
+
+// NewCachedEmbedder wraps e so repeated texts are embedded from store.
+// Entries are keyed by the embedding model and text, so the store
+// namespace normally omits the commit to allow reuse across commits.
+func NewCachedEmbedder(e model.Embedder, store *Store) *CachedEmbedder {
+	return &CachedEmbedder{Embedder: e, store: store}
+}
+
+// Embed returns the cached embeddings of texts and embeds the rest in one
+// request, storing them
+func (c *CachedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	var missing []string
+	var positions []int
+	for i, text := range texts {
+		if vector, found := c.lookup(ctx, text); found {
+			vectors[i] = vector
+			continue
+		}
+		missing = append(missing, text)
+		positions = append(positions, i)
+	}
+	if len(missing) == 0 {
+		logger.Debug("embedding cache hit", "model", c.EmbeddingModel(), "texts", len(texts))
+		return vectors, nil
+	}
+
+	embedded, err := c.Embedder.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(missing) {
+		return nil, errors.New(errors.ErrorTypeModel, "Embed",
+			fmt.Sprintf("expected %d embeddings, got %d", len(missing), len(embedded)))
+	}
+	for i, position := range positions {
+		vectors[position] = embedded[i]
+		c.save(ctx, missing[i], embedded[i])
+	}
+	return vectors, nil
+}
+
+// lookup returns the cached embedding of text, if any
+func (c *CachedEmbedder) lookup(ctx context.Context, text string) ([]float32, bool) {
+	data, found, err := c.store.Get(ctx, KindEmbedding, c.EmbeddingModel(), text)
+	if err != nil {
+		logger.Warn("embedding cache lookup failed", "error", err)
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+	if len(data) == 0 || len(data)%4 != 0 {
+		logger.Warn("discarding corrupt embedding cache entry", "model", c.EmbeddingModel())
+		return nil, false
+	}
+
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return vector, true
+}
+
+// save caches the embedding of text as little-endian float32s
+func (c *CachedEmbedder) save(ctx context.Context, text string, vector []float32) {
+	data := make([]byte, 4*len(vector))
+	for i, value := range vector {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(value))
+	}
+	if err := c.store.Put(ctx, KindEmbedding, data, c.EmbeddingModel(), text); err != nil {
+		logger.Warn("failed to store embedding cache entry", "error", err)
+	}
+}
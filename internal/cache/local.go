@@ -0,0 +1,171 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// LocalBackend stores cache entries on the local filesystem
+type LocalBackend struct {
+	root string
+}
+
+// NewLocalBackend creates a filesystem backend rooted at dir
+func NewLocalBackend(dir string) *LocalBackend {
+	return &LocalBackend{root: dir}
+}
+
+// Name returns the backend name
+func (b *LocalBackend) Name() string {
+	return "local"
+}
+
+// Get reads a cache entry from disk
+func (b *LocalBackend) Get(_ context.Context, key string) ([]byte, bool, error) {
+	path, err := b.pathFor(key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 - path is confined to the cache root
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, errors.Wrap(err, errors.ErrorTypeFS, "Get", "failed to read cache entry")
+	}
+
+	return data, true, nil
+}
+
+// Put writes a cache entry atomically
+func (b *LocalBackend) Put(_ context.Context, key string, data []byte) error {
+	path, err := b.pathFor(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Put", "failed to create cache directory")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Put", "failed to create temporary file")
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return errors.Wrap(err, errors.ErrorTypeFS, "Put", "failed to write cache entry")
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return errors.Wrap(err, errors.ErrorTypeFS, "Put", "failed to close cache entry")
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return errors.Wrap(err, errors.ErrorTypeFS, "Put", "failed to commit cache entry")
+	}
+
+	return nil
+}
+
+// Delete removes a cache entry
+func (b *LocalBackend) Delete(_ context.Context, key string) error {
+	path, err := b.pathFor(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Delete", "failed to delete cache entry")
+	}
+	return nil
+}
+
+// pathFor maps a key to a file path, rejecting keys that escape the root
+func (b *LocalBackend) pathFor(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", errors.New(errors.ErrorTypeInput, "pathFor", "invalid cache key").WithContext("key", key)
+	}
+	return filepath.Join(b.root, clean), nil
+}
+
+// TieredBackend reads through a local backend before falling back to a
+// remote one, and writes to both unless the remote is read-only
+type TieredBackend struct {
+	local    Backend
+	remote   Backend
+	readOnly bool
+}
+
+// NewTieredBackend layers a local backend in front of a remote backend
+func NewTieredBackend(local, remote Backend, readOnly bool) *TieredBackend {
+	return &TieredBackend{local: local, remote: remote, readOnly: readOnly}
+}
+
+// Name returns the backend name
+func (b *TieredBackend) Name() string {
+	return b.local.Name() + "+" + b.remote.Name()
+}
+
+// SignedURL returns a shareable URL for key from the remote backend
+func (b *TieredBackend) SignedURL(method, key string) (string, error) {
+	remote, ok := b.remote.(URLBackend)
+	if !ok {
+		return "", errors.New(errors.ErrorTypeConfig, "SignedURL",
+			fmt.Sprintf("the %s cache backend does not share URLs", b.remote.Name()))
+	}
+	return remote.SignedURL(method, key)
+}
+
+// Get checks the local backend, then the remote, populating the local copy on a remote hit
+func (b *TieredBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if data, found, err := b.local.Get(ctx, key); err == nil && found {
+		return data, true, nil
+	} else if err != nil {
+		logger.Warn("local cache read failed", "key", key, "error", err)
+	}
+
+	data, found, err := b.remote.Get(ctx, key)
+	if err != nil || !found {
+		return nil, false, err
+	}
+
+	if err := b.local.Put(ctx, key, data); err != nil {
+		logger.Warn("failed to populate local cache", "key", key, "error", err)
+	}
+	return data, true, nil
+}
+
+// Put writes to the local backend and, unless read-only, the remote backend
+func (b *TieredBackend) Put(ctx context.Context, key string, data []byte) error {
+	if err := b.local.Put(ctx, key, data); err != nil {
+		return err
+	}
+	if b.readOnly {
+		return nil
+	}
+	return b.remote.Put(ctx, key, data)
+}
+
+// Delete removes the entry locally and, unless read-only, remotely
+func (b *TieredBackend) Delete(ctx context.Context, key string) error {
+	if err := b.local.Delete(ctx, key); err != nil {
+		return err
+	}
+	if b.readOnly {
+		return nil
+	}
+	return b.remote.Delete(ctx, key)
+}
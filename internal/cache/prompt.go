@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// CachedModel wraps a model and serves repeated prompts from a store
+type CachedModel struct {
+	model.Model
+	store *Store
+}
+
+// NewCachedModel wraps m so identical prompts are answered from store.
+// Prompt entries are keyed by their full input, so the store namespace
+// normally omits the commit to allow reuse across commits.
+func NewCachedModel(m model.Model, store *Store) *CachedModel {
+	return &CachedModel{Model: m, store: store}
+}
+
+// RunPrompt returns a cached response when available, otherwise runs the
+// prompt and stores the result
+func (c *CachedModel) RunPrompt(ctx context.Context, input model.PromptInput) (model.PromptOutput, error) {
+	if output, found := c.lookup(ctx, input); found {
+		return output, nil
+	}
+
+	output, err := c.Model.RunPrompt(ctx, input)
+	if err != nil {
+		return output, err
+	}
+	c.save(ctx, input, output)
+	return output, nil
+}
+
+// lookup returns the cached response to input, if any
+func (c *CachedModel) lookup(ctx context.Context, input model.PromptInput) (model.PromptOutput, bool) {
+	data, found, err := c.store.Get(ctx, KindPrompt, promptKeyParts(c.Model.Name(), input)...)
+	if err != nil {
+		logger.Warn("prompt cache lookup failed", "error", err)
+		return model.PromptOutput{}, false
+	}
+	if !found {
+		return model.PromptOutput{}, false
+	}
+
+	var output model.PromptOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		logger.Warn("discarding corrupt prompt cache entry", "model", c.Model.Name())
+		return model.PromptOutput{}, false
+	}
+	logger.Debug("prompt cache hit", "model", c.Model.Name())
+	if output.Metadata == nil {
+		output.Metadata = make(map[string]string)
+	}
+	output.Metadata["cache"] = "hit"
+	return output, true
+}
+
+// save caches the response to input
+func (c *CachedModel) save(ctx context.Context, input model.PromptInput, output model.PromptOutput) {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return
+	}
+	if err := c.store.Put(ctx, KindPrompt, data, promptKeyParts(c.Model.Name(), input)...); err != nil {
+		logger.Warn("failed to store prompt cache entry", "error", err)
+	}
+}
+
+// StreamPrompt passes a cached response on in one piece when available,
+// otherwise streams the prompt and stores the result
+func (c *CachedModel) StreamPrompt(ctx context.Context, input model.PromptInput, onChunk model.ChunkFunc) (model.PromptOutput, error) {
+	if output, found := c.lookup(ctx, input); found {
+		onChunk(output.Response)
+		return output, nil
+	}
+
+	output, err := model.Stream(ctx, c.Model, input, onChunk)
+	if err != nil {
+		return output, err
+	}
+	c.save(ctx, input, output)
+	return output, nil
+}
+
+// Unwrap returns the wrapped model
+func (c *CachedModel) Unwrap() model.Model {
+	return c.Model
+}
+
+// promptKeyParts lists every input field that affects the model response
+func promptKeyParts(modelName string, input model.PromptInput) []string {
+	parts := []string{
+		modelName,
+		input.SystemPrompt,
+		input.UserPrompt,
+		fmt.Sprintf("%d", input.MaxTokens),
+		fmt.Sprintf("%g", input.Temperature),
+	}
+
+	for _, file := range input.Files {
+		parts = append(parts, file.Path, file.Type, file.Content)
+	}
+	for _, entry := range input.Memory {
+		parts = append(parts, entry.Type, entry.Content)
+	}
+
+	keys := make([]string, 0, len(input.Metadata))
+	for k := range input.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, k+"="+input.Metadata[k])
+	}
+
+	return parts
+}
@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// URLSigner produces pre-signed URLs for object operations
+type URLSigner interface {
+	// SignURL returns a URL authorizing method on key until expiry elapses
+	SignURL(method, key string, expiry time.Duration) (string, error)
+}
+
+// HTTPConfig configures an HTTP cache backend
+type HTTPConfig struct {
+	// Base URL for plain HTTP caches; ignored when Signer is set
+	BaseURL string
+
+	// Key prefix
+	Prefix string
+
+	// Bearer token for plain HTTP caches
+	Token string
+
+	// Signer for object stores (S3, GCS)
+	Signer URLSigner
+
+	// Signed URL lifetime
+	URLExpiry time.Duration
+
+	// Request timeout
+	Timeout time.Duration
+}
+
+// HTTPBackend stores cache entries on an HTTP server or object store using GET/PUT/DELETE
+type HTTPBackend struct {
+	config HTTPConfig
+	client *http.Client
+}
+
+// NewHTTPBackend creates a new HTTP backend
+func NewHTTPBackend(cfg HTTPConfig) *HTTPBackend {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.URLExpiry <= 0 {
+		cfg.URLExpiry = 15 * time.Minute
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &HTTPBackend{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Name returns the backend name
+func (b *HTTPBackend) Name() string {
+	if b.config.Signer != nil {
+		return "object"
+	}
+	return "http"
+}
+
+// SignedURL returns a URL that can be shared to perform method on key
+// without further credentials: a presigned URL for object stores, and the
+// plain URL for HTTP caches that need no token
+func (b *HTTPBackend) SignedURL(method, key string) (string, error) {
+	if b.config.Signer == nil && b.config.Token != "" {
+		return "", errors.New(errors.ErrorTypeConfig, "SignedURL", "the http cache needs a bearer token, so its URLs cannot be shared")
+	}
+	return b.urlFor(method, key)
+}
+
+// Get downloads a cache entry
+func (b *HTTPBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	resp, err := b.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, nil
+	case resp.StatusCode >= 300:
+		return nil, false, statusError("Get", resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, errors.Wrap(err, errors.ErrorTypeNetwork, "Get", "failed to read cache entry")
+	}
+	return data, true, nil
+}
+
+// Put uploads a cache entry
+func (b *HTTPBackend) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := b.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return statusError("Put", resp)
+	}
+	return nil
+}
+
+// Delete removes a cache entry
+func (b *HTTPBackend) Delete(ctx context.Context, key string) error {
+	resp, err := b.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return statusError("Delete", resp)
+	}
+	return nil
+}
+
+// do issues a request for key against the configured endpoint
+func (b *HTTPBackend) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	target, err := b.urlFor(method, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeNetwork, method, "failed to create request")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	if b.config.Signer == nil && b.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.config.Token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeNetwork, method, "cache request failed")
+	}
+	return resp, nil
+}
+
+// urlFor returns the request URL for key
+func (b *HTTPBackend) urlFor(method, key string) (string, error) {
+	objectKey := b.objectKey(key)
+	if b.config.Signer != nil {
+		return b.config.Signer.SignURL(method, objectKey, b.config.URLExpiry)
+	}
+	if b.config.BaseURL == "" {
+		return "", errors.New(errors.ErrorTypeConfig, "urlFor", "cache base URL is not configured")
+	}
+	return b.config.BaseURL + "/" + escapePath(objectKey), nil
+}
+
+// objectKey applies the configured prefix to key
+func (b *HTTPBackend) objectKey(key string) string {
+	if b.config.Prefix == "" {
+		return key
+	}
+	return strings.Trim(b.config.Prefix, "/") + "/" + key
+}
+
+// statusError converts an unexpected HTTP status into an error
+func statusError(op string, resp *http.Response) error {
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return errors.New(errors.ErrorTypeNetwork, op,
+		fmt.Sprintf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet))))
+}
@@ -0,0 +1,258 @@
+// Package chunk splits files into the pieces that are embedded for retrieval
+// and summarized one at a time, at boundaries that suit each file type:
+// declarations in code, sections in markdown and fixed windows of lines
+// elsewhere.
+package chunk
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Chunking strategies
+const (
+	StrategyLines        = "lines"
+	StrategyDeclarations = "declarations"
+	StrategyMarkdown     = "markdown"
+)
+
+// Chunk is a range of lines from a file
+type Chunk struct {
+	StartLine int // 1-based
+	EndLine   int
+	Content   string
+}
+
+// Options bound the chunks of a file
+type Options struct {
+	// Size is the most lines in a chunk
+	Size int
+	// Overlap is the number of lines consecutive chunks share when a run of
+	// lines with no better boundary is split into windows
+	Overlap int
+}
+
+// Validate checks that the options describe chunks
+func (o Options) Validate() error {
+	if o.Size < 1 {
+		return fmt.Errorf("invalid chunk size: %d (must be at least 1)", o.Size)
+	}
+	if o.Overlap < 0 || o.Overlap >= o.Size {
+		return fmt.Errorf("invalid chunk overlap: %d (must be at least 0 and less than the size %d)", o.Overlap, o.Size)
+	}
+	return nil
+}
+
+// Chunker splits a file's content into chunks of at most opts.Size lines
+type Chunker interface {
+	Chunk(path, content string, opts Options) []Chunk
+}
+
+// chunkers are the chunking strategies by name
+var chunkers = map[string]Chunker{
+	StrategyLines:        LineChunker{},
+	StrategyDeclarations: DeclarationChunker{},
+	StrategyMarkdown:     MarkdownChunker{},
+}
+
+// Get returns the chunker of a strategy
+func Get(strategy string) (Chunker, error) {
+	chunker, ok := chunkers[strategy]
+	if !ok {
+		return nil, fmt.Errorf("unknown chunking strategy: %s (valid: %s)", strategy, strings.Join(Strategies(), ", "))
+	}
+	return chunker, nil
+}
+
+// Register adds a chunking strategy, replacing any of the same name
+func Register(strategy string, chunker Chunker) {
+	chunkers[strategy] = chunker
+}
+
+// Strategies returns the names of the chunking strategies, sorted
+func Strategies() []string {
+	names := make([]string, 0, len(chunkers))
+	for name := range chunkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultStrategies are the strategies of file types that are not chunked
+// by lines, by extension
+var defaultStrategies = map[string]string{
+	".go":       StrategyDeclarations,
+	".py":       StrategyDeclarations,
+	".js":       StrategyDeclarations,
+	".jsx":      StrategyDeclarations,
+	".mjs":      StrategyDeclarations,
+	".cjs":      StrategyDeclarations,
+	".ts":       StrategyDeclarations,
+	".tsx":      StrategyDeclarations,
+	".md":       StrategyMarkdown,
+	".markdown": StrategyMarkdown,
+	".mdx":      StrategyMarkdown,
+}
+
+// Rule is how the files of one type are chunked. Zero fields fall back to
+// the default strategy of the type and the options of Rules.
+type Rule struct {
+	Strategy string
+	Index    Options
+	Summary  Options
+}
+
+// Rules decide how each file is chunked for the embeddings index and for
+// summarizing files in parts
+type Rules struct {
+	Index   Options
+	Summary Options
+	Types   map[string]Rule // By extension, such as ".sql"
+}
+
+// DefaultRules returns the rules used without configuration: 40-line index
+// chunks sharing 10 lines, and 400-line summary parts
+func DefaultRules() Rules {
+	return Rules{
+		Index:   Options{Size: 40, Overlap: 10},
+		Summary: Options{Size: 400},
+	}
+}
+
+// Validate checks the strategies and options of the rules
+func (r Rules) Validate() error {
+	if err := r.Index.Validate(); err != nil {
+		return fmt.Errorf("index: %w", err)
+	}
+	if err := r.Summary.Validate(); err != nil {
+		return fmt.Errorf("summary: %w", err)
+	}
+	for _, ext := range r.types() {
+		if r.Types[ext].Strategy != "" {
+			if _, err := Get(r.Types[ext].Strategy); err != nil {
+				return fmt.Errorf("%s: %w", ext, err)
+			}
+		}
+		path := "file" + Extension(ext)
+		if err := r.IndexOptions(path).Validate(); err != nil {
+			return fmt.Errorf("%s index: %w", ext, err)
+		}
+		if err := r.SummaryOptions(path).Validate(); err != nil {
+			return fmt.Errorf("%s summary: %w", ext, err)
+		}
+	}
+	return nil
+}
+
+// Extension normalizes a file type to a lowercase extension with its dot,
+// so "MD" and ".md" are the same type
+func Extension(fileType string) string {
+	fileType = strings.ToLower(strings.TrimSpace(fileType))
+	if fileType != "" && !strings.HasPrefix(fileType, ".") {
+		fileType = "." + fileType
+	}
+	return fileType
+}
+
+// rule returns the configured rule for a file's type
+func (r Rules) rule(path string) Rule {
+	ext := strings.ToLower(filepath.Ext(path))
+	for fileType, rule := range r.Types {
+		if Extension(fileType) == ext {
+			return rule
+		}
+	}
+	return Rule{}
+}
+
+// Chunker returns the chunker for a file: its type's configured strategy,
+// or the default strategy for the type
+func (r Rules) Chunker(path string) Chunker {
+	strategy := r.rule(path).Strategy
+	if strategy == "" {
+		strategy = defaultStrategies[strings.ToLower(filepath.Ext(path))]
+	}
+	if chunker, err := Get(strategy); err == nil {
+		return chunker
+	}
+	return LineChunker{}
+}
+
+// IndexOptions returns the options of a file's index chunks
+func (r Rules) IndexOptions(path string) Options {
+	return merge(r.rule(path).Index, r.Index)
+}
+
+// SummaryOptions returns the options of a file's summary parts
+func (r Rules) SummaryOptions(path string) Options {
+	return merge(r.rule(path).Summary, r.Summary)
+}
+
+// merge returns options, or defaults when options set no size. A size
+// without an overlap means no overlap.
+func merge(options, defaults Options) Options {
+	if options.Size == 0 {
+		return defaults
+	}
+	return options
+}
+
+// IndexChunks splits a file into the chunks it is indexed by
+func (r Rules) IndexChunks(path, content string) []Chunk {
+	return r.Chunker(path).Chunk(path, content, r.IndexOptions(path))
+}
+
+// SummaryParts splits a file into the parts it is summarized in, or
+// returns nil when it is small enough to summarize whole
+func (r Rules) SummaryParts(path, content string) []Chunk {
+	opts := r.SummaryOptions(path)
+	if len(splitLines(content)) <= opts.Size {
+		return nil
+	}
+	return r.Chunker(path).Chunk(path, content, opts)
+}
+
+// Fingerprint identifies how the rules chunk files for the index, so an
+// index built with other rules is rebuilt
+func (r Rules) Fingerprint() string {
+	var fingerprint strings.Builder
+	fingerprint.WriteString(fmt.Sprintf("%d/%d", r.Index.Size, r.Index.Overlap))
+	for _, ext := range r.types() {
+		rule := r.Types[ext]
+		fingerprint.WriteString(fmt.Sprintf(";%s=%s:%d/%d", Extension(ext), rule.Strategy, rule.Index.Size, rule.Index.Overlap))
+	}
+	return fingerprint.String()
+}
+
+// types returns the configured file types, sorted
+func (r Rules) types() []string {
+	types := make([]string, 0, len(r.Types))
+	for fileType := range r.Types {
+		types = append(types, fileType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// splitLines splits content into lines, without a final empty line for
+// content ending in a newline
+func splitLines(content string) []string {
+	content = strings.TrimRight(content, "\n")
+	if content == "" {
+		return nil
+	}
+	return strings.Split(content, "\n")
+}
+
+// newChunk returns the chunk of lines [start, end), or false when they are
+// all blank
+func newChunk(lines []string, start, end int) (Chunk, bool) {
+	content := strings.Join(lines[start:end], "\n")
+	if strings.TrimSpace(content) == "" {
+		return Chunk{}, false
+	}
+	return Chunk{StartLine: start + 1, EndLine: end, Content: content}, true
+}
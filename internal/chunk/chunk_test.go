@@ -0,0 +1,141 @@
+package chunk
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ranges returns the line ranges of chunks
+func ranges(chunks []Chunk) []string {
+	var spans []string
+	for _, chunk := range chunks {
+		spans = append(spans, fmt.Sprintf("%d-%d", chunk.StartLine, chunk.EndLine))
+	}
+	return spans
+}
+
+func TestLineChunker(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 25; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	chunks := LineChunker{}.Chunk("a.txt", strings.Join(lines, "\n")+"\n", Options{Size: 10, Overlap: 3})
+
+	assert.Equal(t, []string{"1-10", "8-17", "15-24", "22-25"}, ranges(chunks))
+	assert.Equal(t, "line 8", strings.Split(chunks[1].Content, "\n")[0])
+	assert.Empty(t, LineChunker{}.Chunk("empty.txt", "", Options{Size: 10, Overlap: 3}))
+}
+
+const goSource = `package store
+
+import "sync"
+
+// Open opens a store
+func Open() *Store {
+	return &Store{}
+}
+
+// Store keeps entries
+type Store struct {
+	mu sync.Mutex
+}
+
+// Get returns an entry
+func (s *Store) Get(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := 1
+	b := 2
+	c := 3
+	d := 4
+	return key
+}
+`
+
+func TestDeclarationChunker(t *testing.T) {
+	chunks := DeclarationChunker{}.Chunk("store.go", goSource, Options{Size: 14, Overlap: 2})
+
+	// Open with the package clause and Store fit together; Get is split
+	assert.Equal(t, []string{"1-13", "14-24"}, ranges(chunks))
+	assert.True(t, strings.HasPrefix(chunks[1].Content, "\n// Get returns an entry"))
+
+	chunks = DeclarationChunker{}.Chunk("store.go", goSource, Options{Size: 8, Overlap: 2})
+	assert.Equal(t, []string{"1-8", "9-13", "14-21", "20-24"}, ranges(chunks), "long declarations are split into windows")
+
+	chunks = DeclarationChunker{}.Chunk("broken.go", "package store\nfunc (\n", Options{Size: 1})
+	assert.Equal(t, []string{"1-1", "2-2"}, ranges(chunks), "files that do not parse are split by lines")
+}
+
+func TestDeclarationChunker_Nested(t *testing.T) {
+	source := `import os
+
+
+class Store:
+    """Keeps entries"""
+
+    def get(self, key):
+        return key
+
+    def put(self, key, value):
+        pass
+
+
+def main():
+    pass
+`
+	chunks := DeclarationChunker{}.Chunk("store.py", source, Options{Size: 6})
+	assert.Equal(t, []string{"1-3", "4-8", "9-11", "12-15"}, ranges(chunks), "a class too long for a chunk is split at its methods")
+}
+
+func TestMarkdownChunker(t *testing.T) {
+	source := strings.Join([]string{
+		"# Guide",
+		"Intro",
+		"## Install",
+		"Run it",
+		"```sh",
+		"# not a heading",
+		"```",
+		"## Usage",
+		"Use it",
+		"### Flags",
+		"--x",
+		"--y",
+	}, "\n")
+
+	chunks := MarkdownChunker{}.Chunk("guide.md", source, Options{Size: 8})
+	assert.Equal(t, []string{"1-7", "8-12"}, ranges(chunks))
+
+	chunks = MarkdownChunker{}.Chunk("guide.md", source, Options{Size: 4})
+	assert.Equal(t, []string{"1-2", "3-6", "7-7", "8-9", "10-12"}, ranges(chunks))
+}
+
+func TestRules(t *testing.T) {
+	rules := DefaultRules()
+	rules.Types = map[string]Rule{
+		"SQL": {Strategy: StrategyLines, Index: Options{Size: 20}},
+		".md": {Strategy: StrategyLines},
+	}
+	require.NoError(t, rules.Validate())
+
+	assert.IsType(t, DeclarationChunker{}, rules.Chunker("main.go"))
+	assert.IsType(t, LineChunker{}, rules.Chunker("README.md"), "configured strategies replace the defaults")
+	assert.IsType(t, LineChunker{}, rules.Chunker("Makefile"))
+	assert.Equal(t, Options{Size: 20}, rules.IndexOptions("schema.sql"))
+	assert.Equal(t, Options{Size: 40, Overlap: 10}, rules.IndexOptions("main.go"))
+
+	short := strings.Repeat("x\n", 400)
+	assert.Nil(t, rules.SummaryParts("a.txt", short), "files within the summary size are summarized whole")
+	assert.Len(t, rules.SummaryParts("a.txt", short+"x\n"), 2)
+
+	assert.NotEqual(t, DefaultRules().Fingerprint(), rules.Fingerprint())
+
+	rules.Types["rs"] = Rule{Strategy: "ast"}
+	assert.ErrorContains(t, rules.Validate(), "unknown chunking strategy: ast")
+	rules.Types["rs"] = Rule{Index: Options{Size: 5, Overlap: 5}}
+	assert.ErrorContains(t, rules.Validate(), "rs index: invalid chunk overlap")
+}
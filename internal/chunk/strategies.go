@@ -0,0 +1,181 @@
+package chunk
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/outline"
+)
+
+// LineChunker splits files into fixed windows of lines
+type LineChunker struct{}
+
+// Chunk splits content into windows of opts.Size lines, each sharing
+// opts.Overlap lines with the one before
+func (LineChunker) Chunk(path, content string, opts Options) []Chunk {
+	lines := splitLines(content)
+	return windows(lines, 0, len(lines), opts)
+}
+
+// DeclarationChunker splits code at its declarations, so functions, types
+// and classes are kept whole where they fit
+type DeclarationChunker struct{}
+
+// Chunk packs consecutive declarations into chunks, each declaration with
+// the comments before it. A declaration too long for a chunk is
+// split at the declarations inside it, such as methods, and then into
+// windows. Files whose declarations cannot be found are split into windows.
+func (DeclarationChunker) Chunk(path, content string, opts Options) []Chunk {
+	lines := splitLines(content)
+	spans, err := outline.Spans(path, content)
+	if err != nil || len(spans) == 0 {
+		return windows(lines, 0, len(lines), opts)
+	}
+	return pack(lines, sections(0, len(lines), spans, 0), opts)
+}
+
+// MarkdownChunker splits markdown at its headings, so sections are kept
+// whole where they fit
+type MarkdownChunker struct{}
+
+// headingPattern matches an ATX heading, capturing its level
+var headingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})(\s|$)`)
+
+// Chunk packs consecutive sections into chunks. A section too long for a
+// chunk is split at its subsections, and then into windows.
+func (MarkdownChunker) Chunk(path, content string, opts Options) []Chunk {
+	lines := splitLines(content)
+
+	// A section runs to the next heading of the same or a higher level
+	var spans []outline.Span
+	var open []int // Indices in spans of the sections still running
+	fenced := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") || strings.HasPrefix(strings.TrimSpace(line), "~~~") {
+			fenced = !fenced
+		}
+		match := headingPattern.FindStringSubmatch(line)
+		if fenced || match == nil {
+			continue
+		}
+		level := len(match[1])
+		for len(open) > 0 && spans[open[len(open)-1]].Depth >= level-1 {
+			spans[open[len(open)-1]].End = i
+			open = open[:len(open)-1]
+		}
+		open = append(open, len(spans))
+		spans = append(spans, outline.Span{Start: i + 1, End: len(lines), Depth: level - 1})
+	}
+	if len(spans) == 0 {
+		return windows(lines, 0, len(lines), opts)
+	}
+	return pack(lines, sections(0, len(lines), spans, 0), opts)
+}
+
+// section is a run of lines, [start, end) from 0, best kept in one chunk,
+// and the sections it divides into when it is too long
+type section struct {
+	start, end int
+	parts      []section
+}
+
+// sections divides lines [from, to) at the shallowest spans inside them
+// that are at least depth deep. The lines before the first span, such as
+// imports or an introduction, are a section of their own. Each other
+// section takes the lines between its span and the one before, like its
+// comments, and the last also those after it. Spans within the sections
+// divide them in turn.
+func sections(from, to int, spans []outline.Span, depth int) []section {
+	shallowest := -1
+	for _, span := range spans {
+		if span.Depth >= depth && span.Start-1 >= from && span.End <= to && (shallowest < 0 || span.Depth < shallowest) {
+			shallowest = span.Depth
+		}
+	}
+	if shallowest < 0 {
+		return nil
+	}
+
+	var bounds []outline.Span
+	for _, span := range spans {
+		if span.Depth != shallowest || span.Start-1 < from || span.End > to {
+			continue
+		}
+		// Skip spans overlapping the one before, like specs of one group
+		if n := len(bounds); n > 0 && span.Start <= bounds[n-1].End {
+			continue
+		}
+		bounds = append(bounds, span)
+	}
+
+	var divided []section
+	if lead := bounds[0].Start - 1; lead > from {
+		divided = append(divided, section{start: from, end: lead})
+	}
+	for i, bound := range bounds {
+		start, end := bound.Start-1, bound.End
+		if n := len(divided); n > 0 {
+			start = divided[n-1].end
+		}
+		if i == len(bounds)-1 {
+			end = to
+		}
+		divided = append(divided, section{start: start, end: end, parts: sections(start, end, spans, shallowest+1)})
+	}
+	return divided
+}
+
+// pack fills chunks of at most opts.Size lines with whole sections, in
+// order. Sections longer than that are packed from their parts or, without
+// parts, split into windows.
+func pack(lines []string, sections []section, opts Options) []Chunk {
+	var chunks []Chunk
+	start, end := -1, -1
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		if chunk, ok := newChunk(lines, start, end); ok {
+			chunks = append(chunks, chunk)
+		}
+		start = -1
+	}
+
+	for _, s := range sections {
+		if s.end-s.start > opts.Size {
+			flush()
+			if len(s.parts) > 0 {
+				chunks = append(chunks, pack(lines, s.parts, opts)...)
+			} else {
+				chunks = append(chunks, windows(lines, s.start, s.end, opts)...)
+			}
+			continue
+		}
+		if start >= 0 && s.end-start > opts.Size {
+			flush()
+		}
+		if start < 0 {
+			start = s.start
+		}
+		end = s.end
+	}
+	flush()
+	return chunks
+}
+
+// windows splits lines [from, to) into windows of opts.Size lines, each
+// sharing opts.Overlap lines with the one before
+func windows(lines []string, from, to int, opts Options) []Chunk {
+	step := max(opts.Size-opts.Overlap, 1)
+	var chunks []Chunk
+	for start := from; start < to; start += step {
+		end := min(start+max(opts.Size, 1), to)
+		if chunk, ok := newChunk(lines, start, end); ok {
+			chunks = append(chunks, chunk)
+		}
+		if end == to {
+			break
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,330 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// Reasons a project is affected
+const (
+	AffectedChanged    = "changed"
+	AffectedDependency = "dependency"
+)
+
+// AffectedCommand reports the projects of a monorepo that changes touch
+type AffectedCommand struct {
+	*BaseCommand
+	Base         string
+	Paths        bool
+	NoDependents bool
+}
+
+// monorepoProject is a directory holding a module manifest
+type monorepoProject struct {
+	Path     string // Relative to the repository root, "." for the root
+	Name     string // Module or package name from the manifest
+	Manifest string // Manifest file name
+	content  string // Manifest content, read for dependencies
+}
+
+// affectedProject is a project the changes affect, and why
+type affectedProject struct {
+	Path     string   `json:"path"`
+	Name     string   `json:"name,omitempty"`
+	Manifest string   `json:"manifest"`
+	Reason   string   `json:"reason"`
+	Files    []string `json:"files,omitempty"` // Changed files in the project
+	Via      []string `json:"via,omitempty"`   // Affected projects it depends on
+}
+
+// affectedReport is the output of sigil affected
+type affectedReport struct {
+	Base      string            `json:"base"`
+	MergeBase string            `json:"merge_base"`
+	Projects  []affectedProject `json:"projects"`
+	Unowned   []string          `json:"unowned,omitempty"` // Changed files outside every project
+}
+
+// NewAffectedCommand creates a new affected command
+func NewAffectedCommand() *AffectedCommand {
+	return &AffectedCommand{
+		BaseCommand: NewBaseCommand("affected", "List the projects changes affect",
+			"Map the files changed since a base to the monorepo projects that own them."),
+		Base: "origin/main",
+	}
+}
+
+// Execute runs the affected command
+func (c *AffectedCommand) Execute(ctx context.Context) error {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to open git repository")
+	}
+	root := &git.Repository{Path: repo.Root}
+
+	mergeBase, err := root.EnsureMergeBase(c.Base, deepenOptions())
+	if err != nil {
+		if stderrors.Is(err, git.ErrShallowHistory) {
+			return shallowHistoryError(err)
+		}
+		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", fmt.Sprintf("failed to find where HEAD diverged from %s", c.Base)).
+			WithHint("fetch the base first, for example git fetch origin main")
+	}
+	changed, err := root.ChangedFiles(c.Base)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to list changed files")
+	}
+	projects, err := discoverProjects(root)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to find projects")
+	}
+	logger.Debug("mapping changes to projects", "files", len(changed), "projects", len(projects))
+
+	report := affectedProjects(projects, changed, !c.NoDependents)
+	report.Base = c.Base
+	report.MergeBase = mergeBase
+
+	if c.Paths {
+		for _, project := range report.Projects {
+			fmt.Println(project.Path)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to encode affected projects")
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// CreateCobraCommand creates the cobra command for affected
+func (c *AffectedCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "affected",
+		Short: "List the projects changes affect",
+		Long: `Map the files changed on HEAD since it diverged from a base to the projects
+of a monorepo and print the affected set as JSON, so CI can test and review
+only what changed.
+
+A project is a directory holding a module manifest (go.mod, package.json,
+pyproject.toml, setup.py, Cargo.toml, pom.xml or build.gradle); a file
+belongs to the deepest project containing it. Projects that depend on an
+affected project through their go.mod, package.json, Cargo.toml or
+pyproject.toml are affected too, unless --no-dependents is given. Changed
+files outside every project are listed as unowned.`,
+		Example: `  sigil affected --base origin/main
+  sigil affected --base origin/main --paths | xargs -I{} make -C {} test`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.Execute(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVar(&c.Base, "base", c.Base, "Branch or commit the changes are compared with")
+	cmd.Flags().BoolVar(&c.Paths, "paths", false, "Print only the paths of affected projects, one per line")
+	cmd.Flags().BoolVar(&c.NoDependents, "no-dependents", false, "Leave out projects that only depend on changed ones")
+	return cmd
+}
+
+// discoverProjects finds the module manifests in a repository, skipping
+// vendored and installed dependencies
+func discoverProjects(repo *git.Repository) ([]monorepoProject, error) {
+	files, err := repo.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make(map[string]bool, len(moduleManifests))
+	for _, manifest := range moduleManifests {
+		manifests[manifest] = true
+	}
+
+	byDir := make(map[string]monorepoProject)
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		if !manifests[path.Base(file)] || inSkippedDir(file) {
+			continue
+		}
+		dir := path.Dir(file)
+		// The first manifest in moduleManifests order names the project
+		if existing, ok := byDir[dir]; ok && manifestRank(existing.Manifest) < manifestRank(path.Base(file)) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(repo.Path, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+		project := monorepoProject{Path: dir, Manifest: path.Base(file), content: string(content)}
+		project.Name = manifestName(project.Manifest, project.content, dir)
+		byDir[dir] = project
+	}
+
+	projects := make([]monorepoProject, 0, len(byDir))
+	for _, project := range byDir {
+		projects = append(projects, project)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Path < projects[j].Path })
+	return projects, nil
+}
+
+// inSkippedDir reports whether a path is below a directory never searched
+func inSkippedDir(file string) bool {
+	for _, part := range strings.Split(path.Dir(file), "/") {
+		if skippedDirs[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// manifestRank orders manifests as moduleManifests lists them
+func manifestRank(manifest string) int {
+	for i, candidate := range moduleManifests {
+		if candidate == manifest {
+			return i
+		}
+	}
+	return len(moduleManifests)
+}
+
+// manifestName reads the module or package name a manifest declares,
+// falling back to the directory name
+func manifestName(manifest, content, dir string) string {
+	switch manifest {
+	case "go.mod":
+		if match := goModule.FindStringSubmatch(content); match != nil {
+			return match[1]
+		}
+	case "package.json":
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal([]byte(content), &pkg) == nil && pkg.Name != "" {
+			return pkg.Name
+		}
+	case "Cargo.toml", "pyproject.toml":
+		if match := tomlName.FindStringSubmatch(content); match != nil {
+			return match[1]
+		}
+	}
+	if dir == "." {
+		return ""
+	}
+	return path.Base(dir)
+}
+
+// dependsOn reports whether a project's manifest declares another project
+// as a dependency
+func (p monorepoProject) dependsOn(other monorepoProject) bool {
+	if other.Name == "" || p.Path == other.Path {
+		return false
+	}
+	switch p.Manifest {
+	case "go.mod":
+		pattern := regexp.MustCompile(`(?m)^\s*(?:require\s+|replace\s+)?` + regexp.QuoteMeta(other.Name) + `\s`)
+		return pattern.MatchString(p.content)
+	case "package.json":
+		var pkg map[string]json.RawMessage
+		if json.Unmarshal([]byte(p.content), &pkg) != nil {
+			return false
+		}
+		for _, field := range []string{"dependencies", "devDependencies", "peerDependencies", "optionalDependencies"} {
+			var deps map[string]string
+			if json.Unmarshal(pkg[field], &deps) == nil {
+				if _, ok := deps[other.Name]; ok {
+					return true
+				}
+			}
+		}
+	case "Cargo.toml", "pyproject.toml":
+		quoted := regexp.QuoteMeta(other.Name)
+		pattern := regexp.MustCompile(`(?m)^\s*` + quoted + `\s*=|["']` + quoted + `(?:[\s<>=~!;\[,"']|$)`)
+		// The project's own name line is not a dependency
+		content := tomlName.ReplaceAllString(p.content, "")
+		return pattern.MatchString(content)
+	}
+	return false
+}
+
+// affectedProjects maps changed files to the deepest project containing
+// each and, when dependents is set, adds the projects that depend on
+// affected ones
+func affectedProjects(projects []monorepoProject, changed []string, dependents bool) affectedReport {
+	report := affectedReport{Projects: []affectedProject{}}
+	affected := make(map[string]*affectedProject)
+	var order []string
+
+	for _, file := range changed {
+		owner := owningProject(projects, file)
+		if owner == nil {
+			report.Unowned = append(report.Unowned, file)
+			continue
+		}
+		entry, ok := affected[owner.Path]
+		if !ok {
+			entry = &affectedProject{Path: owner.Path, Name: owner.Name, Manifest: owner.Manifest, Reason: AffectedChanged}
+			affected[owner.Path] = entry
+			order = append(order, owner.Path)
+		}
+		entry.Files = append(entry.Files, file)
+	}
+
+	// Dependents of dependents are affected too, so repeat until no more
+	// projects are added
+	for dependents {
+		added := false
+		for _, project := range projects {
+			if _, ok := affected[project.Path]; ok {
+				continue
+			}
+			var via []string
+			for _, dependency := range projects {
+				if _, ok := affected[dependency.Path]; ok && project.dependsOn(dependency) {
+					via = append(via, dependency.Path)
+				}
+			}
+			if len(via) > 0 {
+				affected[project.Path] = &affectedProject{Path: project.Path, Name: project.Name,
+					Manifest: project.Manifest, Reason: AffectedDependency, Via: via}
+				order = append(order, project.Path)
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+
+	sort.Strings(order)
+	for _, projectPath := range order {
+		report.Projects = append(report.Projects, *affected[projectPath])
+	}
+	return report
+}
+
+// owningProject returns the deepest project containing a file
+func owningProject(projects []monorepoProject, file string) *monorepoProject {
+	var owner *monorepoProject
+	for i, project := range projects {
+		inside := project.Path == "." || strings.HasPrefix(file, project.Path+"/")
+		if inside && (owner == nil || len(project.Path) > len(owner.Path) || owner.Path == ".") {
+			owner = &projects[i]
+		}
+	}
+	return owner
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/git"
+)
+
+// monorepoFiles is a repository with Go and Node projects depending on
+// each other
+var monorepoFiles = map[string]string{
+	"README.md":                           "# Mono\n",
+	"libs/core/go.mod":                    "module example.com/core\n\ngo 1.22\n",
+	"libs/core/core.go":                   "package core\n",
+	"services/api/go.mod":                 "module example.com/api\n\ngo 1.22\n\nrequire example.com/core v0.0.0\n\nreplace example.com/core => ../../libs/core\n",
+	"services/api/main.go":                "package main\n",
+	"services/gateway/go.mod":             "module example.com/gateway\n\nrequire (\n\texample.com/api v0.0.0\n)\n",
+	"web/ui/package.json":                 `{"name": "@mono/ui", "version": "1.0.0"}`,
+	"web/app/package.json":                `{"name": "@mono/app", "dependencies": {"@mono/ui": "workspace:*"}}`,
+	"web/app/node_modules/x/package.json": `{"name": "x"}`,
+}
+
+func TestDiscoverProjects(t *testing.T) {
+	dir := t.TempDir()
+	gitCommit(t, dir, monorepoFiles)
+
+	projects, err := discoverProjects(&git.Repository{Path: dir})
+	require.NoError(t, err)
+
+	var paths, names []string
+	for _, project := range projects {
+		paths = append(paths, project.Path)
+		names = append(names, project.Name)
+	}
+	assert.Equal(t, []string{"libs/core", "services/api", "services/gateway", "web/app", "web/ui"}, paths,
+		"installed dependencies are not projects")
+	assert.Equal(t, []string{"example.com/core", "example.com/api", "example.com/gateway", "@mono/app", "@mono/ui"}, names)
+}
+
+func TestAffectedProjects(t *testing.T) {
+	dir := t.TempDir()
+	gitCommit(t, dir, monorepoFiles)
+	projects, err := discoverProjects(&git.Repository{Path: dir})
+	require.NoError(t, err)
+
+	report := affectedProjects(projects, []string{"libs/core/core.go", "web/ui/index.js", "README.md"}, true)
+	assert.Equal(t, []affectedProject{
+		{Path: "libs/core", Name: "example.com/core", Manifest: "go.mod", Reason: AffectedChanged, Files: []string{"libs/core/core.go"}},
+		{Path: "services/api", Name: "example.com/api", Manifest: "go.mod", Reason: AffectedDependency, Via: []string{"libs/core"}},
+		{Path: "services/gateway", Name: "example.com/gateway", Manifest: "go.mod", Reason: AffectedDependency, Via: []string{"services/api"}},
+		{Path: "web/app", Name: "@mono/app", Manifest: "package.json", Reason: AffectedDependency, Via: []string{"web/ui"}},
+		{Path: "web/ui", Name: "@mono/ui", Manifest: "package.json", Reason: AffectedChanged, Files: []string{"web/ui/index.js"}},
+	}, report.Projects)
+	assert.Equal(t, []string{"README.md"}, report.Unowned)
+
+	report = affectedProjects(projects, []string{"services/api/main.go"}, false)
+	require.Len(t, report.Projects, 1)
+	assert.Equal(t, "services/api", report.Projects[0].Path)
+
+	report = affectedProjects(projects, nil, true)
+	assert.Empty(t, report.Projects)
+}
+
+func TestOwningProject_Root(t *testing.T) {
+	projects := []monorepoProject{{Path: "."}, {Path: "tools"}}
+	assert.Equal(t, "tools", owningProject(projects, "tools/gen.go").Path, "the deepest project owns a file")
+	assert.Equal(t, ".", owningProject(projects, "main.go").Path)
+}
+
+func TestMonorepoProject_DependsOn(t *testing.T) {
+	crate := monorepoProject{Manifest: "Cargo.toml", Path: "app", content: "[package]\nname = \"app\"\n\n[dependencies]\ncore = { path = \"../core\" }\n"}
+	assert.True(t, crate.dependsOn(monorepoProject{Path: "core", Name: "core"}))
+	assert.False(t, crate.dependsOn(monorepoProject{Path: "other", Name: "app"}), "a project's own name is not a dependency")
+
+	python := monorepoProject{Manifest: "pyproject.toml", Path: "svc", content: "[project]\nname = \"svc\"\ndependencies = [\"shared>=1.0\", \"requests\"]\n"}
+	assert.True(t, python.dependsOn(monorepoProject{Path: "shared", Name: "shared"}))
+	assert.False(t, python.dependsOn(monorepoProject{Path: "share", Name: "share"}))
+}
@@ -3,10 +3,13 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/glossary"
 	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/memory"
 	"github.com/dshills/sigil/internal/model"
@@ -17,6 +20,9 @@ import (
 type AskCommand struct {
 	*BaseCommand
 	Question string
+	Preset   promptPresetFlags
+	Files    []string
+	Top      int
 }
 
 // NewAskCommand creates a new ask command
@@ -28,10 +34,17 @@ func NewAskCommand() *AskCommand {
 			`Ask a question about code in files, directories, or from stdin.
 The LLM will analyze the code and provide an answer.
 
+Without an input source, the question is answered from the repository:
+the most relevant code is retrieved from an embeddings index kept in
+.sigil/index, and the answer cites the files and lines it used.
+
 Examples:
+  sigil ask "Where are API tokens validated?"
+  sigil ask "How is the cache invalidated?" --files internal/cache --json
   sigil ask "What does this function do?" --file main.go
   sigil ask "How can I optimize this code?" --dir src/
-  sigil ask "Explain this algorithm" --git --staged`,
+  sigil ask "Explain this algorithm" --git --staged
+  sigil ask --prompt github-mcp/summarize-pr --prompt-arg pr=42`,
 		),
 	}
 }
@@ -41,30 +54,41 @@ func (c *AskCommand) Execute(ctx context.Context, args []string) error {
 	start := time.Now()
 
 	// Validate arguments
-	if len(args) == 0 {
+	if len(args) == 0 && c.Preset.Name == "" {
 		return errors.ValidationError("Execute", "question is required")
 	}
 
-	c.Question = strings.Join(args, " ")
+	// Seed the question from a prompt preset if one was selected
+	preset, err := c.Preset.resolve(ctx, os.Stdin)
+	if err != nil {
+		return err
+	}
+	c.Question = strings.TrimSpace(preset + "\n\n" + strings.Join(args, " "))
 
 	// Run pre-checks
 	if err := c.RunPreChecks(); err != nil {
 		return err
 	}
 
-	// Get input
-	inputHandler := NewInputHandler(c.GetCommonFlags())
-	inputCtx, err := inputHandler.GetInput()
-	if err != nil {
-		return errors.Wrap(err, errors.ErrorTypeInput, "Execute", "failed to get input")
-	}
-
 	// Get model
 	mdl, err := c.GetModel(ctx)
 	if err != nil {
 		return errors.Wrap(err, errors.ErrorTypeModel, "Execute", "failed to get model")
 	}
 
+	// Get input, retrieving it from the repository when no source is given
+	inputHandler := NewInputHandler(c.GetCommonFlags())
+	var inputCtx *CommandContext
+	var sources []Source
+	if c.HasInputSource() {
+		inputCtx, err = inputHandler.GetInput()
+	} else {
+		inputCtx, sources, err = retrieveContext(ctx, mdl, c.Question, c.Files, c.Top)
+	}
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInput, "Execute", "failed to get input")
+	}
+
 	// Get memory context if requested
 	memoryCtx, err := inputHandler.GetMemoryContext()
 	if err != nil {
@@ -76,12 +100,17 @@ func (c *AskCommand) Execute(ctx context.Context, args []string) error {
 
 	logger.Debug("executing ask command", "question", c.Question, "input_type", inputCtx.InputType)
 
-	// Run model
-	response, err := mdl.RunPrompt(ctx, promptInput)
+	// Run model, printing the answer as it arrives when it goes to stdout
+	outputHandler := NewOutputHandler(c.GetCommonFlags())
+	streamed := outputHandler.Streams()
+	onChunk := func(string) {}
+	if streamed {
+		onChunk = func(chunk string) { fmt.Print(chunk) }
+	}
+	response, err := model.Stream(ctx, mdl, promptInput, onChunk)
 	if err != nil {
 		duration := time.Since(start)
 		output := CreateErrorOutput("ask", err, duration)
-		outputHandler := NewOutputHandler(c.GetCommonFlags())
 		outputHandler.WriteOutput(output)
 		return errors.Wrap(err, errors.ErrorTypeModel, "Execute", "model execution failed")
 	}
@@ -89,6 +118,7 @@ func (c *AskCommand) Execute(ctx context.Context, args []string) error {
 	// Create output
 	duration := time.Since(start)
 	output := CreateOutput("ask", inputCtx, response, duration)
+	output.Sources = sources
 
 	// Store session in memory
 	if err := c.storeSession(inputCtx, response, duration); err != nil {
@@ -97,8 +127,11 @@ func (c *AskCommand) Execute(ctx context.Context, args []string) error {
 	}
 
 	// Write output
-	outputHandler := NewOutputHandler(c.GetCommonFlags())
-	if err := outputHandler.WriteOutput(output); err != nil {
+	write := outputHandler.WriteOutput
+	if streamed {
+		write = outputHandler.WriteStreamed
+	}
+	if err := write(output); err != nil {
 		return errors.Wrap(err, errors.ErrorTypeOutput, "Execute", "failed to write output")
 	}
 
@@ -110,10 +143,13 @@ func (c *AskCommand) GetCobraCommand() *cobra.Command {
 	cmd := c.BaseCommand.GetCobraCommand()
 
 	cmd.Use = "ask [question]"
-	cmd.Args = cobra.MinimumNArgs(1)
+	cmd.Args = cobra.ArbitraryArgs
 	cmd.RunE = func(cobraCmd *cobra.Command, args []string) error {
 		return c.Execute(context.Background(), args)
 	}
+	c.Preset.register(cmd)
+	cmd.Flags().StringSliceVar(&c.Files, "files", nil, "Limit repository questions to these files, directories or globs")
+	cmd.Flags().IntVar(&c.Top, "top", defaultRetrievalResults, "Number of code excerpts retrieved for repository questions")
 
 	return cmd
 }
@@ -125,36 +161,53 @@ func (c *AskCommand) buildPrompt(inputCtx *CommandContext, memoryCtx []model.Mem
 	systemPrompt.WriteString("You are an AI assistant specialized in code analysis and explanation. ")
 	systemPrompt.WriteString("Your task is to answer questions about code accurately and helpfully. ")
 	systemPrompt.WriteString("Provide clear, concise explanations that are appropriate for the user's level of understanding.")
+	if inputCtx.InputType == InputTypeRepository {
+		systemPrompt.WriteString(" Answer from the numbered repository excerpts provided. Cite the excerpts you rely on as [n] after each claim. ")
+		systemPrompt.WriteString("If the excerpts do not contain the answer, say so rather than guessing.")
+	}
+
+	systemPrompt.WriteString("\n\n" + model.UntrustedContentRule)
 
 	var userPrompt strings.Builder
 	userPrompt.WriteString(fmt.Sprintf("Question: %s\n\n", c.Question))
 
-	// Add context based on input type
+	// Add context based on input type, declaring the files behind content
+	// not fenced under a path of its own
+	sources := make(map[string][]string)
 	switch inputCtx.InputType {
 	case InputTypeFile:
+		source := "file"
 		if len(inputCtx.Files) > 0 {
-			userPrompt.WriteString(fmt.Sprintf("File: %s\n", inputCtx.Files[0].Path))
+			source = inputCtx.Files[0].Path
+			userPrompt.WriteString(fmt.Sprintf("File: %s\n", source))
 		}
-		userPrompt.WriteString("Code:\n```\n")
-		userPrompt.WriteString(inputCtx.Input)
-		userPrompt.WriteString("\n```\n")
+		userPrompt.WriteString("Code:\n")
+		userPrompt.WriteString(model.FenceUntrusted(source, inputCtx.Input))
 
 	case InputTypeDirectory:
+		for _, file := range inputCtx.Files {
+			sources["directory"] = append(sources["directory"], file.Path)
+		}
 		userPrompt.WriteString("Code from directory:\n")
-		userPrompt.WriteString(inputCtx.Input)
+		userPrompt.WriteString(model.FenceUntrusted("directory", inputCtx.Input))
 
 	case InputTypeGitDiff:
-		userPrompt.WriteString("Git diff:\n```diff\n")
-		userPrompt.WriteString(inputCtx.Input)
-		userPrompt.WriteString("\n```\n")
+		root, err := git.GetRepositoryRoot()
+		if err != nil {
+			root = "."
+		}
+		sources["git diff"] = diffSources(root, inputCtx.Input)
+		userPrompt.WriteString("Git diff:\n")
+		userPrompt.WriteString(model.FenceUntrusted("git diff", inputCtx.Input))
 
-	case InputTypeText:
-		userPrompt.WriteString("Context:\n")
+	case InputTypeRepository:
+		userPrompt.WriteString("Relevant excerpts from the repository:\n\n")
+		// Excerpts are fenced one by one as they are retrieved
 		userPrompt.WriteString(inputCtx.Input)
 
 	default:
 		userPrompt.WriteString("Context:\n")
-		userPrompt.WriteString(inputCtx.Input)
+		userPrompt.WriteString(model.FenceUntrusted("input", inputCtx.Input))
 	}
 
 	// Build file content for model
@@ -168,9 +221,10 @@ func (c *AskCommand) buildPrompt(inputCtx *CommandContext, memoryCtx []model.Mem
 	}
 
 	return model.PromptInput{
-		SystemPrompt: systemPrompt.String(),
+		SystemPrompt: glossary.Instruct(systemPrompt.String()),
 		UserPrompt:   userPrompt.String(),
 		Files:        files,
+		Sources:      sources,
 		Memory:       memoryCtx,
 		MaxTokens:    4000,
 		Temperature:  0.1, // Lower temperature for more focused responses
@@ -0,0 +1,371 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/glossary"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/memory"
+	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/theme"
+)
+
+// maxChatTurns is how many earlier turns each prompt repeats
+const maxChatTurns = 10
+
+// chatHelp lists the commands a chat session takes
+const chatHelp = `Commands:
+  /add <path>...    Add files, directories or globs to the conversation
+  /drop [path]...   Remove added files, or all of them
+  /files            List the added files
+  /remember <note>  Save a note to memory for later sessions
+  /clear            Forget the conversation so far, keeping the files
+  /help             Show this help
+  /exit             End the session (or press Ctrl-D)
+`
+
+// ChatCommand implements the chat command
+type ChatCommand struct {
+	*BaseCommand
+	Files      []string
+	Top        int
+	summarizer *SummarizeCommand
+}
+
+// NewChatCommand creates a new chat command
+func NewChatCommand() *ChatCommand {
+	return &ChatCommand{
+		BaseCommand: NewBaseCommand("chat", "Talk about the codebase in an interactive session",
+			"Hold a multi-turn conversation about the codebase, with files added as it goes."),
+		Top:        defaultRetrievalResults,
+		summarizer: NewSummarizeCommand(),
+	}
+}
+
+// chatTurn is one question of a conversation and its answer
+type chatTurn struct {
+	Question string
+	Answer   string
+}
+
+// chatSession is what a conversation keeps between turns
+type chatSession struct {
+	model      model.Model
+	files      []string // Added with /add, in order
+	turns      []chatTurn
+	memory     []model.MemoryEntry // Given to every prompt
+	remember   memory.Manager      // Where turns and notes are stored, if anywhere
+	retrieve   func(ctx context.Context, query string) (*CommandContext, []Source, error)
+	summarizer *SummarizeCommand
+}
+
+// Execute runs a chat session reading from in and writing to out
+func (c *ChatCommand) Execute(ctx context.Context, in io.Reader, out io.Writer) error {
+	mdl, err := c.GetModel(ctx)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeModel, "Execute", "failed to get model")
+	}
+
+	session := &chatSession{
+		model: mdl,
+		retrieve: func(ctx context.Context, query string) (*CommandContext, []Source, error) {
+			return retrieveContext(ctx, mdl, query, nil, c.Top)
+		},
+		summarizer: c.summarizer,
+	}
+	if session.memory, err = NewInputHandler(c.GetCommonFlags()).GetMemoryContext(); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInput, "Execute", "failed to get memory context")
+	}
+	if session.remember, err = memory.NewManager(); err != nil {
+		logger.Warn("chat turns will not be stored in memory", "error", err)
+	}
+	if _, err := session.add(c.Files); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Chatting with %s about this repository. /help lists commands, /exit ends the session.\n", mdl.Name())
+	return session.run(ctx, in, out)
+}
+
+// run reads questions and commands until /exit or the end of in
+func (s *chatSession) run(ctx context.Context, in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	for {
+		fmt.Fprint(out, "\n> ")
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return errors.Wrap(err, errors.ErrorTypeInput, "run", "failed to read input")
+		}
+		input := strings.TrimSpace(line)
+
+		var turnErr error
+		switch {
+		case input == "":
+		case strings.HasPrefix(input, "/"):
+			var done bool
+			if done, turnErr = s.command(input, out); done {
+				return nil
+			}
+		default:
+			turnErr = s.ask(ctx, input, out)
+		}
+		// A failed turn should not end the session
+		if turnErr != nil {
+			fmt.Fprint(out, errors.FormatForUser(turnErr, Verbose()))
+		}
+
+		if err == io.EOF {
+			fmt.Fprintln(out)
+			return nil
+		}
+	}
+}
+
+// command runs a /command, reporting whether it ends the session
+func (s *chatSession) command(input string, out io.Writer) (bool, error) {
+	name, rest, _ := strings.Cut(input, " ")
+	args := strings.Fields(rest)
+
+	switch name {
+	case "/exit", "/quit":
+		return true, nil
+	case "/help":
+		fmt.Fprint(out, chatHelp)
+	case "/add":
+		if len(args) == 0 {
+			return false, errors.ValidationError("command", "/add needs a file, directory or glob")
+		}
+		added, err := s.add(args)
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprintf(out, "Added %d files (%d in the conversation)\n", len(added), len(s.files))
+	case "/drop":
+		dropped := s.drop(args)
+		fmt.Fprintf(out, "Dropped %d files (%d in the conversation)\n", dropped, len(s.files))
+	case "/files":
+		if len(s.files) == 0 {
+			fmt.Fprintln(out, "No files added; questions are answered from the repository's index")
+		}
+		for _, file := range s.files {
+			fmt.Fprintf(out, "  %s\n", file)
+		}
+	case "/remember":
+		note := strings.TrimSpace(rest)
+		if note == "" {
+			return false, errors.ValidationError("command", "/remember needs a note")
+		}
+		if s.remember == nil {
+			return false, errors.New(errors.ErrorTypeConfig, "command", "memory is unavailable")
+		}
+		if err := s.remember.StoreContext(note, "chat"); err != nil {
+			return false, err
+		}
+		fmt.Fprintln(out, "Saved to memory")
+	case "/clear":
+		s.turns = nil
+		fmt.Fprintln(out, "Conversation cleared")
+	default:
+		return false, errors.ValidationError("command", fmt.Sprintf("unknown command: %s", name)).
+			WithHint("/help lists the commands")
+	}
+	return false, nil
+}
+
+// add adds files, and the source files below directories, to the
+// conversation, returning those not already in it
+func (s *chatSession) add(patterns []string) ([]string, error) {
+	var added []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return added, errors.ValidationError("add", fmt.Sprintf("invalid pattern: %s", pattern))
+		}
+		if len(matches) == 0 {
+			return added, errors.New(errors.ErrorTypeInput, "add", fmt.Sprintf("file not found: %s", pattern))
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return added, errors.Wrap(err, errors.ErrorTypeFS, "add", fmt.Sprintf("failed to read %s", match))
+			}
+			expanded := []string{match}
+			if info.IsDir() {
+				if expanded, err = s.summarizer.collectSourceFiles(match); err != nil {
+					return added, err
+				}
+			}
+			for _, file := range expanded {
+				file = filepath.Clean(file)
+				if !slices.Contains(s.files, file) {
+					s.files = append(s.files, file)
+					added = append(added, file)
+				}
+			}
+		}
+	}
+	return added, nil
+}
+
+// drop removes the given files, or those below the given directories,
+// from the conversation; no paths removes all of them
+func (s *chatSession) drop(paths []string) int {
+	before := len(s.files)
+	if len(paths) == 0 {
+		s.files = nil
+		return before
+	}
+	s.files = slices.DeleteFunc(s.files, func(file string) bool {
+		for _, path := range paths {
+			path = filepath.Clean(path)
+			if file == path || strings.HasPrefix(file, path+string(filepath.Separator)) {
+				return true
+			}
+			if matched, _ := filepath.Match(path, file); matched {
+				return true
+			}
+		}
+		return false
+	})
+	return before - len(s.files)
+}
+
+// ask answers a question in the context of the conversation so far
+func (s *chatSession) ask(ctx context.Context, question string, out io.Writer) error {
+	start := time.Now()
+	prompt, sources, err := s.prompt(ctx, question)
+	if err != nil {
+		return err
+	}
+
+	// Print the answer as it arrives
+	fmt.Fprintln(out)
+	stream := newAnswerStream(out, func(text string) string { return theme.Render(out, applyGlossary(text)) })
+	response, err := model.Stream(ctx, s.model, prompt, stream.Write)
+	stream.Close()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeModel, "ask", "model execution failed")
+	}
+	answer := strings.TrimSpace(applyGlossary(response.Response))
+	s.turns = append(s.turns, chatTurn{Question: question, Answer: answer})
+	if len(sources) > 0 {
+		fmt.Fprintln(out, "\nSources:")
+		for _, source := range sources {
+			fmt.Fprintf(out, "  %s\n", source)
+		}
+	}
+
+	if s.remember != nil {
+		if err := s.remember.StoreSession("chat", fmt.Sprintf("Question: %s", question), response, time.Since(start)); err != nil {
+			logger.Warn("failed to store chat turn in memory", "error", err)
+		}
+	}
+	return nil
+}
+
+// prompt builds the prompt for a question: the recent conversation, then
+// the added files or, without any, the repository excerpts most relevant
+// to the question
+func (s *chatSession) prompt(ctx context.Context, question string) (model.PromptInput, []Source, error) {
+	var systemPrompt strings.Builder
+	systemPrompt.WriteString("You are an AI assistant in a conversation with a developer about their codebase. ")
+	systemPrompt.WriteString("Answer the latest question, using the earlier conversation for context. ")
+	systemPrompt.WriteString("Provide clear, concise explanations grounded in the code provided, and say so when it does not contain the answer.")
+
+	var userPrompt strings.Builder
+	turns := s.turns
+	if len(turns) > maxChatTurns {
+		turns = turns[len(turns)-maxChatTurns:]
+	}
+	if len(turns) > 0 {
+		userPrompt.WriteString("Conversation so far:\n\n")
+		for _, turn := range turns {
+			userPrompt.WriteString(fmt.Sprintf("User: %s\n\nAssistant: %s\n\n", turn.Question, turn.Answer))
+		}
+	}
+	userPrompt.WriteString(fmt.Sprintf("Question: %s\n", question))
+
+	var files []model.FileContent
+	var sources []Source
+	if len(s.files) > 0 {
+		// Files are read every turn, so edits between questions are seen
+		for _, path := range s.files {
+			content, err := s.summarizer.readFile(path)
+			if err != nil {
+				return model.PromptInput{}, nil, errors.Wrap(err, errors.ErrorTypeFS, "prompt",
+					fmt.Sprintf("failed to read %s", path)).WithHint("remove it with /drop")
+			}
+			files = append(files, model.FileContent{Path: path, Content: content, Type: "code"})
+		}
+		userPrompt.WriteString("\nThe files added to the conversation are attached.\n")
+	} else {
+		// Follow-up questions often lean on the previous one
+		query := question
+		if len(s.turns) > 0 {
+			query = s.turns[len(s.turns)-1].Question + "\n" + question
+		}
+		inputCtx, found, err := s.retrieve(ctx, query)
+		if err != nil {
+			logger.Warn("answering without repository excerpts", "error", err)
+		} else {
+			sources = found
+			systemPrompt.WriteString(" Cite the numbered repository excerpts you rely on as [n] after each claim.")
+			userPrompt.WriteString("\nRelevant excerpts from the repository:\n\n")
+			// Excerpts are fenced one by one as they are retrieved
+			userPrompt.WriteString(inputCtx.Input)
+		}
+	}
+	systemPrompt.WriteString("\n\n" + model.UntrustedContentRule)
+
+	return model.PromptInput{
+		SystemPrompt: glossary.Instruct(systemPrompt.String()),
+		UserPrompt:   userPrompt.String(),
+		Files:        files,
+		Memory:       s.memory,
+		MaxTokens:    4000,
+		Temperature:  0.1,
+	}, sources, nil
+}
+
+// CreateCobraCommand creates the cobra command for chat
+func (c *ChatCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "chat",
+		Short: "Talk about the codebase in an interactive session",
+		Long: `Start an interactive session for a multi-turn conversation about the
+codebase. Each question is answered with the conversation so far, so
+follow-up questions work as they would with a colleague.
+
+Questions are answered from the files added with /add or --files, read
+afresh every turn. Without any, the most relevant code is retrieved from
+the repository's embeddings index, as sigil ask does. Each turn is stored in
+memory, and /remember saves a note there; --include-memory gives the
+session the recent memory entries. /help lists the commands.`,
+		Example: `  sigil chat
+  sigil chat --files internal/auth --include-memory`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.Execute(cmd.Context(), os.Stdin, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&c.Files, "files", nil, "Files, directories or globs to start the conversation with")
+	cmd.Flags().IntVar(&c.Top, "top", c.Top, "Number of code excerpts retrieved for each question without added files")
+	cmd.Flags().StringVarP(&c.ModelFlag, "model", "m", "", "Model to use (overrides config)")
+	cmd.Flags().BoolVar(&c.IncludeMemoryFlag, "include-memory", false, "Include memory context")
+	cmd.Flags().IntVar(&c.MemoryDepthFlag, "memory-depth", 5, "Number of memory entries to include")
+
+	return cmd
+}
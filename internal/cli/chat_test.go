@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/model"
+)
+
+func TestChatSession_run(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "auth.go"), []byte("package auth\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "auth_test.go"), []byte("package auth_test\n"), 0644))
+
+	mdl := &scriptedModel{responses: []string{"Login checks the password.", "In auth_test.go."}}
+	retrievals := 0
+	session := &chatSession{
+		model: mdl,
+		retrieve: func(ctx context.Context, query string) (*CommandContext, []Source, error) {
+			retrievals++
+			return nil, nil, errors.New(errors.ErrorTypeInput, "retrieve", "no index")
+		},
+		summarizer: NewSummarizeCommand(),
+	}
+
+	input := strings.Join([]string{
+		"/add " + dir,
+		"How does login work?",
+		"/drop " + filepath.Join(dir, "auth_test.go"),
+		"/files",
+		"Where is it tested?",
+		"/bogus",
+		"/exit",
+		"never read",
+	}, "\n")
+	var out bytes.Buffer
+	require.NoError(t, session.run(context.Background(), strings.NewReader(input), &out))
+
+	assert.Contains(t, out.String(), "Added 2 files (2 in the conversation)")
+	assert.Contains(t, out.String(), "Dropped 1 files (1 in the conversation)")
+	assert.Contains(t, out.String(), "unknown command: /bogus")
+	assert.Equal(t, 0, retrievals, "added files replace retrieval")
+
+	require.Len(t, mdl.prompts, 2)
+	assert.Len(t, mdl.prompts[0].Files, 2)
+	assert.NotContains(t, mdl.prompts[0].UserPrompt, "Conversation so far")
+	second := mdl.prompts[1]
+	require.Len(t, second.Files, 1)
+	assert.Equal(t, filepath.Join(dir, "auth.go"), second.Files[0].Path)
+	assert.Contains(t, second.UserPrompt, "User: How does login work?\n\nAssistant: Login checks the password.")
+	assert.Contains(t, second.UserPrompt, "Question: Where is it tested?")
+	assert.Len(t, session.turns, 2)
+}
+
+func TestChatSession_prompt_Retrieval(t *testing.T) {
+	var queries []string
+	session := &chatSession{
+		turns: []chatTurn{{Question: "What caches tokens?", Answer: "The token cache."}},
+		retrieve: func(ctx context.Context, query string) (*CommandContext, []Source, error) {
+			queries = append(queries, query)
+			return &CommandContext{InputType: InputTypeRepository, Input: "[1] cache.go:1-9\n"},
+				[]Source{{ID: 1, Path: "cache.go", StartLine: 1, EndLine: 9}}, nil
+		},
+		summarizer: NewSummarizeCommand(),
+	}
+
+	prompt, sources, err := session.prompt(context.Background(), "How is it invalidated?")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"What caches tokens?\nHow is it invalidated?"}, queries, "follow-ups are retrieved with the previous question")
+	assert.Len(t, sources, 1)
+	assert.Contains(t, prompt.UserPrompt, "[1] cache.go:1-9")
+	assert.Contains(t, prompt.SystemPrompt, "Cite the numbered repository excerpts")
+	assert.Contains(t, prompt.SystemPrompt, model.UntrustedContentRule)
+}
+
+func TestChatSession_add(t *testing.T) {
+	session := &chatSession{summarizer: NewSummarizeCommand()}
+	_, err := session.add([]string{filepath.Join(t.TempDir(), "missing.go")})
+	assert.ErrorContains(t, err, "file not found")
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// configureOrchestrator connects the orchestrator to the user for
+// clarification questions, low-confidence confirmations and progress
+func configureOrchestrator(orchestrator *agent.DefaultOrchestrator) {
+	orchestrator.SetClarifyFunc(clarifyFromUser(answersFile, os.Stdin))
+	orchestrator.SetConfirmFunc(confirmLowConfidence(os.Stdin))
+	renderProgress(orchestrator, os.Stderr)
+}
+
+// clarifyFromUser answers agent questions from the answers file at path and
+// asks for the rest when in is a terminal. It returns nil when neither is
+// available, so tasks needing clarification end incomplete.
+func clarifyFromUser(path string, in *os.File) agent.ClarifyFunc {
+	interactive := isTerminal(in)
+	if path == "" && !interactive {
+		return nil
+	}
+
+	return func(_ context.Context, questions []agent.Question) ([]agent.Answer, error) {
+		var preset map[string]string
+		if path != "" {
+			var err error
+			if preset, err = loadAnswers(path); err != nil {
+				return nil, err
+			}
+		}
+
+		reader := bufio.NewReader(in)
+		answers := make([]agent.Answer, 0, len(questions))
+		for _, question := range questions {
+			text, ok := lookupAnswer(preset, question)
+			if !ok {
+				if !interactive {
+					return nil, errors.ValidationError("clarify", fmt.Sprintf("%s has no answer for %q", path, question.Text)).
+						WithHint(fmt.Sprintf("add an entry keyed by %q or by the question text", question.ID))
+				}
+				var err error
+				if text, err = askQuestion(reader, question); err != nil {
+					return nil, err
+				}
+			}
+			answers = append(answers, agent.Answer{Question: question, Text: text})
+		}
+		return answers, nil
+	}
+}
+
+// loadAnswers reads a YAML or JSON mapping of question ID or text to answer
+func loadAnswers(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "loadAnswers", "failed to read answers file")
+	}
+
+	var answers map[string]string
+	if err := yaml.Unmarshal(data, &answers); err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeInput, "loadAnswers",
+			fmt.Sprintf("invalid answers file %s: expected a mapping of question to answer", path))
+	}
+	return answers, nil
+}
+
+// lookupAnswer finds the answer to a question by ID or by its text
+func lookupAnswer(answers map[string]string, question agent.Question) (string, bool) {
+	if answer, ok := answers[question.ID]; ok {
+		return answer, true
+	}
+	for key, answer := range answers {
+		if strings.EqualFold(strings.TrimSpace(key), question.Text) {
+			return answer, true
+		}
+	}
+	return "", false
+}
+
+// askQuestion reads the answer to one question interactively
+func askQuestion(reader *bufio.Reader, question agent.Question) (string, error) {
+	fmt.Fprintf(os.Stderr, "\n%s\n", question.Text)
+	if len(question.Options) > 0 {
+		fmt.Fprintf(os.Stderr, "Options: %s\n", strings.Join(question.Options, ", "))
+	}
+	fmt.Fprint(os.Stderr, "> ")
+
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", errors.Wrap(err, errors.ErrorTypeInput, "askQuestion", "failed to read answer")
+	}
+	answer := strings.TrimSpace(line)
+	if answer == "" {
+		return "", errors.ValidationError("askQuestion", fmt.Sprintf("no answer given for %q", question.Text))
+	}
+	return answer, nil
+}
+
+// clarificationError reports questions that were left unanswered
+func clarificationError(op string, questions []agent.Question) error {
+	lines := make([]string, len(questions))
+	for i, question := range questions {
+		lines[i] = fmt.Sprintf("  %s: %s", question.ID, question.Text)
+	}
+	return errors.New(errors.ErrorTypeInput, op, "the agents need clarification:\n"+strings.Join(lines, "\n")).
+		WithHint("run interactively or pass --answers with a file answering each question")
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClarifyFromUser_AnswersFile(t *testing.T) {
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	require.NoError(t, err)
+	defer stdin.Close()
+
+	path := filepath.Join(t.TempDir(), "answers.yml")
+	require.NoError(t, os.WriteFile(path, []byte("q1: internal/cache\n\"Should entries expire?\": \"no\"\n"), 0600))
+
+	clarify := clarifyFromUser(path, stdin)
+	require.NotNil(t, clarify)
+
+	answers, err := clarify(context.Background(), []agent.Question{
+		{ID: "q1", Text: "Which package?"},
+		{ID: "q2", Text: "should entries expire?"},
+	})
+	require.NoError(t, err)
+	require.Len(t, answers, 2)
+	assert.Equal(t, "internal/cache", answers[0].Text)
+	assert.Equal(t, "no", answers[1].Text)
+
+	// Unattended runs cannot fall back to asking
+	_, err = clarify(context.Background(), []agent.Question{{ID: "q3", Text: "Which cache size?"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Which cache size?")
+}
+
+func TestClarifyFromUser_NotTerminal(t *testing.T) {
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	require.NoError(t, err)
+	defer stdin.Close()
+
+	assert.Nil(t, clarifyFromUser("", stdin))
+}
+
+func TestClarificationError(t *testing.T) {
+	err := clarificationError("executeReview", []agent.Question{{ID: "q1", Text: "Which package?"}})
+
+	assert.Contains(t, err.Error(), "q1: Which package?")
+	assert.Contains(t, errors.HintOf(err), "--answers")
+}
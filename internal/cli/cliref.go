@@ -0,0 +1,355 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/findings"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// CLI reference output formats
+const (
+	CLIRefFormatMarkdown = "markdown"
+	CLIRefFormatMan      = "man"
+	CLIRefFormatRST      = "rst"
+)
+
+// helpTimeout bounds each help invocation of an introspected binary
+const helpTimeout = 10 * time.Second
+
+var (
+	// helpSection matches a section title in cobra help output
+	helpSection = regexp.MustCompile(`^(Usage|Aliases|Examples|Available Commands|Additional Commands|Flags|Global Flags|Additional help topics):$`)
+
+	// helpCommand matches an entry of the Available Commands section
+	helpCommand = regexp.MustCompile(`^\s+(\S+)\s+(.*)$`)
+
+	// helpFlag matches a flag line: shorthand, name, type and usage
+	helpFlag = regexp.MustCompile(`^\s+(?:-(\w), )?--([\w-]+)(?: (\w+))?\s+(.*)$`)
+
+	// helpDefault matches the default value cobra appends to flag usage
+	helpDefault = regexp.MustCompile(`\s*\(default (.*)\)$`)
+)
+
+// cliFlag is a documented command-line flag
+type cliFlag struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Default   string `json:"default,omitempty"`
+	Usage     string `json:"usage"`
+}
+
+// cliCommand is a documented command and its subcommands
+type cliCommand struct {
+	Path           string        `json:"path"`
+	Usage          string        `json:"usage"`
+	Short          string        `json:"short"`
+	Long           string        `json:"long,omitempty"`
+	Example        string        `json:"example,omitempty"`
+	Flags          []cliFlag     `json:"flags,omitempty"`
+	InheritedFlags []cliFlag     `json:"inherited_flags,omitempty"`
+	Children       []*cliCommand `json:"children,omitempty"`
+	Parent         *cliCommand   `json:"-"`
+}
+
+// Name returns the last word of the command path
+func (c *cliCommand) Name() string {
+	fields := strings.Fields(c.Path)
+	return fields[len(fields)-1]
+}
+
+// walk visits the command and its descendants depth first
+func (c *cliCommand) walk(visit func(*cliCommand)) {
+	visit(c)
+	for _, child := range c.Children {
+		child.walk(visit)
+	}
+}
+
+// commandFromCobra converts a cobra command tree, leaving out hidden
+// commands and the generated help and completion commands
+func commandFromCobra(cmd *cobra.Command, parent *cliCommand) *cliCommand {
+	doc := &cliCommand{
+		Path:    cmd.CommandPath(),
+		Usage:   cmd.UseLine(),
+		Short:   cmd.Short,
+		Long:    strings.TrimSpace(cmd.Long),
+		Example: strings.TrimRight(cmd.Example, "\n "),
+		Parent:  parent,
+	}
+	doc.Flags = flagsFromSet(cmd.NonInheritedFlags())
+	doc.InheritedFlags = flagsFromSet(cmd.InheritedFlags())
+
+	for _, child := range cmd.Commands() {
+		if !child.IsAvailableCommand() || child.Name() == "help" || child.Name() == "completion" {
+			continue
+		}
+		doc.Children = append(doc.Children, commandFromCobra(child, doc))
+	}
+	return doc
+}
+
+// flagsFromSet lists the visible flags of a flag set other than help
+func flagsFromSet(set *pflag.FlagSet) []cliFlag {
+	var flags []cliFlag
+	set.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden || flag.Name == "help" {
+			return
+		}
+		// Name types and unquote usage the way cobra's help output does
+		typ, usage := pflag.UnquoteUsage(flag)
+		flags = append(flags, cliFlag{
+			Name:      flag.Name,
+			Shorthand: flag.Shorthand,
+			Type:      typ,
+			Default:   flag.DefValue,
+			Usage:     usage,
+		})
+	})
+	return flags
+}
+
+// commandFromBinary introspects a cobra-based binary by walking its help
+// output, running "<binary> <path> --help" for every command it lists.
+// short is the description the parent listed the command with.
+func commandFromBinary(ctx context.Context, binary string, args []string, short string, parent *cliCommand) (*cliCommand, error) {
+	helpCtx, cancel := context.WithTimeout(ctx, helpTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(helpCtx, binary, append(append([]string{}, args...), "--help")...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeInput, "commandFromBinary",
+			fmt.Sprintf("failed to run %s %s --help: %s", binary, strings.Join(args, " "), strings.TrimSpace(stderr.String())))
+	}
+
+	path := strings.Join(append([]string{filepath.Base(binary)}, args...), " ")
+	doc, subcommands := parseHelp(path, stdout.String())
+	doc.Parent = parent
+	if short != "" {
+		doc.Short = short
+	}
+
+	for _, sub := range subcommands {
+		if sub.Name == "help" || sub.Name == "completion" {
+			continue
+		}
+		child, err := commandFromBinary(ctx, binary, append(append([]string{}, args...), sub.Name), sub.Short, doc)
+		if err != nil {
+			return nil, err
+		}
+		doc.Children = append(doc.Children, child)
+	}
+	return doc, nil
+}
+
+// helpEntry is a subcommand listed in help output
+type helpEntry struct {
+	Name  string
+	Short string
+}
+
+// parseHelp reads cobra's default help output: the description, then
+// titled sections for usage, subcommands, examples and flags
+func parseHelp(path, help string) (*cliCommand, []helpEntry) {
+	doc := &cliCommand{Path: path}
+	var subcommands []helpEntry
+	var description []string
+	var examples []string
+
+	section := ""
+	for _, line := range strings.Split(help, "\n") {
+		// cobra prints Usage first, so titles in the description are text
+		if match := helpSection.FindStringSubmatch(line); match != nil && (section != "" || match[1] == "Usage") {
+			section = match[1]
+			continue
+		}
+
+		switch section {
+		case "":
+			description = append(description, line)
+		case "Usage":
+			if trimmed := strings.TrimSpace(line); trimmed != "" && doc.Usage == "" {
+				doc.Usage = trimmed
+			}
+		case "Examples":
+			examples = append(examples, line)
+		case "Available Commands":
+			if match := helpCommand.FindStringSubmatch(line); match != nil {
+				subcommands = append(subcommands, helpEntry{Name: match[1], Short: match[2]})
+			}
+		case "Flags", "Global Flags":
+			match := helpFlag.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			flag := cliFlag{Shorthand: match[1], Name: match[2], Type: match[3], Usage: strings.TrimSpace(match[4])}
+			if flag.Name == "help" {
+				continue
+			}
+			if def := helpDefault.FindStringSubmatch(flag.Usage); def != nil {
+				flag.Default = strings.Trim(def[1], `"`)
+				flag.Usage = strings.TrimSpace(helpDefault.ReplaceAllString(flag.Usage, ""))
+			}
+			if section == "Flags" {
+				doc.Flags = append(doc.Flags, flag)
+			} else {
+				doc.InheritedFlags = append(doc.InheritedFlags, flag)
+			}
+		}
+	}
+
+	// The first paragraph is the short description when a longer one follows
+	text := strings.TrimSpace(strings.Join(description, "\n"))
+	paragraphs := strings.SplitN(text, "\n\n", 2)
+	doc.Short = strings.ReplaceAll(paragraphs[0], "\n", " ")
+	doc.Long = text
+	doc.Example = strings.TrimRight(strings.Join(trimTrailingBlank(examples), "\n"), " ")
+	return doc, subcommands
+}
+
+// CLIRefCommand generates reference documentation for a cobra command tree
+type CLIRefCommand struct {
+	*BaseCommand
+	Binary    string
+	Format    string
+	OutputDir string
+	Check     bool
+	// HistoryPath is the run history --check records drift in; empty
+	// records nothing
+	HistoryPath string
+	root        *cobra.Command
+}
+
+// NewCLIRefCommand creates a new CLI reference command
+func NewCLIRefCommand() *CLIRefCommand {
+	return &CLIRefCommand{
+		BaseCommand: NewBaseCommand("cli", "Generate a CLI reference",
+			"Generate reference documentation for every command and flag."),
+		Format:    CLIRefFormatMarkdown,
+		OutputDir: filepath.Join("docs", "cli"),
+	}
+}
+
+// Execute runs the CLI reference command
+func (c *CLIRefCommand) Execute(ctx context.Context) error {
+	logger.Info("starting CLI reference generation", "binary", c.Binary, "format", c.Format, "output", c.OutputDir)
+
+	if err := c.validateInputs(); err != nil {
+		return err
+	}
+
+	var tree *cliCommand
+	if c.Binary != "" {
+		var err error
+		if tree, err = commandFromBinary(ctx, c.Binary, nil, "", nil); err != nil {
+			return err
+		}
+	} else {
+		tree = commandFromCobra(c.root, nil)
+	}
+
+	files := renderCLIReference(tree, c.Format)
+
+	if c.Check {
+		return c.checkFiles(files)
+	}
+
+	if err := os.MkdirAll(c.OutputDir, 0755); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Execute", "failed to create output directory")
+	}
+	for _, name := range sortedKeys(files) {
+		if err := os.WriteFile(filepath.Join(c.OutputDir, name), []byte(files[name]), 0644); err != nil {
+			return errors.Wrap(err, errors.ErrorTypeFS, "Execute", fmt.Sprintf("failed to write %s", name))
+		}
+	}
+	fmt.Printf("CLI reference (%d pages) written to: %s\n", len(files), c.OutputDir)
+	return nil
+}
+
+// validateInputs validates the command inputs
+func (c *CLIRefCommand) validateInputs() error {
+	switch c.Format {
+	case CLIRefFormatMarkdown, CLIRefFormatMan, CLIRefFormatRST:
+	default:
+		return errors.New(errors.ErrorTypeInput, "validateInputs",
+			fmt.Sprintf("invalid format: %s (valid: %s, %s, %s)", c.Format, CLIRefFormatMarkdown, CLIRefFormatMan, CLIRefFormatRST))
+	}
+	if c.Binary == "" && c.root == nil {
+		return errors.New(errors.ErrorTypeInternal, "validateInputs", "no command tree to document")
+	}
+	return nil
+}
+
+// checkFiles reports pages that are missing or differ from what would be
+// generated, so CI can keep the reference in sync
+func (c *CLIRefCommand) checkFiles(files map[string]string) error {
+	var stale []string
+	for _, name := range sortedKeys(files) {
+		current, err := os.ReadFile(filepath.Join(c.OutputDir, name))
+		if err != nil || string(current) != files[name] {
+			stale = append(stale, name)
+		}
+	}
+	if c.HistoryPath != "" {
+		if err := recordMetrics(c.HistoryPath, "doc cli", map[string]float64{findings.MetricDocDrift: float64(len(stale))}); err != nil {
+			logger.Warn("failed to record documentation drift", "error", err)
+		}
+	}
+	if len(stale) > 0 {
+		return errors.New(errors.ErrorTypeValidation, "checkFiles",
+			fmt.Sprintf("CLI reference is out of date: %s", strings.Join(stale, ", "))).
+			WithHint("run sigil doc cli to regenerate it")
+	}
+	fmt.Printf("CLI reference is up to date: %s\n", c.OutputDir)
+	return nil
+}
+
+// CreateCobraCommand creates the cobra command for doc cli
+func (c *CLIRefCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cli",
+		Short: "Generate a CLI reference",
+		Long: `Generate reference documentation for every command: usage, description,
+examples, flags and inherited flags, one page per command with links
+between parents and subcommands.
+
+By default sigil documents itself. With --binary, any cobra-based program is
+documented by walking its --help output. --check compares the pages on disk
+with what would be generated and fails when they differ, keeping the
+reference in sync in CI, and records how many pages drifted in the run
+history for sigil trends.`,
+		Example: `  sigil doc cli
+  sigil doc cli --format man --output man/man1
+  sigil doc cli --binary ./bin/tool --output docs/tool
+  sigil doc cli --check`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.root = cmd.Root()
+			return c.Execute(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVar(&c.Binary, "binary", "", "Cobra-based binary to document instead of sigil")
+	cmd.Flags().StringVar(&c.Format, "format", CLIRefFormatMarkdown, "Output format (markdown, man, rst)")
+	cmd.Flags().StringVarP(&c.OutputDir, "output", "o", c.OutputDir, "Output directory")
+	cmd.Flags().BoolVar(&c.Check, "check", false, "Fail if the reference on disk is out of date instead of writing it")
+	cmd.Flags().StringVar(&c.HistoryPath, "history", findings.DefaultHistoryPath, "Run history --check records drift in for sigil trends (empty to disable)")
+
+	return cmd
+}
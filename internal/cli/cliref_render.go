@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderCLIReference renders one page per command, keyed by file name
+func renderCLIReference(tree *cliCommand, format string) map[string]string {
+	files := make(map[string]string)
+	tree.walk(func(cmd *cliCommand) {
+		switch format {
+		case CLIRefFormatMan:
+			files[cliPageName(cmd, "-")+".1"] = renderManPage(cmd)
+		case CLIRefFormatRST:
+			files[cliPageName(cmd, "_")+".rst"] = renderRSTPage(cmd)
+		default:
+			files[cliPageName(cmd, "_")+".md"] = renderMarkdownPage(cmd)
+		}
+	})
+	return files
+}
+
+// cliPageName joins the command path with sep, as in sigil_doc_readme
+func cliPageName(cmd *cliCommand, sep string) string {
+	return strings.Join(strings.Fields(cmd.Path), sep)
+}
+
+// seeAlso lists the parent and subcommands a page links to
+func seeAlso(cmd *cliCommand) []*cliCommand {
+	var related []*cliCommand
+	if cmd.Parent != nil {
+		related = append(related, cmd.Parent)
+	}
+	return append(related, cmd.Children...)
+}
+
+// flagUsages formats flags as an aligned block like cobra's help output
+func flagUsages(flags []cliFlag) string {
+	lines := make([]string, len(flags))
+	width := 0
+	for i, flag := range flags {
+		line := "      --" + flag.Name
+		if flag.Shorthand != "" {
+			line = "  -" + flag.Shorthand + ", --" + flag.Name
+		}
+		if flag.Type != "" && flag.Type != "bool" {
+			line += " " + flag.Type
+		}
+		lines[i] = line
+		width = max(width, len(line))
+	}
+
+	var result strings.Builder
+	for i, flag := range flags {
+		usage := flag.Usage
+		if flag.Default != "" && flag.Default != "false" && flag.Default != "[]" && flag.Default != "0" {
+			usage += fmt.Sprintf(" (default %s)", flag.Default)
+		}
+		result.WriteString(fmt.Sprintf("%-*s   %s\n", width, lines[i], usage))
+	}
+	return result.String()
+}
+
+// renderMarkdownPage renders a command as Markdown
+func renderMarkdownPage(cmd *cliCommand) string {
+	var page strings.Builder
+	page.WriteString(fmt.Sprintf("## %s\n\n%s\n\n", cmd.Path, cmd.Short))
+
+	page.WriteString("### Synopsis\n\n")
+	if cmd.Long != "" && cmd.Long != cmd.Short {
+		page.WriteString(cmd.Long + "\n\n")
+	}
+	page.WriteString(fmt.Sprintf("```\n%s\n```\n\n", cmd.Usage))
+
+	if cmd.Example != "" {
+		page.WriteString(fmt.Sprintf("### Examples\n\n```\n%s\n```\n\n", cmd.Example))
+	}
+	if len(cmd.Flags) > 0 {
+		page.WriteString(fmt.Sprintf("### Options\n\n```\n%s```\n\n", flagUsages(cmd.Flags)))
+	}
+	if len(cmd.InheritedFlags) > 0 {
+		page.WriteString(fmt.Sprintf("### Options inherited from parent commands\n\n```\n%s```\n\n", flagUsages(cmd.InheritedFlags)))
+	}
+
+	if related := seeAlso(cmd); len(related) > 0 {
+		page.WriteString("### See also\n\n")
+		for _, other := range related {
+			page.WriteString(fmt.Sprintf("* [%s](%s.md) - %s\n", other.Path, cliPageName(other, "_"), other.Short))
+		}
+	}
+	return strings.TrimRight(page.String(), "\n") + "\n"
+}
+
+// renderRSTPage renders a command as reStructuredText
+func renderRSTPage(cmd *cliCommand) string {
+	var page strings.Builder
+	label := cliPageName(cmd, "_")
+	page.WriteString(fmt.Sprintf(".. _%s:\n\n%s\n%s\n\n%s\n\n", label, cmd.Path, strings.Repeat("-", len(cmd.Path)), cmd.Short))
+
+	heading := func(title string) {
+		page.WriteString(fmt.Sprintf("%s\n%s\n\n", title, strings.Repeat("~", len(title))))
+	}
+	literal := func(text string) {
+		page.WriteString("::\n\n")
+		for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+			page.WriteString(strings.TrimRight("  "+line, " ") + "\n")
+		}
+		page.WriteString("\n")
+	}
+
+	heading("Synopsis")
+	if cmd.Long != "" && cmd.Long != cmd.Short {
+		page.WriteString(cmd.Long + "\n\n")
+	}
+	literal(cmd.Usage)
+
+	if cmd.Example != "" {
+		heading("Examples")
+		literal(cmd.Example)
+	}
+	if len(cmd.Flags) > 0 {
+		heading("Options")
+		literal(flagUsages(cmd.Flags))
+	}
+	if len(cmd.InheritedFlags) > 0 {
+		heading("Options inherited from parent commands")
+		literal(flagUsages(cmd.InheritedFlags))
+	}
+
+	if related := seeAlso(cmd); len(related) > 0 {
+		heading("See also")
+		for _, other := range related {
+			page.WriteString(fmt.Sprintf("* :ref:`%s <%s>` - %s\n", other.Path, cliPageName(other, "_"), other.Short))
+		}
+	}
+	return strings.TrimRight(page.String(), "\n") + "\n"
+}
+
+// renderManPage renders a command as a section 1 man page
+func renderManPage(cmd *cliCommand) string {
+	var page strings.Builder
+	root := strings.Fields(cmd.Path)[0]
+	page.WriteString(fmt.Sprintf(".TH \"%s\" \"1\" \"\" \"%s\" \"%s Manual\"\n", strings.ToUpper(cliPageName(cmd, "-")), root, root))
+
+	page.WriteString(".SH NAME\n")
+	page.WriteString(fmt.Sprintf("%s \\- %s\n", roffEscape(cliPageName(cmd, "-")), roffEscape(cmd.Short)))
+
+	page.WriteString(".SH SYNOPSIS\n")
+	page.WriteString(fmt.Sprintf("\\fB%s\\fP\n", roffEscape(cmd.Usage)))
+
+	page.WriteString(".SH DESCRIPTION\n")
+	description := cmd.Long
+	if description == "" {
+		description = cmd.Short
+	}
+	page.WriteString(roffText(description))
+
+	manFlags := func(title string, flags []cliFlag) {
+		if len(flags) == 0 {
+			return
+		}
+		page.WriteString(".SH " + title + "\n")
+		for _, flag := range flags {
+			page.WriteString(".TP\n")
+			name := "\\fB\\-\\-" + roffEscape(flag.Name) + "\\fP"
+			if flag.Shorthand != "" {
+				name = "\\fB\\-" + flag.Shorthand + "\\fP, " + name
+			}
+			if flag.Type != "" && flag.Type != "bool" {
+				name += "=\\fI" + flag.Type + "\\fP"
+			}
+			page.WriteString(name + "\n")
+			usage := flag.Usage
+			if flag.Default != "" && flag.Default != "false" && flag.Default != "[]" && flag.Default != "0" {
+				usage += fmt.Sprintf(" (default %s)", flag.Default)
+			}
+			page.WriteString(roffEscape(usage) + "\n")
+		}
+	}
+	manFlags("OPTIONS", cmd.Flags)
+	manFlags("OPTIONS INHERITED FROM PARENT COMMANDS", cmd.InheritedFlags)
+
+	if cmd.Example != "" {
+		page.WriteString(".SH EXAMPLES\n.PP\n.nf\n")
+		page.WriteString(roffText(cmd.Example))
+		page.WriteString(".fi\n")
+	}
+
+	if related := seeAlso(cmd); len(related) > 0 {
+		page.WriteString(".SH SEE ALSO\n")
+		refs := make([]string, len(related))
+		for i, other := range related {
+			refs[i] = "\\fB" + cliPageName(other, "-") + "\\fP(1)"
+		}
+		page.WriteString(strings.Join(refs, ", ") + "\n")
+	}
+	return page.String()
+}
+
+// roffEscape escapes backslashes and hyphens for roff
+func roffEscape(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, `\`, `\e`), "-", `\-`)
+}
+
+// roffText escapes a block of text, guarding lines that start with a
+// control character and turning blank lines into paragraph breaks
+func roffText(text string) string {
+	var result strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		line = roffEscape(line)
+		switch {
+		case strings.TrimSpace(line) == "":
+			result.WriteString(".PP\n")
+		case strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'"):
+			result.WriteString(`\&` + line + "\n")
+		default:
+			result.WriteString(line + "\n")
+		}
+	}
+	return result.String()
+}
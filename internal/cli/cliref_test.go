@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/findings"
+)
+
+// newCLIRefFixture builds a small cobra tree with a hidden command and
+// persistent flags
+func newCLIRefFixture() *cobra.Command {
+	root := &cobra.Command{Use: "tool", Short: "Does tool things"}
+	root.PersistentFlags().BoolP("verbose", "v", false, "Verbose output")
+
+	serve := &cobra.Command{
+		Use:     "serve [dir]",
+		Short:   "Serve a directory",
+		Long:    "Serve a directory over HTTP.\n\nFiles are served read-only.",
+		Example: "  tool serve ./public --port 8080",
+		Run:     func(cmd *cobra.Command, args []string) {},
+	}
+	serve.Flags().IntP("port", "p", 8080, "Port to listen on")
+	serve.Flags().StringSlice("header", nil, "Extra `header` to send")
+
+	hidden := &cobra.Command{Use: "debug", Hidden: true, Run: func(cmd *cobra.Command, args []string) {}}
+
+	root.AddCommand(serve, hidden)
+	root.InitDefaultHelpCmd()
+	return root
+}
+
+func TestCommandFromCobra(t *testing.T) {
+	tree := commandFromCobra(newCLIRefFixture(), nil)
+
+	assert.Equal(t, "tool", tree.Path)
+	require.Len(t, tree.Children, 1, "hidden and help commands are left out")
+
+	serve := tree.Children[0]
+	assert.Equal(t, "tool serve", serve.Path)
+	assert.Equal(t, "tool serve [dir] [flags]", serve.Usage)
+	assert.Same(t, tree, serve.Parent)
+	assert.Equal(t, []cliFlag{
+		{Name: "header", Type: "header", Default: "[]", Usage: "Extra header to send"},
+		{Name: "port", Shorthand: "p", Type: "int", Default: "8080", Usage: "Port to listen on"},
+	}, serve.Flags)
+	assert.Equal(t, []cliFlag{
+		{Name: "verbose", Shorthand: "v", Default: "false", Usage: "Verbose output"},
+	}, serve.InheritedFlags)
+}
+
+func TestParseHelp(t *testing.T) {
+	help := `Serve a directory over HTTP:
+
+the files are served read-only.
+
+Usage:
+  tool serve [dir] [flags]
+  tool serve [command]
+
+Examples:
+  tool serve ./public --port 8080
+
+Available Commands:
+  reload      Reload the served files
+  help        Help about any command
+
+Flags:
+  -h, --help           help for serve
+  -p, --port int       Port to listen on (default 8080)
+      --root string    Directory to serve (default "public")
+
+Global Flags:
+  -v, --verbose   Verbose output
+
+Use "tool serve [command] --help" for more information about a command.
+`
+	doc, subcommands := parseHelp("tool serve", help)
+
+	assert.Equal(t, "Serve a directory over HTTP:", doc.Short)
+	assert.Equal(t, "Serve a directory over HTTP:\n\nthe files are served read-only.", doc.Long)
+	assert.Equal(t, "tool serve [dir] [flags]", doc.Usage)
+	assert.Equal(t, "  tool serve ./public --port 8080", doc.Example)
+	assert.Equal(t, []helpEntry{
+		{Name: "reload", Short: "Reload the served files"},
+		{Name: "help", Short: "Help about any command"},
+	}, subcommands)
+	assert.Equal(t, []cliFlag{
+		{Name: "port", Shorthand: "p", Type: "int", Default: "8080", Usage: "Port to listen on"},
+		{Name: "root", Type: "string", Default: "public", Usage: "Directory to serve"},
+	}, doc.Flags)
+	assert.Equal(t, []cliFlag{
+		{Name: "verbose", Shorthand: "v", Usage: "Verbose output"},
+	}, doc.InheritedFlags)
+}
+
+func TestParseHelp_SectionTitlesInDescription(t *testing.T) {
+	help := "Generate docs.\n\nExamples:\n  listed in the description\n\nUsage:\n  tool doc [flags]\n"
+
+	doc, _ := parseHelp("tool doc", help)
+
+	assert.Contains(t, doc.Long, "listed in the description")
+	assert.Empty(t, doc.Example)
+	assert.Equal(t, "tool doc [flags]", doc.Usage)
+}
+
+func TestRenderCLIReference(t *testing.T) {
+	tree := commandFromCobra(newCLIRefFixture(), nil)
+
+	t.Run("markdown", func(t *testing.T) {
+		files := renderCLIReference(tree, CLIRefFormatMarkdown)
+
+		assert.Equal(t, []string{"tool.md", "tool_serve.md"}, sortedKeys(files))
+		page := files["tool_serve.md"]
+		assert.Contains(t, page, "## tool serve\n\nServe a directory\n")
+		assert.Contains(t, page, "Files are served read-only.")
+		assert.Contains(t, page, "### Examples\n\n```\n  tool serve ./public --port 8080\n```")
+		assert.Contains(t, page, "  -p, --port int        Port to listen on (default 8080)\n")
+		assert.Contains(t, page, "### Options inherited from parent commands")
+		assert.Contains(t, page, "* [tool](tool.md) - Does tool things")
+		assert.Contains(t, files["tool.md"], "* [tool serve](tool_serve.md) - Serve a directory")
+	})
+
+	t.Run("rst", func(t *testing.T) {
+		files := renderCLIReference(tree, CLIRefFormatRST)
+
+		assert.Equal(t, []string{"tool.rst", "tool_serve.rst"}, sortedKeys(files))
+		page := files["tool_serve.rst"]
+		assert.Contains(t, page, ".. _tool_serve:\n\ntool serve\n----------\n")
+		assert.Contains(t, page, "Options\n~~~~~~~\n\n::\n\n")
+		assert.Contains(t, page, "* :ref:`tool <tool>` - Does tool things")
+	})
+
+	t.Run("man", func(t *testing.T) {
+		files := renderCLIReference(tree, CLIRefFormatMan)
+
+		assert.Equal(t, []string{"tool-serve.1", "tool.1"}, sortedKeys(files))
+		page := files["tool-serve.1"]
+		assert.Contains(t, page, `.TH "TOOL-SERVE" "1"`)
+		assert.Contains(t, page, "tool\\-serve \\- Serve a directory\n")
+		assert.Contains(t, page, "\\fB\\-p\\fP, \\fB\\-\\-port\\fP=\\fIint\\fP\n")
+		assert.Contains(t, page, ".SH SEE ALSO\n\\fBtool\\fP(1)\n")
+	})
+}
+
+func TestRoffText(t *testing.T) {
+	assert.Equal(t, "one\\-two\n.PP\n\\&.hidden\nC:\\epath\n", roffText("one-two\n\n.hidden\nC:\\path"))
+}
+
+func TestCLIRefCommand_Check(t *testing.T) {
+	dir := t.TempDir()
+	cmd := NewCLIRefCommand()
+	cmd.OutputDir = dir
+	cmd.root = newCLIRefFixture()
+
+	cmd.Check = true
+	err := cmd.Execute(context.Background())
+	require.Error(t, err, "missing pages are out of date")
+	assert.Contains(t, err.Error(), "tool.md")
+
+	cmd.Check = false
+	require.NoError(t, cmd.Execute(context.Background()))
+
+	cmd.Check = true
+	require.NoError(t, cmd.Execute(context.Background()))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tool_serve.md"), []byte("stale"), 0644))
+	err = cmd.Execute(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tool_serve.md")
+	assert.NotContains(t, err.Error(), "tool.md,")
+}
+
+func TestCLIRefCommand_CheckRecordsDrift(t *testing.T) {
+	dir := t.TempDir()
+	cmd := NewCLIRefCommand()
+	cmd.OutputDir = dir
+	cmd.root = newCLIRefFixture()
+	cmd.Check = true
+	cmd.HistoryPath = filepath.Join(dir, "history.json")
+
+	require.Error(t, cmd.Execute(context.Background()))
+
+	history, err := findings.LoadHistory(cmd.HistoryPath)
+	require.NoError(t, err)
+	require.Len(t, history.Runs, 1)
+	assert.Equal(t, "doc cli", history.Runs[0].Command)
+	assert.Greater(t, history.Runs[0].Metrics[findings.MetricDocDrift], 0.0)
+}
+
+func TestCLIRefCommand_InvalidFormat(t *testing.T) {
+	cmd := NewCLIRefCommand()
+	cmd.root = newCLIRefFixture()
+	cmd.Format = "pdf"
+
+	err := cmd.Execute(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid format")
+}
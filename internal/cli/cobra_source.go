@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cobraFlagMethods are the pflag methods that define a flag, with the index
+// of the argument holding its name
+var cobraFlagMethods = flagMethods()
+
+// flagMethods builds the pflag method table for every flag type
+func flagMethods() map[string]int {
+	methods := make(map[string]int)
+	types := []string{"Bool", "BoolSlice", "Count", "Duration", "DurationSlice", "Float32", "Float64",
+		"Int", "Int32", "Int64", "IntSlice", "String", "StringArray", "StringSlice", "StringToString",
+		"Uint", "Uint32", "Uint64"}
+	for _, typ := range types {
+		methods[typ] = 0
+		methods[typ+"P"] = 0
+		methods[typ+"Var"] = 1
+		methods[typ+"VarP"] = 1
+	}
+	return methods
+}
+
+// cobraCommandInfo is a cobra command as defined in Go source
+type cobraCommandInfo struct {
+	Use     string
+	Short   string
+	Example string
+	Flags   []string
+	File    string
+}
+
+// Name returns the command name, the first word of its usage line
+func (c cobraCommandInfo) Name() string {
+	if fields := strings.Fields(c.Use); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// extractCobraCommands finds the cobra.Command literals in the Go files
+// below dir. Flags defined in the same function are attributed to the
+// command when the function defines only one.
+func extractCobraCommands(dir string, skipDir func(name string) bool) ([]cobraCommandInfo, error) {
+	var commands []cobraCommandInfo
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && skipDir(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			// Unparseable files cannot define commands we could document
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+
+		for _, decl := range file.Decls {
+			var found []cobraCommandInfo
+			var flags []string
+			ast.Inspect(decl, func(node ast.Node) bool {
+				switch n := node.(type) {
+				case *ast.CompositeLit:
+					if isCobraCommandType(n.Type) {
+						info := cobraCommandFromLiteral(n)
+						info.File = filepath.ToSlash(rel)
+						if info.Use != "" {
+							found = append(found, info)
+						}
+					}
+				case *ast.CallExpr:
+					if name, ok := cobraFlagName(n); ok {
+						flags = append(flags, name)
+					}
+				}
+				return true
+			})
+			if len(found) == 1 {
+				found[0].Flags = flags
+			}
+			commands = append(commands, found...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(commands, func(i, j int) bool { return commands[i].Use < commands[j].Use })
+	return commands, nil
+}
+
+// isCobraCommandType reports whether a literal's type is cobra.Command
+func isCobraCommandType(expr ast.Expr) bool {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != "Command" {
+		return false
+	}
+	pkg, ok := selector.X.(*ast.Ident)
+	return ok && pkg.Name == "cobra"
+}
+
+// cobraCommandFromLiteral reads the string fields of a cobra.Command literal
+func cobraCommandFromLiteral(lit *ast.CompositeLit) cobraCommandInfo {
+	var info cobraCommandInfo
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		value, ok := stringLiteral(kv.Value)
+		if !ok {
+			continue
+		}
+		switch key.Name {
+		case "Use":
+			info.Use = value
+		case "Short":
+			info.Short = value
+		case "Example":
+			info.Example = value
+		}
+	}
+	return info
+}
+
+// cobraFlagName returns the flag name defined by a call such as
+// cmd.Flags().StringVar(&v, "name", ...)
+func cobraFlagName(call *ast.CallExpr) (string, bool) {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	index, ok := cobraFlagMethods[selector.Sel.Name]
+	if !ok || len(call.Args) <= index {
+		return "", false
+	}
+	inner, ok := selector.X.(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	flagSet, ok := inner.Fun.(*ast.SelectorExpr)
+	if !ok || (flagSet.Sel.Name != "Flags" && flagSet.Sel.Name != "PersistentFlags") {
+		return "", false
+	}
+	return stringLiteral(call.Args[index])
+}
+
+// stringLiteral evaluates a string literal or a concatenation of them
+func stringLiteral(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		value, err := strconv.Unquote(e.Value)
+		return value, err == nil
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		left, ok := stringLiteral(e.X)
+		if !ok {
+			return "", false
+		}
+		right, ok := stringLiteral(e.Y)
+		return left + right, ok
+	case *ast.ParenExpr:
+		return stringLiteral(e.X)
+	}
+	return "", false
+}
@@ -3,11 +3,16 @@ package cli
 
 import (
 	"context"
+	"net/http"
 
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/cache"
 	"github.com/dshills/sigil/internal/config"
 	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
 	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/secrets"
 	"github.com/spf13/cobra"
 )
 
@@ -141,6 +146,11 @@ func (b *BaseCommand) GetCommonFlags() CommonFlags {
 	}
 }
 
+// HasInputSource reports whether an input source flag was given
+func (b *BaseCommand) HasInputSource() bool {
+	return b.FileFlag != "" || b.DirFlag != "" || b.GitFlag || b.StdinFlag
+}
+
 // ValidateFlags validates common flag combinations
 func (b *BaseCommand) ValidateFlags() error {
 	// Count input sources
@@ -205,11 +215,16 @@ func (b *BaseCommand) GetModel(ctx context.Context) (model.Model, error) {
 		cfg := getConfig()
 		modelStr = cfg.Models.Lead
 	}
+	return loadModel(modelStr)
+}
 
+// loadModel returns the model a provider:model string names, paced by its
+// configured quota and with the configured prompt cache
+func loadModel(modelStr string) (model.Model, error) {
 	// Parse model string
 	provider, modelName, err := model.ParseModelString(modelStr)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrorTypeModel, "GetModel", "invalid model string")
+		return nil, errors.Wrap(err, errors.ErrorTypeModel, "loadModel", "invalid model string")
 	}
 
 	// Get or create model
@@ -232,14 +247,134 @@ func (b *BaseCommand) GetModel(ctx context.Context) (model.Model, error) {
 				config.Options = providerCfg.Options
 			}
 		}
+		if name, ok := secrets.ParseRef(config.APIKey); ok {
+			if config.APIKey, err = secrets.Default().Get(name); err != nil {
+				return nil, err
+			}
+		}
 
 		mdl, err = model.CreateModel(config)
 		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrorTypeModel, "GetModel", "failed to create model")
+			return nil, errors.Wrap(err, errors.ErrorTypeModel, "loadModel", "failed to create model")
 		}
 	}
 
-	return mdl, nil
+	return withPromptCache(withPacing(mdl, provider, modelName)), nil
+}
+
+// withPacing paces a model by the quota configured for it, or else for its
+// provider, and retries the requests its provider refuses for rate limits.
+// Models under a provider quota share one pacer.
+func withPacing(mdl model.Model, provider, modelName string) model.Model {
+	quotas := getConfig().Models.Quotas
+	name := provider + ":" + modelName
+	quota, ok := quotas[name]
+	if !ok {
+		name = provider
+		quota = quotas[provider]
+	}
+	pacer := model.PacerFor(name, model.Quota{RequestsPerMinute: quota.RPM, TokensPerMinute: quota.TPM})
+	return model.NewPacedModel(mdl, pacer)
+}
+
+// withPromptCache wraps a model with the configured prompt cache, if enabled
+func withPromptCache(mdl model.Model) model.Model {
+	store, ok := sharedCache()
+	if !ok {
+		return mdl
+	}
+	return cache.NewCachedModel(mdl, store)
+}
+
+// withEmbeddingCache wraps an embedder with the configured cache, if
+// enabled, so the embeddings of the index are shared as prompts are
+func withEmbeddingCache(embedder model.Embedder) model.Embedder {
+	store, ok := sharedCache()
+	if !ok {
+		return embedder
+	}
+	return cache.NewCachedEmbedder(embedder, store)
+}
+
+// sharedCache returns the configured cache store, if enabled. Entries are
+// keyed by their full input, so they are scoped to the repository only and
+// reused across commits.
+func sharedCache() (*cache.Store, bool) {
+	return cacheStore(false)
+}
+
+// cacheStore returns the configured cache store, if enabled, scoped to the
+// repository and, with perCommit, its HEAD commit
+func cacheStore(perCommit bool) (*cache.Store, bool) {
+	cfg := getConfig()
+	if !cfg.Cache.Enabled {
+		return nil, false
+	}
+
+	backend, err := cache.NewBackend(cfg.Cache)
+	if err != nil {
+		logger.Warn("cache disabled", "error", err)
+		return nil, false
+	}
+
+	namespace := cache.Namespace{}
+	if repo, err := git.NewRepository(""); err == nil {
+		if ns, err := cache.NamespaceFromRepo(repo); err == nil {
+			namespace.Repo = ns.Repo
+			if perCommit {
+				namespace.Commit = ns.Commit
+			}
+		}
+	}
+
+	return cache.NewStore(backend, namespace), true
+}
+
+// publishArtifact stores an artifact of the run, such as a report or a task
+// record, in the configured cache under the repository and HEAD commit so
+// teammates and CI can fetch it. It returns a URL to share the artifact
+// when the backend offers one.
+func publishArtifact(ctx context.Context, group, name string, data []byte) string {
+	store, ok := cacheStore(true)
+	if !ok {
+		return ""
+	}
+	if err := store.Put(ctx, cache.KindArtifact, data, group, name); err != nil {
+		logger.Warn("failed to publish artifact", "group", group, "name", name, "error", err)
+		return ""
+	}
+	if getConfig().Cache.ReadOnly {
+		return ""
+	}
+	url, err := store.SignedURL(http.MethodGet, cache.KindArtifact, group, name)
+	if err != nil {
+		logger.Debug("artifact has no shareable URL", "group", group, "name", name, "error", err)
+		return ""
+	}
+	return url
+}
+
+// fetchArtifact returns an artifact published at the current HEAD commit,
+// if the configured cache holds it
+func fetchArtifact(ctx context.Context, group, name string) ([]byte, bool) {
+	store, ok := cacheStore(true)
+	if !ok {
+		return nil, false
+	}
+	data, found, err := store.Get(ctx, cache.KindArtifact, group, name)
+	if err != nil {
+		logger.Warn("failed to fetch artifact", "group", group, "name", name, "error", err)
+		return nil, false
+	}
+	return data, found
+}
+
+// orchestrationConfig returns the default orchestration settings, keeping
+// confidence calibration in the working directory across runs
+func orchestrationConfig() agent.OrchestrationConfig {
+	config := agent.DefaultOrchestrationConfig()
+	config.Confidence.File = agent.DefaultCalibrationFile
+	return config
 }
 
 // RunPreChecks performs common pre-execution checks
@@ -279,10 +414,11 @@ type CommandContext struct {
 type InputType string
 
 const (
-	InputTypeText      InputType = "text"
-	InputTypeFile      InputType = "file"
-	InputTypeDirectory InputType = "directory"
-	InputTypeGitDiff   InputType = "git-diff"
+	InputTypeText       InputType = "text"
+	InputTypeFile       InputType = "file"
+	InputTypeDirectory  InputType = "directory"
+	InputTypeGitDiff    InputType = "git-diff"
+	InputTypeRepository InputType = "repository"
 )
 
 // OutputFormat represents the output format
@@ -0,0 +1,577 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/glossary"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// maxCommitDiff is the most of the staged diff sent to the model
+const maxCommitDiff = 24000
+
+// commitTypes are the conventional-commit types a message may use
+var commitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// conventionalHeader matches a conventional-commit subject line:
+// type, optional scope, optional breaking marker and description
+var conventionalHeader = regexp.MustCompile(`^([a-z]+)(?:\(([^)]*)\))?(!)?: (.+)$`)
+
+// CommitCommand writes a commit message for the staged changes and commits
+// them
+type CommitCommand struct {
+	*BaseCommand
+	Type         string
+	Scope        string
+	TemplateFile string
+	DryRun       bool
+	QualityPass  bool
+}
+
+// stagedFile is one file in the staged diff
+type stagedFile struct {
+	Path       string
+	Status     string // added, deleted, renamed or modified
+	Insertions int
+	Deletions  int
+}
+
+// commitFields fill the placeholders of a commit message template
+type commitFields struct {
+	Type    string
+	Scope   string
+	Subject string
+	Body    string
+	Files   []stagedFile
+	Branch  string
+	Issue   string
+}
+
+// commitDraft is the message the model proposes
+type commitDraft struct {
+	Type    string `json:"type"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// NewCommitCommand creates a new commit command
+func NewCommitCommand() *CommitCommand {
+	return &CommitCommand{
+		BaseCommand: NewBaseCommand("commit", "Commit staged changes with a generated message",
+			"Write a conventional-commit message for the staged changes and commit them."),
+	}
+}
+
+// Execute runs the commit command
+func (c *CommitCommand) Execute(ctx context.Context) error {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to open git repository")
+	}
+	diff, err := repo.GetStagedDiff()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to read staged changes")
+	}
+	if strings.TrimSpace(diff) == "" {
+		return errors.ValidationError("Execute", "nothing is staged").WithHint("stage changes with git add first")
+	}
+	if c.Type != "" && !validCommitType(c.Type) {
+		return errors.ValidationError("Execute", fmt.Sprintf("invalid commit type: %s (valid: %s)", c.Type, strings.Join(commitTypes, ", ")))
+	}
+
+	cfg := getConfig().Git.Commit
+	template, err := c.loadTemplate(repo.Root, cfg)
+	if err != nil {
+		return err
+	}
+
+	fields := commitFields{Files: analyzeStagedDiff(diff)}
+	fields.Type = inferCommitType(fields.Files)
+	fields.Scope = inferScope(fields.Files, cfg.Scopes)
+	if branch, err := repo.GetCurrentBranch(); err == nil {
+		fields.Branch = branch
+		fields.Issue = branchIssue(branch)
+	}
+	if c.Scope != "" {
+		fields.Scope = c.Scope
+	}
+	logger.Debug("analyzed staged changes", "files", len(fields.Files), "type", fields.Type, "scope", fields.Scope)
+
+	mdl, err := c.GetModel(ctx)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeModel, "Execute", "failed to get model")
+	}
+	response, err := mdl.RunPrompt(ctx, buildCommitPrompt(repo.Root, diff, fields, cfg))
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeModel, "Execute", "failed to generate commit message")
+	}
+
+	draft := parseCommitDraft(response.Response)
+	switch {
+	case c.Type != "":
+		fields.Type = c.Type
+	case validCommitType(draft.Type):
+		fields.Type = draft.Type
+	case fields.Type == "":
+		fields.Type = "chore"
+	}
+	fields.Subject = applyGlossary(draft.Subject)
+	fields.Body = applyGlossary(draft.Body)
+	if fields.Subject == "" {
+		return errors.New(errors.ErrorTypeModel, "Execute", "the model did not propose a subject")
+	}
+
+	message := renderCommitMessage(template, fields, cfg)
+	if c.QualityPass {
+		quality, err := newQualityChecker(ctx, c.BaseCommand, true)
+		if err != nil {
+			return err
+		}
+		message = c.checkMessage(ctx, quality, message, diff, template, fields, cfg)
+	}
+	if c.DryRun {
+		fmt.Println(message)
+		return nil
+	}
+	if err := repo.Commit(message); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to commit").
+			WithHint("check the output of git commit, for example a failing hook")
+	}
+	fmt.Println(message)
+	return nil
+}
+
+// checkMessage runs the quality pass over a rendered message and renders
+// the revision through the template again, so its limits still hold
+func (c *CommitCommand) checkMessage(ctx context.Context, quality *qualityChecker, message, diff, template string, fields commitFields, cfg config.CommitConfig) string {
+	instructions := fmt.Sprintf("A conventional-commit message describing the staged diff: an imperative subject of at most %d characters "+
+		"with no trailing period, then a body explaining what changed and why, wrapped at %d columns.", cfg.MaxSubject, cfg.BodyWrap)
+	sources := []model.FileContent{{Path: "staged.diff", Content: truncateDiff(diff), Type: "diff"}}
+
+	revised := quality.check(ctx, "commit message", instructions, message, sources)
+	if revised == message {
+		return message
+	}
+	draft := parseCommitDraft(revised)
+	if draft.Subject == "" {
+		return message
+	}
+	if c.Type == "" && validCommitType(draft.Type) {
+		fields.Type = draft.Type
+	}
+	fields.Subject = draft.Subject
+	fields.Body = draft.Body
+	return renderCommitMessage(template, fields, cfg)
+}
+
+// loadTemplate returns the message template: the --template file, then the
+// repository's template file, then the configured template
+func (c *CommitCommand) loadTemplate(root string, cfg config.CommitConfig) (string, error) {
+	file := c.TemplateFile
+	explicit := file != ""
+	if !explicit {
+		file = cfg.TemplateFile
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) && !explicit {
+			file = filepath.Join(root, file)
+		}
+		data, err := os.ReadFile(file)
+		switch {
+		case err == nil:
+			return strings.TrimRight(string(data), "\n"), nil
+		case explicit || !os.IsNotExist(err):
+			return "", errors.Wrap(err, errors.ErrorTypeFS, "loadTemplate", fmt.Sprintf("failed to read commit template %s", file))
+		}
+	}
+	if cfg.Template != "" {
+		return cfg.Template, nil
+	}
+	return "{type}({scope}): {subject}\n\n{body}", nil
+}
+
+// CreateCobraCommand creates the cobra command for commit
+func (c *CommitCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "commit",
+		Short: "Commit staged changes with a generated message",
+		Long: `Write a conventional-commit message for the staged changes and commit them.
+
+The type and scope are inferred from the files touched: the scope comes from
+git.commit.scopes in .sigil/config.yml, which maps path prefixes or globs to
+scopes, or else from the directory the changes share. The message follows
+the repository's template, read from .sigil/commit_template or
+git.commit.template, whose placeholders {type}, {scope}, {subject}, {body},
+{files}, {stats}, {branch} and {issue} are filled from the diff and the
+branch name. Subjects are shortened to git.commit.max_subject characters and
+bodies wrapped at git.commit.body_wrap. --quality-pass has a reviewer
+critique the message for accuracy, completeness and formatting and the
+model revise it once when the critique finds problems.`,
+		Example: `  sigil commit
+  sigil commit --dry-run
+  sigil commit --quality-pass
+  sigil commit --type fix --scope parser
+  sigil commit --template .github/commit_template`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.Execute(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVar(&c.Type, "type", "", "Commit type (default: inferred)")
+	cmd.Flags().StringVar(&c.Scope, "scope", "", "Commit scope (default: inferred from the paths touched)")
+	cmd.Flags().StringVar(&c.TemplateFile, "template", "", "Message template file (default: .sigil/commit_template)")
+	cmd.Flags().BoolVar(&c.DryRun, "dry-run", false, "Print the message without committing")
+	cmd.Flags().BoolVar(&c.QualityPass, "quality-pass", false, "Critique the message against a rubric and revise it once before committing")
+	cmd.Flags().StringVarP(&c.ModelFlag, "model", "m", "", "Model to use (overrides config)")
+	return cmd
+}
+
+// buildCommitPrompt asks the model for the type, subject and body of the
+// message, declaring the files the staged diff of the repository at root
+// touches for the consent gate
+func buildCommitPrompt(root, diff string, fields commitFields, cfg config.CommitConfig) model.PromptInput {
+	var system strings.Builder
+	system.WriteString("You write git commit messages following the Conventional Commits specification. ")
+	system.WriteString(fmt.Sprintf("Choose a type from: %s. ", strings.Join(commitTypes, ", ")))
+	system.WriteString("The subject is an imperative summary in lower case without a trailing period")
+	if cfg.MaxSubject > 0 {
+		limit := cfg.MaxSubject - len(fields.Type) - len(fields.Scope) - 4
+		if limit < 20 {
+			limit = 20
+		}
+		system.WriteString(fmt.Sprintf(", at most %d characters", limit))
+	}
+	system.WriteString(". The body explains what changed and why in plain sentences or - bullets; leave it empty for trivial changes. ")
+	system.WriteString(`Reply with only a JSON object: {"type": "...", "subject": "...", "body": "..."}.`)
+	system.WriteString("\n\n" + model.UntrustedContentRule)
+
+	var user strings.Builder
+	if fields.Type != "" {
+		user.WriteString(fmt.Sprintf("The files touched suggest the type %s.\n", fields.Type))
+	}
+	if fields.Scope != "" {
+		user.WriteString(fmt.Sprintf("The scope is %s; do not repeat it in the subject.\n", fields.Scope))
+	}
+	user.WriteString(fmt.Sprintf("%s\n\n", diffStats(fields.Files)))
+	user.WriteString("Staged diff:\n")
+	user.WriteString(model.FenceUntrusted("staged diff", truncateDiff(diff)))
+
+	return model.PromptInput{
+		SystemPrompt: glossary.Instruct(system.String()),
+		UserPrompt:   user.String(),
+		Sources:      map[string][]string{"staged diff": diffSources(root, diff)},
+		MaxTokens:    1000,
+		Temperature:  0.2,
+	}
+}
+
+// truncateDiff cuts a diff to the most sent to the model
+func truncateDiff(diff string) string {
+	if len(diff) > maxCommitDiff {
+		return diff[:maxCommitDiff] + "\n... (diff truncated)"
+	}
+	return diff
+}
+
+// parseCommitDraft reads the model's proposal, falling back to treating
+// the response as a message when it is not JSON
+func parseCommitDraft(response string) commitDraft {
+	candidates := []string{strings.TrimSpace(response)}
+	for _, block := range fencedJSON.FindAllStringSubmatch(response, -1) {
+		candidates = append([]string{block[1]}, candidates...)
+	}
+	if start, end := strings.Index(response, "{"), strings.LastIndex(response, "}"); start >= 0 && end > start {
+		candidates = append(candidates, response[start:end+1])
+	}
+	for _, candidate := range candidates {
+		var draft commitDraft
+		if err := json.Unmarshal([]byte(candidate), &draft); err == nil && draft.Subject != "" {
+			draft.Type = strings.ToLower(strings.TrimSpace(draft.Type))
+			draft.Subject = cleanSubject(draft.Subject)
+			draft.Body = strings.TrimSpace(draft.Body)
+			return draft
+		}
+	}
+
+	subject, body, _ := strings.Cut(strings.TrimSpace(response), "\n")
+	var draft commitDraft
+	if match := conventionalHeader.FindStringSubmatch(strings.TrimSpace(subject)); match != nil {
+		draft.Type = match[1]
+		subject = match[4]
+	}
+	draft.Subject = cleanSubject(subject)
+	draft.Body = strings.TrimSpace(body)
+	return draft
+}
+
+// cleanSubject strips a repeated conventional prefix and trailing period
+func cleanSubject(subject string) string {
+	subject = strings.TrimSpace(subject)
+	if match := conventionalHeader.FindStringSubmatch(subject); match != nil && validCommitType(match[1]) {
+		subject = match[4]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(subject, "."))
+}
+
+// validCommitType reports whether a type is a conventional-commit type
+func validCommitType(commitType string) bool {
+	for _, candidate := range commitTypes {
+		if commitType == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeStagedDiff lists the files of a diff with their line counts
+func analyzeStagedDiff(diff string) []stagedFile {
+	var files []stagedFile
+	var current *stagedFile
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			filePath := line[strings.LastIndex(line, " b/")+3:]
+			files = append(files, stagedFile{Path: filePath, Status: "modified"})
+			current = &files[len(files)-1]
+		case current == nil:
+		case strings.HasPrefix(line, "new file mode"):
+			current.Status = "added"
+		case strings.HasPrefix(line, "deleted file mode"):
+			current.Status = "deleted"
+		case strings.HasPrefix(line, "rename from "):
+			current.Status = "renamed"
+		case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+		case strings.HasPrefix(line, "+"):
+			current.Insertions++
+		case strings.HasPrefix(line, "-"):
+			current.Deletions++
+		}
+	}
+	return files
+}
+
+// inferCommitType returns the type the kinds of files touched imply, or ""
+// when the change itself has to decide
+func inferCommitType(files []stagedFile) string {
+	kinds := map[string]bool{}
+	for _, file := range files {
+		kinds[fileKind(file.Path)] = true
+	}
+	if len(kinds) != 1 {
+		return ""
+	}
+	for kind := range kinds {
+		if kind != "source" {
+			return kind
+		}
+	}
+	return ""
+}
+
+// fileKind classifies a path as docs, test, ci, build or source
+func fileKind(filePath string) string {
+	base := path.Base(filePath)
+	ext := strings.ToLower(path.Ext(base))
+	dirs := "/" + path.Dir(filePath) + "/"
+	switch {
+	case strings.HasPrefix(filePath, ".github/workflows/"), base == ".gitlab-ci.yml", strings.HasPrefix(filePath, ".circleci/"):
+		return "ci"
+	case strings.HasSuffix(base, "_test.go"), strings.Contains(base, ".test."), strings.Contains(base, ".spec."),
+		strings.HasPrefix(base, "test_") && ext == ".py", strings.Contains(dirs, "/testdata/"),
+		strings.Contains(dirs, "/tests/"), strings.Contains(dirs, "/__tests__/"):
+		return "test"
+	case ext == ".md", ext == ".rst", ext == ".adoc", strings.Contains(dirs, "/docs/"), base == "LICENSE":
+		return "docs"
+	case base == "go.mod", base == "go.sum", base == "package.json", base == "package-lock.json",
+		base == "yarn.lock", base == "Makefile", base == "Dockerfile", base == "pyproject.toml":
+		return "build"
+	}
+	return "source"
+}
+
+// genericDirs are directories too broad to name a scope
+var genericDirs = map[string]bool{"internal": true, "pkg": true, "src": true, "lib": true, "cmd": true, "app": true}
+
+// inferScope returns the scope shared by every file touched, or "" when
+// they span several
+func inferScope(files []stagedFile, scopes map[string]string) string {
+	scope := ""
+	for i, file := range files {
+		fileScope := scopeFor(file.Path, scopes)
+		if i > 0 && fileScope != scope {
+			return ""
+		}
+		scope = fileScope
+	}
+	return scope
+}
+
+// scopeFor returns the scope of one path: the longest matching mapping,
+// or the first directory below the generic ones
+func scopeFor(filePath string, scopes map[string]string) string {
+	best, scope := -1, ""
+	for key, value := range scopes {
+		prefix := strings.TrimSuffix(key, "/")
+		matched := filePath == prefix || strings.HasPrefix(filePath, prefix+"/")
+		if !matched {
+			matched, _ = path.Match(key, filePath)
+		}
+		if !matched {
+			matched, _ = path.Match(key, path.Dir(filePath))
+		}
+		if matched && len(key) > best {
+			best, scope = len(key), value
+		}
+	}
+	if best >= 0 {
+		return scope
+	}
+
+	dir := path.Dir(filePath)
+	if dir == "." {
+		return ""
+	}
+	for _, part := range strings.Split(dir, "/") {
+		if !genericDirs[part] && !strings.HasPrefix(part, ".") {
+			return part
+		}
+	}
+	return ""
+}
+
+// issuePatterns find an issue reference in a branch name: a tracker key
+// such as ABC-123, or a number set off by separators
+var issuePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`[A-Z][A-Z0-9]+-\d+`),
+	regexp.MustCompile(`(?:^|[/_-])(\d+)(?:[/_-]|$)`),
+}
+
+// branchIssue returns the issue a branch name refers to
+func branchIssue(branch string) string {
+	if match := issuePatterns[0].FindString(branch); match != "" {
+		return match
+	}
+	if match := issuePatterns[1].FindStringSubmatch(branch); match != nil {
+		return "#" + match[1]
+	}
+	return ""
+}
+
+// diffStats summarizes the size of the change
+func diffStats(files []stagedFile) string {
+	insertions, deletions := 0, 0
+	for _, file := range files {
+		insertions += file.Insertions
+		deletions += file.Deletions
+	}
+	noun := "files"
+	if len(files) == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("%d %s changed, %d insertions(+), %d deletions(-)", len(files), noun, insertions, deletions)
+}
+
+// renderCommitMessage fills a template, shortens the subject line to the
+// limit and wraps the rest of the message
+func renderCommitMessage(template string, fields commitFields, cfg config.CommitConfig) string {
+	if fields.Scope == "" {
+		template = strings.ReplaceAll(template, "({scope})", "")
+	}
+	files := make([]string, 0, len(fields.Files))
+	for _, file := range fields.Files {
+		files = append(files, "- "+file.Path)
+	}
+
+	fill := func(subject string) string {
+		return strings.NewReplacer(
+			"{type}", fields.Type,
+			"{scope}", fields.Scope,
+			"{subject}", subject,
+			"{body}", fields.Body,
+			"{files}", strings.Join(files, "\n"),
+			"{stats}", diffStats(fields.Files),
+			"{branch}", fields.Branch,
+			"{issue}", fields.Issue,
+		).Replace(template)
+	}
+
+	message := fill(fields.Subject)
+	header, _, _ := strings.Cut(message, "\n")
+	if over := utf8.RuneCountInString(header) - cfg.MaxSubject; cfg.MaxSubject > 0 && over > 0 {
+		message = fill(shortenSubject(fields.Subject, utf8.RuneCountInString(fields.Subject)-over))
+	}
+
+	header, rest, _ := strings.Cut(message, "\n")
+	lines := []string{strings.TrimRight(header, " ")}
+	for _, line := range strings.Split(rest, "\n") {
+		lines = append(lines, wrapLine(strings.TrimRight(line, " "), cfg.BodyWrap)...)
+	}
+	message = strings.Join(lines, "\n")
+	for strings.Contains(message, "\n\n\n") {
+		message = strings.ReplaceAll(message, "\n\n\n", "\n\n")
+	}
+	return strings.TrimSpace(message)
+}
+
+// shortenSubject cuts a subject to at most limit characters at a word
+// boundary
+func shortenSubject(subject string, limit int) string {
+	runes := []rune(subject)
+	if limit <= 0 {
+		return ""
+	}
+	if len(runes) <= limit {
+		return subject
+	}
+	cut := string(runes[:limit])
+	if i := strings.LastIndex(cut, " "); i > 0 && runes[limit] != ' ' {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:-")
+}
+
+// wrapLine wraps one line of a message body at width, indenting the
+// continuation of a bullet under its text. Indented lines are kept as they
+// are, since they are usually code.
+func wrapLine(line string, width int) []string {
+	if width <= 0 || utf8.RuneCountInString(line) <= width || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+		return []string{line}
+	}
+	indent := ""
+	if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
+		indent = "  "
+	}
+
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(line) {
+		switch {
+		case current == "":
+			current = word
+			if len(lines) > 0 {
+				current = indent + word
+			}
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width:
+			lines = append(lines, current)
+			current = indent + word
+		default:
+			current += " " + word
+		}
+	}
+	return append(lines, current)
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/consent"
+	"github.com/dshills/sigil/internal/model"
+)
+
+const stagedDiff = `diff --git a/internal/parser/lexer.go b/internal/parser/lexer.go
+index 1111111..2222222 100644
+--- a/internal/parser/lexer.go
++++ b/internal/parser/lexer.go
+@@ -1,3 +1,4 @@
+-old
++new
++added
+diff --git a/internal/parser/token.go b/internal/parser/token.go
+new file mode 100644
+--- /dev/null
++++ b/internal/parser/token.go
+@@ -0,0 +1 @@
++package parser
+`
+
+func TestBuildCommitPrompt_ScopedConsent(t *testing.T) {
+	root := t.TempDir()
+	t.Cleanup(func() {
+		consent.Set("", nil)
+		model.SetGate(nil)
+	})
+	consent.Set(root, &consent.Consent{Providers: []string{"commitgatetest"}, Paths: []string{"internal/**"}})
+	model.SetGate(consent.Check)
+	require.NoError(t, model.RegisterProvider("commitgatetest", samplingFactory{}))
+	mdl, err := model.CreateModel(model.ModelConfig{Provider: "commitgatetest", Model: "commit", Endpoint: "https://api.example.com"})
+	require.NoError(t, err)
+
+	cfg := config.CommitConfig{}
+	_, err = mdl.RunPrompt(context.Background(), buildCommitPrompt(root, stagedDiff, commitFields{}, cfg))
+	assert.NoError(t, err, "a diff inside the consented paths is sent")
+
+	outside := stagedDiff + `diff --git a/vendor/lib.go b/vendor/lib.go
+--- a/vendor/lib.go
++++ b/vendor/lib.go
+@@ -1 +1 @@
+-package lib
++package vendored
+`
+	_, err = mdl.RunPrompt(context.Background(), buildCommitPrompt(root, outside, commitFields{}, cfg))
+	assert.ErrorIs(t, err, consent.ErrOutOfScope, "a diff touching a file outside the consented paths is refused")
+	assert.ErrorContains(t, err, filepath.Join(root, "vendor", "lib.go"))
+}
+
+func TestAnalyzeStagedDiff(t *testing.T) {
+	files := analyzeStagedDiff(stagedDiff)
+	assert.Equal(t, []stagedFile{
+		{Path: "internal/parser/lexer.go", Status: "modified", Insertions: 2, Deletions: 1},
+		{Path: "internal/parser/token.go", Status: "added", Insertions: 1},
+	}, files)
+	assert.Equal(t, "2 files changed, 3 insertions(+), 1 deletions(-)", diffStats(files))
+}
+
+func TestInferScope(t *testing.T) {
+	files := analyzeStagedDiff(stagedDiff)
+	assert.Equal(t, "parser", inferScope(files, nil), "generic directories are skipped")
+
+	scopes := map[string]string{"internal": "core", "internal/parser/": "syntax", "*.md": "docs"}
+	assert.Equal(t, "syntax", inferScope(files, scopes), "the longest mapping wins")
+	assert.Equal(t, "docs", scopeFor("README.md", scopes))
+
+	mixed := append(files, stagedFile{Path: "cmd/sigil/main.go"})
+	assert.Equal(t, "", inferScope(mixed, nil), "changes spanning scopes have none")
+	assert.Equal(t, "", scopeFor("main.go", nil))
+}
+
+func TestInferCommitType(t *testing.T) {
+	assert.Equal(t, "docs", inferCommitType([]stagedFile{{Path: "README.md"}, {Path: "docs/usage.txt"}}))
+	assert.Equal(t, "test", inferCommitType([]stagedFile{{Path: "a/b_test.go"}, {Path: "a/testdata/in.json"}}))
+	assert.Equal(t, "ci", inferCommitType([]stagedFile{{Path: ".github/workflows/ci.yml"}}))
+	assert.Equal(t, "", inferCommitType([]stagedFile{{Path: "main.go"}, {Path: "README.md"}}))
+}
+
+func TestBranchIssue(t *testing.T) {
+	assert.Equal(t, "ABC-123", branchIssue("feature/ABC-123-login"))
+	assert.Equal(t, "#42", branchIssue("fix/42-crash"))
+	assert.Equal(t, "", branchIssue("main"))
+}
+
+func TestParseCommitDraft(t *testing.T) {
+	draft := parseCommitDraft("Here it is:\n```json\n{\"type\": \"Fix\", \"subject\": \"fix(parser): handle empty input.\", \"body\": \"Details\"}\n```")
+	assert.Equal(t, commitDraft{Type: "fix", Subject: "handle empty input", Body: "Details"}, draft)
+
+	draft = parseCommitDraft("feat(cli): add commit command\n\nWrites messages.")
+	assert.Equal(t, commitDraft{Type: "feat", Subject: "add commit command", Body: "Writes messages."}, draft)
+}
+
+func TestRenderCommitMessage(t *testing.T) {
+	cfg := config.CommitConfig{MaxSubject: 40, BodyWrap: 30}
+	fields := commitFields{
+		Type:    "feat",
+		Subject: "add scope inference to the commit command",
+		Body:    "Scopes come from the configured mapping or the directories touched.\n- bullets wrap under their text when long",
+		Files:   []stagedFile{{Path: "internal/cli/commit.go", Insertions: 3}},
+		Issue:   "#7",
+	}
+
+	message := renderCommitMessage("{type}({scope}): {subject}\n\n{body}\n\nRefs: {issue}\n{stats}", fields, cfg)
+	assert.Equal(t, `feat: add scope inference to the commit
+
+Scopes come from the
+configured mapping or the
+directories touched.
+- bullets wrap under their
+  text when long
+
+Refs: #7
+1 file changed, 3
+insertions(+), 0 deletions(-)`, message, "the subject is cut at a word and the body wrapped")
+
+	fields.Scope = "cli"
+	fields.Body = ""
+	message = renderCommitMessage("{type}({scope}): {subject}\n\n{body}\n\n{files}", fields, cfg)
+	assert.Equal(t, "feat(cli): add scope inference to the\n\n- internal/cli/commit.go", message)
+}
+
+func TestCommitCommand_LoadTemplate(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.CommitConfig{Template: "{type}: {subject}", TemplateFile: ".sigil/commit_template"}
+	c := NewCommitCommand()
+
+	template, err := c.loadTemplate(root, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "{type}: {subject}", template, "the configured template is used without a file")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".sigil"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".sigil", "commit_template"), []byte("{subject}\n\n{files}\n"), 0600))
+	template, err = c.loadTemplate(root, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "{subject}\n\n{files}", template)
+
+	c.TemplateFile = filepath.Join(root, "missing")
+	_, err = c.loadTemplate(root, cfg)
+	assert.Error(t, err, "an explicit template must exist")
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// confirmLowConfidence asks on the terminal whether to act on a result the
+// agents are not confident in. It returns nil when in is not a terminal, so
+// unattended runs abstain.
+func confirmLowConfidence(in *os.File) agent.ConfirmFunc {
+	if !isTerminal(in) {
+		return nil
+	}
+	return func(_ context.Context, result *agent.Result, abstention *agent.Abstention) (bool, error) {
+		fmt.Fprintf(os.Stderr, "Agent %s has low confidence in its result (%.2f, floor %.2f).\nProceed anyway? [y/N]: ",
+			result.AgentID, abstention.Confidence, abstention.Floor)
+
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		answer := strings.ToLower(strings.TrimSpace(line))
+		return answer == "y" || answer == "yes", nil
+	}
+}
+
+// abstentionError reports that the orchestrator declined to act on a result
+func abstentionError(op string, abstention *agent.Abstention) error {
+	return errors.New(errors.ErrorTypeModel, op, abstention.Reason).
+		WithHint("add detail to the request or include more files, then try again")
+}
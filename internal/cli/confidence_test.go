@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmLowConfidence_NotTerminal(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "stdin")
+	assert.NoError(t, err)
+	defer file.Close()
+
+	assert.Nil(t, confirmLowConfidence(file))
+}
+
+func TestAbstentionError(t *testing.T) {
+	err := abstentionError("executeReview", &agent.Abstention{Confidence: 0.3, Floor: 0.5, Reason: "confidence 0.30 is below the floor of 0.50"})
+
+	assert.Contains(t, err.Error(), "below the floor")
+	assert.NotEmpty(t, errors.HintOf(err))
+}
@@ -0,0 +1,351 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// PackedFile is a file as included in a context bundle
+type PackedFile struct {
+	agent.FileBudget
+	Language string `json:"language"`
+	Content  string `json:"content,omitempty"`
+}
+
+// ContextBundle is a self-contained description of a task and the code it
+// concerns, sized to fit a model's context window
+type ContextBundle struct {
+	Task        string           `json:"task"`
+	Project     string           `json:"project"`
+	Languages   []string         `json:"languages"`
+	Conventions []string         `json:"conventions"`
+	Commands    []ProjectCommand `json:"commands"`
+	Budget      int              `json:"budget"`
+	Tokens      int              `json:"tokens"`
+	Files       []PackedFile     `json:"files"`
+}
+
+// ContextPackCommand bundles files and project facts for use outside sigil
+type ContextPackCommand struct {
+	*BaseCommand
+	Files      []string
+	Task       string
+	Budget     int
+	Top        int
+	Format     string
+	OutputFile string
+	summarizer *SummarizeCommand
+	onboard    *OnboardCommand
+}
+
+// NewContextPackCommand creates a new context pack command
+func NewContextPackCommand() *ContextPackCommand {
+	return &ContextPackCommand{
+		BaseCommand: NewBaseCommand("pack", "Bundle code and project facts into one paste-ready context",
+			"Produce a single token-budgeted context bundle for external chat tools."),
+		Budget:     agent.DefaultContextConfig().MaxTokens,
+		Top:        defaultRetrievalResults,
+		Format:     FormatMarkdown,
+		summarizer: NewSummarizeCommand(),
+		onboard:    NewOnboardCommand(),
+	}
+}
+
+// Execute runs the context pack command
+func (c *ContextPackCommand) Execute(ctx context.Context) error {
+	logger.Info("starting context pack", "files", len(c.Files), "budget", c.Budget)
+
+	if err := c.validateInputs(); err != nil {
+		return err
+	}
+
+	files, err := c.selectFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	facts, err := c.onboard.detectFacts()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to inspect project")
+	}
+
+	bundle := c.buildBundle(files, facts)
+	formatted, err := c.formatBundle(bundle)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to format bundle")
+	}
+
+	if c.OutputFile == "" {
+		fmt.Print(formatted)
+		return nil
+	}
+	if err := os.WriteFile(c.OutputFile, []byte(formatted), 0600); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Execute",
+			fmt.Sprintf("failed to write output file: %s", c.OutputFile))
+	}
+	fmt.Fprintf(os.Stderr, "Context bundle (%d of %d tokens) written to: %s\n", bundle.Tokens, bundle.Budget, c.OutputFile)
+	return nil
+}
+
+// validateInputs validates the command inputs
+func (c *ContextPackCommand) validateInputs() error {
+	if strings.TrimSpace(c.Task) == "" {
+		return errors.ValidationError("validateInputs", "a task description is required").
+			WithHint(`pass --task "what you want done"`)
+	}
+	if c.Budget < 0 {
+		return errors.ValidationError("validateInputs",
+			fmt.Sprintf("invalid budget: %d (must be 0 or more)", c.Budget))
+	}
+	if c.Format != FormatMarkdown && c.Format != string(OutputFormatJSON) {
+		return errors.ValidationError("validateInputs",
+			fmt.Sprintf("invalid format: %s (valid: %s, %s)", c.Format, FormatMarkdown, OutputFormatJSON))
+	}
+	return nil
+}
+
+// selectFiles reads the requested files, expanding directories to the
+// source files below them. Without --files, the files most relevant to the
+// task are retrieved from the embeddings index, most relevant first.
+func (c *ContextPackCommand) selectFiles(ctx context.Context) ([]agent.FileContext, error) {
+	paths, err := c.expandFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Files) == 0 {
+		if paths, err = c.retrieveFiles(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	files := make([]agent.FileContext, 0, len(paths))
+	for _, path := range paths {
+		content, err := c.summarizer.readFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeFS, "selectFiles",
+				fmt.Sprintf("failed to read file: %s", path))
+		}
+		files = append(files, agent.FileContext{
+			Path:     filepath.ToSlash(path),
+			Content:  content,
+			Language: c.summarizer.detectLanguage(path),
+		})
+	}
+	return files, nil
+}
+
+// expandFiles resolves --files to file paths, keeping their order
+func (c *ContextPackCommand) expandFiles() ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, file := range c.Files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeInput, "expandFiles",
+				fmt.Sprintf("file not found: %s", file))
+		}
+
+		expanded := []string{file}
+		if info.IsDir() {
+			if expanded, err = c.summarizer.collectSourceFiles(file); err != nil {
+				return nil, err
+			}
+		}
+		for _, path := range expanded {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// retrieveFiles returns the files holding the chunks most relevant to the
+// task, using the configured model's embeddings when it has them
+func (c *ContextPackCommand) retrieveFiles(ctx context.Context) ([]string, error) {
+	mdl, err := c.GetModel(ctx)
+	if err != nil {
+		logger.Debug("no model for retrieval, using local embeddings", "error", err)
+		mdl = nil
+	}
+
+	_, sources, err := retrieveContext(ctx, mdl, c.Task, nil, c.Top)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := git.NewRepository("")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeGit, "retrieveFiles", "failed to open repository")
+	}
+	root, err := repo.GetRoot()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeGit, "retrieveFiles", "failed to resolve repository root")
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "retrieveFiles", "failed to resolve working directory")
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		if seen[source.Path] {
+			continue
+		}
+		seen[source.Path] = true
+
+		path := filepath.Join(root, source.Path)
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			path = rel
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// buildBundle fits the files to the budget alongside the project facts
+func (c *ContextPackCommand) buildBundle(files []agent.FileContext, facts *ProjectFacts) *ContextBundle {
+	fitted, report := agent.FitFiles(files, c.Budget)
+
+	bundle := &ContextBundle{
+		Task:        c.Task,
+		Project:     facts.Name,
+		Languages:   facts.languagesByUse(),
+		Conventions: facts.Conventions,
+		Commands:    facts.Commands,
+		Budget:      c.Budget,
+	}
+
+	next := 0
+	for i, budget := range report {
+		packed := PackedFile{FileBudget: budget, Language: files[i].Language}
+		if !budget.Omitted {
+			packed.Content = fitted[next].Content
+			next++
+		}
+		bundle.Tokens += agent.EstimateTokens(packed.Content)
+		bundle.Files = append(bundle.Files, packed)
+	}
+	return bundle
+}
+
+// formatBundle renders the bundle as markdown or JSON
+func (c *ContextPackCommand) formatBundle(bundle *ContextBundle) (string, error) {
+	if c.Format == string(OutputFormatJSON) {
+		data, err := json.MarshalIndent(bundle, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+
+	var result strings.Builder
+	result.WriteString("# Task\n\n")
+	result.WriteString(bundle.Task + "\n\n")
+
+	result.WriteString("# Project\n\n")
+	result.WriteString(fmt.Sprintf("Project: %s\n", bundle.Project))
+	if len(bundle.Languages) > 0 {
+		result.WriteString(fmt.Sprintf("Languages: %s\n", strings.Join(bundle.Languages, ", ")))
+	}
+	if len(bundle.Conventions) > 0 {
+		result.WriteString("\nConventions:\n")
+		for _, convention := range bundle.Conventions {
+			result.WriteString("- " + convention + "\n")
+		}
+	}
+	if len(bundle.Commands) > 0 {
+		result.WriteString("\nBuild and test commands:\n")
+		for _, command := range bundle.Commands {
+			result.WriteString(fmt.Sprintf("- `%s` (%s)\n", command.Command, command.Purpose))
+		}
+	}
+
+	result.WriteString("\n# File Map\n\n")
+	result.WriteString("| File | Tokens | Included |\n|------|--------|----------|\n")
+	for _, file := range bundle.Files {
+		included := "whole"
+		switch {
+		case file.Omitted:
+			included = "omitted"
+		case file.Outlined:
+			included = fmt.Sprintf("outline, ~%d tokens", file.KeptTokens)
+		case file.Trimmed:
+			included = fmt.Sprintf("trimmed to ~%d tokens", file.KeptTokens)
+		}
+		result.WriteString(fmt.Sprintf("| %s | %d | %s |\n", file.Path, file.Tokens, included))
+	}
+
+	result.WriteString("\n# Files\n")
+	for _, file := range bundle.Files {
+		if file.Omitted {
+			continue
+		}
+		result.WriteString(fmt.Sprintf("\n## %s\n\n```%s\n%s\n```\n", file.Path, file.Language, strings.TrimRight(file.Content, "\n")))
+	}
+	return result.String(), nil
+}
+
+// NewContextCommand creates the context command
+func NewContextCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "context",
+		Short: "Prepare code context for use outside sigil",
+		Long: `Prepare code context for use outside sigil.
+
+Bundles are trimmed with the same token budgeting the agents use, so they
+can be pasted into a chat interface or handed to another tool.`,
+		Example: `  # Bundle named files for a task
+  sigil context pack --files internal/cache --task "add TTL expiry to the prompt cache"
+
+  # Let sigil pick the relevant files from the embeddings index
+  sigil context pack --task "why does login fail after a token refresh?" -o context.md`,
+	}
+
+	cmd.AddCommand(NewContextPackCommand().CreateCobraCommand())
+	return cmd
+}
+
+// CreateCobraCommand creates the cobra command for context pack
+func (c *ContextPackCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pack",
+		Short: "Bundle code and project facts into one paste-ready context",
+		Long: `Produce a single context bundle for a task: the task, project conventions
+and build commands, a map of the included files, and their contents trimmed
+to fit a token budget.
+
+Files that fit their share of the budget are kept whole. Larger files keep
+their beginning and end, and when the budget runs short the last listed
+files are left out; the file map shows what happened to each. Without
+--files, the files most relevant to the task are retrieved from the
+repository's embeddings index.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.Execute(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&c.Files, "files", nil, "Files or directories to include, most important first")
+	cmd.Flags().StringVar(&c.Task, "task", "", "Task the context is for")
+	cmd.Flags().IntVar(&c.Budget, "budget", c.Budget, "Token budget for file contents (0 for no limit)")
+	cmd.Flags().IntVar(&c.Top, "top", c.Top, "Index chunks to retrieve when --files is not given")
+	cmd.Flags().StringVar(&c.ModelFlag, "model", "", "Model whose embeddings select files (default: configured lead model)")
+	cmd.Flags().StringVar(&c.Format, "format", "markdown", "Output format (markdown, json)")
+	cmd.Flags().StringVarP(&c.OutputFile, "output", "o", "", "Output file (default: stdout)")
+
+	return cmd
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+)
+
+func TestContextPackCommand_validateInputs(t *testing.T) {
+	cmd := NewContextPackCommand()
+	assert.Error(t, cmd.validateInputs(), "a task is required")
+
+	cmd.Task = "add caching"
+	assert.NoError(t, cmd.validateInputs())
+
+	cmd.Budget = -1
+	assert.Error(t, cmd.validateInputs())
+
+	cmd.Budget = 0
+	cmd.Format = "text"
+	assert.Error(t, cmd.validateInputs())
+}
+
+func TestContextPackCommand_Bundle(t *testing.T) {
+	root := writeOnboardFixture(t)
+
+	cmd := NewContextPackCommand()
+	cmd.onboard.Dir = root
+	cmd.Task = "add a database migration"
+	cmd.Files = []string{filepath.Join(root, "internal"), filepath.Join(root, "cmd/app/main.go")}
+
+	files, err := cmd.selectFiles(context.Background())
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.True(t, strings.HasSuffix(files[0].Path, "internal/store/db.go"), "directories expand in place")
+	assert.True(t, strings.HasSuffix(files[2].Path, "cmd/app/main.go"))
+	assert.Equal(t, "go", files[2].Language)
+
+	facts, err := cmd.onboard.detectFacts()
+	require.NoError(t, err)
+	bundle := cmd.buildBundle(files, facts)
+	assert.Equal(t, filepath.Base(root), bundle.Project)
+	assert.Contains(t, bundle.Conventions, "golangci-lint configuration in .golangci.yml")
+	assert.Positive(t, bundle.Tokens)
+
+	formatted, err := cmd.formatBundle(bundle)
+	require.NoError(t, err)
+	assert.Contains(t, formatted, "# Task\n\nadd a database migration")
+	assert.Contains(t, formatted, "`make test` (test)")
+	assert.Contains(t, formatted, "main.go | 15 | whole |")
+	assert.Contains(t, formatted, "```go\n// Package main runs the app")
+
+	cmd.Format = "json"
+	formatted, err = cmd.formatBundle(bundle)
+	require.NoError(t, err)
+	var decoded ContextBundle
+	require.NoError(t, json.Unmarshal([]byte(formatted), &decoded))
+	assert.Len(t, decoded.Files, 3)
+}
+
+func TestContextPackCommand_BundleOverBudget(t *testing.T) {
+	root := writeOnboardFixture(t)
+
+	cmd := NewContextPackCommand()
+	cmd.onboard.Dir = root
+	cmd.Task = "explain the store"
+	cmd.Budget = 300
+
+	large := strings.Repeat("// filler line for the budget\n", 200)
+	files := []agent.FileContext{
+		{Path: "big.go", Content: large, Language: "go"},
+		{Path: "bigger.go", Content: large + large, Language: "go"},
+	}
+
+	facts, err := cmd.onboard.detectFacts()
+	require.NoError(t, err)
+	bundle := cmd.buildBundle(files, facts)
+	require.Len(t, bundle.Files, 2)
+	assert.True(t, bundle.Files[0].Trimmed)
+	assert.True(t, bundle.Files[1].Omitted, "the last listed file is omitted first")
+
+	formatted, err := cmd.formatBundle(bundle)
+	require.NoError(t, err)
+	assert.Contains(t, formatted, "| bigger.go | 3000 | omitted |")
+	assert.NotContains(t, formatted, "## bigger.go")
+}
@@ -4,6 +4,7 @@ package cli
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -13,6 +14,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/git"
 	"github.com/dshills/sigil/internal/logger"
@@ -21,18 +23,23 @@ import (
 // DiffCommand handles diff analysis operations
 type DiffCommand struct {
 	*BaseCommand
-	Files      []string
-	Staged     bool
-	Commit     string
-	Branch     string
-	Summary    bool
-	Detailed   bool
-	Format     string
-	OutputFile string
-	Context    int
-	startTime  time.Time
+	Files       []string
+	Staged      bool
+	Commit      string
+	Branch      string
+	Summary     bool
+	Detailed    bool
+	Format      string
+	Outputs     []string
+	Context     int
+	Interactive bool
+	jsonVersion int
+	startTime   time.Time
 }
 
+// diffFormats are the formats a diff analysis can be written in
+var diffFormats = []string{"markdown", "text", "json", "html"}
+
 // NewDiffCommand creates a new diff command
 func NewDiffCommand() *DiffCommand {
 	return &DiffCommand{
@@ -47,6 +54,17 @@ func NewDiffCommand() *DiffCommand {
 // Execute runs the diff command
 func (c *DiffCommand) Execute(ctx context.Context) error {
 	logger.Info("starting diff analysis", "files", c.Files, "staged", c.Staged, "commit", c.Commit)
+	c.startTime = deterministic.Time(c.startTime)
+	c.Files = deterministic.Sorted(c.Files)
+
+	format, version, err := parseFormat(c.Format)
+	if err != nil {
+		return err
+	}
+	c.Format, c.jsonVersion = format, version
+	if _, err := parseOutputs(c.Outputs, c.Format, c.jsonVersion, diffFormats); err != nil {
+		return err
+	}
 
 	// Validate Git repository
 	gitRepo, err := git.NewRepository(".")
@@ -65,6 +83,14 @@ func (c *DiffCommand) Execute(ctx context.Context) error {
 		return nil
 	}
 
+	if c.Interactive {
+		if !isTerminal(os.Stdin) {
+			return errors.ValidationError("Execute", "--interactive requires a terminal").
+				WithHint("run without --interactive to analyze the whole diff at once")
+		}
+		return c.executeInteractive(ctx, diffContent, os.Stdin, os.Stdout)
+	}
+
 	// Create task for agent processing
 	task, err := c.createDiffTask(diffContent)
 	if err != nil {
@@ -78,7 +104,7 @@ func (c *DiffCommand) Execute(ctx context.Context) error {
 	}
 
 	// Output result
-	return c.outputResult(result, diffContent)
+	return c.outputResult(ctx, result, diffContent)
 }
 
 // getDiffContent retrieves diff content based on command options
@@ -118,6 +144,11 @@ func (c *DiffCommand) getCommitDiff(gitRepo *git.Repository, commit string) (str
 		return "", errors.New(errors.ErrorTypeInput, "getCommitDiff", "commit hash cannot be empty")
 	}
 
+	// A commit at the edge of a shallow clone would show every file as added
+	if err := gitRepo.EnsureParents(commit, deepenOptions()); stderrors.Is(err, git.ErrShallowHistory) {
+		return "", shallowHistoryError(err)
+	}
+
 	// Use git show to get the diff for a specific commit
 	cmd := exec.Command("git", "show", "--format=", commit)
 	cmd.Dir = gitRepo.Path
@@ -142,6 +173,11 @@ func (c *DiffCommand) getBranchDiff(gitRepo *git.Repository, branch string) (str
 		return "", errors.New(errors.ErrorTypeInput, "getBranchDiff", "branch name cannot be empty")
 	}
 
+	// Three-dot diffs need the merge base, which shallow clones may lack
+	if _, err := gitRepo.EnsureMergeBase(branch, deepenOptions()); stderrors.Is(err, git.ErrShallowHistory) {
+		return "", shallowHistoryError(err)
+	}
+
 	// Get diff between the specified branch and current HEAD
 	// Using three dots (...) to show changes on HEAD since the branches diverged
 	cmd := exec.Command("git", "diff", fmt.Sprintf("%s...HEAD", branch))
@@ -168,6 +204,18 @@ func (c *DiffCommand) getBranchDiff(gitRepo *git.Repository, branch string) (str
 	return string(output), nil
 }
 
+// deepenOptions returns how much history may be fetched into shallow clones
+func deepenOptions() git.DeepenOptions {
+	cfg := getConfig()
+	return git.DeepenOptions{Auto: cfg.Git.AutoDeepen, Max: cfg.Git.MaxDeepen}
+}
+
+// shallowHistoryError explains how to fetch the history a diff needs
+func shallowHistoryError(err error) error {
+	return errors.Wrap(err, errors.ErrorTypeGit, "getDiffContent", "shallow clone is missing history").
+		WithHint("run 'git fetch --unshallow', or set fetch-depth: 0 on actions/checkout; git.auto_deepen and git.max_deepen control automatic fetching")
+}
+
 // getFileDiff gets diff for specific files
 func (c *DiffCommand) getFileDiff(gitRepo *git.Repository, files []string) (string, error) {
 	// Use the existing Diff method from the git package
@@ -239,7 +287,7 @@ func (c *DiffCommand) createDiffTask(diffContent string) (*agent.Task, error) {
 
 	// Create task
 	task := &agent.Task{
-		ID:          fmt.Sprintf("diff_%d", c.startTime.Unix()),
+		ID:          fmt.Sprintf("diff_%d_%s", c.startTime.Unix(), deterministic.TokenFor("diff", 6)),
 		Type:        agent.TaskTypeAnalyze,
 		Description: c.buildDescription(),
 		Context: agent.TaskContext{
@@ -284,11 +332,12 @@ func (c *DiffCommand) executeDiffAnalysis(ctx context.Context, task *agent.Task)
 	logger.Info("executing diff analysis with agent system")
 
 	// Create agent factory and orchestrator
-	factory := agent.NewFactory(nil, agent.DefaultOrchestrationConfig()) // No sandbox needed for diff analysis
+	factory := agent.NewFactory(nil, orchestrationConfig()) // No sandbox needed for diff analysis
 	orchestrator, err := factory.CreateOrchestrator()
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrorTypeInternal, "executeDiffAnalysis", "failed to create orchestrator")
 	}
+	configureOrchestrator(orchestrator)
 
 	// Execute task
 	result, err := orchestrator.ExecuteTask(ctx, *task)
@@ -296,6 +345,13 @@ func (c *DiffCommand) executeDiffAnalysis(ctx context.Context, task *agent.Task)
 		return nil, errors.Wrap(err, errors.ErrorTypeInternal, "executeDiffAnalysis", "task execution failed")
 	}
 
+	if len(result.Questions) > 0 {
+		return nil, clarificationError("executeDiffAnalysis", result.Questions)
+	}
+	if result.Abstention != nil {
+		return nil, abstentionError("executeDiffAnalysis", result.Abstention)
+	}
+
 	if result.Status != agent.StatusSuccess {
 		return nil, errors.New(errors.ErrorTypeInternal, "executeDiffAnalysis",
 			fmt.Sprintf("diff analysis failed with status: %s", result.Status))
@@ -306,39 +362,34 @@ func (c *DiffCommand) executeDiffAnalysis(ctx context.Context, task *agent.Task)
 }
 
 // outputResult outputs the diff analysis result
-func (c *DiffCommand) outputResult(result *agent.OrchestrationResult, diffContent string) error {
+func (c *DiffCommand) outputResult(ctx context.Context, result *agent.OrchestrationResult, diffContent string) error {
 	if result.FinalResult == nil {
 		return errors.New(errors.ErrorTypeInternal, "outputResult", "no final result available")
 	}
 
-	analysis := result.FinalResult.Reasoning
-	if analysis == "" && len(result.FinalResult.Artifacts) > 0 {
-		// Use artifact content if reasoning is empty
-		analysis = result.FinalResult.Artifacts[0].Content
-	}
-
+	analysis := analysisText(result)
 	if analysis == "" {
 		return errors.New(errors.ErrorTypeInternal, "outputResult", "no analysis content generated")
 	}
 
-	// Format the output
-	formatted, err := c.formatOutput(analysis, diffContent)
+	// Write to each output, or stdout
+	targets, err := parseOutputs(c.Outputs, c.Format, c.jsonVersion, diffFormats)
 	if err != nil {
-		return errors.Wrap(err, errors.ErrorTypeInternal, "outputResult", "failed to format output")
-	}
-
-	// Write to file or stdout
-	if c.OutputFile != "" {
-		if err := c.writeFile(c.OutputFile, formatted); err != nil {
-			return errors.Wrap(err, errors.ErrorTypeInternal, "outputResult",
-				fmt.Sprintf("failed to write output file: %s", c.OutputFile))
-		}
-		fmt.Printf("Diff analysis written to: %s\n", c.OutputFile)
-	} else {
-		fmt.Print(formatted)
+		return err
 	}
+	return writeOutputs(ctx, os.Stdout, targets, "Diff analysis", func(target outputTarget) (string, error) {
+		return c.formatAs(target, func() (string, error) {
+			return c.formatOutput(analysis, diffContent)
+		})
+	}, c.writeFile)
+}
 
-	return nil
+// formatAs runs format with the output target's format in place of --format
+func (c *DiffCommand) formatAs(target outputTarget, format func() (string, error)) (string, error) {
+	previous, version := c.Format, c.jsonVersion
+	defer func() { c.Format, c.jsonVersion = previous, version }()
+	c.Format, c.jsonVersion = target.Format, target.Version
+	return format()
 }
 
 // formatOutput formats the diff analysis based on the requested format
@@ -429,20 +480,20 @@ func (c *DiffCommand) formatText(analysis, diffContent string) string {
 
 // formatJSON formats content as JSON
 func (c *DiffCommand) formatJSON(analysis, diffContent string) string {
-	diffType := "working"
-	reference := ""
-
-	if c.Commit != "" {
-		diffType = "commit"
-		reference = c.Commit
-	} else if c.Branch != "" {
-		diffType = "branch"
-		reference = c.Branch
-	} else if c.Staged {
-		diffType = "staged"
-	} else if len(c.Files) > 0 {
-		diffType = "files"
-		reference = strings.Join(c.Files, ",")
+	diffType, reference := c.diffTarget()
+	if c.jsonVersion == JSONVersion2 {
+		output, err := formatEnvelope("diff", c.startTime, map[string]interface{}{
+			"type":         diffType,
+			"reference":    reference,
+			"summary":      c.Summary,
+			"detailed":     c.Detailed,
+			"analysis":     analysis,
+			"diff_content": diffContent,
+		})
+		if err != nil {
+			return fmt.Sprintf(`{"error": "Failed to format JSON: %s"}`, err.Error())
+		}
+		return output
 	}
 
 	data := map[string]interface{}{
@@ -465,6 +516,28 @@ func (c *DiffCommand) formatJSON(analysis, diffContent string) string {
 	return string(jsonBytes)
 }
 
+// diffTarget returns what the diff is of and the commit, branch or files
+// it refers to
+func (c *DiffCommand) diffTarget() (string, string) {
+	diffType := "working"
+	reference := ""
+
+	if c.Commit != "" {
+		diffType = "commit"
+		reference = c.Commit
+	} else if c.Branch != "" {
+		diffType = "branch"
+		reference = c.Branch
+	} else if c.Staged {
+		diffType = "staged"
+	} else if len(c.Files) > 0 {
+		diffType = "files"
+		reference = strings.Join(c.Files, ",")
+	}
+
+	return diffType, reference
+}
+
 // formatHTML formats content as HTML
 func (c *DiffCommand) formatHTML(analysis, diffContent string) string {
 	var output strings.Builder
@@ -553,7 +626,12 @@ Examples:
   sigil diff --commit abc123              # Analyze specific commit
   sigil diff --branch main                # Compare current branch to main
   sigil diff file1.go file2.go           # Analyze specific files
-  sigil diff --summary --format json     # Get summary in JSON format`,
+  sigil diff --summary --format json     # Get summary in JSON format
+  sigil diff --interactive -o review.md  # Walk through hunks and mark each one
+
+With --interactive, each hunk is shown with its own explanation and can be
+marked as reviewed (r), questionable with a note (q) or skipped (s), like
+git add -p. The marks are written as an annotated report at the end.`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c.Files = args
@@ -568,9 +646,10 @@ Examples:
 	cmd.Flags().StringVar(&c.Branch, "branch", "", "Compare against specific branch")
 	cmd.Flags().BoolVar(&c.Summary, "summary", false, "Provide summary only (exclude diff content)")
 	cmd.Flags().BoolVar(&c.Detailed, "detailed", false, "Provide detailed line-by-line analysis")
-	cmd.Flags().StringVar(&c.Format, "format", "markdown", "Output format (markdown,text,json,html)")
-	cmd.Flags().StringVarP(&c.OutputFile, "output", "o", "", "Output file (default: stdout)")
+	cmd.Flags().StringVar(&c.Format, "format", "markdown", "Output format (markdown,text,json,json@v2,html)")
+	cmd.Flags().StringArrayVarP(&c.Outputs, "output", "o", nil, "Output file, or - for stdout, optionally as format:file (repeatable; default: stdout)")
 	cmd.Flags().IntVarP(&c.Context, "context", "C", 3, "Lines of context around changes")
+	cmd.Flags().BoolVarP(&c.Interactive, "interactive", "i", false, "Step through hunks one at a time and mark each")
 
 	return cmd
 }
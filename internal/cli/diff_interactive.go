@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/theme"
+)
+
+// Hunk review marks
+const (
+	HunkReviewed     = "reviewed"
+	HunkQuestionable = "questionable"
+	HunkSkipped      = "skipped"
+)
+
+// diffHunk is one hunk of a unified diff with the file header it belongs to
+type diffHunk struct {
+	File   string   `json:"file"`
+	Header []string `json:"-"`
+	Lines  []string `json:"lines"`
+}
+
+// Patch returns the hunk as a standalone diff, including its file header
+func (h diffHunk) Patch() string {
+	return strings.Join(append(append([]string{}, h.Header...), h.Lines...), "\n")
+}
+
+// hunkReview records how a hunk was marked during the walkthrough
+type hunkReview struct {
+	Hunk        diffHunk `json:"hunk"`
+	Explanation string   `json:"explanation,omitempty"`
+	Mark        string   `json:"mark"`
+	Note        string   `json:"note,omitempty"`
+}
+
+// hunkExplainer explains a single hunk
+type hunkExplainer func(ctx context.Context, index int, hunk diffHunk) (string, error)
+
+// splitHunks splits a unified diff into hunks. Files without hunks, such as
+// binary files and pure renames, are left out.
+func splitHunks(diffContent string) []diffHunk {
+	var hunks []diffHunk
+	var header []string
+	file := ""
+	inHunk := false
+
+	for _, line := range strings.Split(strings.TrimRight(diffContent, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			header = []string{line}
+			file = diffFileName(line)
+			inHunk = false
+		case strings.HasPrefix(line, "@@"):
+			hunks = append(hunks, diffHunk{File: file, Header: header, Lines: []string{line}})
+			inHunk = true
+		case inHunk:
+			hunks[len(hunks)-1].Lines = append(hunks[len(hunks)-1].Lines, line)
+		default:
+			header = append(header, line)
+			if name, ok := strings.CutPrefix(line, "+++ b/"); ok {
+				file = name
+			}
+		}
+	}
+	return hunks
+}
+
+// diffFileName reads the destination path from a "diff --git a/x b/x" line
+func diffFileName(line string) string {
+	if index := strings.LastIndex(line, " b/"); index >= 0 {
+		return line[index+len(" b/"):]
+	}
+	return strings.TrimPrefix(line, "diff --git ")
+}
+
+// executeInteractive walks through the hunks of a diff, explaining each and
+// recording the user's marks, then writes the annotated report
+func (c *DiffCommand) executeInteractive(ctx context.Context, diffContent string, in io.Reader, out io.Writer) error {
+	hunks := splitHunks(diffContent)
+	if len(hunks) == 0 {
+		fmt.Fprintln(out, "No hunks to review")
+		return nil
+	}
+
+	reviews, err := walkHunks(ctx, hunks, c.explainHunk, in, out)
+	if err != nil {
+		return err
+	}
+
+	targets, err := parseOutputs(c.Outputs, c.Format, c.jsonVersion, diffFormats)
+	if err != nil {
+		return err
+	}
+	return writeOutputs(ctx, out, targets, "Review report", func(target outputTarget) (string, error) {
+		return c.formatAs(target, func() (string, error) {
+			return c.formatHunkReport(reviews)
+		})
+	}, c.writeFile)
+}
+
+// explainHunk asks the agents to explain one hunk
+func (c *DiffCommand) explainHunk(ctx context.Context, index int, hunk diffHunk) (string, error) {
+	task, err := c.createDiffTask(hunk.Patch())
+	if err != nil {
+		return "", err
+	}
+	task.ID = fmt.Sprintf("%s_hunk%d", task.ID, index+1)
+	task.Description = fmt.Sprintf("Explain this hunk of %s: what it changes and why it matters", hunk.File)
+
+	result, err := c.executeDiffAnalysis(ctx, task)
+	if err != nil {
+		return "", err
+	}
+	return analysisText(result), nil
+}
+
+// analysisText returns the analysis from a result, preferring the reasoning
+// over the first artifact
+func analysisText(result *agent.OrchestrationResult) string {
+	if result.FinalResult == nil {
+		return ""
+	}
+	if result.FinalResult.Reasoning != "" {
+		return result.FinalResult.Reasoning
+	}
+	if len(result.FinalResult.Artifacts) > 0 {
+		return result.FinalResult.Artifacts[0].Content
+	}
+	return ""
+}
+
+// walkHunks shows each hunk with its explanation and reads a mark for it,
+// like git add -p. Quitting early marks the remaining hunks as skipped.
+func walkHunks(ctx context.Context, hunks []diffHunk, explain hunkExplainer, in io.Reader, out io.Writer) ([]hunkReview, error) {
+	reader := bufio.NewReader(in)
+	reviews := make([]hunkReview, 0, len(hunks))
+
+	for i, hunk := range hunks {
+		header := theme.Paint(out, theme.Heading, fmt.Sprintf("=== Hunk %d/%d: %s ===", i+1, len(hunks), hunk.File))
+		fmt.Fprintf(out, "\n%s\n%s\n", header, theme.Render(out, strings.Join(hunk.Lines, "\n")))
+
+		explanation, err := explain(ctx, i, hunk)
+		if err != nil {
+			// A failed explanation should not end the walkthrough
+			fmt.Fprintf(out, "\n(explanation unavailable: %v)\n", err)
+		} else if explanation != "" {
+			fmt.Fprintf(out, "\n%s\n", theme.Render(out, strings.TrimSpace(explanation)))
+		}
+
+		mark, note, err := readHunkMark(reader, out, fmt.Sprintf("%d/%d", i+1, len(hunks)))
+		if err != nil {
+			return nil, err
+		}
+		if mark == "" {
+			// Quit: the current and remaining hunks are left unreviewed
+			reviews = append(reviews, hunkReview{Hunk: hunk, Explanation: strings.TrimSpace(explanation), Mark: HunkSkipped})
+			for _, rest := range hunks[i+1:] {
+				reviews = append(reviews, hunkReview{Hunk: rest, Mark: HunkSkipped})
+			}
+			break
+		}
+		reviews = append(reviews, hunkReview{Hunk: hunk, Explanation: strings.TrimSpace(explanation), Mark: mark, Note: note})
+	}
+	return reviews, nil
+}
+
+// readHunkMark prompts until a valid mark is entered. It returns an empty
+// mark when the user quits or input ends.
+func readHunkMark(reader *bufio.Reader, out io.Writer, position string) (string, string, error) {
+	for {
+		fmt.Fprintf(out, "\nMark hunk %s [r,q,s,x,?]? ", position)
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", "", errors.Wrap(err, errors.ErrorTypeInput, "readHunkMark", "failed to read answer")
+		}
+		answer := strings.ToLower(strings.TrimSpace(line))
+		if err == io.EOF && answer == "" {
+			return "", "", nil
+		}
+
+		switch answer {
+		case "r":
+			return HunkReviewed, "", nil
+		case "q":
+			fmt.Fprint(out, "Note: ")
+			note, err := reader.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return "", "", errors.Wrap(err, errors.ErrorTypeInput, "readHunkMark", "failed to read note")
+			}
+			return HunkQuestionable, strings.TrimSpace(note), nil
+		case "s":
+			return HunkSkipped, "", nil
+		case "x":
+			return "", "", nil
+		default:
+			fmt.Fprint(out, "r - mark as reviewed\nq - mark as questionable and add a note\ns - skip this hunk\nx - stop and skip the remaining hunks\n? - print help\n")
+		}
+	}
+}
+
+// formatHunkReport formats the annotated walkthrough as JSON or Markdown
+func (c *DiffCommand) formatHunkReport(reviews []hunkReview) (string, error) {
+	counts := make(map[string]int)
+	for _, review := range reviews {
+		counts[review.Mark]++
+	}
+
+	if c.Format == "json" && c.jsonVersion == JSONVersion2 {
+		if reviews == nil {
+			reviews = []hunkReview{}
+		}
+		output, err := formatEnvelope("diff", c.startTime, map[string]interface{}{
+			"hunks": reviews,
+			"marks": counts,
+		})
+		if err != nil {
+			return "", err
+		}
+		return output + "\n", nil
+	}
+	if c.Format == "json" {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"hunks":   reviews,
+			"summary": counts,
+		}, "", "  ")
+		if err != nil {
+			return "", errors.Wrap(err, errors.ErrorTypeOutput, "formatHunkReport", "failed to marshal report")
+		}
+		return string(data) + "\n", nil
+	}
+
+	var output strings.Builder
+	output.WriteString("# Diff Review\n\n")
+	output.WriteString(fmt.Sprintf("%d hunks: %d reviewed, %d questionable, %d skipped\n",
+		len(reviews), counts[HunkReviewed], counts[HunkQuestionable], counts[HunkSkipped]))
+
+	for i, review := range reviews {
+		output.WriteString(fmt.Sprintf("\n## Hunk %d: %s (%s)\n\n", i+1, review.Hunk.File, review.Mark))
+		if review.Note != "" {
+			output.WriteString(fmt.Sprintf("> %s\n\n", review.Note))
+		}
+		output.WriteString("```diff\n")
+		output.WriteString(strings.Join(review.Hunk.Lines, "\n"))
+		output.WriteString("\n```\n")
+		if review.Explanation != "" {
+			output.WriteString("\n" + review.Explanation + "\n")
+		}
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const interactiveDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var a = 1
++var a = 2
+@@ -10,2 +10,3 @@ func main() {
+ 	run()
++	stop()
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+diff --git a/util.go b/util.go
+--- a/util.go
++++ b/util.go
+@@ -5 +5 @@
+-old
++new
+`
+
+// fixedExplainer explains every hunk with its file and position
+func fixedExplainer(_ context.Context, index int, hunk diffHunk) (string, error) {
+	return fmt.Sprintf("explains %s #%d", hunk.File, index+1), nil
+}
+
+func TestSplitHunks(t *testing.T) {
+	hunks := splitHunks(interactiveDiff)
+
+	require.Len(t, hunks, 3)
+	assert.Equal(t, "main.go", hunks[0].File)
+	assert.Equal(t, []string{"@@ -1,3 +1,3 @@", " package main", "-var a = 1", "+var a = 2"}, hunks[0].Lines)
+	assert.Equal(t, "main.go", hunks[1].File)
+	assert.Equal(t, "util.go", hunks[2].File)
+
+	patch := hunks[1].Patch()
+	assert.True(t, strings.HasPrefix(patch, "diff --git a/main.go b/main.go\nindex 1111111..2222222 100644\n--- a/main.go\n+++ b/main.go\n@@ -10,2"))
+	assert.NotContains(t, patch, "var a")
+}
+
+func TestWalkHunks(t *testing.T) {
+	hunks := splitHunks(interactiveDiff)
+	in := strings.NewReader("?\nr\nq\nwhy stop here?\ns\n")
+	var out bytes.Buffer
+
+	reviews, err := walkHunks(context.Background(), hunks, fixedExplainer, in, &out)
+	require.NoError(t, err)
+
+	require.Len(t, reviews, 3)
+	assert.Equal(t, HunkReviewed, reviews[0].Mark)
+	assert.Equal(t, "explains main.go #1", reviews[0].Explanation)
+	assert.Equal(t, HunkQuestionable, reviews[1].Mark)
+	assert.Equal(t, "why stop here?", reviews[1].Note)
+	assert.Equal(t, HunkSkipped, reviews[2].Mark)
+
+	assert.Contains(t, out.String(), "=== Hunk 1/3: main.go ===")
+	assert.Contains(t, out.String(), "explains util.go #3")
+	assert.Contains(t, out.String(), "q - mark as questionable and add a note", "? prints help")
+}
+
+func TestWalkHunks_QuitSkipsRemaining(t *testing.T) {
+	hunks := splitHunks(interactiveDiff)
+	explained := 0
+	explain := func(ctx context.Context, index int, hunk diffHunk) (string, error) {
+		explained++
+		return fixedExplainer(ctx, index, hunk)
+	}
+
+	reviews, err := walkHunks(context.Background(), hunks, explain, strings.NewReader("r\nx\n"), &bytes.Buffer{})
+	require.NoError(t, err)
+
+	require.Len(t, reviews, 3)
+	assert.Equal(t, HunkReviewed, reviews[0].Mark)
+	assert.Equal(t, HunkSkipped, reviews[1].Mark)
+	assert.Equal(t, "explains main.go #2", reviews[1].Explanation)
+	assert.Equal(t, HunkSkipped, reviews[2].Mark)
+	assert.Empty(t, reviews[2].Explanation)
+	assert.Equal(t, 2, explained, "hunks after quitting are not explained")
+}
+
+func TestWalkHunks_ExplanationFailure(t *testing.T) {
+	hunks := splitHunks(interactiveDiff)[:1]
+	explain := func(context.Context, int, diffHunk) (string, error) {
+		return "", fmt.Errorf("model offline")
+	}
+	var out bytes.Buffer
+
+	reviews, err := walkHunks(context.Background(), hunks, explain, strings.NewReader("r\n"), &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, HunkReviewed, reviews[0].Mark)
+	assert.Contains(t, out.String(), "explanation unavailable: model offline")
+}
+
+func TestDiffCommand_formatHunkReport(t *testing.T) {
+	hunks := splitHunks(interactiveDiff)
+	reviews := []hunkReview{
+		{Hunk: hunks[0], Explanation: "Bumps a.", Mark: HunkReviewed},
+		{Hunk: hunks[1], Mark: HunkQuestionable, Note: "why stop here?"},
+		{Hunk: hunks[2], Mark: HunkSkipped},
+	}
+
+	t.Run("markdown", func(t *testing.T) {
+		cmd := NewDiffCommand()
+		report, err := cmd.formatHunkReport(reviews)
+		require.NoError(t, err)
+
+		assert.Contains(t, report, "3 hunks: 1 reviewed, 1 questionable, 1 skipped")
+		assert.Contains(t, report, "## Hunk 1: main.go (reviewed)\n\n```diff\n@@ -1,3 +1,3 @@")
+		assert.Contains(t, report, "\nBumps a.\n")
+		assert.Contains(t, report, "## Hunk 2: main.go (questionable)\n\n> why stop here?\n")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		cmd := NewDiffCommand()
+		cmd.Format = "json"
+		report, err := cmd.formatHunkReport(reviews)
+		require.NoError(t, err)
+
+		var parsed struct {
+			Hunks   []hunkReview   `json:"hunks"`
+			Summary map[string]int `json:"summary"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(report), &parsed))
+		assert.Len(t, parsed.Hunks, 3)
+		assert.Equal(t, "why stop here?", parsed.Hunks[1].Note)
+		assert.Equal(t, 1, parsed.Summary[HunkQuestionable])
+	})
+}
+
+func TestDiffCommand_executeInteractive(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "review.md")
+	cmd := NewDiffCommand()
+	cmd.Outputs = []string{output}
+
+	var out bytes.Buffer
+	err := cmd.executeInteractive(context.Background(), "", strings.NewReader(""), &out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "No hunks to review")
+	assert.NoFileExists(t, output)
+}
+
+func TestDiffCommand_InteractiveFlag(t *testing.T) {
+	cobraCmd := NewDiffCommand().CreateCobraCommand()
+
+	flag := cobraCmd.Flags().Lookup("interactive")
+	require.NotNil(t, flag)
+	assert.Equal(t, "i", flag.Shorthand)
+}
@@ -4,18 +4,30 @@ package cli
 import (
 	"context"
 	"fmt"
+	"html"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/examples"
+	"github.com/dshills/sigil/internal/index"
 	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/outline"
+	"github.com/dshills/sigil/internal/theme"
+	"github.com/dshills/sigil/internal/translate"
 )
 
+// docArtifacts groups generated documentation among the artifacts in the
+// cache
+const docArtifacts = "docs"
+
 // DocCommand handles documentation generation operations
 type DocCommand struct {
 	*BaseCommand
@@ -25,9 +37,33 @@ type DocCommand struct {
 	Template       string
 	IncludePrivate bool
 	IncludeTests   bool
+	IncludeSymbols []string
+	ExcludeSymbols []string
+	OnlyExported   bool
 	Recursive      bool
+	Include        []string
+	Exclude        []string
+	Submodules     bool
 	UpdateExisting bool
+	Merge          bool
+	Preview        bool
 	Language       string
+	QualityPass    bool
+	VerifyRefs     bool
+	FailFast       bool
+	Resume         bool
+	Examples       bool
+	DocLanguage    string
+	Translate      []string
+	Review         bool
+	Reports        []string
+	failures       fileFailures
+	sourceHashes   map[string]string
+	refs           *referenceGuard
+	miner          *exampleMiner
+	mined          map[string][]examples.Example
+	translator     *translate.Translator
+	docReview      docReview
 	startTime      time.Time
 }
 
@@ -36,46 +72,140 @@ func NewDocCommand() *DocCommand {
 	return &DocCommand{
 		BaseCommand: NewBaseCommand("doc", "Generate documentation with AI assistance",
 			"Generate comprehensive documentation for code files and projects using AI analysis."),
-		Format:    "markdown",
-		OutputDir: "docs",
-		startTime: time.Now(),
+		Format:      "markdown",
+		OutputDir:   "docs",
+		DocLanguage: "en",
+		startTime:   time.Now(),
 	}
 }
 
 // Execute runs the doc command
 func (c *DocCommand) Execute(ctx context.Context) error {
 	logger.Info("starting documentation generation", "files", c.Files, "format", c.Format, "output_dir", c.OutputDir)
+	c.startTime = deterministic.Time(c.startTime)
+	c.Files = deterministic.Sorted(c.Files)
+
+	// Document the files of submodules named on the command line
+	files, err := expandSubmodules(c.Files, c.Submodules)
+	if err != nil {
+		return err
+	}
+	c.Files = files
+
+	// Document the files in directories named on the command line
+	if c.Files, err = c.expandDirectories(c.Files); err != nil {
+		return err
+	}
 
 	// Validate inputs
 	if err := c.validateInputs(); err != nil {
 		return err
 	}
 
-	// Ensure output directory exists
-	if err := c.ensureOutputDir(); err != nil {
-		return errors.Wrap(err, errors.ErrorTypeFS, "Execute", "failed to create output directory")
+	// Ensure output directory exists; a review writes nothing there
+	if !c.Review {
+		if err := c.ensureOutputDir(); err != nil {
+			return errors.Wrap(err, errors.ErrorTypeFS, "Execute", "failed to create output directory")
+		}
 	}
 
-	// Process files for documentation
+	// Process files for documentation, setting aside those that fail
+	c.failures = fileFailures{failFast: c.FailFast}
 	fileContexts, err := c.processFiles()
 	if err != nil {
 		return errors.Wrap(err, errors.ErrorTypeInput, "Execute", "failed to process files")
 	}
+	total := len(fileContexts) + len(c.failures.failures)
 
-	// Create task for agent processing
-	task, err := c.createDocTask(fileContexts)
+	quality, err := newQualityChecker(ctx, c.BaseCommand, c.QualityPass)
 	if err != nil {
-		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to create doc task")
+		return err
+	}
+	if c.refs, err = newReferenceGuard(c.VerifyRefs); err != nil {
+		return err
+	}
+	if c.miner, err = newExampleMiner(c.Examples, fmt.Sprintf("doc_examples_%d", c.startTime.Unix())); err != nil {
+		return err
+	}
+	defer c.miner.close()
+	if c.translator, err = newDocTranslator(ctx, c.BaseCommand, c.Translate); err != nil {
+		return err
 	}
 
-	// Execute documentation generation
+	// Document each file on its own so every artifact maps to one source
+	var entries []docEntry
+	resumed := 0
+	for i, fileContext := range fileContexts {
+		entry, ok := docEntry{}, false
+		if c.Resume {
+			if entry, ok = c.upToDate(fileContext.Path); ok {
+				logger.Info("documentation up to date", "path", entry.DocPath)
+				resumed++
+			}
+		}
+		if !ok {
+			if entry, err = c.documentFile(ctx, quality, i, fileContext); err != nil {
+				if err := c.failures.record(fileContext.Path, err); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		entries = append(entries, entry)
+
+		// Translations are derived from the primary documentation
+		translations, err := c.translateDoc(ctx, entry)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, translations...)
+	}
+
+	if resumed > 0 {
+		fmt.Printf("Resumed: %d of %d files already up to date\n", resumed, len(fileContexts))
+	}
+	if c.Review {
+		if err := c.writeDocReview(ctx); err != nil {
+			return err
+		}
+	} else if !c.Preview {
+		indexFile, err := c.writeIndex(entries, c.failures.failures)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrorTypeFS, "Execute", "failed to write documentation index")
+		}
+		fmt.Printf("Documentation index written to: %s\n", indexFile)
+		if url := c.publishDocs(ctx, entries, indexFile); url != "" {
+			fmt.Printf("Documentation index shared at: %s\n", url)
+		}
+	}
+	return c.failures.err("Execute", "document", total)
+}
+
+// documentFile generates and writes the documentation of one file
+func (c *DocCommand) documentFile(ctx context.Context, quality *qualityChecker, index int, fileContext agent.FileContext) (docEntry, error) {
+	task, err := c.createDocTask([]agent.FileContext{fileContext})
+	if err != nil {
+		return docEntry{}, errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to create doc task")
+	}
+	task.ID = fmt.Sprintf("doc_%d_%d", c.startTime.Unix(), index+1)
+
 	result, err := c.executeDocGeneration(ctx, task)
 	if err != nil {
-		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to execute documentation generation")
+		return docEntry{}, errors.Wrap(err, errors.ErrorTypeInternal, "Execute",
+			fmt.Sprintf("failed to document %s", fileContext.Path))
 	}
+	quality.checkResult(ctx, "documentation", task, result)
 
-	// Output documentation files
-	return c.outputDocumentation(result)
+	if mined := c.miner.mine(ctx, fileContext.Path, fileContext.Content); len(mined) > 0 {
+		if c.mined == nil {
+			c.mined = make(map[string][]examples.Example)
+		}
+		c.mined[fileContext.Path] = mined
+	}
+	if c.Review {
+		return c.reviewDocumentation(ctx, fileContext.Path, result)
+	}
+	return c.outputDocumentation(fileContext.Path, result)
 }
 
 // validateInputs validates the command inputs
@@ -104,6 +234,24 @@ func (c *DocCommand) validateInputs() error {
 			fmt.Sprintf("invalid format: %s (valid: %s)", c.Format, strings.Join(validFormats, ", ")))
 	}
 
+	if err := c.symbolSelection().Validate(); err != nil {
+		return errors.ValidationError("validateInputs", err.Error()).
+			WithHint(`symbol patterns use shell-style wildcards, e.g. "*_test,Deprecated*"`)
+	}
+
+	if err := c.validateLanguages(); err != nil {
+		return err
+	}
+
+	if c.Merge && c.UpdateExisting {
+		return errors.ValidationError("validateInputs", "--merge and --update are mutually exclusive").
+			WithHint("use --merge to keep human-edited sections, or --update to replace existing files")
+	}
+
+	if err := c.validateReview(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -112,9 +260,12 @@ func (c *DocCommand) ensureOutputDir() error {
 	return os.MkdirAll(c.OutputDir, 0755)
 }
 
-// processFiles reads and processes all specified files
+// processFiles reads and processes all specified files. Files that cannot
+// be read are recorded as failures unless --fail-fast is set.
 func (c *DocCommand) processFiles() ([]agent.FileContext, error) {
 	fileContexts := make([]agent.FileContext, 0, len(c.Files))
+	c.sourceHashes = make(map[string]string, len(c.Files))
+	selection := c.symbolSelection()
 
 	for _, filePath := range c.Files {
 		// Skip test files if not including tests
@@ -124,8 +275,28 @@ func (c *DocCommand) processFiles() ([]agent.FileContext, error) {
 
 		content, err := c.readFile(filePath)
 		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrorTypeInput, "processFiles",
+			err = errors.Wrap(err, errors.ErrorTypeInput, "processFiles",
 				fmt.Sprintf("failed to read file: %s", filePath))
+			if err := c.failures.record(filePath, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Leave out the symbols that are not to be documented, so the model
+		// never sees them
+		if !selection.Empty() {
+			var dropped []string
+			content, dropped, err = outline.Select(filePath, content, selection)
+			if err != nil {
+				err = errors.Wrap(err, errors.ErrorTypeInput, "processFiles",
+					fmt.Sprintf("failed to select symbols in %s", filePath))
+				if err := c.failures.record(filePath, err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			logger.Debug("left symbols out of documentation", "file", filePath, "symbols", dropped)
 		}
 
 		// Determine if file contains private/internal code
@@ -134,6 +305,8 @@ func (c *DocCommand) processFiles() ([]agent.FileContext, error) {
 			// Filter out private content
 			content = c.filterPrivateContent(content)
 		}
+		// Documents record what they were generated from for --resume
+		c.sourceHashes[filePath] = index.HashContent([]byte(content))
 
 		fileContext := agent.FileContext{
 			Path:        filePath,
@@ -149,6 +322,18 @@ func (c *DocCommand) processFiles() ([]agent.FileContext, error) {
 	return fileContexts, nil
 }
 
+// symbolSelection combines the symbol selection of the configuration with
+// the flags: patterns from both apply, and either can limit documentation
+// to the exported API
+func (c *DocCommand) symbolSelection() outline.Selection {
+	cfg := getConfig().Docs
+	return outline.Selection{
+		Include:      append(append([]string{}, cfg.IncludeSymbols...), c.IncludeSymbols...),
+		Exclude:      append(append([]string{}, cfg.ExcludeSymbols...), c.ExcludeSymbols...),
+		OnlyExported: cfg.OnlyExported || c.OnlyExported,
+	}
+}
+
 // isTestFile checks if a file is a test file
 func (c *DocCommand) isTestFile(filePath string) bool {
 	fileName := filepath.Base(filePath)
@@ -211,6 +396,10 @@ func (c *DocCommand) createDocTask(fileContexts []agent.FileContext) (*agent.Tas
 		requirements = append(requirements, fmt.Sprintf("Use the template style: %s", c.Template))
 	}
 
+	if c.DocLanguage != "" && c.DocLanguage != "en" {
+		requirements = append(requirements, fmt.Sprintf("Write the documentation in %s", translate.LanguageName(c.DocLanguage)))
+	}
+
 	// Detect project info
 	projectInfo := agent.ProjectInfo{
 		Language:  c.detectProjectLanguage(),
@@ -220,7 +409,7 @@ func (c *DocCommand) createDocTask(fileContexts []agent.FileContext) (*agent.Tas
 
 	// Create task
 	task := &agent.Task{
-		ID:          fmt.Sprintf("doc_%d", c.startTime.Unix()),
+		ID:          fmt.Sprintf("doc_%d_%s", c.startTime.Unix(), deterministic.TokenFor("doc", 6)),
 		Type:        agent.TaskTypeGenerate,
 		Description: c.buildDescription(),
 		Context: agent.TaskContext{
@@ -255,11 +444,12 @@ func (c *DocCommand) executeDocGeneration(ctx context.Context, task *agent.Task)
 	logger.Info("executing documentation generation with agent system")
 
 	// Create agent factory and orchestrator
-	factory := agent.NewFactory(nil, agent.DefaultOrchestrationConfig()) // No sandbox needed for documentation
+	factory := agent.NewFactory(nil, orchestrationConfig()) // No sandbox needed for documentation
 	orchestrator, err := factory.CreateOrchestrator()
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrorTypeInternal, "executeDocGeneration", "failed to create orchestrator")
 	}
+	configureOrchestrator(orchestrator)
 
 	// Execute task
 	result, err := orchestrator.ExecuteTask(ctx, *task)
@@ -267,6 +457,13 @@ func (c *DocCommand) executeDocGeneration(ctx context.Context, task *agent.Task)
 		return nil, errors.Wrap(err, errors.ErrorTypeInternal, "executeDocGeneration", "task execution failed")
 	}
 
+	if len(result.Questions) > 0 {
+		return nil, clarificationError("executeDocGeneration", result.Questions)
+	}
+	if result.Abstention != nil {
+		return nil, abstentionError("executeDocGeneration", result.Abstention)
+	}
+
 	if result.Status != agent.StatusSuccess {
 		return nil, errors.New(errors.ErrorTypeInternal, "executeDocGeneration",
 			fmt.Sprintf("documentation generation failed with status: %s", result.Status))
@@ -276,50 +473,234 @@ func (c *DocCommand) executeDocGeneration(ctx context.Context, task *agent.Task)
 	return result, nil
 }
 
-// outputDocumentation writes the generated documentation to files
-func (c *DocCommand) outputDocumentation(result *agent.OrchestrationResult) error {
+// docEntry records the documentation written for one source file in one
+// language
+type docEntry struct {
+	Source   string
+	DocPath  string
+	Language string
+	Written  bool
+
+	// content is the document as written, or as it stands when left alone
+	content string
+}
+
+// outputDocumentation writes the documentation generated for source
+func (c *DocCommand) outputDocumentation(source string, result *agent.OrchestrationResult) (docEntry, error) {
+	content, err := c.generatedContent(source, result)
+	if err != nil {
+		return docEntry{}, err
+	}
+
+	entry, err := c.writeDocFile(source, content)
+	if err != nil {
+		return docEntry{}, errors.Wrap(err, errors.ErrorTypeFS, "outputDocumentation",
+			fmt.Sprintf("failed to write documentation for %s", source))
+	}
+	if entry.Written {
+		fmt.Printf("Documentation for %s written to: %s\n", source, entry.DocPath)
+	}
+	return entry, nil
+}
+
+// generatedContent returns the documentation generated for source, checked
+// and with its examples
+func (c *DocCommand) generatedContent(source string, result *agent.OrchestrationResult) (string, error) {
 	if result.FinalResult == nil {
-		return errors.New(errors.ErrorTypeInternal, "outputDocumentation", "no final result available")
+		return "", errors.New(errors.ErrorTypeInternal, "generatedContent", "no final result available")
+	}
+
+	// A documentation artifact takes precedence over the lead's reasoning
+	content := result.FinalResult.Reasoning
+	for _, artifact := range result.FinalResult.Artifacts {
+		if artifact.Type == agent.ArtifactTypeDocumentation && artifact.Content != "" {
+			content = artifact.Content
+			break
+		}
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", errors.New(errors.ErrorTypeInternal, "generatedContent",
+			fmt.Sprintf("no documentation generated for %s", source))
+	}
+
+	content = applyGlossary(c.refs.check(content))
+	if c.Format == FormatMarkdown {
+		content = lintDocument(content)
 	}
+	return appendExamples(c.Format, content, c.mined[source]), nil
+}
+
+// docPath maps a source file to its documentation file, mirroring the
+// source tree below the output directory: a/b.go becomes <output>/a/b.go.md
+func (c *DocCommand) docPath(source string) string {
+	return c.docPathIn(c.DocLanguage, source)
+}
 
-	// Check if we have artifacts (individual file documentation)
-	if len(result.FinalResult.Artifacts) > 0 {
-		for _, artifact := range result.FinalResult.Artifacts {
-			if err := c.writeDocFile(artifact); err != nil {
-				logger.Warn("failed to write doc file", "artifact", artifact.Name, "error", err)
+// docPathIn maps a source file to its documentation file in language,
+// below the language's directory
+func (c *DocCommand) docPathIn(language, source string) string {
+	rel := filepath.Clean(source)
+	if filepath.IsAbs(rel) {
+		if cwd, err := os.Getwd(); err == nil {
+			if r, err := filepath.Rel(cwd, rel); err == nil {
+				rel = r
 			}
 		}
 	}
 
-	// Write main documentation from reasoning
-	if result.FinalResult.Reasoning != "" {
-		mainDocFile := filepath.Join(c.OutputDir, fmt.Sprintf("README.%s", c.getFileExtension()))
-		if err := c.writeFile(mainDocFile, result.FinalResult.Reasoning); err != nil {
-			return errors.Wrap(err, errors.ErrorTypeFS, "outputDocumentation", "failed to write main documentation")
+	// Sources outside the working directory keep the rest of their path
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for len(parts) > 1 && (parts[0] == ".." || parts[0] == "") {
+		parts = parts[1:]
+	}
+	return filepath.Join(c.languageDir(language), filepath.FromSlash(strings.Join(parts, "/"))+"."+c.getFileExtension())
+}
+
+// writeDocFile writes the documentation for source, stamped with the hash
+// of the source it was generated from. An existing file is left alone
+// unless UpdateExisting or Resume replaces it or Merge merges into it.
+// With Preview, the proposed change is printed as a diff instead.
+func (c *DocCommand) writeDocFile(source, content string) (docEntry, error) {
+	return c.writeDocFileIn(c.DocLanguage, source, content)
+}
+
+// writeDocFileIn writes the documentation for source in language, as
+// writeDocFile does
+func (c *DocCommand) writeDocFileIn(language, source, content string) (docEntry, error) {
+	entry := docEntry{Source: source, DocPath: c.docPathIn(language, source), Language: language}
+
+	existing, exists := "", c.fileExists(entry.DocPath)
+	if exists {
+		var err error
+		if existing, err = c.readFile(entry.DocPath); err != nil {
+			return entry, err
+		}
+		switch {
+		case c.Merge:
+			content = mergeDoc(existing, content)
+		case !c.UpdateExisting && !c.Resume:
+			logger.Info("skipping existing file", "path", entry.DocPath)
+			entry.content = existing
+			return entry, nil
 		}
-		fmt.Printf("Main documentation written to: %s\n", mainDocFile)
+	}
+	if hash := c.sourceHashes[source]; hash != "" {
+		content = stampSourceHash(c.Format, content, hash)
+	}
+	entry.content = content
+	if exists && content == existing {
+		logger.Info("documentation unchanged", "path", entry.DocPath)
+		return entry, nil
 	}
 
-	fmt.Printf("Documentation generated in: %s\n", c.OutputDir)
-	return nil
+	if c.Preview {
+		fmt.Print(theme.Render(os.Stdout, unifiedDiff(existing, content, filepath.ToSlash(entry.DocPath))))
+		return entry, nil
+	}
+
+	if err := c.writeFile(entry.DocPath, content); err != nil {
+		return entry, err
+	}
+	entry.Written = true
+	return entry, nil
 }
 
-// writeDocFile writes a documentation artifact to a file
-func (c *DocCommand) writeDocFile(artifact agent.Artifact) error {
-	fileName := artifact.Name
-	if !strings.Contains(fileName, ".") {
-		fileName += "." + c.getFileExtension()
+// writeIndex writes a table of contents linking every documented file,
+// followed by an errors section listing the files that failed
+func (c *DocCommand) writeIndex(entries []docEntry, failures []fileFailure) (string, error) {
+	indexFile := filepath.Join(c.OutputDir, "index."+c.getFileExtension())
+
+	sorted := make([]docEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].DocPath < sorted[j].DocPath })
+
+	var links []string
+	for _, entry := range sorted {
+		target, err := filepath.Rel(c.OutputDir, entry.DocPath)
+		if err != nil {
+			return "", err
+		}
+		target = filepath.ToSlash(target)
+		name := strings.TrimSuffix(target, "."+c.getFileExtension())
+
+		switch c.Format {
+		case FormatHTML:
+			links = append(links, fmt.Sprintf("<li><a href=\"%s\">%s</a></li>", target, name))
+		case "rst":
+			links = append(links, fmt.Sprintf("- `%s <%s>`_", name, target))
+		case "asciidoc":
+			links = append(links, fmt.Sprintf("* link:%s[%s]", target, name))
+		case "text":
+			links = append(links, fmt.Sprintf("- %s: %s", name, target))
+		default:
+			links = append(links, fmt.Sprintf("- [%s](%s)", name, target))
+		}
 	}
 
-	filePath := filepath.Join(c.OutputDir, fileName)
+	var errs []string
+	for _, failure := range failures {
+		switch c.Format {
+		case FormatHTML:
+			errs = append(errs, fmt.Sprintf("<li><code>%s</code>: %s</li>", html.EscapeString(failure.Path), html.EscapeString(failure.Error)))
+		case "rst":
+			errs = append(errs, fmt.Sprintf("- ``%s``: %s", failure.Path, failure.Error))
+		case "asciidoc":
+			errs = append(errs, fmt.Sprintf("* `%s`: %s", failure.Path, failure.Error))
+		case "text":
+			errs = append(errs, fmt.Sprintf("- %s: %s", failure.Path, failure.Error))
+		default:
+			errs = append(errs, fmt.Sprintf("- `%s`: %s", failure.Path, failure.Error))
+		}
+	}
 
-	// Check if file exists and UpdateExisting is false
-	if !c.UpdateExisting && c.fileExists(filePath) {
-		logger.Info("skipping existing file", "path", filePath)
-		return nil
+	var content string
+	switch c.Format {
+	case FormatHTML:
+		var errorList string
+		if len(errs) > 0 {
+			errorList = fmt.Sprintf("<h2>Errors</h2>\n<ul>\n%s\n</ul>\n", strings.Join(errs, "\n"))
+		}
+		content = fmt.Sprintf("<html>\n<body>\n<h1>Documentation</h1>\n<ul>\n%s\n</ul>\n%s</body>\n</html>\n", strings.Join(links, "\n"), errorList)
+	case "rst":
+		content = fmt.Sprintf("Documentation\n=============\n\n%s\n", strings.Join(links, "\n"))
+		if len(errs) > 0 {
+			content += fmt.Sprintf("\nErrors\n------\n\n%s\n", strings.Join(errs, "\n"))
+		}
+	case "asciidoc":
+		content = fmt.Sprintf("= Documentation\n\n%s\n", strings.Join(links, "\n"))
+		if len(errs) > 0 {
+			content += fmt.Sprintf("\n== Errors\n\n%s\n", strings.Join(errs, "\n"))
+		}
+	case "text":
+		content = fmt.Sprintf("Documentation\n\n%s\n", strings.Join(links, "\n"))
+		if len(errs) > 0 {
+			content += fmt.Sprintf("\nErrors\n\n%s\n", strings.Join(errs, "\n"))
+		}
+	default:
+		content = fmt.Sprintf("# Documentation\n\n%s\n", strings.Join(links, "\n"))
+		if len(errs) > 0 {
+			content += fmt.Sprintf("\n## Errors\n\n%s\n", strings.Join(errs, "\n"))
+		}
 	}
 
-	return c.writeFile(filePath, artifact.Content)
+	return indexFile, c.writeFile(indexFile, content)
+}
+
+// publishDocs publishes the documents written this run and their index to
+// the cache as artifacts of the run, returning a URL to share the index, if
+// any
+func (c *DocCommand) publishDocs(ctx context.Context, entries []docEntry, indexFile string) string {
+	for _, entry := range entries {
+		if entry.Written {
+			publishArtifact(ctx, docArtifacts, filepath.ToSlash(entry.DocPath), []byte(entry.content))
+		}
+	}
+	index, err := os.ReadFile(indexFile)
+	if err != nil {
+		logger.Warn("failed to read documentation index", "path", indexFile, "error", err)
+		return ""
+	}
+	return publishArtifact(ctx, docArtifacts, filepath.ToSlash(indexFile), index)
 }
 
 // getFileExtension returns the appropriate file extension for the format
@@ -404,11 +785,81 @@ The doc command analyzes code structure, patterns, and functionality to generate
 detailed documentation in various formats. It can include API references,
 usage examples, and architectural overviews.
 
+A directory is documented file by file: the source files directly in it,
+or with --recursive the whole tree below it. Files git ignores are left
+out, as are hidden, vendor, node_modules and testdata directories outside a
+git repository. --include and --exclude choose the files found in
+directories by pattern, matched against the path below the directory named
+("*.go", "vendor/**"); with --include, files in any language are taken.
+
+Each file is documented separately. Its documentation is written below the
+output directory at the file's own path with the format's extension added
+(internal/cli/doc.go becomes docs/internal/cli/doc.go.md), and an index file
+in the output directory links every documented file.
+
+Existing documentation is skipped unless --update replaces it or --merge
+merges into it. Merging keeps human-owned content and regenerates the rest:
+lines from a "sigil:keep" marker to a "sigil:end" marker (usually inside
+comments) stay in their section, and sections listed under "keep:" in YAML
+front matter are carried over whole. --preview prints the proposed changes
+as a diff without writing anything.
+
+--quality-pass has a reviewer critique each file's documentation for
+accuracy, completeness and formatting, and the lead revise it once when the
+critique finds problems, before anything is written. --verify-refs checks
+the files and symbols the documentation names in inline code against the
+repository: a reference with one close match is corrected, and the rest are
+marked "(unverified)".
+
+A file that cannot be read or documented does not stop the run: the rest
+are documented, the failures are listed in an errors section of the index,
+and the command exits with an error. --fail-fast stops at the first failure
+instead.
+
+--include-symbols, --exclude-symbols and --only-exported choose the symbols
+documented, by name pattern and by whether they are exported. They are
+applied to the source before the model sees it, using the declarations of
+Go, Python, JavaScript and TypeScript files; other files cannot be filtered
+and are reported as failures. The docs section of the configuration can set
+the same defaults.
+
+Generated documents record a hash of the source they were generated from,
+in front matter for markdown and a comment for the other formats. --resume
+uses it to pick up an interrupted run: documents that are up to date are
+kept, and the missing and stale ones are regenerated. Plain text documents
+have nowhere to record the hash and are always regenerated.
+
+--examples mines each file's tests (_test.go files, Python test_ modules and
+JavaScript .test/.spec files) for usage examples of the documented symbols.
+Assertions become printed values and the rest of the test harness is left
+out; the examples that still compile in a sandbox are added to an examples
+section. Tests are not compiled in untrusted repositories.
+
+--translate also writes the documentation in other natural languages. The
+documentation is generated in --doc-language first, then translated into
+each language by a separate pass that leaves code blocks, inline code,
+links and markup untouched. Every language gets its own directory below the
+output directory (docs/en/, docs/ja/), and the index links all of them.
+
+--review audits the documentation already in the output directory instead
+of writing any. Each file is documented as usual, and the result compared
+with its existing document: files without one, exported symbols the
+generated documentation covers and the existing one omits, and names in
+inline code that no longer appear in the source are reported, along with
+the statements a model finds the code contradicts. --report writes the
+findings as markdown or JSON.
+
 Examples:
   sigil doc main.go                              # Document a single file
   sigil doc src/                                 # Document all files in directory
+  sigil doc src/ -r --exclude 'vendor/**'        # Document the whole tree
   sigil doc *.go --format html --output docs/   # Generate HTML docs
-  sigil doc project/ --include-private --template api`,
+  sigil doc project/ --include-private --template api
+  sigil doc main.go --merge --preview                # Review a merge first
+  sigil doc $(git ls-files '*.go') --resume      # Continue an interrupted run
+  sigil doc internal/store/store.go --examples   # Add examples from the tests
+  sigil doc src/ --translate ja,de               # English, Japanese and German docs
+  sigil doc src/ --review --report json:audit.json  # Audit the existing docs`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c.Files = args
@@ -423,9 +874,29 @@ Examples:
 	cmd.Flags().StringVar(&c.Template, "template", "", "Documentation template style")
 	cmd.Flags().BoolVar(&c.IncludePrivate, "include-private", false, "Include private/internal components")
 	cmd.Flags().BoolVar(&c.IncludeTests, "include-tests", false, "Include test files in documentation")
+	cmd.Flags().StringSliceVar(&c.IncludeSymbols, "include-symbols", nil, "Document only the top-level symbols matching these patterns (e.g. \"Serve*,Handler\")")
+	cmd.Flags().StringSliceVar(&c.ExcludeSymbols, "exclude-symbols", nil, "Leave out the symbols matching these patterns (e.g. \"*_test,Deprecated*\")")
+	cmd.Flags().BoolVar(&c.OnlyExported, "only-exported", false, "Document only the exported API")
 	cmd.Flags().BoolVarP(&c.Recursive, "recursive", "r", false, "Process directories recursively")
+	cmd.Flags().StringSliceVar(&c.Include, "include", nil, "Document only the files in directories matching these patterns (e.g. \"*.go,*.py\")")
+	cmd.Flags().StringSliceVar(&c.Exclude, "exclude", nil, "Leave out the files in directories matching these patterns (e.g. \"vendor/**,*_gen.go\")")
+	cmd.Flags().BoolVar(&c.Submodules, "recurse-submodules", false, "Document the files of submodules given as arguments")
 	cmd.Flags().BoolVar(&c.UpdateExisting, "update", false, "Update existing documentation files")
+	cmd.Flags().BoolVar(&c.Merge, "merge", false, "Merge into existing documentation, keeping human-owned sections")
+	cmd.Flags().BoolVar(&c.Preview, "preview", false, "Show proposed documentation changes as a diff without writing")
+	cmd.Flags().BoolVar(&c.Review, "review", false, "Audit the existing documentation against what would be generated, reporting findings instead of writing files")
+	cmd.Flags().StringArrayVar(&c.Reports, "report", nil, "Where to write the --review report, or - for stdout, optionally as format:file (markdown or json; repeatable; default: stdout)")
 	cmd.Flags().StringVar(&c.Language, "language", "", "Override language detection")
+	cmd.Flags().BoolVar(&c.VerifyRefs, "verify-refs", false, "Check the files and symbols the documentation refers to, correcting near misses and marking the rest unverified")
+	cmd.Flags().BoolVar(&c.Resume, "resume", false, "Skip files whose documentation is up to date and regenerate the missing and stale ones")
+	cmd.Flags().BoolVar(&c.Examples, "examples", false, "Add usage examples mined from the tests, keeping those that compile")
+	cmd.Flags().StringVar(&c.DocLanguage, "doc-language", "en", "Natural language to write the documentation in")
+	cmd.Flags().StringSliceVar(&c.Translate, "translate", nil, "Also translate the documentation into these languages (e.g. \"ja,de\"), one directory each")
+	cmd.Flags().BoolVar(&c.FailFast, "fail-fast", false, "Stop at the first file that fails instead of documenting the rest")
+	cmd.Flags().BoolVar(&c.QualityPass, "quality-pass", false, "Critique the documentation against a rubric and revise it once before writing")
+
+	cmd.AddCommand(NewReadmeCommand().CreateCobraCommand())
+	cmd.AddCommand(NewCLIRefCommand().CreateCobraCommand())
 
 	return cmd
 }
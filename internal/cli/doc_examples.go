@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/examples"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/sandbox"
+	"github.com/dshills/sigil/internal/trust"
+)
+
+// exampleMiner mines the tests of documented files for usage examples and
+// keeps those that still compile, checked in a sandbox. A nil miner finds
+// none.
+type exampleMiner struct {
+	repo    *git.Repository
+	manager sandbox.Manager
+	id      string
+	checks  int
+}
+
+// newExampleMiner sets up the sandbox for --examples. It is nil when
+// examples are off, and in untrusted repositories, whose tests are not
+// compiled.
+func newExampleMiner(enabled bool, id string) (*exampleMiner, error) {
+	if !enabled {
+		return nil, nil
+	}
+	if trust.Untrusted() {
+		logger.Info("untrusted repository, skipping example extraction")
+		return nil, nil
+	}
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeGit, "newExampleMiner", "failed to open git repository").
+			WithHint("--examples compiles examples in a sandbox of the repository, so run it inside one")
+	}
+	manager, err := sandbox.NewManager(repo, "go", "python3", "node")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeConfig, "newExampleMiner", "failed to create sandbox manager")
+	}
+	return &exampleMiner{repo: repo, manager: manager, id: id}, nil
+}
+
+// close removes the miner's sandboxes
+func (m *exampleMiner) close() {
+	if m == nil {
+		return
+	}
+	if err := m.manager.Cleanup(); err != nil {
+		logger.Warn("failed to cleanup sandbox manager", "error", err)
+	}
+}
+
+// mine returns the examples of the symbols in content, the documented
+// content of source, found in its tests. Examples that do not compile are
+// dropped; mining never fails a file.
+func (m *exampleMiner) mine(ctx context.Context, source, content string) []examples.Example {
+	if m == nil {
+		return nil
+	}
+	var mined []examples.Example
+	for _, testPath := range examples.TestFiles(source) {
+		testContent, err := os.ReadFile(testPath)
+		if err != nil {
+			logger.Warn("failed to read test file", "path", testPath, "error", err)
+			continue
+		}
+		found, err := examples.Extract(source, content, string(testContent))
+		if err != nil {
+			logger.Warn("failed to extract examples", "path", testPath, "error", err)
+			continue
+		}
+		if len(found) == 0 {
+			continue
+		}
+		compiled := m.compile(ctx, source, testPath, string(testContent), found)
+		logger.Info("extracted examples", "path", testPath, "found", len(found), "compiled", len(compiled))
+		mined = append(mined, compiled...)
+	}
+	return mined
+}
+
+// compile returns the examples taken from a test file that compile. They
+// are checked together first, and one by one when that fails.
+func (m *exampleMiner) compile(ctx context.Context, source, testPath, testContent string, found []examples.Example) []examples.Example {
+	if m.check(ctx, source, testPath, testContent, found) {
+		return found
+	}
+	if len(found) == 1 {
+		return nil
+	}
+	var compiled []examples.Example
+	for _, example := range found {
+		if m.check(ctx, source, testPath, testContent, []examples.Example{example}) {
+			compiled = append(compiled, example)
+		}
+	}
+	return compiled
+}
+
+// check compiles examples in a sandbox over HEAD, with the source and test
+// files applied on top as they are on disk
+func (m *exampleMiner) check(ctx context.Context, source, testPath, testContent string, found []examples.Example) bool {
+	sourceRel, err := m.relative(source)
+	if err != nil {
+		logger.Warn("file is outside the repository, skipping its examples", "path", source)
+		return false
+	}
+	testRel, err := m.relative(testPath)
+	if err != nil {
+		logger.Warn("file is outside the repository, skipping its examples", "path", testPath)
+		return false
+	}
+	sourceContent, err := os.ReadFile(source)
+	if err != nil {
+		logger.Warn("failed to read source file", "path", source, "error", err)
+		return false
+	}
+
+	check := examples.NewCheck(testRel, found)
+	files := []sandbox.FileChange{
+		{Path: sourceRel, Content: string(sourceContent), Operation: sandbox.OperationUpdate},
+		{Path: testRel, Content: testContent, Operation: sandbox.OperationUpdate},
+		{Path: check.Path, Content: check.Content, Operation: sandbox.OperationCreate},
+	}
+	steps := []sandbox.ValidationStep{{Name: "compile examples", Command: check.Command, Args: check.Args, Required: true}}
+
+	m.checks++
+	results, err := sandbox.RunValidation(ctx, m.manager, fmt.Sprintf("%s_%d", m.id, m.checks), files, steps)
+	if err != nil {
+		logger.Warn("failed to compile examples", "path", testPath, "error", err)
+		return false
+	}
+	if !sandbox.ValidationPassed(results) {
+		logger.Debug("examples do not compile", "path", testPath, "output", results[0].Output)
+		return false
+	}
+	return true
+}
+
+// relative returns path relative to the repository root
+func (m *exampleMiner) relative(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(m.repo.Root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside the repository", path)
+	}
+	return rel, nil
+}
+
+// appendExamples adds an examples section to a document of format, with
+// one code block per example
+func appendExamples(format, content string, mined []examples.Example) string {
+	if len(mined) == 0 {
+		return content
+	}
+	var b strings.Builder
+	switch format {
+	case FormatMarkdown:
+		b.WriteString("## Examples\n")
+		for _, example := range mined {
+			fmt.Fprintf(&b, "\n### %s\n\n```%s\n%s\n```\n", example.Symbol, example.Language, example.Code)
+		}
+	case FormatHTML:
+		b.WriteString("<h2>Examples</h2>\n")
+		for _, example := range mined {
+			fmt.Fprintf(&b, "<h3>%s</h3>\n<pre><code class=\"language-%s\">%s</code></pre>\n",
+				html.EscapeString(example.Symbol), example.Language, html.EscapeString(example.Code))
+		}
+		// A full page keeps the section inside its body
+		if i := strings.LastIndex(content, "</body>"); i >= 0 {
+			return content[:i] + b.String() + content[i:]
+		}
+	case "rst":
+		b.WriteString("Examples\n--------\n")
+		for _, example := range mined {
+			fmt.Fprintf(&b, "\n%s\n%s\n\n.. code-block:: %s\n\n%s\n",
+				example.Symbol, strings.Repeat("~", len(example.Symbol)), example.Language, indentLines(example.Code, "   "))
+		}
+	case "asciidoc":
+		b.WriteString("== Examples\n")
+		for _, example := range mined {
+			fmt.Fprintf(&b, "\n=== %s\n\n[source,%s]\n----\n%s\n----\n", example.Symbol, example.Language, example.Code)
+		}
+	default:
+		b.WriteString("EXAMPLES\n")
+		for _, example := range mined {
+			fmt.Fprintf(&b, "\n%s:\n\n%s\n", example.Symbol, indentLines(example.Code, "    "))
+		}
+	}
+	return strings.TrimRight(content, "\n") + "\n\n" + b.String()
+}
+
+// indentLines indents the non-empty lines of text by prefix
+func indentLines(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
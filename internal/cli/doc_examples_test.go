@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/examples"
+)
+
+func TestAppendExamples(t *testing.T) {
+	mined := []examples.Example{{Symbol: "Store.Get", Language: examples.LangGo, Code: "func ExampleStore_Get() {\n\tfmt.Println(a < b)\n}"}}
+
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		want    string
+	}{
+		{"markdown", FormatMarkdown, "# Store\n", "# Store\n\n## Examples\n\n### Store.Get\n\n```go\nfunc ExampleStore_Get() {\n\tfmt.Println(a < b)\n}\n```\n"},
+		{"html page", FormatHTML, "<html><body>\n<h1>Store</h1>\n</body></html>\n",
+			"<html><body>\n<h1>Store</h1>\n<h2>Examples</h2>\n<h3>Store.Get</h3>\n<pre><code class=\"language-go\">func ExampleStore_Get() {\n\tfmt.Println(a &lt; b)\n}</code></pre>\n</body></html>\n"},
+		{"rst", "rst", "Store\n=====\n", "Store\n=====\n\nExamples\n--------\n\nStore.Get\n~~~~~~~~~\n\n.. code-block:: go\n\n   func ExampleStore_Get() {\n   \tfmt.Println(a < b)\n   }\n"},
+		{"asciidoc", "asciidoc", "= Store\n", "= Store\n\n== Examples\n\n=== Store.Get\n\n[source,go]\n----\nfunc ExampleStore_Get() {\n\tfmt.Println(a < b)\n}\n----\n"},
+		{"text", "text", "Store\n", "Store\n\nEXAMPLES\n\nStore.Get:\n\n    func ExampleStore_Get() {\n    \tfmt.Println(a < b)\n    }\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, appendExamples(tt.format, tt.content, mined))
+		})
+	}
+	assert.Equal(t, "# Store\n", appendExamples(FormatMarkdown, "# Store\n", nil))
+}
+
+func TestNewExampleMiner_Disabled(t *testing.T) {
+	miner, err := newExampleMiner(false, "doc_examples")
+	require.NoError(t, err)
+	assert.Nil(t, miner)
+	assert.Nil(t, miner.mine(context.Background(), "store.go", "package store"), "a nil miner finds nothing")
+	miner.close()
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+// expandDirectories replaces arguments naming a directory with the files in
+// it: those directly inside, or the whole tree with --recursive. Files found
+// this way are kept when they match --include, or are source code when no
+// --include is given, and dropped when they match --exclude. Files named on
+// the command line are kept as they are.
+func (c *DocCommand) expandDirectories(files []string) ([]string, error) {
+	for _, pattern := range append(append([]string{}, c.Include...), c.Exclude...) {
+		if _, err := sandbox.MatchPath(pattern, "file"); err != nil {
+			return nil, errors.ValidationError("expandDirectories", fmt.Sprintf("invalid file pattern %q: %v", pattern, err)).
+				WithHint(`file patterns use shell-style wildcards, e.g. "*.go" or "vendor/**"`)
+		}
+	}
+
+	expanded := make([]string, 0, len(files))
+	seen := make(map[string]bool)
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			expanded = append(expanded, file)
+		}
+	}
+	for _, file := range files {
+		if info, err := os.Stat(file); err != nil || !info.IsDir() {
+			add(file)
+			continue
+		}
+
+		found, err := c.listDirectory(file)
+		if err != nil {
+			return nil, err
+		}
+		var kept int
+		for _, path := range found {
+			if c.selectFile(file, path) {
+				add(path)
+				kept++
+			}
+		}
+		if kept == 0 {
+			hint := "pass --include to choose the files to document"
+			if !c.Recursive {
+				hint = "pass --recursive to include its subdirectories, or --include to choose the files to document"
+			}
+			return nil, errors.ValidationError("expandDirectories", fmt.Sprintf("no files to document in %s", file)).
+				WithHint(hint)
+		}
+	}
+	return expanded, nil
+}
+
+// listDirectory lists the files in dir, or below it with --recursive. In a
+// git repository, files git ignores are left out; elsewhere, hidden,
+// vendor, node_modules and testdata directories are.
+func (c *DocCommand) listDirectory(dir string) ([]string, error) {
+	var files []string
+	if _, err := git.NewRepository(dir); err == nil {
+		listed, err := (&git.Repository{Path: dir}).ListFiles()
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeGit, "listDirectory",
+				fmt.Sprintf("failed to list files in %s", dir))
+		}
+		for _, path := range listed {
+			if !c.Recursive && strings.Contains(filepath.ToSlash(path), "/") {
+				continue
+			}
+			// Skip deleted files and submodules, which git still lists
+			path = filepath.Join(dir, path)
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				files = append(files, path)
+			}
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && (!c.Recursive || strings.HasPrefix(entry.Name(), ".") || skippedDirs[entry.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "listDirectory",
+			fmt.Sprintf("failed to walk directory: %s", dir))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// selectFile reports whether a file found in dir is documented. Patterns
+// are matched against its path relative to dir and as it was found, so
+// "vendor/**" excludes the vendor directory of whichever directory is given.
+func (c *DocCommand) selectFile(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		rel = path
+	}
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			for _, candidate := range []string{rel, path} {
+				if matched, _ := sandbox.MatchPath(pattern, candidate); matched {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if matches(c.Exclude) {
+		return false
+	}
+	if len(c.Include) > 0 {
+		return matches(c.Include)
+	}
+	return !strings.HasPrefix(filepath.Base(path), ".") && c.detectLanguage(path) != "text"
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocCommand_expandDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"main.go":              "package main\n",
+		"README.md":            "# app\n",
+		"api/server.go":        "package api\n",
+		"api/client.py":        "def get(): pass\n",
+		"vendor/dep/dep.go":    "package dep\n",
+		".cache/generated.go":  "package cache\n",
+		"config/settings.yaml": "debug: true\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0600))
+	}
+	in := func(paths ...string) []string {
+		for i, path := range paths {
+			paths[i] = filepath.Join(dir, path)
+		}
+		return paths
+	}
+
+	t.Run("directory without recursion", func(t *testing.T) {
+		c := NewDocCommand()
+		files, err := c.expandDirectories([]string{dir})
+		require.NoError(t, err)
+		assert.Equal(t, in("main.go"), files)
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		c := NewDocCommand()
+		c.Recursive = true
+		files, err := c.expandDirectories([]string{dir})
+		require.NoError(t, err)
+		assert.Equal(t, in("api/client.py", "api/server.go", "main.go"), files)
+	})
+
+	t.Run("include and exclude", func(t *testing.T) {
+		c := NewDocCommand()
+		c.Recursive = true
+		c.Include = []string{"*.go", "*.yaml"}
+		c.Exclude = []string{"api/**"}
+		files, err := c.expandDirectories([]string{dir})
+		require.NoError(t, err)
+		assert.Equal(t, in("config/settings.yaml", "main.go"), files)
+	})
+
+	t.Run("explicit files are kept once", func(t *testing.T) {
+		c := NewDocCommand()
+		files, err := c.expandDirectories(append(in("README.md", "main.go"), dir))
+		require.NoError(t, err)
+		assert.Equal(t, in("README.md", "main.go"), files)
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		c := NewDocCommand()
+		_, err := c.expandDirectories([]string{filepath.Join(dir, "config")})
+		assert.ErrorContains(t, err, "no files to document")
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		c := NewDocCommand()
+		c.Exclude = []string{"[a-"}
+		_, err := c.expandDirectories([]string{dir})
+		assert.ErrorContains(t, err, "invalid file pattern")
+	})
+}
+
+func TestDocCommand_expandDirectories_Gitignore(t *testing.T) {
+	dir := t.TempDir()
+	gitCommit(t, dir, map[string]string{
+		".gitignore":       "gen/\n",
+		"cmd/tool/main.go": "package main\n",
+		"lib.go":           "package lib\n",
+	})
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "gen"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gen", "api.go"), []byte("package gen\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.go"), []byte("package lib\n"), 0600))
+
+	c := NewDocCommand()
+	c.Recursive = true
+	files, err := c.expandDirectories([]string{dir})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "cmd/tool/main.go"),
+		filepath.Join(dir, "lib.go"),
+		filepath.Join(dir, "new.go"),
+	}, files, "ignored files are skipped and untracked ones kept")
+}
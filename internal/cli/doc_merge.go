@@ -0,0 +1,331 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// keepMarker opens a human-owned region that merges carry over verbatim
+	keepMarker = "sigil:keep"
+
+	// keepEndMarker closes a human-owned region
+	keepEndMarker = "sigil:end"
+
+	// diffContext is the number of unchanged lines shown around a change
+	diffContext = 3
+)
+
+// docSection is a heading and the lines below it up to the next heading.
+// The lines before the first heading form a section with an empty heading.
+type docSection struct {
+	Heading string
+	Lines   []string
+}
+
+// key identifies the section by its heading text
+func (s docSection) key() string {
+	return headingKey(s.Heading)
+}
+
+// parsedDoc is a document split into front matter and sections
+type parsedDoc struct {
+	FrontMatter []string
+	Keep        []string
+	Sections    []docSection
+}
+
+// keepRegion is a human-owned region and the section it belongs to
+type keepRegion struct {
+	Section string
+	Lines   []string
+}
+
+// mergeDoc combines regenerated documentation with an existing document.
+// Human-owned content survives: regions between sigil:keep and sigil:end
+// markers return to the section they were in, and sections named in a
+// front-matter keep list are carried over whole. Everything else is taken
+// from the generated document.
+func mergeDoc(existing, generated string) string {
+	old := parseDoc(existing)
+	fresh := parseDoc(generated)
+
+	keep := make(map[string]bool, len(old.Keep))
+	for _, heading := range old.Keep {
+		keep[headingKey(heading)] = true
+	}
+	oldSections := make(map[string]docSection, len(old.Sections))
+	for _, section := range old.Sections {
+		if _, ok := oldSections[section.key()]; !ok {
+			oldSections[section.key()] = section
+		}
+	}
+
+	regions := old.keepRegions(keep)
+	placed := make([]bool, len(regions))
+	used := make(map[string]bool)
+
+	var lines []string
+	frontMatter := old.FrontMatter
+	if len(frontMatter) == 0 {
+		frontMatter = fresh.FrontMatter
+	}
+	lines = append(lines, frontMatter...)
+
+	for _, section := range fresh.Sections {
+		key := section.key()
+		if oldSection, ok := oldSections[key]; ok && keep[key] && !used[key] {
+			used[key] = true
+			lines = append(lines, sectionLines(oldSection)...)
+			continue
+		}
+
+		body := section.Lines
+		for i, region := range regions {
+			if placed[i] || region.Section != key {
+				continue
+			}
+			placed[i] = true
+			if !containsLines(body, region.Lines) {
+				body = appendBlock(body, region.Lines)
+			}
+		}
+		lines = append(lines, sectionLines(docSection{Heading: section.Heading, Lines: body})...)
+	}
+
+	// Human-owned content whose section was not regenerated goes at the end
+	for _, section := range old.Sections {
+		if key := section.key(); keep[key] && !used[key] {
+			used[key] = true
+			lines = appendBlock(lines, sectionLines(section))
+		}
+	}
+	for i, region := range regions {
+		if !placed[i] {
+			lines = appendBlock(lines, region.Lines)
+		}
+	}
+
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
+
+// parseDoc splits a document into front matter and heading sections
+func parseDoc(content string) parsedDoc {
+	var doc parsedDoc
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) != "---" {
+				continue
+			}
+			var meta struct {
+				Keep []string `yaml:"keep"`
+			}
+			if err := yaml.Unmarshal([]byte(strings.Join(lines[1:i], "\n")), &meta); err == nil {
+				doc.Keep = meta.Keep
+			}
+			doc.FrontMatter = lines[:i+1]
+			lines = lines[i+1:]
+			break
+		}
+	}
+
+	current := docSection{}
+	inFence := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if !inFence && isHeading(line) {
+			if current.Heading != "" || len(current.Lines) > 0 {
+				doc.Sections = append(doc.Sections, current)
+			}
+			current = docSection{Heading: line}
+			continue
+		}
+		current.Lines = append(current.Lines, line)
+	}
+	if current.Heading != "" || len(current.Lines) > 0 {
+		doc.Sections = append(doc.Sections, current)
+	}
+	return doc
+}
+
+// keepRegions returns the marked regions outside sections kept whole
+func (d parsedDoc) keepRegions(keptSections map[string]bool) []keepRegion {
+	var regions []keepRegion
+	for _, section := range d.Sections {
+		if keptSections[section.key()] {
+			continue
+		}
+		start := -1
+		for i, line := range section.Lines {
+			switch {
+			case start < 0 && strings.Contains(line, keepMarker):
+				start = i
+			case start >= 0 && strings.Contains(line, keepEndMarker):
+				regions = append(regions, keepRegion{Section: section.key(), Lines: section.Lines[start : i+1]})
+				start = -1
+			}
+		}
+		// An unterminated region runs to the end of its section
+		if start >= 0 {
+			regions = append(regions, keepRegion{Section: section.key(), Lines: trimTrailingBlank(section.Lines[start:])})
+		}
+	}
+	return regions
+}
+
+// isHeading reports whether a line is a Markdown or AsciiDoc heading
+func isHeading(line string) bool {
+	for _, marker := range []byte{'#', '='} {
+		level := 0
+		for level < len(line) && line[level] == marker {
+			level++
+		}
+		if level > 0 && level <= 6 && level < len(line) && line[level] == ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// headingKey normalizes a heading line, or a keep list entry, for matching
+func headingKey(heading string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(heading), "#=")))
+}
+
+// sectionLines returns a section's heading followed by its lines
+func sectionLines(section docSection) []string {
+	if section.Heading == "" {
+		return section.Lines
+	}
+	return append([]string{section.Heading}, section.Lines...)
+}
+
+// appendBlock appends block after lines, separated by one blank line
+func appendBlock(lines, block []string) []string {
+	result := trimTrailingBlank(lines)
+	if len(result) > 0 {
+		result = append(result, "")
+	}
+	return append(append(result, block...), "")
+}
+
+// trimTrailingBlank returns lines without trailing blank lines
+func trimTrailingBlank(lines []string) []string {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return append([]string(nil), lines[:end]...)
+}
+
+// containsLines reports whether block appears contiguously in lines
+func containsLines(lines, block []string) bool {
+	return strings.Contains(strings.Join(lines, "\n"), strings.Join(block, "\n"))
+}
+
+// unifiedDiff returns a unified diff from original to modified, or an empty
+// string when they are equal
+func unifiedDiff(original, modified, filename string) string {
+	if original == modified {
+		return ""
+	}
+	a := splitLines(original)
+	b := splitLines(modified)
+
+	// Longest common subsequence table over line suffixes
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type edit struct {
+		op   byte
+		line string
+		a, b int
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	var diff strings.Builder
+	diff.WriteString(fmt.Sprintf("--- a/%s\n+++ b/%s\n", filename, filename))
+
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			start++
+			continue
+		}
+
+		// Grow the hunk until diffContext*2 unchanged lines separate changes
+		first := max(start-diffContext, 0)
+		end := start
+		for k := start; k < len(edits); k++ {
+			if edits[k].op != ' ' {
+				end = k
+			} else if k-end > diffContext*2 {
+				break
+			}
+		}
+		last := min(end+diffContext, len(edits)-1)
+
+		var oldCount, newCount int
+		for _, e := range edits[first : last+1] {
+			if e.op != '+' {
+				oldCount++
+			}
+			if e.op != '-' {
+				newCount++
+			}
+		}
+		diff.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", hunkStart(edits[first].a, oldCount), oldCount, hunkStart(edits[first].b, newCount), newCount))
+		for _, e := range edits[first : last+1] {
+			diff.WriteString(fmt.Sprintf("%c%s\n", e.op, e.line))
+		}
+		start = last + 1
+	}
+	return diff.String()
+}
+
+// hunkStart converts a zero-based line index to a hunk header line number
+func hunkStart(index, count int) int {
+	if count == 0 {
+		return index
+	}
+	return index + 1
+}
+
+// splitLines splits text into lines without a trailing empty line
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
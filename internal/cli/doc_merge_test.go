@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeDoc_KeepRegions(t *testing.T) {
+	existing := `# server.go
+
+Old overview.
+
+## Usage
+
+Old usage.
+
+<!-- sigil:keep -->
+Call Start before serving; see the runbook.
+<!-- sigil:end -->
+
+## Internals
+
+Old internals.
+`
+	generated := `# server.go
+
+New overview.
+
+## Usage
+
+New usage.
+
+## Internals
+
+New internals.
+`
+
+	merged := mergeDoc(existing, generated)
+
+	assert.Equal(t, `# server.go
+
+New overview.
+
+## Usage
+
+New usage.
+
+<!-- sigil:keep -->
+Call Start before serving; see the runbook.
+<!-- sigil:end -->
+
+## Internals
+
+New internals.
+`, merged)
+}
+
+func TestMergeDoc_FrontMatterKeep(t *testing.T) {
+	existing := `---
+keep: [Design Notes, History]
+---
+# cache.go
+
+Old overview.
+
+## Design Notes
+
+Written by hand.
+
+## History
+
+Kept too.
+`
+	generated := `# cache.go
+
+New overview.
+
+## Design Notes
+
+Generated notes.
+`
+
+	merged := mergeDoc(existing, generated)
+
+	assert.Equal(t, `---
+keep: [Design Notes, History]
+---
+# cache.go
+
+New overview.
+
+## Design Notes
+
+Written by hand.
+
+## History
+
+Kept too.
+`, merged)
+}
+
+func TestMergeDoc_OrphanedRegion(t *testing.T) {
+	existing := "# a.go\n\n## Removed\n\n<!-- sigil:keep -->\nnote\n<!-- sigil:end -->\n"
+	generated := "# a.go\n\nOverview.\n```\n# not a heading\n```\n"
+
+	merged := mergeDoc(existing, generated)
+
+	assert.Equal(t, "# a.go\n\nOverview.\n```\n# not a heading\n```\n\n<!-- sigil:keep -->\nnote\n<!-- sigil:end -->\n", merged)
+	assert.Equal(t, merged, mergeDoc(merged, generated), "merging again changes nothing")
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	assert.Empty(t, unifiedDiff("same\n", "same\n", "a.md"))
+
+	original := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n"
+	modified := "1\n2\n3\n4\n5\nsix\n7\n8\n9\n10\n11\n12\n13\n14\nfifteen\n"
+
+	assert.Equal(t, `--- a/a.md
++++ b/a.md
+@@ -3,7 +3,7 @@
+ 3
+ 4
+ 5
+-6
++six
+ 7
+ 8
+ 9
+@@ -12,3 +12,4 @@
+ 12
+ 13
+ 14
++fifteen
+`, unifiedDiff(original, modified, "a.md"))
+
+	assert.Equal(t, "--- a/new.md\n+++ b/new.md\n@@ -0,0 +1,1 @@\n+hello\n", unifiedDiff("", "hello\n", "new.md"))
+}
+
+func TestDocCommand_writeDocFile_Merge(t *testing.T) {
+	tmpDir := t.TempDir()
+	existingFile := filepath.Join(tmpDir, "a.go.md")
+	existing := "# a.go\n\nOld.\n\n<!-- sigil:keep -->\nmine\n<!-- sigil:end -->\n"
+	require.NoError(t, os.WriteFile(existingFile, []byte(existing), 0644))
+
+	cmd := NewDocCommand()
+	cmd.OutputDir = tmpDir
+	cmd.Merge = true
+
+	// Preview leaves the file untouched
+	cmd.Preview = true
+	entry, err := cmd.writeDocFile("a.go", "# a.go\n\nNew.\n")
+	require.NoError(t, err)
+	assert.False(t, entry.Written)
+	content, err := os.ReadFile(existingFile)
+	require.NoError(t, err)
+	assert.Equal(t, existing, string(content))
+
+	cmd.Preview = false
+	entry, err = cmd.writeDocFile("a.go", "# a.go\n\nNew.\n")
+	require.NoError(t, err)
+	assert.True(t, entry.Written)
+	content, err = os.ReadFile(existingFile)
+	require.NoError(t, err)
+	assert.Equal(t, "# a.go\n\nNew.\n\n<!-- sigil:keep -->\nmine\n<!-- sigil:end -->\n", string(content))
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"strings"
+)
+
+// sourceHashKey names the hash of the source a document was generated from
+const sourceHashKey = "source_hash"
+
+// sourceHashComment returns the comment markers the source hash is put
+// between in formats without front matter. Plain text has no comments, so
+// text documents are not stamped.
+func sourceHashComment(format string) (prefix, suffix string, ok bool) {
+	switch format {
+	case FormatHTML:
+		return "<!-- ", " -->", true
+	case "rst":
+		return ".. ", "", true
+	case "asciidoc":
+		return "// ", "", true
+	default:
+		return "", "", false
+	}
+}
+
+// stampSourceHash records hash, the hash of the source content, in a
+// document of format, replacing any hash recorded before. Markdown
+// documents get it in their front matter, which is added if missing; the
+// other formats in a comment on the first line.
+func stampSourceHash(format, content, hash string) string {
+	stamp := sourceHashKey + ": " + hash
+	lines := strings.Split(content, "\n")
+
+	if format != FormatMarkdown {
+		prefix, suffix, ok := sourceHashComment(format)
+		if !ok {
+			return content
+		}
+		if docSourceHash(format, content) != "" {
+			lines = lines[1:]
+		}
+		return prefix + stamp + suffix + "\n" + strings.Join(lines, "\n")
+	}
+
+	end := frontMatterEnd(lines)
+	if end == 0 {
+		return "---\n" + stamp + "\n---\n" + content
+	}
+	for i := 1; i < end; i++ {
+		if strings.HasPrefix(lines[i], sourceHashKey+":") {
+			lines[i] = stamp
+			return strings.Join(lines, "\n")
+		}
+	}
+	return strings.Join(append([]string{lines[0], stamp}, lines[1:]...), "\n")
+}
+
+// docSourceHash returns the source hash recorded in a document of format,
+// or "" when it has none
+func docSourceHash(format, content string) string {
+	lines := strings.Split(content, "\n")
+	if format == FormatMarkdown {
+		for i := 1; i < frontMatterEnd(lines); i++ {
+			if value, ok := strings.CutPrefix(lines[i], sourceHashKey+":"); ok {
+				return strings.TrimSpace(value)
+			}
+		}
+		return ""
+	}
+
+	prefix, suffix, ok := sourceHashComment(format)
+	if !ok {
+		return ""
+	}
+	value, ok := strings.CutPrefix(lines[0], prefix+sourceHashKey+":")
+	if !ok || !strings.HasSuffix(value, suffix) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimSuffix(value, suffix))
+}
+
+// frontMatterEnd returns the index of the line closing the front matter
+// lines open with, or 0 when they have none
+func frontMatterEnd(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return i
+		}
+	}
+	return 0
+}
+
+// upToDate reports whether the documentation of source exists and was
+// generated from its current content, so --resume can skip it
+func (c *DocCommand) upToDate(source string) (docEntry, bool) {
+	return c.upToDateIn(c.DocLanguage, source)
+}
+
+// upToDateIn reports whether the documentation of source in language is up
+// to date, as upToDate does
+func (c *DocCommand) upToDateIn(language, source string) (docEntry, bool) {
+	entry := docEntry{Source: source, DocPath: c.docPathIn(language, source), Language: language}
+	hash := c.sourceHashes[source]
+	if hash == "" || !c.fileExists(entry.DocPath) {
+		return entry, false
+	}
+	existing, err := c.readFile(entry.DocPath)
+	if err != nil {
+		return entry, false
+	}
+	entry.content = existing
+	return entry, docSourceHash(c.Format, existing) == hash
+}
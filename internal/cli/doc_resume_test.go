@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStampSourceHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		want    string
+	}{
+		{"markdown", FormatMarkdown, "# Server\n", "---\nsource_hash: abc\n---\n# Server\n"},
+		{"markdown front matter", FormatMarkdown, "---\nkeep: [Notes]\n---\n# Server\n", "---\nsource_hash: abc\nkeep: [Notes]\n---\n# Server\n"},
+		{"html", FormatHTML, "<h1>Server</h1>\n", "<!-- source_hash: abc -->\n<h1>Server</h1>\n"},
+		{"rst", "rst", "Server\n======\n", ".. source_hash: abc\nServer\n======\n"},
+		{"asciidoc", "asciidoc", "= Server\n", "// source_hash: abc\n= Server\n"},
+		{"text", "text", "Server\n", "Server\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stamped := stampSourceHash(tt.format, tt.content, "abc")
+			assert.Equal(t, tt.want, stamped)
+			assert.Equal(t, stamped, stampSourceHash(tt.format, stamped, "abc"), "stamping again changes nothing")
+
+			restamped := stampSourceHash(tt.format, stamped, "def")
+			if tt.format == "text" {
+				assert.Empty(t, docSourceHash(tt.format, restamped))
+				return
+			}
+			assert.Equal(t, "abc", docSourceHash(tt.format, stamped))
+			assert.Equal(t, "def", docSourceHash(tt.format, restamped), "a new hash replaces the old one")
+		})
+	}
+	assert.Empty(t, docSourceHash(FormatMarkdown, "# Server\n\nsource_hash: abc\n"), "only front matter counts")
+}
+
+func TestDocCommand_writeDocFile_Resume(t *testing.T) {
+	tmpDir := t.TempDir()
+	cmd := NewDocCommand()
+	cmd.OutputDir = tmpDir
+	cmd.sourceHashes = map[string]string{"main.go": "v1"}
+
+	_, ok := cmd.upToDate("main.go")
+	assert.False(t, ok, "missing documentation is generated")
+	entry, err := cmd.writeDocFile("main.go", "# Main\n")
+	require.NoError(t, err)
+	assert.True(t, entry.Written)
+	_, ok = cmd.upToDate("main.go")
+	assert.True(t, ok)
+
+	// The source changed: the stale document is replaced under --resume
+	cmd.sourceHashes["main.go"] = "v2"
+	_, ok = cmd.upToDate("main.go")
+	assert.False(t, ok)
+	entry, err = cmd.writeDocFile("main.go", "# Main, revised\n")
+	require.NoError(t, err)
+	assert.False(t, entry.Written, "without --resume or --update the existing document stays")
+
+	cmd.Resume = true
+	entry, err = cmd.writeDocFile("main.go", "# Main, revised\n")
+	require.NoError(t, err)
+	assert.True(t, entry.Written)
+	content, err := os.ReadFile(filepath.Join(tmpDir, "main.go.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\nsource_hash: v2\n---\n# Main, revised\n", string(content))
+
+	// Merging keeps the old front matter but records the new hash
+	cmd.Resume, cmd.Merge = false, true
+	cmd.sourceHashes["main.go"] = "v3"
+	_, err = cmd.writeDocFile("main.go", "# Main\n")
+	require.NoError(t, err)
+	_, ok = cmd.upToDate("main.go")
+	assert.True(t, ok)
+}
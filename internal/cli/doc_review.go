@@ -0,0 +1,347 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/glossary"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/outline"
+)
+
+// Types of documentation review findings
+const (
+	docFindingMissing    agent.CommentType = "missing_doc"
+	docFindingOmission   agent.CommentType = "omission"
+	docFindingStale      agent.CommentType = "stale_reference"
+	docFindingInaccuracy agent.CommentType = "inaccuracy"
+)
+
+// docReviewFormats are the formats a documentation review is reported in
+var docReviewFormats = []string{FormatMarkdown, string(OutputFormatJSON)}
+
+// docReviewTypes orders the finding types in report counts
+var docReviewTypes = []agent.CommentType{docFindingMissing, docFindingOmission, docFindingStale, docFindingInaccuracy}
+
+// inlineCodeRef matches inline code naming a symbol, such as `Open`,
+// `store.Open` or `Open()`
+var inlineCodeRef = regexp.MustCompile("`([A-Za-z_][A-Za-z0-9_]*(?:\\.[A-Za-z_][A-Za-z0-9_]*)*)(?:\\(\\))?`")
+
+// docAuditRequirement asks for the inaccuracies of documentation as
+// findings
+const docAuditRequirement = "List every inaccuracy in a ```json fenced block as an array of objects with the fields " +
+	"line (of the documentation), severity (error, warning or info), message, suggestion and evidence, " +
+	"the documentation line copied verbatim. Use an empty array when the documentation is accurate."
+
+// docReview is what a documentation review found
+type docReview struct {
+	auditor  model.Model // Checks documentation against code, loaded on first use
+	files    []string
+	findings []agent.ReviewComment
+}
+
+// validateReview checks the --review and --report flags
+func (c *DocCommand) validateReview() error {
+	if !c.Review {
+		if len(c.Reports) > 0 {
+			return errors.ValidationError("validateReview", "--report needs --review")
+		}
+		return nil
+	}
+
+	for flag, set := range map[string]bool{"--update": c.UpdateExisting, "--merge": c.Merge, "--preview": c.Preview,
+		"--resume": c.Resume, "--translate": len(c.Translate) > 0} {
+		if set {
+			return errors.ValidationError("validateReview", fmt.Sprintf("--review cannot be combined with %s", flag)).
+				WithHint("--review reports on the existing documentation and writes none")
+		}
+	}
+
+	targets, err := parseOutputs(c.Reports, FormatMarkdown, JSONVersion1, docReviewFormats)
+	if err != nil {
+		return err
+	}
+	for _, target := range targets {
+		if target.Version == JSONVersion2 {
+			return errors.ValidationError("validateReview", "documentation reviews are reported in JSON version 1 only").
+				WithHint("use json: rather than json@v2:")
+		}
+	}
+	return nil
+}
+
+// reviewDocumentation compares the documentation generated for source with
+// the existing documentation, recording what is missing, omitted, stale or
+// wrong in it
+func (c *DocCommand) reviewDocumentation(ctx context.Context, source string, result *agent.OrchestrationResult) (docEntry, error) {
+	generated, err := c.generatedContent(source, result)
+	if err != nil {
+		return docEntry{}, err
+	}
+	entry := docEntry{Source: source, DocPath: c.docPath(source), Language: c.DocLanguage, content: generated}
+	c.docReview.files = append(c.docReview.files, source)
+
+	if !c.fileExists(entry.DocPath) {
+		c.docReview.findings = append(c.docReview.findings, agent.ReviewComment{
+			Type:       docFindingMissing,
+			Severity:   agent.SeverityWarning,
+			Path:       entry.DocPath,
+			Message:    fmt.Sprintf("%s has no documentation", source),
+			Suggestion: "generate it with sigil doc",
+		})
+		return entry, nil
+	}
+	existing, err := c.readFile(entry.DocPath)
+	if err != nil {
+		return entry, errors.Wrap(err, errors.ErrorTypeFS, "reviewDocumentation",
+			fmt.Sprintf("failed to read documentation: %s", entry.DocPath))
+	}
+	// Stale references are checked against the whole source, private
+	// symbols included
+	code, err := c.readFile(source)
+	if err != nil {
+		return entry, errors.Wrap(err, errors.ErrorTypeInput, "reviewDocumentation",
+			fmt.Sprintf("failed to read file: %s", source))
+	}
+
+	c.docReview.findings = append(c.docReview.findings, compareDocs(source, code, entry.DocPath, existing, generated)...)
+	inaccuracies, err := c.auditDoc(ctx, source, code, entry.DocPath, existing, generated)
+	if err != nil {
+		// The structural comparison still stands
+		logger.Warn("skipping accuracy audit of documentation", "path", entry.DocPath, "error", err)
+	}
+	c.docReview.findings = append(c.docReview.findings, inaccuracies...)
+	return entry, nil
+}
+
+// compareDocs compares existing documentation with the documentation
+// generated for the same source: the source's exported symbols that the
+// generated documentation covers and the existing one does not, and names
+// in the existing documentation's inline code that neither the source nor
+// the generated documentation has
+func compareDocs(source, code, docPath, existing, generated string) []agent.ReviewComment {
+	var found []agent.ReviewComment
+
+	if outline.Supported(source) {
+		seen := make(map[string]bool)
+		for _, symbol := range outline.Extract(source, code) {
+			if !symbol.Exported() || seen[symbol.Name] {
+				continue
+			}
+			seen[symbol.Name] = true
+			if mentions(generated, symbol.Name) && !mentions(existing, symbol.Name) {
+				found = append(found, agent.ReviewComment{
+					Type:     docFindingOmission,
+					Severity: agent.SeverityInfo,
+					Path:     docPath,
+					Message:  fmt.Sprintf("The documentation does not cover %s %s", symbol.Kind, symbol.Name),
+					Context:  fmt.Sprintf("%s:%d", source, symbol.Line),
+				})
+			}
+		}
+	}
+
+	stale := make(map[string]bool)
+	fenced := false
+	for i, line := range strings.Split(existing, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			// Examples declare names of their own
+			continue
+		}
+		for _, match := range inlineCodeRef.FindAllStringSubmatch(line, -1) {
+			name := match[1]
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				name = name[i+1:]
+			}
+			if stale[name] || !symbolLike(match[0]) || mentions(code, name) || mentions(generated, name) {
+				continue
+			}
+			stale[name] = true
+			found = append(found, agent.ReviewComment{
+				Type:       docFindingStale,
+				Severity:   agent.SeverityWarning,
+				Path:       docPath,
+				Line:       i + 1,
+				Message:    fmt.Sprintf("%s is not in %s; the documentation may describe code that has changed", match[1], source),
+				Suggestion: "update or remove the reference",
+				Evidence:   line,
+			})
+		}
+	}
+	return found
+}
+
+// symbolLike reports whether inline code looks like a code symbol rather
+// than a plain word such as `true` or `json`: a call, a qualified name, or
+// a name with capitals or underscores
+func symbolLike(code string) bool {
+	return strings.HasSuffix(code, "()`") || strings.ContainsAny(code, "._") ||
+		strings.ToLower(code) != code
+}
+
+// mentions reports whether text has name as a whole word
+func mentions(text, name string) bool {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`).MatchString(text)
+}
+
+// auditDoc asks a model for the statements of existing documentation that
+// the code contradicts, with the generated documentation as a reference
+func (c *DocCommand) auditDoc(ctx context.Context, source, code, docPath, existing, generated string) ([]agent.ReviewComment, error) {
+	if c.docReview.auditor == nil {
+		mdl, err := c.GetModel(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeModel, "auditDoc", "failed to get model")
+		}
+		c.docReview.auditor = mdl
+	}
+
+	lines := strings.Split(existing, "\n")
+	var numbered strings.Builder
+	for i, line := range lines {
+		numbered.WriteString(fmt.Sprintf("%d: %s\n", i+1, line))
+	}
+	var userPrompt strings.Builder
+	userPrompt.WriteString(fmt.Sprintf("Code (%s):\n%s\n", source, model.FenceUntrusted(source, code)))
+	userPrompt.WriteString(fmt.Sprintf("Documentation (%s), with line numbers:\n%s\n", docPath, model.FenceUntrusted(docPath, numbered.String())))
+	userPrompt.WriteString(fmt.Sprintf("Documentation generated from the code, for reference:\n%s\n",
+		model.FenceUntrusted("generated documentation", generated)))
+	userPrompt.WriteString(docAuditRequirement)
+
+	response, err := c.docReview.auditor.RunPrompt(ctx, model.PromptInput{
+		SystemPrompt: glossary.Instruct("You audit human-written documentation against the code it documents. " +
+			"Report only statements in the documentation that the code contradicts, such as wrong behavior, " +
+			"signatures, parameters, defaults, errors or examples. Do not report style, wording or missing topics.\n\n" +
+			model.UntrustedContentRule),
+		UserPrompt:  userPrompt.String(),
+		Sources:     map[string][]string{"generated documentation": {source}},
+		MaxTokens:   2000,
+		Temperature: 0.1,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeModel, "auditDoc", "model execution failed")
+	}
+
+	inaccuracies := parseFindings(response.Response)
+	for i := range inaccuracies {
+		finding := &inaccuracies[i]
+		finding.Type, finding.Path = docFindingInaccuracy, docPath
+		if _, ok := severityRanks[finding.Severity]; !ok {
+			finding.Severity = agent.SeverityWarning
+		}
+		if finding.Line < 1 || finding.Line > len(lines) {
+			finding.Line, finding.EndLine = 0, 0
+		}
+	}
+	return inaccuracies, nil
+}
+
+// docReviewReport is a documentation review as reported in JSON
+type docReviewReport struct {
+	Timestamp string                `json:"timestamp"`
+	Files     []string              `json:"files"`
+	Counts    map[string]int        `json:"counts"`
+	Findings  []agent.ReviewComment `json:"findings"`
+	Errors    []fileFailure         `json:"errors"`
+}
+
+// writeDocReview writes the review's report to each --report target
+func (c *DocCommand) writeDocReview(ctx context.Context) error {
+	targets, err := parseOutputs(c.Reports, FormatMarkdown, JSONVersion1, docReviewFormats)
+	if err != nil {
+		return err
+	}
+	return writeOutputs(ctx, os.Stdout, targets, "Documentation review", func(target outputTarget) (string, error) {
+		if target.Format == string(OutputFormatJSON) {
+			return c.formatDocReviewJSON()
+		}
+		return c.formatDocReviewMarkdown(), nil
+	}, c.writeFile)
+}
+
+// docReviewCounts counts the review's findings by type
+func (c *DocCommand) docReviewCounts() map[string]int {
+	counts := make(map[string]int, len(docReviewTypes))
+	for _, kind := range docReviewTypes {
+		counts[string(kind)] = 0
+	}
+	for _, finding := range c.docReview.findings {
+		counts[string(finding.Type)]++
+	}
+	return counts
+}
+
+// formatDocReviewJSON formats the review as JSON
+func (c *DocCommand) formatDocReviewJSON() (string, error) {
+	report := docReviewReport{
+		Timestamp: c.startTime.Format("2006-01-02T15:04:05Z07:00"),
+		Files:     c.docReview.files,
+		Counts:    c.docReviewCounts(),
+		Findings:  c.docReview.findings,
+		Errors:    c.failures.list(),
+	}
+	if report.Files == nil {
+		report.Files = []string{}
+	}
+	if report.Findings == nil {
+		report.Findings = []agent.ReviewComment{}
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{"doc_review": report}, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeOutput, "formatDocReviewJSON", "failed to marshal report")
+	}
+	return string(data) + "\n", nil
+}
+
+// formatDocReviewMarkdown formats the review as markdown, with the
+// findings of each document together
+func (c *DocCommand) formatDocReviewMarkdown() string {
+	var output strings.Builder
+	output.WriteString("# Documentation Review\n\n")
+	output.WriteString(fmt.Sprintf("**Files Reviewed:** %d\n", len(c.docReview.files)))
+
+	counts := c.docReviewCounts()
+	output.WriteString(fmt.Sprintf("**Findings:** %d (%d missing, %d omissions, %d stale references, %d inaccuracies)\n",
+		len(c.docReview.findings), counts[string(docFindingMissing)], counts[string(docFindingOmission)],
+		counts[string(docFindingStale)], counts[string(docFindingInaccuracy)]))
+
+	var paths []string
+	byPath := make(map[string][]agent.ReviewComment)
+	for _, finding := range c.docReview.findings {
+		if _, ok := byPath[finding.Path]; !ok {
+			paths = append(paths, finding.Path)
+		}
+		byPath[finding.Path] = append(byPath[finding.Path], finding)
+	}
+	for _, path := range paths {
+		output.WriteString(fmt.Sprintf("\n## %s\n\n", path))
+		for _, finding := range byPath[path] {
+			output.WriteString(fmt.Sprintf("- **%s** `%s` %s", finding.Severity, findingLocation(finding), finding.Message))
+			if finding.Context != "" {
+				output.WriteString(fmt.Sprintf(" (`%s`)", finding.Context))
+			}
+			if finding.Suggestion != "" {
+				output.WriteString(fmt.Sprintf(": %s", finding.Suggestion))
+			}
+			output.WriteString("\n")
+		}
+	}
+
+	if failures := c.failures.list(); len(failures) > 0 {
+		output.WriteString("\n## Errors\n\n")
+		for _, failure := range failures {
+			output.WriteString(fmt.Sprintf("- `%s`: %s\n", failure.Path, failure.Error))
+		}
+	}
+	return output.String()
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+)
+
+const storeSource = `package store
+
+// Open opens the store
+func Open(path string) (*Store, error) { return nil, nil }
+
+// Close closes the store
+func (s *Store) Close() error { return nil }
+
+func helper() {}
+
+type Store struct{}
+`
+
+const storeDoc = `# store
+
+Call ` + "`store.Open`" + ` to open a store, then ` + "`Reset()`" + ` it.
+Open returns ` + "`nil`" + ` on success.
+
+` + "```go" + `
+s := store.Open("db")
+` + "`Example`" + `
+` + "```" + `
+`
+
+func TestCompareDocs(t *testing.T) {
+	generated := "# store\n\n`Open` opens the store, `Close` closes it and `Store` holds it.\n"
+	found := compareDocs("store.go", storeSource, "docs/store.go.md", storeDoc, generated)
+
+	require.Len(t, found, 3)
+	assert.Equal(t, docFindingOmission, found[0].Type)
+	assert.Equal(t, "The documentation does not cover method Close", found[0].Message)
+	assert.Equal(t, "store.go:7", found[0].Context)
+	assert.Equal(t, "Store", found[1].Message[len(found[1].Message)-5:])
+
+	stale := found[2]
+	assert.Equal(t, docFindingStale, stale.Type)
+	assert.Equal(t, 3, stale.Line)
+	assert.Contains(t, stale.Message, "Reset is not in store.go")
+	assert.Equal(t, agent.SeverityWarning, stale.Severity)
+}
+
+func TestDocCommand_reviewDocumentation(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { _ = os.Chdir(previous) }()
+
+	require.NoError(t, os.WriteFile("store.go", []byte(storeSource), 0644))
+	require.NoError(t, os.WriteFile("cache.go", []byte("package store\n"), 0644))
+	require.NoError(t, os.MkdirAll("docs", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("docs", "store.go.md"), []byte(storeDoc), 0644))
+
+	cmd := NewDocCommand()
+	cmd.Review = true
+	cmd.startTime = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	auditor := &scriptedModel{responses: []string{"```json\n" +
+		`[{"line": 4, "severity": "bogus", "message": "Open returns an error, not nil", "evidence": "Open returns nil on success."}]` +
+		"\n```"}}
+	cmd.docReview.auditor = auditor
+
+	result := func(doc string) *agent.OrchestrationResult {
+		return &agent.OrchestrationResult{FinalResult: &agent.Result{Reasoning: doc}}
+	}
+	_, err = cmd.reviewDocumentation(context.Background(), "cache.go", result("# cache\n"))
+	require.NoError(t, err)
+	entry, err := cmd.reviewDocumentation(context.Background(), "store.go", result("`Open` and `Close`.\n"))
+	require.NoError(t, err)
+	assert.False(t, entry.Written)
+
+	findings := cmd.docReview.findings
+	require.Len(t, findings, 4)
+	assert.Equal(t, docFindingMissing, findings[0].Type)
+	assert.Equal(t, filepath.Join("docs", "cache.go.md"), findings[0].Path)
+	inaccuracy := findings[3]
+	assert.Equal(t, docFindingInaccuracy, inaccuracy.Type)
+	assert.Equal(t, agent.SeverityWarning, inaccuracy.Severity, "unknown severities become warnings")
+	assert.Equal(t, filepath.Join("docs", "store.go.md"), inaccuracy.Path)
+	require.Len(t, auditor.prompts, 1, "files without documentation are not audited")
+	assert.Contains(t, auditor.prompts[0].UserPrompt, "4: Open returns `nil` on success.")
+
+	report, err := cmd.formatDocReviewJSON()
+	require.NoError(t, err)
+	assertMatchesSchema(t, "doc", report)
+	assert.Contains(t, report, `"inaccuracy": 1`)
+
+	markdown := cmd.formatDocReviewMarkdown()
+	assert.Contains(t, markdown, "**Findings:** 4 (1 missing, 1 omissions, 1 stale references, 1 inaccuracies)")
+	assert.Contains(t, markdown, "- **warning** `docs/store.go.md:4` Open returns an error, not nil")
+}
+
+func TestDocCommand_validateReview(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(cmd *DocCommand)
+		wantErr string
+	}{
+		{name: "report without review", setup: func(cmd *DocCommand) { cmd.Reports = []string{"audit.md"} }, wantErr: "--report needs --review"},
+		{name: "review with merge", setup: func(cmd *DocCommand) { cmd.Review, cmd.Merge = true, true }, wantErr: "cannot be combined with --merge"},
+		{name: "json v2 report", setup: func(cmd *DocCommand) { cmd.Review, cmd.Reports = true, []string{"json@v2:-"} }, wantErr: "JSON version 1 only"},
+		{name: "repeated report", setup: func(cmd *DocCommand) { cmd.Review, cmd.Reports = true, []string{"audit.md", "./audit.md"} }, wantErr: "given more than once"},
+		{name: "reports", setup: func(cmd *DocCommand) { cmd.Review, cmd.Reports = true, []string{"audit.md", "json:-"} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewDocCommand()
+			tt.setup(cmd)
+			err := cmd.validateReview()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package cli
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/verify"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -34,6 +37,8 @@ func TestDocCommand_CreateCobraCommand(t *testing.T) {
 	assert.NotNil(t, cobraCmd.Flags().Lookup("include-private"))
 	assert.NotNil(t, cobraCmd.Flags().Lookup("include-tests"))
 	assert.NotNil(t, cobraCmd.Flags().Lookup("recursive"))
+	assert.NotNil(t, cobraCmd.Flags().Lookup("include"))
+	assert.NotNil(t, cobraCmd.Flags().Lookup("exclude"))
 	assert.NotNil(t, cobraCmd.Flags().Lookup("update"))
 	assert.NotNil(t, cobraCmd.Flags().Lookup("language"))
 }
@@ -291,6 +296,33 @@ func TestDocCommand_getFileExtension(t *testing.T) {
 	}
 }
 
+func TestDocCommand_docPath(t *testing.T) {
+	cmd := NewDocCommand()
+	cmd.OutputDir = "docs"
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"main.go", "docs/main.go.md"},
+		{"internal/cli/doc.go", "docs/internal/cli/doc.go.md"},
+		{"./internal/cli/doc.go", "docs/internal/cli/doc.go.md"},
+		{filepath.Join(cwd, "pkg", "util.go"), "docs/pkg/util.go.md"},
+		{"../other/lib.py", "docs/other/lib.py.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			assert.Equal(t, filepath.FromSlash(tt.want), cmd.docPath(tt.source))
+		})
+	}
+
+	cmd.Format = "rst"
+	assert.Equal(t, filepath.FromSlash("docs/main.go.rst"), cmd.docPath("main.go"))
+}
+
 func TestDocCommand_writeDocFile(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -298,27 +330,23 @@ func TestDocCommand_writeDocFile(t *testing.T) {
 	cmd.OutputDir = tmpDir
 	cmd.Format = "markdown"
 
-	artifact := agent.Artifact{
-		Name:    "test",
-		Type:    "documentation",
-		Content: "# Test Documentation",
-	}
-
-	err := cmd.writeDocFile(artifact)
+	entry, err := cmd.writeDocFile("pkg/test.go", "# Test Documentation")
 	require.NoError(t, err)
+	assert.True(t, entry.Written)
 
-	// Check file was created with correct extension
-	expectedPath := filepath.Join(tmpDir, "test.md")
+	// Check file was created mirroring the source tree
+	expectedPath := filepath.Join(tmpDir, "pkg", "test.go.md")
+	assert.Equal(t, expectedPath, entry.DocPath)
 	content, err := os.ReadFile(expectedPath)
 	require.NoError(t, err)
-	assert.Equal(t, artifact.Content, string(content))
+	assert.Equal(t, "# Test Documentation", string(content))
 }
 
 func TestDocCommand_writeDocFile_UpdateExisting(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	// Create existing file
-	existingFile := filepath.Join(tmpDir, "existing.md")
+	existingFile := filepath.Join(tmpDir, "existing.go.md")
 	err := os.WriteFile(existingFile, []byte("old content"), 0644)
 	require.NoError(t, err)
 
@@ -326,15 +354,11 @@ func TestDocCommand_writeDocFile_UpdateExisting(t *testing.T) {
 	cmd.OutputDir = tmpDir
 	cmd.Format = "markdown"
 
-	artifact := agent.Artifact{
-		Name:    "existing",
-		Content: "new content",
-	}
-
 	// Test with UpdateExisting = false
 	cmd.UpdateExisting = false
-	err = cmd.writeDocFile(artifact)
+	entry, err := cmd.writeDocFile("existing.go", "new content")
 	require.NoError(t, err)
+	assert.False(t, entry.Written)
 
 	// Should not update
 	content, err := os.ReadFile(existingFile)
@@ -343,8 +367,9 @@ func TestDocCommand_writeDocFile_UpdateExisting(t *testing.T) {
 
 	// Test with UpdateExisting = true
 	cmd.UpdateExisting = true
-	err = cmd.writeDocFile(artifact)
+	entry, err = cmd.writeDocFile("existing.go", "new content")
 	require.NoError(t, err)
+	assert.True(t, entry.Written)
 
 	// Should update
 	content, err = os.ReadFile(existingFile)
@@ -352,6 +377,95 @@ func TestDocCommand_writeDocFile_UpdateExisting(t *testing.T) {
 	assert.Equal(t, "new content", string(content))
 }
 
+func TestDocCommand_outputDocumentation(t *testing.T) {
+	cmd := NewDocCommand()
+	cmd.OutputDir = t.TempDir()
+
+	result := &agent.OrchestrationResult{FinalResult: &agent.Result{
+		Reasoning: "reasoning",
+		Artifacts: []agent.Artifact{
+			{Name: "report", Type: agent.ArtifactTypeReport, Content: "report"},
+			{Name: "doc", Type: agent.ArtifactTypeDocumentation, Content: "# main.go"},
+		},
+	}}
+	entry, err := cmd.outputDocumentation("main.go", result)
+	require.NoError(t, err)
+	content, err := os.ReadFile(entry.DocPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# main.go", string(content), "documentation artifacts take precedence")
+
+	result.FinalResult = &agent.Result{Reasoning: "# util.go"}
+	entry, err = cmd.outputDocumentation("util.go", result)
+	require.NoError(t, err)
+	content, err = os.ReadFile(entry.DocPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# util.go", string(content))
+
+	result.FinalResult = &agent.Result{}
+	_, err = cmd.outputDocumentation("empty.go", result)
+	assert.Error(t, err)
+}
+
+func TestDocCommand_outputDocumentation_VerifyRefs(t *testing.T) {
+	cmd := NewDocCommand()
+	cmd.OutputDir = t.TempDir()
+	cmd.refs = &referenceGuard{index: verify.NewIndex("", []string{"internal/store/store.go"})}
+
+	result := &agent.OrchestrationResult{FinalResult: &agent.Result{
+		Reasoning: "# Store\n\nRecords live in `store/store.go`; see `internal/cache/cache.go` for caching.",
+	}}
+	entry, err := cmd.outputDocumentation("store.go", result)
+	require.NoError(t, err)
+	content, err := os.ReadFile(entry.DocPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# Store\n\nRecords live in `internal/store/store.go`; see `internal/cache/cache.go` (unverified) for caching.", string(content))
+}
+
+func TestDocCommand_writeIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cmd := NewDocCommand()
+	cmd.OutputDir = tmpDir
+
+	entries := []docEntry{
+		{Source: "main.go", DocPath: cmd.docPath("main.go")},
+		{Source: "internal/cli/doc.go", DocPath: cmd.docPath("internal/cli/doc.go")},
+	}
+	indexFile, err := cmd.writeIndex(entries, nil)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "index.md"), indexFile)
+
+	content, err := os.ReadFile(indexFile)
+	require.NoError(t, err)
+	assert.Equal(t, "# Documentation\n\n"+
+		"- [internal/cli/doc.go](internal/cli/doc.go.md)\n"+
+		"- [main.go](main.go.md)\n", string(content))
+
+	cmd.Format = "html"
+	entries = []docEntry{{Source: "main.go", DocPath: cmd.docPath("main.go")}}
+	indexFile, err = cmd.writeIndex(entries, nil)
+	require.NoError(t, err)
+	content, err = os.ReadFile(indexFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `<li><a href="main.go.html">main.go</a></li>`)
+	assert.NotContains(t, string(content), "Errors")
+
+	failures := []fileFailure{{Path: "util.go", Error: "model error: <timeout>"}}
+	indexFile, err = cmd.writeIndex(entries, failures)
+	require.NoError(t, err)
+	content, err = os.ReadFile(indexFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "<h2>Errors</h2>\n<ul>\n<li><code>util.go</code>: model error: &lt;timeout&gt;</li>\n</ul>\n</body>")
+
+	cmd.Format = "markdown"
+	entries = []docEntry{{Source: "main.go", DocPath: cmd.docPath("main.go")}}
+	indexFile, err = cmd.writeIndex(entries, failures)
+	require.NoError(t, err)
+	content, err = os.ReadFile(indexFile)
+	require.NoError(t, err)
+	assert.Equal(t, "# Documentation\n\n- [main.go](main.go.md)\n\n## Errors\n\n- `util.go`: model error: <timeout>\n", string(content))
+}
+
 func TestDocCommand_processFiles(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -413,6 +527,56 @@ func TestDocCommand_processFiles(t *testing.T) {
 	}
 }
 
+func TestDocCommand_processFiles_Symbols(t *testing.T) {
+	tmpDir := t.TempDir()
+	goFile := filepath.Join(tmpDir, "store.go")
+	require.NoError(t, os.WriteFile(goFile, []byte("package store\n\n// Get reads\nfunc Get() {}\n\nfunc get() {}\n\n// Deprecated: use Get\nfunc Fetch() {}\n"), 0644))
+	notes := filepath.Join(tmpDir, "notes.txt")
+	require.NoError(t, os.WriteFile(notes, []byte("notes\n"), 0644))
+
+	cmd := NewDocCommand()
+	cmd.Files = []string{goFile, notes}
+	cmd.IncludePrivate = true
+	cmd.OnlyExported = true
+	cmd.ExcludeSymbols = []string{"Fetch"}
+	contexts, err := cmd.processFiles()
+	require.NoError(t, err)
+	require.Len(t, contexts, 1)
+	assert.Equal(t, "package store\n\n// Get reads\nfunc Get() {}\n\n\n", contexts[0].Content)
+	require.Len(t, cmd.failures.failures, 1, "files whose symbols cannot be selected are not documented whole")
+	assert.Contains(t, cmd.failures.failures[0].Error, "symbols cannot be selected in .txt files")
+
+	cmd.ExcludeSymbols = []string{"[Fetch"}
+	err = cmd.validateInputs()
+	assert.ErrorContains(t, err, `invalid symbol pattern "[Fetch"`)
+}
+
+func TestDocCommand_processFiles_Unreadable(t *testing.T) {
+	tmpDir := t.TempDir()
+	regularFile := filepath.Join(tmpDir, "regular.go")
+	require.NoError(t, os.WriteFile(regularFile, []byte("package main\n"), 0644))
+	unreadable := filepath.Join(tmpDir, "dir.go")
+	require.NoError(t, os.Mkdir(unreadable, 0755))
+
+	cmd := NewDocCommand()
+	cmd.Files = []string{unreadable, regularFile}
+	contexts, err := cmd.processFiles()
+	require.NoError(t, err)
+	require.Len(t, contexts, 1, "the rest are documented")
+	assert.Equal(t, regularFile, contexts[0].Path)
+	require.Len(t, cmd.failures.failures, 1)
+	assert.Equal(t, unreadable, cmd.failures.failures[0].Path)
+	assert.Contains(t, cmd.failures.failures[0].Error, "failed to read file")
+	assert.ErrorContains(t, cmd.failures.err("Execute", "document", 2), "failed to document 1 of 2 files")
+
+	cmd = NewDocCommand()
+	cmd.Files = []string{unreadable, regularFile}
+	cmd.failures = fileFailures{failFast: true}
+	_, err = cmd.processFiles()
+	assert.ErrorContains(t, err, "failed to read file", "--fail-fast stops at the first failure")
+	assert.Empty(t, cmd.failures.failures)
+}
+
 func TestDocCommand_detectProjectLanguage(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -545,3 +709,29 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+func TestDocCommand_publishDocs(t *testing.T) {
+	cfg := *config.Get()
+	cfg.Cache = config.CacheConfig{Enabled: true, Backend: "local", Path: t.TempDir()}
+	config.Set(&cfg)
+	defer config.Set(nil)
+
+	indexFile := filepath.Join(t.TempDir(), "index.md")
+	require.NoError(t, os.WriteFile(indexFile, []byte("# Documentation\n"), 0600))
+	entries := []docEntry{
+		{Source: "a.go", DocPath: "docs/a.md", Written: true, content: "# a"},
+		{Source: "b.go", DocPath: "docs/b.md", content: "# b"},
+	}
+
+	ctx := context.Background()
+	assert.Empty(t, NewDocCommand().publishDocs(ctx, entries, indexFile), "the local cache has no URLs to share")
+
+	data, found := fetchArtifact(ctx, docArtifacts, "docs/a.md")
+	require.True(t, found)
+	assert.Equal(t, "# a", string(data))
+	_, found = fetchArtifact(ctx, docArtifacts, "docs/b.md")
+	assert.False(t, found, "documents left alone are not published")
+	data, found = fetchArtifact(ctx, docArtifacts, filepath.ToSlash(indexFile))
+	require.True(t, found)
+	assert.Equal(t, "# Documentation\n", string(data))
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/translate"
+)
+
+// newDocTranslator returns the translator for --translate, or nil when no
+// translations are asked for
+func newDocTranslator(ctx context.Context, b *BaseCommand, languages []string) (*translate.Translator, error) {
+	if len(languages) == 0 {
+		return nil, nil
+	}
+	m, err := b.GetModel(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeModel, "newDocTranslator", "failed to get model for translation")
+	}
+	return translate.New(m), nil
+}
+
+// languageDir returns the directory documentation in language is written
+// to. Translating gives every language its own directory below the output
+// directory; otherwise documentation goes in the output directory itself.
+func (c *DocCommand) languageDir(language string) string {
+	if len(c.Translate) == 0 {
+		return c.OutputDir
+	}
+	return filepath.Join(c.OutputDir, language)
+}
+
+// validateLanguages checks the primary language and the languages to
+// translate into, which name directories
+func (c *DocCommand) validateLanguages() error {
+	if !translate.ValidLanguage(c.DocLanguage) {
+		return errors.ValidationError("validateLanguages", fmt.Sprintf("invalid documentation language: %q", c.DocLanguage)).
+			WithHint(`use a language code such as "en" or "pt-BR"`)
+	}
+	seen := map[string]bool{c.DocLanguage: true}
+	for _, language := range c.Translate {
+		if !translate.ValidLanguage(language) {
+			return errors.ValidationError("validateLanguages", fmt.Sprintf("invalid translation language: %q", language)).
+				WithHint(`use language codes such as "ja,de"`)
+		}
+		if seen[language] {
+			return errors.ValidationError("validateLanguages", fmt.Sprintf("language %s is given more than once", language)).
+				WithHint("--translate lists the languages besides --doc-language, each once")
+		}
+		seen[language] = true
+	}
+	return nil
+}
+
+// translateDoc writes the translations of entry, the primary documentation
+// of a file. A translation that fails is recorded as a failure of the file
+// and the others carry on; the error is returned under --fail-fast only.
+func (c *DocCommand) translateDoc(ctx context.Context, entry docEntry) ([]docEntry, error) {
+	if c.translator == nil {
+		return nil, nil
+	}
+
+	var entries []docEntry
+	for _, language := range c.Translate {
+		if c.Resume {
+			if translated, ok := c.upToDateIn(language, entry.Source); ok {
+				logger.Info("translation up to date", "path", translated.DocPath)
+				entries = append(entries, translated)
+				continue
+			}
+		}
+		// Existing translations are left alone without asking for new ones
+		path := c.docPathIn(language, entry.Source)
+		if c.fileExists(path) && !c.UpdateExisting && !c.Merge && !c.Resume {
+			logger.Info("skipping existing file", "path", path)
+			entries = append(entries, docEntry{Source: entry.Source, DocPath: path, Language: language})
+			continue
+		}
+
+		translated, err := c.writeTranslation(ctx, language, entry)
+		if err != nil {
+			if err := c.failures.record(entry.Source, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if translated.Written {
+			fmt.Printf("Documentation for %s in %s written to: %s\n", entry.Source, translate.LanguageName(language), translated.DocPath)
+		}
+		entries = append(entries, translated)
+	}
+	return entries, nil
+}
+
+// writeTranslation translates the primary documentation of entry into
+// language and writes it
+func (c *DocCommand) writeTranslation(ctx context.Context, language string, entry docEntry) (docEntry, error) {
+	content, err := c.translator.Translate(ctx, c.Format, c.DocLanguage, language, entry.content)
+	if err != nil {
+		return docEntry{}, errors.Wrap(err, errors.ErrorTypeModel, "writeTranslation",
+			fmt.Sprintf("failed to translate the documentation of %s into %s", entry.Source, language))
+	}
+	translated, err := c.writeDocFileIn(language, entry.Source, applyGlossary(content))
+	if err != nil {
+		return docEntry{}, errors.Wrap(err, errors.ErrorTypeFS, "writeTranslation",
+			fmt.Sprintf("failed to write the %s documentation of %s", language, entry.Source))
+	}
+	return translated, nil
+}
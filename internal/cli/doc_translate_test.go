@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/translate"
+)
+
+func TestDocCommand_translateDoc(t *testing.T) {
+	tmpDir := t.TempDir()
+	cmd := NewDocCommand()
+	cmd.OutputDir = tmpDir
+	cmd.Translate = []string{"ja", "de"}
+	cmd.sourceHashes = map[string]string{"main.go": "v1"}
+	translator := &scriptedModel{responses: []string{"⟦0⟧\n# メイン\n\n⟦1⟧ を呼び出します。", "nichts"}}
+	cmd.translator = translate.New(translator)
+
+	entry, err := cmd.writeDocFile("main.go", "# Main\n\nCall `Run`.\n")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "en", "main.go.md"), entry.DocPath, "the primary language gets a directory too")
+
+	// German loses the inline code twice and is recorded as a failure
+	translator.responses = append(translator.responses, "nichts")
+	translations, err := cmd.translateDoc(context.Background(), entry)
+	require.NoError(t, err)
+	require.Len(t, translations, 1)
+	assert.Equal(t, "ja", translations[0].Language)
+	content, err := os.ReadFile(filepath.Join(tmpDir, "ja", "main.go.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\nsource_hash: v1\n---\n# メイン\n\n`Run` を呼び出します。\n", string(content))
+	assert.Contains(t, translator.prompts[0].UserPrompt, "⟦0⟧\n# Main", "front matter is protected")
+	require.Len(t, cmd.failures.failures, 1)
+	assert.Contains(t, cmd.failures.failures[0].Error, "into de")
+
+	// Resuming keeps the Japanese translation and fills in the German one
+	cmd.Resume = true
+	cmd.failures = fileFailures{}
+	translator.responses = []string{"⟦0⟧\n# Haupt\n\nRuft ⟦1⟧ auf.", "# unused"}
+	translator.prompts = nil
+	translations, err = cmd.translateDoc(context.Background(), entry)
+	require.NoError(t, err)
+	require.Len(t, translations, 2)
+	assert.Len(t, translator.prompts, 1)
+	assert.True(t, translations[1].Written)
+	content, err = os.ReadFile(filepath.Join(tmpDir, "de", "main.go.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\nsource_hash: v1\n---\n# Haupt\n\nRuft `Run` auf.\n", string(content))
+}
+
+func TestDocCommand_validateLanguages(t *testing.T) {
+	cmd := NewDocCommand()
+	assert.NoError(t, cmd.validateLanguages())
+
+	cmd.Translate = []string{"ja", "pt-BR"}
+	assert.NoError(t, cmd.validateLanguages())
+
+	cmd.Translate = []string{"ja", "en"}
+	assert.ErrorContains(t, cmd.validateLanguages(), "more than once")
+
+	cmd.Translate = []string{"../ja"}
+	assert.ErrorContains(t, cmd.validateLanguages(), "invalid translation language")
+
+	cmd.Translate = nil
+	cmd.DocLanguage = ""
+	assert.ErrorContains(t, cmd.validateLanguages(), "invalid documentation language")
+}
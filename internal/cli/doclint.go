@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/dshills/sigil/internal/doclint"
+	"github.com/dshills/sigil/internal/glossary"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// lintDocument applies the configured glossary and style rules to generated
+// Markdown, fixing what it can when fixes are enabled and logging the rest
+func lintDocument(content string) string {
+	cfg := getConfig().Docs
+	if !cfg.Lint {
+		return content
+	}
+
+	linter := doclint.New(glossary.Current(), doclint.Rules{
+		HeadingCase:   strings.ToLower(cfg.HeadingCase),
+		MaxLineLength: cfg.MaxLineLength,
+	})
+
+	var issues []doclint.Issue
+	if cfg.Fix {
+		content, issues = linter.Fix(content)
+	} else {
+		issues = linter.Lint(content)
+	}
+
+	fixed := 0
+	for _, issue := range issues {
+		if issue.Fixed {
+			fixed++
+			continue
+		}
+		logger.Warn("documentation style issue", "issue", issue.String())
+	}
+	if fixed > 0 {
+		logger.Debug("fixed documentation style issues", "fixed", fixed)
+	}
+	return content
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/glossary"
+)
+
+func TestLintDocument(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glossary.yml")
+	require.NoError(t, os.WriteFile(path, []byte("terms:\n  - term: GitHub\n"), 0644))
+
+	cfg := *config.Get()
+	cfg.Docs = config.DocsConfig{Lint: true, Fix: true, Glossary: path, HeadingCase: "sentence"}
+	config.Set(&cfg)
+	defer config.Set(nil)
+	initGlossary()
+	defer glossary.Set(nil)
+
+	assert.Equal(t, "# Using GitHub actions\n\nPush to GitHub.", lintDocument("# Using Github Actions\n\nPush to github."),
+		"headings are cased after glossary fixes")
+
+	cfg.Docs.Fix = false
+	assert.Equal(t, "Push to github.", lintDocument("Push to github."), "issues are only reported without fixes")
+
+	cfg.Docs.Lint = false
+	cfg.Docs.Fix = true
+	assert.Equal(t, "Push to github.", lintDocument("Push to github."))
+}
@@ -0,0 +1,536 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/model/providers/mcp"
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+// How old cache entries and sandboxes are before doctor reports them as stale
+const (
+	staleCacheAge   = 30 * 24 * time.Hour
+	staleTempAge    = time.Hour
+	staleSandboxAge = 7 * 24 * time.Hour
+)
+
+// doctorStatus is the outcome of a doctor check
+type doctorStatus string
+
+const (
+	doctorOK    doctorStatus = "ok"
+	doctorWarn  doctorStatus = "warn"
+	doctorFail  doctorStatus = "fail"
+	doctorFixed doctorStatus = "fixed"
+)
+
+// doctorResult is what a doctor check found, and the safe remediation
+// --fix applies for it, if there is one
+type doctorResult struct {
+	Check  string       `json:"check"`
+	Status doctorStatus `json:"status"`
+	Detail string       `json:"detail"`
+	Hint   string       `json:"hint,omitempty"`
+	fix    func() error
+}
+
+// DoctorCommand checks sigil's setup in a repository for common
+// misconfigurations
+type DoctorCommand struct {
+	*BaseCommand
+	Fix      bool
+	Offline  bool
+	JSON     bool
+	out      io.Writer
+	lookPath func(file string) (string, error)
+	probe    func(ctx context.Context, modelStr string) error
+}
+
+// NewDoctorCommand creates a new doctor command
+func NewDoctorCommand() *DoctorCommand {
+	c := &DoctorCommand{
+		BaseCommand: NewBaseCommand("doctor", "Check sigil's setup for problems",
+			"Check configuration, provider access, git, MCP servers, .sigil permissions and caches."),
+		out:      os.Stdout,
+		lookPath: exec.LookPath,
+	}
+	c.probe = c.probeProvider
+	return c
+}
+
+// Execute runs the doctor command
+func (c *DoctorCommand) Execute(ctx context.Context) error {
+	cfg, results := c.checkConfig(configPath())
+	results = append(results, c.checkProviders(ctx, cfg)...)
+	results = append(results, c.checkGit())
+	results = append(results, c.checkMCPServers()...)
+	results = append(results, c.checkSigilDir())
+	results = append(results, c.checkCaches(cfg)...)
+
+	if c.Fix {
+		for i := range results {
+			c.applyFix(&results[i])
+		}
+	}
+
+	if c.JSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, errors.ErrorTypeOutput, "Execute", "failed to encode results")
+		}
+		fmt.Fprintln(c.out, string(data))
+	} else {
+		fmt.Fprint(c.out, formatDoctorResults(results, c.Fix))
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status == doctorFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.New(errors.ErrorTypeValidation, "Execute", fmt.Sprintf("%d doctor checks failed", failed)).
+			WithHint("follow the instructions above, or run sigil doctor --fix for the problems it can repair")
+	}
+	return nil
+}
+
+// applyFix applies the remediation of a result that has one
+func (c *DoctorCommand) applyFix(result *doctorResult) {
+	if result.fix == nil || result.Status == doctorOK || result.Status == doctorFixed {
+		return
+	}
+	if err := result.fix(); err != nil {
+		result.Detail += fmt.Sprintf(" (fix failed: %v)", err)
+		return
+	}
+	result.Status = doctorFixed
+	result.Hint = ""
+}
+
+// configPath returns the configuration file sigil loads
+func configPath() string {
+	if configFile != "" {
+		return configFile
+	}
+	return filepath.FromSlash(ConfigFile)
+}
+
+// checkConfig checks that the configuration parses and validates, and
+// for keys sigil ignores because they are misnamed. It returns the
+// configuration the other checks run against.
+func (c *DoctorCommand) checkConfig(path string) (*config.Config, []doctorResult) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config.Get(), []doctorResult{{Check: "config", Status: doctorWarn,
+			Detail: fmt.Sprintf("%s not found, using the defaults", path),
+			Hint:   "run sigil init to create it"}}
+	}
+	if err != nil {
+		return config.Get(), []doctorResult{{Check: "config", Status: doctorFail,
+			Detail: fmt.Sprintf("cannot read %s: %v", path, err)}}
+	}
+
+	var results []doctorResult
+	for _, provider := range misnamedAPIKeys(data) {
+		results = append(results, doctorResult{Check: "config", Status: doctorFail,
+			Detail: fmt.Sprintf("models.configs.%s.api_key in %s is ignored", provider, path),
+			Hint:   "rename it to apikey",
+			fix:    func() error { return renameAPIKeys(path) }})
+	}
+	if c.Fix && len(results) > 0 {
+		// Fix the keys before parsing, so the providers are checked with them
+		for i := range results {
+			c.applyFix(&results[i])
+		}
+		if fixed, err := os.ReadFile(path); err == nil {
+			data = fixed
+		}
+	}
+
+	cfg, err := config.Parse(bytes.NewReader(data))
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		return config.Get(), append(results, doctorResult{Check: "config", Status: doctorFail,
+			Detail: fmt.Sprintf("%s is invalid: %v", path, err),
+			Hint:   fmt.Sprintf("fix %s, or move it aside and run sigil init", path)})
+	}
+	config.Set(cfg)
+	return cfg, append(results, doctorResult{Check: "config", Status: doctorOK, Detail: fmt.Sprintf("%s is valid", path)})
+}
+
+// misnamedAPIKeys returns the providers under models.configs whose API key
+// is written as api_key, which model.ModelConfig does not read
+func misnamedAPIKeys(data []byte) []string {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	var providers []string
+	forEachModelConfig(&doc, func(provider string, entry *yaml.Node) {
+		for i := 0; i+1 < len(entry.Content); i += 2 {
+			if entry.Content[i].Value == "api_key" {
+				providers = append(providers, provider)
+			}
+		}
+	})
+	return providers
+}
+
+// renameAPIKeys renames the api_key keys under models.configs in the
+// configuration at path to apikey, keeping its comments
+func renameAPIKeys(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "renameAPIKeys", "failed to read configuration")
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeConfig, "renameAPIKeys", "failed to parse configuration")
+	}
+	forEachModelConfig(&doc, func(_ string, entry *yaml.Node) {
+		for i := 0; i+1 < len(entry.Content); i += 2 {
+			if entry.Content[i].Value == "api_key" {
+				entry.Content[i].Value = "apikey"
+			}
+		}
+	})
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeConfig, "renameAPIKeys", "failed to encode configuration")
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "renameAPIKeys", "failed to write configuration")
+	}
+	return nil
+}
+
+// forEachModelConfig calls visit with each provider mapping under
+// models.configs of a configuration document
+func forEachModelConfig(doc *yaml.Node, visit func(provider string, entry *yaml.Node)) {
+	if len(doc.Content) == 0 {
+		return
+	}
+	configs := mappingValue(mappingValue(doc.Content[0], "models"), "configs")
+	if configs == nil {
+		return
+	}
+	for i := 0; i+1 < len(configs.Content); i += 2 {
+		if entry := configs.Content[i+1]; entry.Kind == yaml.MappingNode {
+			visit(configs.Content[i].Value, entry)
+		}
+	}
+}
+
+// mappingValue returns the value of key in a YAML mapping, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// checkProviders checks that each provider the configured models use
+// accepts sigil's credentials, probing it with the first of its models
+func (c *DoctorCommand) checkProviders(ctx context.Context, cfg *config.Config) []doctorResult {
+	seen := make(map[string]bool)
+	var results []doctorResult
+	for _, modelStr := range append([]string{cfg.Models.Lead}, cfg.Models.Reviewers...) {
+		provider, _, err := model.ParseModelString(modelStr)
+		if err != nil || seen[provider] {
+			continue
+		}
+		seen[provider] = true
+
+		if err := c.probe(ctx, modelStr); err != nil {
+			hint := providerHint(provider)
+			if errors.CodeOf(err) == errors.CodeDisallowed {
+				hint = errors.HintOf(err)
+			}
+			results = append(results, doctorResult{Check: "provider", Status: doctorFail,
+				Detail: fmt.Sprintf("%s: %v", modelStr, err), Hint: hint})
+			continue
+		}
+		detail := fmt.Sprintf("%s answered", modelStr)
+		if c.Offline {
+			detail = fmt.Sprintf("%s is configured (not contacted)", modelStr)
+		}
+		results = append(results, doctorResult{Check: "provider", Status: doctorOK, Detail: detail})
+	}
+	return results
+}
+
+// probeProvider loads a model and, unless --offline, sends it a short prompt
+func (c *DoctorCommand) probeProvider(ctx context.Context, modelStr string) error {
+	if c.Offline {
+		_, err := loadModel(modelStr)
+		return err
+	}
+	return probeModel(ctx, modelStr)
+}
+
+// providerHint tells how to give sigil access to a provider
+func providerHint(provider string) string {
+	if setup, ok := initProviders[provider]; ok && setup.KeyEnv != "" {
+		return fmt.Sprintf("set $%s, or store the key with sigil secret set %s_api_key and set apikey: ${secret:%s_api_key} under models.configs.%s",
+			setup.KeyEnv, provider, provider, provider)
+	}
+	if provider == "ollama" {
+		return "start ollama (ollama serve) or set endpoint under models.configs.ollama"
+	}
+	return fmt.Sprintf("check models.configs.%s in the configuration", provider)
+}
+
+// checkGit checks that git is installed and sigil runs inside a repository
+func (c *DoctorCommand) checkGit() doctorResult {
+	path, err := c.lookPath("git")
+	if err != nil {
+		return doctorResult{Check: "git", Status: doctorFail, Detail: "git not found on PATH",
+			Hint: "install git and make sure it is on PATH"}
+	}
+	repo, err := git.NewRepository("")
+	if err != nil {
+		return doctorResult{Check: "git", Status: doctorFail, Detail: fmt.Sprintf("not in a git repository: %v", err),
+			Hint: "run sigil from inside a Git repository, or create one with 'git init'"}
+	}
+	return doctorResult{Check: "git", Status: doctorOK, Detail: fmt.Sprintf("%s, repository at %s", path, repo.Root)}
+}
+
+// checkMCPServers checks that the commands of the configured stdio MCP
+// servers are on PATH
+func (c *DoctorCommand) checkMCPServers() []doctorResult {
+	globalPath, projectPath := mcp.GetDefaultPaths()
+	servers, err := mcp.NewConfigLoader(globalPath, projectPath).LoadConfigurations()
+	if err != nil {
+		return []doctorResult{{Check: "mcp", Status: doctorFail, Detail: fmt.Sprintf("cannot load MCP servers: %v", err)}}
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+
+	var results []doctorResult
+	for _, server := range servers {
+		if server.Command == "" || (server.Transport != "" && server.Transport != "stdio") {
+			continue
+		}
+		if _, err := c.lookPath(server.Command); err != nil {
+			results = append(results, doctorResult{Check: "mcp", Status: doctorFail,
+				Detail: fmt.Sprintf("server %s: %s not found on PATH", server.Name, server.Command),
+				Hint:   fmt.Sprintf("install %s, or fix the server's command in %s", server.Command, projectPath)})
+			continue
+		}
+		results = append(results, doctorResult{Check: "mcp", Status: doctorOK,
+			Detail: fmt.Sprintf("server %s: %s found", server.Name, server.Command)})
+	}
+	if len(results) == 0 {
+		results = append(results, doctorResult{Check: "mcp", Status: doctorOK, Detail: "no stdio MCP servers configured"})
+	}
+	return results
+}
+
+// checkSigilDir checks that sigil can write its state to .sigil
+func (c *DoctorCommand) checkSigilDir() doctorResult {
+	const dir = ".sigil"
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return doctorResult{Check: "permissions", Status: doctorWarn, Detail: dir + " does not exist",
+			Hint: "create it with mkdir .sigil, or run sigil init",
+			fix:  func() error { return os.MkdirAll(dir, 0755) }}
+	}
+	if err == nil && !info.IsDir() {
+		return doctorResult{Check: "permissions", Status: doctorFail, Detail: dir + " is not a directory",
+			Hint: "move the file aside; sigil keeps its state in the .sigil directory"}
+	}
+	if err == nil {
+		var probe *os.File
+		if probe, err = os.CreateTemp(dir, ".doctor-*"); err == nil {
+			probe.Close()
+			os.Remove(probe.Name())
+		}
+	}
+	if err != nil {
+		return doctorResult{Check: "permissions", Status: doctorFail, Detail: fmt.Sprintf("cannot write to %s: %v", dir, err),
+			Hint: "make it writable with chmod -R u+w .sigil"}
+	}
+	return doctorResult{Check: "permissions", Status: doctorOK, Detail: dir + " is writable"}
+}
+
+// checkCaches looks for prompt cache entries of commits no longer in use,
+// temporary files interrupted writes left behind, and sandboxes left over
+// from earlier runs
+func (c *DoctorCommand) checkCaches(cfg *config.Config) []doctorResult {
+	cacheDir := cfg.Cache.Path
+	if cacheDir == "" {
+		cacheDir = filepath.Join(".sigil", "cache")
+	}
+	head := ""
+	if repo, err := git.NewRepository(""); err == nil {
+		head, _ = repo.GetHeadCommit()
+	}
+
+	var results []doctorResult
+	stale, size := staleCacheEntries(cacheDir, head, time.Now())
+	if len(stale) == 0 {
+		results = append(results, doctorResult{Check: "cache", Status: doctorOK, Detail: "no stale prompt cache entries"})
+	} else {
+		results = append(results, doctorResult{Check: "cache", Status: doctorWarn,
+			Detail: fmt.Sprintf("%d stale entries in %s using %s", len(stale), cacheDir, sandbox.FormatBytes(size)),
+			Hint:   "remove them with sigil doctor --fix",
+			fix:    func() error { return removeAll(stale) }})
+	}
+
+	usage, err := sandbox.MeasureDiskUsage(filepath.FromSlash(sandbox.SandboxDir))
+	if err != nil {
+		return append(results, doctorResult{Check: "sandbox", Status: doctorWarn, Detail: err.Error()})
+	}
+	old := 0
+	for _, worktree := range usage.Worktrees {
+		if time.Since(worktree.Modified) > staleSandboxAge {
+			old++
+		}
+	}
+	if old > 0 {
+		return append(results, doctorResult{Check: "sandbox", Status: doctorWarn,
+			Detail: fmt.Sprintf("%d sandboxes unused for over %d days", old, int(staleSandboxAge.Hours()/24)),
+			Hint:   "remove them with sigil sandbox clean"})
+	}
+	return append(results, doctorResult{Check: "sandbox", Status: doctorOK,
+		Detail: fmt.Sprintf("%d sandboxes using %s", len(usage.Worktrees), sandbox.FormatBytes(usage.Total))})
+}
+
+// staleCacheEntries returns the paths of the stale entries in the local
+// prompt cache and the bytes they use: the commit directories of commits
+// other than head not used for staleCacheAge, and temporary files older
+// than staleTempAge. Entries valid across commits are kept.
+func staleCacheEntries(dir, head string, now time.Time) ([]string, int64) {
+	var stale []string
+	var total int64
+	repos, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, 0
+	}
+	for _, repo := range repos {
+		if !repo.IsDir() {
+			continue
+		}
+		commits, err := sandbox.MeasureDiskUsage(filepath.Join(dir, repo.Name()))
+		if err != nil {
+			continue
+		}
+		for _, commit := range commits.Worktrees {
+			if commit.ID == "any" || commit.ID == head || now.Sub(commit.Modified) <= staleCacheAge {
+				continue
+			}
+			stale = append(stale, commit.Path)
+			total += commit.Bytes
+		}
+	}
+
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasPrefix(entry.Name(), ".tmp-") {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil && now.Sub(info.ModTime()) > staleTempAge {
+			for _, commit := range stale {
+				if strings.HasPrefix(path, commit+string(filepath.Separator)) {
+					return nil
+				}
+			}
+			stale = append(stale, path)
+			total += info.Size()
+		}
+		return nil
+	})
+	return stale, total
+}
+
+// removeAll removes each of paths
+func removeAll(paths []string) error {
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			return errors.Wrap(err, errors.ErrorTypeFS, "removeAll", fmt.Sprintf("failed to remove %s", path))
+		}
+	}
+	return nil
+}
+
+// formatDoctorResults renders the results of the checks for the terminal
+func formatDoctorResults(results []doctorResult, fixed bool) string {
+	var output strings.Builder
+	fixable := 0
+	for _, result := range results {
+		output.WriteString(fmt.Sprintf("[%-5s] %-11s %s\n", result.Status, result.Check, result.Detail))
+		if result.Hint != "" && result.Status != doctorOK {
+			output.WriteString(fmt.Sprintf("        %-11s → %s\n", "", result.Hint))
+		}
+		if result.fix != nil && result.Status != doctorOK && result.Status != doctorFixed {
+			fixable++
+		}
+	}
+	if fixable > 0 && !fixed {
+		output.WriteString(fmt.Sprintf("\n%d problems can be fixed with sigil doctor --fix\n", fixable))
+	}
+	return output.String()
+}
+
+// CreateCobraCommand creates the cobra command for doctor
+func (c *DoctorCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check sigil's setup for problems",
+		Long: `Check sigil's setup in this repository for common misconfigurations.
+
+doctor checks that the configuration is valid, that each model provider
+accepts sigil's credentials, that git is installed, that the commands of
+the configured MCP servers are on PATH, that .sigil is writable, and
+looks for stale prompt cache entries and sandboxes. It exits non-zero when
+a check fails.
+
+With --fix it applies the remediations that are safe to make unattended:
+renaming misnamed api_key entries to apikey, creating .sigil and removing
+stale cache entries. The other problems come with instructions.`,
+		Example: `  sigil doctor
+
+  # Repair what can be repaired
+  sigil doctor --fix
+
+  # Check without contacting the model providers
+  sigil doctor --offline`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.Execute(cmd.Context())
+		},
+	}
+
+	cmd.Flags().BoolVar(&c.Fix, "fix", false, "Apply safe remediations")
+	cmd.Flags().BoolVar(&c.Offline, "offline", false, "Check provider configuration without sending a prompt")
+	cmd.Flags().BoolVar(&c.JSON, "json", false, "Output the results as JSON")
+	return cmd
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/config"
+)
+
+// newTestDoctor creates a doctor command in a new repository, with the
+// providers and commands it checks faked
+func newTestDoctor(t *testing.T, files map[string]string) (*DoctorCommand, *bytes.Buffer, string) {
+	dir := t.TempDir()
+	gitCommit(t, dir, files)
+	originalWd, _ := os.Getwd()
+	previous := config.Get()
+	t.Cleanup(func() {
+		os.Chdir(originalWd)
+		config.Set(previous)
+	})
+	require.NoError(t, os.Chdir(dir))
+	t.Setenv("HOME", t.TempDir())
+
+	var out bytes.Buffer
+	cmd := NewDoctorCommand()
+	cmd.out = &out
+	cmd.probe = func(context.Context, string) error { return nil }
+	cmd.lookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	return cmd, &out, dir
+}
+
+func TestDoctorCommand_Execute(t *testing.T) {
+	cmd, out, _ := newTestDoctor(t, map[string]string{
+		ConfigFile:               "models:\n  lead: anthropic:claude-3-5-sonnet-20241022\n  reviewers: [openai:gpt-4o, openai:gpt-4o-mini]\n",
+		".sigil/mcp-servers.yml": "servers:\n  - name: github\n    command: github-mcp\n    transport: stdio\n",
+	})
+	var probed []string
+	cmd.probe = func(_ context.Context, modelStr string) error {
+		probed = append(probed, modelStr)
+		if modelStr == "openai:gpt-4o" {
+			return fmt.Errorf("401 unauthorized")
+		}
+		return nil
+	}
+	cmd.lookPath = func(file string) (string, error) {
+		if file == "github-mcp" {
+			return "", fmt.Errorf("not found")
+		}
+		return "/usr/bin/" + file, nil
+	}
+
+	err := cmd.Execute(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 doctor checks failed")
+	assert.Equal(t, []string{"anthropic:claude-3-5-sonnet-20241022", "openai:gpt-4o"}, probed, "each provider is probed once")
+
+	report := out.String()
+	assert.Contains(t, report, "[ok   ] config      .sigil/config.yml is valid")
+	assert.Contains(t, report, "[fail ] provider    openai:gpt-4o: 401 unauthorized")
+	assert.Contains(t, report, "set $OPENAI_API_KEY")
+	assert.Contains(t, report, "[fail ] mcp         server github: github-mcp not found on PATH")
+	assert.Contains(t, report, "[ok   ] git         /usr/bin/git")
+	assert.Contains(t, report, "[ok   ] permissions .sigil is writable")
+}
+
+func TestDoctorCommand_Execute_Fix(t *testing.T) {
+	cmd, out, dir := newTestDoctor(t, map[string]string{"README.md": "# x\n"})
+	configPath := filepath.Join(dir, "custom.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(
+		"# my models\nmodels:\n  lead: anthropic:claude-3-5-sonnet-20241022\n  configs:\n    anthropic:\n      provider: anthropic\n      api_key: sk-test # rotated monthly\n"), 0644))
+	configFile = configPath
+	t.Cleanup(func() { configFile = "" })
+
+	var keys []string
+	cmd.probe = func(context.Context, string) error {
+		keys = append(keys, config.Get().Models.Configs["anthropic"].APIKey)
+		return nil
+	}
+	require.Error(t, cmd.Execute(context.Background()))
+	assert.Contains(t, out.String(), "api_key in "+configPath+" is ignored")
+	assert.Contains(t, out.String(), "2 problems can be fixed with sigil doctor --fix", "the key and .sigil")
+	assert.Equal(t, []string{""}, keys)
+
+	out.Reset()
+	keys = nil
+	cmd.Fix = true
+	require.NoError(t, cmd.Execute(context.Background()))
+	assert.Contains(t, out.String(), "[fixed] config")
+	assert.Contains(t, out.String(), "[fixed] permissions .sigil does not exist")
+	assert.Equal(t, []string{"sk-test"}, keys, "the providers are checked with the fixed configuration")
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# my models")
+	assert.Contains(t, string(data), "apikey: sk-test # rotated monthly")
+	assert.NotContains(t, string(data), "api_key")
+	info, err := os.Stat(".sigil")
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+}
+
+func TestDoctorCommand_checkConfig(t *testing.T) {
+	cmd := NewDoctorCommand()
+	previous := config.Get()
+	t.Cleanup(func() { config.Set(previous) })
+	dir := t.TempDir()
+
+	_, results := cmd.checkConfig(filepath.Join(dir, "missing.yml"))
+	require.Len(t, results, 1)
+	assert.Equal(t, doctorWarn, results[0].Status)
+	assert.Equal(t, "run sigil init to create it", results[0].Hint)
+
+	invalid := filepath.Join(dir, "invalid.yml")
+	require.NoError(t, os.WriteFile(invalid, []byte("models:\n  lead: gpt-4\n"), 0644))
+	cfg, results := cmd.checkConfig(invalid)
+	require.Len(t, results, 1)
+	assert.Equal(t, doctorFail, results[0].Status)
+	assert.Contains(t, results[0].Detail, "invalid lead model format: gpt-4")
+	assert.Same(t, previous, cfg, "the other checks run against the loaded configuration")
+}
+
+func TestStaleCacheEntries(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	old := now.Add(-2 * staleCacheAge)
+	write := func(path string, modified time.Time) {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("cached"), 0644))
+		require.NoError(t, os.Chtimes(path, modified, modified))
+	}
+	write("repo/abc123/prompts/h1", old)
+	write("repo/abc123/prompts/.tmp-1", old)
+	write("repo/head/prompts/h2", old)
+	write("repo/any/embeddings/h3", old)
+	write("repo/def456/prompts/h4", now)
+	write("repo/def456/prompts/.tmp-2", old)
+	write("repo/def456/prompts/.tmp-3", now)
+	require.NoError(t, filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return err
+		}
+		return os.Chtimes(path, old, old)
+	}))
+
+	stale, size := staleCacheEntries(dir, "head", now)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "repo", "abc123"),
+		filepath.Join(dir, "repo", "def456", "prompts", ".tmp-2"),
+	}, stale)
+	assert.Equal(t, int64(18), size)
+
+	stale, _ = staleCacheEntries(filepath.Join(dir, "missing"), "head", now)
+	assert.Empty(t, stale, "a cache never written has nothing stale")
+}
@@ -11,10 +11,12 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/git"
 	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/sandbox"
+	"github.com/dshills/sigil/internal/trust"
 )
 
 // EditCommand handles code editing operations
@@ -43,6 +45,13 @@ func NewEditCommand() *EditCommand {
 // Execute runs the edit command
 func (c *EditCommand) Execute(ctx context.Context) error {
 	logger.Info("starting edit operation", "files", c.Files, "description", c.Description)
+	c.startTime = deterministic.Time(c.startTime)
+	c.Files = deterministic.Sorted(c.Files)
+
+	// Editing changes the working tree, which untrusted mode never does
+	if err := trust.Check("Execute", "editing files"); err != nil {
+		return err
+	}
 
 	// Validate Git repository
 	gitRepo, err := git.NewRepository(".")
@@ -141,7 +150,7 @@ func (c *EditCommand) createEditTask() (*agent.Task, error) {
 
 	// Create task
 	task := &agent.Task{
-		ID:          fmt.Sprintf("edit_%d", c.startTime.Unix()),
+		ID:          fmt.Sprintf("edit_%d_%s", c.startTime.Unix(), deterministic.TokenFor("edit", 6)),
 		Type:        agent.TaskTypeEdit,
 		Description: c.Description,
 		Context: agent.TaskContext{
@@ -173,11 +182,12 @@ func (c *EditCommand) executeWithAgent(ctx context.Context, task *agent.Task, gi
 	}()
 
 	// Create agent factory and orchestrator
-	factory := agent.NewFactory(sandbox, agent.DefaultOrchestrationConfig())
+	factory := agent.NewFactory(sandbox, orchestrationConfig())
 	orchestrator, err := factory.CreateOrchestrator()
 	if err != nil {
 		return errors.Wrap(err, errors.ErrorTypeInternal, "executeWithAgent", "failed to create orchestrator")
 	}
+	configureOrchestrator(orchestrator)
 
 	// Execute task
 	result, err := orchestrator.ExecuteTask(ctx, *task)
@@ -185,6 +195,13 @@ func (c *EditCommand) executeWithAgent(ctx context.Context, task *agent.Task, gi
 		return errors.Wrap(err, errors.ErrorTypeInternal, "executeWithAgent", "task execution failed")
 	}
 
+	if len(result.Questions) > 0 {
+		return clarificationError("executeWithAgent", result.Questions)
+	}
+	if result.Abstention != nil {
+		return abstentionError("executeWithAgent", result.Abstention)
+	}
+
 	// Process results
 	if err := c.processAgentResult(result, gitRepo); err != nil {
 		return errors.Wrap(err, errors.ErrorTypeInternal, "executeWithAgent", "failed to process agent result")
@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/logger"
 )
@@ -41,6 +42,8 @@ func NewExplainCommand() *ExplainCommand {
 // Execute runs the explain command
 func (c *ExplainCommand) Execute(ctx context.Context) error {
 	logger.Info("starting explain operation", "files", c.Files, "query", c.Query)
+	c.startTime = deterministic.Time(c.startTime)
+	c.Files = deterministic.Sorted(c.Files)
 
 	// Validate inputs
 	if err := c.validateInputs(); err != nil {
@@ -143,7 +146,7 @@ func (c *ExplainCommand) createExplainTask() (*agent.Task, error) {
 
 	// Create task
 	task := &agent.Task{
-		ID:          fmt.Sprintf("explain_%d", c.startTime.Unix()),
+		ID:          fmt.Sprintf("explain_%d_%s", c.startTime.Unix(), deterministic.TokenFor("explain", 6)),
 		Type:        agent.TaskTypeAnalyze,
 		Description: c.buildDescription(),
 		Context: agent.TaskContext{
@@ -178,11 +181,12 @@ func (c *ExplainCommand) executeExplanation(ctx context.Context, task *agent.Tas
 	logger.Info("executing explanation with agent system")
 
 	// Create agent factory and orchestrator
-	factory := agent.NewFactory(nil, agent.DefaultOrchestrationConfig()) // No sandbox needed for explanation
+	factory := agent.NewFactory(nil, orchestrationConfig()) // No sandbox needed for explanation
 	orchestrator, err := factory.CreateOrchestrator()
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrorTypeInternal, "executeExplanation", "failed to create orchestrator")
 	}
+	configureOrchestrator(orchestrator)
 
 	// Execute task
 	result, err := orchestrator.ExecuteTask(ctx, *task)
@@ -190,6 +194,13 @@ func (c *ExplainCommand) executeExplanation(ctx context.Context, task *agent.Tas
 		return nil, errors.Wrap(err, errors.ErrorTypeInternal, "executeExplanation", "task execution failed")
 	}
 
+	if len(result.Questions) > 0 {
+		return nil, clarificationError("executeExplanation", result.Questions)
+	}
+	if result.Abstention != nil {
+		return nil, abstentionError("executeExplanation", result.Abstention)
+	}
+
 	if result.Status != agent.StatusSuccess {
 		return nil, errors.New(errors.ErrorTypeInternal, "executeExplanation",
 			fmt.Sprintf("explanation failed with status: %s", result.Status))
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// fileFailure is a file a multi-file command left out because reading or
+// processing it failed
+type fileFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// fileFailures collects the files a multi-file command failed on, so one
+// bad file does not abort the run. With failFast the first failure is
+// returned instead.
+type fileFailures struct {
+	failFast bool
+	failures []fileFailure
+}
+
+// record notes that path failed with err and returns nil so the command
+// carries on, or returns err under --fail-fast
+func (f *fileFailures) record(path string, err error) error {
+	if f.failFast {
+		return err
+	}
+	logger.Warn("skipping failed file", "file", path, "error", err)
+	f.failures = append(f.failures, fileFailure{Path: path, Error: err.Error()})
+	return nil
+}
+
+// list returns the failures, never nil, for reports that always list them
+func (f *fileFailures) list() []fileFailure {
+	if f.failures == nil {
+		return []fileFailure{}
+	}
+	return f.failures
+}
+
+// failed reports whether path failed
+func (f *fileFailures) failed(path string) bool {
+	for _, failure := range f.failures {
+		if failure.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// err returns the error a command exits with once its report is written
+// when some of its total files failed, or nil when none did
+func (f *fileFailures) err(op, verb string, total int) error {
+	if len(f.failures) == 0 {
+		return nil
+	}
+	return errors.New(errors.ErrorTypeInput, op, fmt.Sprintf("failed to %s %d of %d files", verb, len(f.failures), total)).
+		WithHint("see the errors section of the report, or use --fail-fast to stop at the first failure")
+}
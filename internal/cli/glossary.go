@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"github.com/dshills/sigil/internal/glossary"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/trust"
+)
+
+// initGlossary loads the project glossary that every prompt and generated
+// output follows. Untrusted repositories get none, since their glossary
+// would be written into system prompts.
+func initGlossary() {
+	if trust.Untrusted() {
+		logger.Info("untrusted repository, ignoring its glossary")
+		return
+	}
+	path := getConfig().Docs.Glossary
+	if path == "" {
+		path = glossary.DefaultPath
+	}
+	g, err := glossary.Load(path)
+	if err != nil {
+		logger.Warn("ignoring glossary", "path", path, "error", err)
+		return
+	}
+	glossary.Set(g)
+}
+
+// applyGlossary spells the glossary's terms the preferred way in generated
+// text
+func applyGlossary(text string) string {
+	applied, replaced := glossary.Current().Apply(text)
+	if replaced > 0 {
+		logger.Debug("applied glossary", "replaced", replaced)
+	}
+	return applied
+}
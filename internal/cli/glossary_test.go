@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/glossary"
+	"github.com/dshills/sigil/internal/trust"
+)
+
+func TestInitGlossary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glossary.yml")
+	require.NoError(t, os.WriteFile(path, []byte("terms:\n  - term: PostgreSQL\n    avoid: [postgres]\n"), 0644))
+
+	cfg := *config.Get()
+	cfg.Docs.Glossary = path
+	config.Set(&cfg)
+	t.Cleanup(func() {
+		config.Set(nil)
+		glossary.Set(nil)
+		trust.Set(false, "")
+	})
+
+	initGlossary()
+	assert.Equal(t, "Migrate PostgreSQL tables", applyGlossary("Migrate postgres tables"))
+	assert.Contains(t, glossary.Instruct("You write commit messages."), "- PostgreSQL (not postgres)")
+
+	glossary.Set(nil)
+	trust.Set(true, "--untrusted")
+	initGlossary()
+	assert.True(t, glossary.Current().Empty(), "untrusted repositories do not steer prompts")
+	assert.Equal(t, "Migrate postgres tables", applyGlossary("Migrate postgres tables"))
+}
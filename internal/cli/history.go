@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/history"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// historyArtifacts groups task records among the artifacts in the cache
+const historyArtifacts = "history"
+
+// maxHistoryDescription is how much of a task description history lists show
+const maxHistoryDescription = 60
+
+// HistoryCommand shows the recorded artifacts of orchestrated tasks
+type HistoryCommand struct {
+	*BaseCommand
+	Dir        string
+	JSON       bool
+	Transcript bool
+	Replay     string
+	Share      bool
+}
+
+// NewHistoryCommand creates a new history command
+func NewHistoryCommand() *HistoryCommand {
+	return &HistoryCommand{
+		BaseCommand: NewBaseCommand("history", "Show recorded multi-agent tasks",
+			"Show the agent conversations and sandbox transcripts recorded for multi-agent tasks."),
+		Dir: history.DefaultDir,
+	}
+}
+
+// List prints the recorded tasks, newest first
+func (c *HistoryCommand) List(ctx context.Context) error {
+	records, err := history.NewStore(c.Dir).List()
+	if err != nil {
+		return err
+	}
+
+	if c.JSON {
+		summaries := make([]map[string]any, len(records))
+		for i, record := range records {
+			summaries[i] = map[string]any{
+				"task_id":     record.TaskID,
+				"type":        record.Type,
+				"description": record.Description,
+				"time":        record.Time,
+				"status":      record.Status,
+				"turns":       len(record.Conversation),
+			}
+		}
+		return printHistoryJSON(map[string]any{"tasks": summaries})
+	}
+
+	if len(records) == 0 {
+		fmt.Printf("No tasks recorded in %s\n", c.Dir)
+		return nil
+	}
+	for _, record := range records {
+		fmt.Printf("%-22s %s  %-9s %-10s %3d turns  %s\n", record.TaskID, record.Time.Format("2006-01-02 15:04"),
+			record.Type, record.Status, len(record.Conversation), shortenSubject(strings.Join(strings.Fields(record.Description), " "), maxHistoryDescription))
+	}
+	return nil
+}
+
+// Show prints a recorded task, its full conversation with --transcript, or
+// its conversation replayed against another model with --replay
+func (c *HistoryCommand) Show(ctx context.Context, id string) error {
+	record, err := c.load(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if c.Share {
+		url := publishHistoryRecord(ctx, *record)
+		if url == "" {
+			return errors.New(errors.ErrorTypeConfig, "Show", fmt.Sprintf("task %s cannot be shared", record.TaskID)).
+				WithHint("configure a writable http, s3 or gcs cache to share task records")
+		}
+		fmt.Println(url)
+		return nil
+	}
+	if c.Replay != "" {
+		return c.replay(ctx, record)
+	}
+	if c.JSON {
+		return printHistoryJSON(record)
+	}
+	fmt.Print(formatHistoryRecord(record, c.Transcript))
+	return nil
+}
+
+// load reads a task's record from the history directory or, given its
+// full ID, from the artifacts another run published to the cache
+func (c *HistoryCommand) load(ctx context.Context, id string) (*history.Record, error) {
+	record, err := history.NewStore(c.Dir).Load(id)
+	if err == nil {
+		return record, nil
+	}
+	data, found := fetchArtifact(ctx, historyArtifacts, id)
+	if !found {
+		return nil, err
+	}
+	var shared history.Record
+	if jsonErr := json.Unmarshal(data, &shared); jsonErr != nil {
+		logger.Warn("discarding unreadable shared task record", "task_id", id, "error", jsonErr)
+		return nil, err
+	}
+	return &shared, nil
+}
+
+// publishHistoryRecord publishes a task's record to the cache as an
+// artifact of the run, returning a URL to share it, if any
+func publishHistoryRecord(ctx context.Context, record history.Record) string {
+	data, err := json.Marshal(record)
+	if err != nil {
+		logger.Warn("failed to encode task record", "task_id", record.TaskID, "error", err)
+		return ""
+	}
+	return publishArtifact(ctx, historyArtifacts, record.TaskID, data)
+}
+
+// replay sends a task's recorded turns to the --replay model and prints
+// how its responses compare with the recorded ones
+func (c *HistoryCommand) replay(ctx context.Context, record *history.Record) error {
+	if len(record.Conversation) == 0 {
+		return errors.New(errors.ErrorTypeInput, "replay", fmt.Sprintf("task %s has no recorded conversation", record.TaskID))
+	}
+	mdl, err := loadModel(c.Replay)
+	if err != nil {
+		return err
+	}
+
+	replayed := history.Replay(ctx, mdl, record.Conversation)
+	if c.JSON {
+		return printHistoryJSON(map[string]any{"task_id": record.TaskID, "model": c.Replay, "turns": replayed})
+	}
+	fmt.Print(formatReplay(record, c.Replay, replayed))
+	return nil
+}
+
+// printHistoryJSON prints v as indented JSON
+func printHistoryJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "printHistoryJSON", "failed to encode history")
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// formatHistoryRecord renders a task record as markdown, with every turn
+// of the conversation in full when transcript is set
+func formatHistoryRecord(record *history.Record, transcript bool) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("# Task %s\n\n", record.TaskID))
+	result.WriteString(fmt.Sprintf("**Type:** %s\n", record.Type))
+	result.WriteString(fmt.Sprintf("**Description:** %s\n", record.Description))
+	result.WriteString(fmt.Sprintf("**Time:** %s\n", record.Time.Format(time.RFC3339)))
+	result.WriteString(fmt.Sprintf("**Status:** %s\n", record.Status))
+	if record.LeadAgent != "" {
+		result.WriteString(fmt.Sprintf("**Lead Agent:** %s\n", record.LeadAgent))
+	}
+	result.WriteString(fmt.Sprintf("**Duration:** %s\n\n", record.Duration.Round(time.Millisecond)))
+
+	result.WriteString("## Conversation\n\n")
+	if len(record.Conversation) == 0 {
+		result.WriteString("No model requests were recorded.\n\n")
+	}
+	for i, turn := range record.Conversation {
+		result.WriteString(formatTurnHeading(i, turn))
+		if !transcript {
+			continue
+		}
+		result.WriteString("\n")
+		if turn.SystemPrompt != "" {
+			result.WriteString(fmt.Sprintf("#### System\n\n```\n%s\n```\n\n", strings.TrimRight(turn.SystemPrompt, "\n")))
+		}
+		result.WriteString(fmt.Sprintf("#### User\n\n```\n%s\n```\n\n", strings.TrimRight(turn.UserPrompt, "\n")))
+		for _, file := range turn.Files {
+			result.WriteString(fmt.Sprintf("#### File %s\n\n```\n%s\n```\n\n", file.Path, strings.TrimRight(file.Content, "\n")))
+		}
+		if turn.Error != "" {
+			result.WriteString(fmt.Sprintf("**Error:** %s\n\n", turn.Error))
+		} else {
+			result.WriteString(fmt.Sprintf("#### Response\n\n```\n%s\n```\n\n", strings.TrimRight(turn.Response, "\n")))
+		}
+		if len(turn.ToolCalls) > 0 {
+			result.WriteString("#### Tool Calls\n\n")
+			for _, call := range turn.ToolCalls {
+				result.WriteString(fmt.Sprintf("- `%s`\n", call))
+			}
+			result.WriteString(fmt.Sprintf("\n```\n%s\n```\n\n", strings.Trim(turn.ToolResults, "\n")))
+		}
+	}
+	if !transcript && len(record.Conversation) > 0 {
+		result.WriteString("\nShow the prompts and responses with --transcript.\n\n")
+	}
+
+	if len(record.Sandbox) > 0 {
+		if transcript {
+			result.WriteString(formatTranscriptText(record.Sandbox))
+		} else {
+			result.WriteString(fmt.Sprintf("%d sandbox executions recorded.\n", len(record.Sandbox)))
+		}
+	}
+	return result.String()
+}
+
+// formatTurnHeading summarizes a turn in one heading line
+func formatTurnHeading(i int, turn agent.Turn) string {
+	heading := fmt.Sprintf("### %d. %s (%s) %s", i+1, turn.AgentID, turn.Role, turn.Phase)
+	if turn.Model != "" {
+		heading += " with " + turn.Model
+	}
+	heading += fmt.Sprintf(": %d tokens in %s", turn.TokensUsed, turn.Duration.Round(time.Millisecond))
+	if turn.Error != "" {
+		heading += " (failed)"
+	}
+	return heading + "\n"
+}
+
+// formatReplay renders replayed turns beside the recorded responses
+func formatReplay(record *history.Record, modelName string, replayed []history.Replayed) string {
+	var result strings.Builder
+	changed := 0
+	for _, turn := range replayed {
+		if turn.Changed() {
+			changed++
+		}
+	}
+	result.WriteString(fmt.Sprintf("# Replay of %s with %s\n\n", record.TaskID, modelName))
+	result.WriteString(fmt.Sprintf("%d of %d turns replayed, %d with a different response.\n\n", len(replayed), len(record.Conversation), changed))
+
+	for i, turn := range replayed {
+		result.WriteString(formatTurnHeading(i, turn.Turn))
+		result.WriteString(fmt.Sprintf("\n#### Recorded\n\n```\n%s\n```\n\n", strings.TrimRight(turn.Turn.Response, "\n")))
+		switch {
+		case turn.Error != "":
+			result.WriteString(fmt.Sprintf("**Replay error:** %s\n\n", turn.Error))
+		case !turn.Changed():
+			result.WriteString("Replayed response is identical.\n\n")
+		default:
+			result.WriteString(fmt.Sprintf("#### Replayed (%d tokens in %s)\n\n```\n%s\n```\n\n", turn.TokensUsed,
+				turn.Duration.Round(time.Millisecond), strings.TrimRight(turn.Response, "\n")))
+		}
+	}
+	return result.String()
+}
+
+// CreateCobraCommand creates the cobra command for history
+func (c *HistoryCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show recorded multi-agent tasks",
+		Long: `Show what was recorded for multi-agent tasks: every model request each
+agent made, with its system and user prompts, response and tool calls,
+and the sandbox executions run to validate the proposals. Records are
+kept in .sigil/history, the most recent 50 tasks.
+
+A recorded conversation can be replayed against a different model, sending
+each request again as it was recorded, to see where the responses of a
+new model or provider diverge when debugging regressions.
+
+With a cache configured, each record is also published as an artifact of
+the commit it ran at, so a teammate or CI job at the same commit can show
+it by its full ID; --share prints a URL to download it.`,
+		Example: `  sigil history list
+  sigil history show task_1712345678_k3m9qa --transcript
+  sigil history show task_1712345678_k3 --replay anthropic:claude-3-5-sonnet-latest
+  sigil history show task_1712345678_k3m9qa --share`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.List(cmd.Context())
+		},
+	}
+	cmd.PersistentFlags().StringVar(&c.Dir, "dir", c.Dir, "Directory task records are stored in")
+	cmd.PersistentFlags().BoolVar(&c.JSON, "json", false, "Output as JSON")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List recorded tasks, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.List(cmd.Context())
+		},
+	}
+
+	show := &cobra.Command{
+		Use:   "show <task>",
+		Short: "Show a recorded task",
+		Long: `Show a recorded task by its ID, or by a prefix only it starts with. The
+conversation is summarized one line per model request unless --transcript
+is given; --replay sends the recorded requests to another model instead.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.Show(cmd.Context(), args[0])
+		},
+	}
+	show.Flags().BoolVar(&c.Transcript, "transcript", false, "Show the full prompts, responses and tool calls")
+	show.Flags().StringVar(&c.Replay, "replay", "", "Replay the conversation against another model (provider:model)")
+	show.Flags().BoolVar(&c.Share, "share", false, "Publish the record to the configured cache and print a URL to share it")
+
+	cmd.AddCommand(list, show)
+	return cmd
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/history"
+)
+
+func historyRecordFixture() *history.Record {
+	return &history.Record{
+		TaskID:      "task_100",
+		Type:        agent.TaskTypeEdit,
+		Description: "Add logging",
+		Time:        time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Status:      agent.StatusSuccess,
+		LeadAgent:   "lead",
+		Conversation: []agent.Turn{
+			{AgentID: "lead", Role: agent.RoleLead, Phase: agent.PhaseExecute, SystemPrompt: "You are a lead",
+				UserPrompt: "Add logging", Response: "TOOL: list_dir", Model: "mock", TokensUsed: 40,
+				ToolCalls: []string{"list_dir"}, ToolResults: "\n=== list_dir ===\nmain.go\n"},
+			{AgentID: "security", Role: agent.RoleReviewer, Phase: agent.PhaseReview, UserPrompt: "Review", Error: "rate limited"},
+		},
+	}
+}
+
+func TestFormatHistoryRecord(t *testing.T) {
+	record := historyRecordFixture()
+
+	summary := formatHistoryRecord(record, false)
+	assert.Contains(t, summary, "# Task task_100")
+	assert.Contains(t, summary, "### 1. lead (lead) execute with mock: 40 tokens in 0s")
+	assert.Contains(t, summary, "### 2. security (reviewer) review: 0 tokens in 0s (failed)")
+	assert.Contains(t, summary, "--transcript")
+	assert.NotContains(t, summary, "You are a lead")
+
+	transcript := formatHistoryRecord(record, true)
+	assert.Contains(t, transcript, "#### System\n\n```\nYou are a lead\n```")
+	assert.Contains(t, transcript, "#### Response\n\n```\nTOOL: list_dir\n```")
+	assert.Contains(t, transcript, "- `list_dir`")
+	assert.Contains(t, transcript, "main.go")
+	assert.Contains(t, transcript, "**Error:** rate limited")
+}
+
+func TestFormatReplay(t *testing.T) {
+	record := historyRecordFixture()
+	replayed := []history.Replayed{
+		{Turn: record.Conversation[0], Response: "TOOL: list_dir"},
+		{Turn: record.Conversation[1], Response: "APPROVE"},
+	}
+
+	output := formatReplay(record, "ollama:llama3", replayed)
+	assert.Contains(t, output, "# Replay of task_100 with ollama:llama3")
+	assert.Contains(t, output, "2 of 2 turns replayed, 1 with a different response.")
+	assert.Contains(t, output, "Replayed response is identical.")
+	assert.Contains(t, output, "#### Replayed (0 tokens in 0s)\n\n```\nAPPROVE\n```")
+}
+
+func TestHistoryCommand_SharedRecord(t *testing.T) {
+	cfg := *config.Get()
+	cfg.Cache = config.CacheConfig{Enabled: true, Backend: "local", Path: t.TempDir()}
+	config.Set(&cfg)
+	defer config.Set(nil)
+
+	ctx := context.Background()
+	record := historyRecordFixture()
+	assert.Empty(t, publishHistoryRecord(ctx, *record), "the local cache has no URLs to share")
+
+	c := NewHistoryCommand()
+	c.Dir = t.TempDir()
+	loaded, err := c.load(ctx, record.TaskID)
+	require.NoError(t, err, "a record missing locally is read from the cache")
+	assert.Equal(t, record.Description, loaded.Description)
+	assert.Len(t, loaded.Conversation, 2)
+
+	_, err = c.load(ctx, "task_1")
+	assert.Error(t, err, "prefixes only match local records")
+
+	c.Share = true
+	assert.ErrorContains(t, c.Show(ctx, record.TaskID), "cannot be shared")
+}
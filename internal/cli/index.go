@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/index"
+)
+
+// NewIndexCommand creates the embeddings index command
+func NewIndexCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Manage the embeddings index",
+		Long: `Inspect, migrate and watch the embeddings index that ask and chat
+retrieve repository code from.
+
+The index is kept in .sigil/index by default. With index.backend set to
+qdrant in the configuration, it is kept in a Qdrant collection instead, so
+a team or CI can share one index. migrate copies an existing index between
+backends so it does not have to be embedded again, and watch keeps the
+index up to date as files change.`,
+		Example: `  # Show where the index is kept and what it holds
+  sigil index status
+
+  # Keep the index current while you work
+  sigil index watch
+
+  # Move the local index to the qdrant backend configured in .sigil/config.yml
+  sigil index migrate --from file --to qdrant`,
+	}
+
+	cmd.AddCommand(newIndexStatusCommand())
+	cmd.AddCommand(newIndexMigrateCommand())
+	cmd.AddCommand(newIndexWatchCommand())
+
+	return cmd
+}
+
+// newIndexStatusCommand creates the status subcommand
+func newIndexStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the embeddings index",
+		Long:  "Show the configured index backend, the embedding model it was built with and the number of files it holds.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := indexRoot()
+			if err != nil {
+				return err
+			}
+			return indexStatus(cmd.Context(), root, getConfig().Index.Backend, os.Stdout)
+		},
+	}
+}
+
+// newIndexMigrateCommand creates the migrate subcommand
+func newIndexMigrateCommand() *cobra.Command {
+	var from, to string
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Copy the embeddings index to another backend",
+		Long: `Copy the embeddings index from one backend to another, replacing what
+the target held. Both backends are set up from the index section of the
+configuration; --to defaults to the configured backend.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := indexRoot()
+			if err != nil {
+				return err
+			}
+			if to == "" {
+				to = getConfig().Index.Backend
+			}
+			return migrateIndex(cmd.Context(), root, from, to, os.Stdout)
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "file", "Backend to copy the index from (file, qdrant)")
+	cmd.Flags().StringVar(&to, "to", "", "Backend to copy the index to (file, qdrant)")
+	return cmd
+}
+
+// indexRoot returns the root of the repository the index is kept for
+func indexRoot() (string, error) {
+	repo, err := git.NewRepository("")
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeGit, "indexRoot", "the embeddings index needs a git repository").
+			WithHint("run inside a git repository")
+	}
+	root, err := repo.GetRoot()
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeGit, "indexRoot", "failed to resolve repository root")
+	}
+	return root, nil
+}
+
+// indexStore opens the index store of backend, configured from the index
+// section of the configuration
+func indexStore(root, backend string) (index.VectorStore, error) {
+	cfg := getConfig().Index
+	cfg.Backend = backend
+	return index.NewStore(cfg, root)
+}
+
+// indexStatus writes what the index in backend holds
+func indexStatus(ctx context.Context, root, backend string, out io.Writer) error {
+	store, err := indexStore(root, backend)
+	if err != nil {
+		return err
+	}
+	manifest, err := store.Manifest(ctx)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "indexStatus", fmt.Sprintf("failed to read the %s index", store.Name()))
+	}
+
+	location := index.DefaultCollection(root)
+	if fileStore, ok := store.(*index.FileStore); ok {
+		location = fileStore.Path()
+	} else if collection := getConfig().Index.Collection; collection != "" {
+		location = collection
+	}
+	embeddingModel := manifest.EmbeddingModel
+	if embeddingModel == "" {
+		embeddingModel = "-"
+	}
+
+	fmt.Fprintf(out, "Backend:         %s\n", store.Name())
+	fmt.Fprintf(out, "Location:        %s\n", location)
+	fmt.Fprintf(out, "Embedding model: %s\n", embeddingModel)
+	fmt.Fprintf(out, "Files:           %d\n", len(manifest.Files))
+	return nil
+}
+
+// migrateIndex copies the index from one backend to another
+func migrateIndex(ctx context.Context, root, from, to string, out io.Writer) error {
+	if strings.EqualFold(normalizeIndexBackend(from), normalizeIndexBackend(to)) {
+		return errors.ValidationError("migrateIndex", fmt.Sprintf("the index is already in the %s backend", normalizeIndexBackend(to))).
+			WithHint("pass --to, or set index.backend in the configuration")
+	}
+
+	source, err := indexStore(root, from)
+	if err != nil {
+		return err
+	}
+	target, err := indexStore(root, to)
+	if err != nil {
+		return err
+	}
+
+	files, chunks, err := index.Migrate(ctx, source, target)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Copied %d files (%d chunks) from the %s index to the %s index\n", files, chunks, source.Name(), target.Name())
+	return nil
+}
+
+// normalizeIndexBackend names the default backend
+func normalizeIndexBackend(backend string) string {
+	if backend == "" {
+		return "file"
+	}
+	return backend
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/chunk"
+	"github.com/dshills/sigil/internal/index"
+	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/watch"
+)
+
+func TestIndexStatus(t *testing.T) {
+	root := t.TempDir()
+	ix, err := index.Load(index.DefaultPath(root), "hash:512", chunk.DefaultRules())
+	require.NoError(t, err)
+	require.NoError(t, ix.Save())
+
+	var out bytes.Buffer
+	require.NoError(t, indexStatus(context.Background(), root, "", &out))
+	assert.Contains(t, out.String(), "Backend:         file")
+	assert.Contains(t, out.String(), "Location:        "+filepath.Join(root, ".sigil", "index", "embeddings.json"))
+	assert.Contains(t, out.String(), "Embedding model: hash:512")
+	assert.Contains(t, out.String(), "Files:           0")
+}
+
+func TestMigrateIndex(t *testing.T) {
+	root := t.TempDir()
+
+	err := migrateIndex(context.Background(), root, "file", "", &bytes.Buffer{})
+	assert.ErrorContains(t, err, "the index is already in the file backend")
+
+	err = migrateIndex(context.Background(), root, "file", "qdrant", &bytes.Buffer{})
+	assert.ErrorContains(t, err, "index url is required for the qdrant backend")
+}
+
+func TestIndexWatcher(t *testing.T) {
+	root := t.TempDir()
+	write := func(file, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, file), []byte(content), 0644))
+	}
+	write("auth.go", "package auth\n\n// Login checks a password\nfunc Login() {}\n")
+	write("cache.go", "package cache\n")
+	files := []string{"auth.go", "cache.go"}
+
+	var out bytes.Buffer
+	w := &indexWatcher{
+		root:     root,
+		embedder: func() model.Embedder { return index.NewHashEmbedder() },
+		list:     func() ([]string, error) { return files, nil },
+		out:      &out,
+	}
+	require.NoError(t, w.rebuild(context.Background()))
+	assert.Contains(t, out.String(), "Indexed 2 files (0 unchanged, 0 removed) with hash:")
+
+	write("auth.go", "package auth\n\n// Logout ends a session\nfunc Logout() {}\n")
+	require.NoError(t, os.Remove(filepath.Join(root, "cache.go")))
+	require.NoError(t, w.refresh(context.Background(), watch.Change{Changed: []string{"auth.go"}, Removed: []string{"cache.go"}}))
+	assert.Contains(t, out.String(), "updated 1 files, removed 1")
+
+	// The saved index is current for ask and chat
+	ix, err := index.Load(index.DefaultPath(root), index.NewHashEmbedder().EmbeddingModel(), chunk.DefaultRules())
+	require.NoError(t, err)
+	assert.Equal(t, 1, ix.Len())
+	hits, err := ix.Search(context.Background(), index.NewHashEmbedder(), "logout session", 1, nil)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Contains(t, hits[0].Content, "Logout")
+}
+
+func TestIndexableFiles(t *testing.T) {
+	dir := t.TempDir()
+	gitCommit(t, dir, map[string]string{
+		"main.go":           "package main\n",
+		".sigil/config.yml": "models:\n  lead: openai:gpt-4\n",
+		"go.sum":            "example.com/dep v1.0.0 h1:abc\n",
+	})
+
+	files, err := indexableFiles(dir)()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go"}, files)
+}
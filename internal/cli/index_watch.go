@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/deterministic"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/index"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/watch"
+)
+
+// newIndexWatchCommand creates the watch subcommand
+func newIndexWatchCommand() *cobra.Command {
+	var interval, debounce time.Duration
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Keep the embeddings index up to date as files change",
+		Long: `Keep the embeddings index up to date until interrupted, so ask and chat
+find it current and answer without embedding first.
+
+Files are checked every --interval. Once they have stayed unchanged for
+--debounce, the changed files are chunked again and only chunks whose
+content changed are embedded. A change to the configuration file is
+picked up too; new chunking rules or a new embedding model rebuild the
+index.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := indexRoot()
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			w := &indexWatcher{
+				root:     root,
+				embedder: configuredEmbedder,
+				list:     indexableFiles(root),
+				out:      os.Stdout,
+			}
+			return w.run(ctx, interval, debounce)
+		},
+	}
+	cmd.Flags().DurationVar(&interval, "interval", watch.DefaultInterval, "How often files are checked for changes")
+	cmd.Flags().DurationVar(&debounce, "debounce", watch.DefaultDebounce, "How long files must stay unchanged before they are indexed")
+	return cmd
+}
+
+// configuredEmbedder returns the embedder of the configured lead model
+func configuredEmbedder() model.Embedder {
+	mdl, err := loadModel(getConfig().Models.Lead)
+	if err != nil {
+		logger.Debug("no model for the index, using local embeddings", "error", err)
+		return indexEmbedder(nil)
+	}
+	return indexEmbedder(mdl)
+}
+
+// indexableFiles lists the files of the repository at root the index may
+// hold, leaving out sigil's own state so writing it never triggers an update
+func indexableFiles(root string) func() ([]string, error) {
+	return func() ([]string, error) {
+		files, err := (&git.Repository{Path: root}).ListFiles()
+		if err != nil {
+			return nil, err
+		}
+		kept := files[:0]
+		for _, file := range files {
+			if !index.Skipped(file) {
+				kept = append(kept, file)
+			}
+		}
+		return kept, nil
+	}
+}
+
+// indexWatcher keeps the embeddings index of a repository up to date as
+// its files change
+type indexWatcher struct {
+	root     string
+	embedder func() model.Embedder
+	list     func() ([]string, error)
+	out      io.Writer
+
+	current model.Embedder
+	ix      *index.Index
+}
+
+// run indexes the repository, then watches its files and the configuration
+// until ctx is canceled. Both are checked from one loop, so an update never
+// runs while another is in progress.
+func (w *indexWatcher) run(ctx context.Context, interval, debounce time.Duration) error {
+	if err := w.rebuild(ctx); err != nil {
+		return err
+	}
+
+	files, err := watch.New(w.root, w.list, interval, debounce)
+	if err != nil {
+		return err
+	}
+	files.OnChange(func(change watch.Change) {
+		if err := w.refresh(ctx, change); err != nil {
+			logger.Error("failed to update the embeddings index", "error", err)
+		}
+	})
+	settings := config.NewWatcher(configPath(), interval)
+	settings.OnReload(func(*config.Config) {
+		if err := w.rebuild(ctx); err != nil {
+			logger.Error("failed to rebuild the embeddings index", "error", err)
+		}
+	})
+
+	fmt.Fprintf(w.out, "Watching %s for changes; press Ctrl-C to stop\n", w.root)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			settings.Check()
+			files.Check()
+		}
+	}
+}
+
+// rebuild opens the index for the configured embedder and chunking rules
+// and brings every file up to date
+func (w *indexWatcher) rebuild(ctx context.Context) error {
+	w.current = w.embedder()
+	ix, err := openIndex(ctx, w.root, w.current)
+	if err != nil {
+		return err
+	}
+	if err := ix.LoadVectors(ctx); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "rebuild", "failed to read the embeddings index")
+	}
+
+	files, err := w.list()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "rebuild", "failed to list repository files")
+	}
+	stats, err := ix.Update(ctx, w.root, files, w.current)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeModel, "rebuild", "failed to update the embeddings index")
+	}
+	if err := ix.Save(); err != nil {
+		return err
+	}
+	w.ix = ix
+
+	fmt.Fprintf(w.out, "Indexed %d files (%d unchanged, %d removed) with %s\n",
+		stats.Indexed, stats.Unchanged, stats.Removed, w.current.EmbeddingModel())
+	return nil
+}
+
+// refresh updates the index for changed files
+func (w *indexWatcher) refresh(ctx context.Context, change watch.Change) error {
+	stats, err := w.ix.Refresh(ctx, w.root, change.Files(), w.current)
+	if err != nil {
+		return err
+	}
+	if stats.Indexed == 0 && stats.Removed == 0 {
+		return nil
+	}
+	if err := w.ix.Save(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w.out, "%s  updated %d files, removed %d (%d chunks unchanged)\n",
+		deterministic.Now().Format(time.TimeOnly), stats.Indexed, stats.Removed, stats.Reused)
+	return nil
+}
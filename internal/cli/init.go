@@ -0,0 +1,349 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/sandbox"
+	"github.com/dshills/sigil/internal/secrets"
+	"github.com/dshills/sigil/internal/trust"
+)
+
+// ConfigFile is the project configuration sigil init writes
+const ConfigFile = ".sigil/config.yml"
+
+// verifyTimeout bounds the connectivity check at the end of sigil init
+const verifyTimeout = 30 * time.Second
+
+// providerSetup is what sigil init needs to know about a model provider
+type providerSetup struct {
+	Model    string // suggested lead model
+	KeyEnv   string // environment variable holding the API key, if one is needed
+	Endpoint string // default endpoint, for local providers
+}
+
+// initProviders are the providers sigil init can set up
+var initProviders = map[string]providerSetup{
+	"anthropic": {Model: "claude-3-5-sonnet-20241022", KeyEnv: "ANTHROPIC_API_KEY"},
+	"openai":    {Model: "gpt-4o", KeyEnv: "OPENAI_API_KEY"},
+	"ollama":    {Model: "llama3", Endpoint: "http://localhost:11434"},
+}
+
+// starterConfig is the configuration sigil init writes
+type starterConfig struct {
+	Models starterModels `yaml:"models"`
+}
+
+// starterModels is the model section of the starter configuration
+type starterModels struct {
+	Lead      string                        `yaml:"lead"`
+	Reviewers []string                      `yaml:"reviewers,omitempty"`
+	Configs   map[string]starterModelConfig `yaml:"configs,omitempty"`
+}
+
+// starterModelConfig configures a provider; the keys are those
+// model.ModelConfig is read from
+type starterModelConfig struct {
+	Provider string `yaml:"provider"`
+	APIKey   string `yaml:"apikey,omitempty"`
+	Endpoint string `yaml:"endpoint,omitempty"`
+}
+
+// InitCommand sets sigil up for a project interactively
+type InitCommand struct {
+	*BaseCommand
+	Force    bool
+	NoVerify bool
+	in       io.Reader
+	out      io.Writer
+	secrets  *secrets.Store
+	verify   func(ctx context.Context, cfg *config.Config) error
+}
+
+// NewInitCommand creates a new init command
+func NewInitCommand() *InitCommand {
+	c := &InitCommand{
+		BaseCommand: NewBaseCommand("init", "Set up sigil for this project",
+			"Interactively write .sigil/config.yml, starter rules and a validation profile."),
+		in:      os.Stdin,
+		out:     os.Stdout,
+		secrets: secrets.Default(),
+	}
+	c.verify = verifyConnectivity
+	return c
+}
+
+// Execute runs the init command
+func (c *InitCommand) Execute(ctx context.Context) error {
+	if err := trust.Check("Execute", "refusing to write sigil configuration"); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(c.in)
+
+	configPath := filepath.FromSlash(ConfigFile)
+	if _, err := os.Stat(configPath); err == nil && !c.Force {
+		overwrite, err := c.ask(reader, fmt.Sprintf("%s exists. Overwrite it? (y/n)", ConfigFile), "n")
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(overwrite, "y") && !strings.EqualFold(overwrite, "yes") {
+			fmt.Fprintf(c.out, "Left %s unchanged.\n", ConfigFile)
+			return nil
+		}
+	}
+
+	language := detectRepoLanguage(".")
+	fmt.Fprintf(c.out, "Detected a %s project.\n", language)
+
+	starter, err := c.askModels(reader)
+	if err != nil {
+		return err
+	}
+	cfg, err := writeStarterConfig(configPath, starter)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Wrote %s\n", ConfigFile)
+
+	if err := c.writeStarterFiles(language); err != nil {
+		return err
+	}
+
+	if c.NoVerify {
+		return nil
+	}
+	fmt.Fprintf(c.out, "Checking %s...\n", cfg.Models.Lead)
+	if err := c.verify(ctx, cfg); err != nil {
+		fmt.Fprintf(c.out, "Could not reach %s: %v\n", cfg.Models.Lead, err)
+		fmt.Fprintln(c.out, "The configuration was kept; fix the key or endpoint and run sigil init again, or edit it directly.")
+		return nil
+	}
+	fmt.Fprintf(c.out, "Connected to %s.\n", cfg.Models.Lead)
+	return nil
+}
+
+// askModels asks for the provider, its credentials, the lead model and
+// the reviewer models
+func (c *InitCommand) askModels(reader *bufio.Reader) (starterConfig, error) {
+	var starter starterConfig
+
+	names := sortedKeys(initProviders)
+	provider, err := c.ask(reader, fmt.Sprintf("Model provider (%s)", strings.Join(names, ", ")), "anthropic")
+	if err != nil {
+		return starter, err
+	}
+	setup, ok := initProviders[provider]
+	if !ok {
+		return starter, errors.ValidationError("askModels", fmt.Sprintf("unsupported provider: %s", provider)).
+			WithHint(fmt.Sprintf("choose one of %s, or write an mcp backend into %s by hand", strings.Join(names, ", "), ConfigFile))
+	}
+
+	providerConfig := starterModelConfig{Provider: provider}
+	switch {
+	case setup.KeyEnv != "":
+		name := provider + "_api_key"
+		prompt := fmt.Sprintf("%s API key (stored in %s; empty to use $%s)", provider, c.secrets.Path(), setup.KeyEnv)
+		key, err := c.ask(reader, prompt, "")
+		if err != nil {
+			return starter, err
+		}
+		if key != "" {
+			if err := c.secrets.Set(name, key); err != nil {
+				return starter, err
+			}
+			providerConfig.APIKey = secrets.Ref(name)
+		}
+	case setup.Endpoint != "":
+		endpoint, err := c.ask(reader, fmt.Sprintf("%s endpoint", provider), setup.Endpoint)
+		if err != nil {
+			return starter, err
+		}
+		if endpoint != setup.Endpoint {
+			providerConfig.Endpoint = endpoint
+		}
+	}
+
+	lead, err := c.ask(reader, "Lead model", setup.Model)
+	if err != nil {
+		return starter, err
+	}
+	if !strings.Contains(lead, ":") {
+		lead = provider + ":" + lead
+	}
+	starter.Models.Lead = lead
+
+	reviewers, err := c.ask(reader, "Reviewer models as provider:model, comma-separated (empty for none)", "")
+	if err != nil {
+		return starter, err
+	}
+	for _, reviewer := range strings.Split(reviewers, ",") {
+		if reviewer = strings.TrimSpace(reviewer); reviewer != "" {
+			starter.Models.Reviewers = append(starter.Models.Reviewers, reviewer)
+		}
+	}
+
+	if providerConfig.APIKey != "" || providerConfig.Endpoint != "" {
+		starter.Models.Configs = map[string]starterModelConfig{provider: providerConfig}
+	}
+	return starter, nil
+}
+
+// ask prints a question and reads the answer, returning def for an empty one
+func (c *InitCommand) ask(reader *bufio.Reader, question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(c.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(c.out, "%s: ", question)
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", errors.Wrap(err, errors.ErrorTypeInput, "ask", "failed to read answer")
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// writeStarterConfig validates the starter configuration and writes it to
+// path, returning it as sigil will load it
+func writeStarterConfig(path string, starter starterConfig) (*config.Config, error) {
+	data, err := yaml.Marshal(starter)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeInternal, "writeStarterConfig", "failed to encode configuration")
+	}
+	cfg, err := config.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeConfig, "writeStarterConfig", "failed to parse configuration")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "writeStarterConfig", "failed to create directory")
+	}
+	content := "# Sigil configuration, written by sigil init\n\n" + string(data)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "writeStarterConfig", "failed to write configuration")
+	}
+	return cfg, nil
+}
+
+// writeStarterFiles writes the default validation rules and the validation
+// profile for the project's language, leaving existing files alone
+func (c *InitCommand) writeStarterFiles(language string) error {
+	rules, err := yaml.Marshal(sandbox.DefaultRules())
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "writeStarterFiles", "failed to encode rules")
+	}
+	if err := c.writeIfMissing(sandbox.RulesFile, rules); err != nil {
+		return err
+	}
+
+	profile := sandbox.ProfileForLanguage(language)
+	if _, ok := sandbox.ValidationProfiles()[profile]; !ok {
+		fmt.Fprintf(c.out, "No validation profile for %s; list steps in %s to validate changes.\n", language, sandbox.ValidationFile)
+		return nil
+	}
+	return c.writeIfMissing(sandbox.ValidationFile, []byte(fmt.Sprintf("profile: %s\n", profile)))
+}
+
+// writeIfMissing writes a starter file unless the project already has one
+func (c *InitCommand) writeIfMissing(path string, data []byte) error {
+	local := filepath.FromSlash(path)
+	if _, err := os.Stat(local); err == nil {
+		fmt.Fprintf(c.out, "Kept existing %s\n", path)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "writeIfMissing", "failed to create directory")
+	}
+	if err := os.WriteFile(local, data, 0644); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "writeIfMissing", fmt.Sprintf("failed to write %s", path))
+	}
+	fmt.Fprintf(c.out, "Wrote %s\n", path)
+	return nil
+}
+
+// verifyConnectivity sends the lead model a short prompt
+func verifyConnectivity(ctx context.Context, cfg *config.Config) error {
+	config.Set(cfg)
+	return probeModel(ctx, cfg.Models.Lead)
+}
+
+// probeModel sends a model a short prompt to check that it answers
+func probeModel(ctx context.Context, modelStr string) error {
+	mdl, err := loadModel(modelStr)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, verifyTimeout)
+	defer cancel()
+	_, err = mdl.RunPrompt(ctx, model.PromptInput{UserPrompt: "Reply with OK.", MaxTokens: 5})
+	return err
+}
+
+// detectRepoLanguage detects the primary language of the project in dir
+func detectRepoLanguage(dir string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+	switch {
+	case exists("go.mod"):
+		return "go"
+	case exists("package.json"):
+		return "javascript"
+	case exists("pyproject.toml") || exists("requirements.txt") || exists("setup.py"):
+		return LangPython
+	case exists("pom.xml") || exists("build.gradle"):
+		return "java"
+	default:
+		return "text"
+	}
+}
+
+// CreateCobraCommand creates the cobra command for init
+func (c *InitCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Set up sigil for this project",
+		Long: `Set up sigil for this project interactively.
+
+init detects the project's language, asks for a model provider and its API
+key, the lead model and any reviewer models, and writes .sigil/config.yml.
+API keys are kept in the secret store (see sigil secret) and referenced
+from the configuration as ${secret:name}, so they are never committed.
+It also writes the default validation rules to .sigil/rules.yml and the
+validation profile for the language to .sigil/validation.yml, unless the
+project has them already, and finally checks that the lead model answers.`,
+		Example: `  sigil init
+
+  # Replace an existing configuration without asking
+  sigil init --force`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.Execute(cmd.Context())
+		},
+	}
+
+	cmd.Flags().BoolVar(&c.Force, "force", false, "Overwrite an existing .sigil/config.yml without asking")
+	cmd.Flags().BoolVar(&c.NoVerify, "no-verify", false, "Skip checking that the lead model answers")
+	return cmd
+}
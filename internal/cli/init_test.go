@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/sandbox"
+	"github.com/dshills/sigil/internal/secrets"
+)
+
+// newTestInit creates an init command in a temporary project that reads
+// the given answers, one per line
+func newTestInit(t *testing.T, answers ...string) (*InitCommand, *bytes.Buffer, string) {
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(originalWd) })
+	require.NoError(t, os.Chdir(tmpDir))
+
+	var out bytes.Buffer
+	cmd := NewInitCommand()
+	cmd.in = strings.NewReader(strings.Join(answers, "\n") + "\n")
+	cmd.out = &out
+	cmd.secrets = secrets.NewStore(filepath.Join(t.TempDir(), "secrets.yml"))
+	return cmd, &out, tmpDir
+}
+
+func TestInitCommand_Execute(t *testing.T) {
+	cmd, out, dir := newTestInit(t, "anthropic", "sk-test", "", "openai:gpt-4o")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/x\n"), 0644))
+
+	var verified *config.Config
+	cmd.verify = func(_ context.Context, cfg *config.Config) error {
+		verified = cfg
+		return nil
+	}
+	require.NoError(t, cmd.Execute(context.Background()))
+
+	data, err := os.ReadFile(ConfigFile)
+	require.NoError(t, err)
+	cfg, err := config.Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, "anthropic:claude-3-5-sonnet-20241022", cfg.Models.Lead)
+	assert.Equal(t, []string{"openai:gpt-4o"}, cfg.Models.Reviewers)
+	assert.Equal(t, "${secret:anthropic_api_key}", cfg.Models.Configs["anthropic"].APIKey, "the key is referenced, not written")
+	assert.NotContains(t, string(data), "sk-test")
+
+	key, err := cmd.secrets.Get("anthropic_api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-test", key)
+
+	_, err = os.Stat(sandbox.RulesFile)
+	assert.NoError(t, err, "starter rules are written")
+	validation, err := os.ReadFile(sandbox.ValidationFile)
+	require.NoError(t, err)
+	assert.Equal(t, "profile: go\n", string(validation))
+
+	require.NotNil(t, verified)
+	assert.Equal(t, cfg.Models.Lead, verified.Models.Lead)
+	assert.Contains(t, out.String(), "Detected a go project.")
+	assert.Contains(t, out.String(), "Connected to anthropic:claude-3-5-sonnet-20241022.")
+}
+
+func TestInitCommand_Execute_Ollama(t *testing.T) {
+	cmd, out, _ := newTestInit(t, "ollama", "http://gpu:11434", "codellama", "")
+	cmd.verify = func(context.Context, *config.Config) error {
+		return fmt.Errorf("connection refused")
+	}
+	require.NoError(t, cmd.Execute(context.Background()), "a failed check keeps the configuration")
+
+	data, err := os.ReadFile(ConfigFile)
+	require.NoError(t, err)
+	cfg, err := config.Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "ollama:codellama", cfg.Models.Lead)
+	assert.Equal(t, "http://gpu:11434", cfg.Models.Configs["ollama"].Endpoint)
+	assert.Contains(t, out.String(), "Could not reach ollama:codellama: connection refused")
+
+	_, err = os.Stat(sandbox.ValidationFile)
+	assert.True(t, os.IsNotExist(err), "a text project has no validation profile")
+}
+
+func TestInitCommand_Execute_KeepsExistingConfig(t *testing.T) {
+	cmd, out, _ := newTestInit(t, "n")
+	cmd.NoVerify = true
+	require.NoError(t, os.MkdirAll(".sigil", 0755))
+	require.NoError(t, os.WriteFile(ConfigFile, []byte("models:\n  lead: openai:gpt-4\n"), 0644))
+	require.NoError(t, os.WriteFile(sandbox.RulesFile, []byte("# mine\n"), 0644))
+
+	require.NoError(t, cmd.Execute(context.Background()))
+	data, err := os.ReadFile(ConfigFile)
+	require.NoError(t, err)
+	assert.Equal(t, "models:\n  lead: openai:gpt-4\n", string(data))
+	assert.Contains(t, out.String(), "Left .sigil/config.yml unchanged.")
+
+	cmd, _, _ = newTestInit(t, "ollama", "", "", "")
+	cmd.NoVerify = true
+	cmd.Force = true
+	require.NoError(t, os.MkdirAll(".sigil", 0755))
+	require.NoError(t, os.WriteFile(ConfigFile, []byte("models:\n  lead: openai:gpt-4\n"), 0644))
+	require.NoError(t, os.WriteFile(sandbox.RulesFile, []byte("# mine\n"), 0644))
+
+	require.NoError(t, cmd.Execute(context.Background()))
+	data, err = os.ReadFile(ConfigFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "lead: ollama:llama3", "--force overwrites without asking")
+	rules, err := os.ReadFile(sandbox.RulesFile)
+	require.NoError(t, err)
+	assert.Equal(t, "# mine\n", string(rules), "existing rules are kept")
+}
+
+func TestInitCommand_Execute_InvalidInput(t *testing.T) {
+	cmd, _, _ := newTestInit(t, "bedrock")
+	cmd.NoVerify = true
+	err := cmd.Execute(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported provider: bedrock")
+
+	cmd, _, _ = newTestInit(t, "ollama", "", "", "not-a-model")
+	cmd.NoVerify = true
+	err = cmd.Execute(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid reviewer model format: not-a-model")
+	_, statErr := os.Stat(ConfigFile)
+	assert.True(t, os.IsNotExist(statErr), "nothing is written for invalid input")
+}
@@ -273,6 +273,16 @@ func (h *InputHandler) parseFilesFromDiff(diff string) []string {
 	return files
 }
 
+// diffSources returns the files a diff of the repository at root touches,
+// as the consent gate checks them
+func diffSources(root, diff string) []string {
+	paths := git.DiffPaths(diff)
+	for i, path := range paths {
+		paths[i] = filepath.Join(root, path)
+	}
+	return paths
+}
+
 // GetMemoryContext retrieves memory context if requested
 func (h *InputHandler) GetMemoryContext() ([]model.MemoryEntry, error) {
 	if !h.flags.IncludeMemory {
@@ -9,7 +9,6 @@ import (
 
 	"time"
 
-	"github.com/dshills/sigil/internal/model"
 	"github.com/dshills/sigil/internal/model/providers/mcp"
 	"github.com/spf13/cobra"
 )
@@ -36,7 +35,10 @@ and external models through the Model Context Protocol.`,
   sigil mcp status
 
   # Generate example configuration
-  sigil mcp init`,
+  sigil mcp init
+
+  # Generate a typed Go client for a server's tools
+  sigil mcp bind github-mcp --out internal/mcpclient/github`,
 	}
 
 	// Add subcommands
@@ -45,6 +47,7 @@ and external models through the Model Context Protocol.`,
 	cmd.AddCommand(newMCPStopCommand())
 	cmd.AddCommand(newMCPStatusCommand())
 	cmd.AddCommand(newMCPInitCommand())
+	cmd.AddCommand(newMCPBindCommand())
 
 	return cmd
 }
@@ -104,13 +107,8 @@ func newMCPStartCommand() *cobra.Command {
 			provider := mcp.NewProvider()
 			defer provider.Shutdown()
 
-			// Create a model to trigger server start
-			config := model.ModelConfig{
-				Endpoint: fmt.Sprintf("mcp://%s", serverName),
-			}
-
-			_, err := provider.CreateModel(config)
-			if err != nil {
+			// Start even servers configured for lazy start
+			if _, err := provider.EnsureServer(cmd.Context(), serverName); err != nil {
 				return fmt.Errorf("failed to start server: %w", err)
 			}
 
@@ -241,6 +239,73 @@ Use --global to create a user-wide configuration.`,
 	return cmd
 }
 
+// newMCPBindCommand creates the bind subcommand
+func newMCPBindCommand() *cobra.Command {
+	var (
+		pkg string
+		out string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bind <server-name>",
+		Short: "Generate a typed Go client for a server's tools",
+		Long: `Generate a Go package with one function per tool of an MCP server.
+
+Each function takes the tool's arguments as a struct generated from its input
+schema, checks them against the schema and calls the tool, so Sigil code and
+plugins do not need to build map[string]interface{} arguments by hand.
+
+The package is written to <out>/client.go; use --out - to print it instead.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serverName := args[0]
+			if pkg == "" {
+				pkg = mcp.BindPackageName(serverName)
+			}
+			if out == "" {
+				out = pkg
+			}
+
+			provider := mcp.NewProvider()
+			defer provider.Shutdown()
+
+			server, err := provider.EnsureServer(cmd.Context(), serverName)
+			if err != nil {
+				return fmt.Errorf("failed to start server: %w", err)
+			}
+			tools, err := server.Protocol.ListTools()
+			if err != nil {
+				return fmt.Errorf("failed to list tools: %w", err)
+			}
+
+			src, err := mcp.GenerateBindings(pkg, serverName, tools)
+			if err != nil {
+				return err
+			}
+
+			if out == "-" {
+				_, err = os.Stdout.Write(src)
+				return err
+			}
+			if err := os.MkdirAll(out, 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+			path := filepath.Join(out, "client.go")
+			if err := os.WriteFile(path, src, 0644); err != nil {
+				return fmt.Errorf("failed to write bindings: %w", err)
+			}
+
+			fmt.Printf("Generated bindings for %d tools of %s: %s\n", len(tools), serverName, path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&pkg, "package", "p", "", "Package name (default: derived from the server name)")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "Output directory, or - for stdout (default: the package name)")
+
+	return cmd
+}
+
 // formatDuration formats a duration for display
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
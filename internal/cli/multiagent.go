@@ -8,8 +8,10 @@ import (
 	"time"
 
 	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/history"
 	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/sandbox"
 	"github.com/spf13/cobra"
@@ -18,14 +20,20 @@ import (
 // MultiAgentCommand implements multi-agent operations
 type MultiAgentCommand struct {
 	*BaseCommand
-	UseMultiAgent bool
-	EnableReview  bool
-	MaxAgents     int
-	Reviewers     []string
-	TaskType      string
-	Secure        bool
-	Fast          bool
-	Maintainable  bool
+	UseMultiAgent  bool
+	EnableReview   bool
+	MaxAgents      int
+	Reviewers      []string
+	TaskType       string
+	Secure         bool
+	Fast           bool
+	Maintainable   bool
+	TranscriptFile string
+
+	// Requirements and constraints the task has besides its description,
+	// e.g. from a task template
+	Requirements []string
+	Constraints  []agent.Constraint
 }
 
 // NewMultiAgentCommand creates a new multi-agent command
@@ -52,8 +60,6 @@ Examples:
 
 // Execute runs the multi-agent command
 func (c *MultiAgentCommand) Execute(ctx context.Context, args []string) error {
-	start := time.Now()
-
 	// Validate arguments
 	if len(args) == 0 {
 		return errors.New(errors.ErrorTypeInput, "Execute", "task description is required")
@@ -70,8 +76,6 @@ func (c *MultiAgentCommand) Execute(ctx context.Context, args []string) error {
 		return err
 	}
 
-	logger.Info("starting multi-agent task", "task_type", c.TaskType, "description", taskDescription)
-
 	// Get input context
 	inputHandler := NewInputHandler(c.GetCommonFlags())
 	inputCtx, err := inputHandler.GetInput()
@@ -79,6 +83,14 @@ func (c *MultiAgentCommand) Execute(ctx context.Context, args []string) error {
 		return errors.Wrap(err, errors.ErrorTypeInput, "Execute", "failed to get input")
 	}
 
+	return c.run(ctx, taskDescription, inputCtx)
+}
+
+// run executes a task on the given input with a team of agents
+func (c *MultiAgentCommand) run(ctx context.Context, taskDescription string, inputCtx *CommandContext) error {
+	start := time.Now()
+	logger.Info("starting multi-agent task", "task_type", c.TaskType, "description", taskDescription)
+
 	// Create sandbox manager
 	repo, err := git.NewRepository(".")
 	if err != nil {
@@ -103,6 +115,7 @@ func (c *MultiAgentCommand) Execute(ctx context.Context, args []string) error {
 	if err != nil {
 		return errors.Wrap(err, errors.ErrorTypeConfig, "Execute", "failed to create orchestrator")
 	}
+	configureOrchestrator(orchestrator)
 
 	// Create task
 	task, err := c.createTask(factory, taskDescription, inputCtx)
@@ -114,6 +127,18 @@ func (c *MultiAgentCommand) Execute(ctx context.Context, args []string) error {
 
 	// Execute task with orchestration
 	result, err := orchestrator.ExecuteTask(ctx, *task)
+	if result != nil && c.TranscriptFile != "" {
+		if err := writeTranscript(c.TranscriptFile, result.Transcript); err != nil {
+			logger.Warn("failed to write sandbox transcript", "error", err)
+		}
+	}
+	if result != nil {
+		record := history.NewRecord(*task, result)
+		if err := history.NewStore(history.DefaultDir).Save(record); err != nil {
+			logger.Warn("failed to record task history", "task_id", task.ID, "error", err)
+		}
+		publishHistoryRecord(ctx, record)
+	}
 	if err != nil {
 		duration := time.Since(start)
 		c.handleError(err, duration)
@@ -166,9 +191,10 @@ func (c *MultiAgentCommand) createTask(factory *agent.Factory, description strin
 
 	// Create constraints from flags
 	constraints := factory.CreateConstraintsFromFlags(c.Secure, c.Fast, c.Maintainable)
+	constraints = append(constraints, c.Constraints...)
 
 	// Create requirements
-	requirements := []string{description}
+	requirements := append([]string{description}, c.Requirements...)
 	if c.EnableReview {
 		requirements = append(requirements, "Code must pass review by specialized agents")
 	}
@@ -191,7 +217,7 @@ func (c *MultiAgentCommand) createTask(factory *agent.Factory, description strin
 
 // getAgentConfig creates agent configuration based on command flags
 func (c *MultiAgentCommand) getAgentConfig() agent.OrchestrationConfig {
-	config := agent.DefaultOrchestrationConfig()
+	config := orchestrationConfig()
 
 	// Adjust based on command flags
 	if c.MaxAgents > 0 {
@@ -200,6 +226,18 @@ func (c *MultiAgentCommand) getAgentConfig() agent.OrchestrationConfig {
 
 	config.EnableParallelReview = c.EnableReview
 
+	// Record sampled and failed tasks as configured
+	if traces := getConfig().Traces; traces.SampleRate > 0 || traces.Failed {
+		config.Trace.SampleRate = traces.SampleRate
+		config.Trace.Failed = traces.Failed
+		if traces.Dir != "" {
+			config.Trace.Dir = traces.Dir
+		}
+		if traces.MaxBundles > 0 {
+			config.Trace.MaxBundles = traces.MaxBundles
+		}
+	}
+
 	// Configure specialized reviewers based on flags
 	if c.Secure {
 		config.AgentProfiles["security_reviewer"] = agent.AgentConfig{
@@ -256,17 +294,22 @@ func (c *MultiAgentCommand) getAgentConfig() agent.OrchestrationConfig {
 
 // handleResults processes and outputs the orchestration results
 func (c *MultiAgentCommand) handleResults(result *agent.OrchestrationResult, inputCtx *CommandContext, duration time.Duration) error {
+	duration = deterministic.Duration(duration)
 	output := &CommandOutput{
 		Command:   "multi",
 		Success:   result.Status == agent.StatusSuccess,
 		Duration:  duration,
-		Timestamp: time.Now(),
+		Timestamp: deterministic.Now(),
 	}
 
 	// Add error if task failed
 	if result.Status != agent.StatusSuccess {
 		output.Success = false
-		if result.FinalResult != nil && result.FinalResult.Error != "" {
+		if len(result.Questions) > 0 {
+			output.Error = clarificationError("handleResults", result.Questions).Error()
+		} else if result.Abstention != nil {
+			output.Error = result.Abstention.Reason
+		} else if result.FinalResult != nil && result.FinalResult.Error != "" {
 			output.Error = result.FinalResult.Error
 		}
 	}
@@ -280,6 +323,15 @@ func (c *MultiAgentCommand) handleResults(result *agent.OrchestrationResult, inp
 	responseBuilder.WriteString(fmt.Sprintf("**Lead Agent:** %s\n", result.LeadAgent))
 	responseBuilder.WriteString(fmt.Sprintf("**Duration:** %s\n\n", duration))
 
+	if result.FinalResult != nil && len(result.FinalResult.Injections) > 0 {
+		responseBuilder.WriteString("## Suspected Prompt Injection\n\n")
+		responseBuilder.WriteString("These lines read as instructions to the model. Tools were refused and changes kept to the task's files.\n\n")
+		for _, finding := range result.FinalResult.Injections {
+			responseBuilder.WriteString(fmt.Sprintf("- %s\n", finding))
+		}
+		responseBuilder.WriteString("\n")
+	}
+
 	// Add final result if available
 	if result.FinalResult != nil {
 		responseBuilder.WriteString("## Final Result\n\n")
@@ -288,10 +340,14 @@ func (c *MultiAgentCommand) handleResults(result *agent.OrchestrationResult, inp
 
 		if len(result.FinalResult.Proposals) > 0 {
 			responseBuilder.WriteString("### Proposals\n\n")
+			resolver := newOwnerResolver()
 			for i, proposal := range result.FinalResult.Proposals {
 				responseBuilder.WriteString(fmt.Sprintf("%d. **%s** (Confidence: %.2f)\n",
 					i+1, proposal.Description, proposal.Confidence))
 				responseBuilder.WriteString(fmt.Sprintf("   %s\n\n", proposal.Reasoning))
+				if owners := proposalOwners(resolver, proposal); len(owners) > 0 {
+					responseBuilder.WriteString(fmt.Sprintf("   **Owners:** %s\n\n", strings.Join(owners, ", ")))
+				}
 
 				// Show changes
 				if len(proposal.Changes) > 0 {
@@ -348,6 +404,11 @@ func (c *MultiAgentCommand) handleResults(result *agent.OrchestrationResult, inp
 		}
 	}
 
+	// Show what ran in the sandbox, for debugging failed validations
+	if Verbose() && len(result.Transcript) > 0 {
+		responseBuilder.WriteString(formatTranscriptText(result.Transcript))
+	}
+
 	output.Content = responseBuilder.String()
 
 	// Write output
@@ -360,8 +421,8 @@ func (c *MultiAgentCommand) handleError(err error, duration time.Duration) {
 	output := &CommandOutput{
 		Command:   "multi",
 		Success:   false,
-		Duration:  duration,
-		Timestamp: time.Now(),
+		Duration:  deterministic.Duration(duration),
+		Timestamp: deterministic.Now(),
 		Error:     err.Error(),
 		Content:   fmt.Sprintf("Multi-agent task failed: %s", err.Error()),
 	}
@@ -380,12 +441,7 @@ func (c *MultiAgentCommand) GetCobraCommand() *cobra.Command {
 
 	// Add multi-agent specific flags
 	cmd.Flags().StringVarP(&c.TaskType, "type", "t", "", "Task type (edit, generate, refactor, document, test, review, optimize, analyze)")
-	cmd.Flags().BoolVar(&c.EnableReview, "review", true, "Enable multi-agent review process")
-	cmd.Flags().IntVar(&c.MaxAgents, "max-agents", 5, "Maximum number of agents to use")
-	cmd.Flags().StringSliceVar(&c.Reviewers, "reviewers", []string{}, "Specific reviewer specializations (security, performance, architecture, testing)")
-	cmd.Flags().BoolVar(&c.Secure, "secure", false, "Add security-focused reviewer")
-	cmd.Flags().BoolVar(&c.Fast, "fast", false, "Add performance-focused reviewer")
-	cmd.Flags().BoolVar(&c.Maintainable, "maintainable", false, "Add architecture/maintainability reviewer")
+	c.addAgentFlags(cmd)
 
 	// Mark required flags
 	cmd.MarkFlagRequired("type")
@@ -406,5 +462,16 @@ func (c *MultiAgentCommand) GetCobraCommand() *cobra.Command {
 	return cmd
 }
 
+// addAgentFlags adds the flags shaping the agent team and its output
+func (c *MultiAgentCommand) addAgentFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&c.EnableReview, "review", true, "Enable multi-agent review process")
+	cmd.Flags().IntVar(&c.MaxAgents, "max-agents", 5, "Maximum number of agents to use")
+	cmd.Flags().StringSliceVar(&c.Reviewers, "reviewers", []string{}, "Specific reviewer specializations (security, performance, architecture, testing, accessibility, i18n)")
+	cmd.Flags().BoolVar(&c.Secure, "secure", false, "Add security-focused reviewer")
+	cmd.Flags().BoolVar(&c.Fast, "fast", false, "Add performance-focused reviewer")
+	cmd.Flags().BoolVar(&c.Maintainable, "maintainable", false, "Add architecture/maintainability reviewer")
+	cmd.Flags().StringVar(&c.TranscriptFile, "transcript", "", "Write the sandbox transcript to a file (.html for a report, JSON otherwise)")
+}
+
 // Create the global multi-agent command instance
 var multiAgentCmd = NewMultiAgentCommand().GetCobraCommand()
@@ -0,0 +1,600 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/deterministic"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// maxOnboardEntryPoints limits how many entry point files are sent to the agents
+const maxOnboardEntryPoints = 5
+
+// makeTarget matches a Makefile rule name
+var makeTarget = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_-]*)\s*:([^=]|$)`)
+
+// packageDoc matches the first line of a Go package comment
+var packageDoc = regexp.MustCompile(`^// Package \S+ (.+)$`)
+
+// onboardDocs are repository documents worth reading first, in order
+var onboardDocs = []string{"README.md", "README", "CONTRIBUTING.md", "CLAUDE.md", "AGENTS.md", "ARCHITECTURE.md", "docs/ARCHITECTURE.md"}
+
+// conventionFiles map configuration files to the convention they signal
+var conventionFiles = map[string]string{
+	".golangci.yml":           "golangci-lint configuration in .golangci.yml",
+	".golangci.yaml":          "golangci-lint configuration in .golangci.yaml",
+	".editorconfig":           "editor settings in .editorconfig",
+	".eslintrc.json":          "ESLint configuration in .eslintrc.json",
+	".eslintrc.js":            "ESLint configuration in .eslintrc.js",
+	".prettierrc":             "Prettier formatting in .prettierrc",
+	".pre-commit-config.yaml": "pre-commit hooks in .pre-commit-config.yaml",
+	"CONTRIBUTING.md":         "contribution guidelines in CONTRIBUTING.md",
+	"CHANGELOG.md":            "a maintained CHANGELOG.md",
+	"CODEOWNERS":              "code ownership in CODEOWNERS",
+	".github/CODEOWNERS":      "code ownership in .github/CODEOWNERS",
+}
+
+// ProjectCommand is a build or test command detected in the repository
+type ProjectCommand struct {
+	Purpose string `json:"purpose"`
+	Command string `json:"command"`
+	Source  string `json:"source"`
+}
+
+// DirectoryOutline describes one directory of the project layout
+type DirectoryOutline struct {
+	Path  string `json:"path"`
+	Files int    `json:"files"`
+	Doc   string `json:"doc,omitempty"`
+}
+
+// ProjectFacts is what can be learned about a project without a model
+type ProjectFacts struct {
+	Name         string             `json:"name"`
+	Languages    map[string]int     `json:"languages"`
+	EntryPoints  []string           `json:"entry_points"`
+	Commands     []ProjectCommand   `json:"commands"`
+	Dependencies []string           `json:"dependencies"`
+	Conventions  []string           `json:"conventions"`
+	Docs         []string           `json:"docs"`
+	Layout       []DirectoryOutline `json:"layout"`
+}
+
+// OnboardCommand generates an orientation document for new developers
+type OnboardCommand struct {
+	*BaseCommand
+	Dir        string
+	Summarize  bool
+	Depth      int
+	Format     string
+	OutputFile string
+	summarizer *SummarizeCommand
+	startTime  time.Time
+}
+
+// NewOnboardCommand creates a new onboard command
+func NewOnboardCommand() *OnboardCommand {
+	return &OnboardCommand{
+		BaseCommand: NewBaseCommand("onboard", "Generate an onboarding guide for new developers",
+			"Generate an orientation document for developers new to the project."),
+		Dir:        ".",
+		Depth:      1,
+		Format:     FormatMarkdown,
+		summarizer: NewSummarizeCommand(),
+		startTime:  time.Now(),
+	}
+}
+
+// Execute runs the onboard command
+func (c *OnboardCommand) Execute(ctx context.Context) error {
+	logger.Info("starting onboard operation", "dir", c.Dir, "summarize", c.Summarize)
+	c.startTime = deterministic.Time(c.startTime)
+
+	if err := c.validateInputs(); err != nil {
+		return err
+	}
+
+	facts, err := c.detectFacts()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to inspect project")
+	}
+
+	var rollup *summaryNode
+	if c.Summarize {
+		if rollup, err = c.summarizeProject(ctx); err != nil {
+			return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to summarize project")
+		}
+	}
+
+	task, err := c.createOnboardTask(facts, rollup)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to create onboard task")
+	}
+
+	guide, err := c.summarizer.summarizeTask(ctx, task)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to generate onboarding guide")
+	}
+
+	formatted, err := c.formatOutput(facts, guide)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to format output")
+	}
+	if c.Format == FormatMarkdown {
+		formatted = lintDocument(formatted)
+	}
+
+	c.summarizer.OutputFile = c.OutputFile
+	return c.summarizer.writeOutput(formatted)
+}
+
+// validateInputs validates the command inputs
+func (c *OnboardCommand) validateInputs() error {
+	info, err := os.Stat(c.Dir)
+	if err != nil || !info.IsDir() {
+		return errors.New(errors.ErrorTypeInput, "validateInputs",
+			fmt.Sprintf("not a directory: %s", c.Dir))
+	}
+	if c.Format != FormatMarkdown && c.Format != string(OutputFormatJSON) {
+		return errors.New(errors.ErrorTypeInput, "validateInputs",
+			fmt.Sprintf("invalid format: %s (valid: %s, %s)", c.Format, FormatMarkdown, OutputFormatJSON))
+	}
+	if c.Depth < 0 {
+		return errors.New(errors.ErrorTypeInput, "validateInputs",
+			fmt.Sprintf("invalid depth: %d (must be 0 or more)", c.Depth))
+	}
+	return nil
+}
+
+// detectFacts inspects the repository for build commands, entry points,
+// dependencies, conventions and layout
+func (c *OnboardCommand) detectFacts() (*ProjectFacts, error) {
+	root, err := filepath.Abs(c.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	facts := &ProjectFacts{Name: filepath.Base(root), Languages: make(map[string]int)}
+	if err := c.walkLayout(facts); err != nil {
+		return nil, err
+	}
+
+	for _, doc := range onboardDocs {
+		if c.exists(doc) {
+			facts.Docs = append(facts.Docs, doc)
+		}
+	}
+
+	c.detectGo(facts)
+	c.detectNode(facts)
+	c.detectOtherEcosystems(facts)
+	c.detectMakeTargets(facts)
+	c.detectConventions(facts)
+
+	sort.Strings(facts.EntryPoints)
+	return facts, nil
+}
+
+// walkLayout records source file counts per language and per directory,
+// along with Go package comments and main packages
+func (c *OnboardCommand) walkLayout(facts *ProjectFacts) error {
+	dirs := make(map[string]*DirectoryOutline)
+	err := filepath.WalkDir(c.Dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(c.Dir, path)
+		if entry.IsDir() {
+			if rel != "." && c.summarizer.skipDir(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !c.summarizer.isSourceFile(entry.Name()) {
+			return nil
+		}
+
+		facts.Languages[c.summarizer.detectLanguage(entry.Name())]++
+
+		dir := filepath.Dir(rel)
+		outline, ok := dirs[dir]
+		if !ok {
+			outline = &DirectoryOutline{Path: filepath.ToSlash(dir)}
+			dirs[dir] = outline
+		}
+		outline.Files++
+
+		if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+			doc, isMain := goPackageInfo(path)
+			if outline.Doc == "" {
+				outline.Doc = doc
+			}
+			if isMain && entry.Name() == "main.go" {
+				facts.EntryPoints = append(facts.EntryPoints, filepath.ToSlash(rel))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "walkLayout", fmt.Sprintf("failed to walk %s", c.Dir))
+	}
+
+	for _, outline := range dirs {
+		facts.Layout = append(facts.Layout, *outline)
+	}
+	sort.Slice(facts.Layout, func(i, j int) bool { return facts.Layout[i].Path < facts.Layout[j].Path })
+	return nil
+}
+
+// goPackageInfo returns the first line of a Go file's package comment and
+// whether it is in package main
+func goPackageInfo(path string) (string, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	var doc string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := packageDoc.FindStringSubmatch(line); match != nil && doc == "" {
+			doc = match[1]
+		}
+		if strings.HasPrefix(line, "package ") {
+			return doc, strings.TrimSpace(strings.TrimPrefix(line, "package ")) == "main"
+		}
+	}
+	return doc, false
+}
+
+// detectGo records Go build commands and direct dependencies from go.mod
+func (c *OnboardCommand) detectGo(facts *ProjectFacts) {
+	content, err := os.ReadFile(filepath.Join(c.Dir, "go.mod"))
+	if err != nil {
+		return
+	}
+
+	facts.Commands = append(facts.Commands,
+		ProjectCommand{Purpose: "build", Command: "go build ./...", Source: "go.mod"},
+		ProjectCommand{Purpose: "test", Command: "go test ./...", Source: "go.mod"})
+
+	inRequire := false
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "require (":
+			inRequire = true
+			continue
+		case line == ")":
+			inRequire = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimPrefix(line, "require ")
+		case !inRequire:
+			continue
+		}
+		if line == "" || strings.Contains(line, "// indirect") {
+			continue
+		}
+		facts.Dependencies = append(facts.Dependencies, strings.Fields(line)[0])
+	}
+}
+
+// detectNode records npm scripts, entry points and dependencies from package.json
+func (c *OnboardCommand) detectNode(facts *ProjectFacts) {
+	content, err := os.ReadFile(filepath.Join(c.Dir, "package.json"))
+	if err != nil {
+		return
+	}
+
+	var pkg struct {
+		Main         string            `json:"main"`
+		Scripts      map[string]string `json:"scripts"`
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		logger.Warn("failed to parse package.json", "error", err)
+		return
+	}
+
+	if pkg.Main != "" {
+		facts.EntryPoints = append(facts.EntryPoints, pkg.Main)
+	}
+	for _, script := range sortedKeys(pkg.Scripts) {
+		facts.Commands = append(facts.Commands, ProjectCommand{Purpose: script, Command: "npm run " + script, Source: "package.json"})
+	}
+	facts.Dependencies = append(facts.Dependencies, sortedKeys(pkg.Dependencies)...)
+}
+
+// detectOtherEcosystems records the standard commands of other build systems
+func (c *OnboardCommand) detectOtherEcosystems(facts *ProjectFacts) {
+	if c.exists("Cargo.toml") {
+		facts.Commands = append(facts.Commands,
+			ProjectCommand{Purpose: "build", Command: "cargo build", Source: "Cargo.toml"},
+			ProjectCommand{Purpose: "test", Command: "cargo test", Source: "Cargo.toml"})
+	}
+	if c.exists("pyproject.toml") || c.exists("setup.py") {
+		facts.Commands = append(facts.Commands, ProjectCommand{Purpose: "test", Command: "pytest", Source: "pyproject.toml"})
+	}
+	if c.exists("pom.xml") {
+		facts.Commands = append(facts.Commands, ProjectCommand{Purpose: "build", Command: "mvn package", Source: "pom.xml"})
+	}
+	if c.exists("build.gradle") {
+		facts.Commands = append(facts.Commands, ProjectCommand{Purpose: "build", Command: "gradle build", Source: "build.gradle"})
+	}
+}
+
+// detectMakeTargets records the rules of a Makefile
+func (c *OnboardCommand) detectMakeTargets(facts *ProjectFacts) {
+	content, err := os.ReadFile(filepath.Join(c.Dir, "Makefile"))
+	if err != nil {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(content), "\n") {
+		match := makeTarget.FindStringSubmatch(line)
+		if match == nil || seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		facts.Commands = append(facts.Commands, ProjectCommand{Purpose: match[1], Command: "make " + match[1], Source: "Makefile"})
+	}
+}
+
+// detectConventions records signals of how the project is maintained
+func (c *OnboardCommand) detectConventions(facts *ProjectFacts) {
+	for _, file := range sortedKeys(conventionFiles) {
+		if c.exists(file) {
+			facts.Conventions = append(facts.Conventions, conventionFiles[file])
+		}
+	}
+
+	if workflows, err := filepath.Glob(filepath.Join(c.Dir, ".github", "workflows", "*.y*ml")); err == nil && len(workflows) > 0 {
+		facts.Conventions = append(facts.Conventions, fmt.Sprintf("%d GitHub Actions workflow(s) in .github/workflows", len(workflows)))
+	}
+
+	if c.hasGoTests() {
+		facts.Conventions = append(facts.Conventions, "Go tests live next to the code they test in _test.go files")
+	}
+	if c.exists("tests") || c.exists("test") {
+		facts.Conventions = append(facts.Conventions, "tests are kept in a separate tests directory")
+	}
+}
+
+// hasGoTests reports whether any Go test file exists in the project
+func (c *OnboardCommand) hasGoTests() bool {
+	found := false
+	_ = filepath.WalkDir(c.Dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || found {
+			return filepath.SkipAll
+		}
+		if entry.IsDir() && path != c.Dir && c.summarizer.skipDir(entry.Name()) {
+			return filepath.SkipDir
+		}
+		found = strings.HasSuffix(path, "_test.go")
+		return nil
+	})
+	return found
+}
+
+// exists reports whether a path relative to the project directory exists
+func (c *OnboardCommand) exists(path string) bool {
+	return c.summarizer.fileExists(filepath.Join(c.Dir, path))
+}
+
+// summarizeProject produces directory rollups with the summarize command
+func (c *OnboardCommand) summarizeProject(ctx context.Context) (*summaryNode, error) {
+	c.summarizer.Files = []string{c.Dir}
+	c.summarizer.Recursive = true
+	c.summarizer.Depth = c.Depth
+	c.summarizer.Brief = true
+
+	root, err := c.summarizer.buildSummaryTree()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.summarizer.summarizeTree(ctx, root, c.summarizer.summarizeTask); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// createOnboardTask creates the task writing the onboarding guide
+func (c *OnboardCommand) createOnboardTask(facts *ProjectFacts, rollup *summaryNode) (*agent.Task, error) {
+	var paths []string
+	paths = append(paths, facts.Docs...)
+	for i, entry := range facts.EntryPoints {
+		if i == maxOnboardEntryPoints {
+			break
+		}
+		paths = append(paths, entry)
+	}
+
+	fileContexts := make([]agent.FileContext, 0, len(paths))
+	for _, path := range paths {
+		content, err := c.summarizer.readFile(filepath.Join(c.Dir, path))
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeInput, "createOnboardTask",
+				fmt.Sprintf("failed to read file: %s", path))
+		}
+		fileContexts = append(fileContexts, agent.FileContext{
+			Path:        path,
+			Content:     content,
+			Language:    c.summarizer.detectLanguage(path),
+			Purpose:     "Project documentation and entry points",
+			IsReference: true,
+		})
+	}
+
+	requirements := []string{
+		"Write an onboarding guide for a developer new to this project, in markdown",
+		"Use these sections: Purpose, Architecture Overview, Key Entry Points, Building and Testing, Conventions, Suggested Reading Order",
+		"Base build and test instructions only on the detected commands; do not invent any",
+		"Make the suggested reading order a numbered list of real paths from the layout, starting with the entry points",
+		"Detected project facts:\n" + formatFacts(facts),
+	}
+	if rollup != nil {
+		var summaries strings.Builder
+		writeMarkdownNode(&summaries, rollup, 3)
+		requirements = append(requirements, "Directory summaries:\n"+summaries.String())
+	}
+
+	return &agent.Task{
+		ID:          fmt.Sprintf("onboard_%d_%s", c.startTime.Unix(), deterministic.TokenFor("onboard", 6)),
+		Type:        agent.TaskTypeAnalyze,
+		Description: fmt.Sprintf("Write an onboarding guide for the %s project", facts.Name),
+		Context: agent.TaskContext{
+			Files:        fileContexts,
+			Requirements: requirements,
+			ProjectInfo: agent.ProjectInfo{
+				Language: facts.primaryLanguage(),
+				Style:    "standard",
+			},
+		},
+		Priority:  agent.PriorityMedium,
+		CreatedAt: c.startTime,
+	}, nil
+}
+
+// languagesByUse returns the detected languages, most files first
+func (f *ProjectFacts) languagesByUse() []string {
+	languages := sortedKeys(f.Languages)
+	sort.SliceStable(languages, func(i, j int) bool { return f.Languages[languages[i]] > f.Languages[languages[j]] })
+	return languages
+}
+
+// primaryLanguage returns the language with the most source files
+func (f *ProjectFacts) primaryLanguage() string {
+	if languages := f.languagesByUse(); len(languages) > 0 {
+		return languages[0]
+	}
+	return string(InputTypeText)
+}
+
+// formatFacts renders project facts as plain text for a prompt or report
+func formatFacts(facts *ProjectFacts) string {
+	var result strings.Builder
+
+	result.WriteString("Languages:")
+	for _, language := range facts.languagesByUse() {
+		result.WriteString(fmt.Sprintf(" %s (%d files)", language, facts.Languages[language]))
+	}
+	result.WriteString("\n")
+
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		result.WriteString(title + ":\n")
+		for _, item := range items {
+			result.WriteString("- " + item + "\n")
+		}
+	}
+
+	commands := make([]string, len(facts.Commands))
+	for i, command := range facts.Commands {
+		commands[i] = fmt.Sprintf("`%s` (%s, from %s)", command.Command, command.Purpose, command.Source)
+	}
+	layout := make([]string, len(facts.Layout))
+	for i, dir := range facts.Layout {
+		layout[i] = fmt.Sprintf("%s (%d files)", dir.Path, dir.Files)
+		if dir.Doc != "" {
+			layout[i] += ": " + dir.Doc
+		}
+	}
+
+	writeList("Entry points", facts.EntryPoints)
+	writeList("Build and test commands", commands)
+	writeList("Direct dependencies", facts.Dependencies)
+	writeList("Conventions", facts.Conventions)
+	writeList("Documentation", facts.Docs)
+	writeList("Layout", layout)
+	return result.String()
+}
+
+// formatOutput formats the guide with the detected facts as an appendix
+func (c *OnboardCommand) formatOutput(facts *ProjectFacts, guide string) (string, error) {
+	if c.Format == string(OutputFormatJSON) {
+		data := map[string]interface{}{
+			"project":   facts.Name,
+			"guide":     guide,
+			"facts":     facts,
+			"timestamp": c.startTime.Format("2006-01-02T15:04:05Z07:00"),
+		}
+		jsonBytes, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(jsonBytes), nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("# Onboarding: %s\n\n", facts.Name))
+	result.WriteString(strings.TrimSpace(guide))
+	result.WriteString("\n\n## Appendix: Detected Project Facts\n\n")
+	result.WriteString(formatFacts(facts))
+	return result.String(), nil
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CreateCobraCommand creates the cobra command for onboard
+func (c *OnboardCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "onboard [dir]",
+		Short: "Generate an onboarding guide for new developers",
+		Long: `Generate an orientation document for developers new to the project.
+
+The guide covers the project's purpose, an architecture overview, key entry
+points, build and test instructions detected from the repository, notable
+conventions, and a suggested reading order. Detected facts are appended so
+the instructions can be checked against the repository.
+
+Examples:
+  sigil onboard
+  sigil onboard ~/src/service --output ONBOARDING.md
+  sigil onboard --summarize --depth 2
+  sigil onboard --format json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				c.Dir = args[0]
+			}
+			return c.Execute(cmd.Context())
+		},
+	}
+
+	cmd.Flags().BoolVar(&c.Summarize, "summarize", false, "Include per-directory summaries (one model call per directory)")
+	cmd.Flags().IntVar(&c.Depth, "depth", 1, "Directory levels to summarize individually with --summarize (0 for no limit)")
+	cmd.Flags().StringVar(&c.Format, "format", "markdown", "Output format (markdown, json)")
+	cmd.Flags().StringVarP(&c.OutputFile, "output", "o", "", "Output file (default: stdout)")
+
+	return cmd
+}
+
+// onboardCmd is the registered onboard command
+var onboardCmd = NewOnboardCommand().CreateCobraCommand()
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeOnboardFixture creates a small Go project with a Makefile and docs
+func writeOnboardFixture(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                    "module example.com/app\n\ngo 1.24\n\nrequire (\n\tgithub.com/spf13/cobra v1.9.1\n\tgithub.com/spf13/pflag v1.0.6 // indirect\n)\n",
+		"Makefile":                  "VERSION := 1\n\n.PHONY: build\nbuild:\n\tgo build ./...\n\ntest: build\n\tgo test ./...\n",
+		"README.md":                 "# App\n\nDoes app things.",
+		".golangci.yml":             "linters: {}",
+		"cmd/app/main.go":           "// Package main runs the app\npackage main\n\nfunc main() {}",
+		"internal/store/db.go":      "// Package store persists app data\npackage store",
+		"internal/store/db_test.go": "package store",
+	}
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+	}
+	return root
+}
+
+func TestOnboardCommand_detectFacts(t *testing.T) {
+	cmd := NewOnboardCommand()
+	cmd.Dir = writeOnboardFixture(t)
+
+	facts, err := cmd.detectFacts()
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Base(cmd.Dir), facts.Name)
+	assert.Equal(t, map[string]int{"go": 3}, facts.Languages)
+	assert.Equal(t, []string{"cmd/app/main.go"}, facts.EntryPoints)
+	assert.Equal(t, []string{"github.com/spf13/cobra"}, facts.Dependencies, "indirect dependencies are left out")
+	assert.Equal(t, []string{"README.md"}, facts.Docs)
+
+	commands := make([]string, len(facts.Commands))
+	for i, command := range facts.Commands {
+		commands[i] = command.Command
+	}
+	assert.Equal(t, []string{"go build ./...", "go test ./...", "make build", "make test"}, commands)
+
+	assert.Contains(t, facts.Conventions, "golangci-lint configuration in .golangci.yml")
+	assert.Contains(t, facts.Conventions, "Go tests live next to the code they test in _test.go files")
+
+	assert.Equal(t, []DirectoryOutline{
+		{Path: "cmd/app", Files: 1, Doc: "runs the app"},
+		{Path: "internal/store", Files: 2, Doc: "persists app data"},
+	}, facts.Layout)
+}
+
+func TestOnboardCommand_createOnboardTask(t *testing.T) {
+	cmd := NewOnboardCommand()
+	cmd.Dir = writeOnboardFixture(t)
+
+	facts, err := cmd.detectFacts()
+	require.NoError(t, err)
+
+	rollup := &summaryNode{Path: "internal", Level: LevelPackage, Summary: "Holds the store"}
+	task, err := cmd.createOnboardTask(facts, rollup)
+	require.NoError(t, err)
+
+	require.Len(t, task.Context.Files, 2)
+	assert.Equal(t, "README.md", task.Context.Files[0].Path)
+	assert.Equal(t, "cmd/app/main.go", task.Context.Files[1].Path)
+	assert.Equal(t, "go", task.Context.ProjectInfo.Language)
+
+	requirements := strings.Join(task.Context.Requirements, "\n")
+	assert.Contains(t, requirements, "Suggested Reading Order")
+	assert.Contains(t, requirements, "`make test` (test, from Makefile)")
+	assert.Contains(t, requirements, "Holds the store")
+}
+
+func TestOnboardCommand_formatOutput(t *testing.T) {
+	facts := &ProjectFacts{
+		Name:      "app",
+		Languages: map[string]int{"go": 2},
+		Commands:  []ProjectCommand{{Purpose: "test", Command: "go test ./...", Source: "go.mod"}},
+	}
+
+	cmd := NewOnboardCommand()
+	markdown, err := cmd.formatOutput(facts, "## Purpose\n\nDoes app things.\n")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(markdown, "# Onboarding: app\n\n## Purpose"))
+	assert.Contains(t, markdown, "## Appendix: Detected Project Facts")
+	assert.Contains(t, markdown, "- `go test ./...` (test, from go.mod)")
+
+	cmd.Format = "json"
+	output, err := cmd.formatOutput(facts, "guide")
+	require.NoError(t, err)
+
+	var parsed struct {
+		Guide string       `json:"guide"`
+		Facts ProjectFacts `json:"facts"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &parsed))
+	assert.Equal(t, "guide", parsed.Guide)
+	assert.Equal(t, *facts, parsed.Facts)
+}
+
+func TestOnboardCommand_validateInputs(t *testing.T) {
+	cmd := NewOnboardCommand()
+	cmd.Dir = t.TempDir()
+	assert.NoError(t, cmd.validateInputs())
+
+	cmd.Format = "html"
+	assert.Error(t, cmd.validateInputs())
+
+	cmd.Format = "markdown"
+	cmd.Dir = filepath.Join(cmd.Dir, "missing")
+	assert.Error(t, cmd.validateInputs())
+}
@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/model"
@@ -49,8 +52,9 @@ type CommandOutput struct {
 	Error    string        `json:"error,omitempty"`
 
 	// Additional context
-	Files map[string]string `json:"files,omitempty"`
-	Patch string            `json:"patch,omitempty"`
+	Files   map[string]string `json:"files,omitempty"`
+	Patch   string            `json:"patch,omitempty"`
+	Sources []Source          `json:"sources,omitempty"`
 }
 
 // WriteOutput writes the output in the specified format
@@ -117,7 +121,9 @@ func (h *OutputHandler) writeInPlace(output *CommandOutput) error {
 		return errors.New(errors.ErrorTypeOutput, "writeInPlace", "no files to update")
 	}
 
-	for filePath, content := range output.Files {
+	paths := slices.Sorted(maps.Keys(output.Files))
+	for _, filePath := range paths {
+		content := output.Files[filePath]
 		logger.Debug("writing file in place", "path", filePath)
 
 		if err := os.WriteFile(filePath, []byte(content), 0600); err != nil {
@@ -128,7 +134,7 @@ func (h *OutputHandler) writeInPlace(output *CommandOutput) error {
 
 	// Also output a summary to stdout
 	fmt.Printf("Updated %d file(s)\n", len(output.Files))
-	for filePath := range output.Files {
+	for _, filePath := range paths {
 		fmt.Printf("  %s\n", filePath)
 	}
 
@@ -142,9 +148,38 @@ func (h *OutputHandler) writeText(writer io.Writer, output *CommandOutput) error
 		return errors.Wrap(err, errors.ErrorTypeOutput, "writeText", "failed to write text")
 	}
 
+	return h.writeSources(writer, output.Sources)
+}
+
+// writeSources writes the sources cited by a text response
+func (h *OutputHandler) writeSources(writer io.Writer, sources []Source) error {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	var text strings.Builder
+	text.WriteString("\n\nSources:\n")
+	for _, source := range sources {
+		text.WriteString(source.String() + "\n")
+	}
+	if _, err := fmt.Fprint(writer, text.String()); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeOutput, "writeSources", "failed to write sources")
+	}
 	return nil
 }
 
+// Streams reports whether output is text written to stdout, which a
+// command can write the model's response to as it arrives
+func (h *OutputHandler) Streams() bool {
+	return h.flags.Out == "" && !h.flags.JSON && !h.flags.Patch && !h.flags.InPlace
+}
+
+// WriteStreamed writes the rest of an output whose content was already
+// streamed to stdout
+func (h *OutputHandler) WriteStreamed(output *CommandOutput) error {
+	return h.writeSources(os.Stdout, output.Sources)
+}
+
 // generatePatch generates a unified diff patch format
 func (h *OutputHandler) generatePatch(output *CommandOutput) string {
 	if len(output.Files) == 0 {
@@ -153,7 +188,9 @@ func (h *OutputHandler) generatePatch(output *CommandOutput) string {
 
 	var patch strings.Builder
 
-	for filePath, newContent := range output.Files {
+	for _, filePath := range slices.Sorted(maps.Keys(output.Files)) {
+		newContent := output.Files[filePath]
+
 		// Read original file content
 		originalContent, err := os.ReadFile(filePath)
 		if err != nil {
@@ -220,8 +257,8 @@ func CreateOutput(command string, input *CommandContext, response model.PromptOu
 	output := &CommandOutput{
 		Content:    response.Response,
 		Command:    command,
-		Timestamp:  time.Now(),
-		Duration:   duration,
+		Timestamp:  deterministic.Now(),
+		Duration:   deterministic.Duration(duration),
 		Success:    true,
 		Model:      response.Model,
 		TokensUsed: response.TokensUsed,
@@ -244,11 +281,21 @@ func CreateOutput(command string, input *CommandContext, response model.PromptOu
 		for _, file := range input.Files {
 			output.InputFiles = append(output.InputFiles, file.Path)
 		}
+	case InputTypeRepository:
+		output.InputType = "repository"
+		seen := make(map[string]bool)
+		for _, file := range input.Files {
+			if !seen[file.Path] {
+				seen[file.Path] = true
+				output.InputFiles = append(output.InputFiles, file.Path)
+			}
+		}
 	case InputTypeText:
 		output.InputType = "text"
 	default:
 		output.InputType = "text"
 	}
+	output.InputFiles = deterministic.Sorted(output.InputFiles)
 
 	return output
 }
@@ -257,8 +304,8 @@ func CreateOutput(command string, input *CommandContext, response model.PromptOu
 func CreateErrorOutput(command string, err error, duration time.Duration) *CommandOutput {
 	return &CommandOutput{
 		Command:   command,
-		Timestamp: time.Now(),
-		Duration:  duration,
+		Timestamp: deterministic.Now(),
+		Duration:  deterministic.Duration(duration),
 		Success:   false,
 		Error:     err.Error(),
 	}
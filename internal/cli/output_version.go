@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/version"
+)
+
+// JSON output versions. Version 1 is the original shape of each command's
+// JSON output and stays the default for --format json; version 2 wraps the
+// result in an envelope carrying the version, command and model usage, and
+// reports review findings as one structured list.
+const (
+	JSONVersion1      = 1
+	JSONVersion2      = 2
+	LatestJSONVersion = JSONVersion2
+)
+
+// parseFormat splits a --format value such as json@v2 into the format and
+// the JSON output version it asks for. Plain json is version 1; other
+// formats have no version
+func parseFormat(format string) (string, int, error) {
+	name, version, versioned := strings.Cut(format, "@")
+	if !versioned {
+		if name == string(OutputFormatJSON) {
+			return name, JSONVersion1, nil
+		}
+		return name, 0, nil
+	}
+
+	if name != string(OutputFormatJSON) {
+		return "", 0, errors.ValidationError("parseFormat", fmt.Sprintf("format %s has no versions", name)).
+			WithHint("only json output is versioned, e.g. --format json@v2")
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil || !strings.HasPrefix(version, "v") || number < JSONVersion1 || number > LatestJSONVersion {
+		versions := make([]string, 0, LatestJSONVersion)
+		for v := JSONVersion1; v <= LatestJSONVersion; v++ {
+			versions = append(versions, fmt.Sprintf("json@v%d", v))
+		}
+		return "", 0, errors.ValidationError("parseFormat", fmt.Sprintf("unsupported JSON output version: %s", version)).
+			WithHint(fmt.Sprintf("supported versions: %s", strings.Join(versions, ", ")))
+	}
+	return name, number, nil
+}
+
+// outputEnvelope is version 2 JSON output: a command's result with the
+// version and the model usage behind it
+type outputEnvelope struct {
+	Version      int         `json:"version"`
+	SigilVersion string      `json:"sigil_version"`
+	Command      string      `json:"command"`
+	Timestamp    string      `json:"timestamp"`
+	Usage        outputUsage `json:"usage"`
+	Result       interface{} `json:"result"`
+}
+
+// outputUsage is the tokens the models used and, when prices are configured
+// for them, what they cost
+type outputUsage struct {
+	Tokens int          `json:"tokens"`
+	Cost   *float64     `json:"cost,omitempty"`
+	Models []modelUsage `json:"models"`
+}
+
+// modelUsage is the usage of a single model
+type modelUsage struct {
+	Model  string   `json:"model"`
+	Tokens int      `json:"tokens"`
+	Cost   *float64 `json:"cost,omitempty"`
+}
+
+// collectUsage returns the model usage of this process
+func collectUsage() outputUsage {
+	usage := model.Usage()
+	pricing := getConfig().Models.Pricing
+	names := make([]string, 0, len(usage))
+	for name := range usage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	total := outputUsage{Models: make([]modelUsage, 0, len(names))}
+	for _, name := range names {
+		entry := modelUsage{Model: name, Tokens: usage[name]}
+		total.Tokens += usage[name]
+		if price, ok := pricing[name]; ok {
+			cost := float64(usage[name]) * price / 1e6
+			entry.Cost = &cost
+			if total.Cost == nil {
+				total.Cost = new(float64)
+			}
+			*total.Cost += cost
+		}
+		total.Models = append(total.Models, entry)
+	}
+	return total
+}
+
+// formatEnvelope formats the result of command as version 2 JSON output
+func formatEnvelope(command string, at time.Time, result interface{}) (string, error) {
+	data, err := json.MarshalIndent(outputEnvelope{
+		Version:      JSONVersion2,
+		SigilVersion: version.Get().Version,
+		Command:      command,
+		Timestamp:    at.Format("2006-01-02T15:04:05Z07:00"),
+		Usage:        collectUsage(),
+		Result:       result,
+	}, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeOutput, "formatEnvelope", "failed to marshal output")
+	}
+	return string(data), nil
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		name    string
+		version int
+		err     string
+	}{
+		{format: "json", name: "json", version: JSONVersion1},
+		{format: "json@v1", name: "json", version: JSONVersion1},
+		{format: "json@v2", name: "json", version: JSONVersion2},
+		{format: "markdown", name: "markdown"},
+		{format: "json@v3", err: "unsupported JSON output version: v3"},
+		{format: "json@2", err: "unsupported JSON output version: 2"},
+		{format: "sarif@v2", err: "format sarif has no versions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			name, version, err := parseFormat(tt.format)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
+
+func TestReviewCommand_formatJSONV2(t *testing.T) {
+	previous := config.Get()
+	defer config.Set(previous)
+	cfg := *previous
+	cfg.Models.Pricing = map[string]float64{"openai:gpt-4o": 5}
+	config.Set(&cfg)
+	model.ResetUsage()
+	defer model.ResetUsage()
+	model.RecordUsage("openai:gpt-4o", 200000)
+	model.RecordUsage("ollama:llama3", 1000)
+
+	cmd := NewReviewCommand()
+	cmd.Severity = "all"
+	cmd.NoOwners = true
+	cmd.Files = []string{"server.go"}
+	cmd.startTime = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cmd.jsonVersion = JSONVersion2
+	content := "```json\n[" +
+		`{"path": "server.go", "line": 12, "severity": "error", "type": "security", "message": "SQL injection"},` +
+		`{"path": "server.go", "line": 30, "severity": "warning", "type": "logic", "message": "Off by one"}` +
+		"]\n```\n"
+
+	var output struct {
+		Version int         `json:"version"`
+		Command string      `json:"command"`
+		Usage   outputUsage `json:"usage"`
+		Result  struct {
+			Findings []reviewFinding `json:"findings"`
+			Counts   reviewCounts    `json:"counts"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(cmd.formatJSON(content, &agent.OrchestrationResult{Status: agent.StatusSuccess})), &output))
+
+	assert.Equal(t, JSONVersion2, output.Version)
+	assert.Equal(t, "review", output.Command)
+	assert.Equal(t, 201000, output.Usage.Tokens)
+	require.NotNil(t, output.Usage.Cost)
+	assert.InDelta(t, 1.0, *output.Usage.Cost, 1e-9)
+	require.Len(t, output.Usage.Models, 2)
+	assert.Equal(t, "ollama:llama3", output.Usage.Models[0].Model)
+	assert.Nil(t, output.Usage.Models[0].Cost)
+
+	require.Len(t, output.Result.Findings, 2)
+	assert.Equal(t, "SQL injection", output.Result.Findings[0].Message)
+	assert.Equal(t, agent.FocusSecurity, output.Result.Findings[0].Area)
+	assert.Equal(t, reviewCounts{
+		Total:      2,
+		BySeverity: map[string]int{"error": 1, "warning": 1},
+		ByArea:     map[string]int{string(agent.FocusSecurity): 1, string(agent.FocusGeneral): 1},
+	}, output.Result.Counts)
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/theme"
+)
+
+// outputExtensions maps file extensions to the output formats they imply
+var outputExtensions = map[string]string{
+	".md":       FormatMarkdown,
+	".markdown": FormatMarkdown,
+	".txt":      "text",
+	".json":     string(OutputFormatJSON),
+	".xml":      "xml",
+	".sarif":    "sarif",
+	".html":     "html",
+	".rdjson":   FormatRDJSON,
+}
+
+// outputTarget is one destination of a command's output
+type outputTarget struct {
+	Format  string
+	Version int    // JSON output version
+	Path    string // "-" for stdout
+}
+
+// Stdout reports whether the target is standard output
+func (t outputTarget) Stdout() bool {
+	return t.Path == "-"
+}
+
+// parseOutputs reads --output values, so one run can write its result in
+// several formats. Each value is a file, or - for stdout, optionally
+// prefixed with a format as in sarif:review.sarif or json@v2:-. Without a
+// prefix, a file's extension picks the format when it is one of formats,
+// and format (with its JSON version) applies otherwise. No values means
+// stdout in format.
+func parseOutputs(values []string, format string, version int, formats []string) ([]outputTarget, error) {
+	if len(values) == 0 {
+		return []outputTarget{{Format: format, Version: version, Path: "-"}}, nil
+	}
+
+	targets := make([]outputTarget, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		target := outputTarget{Format: format, Version: version, Path: value}
+		if prefix, path, ok := strings.Cut(value, ":"); ok && slices.Contains(formats, strings.SplitN(prefix, "@", 2)[0]) {
+			name, v, err := parseFormat(prefix)
+			if err != nil {
+				return nil, err
+			}
+			target = outputTarget{Format: name, Version: v, Path: path}
+		} else if implied, ok := outputExtensions[strings.ToLower(filepath.Ext(value))]; ok && slices.Contains(formats, implied) {
+			target.Format, target.Version = implied, 0
+			if implied == string(OutputFormatJSON) {
+				target.Version = JSONVersion1
+				if format == implied {
+					target.Version = version
+				}
+			}
+		}
+
+		if target.Path == "" {
+			return nil, errors.ValidationError("parseOutputs", fmt.Sprintf("output %q has no file", value)).
+				WithHint("give a file, or - for stdout, e.g. --output sarif:review.sarif")
+		}
+		if !slices.Contains(formats, target.Format) {
+			return nil, errors.ValidationError("parseOutputs", fmt.Sprintf("invalid format for output %s: %s (valid: %s)",
+				target.Path, target.Format, strings.Join(formats, ", ")))
+		}
+		key := target.Path
+		if !target.Stdout() {
+			key = filepath.Clean(key)
+		}
+		if seen[key] {
+			return nil, errors.ValidationError("parseOutputs", fmt.Sprintf("output %s is given more than once", target.Path))
+		}
+		seen[key] = true
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// writeOutputs renders the result for each target and writes it there, with
+// out as stdout, where markdown and text are colored. What was written where is noted on out, or on stderr when
+// the result itself goes to out. Files are also published to the configured
+// cache as artifacts of the run.
+func writeOutputs(ctx context.Context, out io.Writer, targets []outputTarget, label string, render func(outputTarget) (string, error), writeFile func(path, content string) error) error {
+	notes := out
+	if slices.ContainsFunc(targets, outputTarget.Stdout) {
+		notes = os.Stderr
+	}
+
+	for _, target := range targets {
+		formatted, err := render(target)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrorTypeInternal, "writeOutputs", fmt.Sprintf("failed to format %s output", target.Format))
+		}
+		if target.Stdout() {
+			if isColorFormat(target.Format) {
+				formatted = theme.Render(out, formatted)
+			}
+			fmt.Fprint(out, formatted)
+			continue
+		}
+		if err := writeFile(target.Path, formatted); err != nil {
+			return errors.Wrap(err, errors.ErrorTypeInternal, "writeOutputs",
+				fmt.Sprintf("failed to write output file: %s", target.Path))
+		}
+		fmt.Fprintf(notes, "%s written to: %s\n", label, target.Path)
+		if url := publishArtifact(ctx, label, filepath.ToSlash(target.Path), []byte(formatted)); url != "" {
+			fmt.Fprintf(notes, "%s shared at: %s\n", label, url)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/config"
+)
+
+func TestParseOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		format  string
+		version int
+		want    []outputTarget
+		wantErr string
+	}{
+		{
+			name:   "stdout by default",
+			format: "markdown",
+			want:   []outputTarget{{Format: "markdown", Path: "-"}},
+		},
+		{
+			name:   "format prefixes and extensions",
+			values: []string{"report.md", "sarif:report.sarif", "json@v2:-", "notes"},
+			format: "text",
+			want: []outputTarget{
+				{Format: "markdown", Path: "report.md"},
+				{Format: "sarif", Path: "report.sarif"},
+				{Format: "json", Version: JSONVersion2, Path: "-"},
+				{Format: "text", Path: "notes"},
+			},
+		},
+		{
+			name:    "json file takes the version of --format",
+			values:  []string{"review.json"},
+			format:  "json",
+			version: JSONVersion2,
+			want:    []outputTarget{{Format: "json", Version: JSONVersion2, Path: "review.json"}},
+		},
+		{
+			name:   "json file defaults to v1",
+			values: []string{"review.json"},
+			format: "markdown",
+			want:   []outputTarget{{Format: "json", Version: JSONVersion1, Path: "review.json"}},
+		},
+		{
+			name:   "unknown prefix is part of the path",
+			values: []string{`C:\reports\review.md`},
+			format: "text",
+			want:   []outputTarget{{Format: "markdown", Path: `C:\reports\review.md`}},
+		},
+		{
+			name:    "no file",
+			values:  []string{"sarif:"},
+			format:  "markdown",
+			wantErr: "has no file",
+		},
+		{
+			name:    "format the command lacks",
+			values:  []string{"page.html"},
+			format:  "html",
+			wantErr: "invalid format for output page.html: html",
+		},
+		{
+			name:    "same file twice",
+			values:  []string{"out/review.md", "text:./out/review.md"},
+			format:  "markdown",
+			wantErr: "given more than once",
+		},
+		{
+			name:    "stdout twice",
+			values:  []string{"-", "json:-"},
+			format:  "markdown",
+			wantErr: "output - is given more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := parseOutputs(tt.values, tt.format, tt.version, reviewFormats)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, targets)
+		})
+	}
+}
+
+func TestWriteOutputs(t *testing.T) {
+	targets := []outputTarget{{Format: "sarif", Path: "review.sarif"}, {Format: "markdown", Path: "-"}}
+	written := make(map[string]string)
+	var out bytes.Buffer
+
+	err := writeOutputs(context.Background(), &out, targets, "Review", func(target outputTarget) (string, error) {
+		return "as " + target.Format, nil
+	}, func(path, content string) error {
+		written[path] = content
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"review.sarif": "as sarif"}, written)
+	assert.Equal(t, "as markdown", out.String(), "notes go to stderr, not the result")
+}
+
+func TestWriteOutputs_PublishesArtifacts(t *testing.T) {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		}
+	}))
+	defer server.Close()
+
+	cfg := *config.Get()
+	cfg.Cache = config.CacheConfig{Enabled: true, Backend: "http", URL: server.URL, Path: t.TempDir()}
+	config.Set(&cfg)
+	defer config.Set(nil)
+
+	ctx := context.Background()
+	var out bytes.Buffer
+	err := writeOutputs(ctx, &out, []outputTarget{{Format: "sarif", Path: "review.sarif"}}, "Review", func(target outputTarget) (string, error) {
+		return "as " + target.Format, nil
+	}, func(path, content string) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, objects, 1, "the report is uploaded")
+	assert.Contains(t, out.String(), "Review shared at: "+server.URL+"/")
+	data, found := fetchArtifact(ctx, "Review", "review.sarif")
+	require.True(t, found)
+	assert.Equal(t, "as sarif", string(data))
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/owners"
+)
+
+// newOwnerResolver returns an owner resolver for the current repository,
+// or nil outside one or when its CODEOWNERS cannot be read
+func newOwnerResolver() *owners.Resolver {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return nil
+	}
+	resolver, err := owners.NewResolver(repo.Root)
+	if err != nil {
+		logger.Warn("failed to load code owners", "error", err)
+		return nil
+	}
+	return resolver
+}
+
+// proposalOwners returns the owners of the code a proposal changes, each
+// once, in the order the changes name them
+func proposalOwners(resolver *owners.Resolver, proposal agent.Proposal) []string {
+	if resolver == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var found []string
+	for _, change := range proposal.Changes {
+		for _, owner := range resolver.Owners(change.Path, change.StartLine, change.EndLine) {
+			if !seen[owner] {
+				seen[owner] = true
+				found = append(found, owner)
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/owners"
+)
+
+func TestProposalOwners(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "CODEOWNERS"), []byte("*.go @core\n/web/ @frontend @core\n"), 0600))
+	resolver, err := owners.NewResolver(root)
+	require.NoError(t, err)
+
+	proposal := agent.Proposal{Changes: []agent.Change{
+		{Path: filepath.Join(root, "main.go")},
+		{Path: filepath.Join(root, "web", "page.ts"), Type: agent.ChangeTypeCreate},
+	}}
+	assert.Equal(t, []string{"@core", "@frontend"}, proposalOwners(resolver, proposal))
+	assert.Nil(t, proposalOwners(nil, proposal))
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// progressBarWidth is how many cells a progress bar has
+const progressBarWidth = 30
+
+// progressRenderer draws the progress long model and tool calls report,
+// such as an MCP server indexing a repository, on one terminal line
+type progressRenderer struct {
+	out   io.Writer
+	shown bool // Whether a progress line is on screen
+}
+
+// renderProgress shows the progress of an orchestrator's tasks on out while
+// it is a terminal
+func renderProgress(orchestrator *agent.DefaultOrchestrator, out *os.File) {
+	if !isTerminal(out) {
+		return
+	}
+	sub := orchestrator.Events().Subscribe(agent.EventProgress, agent.EventTaskCompleted, agent.EventTaskFailed)
+	renderer := &progressRenderer{out: out}
+	go func() {
+		for event := range sub.Events() {
+			renderer.handle(event)
+		}
+	}()
+}
+
+// handle redraws the progress line, clearing it when the task ends
+func (r *progressRenderer) handle(event agent.OrchestrationEvent) {
+	if progress, ok := event.Payload.(agent.ProgressPayload); ok {
+		fmt.Fprintf(r.out, "\r%s\x1b[K", progressLine(progress))
+		r.shown = true
+		return
+	}
+	if r.shown {
+		fmt.Fprint(r.out, "\r\x1b[K")
+		r.shown = false
+	}
+}
+
+// progressLine formats progress as a bar, or as a count when the total is
+// unknown
+func progressLine(progress agent.ProgressPayload) string {
+	label := progress.Source
+	if label == "" {
+		label = "working"
+	}
+
+	var line string
+	if fraction, ok := (model.Progress{Progress: progress.Progress, Total: progress.Total}).Fraction(); ok {
+		filled := int(fraction * progressBarWidth)
+		line = fmt.Sprintf("%s [%s%s] %3.0f%%", label,
+			strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), fraction*100)
+	} else {
+		line = fmt.Sprintf("%s %g", label, progress.Progress)
+	}
+	if progress.Message != "" {
+		line += " " + progress.Message
+	}
+	return line
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dshills/sigil/internal/agent"
+)
+
+func TestProgressLine(t *testing.T) {
+	assert.Equal(t, "indexer [===============               ]  50% parsing",
+		progressLine(agent.ProgressPayload{Source: "indexer", Progress: 5, Total: 10, Message: "parsing"}))
+	assert.Equal(t, "working [==============================] 100%",
+		progressLine(agent.ProgressPayload{Progress: 12, Total: 10}))
+	assert.Equal(t, "indexer 42", progressLine(agent.ProgressPayload{Source: "indexer", Progress: 42}))
+}
+
+func TestProgressRenderer(t *testing.T) {
+	var out bytes.Buffer
+	renderer := &progressRenderer{out: &out}
+
+	renderer.handle(agent.OrchestrationEvent{Type: agent.EventTaskCompleted, Payload: agent.TaskCompletedPayload{}})
+	assert.Empty(t, out.String(), "nothing to clear before any progress")
+
+	renderer.handle(agent.OrchestrationEvent{Type: agent.EventProgress, Payload: agent.ProgressPayload{Source: "indexer", Progress: 3}})
+	renderer.handle(agent.OrchestrationEvent{Type: agent.EventTaskCompleted, Payload: agent.TaskCompletedPayload{}})
+	assert.Equal(t, "\rindexer 3\x1b[K\r\x1b[K", out.String())
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/model/providers/mcp"
+	"github.com/spf13/cobra"
+)
+
+// NewPromptCommand creates the prompt preset command
+func NewPromptCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "Browse prompt presets provided by MCP servers",
+		Long: `Browse prompt templates published by configured MCP servers.
+
+A preset can seed the ask and review commands with --prompt <name>.
+Template arguments are given with --prompt-arg name=value; required
+arguments that are not given are asked for interactively.`,
+		Example: `  # List available prompt presets
+  sigil prompt list
+
+  # Seed a question with a preset
+  sigil ask --prompt github-mcp/summarize-pr --prompt-arg pr=42
+
+  # Review files following a preset
+  sigil review main.go --prompt security-review`,
+	}
+
+	cmd.AddCommand(newPromptListCommand())
+
+	return cmd
+}
+
+// newPromptListCommand creates the list subcommand
+func newPromptListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List prompt presets",
+		Long:  "List the prompt templates offered by all configured MCP servers.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider := mcp.NewProvider()
+			defer provider.Shutdown()
+
+			presets, err := provider.PromptPresets(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if len(presets) == 0 {
+				fmt.Println("No prompt presets available.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tARGUMENTS\tDESCRIPTION")
+			fmt.Fprintln(w, "----\t---------\t-----------")
+			for _, preset := range presets {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", preset.Name(), formatPromptArguments(preset.Template.Arguments), preset.Template.Description)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// formatPromptArguments lists argument names, marking optional ones with '?'
+func formatPromptArguments(args []mcp.TemplateArgument) string {
+	if len(args) == 0 {
+		return "-"
+	}
+	names := make([]string, len(args))
+	for i, arg := range args {
+		names[i] = arg.Name
+		if !arg.Required {
+			names[i] += "?"
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// promptPresetFlags selects an MCP prompt preset to seed a command
+type promptPresetFlags struct {
+	Name string
+	Args []string
+}
+
+// register adds --prompt and --prompt-arg to cmd
+func (f *promptPresetFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.Name, "prompt", "", "MCP prompt preset to seed the task (see 'sigil prompt list')")
+	cmd.Flags().StringArrayVar(&f.Args, "prompt-arg", nil, "Prompt preset argument as name=value (repeatable)")
+}
+
+// resolve renders the selected preset, or returns "" when none is selected.
+// Required arguments missing from the flags are read from in when it is a
+// terminal.
+func (f *promptPresetFlags) resolve(ctx context.Context, in io.Reader) (string, error) {
+	if f.Name == "" {
+		if len(f.Args) > 0 {
+			return "", errors.ValidationError("resolve", "--prompt-arg requires --prompt")
+		}
+		return "", nil
+	}
+
+	args, err := parseArgPairs("--prompt-arg", f.Args)
+	if err != nil {
+		return "", err
+	}
+
+	provider := mcp.NewProvider()
+	defer provider.Shutdown()
+
+	preset, err := provider.FindPromptPreset(ctx, f.Name)
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeInput, "resolve", "failed to find prompt preset")
+	}
+
+	if missing := preset.Template.MissingArguments(args); len(missing) > 0 && isTerminal(in) {
+		reader := bufio.NewReader(in)
+		for _, arg := range missing {
+			value, err := askPromptArgument(reader, arg)
+			if err != nil {
+				return "", err
+			}
+			args[arg.Name] = value
+		}
+	}
+
+	result, err := provider.RenderPromptPreset(ctx, preset, args)
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeInput, "resolve", "failed to render prompt preset")
+	}
+
+	text := strings.TrimSpace(result.Text())
+	if text == "" {
+		return "", errors.New(errors.ErrorTypeInput, "resolve", fmt.Sprintf("prompt %s rendered no text", preset.Name()))
+	}
+	return text, nil
+}
+
+// parseArgPairs parses the name=value pairs given with flag
+func parseArgPairs(flag string, pairs []string) (map[string]string, error) {
+	args := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return nil, errors.ValidationError("parseArgPairs",
+				fmt.Sprintf("invalid %s %q: expected name=value", flag, pair))
+		}
+		args[name] = value
+	}
+	return args, nil
+}
+
+// askPromptArgument reads one argument value interactively
+func askPromptArgument(reader *bufio.Reader, arg mcp.TemplateArgument) (string, error) {
+	if arg.Description != "" {
+		fmt.Fprintf(os.Stderr, "%s (%s): ", arg.Name, arg.Description)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", arg.Name)
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", errors.Wrap(err, errors.ErrorTypeInput, "askPromptArgument", "failed to read prompt argument")
+	}
+	value := strings.TrimRight(line, "\r\n")
+	if value == "" {
+		return "", errors.ValidationError("askPromptArgument", fmt.Sprintf("prompt argument %s is required", arg.Name))
+	}
+	return value, nil
+}
+
+// isTerminal reports whether r is an interactive terminal
+func isTerminal(r io.Reader) bool {
+	file, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/dshills/sigil/internal/model/providers/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgPairs(t *testing.T) {
+	args, err := parseArgPairs("--prompt-arg", []string{"pr=42", "query=a=b", "empty="})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"pr": "42", "query": "a=b", "empty": ""}, args)
+
+	_, err = parseArgPairs("--prompt-arg", []string{"novalue"})
+	assert.ErrorContains(t, err, `invalid --prompt-arg "novalue"`)
+
+	_, err = parseArgPairs("--prompt-arg", []string{"=value"})
+	assert.Error(t, err)
+}
+
+func TestFormatPromptArguments(t *testing.T) {
+	assert.Equal(t, "-", formatPromptArguments(nil))
+	assert.Equal(t, "pr,style?", formatPromptArguments([]mcp.TemplateArgument{
+		{Name: "pr", Required: true},
+		{Name: "style"},
+	}))
+}
+
+func TestPromptPresetFlags_Resolve(t *testing.T) {
+	var none promptPresetFlags
+	text, err := none.resolve(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, text)
+
+	argsOnly := promptPresetFlags{Args: []string{"pr=1"}}
+	_, err = argsOnly.resolve(t.Context(), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// qualityChecker runs the --quality-pass over generated output before it
+// is written. A nil checker leaves output alone.
+type qualityChecker struct {
+	pass *agent.QualityPass
+}
+
+// newQualityChecker creates the checker for a command: the command's model
+// revises, and the first configured reviewer, or else the same model,
+// critiques. It is nil when the quality pass is off.
+func newQualityChecker(ctx context.Context, b *BaseCommand, enabled bool) (*qualityChecker, error) {
+	if !enabled {
+		return nil, nil
+	}
+	lead, err := b.GetModel(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeModel, "newQualityChecker", "failed to get model for the quality pass")
+	}
+
+	var reviewer model.Model
+	if reviewers := getConfig().Models.Reviewers; len(reviewers) > 0 {
+		if reviewer, err = loadModel(reviewers[0]); err != nil {
+			logger.Warn("quality pass reviewer unavailable, the lead critiques its own output", "model", reviewers[0], "error", err)
+			reviewer = nil
+		}
+	}
+	return &qualityChecker{pass: agent.NewQualityPass(lead, reviewer)}, nil
+}
+
+// check returns output after the quality pass: as generated when the
+// critique passes, revised once when it does not. A failing pass keeps
+// the output as generated.
+func (q *qualityChecker) check(ctx context.Context, kind, instructions, output string, sources []model.FileContent) string {
+	if q == nil || strings.TrimSpace(output) == "" {
+		return output
+	}
+	result, err := q.pass.Run(ctx, kind, instructions, output, sources)
+	if err != nil {
+		logger.Warn("quality pass failed, keeping the output as generated", "kind", kind, "error", err)
+		return output
+	}
+	if result.Revised {
+		logger.Info("quality pass revised output", "kind", kind, "issues", len(result.Critique.Issues))
+	}
+	return result.Output
+}
+
+// checkResult runs the quality pass over the generated content of a task's
+// result, the documentation artifact or else the lead's text, in place
+func (q *qualityChecker) checkResult(ctx context.Context, kind string, task *agent.Task, result *agent.OrchestrationResult) {
+	if q == nil || result.FinalResult == nil {
+		return
+	}
+	sources := make([]model.FileContent, 0, len(task.Context.Files))
+	for _, file := range task.Context.Files {
+		sources = append(sources, model.FileContent{Path: file.Path, Content: file.Content, Type: "code"})
+	}
+	instructions := strings.Join(task.Context.Requirements, "\n")
+
+	final := result.FinalResult
+	for i, artifact := range final.Artifacts {
+		if artifact.Type == agent.ArtifactTypeDocumentation && artifact.Content != "" {
+			final.Artifacts[i].Content = q.check(ctx, kind, instructions, artifact.Content, sources)
+			return
+		}
+	}
+	if final.Reasoning == "" && len(final.Artifacts) > 0 {
+		final.Artifacts[0].Content = q.check(ctx, kind, instructions, final.Artifacts[0].Content, sources)
+		return
+	}
+	final.Reasoning = q.check(ctx, kind, instructions, final.Reasoning, sources)
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// scriptedModel answers prompts with its responses in turn
+type scriptedModel struct {
+	responses []string
+	prompts   []model.PromptInput
+}
+
+func (m *scriptedModel) RunPrompt(_ context.Context, input model.PromptInput) (model.PromptOutput, error) {
+	m.prompts = append(m.prompts, input)
+	if len(m.responses) == 0 {
+		return model.PromptOutput{}, errors.New("no response scripted")
+	}
+	response := m.responses[0]
+	m.responses = m.responses[1:]
+	return model.PromptOutput{Response: response}, nil
+}
+
+func (m *scriptedModel) GetCapabilities() model.ModelCapabilities { return model.ModelCapabilities{} }
+
+func (m *scriptedModel) Name() string { return "scripted" }
+
+func TestQualityChecker_checkResult(t *testing.T) {
+	lead := &scriptedModel{responses: []string{"# Store\n\nOpen opens the store."}}
+	reviewer := &scriptedModel{responses: []string{`{"scores": {"accuracy": 2, "completeness": 5, "formatting": 5}, "issues": ["Open takes no timeout"]}`}}
+	quality := &qualityChecker{pass: agent.NewQualityPass(lead, reviewer)}
+
+	task := &agent.Task{Context: agent.TaskContext{
+		Files:        []agent.FileContext{{Path: "store.go", Content: "package store\n"}},
+		Requirements: []string{"Document every exported function"},
+	}}
+	result := &agent.OrchestrationResult{FinalResult: &agent.Result{
+		Reasoning: "I documented the store.",
+		Artifacts: []agent.Artifact{{Type: agent.ArtifactTypeDocumentation, Content: "# Store\n\nOpen takes a timeout."}},
+	}}
+
+	quality.checkResult(context.Background(), "documentation", task, result)
+	assert.Equal(t, "# Store\n\nOpen opens the store.", result.FinalResult.Artifacts[0].Content, "the documentation artifact is revised")
+	assert.Equal(t, "I documented the store.", result.FinalResult.Reasoning)
+	require.Len(t, reviewer.prompts, 1)
+	assert.Contains(t, reviewer.prompts[0].UserPrompt, "Document every exported function")
+	assert.Equal(t, "store.go", reviewer.prompts[0].Files[0].Path)
+
+	// A failing critique keeps the output as generated
+	assert.Equal(t, "A store.", quality.check(context.Background(), "summary", "", "A store.", nil))
+
+	var off *qualityChecker
+	off.checkResult(context.Background(), "summary", task, result)
+	assert.Equal(t, "unchanged", off.check(context.Background(), "summary", "", "unchanged", nil))
+}
+
+func TestCommitCommand_checkMessage(t *testing.T) {
+	lead := &scriptedModel{responses: []string{"fix(parser): handle empty input gracefully and also every other edge case\n\nReturn an empty tree instead of panicking."}}
+	reviewer := &scriptedModel{responses: []string{`{"scores": {"accuracy": 3, "completeness": 3, "formatting": 5}, "issues": ["The body is missing"]}`}}
+	quality := &qualityChecker{pass: agent.NewQualityPass(lead, reviewer)}
+
+	cfg := config.CommitConfig{MaxSubject: 50, BodyWrap: 72}
+	fields := commitFields{Type: "fix", Scope: "parser", Subject: "handle empty input"}
+	template := "{type}({scope}): {subject}\n\n{body}"
+
+	cmd := NewCommitCommand()
+	message := cmd.checkMessage(context.Background(), quality, "fix(parser): handle empty input", "diff --git a/p.go b/p.go\n", template, fields, cfg)
+	assert.Equal(t, "fix(parser): handle empty input gracefully and\n\nReturn an empty tree instead of panicking.", message,
+		"the revision is rendered through the template and its limits")
+	assert.Contains(t, reviewer.prompts[0].UserPrompt, "at most 50 characters")
+}
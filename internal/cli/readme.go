@@ -0,0 +1,571 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// README sections the readme command can manage, in insertion order
+const (
+	ReadmeSectionBadges   = "badges"
+	ReadmeSectionInstall  = "install"
+	ReadmeSectionUsage    = "usage"
+	ReadmeSectionFeatures = "features"
+)
+
+// readmeSections lists every section with the heading it is written under;
+// badges sit below the title without a heading
+var readmeSections = []struct {
+	Name    string
+	Heading string
+}{
+	{ReadmeSectionBadges, ""},
+	{ReadmeSectionInstall, "Installation"},
+	{ReadmeSectionUsage, "Usage"},
+	{ReadmeSectionFeatures, "Features"},
+}
+
+var (
+	// goModule matches the module directive of a go.mod file
+	goModule = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+	// tomlName matches the name key of a Cargo.toml or pyproject.toml section
+	tomlName = regexp.MustCompile(`(?m)^name\s*=\s*"([^"]+)"`)
+
+	// githubRemote matches the owner and repository of a GitHub remote URL
+	githubRemote = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+	// readmeTrailer matches headings that generated sections go before
+	readmeTrailer = regexp.MustCompile(`(?i)^#{1,2}\s+(license|contributing|acknowledg)`)
+)
+
+// ReadmeCommand generates and updates standard README sections
+type ReadmeCommand struct {
+	*BaseCommand
+	Dir        string
+	Sections   []string
+	OutputFile string
+	Preview    bool
+	onboard    *OnboardCommand
+}
+
+// NewReadmeCommand creates a new readme command
+func NewReadmeCommand() *ReadmeCommand {
+	return &ReadmeCommand{
+		BaseCommand: NewBaseCommand("readme", "Generate standard README sections",
+			"Generate or update README badges, installation, usage and feature sections."),
+		Dir:     ".",
+		onboard: NewOnboardCommand(),
+	}
+}
+
+// Execute runs the readme command
+func (c *ReadmeCommand) Execute(_ context.Context) error {
+	logger.Info("starting readme generation", "dir", c.Dir, "sections", c.Sections)
+
+	if err := c.validateInputs(); err != nil {
+		return err
+	}
+
+	readmePath := c.OutputFile
+	if readmePath == "" {
+		readmePath = filepath.Join(c.Dir, "README.md")
+	}
+
+	c.onboard.Dir = c.Dir
+	facts, err := c.onboard.detectFacts()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to inspect project")
+	}
+
+	existing := ""
+	if content, err := os.ReadFile(readmePath); err == nil {
+		existing = string(content)
+	} else if !os.IsNotExist(err) {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Execute", "failed to read README")
+	}
+
+	generated, err := c.generateSections(facts)
+	if err != nil {
+		return err
+	}
+	updated := mergeReadme(existing, facts.Name, generated)
+
+	if c.Preview {
+		fmt.Print(unifiedDiff(existing, updated, filepath.ToSlash(readmePath)))
+		return nil
+	}
+	if updated == existing {
+		fmt.Printf("README is up to date: %s\n", readmePath)
+		return nil
+	}
+	if err := os.WriteFile(readmePath, []byte(updated), 0644); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Execute", "failed to write README")
+	}
+	fmt.Printf("README updated: %s\n", readmePath)
+	return nil
+}
+
+// validateInputs validates the command inputs
+func (c *ReadmeCommand) validateInputs() error {
+	info, err := os.Stat(c.Dir)
+	if err != nil || !info.IsDir() {
+		return errors.New(errors.ErrorTypeInput, "validateInputs",
+			fmt.Sprintf("not a directory: %s", c.Dir))
+	}
+
+	valid := make([]string, len(readmeSections))
+	for i, section := range readmeSections {
+		valid[i] = section.Name
+	}
+	for _, name := range c.Sections {
+		if !contains(valid, name) {
+			return errors.New(errors.ErrorTypeInput, "validateInputs",
+				fmt.Sprintf("invalid section: %s (valid: %s)", name, strings.Join(valid, ", ")))
+		}
+	}
+	return nil
+}
+
+// wants reports whether a section was requested; no selection means all
+func (c *ReadmeCommand) wants(name string) bool {
+	return len(c.Sections) == 0 || contains(c.Sections, name)
+}
+
+// readmeSection is the generated body of one section
+type readmeSection struct {
+	Name    string
+	Heading string
+	Body    string
+}
+
+// generateSections builds the requested sections that have content
+func (c *ReadmeCommand) generateSections(facts *ProjectFacts) ([]readmeSection, error) {
+	meta := c.detectMetadata()
+
+	var commands []cobraCommandInfo
+	if c.wants(ReadmeSectionUsage) || c.wants(ReadmeSectionFeatures) {
+		var err error
+		commands, err = extractCobraCommands(c.Dir, c.onboard.summarizer.skipDir)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeFS, "generateSections", "failed to read command definitions")
+		}
+	}
+
+	var sections []readmeSection
+	for _, section := range readmeSections {
+		if !c.wants(section.Name) {
+			continue
+		}
+
+		var body string
+		switch section.Name {
+		case ReadmeSectionBadges:
+			body = c.badges(meta)
+		case ReadmeSectionInstall:
+			body = c.installation(meta, facts)
+		case ReadmeSectionUsage:
+			body = usage(meta.Binary, commands)
+		case ReadmeSectionFeatures:
+			body = featureMatrix(meta.Binary, commands)
+		}
+		if body == "" {
+			logger.Debug("nothing to generate for README section", "section", section.Name)
+			continue
+		}
+		sections = append(sections, readmeSection{Name: section.Name, Heading: section.Heading, Body: body})
+	}
+	return sections, nil
+}
+
+// projectMetadata is what the README sections are built from
+type projectMetadata struct {
+	GoModule   string
+	Binaries   []string // main packages below cmd/
+	RootMain   bool     // a main package at the module root
+	Binary     string   // the name the CLI is invoked by
+	NodeName   string
+	Python     string
+	Crate      string
+	CrateBin   bool
+	GitHub     string // owner/repo
+	CloneURL   string
+	Workflows  []string
+	HasLicense bool
+}
+
+// detectMetadata reads package names and the repository remote
+func (c *ReadmeCommand) detectMetadata() projectMetadata {
+	var meta projectMetadata
+
+	if content, err := os.ReadFile(filepath.Join(c.Dir, "go.mod")); err == nil {
+		if match := goModule.FindSubmatch(content); match != nil {
+			meta.GoModule = string(match[1])
+		}
+	}
+	if entries, err := os.ReadDir(filepath.Join(c.Dir, "cmd")); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && c.isMainPackage(filepath.Join(c.Dir, "cmd", entry.Name())) {
+				meta.Binaries = append(meta.Binaries, entry.Name())
+			}
+		}
+	}
+	meta.RootMain = c.isMainPackage(c.Dir)
+	switch {
+	case len(meta.Binaries) > 0:
+		meta.Binary = meta.Binaries[0]
+	case meta.GoModule != "":
+		meta.Binary = path.Base(meta.GoModule)
+	}
+
+	if content, err := os.ReadFile(filepath.Join(c.Dir, "package.json")); err == nil {
+		var pkg struct {
+			Name    string `json:"name"`
+			Private bool   `json:"private"`
+		}
+		if json.Unmarshal(content, &pkg) == nil && !pkg.Private {
+			meta.NodeName = pkg.Name
+		}
+	}
+	if content, err := os.ReadFile(filepath.Join(c.Dir, "pyproject.toml")); err == nil {
+		if match := tomlName.FindSubmatch(content); match != nil {
+			meta.Python = string(match[1])
+		}
+	}
+	if content, err := os.ReadFile(filepath.Join(c.Dir, "Cargo.toml")); err == nil {
+		if match := tomlName.FindSubmatch(content); match != nil {
+			meta.Crate = string(match[1])
+		}
+		meta.CrateBin = c.onboard.exists("src/main.rs") || strings.Contains(string(content), "[[bin]]")
+	}
+
+	if repo, err := git.NewRepository(c.Dir); err == nil {
+		if remote, err := repo.GetRemoteURL("origin"); err == nil {
+			meta.CloneURL = remote
+			if match := githubRemote.FindStringSubmatch(remote); match != nil {
+				meta.GitHub = match[1] + "/" + match[2]
+				meta.CloneURL = "https://github.com/" + meta.GitHub + ".git"
+			}
+		}
+	}
+	if workflows, err := filepath.Glob(filepath.Join(c.Dir, ".github", "workflows", "*.y*ml")); err == nil {
+		for _, workflow := range workflows {
+			meta.Workflows = append(meta.Workflows, filepath.Base(workflow))
+		}
+	}
+	meta.HasLicense = c.onboard.exists("LICENSE") || c.onboard.exists("LICENSE.md")
+	return meta
+}
+
+// isMainPackage reports whether dir holds a Go main package
+func (c *ReadmeCommand) isMainPackage(dir string) bool {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return false
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		if name, ok := goPackageName(file); ok && name == "main" {
+			return true
+		}
+	}
+	return false
+}
+
+// goPackageName returns the package clause of a Go file
+func goPackageName(file string) (string, bool) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "package" {
+			return fields[1], true
+		}
+	}
+	return "", false
+}
+
+// badges returns a line of status badges for the project's hosting and ecosystem
+func (c *ReadmeCommand) badges(meta projectMetadata) string {
+	var badges []string
+	if meta.GitHub != "" {
+		for _, workflow := range meta.Workflows {
+			name := strings.TrimSuffix(strings.TrimSuffix(workflow, ".yml"), ".yaml")
+			url := fmt.Sprintf("https://github.com/%s/actions/workflows/%s", meta.GitHub, workflow)
+			badges = append(badges, fmt.Sprintf("[![%s](%s/badge.svg)](%s)", name, url, url))
+		}
+	}
+	if strings.Contains(meta.GoModule, ".") {
+		badges = append(badges,
+			fmt.Sprintf("[![Go Reference](https://pkg.go.dev/badge/%s.svg)](https://pkg.go.dev/%s)", meta.GoModule, meta.GoModule),
+			fmt.Sprintf("[![Go Report Card](https://goreportcard.com/badge/%s)](https://goreportcard.com/report/%s)", meta.GoModule, meta.GoModule))
+	}
+	if meta.NodeName != "" {
+		badges = append(badges, fmt.Sprintf("[![npm](https://img.shields.io/npm/v/%s)](https://www.npmjs.com/package/%s)", meta.NodeName, meta.NodeName))
+	}
+	if meta.Python != "" {
+		badges = append(badges, fmt.Sprintf("[![PyPI](https://img.shields.io/pypi/v/%s)](https://pypi.org/project/%s/)", meta.Python, meta.Python))
+	}
+	if meta.Crate != "" {
+		badges = append(badges, fmt.Sprintf("[![crates.io](https://img.shields.io/crates/v/%s)](https://crates.io/crates/%s)", meta.Crate, meta.Crate))
+	}
+	if meta.GitHub != "" && meta.HasLicense {
+		badges = append(badges, fmt.Sprintf("[![License](https://img.shields.io/github/license/%s)](LICENSE)", meta.GitHub))
+	}
+	return strings.Join(badges, "\n")
+}
+
+// installation returns install commands for each detected ecosystem and
+// the steps to build from source
+func (c *ReadmeCommand) installation(meta projectMetadata, facts *ProjectFacts) string {
+	var install []string
+	if meta.GoModule != "" {
+		for _, binary := range meta.Binaries {
+			install = append(install, fmt.Sprintf("go install %s/cmd/%s@latest", meta.GoModule, binary))
+		}
+		if meta.RootMain {
+			install = append(install, fmt.Sprintf("go install %s@latest", meta.GoModule))
+		}
+		if len(install) == 0 {
+			install = append(install, fmt.Sprintf("go get %s", meta.GoModule))
+		}
+	}
+	if meta.NodeName != "" {
+		install = append(install, fmt.Sprintf("npm install %s", meta.NodeName))
+	}
+	if meta.Python != "" {
+		install = append(install, fmt.Sprintf("pip install %s", meta.Python))
+	}
+	if meta.Crate != "" {
+		if meta.CrateBin {
+			install = append(install, fmt.Sprintf("cargo install %s", meta.Crate))
+		} else {
+			install = append(install, fmt.Sprintf("cargo add %s", meta.Crate))
+		}
+	}
+
+	var build []string
+	if meta.CloneURL != "" {
+		build = append(build, "git clone "+meta.CloneURL, "cd "+strings.TrimSuffix(path.Base(meta.CloneURL), ".git"))
+	}
+	for _, command := range facts.Commands {
+		if command.Purpose == "build" && !contains(build, command.Command) {
+			build = append(build, command.Command)
+		}
+	}
+
+	var result strings.Builder
+	if len(install) > 0 {
+		result.WriteString(fmt.Sprintf("```bash\n%s\n```\n", strings.Join(install, "\n")))
+	}
+	if len(build) > 0 && (meta.CloneURL != "" || len(build) > 1) {
+		if result.Len() > 0 {
+			result.WriteString("\n")
+		}
+		result.WriteString(fmt.Sprintf("To build from source:\n\n```bash\n%s\n```\n", strings.Join(build, "\n")))
+	}
+	return strings.TrimSuffix(result.String(), "\n")
+}
+
+// commandLine returns how a command is invoked: the root command is the
+// binary itself and subcommands follow it
+func commandLine(binary string, command cobraCommandInfo) string {
+	if binary == "" || command.Name() == binary {
+		return command.Use
+	}
+	return binary + " " + command.Use
+}
+
+// usage returns each command's synopsis, description and examples
+func usage(binary string, commands []cobraCommandInfo) string {
+	var result strings.Builder
+	for _, command := range commands {
+		if result.Len() > 0 {
+			result.WriteString("\n")
+		}
+		result.WriteString(fmt.Sprintf("### `%s`\n", commandLine(binary, command)))
+		if command.Short != "" {
+			result.WriteString("\n" + command.Short + "\n")
+		}
+		if example := strings.TrimRight(command.Example, "\n "); example != "" {
+			result.WriteString(fmt.Sprintf("\n```bash\n%s\n```\n", example))
+		}
+	}
+	return strings.TrimSuffix(result.String(), "\n")
+}
+
+// featureMatrix returns a table of commands, what they do and their flags
+func featureMatrix(binary string, commands []cobraCommandInfo) string {
+	if len(commands) == 0 {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString("| Command | Description | Flags |\n|---------|-------------|-------|\n")
+	for _, command := range commands {
+		flags := make([]string, len(command.Flags))
+		for i, flag := range command.Flags {
+			flags[i] = "`--" + flag + "`"
+		}
+		name := command.Name()
+		if binary != "" && name != binary {
+			name = binary + " " + name
+		}
+		result.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", name,
+			strings.ReplaceAll(command.Short, "|", `\|`), strings.Join(flags, ", ")))
+	}
+	return strings.TrimSuffix(result.String(), "\n")
+}
+
+// sectionMarkers returns the comments delimiting a generated section
+func sectionMarkers(name string) (string, string) {
+	return fmt.Sprintf("<!-- sigil:generated %s -->", name), fmt.Sprintf("<!-- /sigil:generated %s -->", name)
+}
+
+// mergeReadme places generated sections into a README without touching
+// anything written by hand. Sections between their markers are replaced;
+// new badges go below the title and other new sections before the license
+// and contributing sections. A hand-written section with the same heading
+// is left alone and not duplicated.
+func mergeReadme(existing, title string, sections []readmeSection) string {
+	content := existing
+	if strings.TrimSpace(content) == "" {
+		content = "# " + title + "\n"
+	}
+
+	for _, section := range sections {
+		begin, end := sectionMarkers(section.Name)
+		block := begin + "\n"
+		if section.Heading != "" {
+			block += "## " + section.Heading + "\n\n"
+		}
+		block += section.Body + "\n" + end
+
+		start := strings.Index(content, begin)
+		if start >= 0 {
+			if stop := strings.Index(content[start:], end); stop >= 0 {
+				content = content[:start] + block + content[start+stop+len(end):]
+				continue
+			}
+		}
+
+		if section.Heading != "" && hasHeading(content, section.Heading) {
+			logger.Info("keeping hand-written README section", "section", section.Heading,
+				"hint", "wrap it in "+begin+" markers to let sigil manage it")
+			continue
+		}
+		content = insertReadmeBlock(content, section.Name, block)
+	}
+	return content
+}
+
+// hasHeading reports whether content has a level one or two heading
+func hasHeading(content, heading string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(strings.TrimLeft(line, "#"))
+		if strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "###") && strings.EqualFold(trimmed, heading) {
+			return true
+		}
+	}
+	return false
+}
+
+// insertReadmeBlock inserts a new generated block where it belongs
+func insertReadmeBlock(content, name, block string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	at := len(lines)
+	if name == ReadmeSectionBadges {
+		at = 0
+		for i, line := range lines {
+			if strings.HasPrefix(line, "# ") {
+				at = i + 1
+				break
+			}
+		}
+	} else {
+		for i, line := range lines {
+			if readmeTrailer.MatchString(line) {
+				at = i
+				break
+			}
+		}
+	}
+
+	before := trimTrailingBlank(lines[:at])
+	after := lines[at:]
+	for len(after) > 0 && strings.TrimSpace(after[0]) == "" {
+		after = after[1:]
+	}
+
+	var result []string
+	result = append(result, before...)
+	if len(before) > 0 {
+		result = append(result, "")
+	}
+	result = append(result, strings.Split(block, "\n")...)
+	if len(after) > 0 {
+		result = append(result, "")
+		result = append(result, after...)
+	}
+	return strings.Join(result, "\n") + "\n"
+}
+
+// contains reports whether values includes value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateCobraCommand creates the cobra command for doc readme
+func (c *ReadmeCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "readme [dir]",
+		Short: "Generate standard README sections",
+		Long: `Generate or update standard README sections from the project itself:
+
+  badges    CI workflow, package registry, Go reference and license badges
+  install   install commands detected from the build system
+  usage     usage and examples extracted from cobra command definitions
+  features  a table of commands, what they do and their flags
+
+Generated sections are wrapped in <!-- sigil:generated NAME --> markers and
+replaced in place on later runs. Everything else in the README is left as
+it is, and a hand-written section with the same heading is never duplicated.`,
+		Example: `  sigil doc readme
+  sigil doc readme --sections badges,install --preview
+  sigil doc readme ../service --output ../service/README.md`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				c.Dir = args[0]
+			}
+			return c.Execute(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&c.Sections, "sections", nil, "Sections to generate (badges, install, usage, features; default: all)")
+	cmd.Flags().StringVarP(&c.OutputFile, "output", "o", "", "README to update (default: README.md in the project directory)")
+	cmd.Flags().BoolVar(&c.Preview, "preview", false, "Show the changes as a diff without writing")
+
+	return cmd
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeReadmeFixture creates a cobra CLI module with a workflow and a license
+func writeReadmeFixture(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                   "module github.com/acme/tool\n\ngo 1.24\n",
+		"LICENSE":                  "MIT",
+		".github/workflows/ci.yml": "name: ci",
+		"cmd/tool/main.go":         "package main\n\nfunc main() {}\n",
+		"internal/cli/root.go": `package cli
+
+import "github.com/spf13/cobra"
+
+var rootCmd = &cobra.Command{
+	Use:   "tool",
+	Short: "Does tool things",
+}
+
+func newServeCommand() *cobra.Command {
+	var port int
+	cmd := &cobra.Command{
+		Use:     "serve [dir]",
+		Short:   "Serve a directory | over HTTP",
+		Example: "  tool serve ./public --port 8080",
+	}
+	cmd.Flags().IntVarP(&port, "port", "p", 8080, "Port to listen on")
+	cmd.PersistentFlags().Bool("verbose", false, "Verbose output")
+	return cmd
+}
+`,
+	}
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+	}
+	return root
+}
+
+func TestExtractCobraCommands(t *testing.T) {
+	root := writeReadmeFixture(t)
+
+	commands, err := extractCobraCommands(root, NewSummarizeCommand().skipDir)
+	require.NoError(t, err)
+
+	require.Len(t, commands, 2)
+	assert.Equal(t, cobraCommandInfo{
+		Use:     "serve [dir]",
+		Short:   "Serve a directory | over HTTP",
+		Example: "  tool serve ./public --port 8080",
+		Flags:   []string{"port", "verbose"},
+		File:    "internal/cli/root.go",
+	}, commands[0])
+	assert.Equal(t, "tool", commands[1].Name())
+}
+
+func TestReadmeCommand_generateSections(t *testing.T) {
+	root := writeReadmeFixture(t)
+
+	cmd := NewReadmeCommand()
+	cmd.Dir = root
+	cmd.onboard.Dir = root
+	facts, err := cmd.onboard.detectFacts()
+	require.NoError(t, err)
+
+	sections, err := cmd.generateSections(facts)
+	require.NoError(t, err)
+	require.Len(t, sections, 4)
+
+	assert.Contains(t, sections[0].Body, "[![Go Reference](https://pkg.go.dev/badge/github.com/acme/tool.svg)](https://pkg.go.dev/github.com/acme/tool)")
+	assert.NotContains(t, sections[0].Body, "actions/workflows", "workflow badges need a GitHub remote")
+	assert.Contains(t, sections[1].Body, "go install github.com/acme/tool/cmd/tool@latest")
+	assert.Contains(t, sections[2].Body, "### `tool serve [dir]`\n\nServe a directory | over HTTP\n\n```bash\n  tool serve ./public --port 8080\n```")
+	assert.Contains(t, sections[2].Body, "### `tool`\n\nDoes tool things")
+	assert.Contains(t, sections[3].Body, "| `tool serve` | Serve a directory \\| over HTTP | `--port`, `--verbose` |")
+
+	cmd.Sections = []string{ReadmeSectionInstall}
+	sections, err = cmd.generateSections(facts)
+	require.NoError(t, err)
+	require.Len(t, sections, 1)
+	assert.Equal(t, "Installation", sections[0].Heading)
+
+	cmd.Sections = []string{"bogus"}
+	assert.Error(t, cmd.validateInputs())
+}
+
+func TestMergeReadme(t *testing.T) {
+	sections := []readmeSection{
+		{Name: ReadmeSectionBadges, Body: "[![b](b.svg)](b)"},
+		{Name: ReadmeSectionInstall, Heading: "Installation", Body: "go install x@latest"},
+		{Name: ReadmeSectionUsage, Heading: "Usage", Body: "generated usage"},
+	}
+
+	existing := "# Tool\n\nIntro.\n\n## Usage\n\nHand-written usage.\n\n## License\n\nMIT\n"
+	merged := mergeReadme(existing, "tool", sections)
+
+	assert.Equal(t, `# Tool
+
+<!-- sigil:generated badges -->
+[![b](b.svg)](b)
+<!-- /sigil:generated badges -->
+
+Intro.
+
+## Usage
+
+Hand-written usage.
+
+<!-- sigil:generated install -->
+## Installation
+
+go install x@latest
+<!-- /sigil:generated install -->
+
+## License
+
+MIT
+`, merged, "hand-written sections are kept and new ones go before the license")
+
+	sections[1].Body = "go install y@latest"
+	remerged := mergeReadme(merged, "tool", sections)
+	assert.Contains(t, remerged, "go install y@latest")
+	assert.NotContains(t, remerged, "go install x@latest")
+	assert.Equal(t, len(merged), len(remerged), "markers are replaced in place")
+
+	assert.Equal(t, "# tool\n\n<!-- sigil:generated badges -->\n[![b](b.svg)](b)\n<!-- /sigil:generated badges -->\n",
+		mergeReadme("", "tool", sections[:1]))
+}
@@ -0,0 +1,600 @@
+package cli
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// ReleaseCommand versions, documents and tags a release from the
+// conventional commits since the last one
+type ReleaseCommand struct {
+	*BaseCommand
+	Bump    string
+	Version string
+	DryRun  bool
+	Yes     bool
+	GitHub  bool
+}
+
+// bumpLevel is how much of a version a release increments
+type bumpLevel int
+
+const (
+	bumpNone bumpLevel = iota
+	bumpPatch
+	bumpMinor
+	bumpMajor
+)
+
+// semver is a semantic version
+type semver struct {
+	Major, Minor, Patch int
+	Pre                 string // Pre-release and build suffix, without the leading dash
+}
+
+// releaseCommit is a commit read as a conventional commit
+type releaseCommit struct {
+	Hash        string
+	Type        string // "" for commits that do not follow the convention
+	Scope       string
+	Description string
+	Breaking    bool
+}
+
+// versionFileChange is a version file and its updated content
+type versionFileChange struct {
+	Path    string
+	Content string
+}
+
+// releasePlan is everything a release changes
+type releasePlan struct {
+	Previous  string // Last release tag, "" for the first release
+	Version   semver
+	Tag       string
+	Commits   []releaseCommit
+	Notes     string // Changelog section
+	Files     []versionFileChange
+	Changelog *versionFileChange
+}
+
+// semverPattern matches a version with an optional v prefix
+var semverPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.+-]+))?$`)
+
+// versionAssignment matches the first version assignment in a file, as in
+// package.json, pyproject.toml, Cargo.toml or a Go constant
+var versionAssignment = regexp.MustCompile(`(?i)(\bversion\b["']?\s*[:=]\s*["']?)(v?)\d+\.\d+\.\d+(?:-[0-9A-Za-z.+-]+)?`)
+
+// NewReleaseCommand creates a new release command
+func NewReleaseCommand() *ReleaseCommand {
+	return &ReleaseCommand{
+		BaseCommand: NewBaseCommand("release", "Version, document and tag a release",
+			"Propose the next version from conventional commits, update version files and the changelog, and tag it."),
+	}
+}
+
+// Execute runs the release command
+func (c *ReleaseCommand) Execute(ctx context.Context) error {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to open git repository")
+	}
+	cfg := getConfig().Git.Release
+
+	if !c.DryRun {
+		status, err := repo.GetStatus()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to check the working tree")
+		}
+		if strings.TrimSpace(status) != "" {
+			return errors.ValidationError("Execute", "the working tree has uncommitted changes").
+				WithHint("commit or stash them first, or preview with --dry-run")
+		}
+	}
+
+	previous, err := repo.LatestTag(cfg.TagPrefix + "[0-9]*")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to find the last release")
+	}
+	commits, err := repo.CommitsSince(previous)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to list commits since the last release")
+	}
+
+	plan, err := c.plan(repo.Root, cfg, previous, commits, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Print(formatReleasePlan(plan))
+
+	if c.DryRun {
+		return nil
+	}
+	if !c.Yes {
+		ok, err := confirmRelease(os.Stdin, plan)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Release cancelled.")
+			return nil
+		}
+	}
+
+	if err := applyRelease(repo, plan); err != nil {
+		return err
+	}
+	if c.GitHub {
+		if err := draftGitHubRelease(ctx, repo, plan); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Tagged %s. Push it with: git push --follow-tags\n", plan.Tag)
+	return nil
+}
+
+// plan works out the next version and the changes releasing it makes
+func (c *ReleaseCommand) plan(root string, cfg config.ReleaseConfig, previous string, commits []git.Commit, now time.Time) (*releasePlan, error) {
+	current := semver{}
+	if previous != "" {
+		parsed, ok := parseSemver(strings.TrimPrefix(previous, cfg.TagPrefix))
+		if !ok {
+			return nil, errors.ValidationError("plan", fmt.Sprintf("the last tag %s is not a semantic version", previous))
+		}
+		current = parsed
+	}
+
+	plan := &releasePlan{Previous: previous}
+	for _, commit := range commits {
+		plan.Commits = append(plan.Commits, parseReleaseCommit(commit))
+	}
+
+	switch {
+	case c.Version != "":
+		next, ok := parseSemver(c.Version)
+		if !ok {
+			return nil, errors.ValidationError("plan", fmt.Sprintf("invalid version: %s", c.Version))
+		}
+		if !current.Less(next) {
+			return nil, errors.ValidationError("plan", fmt.Sprintf("version %s is not after %s", next, current))
+		}
+		plan.Version = next
+	default:
+		level := releaseBump(plan.Commits)
+		if level == bumpMajor && current.Major == 0 {
+			// Breaking changes before 1.0.0 bump the minor version
+			level = bumpMinor
+		}
+		if c.Bump != "" {
+			var ok bool
+			if level, ok = parseBumpLevel(c.Bump); !ok {
+				return nil, errors.ValidationError("plan", fmt.Sprintf("invalid bump: %s (valid: major, minor, patch)", c.Bump))
+			}
+		}
+		if level == bumpNone {
+			return nil, errors.ValidationError("plan", fmt.Sprintf("no feat, fix or breaking commits since %s", describeTag(previous))).
+				WithHint("pass --bump or --version to release anyway")
+		}
+		plan.Version = current.Next(level)
+	}
+	plan.Tag = cfg.TagPrefix + plan.Version.String()
+	plan.Notes = changelogSection(plan.Version, now, plan.Commits)
+
+	for _, path := range cfg.VersionFiles {
+		data, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeFS, "plan", fmt.Sprintf("failed to read version file %s", path))
+		}
+		updated, ok := updateVersion(string(data), plan.Version)
+		if !ok {
+			return nil, errors.ValidationError("plan", fmt.Sprintf("no version found in %s", path)).
+				WithHint("version files need a version assignment, such as \"version\": \"1.2.3\", or only a version")
+		}
+		plan.Files = append(plan.Files, versionFileChange{Path: path, Content: updated})
+	}
+
+	if cfg.Changelog != "" {
+		data, err := os.ReadFile(filepath.Join(root, cfg.Changelog))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrap(err, errors.ErrorTypeFS, "plan", fmt.Sprintf("failed to read %s", cfg.Changelog))
+		}
+		plan.Changelog = &versionFileChange{Path: cfg.Changelog, Content: insertChangelogSection(string(data), plan.Notes)}
+	}
+	return plan, nil
+}
+
+// CreateCobraCommand creates the cobra command for release
+func (c *ReleaseCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "release",
+		Short: "Version, document and tag a release",
+		Long: `Propose the next semantic version from the conventional commits since the
+last release tag and release it: breaking changes bump the major version
+(the minor one before 1.0.0), feat commits the minor version and fix, perf
+and revert commits the patch version.
+
+The version files listed in git.release.version_files are updated, the
+release notes are added to the changelog as a new section, and the changes
+are committed and tagged. The plan is shown first and confirmed before
+anything changes; --dry-run only shows it. With --github, a draft GitHub
+release is created with the gh CLI once the tag exists.`,
+		Example: `  sigil release --dry-run
+  sigil release
+  sigil release --bump major --yes
+  sigil release --version 2.0.0-rc.1 --github`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.Execute(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVar(&c.Bump, "bump", "", "Bump this part of the version instead of the proposed one (major, minor, patch)")
+	cmd.Flags().StringVar(&c.Version, "version", "", "Release this version instead of the proposed one")
+	cmd.Flags().BoolVar(&c.DryRun, "dry-run", false, "Show the release plan without changing anything")
+	cmd.Flags().BoolVarP(&c.Yes, "yes", "y", false, "Release without asking for confirmation")
+	cmd.Flags().BoolVar(&c.GitHub, "github", false, "Draft a GitHub release with the gh CLI")
+	return cmd
+}
+
+// parseSemver parses a version with an optional v prefix
+func parseSemver(version string) (semver, bool) {
+	match := semverPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if match == nil {
+		return semver{}, false
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	patch, _ := strconv.Atoi(match[3])
+	return semver{Major: major, Minor: minor, Patch: patch, Pre: match[4]}, true
+}
+
+// String formats the version without a prefix
+func (v semver) String() string {
+	version := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		version += "-" + v.Pre
+	}
+	return version
+}
+
+// Less reports whether v precedes other. Pre-releases precede their
+// release and are ordered by their dot-separated identifiers.
+func (v semver) Less(other semver) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	if v.Patch != other.Patch {
+		return v.Patch < other.Patch
+	}
+	switch {
+	case v.Pre == other.Pre:
+		return false
+	case v.Pre == "":
+		return false
+	case other.Pre == "":
+		return true
+	}
+	return comparePre(v.Pre, other.Pre) < 0
+}
+
+// comparePre compares pre-release suffixes identifier by identifier, as
+// SemVer orders them: numeric identifiers numerically and before
+// alphanumeric ones, others as text, and a shorter suffix first when it is
+// a prefix of the other, so rc.9 precedes rc.10
+func comparePre(a, b string) int {
+	left, right := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(left) && i < len(right); i++ {
+		x, xErr := strconv.ParseUint(left[i], 10, 64)
+		y, yErr := strconv.ParseUint(right[i], 10, 64)
+		switch {
+		case xErr == nil && yErr == nil:
+			if x != y {
+				return cmp.Compare(x, y)
+			}
+		case xErr == nil:
+			return -1
+		case yErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(left[i], right[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmp.Compare(len(left), len(right))
+}
+
+// Next returns the version after v for a bump. A pre-release is released
+// as is when the bump does not go past it.
+func (v semver) Next(level bumpLevel) semver {
+	if v.Pre != "" {
+		release := semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+		switch {
+		case level == bumpPatch,
+			level == bumpMinor && v.Patch == 0,
+			level == bumpMajor && v.Minor == 0 && v.Patch == 0:
+			return release
+		}
+	}
+	switch level {
+	case bumpMajor:
+		return semver{Major: v.Major + 1}
+	case bumpMinor:
+		return semver{Major: v.Major, Minor: v.Minor + 1}
+	default:
+		return semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+}
+
+// parseBumpLevel parses a --bump value
+func parseBumpLevel(bump string) (bumpLevel, bool) {
+	switch strings.ToLower(bump) {
+	case "major":
+		return bumpMajor, true
+	case "minor":
+		return bumpMinor, true
+	case "patch":
+		return bumpPatch, true
+	}
+	return bumpNone, false
+}
+
+// parseReleaseCommit reads the type, scope and description of a commit and
+// whether it breaks compatibility
+func parseReleaseCommit(commit git.Commit) releaseCommit {
+	parsed := releaseCommit{Hash: commit.Hash, Description: commit.Subject}
+	if match := conventionalHeader.FindStringSubmatch(commit.Subject); match != nil {
+		parsed.Type = match[1]
+		parsed.Scope = match[2]
+		parsed.Breaking = match[3] == "!"
+		parsed.Description = match[4]
+	}
+	for _, line := range strings.Split(commit.Body, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			parsed.Breaking = true
+		}
+	}
+	return parsed
+}
+
+// releaseBump returns the bump the commits call for
+func releaseBump(commits []releaseCommit) bumpLevel {
+	level := bumpNone
+	for _, commit := range commits {
+		commitLevel := bumpNone
+		switch {
+		case commit.Breaking:
+			commitLevel = bumpMajor
+		case commit.Type == "feat":
+			commitLevel = bumpMinor
+		case commit.Type == "fix", commit.Type == "perf", commit.Type == "revert":
+			commitLevel = bumpPatch
+		}
+		if commitLevel > level {
+			level = commitLevel
+		}
+	}
+	return level
+}
+
+// changelogHeadings are the changelog headings, in order
+var changelogHeadings = []string{"Added", "Changed", "Fixed"}
+
+// changelogHeading returns the heading a commit is listed under, or ""
+// for commits left out of the changelog
+func changelogHeading(commit releaseCommit) string {
+	switch commit.Type {
+	case "feat":
+		return "Added"
+	case "fix":
+		return "Fixed"
+	case "perf", "refactor", "revert":
+		return "Changed"
+	}
+	if commit.Breaking {
+		return "Changed"
+	}
+	return ""
+}
+
+// changelogSection writes the Keep a Changelog section of a release, with
+// breaking changes first under each heading
+func changelogSection(version semver, date time.Time, commits []releaseCommit) string {
+	var section strings.Builder
+	section.WriteString(fmt.Sprintf("## [%s] - %s\n", version, date.Format("2006-01-02")))
+
+	for _, heading := range changelogHeadings {
+		var breaking, entries []string
+		for _, commit := range commits {
+			if changelogHeading(commit) != heading {
+				continue
+			}
+			if commit.Breaking {
+				breaking = append(breaking, changelogEntry(commit))
+			} else {
+				entries = append(entries, changelogEntry(commit))
+			}
+		}
+		if len(breaking)+len(entries) == 0 {
+			continue
+		}
+		section.WriteString(fmt.Sprintf("\n### %s\n", heading))
+		for _, entry := range append(breaking, entries...) {
+			section.WriteString(entry + "\n")
+		}
+	}
+	return section.String()
+}
+
+// changelogEntry formats one commit as a changelog line
+func changelogEntry(commit releaseCommit) string {
+	var entry strings.Builder
+	entry.WriteString("- ")
+	if commit.Breaking {
+		entry.WriteString("**Breaking:** ")
+	}
+	if commit.Scope != "" {
+		entry.WriteString(fmt.Sprintf("**%s:** ", commit.Scope))
+	}
+	entry.WriteString(commit.Description)
+	if len(commit.Hash) >= 7 {
+		entry.WriteString(fmt.Sprintf(" (%s)", commit.Hash[:7]))
+	}
+	return entry.String()
+}
+
+// insertChangelogSection adds a release section to a changelog, after the
+// Unreleased section and before earlier releases
+func insertChangelogSection(changelog, section string) string {
+	if strings.TrimSpace(changelog) == "" {
+		return "# Changelog\n\n" + section
+	}
+	lines := strings.SplitAfter(changelog, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "## ") && !strings.Contains(strings.ToLower(line), "unreleased") {
+			return strings.Join(lines[:i], "") + section + "\n" + strings.Join(lines[i:], "")
+		}
+	}
+	if !strings.HasSuffix(changelog, "\n") {
+		changelog += "\n"
+	}
+	return changelog + "\n" + section
+}
+
+// updateVersion replaces the version in a version file: the first version
+// assignment, or the whole content when it is only a version
+func updateVersion(content string, version semver) (string, bool) {
+	if trimmed := strings.TrimSpace(content); semverPattern.MatchString(trimmed) {
+		prefix := ""
+		if strings.HasPrefix(trimmed, "v") {
+			prefix = "v"
+		}
+		return strings.Replace(content, trimmed, prefix+version.String(), 1), true
+	}
+
+	location := versionAssignment.FindStringSubmatchIndex(content)
+	if location == nil {
+		return content, false
+	}
+	replacement := content[location[2]:location[3]] + content[location[4]:location[5]] + version.String()
+	return content[:location[0]] + replacement + content[location[1]:], true
+}
+
+// describeTag names a release tag in messages
+func describeTag(tag string) string {
+	if tag == "" {
+		return "the first commit"
+	}
+	return tag
+}
+
+// formatReleasePlan describes a release before it is made
+func formatReleasePlan(plan *releasePlan) string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Release %s (%d commits since %s)\n\n", plan.Tag, len(plan.Commits), describeTag(plan.Previous)))
+	for _, file := range plan.Files {
+		output.WriteString(fmt.Sprintf("Update version in %s\n", file.Path))
+	}
+	if plan.Changelog != nil {
+		output.WriteString(fmt.Sprintf("Add release notes to %s\n", plan.Changelog.Path))
+	}
+	output.WriteString(fmt.Sprintf("Create tag %s\n\n", plan.Tag))
+	output.WriteString(plan.Notes)
+	output.WriteString("\n")
+	return output.String()
+}
+
+// confirmRelease asks on the terminal whether to make the release. Runs
+// without a terminal must pass --yes.
+func confirmRelease(in *os.File, plan *releasePlan) (bool, error) {
+	if !isTerminal(in) {
+		return false, errors.ValidationError("confirmRelease", "releasing needs confirmation").
+			WithHint("pass --yes to release without a terminal, or --dry-run to preview")
+	}
+	fmt.Fprintf(os.Stderr, "Release %s? [y/N]: ", plan.Tag)
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, errors.Wrap(err, errors.ErrorTypeInput, "confirmRelease", "failed to read confirmation")
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
+
+// applyRelease writes the version files and changelog, commits them and
+// creates the tag
+func applyRelease(repo *git.Repository, plan *releasePlan) error {
+	changes := append([]versionFileChange(nil), plan.Files...)
+	if plan.Changelog != nil {
+		changes = append(changes, *plan.Changelog)
+	}
+
+	// Paths are relative to the root, wherever sigil runs
+	root := &git.Repository{Path: repo.Root}
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		if err := os.WriteFile(filepath.Join(repo.Root, change.Path), []byte(change.Content), 0644); err != nil {
+			return errors.Wrap(err, errors.ErrorTypeFS, "applyRelease", fmt.Sprintf("failed to write %s", change.Path))
+		}
+		paths = append(paths, change.Path)
+	}
+	if len(paths) > 0 {
+		if err := root.Add(paths...); err != nil {
+			return errors.Wrap(err, errors.ErrorTypeGit, "applyRelease", "failed to stage release files")
+		}
+		if err := root.Commit(fmt.Sprintf("chore(release): %s", plan.Tag)); err != nil {
+			return errors.Wrap(err, errors.ErrorTypeGit, "applyRelease", "failed to commit release files")
+		}
+	}
+
+	if err := repo.CreateTag(plan.Tag, plan.Notes); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "applyRelease", "failed to create release tag")
+	}
+	logger.Info("created release", "tag", plan.Tag, "files", len(paths))
+	return nil
+}
+
+// draftGitHubRelease creates a draft GitHub release of the tag with the gh
+// CLI. Drafts do not publish the tag, so the release commit must have
+// been pushed.
+func draftGitHubRelease(ctx context.Context, repo *git.Repository, plan *releasePlan) error {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return errors.New(errors.ErrorTypeInput, "draftGitHubRelease", "the gh CLI is not installed").
+			WithHint("install it from https://cli.github.com, or draft the release on GitHub")
+	}
+	head, err := repo.GetHeadCommit()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "draftGitHubRelease", "failed to resolve the release commit")
+	}
+
+	cmd := exec.CommandContext(ctx, "gh", "release", "create", plan.Tag, "--draft",
+		"--title", plan.Tag, "--notes", plan.Notes, "--target", head)
+	cmd.Dir = repo.Root
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrap(fmt.Errorf("%s: %w", strings.TrimSpace(string(output)), err), errors.ErrorTypeGit,
+			"draftGitHubRelease", "failed to draft the GitHub release").
+			WithHint("push the release first with git push --follow-tags, then run gh release create")
+	}
+	fmt.Printf("Drafted GitHub release: %s\n", strings.TrimSpace(string(output)))
+	return nil
+}
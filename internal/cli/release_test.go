@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/git"
+)
+
+func TestSemver(t *testing.T) {
+	v, ok := parseSemver("v1.2.3")
+	require.True(t, ok)
+	assert.Equal(t, semver{Major: 1, Minor: 2, Patch: 3}, v)
+	_, ok = parseSemver("1.2")
+	assert.False(t, ok)
+
+	assert.Equal(t, "2.0.0", v.Next(bumpMajor).String())
+	assert.Equal(t, "1.3.0", v.Next(bumpMinor).String())
+	assert.Equal(t, "1.2.4", v.Next(bumpPatch).String())
+	assert.Equal(t, "1.0.0", semver{Minor: 2, Patch: 1}.Next(bumpMajor).String())
+
+	rc, _ := parseSemver("2.0.0-rc.1")
+	assert.Equal(t, "2.0.0", rc.Next(bumpMinor).String(), "a pre-release is released")
+	assert.True(t, rc.Less(semver{Major: 2}))
+	assert.True(t, v.Less(rc))
+	assert.False(t, v.Less(v))
+
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0-rc.9", "1.0.0-rc.10", "1.0.0"}
+	for i := 1; i < len(ordered); i++ {
+		before, _ := parseSemver(ordered[i-1])
+		after, _ := parseSemver(ordered[i])
+		assert.True(t, before.Less(after), "%s precedes %s", before, after)
+		assert.False(t, after.Less(before), "%s does not precede %s", after, before)
+	}
+}
+
+func TestReleaseBump(t *testing.T) {
+	commits := []releaseCommit{
+		parseReleaseCommit(git.Commit{Subject: "docs: update readme"}),
+		parseReleaseCommit(git.Commit{Subject: "fix(cli): handle empty input"}),
+	}
+	assert.Equal(t, "cli", commits[1].Scope)
+	assert.Equal(t, bumpPatch, releaseBump(commits))
+
+	commits = append(commits, parseReleaseCommit(git.Commit{Subject: "feat: add release"}))
+	assert.Equal(t, bumpMinor, releaseBump(commits))
+
+	assert.True(t, parseReleaseCommit(git.Commit{Subject: "refactor!: drop v1 API"}).Breaking)
+	assert.True(t, parseReleaseCommit(git.Commit{Subject: "chore: move config", Body: "BREAKING CHANGE: config moved"}).Breaking)
+	assert.Equal(t, bumpNone, releaseBump([]releaseCommit{parseReleaseCommit(git.Commit{Subject: "Merge branch 'main'"})}))
+}
+
+func TestChangelogSection(t *testing.T) {
+	commits := []releaseCommit{
+		{Hash: "aaaaaaa111", Type: "feat", Scope: "cli", Description: "add release command"},
+		{Hash: "bbbbbbb222", Type: "fix", Description: "handle missing tags"},
+		{Hash: "ccccccc333", Type: "chore", Description: "move config", Breaking: true},
+		{Hash: "ddddddd444", Type: "docs", Description: "update readme"},
+		{Hash: "eeeeeee555", Type: "feat", Description: "replace the plan format", Breaking: true},
+	}
+	section := changelogSection(semver{Major: 2}, time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), commits)
+	assert.Equal(t, `## [2.0.0] - 2026-03-04
+
+### Added
+- **Breaking:** replace the plan format (eeeeeee)
+- **cli:** add release command (aaaaaaa)
+
+### Changed
+- **Breaking:** move config (ccccccc)
+
+### Fixed
+- handle missing tags (bbbbbbb)
+`, section)
+}
+
+func TestInsertChangelogSection(t *testing.T) {
+	section := "## [1.1.0] - 2026-03-04\n\n### Fixed\n- a fix\n"
+	changelog := "# Changelog\n\n## [Unreleased]\n\n- pending\n\n## [1.0.0] - 2026-01-01\n"
+	assert.Equal(t, "# Changelog\n\n## [Unreleased]\n\n- pending\n\n"+section+"\n## [1.0.0] - 2026-01-01\n",
+		insertChangelogSection(changelog, section))
+	assert.Equal(t, "# Changelog\n\n"+section, insertChangelogSection("", section))
+	assert.Equal(t, "# Changelog\n\n"+section, insertChangelogSection("# Changelog", section))
+}
+
+func TestUpdateVersion(t *testing.T) {
+	next := semver{Major: 1, Minor: 3}
+	for _, tc := range []struct{ content, want string }{
+		{"{\n  \"name\": \"app\",\n  \"version\": \"1.2.3\"\n}\n", "{\n  \"name\": \"app\",\n  \"version\": \"1.3.0\"\n}\n"},
+		{"[project]\nversion = \"1.2.3\"\n", "[project]\nversion = \"1.3.0\"\n"},
+		{"package main\n\nconst Version = \"v1.2.3\"\n", "package main\n\nconst Version = \"v1.3.0\"\n"},
+		{"1.2.3\n", "1.3.0\n"},
+	} {
+		updated, ok := updateVersion(tc.content, next)
+		assert.True(t, ok, tc.content)
+		assert.Equal(t, tc.want, updated)
+	}
+	_, ok := updateVersion("no version here\n", next)
+	assert.False(t, ok)
+}
+
+func TestReleaseCommand_Plan(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "VERSION"), []byte("1.2.3\n"), 0600))
+	cfg := config.ReleaseConfig{TagPrefix: "v", VersionFiles: []string{"VERSION"}, Changelog: "CHANGELOG.md"}
+	commits := []git.Commit{{Hash: "aaaaaaa111", Subject: "feat: add release"}}
+	now := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	c := NewReleaseCommand()
+	plan, err := c.plan(root, cfg, "v1.2.3", commits, now)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3.0", plan.Tag)
+	assert.Equal(t, []versionFileChange{{Path: "VERSION", Content: "1.3.0\n"}}, plan.Files)
+	require.NotNil(t, plan.Changelog)
+	assert.Contains(t, plan.Changelog.Content, "## [1.3.0] - 2026-03-04")
+
+	c.Bump = "major"
+	plan, err = c.plan(root, cfg, "v1.2.3", commits, now)
+	require.NoError(t, err)
+	assert.Equal(t, "v2.0.0", plan.Tag)
+
+	breaking := []git.Commit{{Hash: "bbbbbbb222", Subject: "feat!: drop the old flags"}}
+	c.Bump = ""
+	plan, err = c.plan(root, cfg, "v0.3.0", breaking, now)
+	require.NoError(t, err)
+	assert.Equal(t, "v0.4.0", plan.Tag, "breaking changes before 1.0.0 bump the minor version")
+
+	c.Bump = "major"
+	plan, err = c.plan(root, cfg, "v0.3.0", breaking, now)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0", plan.Tag, "an explicit major bump reaches 1.0.0")
+
+	c.Bump, c.Version = "", "1.0.0"
+	_, err = c.plan(root, cfg, "v1.2.3", commits, now)
+	assert.ErrorContains(t, err, "is not after")
+
+	c.Version = ""
+	_, err = c.plan(root, cfg, "v1.2.3", []git.Commit{{Subject: "docs: readme"}}, now)
+	assert.ErrorContains(t, err, "no feat, fix or breaking commits since v1.2.3")
+}
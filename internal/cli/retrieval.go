@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/index"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// defaultRetrievalResults is the number of indexed chunks given to the model
+const defaultRetrievalResults = 8
+
+// Source is a file range an answer was grounded in
+type Source struct {
+	ID        int     `json:"id"`
+	Path      string  `json:"path"`
+	StartLine int     `json:"start_line"`
+	EndLine   int     `json:"end_line"`
+	Score     float64 `json:"score"`
+}
+
+// String formats the source as a citation
+func (s Source) String() string {
+	return fmt.Sprintf("[%d] %s:%d-%d", s.ID, s.Path, s.StartLine, s.EndLine)
+}
+
+// retrieveContext finds the repository chunks most relevant to question,
+// updating the repository's embeddings index first. The model's own
+// embeddings are used when it offers them, local hash embeddings otherwise.
+// patterns limit retrieval to matching files.
+func retrieveContext(ctx context.Context, mdl model.Model, question string, patterns []string, top int) (*CommandContext, []Source, error) {
+	repo, err := git.NewRepository("")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errors.ErrorTypeGit, "retrieveContext", "repository questions need a git repository").
+			WithHint("run inside a git repository, or pass --file, --dir or --stdin")
+	}
+	root, err := repo.GetRoot()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errors.ErrorTypeGit, "retrieveContext", "failed to resolve repository root")
+	}
+	files, err := (&git.Repository{Path: root}).ListFiles()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errors.ErrorTypeGit, "retrieveContext", "failed to list repository files")
+	}
+
+	scope, err := fileScope(root, patterns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	embedder := indexEmbedder(mdl)
+	ix, err := openIndex(ctx, root, embedder)
+	if err != nil {
+		return nil, nil, err
+	}
+	stats, err := ix.Update(ctx, root, files, embedder)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errors.ErrorTypeModel, "retrieveContext", "failed to update the embeddings index")
+	}
+	if stats.Indexed > 0 || stats.Removed > 0 {
+		if err := ix.Save(); err != nil {
+			logger.Warn("failed to save embeddings index", "error", err)
+		}
+	}
+
+	hits, err := ix.Search(ctx, embedder, question, top, scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(hits) == 0 {
+		return nil, nil, errors.New(errors.ErrorTypeInput, "retrieveContext", "no indexed files match the question's scope").
+			WithHint("check the --files patterns")
+	}
+
+	inputCtx := &CommandContext{InputType: InputTypeRepository}
+	sources := make([]Source, len(hits))
+	var combined strings.Builder
+	for i, hit := range hits {
+		sources[i] = Source{ID: i + 1, Path: hit.Path, StartLine: hit.StartLine, EndLine: hit.EndLine, Score: hit.Score}
+		// Each excerpt is fenced under its own path, so consent scoped to
+		// paths covers what is sent
+		combined.WriteString(fmt.Sprintf("%s\n%s\n", sources[i], model.FenceUntrusted(filepath.Join(root, hit.Path), hit.Content)))
+		inputCtx.Files = append(inputCtx.Files, FileInput{Path: hit.Path, Content: hit.Content})
+	}
+	inputCtx.Input = combined.String()
+
+	logger.Debug("retrieved repository context", "chunks", len(hits), "indexed", stats.Indexed, "unchanged", stats.Unchanged)
+	return inputCtx, sources, nil
+}
+
+// indexEmbedder returns the embedder the index is built with: the model's
+// own embeddings, through the configured cache, when it offers them, and
+// local hash embeddings otherwise
+func indexEmbedder(mdl model.Model) model.Embedder {
+	if embedder, ok := model.EmbedderOf(mdl); ok {
+		return withEmbeddingCache(embedder)
+	}
+	return index.NewHashEmbedder()
+}
+
+// openIndex opens the embeddings index of the repository at root in the
+// configured store
+func openIndex(ctx context.Context, root string, embedder model.Embedder) (*index.Index, error) {
+	store, err := index.NewStore(getConfig().Index, root)
+	if err != nil {
+		return nil, err
+	}
+	return index.Open(ctx, store, embedder.EmbeddingModel(), getConfig().Chunking.Rules())
+}
+
+// fileScope returns a filter accepting repository-relative paths that match
+// one of patterns: a file, a directory containing it, or a glob. Patterns
+// are relative to the working directory. No patterns accept every file.
+func fileScope(root string, patterns []string) (func(path string) bool, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "fileScope", "failed to resolve working directory")
+	}
+
+	relative := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(cwd, pattern)
+		}
+		rel, err := filepath.Rel(root, pattern)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			return nil, errors.ValidationError("fileScope", fmt.Sprintf("%s is outside the repository", pattern))
+		}
+		relative = append(relative, filepath.ToSlash(rel))
+	}
+
+	return func(path string) bool {
+		for _, pattern := range relative {
+			if pattern == "." || path == pattern || strings.HasPrefix(path, pattern+"/") {
+				return true
+			}
+			if matched, _ := filepath.Match(pattern, path); matched {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/sigil/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileScope(t *testing.T) {
+	root, err := os.Getwd()
+	require.NoError(t, err)
+
+	scope, err := fileScope(root, nil)
+	require.NoError(t, err)
+	assert.Nil(t, scope, "no patterns accept every file")
+
+	scope, err = fileScope(root, []string{"internal/cache", "*.md", filepath.Join(root, "main.go")})
+	require.NoError(t, err)
+	assert.True(t, scope("internal/cache/local.go"))
+	assert.True(t, scope("README.md"))
+	assert.True(t, scope("main.go"))
+	assert.False(t, scope("internal/cachex/local.go"))
+	assert.False(t, scope("docs/guide.md"))
+
+	_, err = fileScope(root, []string{"../elsewhere"})
+	assert.Error(t, err)
+}
+
+func TestOutputHandler_writeTextSources(t *testing.T) {
+	output := &CommandOutput{
+		Content: "Tokens are checked in ValidateToken [1].",
+		Sources: []Source{{ID: 1, Path: "auth/token.go", StartLine: 1, EndLine: 40}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, NewOutputHandler(CommonFlags{}).writeText(&buf, output))
+	assert.Equal(t, "Tokens are checked in ValidateToken [1].\n\nSources:\n[1] auth/token.go:1-40\n", buf.String())
+}
+
+func TestAskCommand_buildPromptRepository(t *testing.T) {
+	cmd := NewAskCommand()
+	cmd.Question = "Where are tokens validated?"
+
+	input := &CommandContext{
+		InputType: InputTypeRepository,
+		Input:     "[1] auth/token.go:1-40\n```\nfunc ValidateToken() {}\n```\n",
+		Files:     []FileInput{{Path: "auth/token.go", Content: "func ValidateToken() {}"}},
+	}
+	prompt := cmd.buildPrompt(input, nil)
+
+	assert.Contains(t, prompt.SystemPrompt, "Cite the excerpts")
+	assert.Contains(t, prompt.UserPrompt, "[1] auth/token.go:1-40")
+
+	output := CreateOutput("ask", &CommandContext{
+		InputType: InputTypeRepository,
+		Files:     []FileInput{{Path: "a.go"}, {Path: "a.go"}, {Path: "b.go"}},
+	}, model.PromptOutput{Response: "answer"}, 0)
+	assert.Equal(t, "repository", output.InputType)
+	assert.Equal(t, []string{"a.go", "b.go"}, output.InputFiles)
+}
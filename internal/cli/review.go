@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"os"
 	"strings"
 	"time"
@@ -12,11 +13,21 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/analyzer"
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/findings"
 	"github.com/dshills/sigil/internal/git"
 	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/owners"
+	"github.com/dshills/sigil/internal/sandbox"
+	"github.com/dshills/sigil/internal/trust"
+	"github.com/dshills/sigil/internal/version"
 )
 
+// reviewFormats are the formats a review can be written in
+var reviewFormats = []string{"markdown", "text", "json", "xml", "sarif", FormatRDJSON}
+
 // ReviewCommand handles code review operations
 type ReviewCommand struct {
 	*BaseCommand
@@ -24,12 +35,45 @@ type ReviewCommand struct {
 	Focus            []string
 	Severity         string
 	Format           string
-	OutputFile       string
+	Outputs          []string
 	IncludeTests     bool
 	CheckSecurity    bool
 	CheckPerformance bool
 	CheckStyle       bool
 	AutoFix          bool
+	Concurrency      bool
+	RequireReasons   bool
+	NoAnalyzers      bool
+	Submodules       bool
+	NoOwners         bool
+	CrossCheck       bool
+	CrossCheckModels []string
+	VerifyRefs       bool
+	EvidencePolicy   string
+	BatchSize        int
+	BatchJobs        int
+	FailFast         bool
+	failures         fileFailures
+	refs             *referenceGuard
+	Preset           promptPresetFlags
+	presetText       string
+	toolResults      []analyzer.Result
+	Baseline         string
+	UpdateBaseline   bool
+	HistoryPath      string
+	TranscriptFile   string
+	Patch            string
+	Base             string
+	patch            map[string]patchFile
+	sources          map[string]string
+	baseline         *findings.Baseline
+	statuses         map[string]string
+	lifecycle        *findings.Lifecycle
+	owners           *owners.Resolver
+	root             string
+	areas            []reviewArea
+	crossChecked     []string
+	jsonVersion      int
 	startTime        time.Time
 }
 
@@ -38,15 +82,21 @@ func NewReviewCommand() *ReviewCommand {
 	return &ReviewCommand{
 		BaseCommand: NewBaseCommand("review", "Review code with AI-powered analysis",
 			"Perform comprehensive code review using AI-powered analysis and best practices."),
-		Severity:  "warning",
-		Format:    "markdown",
-		startTime: time.Now(),
+		Severity:       "warning",
+		Format:         "markdown",
+		EvidencePolicy: EvidenceDowngrade,
+		BatchSize:      DefaultReviewBatchSize,
+		BatchJobs:      DefaultReviewBatchJobs,
+		startTime:      time.Now(),
 	}
 }
 
 // Execute runs the review command
 func (c *ReviewCommand) Execute(ctx context.Context) error {
 	logger.Info("starting code review", "files", c.Files, "focus", c.Focus, "severity", c.Severity)
+	c.startTime = deterministic.Time(c.startTime)
+	c.Files = deterministic.Sorted(c.Files)
+	c.Focus = deterministic.Sorted(c.Focus)
 
 	// Validate Git repository
 	gitRepo, err := git.NewRepository(".")
@@ -54,36 +104,125 @@ func (c *ReviewCommand) Execute(ctx context.Context) error {
 		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to open git repository")
 	}
 
+	// Review the files a patch changes, or the files of submodules named
+	// on the command line
+	if c.Patch != "" {
+		c.root = gitRepo.Root
+		if err := c.loadPatch(os.Stdin); err != nil {
+			return err
+		}
+	} else if c.Files, err = expandSubmodules(c.Files, c.Submodules); err != nil {
+		return err
+	}
+
 	// Validate inputs
 	if err := c.validateInputs(); err != nil {
 		return err
 	}
 
-	// Create task for agent processing
+	// Apply the requirements of the review areas the files are in
+	c.root = gitRepo.Root
+	c.areas, err = matchReviewAreas(getConfig().ReviewAreas, c.root, c.Files)
+	if err != nil {
+		return err
+	}
+
+	// Index the repository to check what the review refers to
+	if c.refs, err = newReferenceGuard(c.VerifyRefs); err != nil {
+		return err
+	}
+
+	// Keep a transcript of what analyzers and fix validation run
+	transcript := &sandbox.Transcript{}
+	ctx = sandbox.WithTranscript(ctx, transcript)
+	defer c.reportTranscript(transcript)
+
+	if c.Baseline != "" && !c.UpdateBaseline {
+		c.baseline, err = findings.LoadBaseline(c.Baseline)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Every accepted risk must say why before any analysis runs
+	if c.RequireReasons {
+		if err := c.checkSuppressionReasons(); err != nil {
+			return err
+		}
+	}
+
+	// Render the prompt preset, if any, into review instructions
+	c.presetText, err = c.Preset.resolve(ctx, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	// Create task for agent processing, setting aside files that fail
+	total := len(c.Files)
+	c.failures = fileFailures{failFast: c.FailFast}
 	task, err := c.createReviewTask()
 	if err != nil {
 		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to create review task")
 	}
 
-	// Execute review
-	result, err := c.executeReview(ctx, task)
+	// Run the external analyzers and let the agents weigh their output
+	analyzers, err := c.reviewAnalyzers()
+	if err != nil {
+		return err
+	}
+	if len(analyzers) > 0 {
+		c.toolResults, err = c.runAnalyzers(ctx, analyzers)
+		if err != nil {
+			return err
+		}
+		task.Context.Requirements = append(task.Context.Requirements, analyzerRequirement(c.toolResults))
+	}
+	if c.Concurrency {
+		task.Context.Requirements = append(task.Context.Requirements, concurrencyRequirement)
+	}
+
+	if c.CrossCheck {
+		if c.crossChecked, err = c.crossCheckModels(); err != nil {
+			return err
+		}
+	}
+
+	// Execute review, in batches when there are too many files for one task
+	var result *agent.OrchestrationResult
+	if c.BatchSize > 0 && len(c.Files) > c.BatchSize {
+		result, err = c.executeBatches(ctx, task, os.Stderr)
+	} else {
+		result, err = c.reviewTask(ctx, task)
+	}
 	if err != nil {
 		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to execute review")
 	}
 
+	// Accept the current findings, or compare them with earlier runs
+	if c.UpdateBaseline {
+		if err := c.writeBaseline(result); err != nil {
+			return err
+		}
+	}
+	if c.HistoryPath != "" {
+		if err := c.recordHistory(task.ID, result); err != nil {
+			logger.Warn("failed to record findings history", "error", err)
+		}
+	}
+
 	// Process and output result
-	if err := c.outputResult(result); err != nil {
+	if err := c.outputResult(ctx, result); err != nil {
 		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to output result")
 	}
 
 	// Auto-fix if requested
 	if c.AutoFix && result.Status == agent.StatusSuccess {
-		if err := c.applyAutoFixes(result, gitRepo); err != nil {
+		if err := c.applyAutoFixes(ctx, result, gitRepo); err != nil {
 			logger.Warn("failed to apply auto-fixes", "error", err)
 		}
 	}
 
-	return nil
+	return c.failures.err("Execute", "review", total)
 }
 
 // validateInputs validates the command inputs
@@ -93,12 +232,21 @@ func (c *ReviewCommand) validateInputs() error {
 	}
 
 	for _, file := range c.Files {
-		if !c.fileExists(file) {
+		if c.patch == nil && !c.fileExists(file) {
 			return errors.New(errors.ErrorTypeInput, "validateInputs",
 				fmt.Sprintf("file does not exist: %s", file))
 		}
 	}
 
+	if c.patch != nil && (c.AutoFix || c.Concurrency) {
+		return errors.ValidationError("validateInputs", "--auto-fix and --concurrency need the files on disk, not a patch").
+			WithHint("apply the patch and review the files instead")
+	}
+	if c.Base != "" && c.patch == nil {
+		return errors.ValidationError("validateInputs", "--base requires a patch").
+			WithHint("review a patch with --patch <file> or - for stdin")
+	}
+
 	validSeverities := []string{"error", "warning", "info", "all"}
 	severityValid := false
 	for _, severity := range validSeverities {
@@ -112,9 +260,40 @@ func (c *ReviewCommand) validateInputs() error {
 			fmt.Sprintf("invalid severity: %s (valid: %s)", c.Severity, strings.Join(validSeverities, ", ")))
 	}
 
-	validFormats := []string{"markdown", "text", "json", "xml", "sarif"}
+	if _, err := c.focusAreas(); err != nil {
+		return err
+	}
+
+	if c.AutoFix {
+		if err := trust.Check("validateInputs", "auto-fix"); err != nil {
+			return err
+		}
+	}
+
+	if c.UpdateBaseline && c.Baseline == "" {
+		return errors.ValidationError("validateInputs", "--update-baseline requires --baseline").
+			WithHint("name the file to write, e.g. --baseline .sigil/baseline.json")
+	}
+
+	if c.Concurrency && c.detectProjectLanguage() != "go" {
+		return errors.ValidationError("validateInputs", "--concurrency requires a Go project").
+			WithHint("run the review from the directory containing go.mod")
+	}
+
+	switch c.EvidencePolicy {
+	case EvidenceDowngrade, EvidenceDrop, EvidenceOff:
+	default:
+		return errors.ValidationError("validateInputs", fmt.Sprintf("invalid evidence policy: %s (valid: %s, %s, %s)",
+			c.EvidencePolicy, EvidenceDowngrade, EvidenceDrop, EvidenceOff))
+	}
+
+	format, version, err := parseFormat(c.Format)
+	if err != nil {
+		return err
+	}
+	c.Format, c.jsonVersion = format, version
 	formatValid := false
-	for _, format := range validFormats {
+	for _, format := range reviewFormats {
 		if c.Format == format {
 			formatValid = true
 			break
@@ -122,32 +301,35 @@ func (c *ReviewCommand) validateInputs() error {
 	}
 	if !formatValid {
 		return errors.New(errors.ErrorTypeInput, "validateInputs",
-			fmt.Sprintf("invalid format: %s (valid: %s)", c.Format, strings.Join(validFormats, ", ")))
+			fmt.Sprintf("invalid format: %s (valid: %s)", c.Format, strings.Join(reviewFormats, ", ")))
+	}
+	if _, err := parseOutputs(c.Outputs, c.Format, c.jsonVersion, reviewFormats); err != nil {
+		return err
+	}
+
+	if c.BatchSize < 0 {
+		return errors.ValidationError("validateInputs", fmt.Sprintf("invalid batch size: %d (must be 0 or more)", c.BatchSize))
+	}
+	if c.BatchJobs < 1 {
+		return errors.ValidationError("validateInputs", fmt.Sprintf("invalid batch jobs: %d (must be 1 or more)", c.BatchJobs))
 	}
 
 	return nil
 }
 
-// createReviewTask creates a task for code review
+// createReviewTask creates a task for code review. Files that cannot be
+// read are recorded as failures and left out of the review, unless
+// --fail-fast is set.
 func (c *ReviewCommand) createReviewTask() (*agent.Task, error) {
-	// Read file contents
-	fileContexts := make([]agent.FileContext, 0, len(c.Files))
-	for _, filePath := range c.Files {
-		content, err := c.readFile(filePath)
-		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrorTypeInput, "createReviewTask",
-				fmt.Sprintf("failed to read file: %s", filePath))
-		}
-
-		fileContext := agent.FileContext{
-			Path:        filePath,
-			Content:     content,
-			Language:    c.detectLanguage(filePath),
-			Purpose:     "Code to review",
-			IsTarget:    true,
-			IsReference: false,
+	// Read file contents, or take them from the patch
+	var fileContexts []agent.FileContext
+	if c.patch != nil {
+		fileContexts = c.patchContexts()
+	} else {
+		var err error
+		if fileContexts, err = c.readTargets(); err != nil {
+			return nil, err
 		}
-		fileContexts = append(fileContexts, fileContext)
 	}
 
 	// Create requirements based on flags
@@ -158,39 +340,50 @@ func (c *ReviewCommand) createReviewTask() (*agent.Task, error) {
 		"Provide constructive feedback and improvement suggestions",
 	}
 
-	if len(c.Focus) > 0 {
-		requirements = append(requirements, fmt.Sprintf("Focus specifically on: %s", strings.Join(c.Focus, ", ")))
+	areas, err := c.focusAreas()
+	if err != nil {
+		return nil, err
+	}
+	if len(areas) > 0 {
+		names := make([]string, len(areas))
+		for i, area := range areas {
+			names[i] = string(area.Area)
+		}
+		requirements = append(requirements, fmt.Sprintf("Focus specifically on: %s", strings.Join(names, ", ")))
+	}
+
+	for _, area := range c.areas {
+		requirements = append(requirements, area.requirements()...)
 	}
 
 	if c.IncludeTests {
 		requirements = append(requirements, "Review test coverage and test quality")
 	}
 
-	requirements = append(requirements, fmt.Sprintf("Report only issues of severity %s and above", c.Severity))
-	requirements = append(requirements, fmt.Sprintf("Format the review as %s", c.Format))
+	if c.presetText != "" {
+		requirements = append(requirements, fmt.Sprintf("Follow these review instructions:\n%s", c.presetText))
+	}
 
-	// Create constraints based on flags
-	var constraints []agent.Constraint
-	if c.CheckSecurity {
-		constraints = append(constraints, agent.Constraint{
-			Type:        agent.ConstraintTypeSecurity,
-			Description: "Identify security vulnerabilities and unsafe practices",
-			Severity:    agent.SeverityError,
-		})
+	requirements = append(requirements, fmt.Sprintf("Report only issues of severity %s and above", c.Severity))
+	if c.Format == FormatRDJSON {
+		// reviewdog needs locations, so findings are the whole output
+		requirements = append(requirements, "Format the review as markdown")
+	} else {
+		requirements = append(requirements, fmt.Sprintf("Format the review as %s", c.Format))
 	}
-	if c.CheckPerformance {
-		constraints = append(constraints, agent.Constraint{
-			Type:        agent.ConstraintTypePerformance,
-			Description: "Identify performance bottlenecks and optimization opportunities",
-			Severity:    agent.SeverityWarning,
-		})
+	// Structured findings let the report be grouped by focus area
+	requirements = append(requirements, findingsRequirement)
+	if c.patch != nil {
+		requirements = append(requirements, patchRequirement)
 	}
-	if c.CheckStyle {
-		constraints = append(constraints, agent.Constraint{
-			Type:        agent.ConstraintTypeStyle,
-			Description: "Check code style and formatting consistency",
-			Severity:    agent.SeverityInfo,
-		})
+
+	// Each focus area constrains the review
+	constraints := make([]agent.Constraint, 0, len(areas))
+	for _, area := range areas {
+		constraints = append(constraints, area.Constraint())
+	}
+	for _, area := range c.areas {
+		constraints = append(constraints, area.constraints()...)
 	}
 
 	// Detect project info
@@ -202,7 +395,7 @@ func (c *ReviewCommand) createReviewTask() (*agent.Task, error) {
 
 	// Create task
 	task := &agent.Task{
-		ID:          fmt.Sprintf("review_%d", c.startTime.Unix()),
+		ID:          fmt.Sprintf("review_%d_%s", c.startTime.Unix(), deterministic.TokenFor("review", 6)),
 		Type:        agent.TaskTypeReview,
 		Description: c.buildDescription(),
 		Context: agent.TaskContext{
@@ -218,6 +411,72 @@ func (c *ReviewCommand) createReviewTask() (*agent.Task, error) {
 	return task, nil
 }
 
+// readTargets reads the files to review. Files that cannot be read are
+// recorded as failures and left out.
+func (c *ReviewCommand) readTargets() ([]agent.FileContext, error) {
+	fileContexts := make([]agent.FileContext, 0, len(c.Files))
+	readable := make([]string, 0, len(c.Files))
+	for _, filePath := range c.Files {
+		content, err := c.readFile(filePath)
+		if err != nil {
+			err = errors.Wrap(err, errors.ErrorTypeInput, "readTargets",
+				fmt.Sprintf("failed to read file: %s", filePath))
+			if err := c.failures.record(filePath, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		readable = append(readable, filePath)
+
+		fileContext := agent.FileContext{
+			Path:        filePath,
+			Content:     content,
+			Language:    c.detectLanguage(filePath),
+			Purpose:     "Code to review",
+			IsTarget:    true,
+			IsReference: false,
+		}
+		fileContexts = append(fileContexts, fileContext)
+	}
+	if len(fileContexts) == 0 && len(c.Files) > 0 {
+		return nil, errors.New(errors.ErrorTypeInput, "readTargets", "none of the files could be read")
+	}
+	c.Files = readable
+	return fileContexts, nil
+}
+
+// focusAreas resolves --focus and the --check-* shorthands against the
+// focus area taxonomy, dropping duplicates
+func (c *ReviewCommand) focusAreas() ([]agent.FocusDefinition, error) {
+	names := append([]string{}, c.Focus...)
+	if c.CheckSecurity {
+		names = append(names, string(agent.FocusSecurity))
+	}
+	if c.CheckPerformance {
+		names = append(names, string(agent.FocusPerformance))
+	}
+	if c.CheckStyle {
+		names = append(names, string(agent.FocusStyle))
+	}
+	if c.Concurrency {
+		names = append(names, string(agent.FocusConcurrency))
+	}
+
+	var areas []agent.FocusDefinition
+	seen := make(map[agent.FocusArea]bool)
+	for _, name := range names {
+		area, err := agent.ParseFocusArea(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[area.Area] {
+			seen[area.Area] = true
+			areas = append(areas, area)
+		}
+	}
+	return areas, nil
+}
+
 // buildDescription builds the task description
 func (c *ReviewCommand) buildDescription() string {
 	description := "Perform comprehensive code review of the provided files"
@@ -233,16 +492,35 @@ func (c *ReviewCommand) buildDescription() string {
 	return description
 }
 
+// reviewTask runs a review task, through two providers when cross-checking
+func (c *ReviewCommand) reviewTask(ctx context.Context, task *agent.Task) (*agent.OrchestrationResult, error) {
+	if c.CrossCheck {
+		return c.executeCrossCheck(ctx, task)
+	}
+	return c.executeReview(ctx, task, "")
+}
+
 // executeReview executes the review using the agent system
-func (c *ReviewCommand) executeReview(ctx context.Context, task *agent.Task) (*agent.OrchestrationResult, error) {
-	logger.Info("executing code review with agent system")
+func (c *ReviewCommand) executeReview(ctx context.Context, task *agent.Task, model string) (*agent.OrchestrationResult, error) {
+	logger.Info("executing code review with agent system", "model", model)
+
+	areas, err := c.focusAreas()
+	if err != nil {
+		return nil, err
+	}
 
-	// Create agent factory and orchestrator
-	factory := agent.NewFactory(nil, agent.DefaultOrchestrationConfig()) // No sandbox needed for review
+	// Create agent factory and orchestrator, bringing in the reviewers the
+	// focus areas are specialized for
+	config := agent.WithFocusReviewers(orchestrationConfig(), areas)
+	if model != "" {
+		config = agent.WithModel(config, model)
+	}
+	factory := agent.NewFactory(nil, config) // No sandbox needed for review
 	orchestrator, err := factory.CreateOrchestrator()
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrorTypeInternal, "executeReview", "failed to create orchestrator")
 	}
+	configureOrchestrator(orchestrator)
 
 	// Execute task
 	result, err := orchestrator.ExecuteTask(ctx, *task)
@@ -250,6 +528,13 @@ func (c *ReviewCommand) executeReview(ctx context.Context, task *agent.Task) (*a
 		return nil, errors.Wrap(err, errors.ErrorTypeInternal, "executeReview", "task execution failed")
 	}
 
+	if len(result.Questions) > 0 {
+		return nil, clarificationError("executeReview", result.Questions)
+	}
+	if result.Abstention != nil {
+		return nil, abstentionError("executeReview", result.Abstention)
+	}
+
 	if result.Status != agent.StatusSuccess {
 		return nil, errors.New(errors.ErrorTypeInternal, "executeReview",
 			fmt.Sprintf("review failed with status: %s", result.Status))
@@ -260,7 +545,7 @@ func (c *ReviewCommand) executeReview(ctx context.Context, task *agent.Task) (*a
 }
 
 // outputResult outputs the review result
-func (c *ReviewCommand) outputResult(result *agent.OrchestrationResult) error {
+func (c *ReviewCommand) outputResult(ctx context.Context, result *agent.OrchestrationResult) error {
 	if result.FinalResult == nil {
 		return errors.New(errors.ErrorTypeInternal, "outputResult", "no final result available")
 	}
@@ -274,25 +559,25 @@ func (c *ReviewCommand) outputResult(result *agent.OrchestrationResult) error {
 	if review == "" {
 		return errors.New(errors.ErrorTypeInternal, "outputResult", "no review content generated")
 	}
+	review = applyGlossary(c.refs.check(review))
 
-	// Format the output
-	formatted, err := c.formatOutput(review, result)
+	// Write to each output, or stdout
+	targets, err := parseOutputs(c.Outputs, c.Format, c.jsonVersion, reviewFormats)
 	if err != nil {
-		return errors.Wrap(err, errors.ErrorTypeInternal, "outputResult", "failed to format output")
-	}
-
-	// Write to file or stdout
-	if c.OutputFile != "" {
-		if err := c.writeFile(c.OutputFile, formatted); err != nil {
-			return errors.Wrap(err, errors.ErrorTypeInternal, "outputResult",
-				fmt.Sprintf("failed to write output file: %s", c.OutputFile))
-		}
-		fmt.Printf("Review written to: %s\n", c.OutputFile)
-	} else {
-		fmt.Print(formatted)
+		return err
 	}
+	return writeOutputs(ctx, os.Stdout, targets, "Review", func(target outputTarget) (string, error) {
+		return c.formatAs(target, review, result)
+	}, c.writeFile)
+}
 
-	return nil
+// formatAs formats the review for an output target, in its format rather
+// than --format
+func (c *ReviewCommand) formatAs(target outputTarget, content string, result *agent.OrchestrationResult) (string, error) {
+	format, version := c.Format, c.jsonVersion
+	defer func() { c.Format, c.jsonVersion = format, version }()
+	c.Format, c.jsonVersion = target.Format, target.Version
+	return c.formatOutput(content, result)
 }
 
 // formatOutput formats the review based on the requested format
@@ -308,6 +593,8 @@ func (c *ReviewCommand) formatOutput(content string, result *agent.Orchestration
 		return c.formatXML(content, result), nil
 	case "sarif":
 		return c.formatSARIF(content, result), nil
+	case FormatRDJSON:
+		return c.formatRDJSON(content, result)
 	default:
 		return content, nil
 	}
@@ -316,6 +603,9 @@ func (c *ReviewCommand) formatOutput(content string, result *agent.Orchestration
 // formatMarkdown formats content as markdown
 func (c *ReviewCommand) formatMarkdown(content string, result *agent.OrchestrationResult) string {
 	var output strings.Builder
+	report := c.reportFindings(content, result)
+	groups := groupFindings(report.Findings)
+	content = stripFindings(content)
 
 	output.WriteString("# Code Review Report\n\n")
 
@@ -333,6 +623,65 @@ func (c *ReviewCommand) formatMarkdown(content string, result *agent.Orchestrati
 	output.WriteString(fmt.Sprintf("**Review Status:** %s\n", result.Status))
 	output.WriteString(fmt.Sprintf("**Total Findings:** %d\n\n", len(result.Results)))
 
+	if len(groups) > 0 {
+		output.WriteString("## Findings by Area\n\n| Area | Findings |\n|------|----------|\n")
+		for _, group := range groups {
+			output.WriteString(fmt.Sprintf("| %s | %d |\n", group.Area, group.Count))
+		}
+		for _, group := range groups {
+			output.WriteString(fmt.Sprintf("\n### %s (%d)\n\n", group.Area, group.Count))
+			for _, finding := range group.Findings {
+				output.WriteString(fmt.Sprintf("- **%s** `%s` %s%s%s%s%s\n", finding.Severity, findingLocation(finding), finding.Message, statusTag(finding), evidenceTag(finding), crossCheckTag(finding), ownersTag(finding)))
+			}
+		}
+		output.WriteString("\n")
+	}
+
+	if cross := report.CrossCheck; cross != nil {
+		output.WriteString("## Cross-Check\n\n")
+		output.WriteString(fmt.Sprintf("**Models:** %s\n", strings.Join(cross.Models, ", ")))
+		output.WriteString(fmt.Sprintf("**Agreed:** %d (high confidence) | **Single model:** %d (needs human attention)\n\n", cross.Agreed, cross.Single))
+	}
+
+	if len(report.Reviewers) > 0 {
+		output.WriteString("## Suggested Reviewers\n\n| Owner | Findings |\n|-------|----------|\n")
+		for _, reviewer := range report.Reviewers {
+			output.WriteString(fmt.Sprintf("| %s | %d |\n", reviewer.Owner, reviewer.Findings))
+		}
+		output.WriteString("\n")
+	}
+
+	if c.lifecycle != nil || report.Baselined > 0 {
+		output.WriteString("## Finding Lifecycle\n\n")
+		if c.lifecycle != nil {
+			output.WriteString(fmt.Sprintf("**New:** %d | **Recurring:** %d | **Fixed:** %d\n",
+				len(c.lifecycle.New), len(c.lifecycle.Recurring), len(c.lifecycle.Fixed)))
+			for _, fixed := range c.lifecycle.Fixed {
+				output.WriteString(fmt.Sprintf("- fixed: `%s:%d` %s\n", fixed.Path, fixed.Line, fixed.Message))
+			}
+		}
+		if report.Baselined > 0 {
+			output.WriteString(fmt.Sprintf("\n%d baseline findings not shown\n", report.Baselined))
+		}
+		output.WriteString("\n")
+	}
+
+	if len(report.Suppressions) > 0 {
+		output.WriteString("## Suppressions\n\n| Location | Rule | Reason | Suppressed |\n|----------|------|--------|------------|\n")
+		for _, s := range report.Suppressions {
+			output.WriteString(fmt.Sprintf("| `%s:%d` | %s | %s | %d |\n", s.Path, s.Line, s.Rule, suppressionReason(s), s.Suppressed))
+		}
+		output.WriteString("\n")
+	}
+
+	if len(c.failures.failures) > 0 {
+		output.WriteString("## Errors\n\n")
+		for _, failure := range c.failures.failures {
+			output.WriteString(fmt.Sprintf("- `%s`: %s\n", failure.Path, failure.Error))
+		}
+		output.WriteString("\n")
+	}
+
 	output.WriteString("## Review Details\n\n")
 	output.WriteString(content)
 	output.WriteString("\n")
@@ -343,6 +692,9 @@ func (c *ReviewCommand) formatMarkdown(content string, result *agent.Orchestrati
 // formatText formats content as plain text
 func (c *ReviewCommand) formatText(content string, result *agent.OrchestrationResult) string {
 	var output strings.Builder
+	report := c.reportFindings(content, result)
+	groups := groupFindings(report.Findings)
+	content = stripFindings(content)
 
 	output.WriteString("CODE REVIEW REPORT\n")
 	output.WriteString("==================\n\n")
@@ -361,6 +713,68 @@ func (c *ReviewCommand) formatText(content string, result *agent.OrchestrationRe
 	output.WriteString(fmt.Sprintf("Review Status: %s\n", result.Status))
 	output.WriteString(fmt.Sprintf("Total Findings: %d\n\n", len(result.Results)))
 
+	if len(groups) > 0 {
+		output.WriteString("Findings by Area:\n")
+		output.WriteString("-----------------\n")
+		for _, group := range groups {
+			output.WriteString(fmt.Sprintf("%s (%d)\n", group.Area, group.Count))
+			for _, finding := range group.Findings {
+				output.WriteString(fmt.Sprintf("  [%s] %s: %s%s%s%s%s\n", finding.Severity, findingLocation(finding), finding.Message, statusTag(finding), evidenceTag(finding), crossCheckTag(finding), ownersTag(finding)))
+			}
+		}
+		output.WriteString("\n")
+	}
+
+	if cross := report.CrossCheck; cross != nil {
+		output.WriteString("Cross-Check:\n")
+		output.WriteString("------------\n")
+		output.WriteString(fmt.Sprintf("Models: %s\n", strings.Join(cross.Models, ", ")))
+		output.WriteString(fmt.Sprintf("Agreed: %d (high confidence), Single model: %d (needs human attention)\n\n", cross.Agreed, cross.Single))
+	}
+
+	if len(report.Reviewers) > 0 {
+		output.WriteString("Suggested Reviewers:\n")
+		output.WriteString("--------------------\n")
+		for _, reviewer := range report.Reviewers {
+			output.WriteString(fmt.Sprintf("  %s (%d findings)\n", reviewer.Owner, reviewer.Findings))
+		}
+		output.WriteString("\n")
+	}
+
+	if c.lifecycle != nil || report.Baselined > 0 {
+		output.WriteString("Finding Lifecycle:\n")
+		output.WriteString("------------------\n")
+		if c.lifecycle != nil {
+			output.WriteString(fmt.Sprintf("New: %d, Recurring: %d, Fixed: %d\n",
+				len(c.lifecycle.New), len(c.lifecycle.Recurring), len(c.lifecycle.Fixed)))
+			for _, fixed := range c.lifecycle.Fixed {
+				output.WriteString(fmt.Sprintf("  fixed %s:%d: %s\n", fixed.Path, fixed.Line, fixed.Message))
+			}
+		}
+		if report.Baselined > 0 {
+			output.WriteString(fmt.Sprintf("%d baseline findings not shown\n", report.Baselined))
+		}
+		output.WriteString("\n")
+	}
+
+	if len(report.Suppressions) > 0 {
+		output.WriteString("Suppressions:\n")
+		output.WriteString("-------------\n")
+		for _, s := range report.Suppressions {
+			output.WriteString(fmt.Sprintf("  %s:%d %s: %s (%d suppressed)\n", s.Path, s.Line, s.Rule, suppressionReason(s), s.Suppressed))
+		}
+		output.WriteString("\n")
+	}
+
+	if len(c.failures.failures) > 0 {
+		output.WriteString("Errors:\n")
+		output.WriteString("-------\n")
+		for _, failure := range c.failures.failures {
+			output.WriteString(fmt.Sprintf("  %s: %s\n", failure.Path, failure.Error))
+		}
+		output.WriteString("\n")
+	}
+
 	output.WriteString("Review Details:\n")
 	output.WriteString("---------------\n")
 	output.WriteString(content)
@@ -371,15 +785,48 @@ func (c *ReviewCommand) formatText(content string, result *agent.OrchestrationRe
 
 // formatJSON formats content as JSON
 func (c *ReviewCommand) formatJSON(content string, result *agent.OrchestrationResult) string {
+	if c.jsonVersion == JSONVersion2 {
+		return c.formatJSONV2(content, result)
+	}
+
+	report := c.reportFindings(content, result)
+	groups := groupFindings(report.Findings)
+	if groups == nil {
+		groups = []findingGroup{}
+	}
+	suppressions := report.Suppressions
+	if suppressions == nil {
+		suppressions = []suppression{}
+	}
+	reviewers := report.Reviewers
+	if reviewers == nil {
+		reviewers = []owners.Reviewer{}
+	}
+	focus, files := c.Focus, c.Files
+	if focus == nil {
+		focus = []string{}
+	}
+	if files == nil {
+		files = []string{}
+	}
+	content = stripFindings(content)
+
 	data := map[string]interface{}{
 		"review": map[string]interface{}{
-			"focus_areas":    c.Focus,
-			"files":          c.Files,
-			"severity":       c.Severity,
-			"status":         string(result.Status),
-			"findings_count": len(result.Results),
-			"timestamp":      c.startTime.Format("2006-01-02T15:04:05Z07:00"),
-			"content":        content,
+			"focus_areas":      focus,
+			"files":            files,
+			"severity":         c.Severity,
+			"status":           string(result.Status),
+			"findings_count":   len(result.Results),
+			"findings_by_area": groups,
+			"suppressions":     suppressions,
+			"reviewers":        reviewers,
+			"cross_check":      report.CrossCheck,
+			"lifecycle":        c.lifecycle,
+			"baselined":        report.Baselined,
+			"errors":           c.failures.list(),
+			"timestamp":        c.startTime.Format("2006-01-02T15:04:05Z07:00"),
+			"content":          content,
 		},
 	}
 
@@ -391,9 +838,74 @@ func (c *ReviewCommand) formatJSON(content string, result *agent.OrchestrationRe
 	return string(jsonBytes)
 }
 
+// reviewFinding is a finding in version 2 review JSON output
+type reviewFinding struct {
+	agent.ReviewComment
+	Area agent.FocusArea `json:"area"`
+}
+
+// reviewCounts counts the findings of a review
+type reviewCounts struct {
+	Total      int            `json:"total"`
+	BySeverity map[string]int `json:"by_severity"`
+	ByArea     map[string]int `json:"by_area"`
+}
+
+// formatJSONV2 formats content as version 2 JSON: the findings as one flat
+// list with their focus area, and the model usage behind the review
+func (c *ReviewCommand) formatJSONV2(content string, result *agent.OrchestrationResult) string {
+	report := c.reportFindings(content, result)
+	reviewFindings := make([]reviewFinding, 0, len(report.Findings))
+	counts := reviewCounts{Total: len(report.Findings), BySeverity: map[string]int{}, ByArea: map[string]int{}}
+	for _, finding := range report.Findings {
+		area := agent.FocusForComment(finding.Type)
+		reviewFindings = append(reviewFindings, reviewFinding{ReviewComment: finding, Area: area})
+		counts.BySeverity[string(finding.Severity)]++
+		counts.ByArea[string(area)]++
+	}
+	suppressions := report.Suppressions
+	if suppressions == nil {
+		suppressions = []suppression{}
+	}
+	reviewers := report.Reviewers
+	if reviewers == nil {
+		reviewers = []owners.Reviewer{}
+	}
+	focus, files := c.Focus, c.Files
+	if focus == nil {
+		focus = []string{}
+	}
+	if files == nil {
+		files = []string{}
+	}
+
+	output, err := formatEnvelope("review", c.startTime, map[string]interface{}{
+		"focus_areas":  focus,
+		"files":        files,
+		"severity":     c.Severity,
+		"status":       string(result.Status),
+		"findings":     reviewFindings,
+		"counts":       counts,
+		"suppressions": suppressions,
+		"reviewers":    reviewers,
+		"cross_check":  report.CrossCheck,
+		"lifecycle":    c.lifecycle,
+		"baselined":    report.Baselined,
+		"errors":       c.failures.list(),
+		"content":      stripFindings(content),
+	})
+	if err != nil {
+		return fmt.Sprintf(`{"error": "Failed to format JSON: %s"}`, err.Error())
+	}
+	return output
+}
+
 // formatXML formats content as XML
 func (c *ReviewCommand) formatXML(content string, result *agent.OrchestrationResult) string {
 	var output strings.Builder
+	report := c.reportFindings(content, result)
+	groups := groupFindings(report.Findings)
+	content = stripFindings(content)
 
 	output.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
 	output.WriteString("<review>\n")
@@ -414,6 +926,31 @@ func (c *ReviewCommand) formatXML(content string, result *agent.OrchestrationRes
 	}
 	output.WriteString("  </files>\n")
 
+	output.WriteString("  <findings_by_area>\n")
+	for _, group := range groups {
+		output.WriteString(fmt.Sprintf("    <area name=\"%s\" count=\"%d\"/>\n", group.Area, group.Count))
+	}
+	output.WriteString("  </findings_by_area>\n")
+
+	if c.lifecycle != nil {
+		output.WriteString(fmt.Sprintf("  <lifecycle new=\"%d\" recurring=\"%d\" fixed=\"%d\"/>\n",
+			len(c.lifecycle.New), len(c.lifecycle.Recurring), len(c.lifecycle.Fixed)))
+	}
+
+	output.WriteString("  <suppressions>\n")
+	for _, s := range report.Suppressions {
+		output.WriteString(fmt.Sprintf("    <suppression path=\"%s\" line=\"%d\" rule=\"%s\" suppressed=\"%d\">%s</suppression>\n",
+			html.EscapeString(s.Path), s.Line, html.EscapeString(s.Rule), s.Suppressed, html.EscapeString(s.Reason)))
+	}
+	output.WriteString("  </suppressions>\n")
+
+	output.WriteString("  <errors>\n")
+	for _, failure := range c.failures.failures {
+		output.WriteString(fmt.Sprintf("    <error path=\"%s\">%s</error>\n",
+			html.EscapeString(failure.Path), html.EscapeString(failure.Error)))
+	}
+	output.WriteString("  </errors>\n")
+
 	output.WriteString("  <content><![CDATA[\n")
 	output.WriteString(content)
 	output.WriteString("\n  ]]></content>\n")
@@ -432,7 +969,7 @@ func (c *ReviewCommand) formatSARIF(content string, result *agent.OrchestrationR
       "tool": {
         "driver": {
           "name": "Sigil Code Review",
-          "version": "1.0.0",
+          "version": %q,
           "informationUri": "https://github.com/dshills/sigil"
         }
       },
@@ -456,16 +993,21 @@ func (c *ReviewCommand) formatSARIF(content string, result *agent.OrchestrationR
       ]
     }
   ]
-}`, c.Severity, len(result.Results), c.Files[0])
+}`, version.Get().Version, c.Severity, len(result.Results), c.Files[0])
 }
 
-// applyAutoFixes applies automatic fixes from the review result
-func (c *ReviewCommand) applyAutoFixes(result *agent.OrchestrationResult, gitRepo *git.Repository) error {
+// applyAutoFixes applies automatic fixes from the review result once they
+// pass the project's validation steps
+func (c *ReviewCommand) applyAutoFixes(ctx context.Context, result *agent.OrchestrationResult, gitRepo *git.Repository) error {
 	if result.FinalResult == nil || len(result.FinalResult.Proposals) == 0 {
 		logger.Info("no auto-fixes available")
 		return nil
 	}
 
+	if err := c.validateFixes(ctx, result.FinalResult.Proposals, gitRepo); err != nil {
+		return err
+	}
+
 	logger.Info("applying auto-fixes", "proposals", len(result.FinalResult.Proposals))
 
 	for _, proposal := range result.FinalResult.Proposals {
@@ -577,13 +1119,38 @@ The review command analyzes code for bugs, security issues, performance problems
 and adherence to best practices. It can focus on specific areas and output results
 in various formats.
 
+A file that cannot be read, or a batch the model fails on, does not stop the
+review: the rest is reviewed, the files left out are listed in an errors
+section of the report, and the command exits with an error. --fail-fast
+stops at the first failure instead.
+
 Examples:
   sigil review main.go
   sigil review src/ --focus security,performance
   sigil review *.go --severity error --format json --output review.json
-  sigil review project/ --auto-fix --check-security`,
-		Args: cobra.MinimumNArgs(1),
+  sigil review project/ --auto-fix --check-security
+  sigil review main.go --prompt security-review
+  sigil review auth/ --cross-check --cross-check-models anthropic:claude-3-5-sonnet-20241022,openai:gpt-4o
+  sigil review *.go --format rdjson | reviewdog -f=rdjson -reporter=github-pr-review
+  sigil review $(git ls-files '*.go') --batch-size 25 --batch-jobs 4
+  git diff | sigil review - --base HEAD
+  sigil review --patch 0001-fix-login.patch`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 && args[0] == "-" {
+				return nil
+			}
+			if c.Patch != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 && args[0] == "-" {
+				if c.Patch != "" {
+					return errors.ValidationError("review", "give the patch either on stdin or with --patch, not both")
+				}
+				c.Patch, args = "-", nil
+			}
 			c.Files = args
 			ctx := cmd.Context()
 			return c.Execute(ctx)
@@ -591,15 +1158,34 @@ Examples:
 	}
 
 	// Add flags
-	cmd.Flags().StringSliceVar(&c.Focus, "focus", []string{}, "Focus areas (security,performance,style,testing)")
+	cmd.Flags().StringSliceVar(&c.Focus, "focus", []string{}, "Focus areas (security,performance,style,testing,accessibility,i18n,concurrency,error-handling)")
 	cmd.Flags().StringVar(&c.Severity, "severity", "warning", "Minimum severity to report (error,warning,info,all)")
-	cmd.Flags().StringVar(&c.Format, "format", "markdown", "Output format (markdown,text,json,xml,sarif)")
-	cmd.Flags().StringVarP(&c.OutputFile, "output", "o", "", "Output file (default: stdout)")
+	cmd.Flags().StringVar(&c.Format, "format", "markdown", "Output format (markdown,text,json,json@v2,xml,sarif,rdjson)")
+	cmd.Flags().StringArrayVarP(&c.Outputs, "output", "o", nil, "Output file, or - for stdout, optionally as format:file (repeatable; default: stdout)")
 	cmd.Flags().BoolVar(&c.IncludeTests, "include-tests", false, "Include test coverage analysis")
-	cmd.Flags().BoolVar(&c.CheckSecurity, "check-security", false, "Focus on security issues")
-	cmd.Flags().BoolVar(&c.CheckPerformance, "check-performance", false, "Focus on performance issues")
-	cmd.Flags().BoolVar(&c.CheckStyle, "check-style", false, "Focus on style and formatting")
+	cmd.Flags().BoolVar(&c.CheckSecurity, "check-security", false, "Same as --focus security")
+	cmd.Flags().BoolVar(&c.CheckPerformance, "check-performance", false, "Same as --focus performance")
+	cmd.Flags().BoolVar(&c.CheckStyle, "check-style", false, "Same as --focus style")
+	cmd.Flags().BoolVar(&c.Concurrency, "concurrency", false, "Run go vet, race-enabled tests and staticcheck in a sandbox and review for data races and deadlocks (Go only)")
+	cmd.Flags().BoolVar(&c.NoAnalyzers, "no-analyzers", false, "Skip the external analyzers declared in the configuration")
+	cmd.Flags().BoolVar(&c.NoOwners, "no-owners", false, "Do not attach CODEOWNERS or git blame owners to findings")
+	cmd.Flags().BoolVar(&c.CrossCheck, "cross-check", false, "Run the review through two model providers and mark the findings they agree on")
+	cmd.Flags().StringSliceVar(&c.CrossCheckModels, "cross-check-models", nil, "The two provider:model pairs to cross-check (default: the lead model and the first reviewer from another provider)")
+	cmd.Flags().StringVar(&c.EvidencePolicy, "evidence", EvidenceDowngrade, "What to do with findings whose quoted source is not at their location (downgrade,drop,off)")
+	cmd.Flags().BoolVar(&c.VerifyRefs, "verify-refs", false, "Check the files and symbols the review refers to, correcting near misses and marking the rest unverified")
+	cmd.Flags().BoolVar(&c.Submodules, "recurse-submodules", false, "Review the files of submodules given as arguments")
+	cmd.Flags().BoolVar(&c.RequireReasons, "require-suppression-reason", false, "Fail if a sigil:ignore comment in the reviewed files gives no reason")
+	cmd.Flags().StringVar(&c.Baseline, "baseline", "", "Leave out findings recorded in this baseline file")
+	cmd.Flags().BoolVar(&c.UpdateBaseline, "update-baseline", false, "Write the current findings to the --baseline file")
+	cmd.Flags().StringVar(&c.HistoryPath, "history", findings.DefaultHistoryPath, "Findings history file for new/recurring/fixed tracking (empty to disable)")
+	cmd.Flags().StringVar(&c.TranscriptFile, "transcript", "", "Write the sandbox transcript to a file (.html for a report, JSON otherwise)")
+	cmd.Flags().IntVar(&c.BatchSize, "batch-size", DefaultReviewBatchSize, "Files per review task; more files are reviewed in batches (0 to review them all at once)")
+	cmd.Flags().IntVar(&c.BatchJobs, "batch-jobs", DefaultReviewBatchJobs, "Batches reviewed at once")
+	cmd.Flags().BoolVar(&c.FailFast, "fail-fast", false, "Stop at the first file or batch that fails instead of reviewing the rest")
+	cmd.Flags().StringVar(&c.Patch, "patch", "", "Review the changes of a unified diff file instead of files on disk (- for stdin)")
+	cmd.Flags().StringVar(&c.Base, "base", "", "With a patch, give the reviewers the files at this revision, before the change")
 	cmd.Flags().BoolVar(&c.AutoFix, "auto-fix", false, "Automatically apply fixes where possible")
+	c.Preset.register(cmd)
 
 	return cmd
 }
@@ -610,8 +1196,12 @@ func (c *ReviewCommand) fileExists(path string) bool {
 	return err == nil
 }
 
-// readFile reads a file's content
+// readFile reads a file's content. Files of a patch are as the patch
+// shows them after the change.
 func (c *ReviewCommand) readFile(path string) (string, error) {
+	if file, ok := c.patch[path]; ok {
+		return file.Changed(), nil
+	}
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return "", err
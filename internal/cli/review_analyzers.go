@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/analyzer"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/sandbox"
+	"github.com/dshills/sigil/internal/trust"
+)
+
+// reviewAnalyzers returns the external analyzers to run for the review:
+// the configured ones that apply to the reviewed files, and the
+// concurrency analyzers when --concurrency is set. None run in untrusted
+// mode, since analyzers build and run tools over the repository.
+func (c *ReviewCommand) reviewAnalyzers() ([]analyzer.Analyzer, error) {
+	if trust.Untrusted() {
+		logger.Info("untrusted repository, skipping external analyzers")
+		return nil, nil
+	}
+
+	if c.patch != nil {
+		logger.Info("reviewing a patch, skipping external analyzers")
+		return nil, nil
+	}
+
+	var analyzers []analyzer.Analyzer
+	if !c.NoAnalyzers {
+		configured, err := analyzer.Resolve(getConfig().Analyzers)
+		if err != nil {
+			return nil, err
+		}
+		for _, candidate := range configured {
+			if candidate.Applies(c.Files) {
+				analyzers = append(analyzers, candidate)
+			}
+		}
+	}
+
+	if c.Concurrency {
+		_, err := exec.LookPath("staticcheck")
+		if err != nil {
+			logger.Info("staticcheck not found, skipping it")
+		}
+		analyzers = append(analyzers, concurrencyAnalyzers(err == nil)...)
+	}
+	return analyzers, nil
+}
+
+// runAnalyzers runs the analyzers in a sandbox over HEAD with the reviewed
+// files applied on top
+func (c *ReviewCommand) runAnalyzers(ctx context.Context, analyzers []analyzer.Analyzer) ([]analyzer.Result, error) {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeGit, "runAnalyzers", "failed to open repository")
+	}
+	manager, err := sandbox.NewManager(repo, analyzer.Commands(analyzers)...)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeConfig, "runAnalyzers", "failed to create sandbox manager")
+	}
+	defer func() {
+		if err := manager.Cleanup(); err != nil {
+			logger.Warn("failed to cleanup sandbox manager", "error", err)
+		}
+	}()
+
+	files := make([]sandbox.FileChange, 0, len(c.Files))
+	for _, path := range c.Files {
+		content, err := c.readFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeInput, "runAnalyzers",
+				fmt.Sprintf("failed to read file: %s", path))
+		}
+		files = append(files, sandbox.FileChange{Path: path, Content: content, Operation: sandbox.OperationUpdate})
+	}
+
+	logger.Info("running analyzers", "count", len(analyzers))
+	return analyzer.Run(ctx, manager, fmt.Sprintf("review_analyzers_%d", c.startTime.Unix()), analyzers, files)
+}
+
+// analyzerRequirement asks the agents to weigh the analyzer findings
+func analyzerRequirement(results []analyzer.Result) string {
+	var builder strings.Builder
+	builder.WriteString("Static analyzers ran over the code. Confirm or dismiss each of their results below " +
+		"rather than repeating them, and use them as evidence for your own findings.\n")
+
+	for _, result := range results {
+		switch {
+		case !result.Ran:
+			builder.WriteString(fmt.Sprintf("\n%s: not run\n", result.Analyzer))
+			continue
+		case result.Error != "":
+			builder.WriteString(fmt.Sprintf("\n%s: output could not be parsed\n", result.Analyzer))
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("\n%s: %d findings\n", result.Analyzer, len(result.Findings)))
+		for _, finding := range result.Findings {
+			builder.WriteString(fmt.Sprintf("- %s %s\n", findingLocation(finding), finding.Message))
+		}
+		if len(result.Findings) == 0 && strings.Contains(result.Output, "FAIL") {
+			// A failing run without findings may be a build error worth reading
+			builder.WriteString(lastLines(result.Output, 40) + "\n")
+		}
+	}
+	return builder.String()
+}
+
+// lastLines keeps the last n lines of text
+func lastLines(text string, n int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mergeToolFindings adds the analyzer findings the agents did not already
+// report with the same type at the same location
+func mergeToolFindings(findings []agent.ReviewComment, results []analyzer.Result) []agent.ReviewComment {
+	reported := make(map[string]bool)
+	for _, finding := range findings {
+		reported[string(finding.Type)+" "+findingLocation(finding)] = true
+	}
+	for _, result := range results {
+		for _, finding := range result.Findings {
+			key := string(finding.Type) + " " + findingLocation(finding)
+			if !reported[key] {
+				reported[key] = true
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/analyzer"
+	"github.com/dshills/sigil/internal/config"
+)
+
+func TestAnalyzerRequirement(t *testing.T) {
+	results := []analyzer.Result{
+		{Analyzer: ToolGoVet, Ran: true, Findings: []agent.ReviewComment{{Path: "cache.go", Line: 21, Message: "passes lock by value"}}},
+		{Analyzer: ToolRaceTests, Ran: true, Output: "cache.go:3:1: undefined: Foo\nFAIL\texample.com/app [build failed]\n"},
+		{Analyzer: "ruff", Ran: true, Error: "failed to parse ruff output"},
+		{Analyzer: ToolStaticcheck},
+	}
+
+	requirement := analyzerRequirement(results)
+
+	assert.Contains(t, requirement, "Confirm or dismiss each of their results")
+	assert.Contains(t, requirement, "go vet: 1 findings\n- cache.go:21 passes lock by value\n")
+	assert.Contains(t, requirement, "undefined: Foo", "failing runs without findings include their output")
+	assert.Contains(t, requirement, "ruff: output could not be parsed")
+	assert.Contains(t, requirement, "staticcheck: not run")
+}
+
+func TestMergeToolFindings(t *testing.T) {
+	agentFindings := []agent.ReviewComment{
+		{Type: agent.CommentTypeConcurrency, Path: "cache.go", Line: 30, Message: "Set races with Get"},
+	}
+	results := []analyzer.Result{{Analyzer: ToolRaceTests, Findings: []agent.ReviewComment{
+		{Type: agent.CommentTypeConcurrency, Path: "cache.go", Line: 30, Message: "data race"},
+		{Type: agent.CommentTypeStyle, Path: "cache.go", Line: 30, Message: "line too long"},
+		{Type: agent.CommentTypeConcurrency, Path: "pool.go", Line: 51, Message: "likely deadlock"},
+	}}}
+
+	merged := mergeToolFindings(agentFindings, results)
+
+	require.Len(t, merged, 3)
+	assert.Equal(t, "Set races with Get", merged[0].Message, "the agent's finding wins at the same location")
+	assert.Equal(t, "line too long", merged[1].Message, "findings of another type are kept")
+	assert.Equal(t, "pool.go", merged[2].Path)
+}
+
+func TestReviewCommand_reviewAnalyzers(t *testing.T) {
+	previous := config.Get()
+	defer config.Set(previous)
+
+	cfg := *previous
+	cfg.Analyzers = []config.AnalyzerConfig{
+		{Name: "ruff"},
+		{Name: "golangci-lint"},
+		{Name: "semgrep", Disabled: true},
+	}
+	config.Set(&cfg)
+
+	cmd := NewReviewCommand()
+	cmd.Files = []string{"main.go"}
+
+	analyzers, err := cmd.reviewAnalyzers()
+	require.NoError(t, err)
+	require.Len(t, analyzers, 1, "ruff does not apply to Go files and semgrep is disabled")
+	assert.Equal(t, "golangci-lint", analyzers[0].Name)
+
+	cmd.NoAnalyzers = true
+	analyzers, err = cmd.reviewAnalyzers()
+	require.NoError(t, err)
+	assert.Empty(t, analyzers)
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+// reviewArea is a configured review area and the reviewed files in it
+type reviewArea struct {
+	config.ReviewAreaConfig
+	focus []agent.FocusDefinition
+	files []string // Matching files, relative to the repository root
+}
+
+// matchReviewAreas returns the configured review areas that hold at least
+// one of the reviewed files, in configuration order
+func matchReviewAreas(configured []config.ReviewAreaConfig, root string, files []string) ([]reviewArea, error) {
+	var areas []reviewArea
+	for _, areaConfig := range configured {
+		area := reviewArea{ReviewAreaConfig: areaConfig}
+		for _, name := range areaConfig.Focus {
+			focus, err := agent.ParseFocusArea(name)
+			if err != nil {
+				return nil, errors.Wrap(err, errors.ErrorTypeConfig, "matchReviewAreas",
+					fmt.Sprintf("invalid focus for review area %s", area.label()))
+			}
+			area.focus = append(area.focus, focus)
+		}
+
+		for _, file := range files {
+			matched, err := area.matches(repoRelative(root, file))
+			if err != nil {
+				return nil, errors.Wrap(err, errors.ErrorTypeConfig, "matchReviewAreas",
+					fmt.Sprintf("invalid path for review area %s", area.label()))
+			}
+			if matched {
+				area.files = append(area.files, repoRelative(root, file))
+			}
+		}
+		if len(area.files) > 0 {
+			areas = append(areas, area)
+		}
+	}
+	return areas, nil
+}
+
+// repoRelative returns a path relative to the repository root, leaving
+// paths outside it as given
+func repoRelative(root, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil || root == "" {
+		return filepath.ToSlash(path)
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// label names an area in instructions and errors
+func (a reviewArea) label() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return strings.Join(a.Paths, ", ")
+}
+
+// matches reports whether a repository-relative path is in the area
+func (a reviewArea) matches(path string) (bool, error) {
+	for _, pattern := range a.Paths {
+		matched, err := sandbox.MatchPath(pattern, path)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+// requirements returns the review instructions of the area
+func (a reviewArea) requirements() []string {
+	scope := fmt.Sprintf("For %s (%s)", a.label(), strings.Join(a.files, ", "))
+	var requirements []string
+	if len(a.focus) > 0 {
+		names := make([]string, len(a.focus))
+		for i, focus := range a.focus {
+			names[i] = string(focus.Area)
+		}
+		if a.Only {
+			requirements = append(requirements, fmt.Sprintf("%s, review only: %s", scope, strings.Join(names, ", ")))
+		} else {
+			requirements = append(requirements, fmt.Sprintf("%s, focus specifically on: %s", scope, strings.Join(names, ", ")))
+		}
+	}
+	for _, requirement := range a.Requirements {
+		requirements = append(requirements, fmt.Sprintf("%s: %s", scope, requirement))
+	}
+	if a.Severity != "" {
+		requirements = append(requirements, fmt.Sprintf("%s, report issues of severity %s and above", scope, strings.ToLower(a.Severity)))
+	}
+	return requirements
+}
+
+// constraints returns the focus constraints of the area, limited to its
+// files
+func (a reviewArea) constraints() []agent.Constraint {
+	constraints := make([]agent.Constraint, 0, len(a.focus))
+	for _, focus := range a.focus {
+		constraint := focus.Constraint()
+		constraint.Description = fmt.Sprintf("%s in %s", constraint.Description, a.label())
+		constraint.Parameters = map[string]string{
+			"area":  a.label(),
+			"paths": strings.Join(a.Paths, ","),
+			"files": strings.Join(a.files, ","),
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints
+}
+
+// areaSeverity returns the lowest severity reported for a finding: that of
+// the last area holding its file which sets one, or else --severity
+func (c *ReviewCommand) areaSeverity(finding agent.ReviewComment) string {
+	severity := c.Severity
+	path := repoRelative(c.root, finding.Path)
+	for _, area := range c.areas {
+		if matched, _ := area.matches(path); matched && area.Severity != "" {
+			severity = strings.ToLower(area.Severity)
+		}
+	}
+	return severity
+}
+
+// inAreaFocus reports whether a finding is of a kind the areas holding its
+// file report: areas set to only their focus drop the others
+func (c *ReviewCommand) inAreaFocus(finding agent.ReviewComment) bool {
+	path := repoRelative(c.root, finding.Path)
+	restricted := false
+	for _, area := range c.areas {
+		if !area.Only {
+			continue
+		}
+		if matched, _ := area.matches(path); !matched {
+			continue
+		}
+		restricted = true
+		for _, focus := range area.focus {
+			if focus.CommentType == finding.Type {
+				return true
+			}
+		}
+	}
+	return !restricted
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/config"
+)
+
+// reviewAreasConfig is stricter about security under auth and reviews only
+// style under docs
+var reviewAreasConfig = []config.ReviewAreaConfig{
+	{Name: "auth", Paths: []string{"auth/**"}, Focus: []string{"security"}, Requirements: []string{"Check every token comparison is constant time"}, Severity: "info"},
+	{Name: "docs", Paths: []string{"docs/**", "*.md"}, Focus: []string{"style"}, Only: true},
+	{Name: "web", Paths: []string{"web/**"}, Focus: []string{"performance"}},
+}
+
+func TestMatchReviewAreas(t *testing.T) {
+	root := t.TempDir()
+	areas, err := matchReviewAreas(reviewAreasConfig, root,
+		[]string{filepath.Join(root, "auth", "session", "token.go"), "docs/guide.md", filepath.Join(root, "main.go")})
+	require.NoError(t, err)
+
+	require.Len(t, areas, 2, "areas without reviewed files are left out")
+	assert.Equal(t, "auth", areas[0].Name)
+	assert.Equal(t, []string{"auth/session/token.go"}, areas[0].files)
+	assert.Equal(t, []string{"docs/guide.md"}, areas[1].files)
+
+	assert.Equal(t, []string{
+		"For auth (auth/session/token.go), focus specifically on: security",
+		"For auth (auth/session/token.go): Check every token comparison is constant time",
+		"For auth (auth/session/token.go), report issues of severity info and above",
+	}, areas[0].requirements())
+	assert.Equal(t, []string{"For docs (docs/guide.md), review only: style"}, areas[1].requirements())
+
+	constraints := areas[0].constraints()
+	require.Len(t, constraints, 1)
+	assert.Equal(t, agent.ConstraintTypeSecurity, constraints[0].Type)
+	assert.Equal(t, "auth/**", constraints[0].Parameters["paths"])
+
+	_, err = matchReviewAreas([]config.ReviewAreaConfig{{Paths: []string{"a/**"}, Focus: []string{"speling"}}}, root, []string{"a/b.go"})
+	assert.ErrorContains(t, err, "invalid focus for review area a/**")
+}
+
+func TestReviewCommand_createReviewTask_Areas(t *testing.T) {
+	root := t.TempDir()
+	cmd := NewReviewCommand()
+	cmd.root = root
+	areas, err := matchReviewAreas(reviewAreasConfig, root, []string{"auth/login.go"})
+	require.NoError(t, err)
+	cmd.areas = areas
+
+	task, err := cmd.createReviewTask()
+	require.NoError(t, err)
+	assert.Contains(t, task.Context.Requirements, "For auth (auth/login.go), focus specifically on: security")
+	require.Len(t, task.Constraints, 1)
+	assert.Equal(t, "auth", task.Constraints[0].Parameters["area"])
+}
+
+func TestReviewCommand_collectFindings_Areas(t *testing.T) {
+	root := t.TempDir()
+	cmd := NewReviewCommand()
+	cmd.root = root
+	cmd.Files = []string{"auth/login.go", "docs/guide.md", "main.go"}
+	areas, err := matchReviewAreas(reviewAreasConfig, root, cmd.Files)
+	require.NoError(t, err)
+	cmd.areas = areas
+
+	content := "```json\n[" +
+		`{"path": "auth/login.go", "severity": "info", "type": "security", "message": "Token logged"},` +
+		`{"path": "main.go", "severity": "info", "type": "logic", "message": "Unused result"},` +
+		`{"path": "docs/guide.md", "severity": "error", "type": "logic", "message": "Example does not compile"},` +
+		`{"path": "docs/guide.md", "severity": "warning", "type": "style", "message": "Heading case"}` +
+		"]\n```\n"
+	result := &agent.OrchestrationResult{Status: agent.StatusSuccess, FinalResult: &agent.Result{Reasoning: content}}
+
+	var messages []string
+	for _, finding := range cmd.collectFindings(content, result) {
+		messages = append(messages, finding.Message)
+	}
+	assert.ElementsMatch(t, []string{"Token logged", "Heading case"}, messages)
+}
@@ -0,0 +1,370 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/findings"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// Review batching defaults: reviews of more files than fit one task are
+// split into batches, a few of which run at once
+const (
+	DefaultReviewBatchSize = 20
+	DefaultReviewBatchJobs = 2
+)
+
+// reviewBatch is one batch of a batched review and its outcome
+type reviewBatch struct {
+	Index  int
+	Files  []string
+	Result *agent.OrchestrationResult
+	Err    error
+}
+
+// batchReviewFunc reviews the files of one batch
+type batchReviewFunc func(ctx context.Context, index int, files []string) (*agent.OrchestrationResult, error)
+
+// splitBatches splits files into batches of at most size files
+func splitBatches(files []string, size int) [][]string {
+	var batches [][]string
+	for start := 0; start < len(files); start += size {
+		batches = append(batches, files[start:min(start+size, len(files))])
+	}
+	return batches
+}
+
+// runBatches reviews the batches through a queue worked by at most jobs
+// reviews at once. Each batch is passed to done as it finishes, on the
+// calling goroutine, and all of them are returned in order. Batches the
+// context was cancelled before are returned with its error.
+func runBatches(ctx context.Context, batches [][]string, jobs int, review batchReviewFunc, done func(reviewBatch)) []reviewBatch {
+	queue := make(chan int)
+	finished := make(chan reviewBatch)
+
+	var wg sync.WaitGroup
+	for range min(jobs, len(batches)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				result, err := review(ctx, i, batches[i])
+				finished <- reviewBatch{Index: i, Files: batches[i], Result: result, Err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(queue)
+		for i := range batches {
+			select {
+			case queue <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	results := make([]reviewBatch, len(batches))
+	ran := make([]bool, len(batches))
+	for batch := range finished {
+		results[batch.Index] = batch
+		ran[batch.Index] = true
+		if done != nil {
+			done(batch)
+		}
+	}
+	for i := range results {
+		if !ran[i] {
+			results[i] = reviewBatch{Index: i, Files: batches[i], Err: ctx.Err()}
+		}
+	}
+	return results
+}
+
+// executeBatches reviews the files of task in batches of --batch-size,
+// writing each batch's findings to progress as it finishes, and merges the
+// batches into one result. The files of failed batches are recorded as
+// failures and left out of the report's files; under --fail-fast the first
+// failed batch cancels the rest and its error is returned.
+func (c *ReviewCommand) executeBatches(ctx context.Context, task *agent.Task, progress io.Writer) (*agent.OrchestrationResult, error) {
+	batches := splitBatches(c.Files, c.BatchSize)
+	logger.Info("reviewing in batches", "files", len(c.Files), "batches", len(batches), "jobs", c.BatchJobs)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	review := func(ctx context.Context, index int, files []string) (*agent.OrchestrationResult, error) {
+		return c.reviewTask(ctx, batchTask(task, index, files))
+	}
+	start, finished := time.Now(), 0
+	var firstErr error
+	results := runBatches(ctx, batches, c.BatchJobs, review, func(batch reviewBatch) {
+		finished++
+		c.writeBatchProgress(progress, batch, len(batches), batchETA(time.Since(start), finished, len(batches)))
+		if batch.Err != nil && c.FailFast && firstErr == nil {
+			firstErr = fmt.Errorf("batch %d: %w", batch.Index+1, batch.Err)
+			cancel()
+		}
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	merged := mergeBatches(results, c.fingerprintedFindings)
+	if merged == nil {
+		return nil, errors.Wrap(results[0].Err, errors.ErrorTypeInternal, "executeBatches", "every review batch failed")
+	}
+	for _, batch := range results {
+		if batch.Err == nil {
+			continue
+		}
+		for _, file := range batch.Files {
+			_ = c.failures.record(file, batch.Err)
+		}
+	}
+	reviewed := make([]string, 0, len(c.Files))
+	for _, file := range c.Files {
+		if !c.failures.failed(file) {
+			reviewed = append(reviewed, file)
+		}
+	}
+	c.Files = reviewed
+	merged.TaskID = task.ID
+	c.synthesizeBatches(ctx, task, merged, results, c.executeSynthesis)
+	return merged, nil
+}
+
+// batchTask narrows task to the files of a batch
+func batchTask(task *agent.Task, index int, files []string) *agent.Task {
+	inBatch := make(map[string]bool, len(files))
+	for _, file := range files {
+		inBatch[file] = true
+	}
+
+	batch := *task
+	batch.ID = fmt.Sprintf("%s_batch%d", task.ID, index+1)
+	batch.Context.Files = nil
+	for _, file := range task.Context.Files {
+		if inBatch[file.Path] {
+			batch.Context.Files = append(batch.Context.Files, file)
+		}
+	}
+	return &batch
+}
+
+// batchETA estimates how long the batches left will take from how long the
+// finished ones took, pacing waits included
+func batchETA(elapsed time.Duration, finished, total int) time.Duration {
+	if finished == 0 || finished >= total {
+		return 0
+	}
+	return elapsed / time.Duration(finished) * time.Duration(total-finished)
+}
+
+// writeBatchProgress reports a finished batch, the findings it reported and
+// how long the batches left are expected to take
+func (c *ReviewCommand) writeBatchProgress(w io.Writer, batch reviewBatch, total int, eta time.Duration) {
+	files := batch.Files[0]
+	if len(batch.Files) > 1 {
+		files = fmt.Sprintf("%s .. %s", batch.Files[0], batch.Files[len(batch.Files)-1])
+	}
+	if batch.Err != nil {
+		fmt.Fprintf(w, "Batch %d/%d (%s) failed: %v\n", batch.Index+1, total, files, batch.Err)
+	} else {
+		found := c.runFindings(batch.Result)
+		fmt.Fprintf(w, "Batch %d/%d (%s): %d findings\n", batch.Index+1, total, files, len(found))
+		for _, finding := range found {
+			fmt.Fprintf(w, "  %s [%s] %s\n", findingLocation(finding), finding.Severity, finding.Message)
+		}
+	}
+	if eta > 0 {
+		fmt.Fprintf(w, "  ETA %s\n", eta.Round(time.Second))
+	}
+}
+
+// fingerprintedFindings returns the findings of a batch with their
+// fingerprints, so the same finding reported by several batches is merged
+func (c *ReviewCommand) fingerprintedFindings(result *agent.OrchestrationResult) []agent.ReviewComment {
+	found := c.runFindings(result)
+	for i := range found {
+		found[i].Fingerprint = findings.Fingerprint(found[i], c.source(found[i].Path))
+	}
+	return found
+}
+
+// mergeBatches combines the results of the review batches into one, with
+// each batch's review text under its own heading and the findings of all
+// of them, deduplicated by fingerprint, in one findings block. Failed
+// batches are noted and make the result partial; it is nil when every
+// batch failed.
+func mergeBatches(batches []reviewBatch, batchFindings func(*agent.OrchestrationResult) []agent.ReviewComment) *agent.OrchestrationResult {
+	var combined *agent.OrchestrationResult
+	var body strings.Builder
+	var found []agent.ReviewComment
+	for _, batch := range batches {
+		body.WriteString(fmt.Sprintf("\n## Batch %d: %s\n\n", batch.Index+1, strings.Join(batch.Files, ", ")))
+		if batch.Err != nil {
+			body.WriteString(fmt.Sprintf("Not reviewed: %v\n", batch.Err))
+			continue
+		}
+		body.WriteString(stripFindings(analysisText(batch.Result)) + "\n")
+		found = append(found, batchFindings(batch.Result)...)
+
+		if combined == nil {
+			first := *batch.Result
+			combined = &first
+			combined.Consensus = nil
+			combined.Results = nil
+			combined.Transcript = nil
+			combined.Conversation = nil
+			combined.Duration = 0
+		}
+		combined.Results = append(combined.Results, batch.Result.Results...)
+		combined.Transcript = append(combined.Transcript, batch.Result.Transcript...)
+		combined.Conversation = append(combined.Conversation, batch.Result.Conversation...)
+		combined.Duration += batch.Result.Duration
+	}
+	if combined == nil {
+		return nil
+	}
+	if failedBatches(batches) > 0 {
+		combined.Status = agent.StatusPartial
+	}
+
+	final := agent.Result{}
+	if combined.FinalResult != nil {
+		final = *combined.FinalResult
+	}
+	final.Reasoning = batchReport(batches, body.String(), findings.Dedupe(found))
+	final.Artifacts = nil
+	combined.FinalResult = &final
+	return combined
+}
+
+// failedBatches counts the batches that failed
+func failedBatches(batches []reviewBatch) int {
+	failed := 0
+	for _, batch := range batches {
+		if batch.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// batchReport assembles the text of a batched review: how it was batched,
+// the review itself and the findings block
+func batchReport(batches []reviewBatch, body string, found []agent.ReviewComment) string {
+	if found == nil {
+		found = []agent.ReviewComment{}
+	}
+	data, err := json.MarshalIndent(found, "", "  ")
+	if err != nil {
+		logger.Warn("failed to encode batched findings", "error", err)
+	}
+
+	summary := fmt.Sprintf("Batched review in %d batches.\n", len(batches))
+	if failed := failedBatches(batches); failed > 0 {
+		summary = fmt.Sprintf("Batched review in %d batches, %d of which failed.\n", len(batches), failed)
+	}
+	return fmt.Sprintf("%s%s\n```json\n%s\n```\n", summary, body, data)
+}
+
+// synthesizeBatches replaces the batch-by-batch text of a merged review
+// with one review of all the files, written by an agent pass that
+// reconciles the batches where they contradict each other. The findings
+// are kept as merged. When the pass fails the batches are reported one by
+// one.
+func (c *ReviewCommand) synthesizeBatches(ctx context.Context, task *agent.Task, merged *agent.OrchestrationResult, batches []reviewBatch, synthesize summarizeFunc) {
+	synthesis := createSynthesisTask(task, batches)
+	if synthesis == nil {
+		return
+	}
+	text, err := synthesize(ctx, synthesis)
+	if err != nil {
+		logger.Warn("failed to synthesize the batch reviews, reporting them one by one", "error", err)
+		return
+	}
+
+	body := "\n" + strings.TrimSpace(text) + "\n"
+	if failedBatches(batches) > 0 {
+		body += "\n## Not reviewed\n\n"
+		for _, batch := range batches {
+			if batch.Err != nil {
+				body += fmt.Sprintf("- Batch %d (%s): %v\n", batch.Index+1, strings.Join(batch.Files, ", "), batch.Err)
+			}
+		}
+	}
+	merged.FinalResult.Reasoning = batchReport(batches, body, parseFindings(analysisText(merged)))
+}
+
+// createSynthesisTask creates the task reconciling the reviews of the
+// batches into one. It is nil when fewer than two batches were reviewed,
+// as there is nothing to reconcile.
+func createSynthesisTask(task *agent.Task, batches []reviewBatch) *agent.Task {
+	requirements := []string{
+		"Synthesize the reviews of the batches below into one coherent review of all the files",
+		"Where the batch reviews contradict each other, reconcile them: say which assessment holds and why",
+		"Do not repeat the individual findings; they are reported separately",
+		"Format the review as markdown without top-level headings",
+	}
+	reviewed := 0
+	for _, batch := range batches {
+		if batch.Err != nil {
+			continue
+		}
+		reviewed++
+		requirements = append(requirements, fmt.Sprintf("Review of batch %d (%s):\n%s",
+			batch.Index+1, strings.Join(batch.Files, ", "), stripFindings(analysisText(batch.Result))))
+	}
+	if reviewed < 2 {
+		return nil
+	}
+
+	return &agent.Task{
+		ID:          task.ID + "_synthesis",
+		Type:        agent.TaskTypeAnalyze,
+		Description: "Synthesize the batch reviews into one review",
+		Context: agent.TaskContext{
+			Requirements: requirements,
+			ProjectInfo:  task.Context.ProjectInfo,
+		},
+		Priority:  agent.PriorityHigh,
+		CreatedAt: task.CreatedAt,
+	}
+}
+
+// executeSynthesis runs the task reconciling the batch reviews
+func (c *ReviewCommand) executeSynthesis(ctx context.Context, task *agent.Task) (string, error) {
+	factory := agent.NewFactory(nil, orchestrationConfig()) // No sandbox needed for synthesis
+	orchestrator, err := factory.CreateOrchestrator()
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeInternal, "executeSynthesis", "failed to create orchestrator")
+	}
+	configureOrchestrator(orchestrator)
+
+	result, err := orchestrator.ExecuteTask(ctx, *task)
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeInternal, "executeSynthesis", "task execution failed")
+	}
+	if result.Status != agent.StatusSuccess {
+		return "", errors.New(errors.ErrorTypeInternal, "executeSynthesis",
+			fmt.Sprintf("synthesis failed with status: %s", result.Status))
+	}
+	text := analysisText(result)
+	if text == "" {
+		return "", errors.New(errors.ErrorTypeInternal, "executeSynthesis", "no synthesis generated")
+	}
+	return text, nil
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+)
+
+func TestSplitBatches(t *testing.T) {
+	files := []string{"a.go", "b.go", "c.go", "d.go", "e.go"}
+	assert.Equal(t, [][]string{{"a.go", "b.go"}, {"c.go", "d.go"}, {"e.go"}}, splitBatches(files, 2))
+	assert.Equal(t, [][]string{files}, splitBatches(files, 5))
+	assert.Nil(t, splitBatches(nil, 2))
+}
+
+func TestRunBatches(t *testing.T) {
+	batches := splitBatches([]string{"a.go", "b.go", "c.go", "d.go", "e.go", "f.go"}, 1)
+
+	var running, peak atomic.Int32
+	review := func(_ context.Context, index int, files []string) (*agent.OrchestrationResult, error) {
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if now <= old || peak.CompareAndSwap(old, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if files[0] == "c.go" {
+			return nil, fmt.Errorf("model unavailable")
+		}
+		return crossCheckReview(files[0], "[]"), nil
+	}
+
+	var streamed []int
+	results := runBatches(context.Background(), batches, 2, review, func(batch reviewBatch) {
+		streamed = append(streamed, batch.Index)
+	})
+
+	assert.LessOrEqual(t, peak.Load(), int32(2), "no more than jobs batches run at once")
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5}, streamed, "every batch is streamed as it finishes")
+	require.Len(t, results, 6)
+	for i, batch := range results {
+		assert.Equal(t, i, batch.Index, "results are in batch order")
+		assert.Equal(t, batches[i], batch.Files)
+	}
+	assert.EqualError(t, results[2].Err, "model unavailable")
+	assert.NoError(t, results[3].Err)
+}
+
+func TestRunBatches_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	review := func(_ context.Context, _ int, files []string) (*agent.OrchestrationResult, error) {
+		cancel()
+		return crossCheckReview(files[0], "[]"), nil
+	}
+
+	results := runBatches(ctx, splitBatches([]string{"a.go", "b.go", "c.go"}, 1), 1, review, nil)
+	require.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[2].Err, context.Canceled, "batches not started are not reviewed")
+}
+
+func TestBatchTask(t *testing.T) {
+	task := &agent.Task{ID: "review_1", Context: agent.TaskContext{
+		Files:        []agent.FileContext{{Path: "a.go"}, {Path: "b.go"}, {Path: "c.go"}},
+		Requirements: []string{"Format the review as markdown"},
+	}}
+
+	batch := batchTask(task, 1, []string{"c.go", "a.go"})
+	assert.Equal(t, "review_1_batch2", batch.ID)
+	assert.Equal(t, []agent.FileContext{{Path: "a.go"}, {Path: "c.go"}}, batch.Context.Files)
+	assert.Equal(t, task.Context.Requirements, batch.Context.Requirements)
+	assert.Len(t, task.Context.Files, 3, "the task itself is unchanged")
+}
+
+func TestReviewCommand_writeBatchProgress(t *testing.T) {
+	cmd := NewReviewCommand()
+	var out bytes.Buffer
+
+	cmd.writeBatchProgress(&out, reviewBatch{Index: 0, Files: []string{"a.go", "b.go"}, Result: crossCheckReview("Fine.",
+		`[{"path": "a.go", "line": 3, "severity": "error", "type": "logic", "message": "Nil dereference"}]`)}, 2, 90*time.Second)
+	cmd.writeBatchProgress(&out, reviewBatch{Index: 1, Files: []string{"c.go"}, Err: fmt.Errorf("timeout")}, 2, 0)
+
+	assert.Equal(t, "Batch 1/2 (a.go .. b.go): 1 findings\n  a.go:3 [error] Nil dereference\n  ETA 1m30s\n"+
+		"Batch 2/2 (c.go) failed: timeout\n", out.String())
+}
+
+func TestBatchETA(t *testing.T) {
+	assert.Equal(t, 4*time.Minute, batchETA(2*time.Minute, 2, 6), "the batches left take as long as the finished ones")
+	assert.Zero(t, batchETA(time.Minute, 6, 6))
+	assert.Zero(t, batchETA(0, 0, 6))
+}
+
+func TestMergeBatches(t *testing.T) {
+	cmd := NewReviewCommand()
+	cmd.Severity = "all"
+	cmd.NoOwners = true
+	cmd.Files = []string{"a.go", "b.go", "c.go"}
+	batches := []reviewBatch{
+		{Index: 0, Files: []string{"a.go"}, Result: crossCheckReview("A looks fine.",
+			`[{"path": "a.go", "line": 3, "severity": "error", "type": "logic", "message": "Nil dereference"}]`)},
+		{Index: 1, Files: []string{"b.go"}, Err: fmt.Errorf("timeout")},
+		{Index: 2, Files: []string{"c.go"}, Result: crossCheckReview("C needs work.",
+			`[{"path": "c.go", "line": 8, "severity": "warning", "type": "logic", "message": "Unused result"}]`)},
+	}
+
+	merged := mergeBatches(batches, cmd.runFindings)
+	require.NotNil(t, merged)
+	assert.Equal(t, agent.StatusPartial, merged.Status, "a failed batch makes the review partial")
+	assert.Len(t, merged.Results, 2)
+
+	text := analysisText(merged)
+	assert.Contains(t, text, "Batched review in 3 batches, 1 of which failed.")
+	assert.Contains(t, text, "## Batch 1: a.go\n\nA looks fine.")
+	assert.Contains(t, text, "## Batch 2: b.go\n\nNot reviewed: timeout")
+	assert.Contains(t, text, "## Batch 3: c.go\n\nC needs work.")
+
+	found := cmd.reviewFindings(text, merged)
+	require.Len(t, found, 2, "the findings of every batch are reported together")
+	assert.Equal(t, "a.go", found[0].Path)
+	assert.Equal(t, "c.go", found[1].Path)
+
+	batches[0].Err, batches[2].Err = fmt.Errorf("timeout"), fmt.Errorf("timeout")
+	assert.Nil(t, mergeBatches(batches, cmd.runFindings))
+}
+
+func TestMergeBatches_DedupesByFingerprint(t *testing.T) {
+	cmd := NewReviewCommand()
+	cmd.Files = []string{"a.go", "b.go"}
+	overlap := `[{"path": "shared.go", "line": 4, "severity": "warning", "type": "logic", "message": "Lock not released"}]`
+	escalated := `[{"path": "shared.go", "line": 4, "severity": "error", "type": "logic", "message": "Lock not released"}]`
+	batches := []reviewBatch{
+		{Index: 0, Files: []string{"a.go"}, Result: crossCheckReview("A calls into shared.go.", overlap)},
+		{Index: 1, Files: []string{"b.go"}, Result: crossCheckReview("B calls into shared.go too.", escalated)},
+	}
+
+	merged := mergeBatches(batches, cmd.fingerprintedFindings)
+	require.NotNil(t, merged)
+	assert.Equal(t, agent.StatusSuccess, merged.Status)
+	found := parseFindings(analysisText(merged))
+	require.Len(t, found, 1, "a finding both batches reported is merged")
+	assert.Equal(t, agent.SeverityError, found[0].Severity, "the merged finding keeps the higher severity")
+}
+
+func TestReviewCommand_synthesizeBatches(t *testing.T) {
+	cmd := NewReviewCommand()
+	cmd.Files = []string{"a.go", "b.go", "c.go"}
+	task := &agent.Task{ID: "review_1", CreatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	batches := []reviewBatch{
+		{Index: 0, Files: []string{"a.go"}, Result: crossCheckReview("Error handling is thorough.",
+			`[{"path": "a.go", "line": 3, "severity": "error", "type": "logic", "message": "Nil dereference"}]`)},
+		{Index: 1, Files: []string{"b.go"}, Result: crossCheckReview("Errors are ignored throughout.", "[]")},
+		{Index: 2, Files: []string{"c.go"}, Err: fmt.Errorf("timeout")},
+	}
+	merged := mergeBatches(batches, cmd.runFindings)
+	require.NotNil(t, merged)
+
+	var asked *agent.Task
+	synthesize := func(_ context.Context, task *agent.Task) (string, error) {
+		asked = task
+		return "Error handling is thorough in a.go but missing in b.go.", nil
+	}
+	cmd.synthesizeBatches(context.Background(), task, merged, batches, synthesize)
+
+	require.NotNil(t, asked)
+	assert.Equal(t, "review_1_synthesis", asked.ID)
+	assert.Contains(t, asked.Context.Requirements, "Review of batch 1 (a.go):\nError handling is thorough.")
+	assert.Contains(t, asked.Context.Requirements, "Review of batch 2 (b.go):\nErrors are ignored throughout.")
+	assert.Len(t, asked.Context.Requirements, 6, "failed batches are not synthesized")
+
+	text := analysisText(merged)
+	assert.Contains(t, text, "Error handling is thorough in a.go but missing in b.go.")
+	assert.NotContains(t, text, "## Batch 1", "the synthesis replaces the batch-by-batch text")
+	assert.Contains(t, text, "## Not reviewed\n\n- Batch 3 (c.go): timeout")
+	found := parseFindings(text)
+	require.Len(t, found, 1, "the findings are kept")
+	assert.Equal(t, "Nil dereference", found[0].Message)
+}
+
+func TestReviewCommand_synthesizeBatches_Fallback(t *testing.T) {
+	cmd := NewReviewCommand()
+	cmd.Files = []string{"a.go", "b.go"}
+	task := &agent.Task{ID: "review_1"}
+	batches := []reviewBatch{
+		{Index: 0, Files: []string{"a.go"}, Result: crossCheckReview("A is fine.", "[]")},
+		{Index: 1, Files: []string{"b.go"}, Result: crossCheckReview("B is fine.", "[]")},
+	}
+	merged := mergeBatches(batches, cmd.runFindings)
+	require.NotNil(t, merged)
+	before := analysisText(merged)
+
+	cmd.synthesizeBatches(context.Background(), task, merged, batches, func(context.Context, *agent.Task) (string, error) {
+		return "", fmt.Errorf("model unavailable")
+	})
+	assert.Equal(t, before, analysisText(merged), "the batches are reported one by one when synthesis fails")
+
+	batches[1] = reviewBatch{Index: 1, Files: []string{"b.go"}, Err: fmt.Errorf("timeout")}
+	assert.Nil(t, createSynthesisTask(task, batches), "a single reviewed batch needs no synthesis")
+}
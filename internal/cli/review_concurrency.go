@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/analyzer"
+)
+
+// Concurrency analyzers run by review --concurrency
+const (
+	ToolGoVet       = "go vet"
+	ToolRaceTests   = "go test -race"
+	ToolStaticcheck = "staticcheck"
+)
+
+// Output formats of the concurrency analyzers
+const (
+	formatGoVet       = "go-vet"
+	formatGoRace      = "go-race"
+	formatStaticcheck = "staticcheck"
+)
+
+// concurrencyRequirement asks the agents to reason about shared state
+const concurrencyRequirement = "Reason about the shared state in this code: which goroutines read and write it, " +
+	"which locks guard it and how channels, WaitGroups and contexts order those accesses. " +
+	"Report likely data races and deadlocks as concurrency findings with suggested fixes."
+
+// Suggested fixes for the concurrency problems the tools report
+const (
+	raceSuggestion     = "Guard the shared state with a sync.Mutex, use sync/atomic, or hand ownership between goroutines over a channel"
+	deadlockSuggestion = "Check that locks are always taken in the same order and released on every path, and that every channel operation has a counterpart"
+	copyLockSuggestion = "Pass the value by pointer so every caller shares the same lock"
+	loopVarSuggestion  = "Copy the loop variable before starting the goroutine, or pass it as an argument"
+	cancelSuggestion   = "Call the cancel function on every path, usually with defer cancel()"
+)
+
+// diagnosticLine matches "file.go:12:5: message" lines from go vet and
+// staticcheck
+var diagnosticLine = regexp.MustCompile(`^(\S+\.go):(\d+)(?::\d+)?: (.+)$`)
+
+// stackFrame matches the "\t/path/file.go:12 +0x1d" lines of a goroutine trace
+var stackFrame = regexp.MustCompile(`^\s+(\S+\.go):(\d+)`)
+
+// staticcheckCode matches the check code staticcheck appends to a message
+var staticcheckCode = regexp.MustCompile(`\s*\((\w+)\)$`)
+
+// concurrencyKeywords mark go vet findings that concern concurrency
+var concurrencyKeywords = []string{"lock", "mutex", "goroutine", "loop variable", "cancel", "atomic", "waitgroup", "sync."}
+
+func init() {
+	analyzer.RegisterParser(formatGoVet, func(output string, paths analyzer.Paths) ([]agent.ReviewComment, error) {
+		return parseDiagnostics(ToolGoVet, output, paths), nil
+	})
+	analyzer.RegisterParser(formatStaticcheck, func(output string, paths analyzer.Paths) ([]agent.ReviewComment, error) {
+		return parseDiagnostics(ToolStaticcheck, output, paths), nil
+	})
+	analyzer.RegisterParser(formatGoRace, func(output string, paths analyzer.Paths) ([]agent.ReviewComment, error) {
+		return parseRaceOutput(output, paths), nil
+	})
+}
+
+// concurrencyAnalyzers returns the analyzers for the concurrency mode.
+// staticcheck only runs when it is installed, and is limited to its
+// concurrency checks.
+func concurrencyAnalyzers(staticcheck bool) []analyzer.Analyzer {
+	analyzers := []analyzer.Analyzer{
+		{Name: ToolGoVet, Command: "go", Args: []string{"vet", "./..."}, Format: formatGoVet, Type: agent.CommentTypeConcurrency},
+		{Name: ToolRaceTests, Command: "go", Args: []string{"test", "-race", "-count=1", "./..."}, Format: formatGoRace, Type: agent.CommentTypeConcurrency},
+	}
+	if staticcheck {
+		analyzers = append(analyzers, analyzer.Analyzer{
+			Name: ToolStaticcheck, Command: "staticcheck", Args: []string{"-checks", "SA2*,SA1029", "./..."},
+			Format: formatStaticcheck, Type: agent.CommentTypeConcurrency,
+		})
+	}
+	return analyzers
+}
+
+// parseDiagnostics reads the concurrency findings from go vet or
+// staticcheck output
+func parseDiagnostics(tool, output string, paths analyzer.Paths) []agent.ReviewComment {
+	var findings []agent.ReviewComment
+	for _, line := range strings.Split(output, "\n") {
+		match := diagnosticLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		path, ok := paths.Relative(match[1])
+		if !ok {
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(match[2])
+		message := match[3]
+
+		finding := agent.ReviewComment{
+			Type:     agent.CommentTypeConcurrency,
+			Severity: agent.SeverityWarning,
+			Path:     path,
+			Line:     lineNumber,
+			Message:  message,
+		}
+		if tool == ToolStaticcheck {
+			// staticcheck only runs its concurrency checks
+			if code := staticcheckCode.FindStringSubmatch(message); code != nil {
+				finding.References = []string{code[1]}
+			}
+		} else if !isConcurrencyDiagnostic(message) {
+			// go vet runs all of its analyzers
+			continue
+		}
+		finding.Suggestion = diagnosticSuggestion(message)
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// isConcurrencyDiagnostic reports whether a go vet message concerns concurrency
+func isConcurrencyDiagnostic(message string) bool {
+	lower := strings.ToLower(message)
+	for _, keyword := range concurrencyKeywords {
+		if strings.Contains(lower, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// diagnosticSuggestion picks the suggested fix for a tool message
+func diagnosticSuggestion(message string) string {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "lock by value"), strings.Contains(lower, "copies lock"):
+		return copyLockSuggestion
+	case strings.Contains(lower, "loop variable"):
+		return loopVarSuggestion
+	case strings.Contains(lower, "cancel"):
+		return cancelSuggestion
+	case strings.Contains(lower, "lock"), strings.Contains(lower, "mutex"):
+		return deadlockSuggestion
+	default:
+		return raceSuggestion
+	}
+}
+
+// parseRaceOutput reads data races and deadlocks from race-enabled test
+// output, locating each at the first frame inside the sandbox
+func parseRaceOutput(output string, paths analyzer.Paths) []agent.ReviewComment {
+	var findings []agent.ReviewComment
+	lines := strings.Split(output, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "WARNING: DATA RACE":
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(lines[end], "==================") {
+				end++
+			}
+			locations := raceAccesses(lines[i+1:end], paths)
+			i = end
+			if len(locations) == 0 {
+				continue
+			}
+			message := fmt.Sprintf("data race at %s", locations[0])
+			if len(locations) > 1 {
+				message += fmt.Sprintf(" conflicts with the access at %s", locations[1])
+			}
+			findings = append(findings, raceFinding(locations[0], message, agent.SeverityError, raceSuggestion))
+
+		case strings.Contains(line, "all goroutines are asleep - deadlock!"), strings.HasPrefix(line, "panic: test timed out after"):
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(lines[end], "FAIL") {
+				end++
+			}
+			locations := sandboxFrames(lines[i+1:end], paths)
+			i = end
+			if len(locations) == 0 {
+				continue
+			}
+			message := fmt.Sprintf("likely deadlock (%s) at %s", line, locations[0])
+			findings = append(findings, raceFinding(locations[0], message, agent.SeverityCritical, deadlockSuggestion))
+		}
+	}
+	return findings
+}
+
+// sandboxFrames returns the distinct "path:line" locations of stack frames
+// inside the sandbox, in order
+func sandboxFrames(lines []string, paths analyzer.Paths) []string {
+	var locations []string
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		match := stackFrame.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		path, ok := paths.Relative(match[1])
+		if !ok {
+			continue
+		}
+		location := path + ":" + match[2]
+		if !seen[location] {
+			seen[location] = true
+			locations = append(locations, location)
+		}
+	}
+	return locations
+}
+
+// raceAccesses returns the first sandbox frame of each conflicting access
+// in a race report, skipping the goroutine creation traces
+func raceAccesses(lines []string, paths analyzer.Paths) []string {
+	var locations []string
+	inAccess, found := false, false
+	for _, line := range lines {
+		if line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			inAccess = strings.Contains(line, " at 0x")
+			found = false
+			continue
+		}
+		if !inAccess || found {
+			continue
+		}
+		if frames := sandboxFrames([]string{line}, paths); len(frames) > 0 {
+			locations = append(locations, frames[0])
+			found = true
+		}
+	}
+	return locations
+}
+
+// raceFinding builds a finding located at "path:line"
+func raceFinding(location, message string, severity agent.Severity, suggestion string) agent.ReviewComment {
+	index := strings.LastIndex(location, ":")
+	line, _ := strconv.Atoi(location[index+1:])
+	return agent.ReviewComment{
+		Type:       agent.CommentTypeConcurrency,
+		Severity:   severity,
+		Path:       location[:index],
+		Line:       line,
+		Message:    message,
+		Suggestion: suggestion,
+	}
+}
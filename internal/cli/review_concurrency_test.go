@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/analyzer"
+)
+
+var testPaths = analyzer.NewPaths("abc123")
+
+func TestConcurrencyAnalyzers(t *testing.T) {
+	analyzers := concurrencyAnalyzers(false)
+	require.Len(t, analyzers, 2)
+	assert.Equal(t, []string{"test", "-race", "-count=1", "./..."}, analyzers[1].Args)
+
+	analyzers = concurrencyAnalyzers(true)
+	require.Len(t, analyzers, 3)
+	assert.Equal(t, "staticcheck", analyzers[2].Command)
+
+	for _, concurrency := range analyzers {
+		assert.Contains(t, analyzer.Formats(), concurrency.Format, "%s has a registered parser", concurrency.Name)
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	t.Run("go vet keeps concurrency findings", func(t *testing.T) {
+		output := `# example.com/app/cache
+cache/cache.go:21:9: Get passes lock by value: example.com/app/cache.Cache contains sync.Mutex
+cache/cache.go:40:2: fmt.Printf format %d has arg name of wrong type string
+./worker.go:12:3: loop variable job captured by func literal
+`
+		findings := parseDiagnostics(ToolGoVet, output, testPaths)
+
+		require.Len(t, findings, 2)
+		assert.Equal(t, "cache/cache.go", findings[0].Path)
+		assert.Equal(t, 21, findings[0].Line)
+		assert.Equal(t, agent.CommentTypeConcurrency, findings[0].Type)
+		assert.Equal(t, copyLockSuggestion, findings[0].Suggestion)
+		assert.Equal(t, "worker.go", findings[1].Path)
+		assert.Equal(t, loopVarSuggestion, findings[1].Suggestion)
+	})
+
+	t.Run("staticcheck", func(t *testing.T) {
+		output := "/repo/.sigil/sandbox/abc123/pool.go:33:2: should call wg.Add(1) before starting the goroutine to avoid a race (SA2000)\n"
+		findings := parseDiagnostics(ToolStaticcheck, output, testPaths)
+
+		require.Len(t, findings, 1)
+		assert.Equal(t, "pool.go", findings[0].Path)
+		assert.Equal(t, []string{"SA2000"}, findings[0].References)
+	})
+}
+
+func TestParseRaceOutput(t *testing.T) {
+	output := `==================
+WARNING: DATA RACE
+Write at 0x00c0000a0018 by goroutine 8:
+  example.com/app/cache.(*Cache).Set()
+      /repo/.sigil/sandbox/abc123/cache/cache.go:30 +0x44
+  example.com/app/cache.TestCache.func1()
+      /repo/.sigil/sandbox/abc123/cache/cache_test.go:14 +0x3c
+
+Previous read at 0x00c0000a0018 by goroutine 7:
+  runtime.mapaccess1()
+      /usr/local/go/src/runtime/map.go:395 +0x0
+  example.com/app/cache.(*Cache).Get()
+      /repo/.sigil/sandbox/abc123/cache/cache.go:24 +0x3a
+
+Goroutine 8 (running) created at:
+  example.com/app/cache.TestCache()
+      /repo/.sigil/sandbox/abc123/cache/cache_test.go:12 +0x1b0
+==================
+--- FAIL: TestCache (0.00s)
+    testing.go:1398: race detected during execution of test
+fatal error: all goroutines are asleep - deadlock!
+
+goroutine 1 [semacquire]:
+sync.runtime_Semacquire(0xc000012345?)
+	/usr/local/go/src/runtime/sema.go:62 +0x25
+example.com/app/pool.(*Pool).Wait(...)
+	/repo/.sigil/sandbox/abc123/pool/pool.go:51
+FAIL	example.com/app/pool	0.012s
+`
+	findings := parseRaceOutput(output, testPaths)
+
+	require.Len(t, findings, 2)
+	assert.Equal(t, "cache/cache.go", findings[0].Path)
+	assert.Equal(t, 30, findings[0].Line)
+	assert.Equal(t, agent.SeverityError, findings[0].Severity)
+	assert.Contains(t, findings[0].Message, "conflicts with the access at cache/cache.go:24")
+	assert.Equal(t, raceSuggestion, findings[0].Suggestion)
+
+	assert.Equal(t, "pool/pool.go", findings[1].Path)
+	assert.Equal(t, 51, findings[1].Line)
+	assert.Equal(t, agent.SeverityCritical, findings[1].Severity)
+	assert.Contains(t, findings[1].Message, "likely deadlock")
+	assert.Equal(t, deadlockSuggestion, findings[1].Suggestion)
+}
+
+func TestReviewCommand_ConcurrencyFocus(t *testing.T) {
+	cmd := NewReviewCommand()
+	cmd.Concurrency = true
+
+	areas, err := cmd.focusAreas()
+	require.NoError(t, err)
+	require.Len(t, areas, 1)
+	assert.Equal(t, agent.FocusConcurrency, areas[0].Area)
+}
@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/findings"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// Agreement of the cross-checked models on a finding
+const (
+	AgreementAgreed = "agreed" // Every model reported it: high confidence
+	AgreementSingle = "single" // One model reported it: needs human attention
+)
+
+// crossCheckLineSlack is how many lines apart two models may place the
+// same finding
+const crossCheckLineSlack = 3
+
+// crossCheckRun is the review of one cross-checked model
+type crossCheckRun struct {
+	Model  string
+	Result *agent.OrchestrationResult
+}
+
+// crossCheckSummary counts the findings the cross-checked models agree on
+type crossCheckSummary struct {
+	Models []string `json:"models"`
+	Agreed int      `json:"agreed"`
+	Single int      `json:"single"`
+}
+
+// executeCrossCheck runs the review task through two model providers and
+// merges their findings into one result, marking which both reported
+func (c *ReviewCommand) executeCrossCheck(ctx context.Context, task *agent.Task) (*agent.OrchestrationResult, error) {
+	models := c.crossChecked
+	if models == nil {
+		var err error
+		if models, err = c.crossCheckModels(); err != nil {
+			return nil, err
+		}
+		c.crossChecked = models
+	}
+
+	runs := make([]crossCheckRun, 0, len(models))
+	for _, reviewModel := range models {
+		result, err := c.executeReview(ctx, task, reviewModel)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeModel, "executeCrossCheck",
+				fmt.Sprintf("cross-check review with %s failed", reviewModel))
+		}
+		runs = append(runs, crossCheckRun{Model: reviewModel, Result: result})
+	}
+
+	merged := c.crossCheckFindings(runs)
+	logger.Info("cross-checked review", "models", models, "findings", len(merged))
+	return mergeCrossCheck(runs, merged), nil
+}
+
+// crossCheckModels returns the two models to cross-check: those given with
+// --cross-check-models, or else the lead model and the first configured
+// reviewer from another provider
+func (c *ReviewCommand) crossCheckModels() ([]string, error) {
+	models := c.CrossCheckModels
+	if len(models) == 0 {
+		cfg := getConfig()
+		models = []string{cfg.Models.Lead}
+		leadProvider, _, _ := model.ParseModelString(cfg.Models.Lead)
+		for _, reviewer := range cfg.Models.Reviewers {
+			if provider, _, err := model.ParseModelString(reviewer); err == nil && provider != leadProvider {
+				models = append(models, reviewer)
+				break
+			}
+		}
+	}
+
+	if len(models) != 2 {
+		return nil, errors.ValidationError("crossCheckModels",
+			fmt.Sprintf("cross-checking needs two models from different providers, have %d", len(models))).
+			WithHint("name them with --cross-check-models, e.g. anthropic:claude-3-5-sonnet-20241022,openai:gpt-4o, or add a reviewer from another provider under models.reviewers")
+	}
+	providers := make([]string, len(models))
+	for i, reviewModel := range models {
+		provider, _, err := model.ParseModelString(reviewModel)
+		if err != nil {
+			return nil, errors.ValidationError("crossCheckModels", fmt.Sprintf("invalid model: %s", reviewModel)).
+				WithHint("give models as provider:model")
+		}
+		providers[i] = provider
+	}
+	if providers[0] == providers[1] {
+		return nil, errors.ValidationError("crossCheckModels",
+			fmt.Sprintf("cross-checked models must come from different providers, both are %s", providers[0])).
+			WithHint("pick a model from another provider with --cross-check-models")
+	}
+	return models, nil
+}
+
+// runFindings returns the located findings of one cross-checked review
+func (c *ReviewCommand) runFindings(result *agent.OrchestrationResult) []agent.ReviewComment {
+	found := parseFindings(analysisText(result))
+	if result.Consensus != nil {
+		for _, review := range result.Consensus.Reviews {
+			found = append(found, review.Comments...)
+		}
+	}
+
+	var located []agent.ReviewComment
+	for _, finding := range found {
+		if finding.Path == "" && len(c.Files) == 1 {
+			finding.Path = c.Files[0]
+		}
+		if finding.Path != "" && finding.Message != "" {
+			located = append(located, finding)
+		}
+	}
+	return located
+}
+
+// crossCheckFindings merges the findings of the cross-checked reviews,
+// recording the models that reported each and whether they all did
+func (c *ReviewCommand) crossCheckFindings(runs []crossCheckRun) []agent.ReviewComment {
+	var merged []agent.ReviewComment
+	for _, run := range runs {
+		for _, finding := range c.runFindings(run.Result) {
+			matched := false
+			for i := range merged {
+				if containsModel(merged[i].Models, run.Model) || !sameFinding(merged[i], finding) {
+					continue
+				}
+				merged[i].Models = append(merged[i].Models, run.Model)
+				if severityRanks[finding.Severity] > severityRanks[merged[i].Severity] {
+					merged[i].Severity = finding.Severity
+				}
+				matched = true
+				break
+			}
+			if !matched {
+				finding.Models = []string{run.Model}
+				merged = append(merged, finding)
+			}
+		}
+	}
+
+	for i := range merged {
+		merged[i].Agreement = AgreementSingle
+		if len(merged[i].Models) == len(runs) {
+			merged[i].Agreement = AgreementAgreed
+		}
+	}
+	return merged
+}
+
+// sameFinding reports whether two models' findings are the same issue: in
+// the same file, at nearby lines and of the same type
+func sameFinding(a, b agent.ReviewComment) bool {
+	if findings.NormalizePath(a.Path) != findings.NormalizePath(b.Path) {
+		return false
+	}
+	distance := a.Line - b.Line
+	if distance < 0 {
+		distance = -distance
+	}
+	if distance > crossCheckLineSlack {
+		return false
+	}
+	return a.Type == b.Type || a.Type == agent.CommentTypeGeneral || b.Type == agent.CommentTypeGeneral
+}
+
+// containsModel reports whether a model is in a list
+func containsModel(models []string, reviewModel string) bool {
+	for _, candidate := range models {
+		if candidate == reviewModel {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeCrossCheck combines the cross-checked reviews into one result whose
+// review text holds each model's review and the merged findings
+func mergeCrossCheck(runs []crossCheckRun, merged []agent.ReviewComment) *agent.OrchestrationResult {
+	models := make([]string, len(runs))
+	for i, run := range runs {
+		models[i] = run.Model
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Cross-checked review by %s.\n", strings.Join(models, " and ")))
+	for _, run := range runs {
+		text.WriteString(fmt.Sprintf("\n## Review by %s\n\n%s\n", run.Model, stripFindings(analysisText(run.Result))))
+	}
+	if merged == nil {
+		merged = []agent.ReviewComment{}
+	}
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		logger.Warn("failed to encode cross-checked findings", "error", err)
+	}
+	text.WriteString(fmt.Sprintf("\n```json\n%s\n```\n", data))
+
+	// The merged findings replace the consensus comments they came from
+	combined := *runs[0].Result
+	combined.Consensus = nil
+	combined.Results = nil
+	combined.Transcript = nil
+	combined.Duration = 0
+	for _, run := range runs {
+		combined.Results = append(combined.Results, run.Result.Results...)
+		combined.Transcript = append(combined.Transcript, run.Result.Transcript...)
+		combined.Duration += run.Result.Duration
+	}
+	final := agent.Result{}
+	if runs[0].Result.FinalResult != nil {
+		final = *runs[0].Result.FinalResult
+	}
+	final.Reasoning = text.String()
+	final.Artifacts = nil
+	combined.FinalResult = &final
+	return &combined
+}
+
+// summarizeCrossCheck counts the reported findings the cross-checked
+// models agree on. It is nil when the review was not cross-checked.
+func (c *ReviewCommand) summarizeCrossCheck(found []agent.ReviewComment) *crossCheckSummary {
+	if len(c.crossChecked) == 0 {
+		return nil
+	}
+	summary := &crossCheckSummary{Models: c.crossChecked}
+	for _, finding := range found {
+		switch finding.Agreement {
+		case AgreementAgreed:
+			summary.Agreed++
+		case AgreementSingle:
+			summary.Single++
+		}
+	}
+	return summary
+}
+
+// crossCheckTag labels findings of a cross-checked review in reports
+func crossCheckTag(finding agent.ReviewComment) string {
+	switch finding.Agreement {
+	case AgreementAgreed:
+		return " (agreed: " + strings.Join(finding.Models, ", ") + ")"
+	case AgreementSingle:
+		return " (only " + strings.Join(finding.Models, ", ") + ", needs human attention)"
+	}
+	return ""
+}
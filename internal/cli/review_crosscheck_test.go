@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/config"
+)
+
+// crossCheckReview is a successful review listing findings
+func crossCheckReview(text, findingsJSON string) *agent.OrchestrationResult {
+	return &agent.OrchestrationResult{
+		Status:      agent.StatusSuccess,
+		FinalResult: &agent.Result{Reasoning: text + "\n\n```json\n" + findingsJSON + "\n```\n"},
+		Results:     []agent.Result{{AgentID: "lead"}},
+	}
+}
+
+func TestReviewCommand_crossCheckModels(t *testing.T) {
+	previous := config.Get()
+	defer config.Set(previous)
+
+	cfg := *previous
+	cfg.Models.Lead = "anthropic:claude-3-5-sonnet-20241022"
+	cfg.Models.Reviewers = []string{"anthropic:claude-3-haiku-20240307", "openai:gpt-4o"}
+	config.Set(&cfg)
+
+	cmd := NewReviewCommand()
+	models, err := cmd.crossCheckModels()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"anthropic:claude-3-5-sonnet-20241022", "openai:gpt-4o"}, models,
+		"the first reviewer from another provider is the second model")
+
+	cmd.CrossCheckModels = []string{"openai:gpt-4o", "openai:gpt-4"}
+	_, err = cmd.crossCheckModels()
+	assert.ErrorContains(t, err, "both are openai")
+
+	cmd.CrossCheckModels = []string{"openai:gpt-4o"}
+	_, err = cmd.crossCheckModels()
+	assert.ErrorContains(t, err, "have 1")
+
+	cfg.Models.Reviewers = nil
+	cmd.CrossCheckModels = nil
+	_, err = cmd.crossCheckModels()
+	assert.ErrorContains(t, err, "two models from different providers")
+}
+
+func TestReviewCommand_crossCheckFindings(t *testing.T) {
+	cmd := NewReviewCommand()
+	cmd.Files = []string{"server.go"}
+	runs := []crossCheckRun{
+		{Model: "anthropic:claude", Result: crossCheckReview("Looks risky.", `[
+			{"path": "server.go", "line": 10, "severity": "warning", "type": "security", "message": "Token compared with =="},
+			{"line": 40, "severity": "info", "type": "style", "message": "Long function"}
+		]`)},
+		{Model: "openai:gpt-4o", Result: crossCheckReview("Two issues.", `[
+			{"path": "./server.go", "line": 12, "severity": "error", "type": "security", "message": "Timing attack on token check"},
+			{"path": "server.go", "line": 90, "severity": "warning", "type": "logic", "message": "Error ignored"}
+		]`)},
+	}
+
+	merged := cmd.crossCheckFindings(runs)
+	require.Len(t, merged, 3)
+	assert.Equal(t, "Token compared with ==", merged[0].Message)
+	assert.Equal(t, agent.SeverityError, merged[0].Severity, "the higher severity wins")
+	assert.Equal(t, []string{"anthropic:claude", "openai:gpt-4o"}, merged[0].Models)
+	assert.Equal(t, AgreementAgreed, merged[0].Agreement)
+	assert.Equal(t, "server.go", merged[1].Path, "the only reviewed file locates findings without a path")
+	assert.Equal(t, AgreementSingle, merged[1].Agreement)
+	assert.Equal(t, []string{"openai:gpt-4o"}, merged[2].Models)
+	assert.Equal(t, AgreementSingle, merged[2].Agreement)
+
+	result := mergeCrossCheck(runs, merged)
+	assert.Len(t, result.Results, 2)
+	assert.Contains(t, result.FinalResult.Reasoning, "## Review by openai:gpt-4o\n\nTwo issues.")
+
+	cmd.NoOwners = true
+	cmd.HistoryPath = ""
+	cmd.crossChecked = []string{"anthropic:claude", "openai:gpt-4o"}
+	report := cmd.reportFindings(analysisText(result), result)
+	require.Len(t, report.Findings, 2, "info findings are below the default severity")
+	assert.Equal(t, &crossCheckSummary{Models: cmd.crossChecked, Agreed: 1, Single: 1}, report.CrossCheck)
+
+	formatted := cmd.formatMarkdown(analysisText(result), result)
+	assert.Contains(t, formatted, "Token compared with == (agreed: anthropic:claude, openai:gpt-4o)")
+	assert.Contains(t, formatted, "Error ignored (only openai:gpt-4o, needs human attention)")
+	assert.Contains(t, formatted, "**Agreed:** 1 (high confidence) | **Single model:** 1 (needs human attention)")
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/verify"
+)
+
+// What review does with findings whose evidence does not check out
+const (
+	EvidenceDowngrade = "downgrade" // Lower their severity a level
+	EvidenceDrop      = "drop"      // Leave them out
+	EvidenceOff       = "off"       // Do not check evidence
+)
+
+// lowerSeverity is the severity a level below each
+var lowerSeverity = map[agent.Severity]agent.Severity{
+	agent.SeverityCritical: agent.SeverityError,
+	agent.SeverityError:    agent.SeverityWarning,
+	agent.SeverityWarning:  agent.SeverityInfo,
+	agent.SeverityInfo:     agent.SeverityInfo,
+}
+
+// checkEvidence confirms the source each finding quotes is at its
+// location. Findings whose evidence is elsewhere in the file are moved
+// there; those quoting nothing, or something not in the file, are
+// downgraded or dropped as the evidence policy says. Findings in files
+// that cannot be read are left as they are.
+func (c *ReviewCommand) checkEvidence(found []agent.ReviewComment) []agent.ReviewComment {
+	if c.EvidencePolicy == EvidenceOff {
+		return found
+	}
+
+	kept := make([]agent.ReviewComment, 0, len(found))
+	for _, finding := range found {
+		path := finding.Path
+		if path == "" && len(c.Files) == 1 {
+			path = c.Files[0]
+		}
+		source := ""
+		if path != "" {
+			source = c.source(path)
+		}
+		if source == "" {
+			kept = append(kept, finding)
+			continue
+		}
+
+		match := verify.Evidence(source, finding.Evidence, finding.Line)
+		finding.EvidenceStatus = match.Status
+		switch match.Status {
+		case verify.EvidenceRelocated:
+			logger.Debug("moving finding to its evidence", "path", path, "from", finding.Line, "to", match.Line)
+			finding.Line, finding.EndLine = match.Line, match.EndLine
+		case verify.EvidenceMissing, verify.EvidenceMismatch:
+			if c.EvidencePolicy == EvidenceDrop {
+				logger.Debug("dropping finding without evidence", "path", path, "line", finding.Line, "evidence", match.Status)
+				continue
+			}
+			if severity, ok := lowerSeverity[finding.Severity]; ok {
+				finding.Severity = severity
+			} else {
+				finding.Severity = agent.SeverityInfo
+			}
+		}
+		kept = append(kept, finding)
+	}
+	return kept
+}
+
+// evidenceTag labels findings whose evidence did not check out in reports
+func evidenceTag(finding agent.ReviewComment) string {
+	switch finding.EvidenceStatus {
+	case verify.EvidenceMissing:
+		return " (no evidence quoted)"
+	case verify.EvidenceMismatch:
+		return " (evidence not found)"
+	}
+	return ""
+}
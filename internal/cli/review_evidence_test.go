@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/verify"
+)
+
+const evidenceFile = `package store
+
+func Open(path string) (*Store, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{file: f}, nil
+}
+`
+
+// evidenceFindings quote the file accurately, at the wrong line, not at
+// all, and wrongly
+func evidenceFindings(path string) []agent.ReviewComment {
+	return []agent.ReviewComment{
+		{Path: path, Line: 4, Severity: agent.SeverityError, Message: "Accurate", Evidence: "f, err := os.Open(path)"},
+		{Path: path, Line: 20, EndLine: 21, Severity: agent.SeverityWarning, Message: "Elsewhere", Evidence: "return &Store{file: f}, nil"},
+		{Path: path, Line: 5, Severity: agent.SeverityCritical, Message: "Unquoted"},
+		{Path: path, Line: 5, Severity: agent.SeverityInfo, Message: "Invented", Evidence: "defer f.Close()"},
+		{Path: "missing.go", Line: 1, Severity: agent.SeverityError, Message: "Unreadable"},
+	}
+}
+
+func TestReviewCommand_checkEvidence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.go")
+	require.NoError(t, os.WriteFile(path, []byte(evidenceFile), 0600))
+
+	t.Run("downgrade", func(t *testing.T) {
+		cmd := NewReviewCommand()
+		checked := cmd.checkEvidence(evidenceFindings(path))
+		require.Len(t, checked, 5)
+
+		assert.Equal(t, verify.EvidenceVerified, checked[0].EvidenceStatus)
+		assert.Equal(t, agent.SeverityError, checked[0].Severity)
+
+		assert.Equal(t, verify.EvidenceRelocated, checked[1].EvidenceStatus)
+		assert.Equal(t, 8, checked[1].Line)
+		assert.Equal(t, 8, checked[1].EndLine)
+		assert.Equal(t, agent.SeverityWarning, checked[1].Severity)
+
+		assert.Equal(t, verify.EvidenceMissing, checked[2].EvidenceStatus)
+		assert.Equal(t, agent.SeverityError, checked[2].Severity)
+		assert.Equal(t, " (no evidence quoted)", evidenceTag(checked[2]))
+
+		assert.Equal(t, verify.EvidenceMismatch, checked[3].EvidenceStatus)
+		assert.Equal(t, agent.SeverityInfo, checked[3].Severity)
+		assert.Equal(t, " (evidence not found)", evidenceTag(checked[3]))
+
+		assert.Empty(t, checked[4].EvidenceStatus, "findings in unreadable files are left alone")
+		assert.Equal(t, agent.SeverityError, checked[4].Severity)
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		cmd := NewReviewCommand()
+		cmd.EvidencePolicy = EvidenceDrop
+		var messages []string
+		for _, finding := range cmd.checkEvidence(evidenceFindings(path)) {
+			messages = append(messages, finding.Message)
+		}
+		assert.Equal(t, []string{"Accurate", "Elsewhere", "Unreadable"}, messages)
+	})
+
+	t.Run("off", func(t *testing.T) {
+		cmd := NewReviewCommand()
+		cmd.EvidencePolicy = EvidenceOff
+		assert.Equal(t, evidenceFindings(path), cmd.checkEvidence(evidenceFindings(path)))
+	})
+}
+
+func TestReviewCommand_validateInputs_Evidence(t *testing.T) {
+	cmd := NewReviewCommand()
+	cmd.Files = []string{"main.go"}
+	cmd.EvidencePolicy = "ignore"
+	assert.Error(t, cmd.validateInputs())
+}
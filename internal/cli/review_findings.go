@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/deterministic"
+	"github.com/dshills/sigil/internal/findings"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/owners"
+)
+
+// findingsRequirement asks the agents for findings that can be located
+// and grouped by focus area
+var findingsRequirement = buildFindingsRequirement()
+
+// fencedJSON matches a ```json fenced block
+var fencedJSON = regexp.MustCompile("(?s)```json\\s*\\n(.*?)```")
+
+// severityRanks orders severities for the --severity filter
+var severityRanks = map[agent.Severity]int{
+	agent.SeverityInfo:     1,
+	agent.SeverityWarning:  2,
+	agent.SeverityError:    3,
+	agent.SeverityCritical: 4,
+}
+
+// findingGroup is the findings of one focus area
+type findingGroup struct {
+	Area     agent.FocusArea       `json:"area"`
+	Count    int                   `json:"count"`
+	Findings []agent.ReviewComment `json:"findings"`
+}
+
+// buildFindingsRequirement lists the finding types of the focus area
+// taxonomy alongside the general ones
+func buildFindingsRequirement() string {
+	types := []string{string(agent.CommentTypeLogic), string(agent.CommentTypeDesign)}
+	for _, area := range agent.FocusAreas() {
+		types = append(types, string(area.CommentType))
+	}
+	types = append(types, string(agent.CommentTypeGeneral))
+
+	return "After the review, list every finding in a ```json fenced block as an array of objects " +
+		"with the fields path, line, end_line, severity (error, warning or info), " +
+		fmt.Sprintf("type (%s), message, suggestion and evidence, ", strings.Join(types, ", ")) +
+		"the exact source lines the finding is based on, copied verbatim from the file"
+}
+
+// parseFindings reads structured findings from the last ```json block of
+// a review that holds an array of comments
+func parseFindings(content string) []agent.ReviewComment {
+	blocks := fencedJSON.FindAllStringSubmatch(content, -1)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		var findings []agent.ReviewComment
+		if err := json.Unmarshal([]byte(blocks[i][1]), &findings); err == nil {
+			return findings
+		}
+	}
+	return nil
+}
+
+// stripFindings removes the findings block from review text shown to
+// people, since the report lists the findings itself
+func stripFindings(content string) string {
+	blocks := fencedJSON.FindAllStringSubmatchIndex(content, -1)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		var findings []agent.ReviewComment
+		body := content[blocks[i][2]:blocks[i][3]]
+		if err := json.Unmarshal([]byte(body), &findings); err == nil {
+			return strings.TrimSpace(content[:blocks[i][0]] + content[blocks[i][1]:])
+		}
+	}
+	return content
+}
+
+// findingsReport is what a review reports about its findings
+type findingsReport struct {
+	Findings     []agent.ReviewComment
+	Suppressions []suppression
+	Baselined    int
+	Reviewers    []owners.Reviewer
+	CrossCheck   *crossCheckSummary
+}
+
+// reviewFindings returns the findings a review reports
+func (c *ReviewCommand) reviewFindings(content string, result *agent.OrchestrationResult) []agent.ReviewComment {
+	return c.reportFindings(content, result).Findings
+}
+
+// reportFindings gathers the findings of a review, leaving out those
+// suppressed in source and those in the baseline, and marks each with its
+// lifecycle status when run history is tracked
+func (c *ReviewCommand) reportFindings(content string, result *agent.OrchestrationResult) findingsReport {
+	active, suppressions := c.applySuppressions(c.collectFindings(content, result))
+	report := findingsReport{Suppressions: suppressions}
+	for _, finding := range active {
+		if c.baseline.Contains(finding) {
+			report.Baselined++
+			continue
+		}
+		if status, ok := c.statuses[finding.Fingerprint]; ok {
+			finding.Status = status
+		}
+		finding.Message = applyGlossary(finding.Message)
+		report.Findings = append(report.Findings, finding)
+	}
+	report.Findings = deterministic.SortedFunc(report.Findings, compareFindings)
+	report.Suppressions = deterministic.SortedFunc(report.Suppressions, func(a, b suppression) int {
+		return cmp.Or(strings.Compare(a.Path, b.Path), cmp.Compare(a.Line, b.Line), strings.Compare(a.Rule, b.Rule))
+	})
+	report.Reviewers = c.assignOwners(report.Findings)
+	report.CrossCheck = c.summarizeCrossCheck(report.Findings)
+	return report
+}
+
+// compareFindings orders findings by location, then by what they say
+func compareFindings(a, b agent.ReviewComment) int {
+	return cmp.Or(
+		strings.Compare(a.Path, b.Path),
+		cmp.Compare(a.Line, b.Line),
+		cmp.Compare(a.EndLine, b.EndLine),
+		strings.Compare(string(a.Severity), string(b.Severity)),
+		strings.Compare(a.Message, b.Message),
+	)
+}
+
+// assignOwners attaches the likely owners of each finding's code and
+// returns the owners to ask for review, by the findings they own
+func (c *ReviewCommand) assignOwners(found []agent.ReviewComment) []owners.Reviewer {
+	resolver := c.ownerResolver()
+	if resolver == nil {
+		return nil
+	}
+	ownerLists := make([][]string, 0, len(found))
+	for i := range found {
+		found[i].Owners = resolver.Owners(found[i].Path, found[i].Line, found[i].EndLine)
+		ownerLists = append(ownerLists, found[i].Owners)
+	}
+	return owners.Reviewers(ownerLists)
+}
+
+// ownerResolver returns the resolver for the repository under review,
+// creating it once. It is nil when owners are not wanted or cannot be
+// found.
+func (c *ReviewCommand) ownerResolver() *owners.Resolver {
+	if c.NoOwners {
+		return nil
+	}
+	if c.owners == nil {
+		c.owners = newOwnerResolver()
+		c.NoOwners = c.owners == nil
+	}
+	return c.owners
+}
+
+// collectFindings gathers the findings of a review: those listed in the
+// review text, the located comments of the consensus reviews and the
+// analyzer findings the agents did not already report. Each is
+// fingerprinted, and duplicates are dropped.
+func (c *ReviewCommand) collectFindings(content string, result *agent.OrchestrationResult) []agent.ReviewComment {
+	collected := parseFindings(content)
+	if result.Consensus != nil {
+		for _, review := range result.Consensus.Reviews {
+			for _, comment := range review.Comments {
+				if comment.Path != "" {
+					collected = append(collected, comment)
+				}
+			}
+		}
+	}
+
+	// Analyzer findings come from the code itself, so only the agents'
+	// evidence is checked
+	collected = c.checkEvidence(collected)
+	collected = mergeToolFindings(collected, c.toolResults)
+
+	var kept []agent.ReviewComment
+	for _, finding := range collected {
+		if finding.Severity == "" {
+			finding.Severity = agent.SeverityWarning
+		}
+		if finding.Path == "" && len(c.Files) == 1 {
+			finding.Path = c.Files[0]
+		}
+		if finding.Path == "" || finding.Message == "" {
+			logger.Debug("dropping finding without a location", "message", finding.Message)
+			continue
+		}
+		if severity := c.areaSeverity(finding); severity != "all" && severityRanks[finding.Severity] < severityRanks[agent.Severity(severity)] {
+			continue
+		}
+		if !c.inAreaFocus(finding) {
+			logger.Debug("dropping finding outside its review area's focus", "path", finding.Path, "type", finding.Type)
+			continue
+		}
+		finding.Fingerprint = findings.Fingerprint(finding, c.source(finding.Path))
+		kept = append(kept, finding)
+	}
+	return findings.Dedupe(kept)
+}
+
+// source returns the content of a file findings point into, reading it
+// once. Unreadable files have no content.
+func (c *ReviewCommand) source(path string) string {
+	key := findings.NormalizePath(path)
+	if content, ok := c.sources[key]; ok {
+		return content
+	}
+	if c.sources == nil {
+		c.sources = make(map[string]string)
+	}
+
+	content, err := c.readFile(path)
+	if err != nil {
+		logger.Debug("failed to read file a finding points into", "path", path, "error", err)
+	}
+	c.sources[key] = content
+	return content
+}
+
+// groupFindings groups findings by focus area in taxonomy order, with
+// findings outside the taxonomy last
+func groupFindings(findings []agent.ReviewComment) []findingGroup {
+	byArea := make(map[agent.FocusArea][]agent.ReviewComment)
+	for _, finding := range findings {
+		area := agent.FocusForComment(finding.Type)
+		byArea[area] = append(byArea[area], finding)
+	}
+
+	var groups []findingGroup
+	for _, definition := range agent.FocusAreas() {
+		if found := byArea[definition.Area]; len(found) > 0 {
+			groups = append(groups, findingGroup{Area: definition.Area, Count: len(found), Findings: found})
+		}
+	}
+	if found := byArea[agent.FocusGeneral]; len(found) > 0 {
+		groups = append(groups, findingGroup{Area: agent.FocusGeneral, Count: len(found), Findings: found})
+	}
+	return groups
+}
+
+// findingLocation formats a finding's file and line
+func findingLocation(finding agent.ReviewComment) string {
+	switch {
+	case finding.Line > 0 && finding.EndLine > finding.Line:
+		return fmt.Sprintf("%s:%d-%d", finding.Path, finding.Line, finding.EndLine)
+	case finding.Line > 0:
+		return fmt.Sprintf("%s:%d", finding.Path, finding.Line)
+	default:
+		return finding.Path
+	}
+}
+
+// writeBaseline accepts the current findings by writing them to the
+// baseline file
+func (c *ReviewCommand) writeBaseline(result *agent.OrchestrationResult) error {
+	active, _ := c.applySuppressions(c.collectFindings(analysisText(result), result))
+	if err := findings.WriteBaseline(c.Baseline, active); err != nil {
+		return err
+	}
+	logger.Info("wrote findings baseline", "path", c.Baseline, "findings", len(active))
+	return nil
+}
+
+// recordHistory records the run, with the tokens and cost it took, in the
+// findings history and keeps the lifecycle of its findings for the report
+func (c *ReviewCommand) recordHistory(runID string, result *agent.OrchestrationResult) error {
+	history, err := findings.LoadHistory(c.HistoryPath)
+	if err != nil {
+		return err
+	}
+
+	active, _ := c.applySuppressions(c.collectFindings(analysisText(result), result))
+	lifecycle := history.Record(runID, c.startTime, c.Files, active, usageMetrics())
+	c.lifecycle = &lifecycle
+	c.statuses = make(map[string]string)
+	for _, record := range lifecycle.New {
+		c.statuses[record.Fingerprint] = record.Status
+	}
+	for _, record := range lifecycle.Recurring {
+		c.statuses[record.Fingerprint] = record.Status
+	}
+	return history.Save()
+}
+
+// ownersTag names the owners of a finding in reports
+func ownersTag(finding agent.ReviewComment) string {
+	if len(finding.Owners) == 0 {
+		return ""
+	}
+	return " (owners: " + strings.Join(finding.Owners, ", ") + ")"
+}
+
+// statusTag labels new findings in reports
+func statusTag(finding agent.ReviewComment) string {
+	if finding.Status == findings.StatusNew {
+		return " (new)"
+	}
+	return ""
+}
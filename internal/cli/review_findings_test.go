@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/deterministic"
+	"github.com/dshills/sigil/internal/findings"
+	"github.com/dshills/sigil/internal/owners"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFindings(t *testing.T) {
+	findings := parseFindings("```json\n{\"not\": \"findings\"}\n```\n" + rdjsonReview)
+
+	require.Len(t, findings, 3)
+	assert.Equal(t, "server.go", findings[0].Path)
+	assert.Equal(t, 12, findings[0].Line)
+	assert.Equal(t, 14, findings[0].EndLine)
+	assert.Equal(t, agent.SeverityError, findings[0].Severity)
+	assert.Equal(t, agent.CommentTypeLogic, findings[0].Type)
+
+	assert.Nil(t, parseFindings("no structured findings"))
+}
+
+func TestStripFindings(t *testing.T) {
+	assert.Equal(t, "## Findings\n\nThe handler leaks a file descriptor.", stripFindings(rdjsonReview))
+	assert.Equal(t, "```json\n{\"a\": 1}\n```", stripFindings("```json\n{\"a\": 1}\n```"), "other JSON blocks are kept")
+}
+
+func TestGroupFindings(t *testing.T) {
+	groups := groupFindings([]agent.ReviewComment{
+		{Type: agent.CommentTypeLogic, Message: "off by one"},
+		{Type: agent.CommentTypeConcurrency, Message: "data race"},
+		{Type: agent.CommentTypeSecurity, Message: "token logged"},
+		{Type: agent.CommentTypeConcurrency, Message: "leaked goroutine"},
+	})
+
+	require.Len(t, groups, 3)
+	assert.Equal(t, agent.FocusSecurity, groups[0].Area)
+	assert.Equal(t, agent.FocusConcurrency, groups[1].Area)
+	assert.Equal(t, 2, groups[1].Count)
+	assert.Equal(t, agent.FocusGeneral, groups[2].Area, "findings outside the taxonomy come last")
+}
+
+func TestReviewCommand_formatMarkdown_FindingsByArea(t *testing.T) {
+	cmd := NewReviewCommand()
+	cmd.Files = []string{"server.go", "util.go"}
+	cmd.Severity = "all"
+	content := "Looks mostly fine.\n\n```json\n[" +
+		`{"path": "server.go", "line": 12, "end_line": 14, "severity": "error", "type": "error_handling", "message": "Close error ignored"},` +
+		`{"path": "util.go", "severity": "info", "type": "style", "message": "Long line"},` +
+		`{"path": "util.go", "line": 3, "severity": "warning", "type": "error_handling", "message": "Error not wrapped"}` +
+		"]\n```\n"
+
+	formatted := cmd.formatMarkdown(content, &agent.OrchestrationResult{Status: agent.StatusSuccess})
+
+	assert.Contains(t, formatted, "| style | 1 |\n| error-handling | 2 |\n")
+	assert.Contains(t, formatted, "### error-handling (2)\n\n- **error** `server.go:12-14` Close error ignored\n- **warning** `util.go:3` Error not wrapped\n")
+	assert.Contains(t, formatted, "- **info** `util.go` Long line")
+	assert.Contains(t, formatted, "## Review Details\n\nLooks mostly fine.\n")
+	assert.NotContains(t, formatted, "```json", "the findings block is replaced by the grouped report")
+}
+
+func TestReviewCommand_formatJSON_Deterministic(t *testing.T) {
+	deterministic.Set(true)
+	defer deterministic.Set(false)
+
+	format := func(findings ...string) string {
+		cmd := NewReviewCommand()
+		cmd.Files = []string{"util.go", "server.go"}
+		cmd.Severity = "all"
+		cmd.NoOwners = true
+		cmd.startTime = deterministic.Time(time.Now())
+		content := "```json\n[" + strings.Join(findings, ",") + "]\n```\n"
+		return cmd.formatJSON(content, &agent.OrchestrationResult{Status: agent.StatusSuccess})
+	}
+	first := `{"path": "util.go", "line": 3, "severity": "warning", "type": "error_handling", "message": "Error not wrapped"}`
+	second := `{"path": "server.go", "line": 12, "severity": "error", "type": "error_handling", "message": "Close error ignored"}`
+
+	formatted := format(first, second)
+	assert.Equal(t, formatted, format(second, first), "findings are listed in the same order")
+	assert.Contains(t, formatted, `"timestamp": "2000-01-01T00:00:00Z"`)
+	assert.Less(t, strings.Index(formatted, "Close error ignored"), strings.Index(formatted, "Error not wrapped"))
+}
+
+func TestReviewCommand_focusAreas(t *testing.T) {
+	cmd := NewReviewCommand()
+	cmd.Focus = []string{"perf", "a11y", "performance"}
+	cmd.CheckSecurity = true
+
+	areas, err := cmd.focusAreas()
+	require.NoError(t, err)
+
+	names := make([]agent.FocusArea, len(areas))
+	for i, area := range areas {
+		names[i] = area.Area
+	}
+	assert.Equal(t, []agent.FocusArea{agent.FocusPerformance, agent.FocusAccessibility, agent.FocusSecurity}, names)
+
+	cmd.Focus = []string{"vibes"}
+	_, err = cmd.focusAreas()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown focus area")
+}
+
+func TestReviewCommand_reportFindings_BaselineAndHistory(t *testing.T) {
+	dir := t.TempDir()
+	review := func(messages ...string) *agent.OrchestrationResult {
+		content := "```json\n["
+		for i, message := range messages {
+			if i > 0 {
+				content += ","
+			}
+			content += `{"path": "server.go", "severity": "warning", "type": "logic", "message": "` + message + `"}`
+		}
+		return &agent.OrchestrationResult{Status: agent.StatusSuccess, FinalResult: &agent.Result{Reasoning: content + "]\n```\n"}}
+	}
+
+	cmd := NewReviewCommand()
+	cmd.Files = []string{"server.go"}
+	cmd.Baseline = filepath.Join(dir, "baseline.json")
+	cmd.UpdateBaseline = true
+	require.NoError(t, cmd.writeBaseline(review("Known issue", "Known issue")))
+
+	baseline, err := findings.LoadBaseline(cmd.Baseline)
+	require.NoError(t, err)
+	assert.Len(t, baseline.Fingerprints, 1, "duplicates share a fingerprint")
+
+	cmd = NewReviewCommand()
+	cmd.Files = []string{"server.go"}
+	cmd.HistoryPath = filepath.Join(dir, "history.json")
+	cmd.baseline = baseline
+	result := review("Known issue", "Nil map write")
+	require.NoError(t, cmd.recordHistory("run1", result))
+
+	report := cmd.reportFindings(analysisText(result), result)
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, "Nil map write", report.Findings[0].Message)
+	assert.Equal(t, findings.StatusNew, report.Findings[0].Status)
+	assert.NotEmpty(t, report.Findings[0].Fingerprint)
+	assert.Equal(t, 1, report.Baselined)
+
+	formatted := cmd.formatMarkdown(analysisText(result), result)
+	assert.Contains(t, formatted, "Nil map write (new)")
+	assert.Contains(t, formatted, "## Finding Lifecycle")
+
+	cmd = NewReviewCommand()
+	cmd.Files = []string{"server.go"}
+	cmd.HistoryPath = filepath.Join(dir, "history.json")
+	result = review("Known issue")
+	require.NoError(t, cmd.recordHistory("run2", result))
+	require.Len(t, cmd.lifecycle.Fixed, 1)
+	assert.Equal(t, "Nil map write", cmd.lifecycle.Fixed[0].Message)
+}
+
+func TestReviewCommand_formatMarkdown_Owners(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "CODEOWNERS"), []byte("*.go @core\n/web/ @frontend\n"), 0600))
+	resolver, err := owners.NewResolver(root)
+	require.NoError(t, err)
+
+	cmd := NewReviewCommand()
+	cmd.Files = []string{"server.go"}
+	cmd.owners = resolver
+	server := filepath.Join(root, "server.go")
+	page := filepath.Join(root, "web", "page.go")
+	content := "```json\n[" +
+		`{"path": "` + server + `", "line": 2, "severity": "error", "type": "logic", "message": "Off by one"},` +
+		`{"path": "` + server + `", "line": 5, "severity": "warning", "type": "logic", "message": "Shadowed error"},` +
+		`{"path": "` + page + `", "line": 1, "severity": "warning", "type": "logic", "message": "Unchecked input"}` +
+		"]\n```\n"
+
+	formatted := cmd.formatMarkdown(content, &agent.OrchestrationResult{Status: agent.StatusSuccess})
+	assert.Contains(t, formatted, "Off by one (owners: @core)")
+	assert.Contains(t, formatted, "Unchecked input (owners: @frontend)")
+	assert.Contains(t, formatted, "## Suggested Reviewers\n\n| Owner | Findings |\n|-------|----------|\n| @core | 2 |\n| @frontend | 1 |\n")
+
+	cmd = NewReviewCommand()
+	cmd.Files = []string{"server.go"}
+	cmd.NoOwners = true
+	formatted = cmd.formatMarkdown(content, &agent.OrchestrationResult{Status: agent.StatusSuccess})
+	assert.NotContains(t, formatted, "owners:")
+	assert.NotContains(t, formatted, "Suggested Reviewers")
+}
@@ -0,0 +1,268 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// hunkHeader matches the "@@ -12,3 +12,4 @@" line starting a hunk
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// patchRequirement asks for a review of the change rather than the files
+const patchRequirement = "The files are unified diff hunks of a patch, not whole files: " +
+	"review the change, mainly the added (+) lines, and report each finding at its line " +
+	"in the file after the change, as numbered by the @@ headers"
+
+// patchFile is what a patch changes in one file
+type patchFile struct {
+	Path    string // Relative to the repository root
+	Deleted bool
+	Hunks   []patchHunk
+}
+
+// patchHunk is one hunk of a patch
+type patchHunk struct {
+	NewStart int
+	Lines    []string // The @@ header, then the hunk's lines
+}
+
+// parsePatch reads the files a unified diff changes, with their hunks. It
+// accepts git diffs, emailed patches and plain diff -u output; text around
+// the diffs, such as mail headers and commit messages, is ignored.
+func parsePatch(content string) ([]patchFile, error) {
+	var files []patchFile
+	index := make(map[string]int)
+	var oldPath, newPath string
+	deleted := false
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			oldPath, newPath, deleted = "", diffFileName(line), false
+			continue
+		case strings.HasPrefix(line, "--- "):
+			oldPath = patchPath(strings.TrimPrefix(line, "--- "))
+			continue
+		case strings.HasPrefix(line, "+++ "):
+			if path := patchPath(strings.TrimPrefix(line, "+++ ")); path != "" {
+				newPath, deleted = path, false
+			} else {
+				deleted = true
+			}
+			continue
+		}
+
+		match := hunkHeader.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		path := newPath
+		if deleted || path == "" {
+			path = oldPath
+		}
+		if path == "" {
+			return nil, errors.ValidationError("parsePatch", fmt.Sprintf("hunk without a file name at line %d of the patch", i+1))
+		}
+
+		oldLines, newLines := hunkCount(match[2]), hunkCount(match[4])
+		newStart, _ := strconv.Atoi(match[3])
+		hunk := patchHunk{NewStart: newStart, Lines: []string{line}}
+		for oldLines > 0 || newLines > 0 {
+			i++
+			if i >= len(lines) {
+				return nil, errors.ValidationError("parsePatch", fmt.Sprintf("the patch ends in the middle of a hunk of %s", path)).
+					WithHint("check the patch was not truncated or reformatted, e.g. by a mail client")
+			}
+			body := lines[i]
+			switch {
+			case strings.HasPrefix(body, "+"):
+				newLines--
+			case strings.HasPrefix(body, "-"):
+				oldLines--
+			case strings.HasPrefix(body, " "), body == "":
+				oldLines--
+				newLines--
+			case strings.HasPrefix(body, `\`):
+				// "\ No newline at end of file"
+			default:
+				return nil, errors.ValidationError("parsePatch", fmt.Sprintf("unexpected line %d in a hunk of %s: %q", i+1, path, body))
+			}
+			hunk.Lines = append(hunk.Lines, body)
+		}
+		// A marker may follow the hunk's last line
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], `\`) {
+			i++
+			hunk.Lines = append(hunk.Lines, lines[i])
+		}
+
+		at, ok := index[path]
+		if !ok {
+			at = len(files)
+			index[path] = at
+			files = append(files, patchFile{Path: path})
+		}
+		files[at].Deleted = deleted
+		files[at].Hunks = append(files[at].Hunks, hunk)
+	}
+
+	if len(files) == 0 {
+		return nil, errors.ValidationError("parsePatch", "the patch changes no files").
+			WithHint("give a unified diff, such as the output of git diff or git format-patch")
+	}
+	return files, nil
+}
+
+// hunkCount reads the line count of a hunk range, which is 1 when omitted
+func hunkCount(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
+
+// patchPath reads the file name of a "---" or "+++" line: without the a/
+// or b/ prefix and any timestamp, and empty for /dev/null
+func patchPath(name string) string {
+	name, _, _ = strings.Cut(name, "\t")
+	name = strings.TrimSpace(name)
+	if name == "/dev/null" {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(name, "a/"); ok {
+		return rest
+	}
+	if rest, ok := strings.CutPrefix(name, "b/"); ok {
+		return rest
+	}
+	return name
+}
+
+// Diff returns the file's hunks as a diff
+func (f patchFile) Diff() string {
+	var diff strings.Builder
+	for _, hunk := range f.Hunks {
+		diff.WriteString(strings.Join(hunk.Lines, "\n"))
+		diff.WriteString("\n")
+	}
+	return diff.String()
+}
+
+// Changed returns the file after the change as far as the patch shows it:
+// the lines of its hunks at their line numbers, and blank lines elsewhere.
+// Findings, suppressions and evidence are checked against it.
+func (f patchFile) Changed() string {
+	var lines []string
+	for _, hunk := range f.Hunks {
+		number := hunk.NewStart
+		for _, line := range hunk.Lines[1:] {
+			if strings.HasPrefix(line, "-") || strings.HasPrefix(line, `\`) {
+				continue
+			}
+			for len(lines) < number {
+				lines = append(lines, "")
+			}
+			if len(line) > 0 {
+				line = line[1:]
+			}
+			lines[number-1] = line
+			number++
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// loadPatch reads the patch to review from --patch, or from in for "-",
+// and reviews the files it changes
+func (c *ReviewCommand) loadPatch(in io.Reader) error {
+	var data []byte
+	var err error
+	if c.Patch == "-" {
+		data, err = io.ReadAll(in)
+	} else {
+		data, err = os.ReadFile(c.Patch)
+	}
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInput, "loadPatch", "failed to read patch")
+	}
+
+	files, err := parsePatch(string(data))
+	if err != nil {
+		return err
+	}
+	c.patch = make(map[string]patchFile, len(files))
+	c.Files = make([]string, 0, len(files))
+	for _, file := range files {
+		if file.Deleted {
+			logger.Debug("skipping file the patch deletes", "path", file.Path)
+			continue
+		}
+		path := c.displayPath(file.Path)
+		c.patch[path] = file
+		c.Files = append(c.Files, path)
+	}
+	if len(c.Files) == 0 {
+		return errors.ValidationError("loadPatch", "the patch only deletes files, so there is nothing to review")
+	}
+	return nil
+}
+
+// displayPath returns a repository path relative to the working directory,
+// as files given on the command line are
+func (c *ReviewCommand) displayPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil || c.root == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(cwd, filepath.Join(c.root, path)); err == nil {
+		return rel
+	}
+	return path
+}
+
+// patchContexts returns the files of the task reviewing the patch: each
+// file's hunks and, with --base, the file before the change
+func (c *ReviewCommand) patchContexts() []agent.FileContext {
+	repo := &git.Repository{Path: c.root}
+	contexts := make([]agent.FileContext, 0, len(c.Files))
+	for _, path := range c.Files {
+		file := c.patch[path]
+		contexts = append(contexts, agent.FileContext{
+			Path:     path,
+			Content:  file.Diff(),
+			Language: c.detectLanguage(path),
+			Purpose:  "Patch to review, as unified diff hunks",
+			IsTarget: true,
+		})
+		if c.Base == "" {
+			continue
+		}
+
+		base, err := repo.ShowFile(c.Base, file.Path)
+		if err != nil {
+			// New files have no base
+			logger.Debug("no base version of patched file", "path", file.Path, "base", c.Base, "error", err)
+			continue
+		}
+		contexts = append(contexts, agent.FileContext{
+			Path:        path,
+			Content:     base,
+			Language:    c.detectLanguage(path),
+			Purpose:     fmt.Sprintf("The file before the change, at %s", c.Base),
+			IsReference: true,
+		})
+	}
+	return contexts
+}
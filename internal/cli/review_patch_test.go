@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gitPatch = `diff --git a/auth/login.go b/auth/login.go
+index 1111111..2222222 100644
+--- a/auth/login.go
++++ b/auth/login.go
+@@ -10,3 +10,4 @@ func Login(user string) error {
+ 	if user == "" {
+-		return nil
++		log.Printf("empty user")
++		return errEmpty
+ 	}
+@@ -40,2 +41,2 @@ func Logout() {
+-	clear()
++	clearSession()
+ }
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package old
+-
+`
+
+func TestParsePatch(t *testing.T) {
+	t.Run("git diff", func(t *testing.T) {
+		files, err := parsePatch(gitPatch)
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+
+		login := files[0]
+		assert.Equal(t, "auth/login.go", login.Path)
+		assert.False(t, login.Deleted)
+		require.Len(t, login.Hunks, 2)
+		assert.Equal(t, 10, login.Hunks[0].NewStart)
+		assert.Equal(t, 41, login.Hunks[1].NewStart)
+		assert.True(t, strings.HasPrefix(login.Diff(), "@@ -10,3 +10,4 @@"))
+
+		assert.Equal(t, "old.go", files[1].Path)
+		assert.True(t, files[1].Deleted)
+	})
+
+	t.Run("emailed patch", func(t *testing.T) {
+		email := `From 3f2a Mon Sep 17 00:00:00 2001
+From: Dev <dev@example.com>
+Subject: [PATCH] Greet by name
+
+---
+ greet.go | 2 +-
+ 1 file changed, 1 insertion(+), 1 deletion(-)
+
+diff --git a/greet.go b/greet.go
+--- a/greet.go
++++ b/greet.go
+@@ -1,3 +1,3 @@
+ package greet
+
+-func Hello() string { return "hi" }
++func Hello(name string) string { return "hi " + name }
+--
+2.43.0
+`
+		files, err := parsePatch(email)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		assert.Equal(t, "greet.go", files[0].Path)
+		require.Len(t, files[0].Hunks, 1)
+		assert.Len(t, files[0].Hunks[0].Lines, 5, "the mail signature is not part of the hunk")
+	})
+
+	t.Run("plain diff -u", func(t *testing.T) {
+		plain := "--- main.c\t2026-10-01 12:00:00\n+++ main.c\t2026-10-02 12:00:00\n@@ -1 +1 @@\n-int x;\n+long x;\n\\ No newline at end of file\n"
+		files, err := parsePatch(plain)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		assert.Equal(t, "main.c", files[0].Path)
+		assert.Equal(t, "long x;", files[0].Changed())
+	})
+
+	t.Run("truncated hunk", func(t *testing.T) {
+		_, err := parsePatch("--- a/x.go\n+++ b/x.go\n@@ -1,3 +1,3 @@\n package x\n")
+		assert.ErrorContains(t, err, "ends in the middle of a hunk of x.go")
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		_, err := parsePatch("just some text\n")
+		assert.ErrorContains(t, err, "the patch changes no files")
+	})
+}
+
+func TestPatchFile_Changed(t *testing.T) {
+	files, err := parsePatch(gitPatch)
+	require.NoError(t, err)
+
+	lines := strings.Split(files[0].Changed(), "\n")
+	require.Len(t, lines, 42)
+	assert.Equal(t, "\tif user == \"\" {", lines[9])
+	assert.Equal(t, "\t\tlog.Printf(\"empty user\")", lines[10])
+	assert.Equal(t, "\t\treturn errEmpty", lines[11])
+	assert.Equal(t, "", lines[20], "lines outside the hunks are blank")
+	assert.Equal(t, "\tclearSession()", lines[40])
+}
+
+func TestReviewCommand_createReviewTask_Patch(t *testing.T) {
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "auth"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "auth", "login.go"), []byte("package auth\n"), 0644))
+	run("add", ".")
+	run("commit", "-qm", "base")
+
+	patchFile := filepath.Join(dir, "change.patch")
+	require.NoError(t, os.WriteFile(patchFile, []byte(gitPatch), 0644))
+	previous, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { _ = os.Chdir(previous) }()
+
+	cmd := NewReviewCommand()
+	cmd.Patch = "change.patch"
+	cmd.Base = "HEAD"
+	cmd.root = dir
+	require.NoError(t, cmd.loadPatch(strings.NewReader("")))
+	assert.Equal(t, []string{filepath.Join("auth", "login.go")}, cmd.Files, "deleted files are not reviewed")
+
+	task, err := cmd.createReviewTask()
+	require.NoError(t, err)
+	require.Len(t, task.Context.Files, 2)
+	target, base := task.Context.Files[0], task.Context.Files[1]
+	assert.True(t, target.IsTarget)
+	assert.Contains(t, target.Content, "+\t\treturn errEmpty")
+	assert.True(t, base.IsReference)
+	assert.Equal(t, "package auth\n", base.Content)
+	assert.Contains(t, task.Context.Requirements, patchRequirement)
+
+	source, err := cmd.readFile(cmd.Files[0])
+	require.NoError(t, err)
+	assert.Contains(t, source, "return errEmpty", "findings are checked against the changed file")
+}
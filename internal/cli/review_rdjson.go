@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"encoding/json"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// FormatRDJSON is reviewdog's diagnostic JSON format
+const FormatRDJSON = "rdjson"
+
+// rdjsonResult is a reviewdog diagnostic result
+type rdjsonResult struct {
+	Source      rdjsonSource       `json:"source"`
+	Diagnostics []rdjsonDiagnostic `json:"diagnostics"`
+}
+
+// rdjsonSource names the tool that produced the diagnostics
+type rdjsonSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// rdjsonDiagnostic is a single reviewdog diagnostic
+type rdjsonDiagnostic struct {
+	Message  string         `json:"message"`
+	Location rdjsonLocation `json:"location"`
+	Severity string         `json:"severity,omitempty"`
+	Code     *rdjsonCode    `json:"code,omitempty"`
+}
+
+// rdjsonLocation is a file and an optional line range
+type rdjsonLocation struct {
+	Path  string       `json:"path"`
+	Range *rdjsonRange `json:"range,omitempty"`
+}
+
+// rdjsonRange is a range of lines
+type rdjsonRange struct {
+	Start rdjsonPosition  `json:"start"`
+	End   *rdjsonPosition `json:"end,omitempty"`
+}
+
+// rdjsonPosition is a 1-based line
+type rdjsonPosition struct {
+	Line int `json:"line"`
+}
+
+// rdjsonCode identifies the kind of finding
+type rdjsonCode struct {
+	Value string `json:"value"`
+}
+
+// formatRDJSON formats the review findings as reviewdog diagnostic JSON
+func (c *ReviewCommand) formatRDJSON(content string, result *agent.OrchestrationResult) (string, error) {
+	output := rdjsonResult{
+		Source:      rdjsonSource{Name: "sigil", URL: "https://github.com/dshills/sigil"},
+		Diagnostics: []rdjsonDiagnostic{},
+	}
+
+	for _, finding := range c.reviewFindings(content, result) {
+		message := finding.Message
+		if finding.Suggestion != "" {
+			message += "\n\nSuggestion: " + finding.Suggestion
+		}
+
+		diagnostic := rdjsonDiagnostic{
+			Message:  message,
+			Location: rdjsonLocation{Path: finding.Path},
+			Severity: rdjsonSeverity(finding.Severity),
+		}
+		if finding.Line > 0 {
+			diagnostic.Location.Range = &rdjsonRange{Start: rdjsonPosition{Line: finding.Line}}
+			if finding.EndLine > finding.Line {
+				diagnostic.Location.Range.End = &rdjsonPosition{Line: finding.EndLine}
+			}
+		}
+		if finding.Type != "" {
+			diagnostic.Code = &rdjsonCode{Value: string(finding.Type)}
+		}
+		output.Diagnostics = append(output.Diagnostics, diagnostic)
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeOutput, "formatRDJSON", "failed to marshal diagnostics")
+	}
+	return string(data) + "\n", nil
+}
+
+// rdjsonSeverity maps a finding severity to reviewdog's
+func rdjsonSeverity(severity agent.Severity) string {
+	switch severity {
+	case agent.SeverityCritical, agent.SeverityError:
+		return "ERROR"
+	case agent.SeverityWarning:
+		return "WARNING"
+	case agent.SeverityInfo:
+		return "INFO"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rdjsonReview = "## Findings\n\nThe handler leaks a file descriptor.\n\n" +
+	"```json\n[\n" +
+	`  {"path": "server.go", "line": 12, "end_line": 14, "severity": "error", "type": "logic", "message": "File is never closed", "suggestion": "defer f.Close()"},` + "\n" +
+	`  {"line": 3, "severity": "info", "type": "style", "message": "Unused import"},` + "\n" +
+	`  {"path": "util.go", "severity": "warning", "message": "Exported function lacks a comment"}` + "\n" +
+	"]\n```\n"
+
+func TestReviewCommand_formatRDJSON(t *testing.T) {
+	cmd := NewReviewCommand()
+	cmd.Files = []string{"server.go"}
+	cmd.Severity = "all"
+	result := &agent.OrchestrationResult{
+		Status: agent.StatusSuccess,
+		Consensus: &agent.ConsensusResult{Reviews: []agent.ReviewResult{{Comments: []agent.ReviewComment{
+			{Type: agent.CommentTypeSecurity, Severity: agent.SeverityCritical, Path: "auth.go", Line: 7, Message: "Token logged"},
+			{Type: agent.CommentTypeGeneral, Message: "Review completed"},
+		}}}},
+	}
+
+	formatted, err := cmd.formatRDJSON(rdjsonReview, result)
+	require.NoError(t, err)
+
+	var parsed rdjsonResult
+	require.NoError(t, json.Unmarshal([]byte(formatted), &parsed))
+	assert.Equal(t, "sigil", parsed.Source.Name)
+	require.Len(t, parsed.Diagnostics, 4, "the unlocated consensus comment is dropped")
+
+	first := parsed.Diagnostics[0]
+	assert.Equal(t, "File is never closed\n\nSuggestion: defer f.Close()", first.Message)
+	assert.Equal(t, "ERROR", first.Severity)
+	assert.Equal(t, "logic", first.Code.Value)
+	assert.Equal(t, "server.go", first.Location.Path)
+	assert.Equal(t, 12, first.Location.Range.Start.Line)
+	assert.Equal(t, 14, first.Location.Range.End.Line)
+
+	assert.Equal(t, "server.go", parsed.Diagnostics[1].Location.Path, "a single reviewed file locates pathless findings")
+	assert.Equal(t, "INFO", parsed.Diagnostics[1].Severity)
+	assert.Nil(t, parsed.Diagnostics[2].Location.Range, "findings without a line annotate the file")
+	assert.Equal(t, "auth.go", parsed.Diagnostics[3].Location.Path)
+	assert.Equal(t, "ERROR", parsed.Diagnostics[3].Severity)
+}
+
+func TestReviewCommand_formatRDJSON_SeverityFilter(t *testing.T) {
+	cmd := NewReviewCommand()
+	cmd.Files = []string{"server.go", "util.go"}
+	cmd.Severity = "warning"
+
+	formatted, err := cmd.formatRDJSON(rdjsonReview, &agent.OrchestrationResult{})
+	require.NoError(t, err)
+
+	var parsed rdjsonResult
+	require.NoError(t, json.Unmarshal([]byte(formatted), &parsed))
+	require.Len(t, parsed.Diagnostics, 2)
+	assert.Equal(t, "server.go", parsed.Diagnostics[0].Location.Path)
+	assert.Equal(t, "util.go", parsed.Diagnostics[1].Location.Path)
+	assert.Equal(t, "WARNING", parsed.Diagnostics[1].Severity)
+}
+
+func TestReviewCommand_createReviewTask_RDJSON(t *testing.T) {
+	cmd := NewReviewCommand()
+	cmd.Format = FormatRDJSON
+
+	task, err := cmd.createReviewTask()
+	require.NoError(t, err)
+
+	assert.Contains(t, task.Context.Requirements, findingsRequirement)
+	assert.Contains(t, task.Context.Requirements, "Format the review as markdown")
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// suppressionComment matches "sigil:ignore <rule> [reason]" in a line or
+// block comment of any common style
+var suppressionComment = regexp.MustCompile(`(?://|#|--|/\*|<!--|^\s*\*)\s*sigil:ignore\s+(\S+)(?:\s+(.*?))?\s*(?:\*/|-->)?\s*$`)
+
+// commentOnly matches lines holding nothing but a comment
+var commentOnly = regexp.MustCompile(`^\s*(?://|#|--|/\*|<!--|\*)`)
+
+// suppression is an inline "sigil:ignore" comment. A comment on its own
+// line applies to the next line; a trailing comment applies to its own.
+type suppression struct {
+	Path       string `json:"path"`
+	Line       int    `json:"line"`
+	Target     int    `json:"target"`
+	Rule       string `json:"rule"`
+	Reason     string `json:"reason,omitempty"`
+	Suppressed int    `json:"suppressed"`
+}
+
+// parseSuppressions finds the suppression comments in a file
+func parseSuppressions(path, content string) []suppression {
+	var suppressions []suppression
+	for i, line := range strings.Split(content, "\n") {
+		match := suppressionComment.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		target := i + 1
+		if commentOnly.MatchString(line) {
+			target = i + 2
+		}
+		suppressions = append(suppressions, suppression{
+			Path:   filepath.ToSlash(filepath.Clean(path)),
+			Line:   i + 1,
+			Target: target,
+			Rule:   match[1],
+			Reason: strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(match[2]), "--")),
+		})
+	}
+	return suppressions
+}
+
+// matches reports whether the suppression covers a finding. The rule is
+// "*", the finding's type or focus area, or one of its references, such
+// as an analyzer rule ID.
+func (s suppression) matches(finding agent.ReviewComment) bool {
+	if filepath.ToSlash(filepath.Clean(finding.Path)) != s.Path || finding.Line == 0 {
+		return false
+	}
+	if s.Target != finding.Line && (s.Target < finding.Line || s.Target > finding.EndLine) {
+		return false
+	}
+
+	rule := strings.ToLower(s.Rule)
+	if rule == "*" || rule == strings.ToLower(string(finding.Type)) || rule == string(agent.FocusForComment(finding.Type)) {
+		return true
+	}
+	for _, reference := range finding.References {
+		if rule == strings.ToLower(reference) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileSuppressions returns the suppressions in a file
+func (c *ReviewCommand) fileSuppressions(path string) []suppression {
+	return parseSuppressions(path, c.source(path))
+}
+
+// applySuppressions splits findings into those reported and the
+// suppressions in effect, counting the findings each one suppressed
+func (c *ReviewCommand) applySuppressions(findings []agent.ReviewComment) ([]agent.ReviewComment, []suppression) {
+	var active []suppression
+	seen := make(map[string]bool)
+	addFile := func(path string) {
+		key := filepath.ToSlash(filepath.Clean(path))
+		if !seen[key] {
+			seen[key] = true
+			active = append(active, c.fileSuppressions(path)...)
+		}
+	}
+	for _, path := range c.Files {
+		addFile(path)
+	}
+	for _, finding := range findings {
+		addFile(finding.Path)
+	}
+
+	var kept []agent.ReviewComment
+	for _, finding := range findings {
+		suppressed := false
+		for i := range active {
+			if active[i].matches(finding) {
+				active[i].Suppressed++
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			kept = append(kept, finding)
+		}
+	}
+	return kept, active
+}
+
+// checkSuppressionReasons fails when a suppression in the reviewed files
+// does not say why the finding is accepted
+func (c *ReviewCommand) checkSuppressionReasons() error {
+	var missing []string
+	for _, path := range c.Files {
+		for _, s := range c.fileSuppressions(path) {
+			if s.Reason == "" {
+				missing = append(missing, fmt.Sprintf("%s:%d", s.Path, s.Line))
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return errors.ValidationError("checkSuppressionReasons",
+		fmt.Sprintf("%d suppressions lack a reason: %s", len(missing), strings.Join(missing, ", "))).
+		WithHint("add a reason after the rule, e.g. // sigil:ignore security input is validated by the router")
+}
+
+// suppressionReason returns the reason or a placeholder for reports
+func suppressionReason(s suppression) string {
+	if s.Reason == "" {
+		return "(no reason given)"
+	}
+	return s.Reason
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+)
+
+const suppressedSource = `package store
+
+// sigil:ignore security -- queries are built from constants only
+func query(table string) string {
+	return "SELECT * FROM " + table // sigil:ignore errcheck
+}
+
+/* sigil:ignore * generated code */
+var generated = 1
+`
+
+func TestParseSuppressions(t *testing.T) {
+	suppressions := parseSuppressions("./store/query.go", suppressedSource)
+
+	require.Len(t, suppressions, 3)
+	assert.Equal(t, suppression{Path: "store/query.go", Line: 3, Target: 4, Rule: "security", Reason: "queries are built from constants only"}, suppressions[0])
+	assert.Equal(t, 5, suppressions[1].Target, "a trailing comment applies to its own line")
+	assert.Empty(t, suppressions[1].Reason)
+	assert.Equal(t, "generated code", suppressions[2].Reason, "the block comment end is not part of the reason")
+
+	hash := parseSuppressions("app.py", "x = eval(data)  # sigil:ignore S307 trusted input\n")
+	require.Len(t, hash, 1)
+	assert.Equal(t, "S307", hash[0].Rule)
+
+	assert.Empty(t, parseSuppressions("doc.go", `fmt.Println("sigil:ignore security")`), "strings are not comments")
+}
+
+func TestSuppression_matches(t *testing.T) {
+	s := suppression{Path: "store/query.go", Target: 4, Rule: "security"}
+
+	assert.True(t, s.matches(agent.ReviewComment{Type: agent.CommentTypeSecurity, Path: "store/query.go", Line: 4}))
+	assert.True(t, s.matches(agent.ReviewComment{Type: agent.CommentTypeSecurity, Path: "store/query.go", Line: 2, EndLine: 6}), "ranges covering the line match")
+	assert.False(t, s.matches(agent.ReviewComment{Type: agent.CommentTypeStyle, Path: "store/query.go", Line: 4}))
+	assert.False(t, s.matches(agent.ReviewComment{Type: agent.CommentTypeSecurity, Path: "store/query.go", Line: 5}))
+	assert.False(t, s.matches(agent.ReviewComment{Type: agent.CommentTypeSecurity, Path: "other.go", Line: 4}))
+
+	byRule := suppression{Path: "a.go", Target: 1, Rule: "errcheck"}
+	assert.True(t, byRule.matches(agent.ReviewComment{Type: agent.CommentTypeErrorHandling, Path: "a.go", Line: 1, References: []string{"golangci-lint", "errcheck"}}))
+
+	byArea := suppression{Path: "a.go", Target: 1, Rule: "error-handling"}
+	assert.True(t, byArea.matches(agent.ReviewComment{Type: agent.CommentTypeErrorHandling, Path: "a.go", Line: 1}), "focus area names match")
+}
+
+func TestReviewCommand_applySuppressions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "query.go")
+	require.NoError(t, os.WriteFile(path, []byte(suppressedSource), 0644))
+
+	cmd := NewReviewCommand()
+	cmd.Files = []string{path}
+	findings := []agent.ReviewComment{
+		{Type: agent.CommentTypeSecurity, Path: path, Line: 4, Message: "SQL injection"},
+		{Type: agent.CommentTypeSecurity, Path: path, Line: 5, Message: "SQL injection"},
+		{Type: agent.CommentTypeStyle, Path: path, Line: 9, Message: "unused"},
+	}
+
+	kept, suppressions := cmd.applySuppressions(findings)
+
+	require.Len(t, kept, 1)
+	assert.Equal(t, 5, kept[0].Line)
+	require.Len(t, suppressions, 3)
+	assert.Equal(t, []int{1, 0, 1}, []int{suppressions[0].Suppressed, suppressions[1].Suppressed, suppressions[2].Suppressed})
+
+	_, again := cmd.applySuppressions(findings)
+	assert.Equal(t, 1, again[0].Suppressed, "counts do not accumulate across reports")
+}
+
+func TestReviewCommand_checkSuppressionReasons(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "query.go")
+	require.NoError(t, os.WriteFile(path, []byte(suppressedSource), 0644))
+
+	cmd := NewReviewCommand()
+	cmd.Files = []string{path}
+
+	err := cmd.checkSuppressionReasons()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 suppressions lack a reason")
+	assert.Contains(t, err.Error(), "query.go:5")
+
+	cmd = NewReviewCommand()
+	cmd.Files = []string{filepath.Join(dir, "missing.go")}
+	assert.NoError(t, cmd.checkSuppressionReasons())
+}
+
+func TestReviewCommand_formatMarkdownSuppressions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "query.go")
+	require.NoError(t, os.WriteFile(path, []byte(suppressedSource), 0644))
+
+	cmd := NewReviewCommand()
+	cmd.Files = []string{path}
+	content := "Review.\n\n```json\n[{\"path\": \"" + filepath.ToSlash(path) + "\", \"line\": 4, \"severity\": \"error\", \"type\": \"security\", \"message\": \"SQL injection\"}]\n```\n"
+
+	report := cmd.formatMarkdown(content, &agent.OrchestrationResult{Status: agent.StatusSuccess})
+
+	assert.NotContains(t, report, "## Findings by Area", "the only finding is suppressed")
+	assert.Contains(t, report, "## Suppressions")
+	assert.Contains(t, report, "| security | queries are built from constants only | 1 |")
+	assert.Contains(t, report, "| errcheck | (no reason given) | 0 |")
+}
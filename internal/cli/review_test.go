@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -39,6 +40,8 @@ func TestReviewCommand_CreateCobraCommand(t *testing.T) {
 	assert.NotNil(t, cobraCmd.Flags().Lookup("check-performance"))
 	assert.NotNil(t, cobraCmd.Flags().Lookup("check-style"))
 	assert.NotNil(t, cobraCmd.Flags().Lookup("auto-fix"))
+	assert.NotNil(t, cobraCmd.Flags().Lookup("prompt"))
+	assert.NotNil(t, cobraCmd.Flags().Lookup("prompt-arg"))
 }
 
 func TestReviewCommand_validateInputs(t *testing.T) {
@@ -235,6 +238,17 @@ func TestReviewCommand_createReviewTask(t *testing.T) {
 				assert.False(t, task.Context.Files[0].IsReference)
 			},
 		},
+		{
+			name: "with prompt preset",
+			setup: func(c *ReviewCommand) {
+				c.Files = []string{testFile}
+				c.presetText = "Check error wrapping"
+			},
+			wantErr: false,
+			check: func(t *testing.T, task *agent.Task) {
+				assert.Contains(t, task.Context.Requirements, "Follow these review instructions:\nCheck error wrapping")
+			},
+		},
 		{
 			name: "with focus areas",
 			setup: func(c *ReviewCommand) {
@@ -253,6 +267,20 @@ func TestReviewCommand_createReviewTask(t *testing.T) {
 				assert.True(t, found, "Focus requirement not found")
 			},
 		},
+		{
+			name: "with focus aliases",
+			setup: func(c *ReviewCommand) {
+				c.Files = []string{testFile}
+				c.Focus = []string{"perf", "error_handling"}
+			},
+			wantErr: false,
+			check: func(t *testing.T, task *agent.Task) {
+				assert.Contains(t, task.Context.Requirements, "Focus specifically on: performance, error-handling")
+				require.Len(t, task.Constraints, 2)
+				assert.Equal(t, agent.ConstraintTypePerformance, task.Constraints[0].Type)
+				assert.Equal(t, agent.ConstraintTypeErrorHandling, task.Constraints[1].Type)
+			},
+		},
 		{
 			name: "with include tests",
 			setup: func(c *ReviewCommand) {
@@ -357,6 +385,36 @@ func TestReviewCommand_createReviewTask(t *testing.T) {
 	}
 }
 
+func TestReviewCommand_createReviewTask_Unreadable(t *testing.T) {
+	tmpDir := t.TempDir()
+	readable := filepath.Join(tmpDir, "main.go")
+	require.NoError(t, os.WriteFile(readable, []byte("package main\n"), 0644))
+	unreadable := filepath.Join(tmpDir, "dir.go")
+	require.NoError(t, os.Mkdir(unreadable, 0755))
+
+	cmd := NewReviewCommand()
+	cmd.Files = []string{unreadable, readable}
+	task, err := cmd.createReviewTask()
+	require.NoError(t, err)
+	require.Len(t, task.Context.Files, 1)
+	assert.Equal(t, readable, task.Context.Files[0].Path)
+	assert.Equal(t, []string{readable}, cmd.Files, "the report lists the files reviewed")
+	require.Len(t, cmd.failures.failures, 1)
+	assert.Equal(t, unreadable, cmd.failures.failures[0].Path)
+
+	report := cmd.formatMarkdown("Looks fine.", &agent.OrchestrationResult{Status: agent.StatusSuccess})
+	assert.Contains(t, report, "## Errors\n\n- `"+unreadable+"`: ")
+	assert.Contains(t, cmd.formatText("Looks fine.", &agent.OrchestrationResult{Status: agent.StatusSuccess}), "Errors:\n-------\n  "+unreadable+": ")
+	assert.Contains(t, cmd.formatXML("Looks fine.", &agent.OrchestrationResult{Status: agent.StatusSuccess}), `<error path="`+unreadable+`">`)
+	assert.ErrorContains(t, cmd.failures.err("Execute", "review", 2), "failed to review 1 of 2 files")
+
+	cmd = NewReviewCommand()
+	cmd.Files = []string{unreadable, readable}
+	cmd.failures = fileFailures{failFast: true}
+	_, err = cmd.createReviewTask()
+	assert.ErrorContains(t, err, "failed to read file", "--fail-fast stops at the first failure")
+}
+
 func TestReviewCommand_formatMarkdown(t *testing.T) {
 	cmd := NewReviewCommand()
 	cmd.Files = []string{"test.go", "main.go"}
@@ -696,9 +754,11 @@ func TestReviewCommand_outputResult(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			cmd := NewReviewCommand()
 			cmd.Format = tt.format
-			cmd.OutputFile = tt.outputFile
+			if tt.outputFile != "" {
+				cmd.Outputs = []string{tt.outputFile}
+			}
 
-			err := cmd.outputResult(tt.result)
+			err := cmd.outputResult(context.Background(), tt.result)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -834,6 +894,12 @@ func TestReviewCommand_formatOutput(t *testing.T) {
 			wantErr:  false,
 			contains: []string{"sarif-2.1.0", "Sigil Code Review"},
 		},
+		{
+			format:   "rdjson",
+			content:  "test content",
+			wantErr:  false,
+			contains: []string{`"name": "sigil"`, `"diagnostics": []`},
+		},
 		{
 			format:   "unknown",
 			content:  "test content",
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+// fixFiles returns the changes of the fix proposals as sandbox file
+// changes, the way applyProposal writes them
+func fixFiles(proposals []agent.Proposal) []sandbox.FileChange {
+	var files []sandbox.FileChange
+	for _, proposal := range proposals {
+		for _, change := range proposal.Changes {
+			switch change.Type {
+			case agent.ChangeTypeUpdate:
+				files = append(files, sandbox.FileChange{Path: change.Path, Content: change.NewContent, Operation: sandbox.OperationUpdate})
+			case agent.ChangeTypeCreate:
+				files = append(files, sandbox.FileChange{Path: change.Path, Content: change.NewContent, Operation: sandbox.OperationCreate})
+			case agent.ChangeTypeDelete:
+				files = append(files, sandbox.FileChange{Path: change.Path, Operation: sandbox.OperationDelete})
+			}
+		}
+	}
+	return files
+}
+
+// reportTranscript writes the sandbox transcript of the review to the
+// transcript file and, with --verbose, to stderr
+func (c *ReviewCommand) reportTranscript(transcript *sandbox.Transcript) {
+	executions := transcript.Executions()
+	if c.TranscriptFile != "" {
+		if err := writeTranscript(c.TranscriptFile, executions); err != nil {
+			logger.Warn("failed to write sandbox transcript", "error", err)
+		}
+	}
+	if Verbose() && len(executions) > 0 {
+		fmt.Fprint(os.Stderr, formatTranscriptText(executions))
+	}
+}
+
+// validateFixes runs the project's validation steps over the fixes in a
+// sandbox and fails when a required step does not pass, so fixes that
+// break the build or tests are never applied
+func (c *ReviewCommand) validateFixes(ctx context.Context, proposals []agent.Proposal, gitRepo *git.Repository) error {
+	manager, err := sandbox.NewManager(gitRepo)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeConfig, "validateFixes", "failed to create sandbox manager")
+	}
+	defer func() {
+		if err := manager.Cleanup(); err != nil {
+			logger.Warn("failed to cleanup sandbox manager", "error", err)
+		}
+	}()
+
+	steps := manager.ValidationSteps()
+	if len(steps) == 0 {
+		logger.Info("no validation profile for this project, applying fixes unvalidated")
+		return nil
+	}
+
+	results, err := sandbox.RunValidation(ctx, manager, fmt.Sprintf("review_autofix_%d", c.startTime.Unix()), fixFiles(proposals), steps)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "validateFixes", "failed to validate auto-fixes")
+	}
+	if !sandbox.ValidationPassed(results) {
+		return errors.ValidationError("validateFixes",
+			fmt.Sprintf("auto-fixes failed validation: %s", strings.Join(sandbox.FailedSteps(results), ", "))).
+			WithHint("the fixes were not applied; apply them by hand from the review")
+	}
+
+	logger.Info("auto-fixes passed validation", "steps", len(steps))
+	return nil
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+func TestFixFiles(t *testing.T) {
+	proposals := []agent.Proposal{
+		{Changes: []agent.Change{
+			{Type: agent.ChangeTypeUpdate, Path: "main.go", NewContent: "package main"},
+			{Type: agent.ChangeTypeDelete, Path: "old.go"},
+		}},
+		{Changes: []agent.Change{{Type: agent.ChangeTypeCreate, Path: "new.go", NewContent: "package main"}}},
+	}
+
+	files := fixFiles(proposals)
+	require.Len(t, files, 3)
+	assert.Equal(t, sandbox.FileChange{Path: "main.go", Content: "package main", Operation: sandbox.OperationUpdate}, files[0])
+	assert.Equal(t, sandbox.OperationDelete, files[1].Operation)
+	assert.Equal(t, sandbox.OperationCreate, files[2].Operation)
+	assert.Empty(t, fixFiles(nil))
+}
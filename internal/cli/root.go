@@ -1,25 +1,36 @@
 package cli
 
 import (
+	stderrors "errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/deterministic"
+	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/memory"
 	"github.com/dshills/sigil/internal/model"
 	"github.com/dshills/sigil/internal/model/providers/anthropic"
 	"github.com/dshills/sigil/internal/model/providers/mcp"
 	"github.com/dshills/sigil/internal/model/providers/ollama"
 	"github.com/dshills/sigil/internal/model/providers/openai"
+	"github.com/dshills/sigil/internal/trust"
+	"github.com/dshills/sigil/internal/version"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// Global flags
-	verboseFlag bool
-	jsonFlag    bool
-	configFile  string
+	verboseFlag       bool
+	jsonFlag          bool
+	configFile        string
+	answersFile       string
+	untrustedFlag     bool
+	deterministicFlag bool
+	colorFlag         string
 
 	// Root command
 	rootCmd = &cobra.Command{
@@ -30,13 +41,27 @@ transformation, explanation, review, and generation using local or remote LLMs.
 
 It supports multiple LLM backends, sandboxed validation, fully autonomous execution,
 memory persistence via Markdown files, and integration with MCP servers.`,
-		Version: "0.1.0",
+		Version: version.Get().Version,
+		// Errors are rendered by the caller with codes and hints, and
+		// usage is left to --help so it does not bury runtime failures
+		SilenceErrors: true,
+		SilenceUsage:  true,
 	}
 )
 
-// Execute runs the CLI
+// Execute runs the CLI, recording the run when the user opted in to
+// telemetry
 func Execute() error {
-	return rootCmd.Execute()
+	start := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	err = asUsageError(cmd, err)
+	recordTelemetry(cmd, start, err)
+	return err
+}
+
+// Verbose reports whether --verbose was given
+func Verbose() bool {
+	return verboseFlag
 }
 
 func init() {
@@ -46,38 +71,74 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verboseFlag, "verbose", "v", false, "Enable verbose output")
 	rootCmd.PersistentFlags().BoolVar(&jsonFlag, "json", false, "Output in JSON format")
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file (default: .sigil/config.yml)")
+	rootCmd.PersistentFlags().StringVar(&answersFile, "answers", "", "YAML or JSON file answering agent clarification questions (for non-interactive runs)")
+	rootCmd.PersistentFlags().BoolVar(&deterministicFlag, "deterministic", false, "Reproducible output for golden files and CI artifacts: temperature 0, fixed timestamps, sorted lists and seeded names")
+	rootCmd.PersistentFlags().StringVar(&colorFlag, "color", "auto", "Color terminal output: auto (terminals, unless NO_COLOR is set), always or never")
+	rootCmd.PersistentFlags().BoolVar(&untrustedFlag, "untrusted", false, "Treat the repository as untrusted: read-only analysis, no auto-fix, repo commands or MCP tool calls (default: on for fresh clones)")
 
 	// Add commands
+	rootCmd.AddCommand(NewInitCommand().CreateCobraCommand())
+	rootCmd.AddCommand(NewDoctorCommand().CreateCobraCommand())
 	rootCmd.AddCommand(askCmd)
+	rootCmd.AddCommand(NewChatCommand().CreateCobraCommand())
 	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(explainCmd)
 	rootCmd.AddCommand(summarizeCmd)
+	rootCmd.AddCommand(onboardCmd)
+	rootCmd.AddCommand(NewContextCommand())
+	rootCmd.AddCommand(NewIndexCommand())
 	rootCmd.AddCommand(reviewCmd)
 	rootCmd.AddCommand(diffCmd)
 	rootCmd.AddCommand(docCmd)
 	rootCmd.AddCommand(memoryCmd)
 	rootCmd.AddCommand(sandboxCmd)
 	rootCmd.AddCommand(multiAgentCmd)
+	rootCmd.AddCommand(NewTaskCommand())
 	rootCmd.AddCommand(NewMCPCommand())
+	rootCmd.AddCommand(NewSecretCommand())
+	rootCmd.AddCommand(NewPromptCommand())
+	rootCmd.AddCommand(NewTrendsCommand().CreateCobraCommand())
+	rootCmd.AddCommand(NewHistoryCommand().CreateCobraCommand())
+	rootCmd.AddCommand(NewStatusCommand().CreateCobraCommand())
+	rootCmd.AddCommand(NewCommitCommand().CreateCobraCommand())
+	rootCmd.AddCommand(NewReleaseCommand().CreateCobraCommand())
+	rootCmd.AddCommand(NewAffectedCommand().CreateCobraCommand())
+	rootCmd.AddCommand(NewRulesCommand())
+	rootCmd.AddCommand(NewSchemaCommand())
+	rootCmd.AddCommand(NewTrustCommand().CreateCobraCommand())
+	rootCmd.AddCommand(NewVersionCommand())
+	rootCmd.AddCommand(NewTelemetryCommand())
+
+	reportUsageErrors(rootCmd)
 }
 
 func initConfig() {
 	// Check if we're in a Git repository
 	if err := checkGitRepository(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		fmt.Fprint(os.Stderr, errors.FormatForUser(err, verboseFlag))
+		os.Exit(errors.ExitCode(err))
 	}
+	initTrust()
+	deterministic.Set(deterministicFlag)
 
 	// Load configuration
-	if _, err := config.Load(configFile); err != nil {
+	if _, err := config.Load(configPath()); err != nil {
 		if verboseFlag {
 			fmt.Fprintf(os.Stderr, "Warning: Failed to load config: %v\n", err)
 		}
 		// Continue with default configuration
 	}
+	if err := initTheme(); err != nil {
+		fmt.Fprint(os.Stderr, errors.FormatForUser(err, verboseFlag))
+		os.Exit(errors.ExitCode(err))
+	}
+	initGlossary()
 
-	// Register model providers
+	// Register model providers, restricted by the repository's policy and
+	// gated on its consent
 	initModelProviders()
+	model.SetPolicy(getConfig().Policy.Model())
+	initConsent()
 
 	// Initialize memory system
 	if err := memory.InitializeMemory(); err != nil {
@@ -89,12 +150,14 @@ func initConfig() {
 }
 
 func initModelProviders() {
-	// Register all providers
+	// Register all providers; MCP servers sample from the lead model
+	mcpProvider := mcp.NewProvider()
+	mcpProvider.SetSamplingModel(&samplingModel{})
 	providers := map[string]model.Factory{
 		"openai":    openai.NewProvider(),
 		"anthropic": anthropic.NewProvider(),
 		"ollama":    ollama.NewProvider(),
-		"mcp":       mcp.NewProvider(),
+		"mcp":       mcpProvider,
 	}
 
 	for name, provider := range providers {
@@ -107,9 +170,37 @@ func initModelProviders() {
 }
 
 func checkGitRepository() error {
-	if err := git.IsGitRepository(); err != nil {
-		return fmt.Errorf("not in a git repository: %w", err)
+	repo, err := git.NewRepository("")
+	if stderrors.Is(err, git.ErrNoWorkTree) {
+		return errors.Wrap(err, errors.ErrorTypeGit, "checkGitRepository", "not in a git working tree").
+			WithCode(errors.CodeNotGitRepo).
+			WithHint("sigil reads and changes files, so it needs a checkout; see the error for where to run it")
+	}
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "checkGitRepository", "not in a git repository").
+			WithCode(errors.CodeNotGitRepo).
+			WithHint("run sigil from inside a Git repository, or create one with 'git init'")
 	}
 
+	if repo.IsSubmodule() {
+		logger.Info("running inside a submodule", "root", repo.Root, "superproject", repo.Superproject)
+	}
+	if repo.IsLinkedWorktree() {
+		logger.Info("running inside a linked worktree", "root", repo.Root, "git_dir", repo.CommonDir)
+	}
 	return nil
 }
+
+// initTrust decides whether the repository is untrusted: as --untrusted says
+// when given, otherwise when it is a fresh clone
+func initTrust() {
+	if rootCmd.PersistentFlags().Changed("untrusted") {
+		trust.Set(untrustedFlag, "--untrusted")
+	} else if repo, err := git.NewRepository(""); err == nil {
+		trust.Set(trust.Detect(repo))
+	}
+
+	if trust.Untrusted() {
+		logger.Warn("running in untrusted mode: analysis only", "reason", trust.Reason())
+	}
+}
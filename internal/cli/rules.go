@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+// NewRulesCommand creates the validation rules command
+func NewRulesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Inspect sandbox validation rules",
+		Long: `Inspect the rules sandbox changes are validated against.
+
+The rules in .sigil/rules.yml at the project root apply everywhere. A
+subdirectory can add its own .sigil/rules.yml: its rules replace the
+inherited rule of the same name (or remove it with disabled: true) and are
+added otherwise, and the size and security settings it sets override the
+inherited ones for the files below it. Path patterns in a subdirectory
+rules file are relative to that subdirectory.`,
+		Example: `  # Show the rules a file is validated against and where they come from
+  sigil rules effective payments/charge.go`,
+	}
+
+	cmd.AddCommand(newRulesEffectiveCommand())
+
+	return cmd
+}
+
+// newRulesEffectiveCommand creates the effective subcommand
+func newRulesEffectiveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "effective <path>",
+		Short: "Show the merged rules for a path",
+		Long:  "Show the validation rules that apply to a path after merging the rules files of every directory above it.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			validator, err := sandbox.NewValidator()
+			if err != nil {
+				return err
+			}
+			rules, sources, err := validator.EffectiveRules(args[0])
+			if err != nil {
+				return err
+			}
+			output, err := formatEffectiveRules(args[0], rules, sources)
+			if err != nil {
+				return err
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+}
+
+// formatEffectiveRules renders the rules applying to a path as rules file
+// YAML, headed by the files they were merged from
+func formatEffectiveRules(path string, rules sandbox.Rules, sources []string) (string, error) {
+	rules.FileRules, rules.ContentRules = rules.ForPath(path)
+
+	data, err := yaml.Marshal(rules)
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrorTypeInternal, "formatEffectiveRules", "failed to encode rules")
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("# Effective rules for %s\n", path))
+	output.WriteString(fmt.Sprintf("# Merged from: %s\n", strings.Join(sources, ", ")))
+	output.Write(data)
+	return output.String(), nil
+}
@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+func TestFormatEffectiveRules(t *testing.T) {
+	rules := sandbox.DefaultRules()
+	rules.FileRules[0].Source = "defaults"
+
+	output, err := formatEffectiveRules("cmd/main.go", rules, []string{"defaults"})
+	require.NoError(t, err)
+
+	assert.Contains(t, output, "# Effective rules for cmd/main.go\n# Merged from: defaults\n")
+	assert.Contains(t, output, "name: Go source files")
+	assert.Contains(t, output, "source: defaults")
+	assert.NotContains(t, output, "name: Documentation")
+	assert.Contains(t, output, "max_file_size: 1048576")
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// samplingModel answers the sampling requests of MCP servers with the
+// configured lead model, loaded on the first request. An MCP lead model is
+// refused, so a server never samples through another MCP server.
+type samplingModel struct {
+	once sync.Once
+	mdl  model.Model
+	err  error
+}
+
+// load returns the lead model
+func (m *samplingModel) load() (model.Model, error) {
+	m.once.Do(func() {
+		lead := getConfig().Models.Lead
+		if provider, _, err := model.ParseModelString(lead); err == nil && strings.EqualFold(provider, "mcp") {
+			m.err = errors.ConfigError("samplingModel", "MCP servers cannot sample from an MCP lead model").
+				WithHint("set models.lead to an anthropic, openai or ollama model to let MCP servers sample")
+			return
+		}
+		m.mdl, m.err = loadModel(lead)
+	})
+	return m.mdl, m.err
+}
+
+// RunPrompt runs a server's sampling request on the lead model
+func (m *samplingModel) RunPrompt(ctx context.Context, input model.PromptInput) (model.PromptOutput, error) {
+	mdl, err := m.load()
+	if err != nil {
+		return model.PromptOutput{}, err
+	}
+	return mdl.RunPrompt(ctx, input)
+}
+
+// GetCapabilities returns the capabilities of the lead model once loaded
+func (m *samplingModel) GetCapabilities() model.ModelCapabilities {
+	if mdl, err := m.load(); err == nil {
+		return mdl.GetCapabilities()
+	}
+	return model.ModelCapabilities{}
+}
+
+// Name returns the lead model
+func (m *samplingModel) Name() string {
+	return getConfig().Models.Lead
+}
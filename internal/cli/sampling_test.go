@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// samplingFactory creates models that echo their prompt
+type samplingFactory struct{}
+
+func (samplingFactory) CreateModel(config model.ModelConfig) (model.Model, error) {
+	return &echoModel{name: config.Provider + ":" + config.Model}, nil
+}
+
+// echoModel answers with the prompt it was given
+type echoModel struct {
+	name string
+}
+
+func (m *echoModel) RunPrompt(_ context.Context, input model.PromptInput) (model.PromptOutput, error) {
+	return model.PromptOutput{Response: "echo: " + input.UserPrompt, Model: m.name}, nil
+}
+
+func (m *echoModel) GetCapabilities() model.ModelCapabilities {
+	return model.ModelCapabilities{}
+}
+
+func (m *echoModel) Name() string {
+	return m.name
+}
+
+func TestSamplingModel(t *testing.T) {
+	previous := config.Get()
+	defer config.Set(previous)
+	require.NoError(t, model.RegisterProvider("samplingtest", samplingFactory{}))
+
+	cfg := *previous
+	cfg.Models.Lead = "samplingtest:echo"
+	config.Set(&cfg)
+
+	sampler := &samplingModel{}
+	output, err := sampler.RunPrompt(context.Background(), model.PromptInput{UserPrompt: "user: hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "echo: user: hello", output.Response)
+	assert.Equal(t, "samplingtest:echo", sampler.Name())
+
+	cfg.Models.Lead = "mcp:github"
+	_, err = (&samplingModel{}).RunPrompt(context.Background(), model.PromptInput{UserPrompt: "user: hello"})
+	assert.ErrorContains(t, err, "cannot sample from an MCP lead model")
+}
@@ -6,10 +6,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/git"
 	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/sandbox"
+	"github.com/dshills/sigil/internal/trust"
 	"github.com/spf13/cobra"
 )
 
@@ -213,7 +215,11 @@ func (c *SandboxCommand) executeValidate(manager sandbox.Manager, args []string)
 
 	err := manager.ValidateCode(filePath, content)
 	if err != nil {
-		fmt.Printf("Validation failed for %s:\n%s\n", filePath, err.Error())
+		if violation, ok := sandbox.ViolationOf(err); ok {
+			fmt.Printf("Validation failed for %s:\n%s", filePath, violation.Explain())
+		} else {
+			fmt.Printf("Validation failed for %s:\n%s\n", filePath, err.Error())
+		}
 		return nil // Don't return error, just show validation result
 	}
 
@@ -249,6 +255,11 @@ func (c *SandboxCommand) executeStats(manager sandbox.Manager) error {
 		fmt.Printf("  Total Executions: %d\n", metrics.TotalExecutions)
 		fmt.Printf("  Successful Runs: %d\n", metrics.SuccessfulRuns)
 		fmt.Printf("  Failed Runs: %d\n", metrics.FailedRuns)
+		if limit := dm.DiskConfig().TotalCap; limit > 0 {
+			fmt.Printf("  Disk Usage: %s of %s\n", sandbox.FormatBytes(metrics.DiskUsage), sandbox.FormatBytes(limit))
+		} else {
+			fmt.Printf("  Disk Usage: %s\n", sandbox.FormatBytes(metrics.DiskUsage))
+		}
 
 		if metrics.TotalExecutions > 0 {
 			successRate := float64(metrics.SuccessfulRuns) / float64(metrics.TotalExecutions) * 100
@@ -288,19 +299,19 @@ func (c *SandboxCommand) executeClean(manager sandbox.Manager) error {
 func (c *SandboxCommand) executeTest(manager sandbox.Manager, args []string) error {
 	fmt.Println("Running tests in sandbox environment...")
 
-	// Create execution request for testing
+	// Run the project's validation profile
+	if err := trust.Check("executeTest", "running project tests"); err != nil {
+		return err
+	}
+	steps := manager.ValidationSteps()
+	if len(steps) == 0 {
+		return errors.ConfigError("executeTest", "no validation steps for this project").
+			WithHint(fmt.Sprintf("list steps in %s", sandbox.ValidationFile))
+	}
 	request := sandbox.ExecutionRequest{
-		ID:   fmt.Sprintf("test-%d", time.Now().Unix()),
-		Type: "test",
-		ValidationSteps: []sandbox.ValidationStep{
-			{
-				Name:        "Run Tests",
-				Command:     "go",
-				Args:        []string{"test", "./..."},
-				Required:    true,
-				Description: "Execute Go tests",
-			},
-		},
+		ID:              fmt.Sprintf("test-%d-%s", deterministic.Now().Unix(), deterministic.TokenFor("test", 6)),
+		Type:            "test",
+		ValidationSteps: steps,
 	}
 
 	// Add any file changes from args
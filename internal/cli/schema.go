@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// schemaFiles holds the JSON Schemas of the commands' JSON output
+//
+//go:embed schemas/*.schema.json
+var schemaFiles embed.FS
+
+// OutputSchema returns the JSON Schema of a command's JSON output. A
+// version suffix such as review@v2 selects the schema of that version of
+// the output; without one it is the version 1 schema
+func OutputSchema(command string) ([]byte, error) {
+	name, version, _ := strings.Cut(command, "@")
+	file := "schemas/" + name + ".schema.json"
+	if version != "" && version != "v1" {
+		file = "schemas/" + name + "." + version + ".schema.json"
+	}
+	data, err := schemaFiles.ReadFile(file)
+	if err != nil {
+		return nil, errors.ValidationError("OutputSchema", fmt.Sprintf("no JSON output schema for command: %s", command)).
+			WithHint(fmt.Sprintf("schemas are published for: %s", strings.Join(SchemaCommands(), ", ")))
+	}
+	return data, nil
+}
+
+// SchemaCommands lists the commands with a published output schema, with
+// the schemas of later output versions as e.g. review@v2
+func SchemaCommands() []string {
+	entries, _ := schemaFiles.ReadDir("schemas")
+	commands := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		commands = append(commands, strings.Replace(strings.TrimSuffix(entry.Name(), ".schema.json"), ".v", "@v", 1))
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// NewSchemaCommand creates the schema command
+func NewSchemaCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema [command]",
+		Short: "Print the JSON Schema of a command's JSON output",
+		Long: `Print the JSON Schema (draft 2020-12) that a command's --format json
+output conforms to, so downstream tools can validate what they consume.
+Without a command, list the commands that have one. Append a version,
+e.g. review@v2, for the schema of --format json@v2 output.
+
+The doc command writes documentation files rather than JSON; its schema is
+that of the report doc --review writes.`,
+		Example: `  # Validate a review in CI
+  sigil schema review > review.schema.json
+  sigil review --format json main.go > review.json
+
+  # The schema of version 2 output
+  sigil schema review@v2
+
+  # List the commands with a schema
+  sigil schema`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: SchemaCommands(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				fmt.Println(strings.Join(SchemaCommands(), "\n"))
+				return nil
+			}
+			data, err := OutputSchema(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(data))
+			return nil
+		},
+	}
+}
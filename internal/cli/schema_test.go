@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/findings"
+	"github.com/dshills/sigil/internal/model/providers/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertMatchesSchema fails unless output is valid against command's schema
+func assertMatchesSchema(t *testing.T, command, output string) {
+	t.Helper()
+	data, err := OutputSchema(command)
+	require.NoError(t, err)
+	var schema, value map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	require.NoError(t, json.Unmarshal([]byte(output), &value), output)
+	assert.NoError(t, mcp.ValidateArguments(command, schema, value), output)
+}
+
+func TestOutputSchema(t *testing.T) {
+	assert.Equal(t, []string{"diff", "diff@v2", "doc", "review", "review@v2", "summarize", "summarize@v2"}, SchemaCommands())
+
+	v1, err := OutputSchema("review")
+	require.NoError(t, err)
+	explicit, err := OutputSchema("review@v1")
+	require.NoError(t, err)
+	assert.Equal(t, v1, explicit)
+
+	_, err = OutputSchema("doc@v2")
+	assert.ErrorContains(t, err, "no JSON output schema for command: doc@v2")
+	_, err = OutputSchema("review@v3")
+	assert.Error(t, err)
+}
+
+func TestOutputSchema_Review(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cmd := NewReviewCommand()
+	cmd.Severity = "all"
+	cmd.NoOwners = true
+	cmd.startTime = at
+	assertMatchesSchema(t, "review", cmd.formatJSON("Nothing to report.", &agent.OrchestrationResult{Status: agent.StatusSuccess}))
+
+	cmd.Files = []string{"server.go"}
+	cmd.Focus = []string{"security"}
+	cmd.failures.failures = []fileFailure{{Path: "broken.go", Error: "failed to read file: broken.go"}}
+	cmd.lifecycle = &findings.Lifecycle{Fixed: []findings.Record{{
+		Fingerprint: "abc", Rule: "logic", Path: "server.go", Line: 3, Severity: agent.SeverityWarning,
+		Message: "Off by one", Status: findings.StatusFixed, FirstSeen: at, LastSeen: at, FixedAt: at,
+	}}}
+	content := "Looks mostly fine.\n\n```json\n[" +
+		`{"path": "server.go", "line": 12, "end_line": 14, "severity": "error", "type": "error_handling", "message": "Close error ignored", "suggestion": "Check it"}` +
+		"]\n```\n"
+	assertMatchesSchema(t, "review", cmd.formatJSON(content, &agent.OrchestrationResult{Status: agent.StatusSuccess}))
+
+	cmd.jsonVersion = JSONVersion2
+	assertMatchesSchema(t, "review@v2", cmd.formatJSON(content, &agent.OrchestrationResult{Status: agent.StatusSuccess}))
+	cmd.Files, cmd.Focus, cmd.lifecycle, cmd.failures = nil, nil, nil, fileFailures{}
+	assertMatchesSchema(t, "review@v2", cmd.formatJSON("Nothing to report.", &agent.OrchestrationResult{Status: agent.StatusSuccess}))
+}
+
+func TestOutputSchema_Diff(t *testing.T) {
+	cmd := NewDiffCommand()
+	cmd.Staged = true
+	cmd.startTime = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	assertMatchesSchema(t, "diff", cmd.formatJSON("Adds a flag.", "+flag"))
+
+	cmd.Format = "json"
+	reviews := []hunkReview{
+		{Hunk: diffHunk{File: "main.go", Lines: []string{"@@ -1 +1 @@", "-a", "+b"}}, Explanation: "Renames a", Mark: HunkReviewed},
+		{Hunk: diffHunk{File: "util.go", Lines: []string{"@@ -3 +3 @@", "+c"}}, Mark: HunkQuestionable, Note: "why?"},
+	}
+	report, err := cmd.formatHunkReport(reviews)
+	require.NoError(t, err)
+	assertMatchesSchema(t, "diff", report)
+
+	cmd.jsonVersion = JSONVersion2
+	assertMatchesSchema(t, "diff@v2", cmd.formatJSON("Adds a flag.", "+flag"))
+	report, err = cmd.formatHunkReport(reviews)
+	require.NoError(t, err)
+	assertMatchesSchema(t, "diff@v2", report)
+	report, err = cmd.formatHunkReport(nil)
+	require.NoError(t, err)
+	assertMatchesSchema(t, "diff@v2", report)
+}
+
+func TestOutputSchema_Summarize(t *testing.T) {
+	cmd := NewSummarizeCommand()
+	cmd.Files = []string{"main.go"}
+	cmd.Format = "json"
+	cmd.startTime = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	assertMatchesSchema(t, "summarize", cmd.formatJSON("A small CLI."))
+
+	root := &summaryNode{
+		Path: ".", Level: LevelProject, Summary: "A small CLI.",
+		Children: []*summaryNode{{Path: "cmd", Level: LevelPackage, Files: []string{"cmd/main.go"}, Summary: "Entry point."}},
+	}
+	tree, err := cmd.formatTree(root)
+	require.NoError(t, err)
+	assertMatchesSchema(t, "summarize", tree)
+
+	cmd.jsonVersion = JSONVersion2
+	assertMatchesSchema(t, "summarize@v2", cmd.formatJSON("A small CLI."))
+	tree, err = cmd.formatTree(root)
+	require.NoError(t, err)
+	assertMatchesSchema(t, "summarize@v2", tree)
+}
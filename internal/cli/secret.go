@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dshills/sigil/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+// NewSecretCommand creates the secret management command
+func NewSecretCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Manage stored credentials",
+		Long: `Manage credentials kept in the user's secret store.
+
+Stored secrets can be referenced from MCP server configuration as
+${secret:name} so tokens never need to be written into YAML files.
+An environment variable SIGIL_SECRET_<NAME> overrides the stored value.`,
+		Example: `  # Store a token (the value is read from stdin)
+  sigil secret set github_token
+
+  # Pipe a value in non-interactively
+  echo "$TOKEN" | sigil secret set github_token
+
+  # List stored secret names
+  sigil secret list
+
+  # Remove a secret
+  sigil secret rm github_token`,
+	}
+
+	cmd.AddCommand(newSecretSetCommand())
+	cmd.AddCommand(newSecretListCommand())
+	cmd.AddCommand(newSecretRemoveCommand())
+
+	return cmd
+}
+
+// newSecretSetCommand creates the set subcommand
+func newSecretSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <name>",
+		Short: "Store a secret",
+		Long:  "Store a secret read from stdin. Values are never accepted as arguments so they stay out of shell history.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if err := secrets.ValidateName(name); err != nil {
+				return err
+			}
+
+			if isTerminal(os.Stdin) {
+				fmt.Fprintf(os.Stderr, "Value for %s: ", name)
+			}
+			value, err := readSecretValue(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			store := secrets.Default()
+			if err := store.Set(name, value); err != nil {
+				return err
+			}
+
+			fmt.Printf("Stored secret %s in %s\n", name, store.Path())
+			return nil
+		},
+	}
+}
+
+// readSecretValue reads a single-line secret value
+func readSecretValue(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read secret value: %w", err)
+	}
+	value := strings.TrimRight(line, "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("secret value is empty")
+	}
+	return value, nil
+}
+
+// newSecretListCommand creates the list subcommand
+func newSecretListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List stored secret names",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := secrets.Default().List()
+			if err != nil {
+				return err
+			}
+			if len(names) == 0 {
+				fmt.Println("No secrets stored.")
+				return nil
+			}
+			for _, name := range names {
+				fmt.Println(name)
+			}
+			return nil
+		},
+	}
+}
+
+// newSecretRemoveCommand creates the rm subcommand
+func newSecretRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm <name>",
+		Aliases: []string{"remove", "delete"},
+		Short:   "Remove a stored secret",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := secrets.Default().Delete(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Removed secret %s\n", args[0])
+			return nil
+		},
+	}
+}
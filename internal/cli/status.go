@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+// StatusCommand reports the state sigil keeps in the repository
+type StatusCommand struct {
+	*BaseCommand
+	JSON bool
+}
+
+// sandboxStatus is the disk the sandboxes use and the limits they run under
+type sandboxStatus struct {
+	Usage sandbox.DiskUsage         `json:"usage"`
+	Disk  sandbox.DiskConfiguration `json:"limits"`
+}
+
+// NewStatusCommand creates a new status command
+func NewStatusCommand() *StatusCommand {
+	return &StatusCommand{
+		BaseCommand: NewBaseCommand("status", "Show sigil's state in this repository",
+			"Show the disk sandboxes use against their quotas."),
+	}
+}
+
+// Execute runs the status command
+func (c *StatusCommand) Execute(ctx context.Context) error {
+	usage, err := sandbox.MeasureDiskUsage(filepath.FromSlash(sandbox.SandboxDir))
+	if err != nil {
+		return err
+	}
+	status := sandboxStatus{Usage: usage, Disk: sandbox.LoadDiskConfiguration()}
+
+	if c.JSON {
+		data, err := json.MarshalIndent(map[string]any{"sandboxes": status}, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to encode status")
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Print(formatSandboxStatus(status))
+	return nil
+}
+
+// formatSandboxStatus renders the sandbox disk usage for the terminal
+func formatSandboxStatus(status sandboxStatus) string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Sandboxes: %d worktrees using %s", len(status.Usage.Worktrees), sandbox.FormatBytes(status.Usage.Total)))
+	if status.Disk.TotalCap > 0 {
+		output.WriteString(fmt.Sprintf(" of %s (%.0f%%)", sandbox.FormatBytes(status.Disk.TotalCap),
+			float64(status.Usage.Total)/float64(status.Disk.TotalCap)*100))
+	}
+	output.WriteString("\n")
+	if status.Disk.WorktreeQuota > 0 {
+		output.WriteString(fmt.Sprintf("Worktree quota: %s\n", sandbox.FormatBytes(status.Disk.WorktreeQuota)))
+	}
+
+	for _, worktree := range status.Usage.Worktrees {
+		output.WriteString(fmt.Sprintf("  %-24s %10s  %s", worktree.ID, sandbox.FormatBytes(worktree.Bytes),
+			worktree.Modified.Format("2006-01-02 15:04")))
+		if status.Disk.WorktreeQuota > 0 && worktree.Bytes > status.Disk.WorktreeQuota {
+			output.WriteString("  over quota")
+		}
+		output.WriteString("\n")
+	}
+	if status.Disk.TotalCap > 0 && status.Usage.Total > status.Disk.TotalCap {
+		output.WriteString("Over the cap: the oldest sandboxes are removed when the next one is created, or run sigil sandbox clean --all\n")
+	}
+	return output.String()
+}
+
+// CreateCobraCommand creates the cobra command for status
+func (c *StatusCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show sigil's state in this repository",
+		Long: `Show the state sigil keeps in this repository: the sandbox worktrees under
+.sigil/sandbox, the disk each one uses, and how that compares with the
+per-worktree quota and the cap on all sandboxes set in .sigil/project.yml.`,
+		Example: `  sigil status
+  sigil status --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.Execute(cmd.Context())
+		},
+	}
+
+	cmd.Flags().BoolVar(&c.JSON, "json", false, "Output as JSON")
+	return cmd
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+func TestFormatSandboxStatus(t *testing.T) {
+	status := sandboxStatus{
+		Usage: sandbox.DiskUsage{
+			Worktrees: []sandbox.WorktreeUsage{
+				{ID: "1700000000-abcdefgh", Bytes: 3 * 1024 * 1024, Modified: time.Date(2026, 1, 2, 3, 4, 0, 0, time.Local)},
+				{ID: "1700000001-ijklmnop", Bytes: 512},
+			},
+			Total: 3*1024*1024 + 512,
+		},
+		Disk: sandbox.DiskConfiguration{WorktreeQuota: 2 * 1024 * 1024, TotalCap: 4 * 1024 * 1024},
+	}
+
+	output := formatSandboxStatus(status)
+	assert.Contains(t, output, "Sandboxes: 2 worktrees using 3.0MB of 4.0MB (75%)")
+	assert.Contains(t, output, "Worktree quota: 2.0MB")
+	assert.Contains(t, output, "1700000000-abcdefgh")
+	assert.Contains(t, output, "2026-01-02 03:04  over quota")
+	assert.NotContains(t, output, "Over the cap")
+
+	status.Disk.TotalCap = 1024
+	assert.Contains(t, formatSandboxStatus(status), "Over the cap")
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// answerStream writes a model's answer as it arrives, a line at a time so
+// each line can be rendered. Rendering may look one line ahead, for
+// underlined headings and diffs, so a line is held until the one after it
+// completes.
+type answerStream struct {
+	out     io.Writer
+	render  func(text string) string
+	text    strings.Builder
+	written int
+}
+
+// newAnswerStream creates a stream writing the answer to out, rendered
+// with render
+func newAnswerStream(out io.Writer, render func(text string) string) *answerStream {
+	return &answerStream{out: out, render: render}
+}
+
+// Write adds a chunk of the answer, writing the lines it completes
+func (s *answerStream) Write(chunk string) {
+	s.text.WriteString(chunk)
+	s.flush(false)
+}
+
+// Close writes the rest of the answer
+func (s *answerStream) Close() {
+	s.flush(true)
+}
+
+// flush writes the lines that are ready: all but the incomplete last and
+// the one it may change the rendering of, or every line once done
+func (s *answerStream) flush(done bool) {
+	text := strings.TrimLeftFunc(s.text.String(), unicode.IsSpace)
+	if done {
+		text = strings.TrimRightFunc(text, unicode.IsSpace)
+	} else if i := strings.LastIndex(text, "\n"); i >= 0 {
+		text = text[:i]
+	} else {
+		return
+	}
+	if text == "" {
+		return
+	}
+
+	lines := strings.Split(s.render(text), "\n")
+	ready := len(lines)
+	if !done {
+		ready--
+	}
+	for ; s.written < ready; s.written++ {
+		fmt.Fprintln(s.out, lines[s.written])
+	}
+}
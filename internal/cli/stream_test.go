@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnswerStream(t *testing.T) {
+	var out bytes.Buffer
+	var rendered []string
+	stream := newAnswerStream(&out, func(text string) string {
+		rendered = append(rendered, text)
+		return strings.ToUpper(text)
+	})
+
+	stream.Write("\n  Login checks")
+	assert.Empty(t, out.String(), "leading space is skipped and incomplete lines held")
+
+	stream.Write(" the password.\nTitle\n")
+	assert.Equal(t, "LOGIN CHECKS THE PASSWORD.\n", out.String(), "a line is held until the next completes")
+
+	stream.Write("-----\nDone")
+	assert.Equal(t, "LOGIN CHECKS THE PASSWORD.\nTITLE\n", out.String())
+	assert.Equal(t, "Login checks the password.\nTitle\n-----", rendered[len(rendered)-1], "lines render with the one after them")
+
+	stream.Write(".\n\n")
+	stream.Close()
+	assert.Equal(t, "LOGIN CHECKS THE PASSWORD.\nTITLE\n-----\nDONE.\n", out.String())
+
+	var empty bytes.Buffer
+	stream = newAnswerStream(&empty, func(text string) string { return text })
+	stream.Write(" \n")
+	stream.Close()
+	assert.Empty(t, empty.String())
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+)
+
+// expandSubmodules replaces arguments naming a submodule with the files in
+// it, including those of nested submodules, when recurse is set. Without
+// recurse, submodules are refused, since reading them as files fails.
+func expandSubmodules(files []string, recurse bool) ([]string, error) {
+	expanded := make([]string, 0, len(files))
+	for _, file := range files {
+		repo, ok := submoduleAt(file)
+		if !ok {
+			expanded = append(expanded, file)
+			continue
+		}
+		if !recurse {
+			return nil, errors.ValidationError("expandSubmodules", fmt.Sprintf("%s is a git submodule", file)).
+				WithHint("pass --recurse-submodules to include its files")
+		}
+
+		submoduleFiles, err := listSubmoduleFiles(repo)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeGit, "expandSubmodules",
+				fmt.Sprintf("failed to list files of submodule %s", file))
+		}
+		for _, path := range submoduleFiles {
+			expanded = append(expanded, filepath.Join(file, path))
+		}
+	}
+	return expanded, nil
+}
+
+// submoduleAt returns the repository of a submodule whose top is path
+func submoduleAt(path string) (*git.Repository, bool) {
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return nil, false
+	}
+	repo, err := git.NewRepository(path)
+	if err != nil || !repo.IsSubmodule() {
+		return nil, false
+	}
+	return repo, samePath(repo.Root, path)
+}
+
+// listSubmoduleFiles lists the files of a submodule and of its initialized
+// submodules, relative to it
+func listSubmoduleFiles(repo *git.Repository) ([]string, error) {
+	files, err := repo.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+	nested, err := repo.Submodules()
+	if err != nil {
+		return nil, err
+	}
+	for _, submodule := range nested {
+		if !submodule.Initialized {
+			continue
+		}
+		nestedFiles, err := (&git.Repository{Path: filepath.Join(repo.Path, submodule.Path)}).ListFiles()
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range nestedFiles {
+			files = append(files, filepath.Join(submodule.Path, path))
+		}
+	}
+	return files, nil
+}
+
+// samePath reports whether two paths name the same directory
+func samePath(a, b string) bool {
+	resolvedA, errA := filepath.EvalSymlinks(a)
+	resolvedB, errB := filepath.EvalSymlinks(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return resolvedA == resolvedB
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitCommit creates a repository in dir with the given files committed
+func gitCommit(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0600))
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+}
+
+func TestExpandSubmodules(t *testing.T) {
+	lib := t.TempDir()
+	gitCommit(t, lib, map[string]string{"lib.go": "package lib\n", "util/util.go": "package util\n"})
+	app := t.TempDir()
+	gitCommit(t, app, map[string]string{"main.go": "package main\n"})
+
+	cmd := exec.Command("git", "-c", "protocol.file.allow=always", "submodule", "add", "-q", lib, "vendor/lib")
+	cmd.Dir = app
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	main := filepath.Join(app, "main.go")
+	submodule := filepath.Join(app, "vendor", "lib")
+
+	files, err := expandSubmodules([]string{main, submodule}, true)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{main, filepath.Join(submodule, "lib.go"), filepath.Join(submodule, "util", "util.go")}, files)
+
+	_, err = expandSubmodules([]string{main, submodule}, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is a git submodule")
+
+	// Directories inside a submodule are not the submodule itself
+	files, err = expandSubmodules([]string{filepath.Join(submodule, "util")}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(submodule, "util")}, files)
+}
@@ -12,8 +12,11 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/chunk"
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/outline"
 )
 
 // Output format constants
@@ -41,13 +44,21 @@ const (
 // SummarizeCommand handles code summarization operations
 type SummarizeCommand struct {
 	*BaseCommand
-	Files      []string
-	Recursive  bool
-	Brief      bool
-	Focus      string
-	Format     string
-	OutputFile string
-	startTime  time.Time
+	Files       []string
+	Recursive   bool
+	Depth       int
+	Brief       bool
+	Focus       string
+	Format      string
+	OutputFile  string
+	Outline     bool
+	QualityPass bool
+	VerifyRefs  bool
+	quality     *qualityChecker
+	refs        *referenceGuard
+	chunking    chunk.Rules
+	jsonVersion int
+	startTime   time.Time
 }
 
 // NewSummarizeCommand creates a new summarize command
@@ -56,6 +67,7 @@ func NewSummarizeCommand() *SummarizeCommand {
 		BaseCommand: NewBaseCommand("summarize", "Generate code summaries with AI analysis",
 			"Generate comprehensive summaries of code files and projects using AI analysis."),
 		Format:    FormatMarkdown,
+		chunking:  chunk.DefaultRules(),
 		startTime: time.Now(),
 	}
 }
@@ -63,23 +75,44 @@ func NewSummarizeCommand() *SummarizeCommand {
 // Execute runs the summarize command
 func (c *SummarizeCommand) Execute(ctx context.Context) error {
 	logger.Info("starting summarize operation", "files", c.Files, "focus", c.Focus)
+	c.startTime = deterministic.Time(c.startTime)
+	c.Files = deterministic.Sorted(c.Files)
 
 	// Validate inputs
 	if err := c.validateInputs(); err != nil {
 		return err
 	}
 
+	var err error
+	c.quality, err = newQualityChecker(ctx, c.BaseCommand, c.QualityPass)
+	if err != nil {
+		return err
+	}
+
+	if c.refs, err = newReferenceGuard(c.VerifyRefs); err != nil {
+		return err
+	}
+	c.chunking = getConfig().Chunking.Rules()
+
+	if c.Recursive && c.hasDirectory() {
+		return c.executeRecursive(ctx)
+	}
+
 	// Create task for agent processing
 	task, err := c.createSummarizeTask()
 	if err != nil {
 		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to create summarize task")
 	}
+	if err := c.summarizeParts(ctx, task, c.summarizePart); err != nil {
+		return err
+	}
 
 	// Execute summarization
 	result, err := c.executeSummarization(ctx, task)
 	if err != nil {
 		return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to execute summarization")
 	}
+	c.quality.checkResult(ctx, "summary", task, result)
 
 	// Output result
 	return c.outputResult(result)
@@ -92,12 +125,28 @@ func (c *SummarizeCommand) validateInputs() error {
 	}
 
 	for _, file := range c.Files {
-		if !c.fileExists(file) {
+		info, err := os.Stat(file)
+		if err != nil {
 			return errors.New(errors.ErrorTypeInput, "validateInputs",
 				fmt.Sprintf("file does not exist: %s", file))
 		}
+		if info.IsDir() && !c.Recursive {
+			return errors.New(errors.ErrorTypeInput, "validateInputs",
+				fmt.Sprintf("%s is a directory", file)).
+				WithHint("use --recursive to summarize directories")
+		}
+	}
+
+	if c.Depth < 0 {
+		return errors.New(errors.ErrorTypeInput, "validateInputs",
+			fmt.Sprintf("invalid depth: %d (must be 0 or more)", c.Depth))
 	}
 
+	format, version, err := parseFormat(c.Format)
+	if err != nil {
+		return err
+	}
+	c.Format, c.jsonVersion = format, version
 	validFormats := []string{FormatMarkdown, string(InputTypeText), string(OutputFormatJSON), FormatHTML, "yaml"}
 	formatValid := false
 	for _, format := range validFormats {
@@ -111,6 +160,19 @@ func (c *SummarizeCommand) validateInputs() error {
 			fmt.Sprintf("invalid format: %s (valid: %s)", c.Format, strings.Join(validFormats, ", ")))
 	}
 
+	if c.Recursive && c.hasDirectory() && c.Format != FormatMarkdown && c.Format != string(OutputFormatJSON) {
+		return errors.New(errors.ErrorTypeInput, "validateInputs",
+			fmt.Sprintf("invalid format for recursive summaries: %s (valid: %s, %s)", c.Format, FormatMarkdown, OutputFormatJSON))
+	}
+
+	if c.Outline && c.Recursive && c.hasDirectory() {
+		return errors.New(errors.ErrorTypeInput, "validateInputs", "--outline is not supported for recursive summaries")
+	}
+	if c.Outline && c.Format != FormatMarkdown && c.Format != string(InputTypeText) && c.Format != string(OutputFormatJSON) {
+		return errors.New(errors.ErrorTypeInput, "validateInputs",
+			fmt.Sprintf("invalid format for outlines: %s (valid: %s, %s, %s)", c.Format, FormatMarkdown, InputTypeText, OutputFormatJSON))
+	}
+
 	return nil
 }
 
@@ -167,7 +229,7 @@ func (c *SummarizeCommand) createSummarizeTask() (*agent.Task, error) {
 
 	// Create task
 	task := &agent.Task{
-		ID:          fmt.Sprintf("summarize_%d", c.startTime.Unix()),
+		ID:          fmt.Sprintf("summarize_%d_%s", c.startTime.Unix(), deterministic.TokenFor("summarize", 6)),
 		Type:        agent.TaskTypeAnalyze,
 		Description: c.buildDescription(),
 		Context: agent.TaskContext{
@@ -202,11 +264,12 @@ func (c *SummarizeCommand) executeSummarization(ctx context.Context, task *agent
 	logger.Info("executing summarization with agent system")
 
 	// Create agent factory and orchestrator
-	factory := agent.NewFactory(nil, agent.DefaultOrchestrationConfig()) // No sandbox needed for summarization
+	factory := agent.NewFactory(nil, orchestrationConfig()) // No sandbox needed for summarization
 	orchestrator, err := factory.CreateOrchestrator()
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrorTypeInternal, "executeSummarization", "failed to create orchestrator")
 	}
+	configureOrchestrator(orchestrator)
 
 	// Execute task
 	result, err := orchestrator.ExecuteTask(ctx, *task)
@@ -214,6 +277,13 @@ func (c *SummarizeCommand) executeSummarization(ctx context.Context, task *agent
 		return nil, errors.Wrap(err, errors.ErrorTypeInternal, "executeSummarization", "task execution failed")
 	}
 
+	if len(result.Questions) > 0 {
+		return nil, clarificationError("executeSummarization", result.Questions)
+	}
+	if result.Abstention != nil {
+		return nil, abstentionError("executeSummarization", result.Abstention)
+	}
+
 	if result.Status != agent.StatusSuccess {
 		return nil, errors.New(errors.ErrorTypeInternal, "executeSummarization",
 			fmt.Sprintf("summarization failed with status: %s", result.Status))
@@ -225,8 +295,28 @@ func (c *SummarizeCommand) executeSummarization(ctx context.Context, task *agent
 
 // outputResult outputs the summarization result
 func (c *SummarizeCommand) outputResult(result *agent.OrchestrationResult) error {
+	summary, err := summaryText(result)
+	if err != nil {
+		return err
+	}
+	summary = applyGlossary(c.refs.check(summary))
+
+	// Format the output
+	formatted, err := c.formatOutput(summary)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "outputResult", "failed to format output")
+	}
+	if c.Format == FormatMarkdown {
+		formatted = lintDocument(formatted)
+	}
+
+	return c.writeOutput(formatted)
+}
+
+// summaryText extracts the summary from an orchestration result
+func summaryText(result *agent.OrchestrationResult) (string, error) {
 	if result.FinalResult == nil {
-		return errors.New(errors.ErrorTypeInternal, "outputResult", "no final result available")
+		return "", errors.New(errors.ErrorTypeInternal, "summaryText", "no final result available")
 	}
 
 	summary := result.FinalResult.Reasoning
@@ -236,19 +326,16 @@ func (c *SummarizeCommand) outputResult(result *agent.OrchestrationResult) error
 	}
 
 	if summary == "" {
-		return errors.New(errors.ErrorTypeInternal, "outputResult", "no summary content generated")
-	}
-
-	// Format the output
-	formatted, err := c.formatOutput(summary)
-	if err != nil {
-		return errors.Wrap(err, errors.ErrorTypeInternal, "outputResult", "failed to format output")
+		return "", errors.New(errors.ErrorTypeInternal, "summaryText", "no summary content generated")
 	}
+	return summary, nil
+}
 
-	// Write to file or stdout
+// writeOutput writes formatted output to the output file or stdout
+func (c *SummarizeCommand) writeOutput(formatted string) error {
 	if c.OutputFile != "" {
 		if err := c.writeFile(c.OutputFile, formatted); err != nil {
-			return errors.Wrap(err, errors.ErrorTypeInternal, "outputResult",
+			return errors.Wrap(err, errors.ErrorTypeInternal, "writeOutput",
 				fmt.Sprintf("failed to write output file: %s", c.OutputFile))
 		}
 		fmt.Printf("Summary written to: %s\n", c.OutputFile)
@@ -297,6 +384,13 @@ func (c *SummarizeCommand) formatMarkdown(content string) string {
 	result.WriteString(content)
 	result.WriteString("\n")
 
+	if outlines := c.outlines(); len(outlines) > 0 {
+		result.WriteString("\n## Outline\n")
+		for _, file := range outlines {
+			result.WriteString(fmt.Sprintf("\n### `%s`\n\n```\n%s```\n", file.Path, outline.Format(file.Symbols)))
+		}
+	}
+
 	return result.String()
 }
 
@@ -322,11 +416,23 @@ func (c *SummarizeCommand) formatText(content string) string {
 	result.WriteString(content)
 	result.WriteString("\n")
 
+	if outlines := c.outlines(); len(outlines) > 0 {
+		result.WriteString("\nOutline:\n")
+		result.WriteString("--------\n")
+		for _, file := range outlines {
+			result.WriteString(fmt.Sprintf("%s:\n%s", file.Path, outline.Format(file.Symbols)))
+		}
+	}
+
 	return result.String()
 }
 
 // formatJSON formats content as JSON
 func (c *SummarizeCommand) formatJSON(content string) string {
+	if c.jsonVersion == JSONVersion2 {
+		return c.formatJSONV2(content)
+	}
+
 	data := map[string]interface{}{
 		"focus":     c.Focus,
 		"files":     c.Files,
@@ -335,6 +441,9 @@ func (c *SummarizeCommand) formatJSON(content string) string {
 		"timestamp": c.startTime.Format("2006-01-02T15:04:05Z07:00"),
 		"brief":     c.Brief,
 	}
+	if outlines := c.outlines(); len(outlines) > 0 {
+		data["outline"] = outlines
+	}
 
 	jsonBytes, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
@@ -344,6 +453,29 @@ func (c *SummarizeCommand) formatJSON(content string) string {
 	return string(jsonBytes)
 }
 
+// formatJSONV2 formats content as version 2 JSON
+func (c *SummarizeCommand) formatJSONV2(content string) string {
+	files := c.Files
+	if files == nil {
+		files = []string{}
+	}
+	result := map[string]interface{}{
+		"focus":   c.Focus,
+		"files":   files,
+		"summary": content,
+		"brief":   c.Brief,
+	}
+	if outlines := c.outlines(); len(outlines) > 0 {
+		result["outline"] = outlines
+	}
+
+	output, err := formatEnvelope("summarize", c.startTime, result)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "Failed to format JSON: %s"}`, err.Error())
+	}
+	return output
+}
+
 // formatHTML formats content as HTML
 func (c *SummarizeCommand) formatHTML(content string) string {
 	var result strings.Builder
@@ -399,6 +531,32 @@ func (c *SummarizeCommand) formatYAML(content string) string {
 	return result.String()
 }
 
+// fileOutline is the outline of one summarized file
+type fileOutline struct {
+	Path    string           `json:"path"`
+	Symbols []outline.Symbol `json:"symbols"`
+}
+
+// outlines returns the declarations of the summarized files when --outline
+// is set, skipping files that cannot be read or declare nothing
+func (c *SummarizeCommand) outlines() []fileOutline {
+	if !c.Outline {
+		return nil
+	}
+	var outlines []fileOutline
+	for _, path := range c.Files {
+		content, err := c.readFile(path)
+		if err != nil {
+			logger.Warn("failed to read file for outline", "file", path, "error", err)
+			continue
+		}
+		if symbols := outline.Extract(path, content); len(symbols) > 0 {
+			outlines = append(outlines, fileOutline{Path: path, Symbols: symbols})
+		}
+	}
+	return outlines
+}
+
 // detectProjectLanguage detects the primary language of the project
 func (c *SummarizeCommand) detectProjectLanguage() string {
 	if c.fileExists("go.mod") || c.fileExists("main.go") {
@@ -462,7 +620,10 @@ Examples:
   sigil summarize main.go
   sigil summarize src/ --brief --focus "error handling"
   sigil summarize *.go --format html --output summary.html
-  sigil summarize project/ --recursive --format yaml`,
+  sigil summarize project/ --recursive --depth 2
+  sigil summarize internal/ --recursive --format json
+  sigil summarize store.go --outline
+  sigil summarize store.go --quality-pass`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c.Files = args
@@ -473,10 +634,14 @@ Examples:
 
 	// Add flags
 	cmd.Flags().BoolVarP(&c.Recursive, "recursive", "r", false, "Recursively summarize directories")
+	cmd.Flags().IntVar(&c.Depth, "depth", 0, "Directory levels to summarize individually with --recursive (0 for no limit)")
 	cmd.Flags().BoolVar(&c.Brief, "brief", false, "Generate brief, high-level summary")
 	cmd.Flags().StringVar(&c.Focus, "focus", "", "Focus area for summarization")
-	cmd.Flags().StringVar(&c.Format, "format", "markdown", "Output format (markdown, text, json, html, yaml)")
+	cmd.Flags().StringVar(&c.Format, "format", "markdown", "Output format (markdown, text, json, json@v2, html, yaml)")
 	cmd.Flags().StringVarP(&c.OutputFile, "output", "o", "", "Output file (default: stdout)")
+	cmd.Flags().BoolVar(&c.Outline, "outline", false, "Append the declarations of each file (markdown, text, json)")
+	cmd.Flags().BoolVar(&c.VerifyRefs, "verify-refs", false, "Check the files and symbols summaries refer to, correcting near misses and marking the rest unverified")
+	cmd.Flags().BoolVar(&c.QualityPass, "quality-pass", false, "Critique each summary against a rubric and revise it once before output")
 
 	return cmd
 }
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/chunk"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// summarizeParts is the map step of a map-reduce summary: each file of the
+// task too long to summarize whole is split into parts by its chunking
+// rules, each part is summarized on its own, and the part summaries replace
+// the file's content for the task to reduce
+func (c *SummarizeCommand) summarizeParts(ctx context.Context, task *agent.Task, summarize summarizeFunc) error {
+	for i := range task.Context.Files {
+		file := &task.Context.Files[i]
+		parts := c.chunking.SummaryParts(file.Path, file.Content)
+		if len(parts) == 0 {
+			continue
+		}
+		logger.Info("summarizing file in parts", "file", file.Path, "parts", len(parts))
+
+		var summaries strings.Builder
+		for n, part := range parts {
+			summary, err := summarize(ctx, c.createPartTask(task, *file, part, n, len(parts)))
+			if err != nil {
+				return errors.Wrap(err, errors.ErrorTypeInternal, "summarizeParts",
+					fmt.Sprintf("failed to summarize lines %d-%d of %s", part.StartLine, part.EndLine, file.Path))
+			}
+			summaries.WriteString(fmt.Sprintf("Lines %d-%d:\n%s\n\n", part.StartLine, part.EndLine, strings.TrimSpace(summary)))
+		}
+		file.Content = strings.TrimSpace(summaries.String())
+		file.Language = string(InputTypeText)
+		file.Purpose = "Summaries of the parts of a file too long to summarize whole"
+	}
+	return nil
+}
+
+// createPartTask creates the task summarizing one part of a file
+func (c *SummarizeCommand) createPartTask(task *agent.Task, file agent.FileContext, part chunk.Chunk, n, total int) *agent.Task {
+	requirements := []string{
+		fmt.Sprintf("Summarize lines %d-%d of %s, part %d of %d; the other parts are summarized separately",
+			part.StartLine, part.EndLine, file.Path, n+1, total),
+		"Identify the types, functions, and dependencies it declares and what they do",
+	}
+	if c.Focus != "" {
+		requirements = append(requirements, fmt.Sprintf("Focus specifically on: %s", c.Focus))
+	}
+	requirements = append(requirements, "Provide a concise summary formatted as markdown without headings")
+
+	file.Content = part.Content
+	file.Purpose = "Part of a file to summarize"
+	return &agent.Task{
+		ID: fmt.Sprintf("%s_%s_%d", task.ID,
+			strings.ReplaceAll(filepath.ToSlash(file.Path), "/", "_"), part.StartLine),
+		Type:        agent.TaskTypeAnalyze,
+		Description: fmt.Sprintf("Summarize lines %d-%d of %s", part.StartLine, part.EndLine, file.Path),
+		Context: agent.TaskContext{
+			Files:        []agent.FileContext{file},
+			Requirements: requirements,
+			ProjectInfo:  task.Context.ProjectInfo,
+		},
+		Priority:  task.Priority,
+		CreatedAt: task.CreatedAt,
+	}
+}
+
+// summarizePart runs a part summary task with the agent system
+func (c *SummarizeCommand) summarizePart(ctx context.Context, task *agent.Task) (string, error) {
+	result, err := c.executeSummarization(ctx, task)
+	if err != nil {
+		return "", err
+	}
+	return summaryText(result)
+}
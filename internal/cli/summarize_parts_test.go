@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/chunk"
+	"github.com/dshills/sigil/internal/errors"
+)
+
+func TestSummarizeCommand_summarizeParts(t *testing.T) {
+	var source strings.Builder
+	source.WriteString("package store\n")
+	for _, name := range []string{"Open", "Get", "Put"} {
+		source.WriteString(fmt.Sprintf("\n// %s does a thing\nfunc %s() {\n\t_ = 1\n\t_ = 2\n}\n", name, name))
+	}
+
+	cmd := NewSummarizeCommand()
+	cmd.Focus = "locking"
+	cmd.chunking = chunk.DefaultRules()
+	cmd.chunking.Summary.Size = 12
+	task := &agent.Task{ID: "summarize_1", Context: agent.TaskContext{Files: []agent.FileContext{
+		{Path: "store/store.go", Content: source.String(), Language: "go"},
+		{Path: "store/small.go", Content: "package store\n", Language: "go"},
+	}}}
+
+	var parts []*agent.Task
+	summarize := func(_ context.Context, part *agent.Task) (string, error) {
+		parts = append(parts, part)
+		return "summary of " + part.Description, nil
+	}
+	require.NoError(t, cmd.summarizeParts(context.Background(), task, summarize))
+
+	// Parts end at declarations rather than mid-function
+	require.Len(t, parts, 2)
+	assert.Equal(t, "Summarize lines 1-7 of store/store.go", parts[0].Description)
+	assert.Equal(t, "Summarize lines 8-19 of store/store.go", parts[1].Description)
+	assert.True(t, strings.HasSuffix(parts[0].Context.Files[0].Content, "func Open() {\n\t_ = 1\n\t_ = 2\n}"))
+	assert.Contains(t, strings.Join(parts[1].Context.Requirements, "\n"), "part 2 of 2")
+	assert.Contains(t, strings.Join(parts[1].Context.Requirements, "\n"), "Focus specifically on: locking")
+
+	reduced := task.Context.Files[0]
+	assert.Equal(t, "Lines 1-7:\nsummary of Summarize lines 1-7 of store/store.go\n\n"+
+		"Lines 8-19:\nsummary of Summarize lines 8-19 of store/store.go", reduced.Content)
+	assert.Equal(t, "package store\n", task.Context.Files[1].Content, "small files are summarized whole")
+
+	failing := func(context.Context, *agent.Task) (string, error) {
+		return "", errors.New(errors.ErrorTypeModel, "summarize", "quota exceeded")
+	}
+	task.Context.Files[0].Content = source.String()
+	assert.ErrorContains(t, cmd.summarizeParts(context.Background(), task, failing), "failed to summarize lines 1-7 of store/store.go")
+}
@@ -34,6 +34,7 @@ func TestSummarizeCommand_CreateCobraCommand(t *testing.T) {
 	assert.NotNil(t, cobraCmd.Flags().Lookup("focus"))
 	assert.NotNil(t, cobraCmd.Flags().Lookup("format"))
 	assert.NotNil(t, cobraCmd.Flags().Lookup("output"))
+	assert.NotNil(t, cobraCmd.Flags().Lookup("outline"))
 }
 
 func TestSummarizeCommand_validateInputs(t *testing.T) {
@@ -334,6 +335,29 @@ func TestSummarizeCommand_formatJSON(t *testing.T) {
 	assert.Contains(t, result, `"timestamp":`)
 }
 
+func TestSummarizeCommand_outline(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "store.go")
+	require.NoError(t, os.WriteFile(source, []byte("package store\n\n// Get returns an entry\nfunc Get(key string) string {\n\treturn key\n}\n"), 0644))
+	notes := filepath.Join(tmpDir, "notes.txt")
+	require.NoError(t, os.WriteFile(notes, []byte("no declarations\n"), 0644))
+
+	cmd := NewSummarizeCommand()
+	cmd.Files = []string{source, notes}
+	assert.NotContains(t, cmd.formatMarkdown("summary"), "## Outline")
+
+	cmd.Outline = true
+	markdown := cmd.formatMarkdown("summary")
+	assert.Contains(t, markdown, "## Outline\n\n### `"+source+"`\n\n```\n1: package store\n4: func Get(key string) string // Get returns an entry\n```\n")
+	assert.NotContains(t, markdown, "### `"+notes)
+
+	assert.Contains(t, cmd.formatText("summary"), "Outline:\n--------\n"+source+":\n1: package store\n")
+	assert.Contains(t, cmd.formatJSON("summary"), `"signature": "func Get(key string) string"`)
+
+	cmd.Format = FormatHTML
+	assert.Error(t, cmd.validateInputs())
+}
+
 func TestSummarizeCommand_formatHTML(t *testing.T) {
 	cmd := NewSummarizeCommand()
 	cmd.Files = []string{"index.js"}
@@ -0,0 +1,357 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// Summary levels, from the smallest rollup to the whole
+const (
+	LevelPackage = "package"
+	LevelModule  = "module"
+	LevelProject = "project"
+)
+
+// moduleManifests mark a directory as the root of a module
+var moduleManifests = []string{"go.mod", "package.json", "pyproject.toml", "setup.py", "Cargo.toml", "pom.xml", "build.gradle"}
+
+// skippedDirs are never descended into when summarizing recursively
+var skippedDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+	"testdata":     true,
+}
+
+// summaryNode is one directory in a recursive summary. Its summary rolls up
+// its own files and the summaries of its children.
+type summaryNode struct {
+	Path     string         `json:"path"`
+	Level    string         `json:"level"`
+	Files    []string       `json:"files,omitempty"`
+	Summary  string         `json:"summary"`
+	Children []*summaryNode `json:"children,omitempty"`
+}
+
+// summarizeFunc produces a summary for a task
+type summarizeFunc func(ctx context.Context, task *agent.Task) (string, error)
+
+// hasDirectory reports whether any input is a directory
+func (c *SummarizeCommand) hasDirectory() bool {
+	for _, file := range c.Files {
+		if info, err := os.Stat(file); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// executeRecursive summarizes the inputs as a tree of directory rollups
+func (c *SummarizeCommand) executeRecursive(ctx context.Context) error {
+	root, err := c.buildSummaryTree()
+	if err != nil {
+		return err
+	}
+
+	if err := c.summarizeTree(ctx, root, c.summarizeTask); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "executeRecursive", "failed to execute summarization")
+	}
+
+	formatted, err := c.formatTree(root)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "executeRecursive", "failed to format output")
+	}
+	if c.Format == FormatMarkdown {
+		formatted = lintDocument(formatted)
+	}
+	return c.writeOutput(formatted)
+}
+
+// buildSummaryTree builds the tree of directories to summarize. A single
+// directory input is the project; otherwise the inputs are combined under
+// one project node.
+func (c *SummarizeCommand) buildSummaryTree() (*summaryNode, error) {
+	project := &summaryNode{Path: ".", Level: LevelProject}
+	for _, file := range c.Files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeInput, "buildSummaryTree",
+				fmt.Sprintf("failed to stat: %s", file))
+		}
+		if !info.IsDir() {
+			project.Files = append(project.Files, file)
+			continue
+		}
+
+		node, err := c.buildDirectoryNode(file, 0)
+		if err != nil {
+			return nil, err
+		}
+		if node != nil {
+			project.Children = append(project.Children, node)
+		}
+	}
+
+	if len(project.Files) == 0 && len(project.Children) == 1 {
+		project = project.Children[0]
+		project.Level = LevelProject
+	}
+	if len(project.Files) == 0 && len(project.Children) == 0 {
+		return nil, errors.New(errors.ErrorTypeInput, "buildSummaryTree", "no source files found to summarize")
+	}
+	return project, nil
+}
+
+// buildDirectoryNode builds the node for dir. Directories deeper than the
+// depth limit are folded into their ancestor at the limit. Directories
+// without source files are left out.
+func (c *SummarizeCommand) buildDirectoryNode(dir string, depth int) (*summaryNode, error) {
+	node := &summaryNode{Path: dir, Level: LevelPackage}
+	for _, manifest := range moduleManifests {
+		if c.fileExists(filepath.Join(dir, manifest)) {
+			node.Level = LevelModule
+			break
+		}
+	}
+
+	if c.Depth > 0 && depth >= c.Depth {
+		files, err := c.collectSourceFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		node.Files = files
+	} else {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeFS, "buildDirectoryNode",
+				fmt.Sprintf("failed to read directory: %s", dir))
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if entry.IsDir() {
+				if c.skipDir(entry.Name()) {
+					continue
+				}
+				child, err := c.buildDirectoryNode(path, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				if child != nil {
+					node.Children = append(node.Children, child)
+				}
+			} else if c.isSourceFile(entry.Name()) {
+				node.Files = append(node.Files, path)
+			}
+		}
+	}
+
+	if len(node.Files) == 0 && len(node.Children) == 0 {
+		logger.Debug("skipping directory without source files", "dir", dir)
+		return nil, nil
+	}
+	return node, nil
+}
+
+// collectSourceFiles returns every source file below dir
+func (c *SummarizeCommand) collectSourceFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && c.skipDir(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if c.isSourceFile(entry.Name()) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "collectSourceFiles",
+			fmt.Sprintf("failed to walk directory: %s", dir))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// skipDir reports whether a directory is left out of recursive summaries
+func (c *SummarizeCommand) skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || skippedDirs[name]
+}
+
+// isSourceFile reports whether a file is code in a language we recognize
+func (c *SummarizeCommand) isSourceFile(name string) bool {
+	return !strings.HasPrefix(name, ".") && c.detectLanguage(name) != string(InputTypeText)
+}
+
+// summarizeTree summarizes each node after its children, so every rollup
+// builds on the summaries below it
+func (c *SummarizeCommand) summarizeTree(ctx context.Context, node *summaryNode, summarize summarizeFunc) error {
+	for _, child := range node.Children {
+		if err := c.summarizeTree(ctx, child, summarize); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("summarizing directory", "path", node.Path, "level", node.Level,
+		"files", len(node.Files), "children", len(node.Children))
+
+	task, err := c.createNodeTask(node)
+	if err != nil {
+		return err
+	}
+	if err := c.summarizeParts(ctx, task, summarize); err != nil {
+		return err
+	}
+	summary, err := summarize(ctx, task)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "summarizeTree",
+			fmt.Sprintf("failed to summarize %s", node.Path))
+	}
+	node.Summary = summary
+	return nil
+}
+
+// createNodeTask creates the task summarizing one node of the tree
+func (c *SummarizeCommand) createNodeTask(node *summaryNode) (*agent.Task, error) {
+	fileContexts := make([]agent.FileContext, 0, len(node.Files))
+	for _, filePath := range node.Files {
+		content, err := c.readFile(filePath)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeInput, "createNodeTask",
+				fmt.Sprintf("failed to read file: %s", filePath))
+		}
+		fileContexts = append(fileContexts, agent.FileContext{
+			Path:        filePath,
+			Content:     content,
+			Language:    c.detectLanguage(filePath),
+			Purpose:     "Code to summarize",
+			IsReference: true,
+		})
+	}
+
+	var requirements []string
+	if len(node.Children) == 0 {
+		requirements = append(requirements,
+			fmt.Sprintf("Summarize the purpose and key components of this %s", node.Level),
+			"Identify its main types, functions, and dependencies")
+	} else {
+		requirements = append(requirements,
+			fmt.Sprintf("Synthesize the summaries of its parts into an overview of the whole %s", node.Level),
+			"Explain how the parts relate and where the main responsibilities live",
+			"Do not repeat the part summaries")
+		for _, child := range node.Children {
+			requirements = append(requirements,
+				fmt.Sprintf("Summary of %s %s:\n%s", child.Level, child.Path, child.Summary))
+		}
+	}
+
+	if c.Focus != "" {
+		requirements = append(requirements, fmt.Sprintf("Focus specifically on: %s", c.Focus))
+	}
+	if c.Brief {
+		requirements = append(requirements, "Provide a concise, high-level summary")
+	}
+	requirements = append(requirements, "Format the summary as markdown without top-level headings")
+
+	return &agent.Task{
+		ID:          fmt.Sprintf("summarize_%d_%s", c.startTime.Unix(), strings.ReplaceAll(filepath.ToSlash(node.Path), "/", "_")),
+		Type:        agent.TaskTypeAnalyze,
+		Description: fmt.Sprintf("Summarize the %s %s", node.Level, node.Path),
+		Context: agent.TaskContext{
+			Files:        fileContexts,
+			Requirements: requirements,
+			ProjectInfo: agent.ProjectInfo{
+				Language:  c.detectProjectLanguage(),
+				Framework: c.detectFramework(),
+				Style:     "standard",
+			},
+		},
+		Priority:  agent.PriorityMedium,
+		CreatedAt: c.startTime,
+	}, nil
+}
+
+// summarizeTask runs a summary task with the agent system
+func (c *SummarizeCommand) summarizeTask(ctx context.Context, task *agent.Task) (string, error) {
+	result, err := c.executeSummarization(ctx, task)
+	if err != nil {
+		return "", err
+	}
+	c.quality.checkResult(ctx, "summary", task, result)
+	summary, err := summaryText(result)
+	if err != nil {
+		return "", err
+	}
+	return c.refs.check(summary), nil
+}
+
+// formatTree formats a summary tree as markdown or JSON
+func (c *SummarizeCommand) formatTree(root *summaryNode) (string, error) {
+	if c.Format == string(OutputFormatJSON) && c.jsonVersion == JSONVersion2 {
+		return formatEnvelope("summarize", c.startTime, map[string]interface{}{
+			"focus": c.Focus,
+			"brief": c.Brief,
+			"depth": c.Depth,
+			"tree":  root,
+		})
+	}
+	if c.Format == string(OutputFormatJSON) {
+		data := map[string]interface{}{
+			"focus":     c.Focus,
+			"brief":     c.Brief,
+			"depth":     c.Depth,
+			"timestamp": c.startTime.Format("2006-01-02T15:04:05Z07:00"),
+			"tree":      root,
+		}
+		jsonBytes, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(jsonBytes), nil
+	}
+
+	var result strings.Builder
+	result.WriteString("# Code Summary\n\n")
+	if c.Focus != "" {
+		result.WriteString(fmt.Sprintf("**Focus:** %s\n\n", c.Focus))
+	}
+	writeMarkdownNode(&result, root, 2)
+	return result.String(), nil
+}
+
+// writeMarkdownNode writes a node and its children as nested sections
+func writeMarkdownNode(result *strings.Builder, node *summaryNode, level int) {
+	result.WriteString(fmt.Sprintf("%s %s `%s`\n\n", strings.Repeat("#", min(level, 6)),
+		strings.ToUpper(node.Level[:1])+node.Level[1:], node.Path))
+
+	if len(node.Files) > 0 {
+		result.WriteString("**Files:**")
+		for _, file := range node.Files {
+			if filepath.Dir(file) == node.Path {
+				file = filepath.Base(file)
+			}
+			result.WriteString(fmt.Sprintf(" `%s`", file))
+		}
+		result.WriteString("\n\n")
+	}
+
+	result.WriteString(strings.TrimSpace(node.Summary))
+	result.WriteString("\n\n")
+
+	for _, child := range node.Children {
+		writeMarkdownNode(result, child, level+1)
+	}
+}
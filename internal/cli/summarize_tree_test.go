@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSummaryFixture creates a small project with a module, nested
+// packages, and directories that must be skipped
+func writeSummaryFixture(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                      "module example.com/app",
+		"main.go":                     "package main",
+		"README.md":                   "# App",
+		"internal/store/store.go":     "package store",
+		"internal/store/cache/lru.go": "package cache",
+		"internal/empty/notes.txt":    "nothing to see",
+		"vendor/dep/dep.go":           "package dep",
+		".git/hooks/hook.go":          "package hook",
+	}
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+	}
+	return root
+}
+
+func TestSummarizeCommand_buildSummaryTree(t *testing.T) {
+	root := writeSummaryFixture(t)
+
+	cmd := NewSummarizeCommand()
+	cmd.Files = []string{root}
+	cmd.Recursive = true
+
+	tree, err := cmd.buildSummaryTree()
+	require.NoError(t, err)
+
+	assert.Equal(t, root, tree.Path)
+	assert.Equal(t, LevelProject, tree.Level)
+	assert.Equal(t, []string{filepath.Join(root, "main.go")}, tree.Files)
+
+	require.Len(t, tree.Children, 1, "empty, vendor and hidden directories are skipped")
+	internal := tree.Children[0]
+	assert.Equal(t, filepath.Join(root, "internal"), internal.Path)
+	assert.Empty(t, internal.Files)
+
+	require.Len(t, internal.Children, 1)
+	store := internal.Children[0]
+	assert.Equal(t, LevelPackage, store.Level)
+	assert.Equal(t, []string{filepath.Join(root, "internal/store/store.go")}, store.Files)
+	require.Len(t, store.Children, 1)
+	assert.Equal(t, filepath.Join(root, "internal/store/cache"), store.Children[0].Path)
+}
+
+func TestSummarizeCommand_buildSummaryTree_Depth(t *testing.T) {
+	root := writeSummaryFixture(t)
+
+	cmd := NewSummarizeCommand()
+	cmd.Files = []string{root}
+	cmd.Recursive = true
+	cmd.Depth = 1
+
+	tree, err := cmd.buildSummaryTree()
+	require.NoError(t, err)
+
+	// Directories below the limit fold into their ancestor at the limit
+	require.Len(t, tree.Children, 1)
+	internal := tree.Children[0]
+	assert.Empty(t, internal.Children)
+	assert.Equal(t, []string{
+		filepath.Join(root, "internal/store/cache/lru.go"),
+		filepath.Join(root, "internal/store/store.go"),
+	}, internal.Files)
+}
+
+func TestSummarizeCommand_buildSummaryTree_MultipleInputs(t *testing.T) {
+	root := writeSummaryFixture(t)
+
+	cmd := NewSummarizeCommand()
+	cmd.Files = []string{filepath.Join(root, "internal"), filepath.Join(root, "main.go")}
+	cmd.Recursive = true
+
+	tree, err := cmd.buildSummaryTree()
+	require.NoError(t, err)
+
+	assert.Equal(t, LevelProject, tree.Level)
+	assert.Equal(t, []string{filepath.Join(root, "main.go")}, tree.Files)
+	require.Len(t, tree.Children, 1)
+	assert.Equal(t, LevelPackage, tree.Children[0].Level)
+}
+
+func TestSummarizeCommand_summarizeTree(t *testing.T) {
+	root := writeSummaryFixture(t)
+
+	cmd := NewSummarizeCommand()
+	cmd.Files = []string{root}
+	cmd.Recursive = true
+	cmd.Focus = "storage"
+
+	tree, err := cmd.buildSummaryTree()
+	require.NoError(t, err)
+
+	var order []string
+	summarize := func(_ context.Context, task *agent.Task) (string, error) {
+		order = append(order, task.Description)
+		return "summary of " + task.Description, nil
+	}
+	require.NoError(t, cmd.summarizeTree(context.Background(), tree, summarize))
+
+	// Children are summarized before the rollups that build on them
+	require.Len(t, order, 4)
+	assert.Contains(t, order[0], "cache")
+	assert.Contains(t, order[3], "project")
+
+	store := tree.Children[0].Children[0]
+	assert.Equal(t, "summary of Summarize the package "+store.Path, store.Summary)
+
+	task, err := cmd.createNodeTask(tree)
+	require.NoError(t, err)
+	requirements := strings.Join(task.Context.Requirements, "\n")
+	assert.Contains(t, requirements, tree.Children[0].Summary)
+	assert.Contains(t, requirements, "Focus specifically on: storage")
+	require.Len(t, task.Context.Files, 1)
+	assert.Equal(t, "package main", task.Context.Files[0].Content)
+}
+
+func TestSummarizeCommand_formatTree(t *testing.T) {
+	tree := &summaryNode{
+		Path:    "app",
+		Level:   LevelProject,
+		Files:   []string{"app/main.go"},
+		Summary: "The whole app",
+		Children: []*summaryNode{
+			{Path: "app/store", Level: LevelPackage, Summary: "Stores things"},
+		},
+	}
+
+	cmd := NewSummarizeCommand()
+	markdown, err := cmd.formatTree(tree)
+	require.NoError(t, err)
+	assert.Contains(t, markdown, "## Project `app`\n\n**Files:** `main.go`\n\nThe whole app")
+	assert.Contains(t, markdown, "### Package `app/store`\n\nStores things")
+
+	cmd.Format = "json"
+	output, err := cmd.formatTree(tree)
+	require.NoError(t, err)
+
+	var parsed struct {
+		Tree summaryNode `json:"tree"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &parsed))
+	assert.Equal(t, *tree, parsed.Tree)
+}
+
+func TestSummarizeCommand_validateInputs_Directory(t *testing.T) {
+	dir := t.TempDir()
+
+	cmd := NewSummarizeCommand()
+	cmd.Files = []string{dir}
+	assert.Error(t, cmd.validateInputs(), "directories need --recursive")
+
+	cmd.Recursive = true
+	assert.NoError(t, cmd.validateInputs())
+
+	cmd.Format = "html"
+	assert.Error(t, cmd.validateInputs(), "recursive summaries are markdown or json")
+
+	cmd.Format = "json"
+	cmd.Depth = -1
+	assert.Error(t, cmd.validateInputs())
+}
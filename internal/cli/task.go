@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/sandbox"
+	"github.com/dshills/sigil/internal/tasks"
+)
+
+// NewTaskCommand creates the task template command
+func NewTaskCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "task",
+		Short: "Run the repository's task templates",
+		Long: `Run reusable tasks defined in .sigil/tasks, one YAML file per template.
+
+A template gives a task's description, type, requirements, constraints and
+the files it works on by default, as globs. Any of them may reference
+parameters as {{name}}, given with --param name=value when the template is
+run. Templates are run by the same team of agents as sigil multi.
+
+  # .sigil/tasks/security-sweep.yml
+  description: Find and fix injection and authorization flaws in {{scope}}
+  type: review
+  params:
+    - name: scope
+      description: Directory to sweep
+      default: ./
+  requirements:
+    - Report every finding with its file and line
+  constraints:
+    - type: security
+      description: Do not weaken existing input validation
+      severity: error
+  files: ["{{scope}}**/*.go"]`,
+		Example: `  # List the templates
+  sigil task list
+
+  # Sweep one directory
+  sigil task run security-sweep --param scope=payments/`,
+	}
+
+	cmd.AddCommand(newTaskListCommand())
+	cmd.AddCommand(NewTaskRunCommand().GetCobraCommand())
+
+	return cmd
+}
+
+// newTaskListCommand creates the list subcommand
+func newTaskListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List task templates",
+		Long:  "List the task templates in .sigil/tasks with their parameters.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo, err := git.NewRepository(".")
+			if err != nil {
+				return errors.Wrap(err, errors.ErrorTypeGit, "list", "failed to open git repository")
+			}
+			templates, err := tasks.List(repo.Root)
+			if err != nil {
+				return err
+			}
+			return writeTaskList(os.Stdout, templates)
+		},
+	}
+}
+
+// writeTaskList writes a table of templates
+func writeTaskList(out io.Writer, templates []*tasks.Template) error {
+	if len(templates) == 0 {
+		fmt.Fprintf(out, "No task templates in %s.\n", tasks.Dir)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tPARAMETERS\tDESCRIPTION")
+	fmt.Fprintln(w, "----\t----\t----------\t-----------")
+	for _, template := range templates {
+		description, _, _ := strings.Cut(strings.TrimSpace(template.Description), "\n")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", template.Name, template.Type, formatTaskParams(template.Parameters()), description)
+	}
+	return w.Flush()
+}
+
+// formatTaskParams lists parameter names, marking those with a default
+// with '?'
+func formatTaskParams(params []tasks.Param) string {
+	if len(params) == 0 {
+		return "-"
+	}
+	names := make([]string, len(params))
+	for i, param := range params {
+		names[i] = param.Name
+		if param.Default != nil {
+			names[i] += "?"
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// TaskRunCommand runs a task template with the multi-agent team
+type TaskRunCommand struct {
+	*MultiAgentCommand
+	Params []string
+}
+
+// NewTaskRunCommand creates a new task run command
+func NewTaskRunCommand() *TaskRunCommand {
+	c := &TaskRunCommand{MultiAgentCommand: NewMultiAgentCommand()}
+	c.BaseCommand = NewBaseCommand("run <template>", "Run a task template",
+		`Run a task template from .sigil/tasks with the given parameters.
+
+The task works on the files the template's globs match, unless an input
+source such as --file, --dir or --git is given.`)
+	return c
+}
+
+// Execute runs the task run command
+func (c *TaskRunCommand) Execute(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New(errors.ErrorTypeInput, "Execute", "a task template name is required")
+	}
+
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to open git repository")
+	}
+	template, err := tasks.Load(repo.Root, args[0])
+	if err != nil {
+		return err
+	}
+	values, err := parseArgPairs("--param", c.Params)
+	if err != nil {
+		return err
+	}
+	rendered, err := template.Render(values)
+	if err != nil {
+		return err
+	}
+
+	c.TaskType = rendered.Type
+	c.Requirements = rendered.Requirements
+	c.Constraints = taskConstraints(rendered.Constraints)
+
+	if err := c.RunPreChecks(); err != nil {
+		return err
+	}
+
+	var inputCtx *CommandContext
+	if c.HasInputSource() || len(rendered.Files) == 0 {
+		inputCtx, err = NewInputHandler(c.GetCommonFlags()).GetInput()
+	} else {
+		inputCtx, err = templateInput(repo.Root, rendered)
+	}
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInput, "Execute", "failed to get input")
+	}
+
+	return c.run(ctx, rendered.Description, inputCtx)
+}
+
+// taskConstraints converts template constraints to task constraints, as
+// warnings unless they say otherwise
+func taskConstraints(constraints []tasks.Constraint) []agent.Constraint {
+	converted := make([]agent.Constraint, 0, len(constraints))
+	for _, constraint := range constraints {
+		severity := agent.Severity(strings.ToLower(constraint.Severity))
+		if severity == "" {
+			severity = agent.SeverityWarning
+		}
+		converted = append(converted, agent.Constraint{
+			Type:        agent.ConstraintType(strings.ToLower(constraint.Type)),
+			Description: constraint.Description,
+			Severity:    severity,
+		})
+	}
+	return converted
+}
+
+// templateInput reads the files of the repository at root that the
+// template's globs match. Their paths are relative to the working directory,
+// as those of --file and --dir are.
+func templateInput(root string, template *tasks.Template) (*CommandContext, error) {
+	files, err := (&git.Repository{Path: root}).ListFiles()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeGit, "templateInput", "failed to list repository files")
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "templateInput", "failed to get working directory")
+	}
+
+	ctx := &CommandContext{InputType: InputTypeDirectory}
+	var combined strings.Builder
+	for _, file := range files {
+		matched := false
+		for _, glob := range template.Files {
+			ok, err := sandbox.MatchPath(glob, file)
+			if err != nil {
+				return nil, errors.ValidationError("templateInput", fmt.Sprintf("invalid file glob in task template %s: %s", template.Name, glob))
+			}
+			if ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		path := filepath.Join(root, file)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeFS, "templateInput", "failed to read file")
+		}
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			path = rel
+		}
+		ctx.Files = append(ctx.Files, FileInput{Path: path, Content: string(content)})
+		combined.WriteString(fmt.Sprintf("=== %s ===\n%s\n\n", path, content))
+	}
+
+	if len(ctx.Files) == 0 {
+		return nil, errors.ValidationError("templateInput", fmt.Sprintf("no files match %s", strings.Join(template.Files, ", "))).
+			WithHint("check the template's parameters, or give the files with --file or --dir")
+	}
+	ctx.Input = combined.String()
+	return ctx, nil
+}
+
+// GetCobraCommand returns the cobra command for task run
+func (c *TaskRunCommand) GetCobraCommand() *cobra.Command {
+	cmd := c.BaseCommand.GetCobraCommand()
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return c.Execute(cmd.Context(), args)
+	}
+
+	cmd.Flags().StringArrayVar(&c.Params, "param", nil, "Template parameter as name=value (repeatable)")
+	c.addAgentFlags(cmd)
+
+	cmd.Example = `  # Sweep the payments package
+  sigil task run security-sweep --param scope=payments/
+
+  # Run a template on the staged changes instead of its files
+  sigil task run release-check --git --staged --secure`
+
+	return cmd
+}
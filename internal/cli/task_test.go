@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/tasks"
+)
+
+func TestWriteTaskList(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeTaskList(&out, nil))
+	assert.Equal(t, "No task templates in .sigil/tasks.\n", out.String())
+
+	scope := "./"
+	out.Reset()
+	require.NoError(t, writeTaskList(&out, []*tasks.Template{{
+		Name:        "security-sweep",
+		Type:        "review",
+		Description: "Sweep {{scope}} for {{kind}} flaws\nin detail",
+		Params:      []tasks.Param{{Name: "scope", Default: &scope}},
+	}}))
+	assert.Contains(t, out.String(), "security-sweep  review  scope?,kind  Sweep {{scope}} for {{kind}} flaws\n")
+}
+
+func TestTaskConstraints(t *testing.T) {
+	assert.Equal(t, []agent.Constraint{
+		{Type: agent.ConstraintTypeSecurity, Description: "No secrets", Severity: agent.SeverityError},
+		{Type: agent.ConstraintTypeStyle, Description: "Short functions", Severity: agent.SeverityWarning},
+	}, taskConstraints([]tasks.Constraint{
+		{Type: "Security", Description: "No secrets", Severity: "error"},
+		{Type: "style", Description: "Short functions"},
+	}))
+}
+
+func TestTemplateInput(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, exec.Command("git", "-C", root, "init", "-q").Run())
+	for path, content := range map[string]string{
+		"payments/charge.go":     "package payments",
+		"payments/charge.md":     "# Charges",
+		"payments/api/refund.go": "package api",
+		"accounts/user.go":       "package accounts",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(content), 0644))
+	}
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(filepath.Join(root, "payments")))
+	defer os.Chdir(wd)
+
+	ctx, err := templateInput(root, &tasks.Template{Name: "sweep", Files: []string{"payments/**/*.go"}})
+	require.NoError(t, err)
+	var paths []string
+	for _, file := range ctx.Files {
+		paths = append(paths, file.Path)
+	}
+	assert.ElementsMatch(t, []string{filepath.Join("api", "refund.go"), "charge.go"}, paths, "paths are relative to the working directory")
+	assert.Contains(t, ctx.Input, "package api")
+
+	_, err = templateInput(root, &tasks.Template{Name: "sweep", Files: []string{"billing/**"}})
+	assert.ErrorContains(t, err, "no files match billing/**")
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/telemetry"
+	"github.com/dshills/sigil/internal/version"
+)
+
+// telemetryStore is where command runs are recorded
+var telemetryStore = telemetry.Default()
+
+// NewTelemetryCommand creates the telemetry command
+func NewTelemetryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Manage anonymous usage telemetry",
+		Long: `Manage the anonymous usage telemetry sigil records for adoption metrics.
+
+Telemetry is off until you enable it. When on, sigil records the name of each
+command run (such as "review" or "task run"), how long it took, whether it
+succeeded and the error code of a failure, with the sigil version. It never
+records code, prompts, arguments, file names, repositories or who ran it.
+
+Events stay on this machine, under ~/.config/sigil/telemetry; sigil sends them
+nowhere. Export them to share them. The SIGIL_TELEMETRY environment variable,
+when set to true or false, overrides the choice made here.`,
+		Example: `  # Opt in, and see what was recorded
+  sigil telemetry enable
+  sigil telemetry export --output usage.json
+
+  # Opt out and delete the events
+  sigil telemetry disable --clear`,
+	}
+
+	cmd.AddCommand(newTelemetryToggleCommand(true))
+	cmd.AddCommand(newTelemetryToggleCommand(false))
+	cmd.AddCommand(newTelemetryStatusCommand())
+	cmd.AddCommand(newTelemetryExportCommand())
+
+	return cmd
+}
+
+// newTelemetryToggleCommand creates the enable or disable subcommand
+func newTelemetryToggleCommand(enable bool) *cobra.Command {
+	var clear bool
+	cmd := &cobra.Command{
+		Use:   "enable",
+		Short: "Opt in to usage telemetry",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := telemetryStore.SetEnabled(enable); err != nil {
+				return err
+			}
+			if clear {
+				if err := telemetryStore.Clear(); err != nil {
+					return err
+				}
+			}
+			return writeTelemetryStatus(cmd.OutOrStdout(), telemetryStore)
+		},
+	}
+	if !enable {
+		cmd.Use = "disable"
+		cmd.Short = "Opt out of usage telemetry"
+		cmd.Flags().BoolVar(&clear, "clear", false, "Also delete the recorded events")
+	}
+	return cmd
+}
+
+// newTelemetryStatusCommand creates the status subcommand
+func newTelemetryStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether usage telemetry is on",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return writeTelemetryStatus(cmd.OutOrStdout(), telemetryStore)
+		},
+	}
+}
+
+// writeTelemetryStatus writes whether telemetry is on, why, and how many
+// events are recorded
+func writeTelemetryStatus(out io.Writer, store *telemetry.Store) error {
+	state := "off"
+	if store.Enabled() {
+		state = "on"
+	}
+	if value, ok := os.LookupEnv(telemetry.EnvVar); ok {
+		state += fmt.Sprintf(" (%s=%s)", telemetry.EnvVar, value)
+	}
+	events, err := store.Events()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Telemetry: %s\n", state)
+	fmt.Fprintf(out, "Events:    %d in %s\n", len(events), store.EventsPath())
+	return nil
+}
+
+// telemetryExport is the exported telemetry
+type telemetryExport struct {
+	ExportedAt string                     `json:"exported_at"`
+	Version    string                     `json:"sigil_version"`
+	Commands   []telemetry.CommandSummary `json:"commands"`
+	Events     []telemetry.Event          `json:"events"`
+}
+
+// newTelemetryExportCommand creates the export subcommand
+func newTelemetryExportCommand() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the recorded events as JSON",
+		Long: `Export the recorded events as JSON, with a summary of each command's
+runs, failures, average duration and error codes.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			events, err := telemetryStore.Events()
+			if err != nil {
+				return err
+			}
+			export := telemetryExport{
+				ExportedAt: time.Now().UTC().Format(time.RFC3339),
+				Version:    version.Get().Version,
+				Commands:   telemetry.Summarize(events),
+				Events:     events,
+			}
+			if export.Events == nil {
+				export.Events = []telemetry.Event{}
+			}
+			data, err := json.MarshalIndent(export, "", "  ")
+			if err != nil {
+				return errors.Wrap(err, errors.ErrorTypeOutput, "export", "failed to marshal telemetry")
+			}
+			data = append(data, '\n')
+
+			if output == "" {
+				_, err = cmd.OutOrStdout().Write(data)
+				return err
+			}
+			if err := os.WriteFile(output, data, 0600); err != nil {
+				return errors.Wrap(err, errors.ErrorTypeFS, "export", "failed to write telemetry export")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the export to a file instead of stdout")
+	return cmd
+}
+
+// recordTelemetry records a command run when the user opted in
+func recordTelemetry(cmd *cobra.Command, start time.Time, err error) {
+	if cmd == nil {
+		return
+	}
+	event := telemetry.Event{
+		Time:     start.UTC(),
+		Command:  strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name())),
+		Duration: time.Since(start).Milliseconds(),
+		Status:   telemetry.StatusSuccess,
+		Version:  version.Get().Version,
+	}
+	if event.Command == "" {
+		event.Command = rootCmd.Name()
+	}
+	if err != nil {
+		event.Status = telemetry.StatusError
+		event.Code = string(errors.CodeOf(err))
+	}
+	if err := telemetryStore.Record(event); err != nil {
+		logger.Debug("failed to record telemetry", "error", err)
+	}
+}
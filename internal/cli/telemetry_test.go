@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/telemetry"
+)
+
+func TestRecordTelemetry(t *testing.T) {
+	previous := telemetryStore
+	defer func() { telemetryStore = previous }()
+	telemetryStore = telemetry.NewStore(t.TempDir())
+	t.Setenv(telemetry.EnvVar, "1")
+
+	parent := &cobra.Command{Use: "task"}
+	run := &cobra.Command{Use: "run <template>"}
+	parent.AddCommand(run)
+	rootCmd.AddCommand(parent)
+	defer rootCmd.RemoveCommand(parent)
+
+	start := time.Now().Add(-2 * time.Second)
+	recordTelemetry(run, start, nil)
+	recordTelemetry(run, start, errors.New(errors.ErrorTypeFS, "Load", "task template not found: sweep").WithCode(errors.CodeNotFound))
+
+	events, err := telemetryStore.Events()
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "task run", events[0].Command)
+	assert.Equal(t, telemetry.StatusSuccess, events[0].Status)
+	assert.GreaterOrEqual(t, events[0].Duration, int64(2000))
+	assert.Equal(t, telemetry.StatusError, events[1].Status)
+	assert.Equal(t, string(errors.CodeNotFound), events[1].Code)
+}
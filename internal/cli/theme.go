@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/theme"
+)
+
+// initTheme sets how terminal output is colored: in the configured theme,
+// when --color says or, without it, when the configuration does
+func initTheme() error {
+	cfg := getConfig().Theme
+	color := cfg.Color
+	if rootCmd.PersistentFlags().Changed("color") {
+		color = colorFlag
+	}
+	mode, err := theme.ParseMode(color)
+	if err != nil {
+		return err
+	}
+
+	colors, err := theme.Default().WithColors(cfg.Colors)
+	if err != nil {
+		logger.Warn("ignoring theme colors", "error", err)
+		colors = theme.Default()
+	}
+	theme.Set(colors, mode)
+	return nil
+}
+
+// isColorFormat reports whether output in format is text a terminal shows,
+// which is colored, rather than data for other tools
+func isColorFormat(format string) bool {
+	return format == FormatMarkdown || format == "text"
+}
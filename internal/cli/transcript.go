@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+// maxTranscriptOutput is how much of a step's output the text transcript
+// shows; the JSON and HTML transcripts keep all of it
+const maxTranscriptOutput = 4000
+
+// formatTranscriptText renders sandbox executions as markdown for verbose
+// output
+func formatTranscriptText(executions []sandbox.Execution) string {
+	var result strings.Builder
+	result.WriteString("## Sandbox Transcript\n\n")
+	for _, execution := range executions {
+		result.WriteString(fmt.Sprintf("### %s (%s): %s in %s\n\n", execution.RequestID, execution.Type,
+			execution.Status, execution.Duration.Round(time.Millisecond)))
+		if execution.Error != "" {
+			result.WriteString(fmt.Sprintf("**Error:** %s\n\n", execution.Error))
+		}
+		if execution.Violation != nil {
+			result.WriteString(fmt.Sprintf("```\n%s```\n\n", execution.Violation.Explain()))
+		}
+		for _, step := range execution.Steps {
+			result.WriteString(fmt.Sprintf("- [%s] `%s` exit %d in %s\n", step.Phase, step.Command, step.ExitCode, step.Duration.Round(time.Millisecond)))
+			for _, stream := range []struct{ name, text string }{{"stdout", step.Stdout}, {"stderr", step.Stderr}} {
+				if text := strings.TrimSpace(stream.text); text != "" {
+					result.WriteString(fmt.Sprintf("\n  %s:\n  ```\n%s\n  ```\n", stream.name, tailOutput(text, maxTranscriptOutput)))
+				}
+			}
+		}
+		if execution.Diff != "" {
+			result.WriteString(fmt.Sprintf("\n```diff\n%s\n```\n", strings.TrimRight(execution.Diff, "\n")))
+		}
+		result.WriteString("\n")
+	}
+	return result.String()
+}
+
+// tailOutput keeps the end of long output, where failures are reported
+func tailOutput(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return "..." + text[len(text)-limit:]
+}
+
+// formatTranscriptHTML renders sandbox executions as a self-contained
+// HTML page, failed executions open
+func formatTranscriptHTML(executions []sandbox.Execution) string {
+	var result strings.Builder
+	result.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	result.WriteString("<title>Sigil Sandbox Transcript</title>\n")
+	result.WriteString("<style>body{font-family:Arial,sans-serif;margin:40px;}pre{background:#f6f8fa;padding:8px;overflow-x:auto;}" +
+		".failed>summary{color:#c0392b;}table{border-collapse:collapse;}td,th{border:1px solid #ddd;padding:4px 8px;text-align:left;}</style>\n")
+	result.WriteString("</head>\n<body>\n<h1>Sigil Sandbox Transcript</h1>\n")
+	result.WriteString(fmt.Sprintf("<p>%d executions</p>\n", len(executions)))
+
+	for _, execution := range executions {
+		class, open := "", ""
+		if execution.Failed() {
+			class, open = " class=\"failed\"", " open"
+		}
+		result.WriteString(fmt.Sprintf("<details%s%s>\n<summary>%s (%s): %s in %s</summary>\n", class, open,
+			html.EscapeString(execution.RequestID), html.EscapeString(execution.Type), execution.Status, execution.Duration.Round(time.Millisecond)))
+		if execution.Error != "" {
+			result.WriteString(fmt.Sprintf("<p><strong>Error:</strong> %s</p>\n", html.EscapeString(execution.Error)))
+		}
+		if execution.Violation != nil {
+			result.WriteString(fmt.Sprintf("<pre>%s</pre>\n", html.EscapeString(execution.Violation.Explain())))
+		}
+
+		if len(execution.Steps) > 0 {
+			result.WriteString("<table>\n<tr><th>Phase</th><th>Command</th><th>Exit</th><th>Duration</th></tr>\n")
+			for _, step := range execution.Steps {
+				result.WriteString(fmt.Sprintf("<tr><td>%s</td><td><code>%s</code></td><td>%d</td><td>%s</td></tr>\n",
+					step.Phase, html.EscapeString(step.Command), step.ExitCode, step.Duration.Round(time.Millisecond)))
+			}
+			result.WriteString("</table>\n")
+		}
+		for _, step := range execution.Steps {
+			for _, stream := range []struct{ name, text string }{{"stdout", step.Stdout}, {"stderr", step.Stderr}} {
+				if strings.TrimSpace(stream.text) != "" {
+					result.WriteString(fmt.Sprintf("<h4><code>%s</code> %s</h4>\n<pre>%s</pre>\n",
+						html.EscapeString(step.Command), stream.name, html.EscapeString(stream.text)))
+				}
+			}
+		}
+		if execution.Diff != "" {
+			result.WriteString(fmt.Sprintf("<h4>Diff</h4>\n<pre>%s</pre>\n", html.EscapeString(execution.Diff)))
+		}
+		result.WriteString("</details>\n")
+	}
+
+	result.WriteString("</body>\n</html>\n")
+	return result.String()
+}
+
+// writeTranscript writes sandbox executions to path, as HTML when the
+// path ends in .html and as JSON otherwise
+func writeTranscript(path string, executions []sandbox.Execution) error {
+	var content string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		content = formatTranscriptHTML(executions)
+	default:
+		if executions == nil {
+			executions = []sandbox.Execution{}
+		}
+		data, err := json.MarshalIndent(map[string]any{"executions": executions}, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, errors.ErrorTypeInternal, "writeTranscript", "failed to encode transcript")
+		}
+		content = string(data) + "\n"
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "writeTranscript",
+			fmt.Sprintf("failed to write transcript: %s", path))
+	}
+	return nil
+}
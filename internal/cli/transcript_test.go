@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+// transcriptExecutions returns a passing, a failing and a rejected sandbox
+// execution
+func transcriptExecutions() []sandbox.Execution {
+	return []sandbox.Execution{
+		{RequestID: "analyze", Type: "analysis", Status: sandbox.StatusCompleted, Duration: time.Second,
+			Steps: []sandbox.TranscriptStep{{Phase: sandbox.PhaseValidation, Command: "golangci-lint run", Stdout: "ok"}}},
+		{RequestID: "autofix", Type: "validation", Status: sandbox.StatusFailed, Error: "required validation step failed: go",
+			Steps: []sandbox.TranscriptStep{{Phase: sandbox.PhaseValidation, Command: "go test ./...", ExitCode: 1, Stderr: "--- FAIL: <TestX>"}},
+			Diff:  "diff --git a/main.go b/main.go"},
+		{RequestID: "rejected", Type: "validation", Status: sandbox.StatusFailed, Error: "request validation failed",
+			Violation: &sandbox.RuleViolation{Kind: sandbox.ViolationContent, Rule: "No secrets", File: "a.go", Line: 3,
+				Message: "content in a.go:3 matches blocked pattern", Remediation: "use <env>"}},
+	}
+}
+
+func TestFormatTranscriptText(t *testing.T) {
+	text := formatTranscriptText(transcriptExecutions())
+
+	assert.Contains(t, text, "### autofix (validation): failed")
+	assert.Contains(t, text, "**Error:** required validation step failed: go")
+	assert.Contains(t, text, "- [validation] `go test ./...` exit 1")
+	assert.Contains(t, text, "--- FAIL: <TestX>")
+	assert.Contains(t, text, "```diff\ndiff --git a/main.go b/main.go\n```")
+	assert.Contains(t, text, "a.go:3: content in a.go:3 matches blocked pattern\n  Rule: No secrets (content)\n")
+
+	long := strings.Repeat("x", maxTranscriptOutput) + "the failure"
+	assert.True(t, strings.HasSuffix(tailOutput(long, maxTranscriptOutput), "the failure"))
+	assert.Len(t, tailOutput(long, maxTranscriptOutput), maxTranscriptOutput+3)
+}
+
+func TestFormatTranscriptHTML(t *testing.T) {
+	page := formatTranscriptHTML(transcriptExecutions())
+
+	assert.Contains(t, page, "<p>3 executions</p>")
+	assert.Contains(t, page, "<details>\n<summary>analyze (analysis)")
+	assert.Contains(t, page, "<details class=\"failed\" open>\n<summary>autofix (validation)")
+	assert.Contains(t, page, "--- FAIL: &lt;TestX&gt;")
+	assert.NotContains(t, page, "<TestX>")
+	assert.Contains(t, page, "  Fix: use &lt;env&gt;")
+}
+
+func TestWriteTranscript(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "transcript.json")
+	require.NoError(t, writeTranscript(jsonPath, transcriptExecutions()))
+	data, err := os.ReadFile(jsonPath)
+	require.NoError(t, err)
+	var decoded struct {
+		Executions []sandbox.Execution `json:"executions"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded.Executions, 3)
+	assert.Equal(t, 3, decoded.Executions[2].Violation.Line)
+
+	htmlPath := filepath.Join(dir, "transcript.html")
+	require.NoError(t, writeTranscript(htmlPath, nil))
+	data, err = os.ReadFile(htmlPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<p>0 executions</p>")
+}
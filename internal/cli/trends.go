@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/findings"
+)
+
+// Trend report output formats
+const (
+	TrendsFormatJSON = "json"
+	TrendsFormatCSV  = "csv"
+	TrendsFormatHTML = "html"
+)
+
+// defaultTrendRuns is how many runs a trend report covers by default
+const defaultTrendRuns = 30
+
+// trendMetrics are the metrics a trend report charts, in order
+var trendMetrics = []string{findings.MetricCoverage, findings.MetricDocDrift, findings.MetricTokens, findings.MetricCost}
+
+// TrendsCommand reports how findings and metrics changed over recent runs
+type TrendsCommand struct {
+	*BaseCommand
+	Last        int
+	Format      string
+	OutputFile  string
+	HistoryPath string
+}
+
+// NewTrendsCommand creates a new trends command
+func NewTrendsCommand() *TrendsCommand {
+	return &TrendsCommand{
+		BaseCommand: NewBaseCommand("trends", "Report trends across recorded runs",
+			"Report findings by severity, coverage, doc drift and cost over recent runs."),
+		Last:        defaultTrendRuns,
+		Format:      TrendsFormatJSON,
+		HistoryPath: findings.DefaultHistoryPath,
+	}
+}
+
+// Execute runs the trends command
+func (c *TrendsCommand) Execute(ctx context.Context) error {
+	if err := c.validateInputs(); err != nil {
+		return err
+	}
+
+	history, err := findings.LoadHistory(c.HistoryPath)
+	if err != nil {
+		return err
+	}
+	points := history.Trends(c.Last)
+	if len(points) == 0 {
+		return errors.New(errors.ErrorTypeInput, "Execute", fmt.Sprintf("no runs recorded in %s", c.HistoryPath)).
+			WithHint("run sigil review or sigil trends record first")
+	}
+
+	var formatted string
+	switch c.Format {
+	case TrendsFormatCSV:
+		formatted = formatTrendsCSV(points)
+	case TrendsFormatHTML:
+		formatted = formatTrendsHTML(points)
+	default:
+		if formatted, err = formatTrendsJSON(points); err != nil {
+			return errors.Wrap(err, errors.ErrorTypeInternal, "Execute", "failed to encode trends")
+		}
+	}
+
+	if c.OutputFile == "" {
+		fmt.Print(formatted)
+		return nil
+	}
+	if err := os.WriteFile(c.OutputFile, []byte(formatted), 0644); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Execute",
+			fmt.Sprintf("failed to write output file: %s", c.OutputFile))
+	}
+	fmt.Fprintf(os.Stderr, "Trends over %d runs written to: %s\n", len(points), c.OutputFile)
+	return nil
+}
+
+// validateInputs validates the command inputs
+func (c *TrendsCommand) validateInputs() error {
+	switch c.Format {
+	case TrendsFormatJSON, TrendsFormatCSV, TrendsFormatHTML:
+	default:
+		return errors.ValidationError("validateInputs",
+			fmt.Sprintf("invalid format: %s (valid: %s, %s, %s)", c.Format, TrendsFormatJSON, TrendsFormatCSV, TrendsFormatHTML))
+	}
+	if c.Last < 0 {
+		return errors.ValidationError("validateInputs",
+			fmt.Sprintf("invalid run count: %d (must be 0 or more)", c.Last))
+	}
+	if c.HistoryPath == "" {
+		return errors.ValidationError("validateInputs", "a history file is required")
+	}
+	return nil
+}
+
+// parseMetrics reads name=value metric arguments
+func parseMetrics(args []string) (map[string]float64, error) {
+	metrics := make(map[string]float64, len(args))
+	for _, arg := range args {
+		name, raw, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, errors.ValidationError("parseMetrics", fmt.Sprintf("invalid metric: %s", arg)).
+				WithHint("give metrics as name=value, e.g. coverage=81.5")
+		}
+		if !isTrendMetric(name) {
+			return nil, errors.ValidationError("parseMetrics",
+				fmt.Sprintf("unknown metric: %s (valid: %s)", name, strings.Join(trendMetrics, ", ")))
+		}
+		value, err := strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+		if err != nil {
+			return nil, errors.ValidationError("parseMetrics", fmt.Sprintf("invalid value for %s: %s", name, raw))
+		}
+		metrics[name] = value
+	}
+	return metrics, nil
+}
+
+// isTrendMetric reports whether name is a metric trend reports chart
+func isTrendMetric(name string) bool {
+	for _, metric := range trendMetrics {
+		if name == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// recordMetrics adds a run of command with metrics to the history at path
+func recordMetrics(path, command string, metrics map[string]float64) error {
+	history, err := findings.LoadHistory(path)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	history.RecordMetrics(fmt.Sprintf("%s_%d", strings.ReplaceAll(command, " ", "_"), now.Unix()), command, now, metrics)
+	return history.Save()
+}
+
+// usageMetrics returns the tokens the models used in this process and,
+// when prices are configured for them, what they cost
+func usageMetrics() map[string]float64 {
+	usage := collectUsage()
+	if len(usage.Models) == 0 {
+		return nil
+	}
+
+	metrics := map[string]float64{findings.MetricTokens: float64(usage.Tokens)}
+	if usage.Cost != nil {
+		metrics[findings.MetricCost] = *usage.Cost
+	}
+	return metrics
+}
+
+// CreateCobraCommand creates the cobra command for trends
+func (c *TrendsCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trends",
+		Short: "Report trends across recorded runs",
+		Long: `Report how code health changed over the last runs recorded in the run
+history: findings by severity, open findings, test coverage, documentation
+drift, and model tokens and cost.
+
+Reviews record their findings, tokens and cost; sigil doc cli --check
+records documentation drift; other metrics such as coverage are recorded
+from CI with sigil trends record. The series is written as JSON or CSV for
+dashboards, or as a self-contained HTML page of charts.`,
+		Example: `  sigil trends
+  sigil trends --last 10 --format csv -o trends.csv
+  sigil trends --format html -o trends.html
+  sigil trends record coverage=81.5`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.Execute(cmd.Context())
+		},
+	}
+
+	cmd.Flags().IntVar(&c.Last, "last", c.Last, "Number of most recent runs to report (0 for all)")
+	cmd.Flags().StringVar(&c.Format, "format", c.Format, "Output format (json, csv, html)")
+	cmd.Flags().StringVarP(&c.OutputFile, "output", "o", "", "Output file (default: stdout)")
+	cmd.PersistentFlags().StringVar(&c.HistoryPath, "history", c.HistoryPath, "Run history file")
+
+	cmd.AddCommand(c.newRecordCommand())
+	return cmd
+}
+
+// newRecordCommand creates the record subcommand, which adds metrics
+// measured outside sigil to the history
+func (c *TrendsCommand) newRecordCommand() *cobra.Command {
+	command := "ci"
+	cmd := &cobra.Command{
+		Use:   "record <metric=value>...",
+		Short: "Record metrics measured outside sigil",
+		Long: fmt.Sprintf(`Record metrics measured outside sigil, such as test coverage from CI, as a
+run in the history so trend reports include them.
+
+Metrics: %s.`, strings.Join(trendMetrics, ", ")),
+		Example: `  sigil trends record coverage=81.5
+  sigil trends record coverage=$(go tool cover -func=cover.out | awk '/^total/ {print $3}')`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			metrics, err := parseMetrics(args)
+			if err != nil {
+				return err
+			}
+			if err := recordMetrics(c.HistoryPath, command, metrics); err != nil {
+				return err
+			}
+			fmt.Printf("Recorded %d metrics in %s\n", len(metrics), c.HistoryPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&command, "command", command, "Name of the run the metrics came from")
+	return cmd
+}
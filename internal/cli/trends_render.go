@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/findings"
+)
+
+// Chart dimensions for HTML trend reports, in pixels
+const (
+	chartWidth   = 640
+	chartHeight  = 200
+	chartPadding = 40
+)
+
+// trendSeverities are the severities charted, most severe first
+var trendSeverities = []agent.Severity{agent.SeverityCritical, agent.SeverityError, agent.SeverityWarning, agent.SeverityInfo}
+
+// chartColors are the line colors of a chart's series, in order
+var chartColors = []string{"#c0392b", "#e67e22", "#f1c40f", "#2980b9", "#7f8c8d"}
+
+// trendSeries is one line of a chart: a value for each point that has one
+type trendSeries struct {
+	Name   string
+	Values []*float64
+}
+
+// trendChart is a titled chart of one or more series over the same points
+type trendChart struct {
+	Title  string
+	Series []trendSeries
+}
+
+// formatTrendsJSON renders the trend points as JSON
+func formatTrendsJSON(points []findings.Point) (string, error) {
+	data, err := json.MarshalIndent(map[string]any{"points": points}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// formatTrendsCSV renders the trend points as CSV, one row per run. Cells
+// a run did not measure are left empty.
+func formatTrendsCSV(points []findings.Point) string {
+	header := []string{"run", "command", "time"}
+	for _, severity := range trendSeverities {
+		header = append(header, string(severity))
+	}
+	header = append(header, "new", "fixed", "open")
+	header = append(header, trendMetrics...)
+
+	var result strings.Builder
+	result.WriteString(strings.Join(header, ",") + "\n")
+	for _, point := range points {
+		row := []string{csvField(point.Run), csvField(point.Command), point.Time.UTC().Format(time.RFC3339)}
+		for _, severity := range trendSeverities {
+			row = append(row, reviewCell(point, point.Severities[severity]))
+		}
+		row = append(row, reviewCell(point, point.New), reviewCell(point, point.Fixed), strconv.Itoa(point.Open))
+		for _, metric := range trendMetrics {
+			cell := ""
+			if value, ok := point.Metrics[metric]; ok {
+				cell = strconv.FormatFloat(value, 'f', -1, 64)
+			}
+			row = append(row, cell)
+		}
+		result.WriteString(strings.Join(row, ",") + "\n")
+	}
+	return result.String()
+}
+
+// reviewCell formats a finding count, left empty for runs without a review
+func reviewCell(point findings.Point, count int) string {
+	if !point.Reviewed() {
+		return ""
+	}
+	return strconv.Itoa(count)
+}
+
+// csvField quotes a field when it holds characters CSV treats specially
+func csvField(value string) string {
+	if !strings.ContainsAny(value, ",\"\n") {
+		return value
+	}
+	return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+}
+
+// trendCharts builds the charts of an HTML trend report, leaving out
+// metrics no run measured
+func trendCharts(points []findings.Point) []trendChart {
+	severities := trendChart{Title: "Findings by severity"}
+	for _, severity := range trendSeverities {
+		series := trendSeries{Name: string(severity)}
+		for _, point := range points {
+			var value *float64
+			if point.Reviewed() {
+				value = floatPtr(float64(point.Severities[severity]))
+			}
+			series.Values = append(series.Values, value)
+		}
+		severities.Series = append(severities.Series, series)
+	}
+
+	open := trendSeries{Name: "open"}
+	for _, point := range points {
+		open.Values = append(open.Values, floatPtr(float64(point.Open)))
+	}
+	charts := []trendChart{severities, {Title: "Open findings", Series: []trendSeries{open}}}
+
+	titles := map[string]string{
+		findings.MetricCoverage: "Test coverage (%)",
+		findings.MetricDocDrift: "Documentation drift (pages out of date)",
+		findings.MetricTokens:   "Model tokens",
+		findings.MetricCost:     "Model cost (USD)",
+	}
+	for _, metric := range trendMetrics {
+		series := trendSeries{Name: metric}
+		measured := false
+		for _, point := range points {
+			var value *float64
+			if v, ok := point.Metrics[metric]; ok {
+				value = floatPtr(v)
+				measured = true
+			}
+			series.Values = append(series.Values, value)
+		}
+		if measured {
+			charts = append(charts, trendChart{Title: titles[metric], Series: []trendSeries{series}})
+		}
+	}
+	return charts
+}
+
+// floatPtr returns a pointer to v
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+// formatTrendsHTML renders the trend points as a self-contained HTML page
+// with one SVG line chart per series group
+func formatTrendsHTML(points []findings.Point) string {
+	var result strings.Builder
+	result.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	result.WriteString("<title>Sigil Trends</title>\n")
+	result.WriteString("<style>body{font-family:Arial,sans-serif;margin:40px;}svg{border:1px solid #ddd;}" +
+		".legend span{margin-right:16px;}</style>\n")
+	result.WriteString("</head>\n<body>\n")
+
+	first, last := points[0].Time.UTC(), points[len(points)-1].Time.UTC()
+	result.WriteString("<h1>Sigil Trends</h1>\n")
+	result.WriteString(fmt.Sprintf("<p>%d runs from %s to %s</p>\n", len(points),
+		first.Format("2006-01-02 15:04"), last.Format("2006-01-02 15:04")))
+
+	for _, chart := range trendCharts(points) {
+		result.WriteString(fmt.Sprintf("<h2>%s</h2>\n", html.EscapeString(chart.Title)))
+		result.WriteString(renderChart(chart, points))
+	}
+
+	result.WriteString("</body>\n</html>\n")
+	return result.String()
+}
+
+// renderChart draws a chart as an SVG line chart with a legend. Runs
+// without a value break the line.
+func renderChart(chart trendChart, points []findings.Point) string {
+	maxValue := 0.0
+	for _, series := range chart.Series {
+		for _, value := range series.Values {
+			if value != nil && *value > maxValue {
+				maxValue = *value
+			}
+		}
+	}
+	if maxValue == 0 {
+		maxValue = 1
+	}
+
+	plotWidth := float64(chartWidth - 2*chartPadding)
+	plotHeight := float64(chartHeight - 2*chartPadding)
+	x := func(i int) float64 {
+		if len(points) == 1 {
+			return chartPadding + plotWidth/2
+		}
+		return chartPadding + plotWidth*float64(i)/float64(len(points)-1)
+	}
+	y := func(value float64) float64 {
+		return chartPadding + plotHeight*(1-value/maxValue)
+	}
+
+	var svg strings.Builder
+	svg.WriteString(fmt.Sprintf("<svg width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", chartWidth, chartHeight, chartWidth, chartHeight))
+	svg.WriteString(fmt.Sprintf("<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"#999\"/>\n",
+		chartPadding, chartHeight-chartPadding, chartWidth-chartPadding, chartHeight-chartPadding))
+	svg.WriteString(fmt.Sprintf("<text x=\"4\" y=\"%d\" font-size=\"10\">%s</text>\n", chartPadding, formatChartValue(maxValue)))
+	svg.WriteString(fmt.Sprintf("<text x=\"4\" y=\"%d\" font-size=\"10\">0</text>\n", chartHeight-chartPadding))
+	svg.WriteString(fmt.Sprintf("<text x=\"%d\" y=\"%d\" font-size=\"10\">%s</text>\n",
+		chartPadding, chartHeight-chartPadding/2, points[0].Time.UTC().Format("2006-01-02")))
+	svg.WriteString(fmt.Sprintf("<text x=\"%d\" y=\"%d\" font-size=\"10\" text-anchor=\"end\">%s</text>\n",
+		chartWidth-chartPadding, chartHeight-chartPadding/2, points[len(points)-1].Time.UTC().Format("2006-01-02")))
+
+	var legend strings.Builder
+	for i, series := range chart.Series {
+		color := chartColors[i%len(chartColors)]
+		var segment []string
+		flush := func() {
+			if len(segment) > 0 {
+				svg.WriteString(fmt.Sprintf("<polyline fill=\"none\" stroke=\"%s\" stroke-width=\"2\" points=\"%s\"/>\n",
+					color, strings.Join(segment, " ")))
+			}
+			segment = nil
+		}
+		for j, value := range series.Values {
+			if value == nil {
+				flush()
+				continue
+			}
+			segment = append(segment, fmt.Sprintf("%.1f,%.1f", x(j), y(*value)))
+			svg.WriteString(fmt.Sprintf("<circle cx=\"%.1f\" cy=\"%.1f\" r=\"3\" fill=\"%s\"><title>%s %s: %s</title></circle>\n",
+				x(j), y(*value), color, html.EscapeString(points[j].Run), html.EscapeString(series.Name), formatChartValue(*value)))
+		}
+		flush()
+		legend.WriteString(fmt.Sprintf("<span style=\"color:%s\">&#9632; %s</span>", color, html.EscapeString(series.Name)))
+	}
+	svg.WriteString("</svg>\n")
+
+	return svg.String() + "<div class=\"legend\">" + legend.String() + "</div>\n"
+}
+
+// formatChartValue formats an axis or tooltip value without needless decimals
+func formatChartValue(value float64) string {
+	formatted := strconv.FormatFloat(value, 'f', 4, 64)
+	return strings.TrimSuffix(strings.TrimRight(formatted, "0"), ".")
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/findings"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// trendPoints is a review, a CI run reporting coverage, and a review
+// with a cost
+func trendPoints() []findings.Point {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []findings.Point{
+		{Run: "review_1", Command: "review", Time: start, New: 3, Open: 3,
+			Severities: map[agent.Severity]int{agent.SeverityError: 1, agent.SeverityWarning: 2}},
+		{Run: "ci_2", Command: "ci", Time: start.Add(time.Hour), Open: 3,
+			Metrics: map[string]float64{findings.MetricCoverage: 81.5}},
+		{Run: "review_3", Command: "review", Time: start.Add(2 * time.Hour), Fixed: 2, Open: 1,
+			Severities: map[agent.Severity]int{agent.SeverityWarning: 1},
+			Metrics:    map[string]float64{findings.MetricTokens: 5200, findings.MetricCost: 0.052}},
+	}
+}
+
+func TestParseMetrics(t *testing.T) {
+	metrics, err := parseMetrics([]string{"coverage=81.5%", "doc_drift=2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{findings.MetricCoverage: 81.5, findings.MetricDocDrift: 2}, metrics)
+
+	_, err = parseMetrics([]string{"coverage"})
+	assert.Error(t, err)
+	_, err = parseMetrics([]string{"velocity=3"})
+	assert.ErrorContains(t, err, "unknown metric: velocity")
+	_, err = parseMetrics([]string{"coverage=high"})
+	assert.ErrorContains(t, err, "invalid value for coverage")
+}
+
+func TestUsageMetrics(t *testing.T) {
+	previous := config.Get()
+	defer config.Set(previous)
+	cfg := *previous
+	cfg.Models.Pricing = map[string]float64{"openai:gpt-4o": 5}
+	config.Set(&cfg)
+
+	model.ResetUsage()
+	defer model.ResetUsage()
+	assert.Nil(t, usageMetrics())
+
+	model.RecordUsage("openai:gpt-4o", 200000)
+	model.RecordUsage("ollama:llama3", 1000)
+	assert.Equal(t, map[string]float64{findings.MetricTokens: 201000, findings.MetricCost: 1}, usageMetrics())
+}
+
+func TestFormatTrendsCSV(t *testing.T) {
+	csv := formatTrendsCSV(trendPoints())
+
+	assert.Equal(t, "run,command,time,critical,error,warning,info,new,fixed,open,coverage,doc_drift,tokens,cost\n"+
+		"review_1,review,2026-03-01T12:00:00Z,0,1,2,0,3,0,3,,,,\n"+
+		"ci_2,ci,2026-03-01T13:00:00Z,,,,,,,3,81.5,,,\n"+
+		"review_3,review,2026-03-01T14:00:00Z,0,0,1,0,0,2,1,,,5200,0.052\n", csv)
+}
+
+func TestFormatTrendsHTML(t *testing.T) {
+	page := formatTrendsHTML(trendPoints())
+
+	assert.Contains(t, page, "<p>3 runs from 2026-03-01 12:00 to 2026-03-01 14:00</p>")
+	assert.Contains(t, page, "<h2>Findings by severity</h2>")
+	assert.Contains(t, page, "<h2>Test coverage (%)</h2>")
+	assert.Contains(t, page, "<h2>Model cost (USD)</h2>")
+	assert.NotContains(t, page, "Documentation drift", "metrics no run measured are not charted")
+	assert.Contains(t, page, "<title>ci_2 coverage: 81.5</title>")
+}
+
+func TestTrendCharts_BreakLinesAtMissingRuns(t *testing.T) {
+	charts := trendCharts(trendPoints())
+
+	require.Len(t, charts, 5)
+	errorSeries := charts[0].Series[1]
+	assert.Equal(t, "error", errorSeries.Name)
+	assert.NotNil(t, errorSeries.Values[0])
+	assert.Nil(t, errorSeries.Values[1], "runs without a review have no findings")
+
+	svg := renderChart(charts[0], trendPoints())
+	assert.Equal(t, 8, strings.Count(svg, "<polyline"), "each series is split at the CI run")
+}
+
+func TestTrendsCommand_Execute(t *testing.T) {
+	dir := t.TempDir()
+	cmd := NewTrendsCommand()
+	cmd.HistoryPath = filepath.Join(dir, "history.json")
+	cmd.OutputFile = filepath.Join(dir, "trends.csv")
+	cmd.Format = TrendsFormatCSV
+
+	err := cmd.Execute(context.Background())
+	assert.ErrorContains(t, err, "no runs recorded")
+
+	require.NoError(t, recordMetrics(cmd.HistoryPath, "ci", map[string]float64{findings.MetricCoverage: 80}))
+	require.NoError(t, cmd.Execute(context.Background()))
+
+	data, err := os.ReadFile(cmd.OutputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), ",ci,")
+	assert.Contains(t, string(data), ",80,")
+
+	cmd.Format = "xml"
+	assert.ErrorContains(t, cmd.Execute(context.Background()), "invalid format: xml")
+}
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/consent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// TrustCommand records which model providers may receive the repository's
+// content, and which of its paths
+type TrustCommand struct {
+	*BaseCommand
+	Providers []string
+	Paths     []string
+	Revoke    bool
+	Show      bool
+}
+
+// NewTrustCommand creates a new trust command
+func NewTrustCommand() *TrustCommand {
+	return &TrustCommand{
+		BaseCommand: NewBaseCommand("trust", "Consent to sending this repository's content to model providers",
+			"Record which model providers may receive this repository's content, and which of its paths."),
+	}
+}
+
+// Execute runs the trust command
+func (c *TrustCommand) Execute(ctx context.Context) error {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeGit, "Execute", "failed to open git repository")
+	}
+	message, err := c.apply(repo.Root, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Print(message)
+	return nil
+}
+
+// apply shows, revokes or grants the consent of the repository at root and
+// returns what it did
+func (c *TrustCommand) apply(root string, now time.Time) (string, error) {
+	current, err := consent.Load(root)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case c.Show:
+		return formatConsent(current), nil
+	case c.Revoke:
+		if err := consent.Remove(root); err != nil {
+			return "", err
+		}
+		consent.Set(root, nil)
+		return fmt.Sprintf("Removed %s: no repository content is sent to external providers without asking\n", consent.File), nil
+	}
+
+	providers := c.Providers
+	if len(providers) == 0 {
+		providers = configuredProviders()
+	}
+	if len(providers) == 0 {
+		return "", errors.ValidationError("apply", "no external providers to consent to").
+			WithHint("name them with --provider, e.g. --provider anthropic")
+	}
+	for _, provider := range providers {
+		if _, err := model.GetProvider(provider); err != nil {
+			return "", errors.ValidationError("apply", fmt.Sprintf("unknown provider: %s", provider)).
+				WithHint("providers are " + strings.Join(model.ListProviders(), ", "))
+		}
+	}
+
+	granted := consent.Grant(current, providers, c.Paths, now)
+	if err := consent.Save(root, granted); err != nil {
+		return "", err
+	}
+	consent.Set(root, granted)
+	return fmt.Sprintf("Recorded consent in %s\n%s", consent.File, formatConsent(granted)), nil
+}
+
+// configuredProviders returns the external providers of the configured
+// models
+func configuredProviders() []string {
+	cfg := getConfig()
+	var providers []string
+	for _, modelStr := range append([]string{cfg.Models.Lead}, cfg.Models.Reviewers...) {
+		provider, _, err := model.ParseModelString(modelStr)
+		if err != nil {
+			continue
+		}
+		if consent.Local(provider, cfg.Models.Configs[provider].Endpoint) {
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+// formatConsent describes a consent for the terminal
+func formatConsent(granted *consent.Consent) string {
+	if granted == nil || len(granted.Providers) == 0 {
+		return "No consent recorded: sigil asks before sending this repository's content to an external provider\n"
+	}
+	paths := "the whole repository"
+	if len(granted.Paths) > 0 {
+		paths = strings.Join(granted.Paths, ", ")
+	}
+	return fmt.Sprintf("Providers: %s\nPaths: %s\nGranted: %s\n",
+		strings.Join(granted.Providers, ", "), paths, granted.GrantedAt.Format(time.RFC3339))
+}
+
+// CreateCobraCommand creates the cobra command for trust
+func (c *TrustCommand) CreateCobraCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trust",
+		Short: "Consent to sending this repository's content to model providers",
+		Long: `Record which model providers may receive this repository's content, and
+which of its paths, in .sigil/trust.yml.
+
+sigil sends nothing from a repository to an external provider without
+consent. The first request to a provider without it asks on the terminal
+and records the answer; runs without a terminal fail until consent is given
+with this command. Providers on this machine, such as Ollama or any
+provider with a loopback endpoint, need no consent.
+
+Without --provider, consent is given to the providers of the configured
+models. --path limits it to the files matching glob patterns (** spans
+directories); without it, the whole repository is covered. Files sent
+from outside those paths are refused.`,
+		Example: `  sigil trust                                   # The configured providers, whole repository
+  sigil trust --provider anthropic --path 'internal/**' --path README.md
+  sigil trust --show
+  sigil trust --revoke`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.Execute(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&c.Providers, "provider", nil, "Providers that may receive the repository's content (default: those of the configured models)")
+	cmd.Flags().StringSliceVar(&c.Paths, "path", nil, "Limit consent to the paths matching these glob patterns")
+	cmd.Flags().BoolVar(&c.Revoke, "revoke", false, "Remove the recorded consent")
+	cmd.Flags().BoolVar(&c.Show, "show", false, "Show the recorded consent")
+	cmd.MarkFlagsMutuallyExclusive("revoke", "show")
+	return cmd
+}
+
+// initConsent loads the repository's consent and puts every model request
+// behind it
+func initConsent() {
+	root := ""
+	if repo, err := git.NewRepository(""); err == nil {
+		root = repo.Root
+	}
+	granted, err := consent.Load(root)
+	if err != nil {
+		logger.Warn("ignoring unreadable consent", "error", err)
+	}
+	consent.Set(root, granted)
+	model.SetGate(consentGate(os.Stdin, os.Stderr))
+}
+
+// consentGate checks model requests against the repository's consent. On
+// a terminal, the first request to a provider without consent asks for it
+// and records the answer; a refusal stands for the rest of the run.
+func consentGate(in *os.File, out io.Writer) model.Gate {
+	var mu sync.Mutex
+	declined := make(map[string]bool)
+
+	return func(provider, endpoint string, paths []string) error {
+		err := consent.Check(provider, endpoint, paths)
+		if !stderrors.Is(err, consent.ErrNoConsent) || !isTerminal(in) {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		// Another request may have asked in the meantime
+		if err = consent.Check(provider, endpoint, paths); !stderrors.Is(err, consent.ErrNoConsent) || declined[provider] {
+			return err
+		}
+
+		root, current := consent.Current()
+		fmt.Fprintf(out, "sigil is about to send content of %s to %s.\nAllow sending this repository's content to %s? [y/N]: ", root, provider, provider)
+		line, readErr := bufio.NewReader(in).ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return errors.Wrap(readErr, errors.ErrorTypeInput, "consentGate", "failed to read consent")
+		}
+		if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+			declined[provider] = true
+			return err
+		}
+
+		granted := consent.Grant(current, []string{provider}, nil, time.Now())
+		if err := consent.Save(root, granted); err != nil {
+			return err
+		}
+		consent.Set(root, granted)
+		fmt.Fprintf(out, "Recorded consent in %s\n", consent.File)
+		return consent.Check(provider, endpoint, paths)
+	}
+}
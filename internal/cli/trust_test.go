@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/chunk"
+	"github.com/dshills/sigil/internal/consent"
+	"github.com/dshills/sigil/internal/index"
+	"github.com/dshills/sigil/internal/model"
+)
+
+func TestTrustCommand_apply(t *testing.T) {
+	initModelProviders()
+	root := t.TempDir()
+	t.Cleanup(func() { consent.Set("", nil) })
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	cmd := NewTrustCommand()
+	cmd.Show = true
+	message, err := cmd.apply(root, now)
+	require.NoError(t, err)
+	assert.Contains(t, message, "No consent recorded")
+
+	cmd = NewTrustCommand()
+	cmd.Providers = []string{"anthropic"}
+	cmd.Paths = []string{"internal/**"}
+	message, err = cmd.apply(root, now)
+	require.NoError(t, err)
+	assert.Equal(t, "Recorded consent in .sigil/trust.yml\nProviders: anthropic\nPaths: internal/**\nGranted: 2026-10-16T09:00:00Z\n", message)
+	_, current := consent.Current()
+	assert.True(t, current.AllowsProvider("anthropic"), "consent applies to the rest of the run")
+
+	cmd.Providers = []string{"nonesuch"}
+	_, err = cmd.apply(root, now)
+	assert.ErrorContains(t, err, "unknown provider: nonesuch")
+
+	cmd = NewTrustCommand()
+	cmd.Revoke = true
+	_, err = cmd.apply(root, now)
+	require.NoError(t, err)
+	recorded, err := consent.Load(root)
+	require.NoError(t, err)
+	assert.Nil(t, recorded)
+}
+
+func TestConsentGate(t *testing.T) {
+	root := t.TempDir()
+	t.Cleanup(func() { consent.Set("", nil) })
+	consent.Set(root, nil)
+
+	// Without a terminal nothing is asked and the request is refused
+	in, err := os.CreateTemp(t.TempDir(), "stdin")
+	require.NoError(t, err)
+	defer in.Close()
+	var out bytes.Buffer
+	gate := consentGate(in, &out)
+
+	assert.ErrorIs(t, gate("anthropic", "", nil), consent.ErrNoConsent)
+	assert.Empty(t, out.String())
+	assert.NoError(t, gate("ollama", "", []string{"main.go"}))
+
+	consent.Set(root, &consent.Consent{Providers: []string{"anthropic"}})
+	assert.NoError(t, gate("anthropic", "", []string{filepath.Join(root, "main.go")}))
+	assert.ErrorIs(t, gate("anthropic", "", []string{filepath.Join(t.TempDir(), "main.go")}), consent.ErrOutOfScope,
+		"files of another repository are refused")
+}
+
+// embeddingFactory creates models that embed with local hash embeddings
+type embeddingFactory struct{}
+
+func (embeddingFactory) CreateModel(config model.ModelConfig) (model.Model, error) {
+	return &embeddingModel{echoModel: echoModel{name: config.Provider + ":" + config.Model}, HashEmbedder: index.NewHashEmbedder()}, nil
+}
+
+type embeddingModel struct {
+	echoModel
+	*index.HashEmbedder
+}
+
+func TestConsentGate_ScopedEmbeddings(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(path string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte("package x\n"), 0600))
+	}
+	writeFile("internal/a.go")
+	writeFile("vendor/b.go")
+
+	t.Cleanup(func() {
+		consent.Set("", nil)
+		model.SetGate(nil)
+	})
+	consent.Set(root, &consent.Consent{Providers: []string{"embedgatetest"}, Paths: []string{"internal/**"}})
+	model.SetGate(consent.Check)
+	require.NoError(t, model.RegisterProvider("embedgatetest", embeddingFactory{}))
+
+	mdl, err := model.CreateModel(model.ModelConfig{Provider: "embedgatetest", Model: "embed", Endpoint: "https://api.example.com"})
+	require.NoError(t, err)
+	embedder, ok := model.EmbedderOf(mdl)
+	require.True(t, ok)
+
+	ix, err := index.Load(index.DefaultPath(root), embedder.EmbeddingModel(), chunk.DefaultRules())
+	require.NoError(t, err)
+	_, err = ix.Update(context.Background(), root, []string{"internal/a.go"}, embedder)
+	require.NoError(t, err, "files in scope are embedded")
+
+	_, err = ix.Update(context.Background(), root, []string{"internal/a.go", "vendor/b.go"}, embedder)
+	assert.ErrorIs(t, err, consent.ErrOutOfScope, "files out of scope are not sent for embedding")
+
+	_, err = embedder.Embed(context.Background(), []string{"where are tokens checked?"})
+	assert.NoError(t, err, "queries carry no repository paths")
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// cobraUsageErrors begin the errors cobra returns for a bad command line
+// without passing them through a flag error func or an args validator
+var cobraUsageErrors = []string{
+	"unknown command",
+	"required flag(s)",
+	"if any flags in the group",
+	"at least one of the flags in the group",
+}
+
+// usageError reports a mistake in how cmd was invoked with the input code
+// and the usage exit status, pointing at the command's help
+func usageError(cmd *cobra.Command, err error) error {
+	return errors.New(errors.ErrorTypeInput, cmd.Name(), err.Error()).
+		WithHint(fmt.Sprintf("run '%s --help' for usage", cmd.CommandPath()))
+}
+
+// reportUsageErrors makes the flag and argument errors of cmd and its
+// subcommands usage errors
+func reportUsageErrors(cmd *cobra.Command) {
+	cmd.SetFlagErrorFunc(usageError)
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return usageError(cmd, err)
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		reportUsageErrors(sub)
+	}
+}
+
+// asUsageError turns the command line errors cobra reports directly into
+// usage errors, leaving other errors as they are
+func asUsageError(cmd *cobra.Command, err error) error {
+	if err == nil || cmd == nil || errors.CodeOf(err) != errors.CodeUnknown {
+		return err
+	}
+	for _, prefix := range cobraUsageErrors {
+		if strings.HasPrefix(err.Error(), prefix) {
+			return usageError(cmd, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	stderrors "errors"
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+func TestReportUsageErrors(t *testing.T) {
+	newRoot := func() *cobra.Command {
+		root := &cobra.Command{Use: "sigil", SilenceErrors: true, SilenceUsage: true}
+		root.SetOut(io.Discard)
+		sub := &cobra.Command{
+			Use:  "explain <file>",
+			Args: cobra.ExactArgs(1),
+			RunE: func(*cobra.Command, []string) error { return stderrors.New("model unavailable") },
+		}
+		sub.Flags().Int("lines", 0, "")
+		sub.Flags().String("model", "", "")
+		_ = sub.MarkFlagRequired("model")
+		root.AddCommand(sub)
+		reportUsageErrors(root)
+		return root
+	}
+
+	for name, args := range map[string][]string{
+		"unknown flag":    {"explain", "main.go", "--model", "m", "--bogus"},
+		"bad flag value":  {"explain", "main.go", "--model", "m", "--lines", "many"},
+		"wrong args":      {"explain", "--model", "m"},
+		"missing flag":    {"explain", "main.go"},
+		"unknown command": {"explian", "main.go"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			root := newRoot()
+			root.SetArgs(args)
+			cmd, err := root.ExecuteC()
+			err = asUsageError(cmd, err)
+			assert.Equal(t, errors.CodeInput, errors.CodeOf(err), err.Error())
+			assert.Equal(t, errors.ExitUsage, errors.ExitCode(err))
+			assert.Contains(t, errors.HintOf(err), "--help' for usage")
+		})
+	}
+
+	root := newRoot()
+	root.SetArgs([]string{"explain", "main.go", "--model", "m"})
+	cmd, err := root.ExecuteC()
+	err = asUsageError(cmd, err)
+	assert.EqualError(t, err, "model unavailable", "runtime failures are not usage errors")
+	assert.Equal(t, errors.ExitGeneral, errors.ExitCode(err))
+}
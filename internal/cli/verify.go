@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/verify"
+)
+
+// referenceGuard checks the files and symbols generated text refers to
+// against the repository. A nil guard leaves text alone.
+type referenceGuard struct {
+	index *verify.Index
+}
+
+// newReferenceGuard indexes the repository for --verify-refs. It is nil
+// when verification is off.
+func newReferenceGuard(enabled bool) (*referenceGuard, error) {
+	if !enabled {
+		return nil, nil
+	}
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeGit, "newReferenceGuard", "failed to open git repository").
+			WithHint("--verify-refs checks references against the repository, so run it inside one")
+	}
+	index, err := verify.LoadIndex(repo.Root)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeGit, "newReferenceGuard", "failed to index repository files")
+	}
+	return &referenceGuard{index: index}, nil
+}
+
+// check returns text with near-miss references corrected and the
+// references that do not exist marked as unverified
+func (g *referenceGuard) check(text string) string {
+	if g == nil {
+		return text
+	}
+	checked, claims := g.index.Check(text)
+	var corrected, unverified int
+	for _, claim := range claims {
+		switch claim.Status {
+		case verify.StatusCorrected:
+			corrected++
+			logger.Debug("corrected reference", "kind", claim.Kind, "text", claim.Text, "correction", claim.Correction)
+		case verify.StatusUnverified:
+			unverified++
+			logger.Debug("unverified reference", "kind", claim.Kind, "text", claim.Text)
+		}
+	}
+	if corrected > 0 || unverified > 0 {
+		logger.Info("verified references", "checked", len(claims), "corrected", corrected, "unverified", unverified)
+	}
+	return checked
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/version"
+)
+
+// NewVersionCommand creates the version command
+func NewVersionCommand() *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version of sigil",
+		Long: `Print the version of sigil with the commit and date it was built from,
+the Go version and the platform.`,
+		Example: `  sigil version
+  sigil version --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := version.Get()
+			if !asJSON {
+				fmt.Fprintln(cmd.OutOrStdout(), info.String())
+				return nil
+			}
+			data, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return errors.Wrap(err, errors.ErrorTypeOutput, "version", "failed to marshal version")
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the build information as JSON")
+	return cmd
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/version"
+)
+
+func TestVersionCommand_JSON(t *testing.T) {
+	previous := version.Version
+	defer func() { version.Version = previous }()
+	version.Version = "v1.4.2"
+
+	cmd := NewVersionCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--json"})
+	require.NoError(t, cmd.Execute())
+
+	var info version.Info
+	require.NoError(t, json.Unmarshal(out.Bytes(), &info))
+	assert.Equal(t, "v1.4.2", info.Version)
+	assert.NotEmpty(t, info.GoVersion)
+	assert.NotEmpty(t, info.Platform)
+}
@@ -5,14 +5,17 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/dshills/sigil/internal/chunk"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/theme"
 	"gopkg.in/yaml.v3"
 )
 
@@ -36,6 +39,33 @@ type Config struct {
 	// Git configuration
 	Git GitConfig `yaml:"git"`
 
+	// Cache configuration
+	Cache CacheConfig `yaml:"cache"`
+
+	// Generated documentation configuration
+	Docs DocsConfig `yaml:"docs"`
+
+	// External static analyzers run alongside reviews
+	Analyzers []AnalyzerConfig `yaml:"analyzers,omitempty"`
+
+	// Review requirements for the files under particular paths
+	ReviewAreas []ReviewAreaConfig `yaml:"review_areas,omitempty"`
+
+	// Providers and endpoints the repository's models may use
+	Policy PolicyConfig `yaml:"policy,omitempty"`
+
+	// Debug bundles recorded for multi-agent tasks
+	Traces TracesConfig `yaml:"traces,omitempty"`
+
+	// Colors of terminal output
+	Theme ThemeConfig `yaml:"theme,omitempty"`
+
+	// How files are split for the embeddings index and summaries
+	Chunking ChunkingConfig `yaml:"chunking,omitempty"`
+
+	// Where the embeddings index is stored
+	Index IndexConfig `yaml:"index,omitempty"`
+
 	// Backend configuration (for MCP)
 	Backend string     `yaml:"backend,omitempty"`
 	MCP     *MCPConfig `yaml:"mcp,omitempty"`
@@ -54,6 +84,24 @@ type ModelsConfig struct {
 
 	// Model-specific configurations
 	Configs map[string]model.ModelConfig `yaml:"configs,omitempty"`
+
+	// Price in USD per million tokens by model, e.g. "openai:gpt-4o", for
+	// cost tracking
+	Pricing map[string]float64 `yaml:"pricing,omitempty"`
+
+	// Rate limits by provider, e.g. "anthropic", or by model, e.g.
+	// "anthropic:claude-3-5-sonnet-20241022", which requests are paced to
+	// stay under
+	Quotas map[string]QuotaConfig `yaml:"quotas,omitempty"`
+}
+
+// QuotaConfig is a provider's rate limit; zero leaves a limit off
+type QuotaConfig struct {
+	// Requests per minute
+	RPM int `yaml:"rpm,omitempty"`
+
+	// Tokens per minute, prompt and response together
+	TPM int `yaml:"tpm,omitempty"`
 }
 
 // SandboxConfig defines sandbox execution settings
@@ -121,6 +169,292 @@ type GitConfig struct {
 
 	// Create checkpoint commits
 	Checkpoints bool `yaml:"checkpoints"`
+
+	// Fetch more history when a shallow clone lacks the commits a diff
+	// needs, up to MaxDeepen commits
+	AutoDeepen bool `yaml:"auto_deepen"`
+	MaxDeepen  int  `yaml:"max_deepen,omitempty"`
+
+	// Messages written by sigil commit
+	Commit CommitConfig `yaml:"commit"`
+
+	// Releases cut by sigil release
+	Release ReleaseConfig `yaml:"release"`
+}
+
+// ReleaseConfig describes how sigil release versions the repository
+type ReleaseConfig struct {
+	// Prefix of version tags, such as "v" in v1.2.3
+	TagPrefix string `yaml:"tag_prefix"`
+
+	// Files holding the version, relative to the repository root. The first
+	// version assignment in each, or a file holding only a version, is
+	// updated.
+	VersionFiles []string `yaml:"version_files,omitempty"`
+
+	// Changelog the release notes are added to ("" to skip)
+	Changelog string `yaml:"changelog,omitempty"`
+}
+
+// CommitConfig shapes the messages sigil commit writes
+type CommitConfig struct {
+	// Message template. Placeholders: {type}, {scope}, {subject}, {body},
+	// {files}, {stats}, {branch} and {issue}. Parentheses left empty by a
+	// missing scope are dropped.
+	Template string `yaml:"template,omitempty"`
+
+	// File holding the template, relative to the repository root; it takes
+	// precedence over Template when present
+	TemplateFile string `yaml:"template_file,omitempty"`
+
+	// Conventional-commit scope for paths, keyed by path prefix or glob. The
+	// longest matching key wins; unmapped paths are scoped by directory.
+	Scopes map[string]string `yaml:"scopes,omitempty"`
+
+	// Longest subject line, in characters (0 for no limit)
+	MaxSubject int `yaml:"max_subject"`
+
+	// Column the body is wrapped at (0 to leave it unwrapped)
+	BodyWrap int `yaml:"body_wrap"`
+}
+
+// CacheConfig defines prompt, embedding and artifact cache settings
+type CacheConfig struct {
+	// Enable caching
+	Enabled bool `yaml:"enabled"`
+
+	// Backend type (local, http, s3, gcs)
+	Backend string `yaml:"backend"`
+
+	// Local cache directory (also used as a read-through layer for remote backends)
+	Path string `yaml:"path,omitempty"`
+
+	// Base URL for the http backend, or a custom endpoint for s3/gcs
+	URL string `yaml:"url,omitempty"`
+
+	// Bucket name for s3/gcs
+	Bucket string `yaml:"bucket,omitempty"`
+
+	// Bucket region for s3 (gcs uses "auto")
+	Region string `yaml:"region,omitempty"`
+
+	// Key prefix prepended to every namespaced key
+	Prefix string `yaml:"prefix,omitempty"`
+
+	// Environment variable holding a bearer token for the http backend
+	TokenEnv string `yaml:"token_env,omitempty"`
+
+	// Environment variables holding HMAC credentials for s3/gcs
+	AccessKeyEnv string `yaml:"access_key_env,omitempty"`
+	SecretKeyEnv string `yaml:"secret_key_env,omitempty"`
+
+	// Lifetime of signed URLs
+	URLExpiry time.Duration `yaml:"url_expiry,omitempty"`
+
+	// Never write to the remote backend (CI populates, teammates consume)
+	ReadOnly bool `yaml:"read_only,omitempty"`
+}
+
+// DocsConfig defines what generated documentation covers and how it is
+// linted
+type DocsConfig struct {
+	// Lint generated documentation and summaries
+	Lint bool `yaml:"lint"`
+
+	// Apply automatic fixes instead of only reporting issues
+	Fix bool `yaml:"fix"`
+
+	// Glossary of preferred terms, which every prompt and generated output
+	// follows
+	Glossary string `yaml:"glossary,omitempty"`
+
+	// Heading case (sentence, title, or empty to leave headings alone)
+	HeadingCase string `yaml:"heading_case,omitempty"`
+
+	// Maximum line length (0 for no limit)
+	MaxLineLength int `yaml:"max_line_length,omitempty"`
+
+	// Symbols to document, as name patterns like "Serve*"; all when empty
+	IncludeSymbols []string `yaml:"include_symbols,omitempty"`
+
+	// Symbols to leave out, as name patterns like "*_test"
+	ExcludeSymbols []string `yaml:"exclude_symbols,omitempty"`
+
+	// Document only the exported API
+	OnlyExported bool `yaml:"only_exported,omitempty"`
+}
+
+// AnalyzerConfig declares an external static analyzer. Known tools
+// (golangci-lint, eslint, ruff, semgrep) only need a name; any other
+// field overrides the tool's defaults.
+type AnalyzerConfig struct {
+	// Analyzer name
+	Name string `yaml:"name"`
+
+	// Command to execute in the sandbox
+	Command string `yaml:"command,omitempty"`
+
+	// Command arguments
+	Args []string `yaml:"args,omitempty"`
+
+	// Output format (golangci-lint, eslint, ruff, semgrep, sarif, line)
+	Format string `yaml:"format,omitempty"`
+
+	// File extensions the analyzer applies to (empty for all files)
+	Extensions []string `yaml:"extensions,omitempty"`
+
+	// Review comment type findings are filed under (e.g. security, style)
+	Type string `yaml:"type,omitempty"`
+
+	// Skip the analyzer without removing its declaration
+	Disabled bool `yaml:"disabled,omitempty"`
+}
+
+// ReviewAreaConfig declares the review requirements of an area of the
+// repository. Reviews of files matching its paths follow its requirements
+// on top of the usual ones; where several areas set a severity, the last
+// wins.
+type ReviewAreaConfig struct {
+	// Area name, used in review instructions
+	Name string `yaml:"name,omitempty"`
+
+	// Path globs relative to the repository root (e.g. auth/**, docs/**)
+	Paths []string `yaml:"paths"`
+
+	// Focus areas reviews of these files always cover
+	Focus []string `yaml:"focus,omitempty"`
+
+	// Further review instructions for these files
+	Requirements []string `yaml:"requirements,omitempty"`
+
+	// Lowest severity reported for these files, overriding --severity
+	Severity string `yaml:"severity,omitempty"`
+
+	// Report only findings of the focus areas for these files
+	Only bool `yaml:"only,omitempty"`
+}
+
+// PolicyConfig restricts the models of the repository, e.g. to keep its
+// data in a region. Models outside it are refused when created.
+type PolicyConfig struct {
+	// Allowed providers (e.g. anthropic); any when empty
+	Providers []string `yaml:"providers,omitempty"`
+
+	// Allowed endpoint hosts, as glob patterns (e.g.
+	// "*.westeurope.inference.ml.azure.com"); any when empty. Models without
+	// an endpoint are reached at their provider's default host.
+	Endpoints []string `yaml:"endpoints,omitempty"`
+
+	// Why the policy exists, quoted when a model is refused
+	Reason string `yaml:"reason,omitempty"`
+}
+
+// Model returns the policy models are created under, or nil when it
+// restricts nothing
+func (p PolicyConfig) Model() *model.Policy {
+	if len(p.Providers) == 0 && len(p.Endpoints) == 0 {
+		return nil
+	}
+	return &model.Policy{Providers: p.Providers, Endpoints: p.Endpoints, Reason: p.Reason}
+}
+
+// TracesConfig decides which multi-agent tasks are recorded in full, with
+// their prompts, events and timings, in debug bundles
+type TracesConfig struct {
+	// Share of tasks recorded, from 0 to 1
+	SampleRate float64 `yaml:"sample_rate,omitempty"`
+
+	// Record every failed task
+	Failed bool `yaml:"failed,omitempty"`
+
+	// Directory bundles are written to (default .sigil/traces)
+	Dir string `yaml:"dir,omitempty"`
+
+	// Bundles kept before the oldest are removed (default 20)
+	MaxBundles int `yaml:"max_bundles,omitempty"`
+}
+
+// ThemeConfig decides when and how terminal output is colored
+type ThemeConfig struct {
+	// When to color: auto (terminals, unless NO_COLOR is set), always or never
+	Color string `yaml:"color,omitempty"`
+
+	// Colors by role, such as "bold red" for error, replacing the defaults
+	Colors map[string]string `yaml:"colors,omitempty"`
+}
+
+// IndexConfig defines where the embeddings index used to answer repository
+// questions is stored
+type IndexConfig struct {
+	// Backend type (file, qdrant)
+	Backend string `yaml:"backend,omitempty"`
+
+	// Index file for the file backend, relative to the repository root
+	Path string `yaml:"path,omitempty"`
+
+	// Base URL of the qdrant server
+	URL string `yaml:"url,omitempty"`
+
+	// Qdrant collection holding the index, sigil-<repository> by default
+	Collection string `yaml:"collection,omitempty"`
+
+	// Environment variable holding the qdrant API key
+	TokenEnv string `yaml:"token_env,omitempty"`
+}
+
+// ChunkingConfig decides how files are split into chunks for the
+// embeddings index, and into parts when they are too long to summarize
+// whole
+type ChunkingConfig struct {
+	// Lines per index chunk (default 40, sharing 10 lines)
+	Size int `yaml:"size,omitempty"`
+
+	// Lines consecutive index chunks share when a size is set
+	Overlap int `yaml:"overlap,omitempty"`
+
+	// Lines above which a file is summarized in parts of at most that many
+	// lines (default 400)
+	SummarySize int `yaml:"summary_size,omitempty"`
+
+	// Chunking by file type, such as "md" or ".sql"
+	Types map[string]ChunkingTypeConfig `yaml:"types,omitempty"`
+}
+
+// ChunkingTypeConfig decides how the files of one type are chunked. Unset
+// fields fall back to the type's default strategy and the top-level sizes.
+type ChunkingTypeConfig struct {
+	// Strategy: lines, declarations or markdown
+	Strategy string `yaml:"strategy,omitempty"`
+
+	Size        int `yaml:"size,omitempty"`
+	Overlap     int `yaml:"overlap,omitempty"`
+	SummarySize int `yaml:"summary_size,omitempty"`
+}
+
+// Rules returns the chunking rules the configuration describes
+func (c ChunkingConfig) Rules() chunk.Rules {
+	rules := chunk.DefaultRules()
+	if c.Size != 0 {
+		rules.Index = chunk.Options{Size: c.Size, Overlap: c.Overlap}
+	}
+	if c.SummarySize != 0 {
+		rules.Summary.Size = c.SummarySize
+	}
+	if len(c.Types) > 0 {
+		rules.Types = make(map[string]chunk.Rule, len(c.Types))
+	}
+	for fileType, typeConfig := range c.Types {
+		rule := chunk.Rule{
+			Strategy: typeConfig.Strategy,
+			Index:    chunk.Options{Size: typeConfig.Size, Overlap: typeConfig.Overlap},
+			Summary:  chunk.Options{Size: typeConfig.SummarySize},
+		}
+		if rule.Index.Size == 0 && rule.Index.Overlap != 0 {
+			rule.Index.Size = rules.Index.Size
+		}
+		rules.Types[chunk.Extension(fileType)] = rule
+	}
+	return rules
 }
 
 // MCPConfig defines MCP server configuration
@@ -139,6 +473,31 @@ type MCPConfig struct {
 
 	// MCP server definitions
 	Servers []MCPServerConfig `yaml:"servers,omitempty"`
+
+	// Additional directories exposed to servers as roots, besides the
+	// repository root; relative paths are resolved against the repository root
+	Roots []string `yaml:"roots,omitempty"`
+
+	// Pooling of extra connections to each server
+	Pool MCPPoolConfig `yaml:"pool,omitempty"`
+}
+
+// MCPPoolConfig defines how connections to MCP servers are pooled
+type MCPPoolConfig struct {
+	// Most connections pooled per server (default 3)
+	Size int `yaml:"size,omitempty"`
+
+	// Retire connections this old (e.g. "30m")
+	MaxLifetime string `yaml:"max_lifetime,omitempty"`
+
+	// Retire connections unused for this long (e.g. "5m")
+	IdleTimeout string `yaml:"idle_timeout,omitempty"`
+
+	// How idle connections are chosen: round_robin or least_loaded
+	Selection string `yaml:"selection,omitempty"`
+
+	// Connections opened when a server starts
+	WarmUp int `yaml:"warm_up,omitempty"`
 }
 
 // MCPServerConfig defines a single MCP server
@@ -167,10 +526,37 @@ type MCPServerConfig struct {
 	// Maximum restart attempts
 	MaxRestarts int `yaml:"max_restarts,omitempty"`
 
+	// Start the server on first use instead of at model creation
+	LazyStart bool `yaml:"lazy_start,omitempty"`
+
+	// Stop the server after this long without requests (e.g. "10m")
+	IdleTimeout string `yaml:"idle_timeout,omitempty"`
+
 	// Server-specific settings
 	Settings struct {
-		Timeout    string `yaml:"timeout,omitempty"`
-		MaxRetries int    `yaml:"max_retries,omitempty"`
+		Timeout         string `yaml:"timeout,omitempty"`
+		MaxRetries      int    `yaml:"max_retries,omitempty"`
+		ProtocolVersion string `yaml:"protocol_version,omitempty"`
+		MaxMessageSize  int    `yaml:"max_message_size,omitempty"`
+		BufferSize      int    `yaml:"buffer_size,omitempty"`
+		WriteTimeout    string `yaml:"write_timeout,omitempty"`
+		CoalesceWindow  string `yaml:"coalesce_window,omitempty"`
+
+		// Liveness probing
+		HealthCheck struct {
+			Interval         string `yaml:"interval,omitempty"`
+			Timeout          string `yaml:"timeout,omitempty"`
+			FailureThreshold int    `yaml:"failure_threshold,omitempty"`
+			Probe            string `yaml:"probe,omitempty"`
+		} `yaml:"health_check,omitempty"`
+
+		// Delay between restart attempts
+		Backoff struct {
+			InitialDelay string  `yaml:"initial_delay,omitempty"`
+			MaxDelay     string  `yaml:"max_delay,omitempty"`
+			Multiplier   float64 `yaml:"multiplier,omitempty"`
+			Jitter       float64 `yaml:"jitter,omitempty"`
+		} `yaml:"backoff,omitempty"`
 	} `yaml:"settings,omitempty"`
 }
 
@@ -197,6 +583,29 @@ var defaultConfig = Config{
 		AutoCommit:     false,
 		Checkpoints:    true,
 		CommitTemplate: "sigil: %s",
+		AutoDeepen:     true,
+		MaxDeepen:      1000,
+		Commit: CommitConfig{
+			Template:     "{type}({scope}): {subject}\n\n{body}",
+			TemplateFile: ".sigil/commit_template",
+			MaxSubject:   72,
+			BodyWrap:     72,
+		},
+		Release: ReleaseConfig{
+			TagPrefix: "v",
+			Changelog: "CHANGELOG.md",
+		},
+	},
+	Cache: CacheConfig{
+		Enabled:   false,
+		Backend:   "local",
+		Path:      ".sigil/cache",
+		URLExpiry: 15 * time.Minute,
+	},
+	Docs: DocsConfig{
+		Lint:     true,
+		Fix:      true,
+		Glossary: ".sigil/glossary.yml",
 	},
 }
 
@@ -220,7 +629,23 @@ func Load(path string) (*Config, error) {
 	}
 	defer file.Close()
 
-	config, err := Parse(file)
+	config, err := parseValidated(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set global config with thread safety
+	globalMu.Lock()
+	globalConfig = config
+	globalMu.Unlock()
+
+	return config, nil
+}
+
+// parseValidated parses a configuration, applies environment overrides and
+// validates it without touching the global configuration
+func parseValidated(r io.Reader) (*Config, error) {
+	config, err := Parse(r)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrorTypeConfig, "Load", "failed to parse config file")
 	}
@@ -233,11 +658,6 @@ func Load(path string) (*Config, error) {
 		return nil, errors.Wrap(err, errors.ErrorTypeConfig, "Load", "invalid configuration")
 	}
 
-	// Set global config with thread safety
-	globalMu.Lock()
-	globalConfig = config
-	globalMu.Unlock()
-
 	return config, nil
 }
 
@@ -298,6 +718,16 @@ func (c *Config) Validate() error {
 			return errors.ConfigError("Validate", fmt.Sprintf("invalid reviewer model format: %s", reviewer))
 		}
 	}
+	for name, price := range c.Models.Pricing {
+		if price < 0 {
+			return errors.ConfigError("Validate", fmt.Sprintf("invalid price for model %s: %g (must be 0 or more)", name, price))
+		}
+	}
+	for name, quota := range c.Models.Quotas {
+		if quota.RPM < 0 || quota.TPM < 0 {
+			return errors.ConfigError("Validate", fmt.Sprintf("invalid quota for %s: rpm and tpm must be 0 or more", name))
+		}
+	}
 
 	// Validate logging level
 	validLevels := []string{"debug", "info", "warn", "error"}
@@ -312,6 +742,108 @@ func (c *Config) Validate() error {
 		return errors.ConfigError("Validate", fmt.Sprintf("invalid log level: %s", c.Logging.Level))
 	}
 
+	// Validate cache backend
+	if c.Cache.Enabled {
+		switch strings.ToLower(c.Cache.Backend) {
+		case "", "local", "http", "s3", "gcs":
+		default:
+			return errors.ConfigError("Validate", fmt.Sprintf("invalid cache backend: %s", c.Cache.Backend))
+		}
+	}
+
+	// Validate documentation style
+	switch strings.ToLower(c.Docs.HeadingCase) {
+	case "", "sentence", "title":
+	default:
+		return errors.ConfigError("Validate", fmt.Sprintf("invalid docs heading case: %s (valid: sentence, title)", c.Docs.HeadingCase))
+	}
+	if c.Docs.MaxLineLength < 0 {
+		return errors.ConfigError("Validate", fmt.Sprintf("invalid docs max line length: %d", c.Docs.MaxLineLength))
+	}
+	for _, pattern := range append(append([]string{}, c.Docs.IncludeSymbols...), c.Docs.ExcludeSymbols...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.ConfigError("Validate", fmt.Sprintf("invalid docs symbol pattern: %s", pattern))
+		}
+	}
+
+	// Validate commit message limits
+	if c.Git.Commit.MaxSubject < 0 {
+		return errors.ConfigError("Validate", fmt.Sprintf("invalid commit max subject: %d", c.Git.Commit.MaxSubject))
+	}
+	if c.Git.Commit.BodyWrap < 0 {
+		return errors.ConfigError("Validate", fmt.Sprintf("invalid commit body wrap: %d", c.Git.Commit.BodyWrap))
+	}
+
+	// Validate analyzers
+	seenAnalyzers := make(map[string]bool)
+	for _, analyzer := range c.Analyzers {
+		if analyzer.Name == "" {
+			return errors.ConfigError("Validate", "analyzer name is required")
+		}
+		if seenAnalyzers[analyzer.Name] {
+			return errors.ConfigError("Validate", fmt.Sprintf("duplicate analyzer: %s", analyzer.Name))
+		}
+		seenAnalyzers[analyzer.Name] = true
+	}
+
+	// Validate review areas
+	for i, area := range c.ReviewAreas {
+		name := area.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if len(area.Paths) == 0 {
+			return errors.ConfigError("Validate", fmt.Sprintf("review area %s has no paths", name))
+		}
+		switch strings.ToLower(area.Severity) {
+		case "", "info", "warning", "error", "critical":
+		default:
+			return errors.ConfigError("Validate", fmt.Sprintf("invalid severity for review area %s: %s (valid: info, warning, error, critical)", name, area.Severity))
+		}
+		if area.Only && len(area.Focus) == 0 {
+			return errors.ConfigError("Validate", fmt.Sprintf("review area %s sets only without focus areas", name))
+		}
+	}
+
+	// Validate trace sampling
+	if c.Traces.SampleRate < 0 || c.Traces.SampleRate > 1 {
+		return errors.ConfigError("Validate", fmt.Sprintf("invalid trace sample rate: %g (must be between 0 and 1)", c.Traces.SampleRate))
+	}
+	if c.Traces.MaxBundles < 0 {
+		return errors.ConfigError("Validate", fmt.Sprintf("invalid trace max bundles: %d", c.Traces.MaxBundles))
+	}
+
+	// Validate theme
+	if _, err := theme.ParseMode(c.Theme.Color); err != nil {
+		return errors.ConfigError("Validate", fmt.Sprintf("invalid theme color: %s (valid: auto, always, never)", c.Theme.Color))
+	}
+	if _, err := theme.Default().WithColors(c.Theme.Colors); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeConfig, "Validate", "invalid theme colors")
+	}
+
+	// Validate chunking
+	if err := c.Chunking.Rules().Validate(); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeConfig, "Validate", "invalid chunking")
+	}
+
+	// Validate index backend
+	switch strings.ToLower(c.Index.Backend) {
+	case "", "file":
+	case "qdrant":
+		if c.Index.URL == "" {
+			return errors.ConfigError("Validate", "index url is required for the qdrant backend")
+		}
+	default:
+		return errors.ConfigError("Validate", fmt.Sprintf("invalid index backend: %s", c.Index.Backend))
+	}
+
+	// Validate the model policy
+	if policy := c.Policy.Model(); policy != nil {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
+
 	// Validate MCP config if backend is MCP
 	if strings.ToLower(c.Backend) == "mcp" && c.MCP == nil {
 		return errors.ConfigError("Validate", "MCP configuration required when backend is 'mcp'")
@@ -339,6 +871,15 @@ func Set(config *Config) {
 	globalConfig = config
 }
 
+// EnvValue reads the environment variable a setting such as token_env
+// names, tolerating an empty name
+func EnvValue(name string) string {
+	if name == "" {
+		return ""
+	}
+	return os.Getenv(name)
+}
+
 // applyEnvOverrides applies environment variable overrides
 func applyEnvOverrides(config *Config) {
 	// Override model from environment
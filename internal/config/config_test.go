@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dshills/sigil/internal/chunk"
 	"github.com/dshills/sigil/internal/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -229,6 +230,197 @@ func TestConfigValidate(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid log level")
 	})
 
+	t.Run("invalid docs heading case fails validation", func(t *testing.T) {
+		config := &Config{
+			Models: ModelsConfig{
+				Lead: "openai:gpt-4",
+			},
+			Logging: LoggingConfig{
+				Level: "info",
+			},
+			Docs: DocsConfig{
+				HeadingCase: "upper",
+			},
+		}
+
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid docs heading case")
+	})
+
+	t.Run("invalid docs symbol pattern fails validation", func(t *testing.T) {
+		config := &Config{
+			Models: ModelsConfig{
+				Lead: "openai:gpt-4",
+			},
+			Logging: LoggingConfig{
+				Level: "info",
+			},
+			Docs: DocsConfig{
+				ExcludeSymbols: []string{"*_test", "[Deprecated"},
+			},
+		}
+
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid docs symbol pattern: [Deprecated")
+	})
+
+	t.Run("duplicate analyzer fails validation", func(t *testing.T) {
+		config := &Config{
+			Models: ModelsConfig{
+				Lead: "openai:gpt-4",
+			},
+			Logging: LoggingConfig{
+				Level: "info",
+			},
+			Analyzers: []AnalyzerConfig{{Name: "ruff"}, {Name: "ruff", Args: []string{"check"}}},
+		}
+
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "duplicate analyzer: ruff")
+	})
+
+	t.Run("invalid review areas fail validation", func(t *testing.T) {
+		for area, want := range map[*ReviewAreaConfig]string{
+			{Name: "auth"}: "review area auth has no paths",
+			{Paths: []string{"auth/**"}, Severity: "high"}:         "invalid severity for review area #1: high",
+			{Name: "docs", Paths: []string{"docs/**"}, Only: true}: "review area docs sets only without focus areas",
+		} {
+			config := &Config{
+				Models: ModelsConfig{
+					Lead: "openai:gpt-4",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+				ReviewAreas: []ReviewAreaConfig{*area},
+			}
+
+			err := config.Validate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+
+	t.Run("negative model price fails validation", func(t *testing.T) {
+		config := &Config{
+			Models: ModelsConfig{
+				Lead:    "openai:gpt-4",
+				Pricing: map[string]float64{"openai:gpt-4": -1},
+			},
+			Logging: LoggingConfig{
+				Level: "info",
+			},
+		}
+
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid price for model openai:gpt-4")
+	})
+
+	t.Run("negative quota fails validation", func(t *testing.T) {
+		config := &Config{
+			Models: ModelsConfig{
+				Lead:   "anthropic:claude-3-5-sonnet-20241022",
+				Quotas: map[string]QuotaConfig{"anthropic": {RPM: 50, TPM: -1}},
+			},
+			Logging: LoggingConfig{
+				Level: "info",
+			},
+		}
+
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid quota for anthropic")
+	})
+
+	t.Run("invalid policy endpoint fails validation", func(t *testing.T) {
+		config := &Config{
+			Models: ModelsConfig{
+				Lead: "openai:gpt-4",
+			},
+			Logging: LoggingConfig{
+				Level: "info",
+			},
+			Policy: PolicyConfig{Endpoints: []string{"[eu"}},
+		}
+
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid policy endpoint pattern: [eu")
+	})
+
+	t.Run("trace sample rate above 1 fails validation", func(t *testing.T) {
+		config := &Config{
+			Models: ModelsConfig{
+				Lead: "openai:gpt-4",
+			},
+			Logging: LoggingConfig{
+				Level: "info",
+			},
+			Traces: TracesConfig{SampleRate: 5},
+		}
+
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid trace sample rate: 5")
+	})
+
+	t.Run("unknown theme color fails validation", func(t *testing.T) {
+		config := &Config{
+			Models: ModelsConfig{
+				Lead: "openai:gpt-4",
+			},
+			Logging: LoggingConfig{
+				Level: "info",
+			},
+			Theme: ThemeConfig{Color: "auto", Colors: map[string]string{"error": "purple"}},
+		}
+
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid theme colors")
+	})
+
+	t.Run("unknown chunking strategy fails validation", func(t *testing.T) {
+		config := &Config{
+			Models: ModelsConfig{
+				Lead: "openai:gpt-4",
+			},
+			Logging: LoggingConfig{
+				Level: "info",
+			},
+			Chunking: ChunkingConfig{Types: map[string]ChunkingTypeConfig{"rs": {Strategy: "ast"}}},
+		}
+
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid chunking")
+	})
+
+	t.Run("qdrant index without url fails validation", func(t *testing.T) {
+		config := &Config{
+			Models: ModelsConfig{
+				Lead: "openai:gpt-4",
+			},
+			Logging: LoggingConfig{
+				Level: "info",
+			},
+			Index: IndexConfig{Backend: "qdrant"},
+		}
+
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "index url is required")
+
+		config.Index = IndexConfig{Backend: "pgvector"}
+		err = config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid index backend: pgvector")
+	})
+
 	t.Run("MCP backend without config fails validation", func(t *testing.T) {
 		config := &Config{
 			Models: ModelsConfig{
@@ -444,6 +636,15 @@ func TestDefaultConfig(t *testing.T) {
 		assert.False(t, config.Git.AutoCommit)
 		assert.True(t, config.Git.Checkpoints)
 		assert.Equal(t, "sigil: %s", config.Git.CommitTemplate)
+		assert.True(t, config.Git.AutoDeepen)
+		assert.Equal(t, 1000, config.Git.MaxDeepen)
+		assert.Equal(t, 72, config.Git.Commit.MaxSubject)
+		assert.Equal(t, 72, config.Git.Commit.BodyWrap)
+		assert.Equal(t, "v", config.Git.Release.TagPrefix)
+		assert.Equal(t, "CHANGELOG.md", config.Git.Release.Changelog)
+		assert.True(t, config.Docs.Lint)
+		assert.True(t, config.Docs.Fix)
+		assert.Equal(t, ".sigil/glossary.yml", config.Docs.Glossary)
 	})
 }
 
@@ -476,3 +677,30 @@ func TestConfigTypes(t *testing.T) {
 		assert.Equal(t, "value1", mcpConfig.Settings["setting1"])
 	})
 }
+
+func TestChunkingConfig_Rules(t *testing.T) {
+	rules := ChunkingConfig{
+		Size:        60,
+		SummarySize: 300,
+		Types: map[string]ChunkingTypeConfig{
+			"MD":   {Strategy: "lines", Overlap: 5},
+			".sql": {Size: 20, SummarySize: 100},
+		},
+	}.Rules()
+	require.NoError(t, rules.Validate())
+
+	assert.Equal(t, chunk.Options{Size: 60}, rules.IndexOptions("main.go"), "a size without an overlap has none")
+	assert.Equal(t, chunk.Options{Size: 60, Overlap: 5}, rules.IndexOptions("README.md"))
+	assert.IsType(t, chunk.LineChunker{}, rules.Chunker("README.md"))
+	assert.Equal(t, chunk.Options{Size: 20}, rules.IndexOptions("schema.sql"))
+	assert.Equal(t, chunk.Options{Size: 100}, rules.SummaryOptions("schema.sql"))
+	assert.Equal(t, chunk.Options{Size: 300}, rules.SummaryOptions("main.go"))
+
+	assert.Equal(t, chunk.DefaultRules(), ChunkingConfig{}.Rules())
+}
+
+func TestEnvValue(t *testing.T) {
+	t.Setenv("SIGIL_TEST_TOKEN", "secret")
+	assert.Equal(t, "secret", EnvValue("SIGIL_TEST_TOKEN"))
+	assert.Empty(t, EnvValue(""), "an unset setting reads nothing")
+}
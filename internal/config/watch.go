@@ -0,0 +1,107 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// DefaultWatchInterval is how often a Watcher checks its file for changes
+const DefaultWatchInterval = 2 * time.Second
+
+// Watcher reloads the configuration when its file changes, for long-running
+// processes. A changed file is parsed and validated first; an invalid file is
+// reported and the current configuration stays in effect.
+type Watcher struct {
+	path     string
+	interval time.Duration
+
+	mu        sync.Mutex
+	digest    []byte
+	listeners []func(*Config)
+}
+
+// NewWatcher creates a watcher for the configuration file at path. The file's
+// current contents are taken as already loaded.
+func NewWatcher(path string, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	w := &Watcher{path: path, interval: interval}
+	if data, err := os.ReadFile(path); err == nil {
+		w.digest = digest(data)
+	}
+	return w
+}
+
+// OnReload registers fn to run with the new configuration after each reload
+func (w *Watcher) OnReload(fn func(*Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.listeners = append(w.listeners, fn)
+}
+
+// Run polls the file until ctx is canceled. Polling keeps the watcher free of
+// platform-specific notification APIs and also catches editors that replace
+// the file instead of writing it in place.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// Check reloads the configuration if the file changed since the last check
+// and reports whether a new configuration was applied
+func (w *Watcher) Check() bool {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("failed to read configuration for reload", "path", w.path, "error", err)
+		}
+		return false
+	}
+
+	w.mu.Lock()
+	sum := digest(data)
+	if bytes.Equal(sum, w.digest) {
+		w.mu.Unlock()
+		return false
+	}
+	// Remember the contents even if invalid so the error is logged once
+	w.digest = sum
+	listeners := append([]func(*Config){}, w.listeners...)
+	w.mu.Unlock()
+
+	config, err := parseValidated(bytes.NewReader(data))
+	if err != nil {
+		logger.Error("configuration reload rejected, keeping current configuration", "path", w.path, "error", err)
+		return false
+	}
+
+	Set(config)
+	logger.Info("configuration reloaded", "path", w.path)
+
+	for _, fn := range listeners {
+		fn(config)
+	}
+	return true
+}
+
+// digest fingerprints file contents
+func digest(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
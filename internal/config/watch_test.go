@@ -0,0 +1,74 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_Check(t *testing.T) {
+	originalConfig := globalConfig
+	defer func() {
+		globalConfig = originalConfig
+	}()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("models:\n  lead: \"openai:gpt-4\"\n"), 0600))
+	_, err := Load(path)
+	require.NoError(t, err)
+
+	watcher := NewWatcher(path, time.Hour)
+	var reloaded []*Config
+	watcher.OnReload(func(c *Config) { reloaded = append(reloaded, c) })
+
+	// Unchanged contents are not reloaded
+	assert.False(t, watcher.Check())
+
+	require.NoError(t, os.WriteFile(path, []byte("models:\n  lead: \"anthropic:claude-3\"\n"), 0600))
+	assert.True(t, watcher.Check())
+	assert.Equal(t, "anthropic:claude-3", Get().Models.Lead)
+	require.Len(t, reloaded, 1)
+	assert.Same(t, Get(), reloaded[0])
+
+	// An invalid file keeps the current configuration
+	require.NoError(t, os.WriteFile(path, []byte("models:\n  lead: \"not a model\"\nlogging:\n  level: loud\n"), 0600))
+	assert.False(t, watcher.Check())
+	assert.Equal(t, "anthropic:claude-3", Get().Models.Lead)
+	assert.Len(t, reloaded, 1)
+
+	// A deleted file keeps the current configuration
+	require.NoError(t, os.Remove(path))
+	assert.False(t, watcher.Check())
+	assert.Equal(t, "anthropic:claude-3", Get().Models.Lead)
+}
+
+func TestWatcher_Run(t *testing.T) {
+	originalConfig := globalConfig
+	defer func() {
+		globalConfig = originalConfig
+	}()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("models:\n  lead: \"openai:gpt-4\"\n"), 0600))
+
+	watcher := NewWatcher(path, 10*time.Millisecond)
+	done := make(chan *Config, 1)
+	watcher.OnReload(func(c *Config) { done <- c })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Run(ctx)
+
+	require.NoError(t, os.WriteFile(path, []byte("models:\n  lead: \"ollama:llama3\"\n"), 0600))
+	select {
+	case c := <-done:
+		assert.Equal(t, "ollama:llama3", c.Models.Lead)
+	case <-time.After(2 * time.Second):
+		t.Fatal("configuration was not reloaded")
+	}
+}
@@ -0,0 +1,244 @@
+// Package consent records which model providers the user agreed to send a
+// repository's content to, and which of its paths. Nothing from the
+// repository is sent to a provider without it, so working across client
+// codebases never leaks one to a provider it was not cleared for.
+package consent
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/sandbox"
+)
+
+// File is where a repository's consent is recorded, relative to its root
+const File = ".sigil/trust.yml"
+
+var (
+	// ErrNoConsent is returned for requests to providers the repository
+	// has no consent for
+	ErrNoConsent = stderrors.New("no consent to send repository content to this provider")
+
+	// ErrOutOfScope is returned for requests carrying content from paths
+	// outside the consent
+	ErrOutOfScope = stderrors.New("content outside the paths consented to")
+)
+
+// Consent is the user's agreement to send a repository's content to model
+// providers. Without paths it covers the whole repository.
+type Consent struct {
+	Providers []string  `yaml:"providers"`
+	Paths     []string  `yaml:"paths,omitempty"`
+	GrantedAt time.Time `yaml:"granted_at"`
+}
+
+// Load reads the consent recorded in the repository at root, or nil when
+// none is
+func Load(root string) (*Consent, error) {
+	data, err := os.ReadFile(filepath.Join(root, File))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "Load", "failed to read consent")
+	}
+	var consent Consent
+	if err := yaml.Unmarshal(data, &consent); err != nil {
+		return nil, errors.ConfigError("Load", fmt.Sprintf("invalid %s: %v", File, err)).
+			WithHint("fix or delete the file, then record consent again with 'sigil trust'")
+	}
+	for _, pattern := range consent.Paths {
+		if _, err := sandbox.MatchPath(pattern, "x"); err != nil {
+			return nil, errors.ConfigError("Load", fmt.Sprintf("invalid path pattern %q in %s: %v", pattern, File, err))
+		}
+	}
+	return &consent, nil
+}
+
+// Save records consent in the repository at root
+func Save(root string, consent *Consent) error {
+	data, err := yaml.Marshal(consent)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Save", "failed to encode consent")
+	}
+	path := filepath.Join(root, File)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Save", "failed to create .sigil directory")
+	}
+	header := "# Model providers sigil may send this repository's content to, and the\n" +
+		"# paths it may send (all when none are listed). Managed by 'sigil trust'.\n"
+	if err := os.WriteFile(path, append([]byte(header), data...), 0644); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Save", "failed to write consent")
+	}
+	return nil
+}
+
+// Remove deletes the consent recorded in the repository at root
+func Remove(root string) error {
+	err := os.Remove(filepath.Join(root, File))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Remove", "failed to remove consent")
+	}
+	return nil
+}
+
+// Grant returns consent, which may be nil, with providers and paths
+// added. Paths narrow a consent given for the first time and widen a
+// narrowed one; a consent for the whole repository stays one.
+func Grant(consent *Consent, providers, paths []string, now time.Time) *Consent {
+	granted := &Consent{}
+	if consent != nil {
+		*granted = *consent
+	}
+	granted.Providers = union(granted.Providers, providers)
+	// A consent already covering the whole repository stays that way
+	if consent == nil || len(consent.Paths) > 0 {
+		granted.Paths = union(granted.Paths, paths)
+	}
+	granted.GrantedAt = now.UTC().Truncate(time.Second)
+	return granted
+}
+
+// union returns the sorted distinct entries of a and b
+func union(a, b []string) []string {
+	seen := make(map[string]bool)
+	var all []string
+	for _, entry := range append(append([]string{}, a...), b...) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		all = append(all, entry)
+	}
+	sort.Strings(all)
+	return all
+}
+
+// AllowsProvider reports whether content may be sent to provider
+func (c *Consent) AllowsProvider(provider string) bool {
+	if c == nil {
+		return false
+	}
+	for _, allowed := range c.Providers {
+		if strings.EqualFold(allowed, provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsPath reports whether the content of path, relative to the
+// repository root, may be sent
+func (c *Consent) AllowsPath(path string) bool {
+	if c == nil {
+		return false
+	}
+	if len(c.Paths) == 0 {
+		return true
+	}
+	for _, pattern := range c.Paths {
+		if matched, err := sandbox.MatchPath(pattern, path); err == nil && matched {
+			return true
+		}
+		// A directory pattern covers the files below it
+		if dir := strings.TrimSuffix(filepath.ToSlash(pattern), "/"); strings.HasPrefix(filepath.ToSlash(path), dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Local reports whether a provider runs on this machine, so sending it
+// content needs no consent: its endpoint is a loopback address, or it is
+// Ollama on its default local endpoint
+func Local(provider, endpoint string) bool {
+	if endpoint == "" {
+		return strings.EqualFold(provider, "ollama")
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	host := parsed.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+var (
+	mu      sync.RWMutex
+	root    string
+	current *Consent
+)
+
+// Set makes consent the consent of the repository at repoRoot for the
+// process; nil means none was given
+func Set(repoRoot string, consent *Consent) {
+	mu.Lock()
+	defer mu.Unlock()
+	root = repoRoot
+	current = consent
+}
+
+// Current returns the repository root and the consent of the process
+func Current() (string, *Consent) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return root, current
+}
+
+// Check refuses sending content from paths to a provider at endpoint
+// unless the provider is local or consented to and every path is in the
+// consent's scope. Paths are relative to the working directory or absolute.
+func Check(provider, endpoint string, paths []string) error {
+	if Local(provider, endpoint) {
+		return nil
+	}
+	repoRoot, consent := Current()
+	if !consent.AllowsProvider(provider) {
+		return errors.Wrap(ErrNoConsent, errors.ErrorTypeValidation, "Check", fmt.Sprintf("sending to %s needs consent", provider)).
+			WithCode(errors.CodeNoConsent).
+			WithHint(fmt.Sprintf("run 'sigil trust --provider %s' to allow sending this repository's content to it", provider))
+	}
+	for _, path := range paths {
+		rel, ok := relative(repoRoot, path)
+		if ok && consent.AllowsPath(rel) {
+			continue
+		}
+		return errors.Wrap(ErrOutOfScope, errors.ErrorTypeValidation, "Check", fmt.Sprintf("%s is outside the paths consented to", path)).
+			WithCode(errors.CodeNoConsent).
+			WithHint(fmt.Sprintf("leave it out, or widen the consent with 'sigil trust --path <pattern>' (see %s)", File))
+	}
+	return nil
+}
+
+// relative returns path relative to the repository root, or false when it
+// is outside the repository
+func relative(repoRoot, path string) (string, bool) {
+	if repoRoot == "" {
+		return filepath.ToSlash(filepath.Clean(path)), true
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(repoRoot, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
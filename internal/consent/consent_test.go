@@ -0,0 +1,96 @@
+package consent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+func TestLoadSave(t *testing.T) {
+	root := t.TempDir()
+
+	consent, err := Load(root)
+	require.NoError(t, err)
+	assert.Nil(t, consent, "no consent until one is given")
+
+	granted := Grant(nil, []string{"openai", "anthropic", "openai"}, []string{"internal/**"}, time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC))
+	require.NoError(t, Save(root, granted))
+	consent, err = Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, &Consent{
+		Providers: []string{"anthropic", "openai"},
+		Paths:     []string{"internal/**"},
+		GrantedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}, consent)
+
+	require.NoError(t, Remove(root))
+	require.NoError(t, Remove(root), "removing twice is fine")
+	consent, err = Load(root)
+	require.NoError(t, err)
+	assert.Nil(t, consent)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, File), []byte("paths: ['{a']\n"), 0644))
+	_, err = Load(root)
+	assert.ErrorContains(t, err, "invalid path pattern")
+}
+
+func TestGrant(t *testing.T) {
+	now := time.Now()
+
+	whole := Grant(nil, []string{"anthropic"}, nil, now)
+	assert.Empty(t, whole.Paths)
+	assert.Empty(t, Grant(whole, []string{"openai"}, []string{"docs/**"}, now).Paths, "a consent for the whole repository is not narrowed")
+
+	narrow := Grant(nil, []string{"anthropic"}, []string{"docs/**"}, now)
+	widened := Grant(narrow, nil, []string{"README.md"}, now)
+	assert.Equal(t, []string{"README.md", "docs/**"}, widened.Paths)
+	assert.Equal(t, []string{"docs/**"}, narrow.Paths, "the consent granted to is left alone")
+}
+
+func TestConsent_Allows(t *testing.T) {
+	var none *Consent
+	assert.False(t, none.AllowsProvider("anthropic"))
+
+	consent := &Consent{Providers: []string{"anthropic"}, Paths: []string{"internal/**", "cmd", "*.md"}}
+	assert.True(t, consent.AllowsProvider("Anthropic"))
+	assert.False(t, consent.AllowsProvider("openai"))
+
+	assert.True(t, consent.AllowsPath("internal/store/store.go"))
+	assert.True(t, consent.AllowsPath("cmd/sigil/main.go"), "a directory covers its files")
+	assert.True(t, consent.AllowsPath("docs/guide.md"), "patterns without a slash match names anywhere")
+	assert.False(t, consent.AllowsPath("client/secrets.go"))
+	assert.True(t, (&Consent{Providers: []string{"anthropic"}}).AllowsPath("client/secrets.go"))
+}
+
+func TestLocal(t *testing.T) {
+	assert.True(t, Local("ollama", ""))
+	assert.True(t, Local("openai", "http://localhost:1234/v1"))
+	assert.True(t, Local("openai", "http://127.0.0.1:8080"))
+	assert.True(t, Local("openai", "http://[::1]:8080"))
+	assert.False(t, Local("openai", ""))
+	assert.False(t, Local("ollama", "http://gpu-box.internal:11434"))
+}
+
+func TestCheck(t *testing.T) {
+	root := t.TempDir()
+	t.Cleanup(func() { Set("", nil) })
+
+	Set(root, nil)
+	assert.NoError(t, Check("ollama", "", []string{"anything.go"}), "local providers need no consent")
+	err := Check("anthropic", "", nil)
+	require.ErrorIs(t, err, ErrNoConsent)
+	assert.Equal(t, errors.CodeNoConsent, errors.CodeOf(err))
+	assert.Contains(t, errors.HintOf(err), "sigil trust --provider anthropic")
+
+	Set(root, &Consent{Providers: []string{"anthropic"}, Paths: []string{"internal/**"}})
+	assert.NoError(t, Check("anthropic", "", []string{filepath.Join(root, "internal", "a.go")}))
+	err = Check("anthropic", "", []string{filepath.Join(root, "vendor", "a.go")})
+	assert.ErrorIs(t, err, ErrOutOfScope)
+	assert.ErrorIs(t, Check("anthropic", "", []string{filepath.Join(filepath.Dir(root), "elsewhere.go")}), ErrOutOfScope)
+}
@@ -0,0 +1,136 @@
+// Package deterministic records whether sigil runs in deterministic mode,
+// where the same inputs give byte-identical output for golden-file tests
+// and reproducible CI artifacts. In that mode models are asked for
+// temperature 0, times read as a fixed epoch, durations as zero, formatted
+// lists are sorted and generated names come from a fixed seed.
+package deterministic
+
+import (
+	"cmp"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Seed seeds randomness in deterministic mode, and is passed to providers
+// that accept a sampling seed
+const Seed = 1
+
+// Epoch is the time reported by Now in deterministic mode
+var Epoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	mu      sync.Mutex
+	enabled bool
+	source  *rand.Rand
+	counts  map[string]uint64 // Tokens handed out by TokenFor, per kind
+)
+
+// Set puts the process in or out of deterministic mode. Entering it
+// restarts the seeded sequence behind Token and the counts behind TokenFor.
+func Set(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = on
+	source = nil
+	counts = nil
+	if on {
+		source = rand.New(rand.NewPCG(Seed, Seed))
+		counts = make(map[string]uint64)
+	}
+}
+
+// Enabled reports whether the process is in deterministic mode
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// Now returns the current time, or Epoch in deterministic mode
+func Now() time.Time {
+	return Time(time.Now())
+}
+
+// Time returns t, or Epoch in deterministic mode. It pins times taken
+// before the mode was known, such as a command's start time.
+func Time(t time.Time) time.Time {
+	if Enabled() {
+		return Epoch
+	}
+	return t
+}
+
+// Duration returns d, or zero in deterministic mode
+func Duration(d time.Duration) time.Duration {
+	if Enabled() {
+		return 0
+	}
+	return d
+}
+
+// Temperature returns the sampling temperature to request for t: t itself,
+// or 0 in deterministic mode
+func Temperature(t float64) float64 {
+	if Enabled() {
+		return 0
+	}
+	return t
+}
+
+// Token returns a random string of n lowercase letters and digits for
+// naming worktrees and artifacts. In deterministic mode it comes from the
+// seeded sequence, so a run names things the same way every time.
+func Token(n int) string {
+	mu.Lock()
+	defer mu.Unlock()
+	if source != nil {
+		return token(n, source.IntN)
+	}
+	return token(n, rand.IntN)
+}
+
+// TokenFor returns a token like Token for naming the next thing of a kind,
+// such as the next edit task or the next proposal of an agent. In
+// deterministic mode it is derived from the kind and how many tokens of
+// that kind came before, so an ID does not depend on what else the run,
+// or another goroutine, named first.
+func TokenFor(kind string, n int) string {
+	mu.Lock()
+	defer mu.Unlock()
+	if counts == nil {
+		return token(n, rand.IntN)
+	}
+	counts[kind]++
+	h := fnv.New64a()
+	h.Write([]byte(kind))
+	return token(n, rand.New(rand.NewPCG(h.Sum64(), counts[kind])).IntN)
+}
+
+// token returns n lowercase letters and digits picked with intN
+func token(n int, intN func(int) int) string {
+	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = charset[intN(len(charset))]
+	}
+	return string(b)
+}
+
+// Sorted returns items in order in deterministic mode, and as they are
+// otherwise. The input is never modified.
+func Sorted[T cmp.Ordered](items []T) []T {
+	return SortedFunc(items, cmp.Compare[T])
+}
+
+// SortedFunc is Sorted with an explicit comparison
+func SortedFunc[T any](items []T, compare func(a, b T) int) []T {
+	if !Enabled() || len(items) < 2 {
+		return items
+	}
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, compare)
+	return sorted
+}
@@ -0,0 +1,54 @@
+package deterministic
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeterministicMode(t *testing.T) {
+	t.Cleanup(func() { Set(false) })
+	start := time.Date(2025, time.March, 3, 10, 0, 0, 0, time.UTC)
+	items := []string{"b.go", "a.go", "c.go"}
+
+	Set(false)
+	assert.Equal(t, start, Time(start))
+	assert.Equal(t, time.Second, Duration(time.Second))
+	assert.Equal(t, 0.2, Temperature(0.2))
+	assert.Equal(t, []string{"b.go", "a.go", "c.go"}, Sorted(items))
+	assert.Len(t, Token(8), 8)
+
+	Set(true)
+	assert.Equal(t, Epoch, Time(start))
+	assert.Equal(t, Epoch, Now())
+	assert.Zero(t, Duration(time.Second))
+	assert.Zero(t, Temperature(0.2))
+	assert.Equal(t, []string{"a.go", "b.go", "c.go"}, Sorted(items))
+	assert.Equal(t, []string{"b.go", "a.go", "c.go"}, items, "the input is left alone")
+	assert.Equal(t, []int{3, 2, 1}, SortedFunc([]int{1, 3, 2}, func(a, b int) int { return b - a }))
+
+	first := Token(8) + Token(8)
+	Set(true)
+	assert.Equal(t, first, Token(8)+Token(8), "the seeded sequence restarts")
+	assert.Empty(t, strings.Trim(first, "abcdefghijklmnopqrstuvwxyz0123456789"))
+}
+
+func TestTokenFor(t *testing.T) {
+	t.Cleanup(func() { Set(false) })
+
+	Set(true)
+	edits := []string{TokenFor("edit", 6), TokenFor("edit", 6)}
+	assert.NotEqual(t, edits[0], edits[1], "each token of a kind differs")
+	assert.NotEqual(t, edits[0], TokenFor("review", 6), "kinds differ")
+
+	Set(true)
+	Token(8)
+	TokenFor("review", 6)
+	assert.Equal(t, edits[0], TokenFor("edit", 6), "other tokens named first do not change it")
+	assert.Equal(t, edits[1], TokenFor("edit", 6))
+
+	Set(false)
+	assert.Len(t, TokenFor("edit", 6), 6)
+}
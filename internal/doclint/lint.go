@@ -0,0 +1,334 @@
+// Package doclint checks generated Markdown documentation against a
+// project glossary and style rules, fixing what it can.
+package doclint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/dshills/sigil/internal/glossary"
+)
+
+// Heading case styles
+const (
+	HeadingCaseSentence = "sentence"
+	HeadingCaseTitle    = "title"
+)
+
+// Rule names reported with issues
+const (
+	RuleGlossary    = "glossary"
+	RuleHeadingCase = "heading-case"
+	RuleLineLength  = "line-length"
+)
+
+var (
+	// headingLine splits a Markdown heading into its marker and text
+	headingLine = regexp.MustCompile(`^(#{1,6}\s+)(.*?)(\s*#*\s*)$`)
+
+	// listPrefix matches the indentation and marker starting a list item or quote
+	listPrefix = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)]|>)?\s*)`)
+
+	// smallWords stay lowercase inside title case headings
+	smallWords = map[string]bool{
+		"a": true, "an": true, "and": true, "as": true, "at": true, "but": true, "by": true,
+		"for": true, "in": true, "nor": true, "of": true, "on": true, "or": true, "the": true,
+		"to": true, "vs": true, "via": true, "with": true,
+	}
+)
+
+// Rules are the style rules applied besides the glossary
+type Rules struct {
+	HeadingCase   string // sentence, title, or empty to leave headings alone
+	MaxLineLength int    // 0 for no limit
+}
+
+// Issue is a problem found in a document
+type Issue struct {
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Fixed   bool   `json:"fixed"`
+}
+
+// String formats the issue for display
+func (i Issue) String() string {
+	return fmt.Sprintf("line %d: %s: %s", i.Line, i.Rule, i.Message)
+}
+
+// Linter checks documents against a glossary and rules
+type Linter struct {
+	glossary *glossary.Glossary
+	rules    Rules
+}
+
+// New creates a linter; a nil glossary checks style rules only
+func New(g *glossary.Glossary, rules Rules) *Linter {
+	if g == nil {
+		g = glossary.New(nil)
+	}
+	return &Linter{glossary: g, rules: rules}
+}
+
+// Lint reports the issues in content without changing it
+func (l *Linter) Lint(content string) []Issue {
+	_, issues := l.check(content, false)
+	return issues
+}
+
+// Fix rewrites content to follow the glossary and rules. Issues that were
+// fixed are marked as such; the rest need a human.
+func (l *Linter) Fix(content string) (string, []Issue) {
+	return l.check(content, true)
+}
+
+// check walks the document line by line, skipping front matter and fenced
+// code, and applies each rule
+func (l *Linter) check(content string, fix bool) (string, []Issue) {
+	var issues []Issue
+	lines := strings.Split(content, "\n")
+	var result []string
+
+	inFence := false
+	inFrontMatter := len(lines) > 0 && strings.TrimSpace(lines[0]) == "---"
+	for i, line := range lines {
+		number := i + 1
+		trimmed := strings.TrimSpace(line)
+
+		if inFrontMatter {
+			result = append(result, line)
+			if i > 0 && trimmed == "---" {
+				inFrontMatter = false
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if inFence || strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			result = append(result, line)
+			continue
+		}
+
+		line, found := l.checkGlossary(line, number, fix)
+		issues = append(issues, found...)
+
+		if match := headingLine.FindStringSubmatch(line); match != nil {
+			line, found = l.checkHeading(match, number, fix)
+			issues = append(issues, found...)
+			result = append(result, line)
+			continue
+		}
+
+		wrapped, found := l.checkLineLength(line, number, fix)
+		issues = append(issues, found...)
+		result = append(result, wrapped...)
+	}
+
+	return strings.Join(result, "\n"), issues
+}
+
+// checkGlossary replaces non-preferred spellings of glossary terms outside
+// protected spans
+func (l *Linter) checkGlossary(line string, number int, fix bool) (string, []Issue) {
+	fixed, replacements := l.glossary.Replace(line)
+	issues := make([]Issue, 0, len(replacements))
+	for _, replacement := range replacements {
+		issues = append(issues, Issue{
+			Line:    number,
+			Rule:    RuleGlossary,
+			Message: fmt.Sprintf("use %q instead of %q", replacement.Term, replacement.Found),
+			Fixed:   fix,
+		})
+	}
+	if fix {
+		line = fixed
+	}
+	return line, issues
+}
+
+// checkHeading applies the heading case rule
+func (l *Linter) checkHeading(match []string, number int, fix bool) (string, []Issue) {
+	line := match[0]
+	if l.rules.HeadingCase == "" {
+		return line, nil
+	}
+
+	text := match[2]
+	want := l.caseHeading(text)
+	if want == text {
+		return line, nil
+	}
+
+	issue := Issue{
+		Line:    number,
+		Rule:    RuleHeadingCase,
+		Message: fmt.Sprintf("use %s case: %q", l.rules.HeadingCase, want),
+		Fixed:   fix,
+	}
+	if fix {
+		line = match[1] + want + match[3]
+	}
+	return line, []Issue{issue}
+}
+
+// caseHeading returns heading text in the configured case. Words that look
+// like names (acronyms, mixed case, code, glossary terms) keep their casing.
+func (l *Linter) caseHeading(text string) string {
+	protected := glossary.ProtectedSpans(text)
+	words := wordSpans(text)
+
+	var result strings.Builder
+	last := 0
+	for i, span := range words {
+		start, end := span[0], span[1]
+		result.WriteString(text[last:start])
+		last = end
+
+		word := text[start:end]
+		if glossary.Overlaps(protected, start, end) || !isPlainWord(word) {
+			result.WriteString(word)
+			continue
+		}
+		if preferred, ok := l.glossary.Word(word); ok {
+			result.WriteString(preferred)
+			continue
+		}
+
+		first := i == 0 || strings.HasSuffix(strings.TrimSpace(text[:start]), ":")
+		switch {
+		case first:
+			result.WriteString(capitalize(word))
+		case l.rules.HeadingCase == HeadingCaseTitle && (!smallWords[strings.ToLower(word)] || i == len(words)-1):
+			result.WriteString(capitalize(word))
+		default:
+			result.WriteString(strings.ToLower(word))
+		}
+	}
+	result.WriteString(text[last:])
+	return result.String()
+}
+
+// checkLineLength wraps lines longer than the limit at spaces. Tables,
+// headings and lines that cannot be broken are reported instead.
+func (l *Linter) checkLineLength(line string, number int, fix bool) ([]string, []Issue) {
+	limit := l.rules.MaxLineLength
+	if limit <= 0 || len([]rune(line)) <= limit {
+		return []string{line}, nil
+	}
+
+	trimmed := strings.TrimSpace(line)
+	if !fix || strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "<") {
+		return []string{line}, []Issue{lineLengthIssue(number, line, limit, false)}
+	}
+
+	wrapped := wrapLine(line, limit)
+	fixed := true
+	for _, w := range wrapped {
+		if len([]rune(w)) > limit {
+			fixed = false
+		}
+	}
+	return wrapped, []Issue{lineLengthIssue(number, line, limit, fixed)}
+}
+
+// lineLengthIssue reports a line over the limit
+func lineLengthIssue(number int, line string, limit int, fixed bool) Issue {
+	return Issue{
+		Line:    number,
+		Rule:    RuleLineLength,
+		Message: fmt.Sprintf("line is %d characters (limit %d)", len([]rune(line)), limit),
+		Fixed:   fixed,
+	}
+}
+
+// wrapLine breaks a line at spaces, indenting continuation lines to line
+// up with the text after any list marker, and never splitting protected spans
+func wrapLine(line string, limit int) []string {
+	prefix := listPrefix.FindString(line)
+	indent := strings.Repeat(" ", len([]rune(prefix)))
+	if strings.TrimSpace(prefix) == ">" {
+		indent = prefix
+	}
+
+	var lines []string
+	current := prefix
+	for _, word := range protectedFields(line[len(prefix):]) {
+		if current != prefix && current != indent && len([]rune(current))+1+len([]rune(word)) > limit {
+			lines = append(lines, current)
+			current = indent
+		}
+		if current != prefix && current != indent {
+			current += " "
+		}
+		current += word
+	}
+	return append(lines, current)
+}
+
+// protectedFields splits text at spaces that fall outside protected spans
+func protectedFields(text string) []string {
+	protected := glossary.ProtectedSpans(text)
+	var fields []string
+	start := -1
+	for i, r := range text {
+		if r == ' ' && !glossary.Overlaps(protected, i, i+1) {
+			if start >= 0 {
+				fields = append(fields, text[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, text[start:])
+	}
+	return fields
+}
+
+// wordSpans returns the byte ranges of words in text
+func wordSpans(text string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '-' || r == '_'
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
+
+// isPlainWord reports whether a word is an ordinary word whose case can be
+// changed: letters only, with at most its first letter capitalized
+func isPlainWord(word string) bool {
+	for i, r := range word {
+		if !unicode.IsLetter(r) && r != '\'' && r != '-' {
+			return false
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// capitalize uppercases the first letter of a word
+func capitalize(word string) string {
+	for i, r := range word {
+		return word[:i] + string(unicode.ToUpper(r)) + word[i+len(string(r)):]
+	}
+	return word
+}
@@ -0,0 +1,89 @@
+package doclint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/glossary"
+)
+
+func TestLinter_Glossary(t *testing.T) {
+	g := glossary.New([]glossary.Term{
+		{Term: "GitHub", Avoid: []string{"git hub"}},
+		{Term: "Kubernetes", Avoid: []string{"k8s"}},
+	})
+	linter := New(g, Rules{})
+
+	content := "Deploy to k8s from Github or git hub.\n" +
+		"See https://github.com/org/repo, `github` and [docs](https://kubernetes.io).\n" +
+		"```\ngithub stays in code\n```\n"
+
+	issues := linter.Lint(content)
+	require.Len(t, issues, 3)
+	assert.Equal(t, RuleGlossary, issues[0].Rule)
+	assert.False(t, issues[0].Fixed)
+
+	fixed, issues := linter.Fix(content)
+	assert.Equal(t, "Deploy to Kubernetes from GitHub or GitHub.\n"+
+		"See https://github.com/org/repo, `github` and [docs](https://kubernetes.io).\n"+
+		"```\ngithub stays in code\n```\n", fixed)
+	assert.Len(t, issues, 3)
+	assert.True(t, issues[0].Fixed)
+	assert.Empty(t, linter.Lint(fixed))
+}
+
+func TestLinter_HeadingCase(t *testing.T) {
+	g := glossary.New([]glossary.Term{{Term: "Go"}})
+
+	tests := []struct {
+		style string
+		input string
+		want  string
+	}{
+		{HeadingCaseSentence, "## Configuring The HTTP Server In Go", "## Configuring the HTTP server in Go"},
+		{HeadingCaseSentence, "# Overview: Using `NewServer`", "# Overview: Using `NewServer`"},
+		{HeadingCaseTitle, "## configuring the http server in go", "## Configuring the Http Server in Go"},
+		{HeadingCaseTitle, "### what it is for", "### What It Is For"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			linter := New(g, Rules{HeadingCase: tt.style})
+			fixed, _ := linter.Fix(tt.input)
+			assert.Equal(t, tt.want, fixed)
+		})
+	}
+
+	linter := New(nil, Rules{})
+	assert.Empty(t, linter.Lint("## Leave This Alone"), "no heading style configured")
+}
+
+func TestLinter_LineLength(t *testing.T) {
+	linter := New(nil, Rules{MaxLineLength: 30})
+
+	content := "- a list item that is far too long for the limit\n" +
+		"| a | table | row | that | is | far | too | long |\n" +
+		"short line"
+
+	fixed, issues := linter.Fix(content)
+	assert.Equal(t, "- a list item that is far too\n"+
+		"  long for the limit\n"+
+		"| a | table | row | that | is | far | too | long |\n"+
+		"short line", fixed)
+	require.Len(t, issues, 2)
+	assert.True(t, issues[0].Fixed)
+	assert.False(t, issues[1].Fixed, "tables are never wrapped")
+
+	_, issues = linter.Fix("see https://example.com/a/very/long/path/that/cannot/break")
+	require.Len(t, issues, 1)
+	assert.False(t, issues[0].Fixed, "unbreakable lines stay over the limit")
+}
+
+func TestLinter_SkipsFrontMatter(t *testing.T) {
+	linter := New(glossary.New([]glossary.Term{{Term: "GitHub"}}), Rules{HeadingCase: HeadingCaseSentence})
+	content := "---\nkeep: [github]\n---\n# Github Setup"
+
+	fixed, _ := linter.Fix(content)
+	assert.Equal(t, "---\nkeep: [github]\n---\n# GitHub setup", fixed)
+}
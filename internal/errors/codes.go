@@ -0,0 +1,249 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Code is a stable identifier for a class of user-facing error
+type Code string
+
+const (
+	CodeUnknown       Code = "SIG000"
+	CodeConfig        Code = "SIG100"
+	CodeMissingAPIKey Code = "SIG101"
+	CodeModel         Code = "SIG200"
+	CodeModelNotFound Code = "SIG201"
+	CodeRateLimited   Code = "SIG202"
+	CodeGit           Code = "SIG300"
+	CodeNotGitRepo    Code = "SIG301"
+	CodeFS            Code = "SIG400"
+	CodeNotFound      Code = "SIG401"
+	CodeValidation    Code = "SIG500"
+	CodeUntrusted     Code = "SIG501"
+	CodeNoConsent     Code = "SIG502"
+	CodeDisallowed    Code = "SIG503"
+	CodeNetwork       Code = "SIG600"
+	CodeInput         Code = "SIG700"
+	CodeOutput        Code = "SIG800"
+	CodeInternal      Code = "SIG900"
+)
+
+// Exit codes follow the BSD sysexits convention
+const (
+	ExitOK          = 0
+	ExitGeneral     = 1
+	ExitUsage       = 64 // EX_USAGE: bad command line input
+	ExitDataErr     = 65 // EX_DATAERR: input data was invalid
+	ExitNoInput     = 66 // EX_NOINPUT: required input (e.g. repository) missing
+	ExitUnavailable = 69 // EX_UNAVAILABLE: a required service is unavailable
+	ExitSoftware    = 70 // EX_SOFTWARE: internal error
+	ExitCantCreate  = 73 // EX_CANTCREAT: output could not be written
+	ExitIOErr       = 74 // EX_IOERR: filesystem error
+	ExitConfig      = 78 // EX_CONFIG: configuration error
+)
+
+// typeDefaults maps each error type to its default code and exit code
+var typeDefaults = map[ErrorType]struct {
+	code Code
+	exit int
+}{
+	ErrorTypeConfig:     {CodeConfig, ExitConfig},
+	ErrorTypeModel:      {CodeModel, ExitUnavailable},
+	ErrorTypeGit:        {CodeGit, ExitNoInput},
+	ErrorTypeFS:         {CodeFS, ExitIOErr},
+	ErrorTypeValidation: {CodeValidation, ExitDataErr},
+	ErrorTypeNetwork:    {CodeNetwork, ExitUnavailable},
+	ErrorTypeInput:      {CodeInput, ExitUsage},
+	ErrorTypeOutput:     {CodeOutput, ExitCantCreate},
+	ErrorTypeInternal:   {CodeInternal, ExitSoftware},
+}
+
+// hintRule recognizes errors that were not annotated at their source
+type hintRule struct {
+	code     Code
+	patterns []string
+	hint     string
+}
+
+// hintRules are matched in order against the lower-cased error text
+var hintRules = []hintRule{
+	{CodeMissingAPIKey, []string{"anthropic api key"}, "set ANTHROPIC_API_KEY or models.configs.anthropic.apikey in .sigil/config.yml"},
+	{CodeMissingAPIKey, []string{"openai api key"}, "set OPENAI_API_KEY or models.configs.openai.apikey in .sigil/config.yml"},
+	{CodeNotGitRepo, []string{"not a git repository", "not in a git repository"}, "run sigil from inside a Git repository, or create one with 'git init'"},
+	{CodeModelNotFound, []string{"model not found", "unknown provider", "invalid model"}, "use the provider:model format, e.g. anthropic:claude-3-5-sonnet-20241022"},
+	{CodeRateLimited, []string{"rate limit", "status 429", "too many requests"}, "the provider is rate limiting requests; wait a moment and retry"},
+	{CodeNetwork, []string{"connection refused"}, "check that the model endpoint is reachable (for Ollama, run 'ollama serve')"},
+}
+
+// chain returns every SigilError in err's chain, outermost first
+func chain(err error) []*SigilError {
+	var errs []*SigilError
+	for err != nil {
+		var sigilErr *SigilError
+		if !errors.As(err, &sigilErr) {
+			break
+		}
+		errs = append(errs, sigilErr)
+		err = sigilErr.Err
+	}
+	return errs
+}
+
+// matchRule finds the first hint rule matching err
+func matchRule(err error) (hintRule, bool) {
+	text := strings.ToLower(err.Error())
+	for _, rule := range hintRules {
+		for _, pattern := range rule.patterns {
+			if strings.Contains(text, pattern) {
+				return rule, true
+			}
+		}
+	}
+	return hintRule{}, false
+}
+
+// CodeOf returns the most specific code for err. Explicit codes deeper in the
+// chain win over outer ones since they are closest to the root cause.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+
+	errs := chain(err)
+	for i := len(errs) - 1; i >= 0; i-- {
+		if errs[i].Code != "" {
+			return errs[i].Code
+		}
+	}
+	if rule, ok := matchRule(err); ok {
+		return rule.code
+	}
+	if errors.Is(err, ErrNotGitRepo) {
+		return CodeNotGitRepo
+	}
+	if errors.Is(err, ErrModelNotFound) {
+		return CodeModelNotFound
+	}
+	if errors.Is(err, ErrNotFound) {
+		return CodeNotFound
+	}
+	if len(errs) > 0 {
+		if defaults, ok := typeDefaults[errs[len(errs)-1].Type]; ok {
+			return defaults.code
+		}
+	}
+	return CodeUnknown
+}
+
+// HintOf returns a remediation hint for err, or an empty string
+func HintOf(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	errs := chain(err)
+	for i := len(errs) - 1; i >= 0; i-- {
+		if errs[i].Hint != "" {
+			return errs[i].Hint
+		}
+	}
+	if rule, ok := matchRule(err); ok {
+		return rule.hint
+	}
+	return ""
+}
+
+// ExitCode maps err to a process exit code based on its root cause type
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	switch CodeOf(err) {
+	case CodeMissingAPIKey:
+		return ExitConfig
+	case CodeNotGitRepo:
+		return ExitNoInput
+	}
+
+	errs := chain(err)
+	if len(errs) == 0 {
+		return ExitGeneral
+	}
+	if defaults, ok := typeDefaults[errs[len(errs)-1].Type]; ok {
+		return defaults.exit
+	}
+	return ExitGeneral
+}
+
+// UserMessage returns a concise description of err without internal operation names
+func UserMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	errs := chain(err)
+	if len(errs) == 0 {
+		return err.Error()
+	}
+
+	root := errs[len(errs)-1]
+	rootText := root.Message
+	if root.Err != nil {
+		rootText = root.Err.Error()
+		if root.Message != "" {
+			rootText = root.Message + ": " + rootText
+		}
+	}
+
+	if len(errs) == 1 || errs[0].Message == rootText {
+		return rootText
+	}
+	return errs[0].Message + ": " + rootText
+}
+
+// FormatForUser renders err for display on the terminal. When verbose is
+// set the full cause chain, including operations and context, is included.
+func FormatForUser(err error, verbose bool) string {
+	if err == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Error [%s]: %s\n", CodeOf(err), UserMessage(err)))
+
+	if hint := HintOf(err); hint != "" {
+		sb.WriteString(fmt.Sprintf("Hint: %s\n", hint))
+	}
+
+	if verbose {
+		sb.WriteString("Cause chain:\n")
+		current := err
+		for depth := 0; current != nil; depth++ {
+			var sigilErr *SigilError
+			if errors.As(current, &sigilErr) && sigilErr == current {
+				sb.WriteString(fmt.Sprintf("  %d. [%s] %s: %s", depth+1, sigilErr.Type, sigilErr.Op, sigilErr.Message))
+				keys := make([]string, 0, len(sigilErr.Context))
+				for key := range sigilErr.Context {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					sb.WriteString(fmt.Sprintf(" (%s=%v)", key, sigilErr.Context[key]))
+				}
+				sb.WriteString("\n")
+				current = sigilErr.Err
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("  %d. %s\n", depth+1, current.Error()))
+			current = errors.Unwrap(current)
+		}
+	} else if len(chain(err)) > 0 {
+		sb.WriteString("Run with --verbose for details.\n")
+	}
+
+	return sb.String()
+}
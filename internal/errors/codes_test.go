@@ -0,0 +1,105 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected Code
+	}{
+		{"nil", nil, ""},
+		{"plain error", errors.New("boom"), CodeUnknown},
+		{"type default", New(ErrorTypeFS, "Read", "failed"), CodeFS},
+		{"explicit code", New(ErrorTypeConfig, "Load", "x").WithCode(CodeMissingAPIKey), CodeMissingAPIKey},
+		{
+			"inner code wins",
+			Wrap(New(ErrorTypeConfig, "CreateModel", "x").WithCode(CodeMissingAPIKey), ErrorTypeInternal, "ExecuteTask", "failed").WithCode(CodeInternal),
+			CodeMissingAPIKey,
+		},
+		{"root type used", Wrap(New(ErrorTypeNetwork, "Do", "timeout"), ErrorTypeInternal, "Run", "failed"), CodeNetwork},
+		{"pattern match", fmt.Errorf("anthropic API key not provided"), CodeMissingAPIKey},
+		{"sentinel", fmt.Errorf("lookup: %w", ErrModelNotFound), CodeModelNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CodeOf(tt.err))
+		})
+	}
+}
+
+func TestHintOf(t *testing.T) {
+	assert.Empty(t, HintOf(nil))
+	assert.Empty(t, HintOf(errors.New("boom")))
+
+	explicit := Wrap(New(ErrorTypeConfig, "Load", "bad").WithHint("fix the config"), ErrorTypeInternal, "Run", "failed")
+	assert.Equal(t, "fix the config", HintOf(explicit))
+
+	assert.Contains(t, HintOf(errors.New("OpenAI API key not provided")), "OPENAI_API_KEY")
+	assert.Contains(t, HintOf(errors.New("not a git repository")), "git init")
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"nil", nil, ExitOK},
+		{"plain", errors.New("boom"), ExitGeneral},
+		{"config", ConfigError("Load", "bad"), ExitConfig},
+		{"input", New(ErrorTypeInput, "Parse", "bad"), ExitUsage},
+		{"validation", ValidationError("Check", "bad"), ExitDataErr},
+		{"fs", New(ErrorTypeFS, "Read", "bad"), ExitIOErr},
+		{"output", New(ErrorTypeOutput, "Write", "bad"), ExitCantCreate},
+		{"network", New(ErrorTypeNetwork, "Do", "bad"), ExitUnavailable},
+		{"internal", New(ErrorTypeInternal, "Run", "bad"), ExitSoftware},
+		{"root cause type", Wrap(New(ErrorTypeFS, "Read", "bad"), ErrorTypeInternal, "Run", "failed"), ExitIOErr},
+		{"missing key", Wrap(errors.New("Anthropic API key is required"), ErrorTypeModel, "Create", "failed"), ExitConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestUserMessage(t *testing.T) {
+	assert.Equal(t, "", UserMessage(nil))
+	assert.Equal(t, "boom", UserMessage(errors.New("boom")))
+	assert.Equal(t, "bad input", UserMessage(New(ErrorTypeInput, "Parse", "bad input")))
+	assert.Equal(t, "failed to read: EOF", UserMessage(Wrap(errors.New("EOF"), ErrorTypeFS, "Read", "failed to read")))
+
+	nested := Wrap(ConfigError("CreateModel", "API key is required"), ErrorTypeInternal, "ExecuteTask", "lead agent execution failed")
+	assert.Equal(t, "lead agent execution failed: API key is required", UserMessage(nested))
+}
+
+func TestFormatForUser(t *testing.T) {
+	assert.Empty(t, FormatForUser(nil, false))
+
+	inner := ConfigError("CreateModel", "Anthropic API key is required").
+		WithCode(CodeMissingAPIKey).
+		WithHint("set ANTHROPIC_API_KEY").
+		WithContext("provider", "anthropic")
+	err := Wrap(inner, ErrorTypeInternal, "ExecuteTask", "lead agent execution failed")
+
+	short := FormatForUser(err, false)
+	assert.Contains(t, short, "Error [SIG101]: lead agent execution failed: Anthropic API key is required")
+	assert.Contains(t, short, "Hint: set ANTHROPIC_API_KEY")
+	assert.Contains(t, short, "--verbose")
+	assert.NotContains(t, short, "ExecuteTask")
+
+	verbose := FormatForUser(fmt.Errorf("command failed: %w", err), true)
+	assert.Contains(t, verbose, "Cause chain:")
+	assert.Contains(t, verbose, "[INTERNAL] ExecuteTask: lead agent execution failed")
+	assert.Contains(t, verbose, "[CONFIG] CreateModel: Anthropic API key is required (provider=anthropic)")
+	assert.NotContains(t, verbose, "--verbose")
+}
@@ -37,6 +37,8 @@ type SigilError struct {
 	Message string
 	Err     error // Underlying error
 	Context map[string]interface{}
+	Code    Code   // Stable code shown to users
+	Hint    string // Remediation hint shown to users
 }
 
 // Error implements the error interface
@@ -94,6 +96,18 @@ func (e *SigilError) WithContext(key string, value interface{}) *SigilError {
 	return e
 }
 
+// WithCode sets the user-facing error code
+func (e *SigilError) WithCode(code Code) *SigilError {
+	e.Code = code
+	return e
+}
+
+// WithHint sets a remediation hint for the user
+func (e *SigilError) WithHint(hint string) *SigilError {
+	e.Hint = hint
+	return e
+}
+
 // Common error constructors
 
 // ConfigError creates a configuration error
@@ -0,0 +1,362 @@
+// Package examples mines the tests of source files for usage examples of
+// their exported API, turns them into snippets for documentation, and
+// builds the files that check the snippets still compile.
+package examples
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/outline"
+)
+
+// Languages examples are mined for
+const (
+	LangGo         = "go"
+	LangPython     = "python"
+	LangJavaScript = "javascript"
+)
+
+// Example is a usage example of a documented symbol, taken from a test
+type Example struct {
+	Symbol   string // Symbol it shows, e.g. Store.Get for a method
+	Test     string // Test it was taken from
+	Language string
+	Code     string // The snippet shown in documentation
+
+	// The import lines the example needs, its code without them, and for
+	// Go the package of the test
+	imports []string
+	body    string
+	pkg     string
+}
+
+// Check compiles a set of examples: a file to write next to the test they
+// came from and the command compiling it
+type Check struct {
+	Path    string
+	Content string
+	Command string
+	Args    []string
+}
+
+// TestFiles returns the test files of a source file that exist, by the
+// naming conventions of its language
+func TestFiles(source string) []string {
+	dir, base := filepath.Split(source)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	var candidates []string
+	switch ext {
+	case ".go":
+		if !strings.HasSuffix(stem, "_test") {
+			candidates = []string{filepath.Join(dir, stem+"_test.go")}
+		}
+	case ".py":
+		if !strings.HasPrefix(stem, "test_") && !strings.HasSuffix(stem, "_test") {
+			candidates = []string{
+				filepath.Join(dir, "test_"+stem+".py"),
+				filepath.Join(dir, stem+"_test.py"),
+				filepath.Join(dir, "tests", "test_"+stem+".py"),
+				filepath.Join(filepath.Dir(filepath.Clean(dir)), "tests", "test_"+stem+".py"),
+			}
+		}
+	case ".js", ".mjs", ".cjs":
+		if !strings.HasSuffix(stem, ".test") && !strings.HasSuffix(stem, ".spec") {
+			candidates = []string{
+				filepath.Join(dir, stem+".test"+ext),
+				filepath.Join(dir, stem+".spec"+ext),
+				filepath.Join(dir, "__tests__", stem+".test"+ext),
+				filepath.Join(dir, "__tests__", base),
+			}
+		}
+	}
+
+	var found []string
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+			found = append(found, candidate)
+		}
+	}
+	return found
+}
+
+// Extract mines testContent, the content of a test file of source, for
+// examples of the exported API declared in sourceContent. Each symbol gets
+// at most one example: from the test named after it, or else the first
+// test using it. Assertions become printed values, and whatever still
+// depends on the test harness is left out, so the snippets read as plain
+// usage.
+func Extract(source, sourceContent, testContent string) ([]Example, error) {
+	switch filepath.Ext(source) {
+	case ".go":
+		return extractGo(sourceContent, testContent)
+	case ".py":
+		return extractPython(source, sourceContent, testContent)
+	case ".js", ".mjs", ".cjs":
+		return extractJavaScript(source, sourceContent, testContent)
+	}
+	return nil, nil
+}
+
+// NewCheck returns the check compiling examples taken from the test file
+// at testPath, all of one language
+func NewCheck(testPath string, examples []Example) Check {
+	dir := filepath.Dir(testPath)
+	switch examples[0].Language {
+	case LangGo:
+		return goCheck(dir, examples)
+	case LangPython:
+		return pythonCheck(dir, examples)
+	default:
+		return javaScriptCheck(dir, examples)
+	}
+}
+
+// choose picks the symbol a test exemplifies among those it uses, in the
+// order it uses them: the one the test is named after, or else the first
+// without an example yet. It returns "" when there is none.
+func choose(testName string, used []string, taken map[string]bool) string {
+	name := normalizeName(testName)
+	for _, symbol := range used {
+		if !taken[symbol] && normalizeName(symbol) == name {
+			return symbol
+		}
+	}
+	for _, symbol := range used {
+		if !taken[symbol] {
+			return symbol
+		}
+	}
+	return ""
+}
+
+// newExample returns an example of a language whose code needs imports
+func newExample(language, symbol, test, body string, imports []string) Example {
+	code := body
+	if len(imports) > 0 {
+		code = strings.Join(imports, "\n") + "\n\n" + body
+	}
+	return Example{Symbol: symbol, Test: test, Language: language, Code: code, imports: imports, body: body}
+}
+
+// api is the public API of a Python or JavaScript file
+type api struct {
+	names   map[string]bool   // Top-level functions, classes and values
+	methods map[string]string // Method name to its class, when unique
+}
+
+// identPattern matches identifiers, with the dot selecting them if any
+var identPattern = regexp.MustCompile(`(?:\.\s*)?[A-Za-z_$][\w$]*`)
+
+// apiSymbols returns the top-level symbols of a file that keep accepts,
+// with the exported methods of the classes among them
+func apiSymbols(source, content string, keep func(outline.Symbol) bool) api {
+	result := api{names: make(map[string]bool), methods: make(map[string]string)}
+	ambiguous := make(map[string]bool)
+	class := ""
+	for _, symbol := range outline.Extract(source, content) {
+		switch {
+		case symbol.Depth == 0:
+			class = ""
+			if keep(symbol) {
+				result.names[symbol.Name] = true
+				if symbol.Kind == outline.KindClass {
+					class = symbol.Name
+				}
+			}
+		case symbol.Depth == 1 && class != "" && symbol.Kind == outline.KindMethod:
+			if !symbol.Exported() || strings.HasPrefix(symbol.Name, "__") || symbol.Name == "constructor" {
+				continue
+			}
+			if _, ok := result.methods[symbol.Name]; ok {
+				ambiguous[symbol.Name] = true
+			}
+			result.methods[symbol.Name] = class
+		}
+	}
+	for name := range ambiguous {
+		delete(result.methods, name)
+	}
+	return result
+}
+
+// used returns the symbols code uses, in order
+func (a api) used(code string) []string {
+	var used []string
+	seen := make(map[string]bool)
+	for _, ident := range identPattern.FindAllString(code, -1) {
+		symbol := ""
+		if name, ok := strings.CutPrefix(ident, "."); ok {
+			name = strings.TrimSpace(name)
+			if class, ok := a.methods[name]; ok {
+				symbol = class + "." + name
+			}
+		} else if a.names[ident] {
+			symbol = ident
+		}
+		if symbol != "" && !seen[symbol] {
+			seen[symbol] = true
+			used = append(used, symbol)
+		}
+	}
+	return used
+}
+
+// logicalLine is a statement of a Python or JavaScript file, with the
+// indentation of its first line
+type logicalLine struct {
+	indent string
+	text   string
+}
+
+// logicalLines groups lines into statements, joining the lines of open
+// brackets and backslash continuations, and drops blank and comment lines
+func logicalLines(lines []string, comment string) []logicalLine {
+	var statements []logicalLine
+	depth := 0
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if depth == 0 && (trimmed == "" || strings.HasPrefix(trimmed, comment)) {
+			continue
+		}
+		if depth > 0 || (len(statements) > 0 && strings.HasSuffix(statements[len(statements)-1].text, "\\")) {
+			statements[len(statements)-1].text += "\n" + line
+		} else {
+			statements = append(statements, logicalLine{indent: line[:len(line)-len(strings.TrimLeft(line, " \t"))], text: trimmed})
+		}
+		depth += bracketDelta(line, comment)
+		if depth < 0 {
+			depth = 0
+		}
+	}
+	return statements
+}
+
+// bracketDelta counts how a line changes the depth of brackets, ignoring
+// those in strings and after the comment marker
+func bracketDelta(line, comment string) int {
+	delta := 0
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case strings.HasPrefix(line[i:], comment):
+			return delta
+		case strings.ContainsRune("([{", r):
+			delta++
+		case strings.ContainsRune(")]}", r):
+			delta--
+		}
+	}
+	return delta
+}
+
+// splitTopLevel returns text before the first of seps outside brackets
+// and strings. ok is false when there is none, and text is returned whole.
+func splitTopLevel(text string, seps []string) (string, bool) {
+	depth := 0
+	var quote rune
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case strings.ContainsRune("([{", r):
+			depth++
+		case strings.ContainsRune(")]}", r):
+			depth--
+		case depth == 0:
+			for _, sep := range seps {
+				if strings.HasPrefix(text[i:], sep) {
+					return text[:i], true
+				}
+			}
+		}
+	}
+	return text, false
+}
+
+// nonWord matches what separates the words of test and symbol names
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+// normalizeName reduces a test or symbol name to its lowercase words, so
+// TestStore_Get, test_store_get and "store get" compare equal
+func normalizeName(name string) string {
+	name = strings.TrimPrefix(name, "Test")
+	name = strings.TrimPrefix(name, "test_")
+	return nonWord.ReplaceAllString(strings.ToLower(name), "")
+}
+
+// usedImports returns the import lines whose bound names the code uses
+func usedImports(lines []string, bound func(line string) []string, code string) []string {
+	var used []string
+	for _, line := range lines {
+		for _, name := range bound(line) {
+			if regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`).MatchString(code) {
+				used = append(used, line)
+				break
+			}
+		}
+	}
+	return used
+}
+
+// firstArg returns the first argument of the call whose argument list
+// starts at text, after its opening parenthesis, and the text after the
+// call. ok is false when the call does not close.
+func firstArg(text string) (arg, rest string, ok bool) {
+	depth := 0
+	end := -1
+	var quote rune
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			if depth == 0 {
+				if end < 0 {
+					end = i
+				}
+				return strings.TrimSpace(text[:end]), text[i+1:], true
+			}
+			depth--
+		case r == ',' && depth == 0 && end < 0:
+			end = i
+		}
+	}
+	return "", "", false
+}
+
+// indent indents every non-empty line of code by prefix
+func indent(code, prefix string) string {
+	lines := strings.Split(code, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
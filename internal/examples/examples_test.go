@@ -0,0 +1,277 @@
+package examples
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"store.go", "store_test.go", "cache.py", "test_cache.py", "math.js", "math.test.js"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "__tests__"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "__tests__", "math.test.js"), nil, 0o644))
+
+	assert.Equal(t, []string{filepath.Join(dir, "store_test.go")}, TestFiles(filepath.Join(dir, "store.go")))
+	assert.Equal(t, []string{filepath.Join(dir, "test_cache.py")}, TestFiles(filepath.Join(dir, "cache.py")))
+	assert.Equal(t, []string{filepath.Join(dir, "math.test.js"), filepath.Join(dir, "__tests__", "math.test.js")},
+		TestFiles(filepath.Join(dir, "math.js")))
+	assert.Empty(t, TestFiles(filepath.Join(dir, "store_test.go")), "tests have no tests")
+	assert.Empty(t, TestFiles(filepath.Join(dir, "main.ts")), "TypeScript is not mined")
+}
+
+const goSource = `package store
+
+// Store keeps entries
+type Store struct{ entries map[string]string }
+
+// New returns an empty store
+func New() *Store { return &Store{entries: map[string]string{}} }
+
+// Put stores value under key
+func (s *Store) Put(key, value string) error { s.entries[key] = value; return nil }
+
+// Get returns the entry for key
+func (s *Store) Get(key string) string { return s.entries[key] }
+
+func helper() {}
+`
+
+func TestExtract_Go(t *testing.T) {
+	test := `package store_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"example.com/store"
+)
+
+func newTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	return store.New()
+}
+
+func TestStore_Get(t *testing.T) {
+	t.Parallel()
+	s := store.New()
+	require.NoError(t, s.Put("greeting", strings.ToUpper("hi")))
+	assert.Equal(t, "HI", s.Get("greeting"))
+	if s.Get("missing") != "" {
+		t.Fatalf("unexpected entry %q", s.Get("missing"))
+	}
+}
+
+func TestPut(t *testing.T) {
+	s := newTestStore(t)
+	tests := []string{"a", "b"}
+	for _, key := range tests {
+		t.Run(key, func(t *testing.T) {
+			err := s.Put(key, key)
+			assert.NoError(t, err)
+		})
+	}
+}
+`
+	examples, err := Extract("store.go", goSource, test)
+	require.NoError(t, err)
+	require.Len(t, examples, 2)
+
+	example := examples[0]
+	assert.Equal(t, "Store.Get", example.Symbol, "the test's name picks the symbol")
+	assert.Equal(t, "TestStore_Get", example.Test)
+	assert.Equal(t, LangGo, example.Language)
+	assert.Equal(t, `func ExampleStore_Get() {
+	s := store.New()
+	if err := s.Put("greeting", strings.ToUpper("hi")); err != nil {
+		panic(err)
+	}
+	fmt.Println(s.Get("greeting"))
+	if s.Get("missing") != "" {
+		panic(fmt.Sprintf("unexpected entry %q", s.Get("missing")))
+	}
+}`, example.Code)
+
+	// Subtests run inline. TestPut makes its store with the test, so its
+	// example does not compile and is left to the check to drop.
+	assert.Equal(t, "Store.Put", examples[1].Symbol)
+	assert.Contains(t, examples[1].Code, "for _, key := range tests {\n\t\t{\n\t\t\terr := s.Put(key, key)\n")
+	assert.NotContains(t, examples[1].Code, "newTestStore")
+
+	check := NewCheck(filepath.Join("pkg", "store", "store_test.go"), examples[:1])
+	assert.Equal(t, filepath.Join("pkg", "store", "zz_sigil_examples_test.go"), check.Path)
+	assert.Equal(t, "go", check.Command)
+	assert.Equal(t, []string{"test", "-vet=off", "-run", "^$", "./pkg/store"}, check.Args)
+	assert.Contains(t, check.Content, "package store_test\n\nimport (\n\t\"example.com/store\"\n\t\"fmt\"\n\t\"strings\"\n)")
+	assert.Contains(t, check.Content, "func ExampleStore_Get_sigil0() {")
+	_, err = parser.ParseFile(token.NewFileSet(), check.Path, check.Content, 0)
+	assert.NoError(t, err)
+
+	_, err = Extract("store.go", goSource, "not go")
+	assert.ErrorContains(t, err, "failed to parse test")
+}
+
+func TestExtract_GoInternalTest(t *testing.T) {
+	test := `package store
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	s := New()
+	helper()
+	if s == nil {
+		t.Fatal("no store")
+	}
+}
+`
+	examples, err := Extract("store.go", goSource, test)
+	require.NoError(t, err)
+	require.Len(t, examples, 1)
+	assert.Equal(t, "New", examples[0].Symbol)
+	assert.Equal(t, `func ExampleNew() {
+	s := New()
+	helper()
+	if s == nil {
+		panic(fmt.Sprint("no store"))
+	}
+}`, examples[0].Code)
+}
+
+func TestExtract_Python(t *testing.T) {
+	source := `class Cache:
+    def get(self, key):
+        return self.values.get(key)
+
+    def _evict(self):
+        pass
+
+
+def make_cache(size):
+    return Cache()
+
+
+def _internal():
+    pass
+`
+	test := `import pytest
+from cache import Cache, make_cache
+import json
+
+
+def test_cache_get():
+    cache = make_cache(10)
+    assert cache.get("missing") is None, "nothing cached yet"
+    with pytest.raises(KeyError):
+        cache.values["missing"]
+    assert json.dumps({"a": 1})
+
+
+def test_with_fixture(tmp_path):
+    assert make_cache(1)
+
+
+def test_make_cache():
+    assert make_cache(
+        2,
+    ) == Cache()
+`
+	examples, err := Extract("cache.py", source, test)
+	require.NoError(t, err)
+	require.Len(t, examples, 2)
+
+	assert.Equal(t, "Cache.get", examples[0].Symbol)
+	assert.Equal(t, LangPython, examples[0].Language)
+	assert.Equal(t, `from cache import Cache, make_cache
+import json
+
+cache = make_cache(10)
+print(cache.get("missing"))
+print(json.dumps({"a": 1}))`, examples[0].Code)
+
+	assert.Equal(t, "make_cache", examples[1].Symbol)
+	assert.Equal(t, "from cache import Cache, make_cache\n\nprint(make_cache(\n        2,\n    ))", examples[1].Code)
+
+	check := NewCheck(filepath.Join("tests", "test_cache.py"), examples)
+	assert.Equal(t, "python3", check.Command)
+	assert.Equal(t, []string{"-m", "py_compile", "tests/zz_sigil_examples.py"}, check.Args)
+	assert.Contains(t, check.Content, "def example_0():\n    from cache import Cache, make_cache\n    import json\n    cache = make_cache(10)\n")
+}
+
+func TestExtract_JavaScript(t *testing.T) {
+	source := `export function add(a, b) {
+  return a + b;
+}
+
+export class Counter {
+  increment() {
+    return 1;
+  }
+}
+
+function helper() {}
+`
+	test := `import { describe, expect, it } from 'vitest';
+import { add, Counter } from './math.js';
+
+describe('math', () => {
+  it('adds numbers', () => {
+    const sum = add(1, 2);
+    expect(sum).toBe(3);
+    expect(() => add()).toThrow();
+  });
+
+  it('counts', async () => {
+    const counter = new Counter();
+    vi.spyOn(counter, 'increment');
+    expect(counter.increment()).toBe(1);
+  });
+});
+`
+	examples, err := Extract("math.js", source, test)
+	require.NoError(t, err)
+	require.Len(t, examples, 2)
+
+	assert.Equal(t, "add", examples[0].Symbol)
+	assert.Equal(t, "adds numbers", examples[0].Test)
+	assert.Equal(t, "import { add, Counter } from './math.js';\n\nconst sum = add(1, 2);\nconsole.log(sum);", examples[0].Code)
+	assert.Equal(t, "Counter", examples[1].Symbol)
+	assert.Equal(t, "import { add, Counter } from './math.js';\n\nconst counter = new Counter();\nconsole.log(counter.increment());", examples[1].Code)
+
+	check := NewCheck(filepath.Join("src", "math.test.js"), examples)
+	assert.Equal(t, filepath.Join("src", "zz_sigil_examples.mjs"), check.Path)
+	assert.Equal(t, []string{"--check", "src/zz_sigil_examples.mjs"}, check.Args)
+	assert.Equal(t, `import { add, Counter } from './math.js';
+
+async function example_0() {
+  const sum = add(1, 2);
+  console.log(sum);
+}
+
+async function example_1() {
+  const counter = new Counter();
+  console.log(counter.increment());
+}
+
+`, check.Content)
+}
+
+func TestExtract_CommonJS(t *testing.T) {
+	source := "function add(a, b) {\n  return a + b;\n}\n\nmodule.exports = { add };\n"
+	test := "const assert = require('assert');\nconst { add } = require('./math');\n\ntest('add', () => {\n  assert.strictEqual(add(1, 2), 3);\n});\n"
+
+	examples, err := Extract("math.js", source, test)
+	require.NoError(t, err)
+	require.Len(t, examples, 1)
+	assert.Equal(t, "const { add } = require('./math');\n\nconsole.log(add(1, 2));", examples[0].Code)
+	assert.Equal(t, filepath.Join("zz_sigil_examples.cjs"), NewCheck("math.test.js", examples).Path)
+}
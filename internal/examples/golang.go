@@ -0,0 +1,479 @@
+package examples
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// testifyPackages are the assertion packages whose calls become prints
+var testifyPackages = map[string]bool{
+	"github.com/stretchr/testify/assert":  true,
+	"github.com/stretchr/testify/require": true,
+}
+
+// expectedFirst are the assertions taking the expected value before the
+// actual one, which is what an example prints
+var expectedFirst = map[string]bool{
+	"Equal": true, "Equalf": true, "EqualValues": true, "EqualValuesf": true,
+	"Exactly": true, "Exactlyf": true, "NotEqual": true, "NotEqualf": true,
+	"InDelta": true, "InDeltaf": true, "InEpsilon": true, "InEpsilonf": true,
+	"JSONEq": true, "JSONEqf": true, "YAMLEq": true, "YAMLEqf": true,
+	"Same": true, "Samef": true, "IsType": true, "IsTypef": true,
+}
+
+// goSymbols are the exported functions, types and methods of a Go file
+type goSymbols struct {
+	pkg     string
+	names   map[string]bool   // Functions and types
+	methods map[string]string // Method name to its type, when unique
+}
+
+// extractGo mines the Test functions of a Go test file
+func extractGo(sourceContent, testContent string) ([]Example, error) {
+	fset := token.NewFileSet()
+	source, err := parser.ParseFile(fset, "source.go", sourceContent, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source: %w", err)
+	}
+	test, err := parser.ParseFile(fset, "source_test.go", testContent, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse test: %w", err)
+	}
+	symbols := newGoSymbols(source)
+
+	imports := make(map[string]string) // Local name to import line
+	assertions := make(map[string]bool)
+	for _, spec := range test.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		switch {
+		case testifyPackages[importPath]:
+			assertions[name] = true
+		case importPath != "testing" && name != "_" && name != ".":
+			imports[name] = importLine(spec)
+		}
+	}
+
+	var examples []Example
+	taken := make(map[string]bool)
+	for _, decl := range test.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Body == nil || !strings.HasPrefix(fn.Name.Name, "Test") {
+			continue
+		}
+		param := testingParam(fn.Type)
+		if param == "" {
+			continue
+		}
+		rewriter := goRewriter{param: param, assertions: assertions}
+		body := &ast.BlockStmt{List: rewriter.rewrite(fn.Body.List)}
+		if len(body.List) == 0 {
+			continue
+		}
+
+		symbol := choose(fn.Name.Name, symbols.used(body, test.Name.Name), taken)
+		if symbol == "" {
+			continue
+		}
+		code, err := printBody(fset, body)
+		if err != nil {
+			continue
+		}
+		taken[symbol] = true
+
+		var lines []string
+		for name, line := range imports {
+			if strings.Contains(code, name+".") {
+				lines = append(lines, line)
+			}
+		}
+		if strings.Contains(code, "fmt.") && imports["fmt"] == "" {
+			lines = append(lines, `"fmt"`)
+		}
+		sort.Strings(lines)
+
+		example := Example{
+			Symbol:   symbol,
+			Test:     fn.Name.Name,
+			Language: LangGo,
+			imports:  lines,
+			body:     code,
+			pkg:      test.Name.Name,
+		}
+		example.Code = example.goFunc("")
+		examples = append(examples, example)
+	}
+	return examples, nil
+}
+
+// newGoSymbols collects the exported API of a parsed Go file
+func newGoSymbols(file *ast.File) goSymbols {
+	symbols := goSymbols{pkg: file.Name.Name, names: make(map[string]bool), methods: make(map[string]string)}
+	ambiguous := make(map[string]bool)
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() {
+				continue
+			}
+			if decl.Recv == nil {
+				symbols.names[decl.Name.Name] = true
+				continue
+			}
+			receiver := receiverType(decl.Recv.List[0].Type)
+			if !ast.IsExported(receiver) {
+				continue
+			}
+			if _, ok := symbols.methods[decl.Name.Name]; ok {
+				ambiguous[decl.Name.Name] = true
+			}
+			symbols.methods[decl.Name.Name] = receiver
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok && spec.Name.IsExported() {
+					symbols.names[spec.Name.Name] = true
+				}
+			}
+		}
+	}
+	// A method name several types share cannot be told apart untyped
+	for name := range ambiguous {
+		delete(symbols.methods, name)
+	}
+	return symbols
+}
+
+// used returns the symbols a test body uses, in order. Tests in the
+// package itself use them unqualified; external tests through its name.
+func (s goSymbols) used(body *ast.BlockStmt, testPkg string) []string {
+	external := testPkg != s.pkg
+	var used []string
+	seen := make(map[string]bool)
+	add := func(symbol string) {
+		if !seen[symbol] {
+			seen[symbol] = true
+			used = append(used, symbol)
+		}
+	}
+	var visit func(node ast.Node) bool
+	visit = func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.SelectorExpr:
+			if ident, ok := node.X.(*ast.Ident); ok && external && ident.Name == s.pkg {
+				if s.names[node.Sel.Name] {
+					add(node.Sel.Name)
+				}
+				return false
+			}
+			if receiver, ok := s.methods[node.Sel.Name]; ok {
+				add(receiver + "." + node.Sel.Name)
+			}
+			// The selected name is a field or method, never a type
+			ast.Inspect(node.X, visit)
+			return false
+		case *ast.Ident:
+			if !external && s.names[node.Name] {
+				add(node.Name)
+			}
+		}
+		return true
+	}
+	ast.Inspect(body, visit)
+	return used
+}
+
+// receiverType returns the name of a method's receiver type
+func receiverType(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(expr.X)
+	case *ast.IndexExpr:
+		return receiverType(expr.X)
+	case *ast.IndexListExpr:
+		return receiverType(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+// testingParam returns the name of a test function's *testing.T
+// parameter, or "" when it is not a test
+func testingParam(fn *ast.FuncType) string {
+	if len(fn.Params.List) != 1 || len(fn.Params.List[0].Names) != 1 {
+		return ""
+	}
+	star, ok := fn.Params.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return ""
+	}
+	selector, ok := star.X.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != "T" {
+		return ""
+	}
+	return fn.Params.List[0].Names[0].Name
+}
+
+// importLine renders an import spec as a line of an import block
+func importLine(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name + " " + spec.Path.Value
+	}
+	return spec.Path.Value
+}
+
+// goRewriter turns the statements of a test into those of an example
+type goRewriter struct {
+	param      string          // The test's *testing.T
+	assertions map[string]bool // Names of the testify packages
+}
+
+// rewrite rewrites a statement list: assertions print what they check,
+// failures panic, subtests run inline, and statements that still need the
+// test are dropped
+func (r goRewriter) rewrite(list []ast.Stmt) []ast.Stmt {
+	var rewritten []ast.Stmt
+	for _, stmt := range list {
+		if replaced, ok := r.replace(stmt); ok {
+			rewritten = append(rewritten, replaced...)
+			continue
+		}
+		r.rewriteNested(stmt)
+		if !r.references(stmt) {
+			rewritten = append(rewritten, stmt)
+		}
+	}
+	return rewritten
+}
+
+// replace rewrites a call to an assertion or to the test. ok is false for
+// any other statement.
+func (r goRewriter) replace(stmt ast.Stmt) ([]ast.Stmt, bool) {
+	expr, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return nil, false
+	}
+	call, ok := expr.X.(*ast.CallExpr)
+	if !ok {
+		return nil, false
+	}
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil, false
+	}
+	receiver, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return nil, false
+	}
+	method := selector.Sel.Name
+
+	if receiver.Name == r.param {
+		switch method {
+		case "Run":
+			if len(call.Args) != 2 {
+				return nil, true
+			}
+			lit, ok := call.Args[1].(*ast.FuncLit)
+			if !ok {
+				return nil, true
+			}
+			param := testingParam(lit.Type)
+			if param == "" {
+				return nil, true
+			}
+			sub := goRewriter{param: param, assertions: r.assertions}
+			return []ast.Stmt{&ast.BlockStmt{List: sub.rewrite(lit.Body.List)}}, true
+		case "Fatal", "Error":
+			return []ast.Stmt{panicStmt(callExpr(selectorExpr("fmt", "Sprint"), call.Args...))}, true
+		case "Fatalf", "Errorf":
+			return []ast.Stmt{panicStmt(callExpr(selectorExpr("fmt", "Sprintf"), call.Args...))}, true
+		case "FailNow", "Fail":
+			return []ast.Stmt{panicStmt(&ast.BasicLit{Kind: token.STRING, Value: `"failed"`})}, true
+		}
+		return nil, true
+	}
+
+	if !r.assertions[receiver.Name] || len(call.Args) < 2 {
+		return nil, false
+	}
+	switch {
+	case method == "NoError" || method == "NoErrorf":
+		return []ast.Stmt{checkErr(call.Args[1])}, true
+	case expectedFirst[method]:
+		if len(call.Args) < 3 {
+			return nil, true
+		}
+		return []ast.Stmt{printStmt(call.Args[2])}, true
+	case strings.HasPrefix(method, "Panics"), strings.HasPrefix(method, "NotPanics"),
+		strings.HasPrefix(method, "Eventually"), strings.HasPrefix(method, "Never"),
+		strings.HasPrefix(method, "Condition"):
+		return nil, true
+	}
+	return []ast.Stmt{printStmt(call.Args[1])}, true
+}
+
+// rewriteNested rewrites the statement lists inside a statement
+func (r goRewriter) rewriteNested(stmt ast.Stmt) {
+	switch stmt := stmt.(type) {
+	case *ast.BlockStmt:
+		stmt.List = r.rewrite(stmt.List)
+	case *ast.IfStmt:
+		stmt.Body.List = r.rewrite(stmt.Body.List)
+		if stmt.Else != nil {
+			r.rewriteNested(stmt.Else)
+		}
+	case *ast.ForStmt:
+		stmt.Body.List = r.rewrite(stmt.Body.List)
+	case *ast.RangeStmt:
+		stmt.Body.List = r.rewrite(stmt.Body.List)
+	case *ast.SwitchStmt:
+		r.rewriteClauses(stmt.Body)
+	case *ast.TypeSwitchStmt:
+		r.rewriteClauses(stmt.Body)
+	case *ast.SelectStmt:
+		r.rewriteClauses(stmt.Body)
+	}
+}
+
+// rewriteClauses rewrites the clauses of a switch or select
+func (r goRewriter) rewriteClauses(body *ast.BlockStmt) {
+	for _, clause := range body.List {
+		switch clause := clause.(type) {
+		case *ast.CaseClause:
+			clause.Body = r.rewrite(clause.Body)
+		case *ast.CommClause:
+			clause.Body = r.rewrite(clause.Body)
+		}
+	}
+}
+
+// references reports whether a statement still uses the test or its
+// assertions
+func (r goRewriter) references(stmt ast.Stmt) bool {
+	found := false
+	ast.Inspect(stmt, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && (ident.Name == r.param || r.assertions[ident.Name]) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func selectorExpr(pkg, name string) ast.Expr {
+	return &ast.SelectorExpr{X: ast.NewIdent(pkg), Sel: ast.NewIdent(name)}
+}
+
+func callExpr(fun ast.Expr, args ...ast.Expr) *ast.CallExpr {
+	return &ast.CallExpr{Fun: fun, Args: args}
+}
+
+func panicStmt(arg ast.Expr) ast.Stmt {
+	return &ast.ExprStmt{X: callExpr(ast.NewIdent("panic"), arg)}
+}
+
+func printStmt(arg ast.Expr) ast.Stmt {
+	return &ast.ExprStmt{X: callExpr(selectorExpr("fmt", "Println"), arg)}
+}
+
+// checkErr panics when err, a variable or a call, is not nil
+func checkErr(err ast.Expr) ast.Stmt {
+	check := &ast.IfStmt{
+		Cond: &ast.BinaryExpr{X: ast.NewIdent("err"), Op: token.NEQ, Y: ast.NewIdent("nil")},
+		Body: &ast.BlockStmt{List: []ast.Stmt{panicStmt(ast.NewIdent("err"))}},
+	}
+	if ident, ok := err.(*ast.Ident); ok {
+		check.Cond.(*ast.BinaryExpr).X = ident
+		check.Body.List = []ast.Stmt{panicStmt(ident)}
+		return check
+	}
+	check.Init = &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent("err")}, Tok: token.DEFINE, Rhs: []ast.Expr{err}}
+	return check
+}
+
+// printBody prints the statements of a body, gofmt'ed and unindented
+func printBody(fset *token.FileSet, body *ast.BlockStmt) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString("package p\n\nfunc _() ")
+	if err := format.Node(&buf, fset, body); err != nil {
+		return "", err
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	source := string(formatted)
+	start := strings.Index(source, "{\n")
+	end := strings.LastIndex(source, "\n}")
+	if start < 0 || end <= start {
+		return "", nil
+	}
+	// Statements built here have no position, which leaves blank lines
+	// around them
+	var lines []string
+	for _, line := range strings.Split(source[start+2:end], "\n") {
+		line = strings.TrimPrefix(line, "\t")
+		if strings.TrimSpace(line) == "" && (len(lines) == 0 || strings.HasSuffix(lines[len(lines)-1], "{")) {
+			continue
+		}
+		if strings.TrimSpace(line) == "}" && len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+// goFunc renders a Go example as an Example function, whose name carries
+// suffix when one is given
+func (e Example) goFunc(suffix string) string {
+	name := "Example" + strings.ReplaceAll(e.Symbol, ".", "_")
+	if suffix != "" {
+		name += "_" + suffix
+	}
+	return "func " + name + "() {\n" + indent(e.body, "\t") + "\n}"
+}
+
+// goCheck compiles Go examples as Example functions in a file of the
+// test's package, built with the package's tests but not run
+func goCheck(dir string, examples []Example) Check {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "package %s\n\n", examples[0].pkg)
+
+	seen := make(map[string]bool)
+	var imports []string
+	for _, example := range examples {
+		for _, line := range example.imports {
+			if !seen[line] {
+				seen[line] = true
+				imports = append(imports, line)
+			}
+		}
+	}
+	if len(imports) > 0 {
+		sort.Strings(imports)
+		buf.WriteString("import (\n\t" + strings.Join(imports, "\n\t") + "\n)\n\n")
+	}
+	for i, example := range examples {
+		buf.WriteString(example.goFunc(fmt.Sprintf("sigil%d", i)) + "\n\n")
+	}
+
+	return Check{
+		Path:    filepath.Join(dir, "zz_sigil_examples_test.go"),
+		Content: buf.String(),
+		Command: "go",
+		Args:    []string{"test", "-vet=off", "-run", "^$", "./" + filepath.ToSlash(dir)},
+	}
+}
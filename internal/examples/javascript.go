@@ -0,0 +1,169 @@
+package examples
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/outline"
+)
+
+var (
+	// jsTestPattern matches the opening line of a test or it block
+	jsTestPattern = regexp.MustCompile(`^(?:test|it)(?:\.only)?\(\s*(['"` + "`" + `])(.*?)['"` + "`" + `]\s*,\s*(?:async\s*)?(?:\(\s*\)\s*=>|function\s*\(\s*\))\s*\{\s*$`)
+	// jsImportPattern matches an import statement or a required module
+	jsImportPattern = regexp.MustCompile(`^(?:import\s|(?:const|let|var)\s+[^=]+=\s*require\()`)
+	// jsAssertPattern matches a Node assert call
+	jsAssertPattern = regexp.MustCompile(`^assert(?:\.\w+)?\(`)
+	// jsFrameworkPattern matches uses of mocks and test callbacks
+	jsFrameworkPattern = regexp.MustCompile(`\b(?:jest|vi|sinon)\.|\bdone\(`)
+	// jsTestModules are the modules providing test functions and assertions
+	jsTestModules = regexp.MustCompile(`['"](?:vitest|@jest/globals|node:test|(?:node:)?assert(?:/strict)?|chai|mocha)['"]`)
+)
+
+// extractJavaScript mines the test and it blocks of a JavaScript test
+// file, top-level or in describe blocks
+func extractJavaScript(source, sourceContent, testContent string) ([]Example, error) {
+	api := apiSymbols(source, sourceContent, func(symbol outline.Symbol) bool {
+		return symbol.Exported() || commonJSExported(sourceContent, symbol.Name)
+	})
+	statements := logicalLines(strings.Split(testContent, "\n"), "//")
+
+	var imports []string
+	for _, statement := range statements {
+		if statement.indent == "" && jsImportPattern.MatchString(statement.text) && !jsTestModules.MatchString(statement.text) {
+			imports = append(imports, statement.text)
+		}
+	}
+
+	var examples []Example
+	taken := make(map[string]bool)
+	lines := strings.Split(testContent, "\n")
+	for i := 0; i < len(lines); i++ {
+		match := jsTestPattern.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if match == nil {
+			continue
+		}
+		// The block closes where its braces balance again
+		depth := bracketDelta(lines[i], "//")
+		start := i + 1
+		for i+1 < len(lines) && depth > 0 {
+			i++
+			depth += bracketDelta(lines[i], "//")
+		}
+		if depth > 0 {
+			break
+		}
+
+		code := rewriteJavaScript(logicalLines(lines[start:i], "//"))
+		if code == "" {
+			continue
+		}
+		symbol := choose(match[2], api.used(code), taken)
+		if symbol == "" {
+			continue
+		}
+		taken[symbol] = true
+		examples = append(examples, newExample(LangJavaScript, symbol, match[2], code,
+			usedImports(imports, jsBound, code)))
+	}
+	return examples, nil
+}
+
+// commonJSExported reports whether a CommonJS module exports name
+func commonJSExported(content, name string) bool {
+	quoted := regexp.QuoteMeta(name)
+	return regexp.MustCompile(`(?:module\.)?exports\.` + quoted + `\s*=|module\.exports\s*=\s*\{[^}]*\b` + quoted + `\b`).MatchString(content)
+}
+
+// rewriteJavaScript rewrites the body of a test: expect and assert calls
+// log what they check, and uses of the test framework are dropped. The
+// statements are those of the body, closing line excluded.
+func rewriteJavaScript(body []logicalLine) string {
+	if len(body) == 0 {
+		return ""
+	}
+	base := body[0].indent
+	var lines []string
+	for _, statement := range body {
+		text := statement.text
+		switch {
+		case strings.HasPrefix(text, "expect("):
+			arg, _, ok := firstArg(strings.TrimPrefix(text, "expect("))
+			if !ok || arg == "" || strings.Contains(arg, "=>") || strings.HasPrefix(arg, "function") {
+				continue
+			}
+			text = "console.log(" + arg + ");"
+		case jsAssertPattern.MatchString(text):
+			_, args, _ := strings.Cut(text, "(")
+			arg, _, ok := firstArg(args)
+			if !ok || arg == "" || strings.Contains(arg, "=>") || strings.HasPrefix(arg, "function") {
+				continue
+			}
+			text = "console.log(" + arg + ");"
+		case jsFrameworkPattern.MatchString(text):
+			continue
+		}
+		lines = append(lines, strings.TrimPrefix(statement.indent, base)+text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jsBound returns the names a JavaScript import or require binds
+func jsBound(line string) []string {
+	var bindings string
+	if strings.HasPrefix(line, "import ") {
+		bindings, _, _ = strings.Cut(strings.TrimPrefix(line, "import "), " from ")
+	} else {
+		_, declared, _ := strings.Cut(line, " ")
+		bindings, _, _ = strings.Cut(declared, "=")
+	}
+
+	var names []string
+	for _, part := range strings.FieldsFunc(bindings, func(r rune) bool { return strings.ContainsRune(",{}", r) }) {
+		part = strings.TrimSpace(part)
+		if ns, ok := strings.CutPrefix(part, "* as "); ok {
+			part = ns
+		} else if _, alias, ok := strings.Cut(part, " as "); ok {
+			part = alias
+		} else if _, alias, ok := strings.Cut(part, ":"); ok {
+			part = alias
+		}
+		if part = strings.TrimSpace(part); part != "" && part != "type" {
+			names = append(names, part)
+		}
+	}
+	return names
+}
+
+// javaScriptCheck compiles JavaScript examples as async functions of a
+// module, which node checks without running or importing anything. The
+// module is ESM when the examples import and CommonJS otherwise.
+func javaScriptCheck(dir string, examples []Example) Check {
+	ext := ".cjs"
+	seen := make(map[string]bool)
+	var buf strings.Builder
+	for _, example := range examples {
+		for _, line := range example.imports {
+			if strings.HasPrefix(line, "import ") {
+				ext = ".mjs"
+			}
+			if !seen[line] {
+				seen[line] = true
+				buf.WriteString(line + "\n")
+			}
+		}
+	}
+	buf.WriteString("\n")
+	for i, example := range examples {
+		fmt.Fprintf(&buf, "async function example_%d() {\n%s\n}\n\n", i, indent(example.body, "  "))
+	}
+	path := filepath.Join(dir, "zz_sigil_examples"+ext)
+	return Check{
+		Path:    path,
+		Content: buf.String(),
+		Command: "node",
+		Args:    []string{"--check", filepath.ToSlash(path)},
+	}
+}
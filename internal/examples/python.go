@@ -0,0 +1,150 @@
+package examples
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/outline"
+)
+
+var (
+	// pythonTestPattern matches a test function taking no fixtures
+	pythonTestPattern = regexp.MustCompile(`^(?:async\s+)?def\s+(test_\w*)\s*\(\s*\)\s*(?:->\s*\w+\s*)?:`)
+	// pythonImportPattern matches an import statement
+	pythonImportPattern = regexp.MustCompile(`^(?:import|from)\s`)
+	// pythonComparisons split an asserted comparison, longest first
+	pythonComparisons = []string{" is not ", " not in ", " == ", " != ", " <= ", " >= ", " is ", " in ", " < ", " > "}
+)
+
+// extractPython mines the top-level test functions of a Python test file.
+// Tests taking fixtures need pytest to run, so they are left out.
+func extractPython(source, sourceContent, testContent string) ([]Example, error) {
+	api := apiSymbols(source, sourceContent, outline.Symbol.Exported)
+	statements := logicalLines(strings.Split(testContent, "\n"), "#")
+
+	var imports []string
+	for _, statement := range statements {
+		if statement.indent == "" && pythonImportPattern.MatchString(statement.text) && !strings.Contains(statement.text, "pytest") {
+			imports = append(imports, statement.text)
+		}
+	}
+
+	var examples []Example
+	taken := make(map[string]bool)
+	for i := 0; i < len(statements); i++ {
+		match := pythonTestPattern.FindStringSubmatch(statements[i].text)
+		if statements[i].indent != "" || match == nil {
+			continue
+		}
+		var body []logicalLine
+		for i+1 < len(statements) && statements[i+1].indent != "" {
+			i++
+			body = append(body, statements[i])
+		}
+
+		code := rewritePython(body)
+		if code == "" {
+			continue
+		}
+		symbol := choose(match[1], api.used(code), taken)
+		if symbol == "" {
+			continue
+		}
+		taken[symbol] = true
+		examples = append(examples, newExample(LangPython, symbol, match[1], code,
+			usedImports(imports, pythonBound, code)))
+	}
+	return examples, nil
+}
+
+// rewritePython rewrites the body of a test: asserts print what they
+// check, and pytest.raises blocks and other uses of pytest are dropped
+func rewritePython(body []logicalLine) string {
+	if len(body) == 0 {
+		return ""
+	}
+	base := body[0].indent
+	var lines []string
+	for i := 0; i < len(body); i++ {
+		statement := body[i]
+		if strings.Contains(statement.text, "pytest.") {
+			// Its block, if it opens one, goes with it
+			for i+1 < len(body) && len(body[i+1].indent) > len(statement.indent) {
+				i++
+			}
+			continue
+		}
+		text := statement.text
+		if asserted, ok := strings.CutPrefix(text, "assert "); ok {
+			text = "print(" + pythonAsserted(asserted) + ")"
+		}
+		lines = append(lines, strings.TrimPrefix(statement.indent, base)+text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pythonAsserted returns the value an assert checks: the left side of a
+// comparison, without the assert's message
+func pythonAsserted(asserted string) string {
+	asserted, _ = splitTopLevel(asserted, []string{","})
+	for _, comparison := range pythonComparisons {
+		if left, ok := splitTopLevel(asserted, []string{comparison}); ok {
+			return strings.TrimSpace(left)
+		}
+	}
+	return strings.TrimSpace(strings.TrimPrefix(asserted, "not "))
+}
+
+// pythonBound returns the names a Python import statement binds
+func pythonBound(line string) []string {
+	var names []string
+	if from, ok := strings.CutPrefix(line, "from "); ok {
+		_, imported, _ := strings.Cut(from, " import ")
+		imported = strings.Trim(strings.TrimSpace(imported), "()")
+		for _, name := range strings.Split(imported, ",") {
+			names = append(names, boundName(name))
+		}
+		return names
+	}
+	for _, name := range strings.Split(strings.TrimPrefix(line, "import "), ",") {
+		module, alias, ok := strings.Cut(strings.TrimSpace(name), " as ")
+		if ok {
+			names = append(names, strings.TrimSpace(alias))
+		} else {
+			names = append(names, strings.Split(module, ".")[0])
+		}
+	}
+	return names
+}
+
+// boundName returns the name an imported name is bound to, its alias
+// when it has one
+func boundName(name string) string {
+	name = strings.TrimSpace(name)
+	if _, alias, ok := strings.Cut(name, " as "); ok {
+		return strings.TrimSpace(alias)
+	}
+	return name
+}
+
+// pythonCheck compiles Python examples as functions of a module, which
+// py_compile checks without running or importing anything
+func pythonCheck(dir string, examples []Example) Check {
+	var buf strings.Builder
+	for i, example := range examples {
+		fmt.Fprintf(&buf, "def example_%d():\n", i)
+		for _, line := range example.imports {
+			buf.WriteString("    " + line + "\n")
+		}
+		buf.WriteString(indent(example.body, "    ") + "\n\n\n")
+	}
+	path := filepath.Join(dir, "zz_sigil_examples.py")
+	return Check{
+		Path:    path,
+		Content: buf.String(),
+		Command: "python3",
+		Args:    []string{"-m", "py_compile", filepath.ToSlash(path)},
+	}
+}
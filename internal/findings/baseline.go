@@ -0,0 +1,69 @@
+package findings
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// Baseline is a set of accepted findings, by fingerprint, that reviews
+// leave out so only findings introduced since are reported
+type Baseline struct {
+	Fingerprints []string `json:"fingerprints"`
+	known        map[string]bool
+}
+
+// LoadBaseline reads a baseline file
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "LoadBaseline", "failed to read baseline").
+			WithHint("create one with --update-baseline")
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeInput, "LoadBaseline", "failed to parse baseline")
+	}
+	baseline.known = make(map[string]bool, len(baseline.Fingerprints))
+	for _, fingerprint := range baseline.Fingerprints {
+		baseline.known[fingerprint] = true
+	}
+	return &baseline, nil
+}
+
+// Contains reports whether a finding is in the baseline
+func (b *Baseline) Contains(finding agent.ReviewComment) bool {
+	return b != nil && finding.Fingerprint != "" && b.known[finding.Fingerprint]
+}
+
+// WriteBaseline writes the fingerprints of findings as a baseline
+func WriteBaseline(path string, findings []agent.ReviewComment) error {
+	known := make(map[string]bool)
+	baseline := Baseline{Fingerprints: []string{}}
+	for _, finding := range findings {
+		if finding.Fingerprint != "" && !known[finding.Fingerprint] {
+			known[finding.Fingerprint] = true
+			baseline.Fingerprints = append(baseline.Fingerprints, finding.Fingerprint)
+		}
+	}
+	sort.Strings(baseline.Fingerprints)
+
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "WriteBaseline", "failed to encode baseline")
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.Wrap(err, errors.ErrorTypeFS, "WriteBaseline", "failed to create baseline directory")
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "WriteBaseline", "failed to write baseline")
+	}
+	return nil
+}
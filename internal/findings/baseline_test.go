@@ -0,0 +1,33 @@
+package findings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+)
+
+func TestBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ci", "baseline.json")
+	require.NoError(t, WriteBaseline(path, []agent.ReviewComment{{Fingerprint: "b"}, {Fingerprint: "a"}, {Fingerprint: "b"}, {}}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"fingerprints": ["a", "b"]}`, string(data))
+
+	baseline, err := LoadBaseline(path)
+	require.NoError(t, err)
+	assert.True(t, baseline.Contains(agent.ReviewComment{Fingerprint: "a"}))
+	assert.False(t, baseline.Contains(agent.ReviewComment{Fingerprint: "c"}))
+	assert.False(t, baseline.Contains(agent.ReviewComment{}))
+
+	var none *Baseline
+	assert.False(t, none.Contains(agent.ReviewComment{Fingerprint: "a"}), "no baseline contains nothing")
+
+	_, err = LoadBaseline(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+// Package findings gives review findings stable identities so they can be
+// deduplicated, baselined and tracked from one run to the next.
+package findings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/agent"
+)
+
+// contextRadius is how many lines around a finding make up its code context
+const contextRadius = 1
+
+// volatileText matches numbers and quoted values, which change between
+// runs without changing what a message is about
+var volatileText = regexp.MustCompile("\\d+|\"[^\"]*\"|'[^']*'|`[^`]*`")
+
+// severityRanks orders severities so duplicates keep the most severe
+var severityRanks = map[agent.Severity]int{
+	agent.SeverityInfo:     1,
+	agent.SeverityWarning:  2,
+	agent.SeverityError:    3,
+	agent.SeverityCritical: 4,
+}
+
+// Fingerprint identifies a finding by its rule, normalized path and a
+// hash of the code around it, so it survives line shifts and rewording.
+// source is the file content; without it, or for findings without a line,
+// the normalized message stands in for the code context.
+func Fingerprint(finding agent.ReviewComment, source string) string {
+	context := codeContext(source, finding.Line)
+	if context == "" {
+		context = volatileText.ReplaceAllString(strings.ToLower(finding.Message), "")
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{RuleID(finding), NormalizePath(finding.Path), context} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// RuleID names the rule a finding breaks: the analyzer and rule that
+// reported it, or its comment type for findings from the agents
+func RuleID(finding agent.ReviewComment) string {
+	if len(finding.References) > 0 {
+		return strings.Join(finding.References, "/")
+	}
+	if finding.Type == "" {
+		return string(agent.CommentTypeGeneral)
+	}
+	return string(finding.Type)
+}
+
+// NormalizePath returns a clean, slash-separated relative path
+func NormalizePath(path string) string {
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+}
+
+// codeContext returns the whitespace-normalized lines around line
+func codeContext(source string, line int) string {
+	if source == "" || line <= 0 {
+		return ""
+	}
+	lines := strings.Split(source, "\n")
+	if line > len(lines) {
+		return ""
+	}
+
+	start := max(line-1-contextRadius, 0)
+	end := min(line+contextRadius, len(lines))
+	context := make([]string, 0, end-start)
+	for _, text := range lines[start:end] {
+		context = append(context, strings.Join(strings.Fields(text), " "))
+	}
+	return strings.Join(context, "\n")
+}
+
+// Dedupe drops findings whose fingerprint was already seen, keeping the
+// first of each with the highest severity among its duplicates. Findings
+// without a fingerprint are kept.
+func Dedupe(findings []agent.ReviewComment) []agent.ReviewComment {
+	index := make(map[string]int)
+	var kept []agent.ReviewComment
+	for _, finding := range findings {
+		if finding.Fingerprint == "" {
+			kept = append(kept, finding)
+			continue
+		}
+		i, ok := index[finding.Fingerprint]
+		if !ok {
+			index[finding.Fingerprint] = len(kept)
+			kept = append(kept, finding)
+			continue
+		}
+		if severityRanks[finding.Severity] > severityRanks[kept[i].Severity] {
+			kept[i].Severity = finding.Severity
+		}
+	}
+	return kept
+}
@@ -0,0 +1,60 @@
+package findings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+)
+
+const source = `package store
+
+func query(table string) string {
+	return "SELECT * FROM " + table
+}
+`
+
+func TestFingerprint(t *testing.T) {
+	finding := agent.ReviewComment{Type: agent.CommentTypeSecurity, Path: "./store/query.go", Line: 4, Message: "SQL injection via table"}
+	fingerprint := Fingerprint(finding, source)
+	assert.Len(t, fingerprint, 16)
+
+	shifted := finding
+	shifted.Path = "store/query.go"
+	shifted.Line = 6
+	shifted.Message = "Table name is concatenated into SQL"
+	assert.Equal(t, fingerprint, Fingerprint(shifted, "// Package store\n\n"+source), "line shifts and rewording keep the fingerprint")
+
+	other := finding
+	other.Type = agent.CommentTypeStyle
+	assert.NotEqual(t, fingerprint, Fingerprint(other, source), "the rule is part of the fingerprint")
+
+	unlocated := agent.ReviewComment{Type: agent.CommentTypeDesign, Path: "store/query.go", Message: "Query builder has 3 callers"}
+	renumbered := unlocated
+	renumbered.Message = "query builder has 5 callers"
+	assert.Equal(t, Fingerprint(unlocated, ""), Fingerprint(renumbered, ""), "messages are compared without numbers or case")
+}
+
+func TestRuleID(t *testing.T) {
+	assert.Equal(t, "golangci-lint/errcheck", RuleID(agent.ReviewComment{Type: agent.CommentTypeErrorHandling, References: []string{"golangci-lint", "errcheck"}}))
+	assert.Equal(t, "security", RuleID(agent.ReviewComment{Type: agent.CommentTypeSecurity}))
+	assert.Equal(t, "general", RuleID(agent.ReviewComment{}))
+}
+
+func TestDedupe(t *testing.T) {
+	findings := []agent.ReviewComment{
+		{Fingerprint: "a", Severity: agent.SeverityWarning, Message: "first"},
+		{Fingerprint: "b", Severity: agent.SeverityInfo},
+		{Fingerprint: "a", Severity: agent.SeverityError, Message: "second"},
+		{Message: "unfingerprinted"},
+	}
+
+	kept := Dedupe(findings)
+
+	require.Len(t, kept, 3)
+	assert.Equal(t, "first", kept[0].Message)
+	assert.Equal(t, agent.SeverityError, kept[0].Severity, "duplicates keep the highest severity")
+	assert.Equal(t, "unfingerprinted", kept[2].Message)
+}
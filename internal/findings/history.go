@@ -0,0 +1,206 @@
+package findings
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/version"
+)
+
+// DefaultHistoryPath is where review runs and their findings are recorded
+const DefaultHistoryPath = ".sigil/findings/history.json"
+
+// Lifecycle statuses of a finding
+const (
+	StatusNew       = "new"
+	StatusRecurring = "recurring"
+	StatusFixed     = "fixed"
+)
+
+// Metrics recorded with runs for trend reports
+const (
+	MetricTokens   = "tokens"    // model tokens used
+	MetricCost     = "cost"      // model cost in USD, when prices are configured
+	MetricCoverage = "coverage"  // test coverage percentage
+	MetricDocDrift = "doc_drift" // generated documentation pages out of date
+)
+
+// Record is a finding as last seen, with when it was first and last seen
+type Record struct {
+	Fingerprint string         `json:"fingerprint"`
+	Rule        string         `json:"rule"`
+	Path        string         `json:"path"`
+	Line        int            `json:"line,omitempty"`
+	Severity    agent.Severity `json:"severity"`
+	Message     string         `json:"message"`
+	Status      string         `json:"status"`
+	FirstSeen   time.Time      `json:"first_seen"`
+	LastSeen    time.Time      `json:"last_seen"`
+	FixedAt     time.Time      `json:"fixed_at,omitzero"`
+}
+
+// Run is one recorded run: a review with its findings, or another command
+// that only reported metrics
+type Run struct {
+	ID           string                 `json:"id"`
+	Command      string                 `json:"command,omitempty"`
+	Time         time.Time              `json:"time"`
+	Files        []string               `json:"files"`
+	Fingerprints []string               `json:"fingerprints"`
+	Severities   map[agent.Severity]int `json:"severities,omitempty"`
+	New          int                    `json:"new"`
+	Recurring    int                    `json:"recurring"`
+	Fixed        int                    `json:"fixed"`
+	Metrics      map[string]float64     `json:"metrics,omitempty"`
+}
+
+// Lifecycle is how a run's findings compare with the history before it
+type Lifecycle struct {
+	New       []Record `json:"new"`
+	Recurring []Record `json:"recurring"`
+	Fixed     []Record `json:"fixed"`
+}
+
+// History records review runs and the findings seen in them
+type History struct {
+	SigilVersion string             `json:"sigil_version,omitempty"` // Version of sigil that last saved the history
+	Runs         []Run              `json:"runs"`
+	Findings     map[string]*Record `json:"findings"`
+	path         string
+}
+
+// LoadHistory reads the history at path. A missing history loads as empty.
+func LoadHistory(path string) (*History, error) {
+	history := &History{Findings: make(map[string]*Record), path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "LoadHistory", "failed to read findings history")
+	}
+
+	if err := json.Unmarshal(data, history); err != nil {
+		logger.Warn("starting over from corrupt findings history", "path", path, "error", err)
+		return &History{Findings: make(map[string]*Record), path: path}, nil
+	}
+	if history.Findings == nil {
+		history.Findings = make(map[string]*Record)
+	}
+	version.CheckState(path, history.SigilVersion)
+	history.path = path
+	return history, nil
+}
+
+// Save writes the history to the path it was loaded from
+func (h *History) Save() error {
+	h.SigilVersion = version.Get().Version
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Save", "failed to create findings history directory")
+	}
+
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Save", "failed to encode findings history")
+	}
+
+	// Write through a temporary file so a failed save keeps the old history
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Save", "failed to write findings history")
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Save", "failed to replace findings history")
+	}
+	return nil
+}
+
+// Record adds a review run and its metrics to the history. Findings already
+// on record are recurring and the rest new; open findings in the run's files
+// that were not found again are fixed. A run without files covers every
+// finding.
+func (h *History) Record(id string, at time.Time, files []string, findings []agent.ReviewComment, metrics map[string]float64) Lifecycle {
+	var lifecycle Lifecycle
+	scope := make(map[string]bool, len(files))
+	for _, file := range files {
+		scope[NormalizePath(file)] = true
+	}
+
+	run := Run{
+		ID:           id,
+		Command:      "review",
+		Time:         at,
+		Files:        files,
+		Fingerprints: []string{},
+		Severities:   make(map[agent.Severity]int),
+		Metrics:      metrics,
+	}
+	seen := make(map[string]bool)
+	for _, finding := range findings {
+		if finding.Fingerprint == "" || seen[finding.Fingerprint] {
+			continue
+		}
+		seen[finding.Fingerprint] = true
+		run.Fingerprints = append(run.Fingerprints, finding.Fingerprint)
+		run.Severities[finding.Severity]++
+
+		record, ok := h.Findings[finding.Fingerprint]
+		if !ok {
+			record = &Record{Fingerprint: finding.Fingerprint, FirstSeen: at}
+			h.Findings[finding.Fingerprint] = record
+		}
+		record.Rule = RuleID(finding)
+		record.Path = NormalizePath(finding.Path)
+		record.Line = finding.Line
+		record.Severity = finding.Severity
+		record.Message = finding.Message
+		record.LastSeen = at
+		record.FixedAt = time.Time{}
+		if ok {
+			record.Status = StatusRecurring
+			lifecycle.Recurring = append(lifecycle.Recurring, *record)
+		} else {
+			record.Status = StatusNew
+			lifecycle.New = append(lifecycle.New, *record)
+		}
+	}
+
+	for _, fingerprint := range sortedFingerprints(h.Findings) {
+		record := h.Findings[fingerprint]
+		if seen[fingerprint] || record.Status == StatusFixed {
+			continue
+		}
+		if len(scope) > 0 && !scope[record.Path] {
+			continue
+		}
+		record.Status = StatusFixed
+		record.FixedAt = at
+		lifecycle.Fixed = append(lifecycle.Fixed, *record)
+	}
+
+	run.New, run.Recurring, run.Fixed = len(lifecycle.New), len(lifecycle.Recurring), len(lifecycle.Fixed)
+	h.Runs = append(h.Runs, run)
+	return lifecycle
+}
+
+// RecordMetrics adds a run of another command that reported metrics only
+func (h *History) RecordMetrics(id, command string, at time.Time, metrics map[string]float64) {
+	h.Runs = append(h.Runs, Run{ID: id, Command: command, Time: at, Metrics: metrics})
+}
+
+// sortedFingerprints returns the fingerprints on record in order
+func sortedFingerprints(records map[string]*Record) []string {
+	fingerprints := make([]string, 0, len(records))
+	for fingerprint := range records {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Strings(fingerprints)
+	return fingerprints
+}
@@ -0,0 +1,98 @@
+package findings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/agent"
+)
+
+func TestHistory_Record(t *testing.T) {
+	history, err := LoadHistory(filepath.Join(t.TempDir(), "history.json"))
+	require.NoError(t, err)
+
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	a := agent.ReviewComment{Fingerprint: "a", Path: "a.go", Line: 1, Type: agent.CommentTypeLogic, Severity: agent.SeverityWarning}
+	b := agent.ReviewComment{Fingerprint: "b", Path: "b.go", Line: 2, Type: agent.CommentTypeStyle}
+	c := agent.ReviewComment{Fingerprint: "c", Path: "c.go", Line: 3, Type: agent.CommentTypeStyle}
+
+	lifecycle := history.Record("run1", first, nil, []agent.ReviewComment{a, b, c}, nil)
+	assert.Len(t, lifecycle.New, 3)
+
+	lifecycle = history.Record("run2", second, []string{"./a.go", "b.go"}, []agent.ReviewComment{a}, map[string]float64{MetricTokens: 1200})
+	require.Len(t, lifecycle.Recurring, 1)
+	assert.Equal(t, first, lifecycle.Recurring[0].FirstSeen)
+	assert.Equal(t, second, lifecycle.Recurring[0].LastSeen)
+	require.Len(t, lifecycle.Fixed, 1, "c.go was not reviewed, so its finding stays open")
+	assert.Equal(t, "b", lifecycle.Fixed[0].Fingerprint)
+	assert.Equal(t, StatusNew, history.Findings["c"].Status)
+
+	lifecycle = history.Record("run3", second.Add(time.Hour), []string{"b.go"}, []agent.ReviewComment{b}, nil)
+	require.Len(t, lifecycle.New, 0)
+	require.Len(t, lifecycle.Recurring, 1, "a fixed finding that returns recurs")
+	assert.True(t, history.Findings["b"].FixedAt.IsZero())
+
+	require.Len(t, history.Runs, 3)
+	assert.Equal(t, Run{
+		ID:           "run2",
+		Command:      "review",
+		Time:         second,
+		Files:        []string{"./a.go", "b.go"},
+		Fingerprints: []string{"a"},
+		Severities:   map[agent.Severity]int{agent.SeverityWarning: 1},
+		Recurring:    1,
+		Fixed:        1,
+		Metrics:      map[string]float64{MetricTokens: 1200},
+	}, history.Runs[1])
+}
+
+func TestHistory_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings", "history.json")
+	history, err := LoadHistory(path)
+	require.NoError(t, err)
+	history.Record("run1", time.Now().UTC(), nil, []agent.ReviewComment{{Fingerprint: "a", Path: "a.go"}}, nil)
+	require.NoError(t, history.Save())
+
+	loaded, err := LoadHistory(path)
+	require.NoError(t, err)
+	assert.Len(t, loaded.Runs, 1)
+	assert.Contains(t, loaded.Findings, "a")
+
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0600))
+	corrupt, err := LoadHistory(path)
+	require.NoError(t, err, "a corrupt history starts over")
+	assert.Empty(t, corrupt.Runs)
+}
+
+func TestHistory_Trends(t *testing.T) {
+	history, err := LoadHistory(filepath.Join(t.TempDir(), "history.json"))
+	require.NoError(t, err)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := agent.ReviewComment{Fingerprint: "a", Path: "a.go", Severity: agent.SeverityError}
+	b := agent.ReviewComment{Fingerprint: "b", Path: "a.go", Severity: agent.SeverityWarning}
+	history.Record("run1", start, nil, []agent.ReviewComment{a, b}, nil)
+	history.RecordMetrics("ci1", "ci", start.Add(time.Hour), map[string]float64{MetricCoverage: 81.5})
+	history.Record("run2", start.Add(2*time.Hour), nil, []agent.ReviewComment{a}, map[string]float64{MetricCost: 0.12})
+
+	points := history.Trends(0)
+	require.Len(t, points, 3)
+	assert.Equal(t, map[agent.Severity]int{agent.SeverityError: 1, agent.SeverityWarning: 1}, points[0].Severities)
+	assert.Equal(t, 2, points[0].Open)
+	assert.False(t, points[1].Reviewed())
+	assert.Equal(t, 2, points[1].Open, "runs without a review carry the open count")
+	assert.Equal(t, 81.5, points[1].Metrics[MetricCoverage])
+	assert.Equal(t, 1, points[2].Open)
+	assert.Equal(t, 1, points[2].Fixed)
+
+	last := history.Trends(2)
+	require.Len(t, last, 2)
+	assert.Equal(t, "ci1", last[0].Run)
+	assert.Equal(t, 2, last[0].Open, "open counts include runs before the window")
+}
@@ -0,0 +1,53 @@
+package findings
+
+import (
+	"time"
+
+	"github.com/dshills/sigil/internal/agent"
+)
+
+// Point is one run in a trend series
+type Point struct {
+	Run        string                 `json:"run"`
+	Command    string                 `json:"command"`
+	Time       time.Time              `json:"time"`
+	Severities map[agent.Severity]int `json:"severities,omitempty"`
+	New        int                    `json:"new"`
+	Fixed      int                    `json:"fixed"`
+	Open       int                    `json:"open"`
+	Metrics    map[string]float64     `json:"metrics,omitempty"`
+}
+
+// Trends returns the last runs on record as a series, oldest first. Open
+// counts the findings still open after each run, carried across runs that
+// did not review code. last <= 0 returns every run.
+func (h *History) Trends(last int) []Point {
+	points := make([]Point, 0, len(h.Runs))
+	open := 0
+	for _, run := range h.Runs {
+		open = max(open+run.New-run.Fixed, 0)
+		command := run.Command
+		if command == "" {
+			command = "review"
+		}
+		points = append(points, Point{
+			Run:        run.ID,
+			Command:    command,
+			Time:       run.Time,
+			Severities: run.Severities,
+			New:        run.New,
+			Fixed:      run.Fixed,
+			Open:       open,
+			Metrics:    run.Metrics,
+		})
+	}
+	if last > 0 && len(points) > last {
+		points = points[len(points)-last:]
+	}
+	return points
+}
+
+// Reviewed reports whether the point is a review, which has findings
+func (p Point) Reviewed() bool {
+	return p.Command == "review"
+}
@@ -0,0 +1,67 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// BlameAuthor is an author of lines of a file and how many they last
+// changed
+type BlameAuthor struct {
+	Name  string
+	Email string
+	Lines int
+}
+
+// Blame returns the authors who last changed lines start to end of a
+// file, most lines first. A start of 0 blames the whole file. The path is
+// relative to the repository path.
+func (r *Repository) Blame(path string, start, end int) ([]BlameAuthor, error) {
+	args := []string{"blame", "--line-porcelain"}
+	if start > 0 {
+		if end < start {
+			end = start
+		}
+		args = append(args, "-L", fmt.Sprintf("%d,%d", start, end))
+	}
+	args = append(args, "--", path)
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Path
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to blame %s: %w", path, err)
+	}
+
+	byEmail := make(map[string]*BlameAuthor)
+	var authors []*BlameAuthor
+	name := ""
+	for _, line := range strings.Split(string(output), "\n") {
+		switch {
+		case strings.HasPrefix(line, "author "):
+			name = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-mail "):
+			email := strings.Trim(strings.TrimPrefix(line, "author-mail "), "<>")
+			// Lines not yet committed have no author to ask
+			if email == "not.committed.yet" {
+				continue
+			}
+			author, ok := byEmail[email]
+			if !ok {
+				author = &BlameAuthor{Name: name, Email: email}
+				byEmail[email] = author
+				authors = append(authors, author)
+			}
+			author.Lines++
+		}
+	}
+
+	result := make([]BlameAuthor, 0, len(authors))
+	for _, author := range authors {
+		result = append(result, *author)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Lines > result[j].Lines })
+	return result, nil
+}
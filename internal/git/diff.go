@@ -83,3 +83,41 @@ func (r *Repository) GeneratePatch(files []string) (string, error) {
 
 	return string(output), nil
 }
+
+// DiffPaths returns the paths a unified diff touches, relative to the
+// repository root, in the order they appear. Both sides of a rename or copy
+// are included, since the diff carries content of each.
+func DiffPaths(diff string) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		path = strings.TrimSuffix(path, "\t")
+		if path == "" || path == "/dev/null" || seen[path] {
+			return
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git a/"):
+			header := strings.TrimPrefix(line, "diff --git a/")
+			if i := strings.LastIndex(header, " b/"); i >= 0 {
+				add(header[:i])
+				add(header[i+len(" b/"):])
+			}
+		case strings.HasPrefix(line, "--- a/"):
+			add(strings.TrimPrefix(line, "--- a/"))
+		case strings.HasPrefix(line, "+++ b/"):
+			add(strings.TrimPrefix(line, "+++ b/"))
+		default:
+			for _, prefix := range []string{"rename from ", "rename to ", "copy from ", "copy to "} {
+				if path, ok := strings.CutPrefix(line, prefix); ok {
+					add(path)
+				}
+			}
+		}
+	}
+	return paths
+}
@@ -162,6 +162,33 @@ func TestRepository_GetDiff(t *testing.T) {
 	})
 }
 
+func TestDiffPaths(t *testing.T) {
+	diff := strings.Join([]string{
+		"diff --git a/internal/auth/token.go b/internal/auth/token.go",
+		"index 1111111..2222222 100644",
+		"--- a/internal/auth/token.go",
+		"+++ b/internal/auth/token.go",
+		"@@ -1 +1 @@",
+		"-package auth",
+		"+package token",
+		"diff --git a/docs/old name.md b/docs/new name.md",
+		"similarity index 90%",
+		"rename from docs/old name.md",
+		"rename to docs/new name.md",
+		"diff --git a/vendor/lib.go b/vendor/lib.go",
+		"new file mode 100644",
+		"--- /dev/null",
+		"+++ b/vendor/lib.go",
+		"diff --git a/logo.png b/logo.png",
+		"Binary files a/logo.png and b/logo.png differ",
+	}, "\n")
+
+	assert.Equal(t, []string{
+		"internal/auth/token.go", "docs/old name.md", "docs/new name.md", "vendor/lib.go", "logo.png",
+	}, DiffPaths(diff))
+	assert.Empty(t, DiffPaths(""))
+}
+
 func TestRepository_GetStagedDiff(t *testing.T) {
 	tempDir, repo := createTestRepo(t)
 
@@ -266,6 +293,69 @@ func TestRepository_Commit(t *testing.T) {
 	})
 }
 
+func TestRepository_Log(t *testing.T) {
+	tempDir, repo := createTestRepo(t)
+
+	for i, message := range []string{"Add log file", "Update log file"} {
+		createTestFile(t, tempDir, "log.txt", message)
+		require.NoError(t, repo.Add("log.txt"))
+		require.NoError(t, repo.Commit(message))
+		if i == 0 {
+			createTestFile(t, tempDir, "other.txt", "other")
+			require.NoError(t, repo.Add("other.txt"))
+			require.NoError(t, repo.Commit("Add other file"))
+		}
+	}
+
+	t.Run("commits for path newest first", func(t *testing.T) {
+		log, err := repo.Log("log.txt", 0)
+		require.NoError(t, err)
+
+		lines := strings.Split(strings.TrimSpace(log), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[0], "Test User Update log file")
+		assert.Contains(t, lines[1], "Add log file")
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		log, err := repo.Log("log.txt", 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(log, "\n"))
+	})
+}
+
+func TestRepository_ListFiles(t *testing.T) {
+	tempDir, repo := createTestRepo(t)
+
+	createTestFile(t, tempDir, "tracked.txt", "tracked")
+	require.NoError(t, repo.Add("tracked.txt"))
+	require.NoError(t, repo.Commit("Add tracked file"))
+
+	createTestFile(t, tempDir, "untracked.txt", "untracked")
+	createTestFile(t, tempDir, "ignored.log", "ignored")
+	createTestFile(t, tempDir, ".gitignore", "*.log\n")
+
+	files, err := repo.ListFiles()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{".gitignore", "tracked.txt", "untracked.txt"}, files)
+}
+
+func TestRepository_ShowFile(t *testing.T) {
+	tempDir, repo := createTestRepo(t)
+
+	createTestFile(t, tempDir, "main.go", "package main\n")
+	require.NoError(t, repo.Add("main.go"))
+	require.NoError(t, repo.Commit("Add main"))
+	createTestFile(t, tempDir, "main.go", "package main\n\nfunc main() {}\n")
+
+	content, err := repo.ShowFile("HEAD", "main.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", content)
+
+	_, err = repo.ShowFile("HEAD", "missing.go")
+	assert.Error(t, err)
+}
+
 func TestRepository_CreateWorktree(t *testing.T) {
 	t.Skip("Worktree tests require specific git configuration and may not work in all environments")
 
@@ -495,3 +585,292 @@ func TestRepository_ErrorHandling(t *testing.T) {
 		repo.Path = originalPath
 	})
 }
+
+// runGit runs a git command in dir for test setup
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+// createSubmoduleRepo creates a repository with a committed file and a
+// submodule at lib
+func createSubmoduleRepo(t *testing.T) (string, string) {
+	t.Helper()
+	libDir, lib := createTestRepo(t)
+	createTestFile(t, libDir, "lib.go", "package lib\n")
+	require.NoError(t, lib.Add("lib.go"))
+	require.NoError(t, lib.Commit("Add lib"))
+
+	appDir, app := createTestRepo(t)
+	createTestFile(t, appDir, "main.go", "package main\n")
+	require.NoError(t, app.Add("main.go"))
+	runGit(t, appDir, "-c", "protocol.file.allow=always", "submodule", "add", libDir, "lib")
+	require.NoError(t, app.Commit("Add lib submodule"))
+	return appDir, filepath.Join(appDir, "lib")
+}
+
+func TestNewRepository_Layouts(t *testing.T) {
+	t.Run("subdirectory", func(t *testing.T) {
+		tempDir, _ := createTestRepo(t)
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "internal", "pkg"), 0750))
+
+		repo, err := NewRepository(filepath.Join(tempDir, "internal", "pkg"))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(tempDir, "internal", "pkg"), repo.Path)
+		assert.Equal(t, filepath.Base(tempDir), filepath.Base(repo.Root))
+		assert.False(t, repo.IsSubmodule())
+		assert.False(t, repo.IsLinkedWorktree())
+	})
+
+	t.Run("linked worktree", func(t *testing.T) {
+		tempDir, repo := createTestRepo(t)
+		createTestFile(t, tempDir, "initial.txt", "content")
+		require.NoError(t, repo.Add("initial.txt"))
+		require.NoError(t, repo.Commit("Initial commit"))
+
+		worktreePath, err := repo.CreateWorktree("test")
+		require.NoError(t, err)
+		defer repo.RemoveWorktree(worktreePath)
+
+		linked, err := NewRepository(worktreePath)
+		require.NoError(t, err)
+		assert.True(t, linked.IsLinkedWorktree())
+		assert.Equal(t, filepath.Base(worktreePath), filepath.Base(linked.Root))
+
+		// Worktrees can be created from a linked worktree too
+		nested, err := linked.CreateWorktree("nested")
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(nested, "initial.txt"))
+		require.NoError(t, linked.RemoveWorktree(nested))
+	})
+
+	t.Run("submodule", func(t *testing.T) {
+		appDir, libDir := createSubmoduleRepo(t)
+
+		lib, err := NewRepository(libDir)
+		require.NoError(t, err)
+		assert.True(t, lib.IsSubmodule())
+		assert.Equal(t, filepath.Base(appDir), filepath.Base(lib.Superproject))
+		assert.Equal(t, "lib", filepath.Base(lib.Root))
+
+		app, err := NewRepository(appDir)
+		require.NoError(t, err)
+		assert.False(t, app.IsSubmodule())
+	})
+
+	t.Run("git directory is refused", func(t *testing.T) {
+		tempDir, _ := createTestRepo(t)
+
+		_, err := NewRepository(filepath.Join(tempDir, ".git"))
+		require.ErrorIs(t, err, ErrNoWorkTree)
+		assert.Contains(t, err.Error(), "run sigil from "+tempDir)
+	})
+
+	t.Run("bare repository is refused", func(t *testing.T) {
+		tempDir := t.TempDir()
+		runGit(t, tempDir, "init", "--bare")
+
+		_, err := NewRepository(tempDir)
+		require.ErrorIs(t, err, ErrNoWorkTree)
+		assert.Contains(t, err.Error(), "git worktree add")
+	})
+}
+
+func TestRepository_Submodules(t *testing.T) {
+	appDir, _ := createSubmoduleRepo(t)
+	app, err := NewRepository(appDir)
+	require.NoError(t, err)
+
+	submodules, err := app.Submodules()
+	require.NoError(t, err)
+	require.Len(t, submodules, 1)
+	assert.Equal(t, "lib", submodules[0].Path)
+	assert.True(t, submodules[0].Initialized)
+	assert.Len(t, submodules[0].Commit, 40)
+
+	files, err := app.ListFiles()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{".gitmodules", "main.go"}, files, "submodules are not listed as files")
+}
+
+// createShallowClone clones a repository whose feature branch forks from
+// main three commits back, keeping only the newest commit of each branch
+func createShallowClone(t *testing.T) *Repository {
+	t.Helper()
+	originDir, origin := createTestRepo(t)
+	for i := 1; i <= 3; i++ {
+		createTestFile(t, originDir, "main.txt", strings.Repeat("main\n", i))
+		require.NoError(t, origin.Add("main.txt"))
+		require.NoError(t, origin.Commit("main commit"))
+	}
+	runGit(t, originDir, "branch", "-M", "main")
+	runGit(t, originDir, "checkout", "-q", "-b", "feature", "HEAD~2")
+	for i := 1; i <= 3; i++ {
+		createTestFile(t, originDir, "feature.txt", strings.Repeat("feature\n", i))
+		require.NoError(t, origin.Add("feature.txt"))
+		require.NoError(t, origin.Commit("feature commit"))
+	}
+
+	cloneDir := filepath.Join(t.TempDir(), "clone")
+	runGit(t, originDir, "clone", "-q", "--depth=1", "--no-single-branch", "--branch", "feature", "file://"+originDir, cloneDir)
+	clone, err := NewRepository(cloneDir)
+	require.NoError(t, err)
+	return clone
+}
+
+func TestRepository_ShallowClone(t *testing.T) {
+	t.Run("merge base", func(t *testing.T) {
+		clone := createShallowClone(t)
+		shallow, err := clone.IsShallow()
+		require.NoError(t, err)
+		assert.True(t, shallow)
+
+		_, err = clone.EnsureMergeBase("origin/main", DeepenOptions{Auto: false, Max: 100})
+		assert.ErrorIs(t, err, ErrShallowHistory)
+
+		mergeBase, err := clone.EnsureMergeBase("origin/main", DeepenOptions{Auto: true, Max: 100})
+		require.NoError(t, err)
+		assert.Len(t, mergeBase, 40)
+	})
+
+	t.Run("parents of a boundary commit", func(t *testing.T) {
+		clone := createShallowClone(t)
+		assert.ErrorIs(t, clone.EnsureParents("HEAD", DeepenOptions{}), ErrShallowHistory)
+
+		require.NoError(t, clone.EnsureParents("HEAD", DeepenOptions{Auto: true, Max: 100}))
+		runGit(t, clone.Path, "rev-parse", "--verify", "HEAD^")
+		assert.NoError(t, clone.EnsureParents("HEAD", DeepenOptions{}), "parents are present now")
+	})
+
+	t.Run("full clones are left alone", func(t *testing.T) {
+		tempDir, repo := createTestRepo(t)
+		createTestFile(t, tempDir, "a.txt", "a")
+		require.NoError(t, repo.Add("a.txt"))
+		require.NoError(t, repo.Commit("Initial commit"))
+
+		shallow, err := repo.IsShallow()
+		require.NoError(t, err)
+		assert.False(t, shallow)
+		assert.NoError(t, repo.EnsureParents("HEAD", DeepenOptions{}))
+	})
+}
+
+func TestRepository_IsFreshClone(t *testing.T) {
+	clone := createShallowClone(t)
+	fresh, err := clone.IsFreshClone()
+	require.NoError(t, err)
+	assert.True(t, fresh)
+
+	runGit(t, clone.Path, "checkout", "-q", "-b", "work")
+	fresh, err = clone.IsFreshClone()
+	require.NoError(t, err)
+	assert.False(t, fresh, "working in the clone makes it trusted")
+
+	tempDir, repo := createTestRepo(t)
+	createTestFile(t, tempDir, "a.txt", "a")
+	require.NoError(t, repo.Add("a.txt"))
+	require.NoError(t, repo.Commit("Initial commit"))
+	fresh, err = repo.IsFreshClone()
+	require.NoError(t, err)
+	assert.False(t, fresh)
+}
+
+func TestRepository_ListFiles_SparseCheckout(t *testing.T) {
+	tempDir, repo := createTestRepo(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "docs"), 0750))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "src"), 0750))
+	createTestFile(t, tempDir, "docs/guide.md", "guide")
+	createTestFile(t, tempDir, "src/main.go", "package main")
+	require.NoError(t, repo.Add("."))
+	require.NoError(t, repo.Commit("Initial commit"))
+
+	runGit(t, tempDir, "sparse-checkout", "set", "src")
+
+	files, err := repo.ListFiles()
+	require.NoError(t, err)
+	assert.Contains(t, files, "src/main.go")
+	assert.NotContains(t, files, "docs/guide.md", "paths outside the sparse checkout are not materialized")
+}
+
+func TestRepository_Tags(t *testing.T) {
+	dir, repo := createTestRepo(t)
+
+	tag, err := repo.LatestTag("v[0-9]*")
+	require.NoError(t, err)
+	assert.Empty(t, tag, "a repository without commits has no tag")
+
+	createTestFile(t, dir, "a.txt", "a\n")
+	require.NoError(t, repo.Add("a.txt"))
+	require.NoError(t, repo.Commit("feat: add a"))
+	require.NoError(t, repo.CreateTag("v1.0.0", "Release v1.0.0"))
+	runGit(t, dir, "tag", "other")
+
+	createTestFile(t, dir, "b.txt", "b\n")
+	require.NoError(t, repo.Add("b.txt"))
+	require.NoError(t, repo.Commit("fix(b): handle b\n\nBREAKING CHANGE: b moved"))
+
+	tag, err = repo.LatestTag("v[0-9]*")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0", tag)
+
+	commits, err := repo.CommitsSince(tag)
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, "fix(b): handle b", commits[0].Subject)
+	assert.Equal(t, "BREAKING CHANGE: b moved", commits[0].Body)
+	assert.Len(t, commits[0].Hash, 40)
+
+	all, err := repo.CommitsSince("")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	assert.Error(t, repo.CreateTag("v1.0.0", "again"), "tags are not overwritten")
+}
+
+func TestRepository_ChangedFiles(t *testing.T) {
+	dir, repo := createTestRepo(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0755))
+	createTestFile(t, dir, "lib/a.go", "package lib\n")
+	createTestFile(t, dir, "main.go", "package main\n")
+	require.NoError(t, repo.Add("."))
+	require.NoError(t, repo.Commit("initial"))
+	runGit(t, dir, "branch", "base")
+
+	runGit(t, dir, "mv", "lib/a.go", "lib/b.go")
+	require.NoError(t, repo.Commit("rename"))
+
+	changed, err := repo.ChangedFiles("base")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"lib/a.go", "lib/b.go"}, changed, "renames list both paths")
+
+	_, err = repo.ChangedFiles("missing")
+	assert.Error(t, err)
+}
+
+func TestRepository_Blame(t *testing.T) {
+	dir, repo := createTestRepo(t)
+	createTestFile(t, dir, "a.go", "one\ntwo\n")
+	require.NoError(t, repo.Add("a.go"))
+	require.NoError(t, repo.Commit("add a"))
+
+	createTestFile(t, dir, "a.go", "one\ntwo\nthree\nfour\nfive\n")
+	require.NoError(t, repo.Add("a.go"))
+	runGit(t, dir, "-c", "user.name=Other", "-c", "user.email=other@example.com", "commit", "-q", "-m", "extend a")
+
+	authors, err := repo.Blame("a.go", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []BlameAuthor{
+		{Name: "Other", Email: "other@example.com", Lines: 3},
+		{Name: "Test User", Email: "test@example.com", Lines: 2},
+	}, authors)
+
+	authors, err = repo.Blame("a.go", 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []BlameAuthor{{Name: "Test User", Email: "test@example.com", Lines: 1}}, authors)
+
+	_, err = repo.Blame("missing.go", 0, 0)
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -8,12 +9,36 @@ import (
 	"strings"
 )
 
+var (
+	// ErrNotRepository is returned for paths outside any git repository
+	ErrNotRepository = errors.New("not a git repository (or any of the parent directories)")
+
+	// ErrNoWorkTree is returned for bare repositories and git directories,
+	// which have no files to work on
+	ErrNoWorkTree = errors.New("not in a git working tree")
+)
+
 // Repository represents a Git repository.
 type Repository struct {
 	Path string
+
+	// Set by NewRepository from the discovered layout
+	Root         string // Top of the working tree
+	GitDir       string // Git directory of this working tree
+	CommonDir    string // Git directory shared by all worktrees
+	Superproject string // Working tree of the containing repository, for submodules
 }
 
-// NewRepository creates a new Repository instance.
+// Submodule is a submodule registered in a repository
+type Submodule struct {
+	Path        string // Relative to the repository root
+	Commit      string
+	Initialized bool
+}
+
+// NewRepository creates a new Repository instance. The path may be
+// anywhere inside a working tree, including a submodule or a linked
+// worktree; bare repositories and git directories are refused.
 func NewRepository(path string) (*Repository, error) {
 	// If no path provided, use current directory
 	if path == "" {
@@ -24,35 +49,87 @@ func NewRepository(path string) (*Repository, error) {
 		}
 	}
 
-	// Check if it's a git repository
-	if err := checkGitRepo(path); err != nil {
+	repo, err := discover(path)
+	if err != nil {
 		return nil, err
 	}
-
-	return &Repository{Path: path}, nil
+	repo.Path = path
+	return repo, nil
 }
 
-// checkGitRepo verifies that the given path is inside a git repository.
+// checkGitRepo verifies that the given path is inside a git working tree.
 func checkGitRepo(path string) error {
-	cmd := exec.Command("git", "rev-parse", "--git-dir")
+	_, err := discover(path)
+	return err
+}
+
+// discover finds the working tree and git directories containing path
+func discover(path string) (*Repository, error) {
+	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree", "--is-bare-repository", "--absolute-git-dir", "--git-common-dir")
 	cmd.Dir = path
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, ErrNotRepository
+	}
+	fields := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(fields) < 4 || fields[2] == "" {
+		return nil, fmt.Errorf("unable to determine git directory")
+	}
+	inWorkTree, bare, gitDir, commonDir := fields[0] == "true", fields[1] == "true", fields[2], fields[3]
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(path, commonDir)
+	}
 
-	output, err := cmd.CombinedOutput()
+	switch {
+	case bare:
+		return nil, fmt.Errorf("%w: %s is a bare repository; run sigil in a clone, or in a worktree created with 'git worktree add'", ErrNoWorkTree, gitDir)
+	case !inWorkTree && filepath.Base(gitDir) == ".git":
+		return nil, fmt.Errorf("%w: %s is inside the git directory; run sigil from %s", ErrNoWorkTree, path, filepath.Dir(gitDir))
+	case !inWorkTree:
+		return nil, fmt.Errorf("%w: %s is inside the git directory %s; run sigil from its working tree", ErrNoWorkTree, path, gitDir)
+	}
+
+	cmd = exec.Command("git", "rev-parse", "--show-toplevel", "--show-superproject-working-tree")
+	cmd.Dir = path
+	output, err = cmd.Output()
 	if err != nil {
-		return fmt.Errorf("not a git repository (or any of the parent directories)")
+		return nil, fmt.Errorf("failed to get repository root: %w", err)
 	}
+	fields = strings.Split(strings.TrimSpace(string(output)), "\n")
 
-	// Verify we got a valid response
-	gitDir := strings.TrimSpace(string(output))
-	if gitDir == "" {
-		return fmt.Errorf("unable to determine git directory")
+	repo := &Repository{Root: fields[0], GitDir: canonical(gitDir), CommonDir: canonical(commonDir)}
+	if len(fields) > 1 {
+		repo.Superproject = fields[1]
 	}
+	return repo, nil
+}
 
-	return nil
+// canonical resolves symlinks in a path so paths reported in different
+// forms compare equal
+func canonical(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// IsSubmodule reports whether the repository is a submodule of another
+func (r *Repository) IsSubmodule() bool {
+	return r.Superproject != ""
+}
+
+// IsLinkedWorktree reports whether the repository is a worktree added with
+// 'git worktree add' rather than the main working tree
+func (r *Repository) IsLinkedWorktree() bool {
+	return r.GitDir != "" && r.CommonDir != "" && r.GitDir != r.CommonDir
 }
 
 // GetRoot returns the root directory of the git repository.
 func (r *Repository) GetRoot() (string, error) {
+	if r.Root != "" {
+		return r.Root, nil
+	}
+
 	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
 	cmd.Dir = r.Path
 
@@ -77,6 +154,53 @@ func (r *Repository) GetCurrentBranch() (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// GetHeadCommit returns the full hash of the HEAD commit
+func (r *Repository) GetHeadCommit() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = r.Path
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetRemoteURL returns the URL of the named remote
+func (r *Repository) GetRemoteURL(remote string) (string, error) {
+	cmd := exec.Command("git", "remote", "get-url", remote)
+	cmd.Dir = r.Path
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to get remote URL: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// IsFreshClone reports whether the working tree is as git clone left it:
+// its HEAD reflog holds only the clone. Any checkout, commit, pull or reset
+// since adds to the reflog. A repository without a HEAD reflog is not
+// reported as a fresh clone.
+func (r *Repository) IsFreshClone() (bool, error) {
+	data, err := os.ReadFile(filepath.Join(r.GitDir, "logs", "HEAD"))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read HEAD reflog: %w", err)
+	}
+
+	entries := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(entries) != 1 {
+		return false, nil
+	}
+	_, message, _ := strings.Cut(entries[0], "\t")
+	return strings.HasPrefix(message, "clone: from "), nil
+}
+
 // GetStatus returns the working tree status
 func (r *Repository) GetStatus() (string, error) {
 	cmd := exec.Command("git", "status", "--porcelain")
@@ -103,6 +227,109 @@ func (r *Repository) GetDiff() (string, error) {
 	return string(output), nil
 }
 
+// ShowFile returns the content of path, relative to the repository root, at
+// the given revision
+func (r *Repository) ShowFile(ref, path string) (string, error) {
+	cmd := exec.Command("git", "show", ref+":"+filepath.ToSlash(path))
+	cmd.Dir = r.Path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s at %s: %w", path, ref, err)
+	}
+
+	return string(output), nil
+}
+
+// Log returns up to limit one-line commit summaries touching path, newest first
+func (r *Repository) Log(path string, limit int) (string, error) {
+	args := []string{"log", "--date=short", "--format=%h %ad %an %s"}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", limit))
+	}
+	args = append(args, "--", path)
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Path
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to get log for %s: %w", path, err)
+	}
+
+	return string(output), nil
+}
+
+// ListFiles returns the tracked and untracked, non-ignored files below the
+// repository path, relative to it. Only files present in the working tree
+// are listed, so paths left out of a sparse checkout are skipped.
+// Submodules are not listed; see Submodules.
+func (r *Repository) ListFiles() ([]string, error) {
+	cmd := exec.Command("git", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = r.Path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, file := range strings.Split(string(output), "\x00") {
+		// Files with unmerged changes are listed once per stage
+		if file == "" || seen[file] {
+			continue
+		}
+		seen[file] = true
+		// Submodules are listed as their directory
+		info, err := os.Lstat(filepath.Join(r.Path, file))
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Submodules returns the submodules of the repository and, recursively, of
+// its initialized submodules
+func (r *Repository) Submodules() ([]Submodule, error) {
+	root, err := r.GetRoot()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("git", "submodule", "status", "--recursive")
+	cmd.Dir = root
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list submodules: %w", err)
+	}
+
+	var submodules []Submodule
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		// Each line is a status flag, the commit, the path and, when
+		// checked out, a description in parentheses
+		commit, rest, ok := strings.Cut(line[1:], " ")
+		if !ok {
+			continue
+		}
+		path := rest
+		if i := strings.LastIndex(rest, " ("); i >= 0 && strings.HasSuffix(rest, ")") {
+			path = rest[:i]
+		}
+		submodules = append(submodules, Submodule{
+			Path:        filepath.ToSlash(path),
+			Commit:      commit,
+			Initialized: line[0] != '-',
+		})
+	}
+	return submodules, nil
+}
+
 // GetStagedDiff returns the diff of staged changes
 func (r *Repository) GetStagedDiff() (string, error) {
 	cmd := exec.Command("git", "diff", "--staged")
@@ -116,6 +343,27 @@ func (r *Repository) GetStagedDiff() (string, error) {
 	return string(output), nil
 }
 
+// ChangedFiles returns the files changed on HEAD since it diverged from
+// base, relative to the repository root. Renames are listed as the removed
+// and the added path.
+func (r *Repository) ChangedFiles(base string) ([]string, error) {
+	cmd := exec.Command("git", "diff", "--name-only", "--no-renames", "-z", base+"...HEAD")
+	cmd.Dir = r.Path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files changed since %s: %w", base, err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
 // Add stages files
 func (r *Repository) Add(files ...string) error {
 	args := append([]string{"add"}, files...)
@@ -149,15 +397,9 @@ func (r *Repository) CreateWorktree(name string) (string, error) {
 		return "", fmt.Errorf("failed to create temp directory: %w", err)
 	}
 
-	// Get current branch
-	branch, err := r.GetCurrentBranch()
-	if err != nil {
-		os.RemoveAll(tmpDir)
-		return "", err
-	}
-
-	// Create worktree
-	cmd := exec.Command("git", "worktree", "add", tmpDir, branch)
+	// Detach at HEAD: the current branch is already checked out here, and
+	// may not exist at all in a submodule or a detached worktree
+	cmd := exec.Command("git", "worktree", "add", "--detach", tmpDir, "HEAD")
 	cmd.Dir = r.Path
 
 	if err := cmd.Run(); err != nil {
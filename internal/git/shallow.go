@@ -0,0 +1,146 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrShallowHistory is returned when a shallow clone lacks the history an
+// operation needs and it could not be fetched
+var ErrShallowHistory = errors.New("history missing from shallow clone")
+
+// initialDeepen is the first number of commits fetched when deepening
+const initialDeepen = 50
+
+// DeepenOptions controls fetching history into shallow clones
+type DeepenOptions struct {
+	Auto   bool   // Fetch missing history; otherwise only explain what is missing
+	Max    int    // Commits to fetch at most before giving up
+	Remote string // Remote to fetch from; the default remote when empty
+}
+
+// IsShallow reports whether the repository is a shallow clone
+func (r *Repository) IsShallow() (bool, error) {
+	cmd := exec.Command("git", "rev-parse", "--is-shallow-repository")
+	cmd.Dir = r.Path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to check for a shallow clone: %w", err)
+	}
+	return strings.TrimSpace(string(output)) == "true", nil
+}
+
+// MergeBase returns the best common ancestor of two commits
+func (r *Repository) MergeBase(a, b string) (string, error) {
+	cmd := exec.Command("git", "merge-base", a, b)
+	cmd.Dir = r.Path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("no merge base between %s and %s: %w", a, b, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Deepen fetches the given number of older commits into a shallow clone
+func (r *Repository) Deepen(remote string, commits int) error {
+	args := []string{"fetch", "--quiet", fmt.Sprintf("--deepen=%d", commits)}
+	if remote != "" {
+		args = append(args, remote)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Path
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to deepen history: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// EnsureMergeBase returns the merge base of base and HEAD, deepening a
+// shallow clone in growing steps until it is found
+func (r *Repository) EnsureMergeBase(base string, opts DeepenOptions) (string, error) {
+	mergeBase, err := r.MergeBase(base, "HEAD")
+	if err == nil {
+		return mergeBase, nil
+	}
+	if shallow, shallowErr := r.IsShallow(); shallowErr != nil || !shallow {
+		return "", err
+	}
+	if !opts.Auto {
+		return "", fmt.Errorf("%w: %s and HEAD have no common ancestor in the fetched history", ErrShallowHistory, base)
+	}
+
+	fetched := 0
+	for step := initialDeepen; fetched < opts.Max; step *= 2 {
+		step = min(step, opts.Max-fetched)
+		if err := r.Deepen(opts.Remote, step); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrShallowHistory, err)
+		}
+		fetched += step
+		if mergeBase, err := r.MergeBase(base, "HEAD"); err == nil {
+			return mergeBase, nil
+		}
+		if shallow, err := r.IsShallow(); err == nil && !shallow {
+			// The whole history is here and the commits are unrelated
+			break
+		}
+	}
+	return "", fmt.Errorf("%w: %s and HEAD have no common ancestor within %d more commits", ErrShallowHistory, base, fetched)
+}
+
+// EnsureParents fetches the parents of a commit at the boundary of a
+// shallow clone, so it can be diffed against them instead of appearing to
+// add every file
+func (r *Repository) EnsureParents(commit string, opts DeepenOptions) error {
+	boundary, err := r.isShallowBoundary(commit)
+	if err != nil || !boundary {
+		return err
+	}
+	if !opts.Auto || opts.Max < 1 {
+		return fmt.Errorf("%w: the parents of %s were not fetched", ErrShallowHistory, commit)
+	}
+	if err := r.Deepen(opts.Remote, 1); err != nil {
+		return fmt.Errorf("%w: %v", ErrShallowHistory, err)
+	}
+	return nil
+}
+
+// isShallowBoundary reports whether a commit's parents were cut off by a
+// shallow clone
+func (r *Repository) isShallowBoundary(commit string) (bool, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "shallow", commit+"^{commit}")
+	cmd.Dir = r.Path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("unknown commit %s: %w", commit, err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) < 2 {
+		return false, fmt.Errorf("unknown commit %s", commit)
+	}
+	path, hash := fields[0], fields[1]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.Path, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == hash {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Commit is a commit's hash and message
+type Commit struct {
+	Hash    string
+	Subject string
+	Body    string
+}
+
+// LatestTag returns the most recent tag reachable from HEAD that matches
+// pattern (any tag when empty), or "" when there is none
+func (r *Repository) LatestTag(pattern string) (string, error) {
+	args := []string{"describe", "--tags", "--abbrev=0"}
+	if pattern != "" {
+		args = append(args, "--match", pattern)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Path
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		text := string(output)
+		if strings.Contains(text, "No names found") || strings.Contains(text, "No tags can describe") ||
+			strings.Contains(text, "cannot describe") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to find the latest tag: %s: %w", strings.TrimSpace(text), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CommitsSince returns the commits reachable from HEAD but not from ref,
+// newest first; every commit when ref is empty
+func (r *Repository) CommitsSince(ref string) ([]Commit, error) {
+	args := []string{"log", "--format=%H%x1f%s%x1f%b%x1e"}
+	if ref != "" {
+		args = append(args, ref+"..HEAD")
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits since %s: %w", ref, err)
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Subject: fields[1],
+			Body:    strings.TrimSpace(fields[2]),
+		})
+	}
+	return commits, nil
+}
+
+// CreateTag creates an annotated tag on HEAD
+func (r *Repository) CreateTag(name, message string) error {
+	cmd := exec.Command("git", "tag", "-a", name, "-m", message)
+	cmd.Dir = r.Path
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create tag %s: %s: %w", name, strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
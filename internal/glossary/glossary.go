@@ -0,0 +1,224 @@
+// Package glossary keeps a project's preferred terminology. The glossary is
+// given to every model prompt and applied to the text sigil writes, so
+// product names, acronyms and domain terms are spelled one way in docs,
+// reviews, summaries and commit messages alike.
+package glossary
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// DefaultPath is where a project keeps its glossary
+const DefaultPath = ".sigil/glossary.yml"
+
+// Term is a preferred spelling, the variants to replace with it, and what
+// it means
+type Term struct {
+	Term       string   `yaml:"term"`
+	Avoid      []string `yaml:"avoid,omitempty"`
+	Definition string   `yaml:"definition,omitempty"`
+}
+
+// Glossary lists a project's preferred terminology. Any other casing of a
+// term, or any of its avoided variants, is replaced with the term.
+type Glossary struct {
+	Terms []Term `yaml:"terms"`
+
+	patterns []*regexp.Regexp
+	words    map[string]string
+}
+
+// Replacement is a spelling a glossary term replaced
+type Replacement struct {
+	Found string
+	Term  string
+}
+
+// protectedSpan matches text never rewritten: inline code, link
+// destinations, URLs, and dotted names such as files and domains
+var protectedSpan = regexp.MustCompile("`[^`]*`|\\]\\([^)]*\\)|[a-zA-Z][a-zA-Z0-9+.-]*://\\S+|[\\w-]+(?:\\.[\\w-]+)+")
+
+// ProtectedSpans returns the [start, end) ranges of text that are never
+// rewritten
+func ProtectedSpans(text string) [][]int {
+	return protectedSpan.FindAllStringIndex(text, -1)
+}
+
+// Load reads a glossary file. A missing file loads as an empty glossary.
+func Load(path string) (*Glossary, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return New(nil), nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "Load", "failed to read glossary")
+	}
+
+	var glossary Glossary
+	if err := yaml.Unmarshal(data, &glossary); err != nil {
+		return nil, errors.ConfigError("Load", "invalid glossary: "+err.Error()).
+			WithHint("expected a terms list of {term, avoid, definition} entries")
+	}
+	for _, term := range glossary.Terms {
+		if strings.TrimSpace(term.Term) == "" {
+			return nil, errors.ConfigError("Load", "glossary entry without a term")
+		}
+	}
+	return New(glossary.Terms), nil
+}
+
+// New creates a glossary from terms
+func New(terms []Term) *Glossary {
+	g := &Glossary{Terms: terms, words: make(map[string]string)}
+	for _, term := range terms {
+		variants := []string{regexp.QuoteMeta(term.Term)}
+		for _, avoid := range term.Avoid {
+			variants = append(variants, regexp.QuoteMeta(avoid))
+		}
+		g.patterns = append(g.patterns, regexp.MustCompile(`(?i)\b(?:`+strings.Join(variants, "|")+`)\b`))
+
+		for _, word := range strings.Fields(term.Term) {
+			g.words[strings.ToLower(word)] = word
+		}
+	}
+	return g
+}
+
+// Empty reports whether the glossary has no terms
+func (g *Glossary) Empty() bool {
+	return len(g.Terms) == 0
+}
+
+// Word returns the glossary's casing of a word that is part of a term
+func (g *Glossary) Word(word string) (string, bool) {
+	preferred, ok := g.words[strings.ToLower(word)]
+	return preferred, ok
+}
+
+// Rule returns the instruction telling a model to use the glossary's
+// terms, or "" for an empty glossary
+func (g *Glossary) Rule() string {
+	if g.Empty() {
+		return ""
+	}
+	var rule strings.Builder
+	rule.WriteString("Use the project's terminology exactly as written below, in prose and in any text you generate. " +
+		"Never use the variants listed after \"not\":")
+	for _, term := range g.Terms {
+		rule.WriteString("\n- " + term.Term)
+		if len(term.Avoid) > 0 {
+			rule.WriteString(" (not " + strings.Join(term.Avoid, ", ") + ")")
+		}
+		if term.Definition != "" {
+			rule.WriteString(": " + term.Definition)
+		}
+	}
+	return rule.String()
+}
+
+// Replace replaces the non-preferred spellings of the glossary's terms in
+// a line, outside protected spans such as inline code and URLs
+func (g *Glossary) Replace(line string) (string, []Replacement) {
+	var replacements []Replacement
+	for i, pattern := range g.patterns {
+		preferred := g.Terms[i].Term
+		protected := ProtectedSpans(line)
+		matches := pattern.FindAllStringIndex(line, -1)
+
+		// Replace from the end so earlier offsets stay valid
+		for m := len(matches) - 1; m >= 0; m-- {
+			start, end := matches[m][0], matches[m][1]
+			if line[start:end] == preferred || Overlaps(protected, start, end) {
+				continue
+			}
+			replacements = append(replacements, Replacement{Found: line[start:end], Term: preferred})
+			line = line[:start] + preferred + line[end:]
+			protected = ProtectedSpans(line)
+		}
+	}
+	return line, replacements
+}
+
+// Apply replaces the non-preferred spellings of the glossary's terms in
+// text, leaving front matter, fenced code and protected spans alone. It
+// returns the text and how many spellings it replaced.
+func (g *Glossary) Apply(text string) (string, int) {
+	if g.Empty() {
+		return text, 0
+	}
+	lines := strings.Split(text, "\n")
+	replaced := 0
+	inFence := false
+	inFrontMatter := len(lines) > 0 && strings.TrimSpace(lines[0]) == "---"
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if inFrontMatter {
+			if i > 0 && trimmed == "---" {
+				inFrontMatter = false
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		var replacements []Replacement
+		lines[i], replacements = g.Replace(line)
+		replaced += len(replacements)
+	}
+	return strings.Join(lines, "\n"), replaced
+}
+
+// Overlaps reports whether [start, end) intersects any of spans
+func Overlaps(spans [][]int, start, end int) bool {
+	for _, span := range spans {
+		if start < span[1] && span[0] < end {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mu      sync.RWMutex
+	current = New(nil)
+)
+
+// Set makes g the glossary of the process; nil clears it
+func Set(g *Glossary) {
+	mu.Lock()
+	defer mu.Unlock()
+	if g == nil {
+		g = New(nil)
+	}
+	current = g
+}
+
+// Current returns the glossary of the process, empty when none is set
+func Current() *Glossary {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Instruct adds the rule of the process's glossary to a system prompt
+func Instruct(systemPrompt string) string {
+	rule := Current().Rule()
+	if rule == "" || strings.Contains(systemPrompt, rule) {
+		return systemPrompt
+	}
+	if systemPrompt == "" {
+		return rule
+	}
+	return systemPrompt + "\n\n" + rule
+}
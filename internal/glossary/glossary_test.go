@@ -0,0 +1,76 @@
+package glossary
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	glossary, err := Load(filepath.Join(dir, "missing.yml"))
+	require.NoError(t, err)
+	assert.Empty(t, glossary.Terms)
+	assert.True(t, glossary.Empty())
+
+	path := filepath.Join(dir, "glossary.yml")
+	require.NoError(t, os.WriteFile(path, []byte("terms:\n  - term: PostgreSQL\n    avoid: [postgres, Postgres]\n    definition: the primary database\n"), 0644))
+	glossary, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Term{{Term: "PostgreSQL", Avoid: []string{"postgres", "Postgres"}, Definition: "the primary database"}}, glossary.Terms)
+
+	require.NoError(t, os.WriteFile(path, []byte("terms:\n  - avoid: [x]\n"), 0644))
+	_, err = Load(path)
+	assert.Error(t, err)
+}
+
+func TestGlossary_Rule(t *testing.T) {
+	assert.Empty(t, New(nil).Rule())
+
+	glossary := New([]Term{
+		{Term: "GitHub", Avoid: []string{"git hub", "Github"}},
+		{Term: "SLO", Definition: "service level objective"},
+	})
+	assert.Equal(t, "Use the project's terminology exactly as written below, in prose and in any text you generate. "+
+		"Never use the variants listed after \"not\":\n"+
+		"- GitHub (not git hub, Github)\n"+
+		"- SLO: service level objective", glossary.Rule())
+}
+
+func TestGlossary_Apply(t *testing.T) {
+	glossary := New([]Term{
+		{Term: "GitHub", Avoid: []string{"git hub"}},
+		{Term: "Kubernetes", Avoid: []string{"k8s"}},
+	})
+
+	text := "---\ntitle: github\n---\nDeploy to k8s from Github or git hub.\n" +
+		"See https://github.com/org/repo, `github` and [docs](https://kubernetes.io).\n" +
+		"```\ngithub stays in code\n```\nfix: k8s rollout"
+	applied, replaced := glossary.Apply(text)
+	assert.Equal(t, "---\ntitle: github\n---\nDeploy to Kubernetes from GitHub or GitHub.\n"+
+		"See https://github.com/org/repo, `github` and [docs](https://kubernetes.io).\n"+
+		"```\ngithub stays in code\n```\nfix: Kubernetes rollout", applied)
+	assert.Equal(t, 4, replaced)
+
+	line, replacements := glossary.Replace("Github on K8S")
+	assert.Equal(t, "GitHub on Kubernetes", line)
+	assert.Equal(t, []Replacement{{Found: "Github", Term: "GitHub"}, {Found: "K8S", Term: "Kubernetes"}}, replacements)
+}
+
+func TestInstruct(t *testing.T) {
+	t.Cleanup(func() { Set(nil) })
+
+	Set(nil)
+	assert.Equal(t, "You write commit messages.", Instruct("You write commit messages."))
+
+	Set(New([]Term{{Term: "Sigil"}}))
+	rule := Current().Rule()
+	instructed := Instruct("You write commit messages.")
+	assert.Equal(t, "You write commit messages.\n\n"+rule, instructed)
+	assert.Equal(t, instructed, Instruct(instructed), "the rule is added once")
+	assert.Equal(t, rule, Instruct(""))
+}
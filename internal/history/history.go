@@ -0,0 +1,183 @@
+// Package history stores the artifacts of orchestrated tasks, the agent
+// conversation and sandbox transcript of each, for later inspection and replay
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/sandbox"
+	"github.com/dshills/sigil/internal/version"
+)
+
+// DefaultDir is where task records are stored
+const DefaultDir = ".sigil/history"
+
+// DefaultMaxRecords is how many task records a store keeps before pruning
+// the oldest
+const DefaultMaxRecords = 50
+
+// Record is what was stored for one task
+type Record struct {
+	TaskID       string              `json:"task_id"`
+	Type         agent.TaskType      `json:"type"`
+	Description  string              `json:"description"`
+	Time         time.Time           `json:"time"`
+	Status       agent.ResultStatus  `json:"status"`
+	LeadAgent    string              `json:"lead_agent,omitempty"`
+	Duration     time.Duration       `json:"duration"`
+	Conversation []agent.Turn        `json:"conversation"`
+	Sandbox      []sandbox.Execution `json:"sandbox,omitempty"`
+	SigilVersion string              `json:"sigil_version,omitempty"` // Version of sigil that ran the task
+}
+
+// NewRecord builds the record of a task from its orchestration result
+func NewRecord(task agent.Task, result *agent.OrchestrationResult) Record {
+	return Record{
+		TaskID:       task.ID,
+		Type:         task.Type,
+		Description:  task.Description,
+		Time:         result.Timestamp,
+		Status:       result.Status,
+		LeadAgent:    result.LeadAgent,
+		Duration:     result.Duration,
+		Conversation: result.Conversation,
+		Sandbox:      result.Transcript,
+		SigilVersion: version.Get().Version,
+	}
+}
+
+// Store keeps task records as JSON files in a directory
+type Store struct {
+	dir        string
+	maxRecords int
+}
+
+// NewStore creates a store in dir keeping the DefaultMaxRecords most recent
+// records
+func NewStore(dir string) *Store {
+	return &Store{dir: dir, maxRecords: DefaultMaxRecords}
+}
+
+// Dir returns the directory records are stored in
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save writes a record, replacing any earlier record of the same task, and
+// prunes the oldest records beyond the store's limit
+func (s *Store) Save(record Record) error {
+	if record.TaskID == "" {
+		return errors.ValidationError("Save", "a task record needs a task ID")
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Save", "failed to create history directory")
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Save", "failed to encode task record")
+	}
+	path := s.pathFor(record.TaskID)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Save", fmt.Sprintf("failed to write task record: %s", path))
+	}
+	return s.prune()
+}
+
+// Load reads the record of a task, given its ID or a prefix that only it
+// starts with
+func (s *Store) Load(id string) (*Record, error) {
+	records, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Record
+	for _, record := range records {
+		if record.TaskID == id {
+			return &record, nil
+		}
+		if strings.HasPrefix(record.TaskID, id) {
+			matches = append(matches, record)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, errors.New(errors.ErrorTypeInput, "Load", fmt.Sprintf("no task recorded as %s", id)).
+			WithHint("run sigil history list to see the recorded tasks")
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, errors.New(errors.ErrorTypeInput, "Load",
+			fmt.Sprintf("%d tasks start with %s", len(matches), id)).
+			WithHint("give more of the task ID")
+	}
+}
+
+// List returns the stored records, newest first. Unreadable records are
+// skipped.
+func (s *Store) List() ([]Record, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "List", "failed to read history directory")
+	}
+
+	var records []Record
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(s.dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logger.Warn("skipping unreadable task record", "path", path, "error", err)
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			logger.Warn("skipping corrupt task record", "path", path, "error", err)
+			continue
+		}
+		version.CheckState(path, record.SigilVersion)
+		records = append(records, record)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].Time.Equal(records[j].Time) {
+			return records[i].Time.After(records[j].Time)
+		}
+		return records[i].TaskID > records[j].TaskID
+	})
+	return records, nil
+}
+
+// prune removes the oldest records beyond the store's limit
+func (s *Store) prune() error {
+	records, err := s.List()
+	if err != nil || len(records) <= s.maxRecords {
+		return err
+	}
+	for _, record := range records[s.maxRecords:] {
+		if err := os.Remove(s.pathFor(record.TaskID)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, errors.ErrorTypeFS, "prune", "failed to remove old task record")
+		}
+	}
+	return nil
+}
+
+// pathFor returns the file a task's record is stored in
+func (s *Store) pathFor(taskID string) string {
+	return filepath.Join(s.dir, strings.NewReplacer("/", "_", "\\", "_").Replace(taskID)+".json")
+}
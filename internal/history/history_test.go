@@ -0,0 +1,112 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoModel answers every prompt with its user prompt, or with a fixed
+// response for prompts it knows
+type echoModel struct {
+	responses map[string]string
+}
+
+func (m *echoModel) RunPrompt(_ context.Context, input model.PromptInput) (model.PromptOutput, error) {
+	if response, ok := m.responses[input.UserPrompt]; ok {
+		return model.PromptOutput{Response: response, Model: "echo"}, nil
+	}
+	return model.PromptOutput{Response: input.UserPrompt, Model: "echo"}, nil
+}
+
+func (m *echoModel) GetCapabilities() model.ModelCapabilities {
+	return model.ModelCapabilities{}
+}
+
+func (m *echoModel) Name() string {
+	return "echo"
+}
+
+func TestStore_SaveLoadList(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "history"))
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	records, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, records, "a missing directory lists as empty")
+
+	task := agent.Task{ID: "task_100", Type: agent.TaskTypeEdit, Description: "Add logging"}
+	result := &agent.OrchestrationResult{
+		Status:       agent.StatusSuccess,
+		LeadAgent:    "lead",
+		Timestamp:    start,
+		Conversation: []agent.Turn{{AgentID: "lead", Phase: agent.PhaseExecute, UserPrompt: "Add logging", Response: "done"}},
+	}
+	require.NoError(t, store.Save(NewRecord(task, result)))
+	require.NoError(t, store.Save(Record{TaskID: "task_200", Time: start.Add(time.Minute)}))
+
+	record, err := store.Load("task_100")
+	require.NoError(t, err)
+	assert.Equal(t, agent.TaskTypeEdit, record.Type)
+	assert.Equal(t, "lead", record.LeadAgent)
+	require.Len(t, record.Conversation, 1)
+	assert.Equal(t, "done", record.Conversation[0].Response)
+
+	record, err = store.Load("task_2")
+	require.NoError(t, err)
+	assert.Equal(t, "task_200", record.TaskID, "a unique prefix finds the task")
+
+	_, err = store.Load("task_")
+	assert.ErrorContains(t, err, "2 tasks start with task_")
+	_, err = store.Load("task_300")
+	assert.ErrorContains(t, err, "no task recorded as task_300")
+
+	require.NoError(t, os.WriteFile(filepath.Join(store.Dir(), "corrupt.json"), []byte("{"), 0600))
+	records, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "task_200", records[0].TaskID, "newest first")
+
+	assert.Error(t, store.Save(Record{}))
+}
+
+func TestStore_PrunesOldest(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.maxRecords = 3
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.Save(Record{TaskID: fmt.Sprintf("task_%d", i), Time: start.Add(time.Duration(i) * time.Second)}))
+	}
+
+	records, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "task_4", records[0].TaskID)
+	assert.Equal(t, "task_2", records[2].TaskID)
+}
+
+func TestReplay(t *testing.T) {
+	turns := []agent.Turn{
+		{AgentID: "lead", UserPrompt: "same", Response: "same"},
+		{AgentID: "lead", UserPrompt: "changed", Response: "old answer"},
+	}
+	replayed := Replay(context.Background(), &echoModel{responses: map[string]string{"changed": "new answer"}}, turns)
+
+	require.Len(t, replayed, 2)
+	assert.False(t, replayed[0].Changed())
+	assert.True(t, replayed[1].Changed())
+	assert.Equal(t, "new answer", replayed[1].Response)
+	assert.Equal(t, "echo", replayed[1].Model)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Empty(t, Replay(ctx, &echoModel{}, turns), "a cancelled replay sends nothing")
+}
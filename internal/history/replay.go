@@ -0,0 +1,51 @@
+package history
+
+import (
+	"context"
+	"time"
+
+	"github.com/dshills/sigil/internal/agent"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// Replayed is a recorded turn sent again to another model
+type Replayed struct {
+	Turn       agent.Turn    `json:"turn"`
+	Response   string        `json:"response,omitempty"`
+	Model      string        `json:"model,omitempty"`
+	TokensUsed int           `json:"tokens_used,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// Changed reports whether the replayed response differs from the recorded one
+func (r Replayed) Changed() bool {
+	return r.Error != "" || r.Response != r.Turn.Response
+}
+
+// Replay sends each recorded turn's request to mdl, in order, for comparing
+// its responses with the recorded ones. Turns are replayed as recorded, so
+// explore turns keep the tool results the original run saw. Replay stops
+// when ctx is done.
+func Replay(ctx context.Context, mdl model.Model, turns []agent.Turn) []Replayed {
+	replayed := make([]Replayed, 0, len(turns))
+	for _, turn := range turns {
+		if ctx.Err() != nil {
+			break
+		}
+		startTime := time.Now()
+		response, err := mdl.RunPrompt(ctx, turn.Input())
+		result := Replayed{
+			Turn:       turn,
+			Response:   response.Response,
+			Model:      response.Model,
+			TokensUsed: response.TokensUsed,
+			Duration:   time.Since(startTime),
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		replayed = append(replayed, result)
+	}
+	return replayed
+}
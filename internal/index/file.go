@@ -0,0 +1,175 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// FileStore keeps an index in a JSON file, read whole when it is opened
+// and written back by Save
+type FileStore struct {
+	path string
+	data fileIndex
+}
+
+// fileIndex is the layout of a file store's JSON
+type fileIndex struct {
+	EmbeddingModel string                `json:"embedding_model"`
+	Chunking       string                `json:"chunking"`
+	Files          map[string]*fileEntry `json:"files"`
+}
+
+// fileEntry holds the chunks of one file and the hash they were built from
+type fileEntry struct {
+	Hash   string  `json:"hash"`
+	Chunks []Chunk `json:"chunks"`
+}
+
+// OpenFileStore opens the index file at path. A missing file opens as an
+// empty store, as does a corrupt one, which the next save replaces.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, data: fileIndex{Files: make(map[string]*fileEntry)}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "OpenFileStore", "failed to read index")
+	}
+
+	var stored fileIndex
+	if err := json.Unmarshal(data, &stored); err != nil {
+		logger.Warn("rebuilding corrupt index", "path", path, "error", err)
+		return s, nil
+	}
+	if stored.Files == nil {
+		stored.Files = make(map[string]*fileEntry)
+	}
+	s.data = stored
+	return s, nil
+}
+
+// Name returns the backend name
+func (s *FileStore) Name() string {
+	return "file"
+}
+
+// Path returns the index file
+func (s *FileStore) Path() string {
+	return s.path
+}
+
+// Manifest returns what the index file holds
+func (s *FileStore) Manifest(_ context.Context) (Manifest, error) {
+	files := make(map[string]string, len(s.data.Files))
+	for path, entry := range s.data.Files {
+		files[path] = entry.Hash
+	}
+	return Manifest{EmbeddingModel: s.data.EmbeddingModel, Chunking: s.data.Chunking, Files: files}, nil
+}
+
+// Reset empties the index
+func (s *FileStore) Reset(_ context.Context, embeddingModel, chunking string) error {
+	s.data = fileIndex{EmbeddingModel: embeddingModel, Chunking: chunking, Files: make(map[string]*fileEntry)}
+	return nil
+}
+
+// Put replaces the chunks of files
+func (s *FileStore) Put(_ context.Context, hashes map[string]string, chunks []Chunk) error {
+	for path, hash := range hashes {
+		s.data.Files[path] = &fileEntry{Hash: hash}
+	}
+	for _, chunk := range chunks {
+		if entry, ok := s.data.Files[chunk.Path]; ok {
+			entry.Chunks = append(entry.Chunks, chunk)
+		}
+	}
+	return nil
+}
+
+// Delete removes files
+func (s *FileStore) Delete(_ context.Context, paths []string) error {
+	for _, path := range paths {
+		delete(s.data.Files, path)
+	}
+	return nil
+}
+
+// Search scores every chunk of paths against vector
+func (s *FileStore) Search(_ context.Context, vector []float32, k int, paths []string) ([]Hit, error) {
+	var hits []Hit
+	score := func(entry *fileEntry) {
+		for _, chunk := range entry.Chunks {
+			hits = append(hits, Hit{Chunk: chunk, Score: cosine(vector, chunk.Vector)})
+		}
+	}
+	if paths == nil {
+		for _, entry := range s.data.Files {
+			score(entry)
+		}
+	}
+	for _, path := range paths {
+		if entry, ok := s.data.Files[path]; ok {
+			score(entry)
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Path != hits[j].Path {
+			return hits[i].Path < hits[j].Path
+		}
+		return hits[i].StartLine < hits[j].StartLine
+	})
+	if k > 0 && len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits, nil
+}
+
+// Walk calls fn with each file in path order
+func (s *FileStore) Walk(_ context.Context, fn func(path, hash string, chunks []Chunk) error) error {
+	paths := make([]string, 0, len(s.data.Files))
+	for path := range s.data.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		entry := s.data.Files[path]
+		if err := fn(path, entry.Hash, entry.Chunks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save writes the index file
+func (s *FileStore) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Save", "failed to create index directory")
+	}
+
+	data, err := json.Marshal(s.data)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Save", "failed to encode index")
+	}
+
+	// Write through a temporary file so a failed save keeps the old index
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Save", "failed to write index")
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Save", "failed to replace index")
+	}
+	return nil
+}
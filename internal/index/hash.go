@@ -0,0 +1,122 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// DefaultHashDimensions is the vector size of the default hash embedder
+const DefaultHashDimensions = 512
+
+// HashEmbedder embeds text locally by hashing its identifiers and words into
+// a fixed number of dimensions. It needs no model, so retrieval works with
+// providers that offer no embeddings, at the cost of matching terms rather
+// than meaning.
+type HashEmbedder struct {
+	Dimensions int
+}
+
+// NewHashEmbedder creates a hash embedder with the default dimensions
+func NewHashEmbedder() *HashEmbedder {
+	return &HashEmbedder{Dimensions: DefaultHashDimensions}
+}
+
+// Embed returns a normalized term-hash vector per text
+func (h *HashEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, h.Dimensions)
+		for _, term := range terms(text) {
+			hasher := fnv.New64a()
+			hasher.Write([]byte(term))
+			sum := hasher.Sum64()
+
+			// The high bit picks a sign so unrelated terms tend to cancel
+			weight := float32(1)
+			if sum>>63 == 1 {
+				weight = -1
+			}
+			vector[sum%uint64(h.Dimensions)] += weight
+		}
+		normalize(vector)
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// EmbeddingModel identifies the hash embedder and its dimensions
+func (h *HashEmbedder) EmbeddingModel() string {
+	return fmt.Sprintf("hash:%d", h.Dimensions)
+}
+
+// terms splits text into lowercase words, also splitting identifiers at
+// camelCase and snake_case boundaries so "parseConfig" matches "config"
+func terms(text string) []string {
+	var result []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		lower := strings.ToLower(word)
+		if len(lower) > 1 {
+			result = append(result, lower)
+		}
+
+		parts := splitIdentifier(word)
+		if len(parts) > 1 {
+			for _, part := range parts {
+				if len(part) > 1 {
+					result = append(result, strings.ToLower(part))
+				}
+			}
+		}
+	}
+	return result
+}
+
+// splitIdentifier splits an identifier at underscores and case changes
+func splitIdentifier(word string) []string {
+	var parts []string
+	var current []rune
+	runes := []rune(word)
+	for i, r := range runes {
+		if r == '_' {
+			if len(current) > 0 {
+				parts = append(parts, string(current))
+			}
+			current = nil
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				parts = append(parts, string(current))
+				current = nil
+			}
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		parts = append(parts, string(current))
+	}
+	return parts
+}
+
+// normalize scales a vector to unit length
+func normalize(vector []float32) {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vector {
+		vector[i] /= norm
+	}
+}
@@ -0,0 +1,415 @@
+// Package index provides an embeddings index of repository files for
+// retrieving the code relevant to a question.
+package index
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dshills/sigil/internal/chunk"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+const (
+	// MaxFileSize is the largest file that is indexed
+	MaxFileSize = 256 * 1024
+
+	// embedBatchSize is the number of chunks embedded per request
+	embedBatchSize = 64
+)
+
+// skippedFiles are generated files with no value for retrieval
+var skippedFiles = map[string]bool{
+	"go.sum":            true,
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"Cargo.lock":        true,
+	"poetry.lock":       true,
+}
+
+// stateDir holds sigil's own state, relative to the repository root, which
+// is never indexed wherever the index itself is kept
+const stateDir = ".sigil/"
+
+// Skipped reports whether file, relative to the repository root, is never
+// indexed: sigil's own state and generated files
+func Skipped(file string) bool {
+	file = filepath.ToSlash(file)
+	return strings.HasPrefix(file, stateDir) || skippedFiles[path.Base(file)]
+}
+
+// Chunk is a range of lines from a file with its embedding
+type Chunk struct {
+	Path      string    `json:"path"`
+	StartLine int       `json:"start_line"`
+	EndLine   int       `json:"end_line"`
+	Content   string    `json:"content"`
+	Vector    []float32 `json:"vector"`
+}
+
+// Hit is a chunk matching a query with its cosine similarity
+type Hit struct {
+	Chunk
+	Score float64 `json:"score"`
+}
+
+// UpdateStats reports what an update changed
+type UpdateStats struct {
+	Indexed   int `json:"indexed"`
+	Unchanged int `json:"unchanged"`
+	Removed   int `json:"removed"`
+	Skipped   int `json:"skipped"`
+	Reused    int `json:"reused"`
+}
+
+// Index maps repository files to embedded chunks held in a vector store.
+// It is rebuilt from scratch when the embedding model or the chunking rules
+// change.
+type Index struct {
+	store    VectorStore
+	manifest Manifest
+	rules    chunk.Rules
+	vectors  map[string]map[string][]float32
+}
+
+// pendingUpdate collects the changes an update makes to the index
+type pendingUpdate struct {
+	hashes  map[string]string
+	chunks  []Chunk
+	removed []string
+}
+
+// DefaultPath returns the index location for a repository root
+func DefaultPath(root string) string {
+	return filepath.Join(root, ".sigil", "index", "embeddings.json")
+}
+
+// Open opens the index held in store for embeddingModel, chunking files by
+// rules. A store built with another embedding model or other rules is
+// emptied.
+func Open(ctx context.Context, store VectorStore, embeddingModel string, rules chunk.Rules) (*Index, error) {
+	manifest, err := store.Manifest(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "Open", fmt.Sprintf("failed to read the %s index", store.Name()))
+	}
+
+	chunking := rules.Fingerprint()
+	if manifest.EmbeddingModel != embeddingModel || manifest.Chunking != chunking {
+		switch {
+		case len(manifest.Files) == 0:
+		case manifest.EmbeddingModel != embeddingModel:
+			logger.Info("rebuilding index for new embedding model", "old", manifest.EmbeddingModel, "new", embeddingModel)
+		default:
+			logger.Info("rebuilding index for new chunking rules")
+		}
+		if err := store.Reset(ctx, embeddingModel, chunking); err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeFS, "Open", fmt.Sprintf("failed to reset the %s index", store.Name()))
+		}
+		manifest = Manifest{EmbeddingModel: embeddingModel, Chunking: chunking}
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]string)
+	}
+	return &Index{store: store, manifest: manifest, rules: rules, vectors: make(map[string]map[string][]float32)}, nil
+}
+
+// Load opens the index in the file at path. A missing or corrupt file
+// loads as empty.
+func Load(path, embeddingModel string, rules chunk.Rules) (*Index, error) {
+	store, err := OpenFileStore(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(context.Background(), store, embeddingModel, rules)
+}
+
+// Save persists changes the store holds back, such as a file store's
+// writes
+func (ix *Index) Save() error {
+	return ix.store.Save()
+}
+
+// Update brings the index in line with files, which are relative to root.
+// Unchanged files keep their embeddings, files no longer listed are dropped,
+// and binary, oversized and generated files and those under .sigil/ are
+// skipped.
+func (ix *Index) Update(ctx context.Context, root string, files []string, embedder model.Embedder) (UpdateStats, error) {
+	var stats UpdateStats
+	update := pendingUpdate{hashes: make(map[string]string)}
+	listed := make(map[string]bool, len(files))
+	own := ix.ownDir(root)
+
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		if strings.HasPrefix(file, stateDir) || own != "" && strings.HasPrefix(file, own) {
+			continue
+		}
+		listed[file] = true
+		ix.scan(root, file, &stats, &update)
+	}
+
+	for file := range ix.manifest.Files {
+		if !listed[file] {
+			update.removed = append(update.removed, file)
+			stats.Removed++
+		}
+	}
+	return ix.apply(ctx, root, embedder, stats, update)
+}
+
+// Refresh brings the given files, which are relative to root, up to date
+// and drops those that no longer exist, leaving the rest of the index as
+// it is. It suits a watcher that knows which files changed.
+func (ix *Index) Refresh(ctx context.Context, root string, files []string, embedder model.Embedder) (UpdateStats, error) {
+	var stats UpdateStats
+	update := pendingUpdate{hashes: make(map[string]string)}
+	own := ix.ownDir(root)
+
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		if strings.HasPrefix(file, stateDir) || own != "" && strings.HasPrefix(file, own) {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(root, file)); os.IsNotExist(err) {
+			if _, indexed := ix.manifest.Files[file]; indexed {
+				update.removed = append(update.removed, file)
+				stats.Removed++
+			}
+			continue
+		}
+		ix.scan(root, file, &stats, &update)
+	}
+	return ix.apply(ctx, root, embedder, stats, update)
+}
+
+// LoadVectors reads the embeddings the store holds so that later updates
+// re-embed only the chunks of a changed file whose content changed.
+// Without it, a changed file is embedded whole the first time.
+func (ix *Index) LoadVectors(ctx context.Context) error {
+	return ix.store.Walk(ctx, func(path, _ string, chunks []Chunk) error {
+		ix.remember(path, chunks)
+		return nil
+	})
+}
+
+// ownDir returns the directory a file store keeps the index in, relative to
+// root, so the index never indexes itself
+func (ix *Index) ownDir(root string) string {
+	fileStore, ok := ix.store.(*FileStore)
+	if !ok {
+		return ""
+	}
+	rel, err := filepath.Rel(root, filepath.Dir(fileStore.Path()))
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel) + "/"
+}
+
+// scan adds file to update if it changed since it was indexed
+func (ix *Index) scan(root, file string, stats *UpdateStats, update *pendingUpdate) {
+	content, ok := readIndexable(filepath.Join(root, file))
+	if !ok {
+		if _, indexed := ix.manifest.Files[file]; indexed {
+			update.removed = append(update.removed, file)
+		}
+		stats.Skipped++
+		return
+	}
+
+	hash := HashContent(content)
+	if ix.manifest.Files[file] == hash {
+		stats.Unchanged++
+		return
+	}
+
+	update.hashes[file] = hash
+	for _, piece := range ix.rules.IndexChunks(file, string(content)) {
+		update.chunks = append(update.chunks, Chunk{Path: file, StartLine: piece.StartLine, EndLine: piece.EndLine, Content: piece.Content})
+	}
+}
+
+// apply embeds the chunks of an update to files under root, reusing the
+// embeddings of chunks whose content did not change, and writes the update
+// to the store
+func (ix *Index) apply(ctx context.Context, root string, embedder model.Embedder, stats UpdateStats, update pendingUpdate) (UpdateStats, error) {
+	var missing []Chunk
+	var positions []int
+	for i, chunk := range update.chunks {
+		if vector, ok := ix.vectors[chunk.Path][HashContent([]byte(chunk.Content))]; ok {
+			update.chunks[i].Vector = vector
+			stats.Reused++
+			continue
+		}
+		missing = append(missing, chunk)
+		positions = append(positions, i)
+	}
+	if err := embedChunks(ctx, root, embedder, missing); err != nil {
+		return stats, err
+	}
+	for i, position := range positions {
+		update.chunks[position].Vector = missing[i].Vector
+	}
+
+	if len(update.removed) > 0 {
+		sort.Strings(update.removed)
+		if err := ix.store.Delete(ctx, update.removed); err != nil {
+			return stats, errors.Wrap(err, errors.ErrorTypeFS, "apply", "failed to remove files from the index")
+		}
+		for _, file := range update.removed {
+			delete(ix.manifest.Files, file)
+			delete(ix.vectors, file)
+		}
+	}
+	if len(update.hashes) > 0 {
+		if err := ix.store.Put(ctx, update.hashes, update.chunks); err != nil {
+			return stats, errors.Wrap(err, errors.ErrorTypeFS, "apply", "failed to store embedded chunks")
+		}
+		for file, hash := range update.hashes {
+			ix.manifest.Files[file] = hash
+			delete(ix.vectors, file)
+		}
+		for _, chunk := range update.chunks {
+			ix.remember(chunk.Path, []Chunk{chunk})
+		}
+	}
+	stats.Indexed = len(update.hashes)
+
+	logger.Debug("index updated", "indexed", stats.Indexed, "unchanged", stats.Unchanged, "removed", stats.Removed,
+		"skipped", stats.Skipped, "chunks", len(update.chunks), "reused", stats.Reused)
+	return stats, nil
+}
+
+// remember keeps the embeddings of a file's chunks by their content
+func (ix *Index) remember(path string, chunks []Chunk) {
+	vectors, ok := ix.vectors[path]
+	if !ok {
+		vectors = make(map[string][]float32)
+		ix.vectors[path] = vectors
+	}
+	for _, chunk := range chunks {
+		if len(chunk.Vector) > 0 {
+			vectors[HashContent([]byte(chunk.Content))] = chunk.Vector
+		}
+	}
+}
+
+// Search returns the k chunks most similar to query among the files
+// accepted by scope; a nil scope accepts every file
+func (ix *Index) Search(ctx context.Context, embedder model.Embedder, query string, k int, scope func(path string) bool) ([]Hit, error) {
+	vectors, err := embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeModel, "Search", "failed to embed query")
+	}
+	if len(vectors) != 1 {
+		return nil, errors.New(errors.ErrorTypeModel, "Search", "embedder returned no vector for the query")
+	}
+
+	var paths []string
+	if scope != nil {
+		paths = []string{}
+		for path := range ix.manifest.Files {
+			if scope(path) {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+	}
+
+	hits, err := ix.store.Search(ctx, vectors[0], k, paths)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "Search", fmt.Sprintf("failed to search the %s index", ix.store.Name()))
+	}
+	return hits, nil
+}
+
+// Len returns the number of indexed files
+func (ix *Index) Len() int {
+	return len(ix.manifest.Files)
+}
+
+// embedChunks fills in chunk vectors in batches, each marked with the files
+// under root its chunks come from
+func embedChunks(ctx context.Context, root string, embedder model.Embedder, chunks []Chunk) error {
+	for start := 0; start < len(chunks); start += embedBatchSize {
+		batch := chunks[start:min(start+embedBatchSize, len(chunks))]
+
+		texts := make([]string, len(batch))
+		var paths []string
+		for i, chunk := range batch {
+			texts[i] = chunk.Path + "\n" + chunk.Content
+			if i == 0 || chunk.Path != batch[i-1].Path {
+				paths = append(paths, filepath.Join(root, chunk.Path))
+			}
+		}
+
+		vectors, err := embedder.Embed(model.WithContentSources(ctx, paths), texts)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrorTypeModel, "embedChunks", "failed to embed chunks")
+		}
+		if len(vectors) != len(batch) {
+			return errors.New(errors.ErrorTypeModel, "embedChunks",
+				fmt.Sprintf("expected %d embeddings, got %d", len(batch), len(vectors)))
+		}
+		for i := range batch {
+			batch[i].Vector = vectors[i]
+		}
+	}
+	return nil
+}
+
+// readIndexable returns a file's content if it is a reasonably sized text file
+func readIndexable(path string) ([]byte, bool) {
+	if skippedFiles[filepath.Base(path)] {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > MaxFileSize {
+		return nil, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	// Treat a NUL byte near the start as a sign of binary content
+	if bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+		return nil, false
+	}
+	return content, true
+}
+
+// HashContent returns a digest identifying file content, by which indexes
+// and generated artifacts tell whether a file changed since they were built
+func HashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// cosine returns the cosine similarity of two vectors
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,188 @@
+package index
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/chunk"
+)
+
+// countingEmbedder wraps the hash embedder and counts embedded texts
+type countingEmbedder struct {
+	*HashEmbedder
+	texts int
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	c.texts += len(texts)
+	return c.HashEmbedder.Embed(ctx, texts)
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+	}
+}
+
+func TestIndex_UpdateAndSearch(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"auth/token.go":  "package auth\n\n// ValidateToken checks a bearer token signature\nfunc ValidateToken(token string) error { return nil }\n",
+		"store/cache.go": "package store\n\n// Cache keeps recently used rows in memory\ntype Cache struct{}\n",
+		"go.sum":         "example.com/dep v1.0.0 h1:abc\n",
+		"logo.png":       "\x89PNG\x00\x00binary",
+	})
+	files := []string{"auth/token.go", "store/cache.go", "go.sum", "logo.png"}
+	embedder := &countingEmbedder{HashEmbedder: NewHashEmbedder()}
+
+	ix, err := Load(DefaultPath(root), embedder.EmbeddingModel(), chunk.DefaultRules())
+	require.NoError(t, err)
+
+	stats, err := ix.Update(context.Background(), root, files, embedder)
+	require.NoError(t, err)
+	assert.Equal(t, UpdateStats{Indexed: 2, Skipped: 2}, stats)
+
+	hits, err := ix.Search(context.Background(), embedder, "where is the bearer token validated?", 1, nil)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "auth/token.go", hits[0].Path)
+	assert.Equal(t, 1, hits[0].StartLine)
+	assert.Greater(t, hits[0].Score, 0.0)
+
+	// Scope limits the candidate files
+	hits, err = ix.Search(context.Background(), embedder, "bearer token", 5, func(path string) bool {
+		return strings.HasPrefix(path, "store/")
+	})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "store/cache.go", hits[0].Path)
+
+	// Saved indexes reload, and unchanged files are not embedded again
+	require.NoError(t, ix.Save())
+	reloaded, err := Load(DefaultPath(root), embedder.EmbeddingModel(), chunk.DefaultRules())
+	require.NoError(t, err)
+	assert.Equal(t, 2, reloaded.Len())
+
+	embedder.texts = 0
+	writeFiles(t, root, map[string]string{"store/cache.go": "package store\n\ntype LRU struct{}\n"})
+	stats, err = reloaded.Update(context.Background(), root, []string{"store/cache.go", ".sigil/index/embeddings.json"}, embedder)
+	require.NoError(t, err)
+	assert.Equal(t, UpdateStats{Indexed: 1, Removed: 1}, stats)
+	assert.Equal(t, 1, embedder.texts)
+}
+
+func TestIndex_Refresh(t *testing.T) {
+	root := t.TempDir()
+	var long strings.Builder
+	for i := range 60 {
+		long.WriteString("// line " + strings.Repeat("x", i) + "\n")
+	}
+	writeFiles(t, root, map[string]string{"long.go": long.String(), "old.go": "package old\n", "keep.go": "package keep\n"})
+	embedder := &countingEmbedder{HashEmbedder: NewHashEmbedder()}
+
+	ix, err := Load(DefaultPath(root), embedder.EmbeddingModel(), chunk.DefaultRules())
+	require.NoError(t, err)
+	_, err = ix.Update(context.Background(), root, []string{"long.go", "old.go", "keep.go"}, embedder)
+	require.NoError(t, err)
+	require.NoError(t, ix.Save())
+
+	// A reopened index reuses the embeddings it loads
+	reopened, err := Load(DefaultPath(root), embedder.EmbeddingModel(), chunk.DefaultRules())
+	require.NoError(t, err)
+	require.NoError(t, reopened.LoadVectors(context.Background()))
+
+	embedder.texts = 0
+	writeFiles(t, root, map[string]string{"long.go": long.String() + "// appended\n"})
+	require.NoError(t, os.Remove(filepath.Join(root, "old.go")))
+	stats, err := reopened.Refresh(context.Background(), root, []string{"long.go", "old.go", "gone.go"}, embedder)
+	require.NoError(t, err)
+	assert.Equal(t, UpdateStats{Indexed: 1, Removed: 1, Reused: 1}, stats, "only the chunk holding the new line is embedded")
+	assert.Equal(t, 1, embedder.texts)
+	assert.Equal(t, 2, reopened.Len(), "files not refreshed are kept")
+
+	hits, err := reopened.Search(context.Background(), embedder, "appended", 1, nil)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "long.go", hits[0].Path)
+	assert.Contains(t, hits[0].Content, "// appended")
+}
+
+func TestIndex_SkipsSigilState(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":                  "package main\n",
+		".sigil/config.yml":        "models:\n  lead: openai:gpt-4\n",
+		".sigil/history/task.json": "{\"task_id\": \"task\"}\n",
+	})
+	embedder := NewHashEmbedder()
+
+	// An index kept outside root does not cover .sigil/ through its own path
+	ix, err := Load(filepath.Join(t.TempDir(), "embeddings.json"), embedder.EmbeddingModel(), chunk.DefaultRules())
+	require.NoError(t, err)
+
+	stats, err := ix.Update(context.Background(), root, []string{"main.go", ".sigil/config.yml", ".sigil/history/task.json"}, embedder)
+	require.NoError(t, err)
+	assert.Equal(t, UpdateStats{Indexed: 1}, stats)
+
+	stats, err = ix.Refresh(context.Background(), root, []string{".sigil/config.yml"}, embedder)
+	require.NoError(t, err)
+	assert.Equal(t, UpdateStats{}, stats)
+	assert.Equal(t, 1, ix.Len())
+
+	assert.True(t, Skipped(".sigil/cache/entry"))
+	assert.True(t, Skipped("web/package-lock.json"))
+	assert.False(t, Skipped("docs/.sigil.md"))
+}
+
+func TestLoad_OtherEmbeddingModel(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"main.go": "package main\n"})
+
+	ix, err := Load(DefaultPath(root), "hash:512", chunk.DefaultRules())
+	require.NoError(t, err)
+	_, err = ix.Update(context.Background(), root, []string{"main.go"}, NewHashEmbedder())
+	require.NoError(t, err)
+	require.NoError(t, ix.Save())
+
+	other, err := Load(DefaultPath(root), "openai:text-embedding-3-small", chunk.DefaultRules())
+	require.NoError(t, err)
+	assert.Zero(t, other.Len(), "vectors from another model are not comparable")
+
+	rules := chunk.DefaultRules()
+	rules.Index.Size = 80
+	rechunked, err := Load(DefaultPath(root), "hash:512", rules)
+	require.NoError(t, err)
+	assert.Zero(t, rechunked.Len(), "chunks from other rules are rebuilt")
+
+	same, err := Load(DefaultPath(root), "hash:512", chunk.DefaultRules())
+	require.NoError(t, err)
+	assert.Equal(t, 1, same.Len())
+}
+
+func TestHashEmbedder(t *testing.T) {
+	embedder := NewHashEmbedder()
+
+	vectors, err := embedder.Embed(context.Background(), []string{"parseConfigFile", "parse the config file", "render HTML template"})
+	require.NoError(t, err)
+	require.Len(t, vectors, 3)
+	assert.Len(t, vectors[0], DefaultHashDimensions)
+
+	assert.Greater(t, cosine(vectors[0], vectors[1]), cosine(vectors[0], vectors[2]),
+		"identifiers match the words they are made of")
+	assert.InDelta(t, 1, cosine(vectors[1], vectors[1]), 1e-6)
+}
+
+func TestSplitIdentifier(t *testing.T) {
+	assert.Equal(t, []string{"parse", "Config", "File"}, splitIdentifier("parseConfigFile"))
+	assert.Equal(t, []string{"HTTP", "Server"}, splitIdentifier("HTTPServer"))
+	assert.Equal(t, []string{"max", "retries"}, splitIdentifier("max_retries"))
+}
@@ -0,0 +1,359 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// qdrantPageSize is the number of points written or read per request
+const qdrantPageSize = 256
+
+// QdrantConfig configures a Qdrant store
+type QdrantConfig struct {
+	// Base URL of the Qdrant REST API
+	URL string
+
+	// Collection holding the index
+	Collection string
+
+	// API key, if the server requires one
+	APIKey string
+
+	// Request timeout
+	Timeout time.Duration
+}
+
+// QdrantStore keeps an index in a Qdrant collection, one point per chunk,
+// so a team can share it. Each point's payload names the file, content
+// hash, embedding model and chunking rules of its chunk. The collection is
+// created on the first write, sized to its vectors.
+type QdrantStore struct {
+	config         QdrantConfig
+	client         *http.Client
+	embeddingModel string
+	chunking       string
+	size           int
+}
+
+// qdrantPayload is the payload of a chunk's point
+type qdrantPayload struct {
+	Path           string `json:"path"`
+	Hash           string `json:"hash"`
+	StartLine      int    `json:"start_line"`
+	EndLine        int    `json:"end_line"`
+	Content        string `json:"content,omitempty"`
+	EmbeddingModel string `json:"embedding_model"`
+	Chunking       string `json:"chunking"`
+}
+
+// qdrantPoint is a point as Qdrant returns it
+type qdrantPoint struct {
+	ID      json.RawMessage `json:"id"`
+	Score   float64         `json:"score"`
+	Payload qdrantPayload   `json:"payload"`
+	Vector  []float32       `json:"vector"`
+}
+
+// NewQdrantStore creates a store for a Qdrant collection
+func NewQdrantStore(cfg QdrantConfig) *QdrantStore {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+
+	return &QdrantStore{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Name returns the backend name
+func (s *QdrantStore) Name() string {
+	return "qdrant"
+}
+
+// Manifest reads the files the collection holds chunks for. A missing
+// collection is an empty store.
+func (s *QdrantStore) Manifest(ctx context.Context) (Manifest, error) {
+	manifest := Manifest{Files: make(map[string]string)}
+	err := s.scroll(ctx, false, func(point qdrantPoint) {
+		if len(manifest.Files) == 0 {
+			manifest.EmbeddingModel = point.Payload.EmbeddingModel
+			manifest.Chunking = point.Payload.Chunking
+		}
+		manifest.Files[point.Payload.Path] = point.Payload.Hash
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+	s.embeddingModel, s.chunking = manifest.EmbeddingModel, manifest.Chunking
+	return manifest, nil
+}
+
+// Reset drops the collection; the next write creates it again
+func (s *QdrantStore) Reset(ctx context.Context, embeddingModel, chunking string) error {
+	if _, err := s.call(ctx, http.MethodDelete, "", nil, nil); err != nil {
+		return err
+	}
+	s.embeddingModel, s.chunking, s.size = embeddingModel, chunking, 0
+	return nil
+}
+
+// Put replaces the points of files
+func (s *QdrantStore) Put(ctx context.Context, hashes map[string]string, chunks []Chunk) error {
+	paths := make([]string, 0, len(hashes))
+	for path := range hashes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	if err := s.Delete(ctx, paths); err != nil {
+		return err
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	if err := s.ensureCollection(ctx, len(chunks[0].Vector)); err != nil {
+		return err
+	}
+
+	type point struct {
+		ID      string        `json:"id"`
+		Vector  []float32     `json:"vector"`
+		Payload qdrantPayload `json:"payload"`
+	}
+	for start := 0; start < len(chunks); start += qdrantPageSize {
+		batch := chunks[start:min(start+qdrantPageSize, len(chunks))]
+		points := make([]point, len(batch))
+		for i, chunk := range batch {
+			points[i] = point{
+				ID:     pointID(chunk.Path, chunk.StartLine),
+				Vector: chunk.Vector,
+				Payload: qdrantPayload{Path: chunk.Path, Hash: hashes[chunk.Path], StartLine: chunk.StartLine,
+					EndLine: chunk.EndLine, Content: chunk.Content, EmbeddingModel: s.embeddingModel, Chunking: s.chunking},
+			}
+		}
+		if _, err := s.call(ctx, http.MethodPut, "/points?wait=true", map[string]any{"points": points}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes the points of files
+func (s *QdrantStore) Delete(ctx context.Context, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	_, err := s.call(ctx, http.MethodPost, "/points/delete?wait=true", map[string]any{"filter": pathFilter(paths)}, nil)
+	return err
+}
+
+// Search asks Qdrant for the points nearest vector
+func (s *QdrantStore) Search(ctx context.Context, vector []float32, k int, paths []string) ([]Hit, error) {
+	if paths != nil && len(paths) == 0 {
+		return nil, nil
+	}
+	if k <= 0 {
+		k = qdrantPageSize
+	}
+
+	request := map[string]any{"vector": vector, "limit": k, "with_payload": true}
+	if paths != nil {
+		request["filter"] = pathFilter(paths)
+	}
+	var points []qdrantPoint
+	found, err := s.call(ctx, http.MethodPost, "/points/search", request, &points)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	hits := make([]Hit, len(points))
+	for i, point := range points {
+		hits[i] = Hit{Chunk: point.chunk(), Score: point.Score}
+	}
+	return hits, nil
+}
+
+// Walk reads every point of the collection, then calls fn with each file
+// in path order
+func (s *QdrantStore) Walk(ctx context.Context, fn func(path, hash string, chunks []Chunk) error) error {
+	hashes := make(map[string]string)
+	files := make(map[string][]Chunk)
+	err := s.scroll(ctx, true, func(point qdrantPoint) {
+		hashes[point.Payload.Path] = point.Payload.Hash
+		files[point.Payload.Path] = append(files[point.Payload.Path], point.chunk())
+	})
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		chunks := files[path]
+		sort.Slice(chunks, func(i, j int) bool { return chunks[i].StartLine < chunks[j].StartLine })
+		if err := fn(path, hashes[path], chunks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save does nothing, as writes go to the server as they are made
+func (s *QdrantStore) Save() error {
+	return nil
+}
+
+// chunk returns the chunk a point holds
+func (p qdrantPoint) chunk() Chunk {
+	return Chunk{Path: p.Payload.Path, StartLine: p.Payload.StartLine, EndLine: p.Payload.EndLine,
+		Content: p.Payload.Content, Vector: p.Vector}
+}
+
+// scroll passes every point of the collection to fn, with its vector when
+// withVector is set. A missing collection has no points.
+func (s *QdrantStore) scroll(ctx context.Context, withVector bool, fn func(point qdrantPoint)) error {
+	var payload any = true
+	if !withVector {
+		payload = []string{"path", "hash", "embedding_model", "chunking"}
+	}
+
+	var offset json.RawMessage
+	for {
+		request := map[string]any{"limit": qdrantPageSize, "with_payload": payload, "with_vector": withVector}
+		if len(offset) > 0 {
+			request["offset"] = offset
+		}
+		var page struct {
+			Points         []qdrantPoint   `json:"points"`
+			NextPageOffset json.RawMessage `json:"next_page_offset"`
+		}
+		found, err := s.call(ctx, http.MethodPost, "/points/scroll", request, &page)
+		if err != nil || !found {
+			return err
+		}
+		for _, point := range page.Points {
+			fn(point)
+		}
+		if len(page.NextPageOffset) == 0 || string(page.NextPageOffset) == "null" {
+			return nil
+		}
+		offset = page.NextPageOffset
+	}
+}
+
+// ensureCollection creates the collection for vectors of size unless it
+// exists, failing if it holds vectors of another size
+func (s *QdrantStore) ensureCollection(ctx context.Context, size int) error {
+	if s.size == size {
+		return nil
+	}
+
+	var info struct {
+		Config struct {
+			Params struct {
+				Vectors struct {
+					Size int `json:"size"`
+				} `json:"vectors"`
+			} `json:"params"`
+		} `json:"config"`
+	}
+	found, err := s.call(ctx, http.MethodGet, "", nil, &info)
+	if err != nil {
+		return err
+	}
+	if found {
+		if existing := info.Config.Params.Vectors.Size; existing != size {
+			return errors.New(errors.ErrorTypeConfig, "ensureCollection",
+				fmt.Sprintf("qdrant collection %s holds vectors of size %d, not %d", s.config.Collection, existing, size)).
+				WithHint("use another collection for this embedding model")
+		}
+	} else {
+		create := map[string]any{"vectors": map[string]any{"size": size, "distance": "Cosine"}}
+		if _, err := s.call(ctx, http.MethodPut, "", create, nil); err != nil {
+			return err
+		}
+	}
+	s.size = size
+	return nil
+}
+
+// call sends a request to the collection's endpoint at path, decoding the
+// response's result into result. found is false when the collection does
+// not exist.
+func (s *QdrantStore) call(ctx context.Context, method, path string, body, result any) (found bool, err error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return false, errors.Wrap(err, errors.ErrorTypeInternal, "call", "failed to encode qdrant request")
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	endpoint := s.config.URL + "/collections/" + url.PathEscape(s.config.Collection) + path
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return false, errors.Wrap(err, errors.ErrorTypeNetwork, "call", "failed to create qdrant request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.APIKey != "" {
+		req.Header.Set("api-key", s.config.APIKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, errors.Wrap(err, errors.ErrorTypeNetwork, "call", "qdrant request failed")
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode >= 300:
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return false, errors.New(errors.ErrorTypeNetwork, "call",
+			fmt.Sprintf("qdrant returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet))))
+	}
+
+	if result != nil {
+		var envelope struct {
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+			return false, errors.Wrap(err, errors.ErrorTypeNetwork, "call", "failed to decode qdrant response")
+		}
+		if err := json.Unmarshal(envelope.Result, result); err != nil {
+			return false, errors.Wrap(err, errors.ErrorTypeNetwork, "call", "failed to decode qdrant result")
+		}
+	}
+	return true, nil
+}
+
+// pathFilter matches the points of any of paths
+func pathFilter(paths []string) map[string]any {
+	return map[string]any{"must": []any{map[string]any{"key": "path", "match": map[string]any{"any": paths}}}}
+}
+
+// pointID derives a stable point ID, in the UUID form Qdrant accepts, from
+// a chunk's file and first line
+func pointID(path string, startLine int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", path, startLine)))
+	id := hex.EncodeToString(sum[:16])
+	return id[0:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:32]
+}
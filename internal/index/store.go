@@ -0,0 +1,139 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// migrateBatchSize is the number of chunks copied per write when migrating
+// an index between stores
+const migrateBatchSize = 256
+
+// VectorStore holds the embedded chunks of an index and searches them by
+// similarity
+type VectorStore interface {
+	// Manifest returns the embedding model and chunking rules the store
+	// was built with and the hash of each file it holds chunks for
+	Manifest(ctx context.Context) (Manifest, error)
+
+	// Reset empties the store for a new embedding model and chunking rules
+	Reset(ctx context.Context, embeddingModel, chunking string) error
+
+	// Put replaces the chunks of the files in hashes, which maps each file
+	// to the hash of the content its chunks were made from
+	Put(ctx context.Context, hashes map[string]string, chunks []Chunk) error
+
+	// Delete removes files and their chunks; unknown files are ignored
+	Delete(ctx context.Context, paths []string) error
+
+	// Search returns the k chunks most similar to vector, best first,
+	// among the chunks of paths; nil paths searches every file
+	Search(ctx context.Context, vector []float32, k int, paths []string) ([]Hit, error)
+
+	// Walk calls fn with each file, the hash of its content and its
+	// chunks, vectors included
+	Walk(ctx context.Context, fn func(path, hash string, chunks []Chunk) error) error
+
+	// Save persists writes the store holds back
+	Save() error
+
+	// Name returns the backend name
+	Name() string
+}
+
+// Manifest describes what a vector store holds
+type Manifest struct {
+	EmbeddingModel string
+	Chunking       string
+	Files          map[string]string
+}
+
+// NewStore creates the vector store configured for the repository at root
+func NewStore(cfg config.IndexConfig, root string) (VectorStore, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", "file":
+		path := cfg.Path
+		switch {
+		case path == "":
+			path = DefaultPath(root)
+		case !filepath.IsAbs(path):
+			path = filepath.Join(root, path)
+		}
+		return OpenFileStore(path)
+	case "qdrant":
+		if cfg.URL == "" {
+			return nil, errors.ConfigError("NewStore", "index url is required for the qdrant backend")
+		}
+		collection := cfg.Collection
+		if collection == "" {
+			collection = DefaultCollection(root)
+		}
+		logger.Debug("initialized index backend", "backend", "qdrant", "url", cfg.URL, "collection", collection)
+		return NewQdrantStore(QdrantConfig{URL: cfg.URL, Collection: collection, APIKey: config.EnvValue(cfg.TokenEnv)}), nil
+	default:
+		return nil, errors.ConfigError("NewStore", fmt.Sprintf("unsupported index backend: %s", cfg.Backend)).
+			WithHint("supported backends are file and qdrant")
+	}
+}
+
+var unsafeCollectionChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// DefaultCollection returns the collection a shared backend keeps the
+// index of the repository at root in, named after the repository
+func DefaultCollection(root string) string {
+	name := strings.Trim(unsafeCollectionChars.ReplaceAllString(filepath.Base(root), "-"), "-")
+	if name == "" {
+		name = "default"
+	}
+	return "sigil-" + name
+}
+
+// Migrate copies the index held in from to to, replacing what to held. It
+// returns the number of files and chunks copied.
+func Migrate(ctx context.Context, from, to VectorStore) (files, chunks int, err error) {
+	manifest, err := from.Manifest(ctx)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, errors.ErrorTypeFS, "Migrate", fmt.Sprintf("failed to read the %s index", from.Name()))
+	}
+	if err := to.Reset(ctx, manifest.EmbeddingModel, manifest.Chunking); err != nil {
+		return 0, 0, errors.Wrap(err, errors.ErrorTypeFS, "Migrate", fmt.Sprintf("failed to reset the %s index", to.Name()))
+	}
+
+	hashes := make(map[string]string)
+	var batch []Chunk
+	flush := func() error {
+		if len(hashes) == 0 {
+			return nil
+		}
+		if err := to.Put(ctx, hashes, batch); err != nil {
+			return errors.Wrap(err, errors.ErrorTypeFS, "Migrate", fmt.Sprintf("failed to write the %s index", to.Name()))
+		}
+		files += len(hashes)
+		chunks += len(batch)
+		hashes, batch = make(map[string]string), nil
+		return nil
+	}
+
+	err = from.Walk(ctx, func(path, hash string, fileChunks []Chunk) error {
+		hashes[path] = hash
+		batch = append(batch, fileChunks...)
+		if len(batch) >= migrateBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err == nil {
+		err = to.Save()
+	}
+	return files, chunks, err
+}
@@ -0,0 +1,224 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/chunk"
+	"github.com/dshills/sigil/internal/config"
+)
+
+// fakeQdrant serves the parts of the Qdrant REST API the store uses, for
+// one collection held in memory
+type fakeQdrant struct {
+	mu     sync.Mutex
+	size   int
+	points map[string]qdrantPoint
+	keys   []string
+}
+
+func (f *fakeQdrant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = append(f.keys, r.Header.Get("api-key"))
+
+	var body struct {
+		Vectors struct {
+			Size int `json:"size"`
+		} `json:"vectors"`
+		Points []struct {
+			ID      string        `json:"id"`
+			Vector  []float32     `json:"vector"`
+			Payload qdrantPayload `json:"payload"`
+		} `json:"points"`
+		Filter struct {
+			Must []struct {
+				Match struct {
+					Any []string `json:"any"`
+				} `json:"match"`
+			} `json:"must"`
+		} `json:"filter"`
+		Vector     []float32 `json:"vector"`
+		Limit      int       `json:"limit"`
+		WithVector bool      `json:"with_vector"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	matches := func(point qdrantPoint) bool {
+		return len(body.Filter.Must) == 0 || slices.Contains(body.Filter.Must[0].Match.Any, point.Payload.Path)
+	}
+	respond := func(result any) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": result, "status": "ok"})
+	}
+
+	action := strings.TrimPrefix(r.URL.Path, "/collections/sigil-repo")
+	if f.points == nil && !(action == "" && r.Method == http.MethodPut) {
+		http.Error(w, `{"status":{"error":"Not found: Collection"}}`, http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		respond(map[string]any{"config": map[string]any{"params": map[string]any{"vectors": map[string]any{"size": f.size}}}})
+	case action == "" && r.Method == http.MethodPut:
+		f.size, f.points = body.Vectors.Size, make(map[string]qdrantPoint)
+		respond(true)
+	case action == "" && r.Method == http.MethodDelete:
+		f.size, f.points = 0, nil
+		respond(true)
+	case action == "/points":
+		for _, point := range body.Points {
+			f.points[point.ID] = qdrantPoint{Vector: point.Vector, Payload: point.Payload}
+		}
+		respond(map[string]any{"status": "completed"})
+	case action == "/points/delete":
+		for id, point := range f.points {
+			if matches(point) {
+				delete(f.points, id)
+			}
+		}
+		respond(map[string]any{"status": "completed"})
+	case action == "/points/search":
+		var hits []qdrantPoint
+		for _, point := range f.points {
+			if matches(point) {
+				hits = append(hits, qdrantPoint{Score: cosine(body.Vector, point.Vector), Payload: point.Payload})
+			}
+		}
+		sort.Slice(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+		respond(hits[:min(body.Limit, len(hits))])
+	case action == "/points/scroll":
+		var points []qdrantPoint
+		for _, point := range f.points {
+			if !body.WithVector {
+				point.Vector = nil
+				point.Payload.Content = ""
+			}
+			points = append(points, point)
+		}
+		respond(map[string]any{"points": points, "next_page_offset": nil})
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func TestQdrantStore(t *testing.T) {
+	fake := &fakeQdrant{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	root := filepath.Join(t.TempDir(), "repo")
+	writeFiles(t, root, map[string]string{
+		"auth/token.go":  "package auth\n\n// ValidateToken checks a bearer token signature\nfunc ValidateToken(token string) error { return nil }\n",
+		"store/cache.go": "package store\n\n// Cache keeps recently used rows in memory\ntype Cache struct{}\n",
+	})
+	t.Setenv("QDRANT_TEST_KEY", "secret")
+	store, err := NewStore(config.IndexConfig{Backend: "qdrant", URL: server.URL + "/", TokenEnv: "QDRANT_TEST_KEY"}, root)
+	require.NoError(t, err)
+	embedder := NewHashEmbedder()
+
+	ix, err := Open(context.Background(), store, embedder.EmbeddingModel(), chunk.DefaultRules())
+	require.NoError(t, err)
+	stats, err := ix.Update(context.Background(), root, []string{"auth/token.go", "store/cache.go"}, embedder)
+	require.NoError(t, err)
+	assert.Equal(t, UpdateStats{Indexed: 2}, stats)
+	assert.Equal(t, DefaultHashDimensions, fake.size, "the collection is sized to the vectors")
+
+	hits, err := ix.Search(context.Background(), embedder, "where is the bearer token validated?", 1, nil)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "auth/token.go", hits[0].Path)
+	assert.Contains(t, hits[0].Content, "ValidateToken")
+
+	hits, err = ix.Search(context.Background(), embedder, "bearer token", 5, func(path string) bool {
+		return strings.HasPrefix(path, "store/")
+	})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "store/cache.go", hits[0].Path)
+
+	// Another checkout sees the shared index and only embeds what changed
+	reopened, err := Open(context.Background(), NewQdrantStore(QdrantConfig{URL: server.URL, Collection: "sigil-repo"}),
+		embedder.EmbeddingModel(), chunk.DefaultRules())
+	require.NoError(t, err)
+	assert.Equal(t, 2, reopened.Len())
+	writeFiles(t, root, map[string]string{"store/cache.go": "package store\n\ntype LRU struct{}\n"})
+	stats, err = reopened.Update(context.Background(), root, []string{"store/cache.go"}, embedder)
+	require.NoError(t, err)
+	assert.Equal(t, UpdateStats{Indexed: 1, Removed: 1}, stats)
+	assert.Len(t, fake.points, 1)
+
+	// Another embedding model starts over
+	other, err := Open(context.Background(), store, "openai:text-embedding-3-small", chunk.DefaultRules())
+	require.NoError(t, err)
+	assert.Zero(t, other.Len())
+	assert.Nil(t, fake.points)
+	assert.Equal(t, "secret", fake.keys[0])
+}
+
+func TestMigrate(t *testing.T) {
+	fake := &fakeQdrant{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go": "package main\n\nfunc main() {}\n",
+		"util.go": "package main\n\n// clamp limits n to max\nfunc clamp(n, max int) int { return min(n, max) }\n",
+	})
+	embedder := NewHashEmbedder()
+	ix, err := Load(DefaultPath(root), embedder.EmbeddingModel(), chunk.DefaultRules())
+	require.NoError(t, err)
+	_, err = ix.Update(context.Background(), root, []string{"main.go", "util.go"}, embedder)
+	require.NoError(t, err)
+	require.NoError(t, ix.Save())
+
+	from, err := NewStore(config.IndexConfig{}, root)
+	require.NoError(t, err)
+	qdrant := NewQdrantStore(QdrantConfig{URL: server.URL, Collection: "sigil-repo"})
+	files, chunks, err := Migrate(context.Background(), from, qdrant)
+	require.NoError(t, err)
+	assert.Equal(t, 2, files)
+	assert.Equal(t, 2, chunks)
+
+	migrated, err := Open(context.Background(), qdrant, embedder.EmbeddingModel(), chunk.DefaultRules())
+	require.NoError(t, err)
+	assert.Equal(t, 2, migrated.Len(), "the manifest moves with the chunks")
+	hits, err := migrated.Search(context.Background(), embedder, "clamp limits", 1, nil)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "util.go", hits[0].Path)
+
+	// And back again, into a fresh file
+	back, err := OpenFileStore(filepath.Join(t.TempDir(), "index.json"))
+	require.NoError(t, err)
+	files, _, err = Migrate(context.Background(), qdrant, back)
+	require.NoError(t, err)
+	assert.Equal(t, 2, files)
+	reloaded, err := Load(back.Path(), embedder.EmbeddingModel(), chunk.DefaultRules())
+	require.NoError(t, err)
+	assert.Equal(t, 2, reloaded.Len())
+}
+
+func TestNewStore(t *testing.T) {
+	root := t.TempDir()
+
+	store, err := NewStore(config.IndexConfig{Path: "idx/embeddings.json"}, root)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "idx", "embeddings.json"), store.(*FileStore).Path())
+
+	_, err = NewStore(config.IndexConfig{Backend: "qdrant"}, root)
+	assert.ErrorContains(t, err, "index url is required")
+
+	assert.Equal(t, "sigil-my-repo", DefaultCollection("/src/my repo"))
+}
@@ -0,0 +1,466 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxMessageSize bounds a single message from a server
+const maxMessageSize = 64 << 20
+
+// shutdownTimeout bounds how long a server is given to shut down cleanly
+const shutdownTimeout = 2 * time.Second
+
+// ErrClosed is returned for requests on a connection that has ended
+var ErrClosed = errors.New("language server connection closed")
+
+// position is a zero-based line and UTF-16 character offset
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// lspRange is a span between two positions
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+// location is a range in a document
+type location struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+// locationLink is the alternative form of a definition result
+type locationLink struct {
+	TargetURI            string   `json:"targetUri"`
+	TargetSelectionRange lspRange `json:"targetSelectionRange"`
+}
+
+// responseError is the error of a failed request
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements error
+func (e *responseError) Error() string {
+	return fmt.Sprintf("language server error %d: %s", e.Code, e.Message)
+}
+
+// incoming is any message read from a server
+type incoming struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *responseError  `json:"error,omitempty"`
+}
+
+// response is the outcome of a request
+type response struct {
+	result json.RawMessage
+	err    error
+}
+
+// client speaks JSON-RPC with one language server over its standard streams
+type client struct {
+	conn    io.ReadWriteCloser
+	cmd     *exec.Cmd
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan response
+	err     error // Set once the connection has ended
+}
+
+// newClient starts reading responses from conn
+func newClient(conn io.ReadWriteCloser) *client {
+	c := &client{conn: conn, pending: make(map[int64]chan response)}
+	go c.readLoop()
+	return c
+}
+
+// processConn joins a server's standard streams into one connection
+type processConn struct {
+	io.ReadCloser
+	stdin io.WriteCloser
+}
+
+// Write implements io.Writer
+func (p processConn) Write(data []byte) (int, error) {
+	return p.stdin.Write(data)
+}
+
+// Close implements io.Closer
+func (p processConn) Close() error {
+	err := p.stdin.Close()
+	if closeErr := p.ReadCloser.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// startClient starts a language server in root and initializes it
+func startClient(ctx context.Context, server ServerConfig, root string) (*client, error) {
+	cmd := exec.Command(server.Command, server.Args...) // #nosec G204 - servers come from configuration
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", server.Command, err)
+	}
+
+	c := newClient(processConn{ReadCloser: stdout, stdin: stdin})
+	c.cmd = cmd
+	if err := c.initialize(ctx, root); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to initialize %s: %w", server.Command, err)
+	}
+	return c, nil
+}
+
+// initialize performs the initialize handshake for a workspace at root
+func (c *client) initialize(ctx context.Context, root string) error {
+	rootURI := fileURI(root)
+	params := map[string]any{
+		"processId": nil,
+		"rootUri":   rootURI,
+		"capabilities": map[string]any{
+			"textDocument": map[string]any{
+				"definition": map[string]any{"linkSupport": true},
+				"references": map[string]any{},
+				"hover":      map[string]any{"contentFormat": []string{"markdown", "plaintext"}},
+			},
+			"workspace": map[string]any{"workspaceFolders": true, "configuration": true},
+		},
+		"workspaceFolders": []map[string]string{{"uri": rootURI, "name": filepath.Base(root)}},
+	}
+	if err := c.call(ctx, "initialize", params, nil); err != nil {
+		return err
+	}
+	return c.notify("initialized", map[string]any{})
+}
+
+// didOpen tells the server a document is open with the given text
+func (c *client) didOpen(uri, language, text string) error {
+	return c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "languageId": language, "version": 1, "text": text},
+	})
+}
+
+// definition returns where the symbol at pos is defined
+func (c *client) definition(ctx context.Context, uri string, pos position) ([]location, error) {
+	var raw json.RawMessage
+	if err := c.call(ctx, "textDocument/definition", positionParams(uri, pos), &raw); err != nil {
+		return nil, err
+	}
+	return parseLocations(raw)
+}
+
+// references returns where the symbol at pos is used, including its
+// declaration
+func (c *client) references(ctx context.Context, uri string, pos position) ([]location, error) {
+	params := positionParams(uri, pos)
+	params["context"] = map[string]bool{"includeDeclaration": true}
+	var locations []location
+	if err := c.call(ctx, "textDocument/references", params, &locations); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// hover returns the documentation and type of the symbol at pos
+func (c *client) hover(ctx context.Context, uri string, pos position) (string, error) {
+	var result *struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := c.call(ctx, "textDocument/hover", positionParams(uri, pos), &result); err != nil {
+		return "", err
+	}
+	if result == nil {
+		return "", nil
+	}
+	return hoverText(result.Contents), nil
+}
+
+// Close shuts the server down, waiting briefly for it to exit
+func (c *client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if c.call(ctx, "shutdown", nil, nil) == nil {
+		_ = c.notify("exit", nil)
+	}
+	err := c.conn.Close()
+
+	if c.cmd != nil {
+		exited := make(chan struct{})
+		go func() {
+			_ = c.cmd.Wait()
+			close(exited)
+		}()
+		select {
+		case <-exited:
+		case <-time.After(shutdownTimeout):
+			_ = c.cmd.Process.Kill()
+			<-exited
+		}
+	}
+	return err
+}
+
+// call sends a request and decodes its result into result, if not nil
+func (c *client) call(ctx context.Context, method string, params, result any) error {
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return err
+	}
+	c.nextID++
+	id := c.nextID
+	done := make(chan response, 1)
+	c.pending[id] = done
+	c.mu.Unlock()
+
+	message := map[string]any{"jsonrpc": "2.0", "id": id, "method": method}
+	if params != nil {
+		message["params"] = params
+	}
+	if err := c.write(message); err != nil {
+		c.forget(id)
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		c.forget(id)
+		_ = c.notify("$/cancelRequest", map[string]int64{"id": id})
+		return ctx.Err()
+	case resp := <-done:
+		if resp.err != nil {
+			return resp.err
+		}
+		if result == nil || len(resp.result) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(resp.result, result); err != nil {
+			return fmt.Errorf("invalid %s result: %w", method, err)
+		}
+		return nil
+	}
+}
+
+// notify sends a notification
+func (c *client) notify(method string, params any) error {
+	message := map[string]any{"jsonrpc": "2.0", "method": method}
+	if params != nil {
+		message["params"] = params
+	}
+	return c.write(message)
+}
+
+// forget drops a pending request
+func (c *client) forget(id int64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// write sends one message with its Content-Length header
+func (c *client) write(message any) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := fmt.Fprintf(c.conn, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		return fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	return nil
+}
+
+// readLoop dispatches messages from the server until the connection ends
+func (c *client) readLoop() {
+	reader := bufio.NewReader(c.conn)
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		switch {
+		case msg.Method != "" && len(msg.ID) > 0:
+			// Servers ask for configuration and progress tokens; defaults
+			// are fine for read-only queries
+			go c.reply(msg)
+		case msg.Method == "" && len(msg.ID) > 0:
+			id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+			if err != nil {
+				continue
+			}
+			c.mu.Lock()
+			done, ok := c.pending[id]
+			delete(c.pending, id)
+			c.mu.Unlock()
+			if !ok {
+				continue
+			}
+			if msg.Error != nil {
+				done <- response{err: msg.Error}
+			} else {
+				done <- response{result: msg.Result}
+			}
+		}
+	}
+}
+
+// reply answers a request from the server with an empty result
+func (c *client) reply(msg incoming) {
+	var result any
+	if msg.Method == "workspace/configuration" {
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		result = make([]any, len(params.Items))
+	}
+	_ = c.write(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "result": result})
+}
+
+// fail ends the connection, failing every pending request
+func (c *client) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = fmt.Errorf("%w: %v", ErrClosed, err)
+	for id, done := range c.pending {
+		done <- response{err: c.err}
+		delete(c.pending, id)
+	}
+}
+
+// readMessage reads one message framed by headers
+func readMessage(reader *bufio.Reader) (incoming, error) {
+	headers, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil {
+		return incoming{}, err
+	}
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return incoming{}, fmt.Errorf("invalid Content-Length %q", headers.Get("Content-Length"))
+	}
+	if length > maxMessageSize {
+		return incoming{}, fmt.Errorf("message of %d bytes exceeds the %d byte limit", length, maxMessageSize)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return incoming{}, err
+	}
+	var msg incoming
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return incoming{}, fmt.Errorf("malformed message: %w", err)
+	}
+	return msg, nil
+}
+
+// positionParams are the parameters of a request about a position
+func positionParams(uri string, pos position) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     pos,
+	}
+}
+
+// parseLocations reads a definition result, which may be a location, a
+// list of locations or a list of location links
+func parseLocations(raw json.RawMessage) ([]location, error) {
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "" || trimmed == "null" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(trimmed, "[") {
+		var single location
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return nil, err
+		}
+		return []location{single}, nil
+	}
+
+	var links []locationLink
+	if err := json.Unmarshal(raw, &links); err == nil && len(links) > 0 && links[0].TargetURI != "" {
+		locations := make([]location, len(links))
+		for i, link := range links {
+			locations[i] = location{URI: link.TargetURI, Range: link.TargetSelectionRange}
+		}
+		return locations, nil
+	}
+	var locations []location
+	if err := json.Unmarshal(raw, &locations); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// hoverText flattens hover contents, which may be markup, a marked string
+// or a list of marked strings
+func hoverText(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return strings.TrimSpace(text)
+	}
+	var markup struct {
+		Language string `json:"language"`
+		Value    string `json:"value"`
+	}
+	if json.Unmarshal(raw, &markup) == nil && markup.Value != "" {
+		return strings.TrimSpace(markup.Value)
+	}
+	var parts []json.RawMessage
+	if json.Unmarshal(raw, &parts) == nil {
+		texts := make([]string, 0, len(parts))
+		for _, part := range parts {
+			if text := hoverText(part); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, "\n\n")
+	}
+	return ""
+}
+
+// fileURI converts an absolute path into a file URI
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// uriPath converts a file URI into a path
+func uriPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(parsed.Path)
+}
@@ -0,0 +1,90 @@
+// Package lsp is a minimal language server protocol client, used to give
+// agents precise definitions, references and hover information instead of
+// text search
+package lsp
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Config selects the language servers to run
+type Config struct {
+	Enabled bool                    `yaml:"enabled"`
+	Timeout time.Duration           `yaml:"timeout"` // Per request, including server startup
+	Servers map[string]ServerConfig `yaml:"servers"` // By language ID
+}
+
+// ServerConfig describes how to start one language server
+type ServerConfig struct {
+	Command    string   `yaml:"command"`
+	Args       []string `yaml:"args"`
+	Extensions []string `yaml:"extensions"` // File extensions the server handles, with the dot
+}
+
+// DefaultConfig returns the default language servers. They are disabled
+// until enabled in configuration, since each must be installed separately.
+func DefaultConfig() Config {
+	return Config{
+		Enabled: false,
+		Timeout: 30 * time.Second,
+		Servers: map[string]ServerConfig{
+			"go": {
+				Command:    "gopls",
+				Extensions: []string{".go"},
+			},
+			"typescript": {
+				Command:    "typescript-language-server",
+				Args:       []string{"--stdio"},
+				Extensions: []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"},
+			},
+			"python": {
+				Command:    "pyright-langserver",
+				Args:       []string{"--stdio"},
+				Extensions: []string{".py"},
+			},
+		},
+	}
+}
+
+// ServerFor returns the language ID and server handling a file
+func (c Config) ServerFor(path string) (string, ServerConfig, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return "", ServerConfig{}, false
+	}
+
+	// Sorted so overlapping extensions resolve the same way every time
+	languages := make([]string, 0, len(c.Servers))
+	for language := range c.Servers {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	for _, language := range languages {
+		server := c.Servers[language]
+		for _, candidate := range server.Extensions {
+			if strings.EqualFold(candidate, ext) && server.Command != "" {
+				return language, server, true
+			}
+		}
+	}
+	return "", ServerConfig{}, false
+}
+
+// languageID returns the LSP language identifier of a document
+func languageID(language, path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ts":
+		return "typescript"
+	case ".tsx":
+		return "typescriptreact"
+	case ".js", ".mjs", ".cjs":
+		return "javascript"
+	case ".jsx":
+		return "javascriptreact"
+	}
+	return language
+}
@@ -0,0 +1,220 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer answers language server requests over a pipe with canned
+// results and records the requests it received
+type fakeServer struct {
+	conn    net.Conn
+	results map[string]any
+
+	mu       sync.Mutex
+	requests []incoming
+}
+
+// newFakeServer returns a client connected to a fake server
+func newFakeServer(t *testing.T, results map[string]any) (*client, *fakeServer) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	server := &fakeServer{conn: serverConn, results: results}
+	go server.serve()
+	c := newClient(clientConn)
+	t.Cleanup(func() { _ = c.Close() })
+	return c, server
+}
+
+// serve answers requests until the connection closes
+func (s *fakeServer) serve() {
+	reader := bufio.NewReader(s.conn)
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, msg)
+		s.mu.Unlock()
+
+		switch {
+		case len(msg.ID) == 0:
+			continue
+		case msg.Method == "initialize":
+			// Ask the client something first, as real servers do
+			s.send(map[string]any{"jsonrpc": "2.0", "id": 99, "method": "workspace/configuration",
+				"params": map[string]any{"items": []any{map[string]string{"section": "gopls"}}}})
+			s.send(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "result": map[string]any{"capabilities": map[string]any{}}})
+		case msg.Method == "fail":
+			s.send(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "error": map[string]any{"code": -32601, "message": "method not found"}})
+		case msg.Method != "":
+			s.send(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "result": s.results[msg.Method]})
+		}
+	}
+}
+
+// send writes one framed message
+func (s *fakeServer) send(message any) {
+	body, _ := json.Marshal(message)
+	_, _ = fmt.Fprintf(s.conn, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// received returns the requests and notifications seen so far
+func (s *fakeServer) received() []incoming {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]incoming(nil), s.requests...)
+}
+
+// methods returns the methods received so far
+func (s *fakeServer) methods() []string {
+	var methods []string
+	for _, msg := range s.received() {
+		if msg.Method != "" {
+			methods = append(methods, msg.Method)
+		}
+	}
+	return methods
+}
+
+func TestClient_Requests(t *testing.T) {
+	c, server := newFakeServer(t, map[string]any{
+		"textDocument/definition": []map[string]any{{
+			"targetUri":            "file:///repo/store.go",
+			"targetSelectionRange": map[string]any{"start": map[string]int{"line": 9, "character": 5}},
+		}},
+		"textDocument/references": []map[string]any{
+			{"uri": "file:///repo/a.go", "range": map[string]any{"start": map[string]int{"line": 1, "character": 2}}},
+			{"uri": "file:///repo/b.go", "range": map[string]any{"start": map[string]int{"line": 3, "character": 4}}},
+		},
+		"textDocument/hover": map[string]any{"contents": map[string]string{"kind": "markdown", "value": "```go\nfunc Get(key string) string\n```\n"}},
+	})
+	ctx := context.Background()
+
+	require.NoError(t, c.initialize(ctx, "/repo"))
+
+	definitions, err := c.definition(ctx, "file:///repo/main.go", position{Line: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []location{{URI: "file:///repo/store.go", Range: lspRange{Start: position{Line: 9, Character: 5}}}}, definitions)
+
+	references, err := c.references(ctx, "file:///repo/main.go", position{Line: 1})
+	require.NoError(t, err)
+	assert.Len(t, references, 2)
+
+	hover, err := c.hover(ctx, "file:///repo/main.go", position{Line: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "```go\nfunc Get(key string) string\n```", hover)
+
+	err = c.call(ctx, "fail", nil, nil)
+	assert.EqualError(t, err, "language server error -32601: method not found")
+
+	assert.Eventually(t, func() bool {
+		for _, msg := range server.received() {
+			if msg.Method == "" && string(msg.ID) == "99" {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond, "server requests are answered")
+	assert.Contains(t, server.methods(), "initialized")
+}
+
+func TestClient_ClosedConnection(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	c := newClient(clientConn)
+	require.NoError(t, serverConn.Close())
+
+	err := c.call(context.Background(), "textDocument/hover", nil, nil)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestParseLocations(t *testing.T) {
+	single, err := parseLocations(json.RawMessage(`{"uri": "file:///a.go", "range": {"start": {"line": 2, "character": 1}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []location{{URI: "file:///a.go", Range: lspRange{Start: position{Line: 2, Character: 1}}}}, single)
+
+	none, err := parseLocations(json.RawMessage(`null`))
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestHoverText(t *testing.T) {
+	assert.Equal(t, "plain", hoverText(json.RawMessage(`"plain"`)))
+	assert.Equal(t, "func f()\n\nDocs", hoverText(json.RawMessage(`[{"language": "go", "value": "func f()"}, "Docs"]`)))
+}
+
+func TestFindSymbol(t *testing.T) {
+	content := "package main\n\nfunc main() { store.Get(getKey()) }\n\t😀 := Get()\n"
+
+	pos, err := findSymbol(content, 3, "Get")
+	require.NoError(t, err)
+	assert.Equal(t, position{Line: 2, Character: 20}, pos, "whole words are preferred")
+
+	pos, err = findSymbol(content, 3, "")
+	require.NoError(t, err)
+	assert.Equal(t, position{Line: 2, Character: 0}, pos)
+
+	pos, err = findSymbol(content, 4, "Get")
+	require.NoError(t, err)
+	assert.Equal(t, position{Line: 3, Character: 7}, pos, "characters are counted in UTF-16")
+
+	_, err = findSymbol(content, 3, "Put")
+	assert.Error(t, err)
+	_, err = findSymbol(content, 9, "")
+	assert.Error(t, err)
+}
+
+func TestManager(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() { run() }\n"), 0600))
+
+	starts := 0
+	var server *fakeServer
+	manager := NewManager(root, DefaultConfig())
+	manager.start = func(ctx context.Context, _ ServerConfig, root string) (*client, error) {
+		starts++
+		var c *client
+		c, server = newFakeServer(t, map[string]any{
+			"textDocument/definition": map[string]any{
+				"uri":   fileURI(filepath.Join(root, "run.go")),
+				"range": map[string]any{"start": map[string]int{"line": 4, "character": 5}},
+			},
+		})
+		return c, c.initialize(ctx, root)
+	}
+	defer manager.Close()
+
+	locations, err := manager.Definition(context.Background(), "main.go", 3, "run")
+	require.NoError(t, err)
+	assert.Equal(t, []Location{{Path: "run.go", Line: 5, Column: 6}}, locations)
+	assert.Equal(t, "run.go:5:6", locations[0].String())
+
+	_, err = manager.Definition(context.Background(), "main.go", 3, "run")
+	require.NoError(t, err)
+	assert.Equal(t, 1, starts, "servers are started once")
+
+	opens := 0
+	for _, method := range server.methods() {
+		if method == "textDocument/didOpen" {
+			opens++
+		}
+	}
+	assert.Equal(t, 1, opens, "documents are opened once")
+
+	_, err = manager.Hover(context.Background(), "README.md", 1, "")
+	assert.ErrorContains(t, err, "no language server configured")
+	assert.True(t, manager.Supported("app.tsx"))
+	assert.False(t, manager.Supported("README.md"))
+}
@@ -0,0 +1,237 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// Location is a place in a file, with 1-based line and column
+type Location struct {
+	Path   string `json:"path"` // Relative to the workspace root when inside it
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// String formats the location as path:line:column
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d:%d", l.Path, l.Line, l.Column)
+}
+
+// Manager starts language servers for a workspace on first use and routes
+// queries to them by file extension
+type Manager struct {
+	root   string
+	config Config
+	start  func(ctx context.Context, server ServerConfig, root string) (*client, error)
+
+	mu      sync.Mutex
+	clients map[string]*client
+	failed  map[string]error // Servers that could not start are not retried
+	opened  map[string]bool  // Documents already sent to their server
+}
+
+// NewManager creates a manager for the workspace at root
+func NewManager(root string, config Config) *Manager {
+	return &Manager{
+		root:    root,
+		config:  config,
+		start:   startClient,
+		clients: make(map[string]*client),
+		failed:  make(map[string]error),
+		opened:  make(map[string]bool),
+	}
+}
+
+// Supported reports whether a language server is configured for a file
+func (m *Manager) Supported(path string) bool {
+	_, _, ok := m.config.ServerFor(path)
+	return ok
+}
+
+// Definition returns where the symbol on a line of a file is defined. Paths
+// are relative to the workspace root and lines are 1-based; an empty
+// symbol refers to the first word on the line.
+func (m *Manager) Definition(ctx context.Context, path string, line int, symbol string) ([]Location, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+	c, uri, pos, err := m.prepare(ctx, path, line, symbol)
+	if err != nil {
+		return nil, err
+	}
+	locations, err := c.definition(ctx, uri, pos)
+	if err != nil {
+		return nil, err
+	}
+	return m.convert(locations), nil
+}
+
+// References returns where the symbol on a line of a file is used,
+// including its declaration
+func (m *Manager) References(ctx context.Context, path string, line int, symbol string) ([]Location, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+	c, uri, pos, err := m.prepare(ctx, path, line, symbol)
+	if err != nil {
+		return nil, err
+	}
+	locations, err := c.references(ctx, uri, pos)
+	if err != nil {
+		return nil, err
+	}
+	return m.convert(locations), nil
+}
+
+// Hover returns the signature and documentation of the symbol on a line of
+// a file
+func (m *Manager) Hover(ctx context.Context, path string, line int, symbol string) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+	c, uri, pos, err := m.prepare(ctx, path, line, symbol)
+	if err != nil {
+		return "", err
+	}
+	return c.hover(ctx, uri, pos)
+}
+
+// Close shuts down every server that was started
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	clients := m.clients
+	m.clients = make(map[string]*client)
+	m.opened = make(map[string]bool)
+	m.mu.Unlock()
+
+	var firstErr error
+	for _, c := range clients {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// withTimeout applies the per-request timeout
+func (m *Manager) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.config.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, m.config.Timeout)
+}
+
+// prepare opens a document with its server and finds the position of the
+// symbol on the line
+func (m *Manager) prepare(ctx context.Context, path string, line int, symbol string) (*client, string, position, error) {
+	language, server, ok := m.config.ServerFor(path)
+	if !ok {
+		return nil, "", position{}, fmt.Errorf("no language server configured for %s", path)
+	}
+
+	abs := path
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(m.root, path)
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, "", position{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	pos, err := findSymbol(string(data), line, symbol)
+	if err != nil {
+		return nil, "", position{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	c, err := m.client(ctx, language, server)
+	if err != nil {
+		return nil, "", position{}, err
+	}
+
+	uri := fileURI(abs)
+	m.mu.Lock()
+	opened := m.opened[uri]
+	m.opened[uri] = true
+	m.mu.Unlock()
+	if !opened {
+		if err := c.didOpen(uri, languageID(language, path), string(data)); err != nil {
+			return nil, "", position{}, err
+		}
+	}
+	return c, uri, pos, nil
+}
+
+// client returns the running server for a language, starting it if needed
+func (m *Manager) client(ctx context.Context, language string, server ServerConfig) (*client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.clients[language]; ok {
+		return c, nil
+	}
+	if err := m.failed[language]; err != nil {
+		return nil, err
+	}
+
+	c, err := m.start(ctx, server, m.root)
+	if err != nil {
+		m.failed[language] = err
+		return nil, err
+	}
+	m.clients[language] = c
+	return c, nil
+}
+
+// convert turns server locations into workspace locations
+func (m *Manager) convert(locations []location) []Location {
+	converted := make([]Location, 0, len(locations))
+	for _, loc := range locations {
+		path := uriPath(loc.URI)
+		if rel, err := filepath.Rel(m.root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			path = filepath.ToSlash(rel)
+		}
+		converted = append(converted, Location{
+			Path:   path,
+			Line:   loc.Range.Start.Line + 1,
+			Column: loc.Range.Start.Character + 1,
+		})
+	}
+	return converted
+}
+
+// wordPattern matches the first identifier on a line
+var wordPattern = regexp.MustCompile(`[\p{L}_$][\p{L}\p{N}_$]*`)
+
+// findSymbol locates a symbol on a 1-based line of content, preferring a
+// whole-word match
+func findSymbol(content string, line int, symbol string) (position, error) {
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		return position{}, fmt.Errorf("line %d is out of range (1-%d)", line, len(lines))
+	}
+	text := lines[line-1]
+
+	offset := -1
+	if symbol == "" {
+		if match := wordPattern.FindStringIndex(text); match != nil {
+			offset = match[0]
+		}
+	} else {
+		word := regexp.MustCompile(`(^|[^\p{L}\p{N}_$])(` + regexp.QuoteMeta(symbol) + `)($|[^\p{L}\p{N}_$])`)
+		if match := word.FindStringSubmatchIndex(text); match != nil {
+			offset = match[4]
+		} else {
+			offset = strings.Index(text, symbol)
+		}
+	}
+	if offset < 0 {
+		if symbol == "" {
+			return position{}, fmt.Errorf("no symbol on line %d", line)
+		}
+		return position{}, fmt.Errorf("%q not found on line %d", symbol, line)
+	}
+
+	// Servers count characters in UTF-16 code units
+	return position{Line: line - 1, Character: len(utf16.Encode([]rune(text[:offset])))}, nil
+}
@@ -4,6 +4,7 @@ package memory
 import (
 	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/model"
 )
 
@@ -146,15 +147,10 @@ func (b *EntryBuilder) Build() MemoryEntry {
 
 // generateID generates a unique ID for memory entries
 func generateID() string {
-	return time.Now().Format("20060102-150405") + "-" + randomString(6)
+	return deterministic.Now().Format("20060102-150405") + "-" + randomString(6)
 }
 
 // randomString generates a random string of given length
 func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
-	}
-	return string(b)
+	return deterministic.Token(length)
 }
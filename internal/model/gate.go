@@ -0,0 +1,150 @@
+package model
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// Gate decides whether a request may be sent to a provider, given the
+// endpoint it is sent to and the repository paths its content came from.
+// An error refuses the request before anything leaves the process.
+type Gate func(provider, endpoint string, paths []string) error
+
+var (
+	gateMu sync.RWMutex
+	gate   Gate
+)
+
+// SetGate sets the gate every request to a model created afterwards goes
+// through; nil lets requests through unchecked
+func SetGate(g Gate) {
+	gateMu.Lock()
+	defer gateMu.Unlock()
+	gate = g
+}
+
+// currentGate returns the gate set, or nil
+func currentGate() Gate {
+	gateMu.RLock()
+	defer gateMu.RUnlock()
+	return gate
+}
+
+// fencedSource matches the source label of an <untrusted-content> block
+var fencedSource = regexp.MustCompile(`<untrusted-content source=("(?:[^"\\]|\\.)*")`)
+
+// ContentSources returns the repository paths the content of a request came
+// from: the files sent with it and the sources of the untrusted content
+// blocks in its prompts. A block label found in input.Sources stands for the
+// paths listed there; any other label is taken as a path, so content whose
+// origin is not declared, such as a diff labelled without its files, fails
+// a path-scoped check rather than passing unchecked.
+func ContentSources(input PromptInput) []string {
+	var sources []string
+	seen := make(map[string]bool)
+	add := func(source string) {
+		if source == "" || seen[source] {
+			return
+		}
+		seen[source] = true
+		sources = append(sources, source)
+	}
+	for _, file := range input.Files {
+		add(file.Path)
+	}
+	for _, prompt := range []string{input.SystemPrompt, input.UserPrompt} {
+		for _, match := range fencedSource.FindAllStringSubmatch(prompt, -1) {
+			label, err := strconv.Unquote(match[1])
+			if err != nil {
+				// An unreadable label still marks repository content
+				label = match[1]
+			}
+			paths := input.Sources[label]
+			if len(paths) == 0 {
+				add(label)
+				continue
+			}
+			for _, path := range paths {
+				add(path)
+			}
+		}
+	}
+	return sources
+}
+
+type contentSourcesKey struct{}
+
+// WithContentSources returns a context marking the texts embedded with it
+// as content of paths, which the gate checks before they are sent. Texts
+// embedded without it, such as a search query, are checked against the
+// provider alone.
+func WithContentSources(ctx context.Context, paths []string) context.Context {
+	return context.WithValue(ctx, contentSourcesKey{}, paths)
+}
+
+// ContentSourcesFromContext returns the paths set by WithContentSources, or
+// nil when the context marks none
+func ContentSourcesFromContext(ctx context.Context) []string {
+	paths, _ := ctx.Value(contentSourcesKey{}).([]string)
+	return paths
+}
+
+// gatedModel checks every request against a gate before sending it
+type gatedModel struct {
+	Model
+	gate     Gate
+	provider string
+	endpoint string
+}
+
+// gatedEmbedder is a gated model that also embeds, checked the same way
+type gatedEmbedder struct {
+	*gatedModel
+	embedder Embedder
+}
+
+// withGate wraps m so its requests go through g
+func withGate(m Model, g Gate, config ModelConfig) Model {
+	gated := &gatedModel{Model: m, gate: g, provider: config.Provider, endpoint: config.Endpoint}
+	if embedder, ok := EmbedderOf(m); ok {
+		return &gatedEmbedder{gatedModel: gated, embedder: embedder}
+	}
+	return gated
+}
+
+// RunPrompt sends input once the gate allows it
+func (m *gatedModel) RunPrompt(ctx context.Context, input PromptInput) (PromptOutput, error) {
+	if err := m.gate(m.provider, m.endpoint, ContentSources(input)); err != nil {
+		return PromptOutput{}, err
+	}
+	return m.Model.RunPrompt(ctx, input)
+}
+
+// StreamPrompt streams input once the gate allows it
+func (m *gatedModel) StreamPrompt(ctx context.Context, input PromptInput, onChunk ChunkFunc) (PromptOutput, error) {
+	if err := m.gate(m.provider, m.endpoint, ContentSources(input)); err != nil {
+		return PromptOutput{}, err
+	}
+	return Stream(ctx, m.Model, input, onChunk)
+}
+
+// Unwrap returns the gated model
+func (m *gatedModel) Unwrap() Model {
+	return m.Model
+}
+
+// Embed sends texts once the gate allows sending them, from the paths the
+// context marks them with, to the provider
+func (m *gatedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := m.gate(m.provider, m.endpoint, ContentSourcesFromContext(ctx)); err != nil {
+		return nil, err
+	}
+	return m.embedder.Embed(ctx, texts)
+}
+
+// EmbeddingModel identifies the vector space of the gated embedder
+func (m *gatedEmbedder) EmbeddingModel() string {
+	return m.embedder.EmbeddingModel()
+}
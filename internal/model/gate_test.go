@@ -0,0 +1,72 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentSources(t *testing.T) {
+	input := PromptInput{
+		SystemPrompt: "Review the change.",
+		UserPrompt: "Files:\n" + FenceUntrusted("internal/store/store.go", "package store") +
+			FenceUntrusted("staged diff", "+x") + FenceUntrusted(`odd"name.go`, "x") +
+			FenceUntrusted("main.go", "package main") + FenceUntrusted("docs/user guide.md", "# Guide"),
+		Files:   []FileContent{{Path: "main.go"}, {Path: "README.md"}},
+		Sources: map[string][]string{"staged diff": {"cmd/sigil/main.go", "go.mod"}},
+	}
+	assert.Equal(t, []string{"main.go", "README.md", "internal/store/store.go", "cmd/sigil/main.go", "go.mod",
+		`odd"name.go`, "docs/user guide.md"}, ContentSources(input))
+	assert.Empty(t, ContentSources(PromptInput{UserPrompt: "What is a monad?"}))
+
+	// A label whose paths are not declared is checked as a path, failing closed
+	undeclared := PromptInput{UserPrompt: FenceUntrusted("git diff", "+x")}
+	assert.Equal(t, []string{"git diff"}, ContentSources(undeclared))
+}
+
+func TestCreateModel_Gate(t *testing.T) {
+	originalProviders := defaultRegistry.providers
+	originalModels := defaultRegistry.models
+	defer func() {
+		defaultRegistry.providers = originalProviders
+		defaultRegistry.models = originalModels
+		SetGate(nil)
+	}()
+	defaultRegistry.providers = make(map[string]Factory)
+	defaultRegistry.models = make(map[string]Model)
+
+	var checked []string
+	SetGate(func(provider, endpoint string, paths []string) error {
+		checked = append(checked, provider+" "+endpoint)
+		if len(paths) > 0 && paths[0] == "secret.go" {
+			return errors.New("refused")
+		}
+		return nil
+	})
+
+	config := ModelConfig{Provider: "test", Model: "gated", Endpoint: "https://api.example.com"}
+	mockModel := &MockModel{}
+	mockFactory := &MockFactory{}
+	mockFactory.On("CreateModel", config).Return(mockModel, nil)
+	mockModel.On("RunPrompt", mock.Anything, mock.Anything).Return(PromptOutput{Response: "ok"}, nil).Once()
+	require.NoError(t, RegisterProvider("test", mockFactory))
+
+	gated, err := CreateModel(config)
+	require.NoError(t, err)
+	cached, err := GetModel("test", "gated")
+	require.NoError(t, err)
+	assert.Same(t, gated, cached, "every user of the model is gated")
+
+	output, err := gated.RunPrompt(context.Background(), PromptInput{Files: []FileContent{{Path: "main.go"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", output.Response)
+
+	_, err = gated.RunPrompt(context.Background(), PromptInput{Files: []FileContent{{Path: "secret.go"}}})
+	assert.EqualError(t, err, "refused")
+	mockModel.AssertNumberOfCalls(t, "RunPrompt", 1)
+	assert.Equal(t, []string{"test https://api.example.com", "test https://api.example.com"}, checked)
+}
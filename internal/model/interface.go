@@ -13,6 +13,10 @@ type PromptInput struct {
 	MaxTokens    int               // Maximum tokens for response
 	Temperature  float64           // Temperature for response generation
 	Metadata     map[string]string // Additional metadata
+
+	// Sources lists, by fence label, the repository paths behind untrusted
+	// content whose label is not itself a path, such as the files of a diff
+	Sources map[string][]string
 }
 
 // FileContent represents a file's content to be included in a prompt.
@@ -37,6 +41,17 @@ type PromptOutput struct {
 	Metadata   map[string]string // Additional metadata
 }
 
+// Metadata keys shared between callers and providers.
+const (
+	// MetadataLogprobs in PromptInput.Metadata asks the provider for token
+	// log probabilities when set to "true"
+	MetadataLogprobs = "logprobs"
+
+	// MetadataMeanLogprob in PromptOutput.Metadata is the mean token log
+	// probability of the response, set by providers that support logprobs
+	MetadataMeanLogprob = "mean_logprob"
+)
+
 // Model is the interface that all LLM backends must implement.
 type Model interface {
 	// RunPrompt executes a prompt and returns the response
@@ -49,6 +64,31 @@ type Model interface {
 	Name() string
 }
 
+// Embedder is implemented by models that can embed text for retrieval.
+type Embedder interface {
+	// Embed returns one vector per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+
+	// EmbeddingModel identifies the vector space the embeddings belong to
+	EmbeddingModel() string
+}
+
+// EmbedderOf returns the embedder behind m, looking through wrappers that
+// expose the model they wrap with an Unwrap method.
+func EmbedderOf(m Model) (Embedder, bool) {
+	for m != nil {
+		if embedder, ok := m.(Embedder); ok {
+			return embedder, true
+		}
+		wrapper, ok := m.(interface{ Unwrap() Model })
+		if !ok {
+			break
+		}
+		m = wrapper.Unwrap()
+	}
+	return nil, false
+}
+
 // ModelCapabilities describes what a model can do.
 type ModelCapabilities struct {
 	MaxTokens         int
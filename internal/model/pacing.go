@@ -0,0 +1,300 @@
+package model
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// Rate limit retries: how often a rate-limited request is retried, and how
+// long to wait when the provider does not say
+const (
+	maxRateLimitRetries = 5
+	defaultRetryAfter   = 10 * time.Second
+)
+
+// quotaWindow is the period provider quotas are counted over
+const quotaWindow = time.Minute
+
+// Quota limits how fast a provider or model may be called. Zero leaves a
+// limit off.
+type Quota struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// pacedRequest is a request a pacer let through, at the time it was sent
+type pacedRequest struct {
+	at     time.Time
+	tokens int
+}
+
+// Pacer spaces the requests to a provider so that those sent within any
+// minute stay under its quota, and holds them back while the provider has
+// asked to be left alone. It is safe for concurrent use.
+type Pacer struct {
+	quota Quota
+	now   func() time.Time
+
+	mu           sync.Mutex
+	sent         []*pacedRequest
+	blockedUntil time.Time
+}
+
+// NewPacer creates a pacer for a quota
+func NewPacer(quota Quota) *Pacer {
+	return &Pacer{quota: quota, now: time.Now}
+}
+
+// pacers are shared by the models of a provider, keyed by quota name, as
+// quotas are counted per account rather than per model instance
+var pacers = struct {
+	mu     sync.Mutex
+	byName map[string]*Pacer
+}{byName: make(map[string]*Pacer)}
+
+// PacerFor returns the pacer for the quota called name, creating it the
+// first time
+func PacerFor(name string, quota Quota) *Pacer {
+	pacers.mu.Lock()
+	defer pacers.mu.Unlock()
+	if pacer, ok := pacers.byName[name]; ok {
+		return pacer
+	}
+	pacer := NewPacer(quota)
+	pacers.byName[name] = pacer
+	return pacer
+}
+
+// reserve books the earliest time a request using tokens may be sent under
+// the quota and returns it with how long that is from now
+func (p *Pacer) reserve(tokens int) (*pacedRequest, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	at := now
+	if p.blockedUntil.After(at) {
+		at = p.blockedUntil
+	}
+	// Requests are booked in order, so one never lands in a window already
+	// counted for a later booking
+	if len(p.sent) > 0 && p.sent[len(p.sent)-1].at.After(at) {
+		at = p.sent[len(p.sent)-1].at
+	}
+	for {
+		var window []*pacedRequest
+		used := 0
+		for _, req := range p.sent {
+			if req.at.After(at.Add(-quotaWindow)) {
+				window = append(window, req)
+				used += req.tokens
+			}
+		}
+		next := at
+		if p.quota.RequestsPerMinute > 0 && len(window) >= p.quota.RequestsPerMinute {
+			next = window[len(window)-p.quota.RequestsPerMinute].at.Add(quotaWindow)
+		} else if p.quota.TokensPerMinute > 0 && len(window) > 0 && used+tokens > p.quota.TokensPerMinute {
+			// Wait for the oldest requests to leave the window until this one fits
+			for _, req := range window {
+				used -= req.tokens
+				next = req.at.Add(quotaWindow)
+				if used+tokens <= p.quota.TokensPerMinute {
+					break
+				}
+			}
+		}
+		if !next.After(at) {
+			break
+		}
+		at = next
+	}
+
+	req := &pacedRequest{at: at, tokens: tokens}
+	kept := p.sent[:0]
+	for _, sent := range p.sent {
+		if sent.at.After(now.Add(-quotaWindow)) {
+			kept = append(kept, sent)
+		}
+	}
+	p.sent = append(kept, req)
+	return req, at.Sub(now)
+}
+
+// settle records the tokens a request actually used
+func (p *Pacer) settle(req *pacedRequest, tokens int) {
+	if tokens <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	req.tokens = tokens
+}
+
+// Block holds back all requests for d, as a provider asks with Retry-After
+func (p *Pacer) Block(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if until := p.now().Add(d); until.After(p.blockedUntil) {
+		p.blockedUntil = until
+	}
+}
+
+// RateLimitError is returned by providers when a request was refused for
+// exceeding a rate limit
+type RateLimitError struct {
+	// RetryAfter is how long the provider asked to wait; zero when it did
+	// not say
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *RateLimitError) Error() string { return e.Err.Error() }
+
+func (e *RateLimitError) Unwrap() error { return e.Err }
+
+// RateLimited marks err as a rate limit refusal, with the provider's
+// Retry-After header value
+func RateLimited(err error, retryAfter string) error {
+	return &RateLimitError{RetryAfter: ParseRetryAfter(retryAfter, time.Now()), Err: err}
+}
+
+// ParseRetryAfter parses a Retry-After header, given in seconds or as an
+// HTTP date, into how long to wait from now; zero when it is missing or
+// invalid
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := time.Parse(time.RFC1123, value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// PacedModel paces the prompts sent to a model by a shared pacer, and
+// waits out and retries the requests the provider refuses for rate limits
+// instead of failing them
+type PacedModel struct {
+	inner Model
+	pacer *Pacer
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewPacedModel wraps m so its prompts are paced by pacer
+func NewPacedModel(m Model, pacer *Pacer) *PacedModel {
+	return &PacedModel{inner: m, pacer: pacer, sleep: sleepContext}
+}
+
+// RunPrompt waits for the pacer, then runs the prompt, retrying it while
+// the provider refuses it for rate limits. Waits are reported as progress.
+func (m *PacedModel) RunPrompt(ctx context.Context, input PromptInput) (PromptOutput, error) {
+	return m.run(ctx, input, func(ctx context.Context) (PromptOutput, error) {
+		return m.inner.RunPrompt(ctx, input)
+	}, func() bool { return true })
+}
+
+// StreamPrompt paces a streamed prompt like RunPrompt. A refused prompt is
+// only retried when none of its response has been passed on.
+func (m *PacedModel) StreamPrompt(ctx context.Context, input PromptInput, onChunk ChunkFunc) (PromptOutput, error) {
+	streamed := false
+	return m.run(ctx, input, func(ctx context.Context) (PromptOutput, error) {
+		return Stream(ctx, m.inner, input, func(chunk string) {
+			streamed = true
+			onChunk(chunk)
+		})
+	}, func() bool { return !streamed })
+}
+
+// run paces prompt, which sends input to the inner model, and retries it
+// for rate limits while retryable allows
+func (m *PacedModel) run(ctx context.Context, input PromptInput, prompt func(ctx context.Context) (PromptOutput, error),
+	retryable func() bool) (PromptOutput, error) {
+	tokens := estimatePromptTokens(input)
+	for attempt := 0; ; attempt++ {
+		req, wait := m.pacer.reserve(tokens)
+		if wait > 0 {
+			m.report(ctx, fmt.Sprintf("waiting %s for the rate limit", wait.Round(time.Second)))
+			if err := m.sleep(ctx, wait); err != nil {
+				return PromptOutput{}, err
+			}
+		}
+
+		output, err := prompt(ctx)
+		if err == nil {
+			m.pacer.settle(req, output.TokensUsed)
+			return output, nil
+		}
+		var limited *RateLimitError
+		if !stderrors.As(err, &limited) || attempt >= maxRateLimitRetries || !retryable() {
+			return output, err
+		}
+
+		retryAfter := limited.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = defaultRetryAfter << attempt
+		}
+		logger.Warn("rate limited, retrying", "model", m.inner.Name(), "retry_after", retryAfter, "attempt", attempt+1)
+		m.pacer.Block(retryAfter)
+	}
+}
+
+// report passes a pacing wait to the progress function of ctx, if any
+func (m *PacedModel) report(ctx context.Context, message string) {
+	if fn := ProgressFromContext(ctx); fn != nil {
+		fn(Progress{Source: m.inner.Name(), Message: message})
+	}
+}
+
+// GetCapabilities returns the wrapped model's capabilities
+func (m *PacedModel) GetCapabilities() ModelCapabilities {
+	return m.inner.GetCapabilities()
+}
+
+// Name returns the wrapped model's name
+func (m *PacedModel) Name() string {
+	return m.inner.Name()
+}
+
+// Unwrap returns the paced model
+func (m *PacedModel) Unwrap() Model {
+	return m.inner
+}
+
+// estimatePromptTokens approximates the tokens a prompt will use: its text,
+// at about four characters a token, and the most the response may take
+func estimatePromptTokens(input PromptInput) int {
+	chars := len(input.SystemPrompt) + len(input.UserPrompt)
+	for _, file := range input.Files {
+		chars += len(file.Content)
+	}
+	for _, entry := range input.Memory {
+		chars += len(entry.Content)
+	}
+	return (chars+3)/4 + input.MaxTokens
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testPacer creates a pacer on a clock the test moves
+func testPacer(quota Quota) (*Pacer, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pacer := NewPacer(quota)
+	pacer.now = func() time.Time { return now }
+	return pacer, &now
+}
+
+func TestPacer_RequestsPerMinute(t *testing.T) {
+	pacer, now := testPacer(Quota{RequestsPerMinute: 2})
+
+	_, wait := pacer.reserve(10)
+	assert.Zero(t, wait)
+	*now = now.Add(10 * time.Second)
+	_, wait = pacer.reserve(10)
+	assert.Zero(t, wait)
+	_, wait = pacer.reserve(10)
+	assert.Equal(t, 50*time.Second, wait, "the third request waits for the first to leave the window")
+	_, wait = pacer.reserve(10)
+	assert.Equal(t, 60*time.Second, wait, "and the fourth for the second")
+}
+
+func TestPacer_TokensPerMinute(t *testing.T) {
+	pacer, now := testPacer(Quota{TokensPerMinute: 1000})
+
+	_, wait := pacer.reserve(600)
+	assert.Zero(t, wait)
+	*now = now.Add(20 * time.Second)
+	_, wait = pacer.reserve(600)
+	assert.Equal(t, 40*time.Second, wait, "the tokens of both do not fit one minute")
+
+	pacer, now = testPacer(Quota{TokensPerMinute: 1000})
+	first, _ := pacer.reserve(600)
+	pacer.settle(first, 300)
+	*now = now.Add(20 * time.Second)
+	_, wait = pacer.reserve(600)
+	assert.Zero(t, wait, "requests count the tokens they used, not the estimate")
+
+	_, wait = pacer.reserve(5000)
+	assert.Equal(t, 60*time.Second, wait, "a request over the quota alone waits for an empty window")
+}
+
+func TestPacer_Block(t *testing.T) {
+	pacer, now := testPacer(Quota{})
+	_, wait := pacer.reserve(10)
+	assert.Zero(t, wait, "no quota, no pacing")
+
+	pacer.Block(30 * time.Second)
+	pacer.Block(10 * time.Second)
+	_, wait = pacer.reserve(10)
+	assert.Equal(t, 30*time.Second, wait)
+	*now = now.Add(time.Minute)
+	_, wait = pacer.reserve(10)
+	assert.Zero(t, wait)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 30*time.Second, ParseRetryAfter("30", now))
+	assert.Equal(t, 1500*time.Millisecond, ParseRetryAfter("1.5", now))
+	assert.Equal(t, 90*time.Second, ParseRetryAfter("Mon, 01 Jan 2024 12:01:30 GMT", now))
+	assert.Zero(t, ParseRetryAfter("", now))
+	assert.Zero(t, ParseRetryAfter("soon", now))
+	assert.Zero(t, ParseRetryAfter("Mon, 01 Jan 2024 11:00:00 GMT", now), "dates passed need no wait")
+}
+
+func TestPacedModel_RunPrompt(t *testing.T) {
+	inner := &MockModel{}
+	inner.On("Name").Return("anthropic")
+	input := PromptInput{UserPrompt: "Review this"}
+	limited := &RateLimitError{RetryAfter: 20 * time.Second, Err: fmt.Errorf("API error 429")}
+	inner.On("RunPrompt", mock.Anything, input).Return(PromptOutput{}, limited).Once()
+	inner.On("RunPrompt", mock.Anything, input).Return(PromptOutput{Response: "OK", TokensUsed: 12}, nil).Once()
+
+	pacer, _ := testPacer(Quota{})
+	paced := NewPacedModel(inner, pacer)
+	var slept []time.Duration
+	paced.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	var reported []Progress
+	ctx := WithProgress(context.Background(), func(p Progress) { reported = append(reported, p) })
+
+	output, err := paced.RunPrompt(ctx, input)
+	require.NoError(t, err)
+	assert.Equal(t, "OK", output.Response)
+	assert.Equal(t, []time.Duration{20 * time.Second}, slept, "the request is retried after the provider's Retry-After")
+	require.Len(t, reported, 1)
+	assert.Equal(t, Progress{Source: "anthropic", Message: "waiting 20s for the rate limit"}, reported[0])
+	inner.AssertExpectations(t)
+}
+
+func TestPacedModel_RunPrompt_GivesUp(t *testing.T) {
+	inner := &MockModel{}
+	inner.On("Name").Return("openai")
+	inner.On("RunPrompt", mock.Anything, mock.Anything).Return(PromptOutput{}, &RateLimitError{Err: fmt.Errorf("API error 429")})
+
+	pacer, now := testPacer(Quota{})
+	paced := NewPacedModel(inner, pacer)
+	var slept []time.Duration
+	paced.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		*now = now.Add(d)
+		return nil
+	}
+
+	_, err := paced.RunPrompt(context.Background(), PromptInput{})
+	require.Error(t, err)
+	inner.AssertNumberOfCalls(t, "RunPrompt", maxRateLimitRetries+1)
+	assert.Equal(t, defaultRetryAfter, slept[0], "without Retry-After the waits back off")
+	assert.Equal(t, defaultRetryAfter<<(maxRateLimitRetries-1), slept[len(slept)-1])
+
+	other := &MockModel{}
+	other.On("RunPrompt", mock.Anything, mock.Anything).Return(PromptOutput{}, fmt.Errorf("API error 500")).Once()
+	_, err = NewPacedModel(other, NewPacer(Quota{})).RunPrompt(context.Background(), PromptInput{})
+	assert.EqualError(t, err, "API error 500", "other errors are not retried")
+	other.AssertExpectations(t)
+}
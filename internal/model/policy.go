@@ -0,0 +1,132 @@
+package model
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// ErrDisallowed reports a model the repository's policy does not allow
+var ErrDisallowed = stderrors.New("model not allowed by the repository policy")
+
+// defaultHosts are the hosts providers are reached at without an endpoint
+var defaultHosts = map[string]string{
+	"openai":    "api.openai.com",
+	"anthropic": "api.anthropic.com",
+	"ollama":    "localhost",
+}
+
+// Policy restricts the providers models may be created for and the hosts
+// they may be reached at, e.g. to keep a repository's data in a region.
+// Empty lists allow anything.
+type Policy struct {
+	// Allowed providers
+	Providers []string
+
+	// Allowed endpoint hosts, as glob patterns like "*.openai.azure.com"
+	Endpoints []string
+
+	// Why the policy exists, quoted in refusals
+	Reason string
+}
+
+var (
+	policyMu sync.RWMutex
+	policy   *Policy
+)
+
+// SetPolicy sets the policy models created afterwards must satisfy; nil
+// allows every model
+func SetPolicy(p *Policy) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	policy = p
+}
+
+// currentPolicy returns the policy set, or nil
+func currentPolicy() *Policy {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	return policy
+}
+
+// Validate checks the endpoint patterns of the policy
+func (p *Policy) Validate() error {
+	for _, pattern := range p.Endpoints {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.ConfigError("Validate", fmt.Sprintf("invalid policy endpoint pattern: %s", pattern))
+		}
+	}
+	return nil
+}
+
+// Allows returns an error wrapping ErrDisallowed when the policy does not
+// allow a model configured so
+func (p *Policy) Allows(config ModelConfig) error {
+	if p == nil {
+		return nil
+	}
+	name := config.Provider + ":" + config.Model
+	if len(p.Providers) > 0 && !containsFold(p.Providers, config.Provider) {
+		return p.refuse(name, fmt.Sprintf("provider %s is not allowed", config.Provider),
+			fmt.Sprintf("use a model of %s, or change policy.providers in the configuration", strings.Join(p.Providers, ", ")))
+	}
+	if len(p.Endpoints) == 0 {
+		return nil
+	}
+	host := EndpointHost(config)
+	for _, pattern := range p.Endpoints {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return nil
+		}
+	}
+	if host == "" {
+		host = "its default endpoint"
+	}
+	return p.refuse(name, fmt.Sprintf("endpoint %s is not allowed", host),
+		fmt.Sprintf("set models.configs.%s.endpoint to a host matching %s, or change policy.endpoints in the configuration",
+			config.Provider, strings.Join(p.Endpoints, ", ")))
+}
+
+// refuse builds the error refusing a model
+func (p *Policy) refuse(name, why, hint string) error {
+	message := fmt.Sprintf("model %s: %s", name, why)
+	if p.Reason != "" {
+		message += " (" + p.Reason + ")"
+	}
+	return errors.Wrap(ErrDisallowed, errors.ErrorTypeConfig, "CreateModel", message).
+		WithCode(errors.CodeDisallowed).
+		WithHint(hint)
+}
+
+// EndpointHost returns the lower-cased host a model is reached at: the host
+// of its endpoint, or its provider's default host
+func EndpointHost(config ModelConfig) string {
+	if config.Endpoint == "" {
+		return defaultHosts[strings.ToLower(config.Provider)]
+	}
+	endpoint := config.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "//" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// containsFold reports whether list holds s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+func TestPolicy_Allows(t *testing.T) {
+	var none *Policy
+	assert.NoError(t, none.Allows(ModelConfig{Provider: "openai", Model: "gpt-4"}))
+
+	policy := &Policy{
+		Providers: []string{"openai", "ollama"},
+		Endpoints: []string{"*.openai.azure.com", "localhost"},
+		Reason:    "data stays in the EU",
+	}
+	assert.NoError(t, policy.Allows(ModelConfig{Provider: "OpenAI", Model: "gpt-4", Endpoint: "https://sigil-eu.openai.azure.com/openai"}))
+	assert.NoError(t, policy.Allows(ModelConfig{Provider: "ollama", Model: "llama3"}), "the default host counts")
+
+	err := policy.Allows(ModelConfig{Provider: "anthropic", Model: "claude-3"})
+	require.ErrorIs(t, err, ErrDisallowed)
+	assert.Contains(t, err.Error(), "model anthropic:claude-3: provider anthropic is not allowed (data stays in the EU)")
+	assert.Equal(t, errors.CodeDisallowed, errors.CodeOf(err))
+	assert.Contains(t, errors.HintOf(err), "use a model of openai, ollama")
+
+	err = policy.Allows(ModelConfig{Provider: "openai", Model: "gpt-4"})
+	require.ErrorIs(t, err, ErrDisallowed)
+	assert.Contains(t, err.Error(), "endpoint api.openai.com is not allowed")
+	assert.Contains(t, errors.HintOf(err), "models.configs.openai.endpoint")
+}
+
+func TestEndpointHost(t *testing.T) {
+	assert.Equal(t, "api.anthropic.com", EndpointHost(ModelConfig{Provider: "anthropic"}))
+	assert.Equal(t, "gpu.example.com", EndpointHost(ModelConfig{Provider: "ollama", Endpoint: "http://GPU.example.com:11434"}))
+	assert.Equal(t, "eu.example.com", EndpointHost(ModelConfig{Provider: "openai", Endpoint: "eu.example.com/v1"}))
+	assert.Equal(t, "tools", EndpointHost(ModelConfig{Provider: "mcp", Endpoint: "mcp://tools/model"}))
+	assert.Empty(t, EndpointHost(ModelConfig{Provider: "custom"}))
+}
+
+func TestCreateModel_Policy(t *testing.T) {
+	originalProviders := defaultRegistry.providers
+	originalModels := defaultRegistry.models
+	defer func() {
+		defaultRegistry.providers = originalProviders
+		defaultRegistry.models = originalModels
+		SetPolicy(nil)
+	}()
+	defaultRegistry.providers = make(map[string]Factory)
+	defaultRegistry.models = make(map[string]Model)
+
+	mockFactory := &MockFactory{}
+	require.NoError(t, RegisterProvider("test", mockFactory))
+	SetPolicy(&Policy{Providers: []string{"anthropic"}})
+
+	_, err := CreateModel(ModelConfig{Provider: "test", Model: "refused"})
+	assert.ErrorIs(t, err, ErrDisallowed)
+	mockFactory.AssertNotCalled(t, "CreateModel")
+	assert.Empty(t, ListModels())
+}
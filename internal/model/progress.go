@@ -0,0 +1,41 @@
+package model
+
+import "context"
+
+// Progress reports how far a long-running operation, such as an MCP tool
+// call indexing a repository, has got.
+type Progress struct {
+	Source   string  // What is doing the work, such as an MCP server name
+	Token    string  // Identifies the operation among those in progress
+	Progress float64 // Work done so far
+	Total    float64 // Work to do in all; zero when unknown
+	Message  string  // Optional description of the current step
+}
+
+// Fraction returns the share of the work done, between 0 and 1, and false
+// when the total is unknown
+func (p Progress) Fraction() (float64, bool) {
+	if p.Total <= 0 {
+		return 0, false
+	}
+	return min(max(p.Progress/p.Total, 0), 1), true
+}
+
+// ProgressFunc receives progress reports. It may be called from any
+// goroutine and should return quickly.
+type ProgressFunc func(Progress)
+
+type progressKey struct{}
+
+// WithProgress returns a context asking providers that can report progress
+// on the requests made with it to pass it to fn
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ProgressFromContext returns the progress function set by WithProgress, or
+// nil when progress was not asked for
+func ProgressFromContext(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return fn
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressContext(t *testing.T) {
+	assert.Nil(t, ProgressFromContext(context.Background()))
+
+	var got []Progress
+	ctx := WithProgress(context.Background(), func(p Progress) { got = append(got, p) })
+	fn := ProgressFromContext(ctx)
+	require.NotNil(t, fn)
+
+	fn(Progress{Token: "1", Progress: 3})
+	assert.Equal(t, []Progress{{Token: "1", Progress: 3}}, got)
+}
+
+func TestProgress_Fraction(t *testing.T) {
+	fraction, ok := Progress{Progress: 25, Total: 100}.Fraction()
+	assert.True(t, ok)
+	assert.InDelta(t, 0.25, fraction, 1e-9)
+
+	fraction, ok = Progress{Progress: 120, Total: 100}.Fraction()
+	assert.True(t, ok)
+	assert.InDelta(t, 1, fraction, 1e-9)
+
+	_, ok = Progress{Progress: 25}.Fraction()
+	assert.False(t, ok)
+}
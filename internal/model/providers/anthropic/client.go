@@ -11,9 +11,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/version"
 )
 
 const (
@@ -39,7 +41,9 @@ func NewProvider() *Provider {
 // CreateModel creates an Anthropic model instance
 func (p *Provider) CreateModel(config model.ModelConfig) (model.Model, error) {
 	if config.APIKey == "" {
-		return nil, errors.ConfigError("CreateModel", "Anthropic API key is required")
+		return nil, errors.ConfigError("CreateModel", "Anthropic API key is required").
+			WithCode(errors.CodeMissingAPIKey).
+			WithHint("set ANTHROPIC_API_KEY or models.configs.anthropic.apikey in .sigil/config.yml")
 	}
 
 	baseURL := config.Endpoint
@@ -113,7 +117,7 @@ func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.P
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("x-api-key", m.apiKey)
 	httpReq.Header.Set("anthropic-version", apiVersion)
-	httpReq.Header.Set("User-Agent", "Sigil/1.0")
+	httpReq.Header.Set("User-Agent", version.UserAgent())
 
 	// Send request
 	resp, err := m.client.Do(httpReq)
@@ -130,8 +134,12 @@ func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.P
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return model.PromptOutput{}, errors.New(errors.ErrorTypeNetwork, "RunPrompt",
+		err := errors.New(errors.ErrorTypeNetwork, "RunPrompt",
 			fmt.Sprintf("API error %d: %s", resp.StatusCode, string(respBody)))
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return model.PromptOutput{}, model.RateLimited(err, resp.Header.Get("Retry-After"))
+		}
+		return model.PromptOutput{}, err
 	}
 
 	// Parse response
@@ -165,6 +173,7 @@ func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.P
 	}
 
 	duration := time.Since(start)
+	model.RecordUsage(m.Name(), output.TokensUsed)
 	logger.Debug("Anthropic request completed", "duration", duration, "tokens", output.TokensUsed)
 
 	return output, nil
@@ -224,7 +233,7 @@ func (m *Model) buildRequest(input model.PromptInput) MessageRequest {
 
 	// Add file context
 	if len(input.Files) > 0 {
-		contextText := m.buildFileContext(input.Files)
+		contextText := model.FormatFileContext(input.Files)
 		if contextText != "" {
 			userContent = append(userContent, ContentBlock{
 				Type: "text",
@@ -250,6 +259,9 @@ func (m *Model) buildRequest(input model.PromptInput) MessageRequest {
 	if input.Temperature > 0 {
 		temperature = float32(input.Temperature)
 	}
+	if deterministic.Enabled() {
+		temperature = 0
+	}
 
 	req := MessageRequest{
 		Model:       m.modelName,
@@ -266,24 +278,6 @@ func (m *Model) buildRequest(input model.PromptInput) MessageRequest {
 	return req
 }
 
-// buildFileContext builds context from file contents
-func (m *Model) buildFileContext(files []model.FileContent) string {
-	if len(files) == 0 {
-		return ""
-	}
-
-	var context strings.Builder
-	context.WriteString("\nAdditional context files:\n")
-
-	for _, file := range files {
-		context.WriteString(fmt.Sprintf("\n--- %s ---\n", file.Path))
-		context.WriteString(file.Content)
-		context.WriteString("\n")
-	}
-
-	return context.String()
-}
-
 // Anthropic API types
 
 // MessageRequest represents a message request
@@ -291,7 +285,7 @@ type MessageRequest struct {
 	Model       string    `json:"model"`
 	Messages    []Message `json:"messages"`
 	MaxTokens   int       `json:"max_tokens"`
-	Temperature float32   `json:"temperature,omitempty"`
+	Temperature float32   `json:"temperature"`
 	System      string    `json:"system,omitempty"`
 }
 
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -394,6 +395,18 @@ func TestModel_buildRequest(t *testing.T) {
 		assert.Equal(t, 1000, req.MaxTokens)           // default
 		assert.Equal(t, float32(0.7), req.Temperature) // default
 	})
+
+	t.Run("deterministic mode", func(t *testing.T) {
+		deterministic.Set(true)
+		defer deterministic.Set(false)
+
+		req := anthropicModel.buildRequest(model.PromptInput{UserPrompt: "Hello", Temperature: 0.3})
+		assert.Zero(t, req.Temperature)
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `"temperature":0`, "a zero temperature is still sent")
+	})
 }
 
 func TestMessage_Structure(t *testing.T) {
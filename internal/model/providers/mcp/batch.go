@@ -0,0 +1,151 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// BatchRequest is one request of a batch
+type BatchRequest struct {
+	Method string
+	Params interface{}
+}
+
+// BatchResult is the outcome of one request of a batch
+type BatchResult struct {
+	Result json.RawMessage
+	Err    error
+}
+
+// RequestBatch sends requests together and waits for all their responses,
+// returning results in request order. The requests go out as one JSON-RPC
+// batch when the transport and negotiated protocol revision allow it, and
+// otherwise as concurrent single requests.
+func (h *ProtocolHandler) RequestBatch(ctx context.Context, requests []BatchRequest) []BatchResult {
+	results := make([]BatchResult, len(requests))
+	if len(requests) == 0 {
+		return results
+	}
+
+	sender, ok := h.transport.(BatchSender)
+	if !ok || !h.Supports(FeatureBatching) {
+		var wg sync.WaitGroup
+		for i, req := range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := h.RequestContext(ctx, req.Method, req.Params)
+				results[i] = BatchResult{Result: result, Err: err}
+			}()
+		}
+		wg.Wait()
+		return results
+	}
+
+	if h.isClosed() {
+		for i := range results {
+			results[i].Err = ErrConnectionClosed
+		}
+		return results
+	}
+
+	msgs := make([]*RPCMessage, 0, len(requests))
+	pending := make([]*pendingRequest, len(requests))
+	for i, req := range requests {
+		paramsJSON, err := json.Marshal(req.Params)
+		if err != nil {
+			results[i].Err = fmt.Errorf("failed to marshal params: %w", err)
+			continue
+		}
+		id := h.requestID.Add(1)
+		msgs = append(msgs, &RPCMessage{JSONRPC: "2.0", ID: &id, Method: req.Method, Params: paramsJSON})
+
+		// Register before sending so a fast response cannot be missed
+		pending[i] = newPendingRequest(req.Method)
+		h.mu.Lock()
+		h.pendingRequests[id] = pending[i]
+		h.mu.Unlock()
+		defer h.takePending(id)
+	}
+	if len(msgs) == 0 {
+		return results
+	}
+
+	h.mu.RLock()
+	timeout := h.requestTimeout
+	h.mu.RUnlock()
+
+	if err := sender.SendBatch(msgs); err != nil {
+		for i := range pending {
+			if pending[i] != nil {
+				results[i].Err = fmt.Errorf("failed to send request: %w", err)
+			}
+		}
+		return results
+	}
+
+	var wg sync.WaitGroup
+	for i := range pending {
+		if pending[i] == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := h.await(ctx, pending[i], timeout)
+			results[i] = BatchResult{Result: result, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// ProcessBatch handles a batch of incoming messages. Responses and
+// notifications are handled as if they arrived alone; server-initiated
+// requests are answered together in one batch once every handler is done.
+func (h *ProtocolHandler) ProcessBatch(msgs []*RPCMessage) {
+	var requests []*RPCMessage
+	for _, msg := range msgs {
+		if msg != nil && msg.Method != "" && msg.ID != nil {
+			requests = append(requests, msg)
+			continue
+		}
+		h.ProcessMessage(msg)
+	}
+	if len(requests) == 0 {
+		return
+	}
+
+	go func() {
+		responses := make([]*RPCMessage, len(requests))
+		var wg sync.WaitGroup
+		for i, msg := range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				responses[i] = h.answer(msg)
+			}()
+		}
+		wg.Wait()
+		h.replyBatch(responses)
+	}()
+}
+
+// replyBatch sends the responses to a batch of server-initiated requests,
+// as a batch when the transport can send one
+func (h *ProtocolHandler) replyBatch(responses []*RPCMessage) {
+	sender, ok := h.transport.(BatchSender)
+	if !ok {
+		for _, response := range responses {
+			h.reply(response)
+		}
+		return
+	}
+	if err := sender.SendBatch(responses); err != nil {
+		logger.Warn("failed to send MCP batch response", "responses", len(responses), "error", err)
+	}
+}
@@ -0,0 +1,141 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchTransport is a MockTransport that can also send batches
+type batchTransport struct {
+	*MockTransport
+	batches chan []*RPCMessage
+}
+
+func newBatchTransport(t *testing.T) *batchTransport {
+	transport := NewMockTransport()
+	require.NoError(t, transport.Connect(context.Background()))
+	return &batchTransport{MockTransport: transport, batches: make(chan []*RPCMessage, 4)}
+}
+
+func (t *batchTransport) SendBatch(msgs []*RPCMessage) error {
+	t.batches <- msgs
+	return nil
+}
+
+func (t *batchTransport) nextBatch(tb testing.TB) []*RPCMessage {
+	select {
+	case batch := <-t.batches:
+		return batch
+	case <-time.After(time.Second):
+		tb.Fatal("no batch sent")
+		return nil
+	}
+}
+
+func TestProtocolHandler_RequestBatch(t *testing.T) {
+	transport := newBatchTransport(t)
+	handler := NewProtocolHandler(transport)
+	handler.state = StateReady
+	handler.protocolVersion = ProtocolVersion20250326
+
+	results := make(chan []BatchResult, 1)
+	go func() {
+		results <- handler.RequestBatch(context.Background(), []BatchRequest{
+			{Method: "tools/list"},
+			{Method: "resources/read", Params: map[string]string{"uri": "file:///missing"}},
+		})
+	}()
+
+	batch := transport.nextBatch(t)
+	require.Len(t, batch, 2)
+	assert.Equal(t, "tools/list", batch[0].Method)
+	assert.Equal(t, "resources/read", batch[1].Method)
+
+	// The server may answer in any order
+	handler.ProcessBatch([]*RPCMessage{
+		{JSONRPC: "2.0", ID: batch[1].ID, Error: &RPCError{Code: InvalidParams, Message: "no such resource"}},
+		{JSONRPC: "2.0", ID: batch[0].ID, Result: json.RawMessage(`{"tools":[]}`)},
+	})
+
+	got := <-results
+	require.Len(t, got, 2)
+	require.NoError(t, got[0].Err)
+	assert.JSONEq(t, `{"tools":[]}`, string(got[0].Result))
+	assert.ErrorContains(t, got[1].Err, "no such resource")
+	assert.Equal(t, 0, handler.PendingRequests())
+}
+
+func TestProtocolHandler_RequestBatchWithoutBatching(t *testing.T) {
+	// 2025-06-18 dropped batches, so requests go out one by one
+	transport := newBatchTransport(t)
+	handler := NewProtocolHandler(transport)
+	transport.SetMessageHandler(handler.ProcessMessage)
+	handler.state = StateReady
+	handler.protocolVersion = ProtocolVersion20250618
+
+	results := handler.RequestBatch(context.Background(), []BatchRequest{{Method: "ping"}, {Method: "tools/list"}})
+	require.Len(t, results, 2)
+	for _, result := range results {
+		require.NoError(t, result.Err)
+		assert.JSONEq(t, `{"status": "ok"}`, string(result.Result))
+	}
+	assert.Empty(t, transport.batches)
+}
+
+func TestProtocolHandler_ProcessBatch(t *testing.T) {
+	transport := newBatchTransport(t)
+	handler := NewProtocolHandler(transport)
+	handler.SetCoalesceWindow(0)
+	handler.SetRootsHandler(func(context.Context) ([]Root, error) {
+		return []Root{{URI: "file:///repo"}}, nil
+	})
+
+	pending := requestAsync(handler, "tools/list")
+	waitPending(t, handler, 1)
+
+	handler.ProcessBatch([]*RPCMessage{
+		serverRequest(7, "roots/list", ""),
+		{JSONRPC: "2.0", Method: "notifications/resources/list_changed"},
+		{JSONRPC: "2.0", ID: int64Ptr(1), Result: json.RawMessage(`{"tools":[]}`)},
+		serverRequest(8, "elicitation/create", `{}`),
+	})
+	require.NoError(t, <-pending)
+
+	// Both server requests are answered together
+	responses := transport.nextBatch(t)
+	require.Len(t, responses, 2)
+	assert.Equal(t, int64(7), *responses[0].ID)
+	assert.JSONEq(t, `{"roots":[{"uri":"file:///repo"}]}`, string(responses[0].Result))
+	assert.Equal(t, int64(8), *responses[1].ID)
+	require.NotNil(t, responses[1].Error)
+	assert.Equal(t, MethodNotFound, responses[1].Error.Code)
+}
+
+func TestStdioTransport_ReceivesBatches(t *testing.T) {
+	script := `read line
+echo '[{"jsonrpc":"2.0","id":1,"result":{"ok":true}}, "junk"]'
+cat >/dev/null`
+	transport := NewStdioTransport("sh", []string{"-c", script}, nil, DefaultTransportConfig())
+	handler := NewProtocolHandler(transport)
+	transport.SetMessageHandler(handler.ProcessMessage)
+	transport.SetBatchHandler(handler.ProcessBatch)
+	transport.SetDisconnectHandler(handler.ConnectionLost)
+
+	dropped := make(chan *FrameError, 4)
+	transport.SetFrameErrorHandler(func(err *FrameError) { dropped <- err })
+
+	require.NoError(t, transport.Connect(context.Background()))
+	defer transport.Close()
+
+	result, err := handler.Request("ping", nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok":true}`, string(result))
+
+	require.Eventually(t, func() bool { return len(dropped) == 1 }, time.Second, 5*time.Millisecond)
+	assert.ErrorIs(t, <-dropped, ErrMalformedMessage)
+}
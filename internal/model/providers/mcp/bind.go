@@ -0,0 +1,377 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ToolCaller calls tools on an MCP server. *Model implements it.
+type ToolCaller interface {
+	CallTool(ctx context.Context, toolCall ToolCall) (*ToolResult, error)
+}
+
+// CallBound calls a tool with typed arguments, as generated bindings do.
+// The arguments are checked against the tool's input schema, given as JSON,
+// before the call, and a result the server flags as an error is returned
+// along with an error.
+func CallBound(ctx context.Context, caller ToolCaller, tool string, schema string, args interface{}) (*ToolResult, error) {
+	arguments, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal arguments for tool %s: %w", tool, err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(arguments, &decoded); err != nil {
+		return nil, fmt.Errorf("arguments for tool %s are not a JSON object: %w", tool, err)
+	}
+	var inputSchema map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &inputSchema); err != nil {
+		return nil, fmt.Errorf("invalid input schema for tool %s: %w", tool, err)
+	}
+	if err := ValidateArguments(tool, inputSchema, decoded); err != nil {
+		return nil, err
+	}
+
+	result, err := caller.CallTool(ctx, ToolCall{Name: tool, Arguments: arguments})
+	if err != nil {
+		return nil, err
+	}
+	if result.IsError {
+		return result, fmt.Errorf("tool %s failed: %s", tool, result.Content)
+	}
+	return result, nil
+}
+
+// maxBindDepth bounds how deeply nested schemas, including $ref chains, are
+// turned into Go types before falling back to interface{}
+const maxBindDepth = 16
+
+// goInitialisms are written in upper case in generated identifiers
+var goInitialisms = map[string]bool{
+	"API": true, "CPU": true, "CSS": true, "DNS": true, "HTML": true, "HTTP": true,
+	"HTTPS": true, "ID": true, "IP": true, "JSON": true, "SQL": true, "SSH": true,
+	"TLS": true, "TTL": true, "UI": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// BindPackageName derives a Go package name from a server name
+func BindPackageName(server string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(server) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "mcp" + name
+	}
+	return name
+}
+
+// GenerateBindings emits the source of a Go package with one function per
+// tool, taking the tool's arguments as a struct generated from its input
+// schema. The functions call the tools through CallBound.
+func GenerateBindings(pkg, server string, tools []ToolDefinition) ([]byte, error) {
+	sorted := append([]ToolDefinition(nil), tools...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	g := &bindGenerator{names: map[string]bool{"Client": true, "New": true, "Server": true}}
+	var funcs bytes.Buffer
+	for _, tool := range sorted {
+		if err := g.tool(&funcs, tool); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by sigil mcp bind; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "// Package %s calls the tools of the %s MCP server with typed arguments.\n", pkg, server)
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	fmt.Fprintf(&out, "import (\n\t\"context\"\n\n\t\"github.com/dshills/sigil/internal/model/providers/mcp\"\n)\n\n")
+	fmt.Fprintf(&out, "// Server is the MCP server these bindings were generated from\nconst Server = %q\n\n", server)
+	fmt.Fprintf(&out, "// Client calls the tools of the %s server\ntype Client struct {\n\tcaller mcp.ToolCaller\n}\n\n", server)
+	fmt.Fprintf(&out, "// New returns a client calling tools through caller, such as the *mcp.Model\n// for the %s server\nfunc New(caller mcp.ToolCaller) *Client {\n\treturn &Client{caller: caller}\n}\n", server)
+	out.Write(funcs.Bytes())
+	out.Write(g.types.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated bindings: %w", err)
+	}
+	return src, nil
+}
+
+// bindGenerator turns tool definitions into Go declarations
+type bindGenerator struct {
+	names map[string]bool // Top-level identifiers in use
+	types bytes.Buffer    // Struct declarations
+	root  map[string]interface{}
+	refs  map[string]string // Types declared for the $refs of the current tool
+}
+
+// unique returns name, or name with a number appended if it is taken
+func (g *bindGenerator) unique(name string) string {
+	candidate := name
+	for i := 2; g.names[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	g.names[candidate] = true
+	return candidate
+}
+
+// tool writes the method calling one tool
+func (g *bindGenerator) tool(w *bytes.Buffer, tool ToolDefinition) error {
+	schema := tool.InputSchema
+	if schema == nil {
+		schema = map[string]interface{}{"type": "object"}
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("failed to encode input schema of tool %s: %w", tool.Name, err)
+	}
+
+	method := g.unique(goIdentifier(tool.Name))
+	argsType := g.unique(method + "Args")
+	schemaVar := "schema" + method
+	g.names[schemaVar] = true
+
+	g.root = schema
+	g.refs = make(map[string]string)
+	g.structType(argsType, fmt.Sprintf("are the arguments of the %s tool", tool.Name), schema, 0)
+
+	fmt.Fprintf(w, "\nconst %s = %s\n\n", schemaVar, strconv.Quote(string(schemaJSON)))
+	fmt.Fprintf(w, "%s", docComment(method, "calls the "+tool.Name+" tool", tool.Description))
+	fmt.Fprintf(w, "func (c *Client) %s(ctx context.Context, args %s) (*mcp.ToolResult, error) {\n", method, argsType)
+	fmt.Fprintf(w, "\treturn mcp.CallBound(ctx, c.caller, %q, %s, args)\n}\n", tool.Name, schemaVar)
+	return nil
+}
+
+// structType writes a struct declaration for an object schema
+func (g *bindGenerator) structType(name, summary string, schema map[string]interface{}, depth int) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, item := range list {
+			if key, ok := item.(string); ok {
+				required[key] = true
+			}
+		}
+	}
+
+	var body bytes.Buffer
+	fields := map[string]bool{}
+	for _, key := range sortedKeys(properties) {
+		prop, _ := properties[key].(map[string]interface{})
+
+		field := goIdentifier(key)
+		for base, i := field, 2; fields[field]; i++ {
+			field = base + strconv.Itoa(i)
+		}
+		fields[field] = true
+
+		goType := g.goType(name+field, prop, depth+1)
+		tag := key
+		if !required[key] {
+			tag += ",omitempty"
+			if optionalByPointer(goType) {
+				goType = "*" + goType
+			}
+		}
+
+		described := g.resolve(prop)
+		description, _ := prop["description"].(string)
+		if description == "" {
+			description, _ = described["description"].(string)
+		}
+		if enum, ok := described["enum"].([]interface{}); ok {
+			description = strings.TrimSpace(description + " (one of " + formatValues(enum) + ")")
+		}
+		for _, line := range commentLines(description) {
+			fmt.Fprintf(&body, "\t%s\n", line)
+		}
+		fmt.Fprintf(&body, "\t%s %s `json:%q`\n", field, goType, tag)
+	}
+
+	fmt.Fprintf(&g.types, "\n// %s %s\ntype %s struct {\n%s}\n", name, summary, name, body.String())
+}
+
+// goType returns the Go type for a property schema, declaring a struct for
+// objects with properties. Objects reached through a $ref are declared once
+// and used by pointer, so recursive schemas stay finite.
+func (g *bindGenerator) goType(name string, schema map[string]interface{}, depth int) string {
+	if schema == nil || depth > maxBindDepth {
+		return "interface{}"
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		if typeName, ok := g.refs[ref]; ok {
+			return typeName
+		}
+		target := g.resolve(schema)
+		if properties, ok := target["properties"].(map[string]interface{}); ok && len(properties) > 0 {
+			typeName := name
+			if token := ref[strings.LastIndex(ref, "/")+1:]; token != "" && token != "#" {
+				typeName = goIdentifier(token)
+			}
+			typeName = g.unique(typeName)
+			g.refs[ref] = "*" + typeName
+			g.structType(typeName, "is part of the arguments", target, depth)
+			return "*" + typeName
+		}
+		return g.goType(name, target, depth+1)
+	}
+
+	types, _ := schemaTypes(schema["type"])
+	// A nullable type such as ["string", "null"] is the type itself
+	if len(types) == 2 && (types[0] == "null" || types[1] == "null") {
+		if types[0] == "null" {
+			types = types[1:]
+		} else {
+			types = types[:1]
+		}
+	}
+	if len(types) != 1 {
+		return "interface{}"
+	}
+
+	switch types[0] {
+	case "string":
+		return "string"
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		if items == nil {
+			return "[]interface{}"
+		}
+		return "[]" + g.goType(name+"Item", items, depth+1)
+	case "object":
+		if properties, ok := schema["properties"].(map[string]interface{}); ok && len(properties) > 0 {
+			typeName := g.unique(name)
+			g.structType(typeName, "is part of the arguments", schema, depth)
+			return typeName
+		}
+		return "map[string]interface{}"
+	}
+	return "interface{}"
+}
+
+// resolve follows a local $ref, returning schema unchanged otherwise and
+// nil when the reference cannot be resolved
+func (g *bindGenerator) resolve(schema map[string]interface{}) map[string]interface{} {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema
+	}
+	target, err := (&schemaValidator{root: g.root}).resolveRef(ref)
+	if err != nil {
+		return nil
+	}
+	return target
+}
+
+// optionalByPointer reports whether an optional field of a generated type
+// needs a pointer to tell a zero value from one left out. Strings are left
+// as they are since servers rarely give an empty string a meaning.
+func optionalByPointer(goType string) bool {
+	switch {
+	case goType == "string", goType == "interface{}":
+		return false
+	case strings.HasPrefix(goType, "*"), strings.HasPrefix(goType, "[]"), strings.HasPrefix(goType, "map["):
+		return false
+	}
+	return true
+}
+
+// goIdentifier turns a tool or property name such as "get_file-contents"
+// or "repoURL" into an exported Go identifier such as GetFileContents
+func goIdentifier(name string) string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		// Split camelCase, keeping runs of capitals such as "URL" together
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		upper := strings.ToUpper(w)
+		if goInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		rs := []rune(strings.ToLower(w))
+		rs[0] = unicode.ToUpper(rs[0])
+		b.WriteString(string(rs))
+	}
+
+	id := b.String()
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "X" + id
+	}
+	return id
+}
+
+// docComment renders a doc comment starting with name, followed by the
+// description if there is one
+func docComment(name, summary, description string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s %s", name, summary)
+	lines := commentLines(description)
+	if len(lines) == 0 {
+		b.WriteString("\n")
+		return b.String()
+	}
+	b.WriteString(".\n//\n")
+	for _, line := range lines {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// commentLines renders text as Go line comments
+func commentLines(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line = strings.TrimRight(line, " \t\r"); line == "" {
+			lines[i] = "//"
+		} else {
+			lines[i] = "// " + line
+		}
+	}
+	return lines
+}
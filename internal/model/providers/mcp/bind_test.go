@@ -0,0 +1,134 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingCaller records tool calls and answers them with result
+type recordingCaller struct {
+	calls  []ToolCall
+	result *ToolResult
+}
+
+func (c *recordingCaller) CallTool(_ context.Context, toolCall ToolCall) (*ToolResult, error) {
+	c.calls = append(c.calls, toolCall)
+	return c.result, nil
+}
+
+func TestGoIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"create_issue":      "CreateIssue",
+		"get-file-contents": "GetFileContents",
+		"repoURL":           "RepoURL",
+		"HTTPRequest":       "HTTPRequest",
+		"userId":            "UserID",
+		"search.code":       "SearchCode",
+		"2fa":               "X2fa",
+		"":                  "X",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, goIdentifier(name), name)
+	}
+
+	assert.Equal(t, "githubmcp", BindPackageName("GitHub-MCP"))
+	assert.Equal(t, "mcp42", BindPackageName("42"))
+}
+
+func TestGenerateBindings(t *testing.T) {
+	tools := []ToolDefinition{
+		{
+			Name:        "search_code",
+			Description: "Search the repository.\n\nMatches are ranked by relevance.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{"type": "string", "description": "Text to find"},
+					"limit": map[string]interface{}{"type": "integer"},
+					"exact": map[string]interface{}{"type": "boolean"},
+					"mode":  map[string]interface{}{"type": "string", "enum": []interface{}{"regex", "literal"}},
+					"paths": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+					"range": map[string]interface{}{
+						"type":       "object",
+						"properties": map[string]interface{}{"start": map[string]interface{}{"type": "number"}},
+					},
+					"owner": map[string]interface{}{"$ref": "#/$defs/user"},
+					"extra": map[string]interface{}{},
+				},
+				"required": []interface{}{"query"},
+				"$defs": map[string]interface{}{
+					"user": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"login":   map[string]interface{}{"type": "string"},
+							"manager": map[string]interface{}{"$ref": "#/$defs/user"},
+						},
+					},
+				},
+			},
+		},
+		{Name: "ping"},
+	}
+
+	src, err := GenerateBindings("search", "code-search", tools)
+	require.NoError(t, err)
+
+	file, err := parser.ParseFile(token.NewFileSet(), "client.go", src, parser.ParseComments)
+	require.NoError(t, err, string(src))
+	assert.Equal(t, "search", file.Name.Name)
+
+	code := string(src)
+	// Compare fields without gofmt's column alignment
+	fields := strings.Join(strings.Fields(code), " ")
+	assert.Contains(t, code, "// Code generated by sigil mcp bind; DO NOT EDIT.")
+	assert.Contains(t, code, "func (c *Client) SearchCode(ctx context.Context, args SearchCodeArgs) (*mcp.ToolResult, error)")
+	assert.Contains(t, code, "func (c *Client) Ping(ctx context.Context, args PingArgs) (*mcp.ToolResult, error)")
+	assert.Contains(t, code, "// Matches are ranked by relevance.")
+	assert.Contains(t, fields, "Query string `json:\"query\"`")
+	assert.Contains(t, fields, "Limit *int64 `json:\"limit,omitempty\"`")
+	assert.Contains(t, fields, "Exact *bool `json:\"exact,omitempty\"`")
+	assert.Contains(t, code, `// (one of "regex", "literal")`)
+	assert.Contains(t, fields, "Paths []string `json:\"paths,omitempty\"`")
+	assert.Contains(t, fields, "Range *SearchCodeArgsRange `json:\"range,omitempty\"`")
+	assert.Contains(t, fields, "Start *float64 `json:\"start,omitempty\"`")
+	assert.Contains(t, fields, "Owner *User `json:\"owner,omitempty\"`")
+	assert.Contains(t, fields, "Manager *User `json:\"manager,omitempty\"`", "recursive references reuse the type")
+	assert.Contains(t, fields, "Extra interface{} `json:\"extra,omitempty\"`")
+
+	again, err := GenerateBindings("search", "code-search", []ToolDefinition{tools[1], tools[0]})
+	require.NoError(t, err)
+	assert.Equal(t, code, string(again), "output does not depend on listing order")
+}
+
+func TestCallBound(t *testing.T) {
+	type args struct {
+		Query string `json:"query"`
+		Limit *int64 `json:"limit,omitempty"`
+	}
+	schema := `{"type":"object","properties":{"query":{"type":"string","minLength":1},"limit":{"type":"integer"}},"required":["query"]}`
+
+	caller := &recordingCaller{result: &ToolResult{Content: json.RawMessage(`[{"type":"text","text":"ok"}]`)}}
+	result, err := CallBound(context.Background(), caller, "search_code", schema, args{Query: "TODO"})
+	require.NoError(t, err)
+	assert.Equal(t, caller.result, result)
+	require.Len(t, caller.calls, 1)
+	assert.Equal(t, "search_code", caller.calls[0].Name)
+	assert.JSONEq(t, `{"query":"TODO"}`, string(caller.calls[0].Arguments))
+
+	// Arguments violating the schema never reach the server
+	_, err = CallBound(context.Background(), caller, "search_code", schema, args{})
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+	assert.Len(t, caller.calls, 1)
+
+	caller.result = &ToolResult{Content: json.RawMessage(`[{"type":"text","text":"boom"}]`), IsError: true}
+	result, err = CallBound(context.Background(), caller, "search_code", schema, args{Query: "TODO"})
+	assert.ErrorContains(t, err, "boom")
+	assert.True(t, result.IsError)
+}
@@ -9,9 +9,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/trust"
 )
 
 // Provider implements the MCP model provider
@@ -37,6 +39,8 @@ func NewProvider() *Provider {
 	if err := provider.loadConfigurations(); err != nil {
 		logger.Warn("failed to load MCP configurations", "error", err)
 	}
+	provider.refreshRoots()
+	provider.applyPoolConfig()
 
 	return provider
 }
@@ -76,17 +80,20 @@ func (p *Provider) CreateModel(config model.ModelConfig) (model.Model, error) {
 		modelName = parts[1]
 	}
 
-	// Get or start server
-	server, err := p.getOrStartServer(serverName, config)
-	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrorTypeNetwork, "CreateModel", "failed to get MCP server")
-	}
-
-	return &Model{
+	mcpModel := &Model{
 		modelName: modelName,
-		server:    server,
+		config:    p.resolveServerConfig(serverName, config),
 		provider:  p,
-	}, nil
+	}
+
+	// Lazily started servers are launched by the first request instead
+	if !mcpModel.config.LazyStart {
+		if _, err := mcpModel.activeServer(context.Background()); err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeNetwork, "CreateModel", "failed to get MCP server")
+		}
+	}
+
+	return mcpModel, nil
 }
 
 // ListModels returns available MCP models
@@ -122,20 +129,27 @@ func (p *Provider) Name() string {
 	return "mcp"
 }
 
+// SetSamplingModel lets MCP servers request completions from mdl via sampling
+func (p *Provider) SetSamplingModel(mdl model.Model) {
+	if mdl == nil {
+		p.processManager.SetSamplingHandler(nil)
+		return
+	}
+	p.processManager.SetSamplingHandler(NewModelSamplingHandler(mdl))
+}
+
 // Shutdown stops all MCP servers
 func (p *Provider) Shutdown() {
 	p.processManager.StopAll()
 }
 
-// getOrStartServer gets an existing server or starts a new one
-func (p *Provider) getOrStartServer(serverName string, modelConfig model.ModelConfig) (*ManagedServer, error) {
-	// Check if server already running
-	server, err := p.processManager.GetServer(serverName)
-	if err == nil && server.Transport.IsConnected() {
-		return server, nil
-	}
+// EnsureServer starts a configured server unless it is already running
+func (p *Provider) EnsureServer(ctx context.Context, serverName string) (*ManagedServer, error) {
+	return p.processManager.EnsureServer(ctx, p.resolveServerConfig(serverName, model.ModelConfig{}))
+}
 
-	// Get server configuration
+// resolveServerConfig returns the configuration used to start serverName
+func (p *Provider) resolveServerConfig(serverName string, modelConfig model.ModelConfig) ServerConfig {
 	var serverConfig ServerConfig
 
 	// First check loaded configurations
@@ -152,9 +166,7 @@ func (p *Provider) getOrStartServer(serverName string, modelConfig model.ModelCo
 		serverConfig = p.parseServerConfig(serverName, modelConfig)
 	}
 
-	// Start server
-	ctx := context.Background()
-	return p.processManager.StartServer(ctx, serverConfig)
+	return serverConfig
 }
 
 // mergeModelOptions merges model-specific options into server config
@@ -246,18 +258,135 @@ func (p *Provider) parseServerConfig(serverName string, config model.ModelConfig
 type Model struct {
 	modelName string
 	server    *ManagedServer
+	config    ServerConfig
 	provider  *Provider
+	toolCache *ToolCache
+	mu        sync.Mutex
+}
+
+// SetToolCache memoizes tool results in cache, typically one cache per task.
+// A nil cache turns memoization off.
+func (m *Model) SetToolCache(cache *ToolCache) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.toolCache = cache
+}
+
+// getToolCache returns the attached tool cache, if any
+func (m *Model) getToolCache() *ToolCache {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.toolCache
+}
+
+// activeServer returns the model's server, starting it again if it was
+// never started or has been stopped for being idle
+func (m *Model) activeServer(ctx context.Context) (*ManagedServer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.server != nil && (m.provider == nil || !m.server.isStopped()) {
+		m.server.touch()
+		return m.server, nil
+	}
+	if m.provider == nil {
+		return nil, fmt.Errorf("MCP server %s is not running", m.config.Name)
+	}
+
+	server, err := m.provider.processManager.EnsureServer(ctx, m.config)
+	if err != nil {
+		return nil, err
+	}
+	m.server = server
+	return server, nil
+}
+
+// currentServer returns the model's server without starting it
+func (m *Model) currentServer() *ManagedServer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.server
+}
+
+// serverName returns the name of the model's server
+func (m *Model) serverName() string {
+	if server := m.currentServer(); server != nil {
+		return server.Name
+	}
+	return m.config.Name
 }
 
 // RunPrompt executes a prompt against MCP server
 func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.PromptOutput, error) {
 	start := time.Now()
 
-	logger.Debug("sending request to MCP server", "model", m.modelName, "server", m.server.Name)
+	server, params, err := m.prepare(ctx, input)
+	if err != nil {
+		return model.PromptOutput{}, err
+	}
+
+	// Send completion request
+	result, err := server.Protocol.CompleteContext(withProgressSource(ctx, server.Name), params)
+	if err != nil {
+		return model.PromptOutput{}, errors.Wrap(err, errors.ErrorTypeNetwork, "RunPrompt", "completion request failed")
+	}
+	return m.output(server, result, start), nil
+}
+
+// StreamPrompt executes a prompt against MCP server, passing the response
+// on as the server streams it
+func (m *Model) StreamPrompt(ctx context.Context, input model.PromptInput, onChunk model.ChunkFunc) (model.PromptOutput, error) {
+	start := time.Now()
+
+	server, params, err := m.prepare(ctx, input)
+	if err != nil {
+		return model.PromptOutput{}, err
+	}
+
+	chunks, err := server.Protocol.CompleteStream(withProgressSource(ctx, server.Name), params)
+	if err != nil {
+		return model.PromptOutput{}, errors.Wrap(err, errors.ErrorTypeNetwork, "StreamPrompt", "completion request failed")
+	}
+
+	var response strings.Builder
+	var result *CompletionResult
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			return model.PromptOutput{}, errors.Wrap(chunk.Err, errors.ErrorTypeNetwork, "StreamPrompt", "completion request failed")
+		}
+		if chunk.Content != "" {
+			response.WriteString(chunk.Content)
+			onChunk(chunk.Content)
+		}
+		if chunk.Done {
+			result = &chunk.CompletionResult
+		}
+	}
+	if result == nil {
+		err := ctx.Err()
+		if err == nil {
+			err = ErrConnectionClosed
+		}
+		return model.PromptOutput{}, errors.Wrap(err, errors.ErrorTypeNetwork, "StreamPrompt", "completion stream ended early")
+	}
+
+	result.Content = response.String()
+	return m.output(server, result, start), nil
+}
+
+// prepare starts the model's server and builds the completion parameters
+// for input
+func (m *Model) prepare(ctx context.Context, input model.PromptInput) (*ManagedServer, CompletionParams, error) {
+	server, err := m.activeServer(ctx)
+	if err != nil {
+		return nil, CompletionParams{}, errors.Wrap(err, errors.ErrorTypeNetwork, "RunPrompt", "failed to start MCP server")
+	}
+
+	logger.Debug("sending request to MCP server", "model", m.modelName, "server", server.Name)
 
 	// Check server is connected
-	if !m.server.Transport.IsConnected() {
-		return model.PromptOutput{}, errors.New(errors.ErrorTypeNetwork, "RunPrompt", "MCP server not connected")
+	if !server.Transport.IsConnected() {
+		return nil, CompletionParams{}, errors.New(errors.ErrorTypeNetwork, "RunPrompt", "MCP server not connected")
 	}
 
 	// Build completion parameters
@@ -267,27 +396,26 @@ func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.P
 		Stream:   false,
 	}
 
-	if input.Temperature > 0 {
-		params.Temperature = input.Temperature
+	if deterministic.Enabled() {
+		params.Temperature = new(float64)
+	} else if input.Temperature > 0 {
+		params.Temperature = &input.Temperature
 	}
 	if input.MaxTokens > 0 {
 		params.MaxTokens = input.MaxTokens
 	}
+	return server, params, nil
+}
 
-	// Send completion request
-	result, err := m.server.Protocol.Complete(params)
-	if err != nil {
-		return model.PromptOutput{}, errors.Wrap(err, errors.ErrorTypeNetwork, "RunPrompt", "completion request failed")
-	}
-
-	// Build output
+// output builds the prompt output from a completion, recording its usage
+func (m *Model) output(server *ManagedServer, result *CompletionResult, start time.Time) model.PromptOutput {
 	output := model.PromptOutput{
 		Response:   result.Content,
 		TokensUsed: 0,
 		Model:      m.modelName,
 		Metadata: map[string]string{
-			"server":      m.server.Name,
-			"mcp_version": "1.0",
+			"server":      server.Name,
+			"mcp_version": server.Protocol.ProtocolVersion(),
 		},
 	}
 
@@ -299,16 +427,17 @@ func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.P
 	}
 
 	duration := time.Since(start)
+	model.RecordUsage(m.Name(), output.TokensUsed)
 	logger.Debug("MCP request completed", "duration", duration, "tokens", output.TokensUsed)
 
-	return output, nil
+	return output
 }
 
 // GetCapabilities returns the model's capabilities
 func (m *Model) GetCapabilities() model.ModelCapabilities {
 	// Get capabilities from server if available
-	if m.server.Protocol.IsInitialized() {
-		caps := m.server.Protocol.GetServerCapabilities()
+	if server := m.currentServer(); server != nil && server.Protocol.IsInitialized() {
+		caps := server.Protocol.GetServerCapabilities()
 		if caps != nil {
 			return model.ModelCapabilities{
 				MaxTokens:         8192, // Default, should query from server
@@ -330,7 +459,7 @@ func (m *Model) GetCapabilities() model.ModelCapabilities {
 
 // Name returns the model identifier
 func (m *Model) Name() string {
-	return fmt.Sprintf("mcp:%s/%s", m.server.Name, m.modelName)
+	return fmt.Sprintf("mcp:%s/%s", m.serverName(), m.modelName)
 }
 
 // buildMessages converts input to MCP messages
@@ -364,13 +493,7 @@ func (m *Model) buildMessages(input model.PromptInput) []Message {
 		if userContent.Len() > 0 {
 			userContent.WriteString("\n\n")
 		}
-		userContent.WriteString("Additional context files:\n")
-
-		for _, file := range input.Files {
-			userContent.WriteString(fmt.Sprintf("\n--- %s ---\n", file.Path))
-			userContent.WriteString(file.Content)
-			userContent.WriteString("\n")
-		}
+		userContent.WriteString(strings.TrimPrefix(model.FormatFileContext(input.Files), "\n"))
 	}
 
 	if userContent.Len() > 0 {
@@ -393,14 +516,54 @@ type ToolCall struct {
 
 // ToolResult represents the result of a tool execution
 type ToolResult struct {
-	ToolCallID string          `json:"toolCallId"`
-	Content    json.RawMessage `json:"content"`
-	IsError    bool            `json:"isError,omitempty"`
+	ToolCallID        string          `json:"toolCallId"`
+	Content           json.RawMessage `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"`
 }
 
-// CallTool executes a tool on the MCP server
+// CallTool executes a tool on the MCP server, answering repeated calls to
+// cacheable tools from the attached tool cache. Tool calls are refused in
+// untrusted mode, where their arguments may come from the repository.
 func (m *Model) CallTool(ctx context.Context, toolCall ToolCall) (*ToolResult, error) {
-	if !m.server.Protocol.IsInitialized() {
+	if err := trust.Check("CallTool", "MCP tool calls"); err != nil {
+		return nil, err
+	}
+
+	cache := m.getToolCache()
+	if cache == nil {
+		return m.callTool(ctx, toolCall)
+	}
+
+	serverName := m.serverName()
+	if !cache.knowsTool(serverName, toolCall.Name) {
+		// Listing records the tool annotations in the cache
+		if _, err := m.ListTools(); err != nil {
+			logger.Debug("failed to list MCP tools for caching", "server", serverName, "error", err)
+		}
+		if !cache.knowsTool(serverName, toolCall.Name) {
+			// Treat a tool missing from the listing as unannotated rather than listing again
+			cache.RememberTools(serverName, []ToolDefinition{{Name: toolCall.Name}})
+		}
+	}
+	if !cache.Cacheable(serverName, toolCall.Name) {
+		cache.bypass()
+		return m.callTool(ctx, toolCall)
+	}
+
+	key := toolCacheKey(serverName, toolCall.Name, toolCall.Arguments)
+	return cache.do(key, func() (*ToolResult, error) {
+		return m.callTool(ctx, toolCall)
+	})
+}
+
+// callTool sends a tool call to the server
+func (m *Model) callTool(ctx context.Context, toolCall ToolCall) (*ToolResult, error) {
+	server, err := m.activeServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !server.Protocol.IsInitialized() {
 		return nil, fmt.Errorf("server protocol not initialized")
 	}
 
@@ -411,10 +574,16 @@ func (m *Model) CallTool(ctx context.Context, toolCall ToolCall) (*ToolResult, e
 	}
 
 	// Call tool on server
-	result, err := m.server.Protocol.CallTool(toolCall.Name, args)
+	result, err := server.Protocol.CallToolContext(withProgressSource(ctx, server.Name), toolCall.Name, args)
 	if err != nil {
+		// Report failures, including schema violations, as tool errors so the
+		// caller can correct the arguments and retry
+		content, marshalErr := json.Marshal(map[string]string{"error": err.Error()})
+		if marshalErr != nil {
+			return nil, fmt.Errorf("failed to marshal tool error: %w", marshalErr)
+		}
 		return &ToolResult{
-			Content: json.RawMessage(fmt.Sprintf(`{"error": "%s"}`, err.Error())),
+			Content: content,
 			IsError: true,
 		}, nil
 	}
@@ -425,60 +594,98 @@ func (m *Model) CallTool(ctx context.Context, toolCall ToolCall) (*ToolResult, e
 		return nil, fmt.Errorf("failed to marshal tool result: %w", err)
 	}
 
-	return &ToolResult{
+	toolResult := &ToolResult{
 		Content: contentBytes,
 		IsError: result.IsError,
-	}, nil
+	}
+
+	// Structured output is only defined from 2025-06-18 onwards
+	if result.StructuredContent != nil && server.Protocol.Supports(FeatureStructuredContent) {
+		structured, err := json.Marshal(result.StructuredContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal structured tool result: %w", err)
+		}
+		toolResult.StructuredContent = structured
+	}
+
+	return toolResult, nil
 }
 
 // ListTools returns available tools from the server
 func (m *Model) ListTools() ([]ToolDefinition, error) {
-	if !m.server.Protocol.IsInitialized() {
+	server, err := m.activeServer(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if !server.Protocol.IsInitialized() {
 		return nil, fmt.Errorf("server protocol not initialized")
 	}
 
-	return m.server.Protocol.ListTools()
+	tools, err := server.Protocol.ListTools()
+	if err != nil {
+		return nil, err
+	}
+	if cache := m.getToolCache(); cache != nil {
+		cache.RememberTools(server.Name, tools)
+	}
+	return tools, nil
 }
 
 // ListResources returns available resources from the server
 func (m *Model) ListResources() ([]ResourceDefinition, error) {
-	if !m.server.Protocol.IsInitialized() {
+	server, err := m.activeServer(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if !server.Protocol.IsInitialized() {
 		return nil, fmt.Errorf("server protocol not initialized")
 	}
 
-	return m.server.Protocol.ListResources()
+	return server.Protocol.ListResources()
 }
 
 // ReadResource reads a resource from the server
 func (m *Model) ReadResource(uri string) (*ResourceContent, error) {
-	if !m.server.Protocol.IsInitialized() {
+	server, err := m.activeServer(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if !server.Protocol.IsInitialized() {
 		return nil, fmt.Errorf("server protocol not initialized")
 	}
 
-	return m.server.Protocol.ReadResource(uri)
+	return server.Protocol.ReadResource(uri)
 }
 
 // ListPrompts returns available prompt templates from the server
 func (m *Model) ListPrompts() ([]PromptTemplate, error) {
-	if !m.server.Protocol.IsInitialized() {
+	server, err := m.activeServer(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if !server.Protocol.IsInitialized() {
 		return nil, fmt.Errorf("server protocol not initialized")
 	}
 
-	return m.server.Protocol.ListPrompts()
+	return server.Protocol.ListPrompts()
 }
 
 // GetPrompt gets a prompt template with arguments
 func (m *Model) GetPrompt(name string, arguments map[string]interface{}) (*PromptResult, error) {
-	if !m.server.Protocol.IsInitialized() {
+	server, err := m.activeServer(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if !server.Protocol.IsInitialized() {
 		return nil, fmt.Errorf("server protocol not initialized")
 	}
 
-	return m.server.Protocol.GetPrompt(name, arguments)
+	return server.Protocol.GetPrompt(name, arguments)
 }
 
 // GetConnectionPool returns a pooled connection for this model
 func (m *Model) GetConnectionPool() (*ManagedServer, error) {
-	return m.provider.processManager.GetPooledConnection(m.server.Name)
+	return m.provider.processManager.GetPooledConnection(m.serverName())
 }
 
 // ReleaseConnection releases a pooled connection
@@ -488,7 +695,11 @@ func (m *Model) ReleaseConnection(server *ManagedServer) {
 
 // GetServerStatus returns the status of the underlying server
 func (m *Model) GetServerStatus() ServerStatus {
-	return m.server.GetStatus()
+	server := m.currentServer()
+	if server == nil {
+		return ServerStatus{Name: m.config.Name}
+	}
+	return server.GetStatus()
 }
 
 // Enhanced provider methods
@@ -512,6 +723,16 @@ func (p *Provider) GetPoolStatus() map[string][]ServerStatus {
 	return p.processManager.GetPoolStatus()
 }
 
+// GetPoolStats returns connection pool counters
+func (p *Provider) GetPoolStats() PoolStats {
+	return p.processManager.GetPoolStats()
+}
+
+// SubscribeLifecycle subscribes to server lifecycle events
+func (p *Provider) SubscribeLifecycle() *LifecycleSubscription {
+	return p.processManager.SubscribeLifecycle()
+}
+
 // GetOverallHealth returns overall health metrics
 func (p *Provider) GetOverallHealth() map[string]interface{} {
 	return p.processManager.GetOverallHealth()
@@ -549,5 +770,9 @@ func (p *Provider) StopServer(name string) error {
 
 // ReloadConfigurations reloads server configurations from files
 func (p *Provider) ReloadConfigurations() error {
-	return p.loadConfigurations()
+	if err := p.loadConfigurations(); err != nil {
+		return err
+	}
+	p.refreshRoots()
+	return nil
 }
@@ -178,10 +178,7 @@ func TestProvider_MergeModelOptions(t *testing.T) {
 	defer provider.Shutdown()
 
 	serverConfig := ServerConfig{
-		Settings: struct {
-			Timeout    string `yaml:"timeout" json:"timeout"`
-			MaxRetries int    `yaml:"maxRetries" json:"maxRetries"`
-		}{
+		Settings: ServerSettings{
 			Timeout:    "10s",
 			MaxRetries: 1,
 		},
@@ -211,7 +208,7 @@ func TestModel_GetCapabilities(t *testing.T) {
 	// Create a mock server with capabilities
 	mockServer := &ManagedServer{
 		Protocol: &ProtocolHandler{
-			initialized: true,
+			state: StateReady,
 			serverCaps: &ServerCapabilities{
 				Streaming: true,
 				Tools:     true,
@@ -232,7 +229,7 @@ func TestModel_GetCapabilities(t *testing.T) {
 	assert.False(t, caps.SupportsImages)
 
 	// Test with uninitialized protocol
-	mcpModel.server.Protocol.initialized = false
+	mcpModel.server.Protocol.state = StateUninitialized
 	caps = mcpModel.GetCapabilities()
 	assert.Equal(t, 4096, caps.MaxTokens)
 	assert.True(t, caps.SupportsTools)
@@ -300,7 +297,7 @@ func TestModel_RunPrompt_Disconnected(t *testing.T) {
 func TestModel_ToolCalling(t *testing.T) {
 	// Create mock server with uninitialized protocol
 	mockServer := &ManagedServer{
-		Protocol: &ProtocolHandler{initialized: false},
+		Protocol: &ProtocolHandler{state: StateUninitialized},
 	}
 
 	mcpModel := &Model{
@@ -328,7 +325,7 @@ func TestModel_ToolCalling(t *testing.T) {
 func TestModel_ResourceManagement(t *testing.T) {
 	// Create mock server with uninitialized protocol
 	mockServer := &ManagedServer{
-		Protocol: &ProtocolHandler{initialized: false},
+		Protocol: &ProtocolHandler{state: StateUninitialized},
 	}
 
 	mcpModel := &Model{
@@ -350,7 +347,7 @@ func TestModel_ResourceManagement(t *testing.T) {
 func TestModel_PromptTemplates(t *testing.T) {
 	// Create mock server with uninitialized protocol
 	mockServer := &ManagedServer{
-		Protocol: &ProtocolHandler{initialized: false},
+		Protocol: &ProtocolHandler{state: StateUninitialized},
 	}
 
 	mcpModel := &Model{
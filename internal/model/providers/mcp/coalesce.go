@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// DefaultCoalesceWindow is how long high-frequency notifications are held
+// so that only the latest of a burst is handled
+const DefaultCoalesceWindow = 50 * time.Millisecond
+
+// notificationCoalescer holds progress and resource update notifications
+// for a short window, keeping only the latest for each progress token or
+// resource, so chatty servers cost one handling per window rather than one
+// per notification. A nil coalescer holds nothing.
+type notificationCoalescer struct {
+	window time.Duration
+	handle func(*RPCMessage)
+
+	mu      sync.Mutex
+	held    map[string]*RPCMessage
+	order   []string // Keys in the order they were first held
+	timer   *time.Timer
+	stopped bool
+}
+
+// newNotificationCoalescer creates a coalescer passing notifications to
+// handle once their window closes
+func newNotificationCoalescer(window time.Duration, handle func(*RPCMessage)) *notificationCoalescer {
+	return &notificationCoalescer{
+		window: window,
+		handle: handle,
+		held:   make(map[string]*RPCMessage),
+	}
+}
+
+// coalesceKey returns what a notification supersedes earlier notifications
+// by, reporting false for notifications that are never coalesced
+func coalesceKey(msg *RPCMessage) (string, bool) {
+	switch msg.Method {
+	case "notifications/progress":
+		var params struct {
+			ProgressToken json.RawMessage `json:"progressToken"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil || len(params.ProgressToken) == 0 {
+			return "", false
+		}
+		return "progress:" + string(params.ProgressToken), true
+	case "notifications/resources/updated":
+		var params struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil || params.URI == "" {
+			return "", false
+		}
+		return "resource:" + params.URI, true
+	}
+	return "", false
+}
+
+// hold keeps a notification until the window closes, replacing any held
+// notification with the same key. It reports false for notifications that
+// are not coalesced, which the caller handles straight away.
+func (c *notificationCoalescer) hold(msg *RPCMessage) bool {
+	if c == nil {
+		return false
+	}
+	key, ok := coalesceKey(msg)
+	if !ok {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.window <= 0 {
+		return false
+	}
+	if c.stopped {
+		return true
+	}
+	if _, ok := c.held[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.held[key] = msg
+	if c.timer == nil {
+		c.timer = time.AfterFunc(c.window, c.flush)
+	}
+	return true
+}
+
+// setWindow changes how long notifications are held. Zero stops coalescing.
+func (c *notificationCoalescer) setWindow(window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.window = window
+}
+
+// flush handles the held notifications in the order they were first held
+func (c *notificationCoalescer) flush() {
+	c.mu.Lock()
+	held, order := c.held, c.order
+	c.held = make(map[string]*RPCMessage)
+	c.order = nil
+	c.timer = nil
+	stopped := c.stopped
+	c.mu.Unlock()
+
+	if stopped {
+		return
+	}
+	for _, key := range order {
+		c.handle(held[key])
+	}
+}
+
+// stop discards held notifications and holds no more
+func (c *notificationCoalescer) stop() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stopped = true
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.held = make(map[string]*RPCMessage)
+	c.order = nil
+}
@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func notification(method, params string) *RPCMessage {
+	return &RPCMessage{JSONRPC: "2.0", Method: method, Params: json.RawMessage(params)}
+}
+
+// recordedParams collects the params of handled notifications
+type recordedParams struct {
+	mu     sync.Mutex
+	params []string
+}
+
+func (r *recordedParams) handle(msg *RPCMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.params = append(r.params, string(msg.Params))
+}
+
+func (r *recordedParams) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.params...)
+}
+
+func TestNotificationCoalescer(t *testing.T) {
+	var handled recordedParams
+	coalescer := newNotificationCoalescer(20*time.Millisecond, handled.handle)
+
+	assert.True(t, coalescer.hold(notification("notifications/progress", `{"progressToken":"index","progress":10,"total":100}`)))
+	assert.True(t, coalescer.hold(notification("notifications/progress", `{"progressToken":7,"progress":1}`)))
+	assert.True(t, coalescer.hold(notification("notifications/resources/updated", `{"uri":"file:///a"}`)))
+	assert.True(t, coalescer.hold(notification("notifications/progress", `{"progressToken":"index","progress":40,"total":100}`)))
+	assert.True(t, coalescer.hold(notification("notifications/resources/updated", `{"uri":"file:///a"}`)))
+
+	assert.False(t, coalescer.hold(notification("notifications/tools/list_changed", `{}`)))
+	assert.False(t, coalescer.hold(notification("notifications/progress", `{"progress":1}`)), "progress without a token is not coalesced")
+
+	require.Eventually(t, func() bool { return len(handled.get()) == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{
+		`{"progressToken":"index","progress":40,"total":100}`,
+		`{"progressToken":7,"progress":1}`,
+		`{"uri":"file:///a"}`,
+	}, handled.get())
+
+	coalescer.setWindow(0)
+	assert.False(t, coalescer.hold(notification("notifications/progress", `{"progressToken":"index","progress":50}`)))
+}
+
+func TestNotificationCoalescer_Stop(t *testing.T) {
+	var handled recordedParams
+	coalescer := newNotificationCoalescer(10*time.Millisecond, handled.handle)
+
+	assert.True(t, coalescer.hold(notification("notifications/progress", `{"progressToken":"index","progress":10}`)))
+	coalescer.stop()
+	assert.True(t, coalescer.hold(notification("notifications/progress", `{"progressToken":"index","progress":20}`)))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Empty(t, handled.get())
+
+	var nilCoalescer *notificationCoalescer
+	assert.False(t, nilCoalescer.hold(notification("notifications/progress", `{"progressToken":"index"}`)))
+	nilCoalescer.stop()
+}
@@ -44,12 +44,29 @@ func (cl *ConfigLoader) LoadConfigurations() ([]ServerConfig, error) {
 				WorkingDir:  srv.WorkingDir,
 				AutoRestart: srv.AutoRestart,
 				MaxRestarts: srv.MaxRestarts,
-				Settings: struct {
-					Timeout    string `yaml:"timeout" json:"timeout"`
-					MaxRetries int    `yaml:"maxRetries" json:"maxRetries"`
-				}{
-					Timeout:    srv.Settings.Timeout,
-					MaxRetries: srv.Settings.MaxRetries,
+				LazyStart:   srv.LazyStart,
+				IdleTimeout: srv.IdleTimeout,
+				Project:     true,
+				Settings: ServerSettings{
+					Timeout:         srv.Settings.Timeout,
+					MaxRetries:      srv.Settings.MaxRetries,
+					ProtocolVersion: srv.Settings.ProtocolVersion,
+					MaxMessageSize:  srv.Settings.MaxMessageSize,
+					BufferSize:      srv.Settings.BufferSize,
+					WriteTimeout:    srv.Settings.WriteTimeout,
+					CoalesceWindow:  srv.Settings.CoalesceWindow,
+					HealthCheck: HealthCheckSettings{
+						Interval:         srv.Settings.HealthCheck.Interval,
+						Timeout:          srv.Settings.HealthCheck.Timeout,
+						FailureThreshold: srv.Settings.HealthCheck.FailureThreshold,
+						Probe:            HealthProbe(srv.Settings.HealthCheck.Probe),
+					},
+					Backoff: BackoffSettings{
+						InitialDelay: srv.Settings.Backoff.InitialDelay,
+						MaxDelay:     srv.Settings.Backoff.MaxDelay,
+						Multiplier:   srv.Settings.Backoff.Multiplier,
+						Jitter:       srv.Settings.Backoff.Jitter,
+					},
 				},
 			}
 			configMap[srv.Name] = serverConfig
@@ -75,6 +92,7 @@ func (cl *ConfigLoader) LoadConfigurations() ([]ServerConfig, error) {
 			logger.Warn("failed to load project MCP configs", "error", err)
 		} else {
 			for _, cfg := range projectConfigs {
+				cfg.Project = true
 				configMap[cfg.Name] = cfg // Project configs override global
 			}
 		}
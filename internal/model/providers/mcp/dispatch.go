@@ -0,0 +1,253 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// RequestHandler answers a server-initiated request. The returned value is
+// encoded as the JSON-RPC result; returning an *RPCError sends that error
+// verbatim, any other error is reported as an internal error.
+type RequestHandler func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// RegisterRequestHandler registers a handler for server-initiated requests to method
+func (h *ProtocolHandler) RegisterRequestHandler(method string, handler RequestHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.requestHandlers == nil {
+		h.requestHandlers = make(map[string]RequestHandler)
+	}
+	if handler == nil {
+		delete(h.requestHandlers, method)
+		return
+	}
+	h.requestHandlers[method] = handler
+}
+
+// hasRequestHandler reports whether a handler is registered for method
+func (h *ProtocolHandler) hasRequestHandler(method string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	_, ok := h.requestHandlers[method]
+	return ok
+}
+
+// dispatchRequest runs the handler for a server-initiated request and sends
+// the response. It runs on its own goroutine so slow handlers such as sampling
+// never block the transport reader.
+func (h *ProtocolHandler) dispatchRequest(msg *RPCMessage) {
+	if !h.hasRequestHandler(msg.Method) {
+		h.reply(h.answer(msg))
+		return
+	}
+	go h.reply(h.answer(msg))
+}
+
+// answer runs the handler for a server-initiated request and returns the
+// response to send
+func (h *ProtocolHandler) answer(msg *RPCMessage) (response *RPCMessage) {
+	h.mu.RLock()
+	handler, ok := h.requestHandlers[msg.Method]
+	h.mu.RUnlock()
+
+	if !ok {
+		if msg.Method == "ping" {
+			return resultResponse(msg.ID, struct{}{})
+		}
+		return errorResponse(msg.ID, &RPCError{
+			Code:    MethodNotFound,
+			Message: fmt.Sprintf("Method not found: %s", msg.Method),
+		})
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("MCP request handler panicked", "method", msg.Method, "panic", r)
+			response = errorResponse(msg.ID, &RPCError{Code: InternalError, Message: "internal error"})
+		}
+	}()
+
+	result, err := handler(context.Background(), msg.Params)
+	if err != nil {
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &RPCError{Code: InternalError, Message: err.Error()}
+		}
+		return errorResponse(msg.ID, rpcErr)
+	}
+	return resultResponse(msg.ID, result)
+}
+
+// resultResponse builds a successful response to a server-initiated request
+func resultResponse(id *int64, result interface{}) *RPCMessage {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(id, &RPCError{Code: InternalError, Message: fmt.Sprintf("failed to marshal result: %v", err)})
+	}
+	return &RPCMessage{JSONRPC: "2.0", ID: id, Result: resultJSON}
+}
+
+// errorResponse builds an error response to a server-initiated request
+func errorResponse(id *int64, rpcErr *RPCError) *RPCMessage {
+	return &RPCMessage{JSONRPC: "2.0", ID: id, Error: rpcErr}
+}
+
+// reply sends a response to a server-initiated request
+func (h *ProtocolHandler) reply(response *RPCMessage) {
+	if err := h.transport.Send(response); err != nil {
+		logger.Warn("failed to send MCP response", "id", *response.ID, "error", err)
+	}
+}
+
+// Sampling support
+
+// SamplingMessage is a conversation message in a sampling request
+type SamplingMessage struct {
+	Role    string          `json:"role"`
+	Content ToolCallContent `json:"content"`
+}
+
+// ModelHint names a model family the server would prefer
+type ModelHint struct {
+	Name string `json:"name,omitempty"`
+}
+
+// ModelPreferences expresses the server's model selection priorities
+type ModelPreferences struct {
+	Hints                []ModelHint `json:"hints,omitempty"`
+	CostPriority         float64     `json:"costPriority,omitempty"`
+	SpeedPriority        float64     `json:"speedPriority,omitempty"`
+	IntelligencePriority float64     `json:"intelligencePriority,omitempty"`
+}
+
+// CreateMessageParams are the parameters of a sampling/createMessage request
+type CreateMessageParams struct {
+	Messages         []SamplingMessage      `json:"messages"`
+	ModelPreferences *ModelPreferences      `json:"modelPreferences,omitempty"`
+	SystemPrompt     string                 `json:"systemPrompt,omitempty"`
+	IncludeContext   string                 `json:"includeContext,omitempty"`
+	Temperature      float64                `json:"temperature,omitempty"`
+	MaxTokens        int                    `json:"maxTokens"`
+	StopSequences    []string               `json:"stopSequences,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// CreateMessageResult is the client's answer to a sampling request
+type CreateMessageResult struct {
+	Role       string          `json:"role"`
+	Content    ToolCallContent `json:"content"`
+	Model      string          `json:"model"`
+	StopReason string          `json:"stopReason,omitempty"`
+}
+
+// SamplingHandler produces a completion on behalf of a server
+type SamplingHandler func(ctx context.Context, params CreateMessageParams) (*CreateMessageResult, error)
+
+// SetSamplingHandler enables the sampling capability and answers
+// sampling/createMessage requests with handler. A nil handler disables sampling.
+func (h *ProtocolHandler) SetSamplingHandler(handler SamplingHandler) {
+	if handler == nil {
+		h.RegisterRequestHandler("sampling/createMessage", nil)
+		return
+	}
+
+	h.RegisterRequestHandler("sampling/createMessage", func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var params CreateMessageParams
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, &RPCError{Code: InvalidParams, Message: fmt.Sprintf("invalid sampling parameters: %v", err)}
+		}
+		if len(params.Messages) == 0 {
+			return nil, &RPCError{Code: InvalidParams, Message: "sampling request has no messages"}
+		}
+		return handler(ctx, params)
+	})
+}
+
+// NewModelSamplingHandler answers sampling requests using a Sigil model
+func NewModelSamplingHandler(mdl model.Model) SamplingHandler {
+	return func(ctx context.Context, params CreateMessageParams) (*CreateMessageResult, error) {
+		var prompt strings.Builder
+		for _, msg := range params.Messages {
+			if msg.Content.Type != ContentTypeText {
+				return nil, &RPCError{Code: InvalidParams, Message: fmt.Sprintf("unsupported sampling content type: %s", msg.Content.Type)}
+			}
+			if prompt.Len() > 0 {
+				prompt.WriteString("\n\n")
+			}
+			prompt.WriteString(fmt.Sprintf("%s: %s", msg.Role, msg.Content.Text))
+		}
+
+		output, err := mdl.RunPrompt(ctx, model.PromptInput{
+			SystemPrompt: params.SystemPrompt,
+			UserPrompt:   prompt.String(),
+			MaxTokens:    params.MaxTokens,
+			Temperature:  params.Temperature,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		modelName := output.Model
+		if modelName == "" {
+			modelName = mdl.Name()
+		}
+
+		return &CreateMessageResult{
+			Role:       "assistant",
+			Content:    ToolCallContent{Type: ContentTypeText, Text: output.Response},
+			Model:      modelName,
+			StopReason: "endTurn",
+		}, nil
+	}
+}
+
+// Roots support
+
+// Root is a filesystem location the client exposes to servers
+type Root struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+// RootsHandler returns the roots currently exposed to servers
+type RootsHandler func(ctx context.Context) ([]Root, error)
+
+// SetRootsHandler enables the roots capability and answers roots/list
+// requests with handler. A nil handler disables roots.
+func (h *ProtocolHandler) SetRootsHandler(handler RootsHandler) {
+	if handler == nil {
+		h.RegisterRequestHandler("roots/list", nil)
+		return
+	}
+
+	h.RegisterRequestHandler("roots/list", func(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+		roots, err := handler(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if roots == nil {
+			roots = []Root{}
+		}
+		return struct {
+			Roots []Root `json:"roots"`
+		}{Roots: roots}, nil
+	})
+}
+
+// NotifyRootsChanged tells the server that the roots have changed so it can
+// request them again. It does nothing before initialization or when roots
+// are not enabled.
+func (h *ProtocolHandler) NotifyRootsChanged() error {
+	if !h.IsInitialized() || !h.hasRequestHandler("roots/list") {
+		return nil
+	}
+	return h.Notify("notifications/roots/list_changed", nil)
+}
@@ -0,0 +1,175 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/dshills/sigil/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDispatchHandler returns a connected handler whose outgoing messages can be inspected
+func newDispatchHandler(t *testing.T) (*ProtocolHandler, *MockTransport) {
+	transport := NewMockTransport()
+	require.NoError(t, transport.Connect(context.Background()))
+	return NewProtocolHandler(transport), transport
+}
+
+func serverRequest(id int64, method string, params string) *RPCMessage {
+	msg := &RPCMessage{JSONRPC: "2.0", ID: int64Ptr(id), Method: method}
+	if params != "" {
+		msg.Params = json.RawMessage(params)
+	}
+	return msg
+}
+
+func TestProtocolHandler_UnknownServerRequest(t *testing.T) {
+	handler, transport := newDispatchHandler(t)
+
+	handler.ProcessMessage(serverRequest(7, "elicitation/create", `{}`))
+
+	response := transport.GetLastMessage()
+	require.NotNil(t, response)
+	assert.Equal(t, int64(7), *response.ID)
+	assert.Empty(t, response.Method)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, MethodNotFound, response.Error.Code)
+}
+
+func TestProtocolHandler_ServerPing(t *testing.T) {
+	handler, transport := newDispatchHandler(t)
+
+	handler.ProcessMessage(serverRequest(3, "ping", ""))
+
+	response := transport.GetLastMessage()
+	require.NotNil(t, response)
+	assert.Nil(t, response.Error)
+	assert.JSONEq(t, `{}`, string(response.Result))
+}
+
+func TestProtocolHandler_ServerRequestDoesNotResolvePending(t *testing.T) {
+	handler, transport := newDispatchHandler(t)
+
+	// A pending client request shares the ID the server picks for its own request
+	pending := newPendingRequest("tools/list")
+	handler.pendingRequests[1] = pending
+
+	handler.ProcessMessage(serverRequest(1, "roots/list", ""))
+
+	assert.Len(t, pending.response, 0)
+	assert.Equal(t, 1, handler.PendingRequests())
+	response := transport.GetLastMessage()
+	require.NotNil(t, response)
+	assert.Equal(t, MethodNotFound, response.Error.Code)
+}
+
+func TestProtocolHandler_RegisteredHandler(t *testing.T) {
+	handler, transport := newDispatchHandler(t)
+
+	handler.RegisterRequestHandler("custom/echo", func(_ context.Context, params json.RawMessage) (interface{}, error) {
+		return json.RawMessage(params), nil
+	})
+	handler.RegisterRequestHandler("custom/fail", func(context.Context, json.RawMessage) (interface{}, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	handler.RegisterRequestHandler("custom/invalid", func(context.Context, json.RawMessage) (interface{}, error) {
+		return nil, &RPCError{Code: InvalidParams, Message: "bad"}
+	})
+
+	handler.ProcessMessage(serverRequest(1, "custom/echo", `{"a":1}`))
+	response := transport.GetLastMessage()
+	require.NotNil(t, response)
+	assert.JSONEq(t, `{"a":1}`, string(response.Result))
+
+	handler.ProcessMessage(serverRequest(2, "custom/fail", ""))
+	response = transport.GetLastMessage()
+	require.NotNil(t, response)
+	assert.Equal(t, InternalError, response.Error.Code)
+	assert.Equal(t, "boom", response.Error.Message)
+
+	handler.ProcessMessage(serverRequest(3, "custom/invalid", ""))
+	response = transport.GetLastMessage()
+	require.NotNil(t, response)
+	assert.Equal(t, InvalidParams, response.Error.Code)
+}
+
+// samplingModel answers every prompt with a fixed response
+type samplingModel struct {
+	input model.PromptInput
+}
+
+func (m *samplingModel) RunPrompt(_ context.Context, input model.PromptInput) (model.PromptOutput, error) {
+	m.input = input
+	return model.PromptOutput{Response: "sampled", Model: "test-model"}, nil
+}
+
+func (m *samplingModel) GetCapabilities() model.ModelCapabilities {
+	return model.ModelCapabilities{}
+}
+
+func (m *samplingModel) Name() string {
+	return "test:sampling"
+}
+
+func TestProtocolHandler_Sampling(t *testing.T) {
+	handler, transport := newDispatchHandler(t)
+	mdl := &samplingModel{}
+	handler.SetSamplingHandler(NewModelSamplingHandler(mdl))
+
+	handler.ProcessMessage(serverRequest(9, "sampling/createMessage",
+		`{"messages":[{"role":"user","content":{"type":"text","text":"hi"}}],"systemPrompt":"be brief","maxTokens":50}`))
+
+	response := transport.GetLastMessage()
+	require.NotNil(t, response)
+	require.Nil(t, response.Error)
+
+	var result CreateMessageResult
+	require.NoError(t, json.Unmarshal(response.Result, &result))
+	assert.Equal(t, "assistant", result.Role)
+	assert.Equal(t, "sampled", result.Content.Text)
+	assert.Equal(t, "test-model", result.Model)
+	assert.Equal(t, "be brief", mdl.input.SystemPrompt)
+	assert.Equal(t, "user: hi", mdl.input.UserPrompt)
+	assert.Equal(t, 50, mdl.input.MaxTokens)
+
+	handler.ProcessMessage(serverRequest(10, "sampling/createMessage", `{"messages":[]}`))
+	response = transport.GetLastMessage()
+	require.NotNil(t, response)
+	assert.Equal(t, InvalidParams, response.Error.Code)
+}
+
+func TestProtocolHandler_RootsList(t *testing.T) {
+	handler, transport := newDispatchHandler(t)
+	handler.SetRootsHandler(func(context.Context) ([]Root, error) {
+		return []Root{{URI: "file:///repo", Name: "repo"}}, nil
+	})
+
+	handler.ProcessMessage(serverRequest(4, "roots/list", ""))
+	response := transport.GetLastMessage()
+	require.NotNil(t, response)
+	assert.JSONEq(t, `{"roots":[{"uri":"file:///repo","name":"repo"}]}`, string(response.Result))
+
+	handler.SetRootsHandler(nil)
+	handler.ProcessMessage(serverRequest(5, "roots/list", ""))
+	response = transport.GetLastMessage()
+	require.NotNil(t, response)
+	assert.Equal(t, MethodNotFound, response.Error.Code)
+}
+
+func TestProtocolHandler_AdvertisesHandlerCapabilities(t *testing.T) {
+	handler, transport := newNegotiationHandler(t, `{"protocolVersion":"2025-06-18","serverInfo":{"name":"s","version":"1"},"capabilities":{}}`)
+	handler.SetSamplingHandler(NewModelSamplingHandler(&samplingModel{}))
+	handler.SetRootsHandler(func(context.Context) ([]Root, error) { return nil, nil })
+
+	_, err := handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{})
+	require.NoError(t, err)
+	assert.True(t, handler.Supports(FeatureSampling))
+
+	var params InitializeParams
+	require.NoError(t, json.Unmarshal(transport.GetLastMessage().Params, &params))
+	assert.True(t, params.Capabilities.Sampling)
+	assert.True(t, params.Capabilities.Roots)
+}
@@ -0,0 +1,52 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// secretRefPrefix marks an environment reference resolved from the secret store
+const secretRefPrefix = "secret:"
+
+// SecretLookup resolves a named credential for a server's environment
+type SecretLookup func(name string) (string, error)
+
+// expandEnv renders a server's environment as KEY=value pairs. Values may
+// reference the CLI's environment as ${VAR} and stored credentials as
+// ${secret:name}; secrets are only ever placed in the server's environment.
+func expandEnv(env map[string]string, lookup SecretLookup) ([]string, error) {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]string, 0, len(env))
+	for _, k := range keys {
+		var expandErr error
+		value := os.Expand(env[k], func(ref string) string {
+			name, isSecret := strings.CutPrefix(ref, secretRefPrefix)
+			if !isSecret {
+				return os.Getenv(ref)
+			}
+			if lookup == nil {
+				if expandErr == nil {
+					expandErr = fmt.Errorf("env %s references secret %q but no secret store is configured", k, name)
+				}
+				return ""
+			}
+			secret, err := lookup(name)
+			if err != nil && expandErr == nil {
+				expandErr = fmt.Errorf("env %s: %w", k, err)
+			}
+			return secret
+		})
+		if expandErr != nil {
+			return nil, expandErr
+		}
+		result = append(result, fmt.Sprintf("%s=%s", k, value))
+	}
+	return result, nil
+}
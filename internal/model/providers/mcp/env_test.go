@@ -0,0 +1,55 @@
+package mcp
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("SIGIL_TEST_HOST", "db.local")
+	lookup := func(name string) (string, error) {
+		if name == "github_token" {
+			return "ghp_123", nil
+		}
+		return "", fmt.Errorf("secret %q is not set", name)
+	}
+
+	env, err := expandEnv(map[string]string{
+		"GITHUB_TOKEN": "${secret:github_token}",
+		"DATABASE_URL": "postgres://${SIGIL_TEST_HOST}/app",
+		"PLAIN":        "value",
+	}, lookup)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"DATABASE_URL=postgres://db.local/app",
+		"GITHUB_TOKEN=ghp_123",
+		"PLAIN=value",
+	}, env)
+
+	_, err = expandEnv(map[string]string{"TOKEN": "${secret:missing}"}, lookup)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TOKEN")
+	assert.Contains(t, err.Error(), "missing")
+
+	_, err = expandEnv(map[string]string{"TOKEN": "${secret:github_token}"}, nil)
+	assert.Error(t, err)
+}
+
+func TestProcessManager_MissingSecretFailsStart(t *testing.T) {
+	pm := NewProcessManager()
+	defer pm.StopAll()
+	pm.SetSecretLookup(func(name string) (string, error) {
+		return "", fmt.Errorf("secret %q is not set", name)
+	})
+
+	config := fakeServerConfig("needs-secret")
+	config.Env = map[string]string{"TOKEN": "${secret:absent}"}
+
+	_, err := pm.StartServer(t.Context(), config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "absent")
+	assert.Empty(t, pm.ListServers())
+}
@@ -0,0 +1,167 @@
+package mcp
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// lifecycleBufferSize is how many events a lifecycle subscriber can fall
+// behind before further events are dropped for it
+const lifecycleBufferSize = 64
+
+// LifecycleEventType identifies a change in a managed server's lifecycle
+type LifecycleEventType string
+
+const (
+	LifecycleStarted       LifecycleEventType = "started"
+	LifecycleStartFailed   LifecycleEventType = "start_failed"
+	LifecycleStopped       LifecycleEventType = "stopped"
+	LifecycleUnhealthy     LifecycleEventType = "unhealthy"
+	LifecycleRestarting    LifecycleEventType = "restarting"
+	LifecycleRestarted     LifecycleEventType = "restarted"
+	LifecycleRestartFailed LifecycleEventType = "restart_failed"
+	LifecycleGaveUp        LifecycleEventType = "gave_up"
+)
+
+// Reasons a server is stopped, as reported in LifecycleStopped events
+const (
+	StopRequested   = "requested"    // StopServer was called
+	StopIdle        = "idle"         // Unused for longer than its idle timeout
+	StopMaxRestarts = "max_restarts" // Still unhealthy after its last restart attempt
+	StopShutdown    = "shutdown"     // The process manager shut down
+)
+
+// LifecycleEvent describes a change in a managed server's lifecycle
+type LifecycleEvent struct {
+	Type      LifecycleEventType `json:"type"`
+	Server    string             `json:"server"`
+	Timestamp time.Time          `json:"timestamp"`
+
+	// Implementation is the name and version the server reported on start
+	Implementation string `json:"implementation,omitempty"`
+
+	// Reason says why a server was stopped
+	Reason string `json:"reason,omitempty"`
+
+	// Attempt counts restart attempts, from 1
+	Attempt int `json:"attempt,omitempty"`
+
+	// Delay is the wait before a restart attempt
+	Delay time.Duration `json:"delay,omitempty"`
+
+	// Error is the failure behind the event, if any
+	Error string `json:"error,omitempty"`
+}
+
+// LifecycleSubscription receives lifecycle events from a ProcessManager
+type LifecycleSubscription struct {
+	stream  *lifecycleStream
+	ch      chan LifecycleEvent
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+// Events returns the channel events are delivered on. It is closed by Unsubscribe.
+func (s *LifecycleSubscription) Events() <-chan LifecycleEvent {
+	return s.ch
+}
+
+// Dropped returns how many events were dropped because the subscriber fell behind
+func (s *LifecycleSubscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe stops delivery and closes the events channel
+func (s *LifecycleSubscription) Unsubscribe() {
+	s.stream.mu.Lock()
+	delete(s.stream.subs, s)
+	s.stream.mu.Unlock()
+	s.once.Do(func() { close(s.ch) })
+}
+
+// lifecycleStream fans lifecycle events out to subscribers. Publishing never
+// blocks: a subscriber that falls behind loses the newest events.
+type lifecycleStream struct {
+	mu   sync.RWMutex
+	subs map[*LifecycleSubscription]bool
+}
+
+func newLifecycleStream() *lifecycleStream {
+	return &lifecycleStream{subs: make(map[*LifecycleSubscription]bool)}
+}
+
+// subscribe registers a new subscriber
+func (s *lifecycleStream) subscribe() *LifecycleSubscription {
+	sub := &LifecycleSubscription{stream: s, ch: make(chan LifecycleEvent, lifecycleBufferSize)}
+	s.mu.Lock()
+	s.subs[sub] = true
+	s.mu.Unlock()
+	return sub
+}
+
+// publish delivers event to every subscriber with room for it
+func (s *lifecycleStream) publish(event LifecycleEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for sub := range s.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// SubscribeLifecycle returns a subscription to the lifecycle events of all
+// managed servers: starts, stops, health failures and restarts
+func (pm *ProcessManager) SubscribeLifecycle() *LifecycleSubscription {
+	return pm.lifecycle.subscribe()
+}
+
+// emit logs a lifecycle event and publishes it to subscribers
+func (pm *ProcessManager) emit(event LifecycleEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	attrs := []any{"server", event.Server}
+	if event.Implementation != "" {
+		attrs = append(attrs, "implementation", event.Implementation)
+	}
+	if event.Reason != "" {
+		attrs = append(attrs, "reason", event.Reason)
+	}
+	if event.Attempt > 0 {
+		attrs = append(attrs, "attempt", event.Attempt)
+	}
+	if event.Delay > 0 {
+		attrs = append(attrs, "delay", event.Delay.Round(time.Millisecond))
+	}
+	if event.Error != "" {
+		attrs = append(attrs, "error", event.Error)
+	}
+
+	msg := "MCP server " + string(event.Type)
+	switch event.Type {
+	case LifecycleStarted, LifecycleStopped, LifecycleRestarted:
+		logger.Info(msg, attrs...)
+	case LifecycleGaveUp, LifecycleStartFailed:
+		logger.Error(msg, attrs...)
+	default:
+		logger.Warn(msg, attrs...)
+	}
+
+	pm.lifecycle.publish(event)
+}
+
+// errorText returns err's message, or nothing for a nil error
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
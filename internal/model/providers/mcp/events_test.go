@@ -0,0 +1,74 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextLifecycleEvent waits for the next event on sub
+func nextLifecycleEvent(t *testing.T, sub *LifecycleSubscription) LifecycleEvent {
+	t.Helper()
+	select {
+	case event := <-sub.Events():
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no lifecycle event")
+		return LifecycleEvent{}
+	}
+}
+
+func TestProcessManager_LifecycleEvents(t *testing.T) {
+	pm := NewProcessManager()
+	defer pm.StopAll()
+	sub := pm.SubscribeLifecycle()
+	defer sub.Unsubscribe()
+
+	_, err := pm.StartServer(context.Background(), fakeServerConfig("fake"))
+	require.NoError(t, err)
+	started := nextLifecycleEvent(t, sub)
+	assert.Equal(t, LifecycleStarted, started.Type)
+	assert.Equal(t, "fake", started.Server)
+	assert.Equal(t, "fake 1", started.Implementation)
+	assert.False(t, started.Timestamp.IsZero())
+
+	require.NoError(t, pm.StopServer("fake"))
+	stopped := nextLifecycleEvent(t, sub)
+	assert.Equal(t, LifecycleStopped, stopped.Type)
+	assert.Equal(t, StopRequested, stopped.Reason)
+
+	_, err = pm.StartServer(context.Background(), ServerConfig{Name: "missing", Command: "/does/not/exist"})
+	require.Error(t, err)
+	failed := nextLifecycleEvent(t, sub)
+	assert.Equal(t, LifecycleStartFailed, failed.Type)
+	assert.NotEmpty(t, failed.Error)
+
+	server := addIdleServer(t, pm, 20*time.Millisecond)
+	go pm.monitorIdle(server)
+	idle := nextLifecycleEvent(t, sub)
+	assert.Equal(t, LifecycleStopped, idle.Type)
+	assert.Equal(t, StopIdle, idle.Reason)
+}
+
+func TestLifecycleSubscription_DropsWhenFull(t *testing.T) {
+	pm := NewProcessManager()
+	defer pm.StopAll()
+	slow := pm.SubscribeLifecycle()
+	fast := pm.SubscribeLifecycle()
+
+	for i := 0; i < lifecycleBufferSize+5; i++ {
+		pm.emit(LifecycleEvent{Type: LifecycleRestarting, Server: "fake", Attempt: i + 1})
+		<-fast.Events()
+	}
+	assert.Equal(t, int64(5), slow.Dropped())
+	assert.Zero(t, fast.Dropped())
+	assert.Equal(t, 1, (<-slow.Events()).Attempt, "the oldest events are kept")
+
+	slow.Unsubscribe()
+	slow.Unsubscribe()
+	pm.emit(LifecycleEvent{Type: LifecycleRestarted, Server: "fake"})
+	assert.Equal(t, LifecycleRestarted, (<-fast.Events()).Type)
+}
@@ -0,0 +1,204 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// DefaultMaxMessageSize bounds a single newline-delimited message
+const DefaultMaxMessageSize = 4 << 20
+
+// frameSnippetSize is how much of a bad frame is kept for diagnostics
+const frameSnippetSize = 256
+
+var (
+	// ErrMessageTooLarge is reported for frames exceeding the maximum message size
+	ErrMessageTooLarge = errors.New("mcp message too large")
+
+	// ErrMalformedMessage is reported for frames that are not valid JSON-RPC messages
+	ErrMalformedMessage = errors.New("mcp message malformed")
+
+	// ErrTruncatedMessage is reported for a partial frame cut off by the end of the stream
+	ErrTruncatedMessage = errors.New("mcp message truncated")
+)
+
+// FrameError describes a frame the transport dropped. The connection stays
+// usable: the reader skips to the next newline and carries on.
+type FrameError struct {
+	// Err is one of ErrMessageTooLarge, ErrMalformedMessage or ErrTruncatedMessage
+	Err error
+
+	// Size is the length of the dropped frame in bytes
+	Size int
+
+	// ID is the request ID recovered from the frame, if it looked like a response
+	ID *int64
+
+	// Snippet is the start of the frame
+	Snippet string
+
+	// Cause is the underlying decoding error, if any
+	Cause error
+}
+
+// Error implements error
+func (e *FrameError) Error() string {
+	msg := fmt.Sprintf("%v (%d bytes)", e.Err, e.Size)
+	if e.Cause != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Cause)
+	}
+	return msg
+}
+
+// Unwrap returns the error class so callers can use errors.Is
+func (e *FrameError) Unwrap() error {
+	return e.Err
+}
+
+// newFrameError builds a FrameError for a dropped frame
+func newFrameError(kind error, frame []byte, size int, cause error) *FrameError {
+	snippet := frame
+	if len(snippet) > frameSnippetSize {
+		snippet = snippet[:frameSnippetSize]
+	}
+	return &FrameError{
+		Err:     kind,
+		Size:    size,
+		ID:      responseID(snippet),
+		Snippet: string(snippet),
+		Cause:   cause,
+	}
+}
+
+var frameIDPattern = regexp.MustCompile(`"id"\s*:\s*(-?\d+)`)
+
+// responseID recovers the ID from the start of a frame that looks like a
+// response, so the pending request can be failed instead of timing out.
+// This is best effort: frames mentioning a method are treated as requests.
+func responseID(prefix []byte) *int64 {
+	if bytes.Contains(prefix, []byte(`"method"`)) {
+		return nil
+	}
+	match := frameIDPattern.FindSubmatch(prefix)
+	if match == nil {
+		return nil
+	}
+	id, err := strconv.ParseInt(string(match[1]), 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// readFrame reads one newline-delimited frame of at most maxSize bytes.
+// Oversized frames are discarded through the next newline so the reader
+// stays aligned on message boundaries. Blank lines are skipped.
+func readFrame(r *bufio.Reader, maxSize int) ([]byte, error) {
+	for {
+		var frame []byte
+		size := 0
+		oversized := false
+
+		for {
+			chunk, err := r.ReadSlice('\n')
+			size += len(chunk)
+			if !oversized {
+				if maxSize > 0 && size > maxSize+1 {
+					// Keep the start for diagnostics and drop the rest
+					oversized = true
+					if len(frame) < frameSnippetSize {
+						frame = append(frame, chunk[:min(len(chunk), frameSnippetSize-len(frame))]...)
+					}
+				} else {
+					frame = append(frame, chunk...)
+				}
+			}
+
+			if err == nil {
+				break
+			}
+			if errors.Is(err, bufio.ErrBufferFull) {
+				continue
+			}
+			if errors.Is(err, io.EOF) && size > 0 && len(bytes.TrimSpace(frame)) > 0 {
+				return nil, newFrameError(ErrTruncatedMessage, frame, size, nil)
+			}
+			return nil, err
+		}
+
+		if oversized {
+			return nil, newFrameError(ErrMessageTooLarge, frame, size, nil)
+		}
+
+		frame = bytes.TrimSpace(frame)
+		if len(frame) == 0 {
+			continue
+		}
+		return frame, nil
+	}
+}
+
+// decodeFrame parses a frame into a JSON-RPC message
+func decodeFrame(frame []byte) (*RPCMessage, error) {
+	var msg RPCMessage
+	if err := json.Unmarshal(frame, &msg); err != nil {
+		return nil, newFrameError(ErrMalformedMessage, frame, len(frame), err)
+	}
+	if msg.ID == nil && msg.Method == "" {
+		return nil, newFrameError(ErrMalformedMessage, frame, len(frame), fmt.Errorf("neither a request nor a response"))
+	}
+	return &msg, nil
+}
+
+// decodeFrames parses a frame holding either one message or a batch, a JSON
+// array of messages. Batch entries that are not valid messages are returned
+// as frame errors without dropping the rest of the batch.
+func decodeFrames(frame []byte) (msgs []*RPCMessage, batch bool, dropped []*FrameError) {
+	if len(frame) == 0 || frame[0] != '[' {
+		msg, err := decodeFrame(frame)
+		if err != nil {
+			return nil, false, []*FrameError{asFrameError(err, frame)}
+		}
+		return []*RPCMessage{msg}, false, nil
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(frame, &entries); err != nil {
+		return nil, true, []*FrameError{newFrameError(ErrMalformedMessage, frame, len(frame), err)}
+	}
+	if len(entries) == 0 {
+		return nil, true, []*FrameError{newFrameError(ErrMalformedMessage, frame, len(frame), fmt.Errorf("empty batch"))}
+	}
+	for _, entry := range entries {
+		msg, err := decodeFrame(entry)
+		if err != nil {
+			dropped = append(dropped, asFrameError(err, entry))
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, true, dropped
+}
+
+// asFrameError returns err as a FrameError for frame
+func asFrameError(err error, frame []byte) *FrameError {
+	var frameErr *FrameError
+	if errors.As(err, &frameErr) {
+		return frameErr
+	}
+	return newFrameError(ErrMalformedMessage, frame, len(frame), err)
+}
+
+// encodeBatch encodes messages as a batch
+func encodeBatch(msgs []*RPCMessage) ([]byte, error) {
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("empty batch")
+	}
+	return json.Marshal(msgs)
+}
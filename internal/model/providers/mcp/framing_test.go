@@ -0,0 +1,132 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFrame(t *testing.T) {
+	long := `{"jsonrpc":"2.0","id":4,"result":"` + strings.Repeat("x", 100) + `"}`
+	input := "\n" + `{"a":1}` + "\n" + long + "\n" + `{"b":2}` + "\r\n" + `{"partial":`
+	reader := bufio.NewReaderSize(strings.NewReader(input), 16)
+
+	frame, err := readFrame(reader, 64)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(frame))
+
+	_, err = readFrame(reader, 64)
+	var frameErr *FrameError
+	require.ErrorAs(t, err, &frameErr)
+	assert.ErrorIs(t, err, ErrMessageTooLarge)
+	assert.Equal(t, len(long)+1, frameErr.Size)
+	require.NotNil(t, frameErr.ID)
+	assert.Equal(t, int64(4), *frameErr.ID)
+
+	// The reader resumes at the next message
+	frame, err = readFrame(reader, 64)
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":2}`, string(frame))
+
+	_, err = readFrame(reader, 64)
+	assert.ErrorIs(t, err, ErrTruncatedMessage)
+
+	_, err = readFrame(reader, 64)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDecodeFrame(t *testing.T) {
+	msg, err := decodeFrame([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), *msg.ID)
+
+	for _, frame := range []string{`starting server...`, `{"jsonrpc":"2.0","id":2,"result":`, `42`, `{}`} {
+		_, err := decodeFrame([]byte(frame))
+		assert.ErrorIs(t, err, ErrMalformedMessage, frame)
+	}
+
+	_, err = decodeFrame([]byte(`{"jsonrpc":"2.0","id":2,"result":{"text":"cut`))
+	var frameErr *FrameError
+	require.ErrorAs(t, err, &frameErr)
+	require.NotNil(t, frameErr.ID)
+	assert.Equal(t, int64(2), *frameErr.ID)
+
+	_, err = decodeFrame([]byte(`{"jsonrpc":"2.0","id":3,"method":"roots/list",`))
+	require.ErrorAs(t, err, &frameErr)
+	assert.Nil(t, frameErr.ID, "a server request must not fail a client request with the same ID")
+}
+
+func TestDecodeFrames(t *testing.T) {
+	msgs, batch, dropped := decodeFrames([]byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	assert.False(t, batch)
+	assert.Empty(t, dropped)
+	require.Len(t, msgs, 1)
+
+	msgs, batch, dropped = decodeFrames([]byte(`[{"jsonrpc":"2.0","id":1,"result":{}}, 42, {"jsonrpc":"2.0","method":"ping","id":9}]`))
+	assert.True(t, batch)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, int64(1), *msgs[0].ID)
+	assert.Equal(t, "ping", msgs[1].Method)
+	require.Len(t, dropped, 1)
+	assert.ErrorIs(t, dropped[0], ErrMalformedMessage)
+
+	for _, frame := range []string{`[]`, `[{"jsonrpc":"2.0","id":1,`, `starting`} {
+		msgs, _, dropped := decodeFrames([]byte(frame))
+		assert.Empty(t, msgs, frame)
+		require.Len(t, dropped, 1, frame)
+		assert.ErrorIs(t, dropped[0], ErrMalformedMessage, frame)
+	}
+}
+
+func TestProtocolHandler_HandleFrameError(t *testing.T) {
+	handler, _ := newDispatchHandler(t)
+
+	pending := requestAsync(handler, "resources/read")
+	waitPending(t, handler, 1)
+
+	handler.HandleFrameError(&FrameError{Err: ErrMalformedMessage})
+	assert.Equal(t, 1, handler.PendingRequests())
+
+	handler.HandleFrameError(&FrameError{Err: ErrMessageTooLarge, Size: 10 << 20, ID: int64Ptr(1)})
+	err := <-pending
+	assert.ErrorIs(t, err, ErrMessageTooLarge)
+	assert.Contains(t, err.Error(), "resources/read")
+}
+
+func TestStdioTransport_SkipsBadFrames(t *testing.T) {
+	script := `read line
+echo 'server starting'
+printf '%0200d\n' 0
+echo '{"jsonrpc":"2.0","id":1,"result":{"ok":true}}'
+cat >/dev/null`
+	transport := NewStdioTransport("sh", []string{"-c", script}, nil, TransportConfig{BufferSize: 64, MaxMessageSize: 128})
+	handler := NewProtocolHandler(transport)
+	transport.SetMessageHandler(handler.ProcessMessage)
+	transport.SetDisconnectHandler(handler.ConnectionLost)
+
+	dropped := make(chan *FrameError, 4)
+	transport.SetFrameErrorHandler(func(err *FrameError) { dropped <- err })
+
+	require.NoError(t, transport.Connect(context.Background()))
+	defer transport.Close()
+
+	result, err := handler.Request("ping", nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok":true}`, string(result))
+	assert.True(t, transport.IsConnected())
+
+	require.Eventually(t, func() bool { return len(dropped) == 2 }, time.Second, 5*time.Millisecond)
+	assert.ErrorIs(t, <-dropped, ErrMalformedMessage)
+	assert.ErrorIs(t, <-dropped, ErrMessageTooLarge)
+
+	// Outgoing messages are held to the same limit
+	err = transport.Send(&RPCMessage{JSONRPC: "2.0", Method: "notify", Params: json.RawMessage(`"` + strings.Repeat("y", 200) + `"`)})
+	assert.ErrorIs(t, err, ErrMessageTooLarge)
+}
@@ -0,0 +1,312 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// HealthProbe selects how a server's liveness is checked
+type HealthProbe string
+
+const (
+	// HealthProbePing sends a ping request
+	HealthProbePing HealthProbe = "ping"
+
+	// HealthProbeToolsList lists the server's tools, for servers that do not answer ping
+	HealthProbeToolsList HealthProbe = "tools/list"
+
+	// HealthProbeNone only checks that the transport is still connected
+	HealthProbeNone HealthProbe = "none"
+)
+
+const (
+	defaultHealthInterval    = 30 * time.Second
+	defaultHealthTimeout     = 10 * time.Second
+	defaultFailureThreshold  = 3
+	defaultBackoffInitial    = time.Second
+	defaultBackoffMax        = time.Minute
+	defaultBackoffMultiplier = 2.0
+	defaultBackoffJitter     = 0.2
+
+	// poolHealthInterval is how often pooled connections are checked
+	poolHealthInterval = 15 * time.Second
+)
+
+// HealthCheckSettings configures periodic health checks for a server
+type HealthCheckSettings struct {
+	Interval         string      `yaml:"interval" json:"interval"`
+	Timeout          string      `yaml:"timeout" json:"timeout"`
+	FailureThreshold int         `yaml:"failureThreshold" json:"failureThreshold"`
+	Probe            HealthProbe `yaml:"probe" json:"probe"`
+}
+
+// BackoffSettings configures the delay between restart attempts
+type BackoffSettings struct {
+	InitialDelay string  `yaml:"initialDelay" json:"initialDelay"`
+	MaxDelay     string  `yaml:"maxDelay" json:"maxDelay"`
+	Multiplier   float64 `yaml:"multiplier" json:"multiplier"`
+	Jitter       float64 `yaml:"jitter" json:"jitter"`
+}
+
+// healthPolicy is the resolved health check and restart configuration of a server
+type healthPolicy struct {
+	interval         time.Duration
+	timeout          time.Duration
+	failureThreshold int
+	probe            HealthProbe
+	backoff          backoffPolicy
+}
+
+// backoffPolicy computes exponential restart delays
+type backoffPolicy struct {
+	initial    time.Duration
+	max        time.Duration
+	multiplier float64
+	jitter     float64
+}
+
+// resolveHealthPolicy validates the health settings and fills in defaults
+func resolveHealthPolicy(settings ServerSettings) (healthPolicy, error) {
+	policy := healthPolicy{
+		interval:         defaultHealthInterval,
+		timeout:          defaultHealthTimeout,
+		failureThreshold: defaultFailureThreshold,
+		probe:            HealthProbePing,
+		backoff: backoffPolicy{
+			initial:    defaultBackoffInitial,
+			max:        defaultBackoffMax,
+			multiplier: defaultBackoffMultiplier,
+			jitter:     defaultBackoffJitter,
+		},
+	}
+
+	health := settings.HealthCheck
+	if err := parsePositiveDuration("health check interval", health.Interval, &policy.interval); err != nil {
+		return policy, err
+	}
+	if err := parsePositiveDuration("health check timeout", health.Timeout, &policy.timeout); err != nil {
+		return policy, err
+	}
+	if health.FailureThreshold < 0 {
+		return policy, fmt.Errorf("invalid health check failure threshold %d", health.FailureThreshold)
+	}
+	if health.FailureThreshold > 0 {
+		policy.failureThreshold = health.FailureThreshold
+	}
+	switch health.Probe {
+	case "":
+	case HealthProbePing, HealthProbeToolsList, HealthProbeNone:
+		policy.probe = health.Probe
+	default:
+		return policy, fmt.Errorf("unknown health check probe %q (want ping, tools/list or none)", health.Probe)
+	}
+
+	backoff := settings.Backoff
+	if err := parsePositiveDuration("backoff initial delay", backoff.InitialDelay, &policy.backoff.initial); err != nil {
+		return policy, err
+	}
+	if err := parsePositiveDuration("backoff max delay", backoff.MaxDelay, &policy.backoff.max); err != nil {
+		return policy, err
+	}
+	if policy.backoff.max < policy.backoff.initial {
+		return policy, fmt.Errorf("backoff max delay %s is shorter than initial delay %s", policy.backoff.max, policy.backoff.initial)
+	}
+	if backoff.Multiplier != 0 {
+		if backoff.Multiplier < 1 {
+			return policy, fmt.Errorf("invalid backoff multiplier %g (must be at least 1)", backoff.Multiplier)
+		}
+		policy.backoff.multiplier = backoff.Multiplier
+	}
+	if backoff.Jitter != 0 {
+		if backoff.Jitter < 0 || backoff.Jitter > 1 {
+			return policy, fmt.Errorf("invalid backoff jitter %g (must be between 0 and 1)", backoff.Jitter)
+		}
+		policy.backoff.jitter = backoff.Jitter
+	}
+
+	return policy, nil
+}
+
+// parsePositiveDuration parses value into dst, leaving dst unchanged when value is empty
+func parsePositiveDuration(name, value string, dst *time.Duration) error {
+	if value == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("invalid %s %q: must be positive", name, value)
+	}
+	*dst = d
+	return nil
+}
+
+// delay returns the wait before restart attempt n (zero based). The delay
+// grows by the multiplier up to the maximum, then is spread by the jitter
+// fraction so that servers failing together do not restart in lockstep.
+func (b backoffPolicy) delay(attempt int) time.Duration {
+	d := float64(b.initial) * math.Pow(b.multiplier, float64(attempt))
+	if d > float64(b.max) {
+		d = float64(b.max)
+	}
+	if b.jitter > 0 {
+		d += d * b.jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// probeServer runs the configured health probe against a server
+func probeServer(ctx context.Context, transport Transport, protocol *ProtocolHandler, policy healthPolicy) error {
+	if transport == nil || !transport.IsConnected() {
+		return fmt.Errorf("server disconnected")
+	}
+	if policy.probe == HealthProbeNone || protocol == nil || !protocol.IsInitialized() {
+		return nil
+	}
+
+	if policy.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.timeout)
+		defer cancel()
+	}
+
+	if policy.probe == HealthProbeToolsList {
+		if _, err := protocol.RequestContext(ctx, "tools/list", nil); err != nil {
+			return fmt.Errorf("tools/list probe failed: %w", err)
+		}
+		return nil
+	}
+
+	params := PingParams{
+		Timestamp: time.Now().UnixMilli(),
+		Data:      "health-check",
+	}
+	if _, err := protocol.RequestContext(ctx, "ping", params); err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+	return nil
+}
+
+// checkServerHealth performs a health check on a single server and records
+// the outcome. It reports whether the server is healthy.
+func (pm *ProcessManager) checkServerHealth(server *ManagedServer) bool {
+	server.mu.RLock()
+	transport := server.Transport
+	protocol := server.Protocol
+	policy := server.health
+	server.mu.RUnlock()
+
+	err := probeServer(pm.ctx, transport, protocol, policy)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.lastHealthCheck = time.Now()
+	if err != nil {
+		server.lastError = err
+		server.healthFailures++
+		return false
+	}
+	server.lastError = nil
+	server.healthFailures = 0
+	return true
+}
+
+// monitorHealth periodically checks a server and restarts it once it has
+// failed enough consecutive checks
+func (pm *ProcessManager) monitorHealth(server *ManagedServer) {
+	policy := server.health
+	ticker := time.NewTicker(policy.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pm.ctx.Done():
+			return
+		case <-server.stopped:
+			return
+		case <-ticker.C:
+		}
+
+		if pm.checkServerHealth(server) {
+			continue
+		}
+
+		server.mu.RLock()
+		failures := server.healthFailures
+		lastErr := server.lastError
+		server.mu.RUnlock()
+
+		if failures < policy.failureThreshold {
+			logger.Warn("MCP server health check failed", "server", server.Name,
+				"failures", failures, "threshold", policy.failureThreshold, "error", lastErr)
+			continue
+		}
+
+		pm.emit(LifecycleEvent{Type: LifecycleUnhealthy, Server: server.Name, Error: errorText(lastErr)})
+		if !server.Config.AutoRestart {
+			continue
+		}
+
+		if !pm.restartWithBackoff(server) {
+			return
+		}
+	}
+}
+
+// restartWithBackoff restarts an unhealthy server, waiting an exponentially
+// growing, jittered delay between attempts. It returns false once the server
+// has been given up on or stopped.
+func (pm *ProcessManager) restartWithBackoff(server *ManagedServer) bool {
+	maxRestarts := server.Config.MaxRestarts
+	if maxRestarts == 0 {
+		maxRestarts = 3
+	}
+
+	for attempt := 0; ; attempt++ {
+		server.mu.RLock()
+		restartCount := server.restartCount
+		server.mu.RUnlock()
+
+		if restartCount >= maxRestarts {
+			pm.emit(LifecycleEvent{Type: LifecycleGaveUp, Server: server.Name, Attempt: restartCount})
+			pm.stopServer(server.Name, StopMaxRestarts)
+			return false
+		}
+
+		delay := server.health.backoff.delay(attempt)
+		pm.emit(LifecycleEvent{Type: LifecycleRestarting, Server: server.Name, Attempt: attempt + 1, Delay: delay})
+
+		select {
+		case <-pm.ctx.Done():
+			return false
+		case <-server.stopped:
+			return false
+		case <-time.After(delay):
+		}
+
+		if err := pm.restartServer(pm.ctx, server); err != nil {
+			pm.emit(LifecycleEvent{Type: LifecycleRestartFailed, Server: server.Name, Attempt: attempt + 1, Error: err.Error()})
+			server.mu.Lock()
+			server.lastError = err
+			server.restartCount++
+			server.mu.Unlock()
+			continue
+		}
+
+		server.mu.Lock()
+		server.healthFailures = 0
+		server.lastError = nil
+		server.mu.Unlock()
+
+		pm.emit(LifecycleEvent{Type: LifecycleRestarted, Server: server.Name, Attempt: attempt + 1})
+		return true
+	}
+}
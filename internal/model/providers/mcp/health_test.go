@@ -0,0 +1,117 @@
+package mcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveHealthPolicy(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		policy, err := resolveHealthPolicy(ServerSettings{})
+		require.NoError(t, err)
+		assert.Equal(t, defaultHealthInterval, policy.interval)
+		assert.Equal(t, defaultHealthTimeout, policy.timeout)
+		assert.Equal(t, defaultFailureThreshold, policy.failureThreshold)
+		assert.Equal(t, HealthProbePing, policy.probe)
+		assert.Equal(t, defaultBackoffInitial, policy.backoff.initial)
+		assert.Equal(t, defaultBackoffMax, policy.backoff.max)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		policy, err := resolveHealthPolicy(ServerSettings{
+			HealthCheck: HealthCheckSettings{Interval: "5s", Timeout: "2s", FailureThreshold: 1, Probe: HealthProbeToolsList},
+			Backoff:     BackoffSettings{InitialDelay: "500ms", MaxDelay: "10s", Multiplier: 3, Jitter: 0.5},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, policy.interval)
+		assert.Equal(t, 2*time.Second, policy.timeout)
+		assert.Equal(t, 1, policy.failureThreshold)
+		assert.Equal(t, HealthProbeToolsList, policy.probe)
+		assert.Equal(t, backoffPolicy{initial: 500 * time.Millisecond, max: 10 * time.Second, multiplier: 3, jitter: 0.5}, policy.backoff)
+	})
+
+	invalid := map[string]ServerSettings{
+		"bad interval":      {HealthCheck: HealthCheckSettings{Interval: "soon"}},
+		"zero timeout":      {HealthCheck: HealthCheckSettings{Timeout: "0s"}},
+		"negative failures": {HealthCheck: HealthCheckSettings{FailureThreshold: -1}},
+		"unknown probe":     {HealthCheck: HealthCheckSettings{Probe: "http"}},
+		"max below initial": {Backoff: BackoffSettings{InitialDelay: "10s", MaxDelay: "1s"}},
+		"small multiplier":  {Backoff: BackoffSettings{Multiplier: 0.5}},
+		"large jitter":      {Backoff: BackoffSettings{Jitter: 1.5}},
+	}
+	for name, settings := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := resolveHealthPolicy(settings)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestBackoffPolicy_Delay(t *testing.T) {
+	backoff := backoffPolicy{initial: time.Second, max: 10 * time.Second, multiplier: 2}
+
+	assert.Equal(t, time.Second, backoff.delay(0))
+	assert.Equal(t, 2*time.Second, backoff.delay(1))
+	assert.Equal(t, 8*time.Second, backoff.delay(3))
+	assert.Equal(t, 10*time.Second, backoff.delay(4))
+	assert.Equal(t, 10*time.Second, backoff.delay(50))
+
+	backoff.jitter = 0.25
+	for i := 0; i < 100; i++ {
+		d := backoff.delay(2)
+		assert.GreaterOrEqual(t, d, 3*time.Second)
+		assert.LessOrEqual(t, d, 5*time.Second)
+	}
+}
+
+func TestProbeServer(t *testing.T) {
+	newServer := func(t *testing.T, probe HealthProbe) (*ManagedServer, *MockTransport) {
+		handler, transport := newNegotiationHandler(t, `{"protocolVersion":"2025-06-18","serverInfo":{"name":"s","version":"1"},"capabilities":{}}`)
+		_, err := handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{})
+		require.NoError(t, err)
+		transport.GetLastMessage()
+		transport.GetLastMessage()
+
+		policy, err := resolveHealthPolicy(ServerSettings{HealthCheck: HealthCheckSettings{Probe: probe, Timeout: "1s"}})
+		require.NoError(t, err)
+		return &ManagedServer{Name: "probe", Transport: transport, Protocol: handler, health: policy}, transport
+	}
+
+	for _, probe := range []HealthProbe{HealthProbePing, HealthProbeToolsList} {
+		t.Run(string(probe), func(t *testing.T) {
+			server, transport := newServer(t, probe)
+			pm := NewProcessManager()
+			defer pm.StopAll()
+
+			assert.True(t, pm.checkServerHealth(server))
+			request := transport.GetLastMessage()
+			require.NotNil(t, request)
+			assert.Equal(t, string(probe), request.Method)
+
+			transport.SetErrorOnSend(true)
+			assert.False(t, pm.checkServerHealth(server))
+			assert.False(t, pm.checkServerHealth(server))
+			assert.Equal(t, 2, server.GetStatus().HealthFailures)
+
+			transport.SetErrorOnSend(false)
+			assert.True(t, pm.checkServerHealth(server))
+			assert.Equal(t, 0, server.GetStatus().HealthFailures)
+		})
+	}
+
+	t.Run("none", func(t *testing.T) {
+		server, transport := newServer(t, HealthProbeNone)
+		pm := NewProcessManager()
+		defer pm.StopAll()
+
+		assert.True(t, pm.checkServerHealth(server))
+		assert.Nil(t, transport.GetLastMessage())
+
+		require.NoError(t, transport.Close())
+		assert.False(t, pm.checkServerHealth(server))
+		assert.Contains(t, server.GetStatus().LastError, "disconnected")
+	})
+}
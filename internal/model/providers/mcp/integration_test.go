@@ -93,10 +93,7 @@ func testRealServer(t *testing.T, serverName string, config IntegrationTestConfi
 		Env:         config.ServerEnv,
 		Transport:   "stdio",
 		AutoRestart: false,
-		Settings: struct {
-			Timeout    string `yaml:"timeout" json:"timeout"`
-			MaxRetries int    `yaml:"maxRetries" json:"maxRetries"`
-		}{
+		Settings: ServerSettings{
 			Timeout:    "10s",
 			MaxRetries: 3,
 		},
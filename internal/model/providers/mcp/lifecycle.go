@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dshills/sigil/internal/logger"
+)
+
+const (
+	minIdleCheckInterval = 10 * time.Millisecond
+	maxIdleCheckInterval = 30 * time.Second
+)
+
+// parseIdleTimeout returns the configured idle timeout, or zero when servers never idle out
+func parseIdleTimeout(config ServerConfig) (time.Duration, error) {
+	if config.IdleTimeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(config.IdleTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid idle timeout %q: %w", config.IdleTimeout, err)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("invalid idle timeout %q: must not be negative", config.IdleTimeout)
+	}
+	return timeout, nil
+}
+
+// EnsureServer returns the running server for config, starting it first if needed
+func (pm *ProcessManager) EnsureServer(ctx context.Context, config ServerConfig) (*ManagedServer, error) {
+	pm.startMu.Lock()
+	defer pm.startMu.Unlock()
+
+	if server, err := pm.GetServer(config.Name); err == nil {
+		server.touch()
+		return server, nil
+	}
+
+	server, err := pm.StartServer(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	server.touch()
+	return server, nil
+}
+
+// touch records that the server was just used
+func (s *ManagedServer) touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastUsed = time.Now()
+}
+
+// idleFor returns how long the server has gone without being used
+func (s *ManagedServer) idleFor() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Since(s.lastUsed)
+}
+
+// isBusy reports whether the server has work in flight
+func (s *ManagedServer) isBusy() bool {
+	s.mu.RLock()
+	inUse := s.inUse
+	protocol := s.Protocol
+	s.mu.RUnlock()
+
+	return inUse || (protocol != nil && protocol.PendingRequests() > 0)
+}
+
+// isStopped reports whether the server has been stopped
+func (s *ManagedServer) isStopped() bool {
+	if s.stopped == nil {
+		return false
+	}
+	select {
+	case <-s.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// monitorIdle stops the server once it has gone unused for its idle timeout.
+// The configuration stays with its owner, so the next EnsureServer starts it again.
+func (pm *ProcessManager) monitorIdle(server *ManagedServer) {
+	interval := server.idleTimeout / 4
+	if interval < minIdleCheckInterval {
+		interval = minIdleCheckInterval
+	}
+	if interval > maxIdleCheckInterval {
+		interval = maxIdleCheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pm.ctx.Done():
+			return
+		case <-server.stopped:
+			return
+		case <-ticker.C:
+		}
+
+		if server.isBusy() {
+			continue
+		}
+		idle := server.idleFor()
+		if idle < server.idleTimeout {
+			continue
+		}
+
+		logger.Debug("stopping idle MCP server", "server", server.Name, "idle", idle.Round(time.Second))
+		if err := pm.stopIfCurrent(server); err != nil {
+			logger.Warn("failed to stop idle MCP server", "server", server.Name, "error", err)
+		}
+		return
+	}
+}
+
+// stopIfCurrent stops server unless it has already been replaced or removed
+func (pm *ProcessManager) stopIfCurrent(server *ManagedServer) error {
+	pm.mu.Lock()
+	if pm.servers[server.Name] != server {
+		pm.mu.Unlock()
+		return nil
+	}
+	delete(pm.servers, server.Name)
+	pm.mu.Unlock()
+
+	return pm.shutdownServer(server, StopIdle)
+}
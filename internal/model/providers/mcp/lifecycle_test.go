@@ -0,0 +1,125 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServerConfig describes a stdio server that completes the handshake and then
+// stays silent, with a short timeout so shutdown does not wait for a reply
+func fakeServerConfig(name string) ServerConfig {
+	return ServerConfig{
+		Name:    name,
+		Command: "sh",
+		Args:    []string{"-c", `read line; echo '{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-06-18","serverInfo":{"name":"fake","version":"1"},"capabilities":{}}}'; cat >/dev/null`},
+		Settings: ServerSettings{
+			Timeout:     "200ms",
+			HealthCheck: HealthCheckSettings{Probe: HealthProbeNone},
+		},
+	}
+}
+
+// addIdleServer registers a mock-backed server with the given idle timeout
+func addIdleServer(t *testing.T, pm *ProcessManager, idleTimeout time.Duration) *ManagedServer {
+	transport := NewMockTransport()
+	require.NoError(t, transport.Connect(context.Background()))
+
+	server := &ManagedServer{
+		Name:        "idle",
+		Transport:   transport,
+		Protocol:    NewProtocolHandler(transport),
+		startTime:   time.Now(),
+		lastUsed:    time.Now(),
+		idleTimeout: idleTimeout,
+		stopped:     make(chan struct{}),
+	}
+	pm.mu.Lock()
+	pm.servers[server.Name] = server
+	pm.mu.Unlock()
+	return server
+}
+
+func TestParseIdleTimeout(t *testing.T) {
+	timeout, err := parseIdleTimeout(ServerConfig{})
+	require.NoError(t, err)
+	assert.Zero(t, timeout)
+
+	timeout, err = parseIdleTimeout(ServerConfig{IdleTimeout: "5m"})
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, timeout)
+
+	_, err = parseIdleTimeout(ServerConfig{IdleTimeout: "later"})
+	assert.Error(t, err)
+	_, err = parseIdleTimeout(ServerConfig{IdleTimeout: "-1s"})
+	assert.Error(t, err)
+}
+
+func TestProcessManager_IdleShutdown(t *testing.T) {
+	pm := NewProcessManager()
+	defer pm.StopAll()
+
+	server := addIdleServer(t, pm, 50*time.Millisecond)
+	go pm.monitorIdle(server)
+
+	require.Eventually(t, server.isStopped, time.Second, 10*time.Millisecond)
+	_, err := pm.GetServer("idle")
+	assert.Error(t, err)
+	assert.False(t, server.Transport.IsConnected())
+}
+
+func TestProcessManager_IdleShutdownSkipsBusyServer(t *testing.T) {
+	pm := NewProcessManager()
+	defer pm.StopAll()
+
+	server := addIdleServer(t, pm, 30*time.Millisecond)
+	server.inUse = true
+	go pm.monitorIdle(server)
+
+	time.Sleep(150 * time.Millisecond)
+	assert.False(t, server.isStopped())
+
+	pm.ReleaseConnection(server)
+	require.Eventually(t, server.isStopped, time.Second, 10*time.Millisecond)
+}
+
+func TestProcessManager_EnsureServerReusesRunning(t *testing.T) {
+	pm := NewProcessManager()
+	defer pm.StopAll()
+
+	server := addIdleServer(t, pm, 0)
+	server.lastUsed = time.Now().Add(-time.Hour)
+
+	ensured, err := pm.EnsureServer(context.Background(), ServerConfig{Name: "idle", Command: "does-not-exist"})
+	require.NoError(t, err)
+	assert.Same(t, server, ensured)
+	assert.Less(t, server.idleFor(), time.Minute)
+}
+
+func TestModel_LazyStart(t *testing.T) {
+	provider := NewProvider()
+	defer provider.Shutdown()
+
+	config := fakeServerConfig("lazy")
+	config.LazyStart = true
+	mcpModel := &Model{modelName: "default", config: config, provider: provider}
+
+	// Nothing runs until the model is used
+	assert.Equal(t, "lazy", mcpModel.GetServerStatus().Name)
+	assert.Empty(t, provider.GetServers())
+
+	first, err := mcpModel.activeServer(context.Background())
+	require.NoError(t, err)
+	assert.True(t, first.Protocol.IsInitialized())
+	assert.Len(t, provider.GetServers(), 1)
+
+	// A server stopped for idleness is started again on the next request
+	require.NoError(t, provider.StopServer("lazy"))
+	second, err := mcpModel.activeServer(context.Background())
+	require.NoError(t, err)
+	assert.NotSame(t, first, second)
+	assert.True(t, second.Protocol.IsInitialized())
+}
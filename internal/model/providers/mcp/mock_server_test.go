@@ -409,10 +409,13 @@ func (m *MockServer) handlePromptGet(msg *RPCMessage) *RPCMessage {
 
 	result := PromptResult{
 		Description: "Mock prompt result",
-		Messages: []Message{
+		Messages: []PromptMessage{
 			{
-				Role:    "user",
-				Content: fmt.Sprintf("Mock prompt %s with args: %v", params.Name, params.Arguments),
+				Role: "user",
+				Content: ToolCallContent{
+					Type: ContentTypeText,
+					Text: fmt.Sprintf("Mock prompt %s with args: %v", params.Name, params.Arguments),
+				},
 			},
 		},
 	}
@@ -0,0 +1,147 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultRequestTimeout bounds how long a request waits for its response
+const DefaultRequestTimeout = 30 * time.Second
+
+var (
+	// ErrConnectionClosed is returned for requests abandoned because the connection went away
+	ErrConnectionClosed = errors.New("mcp connection closed")
+
+	// ErrRequestTimeout is returned when a response does not arrive in time
+	ErrRequestTimeout = errors.New("mcp request timed out")
+
+	// ErrRequestCanceled is returned when the server cancels a pending request
+	ErrRequestCanceled = errors.New("mcp request canceled")
+)
+
+// pendingRequest tracks one in-flight request. A request completes exactly
+// once, either with a response or with an error; later completions are ignored.
+type pendingRequest struct {
+	method   string
+	response chan *RPCMessage
+	done     chan struct{}
+	err      error
+	once     sync.Once
+}
+
+// newPendingRequest creates a pending request for method
+func newPendingRequest(method string) *pendingRequest {
+	return &pendingRequest{
+		method:   method,
+		response: make(chan *RPCMessage, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// resolve completes the request with a response
+func (p *pendingRequest) resolve(msg *RPCMessage) bool {
+	resolved := false
+	p.once.Do(func() {
+		p.response <- msg
+		resolved = true
+	})
+	return resolved
+}
+
+// fail completes the request with err
+func (p *pendingRequest) fail(err error) bool {
+	failed := false
+	p.once.Do(func() {
+		p.err = err
+		close(p.done)
+		failed = true
+	})
+	return failed
+}
+
+// takePending removes and returns the pending request for id
+func (h *ProtocolHandler) takePending(id int64) (*pendingRequest, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	pending, ok := h.pendingRequests[id]
+	if ok {
+		delete(h.pendingRequests, id)
+	}
+	return pending, ok
+}
+
+// PendingRequests returns the number of requests awaiting a response
+func (h *ProtocolHandler) PendingRequests() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.pendingRequests)
+}
+
+// failPending completes every in-flight request with err
+func (h *ProtocolHandler) failPending(err error) int {
+	h.mu.Lock()
+	pending := h.pendingRequests
+	h.pendingRequests = make(map[int64]*pendingRequest)
+	h.mu.Unlock()
+
+	for _, p := range pending {
+		p.fail(err)
+	}
+	return len(pending)
+}
+
+// ConnectionLost fails all in-flight requests after the transport dropped.
+// The handler stays usable so requests can resume if the transport reconnects.
+func (h *ProtocolHandler) ConnectionLost(cause error) {
+	err := ErrConnectionClosed
+	if cause != nil {
+		err = fmt.Errorf("%w: %v", ErrConnectionClosed, cause)
+	}
+	h.failPending(err)
+}
+
+// HandleFrameError fails the request whose response the transport had to
+// drop, rather than leaving it to time out. Frames that cannot be matched to
+// a request are only logged by the transport.
+func (h *ProtocolHandler) HandleFrameError(frameErr *FrameError) {
+	if frameErr == nil || frameErr.ID == nil {
+		return
+	}
+	if pending, ok := h.takePending(*frameErr.ID); ok {
+		pending.fail(fmt.Errorf("response to %s dropped: %w", pending.method, frameErr))
+	}
+}
+
+// Close permanently closes the handler, failing in-flight requests and
+// rejecting new ones. It is safe to call more than once.
+func (h *ProtocolHandler) Close() {
+	h.mu.Lock()
+	h.state = StateClosed
+	h.mu.Unlock()
+
+	h.closeOnce.Do(func() {
+		close(h.closed)
+	})
+	h.coalescer.stop()
+	h.failPending(ErrConnectionClosed)
+}
+
+// isClosed reports whether Close has been called
+func (h *ProtocolHandler) isClosed() bool {
+	select {
+	case <-h.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetRequestTimeout sets how long requests wait for a response. Zero disables the timeout.
+func (h *ProtocolHandler) SetRequestTimeout(timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.requestTimeout = timeout
+}
@@ -0,0 +1,161 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestAsync issues a request in the background and returns its eventual error
+func requestAsync(handler *ProtocolHandler, method string) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		_, err := handler.Request(method, nil)
+		result <- err
+	}()
+	return result
+}
+
+// waitPending waits until the handler has n requests in flight
+func waitPending(t *testing.T, handler *ProtocolHandler, n int) {
+	require.Eventually(t, func() bool { return handler.PendingRequests() == n }, time.Second, 5*time.Millisecond)
+}
+
+func TestProtocolHandler_ConnectionLostFailsPending(t *testing.T) {
+	handler, _ := newDispatchHandler(t)
+
+	first := requestAsync(handler, "tools/list")
+	second := requestAsync(handler, "resources/list")
+	waitPending(t, handler, 2)
+
+	handler.ConnectionLost(errors.New("server exited"))
+
+	for _, result := range []<-chan error{first, second} {
+		select {
+		case err := <-result:
+			assert.ErrorIs(t, err, ErrConnectionClosed)
+		case <-time.After(time.Second):
+			t.Fatal("request was not failed after disconnect")
+		}
+	}
+	assert.Equal(t, 0, handler.PendingRequests())
+
+	// The handler remains usable for a reconnected transport
+	third := requestAsync(handler, "ping")
+	waitPending(t, handler, 1)
+	handler.ProcessMessage(&RPCMessage{JSONRPC: "2.0", ID: int64Ptr(3), Result: json.RawMessage(`{}`)})
+	assert.NoError(t, <-third)
+}
+
+func TestProtocolHandler_CloseIsIdempotent(t *testing.T) {
+	handler, _ := newDispatchHandler(t)
+
+	pending := requestAsync(handler, "tools/list")
+	waitPending(t, handler, 1)
+
+	handler.Close()
+	handler.Close()
+
+	assert.ErrorIs(t, <-pending, ErrConnectionClosed)
+	_, err := handler.Request("tools/list", nil)
+	assert.ErrorIs(t, err, ErrConnectionClosed)
+	assert.Equal(t, 0, handler.PendingRequests())
+}
+
+func TestProtocolHandler_CancellationFailsRequest(t *testing.T) {
+	handler, _ := newDispatchHandler(t)
+
+	pending := requestAsync(handler, "tools/call")
+	waitPending(t, handler, 1)
+
+	handler.ProcessMessage(&RPCMessage{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  json.RawMessage(`{"requestId": 1, "reason": "user abort"}`),
+	})
+
+	err := <-pending
+	assert.ErrorIs(t, err, ErrRequestCanceled)
+	assert.Contains(t, err.Error(), "user abort")
+
+	// A response arriving after cancellation is ignored
+	handler.ProcessMessage(&RPCMessage{JSONRPC: "2.0", ID: int64Ptr(1), Result: json.RawMessage(`{}`)})
+	assert.Equal(t, 0, handler.PendingRequests())
+}
+
+func TestProtocolHandler_DuplicateResponse(t *testing.T) {
+	handler, _ := newDispatchHandler(t)
+
+	pending := requestAsync(handler, "ping")
+	waitPending(t, handler, 1)
+
+	response := &RPCMessage{JSONRPC: "2.0", ID: int64Ptr(1), Result: json.RawMessage(`{}`)}
+	done := make(chan struct{})
+	go func() {
+		handler.ProcessMessage(response)
+		handler.ProcessMessage(response)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("duplicate response blocked the reader")
+	}
+	assert.NoError(t, <-pending)
+}
+
+func TestProtocolHandler_RequestTimeout(t *testing.T) {
+	handler, _ := newDispatchHandler(t)
+	handler.SetRequestTimeout(20 * time.Millisecond)
+
+	_, err := handler.Request("tools/list", nil)
+	assert.ErrorIs(t, err, ErrRequestTimeout)
+	assert.Equal(t, 0, handler.PendingRequests())
+}
+
+func TestProtocolHandler_RequestContext(t *testing.T) {
+	handler, _ := newDispatchHandler(t)
+	handler.SetRequestTimeout(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		waitPending(t, handler, 1)
+		cancel()
+	}()
+
+	_, err := handler.RequestContext(ctx, "tools/list", nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, handler.PendingRequests())
+}
+
+func TestStdioTransport_AbruptDisconnect(t *testing.T) {
+	// The server reads the request and dies without answering
+	transport := NewStdioTransport("sh", []string{"-c", "read line; exit 1"}, nil, DefaultTransportConfig())
+	transport.SetReconnectConfig(0, time.Millisecond)
+	handler := NewProtocolHandler(transport)
+	transport.SetMessageHandler(handler.ProcessMessage)
+	transport.SetDisconnectHandler(handler.ConnectionLost)
+
+	require.NoError(t, transport.Connect(context.Background()))
+	defer transport.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := handler.Request("initialize", InitializeParams{ProtocolVersion: LatestProtocolVersion})
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		assert.ErrorIs(t, err, ErrConnectionClosed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request still pending after the server died")
+	}
+	assert.Equal(t, 0, handler.PendingRequests())
+}
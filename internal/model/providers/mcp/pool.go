@@ -0,0 +1,409 @@
+package mcp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// PoolSelection chooses which idle pooled connection serves a request
+type PoolSelection string
+
+const (
+	// PoolRoundRobin takes the idle connections in turn
+	PoolRoundRobin PoolSelection = "round_robin"
+	// PoolLeastLoaded takes the idle connection that has served the fewest requests
+	PoolLeastLoaded PoolSelection = "least_loaded"
+)
+
+// Reasons pooled connections are evicted, as counted in PoolStats
+const (
+	EvictLifetime  = "lifetime"  // Older than the maximum lifetime
+	EvictIdle      = "idle"      // Unused for longer than the idle timeout
+	EvictUnhealthy = "unhealthy" // Failed health checks or disconnected
+)
+
+// PoolConfig configures the pools of extra connections to each server
+type PoolConfig struct {
+	// Size is the most connections pooled per server
+	Size int
+
+	// MaxLifetime retires connections this old once they are idle; zero
+	// keeps them for as long as they are healthy
+	MaxLifetime time.Duration
+
+	// IdleTimeout retires connections unused for this long; zero keeps them
+	IdleTimeout time.Duration
+
+	// Selection chooses among idle connections
+	Selection PoolSelection
+
+	// WarmUp is how many connections are opened when a server starts,
+	// rather than on first use
+	WarmUp int
+}
+
+// DefaultPoolConfig returns the default pool configuration
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		Size:      3,
+		Selection: PoolRoundRobin,
+	}
+}
+
+// ParsePoolConfig converts the pool section of the configuration file,
+// filling in defaults for what it leaves out
+func ParsePoolConfig(cfg config.MCPPoolConfig) (PoolConfig, error) {
+	pool := DefaultPoolConfig()
+	if cfg.Size > 0 {
+		pool.Size = cfg.Size
+	}
+	if cfg.Selection != "" {
+		pool.Selection = PoolSelection(cfg.Selection)
+	}
+	switch pool.Selection {
+	case PoolRoundRobin, PoolLeastLoaded:
+	default:
+		return PoolConfig{}, fmt.Errorf("invalid pool selection %q (valid: %s, %s)", cfg.Selection, PoolRoundRobin, PoolLeastLoaded)
+	}
+
+	var err error
+	if cfg.MaxLifetime != "" {
+		if pool.MaxLifetime, err = time.ParseDuration(cfg.MaxLifetime); err != nil {
+			return PoolConfig{}, fmt.Errorf("invalid pool max lifetime %q: %w", cfg.MaxLifetime, err)
+		}
+	}
+	if cfg.IdleTimeout != "" {
+		if pool.IdleTimeout, err = time.ParseDuration(cfg.IdleTimeout); err != nil {
+			return PoolConfig{}, fmt.Errorf("invalid pool idle timeout %q: %w", cfg.IdleTimeout, err)
+		}
+	}
+	pool.WarmUp = min(cfg.WarmUp, pool.Size)
+	return pool, nil
+}
+
+// applyPoolConfig sets up connection pooling from the main configuration
+func (p *Provider) applyPoolConfig() {
+	cfg := config.Get()
+	if cfg == nil || cfg.MCP == nil {
+		return
+	}
+	pool, err := ParsePoolConfig(cfg.MCP.Pool)
+	if err != nil {
+		logger.Warn("ignoring invalid MCP pool configuration", "error", err)
+		return
+	}
+	p.processManager.SetPoolConfig(pool)
+}
+
+// PoolStats counts connection pool activity
+type PoolStats struct {
+	Created   int64            `json:"created"`
+	Acquired  int64            `json:"acquired"`
+	Exhausted int64            `json:"exhausted"` // Requests turned away by a full pool
+	Evicted   map[string]int64 `json:"evicted"`   // Evictions by reason
+}
+
+// SetPoolSize sets the connection pool size
+func (pm *ProcessManager) SetPoolSize(size int) {
+	pm.poolMu.Lock()
+	defer pm.poolMu.Unlock()
+	pm.pool.Size = size
+}
+
+// SetPoolConfig sets how connections are pooled
+func (pm *ProcessManager) SetPoolConfig(pool PoolConfig) {
+	pm.poolMu.Lock()
+	defer pm.poolMu.Unlock()
+	pm.pool = pool
+}
+
+// GetPoolStats returns a snapshot of the pool counters
+func (pm *ProcessManager) GetPoolStats() PoolStats {
+	pm.poolMu.RLock()
+	defer pm.poolMu.RUnlock()
+
+	stats := pm.poolStats
+	stats.Evicted = make(map[string]int64, len(pm.poolStats.Evicted))
+	for reason, count := range pm.poolStats.Evicted {
+		stats.Evicted[reason] = count
+	}
+	return stats
+}
+
+// GetPooledConnection gets a connection from the pool or creates a new one.
+// Expired connections are retired first; among the idle ones left, the pool
+// selection decides which serves the request.
+func (pm *ProcessManager) GetPooledConnection(serverName string) (*ManagedServer, error) {
+	pm.evictExpired()
+
+	pm.poolMu.Lock()
+	defer pm.poolMu.Unlock()
+
+	// Check for available connections in pool
+	connections := pm.connectionPool[serverName]
+	if i := pm.selectConnection(serverName, connections); i >= 0 {
+		conn := connections[i]
+		conn.mu.Lock()
+		conn.inUse = true
+		conn.requestCount++
+		conn.lastUsed = time.Now()
+		conn.mu.Unlock()
+
+		pm.poolStats.Acquired++
+		return conn, nil
+	}
+
+	// No available connections, check if we can create a new one
+	pm.mu.RLock()
+	server, exists := pm.servers[serverName]
+	pm.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("server %s not found", serverName)
+	}
+	server.touch()
+
+	// Check pool size limit
+	if len(connections) >= pm.pool.Size {
+		pm.poolStats.Exhausted++
+		return nil, fmt.Errorf("connection pool for server %s is full", serverName)
+	}
+
+	pooledServer, err := pm.addPooledConnection(server.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	pooledServer.mu.Lock()
+	pooledServer.inUse = true
+	pooledServer.requestCount++
+	pooledServer.lastUsed = time.Now()
+	pooledServer.mu.Unlock()
+
+	pm.poolStats.Acquired++
+	return pooledServer, nil
+}
+
+// selectConnection returns the index of the idle connection to use next,
+// or -1 when all are busy. Callers must hold poolMu.
+func (pm *ProcessManager) selectConnection(serverName string, connections []*ManagedServer) int {
+	selected := -1
+	var fewest int64
+	start := pm.poolCursor[serverName]
+	for offset := range connections {
+		i := offset
+		if pm.pool.Selection == PoolRoundRobin {
+			i = (start + offset) % len(connections)
+		}
+
+		conn := connections[i]
+		conn.mu.RLock()
+		idle := !conn.inUse
+		requests := conn.requestCount
+		conn.mu.RUnlock()
+		if !idle {
+			continue
+		}
+
+		if pm.pool.Selection == PoolRoundRobin {
+			pm.poolCursor[serverName] = i + 1
+			return i
+		}
+		if selected < 0 || requests < fewest {
+			selected, fewest = i, requests
+		}
+	}
+	return selected
+}
+
+// addPooledConnection opens a connection and adds it to the pool. Callers
+// must hold poolMu.
+func (pm *ProcessManager) addPooledConnection(config ServerConfig) (*ManagedServer, error) {
+	pooledServer, err := pm.createPooledConnection(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pooled connection: %w", err)
+	}
+
+	if pm.connectionPool[config.Name] == nil {
+		pm.connectionPool[config.Name] = make([]*ManagedServer, 0, pm.pool.Size)
+	}
+	pm.connectionPool[config.Name] = append(pm.connectionPool[config.Name], pooledServer)
+	pm.poolStats.Created++
+	return pooledServer, nil
+}
+
+// ReleaseConnection releases a pooled connection back to the pool, retiring
+// it instead when it has outlived the maximum lifetime
+func (pm *ProcessManager) ReleaseConnection(server *ManagedServer) {
+	server.mu.Lock()
+	server.inUse = false
+	server.lastUsed = time.Now()
+	server.mu.Unlock()
+
+	pm.poolMu.RLock()
+	reason, expired := pm.expiry(server, time.Now())
+	pm.poolMu.RUnlock()
+	if expired {
+		pm.removeFromPool(server, reason)
+	}
+}
+
+// createPooledConnection creates a new connection for the pool
+func (pm *ProcessManager) createPooledConnection(config ServerConfig) (*ManagedServer, error) {
+	policy, err := resolveHealthPolicy(config.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create transport
+	transport, err := pm.createTransport(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transport: %w", err)
+	}
+
+	// Create protocol handler
+	protocol := newProtocolForTransport(transport)
+
+	// Create managed server
+	now := time.Now()
+	server := &ManagedServer{
+		Name:      fmt.Sprintf("%s-pool-%d", config.Name, now.UnixNano()),
+		Config:    config,
+		Transport: transport,
+		Protocol:  protocol,
+		startTime: now,
+		lastUsed:  now,
+		health:    policy,
+	}
+
+	// Connect transport
+	if err := transport.Connect(pm.ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect transport: %w", err)
+	}
+
+	// Initialize protocol
+	_, err = pm.initializeProtocol(protocol, config)
+	if err != nil {
+		transport.Close()
+		return nil, fmt.Errorf("failed to initialize protocol: %w", err)
+	}
+
+	return server, nil
+}
+
+// warmPool opens the configured number of warm-up connections to a server
+// that has just started
+func (pm *ProcessManager) warmPool(serverName string) {
+	pm.mu.RLock()
+	server, exists := pm.servers[serverName]
+	pm.mu.RUnlock()
+	if !exists {
+		return
+	}
+
+	pm.poolMu.Lock()
+	defer pm.poolMu.Unlock()
+
+	for len(pm.connectionPool[serverName]) < min(pm.pool.WarmUp, pm.pool.Size) {
+		if _, err := pm.addPooledConnection(server.Config); err != nil {
+			logger.Warn("failed to warm MCP connection pool", "server", serverName, "error", err)
+			return
+		}
+	}
+}
+
+// expiry returns why an idle pooled connection should be retired, if it
+// should. Callers must hold poolMu.
+func (pm *ProcessManager) expiry(conn *ManagedServer, now time.Time) (string, bool) {
+	conn.mu.RLock()
+	defer conn.mu.RUnlock()
+
+	if conn.inUse {
+		return "", false
+	}
+	if pm.pool.MaxLifetime > 0 && now.Sub(conn.startTime) >= pm.pool.MaxLifetime {
+		return EvictLifetime, true
+	}
+	if pm.pool.IdleTimeout > 0 && now.Sub(conn.lastUsed) >= pm.pool.IdleTimeout {
+		return EvictIdle, true
+	}
+	return "", false
+}
+
+// evictExpired retires idle connections past their lifetime or idle timeout.
+// Connections in use are retired once released and found expired.
+func (pm *ProcessManager) evictExpired() {
+	now := time.Now()
+	var evicted []*ManagedServer
+
+	pm.poolMu.Lock()
+	for serverName, connections := range pm.connectionPool {
+		kept := connections[:0]
+		for _, conn := range connections {
+			if reason, expired := pm.expiry(conn, now); expired {
+				logger.Debug("evicting pooled MCP connection", "server", serverName, "connection", conn.Name, "reason", reason)
+				pm.poolStats.Evicted[reason]++
+				evicted = append(evicted, conn)
+				continue
+			}
+			kept = append(kept, conn)
+		}
+		pm.connectionPool[serverName] = kept
+	}
+	pm.poolMu.Unlock()
+
+	for _, conn := range evicted {
+		closePooled(conn)
+	}
+}
+
+// removeFromPool removes a server from the connection pool
+func (pm *ProcessManager) removeFromPool(server *ManagedServer, reason string) {
+	pm.poolMu.Lock()
+	removed := false
+	for serverName, connections := range pm.connectionPool {
+		for i, conn := range connections {
+			if conn == server {
+				connections[i] = connections[len(connections)-1]
+				pm.connectionPool[serverName] = connections[:len(connections)-1]
+				pm.poolStats.Evicted[reason]++
+				removed = true
+				break
+			}
+		}
+	}
+	pm.poolMu.Unlock()
+
+	// Close the connection without holding up the pool
+	if removed {
+		closePooled(server)
+	}
+}
+
+// closePooled shuts a pooled connection down
+func closePooled(conn *ManagedServer) {
+	if conn.Protocol.IsInitialized() {
+		conn.Protocol.Shutdown()
+	}
+	conn.Transport.Close()
+}
+
+// GetPoolStatus returns the status of all connection pools
+func (pm *ProcessManager) GetPoolStatus() map[string][]ServerStatus {
+	pm.poolMu.RLock()
+	defer pm.poolMu.RUnlock()
+
+	poolStatus := make(map[string][]ServerStatus)
+	for serverName, connections := range pm.connectionPool {
+		status := make([]ServerStatus, len(connections))
+		for i, conn := range connections {
+			status[i] = conn.GetStatus()
+		}
+		poolStatus[serverName] = status
+	}
+
+	return poolStatus
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pooledServers fills a pool for server with connections that need no
+// process behind them
+func pooledServers(pm *ProcessManager, server string, n int) []*ManagedServer {
+	now := time.Now()
+	conns := make([]*ManagedServer, n)
+	for i := range conns {
+		transport := NewMockTransport()
+		_ = transport.Connect(context.Background())
+		conns[i] = &ManagedServer{
+			Name:      fmt.Sprintf("%s-pool-%d", server, i),
+			Transport: transport,
+			Protocol:  NewProtocolHandler(transport),
+			startTime: now,
+			lastUsed:  now,
+		}
+	}
+	pm.connectionPool[server] = append([]*ManagedServer(nil), conns...)
+	return conns
+}
+
+func TestParsePoolConfig(t *testing.T) {
+	pool, err := ParsePoolConfig(config.MCPPoolConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPoolConfig(), pool)
+
+	pool, err = ParsePoolConfig(config.MCPPoolConfig{
+		Size:        5,
+		MaxLifetime: "30m",
+		IdleTimeout: "5m",
+		Selection:   "least_loaded",
+		WarmUp:      8,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PoolConfig{
+		Size:        5,
+		MaxLifetime: 30 * time.Minute,
+		IdleTimeout: 5 * time.Minute,
+		Selection:   PoolLeastLoaded,
+		WarmUp:      5,
+	}, pool)
+
+	_, err = ParsePoolConfig(config.MCPPoolConfig{Selection: "random"})
+	assert.ErrorContains(t, err, "invalid pool selection")
+	_, err = ParsePoolConfig(config.MCPPoolConfig{MaxLifetime: "soon"})
+	assert.ErrorContains(t, err, "invalid pool max lifetime")
+}
+
+func TestProcessManager_PoolRoundRobin(t *testing.T) {
+	pm := NewProcessManager()
+	defer pm.StopAll()
+	conns := pooledServers(pm, "search", 3)
+
+	var got []*ManagedServer
+	for range 4 {
+		conn, err := pm.GetPooledConnection("search")
+		require.NoError(t, err)
+		pm.ReleaseConnection(conn)
+		got = append(got, conn)
+	}
+	assert.Equal(t, []*ManagedServer{conns[0], conns[1], conns[2], conns[0]}, got)
+
+	// Busy connections are skipped
+	busy, err := pm.GetPooledConnection("search")
+	require.NoError(t, err)
+	next, err := pm.GetPooledConnection("search")
+	require.NoError(t, err)
+	assert.Same(t, conns[1], busy)
+	assert.Same(t, conns[2], next)
+}
+
+func TestProcessManager_PoolLeastLoaded(t *testing.T) {
+	pm := NewProcessManager()
+	defer pm.StopAll()
+	pm.SetPoolConfig(PoolConfig{Size: 3, Selection: PoolLeastLoaded})
+	conns := pooledServers(pm, "search", 3)
+	conns[0].requestCount = 10
+	conns[1].requestCount = 2
+	conns[2].requestCount = 5
+
+	first, err := pm.GetPooledConnection("search")
+	require.NoError(t, err)
+	second, err := pm.GetPooledConnection("search")
+	require.NoError(t, err)
+	assert.Same(t, conns[1], first)
+	assert.Same(t, conns[2], second)
+
+	stats := pm.GetPoolStats()
+	assert.Equal(t, int64(2), stats.Acquired)
+}
+
+func TestProcessManager_PoolEviction(t *testing.T) {
+	pm := NewProcessManager()
+	defer pm.StopAll()
+	pm.SetPoolConfig(PoolConfig{Size: 3, Selection: PoolRoundRobin, MaxLifetime: time.Hour, IdleTimeout: time.Minute})
+	conns := pooledServers(pm, "search", 3)
+	conns[0].startTime = time.Now().Add(-2 * time.Hour)
+	conns[1].lastUsed = time.Now().Add(-2 * time.Minute)
+	conns[2].startTime = time.Now().Add(-2 * time.Hour)
+	conns[2].inUse = true
+
+	conn, err := pm.GetPooledConnection("search")
+	require.Error(t, err, "the only connection left is in use")
+	assert.Nil(t, conn)
+	assert.Equal(t, []*ManagedServer{conns[2]}, pm.connectionPool["search"])
+
+	// The connection in use is retired once released
+	pm.ReleaseConnection(conns[2])
+	assert.Empty(t, pm.connectionPool["search"])
+
+	stats := pm.GetPoolStats()
+	assert.Equal(t, map[string]int64{EvictLifetime: 2, EvictIdle: 1}, stats.Evicted)
+	assert.False(t, conns[0].Transport.IsConnected())
+
+	pm.removeFromPool(conns[0], EvictUnhealthy)
+	assert.Zero(t, pm.GetPoolStats().Evicted[EvictUnhealthy], "connections no longer pooled are not counted")
+}
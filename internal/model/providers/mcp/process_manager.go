@@ -3,24 +3,38 @@ package mcp
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/secrets"
+	"github.com/dshills/sigil/internal/trust"
+	"github.com/dshills/sigil/internal/version"
 )
 
 // ProcessManager manages MCP server processes
 type ProcessManager struct {
 	servers        map[string]*ManagedServer
 	connectionPool map[string][]*ManagedServer
-	poolSize       int
+	pool           PoolConfig
+	poolCursor     map[string]int // Where round-robin selection resumes, per server
+	poolStats      PoolStats
 	healthTicker   *time.Ticker
 	ctx            context.Context
 	cancel         context.CancelFunc
 	mu             sync.RWMutex
 	poolMu         sync.RWMutex
+
+	// startMu serializes on-demand starts so a server is only launched once
+	startMu sync.Mutex
+
+	handlersMu      sync.RWMutex
+	samplingHandler SamplingHandler
+	secretLookup    SecretLookup
+	roots           []Root
+
+	lifecycle *lifecycleStream
 }
 
 // ManagedServer represents a managed MCP server instance
@@ -36,9 +50,24 @@ type ManagedServer struct {
 	lastHealthCheck time.Time
 	requestCount    int64
 	inUse           bool
+	lastUsed        time.Time
+	idleTimeout     time.Duration
+	health          healthPolicy
+	healthFailures  int
+	stopped         chan struct{}
+	stopOnce        sync.Once
 	mu              sync.RWMutex
 }
 
+// markStopped signals the server's monitor to exit
+func (s *ManagedServer) markStopped() {
+	s.stopOnce.Do(func() {
+		if s.stopped != nil {
+			close(s.stopped)
+		}
+	})
+}
+
 // ServerConfig defines configuration for an MCP server
 type ServerConfig struct {
 	Name        string            `yaml:"name" json:"name"`
@@ -49,147 +78,139 @@ type ServerConfig struct {
 	WorkingDir  string            `yaml:"workingDir" json:"workingDir"`
 	AutoRestart bool              `yaml:"autoRestart" json:"autoRestart"`
 	MaxRestarts int               `yaml:"maxRestarts" json:"maxRestarts"`
-	Settings    struct {
-		Timeout    string `yaml:"timeout" json:"timeout"`
-		MaxRetries int    `yaml:"maxRetries" json:"maxRetries"`
-	} `yaml:"settings" json:"settings"`
-}
+	LazyStart   bool              `yaml:"lazyStart" json:"lazyStart"`
+	IdleTimeout string            `yaml:"idleTimeout" json:"idleTimeout"`
+	Settings    ServerSettings    `yaml:"settings" json:"settings"`
 
-// NewProcessManager creates a new process manager
-func NewProcessManager() *ProcessManager {
-	ctx, cancel := context.WithCancel(context.Background())
-	pm := &ProcessManager{
-		servers:        make(map[string]*ManagedServer),
-		connectionPool: make(map[string][]*ManagedServer),
-		poolSize:       3, // Default pool size
-		ctx:            ctx,
-		cancel:         cancel,
-	}
-
-	// Start global health monitoring
-	pm.healthTicker = time.NewTicker(15 * time.Second)
-	go pm.globalHealthMonitor()
-
-	return pm
+	// Project marks a server declared by the repository's own configuration,
+	// whose command is refused in untrusted mode
+	Project bool `yaml:"-" json:"-"`
 }
 
-// SetPoolSize sets the connection pool size
-func (pm *ProcessManager) SetPoolSize(size int) {
-	pm.poolMu.Lock()
-	defer pm.poolMu.Unlock()
-	pm.poolSize = size
-}
+// ServerSettings holds per-server protocol settings
+type ServerSettings struct {
+	Timeout    string `yaml:"timeout" json:"timeout"`
+	MaxRetries int    `yaml:"maxRetries" json:"maxRetries"`
 
-// GetPooledConnection gets a connection from the pool or creates a new one
-func (pm *ProcessManager) GetPooledConnection(serverName string) (*ManagedServer, error) {
-	pm.poolMu.Lock()
-	defer pm.poolMu.Unlock()
-
-	// Check for available connections in pool
-	if connections, exists := pm.connectionPool[serverName]; exists {
-		for i, conn := range connections {
-			if !conn.inUse {
-				conn.mu.Lock()
-				conn.inUse = true
-				conn.requestCount++
-				conn.mu.Unlock()
-
-				// Move connection to end of slice (LRU)
-				if i < len(connections)-1 {
-					connections[i], connections[len(connections)-1] = connections[len(connections)-1], connections[i]
-				}
-
-				return conn, nil
-			}
-		}
-	}
-
-	// No available connections, check if we can create a new one
-	pm.mu.RLock()
-	server, exists := pm.servers[serverName]
-	pm.mu.RUnlock()
+	// ProtocolVersion pins the MCP revision offered during initialization
+	ProtocolVersion string `yaml:"protocolVersion" json:"protocolVersion"`
 
-	if !exists {
-		return nil, fmt.Errorf("server %s not found", serverName)
-	}
+	// MaxMessageSize bounds a single message in bytes (default 4 MiB, -1 for no limit)
+	MaxMessageSize int `yaml:"maxMessageSize" json:"maxMessageSize"`
 
-	// Check pool size limit
-	if len(pm.connectionPool[serverName]) >= pm.poolSize {
-		return nil, fmt.Errorf("connection pool for server %s is full", serverName)
-	}
+	// BufferSize is the stdio read buffer and write chunk size in bytes (default 64 KiB)
+	BufferSize int `yaml:"bufferSize" json:"bufferSize"`
 
-	// Create new pooled connection
-	pooledServer, err := pm.createPooledConnection(server.Config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create pooled connection: %w", err)
-	}
+	// WriteTimeout bounds how long a write waits for the server to read
+	// each chunk before it is considered stalled (default 30s)
+	WriteTimeout string `yaml:"writeTimeout" json:"writeTimeout"`
 
-	// Add to pool
-	if pm.connectionPool[serverName] == nil {
-		pm.connectionPool[serverName] = make([]*ManagedServer, 0, pm.poolSize)
-	}
-	pm.connectionPool[serverName] = append(pm.connectionPool[serverName], pooledServer)
+	// CoalesceWindow is how long bursts of progress and resource update
+	// notifications are held so only the latest is handled (default 50ms,
+	// "0s" handles each one)
+	CoalesceWindow string `yaml:"coalesceWindow" json:"coalesceWindow"`
 
-	pooledServer.mu.Lock()
-	pooledServer.inUse = true
-	pooledServer.requestCount++
-	pooledServer.mu.Unlock()
+	// HealthCheck configures liveness probing
+	HealthCheck HealthCheckSettings `yaml:"healthCheck" json:"healthCheck"`
 
-	return pooledServer, nil
+	// Backoff configures the delay between restart attempts
+	Backoff BackoffSettings `yaml:"backoff" json:"backoff"`
 }
 
-// ReleaseConnection releases a pooled connection back to the pool
-func (pm *ProcessManager) ReleaseConnection(server *ManagedServer) {
-	server.mu.Lock()
-	server.inUse = false
-	server.mu.Unlock()
+// newProtocolForTransport creates a protocol handler wired to the transport's callbacks
+func newProtocolForTransport(transport Transport) *ProtocolHandler {
+	protocol := NewProtocolHandler(transport)
+	if stdioTransport, ok := transport.(*StdioTransport); ok {
+		stdioTransport.SetMessageHandler(protocol.ProcessMessage)
+		stdioTransport.SetBatchHandler(protocol.ProcessBatch)
+		stdioTransport.SetDisconnectHandler(protocol.ConnectionLost)
+		stdioTransport.SetFrameErrorHandler(protocol.HandleFrameError)
+	}
+	return protocol
 }
 
-// createPooledConnection creates a new connection for the pool
-func (pm *ProcessManager) createPooledConnection(config ServerConfig) (*ManagedServer, error) {
-	// Create transport
-	transport, err := pm.createTransport(config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create transport: %w", err)
+// initializeProtocol performs the MCP handshake for a freshly connected server
+func (pm *ProcessManager) initializeProtocol(protocol *ProtocolHandler, config ServerConfig) (*InitializeResult, error) {
+	pm.handlersMu.RLock()
+	samplingHandler := pm.samplingHandler
+	pm.handlersMu.RUnlock()
+	if samplingHandler != nil {
+		protocol.SetSamplingHandler(samplingHandler)
+	}
+	if rootsHandler := pm.rootsHandler(); rootsHandler != nil {
+		protocol.SetRootsHandler(rootsHandler)
 	}
 
-	// Create protocol handler
-	protocol := NewProtocolHandler(transport)
-	transport.(*StdioTransport).SetMessageHandler(protocol.ProcessMessage)
+	if config.Settings.Timeout != "" {
+		timeout, err := time.ParseDuration(config.Settings.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Settings.Timeout, err)
+		}
+		protocol.SetRequestTimeout(timeout)
+	}
 
-	// Create managed server
-	server := &ManagedServer{
-		Name:      fmt.Sprintf("%s-pool-%d", config.Name, time.Now().UnixNano()),
-		Config:    config,
-		Transport: transport,
-		Protocol:  protocol,
-		startTime: time.Now(),
+	if config.Settings.CoalesceWindow != "" {
+		window, err := time.ParseDuration(config.Settings.CoalesceWindow)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coalesce window %q: %w", config.Settings.CoalesceWindow, err)
+		}
+		protocol.SetCoalesceWindow(window)
 	}
 
-	// Connect transport
-	if err := transport.Connect(pm.ctx); err != nil {
-		return nil, fmt.Errorf("failed to connect transport: %w", err)
+	if config.Settings.ProtocolVersion != "" {
+		if err := protocol.SetProtocolVersion(config.Settings.ProtocolVersion); err != nil {
+			return nil, err
+		}
 	}
 
-	// Initialize protocol
 	clientInfo := ClientInfo{
 		Name:    "sigil",
-		Version: "1.0.0",
+		Version: version.Get().Version,
 	}
 
 	capabilities := ClientCapabilities{
-		Streaming: false,
+		Streaming: true,
 		Tools:     true,
 		Resources: true,
 	}
 
-	_, err = protocol.Initialize(clientInfo, capabilities)
-	if err != nil {
-		transport.Close()
-		return nil, fmt.Errorf("failed to initialize protocol: %w", err)
+	return protocol.Initialize(clientInfo, capabilities)
+}
+
+// NewProcessManager creates a new process manager
+func NewProcessManager() *ProcessManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	pm := &ProcessManager{
+		servers:        make(map[string]*ManagedServer),
+		connectionPool: make(map[string][]*ManagedServer),
+		pool:           DefaultPoolConfig(),
+		poolCursor:     make(map[string]int),
+		poolStats:      PoolStats{Evicted: make(map[string]int64)},
+		lifecycle:      newLifecycleStream(),
+		ctx:            ctx,
+		cancel:         cancel,
+		secretLookup:   secrets.Default().Get,
 	}
 
-	return server, nil
+	// Start global health monitoring
+	pm.healthTicker = time.NewTicker(poolHealthInterval)
+	go pm.globalHealthMonitor()
+
+	return pm
+}
+
+// SetSamplingHandler answers sampling requests from servers started after the call
+func (pm *ProcessManager) SetSamplingHandler(handler SamplingHandler) {
+	pm.handlersMu.Lock()
+	defer pm.handlersMu.Unlock()
+	pm.samplingHandler = handler
+}
+
+// SetSecretLookup sets how ${secret:name} references in server environments are resolved
+func (pm *ProcessManager) SetSecretLookup(lookup SecretLookup) {
+	pm.handlersMu.Lock()
+	defer pm.handlersMu.Unlock()
+	pm.secretLookup = lookup
 }
 
 // StartServer starts an MCP server
@@ -202,6 +223,23 @@ func (pm *ProcessManager) StartServer(ctx context.Context, config ServerConfig)
 		return nil, fmt.Errorf("server %s already exists", config.Name)
 	}
 
+	policy, err := resolveHealthPolicy(config.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid health settings for server %s: %w", config.Name, err)
+	}
+
+	idleTimeout, err := parseIdleTimeout(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid settings for server %s: %w", config.Name, err)
+	}
+
+	// A fresh clone must not run commands its configuration declares
+	if config.Project && spawnsProcess(config) {
+		if err := trust.Check("StartServer", fmt.Sprintf("starting MCP server %s from the repository's configuration", config.Name)); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create transport based on type
 	transport, err := pm.createTransport(config)
 	if err != nil {
@@ -209,12 +247,10 @@ func (pm *ProcessManager) StartServer(ctx context.Context, config ServerConfig)
 	}
 
 	// Create protocol handler
-	protocol := NewProtocolHandler(transport)
+	protocol := newProtocolForTransport(transport)
 
 	// Configure transport for production use
 	if stdioTransport, ok := transport.(*StdioTransport); ok {
-		stdioTransport.SetMessageHandler(protocol.ProcessMessage)
-
 		// Set up error callback for automatic restart
 		stdioTransport.SetErrorCallback(func(err error) {
 			logger.Warn("MCP server transport error", "server", config.Name, "error", err)
@@ -230,55 +266,59 @@ func (pm *ProcessManager) StartServer(ctx context.Context, config ServerConfig)
 
 	// Create managed server
 	server := &ManagedServer{
-		Name:      config.Name,
-		Config:    config,
-		Transport: transport,
-		Protocol:  protocol,
-		startTime: time.Now(),
+		Name:        config.Name,
+		Config:      config,
+		Transport:   transport,
+		Protocol:    protocol,
+		startTime:   time.Now(),
+		lastUsed:    time.Now(),
+		idleTimeout: idleTimeout,
+		health:      policy,
+		stopped:     make(chan struct{}),
 	}
 
 	// Connect transport
 	if err := transport.Connect(ctx); err != nil {
+		pm.emit(LifecycleEvent{Type: LifecycleStartFailed, Server: config.Name, Error: err.Error()})
 		return nil, fmt.Errorf("failed to connect transport: %w", err)
 	}
 
 	// Initialize protocol
-	clientInfo := ClientInfo{
-		Name:    "sigil",
-		Version: "1.0.0",
-	}
-
-	capabilities := ClientCapabilities{
-		Streaming: false,
-		Tools:     true,
-		Resources: true,
-	}
-
-	initResult, err := protocol.Initialize(clientInfo, capabilities)
+	initResult, err := pm.initializeProtocol(protocol, config)
 	if err != nil {
 		transport.Close()
+		pm.emit(LifecycleEvent{Type: LifecycleStartFailed, Server: config.Name, Error: err.Error()})
 		return nil, fmt.Errorf("failed to initialize protocol: %w", err)
 	}
 
-	// Log server info
-	fmt.Printf("Started MCP server %s (%s %s)\n",
-		config.Name,
-		initResult.ServerInfo.Name,
-		initResult.ServerInfo.Version)
+	pm.emit(LifecycleEvent{
+		Type:           LifecycleStarted,
+		Server:         config.Name,
+		Implementation: strings.TrimSpace(initResult.ServerInfo.Name + " " + initResult.ServerInfo.Version),
+	})
 
 	// Store server
 	pm.servers[config.Name] = server
 
-	// Start health monitoring if auto-restart is enabled
-	if config.AutoRestart {
+	// Start health monitoring unless there is nothing to probe or restart
+	if config.AutoRestart || policy.probe != HealthProbeNone {
 		go pm.monitorHealth(server)
 	}
+	if idleTimeout > 0 {
+		go pm.monitorIdle(server)
+	}
+	go pm.warmPool(config.Name)
 
 	return server, nil
 }
 
 // StopServer stops an MCP server
 func (pm *ProcessManager) StopServer(name string) error {
+	return pm.stopServer(name, StopRequested)
+}
+
+// stopServer stops an MCP server, reporting reason in its stopped event
+func (pm *ProcessManager) stopServer(name, reason string) error {
 	pm.mu.Lock()
 	server, exists := pm.servers[name]
 	if !exists {
@@ -288,6 +328,13 @@ func (pm *ProcessManager) StopServer(name string) error {
 	delete(pm.servers, name)
 	pm.mu.Unlock()
 
+	return pm.shutdownServer(server, reason)
+}
+
+// shutdownServer stops a server that has already been removed from the manager
+func (pm *ProcessManager) shutdownServer(server *ManagedServer, reason string) error {
+	server.markStopped()
+
 	// Shutdown protocol
 	if server.Protocol.IsInitialized() {
 		if err := server.Protocol.Shutdown(); err != nil {
@@ -298,8 +345,12 @@ func (pm *ProcessManager) StopServer(name string) error {
 		}
 	}
 
-	// Close transport
-	return server.Transport.Close()
+	// Close transport and release anything still waiting on it
+	err := server.Transport.Close()
+	server.Protocol.Close()
+
+	pm.emit(LifecycleEvent{Type: LifecycleStopped, Server: server.Name, Reason: reason, Error: errorText(err)})
+	return err
 }
 
 // GetServer returns a managed server by name
@@ -345,7 +396,7 @@ func (pm *ProcessManager) StopAll() {
 
 	// Stop servers outside of lock
 	for _, server := range servers {
-		pm.StopServer(server.Name)
+		pm.stopServer(server.Name, StopShutdown)
 	}
 
 	// Clean up connection pools
@@ -374,118 +425,31 @@ func (pm *ProcessManager) globalHealthMonitor() {
 	}
 }
 
-// performHealthChecks performs health checks on all servers
+// performHealthChecks checks pooled connections and evicts dead ones. Main
+// servers are checked by their own monitor using their configured policy.
 func (pm *ProcessManager) performHealthChecks() {
-	pm.mu.RLock()
-	servers := make([]*ManagedServer, 0, len(pm.servers))
-	for _, server := range pm.servers {
-		servers = append(servers, server)
-	}
-	pm.mu.RUnlock()
+	pm.evictExpired()
 
-	for _, server := range servers {
-		go pm.checkServerHealth(server)
-	}
-
-	// Also check pooled connections
 	pm.poolMu.RLock()
-	for _, connections := range pm.connectionPool {
-		for _, conn := range connections {
-			go pm.checkServerHealth(conn)
-		}
+	connections := make([]*ManagedServer, 0)
+	for _, pooled := range pm.connectionPool {
+		connections = append(connections, pooled...)
 	}
 	pm.poolMu.RUnlock()
-}
-
-// checkServerHealth performs a health check on a single server
-func (pm *ProcessManager) checkServerHealth(server *ManagedServer) {
-	server.mu.Lock()
-	server.lastHealthCheck = time.Now()
-	server.mu.Unlock()
-
-	if !server.Transport.IsConnected() {
-		server.mu.Lock()
-		server.lastError = fmt.Errorf("server disconnected")
-		server.mu.Unlock()
-
-		// Handle disconnection based on server type
-		if strings.Contains(server.Name, "-pool-") {
-			// This is a pooled connection, remove it from pool
-			pm.removeFromPool(server)
-		} else if server.Config.AutoRestart {
-			// This is a main server, attempt restart
-			go pm.attemptRestart(server)
-		}
-		return
-	}
-
-	// Perform ping health check
-	if server.Protocol.IsInitialized() {
-		_, err := server.Protocol.Ping("health-check")
-		if err != nil {
-			server.mu.Lock()
-			server.lastError = fmt.Errorf("ping failed: %w", err)
-			server.mu.Unlock()
-		} else {
-			server.mu.Lock()
-			server.lastError = nil
-			server.mu.Unlock()
-		}
-	}
-}
-
-// removeFromPool removes a server from the connection pool
-func (pm *ProcessManager) removeFromPool(server *ManagedServer) {
-	pm.poolMu.Lock()
-	defer pm.poolMu.Unlock()
 
-	for serverName, connections := range pm.connectionPool {
-		for i, conn := range connections {
-			if conn == server {
-				// Close the connection
-				if conn.Protocol.IsInitialized() {
-					conn.Protocol.Shutdown()
-				}
-				conn.Transport.Close()
-
-				// Remove from slice
-				connections[i] = connections[len(connections)-1]
-				pm.connectionPool[serverName] = connections[:len(connections)-1]
+	for _, conn := range connections {
+		go func(conn *ManagedServer) {
+			if pm.checkServerHealth(conn) {
 				return
 			}
-		}
-	}
-}
-
-// attemptRestart attempts to restart a failed server
-func (pm *ProcessManager) attemptRestart(server *ManagedServer) {
-	server.mu.Lock()
-	restartCount := server.restartCount
-	maxRestarts := server.Config.MaxRestarts
-	server.mu.Unlock()
-
-	if maxRestarts == 0 {
-		maxRestarts = 3
-	}
-
-	if restartCount >= maxRestarts {
-		fmt.Printf("Server %s exceeded max restarts (%d), not restarting\n",
-			server.Name, maxRestarts)
-		pm.StopServer(server.Name)
-		return
-	}
-
-	fmt.Printf("Server %s health check failed, attempting restart...\n", server.Name)
-
-	// Attempt restart
-	if err := pm.restartServer(pm.ctx, server); err != nil {
-		fmt.Printf("Failed to restart server %s: %v\n", server.Name, err)
-		server.mu.Lock()
-		server.lastError = err
-		server.restartCount++
-		server.mu.Unlock()
-	} else {
-		fmt.Printf("Successfully restarted server %s\n", server.Name)
+			conn.mu.RLock()
+			failures := conn.healthFailures
+			connected := conn.Transport.IsConnected()
+			conn.mu.RUnlock()
+			if !connected || failures >= conn.health.failureThreshold {
+				pm.removeFromPool(conn, EvictUnhealthy)
+			}
+		}(conn)
 	}
 }
 
@@ -501,24 +465,37 @@ func (pm *ProcessManager) createTransport(config ServerConfig) (Transport, error
 		}
 	}
 
+	var writeTimeout time.Duration
+	if config.Settings.WriteTimeout != "" {
+		var err error
+		writeTimeout, err = time.ParseDuration(config.Settings.WriteTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid write timeout: %w", err)
+		}
+	}
+
 	// Create transport config
 	transportConfig := TransportConfig{
-		Timeout:    timeout,
-		MaxRetries: config.Settings.MaxRetries,
-		RetryDelay: time.Second,
-		BufferSize: 4096,
+		Timeout:        timeout,
+		MaxRetries:     config.Settings.MaxRetries,
+		RetryDelay:     time.Second,
+		BufferSize:     config.Settings.BufferSize,
+		MaxMessageSize: config.Settings.MaxMessageSize,
+		WriteTimeout:   writeTimeout,
 	}
 
 	if transportConfig.MaxRetries == 0 {
 		transportConfig.MaxRetries = 3
 	}
 
-	// Expand environment variables
-	env := make([]string, 0, len(config.Env))
-	for k, v := range config.Env {
-		// Expand ${VAR} style variables
-		v = os.ExpandEnv(v)
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	// Expand ${VAR} and ${secret:name} references
+	pm.handlersMu.RLock()
+	lookup := pm.secretLookup
+	pm.handlersMu.RUnlock()
+
+	env, err := expandEnv(config.Env, lookup)
+	if err != nil {
+		return nil, fmt.Errorf("server %s: %w", config.Name, err)
 	}
 
 	switch strings.ToLower(config.Transport) {
@@ -536,43 +513,13 @@ func (pm *ProcessManager) createTransport(config ServerConfig) (Transport, error
 	}
 }
 
-// monitorHealth monitors server health and restarts if needed
-func (pm *ProcessManager) monitorHealth(server *ManagedServer) {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		if !server.Transport.IsConnected() {
-			server.mu.Lock()
-			server.lastError = fmt.Errorf("server disconnected")
-			restartCount := server.restartCount
-			maxRestarts := server.Config.MaxRestarts
-			server.mu.Unlock()
-
-			if maxRestarts == 0 {
-				maxRestarts = 3
-			}
-
-			if restartCount >= maxRestarts {
-				fmt.Printf("Server %s exceeded max restarts (%d), not restarting\n",
-					server.Name, maxRestarts)
-				pm.StopServer(server.Name)
-				return
-			}
-
-			fmt.Printf("Server %s disconnected, attempting restart...\n", server.Name)
-
-			// Attempt restart
-			ctx := context.Background()
-			if err := pm.restartServer(ctx, server); err != nil {
-				fmt.Printf("Failed to restart server %s: %v\n", server.Name, err)
-				server.mu.Lock()
-				server.lastError = err
-				server.restartCount++
-				server.mu.Unlock()
-			}
-		}
+// spawnsProcess reports whether config runs a local command
+func spawnsProcess(config ServerConfig) bool {
+	switch strings.ToLower(config.Transport) {
+	case "stdio", "":
+		return true
 	}
+	return false
 }
 
 // restartServer attempts to restart a server
@@ -587,8 +534,7 @@ func (pm *ProcessManager) restartServer(ctx context.Context, server *ManagedServ
 	}
 
 	// Create new protocol handler
-	protocol := NewProtocolHandler(transport)
-	transport.(*StdioTransport).SetMessageHandler(protocol.ProcessMessage)
+	protocol := newProtocolForTransport(transport)
 
 	// Connect transport
 	if err := transport.Connect(ctx); err != nil {
@@ -596,18 +542,7 @@ func (pm *ProcessManager) restartServer(ctx context.Context, server *ManagedServ
 	}
 
 	// Initialize protocol
-	clientInfo := ClientInfo{
-		Name:    "sigil",
-		Version: "1.0.0",
-	}
-
-	capabilities := ClientCapabilities{
-		Streaming: false,
-		Tools:     true,
-		Resources: true,
-	}
-
-	_, err = protocol.Initialize(clientInfo, capabilities)
+	_, err = pm.initializeProtocol(protocol, server.Config)
 	if err != nil {
 		transport.Close()
 		return fmt.Errorf("failed to initialize protocol: %w", err)
@@ -632,8 +567,10 @@ type ServerStatus struct {
 	RestartCount    int           `json:"restartCount"`
 	LastError       string        `json:"lastError,omitempty"`
 	LastHealthCheck time.Time     `json:"lastHealthCheck"`
+	LastUsed        time.Time     `json:"lastUsed"`
 	RequestCount    int64         `json:"requestCount"`
 	InUse           bool          `json:"inUse"`
+	HealthFailures  int           `json:"healthFailures"`
 	Protocol        string        `json:"protocol,omitempty"`
 	ServerInfo      *ServerInfo   `json:"serverInfo,omitempty"`
 }
@@ -649,6 +586,8 @@ func (s *ManagedServer) GetStatus() ServerStatus {
 		Uptime:          time.Since(s.startTime),
 		RestartCount:    s.restartCount,
 		LastHealthCheck: s.lastHealthCheck,
+		LastUsed:        s.lastUsed,
+		HealthFailures:  s.healthFailures,
 		RequestCount:    s.requestCount,
 		InUse:           s.inUse,
 	}
@@ -665,23 +604,6 @@ func (s *ManagedServer) GetStatus() ServerStatus {
 	return status
 }
 
-// GetPoolStatus returns the status of all connection pools
-func (pm *ProcessManager) GetPoolStatus() map[string][]ServerStatus {
-	pm.poolMu.RLock()
-	defer pm.poolMu.RUnlock()
-
-	poolStatus := make(map[string][]ServerStatus)
-	for serverName, connections := range pm.connectionPool {
-		status := make([]ServerStatus, len(connections))
-		for i, conn := range connections {
-			status[i] = conn.GetStatus()
-		}
-		poolStatus[serverName] = status
-	}
-
-	return poolStatus
-}
-
 // GetOverallHealth returns overall health metrics
 func (pm *ProcessManager) GetOverallHealth() map[string]interface{} {
 	pm.mu.RLock()
@@ -710,7 +632,8 @@ func (pm *ProcessManager) GetOverallHealth() map[string]interface{} {
 		"totalServers":        serverCount,
 		"connectedServers":    connectedServers,
 		"pooledConnections":   poolCount,
+		"pool":                pm.GetPoolStats(),
 		"totalRequests":       totalRequests,
-		"healthCheckInterval": "15s",
+		"healthCheckInterval": poolHealthInterval.String(),
 	}
 }
@@ -2,11 +2,15 @@ package mcp
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/trust"
 )
 
 func TestNewProcessManager(t *testing.T) {
@@ -14,7 +18,7 @@ func TestNewProcessManager(t *testing.T) {
 	require.NotNil(t, pm)
 	assert.NotNil(t, pm.servers)
 	assert.NotNil(t, pm.connectionPool)
-	assert.Equal(t, 3, pm.poolSize)
+	assert.Equal(t, 3, pm.pool.Size)
 	assert.NotNil(t, pm.ctx)
 	assert.NotNil(t, pm.cancel)
 	assert.NotNil(t, pm.healthTicker)
@@ -28,7 +32,7 @@ func TestProcessManager_SetPoolSize(t *testing.T) {
 	defer pm.StopAll()
 
 	pm.SetPoolSize(5)
-	assert.Equal(t, 5, pm.poolSize)
+	assert.Equal(t, 5, pm.pool.Size)
 }
 
 func TestProcessManager_ServerLifecycle(t *testing.T) {
@@ -241,10 +245,7 @@ func TestProcessManager_TransportConfig(t *testing.T) {
 		Name:      "config-test",
 		Command:   "echo",
 		Transport: "stdio",
-		Settings: struct {
-			Timeout    string `yaml:"timeout" json:"timeout"`
-			MaxRetries int    `yaml:"maxRetries" json:"maxRetries"`
-		}{
+		Settings: ServerSettings{
 			Timeout:    "30s",
 			MaxRetries: 5,
 		},
@@ -389,3 +390,36 @@ func TestProcessManager_NonexistentPoolConnection(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not found")
 }
+
+func TestProcessManager_UntrustedProjectServer(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "spawned")
+	globalPath := filepath.Join(dir, "global.yml")
+	projectPath := filepath.Join(dir, "repo", ".sigil", "mcp-servers.yml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(projectPath), 0750))
+	require.NoError(t, os.WriteFile(globalPath, []byte("servers:\n  - name: user-server\n    command: echo\n"), 0600))
+	require.NoError(t, os.WriteFile(projectPath, []byte("servers:\n  - name: repo-server\n    command: touch\n    args: [\""+marker+"\"]\n"), 0600))
+
+	configs, err := NewConfigLoader(globalPath, projectPath).LoadConfigurations()
+	require.NoError(t, err)
+	byName := make(map[string]ServerConfig)
+	for _, cfg := range configs {
+		byName[cfg.Name] = cfg
+	}
+	require.True(t, byName["repo-server"].Project)
+	require.False(t, byName["user-server"].Project)
+
+	trust.Set(true, "fresh clone")
+	defer trust.Set(false, "")
+	pm := NewProcessManager()
+	defer pm.StopAll()
+
+	_, err = pm.StartServer(context.Background(), byName["repo-server"])
+	require.ErrorIs(t, err, trust.ErrUntrusted)
+	_, err = pm.GetServer("repo-server")
+	assert.Error(t, err, "the refused server is not registered")
+	assert.NoFileExists(t, marker, "the repository's command never ran")
+
+	_, err = pm.StartServer(context.Background(), byName["user-server"])
+	assert.NotErrorIs(t, err, trust.ErrUntrusted, "servers from the user's own configuration are not refused")
+}
@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// watchProgress asks the server for progress on a request when ctx has a
+// progress function, adding the request ID as the progress token to the
+// request's _meta. It returns the params to send and a function that stops
+// watching, to call once the request completes.
+func (h *ProtocolHandler) watchProgress(ctx context.Context, id int64, paramsJSON json.RawMessage) (json.RawMessage, func()) {
+	fn := model.ProgressFromContext(ctx)
+	if fn == nil {
+		return paramsJSON, func() {}
+	}
+
+	withToken, err := addProgressToken(paramsJSON, id)
+	if err != nil {
+		logger.Debug("not asking for MCP progress", "id", id, "error", err)
+		return paramsJSON, func() {}
+	}
+
+	token := strconv.FormatInt(id, 10)
+	h.mu.Lock()
+	if h.progressListeners == nil {
+		h.progressListeners = make(map[string]model.ProgressFunc)
+	}
+	h.progressListeners[token] = fn
+	h.mu.Unlock()
+
+	return withToken, func() {
+		h.mu.Lock()
+		delete(h.progressListeners, token)
+		h.mu.Unlock()
+	}
+}
+
+// addProgressToken sets _meta.progressToken in request params, which must
+// be an object or absent
+func addProgressToken(paramsJSON json.RawMessage, token int64) (json.RawMessage, error) {
+	params := make(map[string]json.RawMessage)
+	if len(paramsJSON) > 0 && string(paramsJSON) != "null" {
+		if err := json.Unmarshal(paramsJSON, &params); err != nil {
+			return nil, err
+		}
+	}
+
+	meta := make(map[string]json.RawMessage)
+	if raw, ok := params["_meta"]; ok {
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, err
+		}
+	}
+	meta["progressToken"] = json.RawMessage(strconv.FormatInt(token, 10))
+
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	params["_meta"] = metaJSON
+	return json.Marshal(params)
+}
+
+// progressTokenKey returns a progress token as a string, whether the server
+// sent it as a number or a string
+func progressTokenKey(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	return string(raw)
+}
+
+// handleProgress passes progress notifications to the listener for their token
+func (h *ProtocolHandler) handleProgress(msg *RPCMessage) {
+	var params struct {
+		ProgressToken json.RawMessage `json:"progressToken"`
+		Progress      float64         `json:"progress"`
+		Total         float64         `json:"total,omitempty"`
+		Message       string          `json:"message,omitempty"`
+	}
+
+	if err := json.Unmarshal(msg.Params, &params); err != nil || len(params.ProgressToken) == 0 {
+		return
+	}
+
+	token := progressTokenKey(params.ProgressToken)
+	h.mu.RLock()
+	fn := h.progressListeners[token]
+	h.mu.RUnlock()
+
+	if fn == nil {
+		// Progress for a request that has already completed
+		logger.Debug("MCP progress for unknown token", "token", token, "progress", params.Progress, "total", params.Total)
+		return
+	}
+
+	fn(model.Progress{
+		Token:    token,
+		Progress: params.Progress,
+		Total:    params.Total,
+		Message:  params.Message,
+	})
+}
+
+// withProgressSource names the server in the progress ctx reports, if any
+func withProgressSource(ctx context.Context, server string) context.Context {
+	fn := model.ProgressFromContext(ctx)
+	if fn == nil {
+		return ctx
+	}
+	return model.WithProgress(ctx, func(p model.Progress) {
+		p.Source = server
+		fn(p)
+	})
+}
@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dshills/sigil/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddProgressToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		want   string
+	}{
+		{"no params", `null`, `{"_meta":{"progressToken":3}}`},
+		{"object", `{"name":"index"}`, `{"name":"index","_meta":{"progressToken":3}}`},
+		{"existing meta", `{"name":"index","_meta":{"trace":"abc"}}`, `{"name":"index","_meta":{"trace":"abc","progressToken":3}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addProgressToken(json.RawMessage(tt.params), 3)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+
+	_, err := addProgressToken(json.RawMessage(`["positional"]`), 3)
+	assert.Error(t, err)
+}
+
+func TestProtocolHandler_RequestProgress(t *testing.T) {
+	handler, transport := newDispatchHandler(t)
+	handler.SetCoalesceWindow(0)
+
+	var mu sync.Mutex
+	var reported []model.Progress
+	ctx := model.WithProgress(context.Background(), func(p model.Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, p)
+	})
+	ctx = withProgressSource(ctx, "indexer")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := handler.RequestContext(ctx, "tools/call", ToolCallParams{Name: "index"})
+		done <- err
+	}()
+
+	request := transport.GetLastMessage()
+	require.NotNil(t, request)
+	var params struct {
+		Meta struct {
+			ProgressToken int64 `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	require.NoError(t, json.Unmarshal(request.Params, &params))
+	assert.Equal(t, *request.ID, params.Meta.ProgressToken)
+
+	handler.ProcessMessage(notification("notifications/progress", `{"progressToken":1,"progress":40,"total":100,"message":"parsing"}`))
+	handler.ProcessMessage(notification("notifications/progress", `{"progressToken":"other","progress":1}`))
+	handler.ProcessMessage(&RPCMessage{JSONRPC: "2.0", ID: request.ID, Result: json.RawMessage(`{}`)})
+	require.NoError(t, <-done)
+
+	// Progress after the response has nobody to go to
+	handler.ProcessMessage(notification("notifications/progress", `{"progressToken":1,"progress":100,"total":100}`))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []model.Progress{{Source: "indexer", Token: "1", Progress: 40, Total: 100, Message: "parsing"}}, reported)
+}
+
+func TestProtocolHandler_RequestWithoutProgress(t *testing.T) {
+	handler, transport := newDispatchHandler(t)
+
+	go func() {
+		_, _ = handler.RequestContext(context.Background(), "tools/call", ToolCallParams{Name: "index"})
+	}()
+
+	request := transport.GetLastMessage()
+	require.NotNil(t, request)
+	assert.NotContains(t, string(request.Params), "_meta")
+	handler.Close()
+
+	require.Eventually(t, func() bool { return handler.PendingRequests() == 0 }, time.Second, 5*time.Millisecond)
+}
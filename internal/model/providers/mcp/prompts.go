@@ -0,0 +1,132 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// PromptPreset is a prompt template offered by a configured server
+type PromptPreset struct {
+	Server   string
+	Template PromptTemplate
+}
+
+// Name returns the qualified preset name, server/prompt
+func (p PromptPreset) Name() string {
+	return p.Server + "/" + p.Template.Name
+}
+
+// MissingArguments returns the required arguments that args does not supply
+func (t PromptTemplate) MissingArguments(args map[string]string) []TemplateArgument {
+	var missing []TemplateArgument
+	for _, arg := range t.Arguments {
+		if _, ok := args[arg.Name]; arg.Required && !ok {
+			missing = append(missing, arg)
+		}
+	}
+	return missing
+}
+
+// PromptPresets lists the prompt templates of every configured server that
+// supports prompts. Servers configured for lazy start are started; servers
+// that fail to start are skipped with a warning.
+func (p *Provider) PromptPresets(ctx context.Context) ([]PromptPreset, error) {
+	var presets []PromptPreset
+	for _, name := range p.serverNames() {
+		server, err := p.EnsureServer(ctx, name)
+		if err != nil {
+			logger.Warn("skipping MCP server for prompts", "server", name, "error", err)
+			continue
+		}
+		if caps := server.Protocol.GetServerCapabilities(); caps == nil || !caps.Prompts {
+			continue
+		}
+
+		templates, err := server.Protocol.ListPrompts()
+		if err != nil {
+			logger.Warn("failed to list MCP prompts", "server", name, "error", err)
+			continue
+		}
+		for _, template := range templates {
+			presets = append(presets, PromptPreset{Server: name, Template: template})
+		}
+	}
+	return presets, nil
+}
+
+// FindPromptPreset looks up a preset by "server/prompt", or by prompt name
+// alone when exactly one server offers it
+func (p *Provider) FindPromptPreset(ctx context.Context, name string) (*PromptPreset, error) {
+	presets, err := p.PromptPresets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []PromptPreset
+	for _, preset := range presets {
+		if preset.Name() == name || preset.Template.Name == name {
+			matches = append(matches, preset)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("prompt %q not found; run 'sigil prompt list' to see available prompts", name)
+	case 1:
+		return &matches[0], nil
+	default:
+		names := make([]string, len(matches))
+		for i, match := range matches {
+			names[i] = match.Name()
+		}
+		return nil, fmt.Errorf("prompt %q is offered by several servers; use one of: %s", name, strings.Join(names, ", "))
+	}
+}
+
+// RenderPromptPreset fills in a preset's template on its server
+func (p *Provider) RenderPromptPreset(ctx context.Context, preset *PromptPreset, args map[string]string) (*PromptResult, error) {
+	known := make(map[string]bool, len(preset.Template.Arguments))
+	for _, arg := range preset.Template.Arguments {
+		known[arg.Name] = true
+	}
+	for name := range args {
+		if !known[name] {
+			return nil, fmt.Errorf("prompt %s has no argument %q", preset.Name(), name)
+		}
+	}
+	if missing := preset.Template.MissingArguments(args); len(missing) > 0 {
+		names := make([]string, len(missing))
+		for i, arg := range missing {
+			names[i] = arg.Name
+		}
+		return nil, fmt.Errorf("prompt %s requires arguments: %s", preset.Name(), strings.Join(names, ", "))
+	}
+
+	server, err := p.EnsureServer(ctx, preset.Server)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		arguments[name] = value
+	}
+	return server.Protocol.GetPrompt(preset.Template.Name, arguments)
+}
+
+// serverNames returns the configured server names in sorted order
+func (p *Provider) serverNames() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	names := make([]string, 0, len(p.serverConfigs))
+	for name := range p.serverConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
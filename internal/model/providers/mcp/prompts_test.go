@@ -0,0 +1,63 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptResult_Decode(t *testing.T) {
+	raw := `{
+		"description": "Review a change",
+		"messages": [
+			{"role": "user", "content": {"type": "text", "text": "Review PR 42"}},
+			{"role": "user", "content": {"type": "resource", "resource": {"uri": "file:///diff", "text": "diff --git"}}},
+			{"role": "assistant", "content": "Legacy string content"}
+		]
+	}`
+
+	var result PromptResult
+	require.NoError(t, json.Unmarshal([]byte(raw), &result))
+	require.Len(t, result.Messages, 3)
+	assert.Equal(t, ContentTypeText, result.Messages[0].Content.Type)
+	assert.Equal(t, "Review PR 42", result.Messages[0].Content.Text)
+	assert.Equal(t, "assistant", result.Messages[2].Role)
+	assert.Equal(t, "Legacy string content", result.Messages[2].Content.Text)
+
+	assert.Equal(t, "Review PR 42\n\ndiff --git\n\nLegacy string content", result.Text())
+}
+
+func TestPromptTemplate_MissingArguments(t *testing.T) {
+	template := PromptTemplate{
+		Name: "summarize",
+		Arguments: []TemplateArgument{
+			{Name: "pr", Required: true},
+			{Name: "style"},
+			{Name: "repo", Required: true},
+		},
+	}
+
+	missing := template.MissingArguments(map[string]string{"repo": "sigil"})
+	require.Len(t, missing, 1)
+	assert.Equal(t, "pr", missing[0].Name)
+	assert.Empty(t, template.MissingArguments(map[string]string{"pr": "1", "repo": "sigil"}))
+}
+
+func TestProvider_RenderPromptPresetChecksArguments(t *testing.T) {
+	provider := &Provider{serverConfigs: map[string]ServerConfig{}}
+	preset := &PromptPreset{
+		Server:   "srv",
+		Template: PromptTemplate{Name: "summarize", Arguments: []TemplateArgument{{Name: "pr", Required: true}}},
+	}
+	assert.Equal(t, "srv/summarize", preset.Name())
+
+	_, err := provider.RenderPromptPreset(t.Context(), preset, map[string]string{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires arguments: pr")
+
+	_, err = provider.RenderPromptPreset(t.Context(), preset, map[string]string{"pr": "1", "typo": "x"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `no argument "typo"`)
+}
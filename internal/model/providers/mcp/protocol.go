@@ -1,11 +1,15 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/dshills/sigil/internal/model"
 )
 
 // RPCMessage represents a JSON-RPC 2.0 message
@@ -62,12 +66,16 @@ type ClientInfo struct {
 	Version string `json:"version"`
 }
 
-// ClientCapabilities defines what the client supports
+// ClientCapabilities defines what the client supports. It is encoded in the
+// specification's object form by MarshalJSON.
 type ClientCapabilities struct {
-	Streaming    bool     `json:"streaming"`
-	Tools        bool     `json:"tools"`
-	Resources    bool     `json:"resources"`
-	Experimental []string `json:"experimental,omitempty"`
+	Streaming        bool
+	Tools            bool
+	Resources        bool
+	Sampling         bool
+	Roots            bool
+	RootsListChanged bool
+	Experimental     []string
 }
 
 // InitializeResult represents the server's response to initialization
@@ -85,19 +93,39 @@ type ServerInfo struct {
 	Version string `json:"version"`
 }
 
-// ServerCapabilities defines what the server supports
+// ServerCapabilities defines what the server supports. Both the legacy boolean
+// form and the specification's object form are accepted when decoding.
 type ServerCapabilities struct {
-	Streaming    bool     `json:"streaming"`
-	Tools        bool     `json:"tools"`
-	Resources    bool     `json:"resources"`
-	Experimental []string `json:"experimental,omitempty"`
+	Streaming            bool
+	Tools                bool
+	ToolsListChanged     bool
+	Resources            bool
+	ResourcesSubscribe   bool
+	ResourcesListChanged bool
+	Prompts              bool
+	PromptsListChanged   bool
+	Logging              bool
+	Completions          bool
+	Experimental         []string
 }
 
 // ToolDefinition describes a tool available on the server
 type ToolDefinition struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
+	Name         string                 `json:"name"`
+	Title        string                 `json:"title,omitempty"`
+	Description  string                 `json:"description"`
+	InputSchema  map[string]interface{} `json:"inputSchema"`
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations       `json:"annotations,omitempty"`
+}
+
+// ToolAnnotations carries behavioural hints about a tool (2025-03-26 and later)
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`
 }
 
 // ResourceDefinition describes a resource available on the server
@@ -112,7 +140,7 @@ type ResourceDefinition struct {
 type CompletionParams struct {
 	Messages    []Message              `json:"messages"`
 	Model       string                 `json:"model,omitempty"`
-	Temperature float64                `json:"temperature,omitempty"`
+	Temperature *float64               `json:"temperature,omitempty"`
 	MaxTokens   int                    `json:"maxTokens,omitempty"`
 	Stream      bool                   `json:"stream,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
@@ -143,24 +171,97 @@ type Usage struct {
 type ProtocolHandler struct {
 	transport       Transport
 	requestID       atomic.Int64
-	pendingRequests map[int64]chan *RPCMessage
+	pendingRequests map[int64]*pendingRequest
+	requestTimeout  time.Duration
+	closed          chan struct{}
+	closeOnce       sync.Once
 	mu              sync.RWMutex
-	initialized     bool
-	serverCaps      *ServerCapabilities
+
+	// state, serverCaps, clientCaps and protocolVersion are guarded by mu
+	state      ProtocolState
+	serverCaps *ServerCapabilities
+	clientCaps ClientCapabilities
+
+	requestedVersion string
+	protocolVersion  string
+
+	onToolsChanged     func()
+	onPromptsChanged   func()
+	onResourcesChanged func()
+
+	requestHandlers map[string]RequestHandler
+
+	// coalescer holds chatty notifications so only the latest is handled
+	coalescer *notificationCoalescer
+
+	// progressListeners receive the progress of in-flight requests, keyed by
+	// progress token
+	progressListeners map[string]model.ProgressFunc
+
+	// streamListeners receive the completion chunks of in-flight streamed
+	// completions, keyed by request ID
+	streamListeners map[string]func(content string)
+
+	// toolSchemas holds input schemas from the last tools/list, keyed by tool name
+	toolSchemas map[string]map[string]interface{}
 }
 
 // NewProtocolHandler creates a new protocol handler
 func NewProtocolHandler(transport Transport) *ProtocolHandler {
-	return &ProtocolHandler{
-		transport:       transport,
-		pendingRequests: make(map[int64]chan *RPCMessage),
+	h := &ProtocolHandler{
+		transport:        transport,
+		pendingRequests:  make(map[int64]*pendingRequest),
+		requestTimeout:   DefaultRequestTimeout,
+		closed:           make(chan struct{}),
+		requestedVersion: LatestProtocolVersion,
+	}
+	h.coalescer = newNotificationCoalescer(DefaultCoalesceWindow, h.handleServerMessage)
+	return h
+}
+
+// SetCoalesceWindow sets how long progress and resource update
+// notifications are held so that only the latest of a burst is handled.
+// Zero handles every notification as it arrives.
+func (h *ProtocolHandler) SetCoalesceWindow(window time.Duration) {
+	if h.coalescer != nil {
+		h.coalescer.setWindow(window)
 	}
 }
 
+// SetProtocolVersion sets the protocol revision offered during initialization
+func (h *ProtocolHandler) SetProtocolVersion(version string) error {
+	if !IsSupportedProtocolVersion(version) {
+		return fmt.Errorf("unsupported protocol version: %s", version)
+	}
+	h.requestedVersion = version
+	return nil
+}
+
 // Initialize performs the MCP initialization handshake
 func (h *ProtocolHandler) Initialize(clientInfo ClientInfo, capabilities ClientCapabilities) (*InitializeResult, error) {
+	if !h.transition(StateUninitialized, StateInitializing) {
+		return nil, fmt.Errorf("cannot initialize protocol in state %s", h.State())
+	}
+	ready := false
+	defer func() {
+		// A failed handshake may be retried
+		if !ready {
+			h.transition(StateInitializing, StateUninitialized)
+		}
+	}()
+
+	// Advertise capabilities backed by registered request handlers
+	if h.hasRequestHandler("sampling/createMessage") {
+		capabilities.Sampling = true
+	}
+	if h.hasRequestHandler("roots/list") {
+		// NotifyRootsChanged keeps servers current as the workspace changes
+		capabilities.Roots = true
+		capabilities.RootsListChanged = true
+	}
+
 	params := InitializeParams{
-		ProtocolVersion: "1.0",
+		ProtocolVersion: h.requestedVersion,
 		ClientInfo:      clientInfo,
 		Capabilities:    capabilities,
 	}
@@ -175,23 +276,81 @@ func (h *ProtocolHandler) Initialize(clientInfo ClientInfo, capabilities ClientC
 		return nil, fmt.Errorf("failed to parse initialization result: %w", err)
 	}
 
-	// Send initialized notification
-	if err := h.Notify("initialized", nil); err != nil {
+	version, err := NegotiateProtocolVersion(h.requestedVersion, initResult.ProtocolVersion)
+	if err != nil {
+		return nil, fmt.Errorf("initialization failed: %w", err)
+	}
+
+	// The legacy draft used a bare method name for the initialized notification
+	notification := "notifications/initialized"
+	if version == LegacyProtocolVersion {
+		notification = "initialized"
+	}
+	if err := h.Notify(notification, nil); err != nil {
 		return nil, fmt.Errorf("failed to send initialized notification: %w", err)
 	}
 
-	h.initialized = true
+	h.mu.Lock()
+	if h.state != StateInitializing {
+		// Closed while the handshake was in flight
+		h.mu.Unlock()
+		return nil, ErrConnectionClosed
+	}
+	h.protocolVersion = version
+	h.clientCaps = capabilities
 	h.serverCaps = &initResult.Capabilities
+	h.state = StateReady
+	h.mu.Unlock()
+
+	ready = true
 	return &initResult, nil
 }
 
+// ProtocolVersion returns the negotiated protocol revision, or an empty string before initialization
+func (h *ProtocolHandler) ProtocolVersion() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.protocolVersion
+}
+
+// Supports reports whether a feature is available for the negotiated version and capabilities
+func (h *ProtocolHandler) Supports(feature Feature) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.state != StateReady {
+		return false
+	}
+	return supportsFeature(feature, h.protocolVersion, h.clientCaps, h.serverCaps)
+}
+
+// OnToolsChanged registers a callback for tool list change notifications
+func (h *ProtocolHandler) OnToolsChanged(fn func()) {
+	h.onToolsChanged = fn
+}
+
+// OnPromptsChanged registers a callback for prompt list change notifications
+func (h *ProtocolHandler) OnPromptsChanged(fn func()) {
+	h.onPromptsChanged = fn
+}
+
+// OnResourcesChanged registers a callback for resource list change notifications
+func (h *ProtocolHandler) OnResourcesChanged(fn func()) {
+	h.onResourcesChanged = fn
+}
+
 // Complete performs text completion
 func (h *ProtocolHandler) Complete(params CompletionParams) (*CompletionResult, error) {
-	if !h.initialized {
-		return nil, fmt.Errorf("protocol not initialized")
+	return h.CompleteContext(context.Background(), params)
+}
+
+// CompleteContext requests a completion, reporting the server's progress to
+// the progress function of ctx, if any
+func (h *ProtocolHandler) CompleteContext(ctx context.Context, params CompletionParams) (*CompletionResult, error) {
+	if err := h.requireReady(); err != nil {
+		return nil, err
 	}
 
-	result, err := h.Request("completion/complete", params)
+	result, err := h.RequestContext(ctx, "completion/complete", params)
 	if err != nil {
 		return nil, err
 	}
@@ -206,7 +365,7 @@ func (h *ProtocolHandler) Complete(params CompletionParams) (*CompletionResult,
 
 // Shutdown gracefully shuts down the connection
 func (h *ProtocolHandler) Shutdown() error {
-	if !h.initialized {
+	if h.State() != StateReady {
 		return nil
 	}
 
@@ -220,18 +379,32 @@ func (h *ProtocolHandler) Shutdown() error {
 		return fmt.Errorf("failed to send exit notification: %w", err)
 	}
 
-	h.initialized = false
-	return h.transport.Close()
+	err = h.transport.Close()
+	h.Close()
+	return err
 }
 
 // Request sends a request and waits for a response
 func (h *ProtocolHandler) Request(method string, params interface{}) (json.RawMessage, error) {
+	return h.RequestContext(context.Background(), method, params)
+}
+
+// RequestContext sends a request and waits for a response, the request
+// timeout, ctx cancellation or loss of the connection, whichever comes first
+func (h *ProtocolHandler) RequestContext(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	if h.isClosed() {
+		return nil, ErrConnectionClosed
+	}
+
 	id := h.requestID.Add(1)
 
 	paramsJSON, err := json.Marshal(params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal params: %w", err)
 	}
+	paramsJSON, stopProgress := h.watchProgress(ctx, id, paramsJSON)
+	defer stopProgress()
+	defer h.watchStream(ctx, id)()
 
 	msg := &RPCMessage{
 		JSONRPC: "2.0",
@@ -240,26 +413,48 @@ func (h *ProtocolHandler) Request(method string, params interface{}) (json.RawMe
 		Params:  paramsJSON,
 	}
 
-	// Create response channel
-	responseChan := make(chan *RPCMessage, 1)
+	// Register before sending so a fast response cannot be missed
+	pending := newPendingRequest(method)
 	h.mu.Lock()
-	h.pendingRequests[id] = responseChan
+	h.pendingRequests[id] = pending
+	timeout := h.requestTimeout
 	h.mu.Unlock()
+	defer h.takePending(id)
 
 	// Send request
 	if err := h.transport.Send(msg); err != nil {
-		h.mu.Lock()
-		delete(h.pendingRequests, id)
-		h.mu.Unlock()
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
-	// Wait for response
-	response := <-responseChan
+	return h.await(ctx, pending, timeout)
+}
 
-	h.mu.Lock()
-	delete(h.pendingRequests, id)
-	h.mu.Unlock()
+// await waits for the response to a sent request
+func (h *ProtocolHandler) await(ctx context.Context, pending *pendingRequest, timeout time.Duration) (json.RawMessage, error) {
+	method := pending.method
+
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
+	// Wait for response
+	var response *RPCMessage
+	select {
+	case response = <-pending.response:
+	case <-pending.done:
+		return nil, fmt.Errorf("%s: %w", method, pending.err)
+	case <-h.closed:
+		return nil, fmt.Errorf("%s: %w", method, ErrConnectionClosed)
+	case <-timeoutCh:
+		pending.fail(ErrRequestTimeout)
+		return nil, fmt.Errorf("%s after %s: %w", method, timeout, ErrRequestTimeout)
+	case <-ctx.Done():
+		pending.fail(ctx.Err())
+		return nil, fmt.Errorf("%s: %w", method, ctx.Err())
+	}
 
 	if response.Error != nil {
 		return nil, fmt.Errorf("RPC error %d: %s", response.Error.Code, response.Error.Message)
@@ -294,30 +489,34 @@ func (h *ProtocolHandler) ProcessMessage(msg *RPCMessage) {
 		return
 	}
 
-	if msg.ID != nil {
-		// This is a response to a request
-		h.mu.RLock()
-		responseChan, ok := h.pendingRequests[*msg.ID]
-		h.mu.RUnlock()
-
-		if ok {
-			responseChan <- msg
+	switch {
+	case msg.Method == "" && msg.ID != nil:
+		// This is a response to a request; late or duplicate responses are dropped
+		if pending, ok := h.takePending(*msg.ID); ok {
+			pending.resolve(msg)
+		}
+	case msg.Method != "" && msg.ID != nil:
+		// Server-initiated request; IDs are chosen by the server and are
+		// unrelated to our pending requests
+		h.dispatchRequest(msg)
+	case msg.Method != "":
+		// Notification from the server; bursts of progress and resource
+		// updates are coalesced
+		if !h.coalescer.hold(msg) {
+			h.handleServerMessage(msg)
 		}
-	}
-	// Handle server-initiated requests and notifications
-	if msg.Method != "" {
-		// This is a request or notification from the server
-		h.handleServerMessage(msg)
 	}
 }
 
 // IsInitialized returns whether the protocol has been initialized
 func (h *ProtocolHandler) IsInitialized() bool {
-	return h.initialized
+	return h.State() == StateReady
 }
 
 // GetServerCapabilities returns the server's capabilities
 func (h *ProtocolHandler) GetServerCapabilities() *ServerCapabilities {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 	return h.serverCaps
 }
 
@@ -331,25 +530,96 @@ type ToolCallParams struct {
 
 // ToolCallResult represents the result of a tool call
 type ToolCallResult struct {
-	Content []ToolCallContent `json:"content"`
-	IsError bool              `json:"isError,omitempty"`
+	Content           []ToolCallContent      `json:"content"`
+	StructuredContent map[string]interface{} `json:"structuredContent,omitempty"`
+	IsError           bool                   `json:"isError,omitempty"`
 }
 
-// ToolCallContent represents content returned by a tool
+// Content types returned by tools and prompts
+const (
+	ContentTypeText         = "text"
+	ContentTypeImage        = "image"
+	ContentTypeAudio        = "audio"
+	ContentTypeResource     = "resource"
+	ContentTypeResourceLink = "resource_link"
+)
+
+// ToolCallContent represents content returned by a tool. Data holds base64
+// encoded image or audio bytes; Resource holds an embedded resource; URI and
+// Name describe a resource link.
 type ToolCallContent struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
-	Data string `json:"data,omitempty"`
+	Type        string              `json:"type"`
+	Text        string              `json:"text,omitempty"`
+	Data        string              `json:"data,omitempty"`
+	MimeType    string              `json:"mimeType,omitempty"`
+	Resource    *ResourceContent    `json:"resource,omitempty"`
+	URI         string              `json:"uri,omitempty"`
+	Name        string              `json:"name,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Annotations *ContentAnnotations `json:"annotations,omitempty"`
+}
+
+// ContentAnnotations are optional hints about how content should be used
+type ContentAnnotations struct {
+	Audience     []string `json:"audience,omitempty"`
+	Priority     float64  `json:"priority,omitempty"`
+	LastModified string   `json:"lastModified,omitempty"`
+}
+
+// Text renders the result as plain text. Non-text content is summarized by
+// type so callers that only understand text still see that it was returned.
+func (r *ToolCallResult) Text() string {
+	parts := make([]string, 0, len(r.Content))
+	for _, content := range r.Content {
+		if text := contentText(content); text != "" {
+			parts = append(parts, text)
+		}
+	}
+
+	if len(parts) == 0 && r.StructuredContent != nil {
+		if data, err := json.Marshal(r.StructuredContent); err == nil {
+			return string(data)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// contentText renders one content block as text, summarizing non-text blocks by type
+func contentText(content ToolCallContent) string {
+	switch content.Type {
+	case ContentTypeText:
+		return content.Text
+	case ContentTypeResource:
+		if content.Resource != nil && content.Resource.Text != "" {
+			return content.Resource.Text
+		} else if content.Resource != nil {
+			return fmt.Sprintf("[resource %s]", content.Resource.URI)
+		}
+		return ""
+	case ContentTypeResourceLink:
+		return fmt.Sprintf("[resource_link %s]", content.URI)
+	default:
+		return fmt.Sprintf("[%s %s]", content.Type, content.MimeType)
+	}
 }
 
 // CallTool calls a tool on the server
 func (h *ProtocolHandler) CallTool(name string, arguments map[string]interface{}) (*ToolCallResult, error) {
-	if !h.initialized {
-		return nil, fmt.Errorf("protocol not initialized")
+	return h.CallToolContext(context.Background(), name, arguments)
+}
+
+// CallToolContext calls a tool, reporting the server's progress to the
+// progress function of ctx, if any
+func (h *ProtocolHandler) CallToolContext(ctx context.Context, name string, arguments map[string]interface{}) (*ToolCallResult, error) {
+	if err := h.requireCapability("tools"); err != nil {
+		return nil, err
 	}
 
-	if h.serverCaps == nil || !h.serverCaps.Tools {
-		return nil, fmt.Errorf("server does not support tools")
+	// Catch schema mismatches before the round trip
+	if schema := h.toolSchema(name); schema != nil {
+		if err := ValidateArguments(name, schema, arguments); err != nil {
+			return nil, err
+		}
 	}
 
 	params := ToolCallParams{
@@ -357,7 +627,7 @@ func (h *ProtocolHandler) CallTool(name string, arguments map[string]interface{}
 		Arguments: arguments,
 	}
 
-	result, err := h.Request("tools/call", params)
+	result, err := h.RequestContext(ctx, "tools/call", params)
 	if err != nil {
 		return nil, fmt.Errorf("tool call failed: %w", err)
 	}
@@ -372,12 +642,8 @@ func (h *ProtocolHandler) CallTool(name string, arguments map[string]interface{}
 
 // ListTools lists available tools on the server
 func (h *ProtocolHandler) ListTools() ([]ToolDefinition, error) {
-	if !h.initialized {
-		return nil, fmt.Errorf("protocol not initialized")
-	}
-
-	if h.serverCaps == nil || !h.serverCaps.Tools {
-		return nil, fmt.Errorf("server does not support tools")
+	if err := h.requireCapability("tools"); err != nil {
+		return nil, err
 	}
 
 	result, err := h.Request("tools/list", nil)
@@ -392,9 +658,31 @@ func (h *ProtocolHandler) ListTools() ([]ToolDefinition, error) {
 		return nil, fmt.Errorf("failed to parse tools list: %w", err)
 	}
 
+	h.rememberToolSchemas(response.Tools)
 	return response.Tools, nil
 }
 
+// rememberToolSchemas records input schemas so CallTool can validate arguments
+func (h *ProtocolHandler) rememberToolSchemas(tools []ToolDefinition) {
+	schemas := make(map[string]map[string]interface{}, len(tools))
+	for _, tool := range tools {
+		if len(tool.InputSchema) > 0 {
+			schemas[tool.Name] = tool.InputSchema
+		}
+	}
+
+	h.mu.Lock()
+	h.toolSchemas = schemas
+	h.mu.Unlock()
+}
+
+// toolSchema returns the input schema of a listed tool, or nil if unknown
+func (h *ProtocolHandler) toolSchema(name string) map[string]interface{} {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.toolSchemas[name]
+}
+
 // Resource management support
 
 // ResourceParams represents parameters for resource operations
@@ -412,12 +700,8 @@ type ResourceContent struct {
 
 // ListResources lists available resources on the server
 func (h *ProtocolHandler) ListResources() ([]ResourceDefinition, error) {
-	if !h.initialized {
-		return nil, fmt.Errorf("protocol not initialized")
-	}
-
-	if h.serverCaps == nil || !h.serverCaps.Resources {
-		return nil, fmt.Errorf("server does not support resources")
+	if err := h.requireCapability("resources"); err != nil {
+		return nil, err
 	}
 
 	result, err := h.Request("resources/list", nil)
@@ -437,12 +721,8 @@ func (h *ProtocolHandler) ListResources() ([]ResourceDefinition, error) {
 
 // ReadResource reads the content of a resource
 func (h *ProtocolHandler) ReadResource(uri string) (*ResourceContent, error) {
-	if !h.initialized {
-		return nil, fmt.Errorf("protocol not initialized")
-	}
-
-	if h.serverCaps == nil || !h.serverCaps.Resources {
-		return nil, fmt.Errorf("server does not support resources")
+	if err := h.requireCapability("resources"); err != nil {
+		return nil, err
 	}
 
 	params := ResourceParams{URI: uri}
@@ -467,12 +747,13 @@ func (h *ProtocolHandler) ReadResource(uri string) (*ResourceContent, error) {
 
 // SubscribeToResource subscribes to changes in a resource
 func (h *ProtocolHandler) SubscribeToResource(uri string) error {
-	if !h.initialized {
-		return fmt.Errorf("protocol not initialized")
+	if err := h.requireCapability("resources"); err != nil {
+		return err
 	}
 
-	if h.serverCaps == nil || !h.serverCaps.Resources {
-		return fmt.Errorf("server does not support resources")
+	// Dated revisions advertise subscription support separately
+	if versionAtLeast(h.ProtocolVersion(), ProtocolVersion20241105) && !h.Supports(FeatureResourceSubscribe) {
+		return fmt.Errorf("server does not support resource subscriptions")
 	}
 
 	params := ResourceParams{URI: uri}
@@ -486,12 +767,8 @@ func (h *ProtocolHandler) SubscribeToResource(uri string) error {
 
 // UnsubscribeFromResource unsubscribes from changes in a resource
 func (h *ProtocolHandler) UnsubscribeFromResource(uri string) error {
-	if !h.initialized {
-		return fmt.Errorf("protocol not initialized")
-	}
-
-	if h.serverCaps == nil || !h.serverCaps.Resources {
-		return fmt.Errorf("server does not support resources")
+	if err := h.requireCapability("resources"); err != nil {
+		return err
 	}
 
 	params := ResourceParams{URI: uri}
@@ -527,14 +804,55 @@ type PromptParams struct {
 
 // PromptResult represents the result of getting a prompt
 type PromptResult struct {
-	Description string    `json:"description,omitempty"`
-	Messages    []Message `json:"messages"`
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// PromptMessage is one message of a rendered prompt. The specification sends
+// a content block; a bare string, as older servers send, is read as text.
+type PromptMessage struct {
+	Role    string          `json:"role"`
+	Content ToolCallContent `json:"content"`
+}
+
+// UnmarshalJSON accepts both content blocks and plain string content
+func (m *PromptMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	m.Role = raw.Role
+	m.Content = ToolCallContent{}
+	if len(raw.Content) == 0 {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(raw.Content, &text); err == nil {
+		m.Content = ToolCallContent{Type: ContentTypeText, Text: text}
+		return nil
+	}
+	return json.Unmarshal(raw.Content, &m.Content)
+}
+
+// Text renders the prompt's messages as plain text, separated by blank lines
+func (r *PromptResult) Text() string {
+	parts := make([]string, 0, len(r.Messages))
+	for _, msg := range r.Messages {
+		if text := contentText(msg.Content); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
 }
 
 // ListPrompts lists available prompt templates
 func (h *ProtocolHandler) ListPrompts() ([]PromptTemplate, error) {
-	if !h.initialized {
-		return nil, fmt.Errorf("protocol not initialized")
+	if err := h.requireCapability("prompts"); err != nil {
+		return nil, err
 	}
 
 	result, err := h.Request("prompts/list", nil)
@@ -554,8 +872,8 @@ func (h *ProtocolHandler) ListPrompts() ([]PromptTemplate, error) {
 
 // GetPrompt gets a prompt template with arguments
 func (h *ProtocolHandler) GetPrompt(name string, arguments map[string]interface{}) (*PromptResult, error) {
-	if !h.initialized {
-		return nil, fmt.Errorf("protocol not initialized")
+	if err := h.requireReady(); err != nil {
+		return nil, err
 	}
 
 	params := PromptParams{
@@ -608,24 +926,43 @@ func (h *ProtocolHandler) SendLog(level LogLevel, data string, logger string) er
 
 // Server message handling
 
-// handleServerMessage handles incoming requests and notifications from the server
+// handleServerMessage handles incoming notifications from the server
 func (h *ProtocolHandler) handleServerMessage(msg *RPCMessage) {
 	// Handle server-initiated notifications
 	switch msg.Method {
 	case "notifications/initialized":
 		// Server acknowledges initialization
-	case "notifications/canceled":
+	case "notifications/cancelled", "notifications/canceled":
 		// Handle request cancellation
 		h.handleCancellation(msg)
 	case "notifications/progress":
 		// Handle progress updates
 		h.handleProgress(msg)
+	case streamChunkMethod:
+		// Pieces of a streamed completion
+		h.handleStreamChunk(msg)
 	case "notifications/resources/updated":
 		// Handle resource update notifications
 		h.handleResourceUpdate(msg)
 	case "notifications/resources/list_changed":
 		// Handle resource list changes
 		h.handleResourceListChange(msg)
+	case "notifications/tools/list_changed":
+		// Only honoured when the server advertised tools.listChanged
+		if h.Supports(FeatureToolListChanged) {
+			// Stale schemas would reject valid calls; revalidate after the next listing
+			h.mu.Lock()
+			h.toolSchemas = nil
+			h.mu.Unlock()
+			if h.onToolsChanged != nil {
+				h.onToolsChanged()
+			}
+		}
+	case "notifications/prompts/list_changed":
+		// Only honoured when the server advertised prompts.listChanged
+		if h.Supports(FeaturePromptListChanged) && h.onPromptsChanged != nil {
+			h.onPromptsChanged()
+		}
 	default:
 		// Unknown notification - log it
 		h.SendLog(LogLevelWarning, fmt.Sprintf("Unknown server notification: %s", msg.Method), "mcp-client")
@@ -643,29 +980,15 @@ func (h *ProtocolHandler) handleCancellation(msg *RPCMessage) {
 		return
 	}
 
-	// Cancel the pending request
-	h.mu.Lock()
-	if responseChan, ok := h.pendingRequests[params.RequestID]; ok {
-		delete(h.pendingRequests, params.RequestID)
-		close(responseChan)
-	}
-	h.mu.Unlock()
-}
-
-// handleProgress handles progress update notifications
-func (h *ProtocolHandler) handleProgress(msg *RPCMessage) {
-	var params struct {
-		ProgressToken string  `json:"progressToken"`
-		Progress      float64 `json:"progress"`
-		Total         float64 `json:"total,omitempty"`
-	}
-
-	if err := json.Unmarshal(msg.Params, &params); err != nil {
-		return
+	// Fail the pending request rather than closing its channel, which would
+	// hand the waiter a nil response
+	if pending, ok := h.takePending(params.RequestID); ok {
+		err := ErrRequestCanceled
+		if params.Reason != "" {
+			err = fmt.Errorf("%w: %s", ErrRequestCanceled, params.Reason)
+		}
+		pending.fail(err)
 	}
-
-	// Log progress update
-	h.SendLog(LogLevelInfo, fmt.Sprintf("Progress: %.2f%%", (params.Progress/params.Total)*100), "mcp-client")
 }
 
 // handleResourceUpdate handles resource update notifications
@@ -686,6 +1009,10 @@ func (h *ProtocolHandler) handleResourceUpdate(msg *RPCMessage) {
 func (h *ProtocolHandler) handleResourceListChange(msg *RPCMessage) {
 	// Log resource list change
 	h.SendLog(LogLevelInfo, "Resource list changed", "mcp-client")
+
+	if h.onResourcesChanged != nil && (h.ProtocolVersion() == LegacyProtocolVersion || h.Supports(FeatureResourceListChanged)) {
+		h.onResourcesChanged()
+	}
 }
 
 // Error handling and recovery
@@ -746,8 +1073,8 @@ type PingResult struct {
 
 // Ping sends a ping to check server health
 func (h *ProtocolHandler) Ping(data string) (*PingResult, error) {
-	if !h.initialized {
-		return nil, fmt.Errorf("protocol not initialized")
+	if err := h.requireReady(); err != nil {
+		return nil, err
 	}
 
 	params := PingParams{
@@ -186,7 +186,7 @@ func TestProtocolHandler_Complete(t *testing.T) {
 	handler := NewProtocolHandler(transport)
 
 	// Initialize first
-	handler.initialized = true
+	handler.state = StateReady
 	handler.serverCaps = &ServerCapabilities{Tools: true}
 
 	// Set up completion response
@@ -227,7 +227,7 @@ func TestProtocolHandler_CallTool(t *testing.T) {
 	handler := NewProtocolHandler(transport)
 
 	// Initialize first
-	handler.initialized = true
+	handler.state = StateReady
 	handler.serverCaps = &ServerCapabilities{Tools: true}
 
 	// Set up tool call response
@@ -262,7 +262,7 @@ func TestProtocolHandler_ListTools(t *testing.T) {
 	handler := NewProtocolHandler(transport)
 
 	// Initialize first
-	handler.initialized = true
+	handler.state = StateReady
 	handler.serverCaps = &ServerCapabilities{Tools: true}
 
 	// Set up tools list response
@@ -296,7 +296,7 @@ func TestProtocolHandler_ListResources(t *testing.T) {
 	handler := NewProtocolHandler(transport)
 
 	// Initialize first
-	handler.initialized = true
+	handler.state = StateReady
 	handler.serverCaps = &ServerCapabilities{Resources: true}
 
 	// Set up resources list response
@@ -331,7 +331,7 @@ func TestProtocolHandler_ReadResource(t *testing.T) {
 	handler := NewProtocolHandler(transport)
 
 	// Initialize first
-	handler.initialized = true
+	handler.state = StateReady
 	handler.serverCaps = &ServerCapabilities{Resources: true}
 
 	// Set up resource read response
@@ -366,7 +366,7 @@ func TestProtocolHandler_ListPrompts(t *testing.T) {
 	handler := NewProtocolHandler(transport)
 
 	// Initialize first
-	handler.initialized = true
+	handler.state = StateReady
 
 	// Set up prompts list response
 	promptsResponse := struct {
@@ -402,13 +402,13 @@ func TestProtocolHandler_GetPrompt(t *testing.T) {
 	handler := NewProtocolHandler(transport)
 
 	// Initialize first
-	handler.initialized = true
+	handler.state = StateReady
 
 	// Set up prompt get response
 	promptResult := PromptResult{
 		Description: "Generated prompt",
-		Messages: []Message{
-			{Role: "user", Content: "Generated prompt content"},
+		Messages: []PromptMessage{
+			{Role: "user", Content: ToolCallContent{Type: ContentTypeText, Text: "Generated prompt content"}},
 		},
 	}
 	resultBytes, _ := json.Marshal(promptResult)
@@ -435,7 +435,7 @@ func TestProtocolHandler_Ping(t *testing.T) {
 	handler := NewProtocolHandler(transport)
 
 	// Initialize first
-	handler.initialized = true
+	handler.state = StateReady
 
 	// Set up ping response
 	pingResult := PingResult{
@@ -466,7 +466,7 @@ func TestProtocolHandler_ErrorHandling(t *testing.T) {
 	assert.Contains(t, err.Error(), "not initialized")
 
 	// Test server capabilities check
-	handler.initialized = true
+	handler.state = StateReady
 	handler.serverCaps = &ServerCapabilities{Tools: false}
 
 	_, err = handler.CallTool("test", nil)
@@ -519,7 +519,7 @@ func TestProtocolHandler_Shutdown(t *testing.T) {
 	handler := NewProtocolHandler(transport)
 
 	// Initialize first
-	handler.initialized = true
+	handler.state = StateReady
 
 	// Set up shutdown response
 	transport.SetResponse(1, &RPCMessage{
@@ -0,0 +1,127 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// RootFromPath returns the root for a directory, named after its base name
+func RootFromPath(path string) (Root, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Root{}, fmt.Errorf("failed to resolve root %s: %w", path, err)
+	}
+	uri := url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+	return Root{URI: uri.String(), Name: filepath.Base(abs)}, nil
+}
+
+// WorkspaceRoots returns the roots for the current workspace: the repository
+// root (or the working directory outside a repository) followed by the
+// directories listed under mcp.roots in the configuration
+func WorkspaceRoots() ([]Root, error) {
+	base, err := git.GetRepositoryRoot()
+	if err != nil {
+		if base, err = os.Getwd(); err != nil {
+			return nil, fmt.Errorf("failed to determine workspace root: %w", err)
+		}
+	}
+
+	paths := []string{base}
+	if cfg := config.Get(); cfg != nil && cfg.MCP != nil {
+		for _, path := range cfg.MCP.Roots {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(base, path)
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	roots := make([]Root, 0, len(paths))
+	for _, path := range paths {
+		root, err := RootFromPath(path)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(roots, root) {
+			roots = append(roots, root)
+		}
+	}
+	return roots, nil
+}
+
+// SetRoots sets the roots exposed to servers. Servers started afterwards
+// advertise the roots capability; running servers that already do are told
+// when the roots change. A nil slice disables roots for new servers.
+func (pm *ProcessManager) SetRoots(roots []Root) {
+	pm.handlersMu.Lock()
+	changed := !slices.Equal(pm.roots, roots) || (pm.roots == nil) != (roots == nil)
+	pm.roots = slices.Clone(roots)
+	pm.handlersMu.Unlock()
+
+	if !changed {
+		return
+	}
+	for _, server := range pm.runningServers() {
+		if err := server.Protocol.NotifyRootsChanged(); err != nil {
+			logger.Warn("failed to notify MCP server of roots change", "server", server.Name, "error", err)
+		}
+	}
+}
+
+// Roots returns the roots exposed to servers
+func (pm *ProcessManager) Roots() []Root {
+	pm.handlersMu.RLock()
+	defer pm.handlersMu.RUnlock()
+	return slices.Clone(pm.roots)
+}
+
+// rootsHandler answers roots/list with the current roots, or returns nil
+// when roots are disabled
+func (pm *ProcessManager) rootsHandler() RootsHandler {
+	pm.handlersMu.RLock()
+	enabled := pm.roots != nil
+	pm.handlersMu.RUnlock()
+	if !enabled {
+		return nil
+	}
+	return func(context.Context) ([]Root, error) {
+		return pm.Roots(), nil
+	}
+}
+
+// runningServers returns every connected server, including pooled connections
+func (pm *ProcessManager) runningServers() []*ManagedServer {
+	var servers []*ManagedServer
+
+	pm.mu.RLock()
+	for _, server := range pm.servers {
+		servers = append(servers, server)
+	}
+	pm.mu.RUnlock()
+
+	pm.poolMu.RLock()
+	for _, pool := range pm.connectionPool {
+		servers = append(servers, pool...)
+	}
+	pm.poolMu.RUnlock()
+
+	return servers
+}
+
+// refreshRoots recomputes the workspace roots and pushes any change to servers
+func (p *Provider) refreshRoots() {
+	roots, err := WorkspaceRoots()
+	if err != nil {
+		logger.Warn("failed to determine MCP roots", "error", err)
+		return
+	}
+	p.processManager.SetRoots(roots)
+}
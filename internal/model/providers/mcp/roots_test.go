@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/sigil/internal/config"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootFromPath(t *testing.T) {
+	dir := t.TempDir()
+	root, err := RootFromPath(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "file://"+filepath.ToSlash(dir), root.URI)
+	assert.Equal(t, filepath.Base(dir), root.Name)
+
+	spaced := filepath.Join(dir, "my repo")
+	root, err = RootFromPath(spaced)
+	require.NoError(t, err)
+	assert.Contains(t, root.URI, "my%20repo")
+	assert.Equal(t, "my repo", root.Name)
+}
+
+func TestWorkspaceRoots(t *testing.T) {
+	original := config.Get()
+	t.Cleanup(func() { config.Set(original) })
+
+	cfg := *original
+	cfg.MCP = &config.MCPConfig{Roots: []string{"docs", "/opt/shared", "."}}
+	config.Set(&cfg)
+
+	roots, err := WorkspaceRoots()
+	require.NoError(t, err)
+	require.Len(t, roots, 3, "the repository root is listed once")
+
+	repoRoot, err := git.GetRepositoryRoot()
+	require.NoError(t, err)
+	expected, err := RootFromPath(repoRoot)
+	require.NoError(t, err)
+	assert.Equal(t, expected, roots[0])
+	assert.Equal(t, expected.URI+"/docs", roots[1].URI)
+	assert.Equal(t, "file:///opt/shared", roots[2].URI)
+}
+
+func TestSetRoots_NotifiesRunningServers(t *testing.T) {
+	pm := &ProcessManager{
+		servers:        make(map[string]*ManagedServer),
+		connectionPool: make(map[string][]*ManagedServer),
+	}
+	assert.Nil(t, pm.rootsHandler(), "roots are disabled until set")
+
+	first := []Root{{URI: "file:///repo", Name: "repo"}}
+	pm.SetRoots(first)
+
+	handler, transport := newNegotiationHandler(t, `{"protocolVersion":"2025-06-18","serverInfo":{"name":"s","version":"1"},"capabilities":{}}`)
+	handler.SetRootsHandler(pm.rootsHandler())
+	_, err := handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{})
+	require.NoError(t, err)
+
+	var params InitializeParams
+	require.NoError(t, json.Unmarshal(transport.GetLastMessage().Params, &params))
+	assert.True(t, params.Capabilities.Roots)
+	assert.True(t, params.Capabilities.RootsListChanged)
+	transport.GetLastMessage()
+
+	pm.servers["srv"] = &ManagedServer{Name: "srv", Transport: transport, Protocol: handler}
+
+	// Unchanged roots send nothing
+	pm.SetRoots(first)
+	assert.Nil(t, transport.GetLastMessage())
+
+	pm.SetRoots(append(first, Root{URI: "file:///docs", Name: "docs"}))
+	notification := transport.GetLastMessage()
+	require.NotNil(t, notification)
+	assert.Equal(t, "notifications/roots/list_changed", notification.Method)
+	assert.Nil(t, notification.ID)
+
+	handler.ProcessMessage(serverRequest(9, "roots/list", ""))
+	response := transport.GetLastMessage()
+	require.NotNil(t, response)
+	assert.JSONEq(t, `{"roots":[{"uri":"file:///repo","name":"repo"},{"uri":"file:///docs","name":"docs"}]}`, string(response.Result))
+}
@@ -0,0 +1,463 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSchemaIssues caps how many problems a validation error reports
+const maxSchemaIssues = 10
+
+// ErrInvalidArguments is returned when tool arguments do not match the tool's input schema
+var ErrInvalidArguments = errors.New("invalid tool arguments")
+
+// SchemaIssue is a single place where a value violates its schema
+type SchemaIssue struct {
+	// Path is a JSON pointer to the offending value; empty for the root
+	Path string `json:"path"`
+
+	// Message describes the violation
+	Message string `json:"message"`
+}
+
+// ArgumentError lists every way a tool call's arguments violate the tool's
+// input schema, worded so a model can fix the call and retry
+type ArgumentError struct {
+	Tool   string
+	Issues []SchemaIssue
+}
+
+// Error implements error
+func (e *ArgumentError) Error() string {
+	parts := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		path := issue.Path
+		if path == "" {
+			path = "arguments"
+		}
+		parts[i] = fmt.Sprintf("%s: %s", path, issue.Message)
+	}
+	return fmt.Sprintf("invalid arguments for tool %s: %s", e.Tool, strings.Join(parts, "; "))
+}
+
+// Unwrap lets callers match ErrInvalidArguments
+func (e *ArgumentError) Unwrap() error {
+	return ErrInvalidArguments
+}
+
+// ValidateArguments checks arguments against a tool's JSON Schema input
+// schema. It covers the keywords MCP servers use in practice: types, enums,
+// required and additional properties, numeric and length bounds, patterns,
+// array items, combinators and local $ref. Unknown keywords are ignored.
+func ValidateArguments(tool string, schema map[string]interface{}, arguments map[string]interface{}) error {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	// Normalize Go values to their JSON form so ints and float64s compare alike
+	var value interface{} = map[string]interface{}{}
+	if arguments != nil {
+		data, err := json.Marshal(arguments)
+		if err != nil {
+			return &ArgumentError{Tool: tool, Issues: []SchemaIssue{{Message: fmt.Sprintf("arguments are not JSON encodable: %v", err)}}}
+		}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return &ArgumentError{Tool: tool, Issues: []SchemaIssue{{Message: fmt.Sprintf("arguments are not JSON encodable: %v", err)}}}
+		}
+	}
+
+	v := &schemaValidator{root: schema}
+	v.validate(schema, value, "")
+	if len(v.issues) == 0 {
+		return nil
+	}
+	return &ArgumentError{Tool: tool, Issues: v.issues}
+}
+
+// schemaValidator accumulates issues while walking a value and its schema
+type schemaValidator struct {
+	root   map[string]interface{}
+	issues []SchemaIssue
+	depth  int
+}
+
+// report records an issue unless the cap has been reached
+func (v *schemaValidator) report(path, format string, args ...interface{}) {
+	if len(v.issues) < maxSchemaIssues {
+		v.issues = append(v.issues, SchemaIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// check validates value against schema without recording issues
+func (v *schemaValidator) check(schema interface{}, value interface{}, path string) bool {
+	sub := &schemaValidator{root: v.root, depth: v.depth}
+	sub.validateAny(schema, value, path)
+	return len(sub.issues) == 0
+}
+
+// validateAny validates against a schema that may be a boolean or an object
+func (v *schemaValidator) validateAny(schema interface{}, value interface{}, path string) {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.report(path, "no value is allowed here")
+		}
+	case map[string]interface{}:
+		v.validate(s, value, path)
+	}
+}
+
+// validate checks value against an object schema
+func (v *schemaValidator) validate(schema map[string]interface{}, value interface{}, path string) {
+	// Guard against cyclic $ref chains
+	v.depth++
+	defer func() { v.depth-- }()
+	if v.depth > 64 {
+		v.report(path, "schema nesting is too deep")
+		return
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := v.resolveRef(ref)
+		if err != nil {
+			v.report(path, "%v", err)
+			return
+		}
+		v.validate(target, value, path)
+	}
+
+	if types, ok := schemaTypes(schema["type"]); ok && !matchesAnyType(value, types) {
+		v.report(path, "expected %s, got %s", strings.Join(types, " or "), jsonTypeName(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		v.report(path, "must be one of %s", formatValues(enum))
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		v.report(path, "must equal %s", formatValue(constant))
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(schema, val, path)
+	case []interface{}:
+		v.validateArray(schema, val, path)
+	case string:
+		v.validateString(schema, val, path)
+	case float64:
+		v.validateNumber(schema, val, path)
+	}
+
+	v.validateCombinators(schema, value, path)
+}
+
+// validateObject applies object keywords
+func (v *schemaValidator) validateObject(schema map[string]interface{}, obj map[string]interface{}, path string) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, present := obj[key]; !present {
+					v.report(path, "missing required property %q", key)
+				}
+			}
+		}
+	}
+
+	if n, ok := schemaNumber(schema["minProperties"]); ok && float64(len(obj)) < n {
+		v.report(path, "must have at least %v properties", n)
+	}
+	if n, ok := schemaNumber(schema["maxProperties"]); ok && float64(len(obj)) > n {
+		v.report(path, "must have at most %v properties", n)
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "/" + escapePointer(key)
+		if propSchema, ok := properties[key]; ok {
+			v.validateAny(propSchema, obj[key], childPath)
+			continue
+		}
+		if !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok {
+			if !allowed {
+				v.report(path, "unknown property %q (allowed: %s)", key, strings.Join(sortedKeys(properties), ", "))
+			}
+			continue
+		}
+		v.validateAny(additional, obj[key], childPath)
+	}
+}
+
+// validateArray applies array keywords
+func (v *schemaValidator) validateArray(schema map[string]interface{}, arr []interface{}, path string) {
+	if n, ok := schemaNumber(schema["minItems"]); ok && float64(len(arr)) < n {
+		v.report(path, "must have at least %v items", n)
+	}
+	if n, ok := schemaNumber(schema["maxItems"]); ok && float64(len(arr)) > n {
+		v.report(path, "must have at most %v items", n)
+	}
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+		for i := 1; i < len(arr); i++ {
+			if containsValue(arr[:i], arr[i]) {
+				v.report(fmt.Sprintf("%s/%d", path, i), "duplicate item")
+				break
+			}
+		}
+	}
+
+	switch items := schema["items"].(type) {
+	case map[string]interface{}, bool:
+		for i, item := range arr {
+			v.validateAny(items, item, fmt.Sprintf("%s/%d", path, i))
+		}
+	case []interface{}:
+		// Draft 4-7 tuple form
+		for i, item := range arr {
+			if i < len(items) {
+				v.validateAny(items[i], item, fmt.Sprintf("%s/%d", path, i))
+			}
+		}
+	}
+}
+
+// validateString applies string keywords
+func (v *schemaValidator) validateString(schema map[string]interface{}, s string, path string) {
+	length := float64(utf8.RuneCountInString(s))
+	if n, ok := schemaNumber(schema["minLength"]); ok && length < n {
+		v.report(path, "must be at least %v characters", n)
+	}
+	if n, ok := schemaNumber(schema["maxLength"]); ok && length > n {
+		v.report(path, "must be at most %v characters", n)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err == nil && !re.MatchString(s) {
+			v.report(path, "must match pattern %s", pattern)
+		}
+	}
+}
+
+// validateNumber applies numeric keywords, accepting both the draft 4
+// boolean and the newer numeric forms of exclusive bounds
+func (v *schemaValidator) validateNumber(schema map[string]interface{}, n float64, path string) {
+	if min, ok := schemaNumber(schema["minimum"]); ok {
+		if exclusive, _ := schema["exclusiveMinimum"].(bool); exclusive && n <= min {
+			v.report(path, "must be greater than %v", min)
+		} else if n < min {
+			v.report(path, "must be at least %v", min)
+		}
+	}
+	if max, ok := schemaNumber(schema["maximum"]); ok {
+		if exclusive, _ := schema["exclusiveMaximum"].(bool); exclusive && n >= max {
+			v.report(path, "must be less than %v", max)
+		} else if n > max {
+			v.report(path, "must be at most %v", max)
+		}
+	}
+	if min, ok := schemaNumber(schema["exclusiveMinimum"]); ok && n <= min {
+		v.report(path, "must be greater than %v", min)
+	}
+	if max, ok := schemaNumber(schema["exclusiveMaximum"]); ok && n >= max {
+		v.report(path, "must be less than %v", max)
+	}
+	if step, ok := schemaNumber(schema["multipleOf"]); ok && step > 0 {
+		if q := n / step; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.report(path, "must be a multiple of %v", step)
+		}
+	}
+}
+
+// validateCombinators applies allOf, anyOf, oneOf and not
+func (v *schemaValidator) validateCombinators(schema map[string]interface{}, value interface{}, path string) {
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			v.validateAny(sub, value, path)
+		}
+	}
+	if any, ok := schema["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range any {
+			if v.check(sub, value, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.report(path, "does not match any of the allowed forms")
+		}
+	}
+	if one, ok := schema["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, sub := range one {
+			if v.check(sub, value, path) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			v.report(path, "must match exactly one of the allowed forms (matched %d)", matches)
+		}
+	}
+	if not, ok := schema["not"]; ok && v.check(not, value, path) {
+		v.report(path, "matches a form that is not allowed")
+	}
+}
+
+// resolveRef resolves a local reference such as #/$defs/point
+func (v *schemaValidator) resolveRef(ref string) (map[string]interface{}, error) {
+	if ref == "#" {
+		return v.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported schema reference %q", ref)
+	}
+
+	var current interface{} = v.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable schema reference %q", ref)
+		}
+		if current, ok = obj[token]; !ok {
+			return nil, fmt.Errorf("unresolvable schema reference %q", ref)
+		}
+	}
+
+	target, ok := current.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema reference %q does not point to a schema", ref)
+	}
+	return target, nil
+}
+
+// schemaTypes reads the type keyword, which may be a string or a list
+func schemaTypes(raw interface{}) ([]string, bool) {
+	switch t := raw.(type) {
+	case string:
+		return []string{t}, true
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types, len(types) > 0
+	}
+	return nil, false
+}
+
+// matchesAnyType reports whether value is an instance of one of the JSON types
+func matchesAnyType(value interface{}, types []string) bool {
+	actual := jsonTypeName(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTypeName returns the JSON Schema type of a decoded JSON value
+func jsonTypeName(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == math.Trunc(val) && !math.IsInf(val, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// schemaNumber reads a numeric keyword
+func schemaNumber(raw interface{}) (float64, bool) {
+	switch n := raw.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// containsValue reports whether list holds a value equal to value
+func containsValue(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(normalizeNumber(item), normalizeNumber(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeNumber converts Go integers in hand-written schemas to float64
+func normalizeNumber(value interface{}) interface{} {
+	if n, ok := schemaNumber(value); ok {
+		return n
+	}
+	return value
+}
+
+// formatValues renders enum members for an error message
+func formatValues(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = formatValue(value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatValue renders a value as JSON
+func formatValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// escapePointer escapes a property name for use in a JSON pointer
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
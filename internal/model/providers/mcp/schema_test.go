@@ -0,0 +1,164 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeSchema(t *testing.T, raw string) map[string]interface{} {
+	t.Helper()
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(raw), &schema))
+	return schema
+}
+
+func TestValidateArguments(t *testing.T) {
+	schema := decodeSchema(t, `{
+		"type": "object",
+		"properties": {
+			"query": {"type": "string", "minLength": 1, "maxLength": 10},
+			"limit": {"type": "integer", "minimum": 1, "maximum": 100},
+			"mode": {"enum": ["fast", "full"]},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+			"point": {"$ref": "#/$defs/point"},
+			"ratio": {"type": "number", "exclusiveMinimum": 0}
+		},
+		"required": ["query"],
+		"additionalProperties": false,
+		"$defs": {
+			"point": {"type": "object", "required": ["x", "y"], "properties": {"x": {"type": "number"}, "y": {"type": "number"}}}
+		}
+	}`)
+
+	tests := []struct {
+		name   string
+		args   map[string]interface{}
+		issues []string
+	}{
+		{
+			name: "valid",
+			args: map[string]interface{}{"query": "go", "limit": 5, "mode": "fast", "tags": []string{"a"}, "point": map[string]int{"x": 1, "y": 2}},
+		},
+		{
+			name:   "missing required",
+			args:   map[string]interface{}{"limit": 5},
+			issues: []string{`missing required property "query"`},
+		},
+		{
+			name:   "wrong type",
+			args:   map[string]interface{}{"query": "go", "limit": "five"},
+			issues: []string{"/limit: expected integer, got string"},
+		},
+		{
+			name:   "integer with fraction",
+			args:   map[string]interface{}{"query": "go", "limit": 2.5},
+			issues: []string{"/limit: expected integer, got number"},
+		},
+		{
+			name:   "bounds",
+			args:   map[string]interface{}{"query": "", "limit": 500, "ratio": 0},
+			issues: []string{"/limit: must be at most 100", "/query: must be at least 1 characters", "/ratio: must be greater than 0"},
+		},
+		{
+			name:   "enum",
+			args:   map[string]interface{}{"query": "go", "mode": "slow"},
+			issues: []string{`/mode: must be one of "fast", "full"`},
+		},
+		{
+			name:   "array items",
+			args:   map[string]interface{}{"query": "go", "tags": []interface{}{"a", 1, "c"}},
+			issues: []string{"/tags: must have at most 2 items", "/tags/1: expected string, got integer"},
+		},
+		{
+			name:   "ref",
+			args:   map[string]interface{}{"query": "go", "point": map[string]interface{}{"x": 1}},
+			issues: []string{`/point: missing required property "y"`},
+		},
+		{
+			name:   "unknown property",
+			args:   map[string]interface{}{"query": "go", "qeury": "x"},
+			issues: []string{`arguments: unknown property "qeury"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArguments("search", schema, tt.args)
+			if len(tt.issues) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrInvalidArguments))
+			assert.Contains(t, err.Error(), "invalid arguments for tool search")
+			for _, issue := range tt.issues {
+				assert.Contains(t, err.Error(), issue)
+			}
+
+			var argErr *ArgumentError
+			require.True(t, errors.As(err, &argErr))
+			assert.Len(t, argErr.Issues, len(tt.issues))
+		})
+	}
+}
+
+func TestValidateArguments_Combinators(t *testing.T) {
+	schema := decodeSchema(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"oneOf": [{"type": "string", "pattern": "^[a-z]+$"}, {"type": "integer"}]},
+			"value": {"anyOf": [{"type": "null"}, {"type": "boolean"}]},
+			"name": {"type": ["string", "null"], "not": {"const": "root"}}
+		}
+	}`)
+
+	assert.NoError(t, ValidateArguments("t", schema, map[string]interface{}{"id": "abc", "value": nil, "name": nil}))
+	assert.NoError(t, ValidateArguments("t", schema, map[string]interface{}{"id": 7, "value": true, "name": "x"}))
+
+	err := ValidateArguments("t", schema, map[string]interface{}{"id": "ABC", "value": "yes", "name": "root"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "/id: must match exactly one of the allowed forms (matched 0)")
+	assert.Contains(t, err.Error(), "/value: does not match any of the allowed forms")
+	assert.Contains(t, err.Error(), "/name: matches a form that is not allowed")
+}
+
+func TestValidateArguments_EmptySchema(t *testing.T) {
+	assert.NoError(t, ValidateArguments("t", nil, map[string]interface{}{"anything": 1}))
+	assert.NoError(t, ValidateArguments("t", map[string]interface{}{"type": "object"}, nil))
+}
+
+func TestProtocolHandler_CallToolValidatesArguments(t *testing.T) {
+	handler, transport := newNegotiationHandler(t, `{"protocolVersion":"2025-06-18","serverInfo":{"name":"s","version":"1"},"capabilities":{"tools":{"listChanged":true}}}`)
+	_, err := handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{Tools: true})
+	require.NoError(t, err)
+	transport.GetLastMessage()
+	transport.GetLastMessage()
+
+	transport.SetResponse(2, &RPCMessage{
+		JSONRPC: "2.0",
+		ID:      int64Ptr(2),
+		Result:  json.RawMessage(`{"tools":[{"name":"search","inputSchema":{"type":"object","properties":{"query":{"type":"string"}},"required":["query"]}}]}`),
+	})
+	_, err = handler.ListTools()
+	require.NoError(t, err)
+	transport.GetLastMessage()
+
+	_, err = handler.CallTool("search", map[string]interface{}{"query": 3})
+	require.ErrorIs(t, err, ErrInvalidArguments)
+	assert.Contains(t, err.Error(), "/query: expected string, got integer")
+	assert.Nil(t, transport.GetLastMessage(), "invalid call must not reach the server")
+
+	_, err = handler.CallTool("search", map[string]interface{}{"query": "go"})
+	require.NoError(t, err)
+	assert.NotNil(t, transport.GetLastMessage())
+
+	// A changed tool list drops the stale schema
+	handler.handleServerMessage(&RPCMessage{JSONRPC: "2.0", Method: "notifications/tools/list_changed"})
+	_, err = handler.CallTool("search", map[string]interface{}{"query": 3})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ProtocolState is the lifecycle state of a protocol handler. A handler moves
+// from uninitialized through initializing to ready; closed is final.
+type ProtocolState int32
+
+const (
+	// StateUninitialized is the state before the handshake, or after a failed one
+	StateUninitialized ProtocolState = iota
+
+	// StateInitializing is the state while the handshake is in flight
+	StateInitializing
+
+	// StateReady is the state once the handshake has completed
+	StateReady
+
+	// StateClosed is the state once the connection is shut down or lost
+	StateClosed
+)
+
+// ErrNotInitialized is returned by operations that require a completed handshake
+var ErrNotInitialized = errors.New("protocol not initialized")
+
+// String implements fmt.Stringer
+func (s ProtocolState) String() string {
+	switch s {
+	case StateUninitialized:
+		return "uninitialized"
+	case StateInitializing:
+		return "initializing"
+	case StateReady:
+		return "ready"
+	case StateClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("ProtocolState(%d)", int32(s))
+	}
+}
+
+// State returns the handler's current lifecycle state
+func (h *ProtocolHandler) State() ProtocolState {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.state
+}
+
+// transition moves the handler from one state to another and reports
+// whether the handler was in the expected state
+func (h *ProtocolHandler) transition(from, to ProtocolState) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.state != from {
+		return false
+	}
+	h.state = to
+	return true
+}
+
+// requireReady returns an error unless the handshake has completed
+func (h *ProtocolHandler) requireReady() error {
+	switch state := h.State(); state {
+	case StateReady:
+		return nil
+	case StateClosed:
+		return ErrConnectionClosed
+	default:
+		return ErrNotInitialized
+	}
+}
+
+// requireCapability returns an error unless the handler is ready and the
+// server advertised the named capability (tools, resources or prompts)
+func (h *ProtocolHandler) requireCapability(name string) error {
+	if err := h.requireReady(); err != nil {
+		return err
+	}
+
+	caps := h.GetServerCapabilities()
+	supported := false
+	if caps != nil {
+		switch name {
+		case "tools":
+			supported = caps.Tools
+		case "resources":
+			supported = caps.Resources
+		case "prompts":
+			supported = caps.Prompts
+		}
+	}
+	if !supported {
+		return fmt.Errorf("server does not support %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package mcp
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolState_String(t *testing.T) {
+	assert.Equal(t, "uninitialized", StateUninitialized.String())
+	assert.Equal(t, "initializing", StateInitializing.String())
+	assert.Equal(t, "ready", StateReady.String())
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "ProtocolState(9)", ProtocolState(9).String())
+}
+
+func TestProtocolHandler_StateLifecycle(t *testing.T) {
+	handler, transport := newNegotiationHandler(t, `{"protocolVersion":"2025-06-18","serverInfo":{"name":"s","version":"1"},"capabilities":{"tools":{}}}`)
+	assert.Equal(t, StateUninitialized, handler.State())
+
+	_, err := handler.ListTools()
+	assert.ErrorIs(t, err, ErrNotInitialized)
+
+	_, err = handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{})
+	require.NoError(t, err)
+	assert.Equal(t, StateReady, handler.State())
+	assert.True(t, handler.IsInitialized())
+	transport.GetLastMessage()
+	transport.GetLastMessage()
+
+	_, err = handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{})
+	assert.ErrorContains(t, err, "cannot initialize protocol in state ready")
+
+	_, err = handler.ListPrompts()
+	assert.ErrorContains(t, err, "server does not support prompts")
+
+	handler.Close()
+	assert.Equal(t, StateClosed, handler.State())
+	assert.False(t, handler.IsInitialized())
+	assert.False(t, handler.Supports(FeatureStructuredContent))
+
+	_, err = handler.ListTools()
+	assert.ErrorIs(t, err, ErrConnectionClosed)
+	_, err = handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{})
+	assert.Error(t, err)
+}
+
+func TestProtocolHandler_FailedInitializeCanRetry(t *testing.T) {
+	handler, _ := newNegotiationHandler(t, `{"protocolVersion":"1999-01-01","serverInfo":{"name":"s","version":"1"},"capabilities":{}}`)
+
+	_, err := handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{})
+	require.Error(t, err)
+	assert.Equal(t, StateUninitialized, handler.State())
+}
+
+func TestProtocolHandler_StateWhileInitializing(t *testing.T) {
+	// Without a message handler the initialize response never arrives
+	transport := NewMockTransport()
+	require.NoError(t, transport.Connect(t.Context()))
+	handler := NewProtocolHandler(transport)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{})
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return handler.State() == StateInitializing }, time.Second, time.Millisecond)
+
+	_, err := handler.CallTool("search", nil)
+	assert.ErrorIs(t, err, ErrNotInitialized)
+	_, err = handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{})
+	assert.ErrorContains(t, err, "cannot initialize protocol in state initializing")
+
+	handler.Close()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrConnectionClosed)
+	case <-time.After(time.Second):
+		t.Fatal("initialize did not return after close")
+	}
+	assert.Equal(t, StateClosed, handler.State())
+}
+
+func TestProtocolHandler_ConcurrentStateAccess(t *testing.T) {
+	handler, _ := newNegotiationHandler(t, `{"protocolVersion":"2025-06-18","serverInfo":{"name":"s","version":"1"},"capabilities":{"tools":{"listChanged":true}}}`)
+	handler.OnToolsChanged(func() {})
+
+	// Notifications are processed on the transport goroutine while the
+	// handshake updates state; the race detector checks the guarding
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				handler.ProcessMessage(&RPCMessage{JSONRPC: "2.0", Method: "notifications/tools/list_changed"})
+				_ = handler.State()
+				_ = handler.GetServerCapabilities()
+				_ = handler.ProtocolVersion()
+			}
+		}
+	}()
+
+	_, err := handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{})
+	close(stop)
+	wg.Wait()
+	require.NoError(t, err)
+	assert.Equal(t, StateReady, handler.State())
+}
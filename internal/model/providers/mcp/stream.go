@@ -0,0 +1,141 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// streamChunkMethod is the notification servers send the pieces of a
+// streamed completion in, naming the request they belong to
+const streamChunkMethod = "notifications/completion/chunk"
+
+// streamBuffer is the number of chunks held for a slow reader before the
+// transport waits for it
+const streamBuffer = 64
+
+// CompletionChunk is one piece of a streamed completion. Content continues
+// the chunks before it. The last chunk has Done set and carries the model
+// and usage of the whole completion, or Err when the completion failed.
+type CompletionChunk struct {
+	CompletionResult
+	Done bool
+	Err  error
+}
+
+// streamChunkParams are the params of a completion chunk notification
+type streamChunkParams struct {
+	RequestID json.RawMessage `json:"requestId"`
+	Content   string          `json:"content"`
+}
+
+type streamKey struct{}
+
+// withStreamListener returns a context whose requests pass the completion
+// chunks the server sends for them to fn
+func withStreamListener(ctx context.Context, fn func(content string)) context.Context {
+	return context.WithValue(ctx, streamKey{}, fn)
+}
+
+// CompleteStream requests a completion and returns the channel its chunks
+// arrive on, closed after the last. Servers that did not advertise
+// streaming answer in one chunk once the completion is done.
+func (h *ProtocolHandler) CompleteStream(ctx context.Context, params CompletionParams) (<-chan CompletionChunk, error) {
+	if err := h.requireReady(); err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan CompletionChunk, streamBuffer)
+	send := func(chunk CompletionChunk) {
+		select {
+		case chunks <- chunk:
+		case <-ctx.Done():
+		}
+	}
+
+	if caps := h.GetServerCapabilities(); caps == nil || !caps.Streaming {
+		go func() {
+			defer close(chunks)
+			result, err := h.CompleteContext(ctx, params)
+			if err != nil {
+				send(CompletionChunk{Done: true, Err: err})
+				return
+			}
+			send(CompletionChunk{CompletionResult: *result, Done: true})
+		}()
+		return chunks, nil
+	}
+
+	params.Stream = true
+	go func() {
+		defer close(chunks)
+		var streamed strings.Builder
+		raw, err := h.RequestContext(withStreamListener(ctx, func(content string) {
+			streamed.WriteString(content)
+			send(CompletionChunk{CompletionResult: CompletionResult{Content: content}})
+		}), "completion/complete", params)
+		if err != nil {
+			send(CompletionChunk{Done: true, Err: err})
+			return
+		}
+
+		var result CompletionResult
+		if err := json.Unmarshal(raw, &result); err != nil {
+			send(CompletionChunk{Done: true, Err: fmt.Errorf("failed to parse completion result: %w", err)})
+			return
+		}
+		// The result may repeat what was streamed; pass on only the rest
+		if rest, ok := strings.CutPrefix(result.Content, streamed.String()); ok {
+			result.Content = rest
+		} else {
+			result.Content = ""
+		}
+		send(CompletionChunk{CompletionResult: result, Done: true})
+	}()
+	return chunks, nil
+}
+
+// watchStream passes the completion chunks sent for request id to the
+// stream listener of ctx, if any. It returns a function that stops
+// watching, to call once the request completes.
+func (h *ProtocolHandler) watchStream(ctx context.Context, id int64) func() {
+	fn, _ := ctx.Value(streamKey{}).(func(content string))
+	if fn == nil {
+		return func() {}
+	}
+
+	h.mu.Lock()
+	if h.streamListeners == nil {
+		h.streamListeners = make(map[string]func(content string))
+	}
+	h.streamListeners[fmt.Sprint(id)] = fn
+	h.mu.Unlock()
+
+	return func() {
+		h.mu.Lock()
+		delete(h.streamListeners, fmt.Sprint(id))
+		h.mu.Unlock()
+	}
+}
+
+// handleStreamChunk passes a completion chunk to the listener for its request
+func (h *ProtocolHandler) handleStreamChunk(msg *RPCMessage) {
+	var params streamChunkParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil || len(params.RequestID) == 0 {
+		return
+	}
+
+	id := progressTokenKey(params.RequestID)
+	h.mu.RLock()
+	fn := h.streamListeners[id]
+	h.mu.RUnlock()
+
+	if fn == nil {
+		logger.Debug("MCP completion chunk for unknown request", "id", id)
+		return
+	}
+	fn(params.Content)
+}
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collect reads a completion stream to its end
+func collect(chunks <-chan CompletionChunk) []CompletionChunk {
+	var all []CompletionChunk
+	for chunk := range chunks {
+		all = append(all, chunk)
+	}
+	return all
+}
+
+func TestProtocolHandler_CompleteStream(t *testing.T) {
+	handler, transport := newDispatchHandler(t)
+	handler.state = StateReady
+	handler.serverCaps = &ServerCapabilities{Streaming: true}
+
+	chunks, err := handler.CompleteStream(context.Background(), CompletionParams{Messages: []Message{{Role: "user", Content: "Hi"}}})
+	require.NoError(t, err)
+
+	request := transport.GetLastMessage()
+	require.NotNil(t, request)
+	assert.Equal(t, "completion/complete", request.Method)
+	var params CompletionParams
+	require.NoError(t, json.Unmarshal(request.Params, &params))
+	assert.True(t, params.Stream)
+
+	id := *request.ID
+	handler.ProcessMessage(notification(streamChunkMethod, fmt.Sprintf(`{"requestId":%d,"content":"Hello"}`, id)))
+	handler.ProcessMessage(notification(streamChunkMethod, `{"requestId":999,"content":"elsewhere"}`))
+	handler.ProcessMessage(notification(streamChunkMethod, fmt.Sprintf(`{"requestId":"%d","content":", wor"}`, id)))
+	handler.ProcessMessage(&RPCMessage{JSONRPC: "2.0", ID: request.ID,
+		Result: json.RawMessage(`{"content":"Hello, world","model":"m","usage":{"totalTokens":9}}`)})
+
+	all := collect(chunks)
+	require.Len(t, all, 3)
+	assert.Equal(t, "Hello", all[0].Content)
+	assert.Equal(t, ", wor", all[1].Content)
+	assert.False(t, all[1].Done)
+
+	last := all[2]
+	assert.True(t, last.Done)
+	assert.NoError(t, last.Err)
+	assert.Equal(t, "ld", last.Content, "the result only adds what was not streamed")
+	assert.Equal(t, 9, last.Usage.TotalTokens)
+}
+
+func TestProtocolHandler_CompleteStream_NotStreaming(t *testing.T) {
+	handler, transport := newDispatchHandler(t)
+	handler.state = StateReady
+	handler.serverCaps = &ServerCapabilities{Tools: true}
+
+	chunks, err := handler.CompleteStream(context.Background(), CompletionParams{})
+	require.NoError(t, err)
+
+	request := transport.GetLastMessage()
+	require.NotNil(t, request)
+	assert.NotContains(t, string(request.Params), "stream")
+	handler.ProcessMessage(&RPCMessage{JSONRPC: "2.0", ID: request.ID, Result: json.RawMessage(`{"content":"All at once"}`)})
+
+	all := collect(chunks)
+	require.Len(t, all, 1)
+	assert.True(t, all[0].Done)
+	assert.Equal(t, "All at once", all[0].Content)
+}
+
+func TestProtocolHandler_CompleteStream_Error(t *testing.T) {
+	handler, transport := newDispatchHandler(t)
+	handler.state = StateReady
+	handler.serverCaps = &ServerCapabilities{Streaming: true}
+
+	chunks, err := handler.CompleteStream(context.Background(), CompletionParams{})
+	require.NoError(t, err)
+
+	request := transport.GetLastMessage()
+	require.NotNil(t, request)
+	handler.ProcessMessage(&RPCMessage{JSONRPC: "2.0", ID: request.ID, Error: &RPCError{Code: InternalError, Message: "model overloaded"}})
+
+	all := collect(chunks)
+	require.Len(t, all, 1)
+	assert.ErrorContains(t, all[0].Err, "model overloaded")
+
+	handler.state = StateClosed
+	_, err = handler.CompleteStream(context.Background(), CompletionParams{})
+	assert.ErrorIs(t, err, ErrConnectionClosed)
+}
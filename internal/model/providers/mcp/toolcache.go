@@ -0,0 +1,255 @@
+package mcp
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	defaultToolCacheTTL        = 5 * time.Minute
+	defaultToolCacheMaxEntries = 256
+	defaultToolCacheMaxBytes   = 8 << 20
+)
+
+// ToolCacheOptions bounds a ToolCache. Zero values select the defaults.
+type ToolCacheOptions struct {
+	// TTL is how long a result stays valid
+	TTL time.Duration
+
+	// MaxEntries caps the number of cached results
+	MaxEntries int
+
+	// MaxBytes caps the total size of cached results
+	MaxBytes int
+
+	// CacheUnannotated also caches tools that declare no read-only or
+	// idempotent hint. The MCP specification treats such tools as
+	// non-idempotent, so this is off by default.
+	CacheUnannotated bool
+}
+
+// ToolCacheStats reports how a ToolCache has been used
+type ToolCacheStats struct {
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+	Shared   int64 `json:"shared"`
+	Bypassed int64 `json:"bypassed"`
+	Entries  int   `json:"entries"`
+	Bytes    int   `json:"bytes"`
+}
+
+// ToolCache memoizes tool results for the duration of a task. Identical
+// calls to read-only or idempotent tools are answered from the cache, and
+// concurrent identical calls share a single request to the server.
+type ToolCache struct {
+	options ToolCacheOptions
+
+	mu          sync.Mutex
+	entries     map[string]*list.Element
+	lru         *list.List
+	bytes       int
+	inflight    map[string]*toolCall
+	annotations map[string]*ToolAnnotations
+	stats       ToolCacheStats
+}
+
+// toolCacheEntry is one cached result
+type toolCacheEntry struct {
+	key     string
+	result  ToolResult
+	size    int
+	expires time.Time
+}
+
+// toolCall is a call in flight that identical callers wait on
+type toolCall struct {
+	done   chan struct{}
+	result *ToolResult
+	err    error
+}
+
+// NewToolCache creates an empty cache, typically one per task
+func NewToolCache(options ToolCacheOptions) *ToolCache {
+	if options.TTL <= 0 {
+		options.TTL = defaultToolCacheTTL
+	}
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = defaultToolCacheMaxEntries
+	}
+	if options.MaxBytes <= 0 {
+		options.MaxBytes = defaultToolCacheMaxBytes
+	}
+	return &ToolCache{
+		options:     options,
+		entries:     make(map[string]*list.Element),
+		lru:         list.New(),
+		inflight:    make(map[string]*toolCall),
+		annotations: make(map[string]*ToolAnnotations),
+	}
+}
+
+// RememberTools records the annotations of a server's tools
+func (c *ToolCache) RememberTools(server string, tools []ToolDefinition) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tool := range tools {
+		c.annotations[server+"/"+tool.Name] = tool.Annotations
+	}
+}
+
+// knowsTool reports whether the tool's definition has been recorded
+func (c *ToolCache) knowsTool(server, tool string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.annotations[server+"/"+tool]
+	return ok
+}
+
+// Cacheable reports whether results of the tool may be reused. Tools marked
+// read-only or idempotent are cacheable; tools marked non-idempotent or
+// destructive always reach the server.
+func (c *ToolCache) Cacheable(server, tool string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	annotations := c.annotations[server+"/"+tool]
+	if annotations == nil {
+		return c.options.CacheUnannotated
+	}
+	if annotations.ReadOnlyHint != nil && *annotations.ReadOnlyHint {
+		return true
+	}
+	if annotations.IdempotentHint != nil {
+		return *annotations.IdempotentHint
+	}
+	if annotations.DestructiveHint != nil && *annotations.DestructiveHint {
+		return false
+	}
+	return c.options.CacheUnannotated
+}
+
+// Stats returns usage counters
+func (c *ToolCache) Stats() ToolCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// Clear drops every cached result
+func (c *ToolCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+}
+
+// toolCacheKey identifies a call by server, tool and canonical arguments
+func toolCacheKey(server, tool string, arguments json.RawMessage) string {
+	// Re-encoding sorts object keys so equivalent arguments share a key
+	canonical := arguments
+	var decoded interface{}
+	if err := json.Unmarshal(arguments, &decoded); err == nil {
+		if encoded, err := json.Marshal(decoded); err == nil {
+			canonical = encoded
+		}
+	}
+
+	sum := sha256.Sum256(append([]byte(server+"\x00"+tool+"\x00"), canonical...))
+	return hex.EncodeToString(sum[:])
+}
+
+// bypass records a call that skipped the cache
+func (c *ToolCache) bypass() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Bypassed++
+}
+
+// do returns the cached result for key or runs call, sharing the outcome
+// with identical calls made while it is in flight
+func (c *ToolCache) do(key string, call func() (*ToolResult, error)) (*ToolResult, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*toolCacheEntry)
+		if time.Now().Before(entry.expires) {
+			c.lru.MoveToFront(elem)
+			c.stats.Hits++
+			result := entry.result
+			c.mu.Unlock()
+			return &result, nil
+		}
+		c.removeElement(elem)
+	}
+	if pending, ok := c.inflight[key]; ok {
+		c.stats.Shared++
+		c.mu.Unlock()
+		<-pending.done
+		if pending.err != nil || pending.result == nil {
+			return pending.result, pending.err
+		}
+		result := *pending.result
+		return &result, nil
+	}
+
+	pending := &toolCall{done: make(chan struct{})}
+	c.inflight[key] = pending
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	pending.result, pending.err = call()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if pending.err == nil && pending.result != nil && !pending.result.IsError {
+		c.store(key, *pending.result)
+	}
+	c.mu.Unlock()
+	close(pending.done)
+
+	if pending.result == nil {
+		return nil, pending.err
+	}
+	result := *pending.result
+	return &result, pending.err
+}
+
+// store adds a result and evicts the least recently used entries over the bounds
+func (c *ToolCache) store(key string, result ToolResult) {
+	size := len(key) + len(result.ToolCallID) + len(result.Content) + len(result.StructuredContent)
+	if size > c.options.MaxBytes {
+		return
+	}
+
+	entry := &toolCacheEntry{
+		key:     key,
+		result:  result,
+		size:    size,
+		expires: time.Now().Add(c.options.TTL),
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.bytes += size
+
+	for len(c.entries) > c.options.MaxEntries || c.bytes > c.options.MaxBytes {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// removeElement drops a cached entry
+func (c *ToolCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*toolCacheEntry)
+	c.lru.Remove(elem)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
@@ -0,0 +1,187 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dshills/sigil/internal/trust"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestToolCache_Cacheable(t *testing.T) {
+	cache := NewToolCache(ToolCacheOptions{})
+	cache.RememberTools("srv", []ToolDefinition{
+		{Name: "read", Annotations: &ToolAnnotations{ReadOnlyHint: boolPtr(true)}},
+		{Name: "upsert", Annotations: &ToolAnnotations{IdempotentHint: boolPtr(true), DestructiveHint: boolPtr(true)}},
+		{Name: "append", Annotations: &ToolAnnotations{IdempotentHint: boolPtr(false)}},
+		{Name: "delete", Annotations: &ToolAnnotations{DestructiveHint: boolPtr(true)}},
+		{Name: "plain"},
+	})
+
+	assert.True(t, cache.Cacheable("srv", "read"))
+	assert.True(t, cache.Cacheable("srv", "upsert"))
+	assert.False(t, cache.Cacheable("srv", "append"))
+	assert.False(t, cache.Cacheable("srv", "delete"))
+	assert.False(t, cache.Cacheable("srv", "plain"))
+	assert.False(t, cache.Cacheable("other", "read"))
+
+	permissive := NewToolCache(ToolCacheOptions{CacheUnannotated: true})
+	permissive.RememberTools("srv", []ToolDefinition{
+		{Name: "plain"},
+		{Name: "append", Annotations: &ToolAnnotations{IdempotentHint: boolPtr(false)}},
+	})
+	assert.True(t, permissive.Cacheable("srv", "plain"))
+	assert.False(t, permissive.Cacheable("srv", "append"))
+}
+
+func TestToolCacheKey(t *testing.T) {
+	a := toolCacheKey("srv", "search", json.RawMessage(`{"query":"x","limit":5}`))
+	b := toolCacheKey("srv", "search", json.RawMessage(`{ "limit": 5, "query": "x" }`))
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, toolCacheKey("srv", "search", json.RawMessage(`{"query":"y","limit":5}`)))
+	assert.NotEqual(t, a, toolCacheKey("other", "search", json.RawMessage(`{"query":"x","limit":5}`)))
+}
+
+func TestToolCache_Do(t *testing.T) {
+	var calls int32
+	call := func() (*ToolResult, error) {
+		atomic.AddInt32(&calls, 1)
+		return &ToolResult{Content: json.RawMessage(`[{"type":"text","text":"ok"}]`)}, nil
+	}
+
+	t.Run("hit and expiry", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		cache := NewToolCache(ToolCacheOptions{TTL: 50 * time.Millisecond})
+
+		first, err := cache.do("k", call)
+		require.NoError(t, err)
+		second, err := cache.do("k", call)
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+		time.Sleep(60 * time.Millisecond)
+		_, err = cache.do("k", call)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+
+		stats := cache.Stats()
+		assert.EqualValues(t, 1, stats.Hits)
+		assert.EqualValues(t, 2, stats.Misses)
+		assert.Equal(t, 1, stats.Entries)
+	})
+
+	t.Run("size bounds", func(t *testing.T) {
+		cache := NewToolCache(ToolCacheOptions{MaxEntries: 2})
+		for _, key := range []string{"a", "b", "c"} {
+			_, err := cache.do(key, call)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, cache.Stats().Entries)
+
+		small := NewToolCache(ToolCacheOptions{MaxBytes: 10})
+		_, err := small.do("big", call)
+		require.NoError(t, err)
+		assert.Equal(t, 0, small.Stats().Entries)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		cache := NewToolCache(ToolCacheOptions{})
+		failing := func() (*ToolResult, error) {
+			atomic.AddInt32(&calls, 1)
+			return &ToolResult{IsError: true}, nil
+		}
+		_, _ = cache.do("k", failing)
+		_, _ = cache.do("k", failing)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("concurrent calls are deduplicated", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		cache := NewToolCache(ToolCacheOptions{})
+		release := make(chan struct{})
+		slow := func() (*ToolResult, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return &ToolResult{Content: json.RawMessage(`[]`)}, nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := cache.do("k", slow)
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}()
+		}
+		require.Eventually(t, func() bool { return cache.Stats().Shared == 4 }, time.Second, 5*time.Millisecond)
+		close(release)
+		wg.Wait()
+		assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	})
+}
+
+func TestModel_CallToolUsesCache(t *testing.T) {
+	handler, transport := newNegotiationHandler(t, `{"protocolVersion":"2025-06-18","serverInfo":{"name":"s","version":"1"},"capabilities":{"tools":{}}}`)
+	_, err := handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{Tools: true})
+	require.NoError(t, err)
+	transport.GetLastMessage()
+	transport.GetLastMessage()
+
+	mcpModel := &Model{
+		modelName: "default",
+		server:    &ManagedServer{Name: "srv", Transport: transport, Protocol: handler},
+	}
+	cache := NewToolCache(ToolCacheOptions{})
+	cache.RememberTools("srv", []ToolDefinition{
+		{Name: "lookup", Annotations: &ToolAnnotations{ReadOnlyHint: boolPtr(true)}},
+		{Name: "write", Annotations: &ToolAnnotations{IdempotentHint: boolPtr(false)}},
+	})
+	mcpModel.SetToolCache(cache)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := mcpModel.CallTool(ctx, ToolCall{Name: "lookup", Arguments: json.RawMessage(`{"id":1}`)})
+		require.NoError(t, err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := mcpModel.CallTool(ctx, ToolCall{Name: "write", Arguments: json.RawMessage(`{"id":1}`)})
+		require.NoError(t, err)
+	}
+
+	sent := 0
+	for transport.GetLastMessage() != nil {
+		sent++
+	}
+	assert.Equal(t, 3, sent)
+
+	stats := cache.Stats()
+	assert.EqualValues(t, 2, stats.Hits)
+	assert.EqualValues(t, 2, stats.Bypassed)
+}
+
+func TestModel_CallToolRefusedWhenUntrusted(t *testing.T) {
+	trust.Set(true, "--untrusted")
+	t.Cleanup(func() { trust.Set(false, "") })
+
+	transport := NewMockTransport()
+	mcpModel := &Model{
+		modelName: "default",
+		server:    &ManagedServer{Name: "srv", Transport: transport, Protocol: NewProtocolHandler(transport)},
+	}
+	_, err := mcpModel.CallTool(context.Background(), ToolCall{Name: "lookup", Arguments: json.RawMessage(`{}`)})
+	assert.ErrorIs(t, err, trust.ErrUntrusted)
+	assert.Nil(t, transport.GetLastMessage(), "nothing is sent to the server")
+}
@@ -2,9 +2,27 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+const (
+	// DefaultBufferSize is the size of the stdio read buffer and of the
+	// chunks large messages are written in
+	DefaultBufferSize = 64 << 10
+
+	// DefaultWriteTimeout is how long a write may wait for the server to
+	// read before the server is considered stalled
+	DefaultWriteTimeout = 30 * time.Second
+
+	// UnlimitedMessageSize as MaxMessageSize lifts the limit on message size
+	UnlimitedMessageSize = -1
+)
+
+// ErrWriteTimeout is returned when the server stops reading its input for
+// longer than the write timeout
+var ErrWriteTimeout = errors.New("mcp write timed out")
+
 // Transport defines the interface for MCP communication transports
 type Transport interface {
 	// Connect establishes the connection
@@ -23,20 +41,40 @@ type Transport interface {
 	IsConnected() bool
 }
 
+// BatchSender is implemented by transports that can send several messages
+// in one JSON-RPC batch
+type BatchSender interface {
+	// SendBatch sends messages as a single batch
+	SendBatch(msgs []*RPCMessage) error
+}
+
 // TransportConfig contains common transport configuration
 type TransportConfig struct {
 	Timeout    time.Duration
 	MaxRetries int
 	RetryDelay time.Duration
+
+	// BufferSize is the read buffer size and the write chunk size in bytes;
+	// zero uses DefaultBufferSize
 	BufferSize int
+
+	// MaxMessageSize bounds a single message in bytes; zero uses
+	// DefaultMaxMessageSize and UnlimitedMessageSize lifts the limit
+	MaxMessageSize int
+
+	// WriteTimeout bounds how long each chunk of a message may wait for the
+	// server to read it; zero uses DefaultWriteTimeout
+	WriteTimeout time.Duration
 }
 
 // DefaultTransportConfig returns default transport configuration
 func DefaultTransportConfig() TransportConfig {
 	return TransportConfig{
-		Timeout:    30 * time.Second,
-		MaxRetries: 3,
-		RetryDelay: time.Second,
-		BufferSize: 4096,
+		Timeout:        30 * time.Second,
+		MaxRetries:     3,
+		RetryDelay:     time.Second,
+		BufferSize:     DefaultBufferSize,
+		MaxMessageSize: DefaultMaxMessageSize,
+		WriteTimeout:   DefaultWriteTimeout,
 	}
 }
@@ -4,8 +4,10 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"sync"
 	"time"
@@ -21,12 +23,17 @@ type StdioTransport struct {
 	config  TransportConfig
 
 	cmd    *exec.Cmd
-	stdin  io.WriteCloser
+	stdin  *os.File // A pipe of our own, so writes can time out
 	stdout io.ReadCloser
 	stderr io.ReadCloser
 
-	reader *bufio.Reader
-	writer *bufio.Writer
+	reader  *bufio.Reader
+	writeMu sync.Mutex // keeps concurrent messages from interleaving
+
+	// queued holds the rest of a batch for Receive, which returns one
+	// message at a time
+	queued []queuedMessage
+	readMu sync.Mutex
 
 	mu        sync.RWMutex
 	connected bool
@@ -34,13 +41,16 @@ type StdioTransport struct {
 	cancel    context.CancelFunc
 	parentCtx context.Context // Store parent context for reconnection
 
-	messageHandler  func(*RPCMessage)
-	reconnectCount  int
-	maxReconnects   int
-	lastError       error
-	errorCallback   func(error)
-	reconnectDelay  time.Duration
-	reconnectActive bool // Prevent concurrent reconnection attempts
+	messageHandler    func(*RPCMessage)
+	batchHandler      func([]*RPCMessage)
+	disconnectHandler func(error)
+	frameErrorHandler func(*FrameError)
+	reconnectCount    int
+	maxReconnects     int
+	lastError         error
+	errorCallback     func(error)
+	reconnectDelay    time.Duration
+	reconnectActive   bool // Prevent concurrent reconnection attempts
 }
 
 // NewStdioTransport creates a new stdio transport
@@ -60,6 +70,57 @@ func (t *StdioTransport) SetMessageHandler(handler func(*RPCMessage)) {
 	t.messageHandler = handler
 }
 
+// SetBatchHandler sets the handler for incoming batches. Without one, the
+// messages of a batch go to the message handler one by one.
+func (t *StdioTransport) SetBatchHandler(handler func([]*RPCMessage)) {
+	t.batchHandler = handler
+}
+
+// SetDisconnectHandler sets the callback invoked whenever the connection drops,
+// so callers can fail requests that will never receive a response
+func (t *StdioTransport) SetDisconnectHandler(handler func(error)) {
+	t.disconnectHandler = handler
+}
+
+// notifyDisconnect reports a dropped connection to the disconnect handler
+func (t *StdioTransport) notifyDisconnect(err error) {
+	if t.disconnectHandler != nil {
+		t.disconnectHandler(err)
+	}
+}
+
+// SetFrameErrorHandler sets the callback invoked for each dropped frame
+func (t *StdioTransport) SetFrameErrorHandler(handler func(*FrameError)) {
+	t.frameErrorHandler = handler
+}
+
+// maxMessageSize returns the effective message size limit, zero for none
+func (t *StdioTransport) maxMessageSize() int {
+	switch {
+	case t.config.MaxMessageSize > 0:
+		return t.config.MaxMessageSize
+	case t.config.MaxMessageSize < 0:
+		return 0
+	}
+	return DefaultMaxMessageSize
+}
+
+// bufferSize returns the effective read buffer and write chunk size
+func (t *StdioTransport) bufferSize() int {
+	if t.config.BufferSize > 0 {
+		return t.config.BufferSize
+	}
+	return DefaultBufferSize
+}
+
+// writeTimeout returns how long each chunk of a write may block
+func (t *StdioTransport) writeTimeout() time.Duration {
+	if t.config.WriteTimeout > 0 {
+		return t.config.WriteTimeout
+	}
+	return DefaultWriteTimeout
+}
+
 // SetErrorCallback sets the callback for connection errors
 func (t *StdioTransport) SetErrorCallback(callback func(error)) {
 	t.errorCallback = callback
@@ -106,31 +167,41 @@ func (t *StdioTransport) Connect(ctx context.Context) error {
 		t.cmd.Env = append(t.cmd.Env, t.env...)
 	}
 
-	// Get pipes
-	var err error
-	t.stdin, err = t.cmd.StdinPipe()
+	// Get pipes. Stdin is created here rather than by exec so that writes
+	// to a server that stops reading can time out.
+	stdinReader, stdinWriter, err := os.Pipe()
 	if err != nil {
 		return fmt.Errorf("failed to get stdin pipe: %w", err)
 	}
+	t.cmd.Stdin = stdinReader
+	t.stdin = stdinWriter
 
 	t.stdout, err = t.cmd.StdoutPipe()
 	if err != nil {
+		stdinReader.Close()
+		stdinWriter.Close()
 		return fmt.Errorf("failed to get stdout pipe: %w", err)
 	}
 
 	t.stderr, err = t.cmd.StderrPipe()
 	if err != nil {
+		stdinReader.Close()
+		stdinWriter.Close()
 		return fmt.Errorf("failed to get stderr pipe: %w", err)
 	}
 
 	// Start the process
 	if err := t.cmd.Start(); err != nil {
+		stdinReader.Close()
+		stdinWriter.Close()
 		return fmt.Errorf("failed to start process: %w", err)
 	}
 
-	// Create buffered readers/writers
-	t.reader = bufio.NewReaderSize(t.stdout, t.config.BufferSize)
-	t.writer = bufio.NewWriterSize(t.stdin, t.config.BufferSize)
+	// The process has its own copy of the read end
+	stdinReader.Close()
+
+	// Create buffered reader
+	t.reader = bufio.NewReaderSize(t.stdout, t.bufferSize())
 
 	t.connected = true
 
@@ -145,72 +216,177 @@ func (t *StdioTransport) Connect(ctx context.Context) error {
 
 // Send sends a message to the server
 func (t *StdioTransport) Send(msg *RPCMessage) error {
+	// Encode message as JSON
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return t.writeFrame(data)
+}
+
+// SendBatch sends messages to the server as one batch
+func (t *StdioTransport) SendBatch(msgs []*RPCMessage) error {
+	data, err := encodeBatch(msgs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch: %w", err)
+	}
+	return t.writeFrame(data)
+}
+
+// writeFrame writes one encoded message or batch followed by a newline.
+// Writes block while the server is not reading, for up to the write timeout
+// per chunk. A server that stalls part way through a message has left the
+// stream unusable, so the connection is dropped and reconnected.
+func (t *StdioTransport) writeFrame(data []byte) error {
 	t.mu.RLock()
 	if !t.connected {
 		t.mu.RUnlock()
 		return fmt.Errorf("transport not connected")
 	}
-	writer := t.writer
+	stdin := t.stdin
 	t.mu.RUnlock()
 
-	// Encode message as JSON
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+	if limit := t.maxMessageSize(); limit > 0 && len(data) > limit {
+		return newFrameError(ErrMessageTooLarge, data, len(data), nil)
 	}
 
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
 	// Write message followed by newline
-	if _, err := writer.Write(data); err != nil {
-		return fmt.Errorf("failed to write message: %w", err)
+	frame := append(data, '\n')
+	written, err := t.writeChunks(stdin, frame)
+	if err == nil {
+		return nil
 	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		err = fmt.Errorf("%w after %s", ErrWriteTimeout, t.writeTimeout())
+	}
+	if written > 0 {
+		logger.Warn("MCP server stopped reading part way through a message, dropping connection",
+			"written", written, "size", len(frame), "error", err)
+		t.abort()
+	}
+	return fmt.Errorf("failed to write message: %w", err)
+}
 
-	if err := writer.WriteByte('\n'); err != nil {
-		return fmt.Errorf("failed to write newline: %w", err)
+// writeChunks writes data a buffer at a time, giving each chunk the write
+// timeout so a server that keeps reading is never cut off however large the
+// message. It returns how many bytes were written.
+func (t *StdioTransport) writeChunks(w *os.File, data []byte) (int, error) {
+	chunkSize := t.bufferSize()
+	timeout := t.writeTimeout()
+	written := 0
+	for written < len(data) {
+		end := min(written+chunkSize, len(data))
+		// Platforms without pipe deadlines simply block
+		_ = w.SetWriteDeadline(time.Now().Add(timeout))
+		n, err := w.Write(data[written:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
 	}
+	_ = w.SetWriteDeadline(time.Time{})
+	return written, nil
+}
 
-	// Flush the writer
-	if err := writer.Flush(); err != nil {
-		return fmt.Errorf("failed to flush writer: %w", err)
+// abort stops the server process, which the read loop sees as a lost
+// connection and reconnects
+func (t *StdioTransport) abort() {
+	t.mu.RLock()
+	cancel := t.cancel
+	t.mu.RUnlock()
+	if cancel != nil {
+		cancel()
 	}
+}
 
-	return nil
+// queuedMessage is a message, or a dropped batch entry, waiting for Receive
+type queuedMessage struct {
+	msg     *RPCMessage
+	dropped *FrameError
 }
 
-// Receive receives the next message (blocking)
+// inboundFrame is what one frame read from the server decoded to
+type inboundFrame struct {
+	messages []*RPCMessage
+	batch    bool
+	dropped  []*FrameError
+}
+
+// Receive receives the next message (blocking). The messages of a batch are
+// returned one at a time.
 func (t *StdioTransport) Receive() (*RPCMessage, error) {
+	t.readMu.Lock()
+	defer t.readMu.Unlock()
+
+	for len(t.queued) == 0 {
+		frame, err := t.receiveFrame()
+		if err != nil {
+			return nil, err
+		}
+		for _, dropped := range frame.dropped {
+			t.queued = append(t.queued, queuedMessage{dropped: dropped})
+		}
+		for _, msg := range frame.messages {
+			t.queued = append(t.queued, queuedMessage{msg: msg})
+		}
+	}
+
+	next := t.queued[0]
+	t.queued = t.queued[1:]
+	if next.dropped != nil {
+		return nil, next.dropped
+	}
+	return next.msg, nil
+}
+
+// receiveFrame reads and decodes the next frame. Frames that could not be
+// read or decoded are returned as dropped rather than as an error, which is
+// kept for failures of the stream itself.
+func (t *StdioTransport) receiveFrame() (inboundFrame, error) {
 	t.mu.RLock()
 	if !t.connected {
 		t.mu.RUnlock()
-		return nil, fmt.Errorf("transport not connected")
+		return inboundFrame{}, fmt.Errorf("transport not connected")
 	}
 	reader := t.reader
 	t.mu.RUnlock()
 
-	// Read line
-	line, err := reader.ReadBytes('\n')
+	// Read one line, dropping oversized frames
+	data, err := readFrame(reader, t.maxMessageSize())
 	if err != nil {
+		var frameErr *FrameError
+		if errors.As(err, &frameErr) {
+			return inboundFrame{dropped: []*FrameError{frameErr}}, nil
+		}
 		if err == io.EOF {
-			return nil, fmt.Errorf("server closed connection")
+			return inboundFrame{}, fmt.Errorf("server closed connection")
 		}
-		return nil, fmt.Errorf("failed to read message: %w", err)
-	}
-
-	// Parse JSON
-	var msg RPCMessage
-	if err := json.Unmarshal(line, &msg); err != nil {
-		return nil, fmt.Errorf("failed to parse message: %w", err)
+		return inboundFrame{}, fmt.Errorf("failed to read message: %w", err)
 	}
 
-	return &msg, nil
+	msgs, batch, dropped := decodeFrames(data)
+	return inboundFrame{messages: msgs, batch: batch, dropped: dropped}, nil
 }
 
 // Close closes the connection and stops the process
 func (t *StdioTransport) Close() error {
+	if t.shutdown() {
+		// Fail in-flight requests outside the lock
+		t.notifyDisconnect(fmt.Errorf("transport closed"))
+	}
+	return nil
+}
+
+// shutdown stops the process, reporting whether the transport was connected
+func (t *StdioTransport) shutdown() bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	if !t.connected {
-		return nil
+		return false
 	}
 
 	t.connected = false
@@ -255,7 +431,7 @@ func (t *StdioTransport) Close() error {
 		}
 	}
 
-	return nil
+	return true
 }
 
 // IsConnected returns whether the transport is connected
@@ -272,7 +448,7 @@ func (t *StdioTransport) readLoop() {
 		case <-t.ctx.Done():
 			return
 		default:
-			msg, err := t.Receive()
+			frame, err := t.receiveFrame()
 			if err != nil {
 				// Check if we're still supposed to be connected
 				t.mu.RLock()
@@ -283,9 +459,14 @@ func (t *StdioTransport) readLoop() {
 					// Handle read error with reconnection logic
 					t.mu.Lock()
 					t.lastError = err
+					reconnectCount := t.reconnectCount
+					maxReconnects := t.maxReconnects
 					t.mu.Unlock()
 
-					logger.Warn("MCP transport read error", "error", err, "reconnect_count", t.reconnectCount)
+					logger.Warn("MCP transport read error", "error", err, "reconnect_count", reconnectCount)
+
+					// Responses to in-flight requests died with the connection
+					t.notifyDisconnect(err)
 
 					// Notify error callback
 					if t.errorCallback != nil {
@@ -293,19 +474,35 @@ func (t *StdioTransport) readLoop() {
 					}
 
 					// Attempt reconnection if within limits and not already reconnecting
-					if t.reconnectCount < t.maxReconnects {
+					if reconnectCount < maxReconnects {
 						go t.attemptReconnect()
 					} else {
-						logger.Error("MCP transport max reconnects exceeded", "max_reconnects", t.maxReconnects)
+						logger.Error("MCP transport max reconnects exceeded", "max_reconnects", maxReconnects)
 						t.Close()
 					}
 				}
 				return
 			}
 
-			// Process message
+			// A bad frame or batch entry is dropped; the stream is still in sync
+			for _, frameErr := range frame.dropped {
+				logger.Warn("MCP transport dropped message", "error", frameErr, "snippet", frameErr.Snippet)
+				if t.frameErrorHandler != nil {
+					t.frameErrorHandler(frameErr)
+				}
+			}
+
+			// Process messages
+			if frame.batch && t.batchHandler != nil {
+				if len(frame.messages) > 0 {
+					t.batchHandler(frame.messages)
+				}
+				continue
+			}
 			if t.messageHandler != nil {
-				t.messageHandler(msg)
+				for _, msg := range frame.messages {
+					t.messageHandler(msg)
+				}
 			}
 		}
 	}
@@ -2,7 +2,9 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -303,3 +305,91 @@ func TestStdioTransport_Integration(t *testing.T) {
 		t.Errorf("Expected jsonrpc '2.0', got %v", msg.JSONRPC)
 	}
 }
+
+func TestStdioTransport_SizeSettings(t *testing.T) {
+	tests := []struct {
+		config     TransportConfig
+		maxSize    int
+		bufferSize int
+	}{
+		{TransportConfig{}, DefaultMaxMessageSize, DefaultBufferSize},
+		{TransportConfig{MaxMessageSize: 1024, BufferSize: 512}, 1024, 512},
+		{TransportConfig{MaxMessageSize: UnlimitedMessageSize}, 0, DefaultBufferSize},
+	}
+	for _, tt := range tests {
+		transport := NewStdioTransport("cat", nil, nil, tt.config)
+		if got := transport.maxMessageSize(); got != tt.maxSize {
+			t.Errorf("maxMessageSize() = %d, want %d", got, tt.maxSize)
+		}
+		if got := transport.bufferSize(); got != tt.bufferSize {
+			t.Errorf("bufferSize() = %d, want %d", got, tt.bufferSize)
+		}
+	}
+}
+
+// TestStdioTransport_LargeMessage sends a message larger than the default
+// limit through a server that echoes it back
+func TestStdioTransport_LargeMessage(t *testing.T) {
+	transport := NewStdioTransport("cat", nil, nil, TransportConfig{
+		BufferSize:     4096,
+		MaxMessageSize: UnlimitedMessageSize,
+	})
+	received := make(chan *RPCMessage, 1)
+	transport.SetMessageHandler(func(msg *RPCMessage) { received <- msg })
+
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer transport.Close()
+
+	payload := strings.Repeat("x", DefaultMaxMessageSize+1<<20)
+	params, err := json.Marshal(map[string]string{"payload": payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transport.Send(&RPCMessage{JSONRPC: "2.0", Method: "notifications/large", Params: params}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	select {
+	case msg := <-received:
+		if len(msg.Params) != len(params) {
+			t.Errorf("Expected %d bytes of params, got %d", len(params), len(msg.Params))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("large message was not echoed")
+	}
+}
+
+// TestStdioTransport_WriteTimeout drops the connection to a server that
+// stops reading part way through a message
+func TestStdioTransport_WriteTimeout(t *testing.T) {
+	transport := NewStdioTransport("sleep", []string{"10"}, nil, TransportConfig{
+		BufferSize:   4096,
+		WriteTimeout: 100 * time.Millisecond,
+	})
+	transport.SetReconnectConfig(0, time.Millisecond)
+
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer transport.Close()
+
+	// Far more than the pipe holds
+	params, err := json.Marshal(strings.Repeat("y", 1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = transport.Send(&RPCMessage{JSONRPC: "2.0", Method: "notifications/large", Params: params})
+	if !errors.Is(err, ErrWriteTimeout) {
+		t.Fatalf("Expected ErrWriteTimeout, got %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for transport.IsConnected() {
+		if time.Now().After(deadline) {
+			t.Fatal("stalled connection was not dropped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,311 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MCP specification revisions understood by the client
+const (
+	ProtocolVersion20241105 = "2024-11-05"
+	ProtocolVersion20250326 = "2025-03-26"
+	ProtocolVersion20250618 = "2025-06-18"
+
+	// LegacyProtocolVersion is the pre-specification draft spoken by early servers
+	LegacyProtocolVersion = "1.0"
+
+	// LatestProtocolVersion is offered during initialization unless overridden
+	LatestProtocolVersion = ProtocolVersion20250618
+)
+
+// supportedProtocolVersions lists accepted revisions, newest first
+var supportedProtocolVersions = []string{
+	ProtocolVersion20250618,
+	ProtocolVersion20250326,
+	ProtocolVersion20241105,
+	LegacyProtocolVersion,
+}
+
+// SupportedProtocolVersions returns the protocol revisions the client accepts, newest first
+func SupportedProtocolVersions() []string {
+	versions := make([]string, len(supportedProtocolVersions))
+	copy(versions, supportedProtocolVersions)
+	return versions
+}
+
+// IsSupportedProtocolVersion reports whether version is a revision the client can speak
+func IsSupportedProtocolVersion(version string) bool {
+	for _, v := range supportedProtocolVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateProtocolVersion validates the version a server answered with. Servers
+// reply with the requested version when they support it and otherwise with
+// their own preferred revision, which is only usable if the client knows it.
+func NegotiateProtocolVersion(requested, offered string) (string, error) {
+	if offered == "" {
+		// Servers predating negotiation omit the field entirely
+		return LegacyProtocolVersion, nil
+	}
+	if offered == requested || IsSupportedProtocolVersion(offered) {
+		return offered, nil
+	}
+	return "", fmt.Errorf("server protocol version %q is not supported (client supports %s)",
+		offered, strings.Join(supportedProtocolVersions, ", "))
+}
+
+// versionAtLeast reports whether version is a dated revision no older than minimum.
+// The legacy draft predates every dated revision.
+func versionAtLeast(version, minimum string) bool {
+	if version == "" || version == LegacyProtocolVersion {
+		return false
+	}
+	// Dated revisions are YYYY-MM-DD and therefore order lexically
+	return version >= minimum
+}
+
+// Feature identifies protocol functionality gated on version and capabilities
+type Feature string
+
+const (
+	FeatureToolListChanged     Feature = "tools.listChanged"
+	FeaturePromptListChanged   Feature = "prompts.listChanged"
+	FeatureResourceListChanged Feature = "resources.listChanged"
+	FeatureResourceSubscribe   Feature = "resources.subscribe"
+	FeatureSampling            Feature = "sampling"
+	FeatureAudioContent        Feature = "content.audio"
+	FeatureToolAnnotations     Feature = "tools.annotations"
+	FeatureStructuredContent   Feature = "content.structured"
+	FeatureResourceLinks       Feature = "content.resourceLink"
+	FeatureBatching            Feature = "jsonrpc.batch"
+)
+
+// featureVersions records the first revision introducing each feature
+var featureVersions = map[Feature]string{
+	FeatureToolListChanged:     ProtocolVersion20241105,
+	FeaturePromptListChanged:   ProtocolVersion20241105,
+	FeatureResourceListChanged: ProtocolVersion20241105,
+	FeatureResourceSubscribe:   ProtocolVersion20241105,
+	FeatureSampling:            ProtocolVersion20241105,
+	FeatureAudioContent:        ProtocolVersion20250326,
+	FeatureToolAnnotations:     ProtocolVersion20250326,
+	FeatureStructuredContent:   ProtocolVersion20250618,
+	FeatureResourceLinks:       ProtocolVersion20250618,
+	FeatureBatching:            ProtocolVersion20250326,
+}
+
+// supportsFeature reports whether a feature is usable for the negotiated
+// version and the capabilities exchanged during initialization
+func supportsFeature(feature Feature, version string, client ClientCapabilities, server *ServerCapabilities) bool {
+	minimum, ok := featureVersions[feature]
+	if !ok || !versionAtLeast(version, minimum) {
+		return false
+	}
+
+	switch feature {
+	case FeatureToolListChanged:
+		return server != nil && server.Tools && server.ToolsListChanged
+	case FeaturePromptListChanged:
+		return server != nil && server.Prompts && server.PromptsListChanged
+	case FeatureResourceListChanged:
+		return server != nil && server.Resources && server.ResourcesListChanged
+	case FeatureResourceSubscribe:
+		return server != nil && server.Resources && server.ResourcesSubscribe
+	case FeatureSampling:
+		return client.Sampling
+	case FeatureToolAnnotations, FeatureStructuredContent:
+		return server != nil && server.Tools
+	case FeatureBatching:
+		// Batches were dropped from the specification again in 2025-06-18
+		return !versionAtLeast(version, ProtocolVersion20250618)
+	default:
+		return true
+	}
+}
+
+// capabilityFlags is the wire form of a capability object such as {"listChanged": true}
+type capabilityFlags struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+	Subscribe   bool `json:"subscribe,omitempty"`
+}
+
+// decodeCapability accepts either the legacy boolean or the specification's
+// object form, reporting whether the capability is present
+func decodeCapability(raw json.RawMessage) (bool, capabilityFlags, error) {
+	var flags capabilityFlags
+	trimmed := strings.TrimSpace(string(raw))
+	switch {
+	case trimmed == "" || trimmed == "null" || trimmed == "false":
+		return false, flags, nil
+	case trimmed == "true":
+		return true, flags, nil
+	}
+	if err := json.Unmarshal(raw, &flags); err != nil {
+		return false, flags, err
+	}
+	return true, flags, nil
+}
+
+// decodeExperimental accepts either a list of names or the specification's object form
+func decodeExperimental(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var names []string
+	if err := json.Unmarshal(raw, &names); err == nil {
+		return names, nil
+	}
+
+	var objects map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &objects); err != nil {
+		return nil, err
+	}
+	for name := range objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// encodeExperimental renders experimental capability names in the specification's object form
+func encodeExperimental(names []string) map[string]struct{} {
+	if len(names) == 0 {
+		return nil
+	}
+	objects := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		objects[name] = struct{}{}
+	}
+	return objects
+}
+
+// MarshalJSON encodes client capabilities in the specification's object form.
+// Legacy flags are kept for servers speaking the pre-specification draft.
+func (c ClientCapabilities) MarshalJSON() ([]byte, error) {
+	wire := map[string]interface{}{}
+	if c.Streaming {
+		wire["streaming"] = true
+	}
+	if c.Tools {
+		wire["tools"] = true
+	}
+	if c.Resources {
+		wire["resources"] = true
+	}
+	if c.Sampling {
+		wire["sampling"] = struct{}{}
+	}
+	if c.Roots {
+		wire["roots"] = capabilityFlags{ListChanged: c.RootsListChanged}
+	}
+	if experimental := encodeExperimental(c.Experimental); experimental != nil {
+		wire["experimental"] = experimental
+	}
+	return json.Marshal(wire)
+}
+
+// UnmarshalJSON decodes client capabilities in either wire form
+func (c *ClientCapabilities) UnmarshalJSON(data []byte) error {
+	var wire map[string]json.RawMessage
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+
+	*c = ClientCapabilities{}
+	var err error
+	if c.Streaming, _, err = decodeCapability(wire["streaming"]); err != nil {
+		return fmt.Errorf("invalid streaming capability: %w", err)
+	}
+	if c.Tools, _, err = decodeCapability(wire["tools"]); err != nil {
+		return fmt.Errorf("invalid tools capability: %w", err)
+	}
+	if c.Resources, _, err = decodeCapability(wire["resources"]); err != nil {
+		return fmt.Errorf("invalid resources capability: %w", err)
+	}
+	if c.Sampling, _, err = decodeCapability(wire["sampling"]); err != nil {
+		return fmt.Errorf("invalid sampling capability: %w", err)
+	}
+	var roots capabilityFlags
+	if c.Roots, roots, err = decodeCapability(wire["roots"]); err != nil {
+		return fmt.Errorf("invalid roots capability: %w", err)
+	}
+	c.RootsListChanged = roots.ListChanged
+	if c.Experimental, err = decodeExperimental(wire["experimental"]); err != nil {
+		return fmt.Errorf("invalid experimental capability: %w", err)
+	}
+	return nil
+}
+
+// MarshalJSON encodes server capabilities in the specification's object form
+func (c ServerCapabilities) MarshalJSON() ([]byte, error) {
+	wire := map[string]interface{}{}
+	if c.Streaming {
+		wire["streaming"] = true
+	}
+	if c.Tools {
+		wire["tools"] = capabilityFlags{ListChanged: c.ToolsListChanged}
+	}
+	if c.Resources {
+		wire["resources"] = capabilityFlags{ListChanged: c.ResourcesListChanged, Subscribe: c.ResourcesSubscribe}
+	}
+	if c.Prompts {
+		wire["prompts"] = capabilityFlags{ListChanged: c.PromptsListChanged}
+	}
+	if c.Logging {
+		wire["logging"] = struct{}{}
+	}
+	if c.Completions {
+		wire["completions"] = struct{}{}
+	}
+	if experimental := encodeExperimental(c.Experimental); experimental != nil {
+		wire["experimental"] = experimental
+	}
+	return json.Marshal(wire)
+}
+
+// UnmarshalJSON decodes server capabilities in either wire form
+func (c *ServerCapabilities) UnmarshalJSON(data []byte) error {
+	var wire map[string]json.RawMessage
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+
+	*c = ServerCapabilities{}
+	var (
+		flags capabilityFlags
+		err   error
+	)
+	if c.Streaming, _, err = decodeCapability(wire["streaming"]); err != nil {
+		return fmt.Errorf("invalid streaming capability: %w", err)
+	}
+	if c.Tools, flags, err = decodeCapability(wire["tools"]); err != nil {
+		return fmt.Errorf("invalid tools capability: %w", err)
+	}
+	c.ToolsListChanged = flags.ListChanged
+	if c.Resources, flags, err = decodeCapability(wire["resources"]); err != nil {
+		return fmt.Errorf("invalid resources capability: %w", err)
+	}
+	c.ResourcesListChanged = flags.ListChanged
+	c.ResourcesSubscribe = flags.Subscribe
+	if c.Prompts, flags, err = decodeCapability(wire["prompts"]); err != nil {
+		return fmt.Errorf("invalid prompts capability: %w", err)
+	}
+	c.PromptsListChanged = flags.ListChanged
+	if c.Logging, _, err = decodeCapability(wire["logging"]); err != nil {
+		return fmt.Errorf("invalid logging capability: %w", err)
+	}
+	if c.Completions, _, err = decodeCapability(wire["completions"]); err != nil {
+		return fmt.Errorf("invalid completions capability: %w", err)
+	}
+	if c.Experimental, err = decodeExperimental(wire["experimental"]); err != nil {
+		return fmt.Errorf("invalid experimental capability: %w", err)
+	}
+	return nil
+}
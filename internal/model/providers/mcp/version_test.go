@@ -0,0 +1,194 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		offered   string
+		expected  string
+		wantErr   bool
+	}{
+		{"server accepts latest", LatestProtocolVersion, LatestProtocolVersion, LatestProtocolVersion, false},
+		{"server downgrades to known revision", LatestProtocolVersion, ProtocolVersion20241105, ProtocolVersion20241105, false},
+		{"legacy server", LatestProtocolVersion, LegacyProtocolVersion, LegacyProtocolVersion, false},
+		{"server omits version", LatestProtocolVersion, "", LegacyProtocolVersion, false},
+		{"unknown revision", LatestProtocolVersion, "2099-01-01", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := NegotiateProtocolVersion(tt.requested, tt.offered)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+}
+
+func TestServerCapabilities_JSON(t *testing.T) {
+	t.Run("specification form", func(t *testing.T) {
+		var caps ServerCapabilities
+		data := `{"tools":{"listChanged":true},"resources":{"subscribe":true},"prompts":{},"logging":{},"experimental":{"b":{},"a":{}}}`
+		require.NoError(t, json.Unmarshal([]byte(data), &caps))
+
+		assert.True(t, caps.Tools)
+		assert.True(t, caps.ToolsListChanged)
+		assert.True(t, caps.Resources)
+		assert.True(t, caps.ResourcesSubscribe)
+		assert.False(t, caps.ResourcesListChanged)
+		assert.True(t, caps.Prompts)
+		assert.True(t, caps.Logging)
+		assert.Equal(t, []string{"a", "b"}, caps.Experimental)
+	})
+
+	t.Run("legacy form", func(t *testing.T) {
+		var caps ServerCapabilities
+		require.NoError(t, json.Unmarshal([]byte(`{"streaming":true,"tools":true,"resources":false,"experimental":["x"]}`), &caps))
+		assert.True(t, caps.Streaming)
+		assert.True(t, caps.Tools)
+		assert.False(t, caps.Resources)
+		assert.Equal(t, []string{"x"}, caps.Experimental)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		original := ServerCapabilities{Tools: true, ToolsListChanged: true, Prompts: true}
+		data, err := json.Marshal(original)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"tools":{"listChanged":true},"prompts":{}}`, string(data))
+
+		var decoded ServerCapabilities
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, original, decoded)
+	})
+}
+
+func TestClientCapabilities_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(ClientCapabilities{Tools: true, Sampling: true, Roots: true, RootsListChanged: true})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"tools":true,"sampling":{},"roots":{"listChanged":true}}`, string(data))
+
+	var decoded ClientCapabilities
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, decoded.Sampling)
+	assert.True(t, decoded.RootsListChanged)
+}
+
+func TestSupportsFeature(t *testing.T) {
+	server := &ServerCapabilities{Tools: true, ToolsListChanged: true, Resources: true}
+	client := ClientCapabilities{Sampling: true}
+
+	assert.True(t, supportsFeature(FeatureToolListChanged, ProtocolVersion20241105, client, server))
+	assert.False(t, supportsFeature(FeatureToolListChanged, LegacyProtocolVersion, client, server))
+	assert.False(t, supportsFeature(FeatureResourceSubscribe, ProtocolVersion20250618, client, server))
+	assert.True(t, supportsFeature(FeatureSampling, ProtocolVersion20250326, client, server))
+	assert.False(t, supportsFeature(FeatureSampling, ProtocolVersion20250326, ClientCapabilities{}, server))
+	assert.False(t, supportsFeature(FeatureStructuredContent, ProtocolVersion20250326, client, server))
+	assert.True(t, supportsFeature(FeatureStructuredContent, ProtocolVersion20250618, client, server))
+	assert.False(t, supportsFeature(FeatureAudioContent, ProtocolVersion20241105, client, server))
+	assert.True(t, supportsFeature(FeatureBatching, ProtocolVersion20250326, client, server))
+	assert.False(t, supportsFeature(FeatureBatching, ProtocolVersion20250618, client, server))
+	assert.False(t, supportsFeature(FeatureBatching, ProtocolVersion20241105, client, server))
+}
+
+// newNegotiationHandler returns a handler whose server answers initialize with result
+func newNegotiationHandler(t *testing.T, result string) (*ProtocolHandler, *MockTransport) {
+	transport := NewMockTransport()
+	require.NoError(t, transport.Connect(context.Background()))
+	handler := NewProtocolHandler(transport)
+	transport.SetMessageHandler(handler.ProcessMessage)
+	transport.SetResponse(1, &RPCMessage{
+		JSONRPC: "2.0",
+		ID:      int64Ptr(1),
+		Result:  json.RawMessage(result),
+	})
+	return handler, transport
+}
+
+func TestProtocolHandler_InitializeNegotiation(t *testing.T) {
+	t.Run("current revision", func(t *testing.T) {
+		handler, transport := newNegotiationHandler(t, `{"protocolVersion":"2025-06-18","serverInfo":{"name":"s","version":"1"},"capabilities":{"tools":{"listChanged":true}}}`)
+
+		_, err := handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{Tools: true})
+		require.NoError(t, err)
+		assert.Equal(t, ProtocolVersion20250618, handler.ProtocolVersion())
+		assert.True(t, handler.Supports(FeatureToolListChanged))
+		assert.True(t, handler.Supports(FeatureStructuredContent))
+
+		request := transport.GetLastMessage()
+		require.NotNil(t, request)
+		var params InitializeParams
+		require.NoError(t, json.Unmarshal(request.Params, &params))
+		assert.Equal(t, LatestProtocolVersion, params.ProtocolVersion)
+
+		notification := transport.GetLastMessage()
+		require.NotNil(t, notification)
+		assert.Equal(t, "notifications/initialized", notification.Method)
+
+		changed := make(chan struct{}, 1)
+		handler.OnToolsChanged(func() { changed <- struct{}{} })
+		handler.ProcessMessage(&RPCMessage{JSONRPC: "2.0", Method: "notifications/tools/list_changed"})
+		assert.Len(t, changed, 1)
+	})
+
+	t.Run("legacy server", func(t *testing.T) {
+		handler, transport := newNegotiationHandler(t, `{"protocolVersion":"1.0","serverInfo":{"name":"s","version":"1"},"capabilities":{"tools":true}}`)
+
+		_, err := handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{Tools: true})
+		require.NoError(t, err)
+		assert.Equal(t, LegacyProtocolVersion, handler.ProtocolVersion())
+		assert.False(t, handler.Supports(FeatureToolListChanged))
+
+		transport.GetLastMessage()
+		notification := transport.GetLastMessage()
+		require.NotNil(t, notification)
+		assert.Equal(t, "initialized", notification.Method)
+	})
+
+	t.Run("unsupported revision", func(t *testing.T) {
+		handler, _ := newNegotiationHandler(t, `{"protocolVersion":"2099-01-01","serverInfo":{"name":"s","version":"1"},"capabilities":{}}`)
+
+		_, err := handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{})
+		assert.Error(t, err)
+		assert.False(t, handler.IsInitialized())
+	})
+
+	t.Run("pinned revision", func(t *testing.T) {
+		handler, transport := newNegotiationHandler(t, `{"protocolVersion":"2024-11-05","serverInfo":{"name":"s","version":"1"},"capabilities":{}}`)
+		require.NoError(t, handler.SetProtocolVersion(ProtocolVersion20241105))
+		assert.Error(t, handler.SetProtocolVersion("0.1"))
+
+		_, err := handler.Initialize(ClientInfo{Name: "sigil"}, ClientCapabilities{})
+		require.NoError(t, err)
+
+		var params InitializeParams
+		require.NoError(t, json.Unmarshal(transport.GetLastMessage().Params, &params))
+		assert.Equal(t, ProtocolVersion20241105, params.ProtocolVersion)
+	})
+}
+
+func TestToolCallResult_Text(t *testing.T) {
+	result := ToolCallResult{
+		Content: []ToolCallContent{
+			{Type: ContentTypeText, Text: "hello"},
+			{Type: ContentTypeImage, Data: "aGk=", MimeType: "image/png"},
+			{Type: ContentTypeResource, Resource: &ResourceContent{URI: "file:///a", Text: "embedded"}},
+			{Type: ContentTypeResourceLink, URI: "file:///b", Name: "b"},
+		},
+	}
+	assert.Equal(t, "hello\n[image image/png]\nembedded\n[resource_link file:///b]", result.Text())
+
+	structured := ToolCallResult{StructuredContent: map[string]interface{}{"temperature": 21.5}}
+	assert.Equal(t, `{"temperature":21.5}`, structured.Text())
+}
@@ -11,9 +11,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/version"
 )
 
 const (
@@ -124,10 +126,22 @@ type Model struct {
 
 // RunPrompt executes a prompt against Ollama API
 func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.PromptOutput, error) {
+	return m.generate(ctx, input, nil)
+}
+
+// StreamPrompt executes a prompt against Ollama API, passing the response
+// on as Ollama generates it
+func (m *Model) StreamPrompt(ctx context.Context, input model.PromptInput, onChunk model.ChunkFunc) (model.PromptOutput, error) {
+	return m.generate(ctx, input, onChunk)
+}
+
+// generate runs a prompt, streaming the response to onChunk unless it is nil
+func (m *Model) generate(ctx context.Context, input model.PromptInput, onChunk model.ChunkFunc) (model.PromptOutput, error) {
 	start := time.Now()
 
 	// Build request
 	req := m.buildRequest(input)
+	req.Stream = onChunk != nil
 
 	// Marshal request
 	reqBody, err := json.Marshal(req)
@@ -145,7 +159,7 @@ func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.P
 
 	// Set headers
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("User-Agent", "Sigil/1.0")
+	httpReq.Header.Set("User-Agent", version.UserAgent())
 
 	// Send request
 	resp, err := m.client.Do(httpReq)
@@ -161,7 +175,8 @@ func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.P
 			fmt.Sprintf("API error %d: %s", resp.StatusCode, string(respBody)))
 	}
 
-	// Ollama returns streaming JSON responses, read until we get the final one
+	// Ollama returns a JSON object per piece of a streamed response, or a
+	// single one otherwise; read until we get the final one
 	var finalResp GenerateResponse
 	decoder := json.NewDecoder(resp.Body)
 
@@ -175,6 +190,9 @@ func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.P
 		}
 
 		finalResp.Response += response.Response
+		if onChunk != nil && response.Response != "" {
+			onChunk(response.Response)
+		}
 		if response.Done {
 			finalResp.Done = true
 			finalResp.TotalDuration = response.TotalDuration
@@ -203,6 +221,7 @@ func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.P
 	}
 
 	duration := time.Since(start)
+	model.RecordUsage(m.Name(), output.TokensUsed)
 	logger.Debug("Ollama request completed", "duration", duration, "tokens", output.TokensUsed)
 
 	return output, nil
@@ -262,7 +281,7 @@ func (m *Model) buildRequest(input model.PromptInput) GenerateRequest {
 
 	// Add file context
 	if len(input.Files) > 0 {
-		contextText := m.buildFileContext(input.Files)
+		contextText := model.FormatFileContext(input.Files)
 		if contextText != "" {
 			prompt.WriteString(contextText)
 		}
@@ -273,11 +292,16 @@ func (m *Model) buildRequest(input model.PromptInput) GenerateRequest {
 	req := GenerateRequest{
 		Model:  m.modelName,
 		Prompt: prompt.String(),
-		Stream: false, // We'll handle streaming manually
+		Stream: false, // Set by generate when the response is streamed
 	}
 
 	// Set options
-	if input.Temperature > 0 {
+	if deterministic.Enabled() {
+		req.Options = map[string]interface{}{
+			"temperature": 0,
+			"seed":        deterministic.Seed,
+		}
+	} else if input.Temperature > 0 {
 		req.Options = map[string]interface{}{
 			"temperature": input.Temperature,
 		}
@@ -286,24 +310,6 @@ func (m *Model) buildRequest(input model.PromptInput) GenerateRequest {
 	return req
 }
 
-// buildFileContext builds context from file contents
-func (m *Model) buildFileContext(files []model.FileContent) string {
-	if len(files) == 0 {
-		return ""
-	}
-
-	var context strings.Builder
-	context.WriteString("\nAdditional context files:\n")
-
-	for _, file := range files {
-		context.WriteString(fmt.Sprintf("\n--- %s ---\n", file.Path))
-		context.WriteString(file.Content)
-		context.WriteString("\n")
-	}
-
-	return context.String()
-}
-
 // Ollama API types
 
 // GenerateRequest represents a generate request
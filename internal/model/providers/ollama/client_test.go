@@ -345,6 +345,33 @@ func TestModel_RunPrompt(t *testing.T) {
 	})
 }
 
+func TestModel_StreamPrompt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.Stream)
+
+		encoder := json.NewEncoder(w)
+		for _, piece := range []string{"Hello", ", ", "world"} {
+			_ = encoder.Encode(GenerateResponse{Model: "llama2:7b", Response: piece})
+		}
+		_ = encoder.Encode(GenerateResponse{Model: "llama2:7b", Done: true, PromptEvalCount: 4, EvalCount: 3})
+	}))
+	defer server.Close()
+
+	modelInstance, err := NewProvider().CreateModel(model.ModelConfig{Model: "llama2:7b", Endpoint: server.URL})
+	require.NoError(t, err)
+
+	var chunks []string
+	output, err := model.Stream(context.Background(), modelInstance, model.PromptInput{UserPrompt: "Hi"}, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hello", ", ", "world"}, chunks)
+	assert.Equal(t, "Hello, world", output.Response)
+	assert.Equal(t, 7, output.TokensUsed)
+}
+
 func TestModel_buildRequest(t *testing.T) {
 	provider := NewProvider()
 	config := model.ModelConfig{
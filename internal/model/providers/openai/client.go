@@ -8,16 +8,20 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/logger"
 	"github.com/dshills/sigil/internal/model"
+	"github.com/dshills/sigil/internal/version"
 )
 
 const (
-	defaultBaseURL = "https://api.openai.com/v1"
-	defaultTimeout = 30 * time.Second
+	defaultBaseURL        = "https://api.openai.com/v1"
+	defaultTimeout        = 30 * time.Second
+	defaultEmbeddingModel = "text-embedding-3-small"
 )
 
 // Provider implements the OpenAI model provider
@@ -37,7 +41,9 @@ func NewProvider() *Provider {
 // CreateModel creates an OpenAI model instance
 func (p *Provider) CreateModel(config model.ModelConfig) (model.Model, error) {
 	if config.APIKey == "" {
-		return nil, errors.ConfigError("CreateModel", "OpenAI API key is required")
+		return nil, errors.ConfigError("CreateModel", "OpenAI API key is required").
+			WithCode(errors.CodeMissingAPIKey).
+			WithHint("set OPENAI_API_KEY or models.configs.openai.apikey in .sigil/config.yml")
 	}
 
 	baseURL := config.Endpoint
@@ -50,10 +56,16 @@ func (p *Provider) CreateModel(config model.ModelConfig) (model.Model, error) {
 		timeout = timeoutVal
 	}
 
+	embeddingModel := defaultEmbeddingModel
+	if name, ok := config.Options["embedding_model"].(string); ok && name != "" {
+		embeddingModel = name
+	}
+
 	return &Model{
-		apiKey:    config.APIKey,
-		modelName: config.Model,
-		baseURL:   baseURL,
+		apiKey:         config.APIKey,
+		modelName:      config.Model,
+		embeddingModel: embeddingModel,
+		baseURL:        baseURL,
 		client: &http.Client{
 			Timeout: timeout,
 		},
@@ -79,10 +91,11 @@ func (p *Provider) Name() string {
 
 // Model represents an OpenAI model instance
 type Model struct {
-	apiKey    string
-	modelName string
-	baseURL   string
-	client    *http.Client
+	apiKey         string
+	modelName      string
+	embeddingModel string
+	baseURL        string
+	client         *http.Client
 }
 
 // RunPrompt executes a prompt against OpenAI API
@@ -109,7 +122,7 @@ func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.P
 	// Set headers
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", m.apiKey))
-	httpReq.Header.Set("User-Agent", "Sigil/1.0")
+	httpReq.Header.Set("User-Agent", version.UserAgent())
 
 	// Send request
 	resp, err := m.client.Do(httpReq)
@@ -126,8 +139,12 @@ func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.P
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return model.PromptOutput{}, errors.New(errors.ErrorTypeNetwork, "RunPrompt",
+		err := errors.New(errors.ErrorTypeNetwork, "RunPrompt",
 			fmt.Sprintf("API error %d: %s", resp.StatusCode, string(respBody)))
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return model.PromptOutput{}, model.RateLimited(err, resp.Header.Get("Retry-After"))
+		}
+		return model.PromptOutput{}, err
 	}
 
 	// Parse response
@@ -154,8 +171,12 @@ func (m *Model) RunPrompt(ctx context.Context, input model.PromptInput) (model.P
 			"model":         apiResp.Model,
 		},
 	}
+	if mean, ok := choice.Logprobs.mean(); ok {
+		output.Metadata[model.MetadataMeanLogprob] = strconv.FormatFloat(mean, 'f', 6, 64)
+	}
 
 	duration := time.Since(start)
+	model.RecordUsage(m.Name(), apiResp.Usage.TotalTokens)
 	logger.Debug("OpenAI request completed", "duration", duration, "tokens", apiResp.Usage.TotalTokens)
 
 	return output, nil
@@ -193,6 +214,64 @@ func (m *Model) Name() string {
 	return fmt.Sprintf("openai:%s", m.modelName)
 }
 
+// Embed returns embeddings for texts from the OpenAI embeddings API
+func (m *Model) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	reqBody, err := json.Marshal(EmbeddingRequest{Model: m.embeddingModel, Input: texts})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeModel, "Embed", "failed to marshal request")
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", m.baseURL+"/embeddings", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeNetwork, "Embed", "failed to create request")
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", m.apiKey))
+	httpReq.Header.Set("User-Agent", version.UserAgent())
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeNetwork, "Embed", "request failed")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeNetwork, "Embed", "failed to read response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		err := errors.New(errors.ErrorTypeNetwork, "Embed",
+			fmt.Sprintf("API error %d: %s", resp.StatusCode, string(respBody)))
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return nil, model.RateLimited(err, resp.Header.Get("Retry-After"))
+		}
+		return nil, err
+	}
+
+	var apiResp EmbeddingResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeModel, "Embed", "failed to parse response")
+	}
+	if len(apiResp.Data) != len(texts) {
+		return nil, errors.New(errors.ErrorTypeModel, "Embed",
+			fmt.Sprintf("expected %d embeddings, got %d", len(texts), len(apiResp.Data)))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, data := range apiResp.Data {
+		if data.Index < 0 || data.Index >= len(vectors) {
+			return nil, errors.New(errors.ErrorTypeModel, "Embed", fmt.Sprintf("embedding index %d out of range", data.Index))
+		}
+		vectors[data.Index] = data.Embedding
+	}
+	return vectors, nil
+}
+
+// EmbeddingModel returns the model used for embeddings
+func (m *Model) EmbeddingModel() string {
+	return "openai:" + m.embeddingModel
+}
+
 // buildRequest builds the OpenAI API request
 func (m *Model) buildRequest(input model.PromptInput) ChatCompletionRequest {
 	messages := []ChatMessage{}
@@ -223,7 +302,7 @@ func (m *Model) buildRequest(input model.PromptInput) ChatCompletionRequest {
 
 	// Add file context to user message if present
 	if len(input.Files) > 0 {
-		contextMsg := m.buildFileContext(input.Files)
+		contextMsg := model.FormatFileContext(input.Files)
 		if contextMsg != "" {
 			messages = append(messages, ChatMessage{
 				Role:    "user",
@@ -242,30 +321,19 @@ func (m *Model) buildRequest(input model.PromptInput) ChatCompletionRequest {
 		temperature = float32(input.Temperature)
 	}
 
-	return ChatCompletionRequest{
+	req := ChatCompletionRequest{
 		Model:       m.modelName,
 		Messages:    messages,
 		MaxTokens:   maxTokens,
 		Temperature: temperature,
+		Logprobs:    input.Metadata[model.MetadataLogprobs] == "true",
 	}
-}
-
-// buildFileContext builds context from file contents
-func (m *Model) buildFileContext(files []model.FileContent) string {
-	if len(files) == 0 {
-		return ""
+	if deterministic.Enabled() {
+		seed := deterministic.Seed
+		req.Temperature = 0
+		req.Seed = &seed
 	}
-
-	var context bytes.Buffer
-	context.WriteString("\nAdditional context files:\n")
-
-	for _, file := range files {
-		context.WriteString(fmt.Sprintf("\n--- %s ---\n", file.Path))
-		context.WriteString(file.Content)
-		context.WriteString("\n")
-	}
-
-	return context.String()
+	return req
 }
 
 // OpenAI API types
@@ -275,10 +343,12 @@ type ChatCompletionRequest struct {
 	Model       string        `json:"model"`
 	Messages    []ChatMessage `json:"messages"`
 	MaxTokens   int           `json:"max_tokens,omitempty"`
-	Temperature float32       `json:"temperature,omitempty"`
+	Temperature float32       `json:"temperature"`
+	Seed        *int          `json:"seed,omitempty"`
 	TopP        float32       `json:"top_p,omitempty"`
 	N           int           `json:"n,omitempty"`
 	Stop        []string      `json:"stop,omitempty"`
+	Logprobs    bool          `json:"logprobs,omitempty"`
 }
 
 // ChatMessage represents a chat message
@@ -302,6 +372,30 @@ type Choice struct {
 	Index        int         `json:"index"`
 	Message      ChatMessage `json:"message"`
 	FinishReason string      `json:"finish_reason"`
+	Logprobs     *Logprobs   `json:"logprobs,omitempty"`
+}
+
+// Logprobs holds the token log probabilities of a choice
+type Logprobs struct {
+	Content []TokenLogprob `json:"content"`
+}
+
+// TokenLogprob is the log probability of one generated token
+type TokenLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+}
+
+// mean returns the mean token log probability, if any were returned
+func (l *Logprobs) mean() (float64, bool) {
+	if l == nil || len(l.Content) == 0 {
+		return 0, false
+	}
+	var sum float64
+	for _, token := range l.Content {
+		sum += token.Logprob
+	}
+	return sum / float64(len(l.Content)), true
 }
 
 // Usage represents token usage information
@@ -310,3 +404,20 @@ type Usage struct {
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
 }
+
+// EmbeddingRequest represents an OpenAI embeddings request
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbeddingResponse represents an OpenAI embeddings response
+type EmbeddingResponse struct {
+	Data []EmbeddingData `json:"data"`
+}
+
+// EmbeddingData is one embedding in an embeddings response
+type EmbeddingData struct {
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -215,6 +216,34 @@ func TestModel_RunPrompt(t *testing.T) {
 		assert.Equal(t, "This is a test response from GPT-4", output.Response)
 		assert.Equal(t, 40, output.TokensUsed)
 		assert.Equal(t, "gpt-4", output.Model)
+		assert.NotContains(t, output.Metadata, model.MetadataMeanLogprob)
+	})
+
+	t.Run("with logprobs", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req ChatCompletionRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.True(t, req.Logprobs)
+
+			json.NewEncoder(w).Encode(ChatCompletionResponse{
+				Model: "gpt-4",
+				Choices: []Choice{{
+					Message:  ChatMessage{Role: "assistant", Content: "Yes."},
+					Logprobs: &Logprobs{Content: []TokenLogprob{{Token: "Yes", Logprob: -0.1}, {Token: ".", Logprob: -0.3}}},
+				}},
+			})
+		}))
+		defer server.Close()
+
+		modelInstance, err := NewProvider().CreateModel(model.ModelConfig{APIKey: "test-api-key", Model: "gpt-4", Endpoint: server.URL})
+		require.NoError(t, err)
+
+		output, err := modelInstance.RunPrompt(context.Background(), model.PromptInput{
+			UserPrompt: "Is it?",
+			Metadata:   map[string]string{model.MetadataLogprobs: "true"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "-0.200000", output.Metadata[model.MetadataMeanLogprob])
 	})
 
 	t.Run("API error", func(t *testing.T) {
@@ -349,6 +378,42 @@ func TestModel_RunPrompt(t *testing.T) {
 	})
 }
 
+func TestModel_Embed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer test-api-key", r.Header.Get("Authorization"))
+
+		var req EmbeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "text-embedding-3-large", req.Model)
+		assert.Equal(t, []string{"first", "second"}, req.Input)
+
+		// Results may arrive out of order; the index places them
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(EmbeddingResponse{Data: []EmbeddingData{
+			{Index: 1, Embedding: []float32{0, 1}},
+			{Index: 0, Embedding: []float32{1, 0}},
+		}})
+	}))
+	defer server.Close()
+
+	modelInstance, err := NewProvider().CreateModel(model.ModelConfig{
+		APIKey:   "test-api-key",
+		Model:    "gpt-4",
+		Endpoint: server.URL,
+		Options:  map[string]interface{}{"embedding_model": "text-embedding-3-large"},
+	})
+	require.NoError(t, err)
+
+	embedder, ok := model.EmbedderOf(modelInstance)
+	require.True(t, ok)
+	assert.Equal(t, "openai:text-embedding-3-large", embedder.EmbeddingModel())
+
+	vectors, err := embedder.Embed(context.Background(), []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 0}, {0, 1}}, vectors)
+}
+
 func TestModel_buildRequest(t *testing.T) {
 	provider := NewProvider()
 	config := model.ModelConfig{
@@ -415,6 +480,16 @@ func TestModel_buildRequest(t *testing.T) {
 		assert.Equal(t, 1000, req.MaxTokens)           // default
 		assert.Equal(t, float32(0.7), req.Temperature) // default
 	})
+
+	t.Run("deterministic mode", func(t *testing.T) {
+		deterministic.Set(true)
+		defer deterministic.Set(false)
+
+		req := openaiModel.buildRequest(model.PromptInput{UserPrompt: "Hello", Temperature: 0.3})
+		assert.Zero(t, req.Temperature)
+		require.NotNil(t, req.Seed)
+		assert.Equal(t, deterministic.Seed, *req.Seed)
+	})
 }
 
 func TestChatMessage_Structure(t *testing.T) {
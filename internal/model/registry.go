@@ -54,6 +54,10 @@ func GetProvider(name string) (Factory, error) {
 
 // CreateModel creates a model instance from configuration
 func CreateModel(config ModelConfig) (Model, error) {
+	if err := currentPolicy().Allows(config); err != nil {
+		return nil, err
+	}
+
 	// Check if model already exists
 	modelKey := fmt.Sprintf("%s:%s", config.Provider, config.Model)
 
@@ -78,6 +82,11 @@ func CreateModel(config ModelConfig) (Model, error) {
 			fmt.Sprintf("failed to create model %s", config.Model))
 	}
 
+	// Requests from every user of the model go through the gate
+	if g := currentGate(); g != nil {
+		model = withGate(model, g, config)
+	}
+
 	// Cache the model
 	defaultRegistry.mu.Lock()
 	defaultRegistry.models[modelKey] = model
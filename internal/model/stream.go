@@ -0,0 +1,30 @@
+package model
+
+import "context"
+
+// ChunkFunc receives the pieces of a streamed response in order. Each
+// continues the ones before it.
+type ChunkFunc func(chunk string)
+
+// Streamer is implemented by models that can pass their response on as it
+// is generated, rather than only once it is complete.
+type Streamer interface {
+	// StreamPrompt runs a prompt like RunPrompt, passing each piece of the
+	// response to onChunk as it arrives. The output holds the whole
+	// response.
+	StreamPrompt(ctx context.Context, input PromptInput, onChunk ChunkFunc) (PromptOutput, error)
+}
+
+// Stream runs a prompt on m, passing the response to onChunk as it
+// arrives. Models that cannot stream pass the whole response in one piece
+// once it is complete.
+func Stream(ctx context.Context, m Model, input PromptInput, onChunk ChunkFunc) (PromptOutput, error) {
+	if streamer, ok := m.(Streamer); ok {
+		return streamer.StreamPrompt(ctx, input, onChunk)
+	}
+	output, err := m.RunPrompt(ctx, input)
+	if err == nil && output.Response != "" {
+		onChunk(output.Response)
+	}
+	return output, err
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// chunkedModel streams its chunks, failing with err after them if set
+type chunkedModel struct {
+	MockModel
+	chunks []string
+	err    error
+	calls  int
+}
+
+func (m *chunkedModel) StreamPrompt(_ context.Context, _ PromptInput, onChunk ChunkFunc) (PromptOutput, error) {
+	m.calls++
+	var response string
+	for _, chunk := range m.chunks {
+		onChunk(chunk)
+		response += chunk
+	}
+	if m.err != nil {
+		return PromptOutput{}, m.err
+	}
+	return PromptOutput{Response: response}, nil
+}
+
+func TestStream(t *testing.T) {
+	var chunks []string
+	onChunk := func(chunk string) { chunks = append(chunks, chunk) }
+
+	whole := &MockModel{}
+	whole.On("RunPrompt", mock.Anything, mock.Anything).Return(PromptOutput{Response: "All at once"}, nil)
+	output, err := Stream(context.Background(), whole, PromptInput{}, onChunk)
+	require.NoError(t, err)
+	assert.Equal(t, "All at once", output.Response)
+	assert.Equal(t, []string{"All at once"}, chunks, "models that cannot stream answer in one chunk")
+
+	chunks = nil
+	streaming := &chunkedModel{chunks: []string{"Hel", "lo"}}
+	output, err = Stream(context.Background(), streaming, PromptInput{}, onChunk)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", output.Response)
+	assert.Equal(t, []string{"Hel", "lo"}, chunks)
+}
+
+func TestPacedModel_StreamPrompt(t *testing.T) {
+	inner := &chunkedModel{chunks: []string{"Hel"}, err: &RateLimitError{Err: fmt.Errorf("API error 429")}}
+	inner.On("Name").Return("anthropic")
+	paced := NewPacedModel(inner, NewPacer(Quota{}))
+
+	var chunks []string
+	_, err := Stream(context.Background(), paced, PromptInput{}, func(chunk string) { chunks = append(chunks, chunk) })
+	require.Error(t, err)
+	assert.Equal(t, 1, inner.calls, "a response that has begun streaming is not retried")
+	assert.Equal(t, []string{"Hel"}, chunks)
+}
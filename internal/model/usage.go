@@ -0,0 +1,39 @@
+package model
+
+import "sync"
+
+// usage counts the tokens each model has used in this process
+var usage = struct {
+	mu     sync.Mutex
+	tokens map[string]int
+}{tokens: make(map[string]int)}
+
+// RecordUsage adds tokens used by a model, named as by Model.Name.
+// Providers call it for every completed request.
+func RecordUsage(name string, tokens int) {
+	if tokens <= 0 {
+		return
+	}
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+	usage.tokens[name] += tokens
+}
+
+// Usage returns the tokens used by each model so far
+func Usage() map[string]int {
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+
+	tokens := make(map[string]int, len(usage.tokens))
+	for name, count := range usage.tokens {
+		tokens[name] = count
+	}
+	return tokens
+}
+
+// ResetUsage clears the recorded usage
+func ResetUsage() {
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+	usage.tokens = make(map[string]int)
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsage(t *testing.T) {
+	ResetUsage()
+	defer ResetUsage()
+
+	RecordUsage("openai:gpt-4", 100)
+	RecordUsage("openai:gpt-4", 50)
+	RecordUsage("ollama:llama3", 0)
+
+	usage := Usage()
+	assert.Equal(t, map[string]int{"openai:gpt-4": 150}, usage)
+
+	usage["openai:gpt-4"] = 0
+	assert.Equal(t, 150, Usage()["openai:gpt-4"], "callers get a copy")
+}
@@ -0,0 +1,202 @@
+package outline
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// extractGo outlines a Go file: its package, functions and methods with
+// their signatures, types with their fields and methods, and exported
+// constants and variables. Unexported constants and variables are hidden.
+func extractGo(content string) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	line := func(node ast.Node) int { return fset.Position(node.Pos()).Line }
+	symbols := []Symbol{{
+		Kind:      KindPackage,
+		Name:      file.Name.Name,
+		Signature: "package " + file.Name.Name,
+		Doc:       firstLine(file.Doc.Text()),
+		Line:      fset.Position(file.Package).Line,
+		exported:  true,
+	}}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			kind := KindFunction
+			if decl.Recv != nil {
+				kind = KindMethod
+			}
+			signature := *decl
+			signature.Body = nil
+			signature.Doc = nil
+			symbol := Symbol{
+				Kind:      kind,
+				Name:      decl.Name.Name,
+				Signature: render(fset, &signature),
+				Doc:       firstLine(decl.Doc.Text()),
+				Line:      line(decl),
+				exported:  decl.Name.IsExported(),
+			}
+			symbol.start, symbol.end = goSpan(fset, decl.Doc, decl)
+			symbols = append(symbols, symbol)
+
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				symbols = append(symbols, goSpecSymbols(fset, decl, spec, line)...)
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// goSpecSymbols outlines one type, constant or variable declaration
+func goSpecSymbols(fset *token.FileSet, decl *ast.GenDecl, spec ast.Spec, line func(ast.Node) int) []Symbol {
+	doc := func(specDoc *ast.CommentGroup) string {
+		if text := firstLine(specDoc.Text()); text != "" {
+			return text
+		}
+		if len(decl.Specs) == 1 {
+			return firstLine(decl.Doc.Text())
+		}
+		return ""
+	}
+
+	// A declaration without parentheses is removed whole, a grouped one
+	// spec by spec
+	var start, end int
+	if decl.Lparen.IsValid() {
+		start, end = goSpan(fset, specDoc(spec), spec)
+	} else {
+		start, end = goSpan(fset, decl.Doc, decl)
+	}
+
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		symbol := Symbol{Kind: KindType, Name: spec.Name.Name, Doc: doc(spec.Doc), Line: line(spec),
+			start: start, end: end, exported: spec.Name.IsExported()}
+		switch typ := spec.Type.(type) {
+		case *ast.StructType:
+			symbol.Signature = "type " + spec.Name.Name + " struct"
+			return append([]Symbol{symbol}, goFieldSymbols(fset, typ.Fields, KindField, line)...)
+		case *ast.InterfaceType:
+			symbol.Kind = KindInterface
+			symbol.Signature = "type " + spec.Name.Name + " interface"
+			return append([]Symbol{symbol}, goFieldSymbols(fset, typ.Methods, KindMethod, line)...)
+		default:
+			typeSpec := *spec
+			typeSpec.Doc, typeSpec.Comment = nil, nil
+			symbol.Signature = "type " + render(fset, &typeSpec)
+			return []Symbol{symbol}
+		}
+
+	case *ast.ValueSpec:
+		kind := KindVariable
+		if decl.Tok == token.CONST {
+			kind = KindConstant
+		}
+		var symbols []Symbol
+		for _, name := range spec.Names {
+			signature := kind + " " + name.Name
+			if spec.Type != nil {
+				signature += " " + render(fset, spec.Type)
+			}
+			symbols = append(symbols, Symbol{Kind: kind, Name: name.Name, Signature: signature, Doc: doc(spec.Doc), Line: line(name),
+				start: start, end: end, exported: name.IsExported(), hidden: !name.IsExported()})
+		}
+		return symbols
+	}
+	return nil
+}
+
+// goFieldSymbols outlines the fields of a struct or the methods of an
+// interface
+func goFieldSymbols(fset *token.FileSet, fields *ast.FieldList, kind string, line func(ast.Node) int) []Symbol {
+	var symbols []Symbol
+	for _, field := range fields.List {
+		names := make([]string, len(field.Names))
+		for i, name := range field.Names {
+			names[i] = name.Name
+		}
+
+		var signature string
+		switch {
+		case kind == KindMethod && len(names) > 0:
+			signature = names[0] + strings.TrimPrefix(render(fset, field.Type), "func")
+		case len(names) > 0:
+			signature = strings.Join(names, ", ") + " " + render(fset, field.Type)
+		default:
+			// Embedded types
+			signature = render(fset, field.Type)
+		}
+
+		doc := firstLine(field.Doc.Text())
+		if doc == "" {
+			doc = firstLine(field.Comment.Text())
+		}
+		name := strings.Join(names, ", ")
+		exported := len(names) > 0
+		for _, name := range field.Names {
+			exported = exported && name.IsExported()
+		}
+		if len(names) == 0 {
+			// Embedded types are named by their type, less pointer and package
+			embedded := strings.TrimPrefix(signature, "*")
+			exported = ast.IsExported(embedded[strings.LastIndex(embedded, ".")+1:])
+		}
+		symbol := Symbol{
+			Kind:      kind,
+			Name:      name,
+			Signature: signature,
+			Doc:       doc,
+			Line:      line(field),
+			Depth:     1,
+			exported:  exported,
+		}
+		symbol.start, symbol.end = goSpan(fset, field.Doc, field)
+		if field.Comment != nil {
+			symbol.end = fset.Position(field.Comment.End()).Line
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// goSpan returns the lines a declaration spans, from its doc comment if it
+// has one
+func goSpan(fset *token.FileSet, doc *ast.CommentGroup, node ast.Node) (int, int) {
+	start := node.Pos()
+	if doc != nil {
+		start = doc.Pos()
+	}
+	return fset.Position(start).Line, fset.Position(node.End()).Line
+}
+
+// specDoc returns the doc comment of a type or value spec
+func specDoc(spec ast.Spec) *ast.CommentGroup {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		return spec.Doc
+	case *ast.ValueSpec:
+		return spec.Doc
+	}
+	return nil
+}
+
+// render prints a node on one line
+func render(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
@@ -0,0 +1,169 @@
+package outline
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	jsFunction  = regexp.MustCompile(`^(export\s+)?(default\s+)?(async\s+)?function\s*\*?\s*(\w+)`)
+	jsClass     = regexp.MustCompile(`^(export\s+)?(default\s+)?(abstract\s+)?class\s+(\w+)`)
+	jsArrow     = regexp.MustCompile(`^(export\s+)?(const|let|var)\s+(\w+)\s*(:[^=]+)?=\s*(async\s+)?(function\b|\([^)]*\)\s*(:\s*[^=]+)?=>|\w+\s*=>)`)
+	jsTypeDecl  = regexp.MustCompile(`^(export\s+)?(declare\s+)?(interface|type|enum)\s+(\w+)`)
+	jsMethod    = regexp.MustCompile(`^(public\s+|private\s+|protected\s+)?(static\s+)?(async\s+)?(get\s+|set\s+)?\*?(#?\w+)\s*(<[^>]*>)?\(`)
+	jsNotMethod = map[string]bool{"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true, "function": true, "super": true}
+)
+
+// extractJavaScript outlines a JavaScript or TypeScript file: its
+// functions, classes with their methods, and TypeScript interfaces, types
+// and enums, with their JSDoc summaries
+func extractJavaScript(content string) ([]Symbol, error) {
+	lines := strings.Split(content, "\n")
+	var symbols []Symbol
+
+	// classDepths are the brace depths of the class bodies being read
+	var classDepths []int
+	depth := 0
+	doc := ""
+	docStart := -1
+	inDoc := false
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		// Track JSDoc comments for the declaration that follows them
+		switch {
+		case inDoc:
+			if text := strings.TrimSpace(strings.TrimPrefix(trimmed, "*")); doc == "" && text != "" && !strings.HasPrefix(text, "@") && text != "/" {
+				doc = strings.TrimSpace(strings.TrimSuffix(text, "*/"))
+			}
+			if strings.Contains(trimmed, "*/") {
+				inDoc = false
+			}
+			continue
+		case strings.HasPrefix(trimmed, "/**"):
+			doc = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(trimmed, "/**"), "*/"))
+			docStart = i
+			inDoc = !strings.Contains(trimmed, "*/")
+			continue
+		}
+
+		for len(classDepths) > 0 && depth < classDepths[len(classDepths)-1] {
+			classDepths = classDepths[:len(classDepths)-1]
+		}
+		inClass := len(classDepths) > 0 && depth == classDepths[len(classDepths)-1]
+
+		symbol, ok := jsSymbol(trimmed, inClass)
+		if ok && (inClass || depth == 0) {
+			symbol.Line = i + 1
+			symbol.Doc = doc
+			symbol.Signature = signature(trimmed)
+			if inClass {
+				symbol.Depth = len(classDepths)
+			} else {
+				symbol.exported = strings.HasPrefix(trimmed, "export")
+			}
+			symbol.start, symbol.end = i+1, jsEnd(lines, i)+1
+			if docStart >= 0 {
+				symbol.start = docStart + 1
+			}
+			symbols = append(symbols, symbol)
+			if symbol.Kind == KindClass {
+				classDepths = append(classDepths, depth+1)
+			}
+		}
+		if trimmed != "" && !strings.HasPrefix(trimmed, "//") && !strings.HasPrefix(trimmed, "@") {
+			doc = ""
+			docStart = -1
+		}
+		depth += braceDelta(line)
+	}
+	return symbols, nil
+}
+
+// jsSymbol recognizes a declaration on a trimmed line
+func jsSymbol(line string, inClass bool) (Symbol, bool) {
+	if match := jsClass.FindStringSubmatch(line); match != nil {
+		return Symbol{Kind: KindClass, Name: match[4]}, true
+	}
+	if inClass {
+		if match := jsMethod.FindStringSubmatch(line); match != nil && !jsNotMethod[match[5]] {
+			private := strings.HasPrefix(match[5], "#") || strings.TrimSpace(match[1]) == "private"
+			return Symbol{Kind: KindMethod, Name: match[5], exported: !private}, true
+		}
+		return Symbol{}, false
+	}
+	if match := jsFunction.FindStringSubmatch(line); match != nil {
+		return Symbol{Kind: KindFunction, Name: match[4]}, true
+	}
+	if match := jsArrow.FindStringSubmatch(line); match != nil {
+		return Symbol{Kind: KindFunction, Name: match[3]}, true
+	}
+	if match := jsTypeDecl.FindStringSubmatch(line); match != nil {
+		kind := KindType
+		if match[3] == "interface" {
+			kind = KindInterface
+		}
+		return Symbol{Kind: kind, Name: match[4]}, true
+	}
+	return Symbol{}, false
+}
+
+// jsEnd returns the last line of the declaration starting on line start:
+// where the braces it opens close or, when it opens none, the line its
+// parentheses close on that does not continue onto the next
+func jsEnd(lines []string, start int) int {
+	braces, parens := 0, 0
+	opened := false
+	for i := start; i < len(lines); i++ {
+		braces += braceDelta(lines[i])
+		parens += bracketDelta(lines[i], "([", ")]")
+		opened = opened || braces > 0
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case opened && braces <= 0:
+			return i
+		case !opened && parens <= 0 && !strings.HasSuffix(trimmed, "=") && !strings.HasSuffix(trimmed, "=>") &&
+			!strings.HasSuffix(trimmed, ",") && !strings.HasSuffix(trimmed, "|"):
+			return i
+		case !opened && i-start >= maxSignatureLines:
+			return i
+		}
+	}
+	return len(lines) - 1
+}
+
+// braceDelta counts how a line changes the brace depth, ignoring braces in
+// strings and line comments
+func braceDelta(line string) int {
+	return bracketDelta(line, "{", "}")
+}
+
+// bracketDelta counts how a line changes the depth of the brackets in open
+// and close, ignoring those in strings and line comments
+func bracketDelta(line, open, close string) int {
+	delta := 0
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == '/' && strings.HasPrefix(line[i:], "//"):
+			return delta
+		case strings.ContainsRune(open, r):
+			delta++
+		case strings.ContainsRune(close, r):
+			delta--
+		}
+	}
+	return delta
+}
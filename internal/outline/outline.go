@@ -0,0 +1,200 @@
+// Package outline extracts the declarations of source files, so code can be
+// shown to models by its structure instead of its full text
+package outline
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Symbol kinds
+const (
+	KindPackage   = "package"
+	KindFunction  = "function"
+	KindMethod    = "method"
+	KindType      = "type"
+	KindClass     = "class"
+	KindField     = "field"
+	KindConstant  = "const"
+	KindVariable  = "var"
+	KindInterface = "interface"
+)
+
+// Symbol is one declaration in a file
+type Symbol struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+	Doc       string `json:"doc,omitempty"`   // First line of its doc comment
+	Line      int    `json:"line"`            // 1-based
+	Depth     int    `json:"depth,omitempty"` // Nesting, e.g. 1 for methods in a class
+
+	// The lines the declaration spans, its doc comment included, whether it
+	// is part of the file's public API, and whether it is left out of
+	// outlines, which only Select sees
+	start, end int
+	exported   bool
+	hidden     bool
+}
+
+// Exported reports whether the symbol is part of its file's public API, by
+// the rules of its language
+func (s Symbol) Exported() bool {
+	return s.exported
+}
+
+// extractors read the symbols of a language by file extension
+var extractors = map[string]func(content string) ([]Symbol, error){
+	".go":  extractGo,
+	".py":  extractPython,
+	".js":  extractJavaScript,
+	".jsx": extractJavaScript,
+	".mjs": extractJavaScript,
+	".cjs": extractJavaScript,
+	".ts":  extractJavaScript,
+	".tsx": extractJavaScript,
+}
+
+// Supported reports whether symbols are extracted for the file's language
+func Supported(path string) bool {
+	_, ok := extractors[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// Extract returns the symbols declared in a file, in order. Languages
+// without an extractor, and files that do not parse, are outlined by their
+// declaration-looking lines.
+func Extract(path, content string) []Symbol {
+	if extract, ok := extractors[strings.ToLower(filepath.Ext(path))]; ok {
+		if symbols, err := extract(content); err == nil {
+			outlined := symbols[:0]
+			for _, symbol := range symbols {
+				if !symbol.hidden {
+					outlined = append(outlined, symbol)
+				}
+			}
+			return outlined
+		}
+	}
+	return extractLines(content)
+}
+
+// Span is the lines a declaration covers, its doc comment included, and
+// how deeply it is nested
+type Span struct {
+	Start int `json:"start"` // 1-based
+	End   int `json:"end"`
+	Depth int `json:"depth"`
+}
+
+// Spans returns the lines of the declarations in a file, in order. Only
+// languages with an extractor have them; for the others, and for files that
+// do not parse, it returns an error.
+func Spans(filePath, content string) ([]Span, error) {
+	extract, ok := extractors[strings.ToLower(filepath.Ext(filePath))]
+	if !ok {
+		return nil, fmt.Errorf("declarations cannot be found in %s files", filepath.Ext(filePath))
+	}
+	symbols, err := extract(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+
+	var spans []Span
+	for _, symbol := range symbols {
+		if symbol.start == 0 {
+			continue
+		}
+		span := Span{Start: symbol.start, End: symbol.end, Depth: symbol.Depth}
+		// Names declared together, like var a, b = 1, 2, share a span
+		if n := len(spans); n > 0 && spans[n-1] == span {
+			continue
+		}
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
+
+// Format renders symbols one per line after their line number, indented by
+// depth and followed by their doc comment
+func Format(symbols []Symbol) string {
+	var outline strings.Builder
+	for _, symbol := range symbols {
+		outline.WriteString(fmt.Sprintf("%d: %s%s", symbol.Line, strings.Repeat("  ", symbol.Depth), symbol.Signature))
+		if symbol.Doc != "" {
+			outline.WriteString(" // " + symbol.Doc)
+		}
+		outline.WriteString("\n")
+	}
+	return outline.String()
+}
+
+// Render returns the outline of a file, or an empty string when it
+// declares nothing
+func Render(path, content string) string {
+	return Format(Extract(path, content))
+}
+
+// declarationPattern matches lines that declare functions, types and
+// classes in the common languages
+var declarationPattern = regexp.MustCompile(`^\s*(export\s+)?(default\s+)?(pub(\([a-z]+\))?\s+)?(async\s+)?` +
+	`(package|func|type|def|class|function|interface|struct|enum|trait|impl|fn|module)\b`)
+
+// extractLines outlines a file by the lines that look like declarations
+func extractLines(content string) []Symbol {
+	var symbols []Symbol
+	for i, line := range strings.Split(content, "\n") {
+		if !declarationPattern.MatchString(line) {
+			continue
+		}
+		trimmed := strings.TrimLeft(line, " \t")
+		symbols = append(symbols, Symbol{
+			Kind:      strings.Fields(declarationPattern.FindString(line))[0],
+			Signature: signature(trimmed),
+			Line:      i + 1,
+			Depth:     indentDepth(line[:len(line)-len(trimmed)]),
+		})
+	}
+	return symbols
+}
+
+// signature trims a declaration line to its signature
+func signature(line string) string {
+	line = strings.TrimRight(line, " \t\r")
+	line = strings.TrimSuffix(line, "{")
+	return strings.TrimRight(line, " ")
+}
+
+// indentDepth converts leading whitespace into a nesting depth, counting a
+// tab or up to four spaces as one level
+func indentDepth(indent string) int {
+	depth, spaces := 0, 0
+	for _, r := range indent {
+		if r == '\t' {
+			depth++
+			spaces = 0
+			continue
+		}
+		spaces++
+		if spaces == 4 {
+			depth++
+			spaces = 0
+		}
+	}
+	if spaces > 0 {
+		depth++
+	}
+	return depth
+}
+
+// firstLine returns the first non-empty line of a doc comment
+func firstLine(doc string) string {
+	for _, line := range strings.Split(doc, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package outline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender_Go(t *testing.T) {
+	source := `// Package store keeps entries
+package store
+
+import "sync"
+
+// MaxEntries bounds a store
+const MaxEntries = 100
+
+const internal = 1
+
+// Store keeps entries in memory
+type Store struct {
+	mu      sync.Mutex
+	entries map[string]string // by key
+}
+
+// Getter reads entries
+type Getter interface {
+	Get(key string) (string, bool)
+}
+
+type ID string
+
+// Get returns the entry for key
+func (s *Store) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.entries[key]
+	return value, ok
+}
+
+func New() *Store { return &Store{} }
+`
+	assert.Equal(t, `2: package store // Package store keeps entries
+7: const MaxEntries // MaxEntries bounds a store
+12: type Store struct // Store keeps entries in memory
+13:   mu sync.Mutex
+14:   entries map[string]string // by key
+18: type Getter interface // Getter reads entries
+19:   Get(key string) (string, bool)
+22: type ID string
+25: func (s *Store) Get(key string) (string, bool) // Get returns the entry for key
+32: func New() *Store
+`, Render("store.go", source))
+}
+
+func TestRender_Python(t *testing.T) {
+	source := `import os
+
+
+class Cache(Base):
+    """Caches values by key."""
+
+    def get(self, key,
+            default=None):
+        """
+        Return the value for key.
+        """
+        def helper():
+            pass
+        return helper()
+
+    async def refresh(self):
+        pass
+
+
+def load(path: str) -> Cache:
+    '''Load a cache from disk.'''
+    return Cache()
+`
+	assert.Equal(t, `4: class Cache(Base): // Caches values by key.
+7:   def get(self, key, default=None): // Return the value for key.
+16:   async def refresh(self):
+20: def load(path: str) -> Cache: // Load a cache from disk.
+`, Render("cache.py", source))
+}
+
+func TestRender_JavaScript(t *testing.T) {
+	source := `import React from "react";
+
+/**
+ * Renders the user list.
+ * @param props the users
+ */
+export default function UserList(props) {
+  const label = "{not a brace";
+  return null;
+}
+
+/** A store of users */
+export class UserStore extends Store {
+  static create() {
+    if (ready) {
+      return new UserStore();
+    }
+  }
+
+  async load(id: string): Promise<User> {
+    return fetch(id);
+  }
+}
+
+export const fetchUser = async (id) => {
+  return api.get(id);
+};
+
+export interface User {
+  id: string;
+}
+`
+	assert.Equal(t, `7: export default function UserList(props) // Renders the user list.
+13: export class UserStore extends Store // A store of users
+14:   static create()
+20:   async load(id: string): Promise<User>
+25: export const fetchUser = async (id) =>
+29: export interface User
+`, Render("users.ts", source))
+}
+
+func TestRender_Fallback(t *testing.T) {
+	source := "pub struct Point {\n    x: i32,\n}\n\nimpl Point {\n    pub fn new() -> Self {\n        Point { x: 0 }\n    }\n}\n"
+	assert.Equal(t, "1: pub struct Point\n5: impl Point\n6:   pub fn new() -> Self\n", Render("point.rs", source))
+
+	// Files that do not parse are outlined by their declaration lines
+	assert.Equal(t, "1: package broken\n3: func f(\n", Render("broken.go", "package broken\n\nfunc f(\n"))
+
+	assert.Empty(t, Render("notes.txt", "just some text\nwithout declarations\n"))
+}
+
+func TestSupported(t *testing.T) {
+	assert.True(t, Supported("main.go"))
+	assert.True(t, Supported("App.TSX"))
+	assert.False(t, Supported("main.rs"))
+}
+
+func TestSpans(t *testing.T) {
+	source := "package store\n\n// A and b share a line\nvar A, b = 1, 2\n\ntype T struct {\n\tX int\n}\n"
+	spans, err := Spans("store.go", source)
+	assert.NoError(t, err)
+	assert.Equal(t, []Span{{Start: 3, End: 4}, {Start: 6, End: 8}, {Start: 7, End: 7, Depth: 1}}, spans)
+
+	_, err = Spans("main.rs", "fn main() {}\n")
+	assert.Error(t, err)
+}
@@ -0,0 +1,133 @@
+package outline
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	pythonDef   = regexp.MustCompile(`^(\s*)(async\s+)?def\s+(\w+)`)
+	pythonClass = regexp.MustCompile(`^(\s*)class\s+(\w+)`)
+)
+
+// maxSignatureLines bounds how far a signature split over lines is followed
+const maxSignatureLines = 10
+
+// extractPython outlines a Python file: its classes, and the functions and
+// methods outside other functions, with their docstrings
+func extractPython(content string) ([]Symbol, error) {
+	lines := strings.Split(content, "\n")
+
+	// scopes are the indentation of the enclosing definitions, and whether
+	// each is a function
+	type scope struct {
+		indent   int
+		function bool
+	}
+	var scopes []scope
+	var symbols []Symbol
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		for len(scopes) > 0 && scopes[len(scopes)-1].indent >= indent {
+			scopes = scopes[:len(scopes)-1]
+		}
+
+		var symbol Symbol
+		isFunction := false
+		if match := pythonDef.FindStringSubmatch(line); match != nil {
+			isFunction = true
+			symbol = Symbol{Kind: KindFunction, Name: match[3]}
+			if len(scopes) > 0 && !scopes[len(scopes)-1].function {
+				symbol.Kind = KindMethod
+			}
+		} else if match := pythonClass.FindStringSubmatch(line); match != nil {
+			symbol = Symbol{Kind: KindClass, Name: match[2]}
+		} else {
+			continue
+		}
+
+		inFunction := false
+		for _, enclosing := range scopes {
+			inFunction = inFunction || enclosing.function
+		}
+		scopes = append(scopes, scope{indent: indent, function: isFunction})
+		if inFunction {
+			// Helpers nested in functions are implementation detail
+			continue
+		}
+
+		// Follow signatures split over lines to the colon that ends them
+		end := i
+		header := strings.TrimSpace(line)
+		for !strings.HasSuffix(header, ":") && end+1 < len(lines) && end-i < maxSignatureLines {
+			end++
+			header += " " + strings.TrimSpace(lines[end])
+		}
+
+		symbol.Signature = strings.Join(strings.Fields(header), " ")
+		symbol.Line = i + 1
+		symbol.Depth = len(scopes) - 1
+		symbol.Doc = pythonDocstring(lines, end+1)
+		symbol.start, symbol.end = pythonSpan(lines, i, end, indent)
+		symbol.exported = pythonExported(symbol.Name)
+		symbols = append(symbols, symbol)
+		i = end
+	}
+	return symbols, nil
+}
+
+// pythonSpan returns the lines of the definition on line def, whose header
+// ends on line header: its decorators above and its indented body below
+func pythonSpan(lines []string, def, header, indent int) (int, int) {
+	start := def
+	for start > 0 && strings.HasPrefix(strings.TrimSpace(lines[start-1]), "@") {
+		start--
+	}
+	end := header
+	for i := header + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" {
+			continue
+		}
+		if len(lines[i])-len(strings.TrimLeft(lines[i], " \t")) <= indent && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		end = i
+	}
+	return start + 1, end + 1
+}
+
+// pythonExported reports whether a name is public by Python convention:
+// it has no leading underscore, or is a special method like __init__
+func pythonExported(name string) bool {
+	return !strings.HasPrefix(name, "_") || (strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__"))
+}
+
+// pythonDocstring returns the first line of the docstring starting at or
+// after line start, if the body opens with one
+func pythonDocstring(lines []string, start int) string {
+	for i := start; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		for _, prefix := range []string{"r\"\"\"", "\"\"\"", "r'''", "'''"} {
+			if !strings.HasPrefix(line, prefix) {
+				continue
+			}
+			quote := prefix[len(prefix)-3:]
+			text := strings.TrimSuffix(strings.TrimPrefix(line, prefix), quote)
+			if strings.TrimSpace(text) == "" && i+1 < len(lines) {
+				text = strings.TrimSuffix(strings.TrimSpace(lines[i+1]), quote)
+			}
+			return strings.TrimSpace(text)
+		}
+		return ""
+	}
+	return ""
+}
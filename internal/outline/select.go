@@ -0,0 +1,109 @@
+package outline
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Selection picks the declarations of a file to keep. Patterns are
+// path.Match patterns, such as "*_test" or "Deprecated*", matched against
+// symbol names.
+type Selection struct {
+	// Include, when not empty, keeps only the top-level declarations
+	// matching one of its patterns, with everything declared inside them
+	Include []string
+	// Exclude drops the declarations matching one of its patterns, at any
+	// depth
+	Exclude []string
+	// OnlyExported drops the declarations that are not part of the file's
+	// public API, by the rules of its language
+	OnlyExported bool
+}
+
+// Empty reports whether the selection keeps everything
+func (s Selection) Empty() bool {
+	return len(s.Include) == 0 && len(s.Exclude) == 0 && !s.OnlyExported
+}
+
+// Validate checks the selection's patterns
+func (s Selection) Validate() error {
+	for _, pattern := range append(append([]string{}, s.Include...), s.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid symbol pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// keeps reports whether the selection keeps a symbol
+func (s Selection) keeps(symbol Symbol) bool {
+	if symbol.Kind == KindPackage {
+		return true
+	}
+	if s.OnlyExported && !symbol.exported {
+		return false
+	}
+	if matchesAny(s.Exclude, symbol.Name) {
+		return false
+	}
+	return symbol.Depth > 0 || len(s.Include) == 0 || matchesAny(s.Include, symbol.Name)
+}
+
+// matchesAny reports whether name matches one of patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the content of a file without the declarations the
+// selection drops, and the names of those it dropped. Only languages with
+// an extractor can be filtered; for the others, and for files that do not
+// parse, it returns an error rather than the unfiltered content.
+func Select(filePath, content string, selection Selection) (string, []string, error) {
+	if selection.Empty() {
+		return content, nil, nil
+	}
+	extract, ok := extractors[strings.ToLower(filepath.Ext(filePath))]
+	if !ok {
+		return "", nil, fmt.Errorf("symbols cannot be selected in %s files", filepath.Ext(filePath))
+	}
+	symbols, err := extract(content)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+
+	// A declaration shared by a kept symbol, like var A, b = 1, 2, stays
+	kept := make(map[[2]int]bool)
+	for _, symbol := range symbols {
+		if selection.keeps(symbol) {
+			kept[[2]int{symbol.start, symbol.end}] = true
+		}
+	}
+
+	lines := strings.Split(content, "\n")
+	drop := make([]bool, len(lines)+1)
+	var dropped []string
+	for _, symbol := range symbols {
+		if selection.keeps(symbol) || symbol.start == 0 || kept[[2]int{symbol.start, symbol.end}] {
+			continue
+		}
+		for line := symbol.start; line <= symbol.end && line <= len(lines); line++ {
+			drop[line] = true
+		}
+		dropped = append(dropped, symbol.Name)
+	}
+
+	selected := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if !drop[i+1] {
+			selected = append(selected, line)
+		}
+	}
+	return strings.Join(selected, "\n"), dropped, nil
+}
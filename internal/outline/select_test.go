@@ -0,0 +1,143 @@
+package outline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect_Go(t *testing.T) {
+	source := `package store
+
+const (
+	// MaxEntries bounds a store
+	MaxEntries = 100
+	internal   = 1
+)
+
+// Store keeps entries
+type Store struct {
+	Name    string
+	entries map[string]string // by key
+}
+
+// Get returns the entry for key
+func (s *Store) Get(key string) string {
+	return s.entries[key]
+}
+
+// Deprecated: use Get
+func (s *Store) Lookup(key string) string { return s.Get(key) }
+
+func newStore() *Store { return &Store{} }
+`
+	selected, dropped, err := Select("store.go", source, Selection{OnlyExported: true, Exclude: []string{"Lookup"}})
+	require.NoError(t, err)
+	assert.Equal(t, `package store
+
+const (
+	// MaxEntries bounds a store
+	MaxEntries = 100
+)
+
+// Store keeps entries
+type Store struct {
+	Name    string
+}
+
+// Get returns the entry for key
+func (s *Store) Get(key string) string {
+	return s.entries[key]
+}
+
+
+`, selected)
+	assert.Equal(t, []string{"internal", "entries", "Lookup", "newStore"}, dropped)
+
+	selected, _, err = Select("store.go", source, Selection{Include: []string{"Store"}})
+	require.NoError(t, err)
+	assert.Contains(t, selected, "entries map[string]string", "members of included declarations stay")
+	assert.NotContains(t, selected, "MaxEntries")
+	assert.NotContains(t, selected, "func (s *Store) Get", "methods are top-level declarations in Go")
+}
+
+func TestSelect_Python(t *testing.T) {
+	source := `class Cache:
+    """Keeps values."""
+
+    def __init__(self):
+        self.values = {}
+
+    @property
+    def _size(self):
+        return len(self.values)
+
+
+def test_cache():
+    assert Cache()
+`
+	selected, dropped, err := Select("cache.py", source, Selection{OnlyExported: true, Exclude: []string{"test_*"}})
+	require.NoError(t, err)
+	assert.Equal(t, `class Cache:
+    """Keeps values."""
+
+    def __init__(self):
+        self.values = {}
+
+
+
+`, selected)
+	assert.Equal(t, []string{"_size", "test_cache"}, dropped)
+}
+
+func TestSelect_JavaScript(t *testing.T) {
+	source := `/** Adds numbers */
+export function add(a, b) {
+  return a + b;
+}
+
+const helper = (x) =>
+  x * 2;
+
+export class Counter {
+  #count = 0;
+  private reset() {
+    this.#count = 0;
+  }
+  increment() {
+    this.#count++;
+  }
+}
+`
+	selected, dropped, err := Select("math.ts", source, Selection{OnlyExported: true})
+	require.NoError(t, err)
+	assert.Equal(t, `/** Adds numbers */
+export function add(a, b) {
+  return a + b;
+}
+
+
+export class Counter {
+  #count = 0;
+  increment() {
+    this.#count++;
+  }
+}
+`, selected)
+	assert.Equal(t, []string{"helper", "reset"}, dropped)
+}
+
+func TestSelect_Unsupported(t *testing.T) {
+	content, dropped, err := Select("notes.txt", "text", Selection{})
+	require.NoError(t, err)
+	assert.Equal(t, "text", content, "an empty selection keeps any file")
+	assert.Nil(t, dropped)
+
+	_, _, err = Select("main.rs", "fn main() {}", Selection{OnlyExported: true})
+	assert.ErrorContains(t, err, "symbols cannot be selected in .rs files")
+	_, _, err = Select("main.go", "not go", Selection{OnlyExported: true})
+	assert.ErrorContains(t, err, "failed to parse main.go")
+
+	assert.ErrorContains(t, Selection{Exclude: []string{"[a-"}}.Validate(), `invalid symbol pattern "[a-"`)
+}
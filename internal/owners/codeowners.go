@@ -0,0 +1,131 @@
+// Package owners finds the people responsible for code, from CODEOWNERS
+// and git blame, so findings and proposals can be routed to reviewers.
+package owners
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codeownersPaths are where CODEOWNERS files are looked for, in the order
+// GitHub does
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners is a parsed CODEOWNERS file
+type CodeOwners struct {
+	Path  string // File the rules were read from, relative to the root
+	rules []rule
+}
+
+// rule is one CODEOWNERS line
+type rule struct {
+	pattern string
+	match   *regexp.Regexp
+	owners  []string // Empty for paths explicitly left without owners
+}
+
+// LoadCodeOwners reads the CODEOWNERS file of the repository at root. It
+// returns nil when there is none.
+func LoadCodeOwners(root string) (*CodeOwners, error) {
+	for _, path := range codeownersPaths {
+		file, err := os.Open(filepath.Join(root, filepath.FromSlash(path)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		codeowners, err := ParseCodeOwners(file)
+		if err != nil {
+			return nil, err
+		}
+		codeowners.Path = path
+		return codeowners, nil
+	}
+	return nil, nil
+}
+
+// ParseCodeOwners parses CODEOWNERS rules. Comments, blank lines and
+// GitLab section headers are skipped.
+func ParseCodeOwners(r io.Reader) (*CodeOwners, error) {
+	codeowners := &CodeOwners{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		pattern := strings.ReplaceAll(fields[0], `\#`, "#")
+		match, err := compilePattern(pattern)
+		if err != nil {
+			continue
+		}
+		codeowners.rules = append(codeowners.rules, rule{pattern: pattern, match: match, owners: fields[1:]})
+	}
+	return codeowners, scanner.Err()
+}
+
+// Owners returns the owners of a path relative to the repository root.
+// The last matching rule wins, as on GitHub.
+func (c *CodeOwners) Owners(path string) []string {
+	if c == nil {
+		return nil
+	}
+	path = strings.TrimPrefix(filepath.ToSlash(path), "./")
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].match.MatchString(path) {
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// compilePattern turns a gitignore-style CODEOWNERS pattern into a regular
+// expression. Patterns with a slash other than a trailing one are anchored
+// at the root; others match at any depth. A pattern naming a directory
+// matches everything below it, except that a trailing /* matches only the
+// directory's own files.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	shallow := strings.HasSuffix(pattern, "/*")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	if !shallow {
+		expr.WriteString("(?:/.*)?")
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
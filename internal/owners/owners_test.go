@@ -0,0 +1,99 @@
+package owners
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/git"
+)
+
+const codeownersFile = `# Default owners
+*                   @org/maintainers
+
+*.js                @org/frontend
+/docs/              @org/docs
+apps/*              @org/apps-top
+**/migrations/      @org/db  # schema changes
+/internal/cli/      @alice bob@example.com
+/internal/cli/gen/
+[Section]
+`
+
+func TestParseCodeOwners(t *testing.T) {
+	codeowners, err := ParseCodeOwners(strings.NewReader(codeownersFile))
+	require.NoError(t, err)
+
+	for path, want := range map[string][]string{
+		"README.md":                    {"@org/maintainers"},
+		"web/app.js":                   {"@org/frontend"},
+		"docs/guide/intro.md":          {"@org/docs"},
+		"apps/main.go":                 {"@org/apps-top"},
+		"apps/api/main.go":             {"@org/maintainers"},
+		"services/db/migrations/1.sql": {"@org/db"},
+		"internal/cli/review.go":       {"@alice", "bob@example.com"},
+		"./internal/cli/commit.go":     {"@alice", "bob@example.com"},
+		"internal/cli/gen/out.go":      {},
+	} {
+		assert.Equal(t, want, codeowners.Owners(path), path)
+	}
+
+	var none *CodeOwners
+	assert.Nil(t, none.Owners("main.go"))
+}
+
+func TestLoadCodeOwners(t *testing.T) {
+	root := t.TempDir()
+	codeowners, err := LoadCodeOwners(root)
+	require.NoError(t, err)
+	assert.Nil(t, codeowners)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".github"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".github", "CODEOWNERS"), []byte("* @team\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "CODEOWNERS"), []byte("* @other\n"), 0600))
+	codeowners, err = LoadCodeOwners(root)
+	require.NoError(t, err)
+	assert.Equal(t, ".github/CODEOWNERS", codeowners.Path)
+	assert.Equal(t, []string{"@team"}, codeowners.Owners("main.go"))
+}
+
+func TestResolver_Owners(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "CODEOWNERS"), []byte("/owned/ @team\n"), 0600))
+	resolver, err := NewResolver(root)
+	require.NoError(t, err)
+
+	var blamed []string
+	resolver.blame = func(path string, start, end int) ([]git.BlameAuthor, error) {
+		blamed = append(blamed, path)
+		if start > 10 {
+			return nil, errors.New("file has 10 lines")
+		}
+		return []git.BlameAuthor{
+			{Name: "Ann", Email: "ann@example.com", Lines: 5},
+			{Name: "Bo", Email: "bo@example.com", Lines: 3},
+			{Name: "Cy", Email: "cy@example.com", Lines: 1},
+		}, nil
+	}
+
+	assert.Equal(t, []string{"@team"}, resolver.Owners(filepath.Join(root, "owned", "a.go"), 3, 3))
+	assert.Empty(t, blamed, "CODEOWNERS owners are used before blame")
+
+	want := []string{"Ann <ann@example.com>", "Bo <bo@example.com>"}
+	assert.Equal(t, want, resolver.Owners(filepath.Join(root, "lib", "b.go"), 3, 4))
+	assert.Equal(t, want, resolver.Owners(filepath.Join(root, "lib", "b.go"), 3, 4))
+	assert.Equal(t, []string{"lib/b.go"}, blamed, "blame is cached")
+
+	assert.Equal(t, want, resolver.Owners(filepath.Join(root, "lib", "b.go"), 20, 0), "lines past the end blame the file")
+	assert.Nil(t, resolver.Owners(filepath.Join(filepath.Dir(root), "elsewhere.go"), 1, 1))
+}
+
+func TestReviewers(t *testing.T) {
+	reviewers := Reviewers([][]string{{"@b", "@a"}, {"@b"}, nil})
+	assert.Equal(t, []Reviewer{{Owner: "@b", Findings: 2}, {Owner: "@a", Findings: 1}}, reviewers)
+}
@@ -0,0 +1,134 @@
+package owners
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// maxBlameOwners is how many of the top blame authors are named as owners
+const maxBlameOwners = 2
+
+// Resolver finds the likely owners of code: the CODEOWNERS owners of a
+// path, or else the people who last changed the lines in question
+type Resolver struct {
+	root       string
+	codeowners *CodeOwners
+	blame      func(path string, start, end int) ([]git.BlameAuthor, error)
+
+	mu    sync.Mutex
+	cache map[string][]string
+}
+
+// Reviewer is an owner and how many findings they own
+type Reviewer struct {
+	Owner    string `json:"owner"`
+	Findings int    `json:"findings"`
+}
+
+// NewResolver creates a resolver for the repository at root, reading its
+// CODEOWNERS file when it has one
+func NewResolver(root string) (*Resolver, error) {
+	codeowners, err := LoadCodeOwners(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CODEOWNERS: %w", err)
+	}
+	repo := &git.Repository{Path: root}
+	return &Resolver{
+		root:       root,
+		codeowners: codeowners,
+		blame:      repo.Blame,
+		cache:      make(map[string][]string),
+	}, nil
+}
+
+// Owners returns the owners of lines start to end of a file: its
+// CODEOWNERS owners, or else the top authors of those lines by git blame,
+// as name <email>. A start of 0 means the whole file. Paths may be
+// absolute or relative to the working directory.
+func (r *Resolver) Owners(path string, start, end int) []string {
+	rel := r.relative(path)
+	if rel == "" {
+		return nil
+	}
+	if owners := r.codeowners.Owners(rel); owners != nil {
+		return owners
+	}
+
+	key := fmt.Sprintf("%s:%d-%d", rel, start, end)
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok {
+		return cached
+	}
+
+	authors, err := r.blame(rel, start, end)
+	if err != nil && start > 0 {
+		// Lines past the end of the file, or lines the finding misplaced
+		authors, err = r.blame(rel, 0, 0)
+	}
+	if err != nil {
+		logger.Debug("failed to blame file for owners", "path", rel, "error", err)
+	}
+
+	var owners []string
+	for i, author := range authors {
+		if i == maxBlameOwners {
+			break
+		}
+		owners = append(owners, fmt.Sprintf("%s <%s>", author.Name, author.Email))
+	}
+	r.mu.Lock()
+	r.cache[key] = owners
+	r.mu.Unlock()
+	return owners
+}
+
+// relative returns a path relative to the repository root, or "" when it
+// is outside it
+func (r *Resolver) relative(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	root := r.root
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Reviewers counts the findings each owner owns, most first
+func Reviewers(ownerLists [][]string) []Reviewer {
+	counts := make(map[string]int)
+	for _, owners := range ownerLists {
+		for _, owner := range owners {
+			counts[owner]++
+		}
+	}
+
+	reviewers := make([]Reviewer, 0, len(counts))
+	for owner, count := range counts {
+		reviewers = append(reviewers, Reviewer{Owner: owner, Findings: count})
+	}
+	sort.Slice(reviewers, func(i, j int) bool {
+		if reviewers[i].Findings != reviewers[j].Findings {
+			return reviewers[i].Findings > reviewers[j].Findings
+		}
+		return reviewers[i].Owner < reviewers[j].Owner
+	})
+	return reviewers
+}
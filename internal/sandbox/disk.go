@@ -0,0 +1,215 @@
+package sandbox
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// SandboxDir is where sandbox worktrees are created
+const SandboxDir = ".sigil/sandbox"
+
+// DiskConfiguration limits the disk space sandboxes use, in bytes. A
+// negative limit turns it off.
+type DiskConfiguration struct {
+	WorktreeQuota int64 `yaml:"worktree_quota"`
+	TotalCap      int64 `yaml:"total_cap"`
+}
+
+// DefaultDiskConfiguration returns the default disk limits
+func DefaultDiskConfiguration() DiskConfiguration {
+	return DiskConfiguration{
+		WorktreeQuota: 2 * 1024 * 1024 * 1024,  // 2GB per worktree
+		TotalCap:      10 * 1024 * 1024 * 1024, // 10GB for all worktrees
+	}
+}
+
+// withDefaults fills in the limits left unset
+func (d DiskConfiguration) withDefaults() DiskConfiguration {
+	defaults := DefaultDiskConfiguration()
+	if d.WorktreeQuota == 0 {
+		d.WorktreeQuota = defaults.WorktreeQuota
+	}
+	if d.TotalCap == 0 {
+		d.TotalCap = defaults.TotalCap
+	}
+	return d
+}
+
+// LoadDiskConfiguration returns the disk limits of the project in the
+// working directory
+func LoadDiskConfiguration() DiskConfiguration {
+	config, err := loadProjectConfig()
+	if err != nil {
+		return DefaultDiskConfiguration()
+	}
+	return config.Disk.withDefaults()
+}
+
+// WorktreeUsage is the disk space one worktree uses
+type WorktreeUsage struct {
+	ID       string    `json:"id"`
+	Path     string    `json:"path"`
+	Bytes    int64     `json:"bytes"`
+	Modified time.Time `json:"modified"`
+}
+
+// DiskUsage is the disk space the sandbox worktrees use, largest first
+type DiskUsage struct {
+	Worktrees []WorktreeUsage `json:"worktrees"`
+	Total     int64           `json:"total_bytes"`
+}
+
+// MeasureDiskUsage measures the worktrees in a sandbox directory, which
+// may not exist yet
+func MeasureDiskUsage(dir string) (DiskUsage, error) {
+	usage := DiskUsage{Worktrees: []WorktreeUsage{}}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return usage, nil
+		}
+		return usage, errors.Wrap(err, errors.ErrorTypeFS, "MeasureDiskUsage", "failed to read sandbox directory")
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		bytes, modified, err := dirSize(path)
+		if err != nil {
+			return usage, errors.Wrap(err, errors.ErrorTypeFS, "MeasureDiskUsage",
+				fmt.Sprintf("failed to measure worktree %s", entry.Name()))
+		}
+		usage.Worktrees = append(usage.Worktrees, WorktreeUsage{ID: entry.Name(), Path: path, Bytes: bytes, Modified: modified})
+		usage.Total += bytes
+	}
+	sort.Slice(usage.Worktrees, func(i, j int) bool {
+		return usage.Worktrees[i].Bytes > usage.Worktrees[j].Bytes
+	})
+	return usage, nil
+}
+
+// dirSize returns the bytes the files under path use and when the most
+// recently changed one was modified
+func dirSize(path string) (int64, time.Time, error) {
+	var size int64
+	var modified time.Time
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Files removed while walking no longer count
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.ModTime().After(modified) {
+			modified = info.ModTime()
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, modified, err
+}
+
+// FormatBytes formats a byte count for people to read
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%dB", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// checkQuota fails when a worktree uses more than the per-worktree quota
+func (e *Executor) checkQuota(worktree *Worktree) error {
+	quota := e.config.Disk.WorktreeQuota
+	if quota <= 0 {
+		return nil
+	}
+	bytes, _, err := dirSize(worktree.Path)
+	if err != nil {
+		logger.Warn("failed to measure worktree", "id", worktree.ID, "error", err)
+		return nil
+	}
+	if bytes > quota {
+		return errors.New(errors.ErrorTypeValidation, "checkQuota",
+			fmt.Sprintf("worktree %s uses %s, over its %s quota", worktree.ID, FormatBytes(bytes), FormatBytes(quota))).
+			WithHint("raise disk.worktree_quota in .sigil/project.yml")
+	}
+	return nil
+}
+
+// enforceCap removes the oldest worktrees not in use by this process, and
+// the largest first among equally old ones, until the sandbox directory
+// is back under the total cap
+func (wm *WorktreeManager) enforceCap() error {
+	if wm.totalCap <= 0 {
+		return nil
+	}
+	usage, err := MeasureDiskUsage(wm.baseDir)
+	if err != nil {
+		return err
+	}
+	if usage.Total <= wm.totalCap {
+		return nil
+	}
+
+	candidates := make([]WorktreeUsage, 0, len(usage.Worktrees))
+	for _, worktree := range usage.Worktrees {
+		if _, active := wm.worktrees[worktree.ID]; !active {
+			candidates = append(candidates, worktree)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Modified.Before(candidates[j].Modified)
+	})
+
+	total := usage.Total
+	for _, candidate := range candidates {
+		if total <= wm.totalCap {
+			break
+		}
+		wm.worktrees[candidate.ID] = &Worktree{
+			ID:      candidate.ID,
+			Path:    candidate.Path,
+			Branch:  fmt.Sprintf("sigil-sandbox-%s", candidate.ID),
+			manager: wm,
+		}
+		if err := wm.CleanupWorktree(candidate.ID); err != nil {
+			delete(wm.worktrees, candidate.ID)
+			logger.Warn("failed to remove worktree over the disk cap", "id", candidate.ID, "error", err)
+			continue
+		}
+		total -= candidate.Bytes
+		logger.Info("removed worktree over the disk cap", "id", candidate.ID, "bytes", candidate.Bytes)
+	}
+
+	if total > wm.totalCap {
+		return errors.New(errors.ErrorTypeFS, "enforceCap",
+			fmt.Sprintf("sandboxes use %s, over the %s cap", FormatBytes(total), FormatBytes(wm.totalCap))).
+			WithHint("clean up with sigil sandbox clean --all or raise disk.total_cap in .sigil/project.yml")
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/git"
+)
+
+// writeWorktree creates a fake worktree of size bytes modified at modified
+func writeWorktree(t *testing.T, dir, id string, size int, modified time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, id, "data.bin")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+	require.NoError(t, os.Chtimes(path, modified, modified))
+}
+
+func TestMeasureDiskUsage(t *testing.T) {
+	usage, err := MeasureDiskUsage(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, usage.Worktrees)
+	assert.Zero(t, usage.Total)
+
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	writeWorktree(t, dir, "small", 100, now)
+	writeWorktree(t, dir, "large", 300, now.Add(-time.Hour))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stray.txt"), []byte("x"), 0644))
+
+	usage, err = MeasureDiskUsage(dir)
+	require.NoError(t, err)
+	require.Len(t, usage.Worktrees, 2)
+	assert.Equal(t, "large", usage.Worktrees[0].ID)
+	assert.Equal(t, int64(300), usage.Worktrees[0].Bytes)
+	assert.WithinDuration(t, now, usage.Worktrees[1].Modified, time.Second)
+	assert.Equal(t, int64(400), usage.Total)
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512B", FormatBytes(512))
+	assert.Equal(t, "1.5KB", FormatBytes(1536))
+	assert.Equal(t, "2.0GB", FormatBytes(DefaultDiskConfiguration().WorktreeQuota))
+}
+
+func TestDiskConfiguration_WithDefaults(t *testing.T) {
+	disk := DiskConfiguration{WorktreeQuota: -1}.withDefaults()
+	assert.Equal(t, int64(-1), disk.WorktreeQuota)
+	assert.Equal(t, DefaultDiskConfiguration().TotalCap, disk.TotalCap)
+}
+
+func TestExecutor_CheckQuota(t *testing.T) {
+	dir := t.TempDir()
+	writeWorktree(t, dir, "wt", 2048, time.Now())
+	worktree := &Worktree{ID: "wt", Path: filepath.Join(dir, "wt")}
+
+	executor := &Executor{config: ExecutorConfig{Disk: DiskConfiguration{WorktreeQuota: 1024}}}
+	assert.ErrorContains(t, executor.checkQuota(worktree), "worktree wt uses 2.0KB, over its 1.0KB quota")
+
+	executor.config.Disk.WorktreeQuota = 4096
+	assert.NoError(t, executor.checkQuota(worktree))
+
+	executor.config.Disk.WorktreeQuota = -1
+	assert.NoError(t, executor.checkQuota(worktree))
+}
+
+func TestWorktreeManager_EnforceCap(t *testing.T) {
+	// The fake worktrees are not registered with git, so removing them
+	// falls back to deleting the directories
+	repo, err := git.NewRepository(".")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	now := time.Now()
+	writeWorktree(t, dir, "oldest", 400, now.Add(-3*time.Hour))
+	writeWorktree(t, dir, "older", 400, now.Add(-2*time.Hour))
+	writeWorktree(t, dir, "active", 400, now.Add(-4*time.Hour))
+	writeWorktree(t, dir, "newest", 400, now)
+
+	manager := &WorktreeManager{
+		repo:      repo,
+		baseDir:   dir,
+		worktrees: map[string]*Worktree{"active": {ID: "active", Path: filepath.Join(dir, "active")}},
+		totalCap:  1000,
+	}
+	require.NoError(t, manager.enforceCap())
+
+	usage, err := MeasureDiskUsage(dir)
+	require.NoError(t, err)
+	var ids []string
+	for _, worktree := range usage.Worktrees {
+		ids = append(ids, worktree.ID)
+	}
+	assert.ElementsMatch(t, []string{"active", "newest"}, ids)
+	assert.Contains(t, manager.worktrees, "active")
+
+	manager.totalCap = 100
+	assert.ErrorContains(t, manager.enforceCap(), "over the 100B cap")
+}
@@ -22,12 +22,13 @@ type Executor struct {
 
 // ExecutorConfig holds configuration for sandbox execution
 type ExecutorConfig struct {
-	Timeout         time.Duration `yaml:"timeout"`
-	MaxWorktrees    int           `yaml:"max_worktrees"`
-	CleanupInterval time.Duration `yaml:"cleanup_interval"`
-	AllowedCommands []string      `yaml:"allowed_commands"`
-	BlockedCommands []string      `yaml:"blocked_commands"`
-	WorkingDir      string        `yaml:"working_dir"`
+	Timeout         time.Duration     `yaml:"timeout"`
+	MaxWorktrees    int               `yaml:"max_worktrees"`
+	CleanupInterval time.Duration     `yaml:"cleanup_interval"`
+	AllowedCommands []string          `yaml:"allowed_commands"`
+	BlockedCommands []string          `yaml:"blocked_commands"`
+	WorkingDir      string            `yaml:"working_dir"`
+	Disk            DiskConfiguration `yaml:"disk"`
 }
 
 // DefaultExecutorConfig returns default configuration
@@ -48,7 +49,8 @@ func DefaultExecutorConfig() ExecutorConfig {
 			"curl", "wget", "ssh", "scp", "rsync", "nc", "netcat",
 			"systemctl", "service", "killall", "pkill",
 		},
-		WorkingDir: ".sigil/sandbox",
+		WorkingDir: SandboxDir,
+		Disk:       DefaultDiskConfiguration(),
 	}
 }
 
@@ -58,6 +60,7 @@ func NewExecutor(repo *git.Repository, config ExecutorConfig) (*Executor, error)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrorTypeConfig, "NewExecutor", "failed to create worktree manager")
 	}
+	worktreeManager.totalCap = config.Disk.TotalCap
 
 	validator, err := NewValidator()
 	if err != nil {
@@ -113,6 +116,12 @@ func (e *Executor) ExecuteCode(ctx context.Context, request ExecutionRequest) (*
 		response.EndTime = time.Now()
 		return response, errors.Wrap(err, errors.ErrorTypeFS, "ExecuteCode", "failed to apply changes")
 	}
+	if err := e.checkQuota(worktree); err != nil {
+		response.Status = StatusFailed
+		response.Error = err.Error()
+		response.EndTime = time.Now()
+		return response, err
+	}
 
 	// Execute the validation steps
 	if err := e.executeValidation(ctx, worktree, request, response); err != nil {
@@ -163,6 +172,11 @@ func (e *Executor) executeValidation(ctx context.Context, worktree *Worktree, re
 	execCtx, cancel := context.WithTimeout(ctx, e.config.Timeout)
 	defer cancel()
 
+	// Install dependencies before anything runs against them
+	if err := e.executeInstall(execCtx, worktree, request, response); err != nil {
+		return err
+	}
+
 	// Execute validation commands
 	for _, step := range request.ValidationSteps {
 		logger.Debug("executing validation step", "command", step.Command, "args", step.Args)
@@ -181,6 +195,11 @@ func (e *Executor) executeValidation(ctx context.Context, worktree *Worktree, re
 
 		response.Results = append(response.Results, *result)
 
+		// Stop a step from filling the disk before the next one runs
+		if err := e.checkQuota(worktree); err != nil {
+			return err
+		}
+
 		// Check if validation step failed and if it's required to pass
 		if !result.Success() && step.Required {
 			return errors.New(errors.ErrorTypeValidation, "executeValidation",
@@ -207,6 +226,34 @@ func (e *Executor) executeValidation(ctx context.Context, worktree *Worktree, re
 	return nil
 }
 
+// executeInstall runs the install steps of a request. Only package
+// managers may run in this phase, and any failure ends the execution.
+func (e *Executor) executeInstall(ctx context.Context, worktree *Worktree, request ExecutionRequest, response *ExecutionResponse) error {
+	for _, step := range request.InstallSteps {
+		logger.Debug("installing dependencies", "command", step.Command, "args", step.Args)
+
+		if !IsPackageManager(step.Command) || e.isCommandBlocked(step.Command) {
+			return errors.New(errors.ErrorTypeValidation, "executeInstall",
+				fmt.Sprintf("command not allowed to install dependencies: %s", step.Command))
+		}
+
+		result, err := e.executeCommand(ctx, worktree, step)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrorTypeInternal, "executeInstall", "dependency install failed to run")
+		}
+		response.Install = append(response.Install, *result)
+		if err := e.checkQuota(worktree); err != nil {
+			return err
+		}
+
+		if !result.Success() {
+			return errors.New(errors.ErrorTypeValidation, "executeInstall",
+				fmt.Sprintf("dependency install failed: %s", result.Command))
+		}
+	}
+	return nil
+}
+
 // executeCommand executes a single command in the worktree
 func (e *Executor) executeCommand(ctx context.Context, worktree *Worktree, step ValidationStep) (*ExecutionResult, error) {
 	// Set up command with timeout monitoring
@@ -214,7 +261,7 @@ func (e *Executor) executeCommand(ctx context.Context, worktree *Worktree, step
 	errChan := make(chan error, 1)
 
 	go func() {
-		result, err := worktree.Execute(step.Command, step.Args...)
+		result, err := worktree.ExecuteEnv(step.Env, step.Command, step.Args...)
 		if err != nil {
 			errChan <- err
 			return
@@ -235,10 +282,8 @@ func (e *Executor) executeCommand(ctx context.Context, worktree *Worktree, step
 // isCommandAllowed checks if a command is allowed to execute
 func (e *Executor) isCommandAllowed(command string) bool {
 	// Check if command is explicitly blocked
-	for _, blocked := range e.config.BlockedCommands {
-		if command == blocked {
-			return false
-		}
+	if e.isCommandBlocked(command) {
+		return false
 	}
 
 	// Check if command is in allowed list
@@ -252,6 +297,16 @@ func (e *Executor) isCommandAllowed(command string) bool {
 	return false
 }
 
+// isCommandBlocked checks if a command is explicitly blocked
+func (e *Executor) isCommandBlocked(command string) bool {
+	for _, blocked := range e.config.BlockedCommands {
+		if command == blocked {
+			return true
+		}
+	}
+	return false
+}
+
 // cleanupRoutine periodically cleans up old worktrees
 func (e *Executor) cleanupRoutine() {
 	ticker := time.NewTicker(e.config.CleanupInterval)
@@ -311,6 +366,7 @@ type ExecutionRequest struct {
 	ID              string            `json:"id"`
 	Type            string            `json:"type"` // "validation", "test", "build", etc.
 	Files           []FileChange      `json:"files"`
+	InstallSteps    []ValidationStep  `json:"install_steps,omitempty"`
 	ValidationSteps []ValidationStep  `json:"validation_steps"`
 	Context         map[string]string `json:"context,omitempty"`
 }
@@ -324,11 +380,12 @@ type FileChange struct {
 
 // ValidationStep represents a validation command to execute
 type ValidationStep struct {
-	Name        string   `json:"name"`
-	Command     string   `json:"command"`
-	Args        []string `json:"args"`
-	Required    bool     `json:"required"`
-	Description string   `json:"description,omitempty"`
+	Name        string            `json:"name"`
+	Command     string            `json:"command"`
+	Args        []string          `json:"args"`
+	Required    bool              `json:"required"`
+	Description string            `json:"description,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
 }
 
 // ExecutionResponse represents the response from code execution
@@ -338,6 +395,7 @@ type ExecutionResponse struct {
 	Status     ExecutionStatus   `json:"status"`
 	StartTime  time.Time         `json:"start_time"`
 	EndTime    time.Time         `json:"end_time"`
+	Install    []ExecutionResult `json:"install,omitempty"`
 	Results    []ExecutionResult `json:"results"`
 	Diff       string            `json:"diff,omitempty"`
 	Error      string            `json:"error,omitempty"`
@@ -0,0 +1,149 @@
+package sandbox
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// globCaseInsensitive matches paths regardless of case, as the file
+// system does on Windows
+var globCaseInsensitive = runtime.GOOS == "windows"
+
+// MatchPath matches a glob pattern against a path relative to the project
+// root, as rule path patterns are matched. Patterns support *, ?,
+// character classes, {a,b} alternatives and ** spanning directories.
+// Patterns without a directory separator match the file name anywhere in
+// the tree.
+func MatchPath(pattern, filePath string) (bool, error) {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	filePath = normalizePath(filePath)
+	if globCaseInsensitive {
+		pattern = strings.ToLower(pattern)
+		filePath = strings.ToLower(filePath)
+	}
+	if !strings.Contains(pattern, "/") {
+		filePath = path.Base(filePath)
+	}
+
+	alternatives, err := expandBraces(pattern)
+	if err != nil {
+		return false, err
+	}
+	for _, alternative := range alternatives {
+		matched, err := matchSegments(strings.Split(alternative, "/"), strings.Split(filePath, "/"))
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// normalizePath returns a path in the slash-separated, cleaned form rule
+// patterns are written in, relative to the project root
+func normalizePath(filePath string) string {
+	cleaned := path.Clean(filepath.ToSlash(filePath))
+	return strings.TrimPrefix(cleaned, "./")
+}
+
+// expandBraces expands the {a,b} alternatives of a pattern, including
+// nested ones, into the patterns they stand for
+func expandBraces(pattern string) ([]string, error) {
+	start := -1
+	depth := 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				return nil, fmt.Errorf("unmatched } in pattern %q", pattern)
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+
+			prefix, suffix := pattern[:start], pattern[i+1:]
+			var expanded []string
+			for _, option := range splitAlternatives(pattern[start+1 : i]) {
+				rest, err := expandBraces(prefix + option + suffix)
+				if err != nil {
+					return nil, err
+				}
+				expanded = append(expanded, rest...)
+			}
+			return expanded, nil
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("unmatched { in pattern %q", pattern)
+	}
+	return []string{pattern}, nil
+}
+
+// splitAlternatives splits the inside of a brace group on the commas not
+// nested in another group
+func splitAlternatives(group string) []string {
+	var options []string
+	depth, last := 0, 0
+	for i := 0; i < len(group); i++ {
+		switch group[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				options = append(options, group[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(options, group[last:])
+}
+
+// matchSegments matches pattern segments against path segments, where a
+// ** segment matches any number of directories
+func matchSegments(pattern, segments []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse repeated ** and try every split of the remaining path
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true, nil
+			}
+			for i := range segments {
+				matched, err := matchSegments(pattern, segments[i:])
+				if err != nil || matched {
+					return matched, err
+				}
+			}
+			return false, nil
+		}
+
+		if len(segments) == 0 {
+			return false, nil
+		}
+		matched, err := path.Match(pattern[0], segments[0])
+		if err != nil || !matched {
+			return false, err
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0, nil
+}
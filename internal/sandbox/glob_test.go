@@ -0,0 +1,79 @@
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "internal/sandbox/glob.go", true},
+		{"*.go", "main.py", false},
+		{"*.{yml,yaml,json,toml}", "config.yml", true},
+		{"*.{yml,yaml,json,toml}", ".sigil/rules.yaml", true},
+		{"*.{yml,yaml,json,toml}", "config.ini", false},
+		{"*.{go,{md,txt}}", "README.md", true},
+		{"internal/*.go", "internal/main.go", true},
+		{"internal/*.go", "internal/sandbox/glob.go", false},
+		{"internal/**/*.go", "internal/main.go", true},
+		{"internal/**/*.go", "internal/sandbox/glob.go", true},
+		{"**/testdata/**", "internal/sandbox/testdata/a/b.txt", true},
+		{"docs/**", "docs", true},
+		{"./internal/*.go", "internal/main.go", true},
+		{"internal/*.go", "./internal/../internal/main.go", true},
+		{"internal/*.go", "internal//main.go", true},
+		{"cmd/?ain.go", "cmd/main.go", true},
+		{"cmd/[a-m]*.go", "cmd/zeta.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			matched, err := MatchPath(tt.pattern, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, matched)
+		})
+	}
+}
+
+func TestMatchPath_CaseInsensitive(t *testing.T) {
+	original := globCaseInsensitive
+	defer func() { globCaseInsensitive = original }()
+
+	globCaseInsensitive = false
+	matched, err := MatchPath("*.go", "MAIN.GO")
+	require.NoError(t, err)
+	assert.False(t, matched)
+
+	globCaseInsensitive = true
+	matched, err = MatchPath("Internal/*.{Go,md}", "internal/MAIN.GO")
+	require.NoError(t, err)
+	assert.True(t, matched)
+}
+
+func TestMatchPath_InvalidPattern(t *testing.T) {
+	for _, pattern := range []string{"*.{go", "*.go}", "[a-"} {
+		_, err := MatchPath(pattern, "main.go")
+		assert.Error(t, err, pattern)
+	}
+}
+
+func TestExpandBraces(t *testing.T) {
+	expanded, err := expandBraces("src/{a,b{1,2}}/*.{go,md}")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"src/a/*.go", "src/a/*.md",
+		"src/b1/*.go", "src/b1/*.md",
+		"src/b2/*.go", "src/b2/*.md",
+	}, expanded)
+
+	expanded, err = expandBraces(`\{literal\}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`\{literal\}`}, expanded)
+}
@@ -0,0 +1,179 @@
+package sandbox
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// InstallSettings configures the dependency-install phase in the
+// validation file
+type InstallSettings struct {
+	// Skip turns the install phase off
+	Skip bool `yaml:"skip"`
+
+	// Proxy is the HTTP(S) proxy package managers install through
+	Proxy string `yaml:"proxy"`
+
+	// Mirror is the package registry or index to install from
+	Mirror string `yaml:"mirror"`
+}
+
+// lockfile is a dependency lockfile and the command that installs exactly
+// what it pins
+type lockfile struct {
+	File    string
+	Command string
+	Args    []string
+}
+
+// ecosystemLockfiles are the lockfiles of each profile, in order of
+// preference
+var ecosystemLockfiles = map[string][]lockfile{
+	"go": {
+		{File: "go.sum", Command: "go", Args: []string{"mod", "download"}},
+	},
+	"node": {
+		{File: "package-lock.json", Command: "npm", Args: []string{"ci"}},
+		{File: "npm-shrinkwrap.json", Command: "npm", Args: []string{"ci"}},
+		{File: "yarn.lock", Command: "yarn", Args: []string{"install", "--frozen-lockfile"}},
+		{File: "pnpm-lock.yaml", Command: "pnpm", Args: []string{"install", "--frozen-lockfile"}},
+	},
+	"python": {
+		{File: "uv.lock", Command: "uv", Args: []string{"sync", "--frozen"}},
+		{File: "poetry.lock", Command: "poetry", Args: []string{"install", "--no-root"}},
+		{File: "Pipfile.lock", Command: "pipenv", Args: []string{"sync"}},
+		{File: "requirements.txt", Command: "pip", Args: []string{"install", "--no-deps", "-r", "requirements.txt"}},
+	},
+}
+
+// ecosystemManifests are the files declaring dependencies that must be
+// locked. Go modules without go.sum have no dependencies to install.
+var ecosystemManifests = map[string][]string{
+	"node":   {"package.json"},
+	"python": {"pyproject.toml", "Pipfile", "setup.py", "requirements.txt"},
+}
+
+// mirrorEnv names the variable each package manager reads its registry from
+var mirrorEnv = map[string]string{
+	"go":     "GOPROXY",
+	"npm":    "npm_config_registry",
+	"yarn":   "YARN_REGISTRY",
+	"pnpm":   "npm_config_registry",
+	"uv":     "UV_DEFAULT_INDEX",
+	"pipenv": "PIPENV_PYPI_MIRROR",
+	"pip":    "PIP_INDEX_URL",
+}
+
+// IsPackageManager reports whether command installs dependencies for one of
+// the built-in profiles
+func IsPackageManager(command string) bool {
+	for _, lockfiles := range ecosystemLockfiles {
+		for _, lock := range lockfiles {
+			if lock.Command == command {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LoadInstallSteps returns the steps that install the dependencies of a
+// project in language from its lockfile. A project declaring dependencies
+// without a lockfile is an error, since an unpinned install could validate
+// against versions nobody reviewed.
+func LoadInstallSteps(language string) ([]ValidationStep, error) {
+	override, err := readValidationFile()
+	if err != nil {
+		return nil, err
+	}
+	if override.Install.Skip {
+		return nil, nil
+	}
+
+	profile := override.Profile
+	if profile == "" {
+		profile = ProfileForLanguage(language)
+	}
+
+	for _, lock := range ecosystemLockfiles[profile] {
+		if !fileExists(lock.File) {
+			continue
+		}
+		if lock.File == "requirements.txt" {
+			if unpinned := unpinnedRequirements(lock.File); len(unpinned) > 0 {
+				return nil, errors.ConfigError("LoadInstallSteps",
+					fmt.Sprintf("requirements.txt does not pin exact versions: %s", strings.Join(unpinned, ", "))).
+					WithHint("pin every dependency with ==, e.g. with pip-compile or pip freeze")
+			}
+		}
+		return []ValidationStep{{
+			Name:        "install",
+			Command:     lock.Command,
+			Args:        lock.Args,
+			Required:    true,
+			Description: fmt.Sprintf("Install dependencies from %s", lock.File),
+			Env:         installEnv(lock.Command, override.Install),
+		}}, nil
+	}
+
+	for _, manifest := range ecosystemManifests[profile] {
+		if fileExists(manifest) {
+			return nil, errors.ConfigError("LoadInstallSteps",
+				fmt.Sprintf("%s declares dependencies but no lockfile was found", manifest)).
+				WithHint(fmt.Sprintf("commit a lockfile, or set install.skip in %s", ValidationFile))
+		}
+	}
+	return nil, nil
+}
+
+// installEnv returns the environment a package manager installs with,
+// routing it through the configured proxy and mirror
+func installEnv(command string, settings InstallSettings) map[string]string {
+	env := make(map[string]string)
+	if settings.Proxy != "" {
+		for _, key := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+			env[key] = settings.Proxy
+		}
+	}
+	if key, ok := mirrorEnv[command]; ok && settings.Mirror != "" {
+		env[key] = settings.Mirror
+	}
+	if command == "go" {
+		// Downloads must match go.sum rather than update it
+		env["GOFLAGS"] = "-mod=readonly"
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+// unpinnedRequirements returns the requirements in a requirements file not
+// pinned to an exact version
+func unpinnedRequirements(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var unpinned []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(scanner.Text()), "\\"))
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if !strings.Contains(line, "==") {
+			unpinned = append(unpinned, line)
+		}
+	}
+	return unpinned
+}
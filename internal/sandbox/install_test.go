@@ -0,0 +1,85 @@
+package sandbox
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadInstallSteps(t *testing.T) {
+	t.Run("lockfile", func(t *testing.T) {
+		writeValidationFile(t, "")
+		require.NoError(t, os.WriteFile("package.json", []byte("{}"), 0644))
+		require.NoError(t, os.WriteFile("yarn.lock", []byte(""), 0644))
+
+		steps, err := LoadInstallSteps("javascript")
+		require.NoError(t, err)
+		require.Len(t, steps, 1)
+		assert.Equal(t, "yarn", steps[0].Command)
+		assert.Equal(t, []string{"install", "--frozen-lockfile"}, steps[0].Args)
+		assert.True(t, steps[0].Required)
+		assert.Nil(t, steps[0].Env)
+	})
+
+	t.Run("missing lockfile", func(t *testing.T) {
+		writeValidationFile(t, "")
+		require.NoError(t, os.WriteFile("package.json", []byte("{}"), 0644))
+
+		_, err := LoadInstallSteps("javascript")
+		assert.ErrorContains(t, err, "no lockfile")
+	})
+
+	t.Run("skipped", func(t *testing.T) {
+		writeValidationFile(t, "install:\n  skip: true\n")
+		require.NoError(t, os.WriteFile("package.json", []byte("{}"), 0644))
+
+		steps, err := LoadInstallSteps("javascript")
+		require.NoError(t, err)
+		assert.Empty(t, steps)
+	})
+
+	t.Run("no dependencies", func(t *testing.T) {
+		writeValidationFile(t, "")
+		steps, err := LoadInstallSteps("go")
+		require.NoError(t, err)
+		assert.Empty(t, steps)
+	})
+
+	t.Run("proxy and mirror", func(t *testing.T) {
+		writeValidationFile(t, "install:\n  proxy: http://proxy:3128\n  mirror: https://pypi.internal/simple\n")
+		require.NoError(t, os.WriteFile("requirements.txt", []byte("requests==2.31.0 \\\n    --hash=sha256:abc\n"), 0644))
+
+		steps, err := LoadInstallSteps("python")
+		require.NoError(t, err)
+		require.Len(t, steps, 1)
+		assert.Equal(t, "pip", steps[0].Command)
+		assert.Equal(t, "http://proxy:3128", steps[0].Env["HTTPS_PROXY"])
+		assert.Equal(t, "https://pypi.internal/simple", steps[0].Env["PIP_INDEX_URL"])
+	})
+
+	t.Run("unpinned requirements", func(t *testing.T) {
+		writeValidationFile(t, "")
+		require.NoError(t, os.WriteFile("requirements.txt", []byte("# deps\nrequests>=2.0\nflask==3.0.0  # web\n"), 0644))
+
+		_, err := LoadInstallSteps("python")
+		assert.ErrorContains(t, err, "requests>=2.0")
+	})
+}
+
+func TestIsPackageManager(t *testing.T) {
+	assert.True(t, IsPackageManager("npm"))
+	assert.True(t, IsPackageManager("pip"))
+	assert.True(t, IsPackageManager("go"))
+	assert.False(t, IsPackageManager("curl"))
+	assert.False(t, IsPackageManager("make"))
+}
+
+func TestExecutor_ExecuteInstallRejectsOtherCommands(t *testing.T) {
+	executor := &Executor{config: DefaultExecutorConfig()}
+	request := ExecutionRequest{InstallSteps: []ValidationStep{{Name: "install", Command: "curl", Args: []string{"-O", "https://example.com/x"}}}}
+
+	err := executor.executeInstall(t.Context(), nil, request, &ExecutionResponse{})
+	assert.ErrorContains(t, err, "command not allowed to install dependencies: curl")
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/git"
 	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/trust"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,13 +21,17 @@ type DefaultManager struct {
 	executor     *Executor
 	validator    *Validator
 	config       ProjectConfiguration
+	steps        []ValidationStep
+	install      []ValidationStep
+	installErr   error
 	metrics      SandboxMetrics
 	eventManager *EventManager
 	mu           sync.RWMutex
 }
 
-// NewManager creates a new sandbox manager
-func NewManager(repo *git.Repository) (Manager, error) {
+// NewManager creates a new sandbox manager. Commands in allow may run in
+// addition to the project's defaults; blocked commands stay blocked.
+func NewManager(repo *git.Repository, allow ...string) (Manager, error) {
 	// Load project configuration
 	config, err := loadProjectConfig()
 	if err != nil {
@@ -34,14 +39,28 @@ func NewManager(repo *git.Repository) (Manager, error) {
 		config = detectProjectConfig()
 	}
 
+	// The project's validation steps may run whatever commands they name
+	steps, profile, err := LoadValidationSteps(config.Language)
+	if err != nil {
+		return nil, err
+	}
+	allow = append(allow, StepCommands(steps)...)
+
+	// A missing lockfile only fails the requests that would install
+	install, installErr := LoadInstallSteps(config.Language)
+	if installErr != nil {
+		logger.Warn("dependencies cannot be installed in sandboxes", "error", installErr)
+	}
+
 	// Create executor
 	executorConfig := ExecutorConfig{
 		Timeout:         config.Build.Timeout,
 		MaxWorktrees:    10,
 		CleanupInterval: 1 * time.Hour,
-		AllowedCommands: getAllowedCommands(config),
+		AllowedCommands: append(getAllowedCommands(config), allow...),
 		BlockedCommands: getBlockedCommands(),
-		WorkingDir:      ".sigil/sandbox",
+		WorkingDir:      SandboxDir,
+		Disk:            config.Disk.withDefaults(),
 	}
 
 	executor, err := NewExecutor(repo, executorConfig)
@@ -59,6 +78,9 @@ func NewManager(repo *git.Repository) (Manager, error) {
 		executor:     executor,
 		validator:    validator,
 		config:       config,
+		steps:        steps,
+		install:      install,
+		installErr:   installErr,
 		eventManager: NewEventManager(),
 		metrics: SandboxMetrics{
 			TotalSandboxes:  0,
@@ -69,12 +91,35 @@ func NewManager(repo *git.Repository) (Manager, error) {
 		},
 	}
 
-	logger.Info("initialized sandbox manager", "language", config.Language, "framework", config.Framework)
+	logger.Info("initialized sandbox manager", "language", config.Language, "framework", config.Framework,
+		"validation_profile", profile, "install_steps", len(install))
 	return manager, nil
 }
 
-// ExecuteCode executes code in a sandbox environment
+// ExecuteCode executes code in a sandbox environment. Requests that run
+// steps install the project's dependencies first unless they name their
+// own install steps. Executions are recorded in the context's transcript.
+// In untrusted mode requests that run steps are refused: the steps run the
+// repository's own code.
 func (m *DefaultManager) ExecuteCode(ctx context.Context, request ExecutionRequest) (*ExecutionResponse, error) {
+	if len(request.ValidationSteps) > 0 || len(request.InstallSteps) > 0 {
+		if err := trust.Check("ExecuteCode", "running project commands in a sandbox"); err != nil {
+			if transcript := TranscriptFrom(ctx); transcript != nil {
+				transcript.Record(request, nil, err)
+			}
+			return nil, err
+		}
+	}
+	if len(request.ValidationSteps) > 0 && request.InstallSteps == nil {
+		if m.installErr != nil {
+			if transcript := TranscriptFrom(ctx); transcript != nil {
+				transcript.Record(request, nil, m.installErr)
+			}
+			return nil, m.installErr
+		}
+		request.InstallSteps = m.install
+	}
+
 	m.mu.Lock()
 	m.metrics.TotalExecutions++
 	m.mu.Unlock()
@@ -92,6 +137,9 @@ func (m *DefaultManager) ExecuteCode(ctx context.Context, request ExecutionReque
 
 	// Execute the code
 	response, err := m.executor.ExecuteCode(ctx, request)
+	if transcript := TranscriptFrom(ctx); transcript != nil {
+		transcript.Record(request, response, err)
+	}
 
 	// Update metrics
 	m.mu.Lock()
@@ -136,6 +184,15 @@ func (m *DefaultManager) GetValidationRules(path string) ([]FileRule, []ContentR
 	return m.validator.GetRulesForPath(path)
 }
 
+// ValidationSteps returns the steps that validate changes to the project,
+// or none in untrusted mode, where they may not run
+func (m *DefaultManager) ValidationSteps() []ValidationStep {
+	if trust.Untrusted() {
+		return nil
+	}
+	return m.steps
+}
+
 // CreateSandbox creates a sandbox for manual operations
 func (m *DefaultManager) CreateSandbox() (Sandbox, error) {
 	worktree, err := m.executor.worktreeManager.CreateWorktree("HEAD")
@@ -196,11 +253,23 @@ func (m *DefaultManager) Cleanup() error {
 	return nil
 }
 
-// GetMetrics returns sandbox metrics
+// GetMetrics returns sandbox metrics, measuring the disk the sandboxes use
 func (m *DefaultManager) GetMetrics() SandboxMetrics {
+	usage, err := MeasureDiskUsage(m.executor.worktreeManager.baseDir)
+	if err != nil {
+		logger.Warn("failed to measure sandbox disk usage", "error", err)
+	}
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.metrics
+	metrics := m.metrics
+	metrics.DiskUsage = usage.Total
+	return metrics
+}
+
+// DiskConfig returns the disk limits sandboxes run under
+func (m *DefaultManager) DiskConfig() DiskConfiguration {
+	return m.executor.config.Disk
 }
 
 // GetConfig returns project configuration
@@ -331,11 +400,11 @@ func getAllowedCommands(config ProjectConfiguration) []string {
 
 	switch config.Language {
 	case "go":
-		return append(base, "go", "gofmt", "golangci-lint")
+		return append(base, "go", "gofmt", "golangci-lint", "staticcheck")
 	case "javascript":
 		return append(base, "node", "npm", "yarn", "npx")
 	case "python":
-		return append(base, "python", "python3", "pip", "pip3", "pytest", "flake8")
+		return append(base, "python", "python3", "pip", "pip3", "pytest", "flake8", "ruff")
 	default:
 		return base
 	}
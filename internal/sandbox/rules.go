@@ -0,0 +1,237 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// RulesFile holds the validation rules of the directory it is in. The one
+// at the project root sets the base rules; those in subdirectories extend
+// them for the files below.
+const RulesFile = ".sigil/rules.yml"
+
+// rulesOverride is a subdirectory rules file. Rules replace the inherited
+// rule of the same name, or are added when no rule has their name; size
+// and security settings left out keep their inherited values.
+type rulesOverride struct {
+	FileRules     []FileRule       `yaml:"file_rules"`
+	ContentRules  []ContentRule    `yaml:"content_rules"`
+	SizeRules     sizeOverride     `yaml:"size_rules"`
+	SecurityRules securityOverride `yaml:"security_rules"`
+}
+
+type sizeOverride struct {
+	MaxFileSize  *int64 `yaml:"max_file_size"`
+	MaxTotalSize *int64 `yaml:"max_total_size"`
+	MaxFiles     *int   `yaml:"max_files"`
+}
+
+type securityOverride struct {
+	BlockedExtensions  []string `yaml:"blocked_extensions"`
+	BlockedPaths       []string `yaml:"blocked_paths"`
+	RequireTests       *bool    `yaml:"require_tests"`
+	RequireLinting     *bool    `yaml:"require_linting"`
+	AllowNetworkAccess *bool    `yaml:"allow_network_access"`
+}
+
+// EffectiveRules returns the rules that apply to a path: the root rules
+// extended by the rules file of each directory above it, outermost first,
+// and the rules files that were merged
+func (v *Validator) EffectiveRules(filePath string) (Rules, []string, error) {
+	rules := v.rules.clone()
+	root := v.rootSource
+	if root == "" {
+		root = "defaults"
+	}
+	for i := range rules.FileRules {
+		rules.FileRules[i].Source = root
+	}
+	for i := range rules.ContentRules {
+		rules.ContentRules[i].Source = root
+	}
+	sources := []string{root}
+
+	for _, dir := range parentDirs(filePath) {
+		override, source, err := v.directoryRules(dir)
+		if err != nil {
+			return Rules{}, nil, err
+		}
+		if override == nil {
+			continue
+		}
+		rules = rules.merge(*override, source)
+		sources = append(sources, source)
+	}
+	return rules, sources, nil
+}
+
+// parentDirs returns the directories above a project-relative path,
+// outermost first. Paths outside the project have none.
+func parentDirs(filePath string) []string {
+	normalized := normalizePath(filePath)
+	if path.IsAbs(normalized) || normalized == ".." || strings.HasPrefix(normalized, "../") {
+		return nil
+	}
+	var dirs []string
+	for dir := path.Dir(normalized); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+	}
+	return dirs
+}
+
+// directoryRules reads the rules file of a directory once, returning nil
+// when it has none
+func (v *Validator) directoryRules(dir string) (*rulesOverride, string, error) {
+	source := path.Join(dir, RulesFile)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if override, ok := v.dirRules[dir]; ok {
+		return override, source, nil
+	}
+
+	data, err := os.ReadFile(filepath.FromSlash(source))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, source, errors.Wrap(err, errors.ErrorTypeFS, "directoryRules",
+				fmt.Sprintf("failed to read rules file: %s", source))
+		}
+		data = nil
+	}
+
+	var override *rulesOverride
+	if data != nil {
+		override = &rulesOverride{}
+		if err := yaml.Unmarshal(data, override); err != nil {
+			return nil, source, errors.Wrap(err, errors.ErrorTypeInput, "directoryRules",
+				fmt.Sprintf("failed to parse rules file: %s", source))
+		}
+		logger.Debug("loaded directory validation rules", "path", source)
+	}
+
+	if v.dirRules == nil {
+		v.dirRules = make(map[string]*rulesOverride)
+	}
+	v.dirRules[dir] = override
+	return override, source, nil
+}
+
+// clone copies rules so merging never changes the rules they came from
+func (r Rules) clone() Rules {
+	clone := r
+	clone.FileRules = append([]FileRule(nil), r.FileRules...)
+	clone.ContentRules = append([]ContentRule(nil), r.ContentRules...)
+	clone.SecurityRules.BlockedExtensions = append([]string(nil), r.SecurityRules.BlockedExtensions...)
+	clone.SecurityRules.BlockedPaths = append([]string(nil), r.SecurityRules.BlockedPaths...)
+	return clone
+}
+
+// merge extends rules with the rules file at source
+func (r Rules) merge(override rulesOverride, source string) Rules {
+	for _, rule := range override.FileRules {
+		rule.Source = source
+		r.FileRules = mergeNamed(r.FileRules, rule, rule.Name, rule.Disabled, func(existing FileRule) string { return existing.Name })
+	}
+	for _, rule := range override.ContentRules {
+		rule.Source = source
+		r.ContentRules = mergeNamed(r.ContentRules, rule, rule.Name, rule.Disabled, func(existing ContentRule) string { return existing.Name })
+	}
+
+	size := override.SizeRules
+	if size.MaxFileSize != nil {
+		r.SizeRules.MaxFileSize = *size.MaxFileSize
+	}
+	if size.MaxTotalSize != nil {
+		r.SizeRules.MaxTotalSize = *size.MaxTotalSize
+	}
+	if size.MaxFiles != nil {
+		r.SizeRules.MaxFiles = *size.MaxFiles
+	}
+
+	security := override.SecurityRules
+	if security.BlockedExtensions != nil {
+		r.SecurityRules.BlockedExtensions = security.BlockedExtensions
+	}
+	if security.BlockedPaths != nil {
+		r.SecurityRules.BlockedPaths = security.BlockedPaths
+	}
+	if security.RequireTests != nil {
+		r.SecurityRules.RequireTests = *security.RequireTests
+	}
+	if security.RequireLinting != nil {
+		r.SecurityRules.RequireLinting = *security.RequireLinting
+	}
+	if security.AllowNetworkAccess != nil {
+		r.SecurityRules.AllowNetworkAccess = *security.AllowNetworkAccess
+	}
+	return r
+}
+
+// mergeNamed replaces the rule with the same name, removes it when the new
+// rule is disabled, or adds the new rule
+func mergeNamed[T any](rules []T, rule T, name string, disabled bool, nameOf func(T) string) []T {
+	for i, existing := range rules {
+		if name == "" || nameOf(existing) != name {
+			continue
+		}
+		if disabled {
+			return append(rules[:i], rules[i+1:]...)
+		}
+		rules[i] = rule
+		return rules
+	}
+	if disabled {
+		return rules
+	}
+	return append(rules, rule)
+}
+
+// ruleDir returns the directory a rule's path pattern is relative to
+func ruleDir(source string) string {
+	if dir, ok := strings.CutSuffix(source, "/"+RulesFile); ok {
+		return dir
+	}
+	return "."
+}
+
+// matchRule matches a rule's path pattern against a path, relative to the
+// directory of the rules file that defined the rule
+func matchRule(pattern, source, filePath string) (bool, error) {
+	dir := ruleDir(source)
+	if dir != "." {
+		relative, ok := strings.CutPrefix(normalizePath(filePath), dir+"/")
+		if !ok {
+			return false, nil
+		}
+		filePath = relative
+	}
+	return MatchPath(pattern, filePath)
+}
+
+// ForPath returns the file and content rules whose path patterns match a
+// path
+func (r Rules) ForPath(filePath string) ([]FileRule, []ContentRule) {
+	var fileRules []FileRule
+	var contentRules []ContentRule
+
+	for _, rule := range r.FileRules {
+		if matched, err := matchRule(rule.PathPattern, rule.Source, filePath); err == nil && matched {
+			fileRules = append(fileRules, rule)
+		}
+	}
+
+	for _, rule := range r.ContentRules {
+		if matched, err := matchRule(rule.PathPattern, rule.Source, filePath); err == nil && matched {
+			contentRules = append(contentRules, rule)
+		}
+	}
+
+	return fileRules, contentRules
+}
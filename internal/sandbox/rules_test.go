@@ -0,0 +1,122 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRulesFile writes a rules file for dir in the working directory
+func writeRulesFile(t *testing.T, dir, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(RulesFile))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestValidator_EffectiveRules(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeRulesFile(t, "payments", `
+content_rules:
+  - name: No dangerous operations
+    path_pattern: "*.go"
+    blocked_patterns: ["os\\.Exit\\(", "fmt\\.Print"]
+    required: true
+  - name: No card numbers
+    path_pattern: "**/*.go"
+    blocked_patterns: ["4[0-9]{15}"]
+    required: true
+`)
+	writeRulesFile(t, "testdata", `
+size_rules:
+  max_file_size: 52428800
+file_rules:
+  - name: Documentation
+    disabled: true
+`)
+
+	validator, err := NewValidator()
+	require.NoError(t, err)
+
+	t.Run("root rules outside overriding directories", func(t *testing.T) {
+		rules, sources, err := validator.EffectiveRules("cmd/main.go")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"defaults"}, sources)
+		assert.Equal(t, DefaultRules().SizeRules, rules.SizeRules)
+		assert.Len(t, rules.ContentRules, 2)
+	})
+
+	t.Run("stricter content rules in payments", func(t *testing.T) {
+		rules, sources, err := validator.EffectiveRules("payments/api/charge.go")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"defaults", "payments/.sigil/rules.yml"}, sources)
+		require.Len(t, rules.ContentRules, 3)
+		assert.Equal(t, "No dangerous operations", rules.ContentRules[1].Name)
+		assert.Equal(t, "payments/.sigil/rules.yml", rules.ContentRules[1].Source)
+		assert.Contains(t, rules.ContentRules[1].BlockedPatterns, `fmt\.Print`)
+		assert.Equal(t, "No card numbers", rules.ContentRules[2].Name)
+
+		err = validator.ValidateCode("payments/api/charge.go", "package api\n\nfunc log() { fmt.Println(\"charged\") }\n")
+		assert.Error(t, err)
+		assert.NoError(t, validator.ValidateCode("cmd/main.go", "package main\n\nfunc log() { fmt.Println(\"done\") }\n"))
+	})
+
+	t.Run("relaxed size rules in testdata", func(t *testing.T) {
+		rules, _, err := validator.EffectiveRules("testdata/large.json")
+		require.NoError(t, err)
+		assert.Equal(t, int64(52428800), rules.SizeRules.MaxFileSize)
+		assert.Equal(t, DefaultRules().SizeRules.MaxTotalSize, rules.SizeRules.MaxTotalSize)
+		for _, rule := range rules.FileRules {
+			assert.NotEqual(t, "Documentation", rule.Name)
+		}
+
+		large := ExecutionRequest{Files: []FileChange{{
+			Path: "testdata/large.json", Content: strings.Repeat("x", 2*1024*1024), Operation: OperationCreate,
+		}}}
+		assert.NoError(t, validator.validateSizeLimits(large))
+		large.Files[0].Path = "large.json"
+		assert.Error(t, validator.validateSizeLimits(large))
+	})
+
+	t.Run("root rules are not changed by merging", func(t *testing.T) {
+		assert.Len(t, validator.GetRules().ContentRules, 2)
+		assert.Empty(t, validator.GetRules().ContentRules[0].Source)
+	})
+}
+
+func TestValidator_EffectiveRules_InvalidFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeRulesFile(t, "broken", "file_rules: [")
+
+	validator, err := NewValidator()
+	require.NoError(t, err)
+
+	_, _, err = validator.EffectiveRules("broken/main.go")
+	assert.Error(t, err)
+	assert.Error(t, validator.ValidateCode("broken/main.go", "package main"))
+}
+
+func TestParentDirs(t *testing.T) {
+	assert.Nil(t, parentDirs("main.go"))
+	assert.Equal(t, []string{"a", "a/b"}, parentDirs("./a/b/c.go"))
+	assert.Nil(t, parentDirs("../outside/c.go"))
+	assert.Nil(t, parentDirs("/etc/passwd"))
+}
+
+func TestMatchRule_RelativeToRulesFile(t *testing.T) {
+	matched, err := matchRule("api/*.go", "payments/.sigil/rules.yml", "payments/api/charge.go")
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	matched, err = matchRule("api/*.go", "payments/.sigil/rules.yml", "api/charge.go")
+	require.NoError(t, err)
+	assert.False(t, matched)
+
+	matched, err = matchRule("api/*.go", RulesFile, "api/charge.go")
+	require.NoError(t, err)
+	assert.True(t, matched)
+}
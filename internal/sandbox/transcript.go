@@ -0,0 +1,129 @@
+package sandbox
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Transcript phases
+const (
+	PhaseInstall    = "install"
+	PhaseValidation = "validation"
+)
+
+// Transcript records every sandbox execution made while it is in a
+// context, for debugging failed validations
+type Transcript struct {
+	mu         sync.Mutex
+	executions []Execution
+}
+
+// Execution is the transcript of one sandbox execution
+type Execution struct {
+	RequestID  string           `json:"request_id"`
+	Type       string           `json:"type"`
+	WorktreeID string           `json:"worktree_id,omitempty"`
+	Status     ExecutionStatus  `json:"status"`
+	StartTime  time.Time        `json:"start_time"`
+	Duration   time.Duration    `json:"duration"`
+	Files      []FileChange     `json:"files,omitempty"`
+	Steps      []TranscriptStep `json:"steps"`
+	Diff       string           `json:"diff,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	Violation  *RuleViolation   `json:"violation,omitempty"` // Why the validator rejected the request
+}
+
+// TranscriptStep is one command an execution ran
+type TranscriptStep struct {
+	Phase    string        `json:"phase"`
+	Name     string        `json:"name,omitempty"`
+	Command  string        `json:"command"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	Stdout   string        `json:"stdout,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Failed reports whether the execution did not complete
+func (e Execution) Failed() bool {
+	return e.Status != StatusCompleted
+}
+
+type transcriptKey struct{}
+
+// WithTranscript returns a context whose sandbox executions are recorded
+// in transcript
+func WithTranscript(ctx context.Context, transcript *Transcript) context.Context {
+	return context.WithValue(ctx, transcriptKey{}, transcript)
+}
+
+// TranscriptFrom returns the transcript of a context, or nil
+func TranscriptFrom(ctx context.Context) *Transcript {
+	transcript, _ := ctx.Value(transcriptKey{}).(*Transcript)
+	return transcript
+}
+
+// Record adds an execution to the transcript. The response may be nil
+// when the execution failed before a sandbox was created.
+func (t *Transcript) Record(request ExecutionRequest, response *ExecutionResponse, err error) {
+	execution := Execution{
+		RequestID: request.ID,
+		Type:      request.Type,
+		Status:    StatusFailed,
+		StartTime: time.Now(),
+		Files:     request.Files,
+		Steps:     []TranscriptStep{},
+	}
+	if response != nil {
+		execution.WorktreeID = response.WorktreeID
+		execution.Status = response.Status
+		execution.StartTime = response.StartTime
+		execution.Duration = response.Duration()
+		execution.Diff = response.Diff
+		execution.Error = response.Error
+		execution.Steps = append(execution.Steps, transcriptSteps(PhaseInstall, request.InstallSteps, response.Install)...)
+		execution.Steps = append(execution.Steps, transcriptSteps(PhaseValidation, request.ValidationSteps, response.Results)...)
+	}
+	if err != nil && execution.Error == "" {
+		execution.Error = err.Error()
+	}
+	if violation, ok := ViolationOf(err); ok {
+		execution.Violation = violation
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.executions = append(t.executions, execution)
+}
+
+// Executions returns the recorded executions in the order they ran
+func (t *Transcript) Executions() []Execution {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Execution(nil), t.executions...)
+}
+
+// transcriptSteps pairs the results of a phase with the steps that ran them
+func transcriptSteps(phase string, steps []ValidationStep, results []ExecutionResult) []TranscriptStep {
+	transcript := make([]TranscriptStep, len(results))
+	for i, result := range results {
+		transcript[i] = TranscriptStep{
+			Phase:    phase,
+			Command:  result.Command,
+			ExitCode: result.ExitCode,
+			Duration: result.Duration,
+			Stdout:   result.Stdout,
+			Stderr:   result.Stderr,
+			Error:    result.Error,
+		}
+		if i < len(steps) {
+			transcript[i].Name = steps[i].Name
+		}
+	}
+	return transcript
+}
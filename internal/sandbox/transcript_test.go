@@ -0,0 +1,64 @@
+package sandbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+func TestTranscript_Record(t *testing.T) {
+	transcript := &Transcript{}
+	ctx := WithTranscript(context.Background(), transcript)
+	require.Same(t, transcript, TranscriptFrom(ctx))
+	assert.Nil(t, TranscriptFrom(context.Background()))
+
+	start := time.Now()
+	request := ExecutionRequest{
+		ID:              "validate",
+		Type:            "validation",
+		InstallSteps:    []ValidationStep{{Name: "install", Command: "npm", Args: []string{"ci"}}},
+		ValidationSteps: []ValidationStep{{Name: "test", Command: "npm", Args: []string{"test"}}},
+	}
+	response := &ExecutionResponse{
+		WorktreeID: "wt",
+		Status:     StatusFailed,
+		StartTime:  start,
+		EndTime:    start.Add(2 * time.Second),
+		Install:    []ExecutionResult{{Command: "npm ci", Stdout: "added 12 packages", Duration: time.Second}},
+		Results:    []ExecutionResult{{Command: "npm test", Stderr: "1 failing", ExitCode: 1}},
+		Diff:       "diff --git a/x b/x",
+	}
+	TranscriptFrom(ctx).Record(request, response, assert.AnError)
+	TranscriptFrom(ctx).Record(ExecutionRequest{ID: "early"}, nil, assert.AnError)
+
+	executions := transcript.Executions()
+	require.Len(t, executions, 2)
+
+	execution := executions[0]
+	assert.Equal(t, "wt", execution.WorktreeID)
+	assert.Equal(t, 2*time.Second, execution.Duration)
+	assert.True(t, execution.Failed())
+	assert.Equal(t, assert.AnError.Error(), execution.Error)
+	require.Len(t, execution.Steps, 2)
+	assert.Equal(t, TranscriptStep{Phase: PhaseInstall, Name: "install", Command: "npm ci", Duration: time.Second, Stdout: "added 12 packages"}, execution.Steps[0])
+	assert.Equal(t, PhaseValidation, execution.Steps[1].Phase)
+	assert.Equal(t, "1 failing", execution.Steps[1].Stderr)
+	assert.Equal(t, 1, execution.Steps[1].ExitCode)
+
+	assert.Equal(t, StatusFailed, executions[1].Status)
+	assert.Empty(t, executions[1].Steps)
+
+	rejected := &Transcript{}
+	violation := &RuleViolation{Kind: ViolationContent, Rule: "No credentials", File: "a.go", Line: 3}
+	rejected.Record(ExecutionRequest{ID: "rejected"}, nil, errors.Wrap(violation, errors.ErrorTypeValidation, "ValidateRequest", "file validation failed"))
+	require.Len(t, rejected.Executions(), 1)
+	assert.Same(t, violation, rejected.Executions()[0].Violation)
+
+	var missing *Transcript
+	assert.Nil(t, missing.Executions())
+}
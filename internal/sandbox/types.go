@@ -17,6 +17,9 @@ type Manager interface {
 	// Get validation rules for a path
 	GetValidationRules(path string) ([]FileRule, []ContentRule)
 
+	// Get the steps that validate changes to the project
+	ValidationSteps() []ValidationStep
+
 	// Create a sandbox for manual operations
 	CreateSandbox() (Sandbox, error)
 
@@ -134,6 +137,7 @@ type ProjectConfiguration struct {
 	Build       BuildConfiguration `yaml:"build"`
 	Lint        LintConfiguration  `yaml:"lint"`
 	Validation  ValidationConfig   `yaml:"validation"`
+	Disk        DiskConfiguration  `yaml:"disk"`
 	Environment map[string]string  `yaml:"environment"`
 }
 
@@ -0,0 +1,194 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dshills/sigil/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationFile overrides the validation steps of the detected profile
+const ValidationFile = ".sigil/validation.yml"
+
+// ProfileCustom names the steps given in the validation file
+const ProfileCustom = "custom"
+
+// validationOverride is the validation file: a built-in profile to use
+// instead of the detected one, or steps replacing the profile entirely,
+// and how dependencies are installed
+type validationOverride struct {
+	Profile string           `yaml:"profile"`
+	Steps   []ValidationStep `yaml:"steps"`
+	Install InstallSettings  `yaml:"install"`
+}
+
+// readValidationFile reads the validation file, which may be missing
+func readValidationFile() (validationOverride, error) {
+	var override validationOverride
+	data, err := os.ReadFile(ValidationFile)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &override); err != nil {
+			return override, errors.Wrap(err, errors.ErrorTypeConfig, "readValidationFile", "failed to parse validation file").
+				WithHint(fmt.Sprintf("check the syntax of %s", ValidationFile))
+		}
+	case !os.IsNotExist(err):
+		return override, errors.Wrap(err, errors.ErrorTypeFS, "readValidationFile", "failed to read validation file")
+	}
+	return override, nil
+}
+
+// ValidationProfiles returns the built-in validation steps by ecosystem
+func ValidationProfiles() map[string][]ValidationStep {
+	return map[string][]ValidationStep{
+		"go": {
+			{Name: "build", Command: "go", Args: []string{"build", "./..."}, Required: true, Description: "Compile every package"},
+			{Name: "vet", Command: "go", Args: []string{"vet", "./..."}, Required: true, Description: "Report suspicious constructs"},
+			{Name: "test", Command: "go", Args: []string{"test", "./..."}, Required: true, Description: "Run the tests"},
+		},
+		"node": {
+			{Name: "lint", Command: "npm", Args: []string{"run", "lint", "--if-present"}, Required: false, Description: "Run the lint script"},
+			{Name: "test", Command: "npm", Args: []string{"test"}, Required: true, Description: "Run the tests"},
+		},
+		"python": {
+			{Name: "lint", Command: "ruff", Args: []string{"check", "."}, Required: false, Description: "Lint with ruff"},
+			{Name: "test", Command: "python", Args: []string{"-m", "pytest"}, Required: true, Description: "Run the tests"},
+		},
+	}
+}
+
+// ProfileNames lists the built-in validation profiles
+func ProfileNames() []string {
+	names := make([]string, 0, len(ValidationProfiles()))
+	for name := range ValidationProfiles() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProfileForLanguage returns the built-in validation profile for a project
+// language
+func ProfileForLanguage(language string) string {
+	switch language {
+	case "javascript", "typescript", "node":
+		return "node"
+	default:
+		return language
+	}
+}
+
+// LoadValidationSteps returns the validation steps for a project in
+// language and the profile they came from. The validation file, when
+// present, picks another profile or replaces the steps.
+func LoadValidationSteps(language string) ([]ValidationStep, string, error) {
+	override, err := readValidationFile()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(override.Steps) > 0 {
+		for _, step := range override.Steps {
+			if step.Name == "" || step.Command == "" {
+				return nil, "", errors.ConfigError("LoadValidationSteps",
+					fmt.Sprintf("validation steps in %s need a name and a command", ValidationFile))
+			}
+		}
+		return override.Steps, ProfileCustom, nil
+	}
+
+	profile := override.Profile
+	if profile == "" {
+		profile = ProfileForLanguage(language)
+	}
+	steps, ok := ValidationProfiles()[profile]
+	if !ok {
+		if override.Profile == "" {
+			// Languages without a profile are not validated by default
+			return nil, "", nil
+		}
+		return nil, "", errors.ConfigError("LoadValidationSteps", fmt.Sprintf("unknown validation profile: %s", profile)).
+			WithHint(fmt.Sprintf("use one of %v or list steps in %s", ProfileNames(), ValidationFile))
+	}
+	return steps, profile, nil
+}
+
+// StepCommands returns the commands the steps run, once each
+func StepCommands(steps []ValidationStep) []string {
+	var commands []string
+	seen := make(map[string]bool)
+	for _, step := range steps {
+		if !seen[step.Command] {
+			seen[step.Command] = true
+			commands = append(commands, step.Command)
+		}
+	}
+	return commands
+}
+
+// StepResult is the outcome of one validation step
+type StepResult struct {
+	Step   ValidationStep `json:"step"`
+	Ran    bool           `json:"ran"`
+	Passed bool           `json:"passed"`
+	Output string         `json:"output,omitempty"`
+}
+
+// RunValidation applies files in a sandbox and runs the steps over them.
+// A failed required step ends the run, leaving the steps after it not run.
+func RunValidation(ctx context.Context, manager Manager, id string, files []FileChange, steps []ValidationStep) ([]StepResult, error) {
+	response, err := manager.ExecuteCode(ctx, ExecutionRequest{
+		ID:              id,
+		Type:            "validation",
+		Files:           files,
+		ValidationSteps: steps,
+	})
+	if response == nil {
+		return nil, err
+	}
+	// A failing step ends the run with an error; anything else that ended
+	// it, such as a blocked command or a timeout, means it could not run
+	stoppedByStep := len(response.Results) > 0 && !response.Results[len(response.Results)-1].Success()
+	if err != nil && !stoppedByStep {
+		return nil, err
+	}
+
+	results := make([]StepResult, len(steps))
+	for i, step := range steps {
+		results[i].Step = step
+	}
+	for i, result := range response.Results {
+		if i >= len(results) {
+			break
+		}
+		results[i].Ran = true
+		results[i].Passed = result.Success()
+		results[i].Output = strings.TrimSpace(result.Output + "\n" + result.Error)
+	}
+	return results, nil
+}
+
+// ValidationPassed reports whether every required step ran and passed
+func ValidationPassed(results []StepResult) bool {
+	for _, result := range results {
+		if result.Step.Required && (!result.Ran || !result.Passed) {
+			return false
+		}
+	}
+	return true
+}
+
+// FailedSteps names the required steps that failed or did not run
+func FailedSteps(results []StepResult) []string {
+	var failed []string
+	for _, result := range results {
+		if result.Step.Required && (!result.Ran || !result.Passed) {
+			failed = append(failed, result.Step.Name)
+		}
+	}
+	return failed
+}
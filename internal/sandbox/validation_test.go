@@ -0,0 +1,112 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeValidationFile writes the validation file in a temporary working directory
+func writeValidationFile(t *testing.T, content string) {
+	t.Chdir(t.TempDir())
+	if content == "" {
+		return
+	}
+	require.NoError(t, os.MkdirAll(filepath.Dir(ValidationFile), 0755))
+	require.NoError(t, os.WriteFile(ValidationFile, []byte(content), 0644))
+}
+
+func TestLoadValidationSteps_DetectedProfile(t *testing.T) {
+	writeValidationFile(t, "")
+
+	tests := []struct {
+		language string
+		profile  string
+		first    string
+	}{
+		{"go", "go", "build"},
+		{"typescript", "node", "lint"},
+		{"javascript", "node", "lint"},
+		{"python", "python", "lint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			steps, profile, err := LoadValidationSteps(tt.language)
+			require.NoError(t, err)
+			assert.Equal(t, tt.profile, profile)
+			require.NotEmpty(t, steps)
+			assert.Equal(t, tt.first, steps[0].Name)
+		})
+	}
+
+	steps, profile, err := LoadValidationSteps("rust")
+	require.NoError(t, err)
+	assert.Empty(t, steps)
+	assert.Empty(t, profile)
+}
+
+func TestLoadValidationSteps_Override(t *testing.T) {
+	t.Run("profile", func(t *testing.T) {
+		writeValidationFile(t, "profile: python\n")
+		steps, profile, err := LoadValidationSteps("go")
+		require.NoError(t, err)
+		assert.Equal(t, "python", profile)
+		assert.Equal(t, ValidationProfiles()["python"], steps)
+	})
+
+	t.Run("custom steps", func(t *testing.T) {
+		writeValidationFile(t, `steps:
+  - name: check
+    command: make
+    args: [check]
+    required: true
+`)
+		steps, profile, err := LoadValidationSteps("go")
+		require.NoError(t, err)
+		assert.Equal(t, ProfileCustom, profile)
+		require.Len(t, steps, 1)
+		assert.Equal(t, "make", steps[0].Command)
+		assert.Equal(t, []string{"check"}, steps[0].Args)
+		assert.True(t, steps[0].Required)
+	})
+
+	t.Run("step without command", func(t *testing.T) {
+		writeValidationFile(t, "steps:\n  - name: check\n")
+		_, _, err := LoadValidationSteps("go")
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		writeValidationFile(t, "profile: cobol\n")
+		_, _, err := LoadValidationSteps("go")
+		assert.ErrorContains(t, err, "unknown validation profile: cobol")
+	})
+}
+
+func TestStepCommands(t *testing.T) {
+	commands := StepCommands(ValidationProfiles()["python"])
+	assert.Equal(t, []string{"ruff", "python"}, commands)
+	assert.Equal(t, []string{"go"}, StepCommands(ValidationProfiles()["go"]))
+}
+
+func TestValidationPassed(t *testing.T) {
+	required := ValidationStep{Name: "test", Required: true}
+	optional := ValidationStep{Name: "lint"}
+
+	results := []StepResult{
+		{Step: optional, Ran: true, Passed: false},
+		{Step: required, Ran: true, Passed: true},
+	}
+	assert.True(t, ValidationPassed(results))
+	assert.Empty(t, FailedSteps(results))
+
+	results = []StepResult{
+		{Step: ValidationStep{Name: "build", Required: true}, Ran: true, Passed: false},
+		{Step: required},
+	}
+	assert.False(t, ValidationPassed(results))
+	assert.Equal(t, []string{"build", "test"}, FailedSteps(results))
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/logger"
@@ -15,7 +16,11 @@ import (
 
 // Validator enforces rules and validates code changes
 type Validator struct {
-	rules Rules
+	rules      Rules
+	rootSource string
+
+	mu       sync.Mutex
+	dirRules map[string]*rulesOverride
 }
 
 // Rules defines validation rules
@@ -34,6 +39,9 @@ type FileRule struct {
 	BlockedOps  []string `yaml:"blocked_operations"`
 	Required    bool     `yaml:"required"`
 	Description string   `yaml:"description"`
+	Remediation string   `yaml:"remediation,omitempty"` // how to fix a change the rule rejects
+	Disabled    bool     `yaml:"disabled,omitempty"`    // removes the inherited rule of the same name
+	Source      string   `yaml:"source,omitempty"`      // rules file the rule came from, set on effective rules
 }
 
 // ContentRule defines rules for file content
@@ -44,6 +52,10 @@ type ContentRule struct {
 	BlockedPatterns []string `yaml:"blocked_patterns"`
 	Required        bool     `yaml:"required"`
 	Description     string   `yaml:"description"`
+	Remediation     string   `yaml:"remediation,omitempty"` // how to fix a change the rule rejects
+	Redact          bool     `yaml:"redact,omitempty"`      // keep matched text out of explanations
+	Disabled        bool     `yaml:"disabled,omitempty"`    // removes the inherited rule of the same name
+	Source          string   `yaml:"source,omitempty"`      // rules file the rule came from, set on effective rules
 }
 
 // SizeRule defines size limitations
@@ -100,6 +112,8 @@ func DefaultRules() Rules {
 				},
 				Required:    true,
 				Description: "Prevent credential exposure",
+				Remediation: "read the value from the environment or a secret store instead of the source",
+				Redact:      true,
 			},
 			{
 				Name:        "No dangerous operations",
@@ -112,6 +126,7 @@ func DefaultRules() Rules {
 				},
 				Required:    true,
 				Description: "Prevent dangerous system operations",
+				Remediation: "return an error to the caller instead of exiting, deleting from / or shelling out",
 			},
 		},
 		SizeRules: SizeRule{
@@ -146,7 +161,7 @@ func NewValidator() (*Validator, error) {
 
 // LoadRules loads validation rules from .sigil/rules.yml
 func (v *Validator) LoadRules() error {
-	rulesPath := filepath.Join(".sigil", "rules.yml")
+	rulesPath := filepath.FromSlash(RulesFile)
 
 	if _, err := os.Stat(rulesPath); os.IsNotExist(err) {
 		// No custom rules file, use defaults
@@ -164,13 +179,14 @@ func (v *Validator) LoadRules() error {
 	}
 
 	v.rules = rules
+	v.rootSource = RulesFile
 	logger.Info("loaded custom validation rules", "path", rulesPath)
 	return nil
 }
 
 // SaveRules saves current rules to .sigil/rules.yml
 func (v *Validator) SaveRules() error {
-	rulesPath := filepath.Join(".sigil", "rules.yml")
+	rulesPath := filepath.FromSlash(RulesFile)
 
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(rulesPath), 0755); err != nil {
@@ -196,45 +212,78 @@ func (v *Validator) ValidateRequest(request ExecutionRequest) error {
 
 	// Validate overall limits
 	if err := v.validateSizeLimits(request); err != nil {
-		return errors.Wrap(err, errors.ErrorTypeValidation, "ValidateRequest", "size limit validation failed")
+		return wrapViolation(err, "size limit validation failed")
 	}
 
 	// Validate each file
 	for _, file := range request.Files {
 		if err := v.validateFile(file); err != nil {
-			return errors.Wrap(err, errors.ErrorTypeValidation, "ValidateRequest",
-				fmt.Sprintf("file validation failed for %s", file.Path))
+			return wrapViolation(err, fmt.Sprintf("file validation failed for %s", file.Path))
 		}
 	}
 
 	// Validate security rules
 	if err := v.validateSecurity(request); err != nil {
-		return errors.Wrap(err, errors.ErrorTypeValidation, "ValidateRequest", "security validation failed")
+		return wrapViolation(err, "security validation failed")
 	}
 
 	logger.Debug("execution request validation passed", "id", request.ID)
 	return nil
 }
 
-// validateSizeLimits validates size constraints
+// wrapViolation wraps a validation failure, carrying a rule violation's
+// location and remediation to the user
+func wrapViolation(err error, message string) error {
+	wrapped := errors.Wrap(err, errors.ErrorTypeValidation, "ValidateRequest", message)
+	if violation, ok := ViolationOf(err); ok {
+		wrapped.WithContext("location", violation.Location())
+		if violation.Rule != "" {
+			wrapped.WithContext("rule", violation.Rule)
+		}
+		if violation.Remediation != "" {
+			wrapped.WithHint(violation.Remediation)
+		}
+	}
+	return wrapped
+}
+
+// validateSizeLimits validates size constraints. The file count and total
+// size follow the root rules; each file's size follows its directory's.
 func (v *Validator) validateSizeLimits(request ExecutionRequest) error {
 	if len(request.Files) > v.rules.SizeRules.MaxFiles {
-		return fmt.Errorf("too many files: %d (max: %d)", len(request.Files), v.rules.SizeRules.MaxFiles)
+		return &RuleViolation{
+			Kind:        ViolationSize,
+			Message:     fmt.Sprintf("too many files: %d (max: %d)", len(request.Files), v.rules.SizeRules.MaxFiles),
+			Remediation: "split the change into smaller requests or raise size_rules.max_files",
+		}
 	}
 
 	var totalSize int64
 	for _, file := range request.Files {
 		fileSize := int64(len(file.Content))
 
-		if fileSize > v.rules.SizeRules.MaxFileSize {
-			return fmt.Errorf("file %s too large: %d bytes (max: %d)", file.Path, fileSize, v.rules.SizeRules.MaxFileSize)
+		rules, _, err := v.EffectiveRules(file.Path)
+		if err != nil {
+			return err
+		}
+		if fileSize > rules.SizeRules.MaxFileSize {
+			return &RuleViolation{
+				Kind:        ViolationSize,
+				File:        file.Path,
+				Message:     fmt.Sprintf("file %s too large: %d bytes (max: %d)", file.Path, fileSize, rules.SizeRules.MaxFileSize),
+				Remediation: "split the file or raise size_rules.max_file_size for its directory",
+			}
 		}
 
 		totalSize += fileSize
 	}
 
 	if totalSize > v.rules.SizeRules.MaxTotalSize {
-		return fmt.Errorf("total size too large: %d bytes (max: %d)", totalSize, v.rules.SizeRules.MaxTotalSize)
+		return &RuleViolation{
+			Kind:        ViolationSize,
+			Message:     fmt.Sprintf("total size too large: %d bytes (max: %d)", totalSize, v.rules.SizeRules.MaxTotalSize),
+			Remediation: "split the change into smaller requests or raise size_rules.max_total_size",
+		}
 	}
 
 	return nil
@@ -242,9 +291,14 @@ func (v *Validator) validateSizeLimits(request ExecutionRequest) error {
 
 // validateFile validates a single file change
 func (v *Validator) validateFile(file FileChange) error {
+	rules, _, err := v.EffectiveRules(file.Path)
+	if err != nil {
+		return err
+	}
+
 	// Check file rules
-	for _, rule := range v.rules.FileRules {
-		if matched, err := filepath.Match(rule.PathPattern, file.Path); err != nil {
+	for _, rule := range rules.FileRules {
+		if matched, err := matchRule(rule.PathPattern, rule.Source, file.Path); err != nil {
 			logger.Warn("invalid path pattern", "pattern", rule.PathPattern, "error", err)
 			continue
 		} else if matched {
@@ -255,8 +309,8 @@ func (v *Validator) validateFile(file FileChange) error {
 	}
 
 	// Check content rules
-	for _, rule := range v.rules.ContentRules {
-		if matched, err := filepath.Match(rule.PathPattern, file.Path); err != nil {
+	for _, rule := range rules.ContentRules {
+		if matched, err := matchRule(rule.PathPattern, rule.Source, file.Path); err != nil {
 			logger.Warn("invalid path pattern", "pattern", rule.PathPattern, "error", err)
 			continue
 		} else if matched {
@@ -276,7 +330,7 @@ func (v *Validator) validateFileRule(file FileChange, rule FileRule) error {
 	// Check if operation is blocked
 	for _, blocked := range rule.BlockedOps {
 		if operation == blocked {
-			return fmt.Errorf("operation %s not allowed for %s (rule: %s)", operation, file.Path, rule.Name)
+			return fileViolation(file, rule)
 		}
 	}
 
@@ -290,24 +344,41 @@ func (v *Validator) validateFileRule(file FileChange, rule FileRule) error {
 			}
 		}
 		if !allowed {
-			return fmt.Errorf("operation %s not allowed for %s (rule: %s)", operation, file.Path, rule.Name)
+			return fileViolation(file, rule)
 		}
 	}
 
 	return nil
 }
 
+// fileViolation explains an operation a file rule does not allow
+func fileViolation(file FileChange, rule FileRule) *RuleViolation {
+	return &RuleViolation{
+		Kind:        ViolationFile,
+		Rule:        rule.Name,
+		File:        file.Path,
+		Message:     fmt.Sprintf("operation %s not allowed for %s", file.Operation, file.Path),
+		Description: rule.Description,
+		Remediation: rule.Remediation,
+	}
+}
+
 // validateContentRule validates against a content rule
 func (v *Validator) validateContentRule(file FileChange, rule ContentRule) error {
 	content := file.Content
 
 	// Check blocked patterns
 	for _, pattern := range rule.BlockedPatterns {
-		if matched, err := regexp.MatchString(pattern, content); err != nil {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
 			logger.Warn("invalid regex pattern", "pattern", pattern, "error", err)
 			continue
-		} else if matched {
-			return fmt.Errorf("content in %s matches blocked pattern (rule: %s)", file.Path, rule.Name)
+		}
+		if match := re.FindStringIndex(content); match != nil {
+			violation := contentViolation(file, rule)
+			violation.Line, violation.Excerpt = locate(content, match[0], match[1], rule.Redact)
+			violation.Message = fmt.Sprintf("content in %s matches blocked pattern", violation.Location())
+			return violation
 		}
 	}
 
@@ -317,28 +388,59 @@ func (v *Validator) validateContentRule(file FileChange, rule ContentRule) error
 			logger.Warn("invalid regex pattern", "pattern", pattern, "error", err)
 			continue
 		} else if !matched && rule.Required {
-			return fmt.Errorf("content in %s missing required pattern (rule: %s)", file.Path, rule.Name)
+			violation := contentViolation(file, rule)
+			violation.Message = fmt.Sprintf("content in %s missing required pattern %s", file.Path, pattern)
+			return violation
 		}
 	}
 
 	return nil
 }
 
+// contentViolation starts the explanation of content a content rule
+// rejects
+func contentViolation(file FileChange, rule ContentRule) *RuleViolation {
+	return &RuleViolation{
+		Kind:        ViolationContent,
+		Rule:        rule.Name,
+		File:        file.Path,
+		Description: rule.Description,
+		Remediation: rule.Remediation,
+	}
+}
+
 // validateSecurity validates security rules
 func (v *Validator) validateSecurity(request ExecutionRequest) error {
 	for _, file := range request.Files {
+		rules, _, err := v.EffectiveRules(file.Path)
+		if err != nil {
+			return err
+		}
+
 		// Check blocked extensions
 		ext := strings.ToLower(filepath.Ext(file.Path))
-		for _, blocked := range v.rules.SecurityRules.BlockedExtensions {
+		for _, blocked := range rules.SecurityRules.BlockedExtensions {
 			if ext == blocked {
-				return fmt.Errorf("file extension %s not allowed for %s", ext, file.Path)
+				return &RuleViolation{
+					Kind:        ViolationSecurity,
+					Rule:        "blocked_extensions",
+					File:        file.Path,
+					Message:     fmt.Sprintf("file extension %s not allowed for %s", ext, file.Path),
+					Remediation: "binaries cannot be written by sandboxed changes; build them from source instead",
+				}
 			}
 		}
 
 		// Check blocked paths
-		for _, blocked := range v.rules.SecurityRules.BlockedPaths {
+		for _, blocked := range rules.SecurityRules.BlockedPaths {
 			if strings.HasPrefix(file.Path, blocked) {
-				return fmt.Errorf("path %s not allowed (blocked: %s)", file.Path, blocked)
+				return &RuleViolation{
+					Kind:        ViolationSecurity,
+					Rule:        "blocked_paths",
+					File:        file.Path,
+					Message:     fmt.Sprintf("path %s not allowed (blocked: %s)", file.Path, blocked),
+					Remediation: "keep changes inside the project",
+				}
 			}
 		}
 	}
@@ -365,25 +467,19 @@ func (v *Validator) ValidateCode(path string, content string) error {
 		Operation: OperationUpdate,
 	}
 
-	return v.validateFile(file)
+	if err := v.validateFile(file); err != nil {
+		return err
+	}
+
+	return v.validateSecurity(ExecutionRequest{Files: []FileChange{file}})
 }
 
 // GetRulesForPath returns applicable rules for a given path
 func (v *Validator) GetRulesForPath(path string) ([]FileRule, []ContentRule) {
-	var fileRules []FileRule
-	var contentRules []ContentRule
-
-	for _, rule := range v.rules.FileRules {
-		if matched, err := filepath.Match(rule.PathPattern, path); err == nil && matched {
-			fileRules = append(fileRules, rule)
-		}
-	}
-
-	for _, rule := range v.rules.ContentRules {
-		if matched, err := filepath.Match(rule.PathPattern, path); err == nil && matched {
-			contentRules = append(contentRules, rule)
-		}
+	rules, _, err := v.EffectiveRules(path)
+	if err != nil {
+		logger.Warn("failed to load directory rules, using root rules", "path", path, "error", err)
+		rules = v.rules
 	}
-
-	return fileRules, contentRules
+	return rules.ForPath(path)
 }
@@ -375,6 +375,26 @@ func main() {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "blocked pattern")
 	})
+
+	t.Run("credentials in nested file", func(t *testing.T) {
+		err := validator.ValidateCode("internal/config/keys.go", `package config
+
+const token = "abc123"`)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "blocked pattern")
+	})
+
+	t.Run("blocked path", func(t *testing.T) {
+		err := validator.ValidateCode("/etc/hosts", "127.0.0.1 localhost")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not allowed")
+	})
+
+	t.Run("blocked extension", func(t *testing.T) {
+		err := validator.ValidateCode("build/tool.exe", "MZ")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "extension .exe not allowed")
+	})
 }
 
 func TestValidator_GetRulesForPath(t *testing.T) {
@@ -402,10 +422,9 @@ func TestValidator_GetRulesForPath(t *testing.T) {
 	t.Run("Config file", func(t *testing.T) {
 		fileRules, contentRules := validator.GetRulesForPath("config.yml")
 
-		// Note: filepath.Match doesn't support brace expansion like {yml,yaml,json,toml}
-		// So the config rule won't match due to this limitation
-		// fileRules may be nil if no rules match
-		_ = fileRules // May be nil or empty due to pattern limitation
+		// Should match the configuration rule through its brace pattern
+		require.Len(t, fileRules, 1)
+		assert.Equal(t, "*.{yml,yaml,json,toml}", fileRules[0].PathPattern)
 
 		// Should still match universal content rules
 		assert.NotEmpty(t, contentRules)
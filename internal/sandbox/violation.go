@@ -0,0 +1,103 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Violation kinds
+const (
+	ViolationFile     = "file"
+	ViolationContent  = "content"
+	ViolationSize     = "size"
+	ViolationSecurity = "security"
+)
+
+// maxExcerpt is how much of the matched text a violation quotes
+const maxExcerpt = 80
+
+// RuleViolation explains why the validator rejected a file: the rule, where
+// in the file it matched and how to fix it
+type RuleViolation struct {
+	Kind        string `json:"kind"`
+	Rule        string `json:"rule,omitempty"`
+	File        string `json:"file"`
+	Line        int    `json:"line,omitempty"`
+	Excerpt     string `json:"excerpt,omitempty"`
+	Message     string `json:"message"`
+	Description string `json:"description,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Error implements the error interface
+func (v *RuleViolation) Error() string {
+	var sb strings.Builder
+	sb.WriteString(v.Message)
+	if v.Excerpt != "" {
+		sb.WriteString(fmt.Sprintf(": %q", v.Excerpt))
+	}
+	if v.Rule != "" {
+		sb.WriteString(fmt.Sprintf(" (rule: %s)", v.Rule))
+	}
+	return sb.String()
+}
+
+// Location returns the file and line the violation points at
+func (v *RuleViolation) Location() string {
+	if v.Line > 0 {
+		return fmt.Sprintf("%s:%d", v.File, v.Line)
+	}
+	return v.File
+}
+
+// Explain renders the violation for people reading an audit of why a
+// change was rejected
+func (v *RuleViolation) Explain() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s: %s\n", v.Location(), v.Message))
+	if v.Rule != "" {
+		sb.WriteString(fmt.Sprintf("  Rule: %s (%s)\n", v.Rule, v.Kind))
+	}
+	if v.Excerpt != "" {
+		sb.WriteString(fmt.Sprintf("  Matched: %s\n", v.Excerpt))
+	}
+	if v.Description != "" {
+		sb.WriteString(fmt.Sprintf("  Why: %s\n", v.Description))
+	}
+	if v.Remediation != "" {
+		sb.WriteString(fmt.Sprintf("  Fix: %s\n", v.Remediation))
+	}
+	return sb.String()
+}
+
+// ViolationOf returns the rule violation that caused err, if any
+func ViolationOf(err error) (*RuleViolation, bool) {
+	var violation *RuleViolation
+	if errors.As(err, &violation) {
+		return violation, true
+	}
+	return nil, false
+}
+
+// locate returns the line of content an offset falls on and the matched
+// text, trimmed to one line and redacted when the rule asks for it
+func locate(content string, start, end int, redact bool) (int, string) {
+	line := strings.Count(content[:start], "\n") + 1
+	excerpt := content[start:end]
+	if i := strings.IndexByte(excerpt, '\n'); i >= 0 {
+		excerpt = excerpt[:i]
+	}
+	excerpt = strings.TrimSpace(excerpt)
+	if redact {
+		// Keep enough to find the match without repeating a secret
+		if len(excerpt) > 4 {
+			excerpt = excerpt[:4]
+		}
+		return line, excerpt + "****"
+	}
+	if len(excerpt) > maxExcerpt {
+		excerpt = excerpt[:maxExcerpt] + "..."
+	}
+	return line, excerpt
+}
@@ -0,0 +1,88 @@
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+func TestValidator_ViolationExplainsBlockedContent(t *testing.T) {
+	validator := &Validator{rules: DefaultRules()}
+
+	err := validator.ValidateRequest(ExecutionRequest{Files: []FileChange{{
+		Path:      "cmd/main.go",
+		Content:   "package main\n\nfunc main() {\n\tos.Exit(1)\n}\n",
+		Operation: OperationUpdate,
+	}}})
+	require.Error(t, err)
+
+	violation, ok := ViolationOf(err)
+	require.True(t, ok)
+	assert.Equal(t, ViolationContent, violation.Kind)
+	assert.Equal(t, "No dangerous operations", violation.Rule)
+	assert.Equal(t, "cmd/main.go", violation.File)
+	assert.Equal(t, 4, violation.Line)
+	assert.Equal(t, "os.Exit(", violation.Excerpt)
+	assert.Equal(t, "Prevent dangerous system operations", violation.Description)
+	assert.NotEmpty(t, violation.Remediation)
+	assert.Equal(t, `content in cmd/main.go:4 matches blocked pattern: "os.Exit(" (rule: No dangerous operations)`, violation.Error())
+
+	assert.Equal(t, violation.Remediation, errors.HintOf(err))
+	assert.Contains(t, errors.FormatForUser(err, true), "location=cmd/main.go:4")
+
+	explanation := violation.Explain()
+	assert.Contains(t, explanation, "cmd/main.go:4: content in cmd/main.go:4 matches blocked pattern\n")
+	assert.Contains(t, explanation, "  Rule: No dangerous operations (content)\n")
+	assert.Contains(t, explanation, "  Matched: os.Exit(\n")
+	assert.Contains(t, explanation, "  Fix: ")
+}
+
+func TestValidator_ViolationRedactsSecrets(t *testing.T) {
+	validator := &Validator{rules: DefaultRules()}
+
+	err := validator.ValidateCode("config.go", "package config\n\nvar password = \"hunter22\"\n")
+	violation, ok := ViolationOf(err)
+	require.True(t, ok)
+	assert.Equal(t, "No credentials", violation.Rule)
+	assert.Equal(t, 3, violation.Line)
+	assert.Equal(t, "pass****", violation.Excerpt)
+	assert.NotContains(t, violation.Explain(), "hunter22")
+}
+
+func TestValidator_ViolationKinds(t *testing.T) {
+	validator := &Validator{rules: DefaultRules()}
+	validator.rules.FileRules = append(validator.rules.FileRules, FileRule{
+		Name: "Lockfiles", PathPattern: "go.sum", BlockedOps: []string{"delete"},
+		Remediation: "run go mod tidy instead",
+	})
+
+	err := validator.ValidateRequest(ExecutionRequest{Files: []FileChange{{Path: "go.sum", Operation: OperationDelete}}})
+	violation, ok := ViolationOf(err)
+	require.True(t, ok)
+	assert.Equal(t, ViolationFile, violation.Kind)
+	assert.Equal(t, "operation delete not allowed for go.sum (rule: Lockfiles)", violation.Error())
+	assert.Equal(t, "run go mod tidy instead", errors.HintOf(err))
+
+	err = validator.ValidateCode("tool.exe", "MZ")
+	violation, ok = ViolationOf(err)
+	require.True(t, ok)
+	assert.Equal(t, ViolationSecurity, violation.Kind)
+	assert.Equal(t, "tool.exe", violation.Location())
+
+	_, ok = ViolationOf(assert.AnError)
+	assert.False(t, ok)
+}
+
+func TestLocate(t *testing.T) {
+	content := "a\nb\n  first match here\nrest"
+	line, excerpt := locate(content, 6, 22, false)
+	assert.Equal(t, 3, line)
+	assert.Equal(t, "first match here", excerpt)
+
+	line, excerpt = locate("x\nline one\nline two", 2, 19, false)
+	assert.Equal(t, 2, line)
+	assert.Equal(t, "line one", excerpt)
+}
@@ -2,13 +2,16 @@
 package sandbox
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/dshills/sigil/internal/deterministic"
 	"github.com/dshills/sigil/internal/errors"
 	"github.com/dshills/sigil/internal/git"
 	"github.com/dshills/sigil/internal/logger"
@@ -19,6 +22,7 @@ type WorktreeManager struct {
 	repo      *git.Repository
 	baseDir   string
 	worktrees map[string]*Worktree
+	totalCap  int64
 }
 
 // Worktree represents a Git worktree sandbox
@@ -33,7 +37,7 @@ type Worktree struct {
 
 // NewWorktreeManager creates a new worktree manager
 func NewWorktreeManager(repo *git.Repository) (*WorktreeManager, error) {
-	baseDir := filepath.Join(".sigil", "sandbox")
+	baseDir := filepath.FromSlash(SandboxDir)
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		return nil, errors.Wrap(err, errors.ErrorTypeFS, "NewWorktreeManager", "failed to create sandbox directory")
 	}
@@ -53,6 +57,11 @@ func (wm *WorktreeManager) CreateWorktree(branchName string) (*Worktree, error)
 
 	logger.Debug("creating worktree", "id", id, "path", worktreePath, "branch", branchName)
 
+	// Make room under the disk cap before adding to it
+	if err := wm.enforceCap(); err != nil {
+		return nil, err
+	}
+
 	// Note: Fetch functionality not implemented in git.Repository yet
 	// Continue with local state
 
@@ -182,6 +191,11 @@ func (wm *WorktreeManager) CleanupOldWorktrees(maxAge time.Duration) error {
 
 // Execute runs a command in the worktree
 func (wt *Worktree) Execute(command string, args ...string) (*ExecutionResult, error) {
+	return wt.ExecuteEnv(nil, command, args...)
+}
+
+// ExecuteEnv runs a command in the worktree with env added to the environment
+func (wt *Worktree) ExecuteEnv(env map[string]string, command string, args ...string) (*ExecutionResult, error) {
 	wt.LastUsed = time.Now()
 
 	logger.Debug("executing command in worktree", "id", wt.ID, "command", command, "args", args)
@@ -191,14 +205,25 @@ func (wt *Worktree) Execute(command string, args ...string) (*ExecutionResult, e
 
 	// Set environment to ensure Git operations work correctly
 	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
 
-	// Capture both stdout and stderr
-	output, err := cmd.CombinedOutput()
+	// Capture stdout and stderr apart and as the terminal would show them
+	var stdout, stderr, combined bytes.Buffer
+	cmd.Stdout = io.MultiWriter(&stdout, &combined)
+	cmd.Stderr = io.MultiWriter(&stderr, &combined)
+	start := time.Now()
+	err := cmd.Run()
+	output := combined.Bytes()
 
 	result := &ExecutionResult{
 		Command:    fmt.Sprintf("%s %s", command, strings.Join(args, " ")),
 		Output:     string(output),
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
 		ExitCode:   0,
+		Duration:   time.Since(start),
 		WorktreeID: wt.ID,
 		Timestamp:  time.Now(),
 	}
@@ -303,12 +328,15 @@ func (wt *Worktree) Cleanup() error {
 
 // ExecutionResult represents the result of a command execution
 type ExecutionResult struct {
-	Command    string    `json:"command"`
-	Output     string    `json:"output"`
-	Error      string    `json:"error,omitempty"`
-	ExitCode   int       `json:"exit_code"`
-	WorktreeID string    `json:"worktree_id"`
-	Timestamp  time.Time `json:"timestamp"`
+	Command    string        `json:"command"`
+	Output     string        `json:"output"`
+	Stdout     string        `json:"stdout,omitempty"`
+	Stderr     string        `json:"stderr,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	ExitCode   int           `json:"exit_code"`
+	Duration   time.Duration `json:"duration"`
+	WorktreeID string        `json:"worktree_id"`
+	Timestamp  time.Time     `json:"timestamp"`
 }
 
 // Success returns true if the command executed successfully
@@ -318,15 +346,10 @@ func (er *ExecutionResult) Success() bool {
 
 // generateWorktreeID generates a unique identifier for a worktree
 func generateWorktreeID() string {
-	return fmt.Sprintf("%d-%s", time.Now().Unix(), randomString(8))
+	return fmt.Sprintf("%d-%s", deterministic.Now().Unix(), randomString(8))
 }
 
 // randomString generates a random string of given length
 func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
-	}
-	return string(b)
+	return deterministic.Token(length)
 }
@@ -0,0 +1,232 @@
+// Package secrets stores credentials outside of project configuration so
+// they can be referenced by name instead of being written into YAML files.
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of environment variables that override stored
+// secrets, e.g. SIGIL_SECRET_GITHUB_TOKEN for "github_token"
+const EnvPrefix = "SIGIL_SECRET_"
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// refPattern matches a configuration value that is a reference to a
+// stored secret
+var refPattern = regexp.MustCompile(`^\$\{secret:([A-Za-z0-9_.-]+)\}$`)
+
+// Store is a file-backed secret store readable only by the current user
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// DefaultPath returns the location of the user's secret store
+func DefaultPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".config", "sigil", "secrets.yml")
+}
+
+// NewStore creates a store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Default returns the store at DefaultPath
+func Default() *Store {
+	return NewStore(DefaultPath())
+}
+
+// Path returns the file backing the store
+func (s *Store) Path() string {
+	return s.path
+}
+
+// ValidateName checks that name can be stored and referenced
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return errors.ValidationError("ValidateName",
+			fmt.Sprintf("invalid secret name %q: use letters, digits, '_', '-' and '.'", name))
+	}
+	return nil
+}
+
+// envName returns the environment variable that overrides name
+func envName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// Ref returns the reference to the secret called name, for configuration
+// values such as API keys
+func Ref(name string) string {
+	return "${secret:" + name + "}"
+}
+
+// ParseRef returns the name of the secret value references as
+// ${secret:name}, and whether it is such a reference
+func ParseRef(value string) (string, bool) {
+	match := refPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// Get returns the secret called name, or an error matching errors.ErrNotFound.
+// An environment override takes precedence over the stored value so CI can
+// inject secrets without a file.
+func (s *Store) Get(name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	if value, ok := os.LookupEnv(envName(name)); ok {
+		return value, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[name]
+	if !ok {
+		return "", errors.Wrap(errors.ErrNotFound, errors.ErrorTypeConfig, "Get", fmt.Sprintf("secret %q is not set", name)).
+			WithCode(errors.CodeConfig).
+			WithHint(fmt.Sprintf("Run 'sigil secret set %s' or export %s", name, envName(name)))
+	}
+	return value, nil
+}
+
+// Set stores value under name
+func (s *Store) Set(name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, err := s.load()
+	if err != nil {
+		return err
+	}
+	values[name] = value
+	return s.save(values)
+}
+
+// Delete removes name from the store. Deleting a missing secret is not an error.
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[name]; !ok {
+		return nil
+	}
+	delete(values, name)
+	return s.save(values)
+}
+
+// List returns the names of stored secrets in sorted order
+func (s *Store) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// load reads the store, returning an empty map if the file does not exist yet
+func (s *Store) load() (map[string]string, error) {
+	values := make(map[string]string)
+	if s.path == "" {
+		return values, nil
+	}
+
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "load", "failed to read secret store")
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		logger.Warn("secret store is accessible by other users", "path", s.path, "mode", info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "load", "failed to read secret store")
+	}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeConfig, "load", "failed to parse secret store")
+	}
+	if values == nil {
+		values = make(map[string]string)
+	}
+	return values, nil
+}
+
+// save writes the store with owner-only permissions
+func (s *Store) save(values map[string]string) error {
+	if s.path == "" {
+		return errors.ConfigError("save", "no location for the secret store (home directory unknown)")
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "save", "failed to create secret store directory")
+	}
+
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "save", "failed to encode secret store")
+	}
+
+	// Write to a private temporary file and rename so a crash never leaves a partial store
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".secrets-*")
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "save", "failed to write secret store")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, errors.ErrorTypeFS, "save", "failed to write secret store")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "save", "failed to write secret store")
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "save", "failed to secure secret store")
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "save", "failed to write secret store")
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_SetGetDelete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "secrets.yml")
+	store := NewStore(path)
+
+	_, err := store.Get("github_token")
+	assert.ErrorIs(t, err, errors.ErrNotFound)
+
+	require.NoError(t, store.Set("github_token", "ghp_123"))
+	require.NoError(t, store.Set("db.password", "hunter2"))
+
+	value, err := store.Get("github_token")
+	require.NoError(t, err)
+	assert.Equal(t, "ghp_123", value)
+
+	names, err := store.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db.password", "github_token"}, names)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, store.Delete("github_token"))
+	require.NoError(t, store.Delete("github_token"))
+	_, err = store.Get("github_token")
+	assert.ErrorIs(t, err, errors.ErrNotFound)
+}
+
+func TestStore_EnvOverride(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "secrets.yml"))
+	require.NoError(t, store.Set("api-key", "stored"))
+
+	t.Setenv("SIGIL_SECRET_API_KEY", "from-env")
+	value, err := store.Get("api-key")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"token", "GITHUB_TOKEN", "db.password", "api-key-2"} {
+		assert.NoError(t, ValidateName(name), name)
+	}
+	for _, name := range []string{"", "has space", "a/b", "x}"} {
+		assert.Error(t, ValidateName(name), name)
+	}
+
+	store := NewStore(filepath.Join(t.TempDir(), "secrets.yml"))
+	assert.Error(t, store.Set("bad name", "value"))
+}
+
+func TestParseRef(t *testing.T) {
+	name, ok := ParseRef(Ref("anthropic_api_key"))
+	assert.True(t, ok)
+	assert.Equal(t, "anthropic_api_key", name)
+
+	for _, value := range []string{"sk-plain", "${ANTHROPIC_API_KEY}", "prefix ${secret:key}", "${secret:bad name}"} {
+		_, ok := ParseRef(value)
+		assert.False(t, ok, value)
+	}
+}
@@ -0,0 +1,265 @@
+// Package tasks loads task templates: recurring agent tasks a team codifies
+// once in .sigil/tasks and runs with parameters, such as a security sweep
+// of one directory.
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// Dir holds a repository's task templates, one YAML file per template
+const Dir = ".sigil/tasks"
+
+var (
+	// placeholder matches a {{param}} reference
+	placeholder = regexp.MustCompile(`{{\s*([A-Za-z_][A-Za-z0-9_-]*)\s*}}`)
+
+	// paramName matches a valid parameter name
+	paramName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+)
+
+// Template is a reusable task. Its text and file globs may reference
+// parameters as {{name}}.
+type Template struct {
+	// Name of the template, from its file name
+	Name string `yaml:"-"`
+
+	// What the task asks for
+	Description string `yaml:"description"`
+
+	// Task type (edit, generate, refactor, document, test, review, optimize,
+	// analyze)
+	Type string `yaml:"type"`
+
+	// Parameters, with their descriptions and defaults. Parameters
+	// referenced without a declaration are required.
+	Params []Param `yaml:"params,omitempty"`
+
+	// Further requirements the result must meet
+	Requirements []string `yaml:"requirements,omitempty"`
+
+	// Constraints on how the task is done
+	Constraints []Constraint `yaml:"constraints,omitempty"`
+
+	// Files the task works on, as globs relative to the repository root,
+	// when none are given on the command line
+	Files []string `yaml:"files,omitempty"`
+}
+
+// Param declares a template parameter
+type Param struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description,omitempty"`
+	Default     *string `yaml:"default,omitempty"`
+}
+
+// Constraint is a constraint on a task, such as a security requirement
+type Constraint struct {
+	Type        string `yaml:"type"`
+	Description string `yaml:"description"`
+	Severity    string `yaml:"severity,omitempty"`
+}
+
+// Path returns the file of the template name in the repository at root
+func Path(root, name string) string {
+	return filepath.Join(root, Dir, name+".yml")
+}
+
+// Load reads the template name from the repository at root
+func Load(root, name string) (*Template, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, errors.ValidationError("Load", fmt.Sprintf("invalid task template name: %q", name))
+	}
+	path := Path(root, name)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if alternative := strings.TrimSuffix(path, ".yml") + ".yaml"; fileExists(alternative) {
+			path = alternative
+		}
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.New(errors.ErrorTypeFS, "Load", fmt.Sprintf("task template not found: %s", name)).
+			WithCode(errors.CodeNotFound).
+			WithHint(fmt.Sprintf("templates are the YAML files in %s; list them with 'sigil task list'", Dir))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "Load", "failed to read task template")
+	}
+	return parse(name, data)
+}
+
+// List reads every template of the repository at root, by name
+func List(root string) ([]*Template, error) {
+	entries, err := os.ReadDir(filepath.Join(root, Dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "List", "failed to read task templates")
+	}
+
+	var templates []*Template
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, Dir, entry.Name()))
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeFS, "List", "failed to read task template")
+		}
+		template, err := parse(strings.TrimSuffix(entry.Name(), ext), data)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// parse decodes and checks a template
+func parse(name string, data []byte) (*Template, error) {
+	var template Template
+	if err := yaml.Unmarshal(data, &template); err != nil {
+		return nil, errors.ConfigError("parse", fmt.Sprintf("invalid task template %s: %v", name, err))
+	}
+	template.Name = name
+
+	if strings.TrimSpace(template.Description) == "" {
+		return nil, errors.ConfigError("parse", fmt.Sprintf("task template %s has no description", name))
+	}
+	if template.Type == "" {
+		return nil, errors.ConfigError("parse", fmt.Sprintf("task template %s has no type", name)).
+			WithHint("set type to edit, generate, refactor, document, test, review, optimize or analyze")
+	}
+	seen := make(map[string]bool)
+	for _, param := range template.Params {
+		if !paramName.MatchString(param.Name) {
+			return nil, errors.ConfigError("parse", fmt.Sprintf("task template %s: invalid parameter name %q", name, param.Name))
+		}
+		if seen[param.Name] {
+			return nil, errors.ConfigError("parse", fmt.Sprintf("task template %s declares parameter %s twice", name, param.Name))
+		}
+		seen[param.Name] = true
+	}
+	for _, constraint := range template.Constraints {
+		if constraint.Type == "" || constraint.Description == "" {
+			return nil, errors.ConfigError("parse", fmt.Sprintf("task template %s has a constraint without a type or description", name))
+		}
+	}
+	return &template, nil
+}
+
+// Parameters returns the template's parameters: the declared ones, then
+// those only referenced, in order of first reference
+func (t *Template) Parameters() []Param {
+	params := append([]Param{}, t.Params...)
+	declared := make(map[string]bool)
+	for _, param := range t.Params {
+		declared[param.Name] = true
+	}
+	for _, text := range t.texts() {
+		for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+			if !declared[match[1]] {
+				declared[match[1]] = true
+				params = append(params, Param{Name: match[1]})
+			}
+		}
+	}
+	return params
+}
+
+// Render returns the template with its parameters substituted. Values not
+// given fall back to the parameter defaults; parameters with neither, and
+// values for parameters the template does not have, are errors.
+func (t *Template) Render(values map[string]string) (*Template, error) {
+	params := t.Parameters()
+	resolved := make(map[string]string, len(params))
+	known := make([]string, 0, len(params))
+	var missing []string
+	for _, param := range params {
+		known = append(known, param.Name)
+		value, ok := values[param.Name]
+		if !ok && param.Default != nil {
+			value, ok = *param.Default, true
+		}
+		if !ok {
+			missing = append(missing, param.Name)
+			continue
+		}
+		resolved[param.Name] = value
+	}
+
+	var unknown []string
+	for name := range values {
+		if _, ok := resolved[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	if len(unknown) > 0 {
+		return nil, errors.ValidationError("Render", fmt.Sprintf("task template %s has no parameter %s", t.Name, strings.Join(unknown, ", "))).
+			WithHint(parametersHint(known))
+	}
+	if len(missing) > 0 {
+		return nil, errors.ValidationError("Render", fmt.Sprintf("task template %s needs parameter %s", t.Name, strings.Join(missing, ", "))).
+			WithHint(fmt.Sprintf("give it with --param %s=<value>", missing[0]))
+	}
+
+	substitute := func(text string) string {
+		return placeholder.ReplaceAllStringFunc(text, func(match string) string {
+			return resolved[placeholder.FindStringSubmatch(match)[1]]
+		})
+	}
+	rendered := &Template{
+		Name:        t.Name,
+		Description: substitute(t.Description),
+		Type:        t.Type,
+		Params:      t.Params,
+	}
+	for _, requirement := range t.Requirements {
+		rendered.Requirements = append(rendered.Requirements, substitute(requirement))
+	}
+	for _, constraint := range t.Constraints {
+		constraint.Description = substitute(constraint.Description)
+		rendered.Constraints = append(rendered.Constraints, constraint)
+	}
+	for _, glob := range t.Files {
+		rendered.Files = append(rendered.Files, substitute(glob))
+	}
+	return rendered, nil
+}
+
+// texts returns every text of the template parameters may appear in
+func (t *Template) texts() []string {
+	texts := []string{t.Description}
+	texts = append(texts, t.Requirements...)
+	for _, constraint := range t.Constraints {
+		texts = append(texts, constraint.Description)
+	}
+	return append(texts, t.Files...)
+}
+
+// parametersHint lists the parameters a template takes
+func parametersHint(names []string) string {
+	if len(names) == 0 {
+		return "the template takes no parameters"
+	}
+	return "its parameters are " + strings.Join(names, ", ")
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,104 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+const sweep = `description: Find injection flaws in {{ scope }}
+type: review
+params:
+  - name: scope
+    description: Directory to sweep
+    default: ./
+requirements:
+  - Fix what {{owner}} owns
+constraints:
+  - type: security
+    description: Keep {{scope}} validation
+    severity: error
+files: ["{{scope}}**/*.go"]
+`
+
+func writeTemplate(t *testing.T, root, file, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, Dir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, Dir, file), []byte(content), 0644))
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	writeTemplate(t, root, "security-sweep.yml", sweep)
+	writeTemplate(t, root, "docs.yaml", "description: Document {{pkg}}\ntype: document\n")
+
+	template, err := Load(root, "security-sweep")
+	require.NoError(t, err)
+	assert.Equal(t, "security-sweep", template.Name)
+	assert.Equal(t, "review", template.Type)
+
+	template, err = Load(root, "docs")
+	require.NoError(t, err)
+	assert.Equal(t, "document", template.Type)
+
+	_, err = Load(root, "missing")
+	assert.Equal(t, errors.CodeNotFound, errors.CodeOf(err))
+	_, err = Load(root, "../secrets")
+	assert.ErrorContains(t, err, "invalid task template name")
+
+	writeTemplate(t, root, "untyped.yml", "description: x\n")
+	_, err = Load(root, "untyped")
+	assert.ErrorContains(t, err, "task template untyped has no type")
+}
+
+func TestList(t *testing.T) {
+	root := t.TempDir()
+	templates, err := List(root)
+	require.NoError(t, err)
+	assert.Empty(t, templates)
+
+	writeTemplate(t, root, "security-sweep.yml", sweep)
+	writeTemplate(t, root, "docs.yaml", "description: Document {{pkg}}\ntype: document\n")
+	writeTemplate(t, root, "README.md", "not a template")
+	templates, err = List(root)
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "docs", templates[0].Name)
+	assert.Equal(t, "security-sweep", templates[1].Name)
+}
+
+func TestTemplate_Render(t *testing.T) {
+	template, err := parse("security-sweep", []byte(sweep))
+	require.NoError(t, err)
+
+	params := template.Parameters()
+	require.Len(t, params, 2)
+	assert.Equal(t, "scope", params[0].Name)
+	assert.Equal(t, "owner", params[1].Name, "referenced parameters need no declaration")
+	assert.Nil(t, params[1].Default)
+
+	rendered, err := template.Render(map[string]string{"scope": "payments/", "owner": "billing"})
+	require.NoError(t, err)
+	assert.Equal(t, "Find injection flaws in payments/", rendered.Description)
+	assert.Equal(t, []string{"Fix what billing owns"}, rendered.Requirements)
+	assert.Equal(t, "Keep payments/ validation", rendered.Constraints[0].Description)
+	assert.Equal(t, []string{"payments/**/*.go"}, rendered.Files)
+	assert.Equal(t, "Keep {{scope}} validation", template.Constraints[0].Description, "the template is left alone")
+
+	rendered, err = template.Render(map[string]string{"owner": "billing"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"./**/*.go"}, rendered.Files, "defaults fill in")
+
+	_, err = template.Render(nil)
+	assert.ErrorContains(t, err, "task template security-sweep needs parameter owner")
+	assert.Contains(t, errors.HintOf(err), "--param owner=<value>")
+
+	_, err = template.Render(map[string]string{"owner": "billing", "scop": "x"})
+	assert.ErrorContains(t, err, "has no parameter scop")
+	assert.Contains(t, errors.HintOf(err), "scope, owner")
+}
@@ -0,0 +1,224 @@
+// Package telemetry records anonymous usage of sigil for adoption metrics:
+// which commands run, how long they take and how they end. It is off unless
+// the user opts in, records no code, prompts, arguments, paths or
+// identities, and never leaves the machine; users export it to share it.
+package telemetry
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// EnvVar overrides the user's choice when set, e.g. SIGIL_TELEMETRY=0 in CI
+const EnvVar = "SIGIL_TELEMETRY"
+
+// Statuses of a recorded command
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+// Event is one command run. It holds nothing beyond these fields.
+type Event struct {
+	Time     time.Time `json:"time"`
+	Command  string    `json:"command"` // e.g. "review" or "task run", without arguments
+	Duration int64     `json:"duration_ms"`
+	Status   string    `json:"status"`
+	Code     string    `json:"code,omitempty"` // Error code of a failed command, e.g. SIG201
+	Version  string    `json:"version"`
+}
+
+// settings is the user's choice, kept next to the events
+type settings struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// Store keeps the telemetry choice and events of a user in a directory
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns the directory of the user's telemetry
+func DefaultDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".config", "sigil", "telemetry")
+}
+
+// NewStore creates a store in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Default returns the store in DefaultDir
+func Default() *Store {
+	return NewStore(DefaultDir())
+}
+
+// EventsPath returns the file events are appended to, one JSON object per
+// line
+func (s *Store) EventsPath() string {
+	return filepath.Join(s.dir, "events.jsonl")
+}
+
+// settingsPath returns the file the user's choice is kept in
+func (s *Store) settingsPath() string {
+	return filepath.Join(s.dir, "settings.yml")
+}
+
+// Enabled reports whether usage is recorded: as SIGIL_TELEMETRY says when
+// set, otherwise as the user chose, off by default
+func (s *Store) Enabled() bool {
+	if value, ok := os.LookupEnv(EnvVar); ok {
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		return err == nil && enabled
+	}
+	if s.dir == "" {
+		return false
+	}
+	data, err := os.ReadFile(s.settingsPath())
+	if err != nil {
+		return false
+	}
+	var choice settings
+	if err := yaml.Unmarshal(data, &choice); err != nil {
+		logger.Warn("ignoring corrupt telemetry settings", "path", s.settingsPath(), "error", err)
+		return false
+	}
+	return choice.Enabled
+}
+
+// SetEnabled records the user's choice
+func (s *Store) SetEnabled(enabled bool) error {
+	if s.dir == "" {
+		return errors.New(errors.ErrorTypeFS, "SetEnabled", "no home directory to keep telemetry settings in")
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "SetEnabled", "failed to create telemetry directory")
+	}
+	data, err := yaml.Marshal(settings{Enabled: enabled})
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "SetEnabled", "failed to encode telemetry settings")
+	}
+	if err := os.WriteFile(s.settingsPath(), data, 0600); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "SetEnabled", "failed to write telemetry settings")
+	}
+	return nil
+}
+
+// Record appends an event when telemetry is enabled
+func (s *Store) Record(event Event) error {
+	if !s.Enabled() {
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Record", "failed to create telemetry directory")
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeInternal, "Record", "failed to encode telemetry event")
+	}
+	file, err := os.OpenFile(s.EventsPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Record", "failed to open telemetry events")
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Record", "failed to write telemetry event")
+	}
+	return nil
+}
+
+// Events returns the recorded events, oldest first. Unreadable lines are
+// skipped.
+func (s *Store) Events() ([]Event, error) {
+	file, err := os.Open(s.EventsPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "Events", "failed to open telemetry events")
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "Events", "failed to read telemetry events")
+	}
+	return events, nil
+}
+
+// Clear deletes the recorded events
+func (s *Store) Clear() error {
+	if err := os.Remove(s.EventsPath()); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, errors.ErrorTypeFS, "Clear", "failed to delete telemetry events")
+	}
+	return nil
+}
+
+// CommandSummary is the usage of one command
+type CommandSummary struct {
+	Command     string         `json:"command"`
+	Runs        int            `json:"runs"`
+	Failures    int            `json:"failures"`
+	AvgDuration int64          `json:"avg_duration_ms"`
+	ErrorCodes  map[string]int `json:"error_codes,omitempty"`
+}
+
+// Summarize returns the usage of each command, most run first
+func Summarize(events []Event) []CommandSummary {
+	byCommand := make(map[string]*CommandSummary)
+	totals := make(map[string]int64)
+	for _, event := range events {
+		summary, ok := byCommand[event.Command]
+		if !ok {
+			summary = &CommandSummary{Command: event.Command}
+			byCommand[event.Command] = summary
+		}
+		summary.Runs++
+		totals[event.Command] += event.Duration
+		if event.Status == StatusError {
+			summary.Failures++
+		}
+		if event.Code != "" {
+			if summary.ErrorCodes == nil {
+				summary.ErrorCodes = make(map[string]int)
+			}
+			summary.ErrorCodes[event.Code]++
+		}
+	}
+
+	summaries := make([]CommandSummary, 0, len(byCommand))
+	for command, summary := range byCommand {
+		summary.AvgDuration = totals[command] / int64(summary.Runs)
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Runs != summaries[j].Runs {
+			return summaries[i].Runs > summaries[j].Runs
+		}
+		return summaries[i].Command < summaries[j].Command
+	})
+	return summaries
+}
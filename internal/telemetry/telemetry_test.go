@@ -0,0 +1,68 @@
+package telemetry
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_OptIn(t *testing.T) {
+	t.Setenv(EnvVar, "")
+	os.Unsetenv(EnvVar)
+	store := NewStore(t.TempDir())
+	event := Event{Time: time.Now(), Command: "review", Duration: 1200, Status: StatusSuccess, Version: "v1.0.0"}
+
+	assert.False(t, store.Enabled(), "telemetry is off by default")
+	require.NoError(t, store.Record(event))
+	events, err := store.Events()
+	require.NoError(t, err)
+	assert.Empty(t, events, "nothing is recorded before opting in")
+
+	require.NoError(t, store.SetEnabled(true))
+	assert.True(t, store.Enabled())
+	require.NoError(t, store.Record(event))
+	require.NoError(t, store.Record(event))
+	events, err = store.Events()
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	info, err := os.Stat(store.EventsPath())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, store.Clear())
+	events, err = store.Events()
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestStore_EnvOverride(t *testing.T) {
+	store := NewStore(t.TempDir())
+	require.NoError(t, store.SetEnabled(true))
+
+	t.Setenv(EnvVar, "0")
+	assert.False(t, store.Enabled())
+
+	require.NoError(t, store.SetEnabled(false))
+	t.Setenv(EnvVar, "true")
+	assert.True(t, store.Enabled())
+
+	t.Setenv(EnvVar, "maybe")
+	assert.False(t, store.Enabled(), "an unclear value leaves telemetry off")
+}
+
+func TestSummarize(t *testing.T) {
+	events := []Event{
+		{Command: "review", Duration: 1000, Status: StatusSuccess},
+		{Command: "review", Duration: 3000, Status: StatusError, Code: "SIG201"},
+		{Command: "commit", Duration: 500, Status: StatusSuccess},
+	}
+
+	summaries := Summarize(events)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, CommandSummary{Command: "review", Runs: 2, Failures: 1, AvgDuration: 2000, ErrorCodes: map[string]int{"SIG201": 1}}, summaries[0])
+	assert.Equal(t, CommandSummary{Command: "commit", Runs: 1, AvgDuration: 500}, summaries[1])
+}
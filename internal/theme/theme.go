@@ -0,0 +1,314 @@
+// Package theme colors sigil's terminal output: headings, severity labels
+// and diffs in review, diff and doc output. Output is colored on terminals
+// unless NO_COLOR is set or TERM is dumb, and can be forced on or off; the
+// colors come from a theme the configuration can change.
+package theme
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// Mode decides when output is colored
+type Mode string
+
+// Color modes
+const (
+	ModeAuto   Mode = "auto" // On terminals, unless NO_COLOR is set or TERM is dumb
+	ModeAlways Mode = "always"
+	ModeNever  Mode = "never"
+)
+
+// Role is what a piece of output is, which decides its color
+type Role string
+
+// Roles a theme colors
+const (
+	Heading  Role = "heading"
+	Critical Role = "critical"
+	Error    Role = "error"
+	Warning  Role = "warning"
+	Info     Role = "info"
+	Added    Role = "added"
+	Removed  Role = "removed"
+	Hunk     Role = "hunk" // A diff's @@ lines
+	Meta     Role = "meta" // A diff's file headers
+)
+
+// Roles lists the roles in the order they are documented
+var Roles = []Role{Heading, Critical, Error, Warning, Info, Added, Removed, Hunk, Meta}
+
+// Theme maps roles to SGR parameters, such as "1;31" for bold red. Roles
+// without parameters are not colored.
+type Theme map[Role]string
+
+// Default returns the theme used unless the configuration changes it
+func Default() Theme {
+	return Theme{
+		Heading:  "1;36",
+		Critical: "1;35",
+		Error:    "1;31",
+		Warning:  "33",
+		Info:     "34",
+		Added:    "32",
+		Removed:  "31",
+		Hunk:     "36",
+		Meta:     "1",
+	}
+}
+
+// attributes are the words of a color, with their SGR parameters
+var attributes = map[string]string{
+	"bold": "1", "dim": "2", "italic": "3", "underline": "4",
+	"black": "30", "red": "31", "green": "32", "yellow": "33",
+	"blue": "34", "magenta": "35", "cyan": "36", "white": "37",
+	"bright-black": "90", "bright-red": "91", "bright-green": "92", "bright-yellow": "93",
+	"bright-blue": "94", "bright-magenta": "95", "bright-cyan": "96", "bright-white": "97",
+}
+
+// ParseColor reads a color such as "bold red" into SGR parameters. A
+// number picks one of the terminal's 256 colors, and "none" is no color.
+func ParseColor(color string) (string, error) {
+	words := strings.Fields(strings.ToLower(color))
+	if len(words) == 1 && words[0] == "none" {
+		return "", nil
+	}
+	if len(words) == 0 {
+		return "", errors.ValidationError("ParseColor", "empty color").
+			WithHint("use words such as bold, red or bright-blue, a 256-color number, or none")
+	}
+
+	params := make([]string, 0, len(words))
+	for _, word := range words {
+		if param, ok := attributes[word]; ok {
+			params = append(params, param)
+			continue
+		}
+		if n, err := strconv.Atoi(word); err == nil && n >= 0 && n <= 255 {
+			params = append(params, fmt.Sprintf("38;5;%d", n))
+			continue
+		}
+		return "", errors.ValidationError("ParseColor", fmt.Sprintf("unknown color %q in %q", word, color)).
+			WithHint("use words such as bold, red or bright-blue, a 256-color number, or none")
+	}
+	return strings.Join(params, ";"), nil
+}
+
+// ParseMode reads a color mode, where empty means auto
+func ParseMode(mode string) (Mode, error) {
+	switch Mode(strings.ToLower(mode)) {
+	case "", ModeAuto:
+		return ModeAuto, nil
+	case ModeAlways:
+		return ModeAlways, nil
+	case ModeNever:
+		return ModeNever, nil
+	}
+	return "", errors.ValidationError("ParseMode", fmt.Sprintf("invalid color mode: %s (valid: auto, always, never)", mode))
+}
+
+// WithColors returns a copy of the theme with colors, by role name, in
+// place of its own
+func (t Theme) WithColors(colors map[string]string) (Theme, error) {
+	theme := make(Theme, len(t))
+	for role, params := range t {
+		theme[role] = params
+	}
+	for name, color := range colors {
+		role := Role(strings.ToLower(name))
+		if _, ok := t[role]; !ok {
+			return nil, errors.ValidationError("WithColors", fmt.Sprintf("unknown theme role: %s", name)).
+				WithHint(fmt.Sprintf("theme roles are %s", joinRoles()))
+		}
+		params, err := ParseColor(color)
+		if err != nil {
+			return nil, err
+		}
+		theme[role] = params
+	}
+	return theme, nil
+}
+
+// joinRoles lists the roles for messages
+func joinRoles() string {
+	names := make([]string, len(Roles))
+	for i, role := range Roles {
+		names[i] = string(role)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Paint wraps text in the role's color
+func (t Theme) Paint(role Role, text string) string {
+	params := t[role]
+	if params == "" || text == "" {
+		return text
+	}
+	return "\x1b[" + params + "m" + text + "\x1b[0m"
+}
+
+var (
+	mu      sync.Mutex
+	current = Default()
+	mode    = ModeAuto
+)
+
+// Set changes the process's theme and color mode
+func Set(theme Theme, m Mode) {
+	mu.Lock()
+	defer mu.Unlock()
+	current, mode = theme, m
+}
+
+// Current returns the process's theme and color mode
+func Current() (Theme, Mode) {
+	mu.Lock()
+	defer mu.Unlock()
+	return current, mode
+}
+
+// Enabled reports whether output written to out is colored
+func Enabled(out io.Writer) bool {
+	_, m := Current()
+	switch m {
+	case ModeAlways:
+		return true
+	case ModeNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Paint colors text in the role's color when out is colored
+func Paint(out io.Writer, role Role, text string) string {
+	if !Enabled(out) {
+		return text
+	}
+	theme, _ := Current()
+	return theme.Paint(role, text)
+}
+
+// Render colors text for out when out is colored
+func Render(out io.Writer, text string) string {
+	if !Enabled(out) {
+		return text
+	}
+	theme, _ := Current()
+	return theme.Render(text)
+}
+
+// severityLabel matches the severity of a finding as reports write it,
+// such as **error** or [critical]
+var severityLabel = regexp.MustCompile(`(?i)(\*\*|\[)(critical|error|warning|info|high|medium|low)(\*\*|\])`)
+
+// severityRoles maps severities, including the names models often use, to
+// roles
+var severityRoles = map[string]Role{
+	"critical": Critical,
+	"error":    Error,
+	"high":     Error,
+	"warning":  Warning,
+	"medium":   Warning,
+	"info":     Info,
+	"low":      Info,
+}
+
+// Render colors a markdown or plain text report: headings, whether marked
+// with # or underlined, severity labels, and unified diffs, whether fenced
+// as diff blocks or not. Other code blocks are left alone.
+func (t Theme) Render(text string) string {
+	lines := strings.Split(text, "\n")
+	fenced, inDiff := false, false
+	for i, line := range lines {
+		next := ""
+		if i+1 < len(lines) {
+			next = lines[i+1]
+		}
+
+		switch {
+		case strings.HasPrefix(line, "```"):
+			if fenced {
+				fenced, inDiff = false, false
+			} else {
+				lang := strings.TrimSpace(strings.TrimPrefix(line, "```"))
+				fenced, inDiff = true, lang == "diff" || lang == "patch"
+			}
+		case fenced:
+			if inDiff {
+				lines[i] = t.diffLine(line)
+			}
+		case inDiff && isDiffLine(line), startsDiff(line, next):
+			inDiff = true
+			lines[i] = t.diffLine(line)
+		default:
+			inDiff = false
+			if strings.HasPrefix(line, "#") || (strings.TrimSpace(line) != "" && isUnderline(next)) ||
+				(i > 0 && isUnderline(line) && strings.TrimSpace(lines[i-1]) != "") {
+				lines[i] = t.Paint(Heading, line)
+				continue
+			}
+			lines[i] = severityLabel.ReplaceAllStringFunc(line, func(label string) string {
+				word := strings.ToLower(strings.Trim(label, "*[]"))
+				return t.Paint(severityRoles[word], label)
+			})
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// diffLine colors one line of a unified diff
+func (t Theme) diffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "diff "),
+		strings.HasPrefix(line, "index "):
+		return t.Paint(Meta, line)
+	case strings.HasPrefix(line, "@@"):
+		return t.Paint(Hunk, line)
+	case strings.HasPrefix(line, "+"):
+		return t.Paint(Added, line)
+	case strings.HasPrefix(line, "-"):
+		return t.Paint(Removed, line)
+	}
+	return line
+}
+
+// startsDiff reports whether a unified diff starts at line
+func startsDiff(line, next string) bool {
+	return strings.HasPrefix(line, "diff --git ") || strings.HasPrefix(line, "@@ -") ||
+		(strings.HasPrefix(line, "--- ") && strings.HasPrefix(next, "+++ "))
+}
+
+// diffPrefixes start the lines a unified diff continues with
+var diffPrefixes = []string{" ", "+", "-", "@@", `\`, "diff ", "index ", "new file", "deleted file",
+	"similarity", "rename ", "old mode", "new mode", "Binary files"}
+
+// isDiffLine reports whether line continues a unified diff
+func isDiffLine(line string) bool {
+	for _, prefix := range diffPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnderline reports whether line underlines a heading, as in ==== or ----
+func isUnderline(line string) bool {
+	line = strings.TrimSpace(line)
+	return len(line) >= 3 && (strings.Trim(line, "=") == "" || strings.Trim(line, "-") == "")
+}
@@ -0,0 +1,102 @@
+package theme
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColor(t *testing.T) {
+	params, err := ParseColor("Bold bright-red")
+	require.NoError(t, err)
+	assert.Equal(t, "1;91", params)
+
+	params, err = ParseColor("underline 208")
+	require.NoError(t, err)
+	assert.Equal(t, "4;38;5;208", params)
+
+	params, err = ParseColor("none")
+	require.NoError(t, err)
+	assert.Empty(t, params)
+
+	_, err = ParseColor("purple")
+	assert.ErrorContains(t, err, `unknown color "purple"`)
+	_, err = ParseColor("300")
+	assert.Error(t, err)
+}
+
+func TestTheme_WithColors(t *testing.T) {
+	theme, err := Default().WithColors(map[string]string{"Error": "magenta", "info": "none"})
+	require.NoError(t, err)
+	assert.Equal(t, "35", theme[Error])
+	assert.Equal(t, "info", theme.Paint(Info, "info"), "roles without a color are not painted")
+	assert.Equal(t, "1;31", Default()[Error], "the default theme is unchanged")
+
+	_, err = Default().WithColors(map[string]string{"title": "red"})
+	assert.ErrorContains(t, err, "unknown theme role: title")
+}
+
+func TestEnabled(t *testing.T) {
+	defer Set(Default(), ModeAuto)
+	var buf bytes.Buffer
+
+	Set(Default(), ModeAuto)
+	assert.False(t, Enabled(&buf), "only terminals are colored")
+	assert.Equal(t, "text", Render(&buf, "text"))
+
+	Set(Default(), ModeAlways)
+	t.Setenv("NO_COLOR", "1")
+	assert.True(t, Enabled(&buf), "always overrides NO_COLOR")
+	assert.Equal(t, "\x1b[32m+x\x1b[0m", Paint(&buf, Added, "+x"))
+
+	Set(Default(), ModeNever)
+	assert.False(t, Enabled(&buf))
+}
+
+func TestTheme_Render(t *testing.T) {
+	theme := Theme{Heading: "H", Error: "E", Critical: "C", Added: "A", Removed: "R", Hunk: "U", Meta: "M"}
+	paint := func(params, text string) string { return "\x1b[" + params + "m" + text + "\x1b[0m" }
+
+	report := strings.Join([]string{
+		"# Code Review Report",
+		"- **error** `main.go:3` leaked file",
+		"  [CRITICAL] db.go:9: SQL injection",
+		"- keep this list item",
+		"```diff",
+		"@@ -1 +1 @@",
+		"-old",
+		"+new",
+		"```",
+		"```go",
+		"-x := 1",
+		"```",
+		"Diff Content:",
+		"-------------",
+		"diff --git a/x b/x",
+		"--- a/x",
+		"+++ b/x",
+		" same",
+		"-gone",
+		"after the diff",
+	}, "\n")
+
+	lines := strings.Split(theme.Render(report), "\n")
+	assert.Equal(t, paint("H", "# Code Review Report"), lines[0])
+	assert.Equal(t, "- "+paint("E", "**error**")+" `main.go:3` leaked file", lines[1])
+	assert.Equal(t, "  "+paint("C", "[CRITICAL]")+" db.go:9: SQL injection", lines[2])
+	assert.Equal(t, "- keep this list item", lines[3])
+	assert.Equal(t, paint("U", "@@ -1 +1 @@"), lines[5])
+	assert.Equal(t, paint("R", "-old"), lines[6])
+	assert.Equal(t, paint("A", "+new"), lines[7])
+	assert.Equal(t, "-x := 1", lines[10], "other code blocks are left alone")
+	assert.Equal(t, paint("H", "Diff Content:"), lines[12])
+	assert.Equal(t, paint("H", "-------------"), lines[13])
+	assert.Equal(t, paint("M", "diff --git a/x b/x"), lines[14])
+	assert.Equal(t, paint("M", "+++ b/x"), lines[16])
+	assert.Equal(t, " same", lines[17])
+	assert.Equal(t, paint("R", "-gone"), lines[18])
+	assert.Equal(t, "after the diff", lines[19])
+}
@@ -0,0 +1,262 @@
+package translate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dshills/sigil/internal/errors"
+)
+
+// Document formats whose markup is protected
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatRST      = "rst"
+	FormatAsciiDoc = "asciidoc"
+	FormatText     = "text"
+)
+
+// placeholder matches the tokens protected spans are replaced with, as
+// models sometimes pad them with spaces
+var placeholder = regexp.MustCompile(`⟦\s*(\d+)\s*⟧`)
+
+// url matches a bare URL
+const url = `[a-zA-Z][a-zA-Z0-9+.-]*://[^\s<>()\[\]` + "`" + `]+`
+
+// inlineSpans match the spans of a line kept as they are in each format:
+// code, link targets, markup and URLs
+var inlineSpans = map[string]*regexp.Regexp{
+	FormatMarkdown: regexp.MustCompile(placeholder.String() + "|``.+?``|`[^`]+`|\\]\\([^)]*\\)|^\\s*\\[[^\\]]+\\]:\\s*\\S.*$|<!--.*?-->|</?[a-zA-Z][^>]*>|" + url),
+	FormatHTML:     regexp.MustCompile(`(?is)` + placeholder.String() + `|<!--.*?-->|<pre\b.*?</pre>|<code\b.*?</code>|<script\b.*?</script>|<style\b.*?</style>|<[^>]+>|` + url),
+	FormatRST:      regexp.MustCompile(placeholder.String() + "|``.+?``|:[\\w-]+:`[^`]+`|<[^>]+>`__?|" + url),
+	FormatAsciiDoc: regexp.MustCompile(placeholder.String() + "|`[^`]+`|<<[^>]*>>|\\{[\\w-]+\\}|(?:link|xref|image|include|mailto):[^\\s\\[]*\\[|" + url),
+	FormatText:     regexp.MustCompile(placeholder.String() + "|" + url),
+}
+
+var (
+	// asciidocDelimiter matches the line opening or closing a verbatim
+	// AsciiDoc block
+	asciidocDelimiter = regexp.MustCompile(`^(-{4,}|\.{4,}|\+{4,}|/{4,})\s*$`)
+
+	// rstCodeDirective matches the directives whose body is code
+	rstCodeDirective = regexp.MustCompile(`^\s*\.\.\s+(?:code-block|code|sourcecode|literalinclude)::`)
+)
+
+// protector replaces spans with numbered placeholders
+type protector struct {
+	spans []string
+}
+
+// hold returns the placeholder standing for span
+func (p *protector) hold(span string) string {
+	p.spans = append(p.spans, span)
+	return fmt.Sprintf("⟦%d⟧", len(p.spans)-1)
+}
+
+// Protect replaces the parts of a document of format that translation must
+// not change, such as code blocks, inline code, link targets and markup,
+// with placeholders. It returns the text to translate and the spans the
+// placeholders stand for, in the order Restore expects.
+func Protect(format, text string) (string, []string) {
+	p := &protector{}
+	inline, ok := inlineSpans[format]
+	if !ok {
+		inline = inlineSpans[FormatText]
+	}
+	if format == FormatHTML {
+		return inline.ReplaceAllStringFunc(text, p.hold), p.spans
+	}
+
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); {
+		if end := blockEnd(format, lines, i); end > i {
+			out = append(out, p.hold(strings.Join(lines[i:end], "\n")))
+			i = end
+			continue
+		}
+		out = append(out, inline.ReplaceAllStringFunc(lines[i], p.hold))
+		i++
+
+		// A reStructuredText paragraph ending in "::" introduces a literal
+		// block
+		if format == FormatRST && strings.HasSuffix(strings.TrimSpace(lines[i-1]), "::") {
+			if end := indentedEnd(lines, i, indentation(lines[i-1])); end > i {
+				out = append(out, p.hold(strings.Join(lines[i:end], "\n")))
+				i = end
+			}
+		}
+	}
+	return strings.Join(out, "\n"), p.spans
+}
+
+// blockEnd returns the end of the verbatim block of format starting at
+// lines[start], or start when no block starts there
+func blockEnd(format string, lines []string, start int) int {
+	line := lines[start]
+	trimmed := strings.TrimSpace(line)
+	switch format {
+	case FormatMarkdown:
+		if start == 0 && trimmed == "---" {
+			for i := 1; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) == "---" {
+					return i + 1
+				}
+			}
+			return start
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence := trimmed[:3]
+			for i := start + 1; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					return i + 1
+				}
+			}
+			return len(lines)
+		}
+	case FormatRST:
+		if rstAdornment(line) {
+			return start + 1
+		}
+		if rstCodeDirective.MatchString(line) {
+			return indentedEnd(lines, start+1, indentation(line))
+		}
+		// Other directives and comments keep their markup line; their
+		// bodies are prose
+		if strings.HasPrefix(trimmed, ".. ") {
+			return start + 1
+		}
+	case FormatAsciiDoc:
+		if asciidocDelimiter.MatchString(line) {
+			for i := start + 1; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) == trimmed {
+					return i + 1
+				}
+			}
+			return len(lines)
+		}
+		if strings.HasPrefix(trimmed, "//") || (strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]")) ||
+			(strings.HasPrefix(trimmed, ":") && strings.Count(trimmed, ":") >= 2 && !strings.HasPrefix(trimmed, "::")) {
+			return start + 1
+		}
+	default:
+		if indentation(line) >= 4 && trimmed != "" {
+			return indentedEnd(lines, start, 3)
+		}
+	}
+	return start
+}
+
+// rstAdornment reports whether line is a section underline or overline: at
+// least three of the same punctuation character
+func rstAdornment(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	if len(line) < 3 || !strings.ContainsRune("=-~^\"'`#*+:.", rune(line[0])) {
+		return false
+	}
+	return strings.Count(line, line[:1]) == len(line)
+}
+
+// indentedEnd returns the end of the block of lines from start indented
+// deeper than indent, taking in the blank lines between them
+func indentedEnd(lines []string, start, indent int) int {
+	end := start
+	for i := start; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if indentation(lines[i]) <= indent {
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+// indentation returns the width of a line's leading whitespace, a tab
+// counting as four columns
+func indentation(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// Restore puts the protected spans back in place of their placeholders. It
+// fails when the translation dropped, repeated or invented a placeholder.
+func Restore(text string, spans []string) (string, error) {
+	seen := make([]int, len(spans))
+	unknown := 0
+	restored := placeholder.ReplaceAllStringFunc(text, func(token string) string {
+		i, err := strconv.Atoi(placeholder.FindStringSubmatch(token)[1])
+		if err != nil || i >= len(spans) {
+			unknown++
+			return token
+		}
+		seen[i]++
+		return spans[i]
+	})
+
+	missing, repeated := 0, 0
+	for _, count := range seen {
+		switch {
+		case count == 0:
+			missing++
+		case count > 1:
+			repeated++
+		}
+	}
+	if missing+repeated+unknown > 0 {
+		return "", errors.New(errors.ErrorTypeModel, "Restore",
+			fmt.Sprintf("translation changed protected content: %d missing, %d repeated, %d unknown", missing, repeated, unknown))
+	}
+	return restored, nil
+}
+
+// fixUnderlines lengthens reStructuredText section adornments that became
+// shorter than their translated titles
+func fixUnderlines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if i == 0 || !rstAdornment(line) || strings.TrimSpace(lines[i-1]) == "" || rstAdornment(lines[i-1]) {
+			continue
+		}
+		adornment := strings.TrimRight(line, " \t")
+		width := displayWidth(strings.TrimRight(lines[i-1], " \t"))
+		if width <= len(adornment) {
+			continue
+		}
+		lines[i] = strings.Repeat(adornment[:1], width)
+		// An overline matches its underline
+		if i >= 2 && strings.TrimRight(lines[i-2], " \t") == adornment {
+			lines[i-2] = lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// displayWidth returns the columns text takes up, with East Asian wide
+// characters taking two
+func displayWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+			(r >= 0x3000 && r <= 0x303f) || (r >= 0xff00 && r <= 0xff60) {
+			width += 2
+			continue
+		}
+		width++
+	}
+	return width
+}
@@ -0,0 +1,135 @@
+// Package translate translates generated documentation into other natural
+// languages. Code, links and markup are replaced with placeholders before
+// the model sees the text and put back afterwards, so only prose changes.
+package translate
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/glossary"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/model"
+)
+
+// attempts is how many times a translation is asked for before giving up
+// on one that keeps losing protected content
+const attempts = 2
+
+// languageCode matches a language tag such as "ja" or "pt-BR"
+var languageCode = regexp.MustCompile(`^[a-zA-Z]{2,3}(?:[-_][a-zA-Z0-9]{2,8})*$`)
+
+// languageNames names the languages of common codes in prompts
+var languageNames = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"hi": "Hindi",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"sv": "Swedish",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+// ValidLanguage reports whether code is a language tag, safe to use as a
+// directory name
+func ValidLanguage(code string) bool {
+	return languageCode.MatchString(code)
+}
+
+// LanguageName describes a language code for a prompt, e.g. "Japanese (ja)"
+func LanguageName(code string) string {
+	if !ValidLanguage(code) {
+		return code
+	}
+	primary := strings.ToLower(strings.FieldsFunc(code, func(r rune) bool { return r == '-' || r == '_' })[0])
+	if name, ok := languageNames[primary]; ok {
+		return fmt.Sprintf("%s (%s)", name, code)
+	}
+	return code
+}
+
+// Translator translates documents with a model
+type Translator struct {
+	Model model.Model
+}
+
+// New creates a translator using m
+func New(m model.Model) *Translator {
+	return &Translator{Model: m}
+}
+
+// Translate translates text, a document of format written in the language
+// from, into the language to. Protected content comes back unchanged, and
+// a translation that loses any of it is asked for again before failing.
+func (t *Translator) Translate(ctx context.Context, format, from, to, text string) (string, error) {
+	masked, spans := Protect(format, text)
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var response model.PromptOutput
+		response, err = t.Model.RunPrompt(ctx, model.PromptInput{
+			SystemPrompt: glossary.Instruct(prompt(format, from, to)),
+			UserPrompt:   masked,
+			Temperature:  0.2,
+		})
+		if err != nil {
+			return "", errors.Wrap(err, errors.ErrorTypeModel, "Translate", fmt.Sprintf("failed to translate into %s", to))
+		}
+
+		translated := unwrap(response.Response)
+		if strings.TrimSpace(translated) == "" {
+			err = errors.New(errors.ErrorTypeModel, "Translate", fmt.Sprintf("empty translation into %s", to))
+			continue
+		}
+		var restored string
+		if restored, err = Restore(translated, spans); err != nil {
+			logger.Debug("translation lost protected content", "language", to, "attempt", attempt, "error", err)
+			continue
+		}
+		if format == FormatRST {
+			restored = fixUnderlines(restored)
+		}
+		if strings.HasSuffix(text, "\n") && !strings.HasSuffix(restored, "\n") {
+			restored += "\n"
+		}
+		return restored, nil
+	}
+	return "", err
+}
+
+// prompt instructs the model to translate the prose of a document and keep
+// its placeholders
+func prompt(format, from, to string) string {
+	return fmt.Sprintf("You translate technical documentation written in %s into %s. "+
+		"Translate the prose only, keeping the document's structure and %s markup as they are. "+
+		"Tokens such as ⟦0⟧ stand for code, links and markup that must not change: copy every one exactly once, "+
+		"where it belongs in the translated sentence. Leave identifiers and product names untranslated. "+
+		"Return only the translated document, with no commentary.", LanguageName(from), LanguageName(to), format)
+}
+
+// unwrap removes a code fence a model wrapped its whole answer in. The
+// text it was given never starts with one, as fences are protected.
+func unwrap(response string) string {
+	trimmed := strings.TrimSpace(response)
+	if !strings.HasPrefix(trimmed, "```") || !strings.HasSuffix(trimmed, "```") || len(trimmed) < 6 {
+		return response
+	}
+	body := strings.TrimSuffix(trimmed, "```")
+	if newline := strings.Index(body, "\n"); newline >= 0 {
+		return strings.TrimSpace(body[newline+1:])
+	}
+	return response
+}
@@ -0,0 +1,129 @@
+package translate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dshills/sigil/internal/model"
+)
+
+// funcModel answers prompts with a function of the prompt
+type funcModel struct {
+	answer  func(input model.PromptInput) string
+	prompts []model.PromptInput
+}
+
+func (m *funcModel) RunPrompt(_ context.Context, input model.PromptInput) (model.PromptOutput, error) {
+	m.prompts = append(m.prompts, input)
+	return model.PromptOutput{Response: m.answer(input)}, nil
+}
+
+func (m *funcModel) GetCapabilities() model.ModelCapabilities { return model.ModelCapabilities{} }
+
+func (m *funcModel) Name() string { return "func" }
+
+func TestProtect_Markdown(t *testing.T) {
+	doc := "---\nsource_hash: abc\n---\n# Store\n\nUse `Open` as in [the guide](docs/guide.md) or https://example.com/x.\n\n```go\ns := store.Open()\n```\n"
+	masked, spans := Protect(FormatMarkdown, doc)
+	assert.Equal(t, "⟦0⟧\n# Store\n\nUse ⟦1⟧ as in [the guide⟦2⟧ or ⟦3⟧\n\n⟦4⟧\n", masked)
+	assert.Equal(t, []string{"---\nsource_hash: abc\n---", "`Open`", "](docs/guide.md)", "https://example.com/x.", "```go\ns := store.Open()\n```"}, spans)
+
+	restored, err := Restore(masked, spans)
+	require.NoError(t, err)
+	assert.Equal(t, doc, restored)
+}
+
+func TestProtect_Formats(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		doc    string
+		masked string
+	}{
+		{
+			name:   "html keeps tags and code",
+			format: FormatHTML,
+			doc:    "<h1>Store</h1>\n<p>Call <code>Open</code> first.</p>\n<pre>s.Open()\n</pre>",
+			masked: "⟦0⟧Store⟦1⟧\n⟦2⟧Call ⟦3⟧ first.⟦4⟧\n⟦5⟧",
+		},
+		{
+			name:   "rst keeps literal blocks, directives and adornments",
+			format: FormatRST,
+			doc:    "Store\n=====\n\nFor example::\n\n    s.Open()\n\n.. code-block:: go\n\n   s.Close()\n\nSee ``Open``.",
+			masked: "Store\n⟦0⟧\n\nFor example::\n⟦1⟧\n\n⟦2⟧\n\nSee ⟦3⟧.",
+		},
+		{
+			name:   "asciidoc keeps delimited blocks and attributes",
+			format: FormatAsciiDoc,
+			doc:    "= Store\n:toc:\n\n[source,go]\n----\ns.Open()\n----\n\nSee link:guide.html[the guide].",
+			masked: "= Store\n⟦0⟧\n\n⟦1⟧\n⟦2⟧\n\nSee ⟦3⟧the guide].",
+		},
+		{
+			name:   "text keeps indented blocks",
+			format: FormatText,
+			doc:    "EXAMPLES\n\n    s := store.Open()\n    defer s.Close()\n\nDone.",
+			masked: "EXAMPLES\n\n⟦0⟧\n\nDone.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masked, spans := Protect(tt.format, tt.doc)
+			assert.Equal(t, tt.masked, masked)
+			restored, err := Restore(masked, spans)
+			require.NoError(t, err)
+			assert.Equal(t, tt.doc, restored)
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	spans := []string{"`a`", "`b`"}
+
+	restored, err := Restore("⟦ 1 ⟧ then ⟦0⟧", spans)
+	require.NoError(t, err)
+	assert.Equal(t, "`b` then `a`", restored, "placeholders may move and be padded")
+
+	_, err = Restore("⟦0⟧ only", spans)
+	assert.ErrorContains(t, err, "1 missing")
+	_, err = Restore("⟦0⟧ ⟦1⟧ ⟦1⟧ ⟦7⟧", spans)
+	assert.ErrorContains(t, err, "1 repeated, 1 unknown")
+}
+
+func TestTranslator_Translate(t *testing.T) {
+	m := &funcModel{answer: func(input model.PromptInput) string {
+		translated := strings.ReplaceAll(input.UserPrompt, "Store", "ストア")
+		return "```markdown\n" + strings.ReplaceAll(translated, "Use", "使用") + "\n```"
+	}}
+	translator := New(m)
+
+	doc := "# Store\n\nUse `Store.Open`.\n\n```go\nStore{}\n```\n"
+	translated, err := translator.Translate(context.Background(), FormatMarkdown, "en", "ja", doc)
+	require.NoError(t, err)
+	assert.Equal(t, "# ストア\n\n使用 `Store.Open`.\n\n```go\nStore{}\n```\n", translated)
+	assert.Contains(t, m.prompts[0].SystemPrompt, "written in English (en)")
+	assert.Contains(t, m.prompts[0].SystemPrompt, "into Japanese (ja)")
+
+	// A translation that keeps dropping code is asked for twice, then fails
+	m.answer = func(model.PromptInput) string { return "# ストア" }
+	m.prompts = nil
+	_, err = translator.Translate(context.Background(), FormatMarkdown, "en", "ja", doc)
+	assert.ErrorContains(t, err, "protected content")
+	assert.Len(t, m.prompts, attempts)
+}
+
+func TestFixUnderlines(t *testing.T) {
+	assert.Equal(t, "======\nストア\n======\n\nText", fixUnderlines("=====\nストア\n=====\n\nText"))
+	assert.Equal(t, "Store\n=====", fixUnderlines("Store\n====="))
+}
+
+func TestLanguageName(t *testing.T) {
+	assert.Equal(t, "Portuguese (pt-BR)", LanguageName("pt-BR"))
+	assert.Equal(t, "tlh", LanguageName("tlh"))
+	assert.True(t, ValidLanguage("zh_Hant"))
+	assert.False(t, ValidLanguage("../ja"))
+	assert.False(t, ValidLanguage(""))
+}
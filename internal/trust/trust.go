@@ -0,0 +1,75 @@
+// Package trust records whether sigil treats the repository it runs in as
+// untrusted. An untrusted repository gets read-only analysis: no changes
+// applied, no commands it configures run, and no MCP tool calls made from
+// model output about its content.
+package trust
+
+import (
+	stderrors "errors"
+	"fmt"
+	"sync"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+)
+
+// ErrUntrusted is returned for operations refused in untrusted mode
+var ErrUntrusted = stderrors.New("disabled for untrusted repositories")
+
+var (
+	mu        sync.RWMutex
+	untrusted bool
+	reason    string
+)
+
+// Set puts the process in or out of untrusted mode, with why
+func Set(enabled bool, why string) {
+	mu.Lock()
+	defer mu.Unlock()
+	untrusted = enabled
+	reason = why
+	if !enabled {
+		reason = ""
+	}
+}
+
+// Untrusted reports whether the process is in untrusted mode
+func Untrusted() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return untrusted
+}
+
+// Reason returns why the process is in untrusted mode
+func Reason() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return reason
+}
+
+// Check returns an error refusing what, such as "auto-fix", in untrusted
+// mode, and nil otherwise
+func Check(op, what string) error {
+	mu.RLock()
+	defer mu.RUnlock()
+	if !untrusted {
+		return nil
+	}
+	return errors.Wrap(ErrUntrusted, errors.ErrorTypeValidation, op, what).
+		WithCode(errors.CodeUntrusted).
+		WithHint(fmt.Sprintf("sigil runs read-only because the repository is untrusted (%s); "+
+			"once you trust it, rerun with --untrusted=false", reason))
+}
+
+// Detect reports whether repo should be treated as untrusted without being
+// asked: it is a fresh clone of a remote nobody has worked in yet
+func Detect(repo *git.Repository) (bool, string) {
+	fresh, err := repo.IsFreshClone()
+	if err != nil || !fresh {
+		return false, ""
+	}
+	if url, err := repo.GetRemoteURL("origin"); err == nil && url != "" {
+		return true, fmt.Sprintf("freshly cloned from %s", url)
+	}
+	return true, "freshly cloned"
+}
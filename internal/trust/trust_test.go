@@ -0,0 +1,57 @@
+package trust
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	t.Cleanup(func() { Set(false, "") })
+
+	Set(false, "")
+	assert.NoError(t, Check("Execute", "auto-fix"))
+	assert.False(t, Untrusted())
+
+	Set(true, "--untrusted")
+	assert.True(t, Untrusted())
+	assert.Equal(t, "--untrusted", Reason())
+	err := Check("Execute", "auto-fix")
+	require.ErrorIs(t, err, ErrUntrusted)
+	assert.Equal(t, errors.CodeUntrusted, errors.CodeOf(err))
+	assert.Contains(t, errors.FormatForUser(err, false), "--untrusted=false")
+
+	Set(false, "--untrusted")
+	assert.Empty(t, Reason())
+}
+
+func TestDetect(t *testing.T) {
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+
+	originDir := t.TempDir()
+	run(originDir, "init", "-q")
+	run(originDir, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial")
+	origin, err := git.NewRepository(originDir)
+	require.NoError(t, err)
+	untrusted, _ := Detect(origin)
+	assert.False(t, untrusted)
+
+	cloneDir := filepath.Join(t.TempDir(), "clone")
+	run(originDir, "clone", "-q", "file://"+originDir, cloneDir)
+	clone, err := git.NewRepository(cloneDir)
+	require.NoError(t, err)
+	untrusted, reason := Detect(clone)
+	assert.True(t, untrusted)
+	assert.Equal(t, "freshly cloned from file://"+originDir, reason)
+}
@@ -0,0 +1,144 @@
+package verify
+
+import (
+	"strings"
+)
+
+// Evidence statuses
+const (
+	EvidenceVerified  = "verified"  // Found at the claimed lines
+	EvidenceRelocated = "relocated" // Found elsewhere in the file
+	EvidenceMismatch  = "mismatch"  // Not found in the file
+	EvidenceMissing   = "missing"   // No evidence was quoted
+)
+
+const (
+	// evidenceSimilarity is the lowest similarity of a quoted line to a
+	// source line that still counts as a match, allowing for the
+	// whitespace and small slips of a quote
+	evidenceSimilarity = 0.8
+
+	// evidenceSlack is how many lines from the claimed location quoted
+	// evidence may start and still be at it
+	evidenceSlack = 3
+
+	// evidenceWindow is how far from the claimed location fuzzy matches
+	// are looked for; further away only exact matches count
+	evidenceWindow = 30
+)
+
+// EvidenceMatch is where quoted evidence was found in a file
+type EvidenceMatch struct {
+	Status  string
+	Line    int // First line of the match, 1-based; 0 when not found
+	EndLine int
+}
+
+// Evidence checks that quoted source lines appear in a file at the claimed
+// 1-based line, matching each line fuzzily and skipping blank lines.
+// Evidence found elsewhere in the file is reported relocated, with where
+// it was found.
+func Evidence(source, evidence string, line int) EvidenceMatch {
+	quoted := normalizedLines(evidence)
+	if len(quoted) == 0 {
+		return EvidenceMatch{Status: EvidenceMissing}
+	}
+
+	// Match against the non-blank lines, remembering where each was
+	var lines []string
+	var numbers []int
+	for i, sourceLine := range strings.Split(source, "\n") {
+		if sourceLine = normalizeLine(sourceLine); sourceLine != "" {
+			lines = append(lines, sourceLine)
+			numbers = append(numbers, i+1)
+		}
+	}
+
+	// Near the claimed line small differences are allowed, and the nearest
+	// match wins
+	best, bestScore := -1, 0.0
+	for start := range lines {
+		if line <= 0 || distance(numbers[start], line) > evidenceWindow {
+			continue
+		}
+		score := matchAt(lines, quoted, start)
+		if score < evidenceSimilarity {
+			continue
+		}
+		if best < 0 || distance(numbers[start], line) < distance(numbers[best], line) ||
+			distance(numbers[start], line) == distance(numbers[best], line) && score > bestScore {
+			best, bestScore = start, score
+		}
+	}
+
+	// Anywhere else, the quote has to be exact
+	if best < 0 {
+		for start := range lines {
+			if matchAt(lines, quoted, start) == 1 {
+				best = start
+				break
+			}
+		}
+	}
+	if best < 0 {
+		return EvidenceMatch{Status: EvidenceMismatch}
+	}
+
+	match := EvidenceMatch{Status: EvidenceRelocated, Line: numbers[best], EndLine: numbers[best+len(quoted)-1]}
+	if line > 0 && distance(match.Line, line) <= evidenceSlack {
+		match.Status = EvidenceVerified
+	}
+	return match
+}
+
+// matchAt scores quoted lines against the source lines from start: the
+// mean similarity, or 0 when any line falls below the threshold
+func matchAt(lines, quoted []string, start int) float64 {
+	if start+len(quoted) > len(lines) {
+		return 0
+	}
+	total := 0.0
+	for i, quote := range quoted {
+		score := similarity(quote, lines[start+i])
+		if score < evidenceSimilarity {
+			return 0
+		}
+		total += score
+	}
+	return total / float64(len(quoted))
+}
+
+// similarity is 1 for equal strings, falling with their edit distance
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	longest := max(len(a), len(b))
+	if abs(len(a)-len(b)) > longest/4 {
+		return 0
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// normalizedLines returns the non-blank lines of quoted evidence,
+// normalized, leaving out the ellipses quotes skip lines with
+func normalizedLines(evidence string) []string {
+	var lines []string
+	for _, line := range strings.Split(evidence, "\n") {
+		line = normalizeLine(line)
+		if line != "" && line != "..." && line != "…" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// normalizeLine collapses the whitespace of a line
+func normalizeLine(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// distance is how many lines apart two lines are
+func distance(a, b int) int {
+	return abs(a - b)
+}
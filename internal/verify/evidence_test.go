@@ -0,0 +1,45 @@
+package verify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const evidenceSource = `package store
+
+func Open(path string) (*Store, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+	return &Store{file: f}, nil
+}
+
+func (s *Store) Close() error {
+	return s.file.Close()
+}
+`
+
+func TestEvidence(t *testing.T) {
+	tests := []struct {
+		name     string
+		evidence string
+		line     int
+		want     EvidenceMatch
+	}{
+		{"exact", "f, err := os.Open(path)", 4, EvidenceMatch{Status: EvidenceVerified, Line: 4, EndLine: 4}},
+		{"whitespace and blank lines", "f, err :=  os.Open(path)\n    if err != nil {", 4, EvidenceMatch{Status: EvidenceVerified, Line: 4, EndLine: 6}},
+		{"small slip", "return &Store{file: f}, nill", 8, EvidenceMatch{Status: EvidenceVerified, Line: 9, EndLine: 9}},
+		{"elsewhere", "return s.file.Close()", 4, EvidenceMatch{Status: EvidenceRelocated, Line: 13, EndLine: 13}},
+		{"invented", "defer f.Close()", 4, EvidenceMatch{Status: EvidenceMismatch}},
+		{"missing", "  \n...\n", 4, EvidenceMatch{Status: EvidenceMissing}},
+		{"no line", "func (s *Store) Close() error {", 0, EvidenceMatch{Status: EvidenceRelocated, Line: 12, EndLine: 12}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Evidence(evidenceSource, tt.evidence, tt.line))
+		})
+	}
+}
@@ -0,0 +1,364 @@
+// Package verify checks that the files and symbols generated text refers
+// to exist in the repository, correcting near misses and flagging the rest.
+package verify
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/sigil/internal/git"
+	"github.com/dshills/sigil/internal/logger"
+	"github.com/dshills/sigil/internal/outline"
+)
+
+// maxSourceSize is the largest file whose symbols are indexed
+const maxSourceSize = 512 * 1024
+
+// Reference kinds
+const (
+	KindPath   = "path"
+	KindSymbol = "symbol"
+)
+
+// Reference statuses
+const (
+	StatusVerified   = "verified"
+	StatusCorrected  = "corrected"
+	StatusUnverified = "unverified"
+)
+
+// UnverifiedNote follows references that could not be verified
+const UnverifiedNote = " (unverified)"
+
+var (
+	// inlineCode matches Markdown code spans
+	inlineCode = regexp.MustCompile("`([^`\n]+)`")
+
+	// symbolReference matches identifiers, optionally qualified and called:
+	// Open, store.Open, Store.Close()
+	symbolReference = regexp.MustCompile(`^[A-Za-z_]\w*(?:\.[A-Za-z_]\w*)*(?:\(\))?$`)
+
+	// pathReference matches relative file paths with an optional line:
+	// internal/store/store.go, main.go:42
+	pathReference = regexp.MustCompile(`^\.?/?[\w.-]+(?:/[\w.-]+)*/?(?::\d+(?:-\d+)?)?$`)
+
+	// lineSuffix is the :line or :start-end suffix of a path reference
+	lineSuffix = regexp.MustCompile(`:\d+(?:-\d+)?$`)
+
+	// fileExtension matches the extension of a file name
+	fileExtension = regexp.MustCompile(`\.[A-Za-z][A-Za-z0-9]{0,5}$`)
+)
+
+// Index is the files, directories and declared symbols of a repository
+type Index struct {
+	root    string
+	files   map[string]bool
+	dirs    map[string]bool
+	symbols map[string]bool
+}
+
+// Claim is a reference found in text and what checking it showed
+type Claim struct {
+	Text       string `json:"text"`
+	Kind       string `json:"kind"`
+	Status     string `json:"status"`
+	Correction string `json:"correction,omitempty"`
+}
+
+// LoadIndex indexes the files git tracks or would track in the repository
+// at root
+func LoadIndex(root string) (*Index, error) {
+	files, err := (&git.Repository{Path: root}).ListFiles()
+	if err != nil {
+		return nil, err
+	}
+	return NewIndex(root, files), nil
+}
+
+// NewIndex indexes files relative to root, reading the symbols of those
+// whose language has an outline
+func NewIndex(root string, files []string) *Index {
+	ix := &Index{
+		root:    root,
+		files:   make(map[string]bool, len(files)),
+		dirs:    make(map[string]bool),
+		symbols: make(map[string]bool),
+	}
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		ix.files[file] = true
+		for dir := path.Dir(file); dir != "."; dir = path.Dir(dir) {
+			ix.dirs[dir] = true
+		}
+
+		if !outline.Supported(file) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil || info.Size() > maxSourceSize {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			logger.Debug("failed to read file for symbols", "path", file, "error", err)
+			continue
+		}
+		for _, symbol := range outline.Extract(file, string(content)) {
+			for _, name := range strings.Split(symbol.Name, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					ix.symbols[name] = true
+				}
+			}
+		}
+	}
+	return ix
+}
+
+// Check finds the file and symbol references in the inline code of
+// Markdown text, outside fenced blocks, and checks each against the index.
+// References with a single close match are corrected in place; others that
+// do not exist are followed by UnverifiedNote.
+func (ix *Index) Check(text string) (string, []Claim) {
+	var claims []Claim
+	lines := strings.Split(text, "\n")
+	fenced := false
+	for i, line := range lines {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		lines[i] = inlineCode.ReplaceAllStringFunc(line, func(span string) string {
+			claim, ok := ix.checkReference(strings.TrimSpace(span[1 : len(span)-1]))
+			if !ok {
+				return span
+			}
+			claims = append(claims, claim)
+			switch claim.Status {
+			case StatusCorrected:
+				return "`" + claim.Correction + "`"
+			case StatusUnverified:
+				if strings.HasPrefix(line[strings.Index(line, span)+len(span):], UnverifiedNote) {
+					return span
+				}
+				return span + UnverifiedNote
+			}
+			return span
+		})
+	}
+	return strings.Join(lines, "\n"), claims
+}
+
+// checkReference checks one code span. It reports false for spans that are
+// not file or symbol references, such as commands, flags, literals and
+// the members of packages outside the repository.
+func (ix *Index) checkReference(ref string) (Claim, bool) {
+	switch {
+	case looksLikePath(ref):
+		claim := Claim{Text: ref, Kind: KindPath, Status: StatusVerified}
+		suffix := lineSuffix.FindString(ref)
+		file := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(strings.TrimSuffix(ref, suffix), "./"), "/"), "/")
+		if ix.hasPath(file) {
+			return claim, true
+		}
+		if correction := ix.closestPath(file); correction != "" {
+			claim.Status = StatusCorrected
+			claim.Correction = correction + suffix
+			return claim, true
+		}
+		claim.Status = StatusUnverified
+		return claim, true
+
+	case symbolReference.MatchString(ref):
+		name := strings.TrimSuffix(ref, "()")
+		parts := strings.Split(name, ".")
+		called := strings.HasSuffix(ref, "()")
+		// Qualified names whose qualifier the repository does not declare
+		// belong to other packages; plain lowercase words are just words
+		if len(parts) > 1 && !ix.symbols[parts[0]] && !ix.dirs[parts[0]] && !ix.hasDirNamed(parts[0]) {
+			return Claim{}, false
+		}
+		if len(parts) == 1 && !called && !hasUpper(name) {
+			return Claim{}, false
+		}
+
+		claim := Claim{Text: ref, Kind: KindSymbol, Status: StatusVerified}
+		last := parts[len(parts)-1]
+		if ix.symbols[last] {
+			return claim, true
+		}
+		if correction := ix.closestSymbol(last); correction != "" {
+			parts[len(parts)-1] = correction
+			claim.Status = StatusCorrected
+			claim.Correction = strings.Join(parts, ".")
+			if called {
+				claim.Correction += "()"
+			}
+			return claim, true
+		}
+		claim.Status = StatusUnverified
+		return claim, true
+	}
+	return Claim{}, false
+}
+
+// looksLikePath reports whether a reference names a file or directory
+// rather than a symbol: it has a directory separator or a file extension
+// that is not a method call
+func looksLikePath(ref string) bool {
+	if !pathReference.MatchString(ref) {
+		return false
+	}
+	bare := lineSuffix.ReplaceAllString(ref, "")
+	if strings.Contains(bare, "/") {
+		return !strings.Contains(bare, "://")
+	}
+	ext := fileExtension.FindString(bare)
+	return ext != "" && isKnownExtension(ext)
+}
+
+// hasPath reports whether a file or directory exists in the repository
+func (ix *Index) hasPath(file string) bool {
+	if ix.files[file] || ix.dirs[file] {
+		return true
+	}
+	if ix.root == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(ix.root, filepath.FromSlash(file)))
+	return err == nil
+}
+
+// hasDirNamed reports whether a directory of the repository, at any depth,
+// has a name, as Go packages are named by their directory
+func (ix *Index) hasDirNamed(name string) bool {
+	for dir := range ix.dirs {
+		if path.Base(dir) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// closestPath returns the only file a missing path plausibly meant: the
+// only file with its name, or the only path a small typo away
+func (ix *Index) closestPath(file string) string {
+	base := path.Base(file)
+	var sameName []string
+	for candidate := range ix.files {
+		if path.Base(candidate) == base {
+			sameName = append(sameName, candidate)
+		}
+	}
+	if len(sameName) == 1 {
+		return sameName[0]
+	}
+	if len(sameName) > 1 {
+		return ""
+	}
+
+	candidates := make([]string, 0, len(ix.files)+len(ix.dirs))
+	for candidate := range ix.files {
+		candidates = append(candidates, candidate)
+	}
+	for candidate := range ix.dirs {
+		candidates = append(candidates, candidate)
+	}
+	return closest(file, candidates)
+}
+
+// closestSymbol returns the only symbol a missing one plausibly meant: the
+// only one differing in case, or the only one a small typo away
+func (ix *Index) closestSymbol(name string) string {
+	var sameFold []string
+	candidates := make([]string, 0, len(ix.symbols))
+	for candidate := range ix.symbols {
+		if strings.EqualFold(candidate, name) {
+			sameFold = append(sameFold, candidate)
+		}
+		candidates = append(candidates, candidate)
+	}
+	if len(sameFold) == 1 {
+		return sameFold[0]
+	}
+	return closest(name, candidates)
+}
+
+// closest returns the only candidate within a typo's distance of a name,
+// or "" when there is none or more than one. Short names need an exact
+// match, since any change makes them another word.
+func closest(name string, candidates []string) string {
+	limit := 2
+	if len(name) < 6 {
+		return ""
+	}
+	if len(name) < 10 {
+		limit = 1
+	}
+
+	match := ""
+	for _, candidate := range candidates {
+		if abs(len(candidate)-len(name)) > limit || levenshtein(name, candidate) > limit {
+			continue
+		}
+		if match != "" {
+			return ""
+		}
+		match = candidate
+	}
+	return match
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// hasUpper reports whether a name has an upper-case letter, as exported
+// and type names do
+func hasUpper(name string) bool {
+	return strings.ToLower(name) != name
+}
+
+// knownExtensions are the file extensions references are recognized by
+var knownExtensions = map[string]bool{
+	".go": true, ".mod": true, ".sum": true, ".py": true, ".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".ts": true, ".tsx": true, ".rs": true, ".java": true, ".kt": true, ".rb": true, ".php": true, ".c": true,
+	".h": true, ".cpp": true, ".hpp": true, ".cs": true, ".swift": true, ".sh": true, ".md": true, ".yml": true,
+	".yaml": true, ".json": true, ".toml": true, ".sql": true, ".proto": true, ".html": true, ".css": true,
+}
+
+// isKnownExtension reports whether an extension is a source or config file
+// extension, so that references like config.Load are not taken for files
+func isKnownExtension(ext string) bool {
+	return knownExtensions[strings.ToLower(ext)]
+}
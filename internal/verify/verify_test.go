@@ -0,0 +1,90 @@
+package verify
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestIndex indexes a small repository written to a temporary directory
+func newTestIndex(t *testing.T) *Index {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                    "module example.com/app\n",
+		"internal/store/store.go":   "package store\n\n// Store keeps records\ntype Store struct {\n\tPath string\n}\n\n// Open opens a store\nfunc Open(path string) (*Store, error) { return nil, nil }\n\nfunc (s *Store) Close() error { return nil }\n\nfunc (s *Store) Transaction() {}\n",
+		"internal/server/server.go": "package server\n\nfunc NewServer() {}\n",
+		"README.md":                 "# App\n",
+	}
+	var names []string
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		names = append(names, name)
+	}
+	return NewIndex(root, names)
+}
+
+func TestIndex_Check(t *testing.T) {
+	ix := newTestIndex(t)
+
+	text := "The `Store` type in `internal/store/store.go:4` is opened with `store.Open()` and closed by `Store.Close`.\n" +
+		"`NewServer` lives in `server/server.go`, and `Store.Transacton()` runs work atomically.\n" +
+		"`Store.Flush()` and `internal/cache/cache.go` do not exist.\n" +
+		"Run `go test ./...` with `--verbose`; `fmt.Println` and `true` are not references.\n" +
+		"```go\ns := store.Missing()\n```\n" +
+		"See `README.md`."
+
+	checked, claims := ix.Check(text)
+	assert.Equal(t, "The `Store` type in `internal/store/store.go:4` is opened with `store.Open()` and closed by `Store.Close`.\n"+
+		"`NewServer` lives in `internal/server/server.go`, and `Store.Transaction()` runs work atomically.\n"+
+		"`Store.Flush()` (unverified) and `internal/cache/cache.go` (unverified) do not exist.\n"+
+		"Run `go test ./...` with `--verbose`; `fmt.Println` and `true` are not references.\n"+
+		"```go\ns := store.Missing()\n```\n"+
+		"See `README.md`.", checked)
+
+	statuses := make(map[string]string)
+	for _, claim := range claims {
+		statuses[claim.Text] = claim.Status
+	}
+	assert.Equal(t, map[string]string{
+		"Store":                     StatusVerified,
+		"internal/store/store.go:4": StatusVerified,
+		"store.Open()":              StatusVerified,
+		"Store.Close":               StatusVerified,
+		"NewServer":                 StatusVerified,
+		"server/server.go":          StatusCorrected,
+		"Store.Transacton()":        StatusCorrected,
+		"Store.Flush()":             StatusUnverified,
+		"internal/cache/cache.go":   StatusUnverified,
+		"README.md":                 StatusVerified,
+	}, statuses)
+
+	again, _ := ix.Check(checked)
+	assert.Equal(t, checked, again, "checked text is not annotated twice")
+}
+
+func TestLooksLikePath(t *testing.T) {
+	for ref, want := range map[string]bool{
+		"main.go":             true,
+		"./cmd/sigil":         true,
+		"internal/cli/":       true,
+		"store.go:12-20":      true,
+		"config.Load":         false,
+		"https://example.com": false,
+		"Store":               false,
+	} {
+		assert.Equal(t, want, looksLikePath(ref), ref)
+	}
+}
+
+func TestClosest(t *testing.T) {
+	assert.Equal(t, "Transaction", closest("Transacton", []string{"Transaction", "Close"}))
+	assert.Equal(t, "", closest("Open", []string{"Opens"}), "short names need an exact match")
+	assert.Equal(t, "", closest("Handler1", []string{"Handler2", "Handler3"}), "ambiguous matches are not corrected")
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+}
@@ -0,0 +1,129 @@
+// Package version describes the sigil build: its version, commit and build
+// date, stamped at build time with
+//
+//	-ldflags "-X github.com/dshills/sigil/internal/version.Version=v1.2.0 ..."
+//
+// and checks state files against it.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/dshills/sigil/internal/logger"
+)
+
+// Stamped at build time. Unstamped builds fall back to the module and VCS
+// information Go records.
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the build information
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+	if len(info.Commit) > 12 {
+		info.Commit = info.Commit[:12]
+	}
+	return info
+}
+
+// String describes the build on one line
+func (i Info) String() string {
+	details := []string{}
+	if i.Commit != "" {
+		details = append(details, i.Commit)
+	}
+	if i.Date != "" {
+		details = append(details, i.Date)
+	}
+	details = append(details, i.GoVersion, i.Platform)
+	return fmt.Sprintf("sigil %s (%s)", i.Version, strings.Join(details, ", "))
+}
+
+// UserAgent identifies sigil to model providers
+func UserAgent() string {
+	return "Sigil/" + strings.TrimPrefix(Get().Version, "v")
+}
+
+// Compatible reports whether state written by version written can be read
+// by this build: it was not written by a newer major or minor release.
+// Development builds and unversioned state are always compatible.
+func Compatible(written string) bool {
+	w, ok := parse(written)
+	if !ok {
+		return true
+	}
+	current, ok := parse(Get().Version)
+	if !ok {
+		return true
+	}
+	if w[0] != current[0] {
+		return w[0] < current[0]
+	}
+	return w[1] <= current[1]
+}
+
+// CheckState warns when the state file at path was written by a newer,
+// incompatible version of sigil, whose changes this build may not read or
+// may undo
+func CheckState(path, written string) {
+	if !Compatible(written) {
+		logger.Warn("state file was written by a newer sigil; upgrade to use it safely",
+			"path", path, "written_by", written, "version", Get().Version)
+	}
+}
+
+// parse reads the major, minor and patch numbers of a version such as
+// v1.2.3 or 1.2.3-rc.1
+func parse(version string) ([3]int, bool) {
+	var numbers [3]int
+	version = strings.TrimPrefix(version, "v")
+	version, _, _ = strings.Cut(version, "-")
+	version, _, _ = strings.Cut(version, "+")
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return numbers, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return numbers, false
+		}
+		numbers[i] = n
+	}
+	return numbers, true
+}
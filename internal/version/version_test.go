@@ -0,0 +1,45 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompatible(t *testing.T) {
+	previous := Version
+	defer func() { Version = previous }()
+	Version = "v1.4.2"
+
+	tests := []struct {
+		written string
+		want    bool
+	}{
+		{"v1.4.2", true},
+		{"1.4.9", true},   // newer patch release
+		{"v1.3.0", true},  // older minor release
+		{"v0.9.1", true},  // older major release
+		{"v1.5.0", false}, // newer minor release
+		{"v2.0.0-rc.1", false},
+		{"", true},
+		{"dev", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Compatible(tt.written), "written by %q", tt.written)
+	}
+
+	Version = "dev"
+	assert.True(t, Compatible("v9.0.0"), "development builds read any state")
+}
+
+func TestInfo(t *testing.T) {
+	previous := [3]string{Version, Commit, Date}
+	defer func() { Version, Commit, Date = previous[0], previous[1], previous[2] }()
+	Version, Commit, Date = "v1.4.2", "0123456789abcdef", "2026-10-16T12:00:00Z"
+
+	info := Get()
+	assert.Equal(t, "v1.4.2", info.Version)
+	assert.Equal(t, "0123456789ab", info.Commit)
+	assert.Contains(t, info.String(), "sigil v1.4.2 (0123456789ab, 2026-10-16T12:00:00Z, go")
+	assert.Equal(t, "Sigil/1.4.2", UserAgent())
+}
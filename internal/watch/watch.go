@@ -0,0 +1,179 @@
+// Package watch reports changes to the files of a repository, for
+// long-running processes that keep derived data such as the embeddings
+// index up to date.
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dshills/sigil/internal/errors"
+	"github.com/dshills/sigil/internal/logger"
+)
+
+const (
+	// DefaultInterval is how often a Watcher checks its files for changes
+	DefaultInterval = time.Second
+
+	// DefaultDebounce is how long files must stay unchanged before their
+	// changes are reported
+	DefaultDebounce = 2 * time.Second
+)
+
+// Change lists the files, relative to the watched root, that changed since
+// the last report
+type Change struct {
+	// Changed holds files added or modified
+	Changed []string
+
+	// Removed holds files deleted or no longer listed
+	Removed []string
+}
+
+// Files returns the changed and removed files together
+func (c Change) Files() []string {
+	return append(append([]string{}, c.Changed...), c.Removed...)
+}
+
+// fileState is what a check compares to tell whether a file changed
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// Watcher polls the files of a directory tree and reports changes once
+// they settle, so a burst of saves, a branch switch or a formatter run is
+// reported as one change. Like the configuration watcher it polls, which
+// keeps it free of platform-specific notification APIs and catches editors
+// that replace files instead of writing them in place.
+type Watcher struct {
+	root     string
+	list     func() ([]string, error)
+	interval time.Duration
+	debounce time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	states    map[string]fileState
+	pending   map[string]bool
+	changedAt time.Time
+	listeners []func(Change)
+}
+
+// New creates a watcher for the files list returns, relative to root, such
+// as the files git tracks. The files as they are now are taken as seen. A
+// zero interval checks every DefaultInterval, and a negative debounce waits
+// DefaultDebounce.
+func New(root string, list func() ([]string, error), interval, debounce time.Duration) (*Watcher, error) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if debounce < 0 {
+		debounce = DefaultDebounce
+	}
+	w := &Watcher{root: root, list: list, interval: interval, debounce: debounce, now: time.Now,
+		pending: make(map[string]bool)}
+
+	states, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	w.states = states
+	return w, nil
+}
+
+// OnChange registers fn to run with each change reported
+func (w *Watcher) OnChange(fn func(Change)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.listeners = append(w.listeners, fn)
+}
+
+// Run checks the files every interval until ctx is canceled
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// Check looks for changed files and, once no file has changed for the
+// debounce period, reports the changes collected so far. It returns whether
+// a change was reported.
+func (w *Watcher) Check() bool {
+	states, err := w.scan()
+	if err != nil {
+		logger.Warn("failed to check files for changes", "root", w.root, "error", err)
+		return false
+	}
+
+	w.mu.Lock()
+	now := w.now()
+	for file, state := range states {
+		if previous, ok := w.states[file]; !ok || previous.size != state.size || !previous.modTime.Equal(state.modTime) {
+			w.pending[file] = true
+			w.changedAt = now
+		}
+	}
+	for file := range w.states {
+		if _, ok := states[file]; !ok {
+			w.pending[file] = true
+			w.changedAt = now
+		}
+	}
+	w.states = states
+
+	if len(w.pending) == 0 || now.Sub(w.changedAt) < w.debounce {
+		w.mu.Unlock()
+		return false
+	}
+
+	var change Change
+	for file := range w.pending {
+		if _, ok := states[file]; ok {
+			change.Changed = append(change.Changed, file)
+		} else {
+			change.Removed = append(change.Removed, file)
+		}
+	}
+	sort.Strings(change.Changed)
+	sort.Strings(change.Removed)
+	w.pending = make(map[string]bool)
+	listeners := append([]func(Change){}, w.listeners...)
+	w.mu.Unlock()
+
+	logger.Debug("files changed", "changed", len(change.Changed), "removed", len(change.Removed))
+	for _, fn := range listeners {
+		fn(change)
+	}
+	return true
+}
+
+// scan records the size and modification time of each listed file
+func (w *Watcher) scan() (map[string]fileState, error) {
+	files, err := w.list()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeFS, "scan", "failed to list files to watch")
+	}
+
+	states := make(map[string]fileState, len(files))
+	for _, file := range files {
+		info, err := os.Stat(filepath.Join(w.root, file))
+		if err != nil {
+			continue
+		}
+		states[filepath.ToSlash(file)] = fileState{size: info.Size(), modTime: info.ModTime()}
+	}
+	return states, nil
+}
@@ -0,0 +1,52 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_Check(t *testing.T) {
+	root := t.TempDir()
+	write := func(file, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, file), []byte(content), 0644))
+	}
+	write("main.go", "package main\n")
+	write("util.go", "package main\n")
+	files := []string{"main.go", "util.go"}
+
+	watcher, err := New(root, func() ([]string, error) { return files, nil }, time.Hour, 2*time.Second)
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	watcher.now = func() time.Time { return now }
+	var changes []Change
+	watcher.OnChange(func(c Change) { changes = append(changes, c) })
+
+	// Files as they were when the watcher was created are not reported
+	assert.False(t, watcher.Check())
+
+	write("main.go", "package main\n\nfunc main() {}\n")
+	assert.False(t, watcher.Check(), "changes wait for the debounce period")
+
+	// Further changes within the period are collected into one report
+	now = now.Add(time.Second)
+	write("new.go", "package main\n")
+	require.NoError(t, os.Remove(filepath.Join(root, "util.go")))
+	files = []string{"main.go", "new.go", "util.go"}
+	assert.False(t, watcher.Check())
+
+	now = now.Add(time.Second)
+	assert.False(t, watcher.Check(), "the period restarts with each change")
+	now = now.Add(2 * time.Second)
+	assert.True(t, watcher.Check())
+	require.Len(t, changes, 1)
+	assert.Equal(t, []string{"main.go", "new.go"}, changes[0].Changed)
+	assert.Equal(t, []string{"util.go"}, changes[0].Removed)
+	assert.Equal(t, []string{"main.go", "new.go", "util.go"}, changes[0].Files())
+
+	assert.False(t, watcher.Check(), "reported changes are not reported again")
+}